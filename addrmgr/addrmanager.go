@@ -8,7 +8,6 @@ package addrmgr
 import (
 	"container/list"
 	crand "crypto/rand" // for seeding
-	"encoding/base32"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
@@ -31,7 +30,8 @@ import (
 // peers on the bitcoin network.
 type AddrManager struct {
 	mtx            sync.RWMutex
-	peersFile      string
+	store          Store
+	peersFile      string // legacy peers file migrated into the store
 	lookupFunc     func(string) ([]net.IP, error)
 	rand           *rand.Rand
 	key            [32]byte
@@ -46,9 +46,16 @@ type AddrManager struct {
 	nNew           int
 	lamtx          sync.Mutex
 	localAddresses map[string]*localAddress
-	version        int
+
+	// saved holds the addresses as last written to the store, which is
+	// used to only write the addresses which changed since then.
+	// resetStore indicates the store has to be rewritten as a whole.
+	saved      map[string]*StoredAddress
+	resetStore bool
 }
 
+// serializedKnownAddress is a known address in the peers file of previous
+// versions, which is migrated into the store on startup.
 type serializedKnownAddress struct {
 	Addr        string
 	Src         string
@@ -58,6 +65,8 @@ type serializedKnownAddress struct {
 	LastSuccess int64
 	Services    wire.ServiceFlag
 	SrcServices wire.ServiceFlag
+	Network     wire.NetworkID
+	SrcNetwork  wire.NetworkID
 	// no refcount or tried, that is available from context.
 }
 
@@ -70,7 +79,7 @@ type serializedAddrManager struct {
 }
 
 type localAddress struct {
-	na    *wire.NetAddress
+	na    *wire.NetAddressV2
 	score AddressPriority
 }
 
@@ -100,8 +109,8 @@ const (
 	// address manager will claim to need more addresses.
 	needAddressThreshold = 1000
 
-	// dumpAddressInterval is the interval used to dump the address
-	// cache to disk for future use.
+	// dumpAddressInterval is the interval used to write the addresses
+	// which changed to the store for future use.
 	dumpAddressInterval = time.Minute * 10
 
 	// triedBucketSize is the maximum number of addresses in each
@@ -157,13 +166,14 @@ const (
 	// will share with a call to AddressCache.
 	getAddrPercent = 23
 
-	// serialisationVersion is the current version of the on-disk format.
-	serialisationVersion = 2
+	// serialisationVersion is the latest version of the peers file of
+	// previous versions which can be migrated into the store.
+	serialisationVersion = 3
 )
 
 // updateAddress is a helper function to either update an address already known
 // to the address manager, or to add the address if not already known.
-func (a *AddrManager) updateAddress(netAddr, srcAddr *wire.NetAddress) {
+func (a *AddrManager) updateAddress(netAddr, srcAddr *wire.NetAddressV2) {
 	// Filter out non-routable addresses. Note that non-routable
 	// also includes invalid and local addresses.
 	if !IsRoutable(netAddr) {
@@ -294,7 +304,7 @@ func (a *AddrManager) pickTried(bucket int) *list.Element {
 	return oldestElem
 }
 
-func (a *AddrManager) getNewBucket(netAddr, srcAddr *wire.NetAddress) int {
+func (a *AddrManager) getNewBucket(netAddr, srcAddr *wire.NetAddressV2) int {
 	// bitcoind:
 	// doublesha256(key + sourcegroup + int64(doublesha256(key + group + sourcegroup))%bucket_per_source_group) % num_new_buckets
 
@@ -316,7 +326,7 @@ func (a *AddrManager) getNewBucket(netAddr, srcAddr *wire.NetAddress) int {
 	return int(binary.LittleEndian.Uint64(hash2) % newBucketCount)
 }
 
-func (a *AddrManager) getTriedBucket(netAddr *wire.NetAddress) int {
+func (a *AddrManager) getTriedBucket(netAddr *wire.NetAddressV2) int {
 	// bitcoind hashes this as:
 	// doublesha256(key + group + truncate_to_64bits(doublesha256(key)) % buckets_per_group) % num_buckets
 	data1 := []byte{}
@@ -352,91 +362,231 @@ out:
 		}
 	}
 	a.savePeers()
+	if err := a.store.Close(); err != nil {
+		log.Errorf("Failed to close address store: %v", err)
+	}
 	a.wg.Done()
 	log.Trace("Address handler done")
 }
 
-// savePeers saves all the known addresses to a file so they can be read back
-// in at next run.
+// savePeers writes the addresses which changed since they were last saved to
+// the store so they can be read back in at next run.
 func (a *AddrManager) savePeers() {
 	a.mtx.Lock()
 	defer a.mtx.Unlock()
 
-	// First we make a serialisable datastructure so we can encode it to
-	// json.
-	sam := new(serializedAddrManager)
-	sam.Version = a.version
-	copy(sam.Key[:], a.key[:])
+	if err := a.syncStore(); err != nil {
+		log.Errorf("Failed to save addresses: %v", err)
+	}
+}
 
-	sam.Addresses = make([]*serializedKnownAddress, len(a.addrIndex))
-	i := 0
+// storedAddresses returns the stored form of all known addresses keyed by
+// their address key.
+//
+// This function MUST be called with the address manager lock held (for reads).
+func (a *AddrManager) storedAddresses() map[string]*StoredAddress {
+	addrs := make(map[string]*StoredAddress, len(a.addrIndex))
 	for k, v := range a.addrIndex {
-		ska := new(serializedKnownAddress)
-		ska.Addr = k
-		ska.TimeStamp = v.na.Timestamp.Unix()
-		ska.Src = NetAddressKey(v.srcAddr)
-		ska.Attempts = v.attempts
-		ska.LastAttempt = v.lastattempt.Unix()
-		ska.LastSuccess = v.lastsuccess.Unix()
-		if a.version > 1 {
-			ska.Services = v.na.Services
-			ska.SrcServices = v.srcAddr.Services
+		addrs[k] = &StoredAddress{
+			Addr:        k,
+			Src:         NetAddressKey(v.srcAddr),
+			Network:     v.na.NetworkID,
+			SrcNetwork:  v.srcAddr.NetworkID,
+			Services:    v.na.Services,
+			SrcServices: v.srcAddr.Services,
+			TimeStamp:   v.na.Timestamp.Unix(),
+			Attempts:    v.attempts,
+			LastAttempt: v.lastattempt.Unix(),
+			LastSuccess: v.lastsuccess.Unix(),
 		}
-		// Tried and refs are implicit in the rest of the structure
-		// and will be worked out from context on unserialisation.
-		sam.Addresses[i] = ska
-		i++
 	}
 	for i := range a.addrNew {
-		sam.NewBuckets[i] = make([]string, len(a.addrNew[i]))
-		j := 0
 		for k := range a.addrNew[i] {
-			sam.NewBuckets[i][j] = k
-			j++
+			addrs[k].NewBuckets = append(addrs[k].NewBuckets, i)
 		}
 	}
 	for i := range a.addrTried {
-		sam.TriedBuckets[i] = make([]string, a.addrTried[i].Len())
-		j := 0
 		for e := a.addrTried[i].Front(); e != nil; e = e.Next() {
-			ka := e.Value.(*KnownAddress)
-			sam.TriedBuckets[i][j] = NetAddressKey(ka.na)
-			j++
+			sa := addrs[NetAddressKey(e.Value.(*KnownAddress).na)]
+			sa.Tried = true
+			sa.TriedBucket = i
 		}
 	}
+	return addrs
+}
 
-	w, err := os.Create(a.peersFile)
-	if err != nil {
-		log.Errorf("Error opening file %s: %v", a.peersFile, err)
-		return
+// syncStore writes the addresses which changed since they were last saved to
+// the store and removes the ones which are no longer known from it.
+//
+// This function MUST be called with the address manager lock held (for writes).
+func (a *AddrManager) syncStore() error {
+	addrs := a.storedAddresses()
+	batch := &StoreBatch{Key: a.key, Reset: a.resetStore}
+	for k, sa := range addrs {
+		saved, ok := a.saved[k]
+		if batch.Reset || !ok || !saved.equal(sa) {
+			batch.Put = append(batch.Put, sa)
+		}
 	}
-	enc := json.NewEncoder(w)
-	defer w.Close()
-	if err := enc.Encode(&sam); err != nil {
-		log.Errorf("Failed to encode file %s: %v", a.peersFile, err)
-		return
+	if !batch.Reset {
+		for k := range a.saved {
+			if _, ok := addrs[k]; !ok {
+				batch.Delete = append(batch.Delete, k)
+			}
+		}
+		if len(batch.Put) == 0 && len(batch.Delete) == 0 {
+			return nil
+		}
 	}
+
+	if err := a.store.Update(batch); err != nil {
+		return err
+	}
+	a.saved = addrs
+	a.resetStore = false
+	return nil
 }
 
-// loadPeers loads the known address from the saved file.  If empty, missing, or
-// malformed file, just don't load anything and start fresh
+// loadPeers loads the known addresses from the store.  When the store is
+// empty, the addresses are migrated from the peers file of previous versions
+// if there is one.  If the store can't be read, just don't load anything and
+// start fresh.
 func (a *AddrManager) loadPeers() {
 	a.mtx.Lock()
 	defer a.mtx.Unlock()
 
-	err := a.deserializePeers(a.peersFile)
+	state, err := a.store.Load()
 	if err != nil {
-		log.Errorf("Failed to parse file %s: %v", a.peersFile, err)
-		// if it is invalid we nuke the old one unconditionally.
-		err = os.Remove(a.peersFile)
+		log.Errorf("Failed to load addresses: %v", err)
+		a.reset()
+		return
+	}
+	if state == nil {
+		a.migratePeersFile()
+		return
+	}
+
+	a.restoreState(state)
+	log.Infof("Loaded %d addresses", a.numAddresses())
+}
+
+// restoreState restores the known addresses from the passed state loaded from
+// the store.  Addresses which can't be restored are dropped.
+//
+// This function MUST be called with the address manager lock held (for writes).
+func (a *AddrManager) restoreState(state *StoreState) {
+	a.key = state.Key
+	a.saved = make(map[string]*StoredAddress, len(state.Addresses))
+	a.resetStore = false
+
+	// restoreAddress restores a single stored address and returns the
+	// reason when it is invalid.
+	restoreAddress := func(sa *StoredAddress) (*KnownAddress, error) {
+		if a.addrIndex[sa.Addr] != nil {
+			return nil, fmt.Errorf("duplicate address")
+		}
+		if sa.Tried && (sa.TriedBucket < 0 ||
+			sa.TriedBucket >= triedBucketCount) {
+
+			return nil, fmt.Errorf("invalid tried bucket %d",
+				sa.TriedBucket)
+		}
+		if !sa.Tried && (len(sa.NewBuckets) == 0 ||
+			len(sa.NewBuckets) > newBucketsPerAddress) {
+
+			return nil, fmt.Errorf("invalid number of new buckets "+
+				"%d", len(sa.NewBuckets))
+		}
+		for _, bucket := range sa.NewBuckets {
+			if bucket < 0 || bucket >= newBucketCount {
+				return nil, fmt.Errorf("invalid new bucket %d",
+					bucket)
+			}
+		}
+
+		na, err := a.DeserializeNetAddress(sa.Addr, sa.Services)
 		if err != nil {
-			log.Warnf("Failed to remove corrupt peers file %s: %v",
-				a.peersFile, err)
+			return nil, err
 		}
+		restoreNetwork(na, sa.Network)
+		if NetAddressKey(na) != sa.Addr {
+			return nil, fmt.Errorf("mismatched address key")
+		}
+		na.Timestamp = time.Unix(sa.TimeStamp, 0)
+
+		srcAddr, err := a.DeserializeNetAddress(sa.Src, sa.SrcServices)
+		if err != nil {
+			return nil, err
+		}
+		restoreNetwork(srcAddr, sa.SrcNetwork)
+
+		return &KnownAddress{
+			na:          na,
+			srcAddr:     srcAddr,
+			attempts:    sa.Attempts,
+			lastattempt: time.Unix(sa.LastAttempt, 0),
+			lastsuccess: time.Unix(sa.LastSuccess, 0),
+		}, nil
+	}
+
+	for _, sa := range state.Addresses {
+		ka, err := restoreAddress(sa)
+		if err != nil {
+			log.Warnf("Discarding stored address %s: %v", sa.Addr,
+				err)
+			a.resetStore = true
+			continue
+		}
+
+		a.addrIndex[sa.Addr] = ka
+		a.saved[sa.Addr] = sa
+		if sa.Tried {
+			ka.tried = true
+			a.nTried++
+			a.addrTried[sa.TriedBucket].PushBack(ka)
+			continue
+		}
+		for _, bucket := range sa.NewBuckets {
+			if _, ok := a.addrNew[bucket][sa.Addr]; ok {
+				continue
+			}
+			a.addrNew[bucket][sa.Addr] = ka
+			ka.refs++
+		}
+		a.nNew++
+	}
+}
+
+// migratePeersFile migrates the addresses from the peers file of previous
+// versions into the store and removes the file afterwards.
+//
+// This function MUST be called with the address manager lock held (for writes).
+func (a *AddrManager) migratePeersFile() {
+	if a.peersFile == "" {
+		return
+	}
+	if _, err := os.Stat(a.peersFile); os.IsNotExist(err) {
+		return
+	}
+
+	err := a.deserializePeers(a.peersFile)
+	if err != nil {
+		log.Errorf("Failed to parse file %s: %v", a.peersFile, err)
 		a.reset()
+	} else if err := a.syncStore(); err != nil {
+		// Keep the file to retry the migration at next run.
+		log.Errorf("Failed to migrate addresses from file %s: %v",
+			a.peersFile, err)
 		return
+	} else {
+		log.Infof("Migrated %d addresses from file '%s'",
+			a.numAddresses(), a.peersFile)
+	}
+
+	if err := os.Remove(a.peersFile); err != nil {
+		log.Warnf("Failed to remove peers file %s: %v", a.peersFile,
+			err)
 	}
-	log.Infof("Loaded %d addresses from file '%s'", a.numAddresses(), a.peersFile)
 }
 
 func (a *AddrManager) deserializePeers(filePath string) error {
@@ -482,6 +632,7 @@ func (a *AddrManager) deserializePeers(filePath string) error {
 			return fmt.Errorf("failed to deserialize netaddress "+
 				"%s: %v", v.Addr, err)
 		}
+		restoreNetwork(ka.na, v.Network)
 
 		// The first version of the serialized address manager was not
 		// aware of the service bits associated with the source address,
@@ -494,6 +645,7 @@ func (a *AddrManager) deserializePeers(filePath string) error {
 			return fmt.Errorf("failed to deserialize netaddress "+
 				"%s: %v", v.Src, err)
 		}
+		restoreNetwork(ka.srcAddr, v.SrcNetwork)
 
 		ka.attempts = v.Attempts
 		ka.lastattempt = time.Unix(v.LastAttempt, 0)
@@ -546,9 +698,20 @@ func (a *AddrManager) deserializePeers(filePath string) error {
 	return nil
 }
 
-// DeserializeNetAddress converts a given address string to a *wire.NetAddress.
+// restoreNetwork restores the network of a deserialized address.  CJDNS
+// addresses share their string representation with IPv6 addresses, so they
+// are deserialized as such and need to have their network restored from the
+// serialized network id.
+func restoreNetwork(na *wire.NetAddressV2, netID wire.NetworkID) {
+	if netID == wire.NetIDCJDNS && na.NetworkID == wire.NetIDIPv6 {
+		na.NetworkID = wire.NetIDCJDNS
+	}
+}
+
+// DeserializeNetAddress converts a given address string to a
+// *wire.NetAddressV2.
 func (a *AddrManager) DeserializeNetAddress(addr string,
-	services wire.ServiceFlag) (*wire.NetAddress, error) {
+	services wire.ServiceFlag) (*wire.NetAddressV2, error) {
 
 	host, portStr, err := net.SplitHostPort(addr)
 	if err != nil {
@@ -572,7 +735,7 @@ func (a *AddrManager) Start() {
 
 	log.Trace("Starting address manager")
 
-	// Load peers we already know about from file.
+	// Load peers we already know about from the store.
 	a.loadPeers()
 
 	// Start the address ticker to save addresses periodically.
@@ -597,7 +760,7 @@ func (a *AddrManager) Stop() error {
 // AddAddresses adds new addresses to the address manager.  It enforces a max
 // number of addresses and silently ignores duplicate addresses.  It is
 // safe for concurrent access.
-func (a *AddrManager) AddAddresses(addrs []*wire.NetAddress, srcAddr *wire.NetAddress) {
+func (a *AddrManager) AddAddresses(addrs []*wire.NetAddressV2, srcAddr *wire.NetAddressV2) {
 	a.mtx.Lock()
 	defer a.mtx.Unlock()
 
@@ -609,7 +772,7 @@ func (a *AddrManager) AddAddresses(addrs []*wire.NetAddress, srcAddr *wire.NetAd
 // AddAddress adds a new address to the address manager.  It enforces a max
 // number of addresses and silently ignores duplicate addresses.  It is
 // safe for concurrent access.
-func (a *AddrManager) AddAddress(addr, srcAddr *wire.NetAddress) {
+func (a *AddrManager) AddAddress(addr, srcAddr *wire.NetAddressV2) {
 	a.mtx.Lock()
 	defer a.mtx.Unlock()
 
@@ -633,7 +796,8 @@ func (a *AddrManager) AddAddressByIP(addrIP string) error {
 	if err != nil {
 		return fmt.Errorf("invalid port %s: %v", portStr, err)
 	}
-	na := wire.NewNetAddressIPPort(ip, uint16(port), 0)
+	na := wire.NetAddressV2FromLegacy(
+		wire.NewNetAddressIPPort(ip, uint16(port), 0))
 	a.AddAddress(na, na) // XXX use correct src address
 	return nil
 }
@@ -662,7 +826,7 @@ func (a *AddrManager) NeedMoreAddresses() bool {
 
 // AddressCache returns the current address cache.  It must be treated as
 // read-only (but since it is a copy now, this is not as dangerous).
-func (a *AddrManager) AddressCache() []*wire.NetAddress {
+func (a *AddrManager) AddressCache() []*wire.NetAddressV2 {
 	allAddr := a.getAddresses()
 
 	numAddresses := len(allAddr) * getAddrPercent / 100
@@ -684,7 +848,7 @@ func (a *AddrManager) AddressCache() []*wire.NetAddress {
 
 // getAddresses returns all of the addresses currently found within the
 // manager's address cache.
-func (a *AddrManager) getAddresses() []*wire.NetAddress {
+func (a *AddrManager) getAddresses() []*wire.NetAddressV2 {
 	a.mtx.RLock()
 	defer a.mtx.RUnlock()
 
@@ -693,7 +857,7 @@ func (a *AddrManager) getAddresses() []*wire.NetAddress {
 		return nil
 	}
 
-	addrs := make([]*wire.NetAddress, 0, addrIndexLen)
+	addrs := make([]*wire.NetAddressV2, 0, addrIndexLen)
 	for _, v := range a.addrIndex {
 		addrs = append(addrs, v.na)
 	}
@@ -706,6 +870,10 @@ func (a *AddrManager) getAddresses() []*wire.NetAddress {
 func (a *AddrManager) reset() {
 
 	a.addrIndex = make(map[string]*KnownAddress)
+	a.nNew = 0
+	a.nTried = 0
+	a.saved = nil
+	a.resetStore = true
 
 	// fill key with bytes from a good random source.
 	io.ReadFull(crand.Reader, a.key[:])
@@ -718,55 +886,42 @@ func (a *AddrManager) reset() {
 }
 
 // HostToNetAddress returns a netaddress given a host address.  If the address
-// is a Tor .onion address this will be taken care of.  Else if the host is
-// not an IP address it will be resolved (via Tor if required).
-func (a *AddrManager) HostToNetAddress(host string, port uint16, services wire.ServiceFlag) (*wire.NetAddress, error) {
-	// Tor address is 16 char base32 + ".onion"
-	var ip net.IP
-	if len(host) == 22 && host[16:] == ".onion" {
-		// go base32 encoding uses capitals (as does the rfc
-		// but Tor and bitcoind tend to user lowercase, so we switch
-		// case here.
-		data, err := base32.StdEncoding.DecodeString(
-			strings.ToUpper(host[:16]))
-		if err != nil {
-			return nil, err
-		}
-		prefix := []byte{0xfd, 0x87, 0xd8, 0x7e, 0xeb, 0x43}
-		ip = net.IP(append(prefix, data...))
-	} else if ip = net.ParseIP(host); ip == nil {
-		ips, err := a.lookupFunc(host)
-		if err != nil {
-			return nil, err
-		}
-		if len(ips) == 0 {
-			return nil, fmt.Errorf("no addresses found for %s", host)
-		}
-		ip = ips[0]
+// is a Tor .onion or an I2P .b32.i2p address this will be taken care of.  Else
+// if the host is not an IP address it will be resolved (via Tor if required).
+func (a *AddrManager) HostToNetAddress(host string, port uint16, services wire.ServiceFlag) (*wire.NetAddressV2, error) {
+	na, err := wire.NewNetAddressV2FromHost(host, port, services)
+	if err == nil {
+		return na, nil
 	}
 
-	return wire.NewNetAddressIPPort(ip, port, services), nil
-}
+	// Overlay network addresses can't be resolved, so there is no point
+	// in trying when they failed to parse.
+	lowerHost := strings.ToLower(host)
+	if strings.HasSuffix(lowerHost, ".onion") ||
+		strings.HasSuffix(lowerHost, ".i2p") {
 
-// ipString returns a string for the ip from the provided NetAddress. If the
-// ip is in the range used for Tor addresses then it will be transformed into
-// the relevant .onion address.
-func ipString(na *wire.NetAddress) string {
-	if IsOnionCatTor(na) {
-		// We know now that na.IP is long enough.
-		base32 := base32.StdEncoding.EncodeToString(na.IP[6:])
-		return strings.ToLower(base32) + ".onion"
+		return nil, err
 	}
 
-	return na.IP.String()
+	ips, err := a.lookupFunc(host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+
+	legacy := wire.NewNetAddressIPPort(ips[0], port, services)
+	return wire.NetAddressV2FromLegacy(legacy), nil
 }
 
-// NetAddressKey returns a string key in the form of ip:port for IPv4 addresses
-// or [ip]:port for IPv6 addresses.
-func NetAddressKey(na *wire.NetAddress) string {
+// NetAddressKey returns a string key in the form of ip:port for IPv4 addresses,
+// [ip]:port for IPv6 and CJDNS addresses, and host:port for Tor and I2P
+// addresses.
+func NetAddressKey(na *wire.NetAddressV2) string {
 	port := strconv.FormatUint(uint64(na.Port), 10)
 
-	return net.JoinHostPort(ipString(na), port)
+	return net.JoinHostPort(na.Host(), port)
 }
 
 // GetAddress returns a single address that should be routable.  It picks a
@@ -840,13 +995,13 @@ func (a *AddrManager) GetAddress() *KnownAddress {
 	}
 }
 
-func (a *AddrManager) find(addr *wire.NetAddress) *KnownAddress {
+func (a *AddrManager) find(addr *wire.NetAddressV2) *KnownAddress {
 	return a.addrIndex[NetAddressKey(addr)]
 }
 
 // Attempt increases the given address' attempt counter and updates
 // the last attempt time.
-func (a *AddrManager) Attempt(addr *wire.NetAddress) {
+func (a *AddrManager) Attempt(addr *wire.NetAddressV2) {
 	a.mtx.Lock()
 	defer a.mtx.Unlock()
 
@@ -864,7 +1019,7 @@ func (a *AddrManager) Attempt(addr *wire.NetAddress) {
 // Connected Marks the given address as currently connected and working at the
 // current time.  The address must already be known to AddrManager else it will
 // be ignored.
-func (a *AddrManager) Connected(addr *wire.NetAddress) {
+func (a *AddrManager) Connected(addr *wire.NetAddressV2) {
 	a.mtx.Lock()
 	defer a.mtx.Unlock()
 
@@ -887,7 +1042,7 @@ func (a *AddrManager) Connected(addr *wire.NetAddress) {
 // Good marks the given address as good.  To be called after a successful
 // connection and version exchange.  If the address is unknown to the address
 // manager it will be ignored.
-func (a *AddrManager) Good(addr *wire.NetAddress) {
+func (a *AddrManager) Good(addr *wire.NetAddressV2) {
 	a.mtx.Lock()
 	defer a.mtx.Unlock()
 
@@ -974,7 +1129,7 @@ func (a *AddrManager) Good(addr *wire.NetAddress) {
 }
 
 // SetServices sets the services for the giiven address to the provided value.
-func (a *AddrManager) SetServices(addr *wire.NetAddress, services wire.ServiceFlag) {
+func (a *AddrManager) SetServices(addr *wire.NetAddressV2, services wire.ServiceFlag) {
 	a.mtx.Lock()
 	defer a.mtx.Unlock()
 
@@ -992,11 +1147,24 @@ func (a *AddrManager) SetServices(addr *wire.NetAddress, services wire.ServiceFl
 	}
 }
 
+// Services returns the services the given address is known to advertise.  Zero
+// is returned if the address is unknown to the address manager.
+func (a *AddrManager) Services(addr *wire.NetAddressV2) wire.ServiceFlag {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	ka := a.find(addr)
+	if ka == nil {
+		return 0
+	}
+	return ka.na.Services
+}
+
 // AddLocalAddress adds na to the list of known local addresses to advertise
 // with the given priority.
-func (a *AddrManager) AddLocalAddress(na *wire.NetAddress, priority AddressPriority) error {
+func (a *AddrManager) AddLocalAddress(na *wire.NetAddressV2, priority AddressPriority) error {
 	if !IsRoutable(na) {
-		return fmt.Errorf("address %s is not routable", na.IP)
+		return fmt.Errorf("address %s is not routable", na.Host())
 	}
 
 	a.lamtx.Lock()
@@ -1019,7 +1187,7 @@ func (a *AddrManager) AddLocalAddress(na *wire.NetAddress, priority AddressPrior
 
 // getReachabilityFrom returns the relative reachability of the provided local
 // address to the provided remote address.
-func getReachabilityFrom(localAddr, remoteAddr *wire.NetAddress) int {
+func getReachabilityFrom(localAddr, remoteAddr *wire.NetAddressV2) int {
 	const (
 		Unreachable = 0
 		Default     = iota
@@ -1034,8 +1202,8 @@ func getReachabilityFrom(localAddr, remoteAddr *wire.NetAddress) int {
 		return Unreachable
 	}
 
-	if IsOnionCatTor(remoteAddr) {
-		if IsOnionCatTor(localAddr) {
+	if IsOnionCatTor(remoteAddr) || IsTorV3(remoteAddr) {
+		if IsOnionCatTor(localAddr) || IsTorV3(localAddr) {
 			return Private
 		}
 
@@ -1046,6 +1214,20 @@ func getReachabilityFrom(localAddr, remoteAddr *wire.NetAddress) int {
 		return Default
 	}
 
+	if IsI2P(remoteAddr) {
+		if IsI2P(localAddr) {
+			return Private
+		}
+		return Unreachable
+	}
+
+	if IsCJDNS(remoteAddr) {
+		if IsCJDNS(localAddr) {
+			return Private
+		}
+		return Unreachable
+	}
+
 	if IsRFC4380(remoteAddr) {
 		if !IsRoutable(localAddr) {
 			return Default
@@ -1069,6 +1251,11 @@ func getReachabilityFrom(localAddr, remoteAddr *wire.NetAddress) int {
 		return Unreachable
 	}
 
+	// Addresses of the overlay networks can't be reached from IPv6.
+	if IsTorV3(localAddr) || IsI2P(localAddr) || IsCJDNS(localAddr) {
+		return Unreachable
+	}
+
 	/* ipv6 */
 	var tunnelled bool
 	// Is our v6 is tunnelled?
@@ -1098,13 +1285,13 @@ func getReachabilityFrom(localAddr, remoteAddr *wire.NetAddress) int {
 
 // GetBestLocalAddress returns the most appropriate local address to use
 // for the given remote address.
-func (a *AddrManager) GetBestLocalAddress(remoteAddr *wire.NetAddress) *wire.NetAddress {
+func (a *AddrManager) GetBestLocalAddress(remoteAddr *wire.NetAddressV2) *wire.NetAddressV2 {
 	a.lamtx.Lock()
 	defer a.lamtx.Unlock()
 
 	bestreach := 0
 	var bestscore AddressPriority
-	var bestAddress *wire.NetAddress
+	var bestAddress *wire.NetAddressV2
 	for _, la := range a.localAddresses {
 		reach := getReachabilityFrom(la.na, remoteAddr)
 		if reach > bestreach ||
@@ -1115,11 +1302,10 @@ func (a *AddrManager) GetBestLocalAddress(remoteAddr *wire.NetAddress) *wire.Net
 		}
 	}
 	if bestAddress != nil {
-		log.Debugf("Suggesting address %s:%d for %s:%d", bestAddress.IP,
-			bestAddress.Port, remoteAddr.IP, remoteAddr.Port)
+		log.Debugf("Suggesting address %s for %s", bestAddress,
+			remoteAddr)
 	} else {
-		log.Debugf("No worthy address for %s:%d", remoteAddr.IP,
-			remoteAddr.Port)
+		log.Debugf("No worthy address for %s", remoteAddr)
 
 		// Send something unroutable if nothing suitable.
 		var ip net.IP
@@ -1129,22 +1315,36 @@ func (a *AddrManager) GetBestLocalAddress(remoteAddr *wire.NetAddress) *wire.Net
 			ip = net.IPv4zero
 		}
 		services := wire.SFNodeNetwork | wire.SFNodeWitness | wire.SFNodeBloom
-		bestAddress = wire.NewNetAddressIPPort(ip, 0, services)
+		bestAddress = wire.NetAddressV2FromLegacy(
+			wire.NewNetAddressIPPort(ip, 0, services))
 	}
 
 	return bestAddress
 }
 
-// New returns a new bitcoin address manager.
+// New returns a new bitcoin address manager which keeps the known addresses
+// in a file in the passed data directory.
 // Use Start to begin processing asynchronous address updates.
 func New(dataDir string, lookupFunc func(string) ([]net.IP, error)) *AddrManager {
+	store := NewFileStore(filepath.Join(dataDir, "peers.jsonl"))
+	return NewWithStore(dataDir, store, lookupFunc)
+}
+
+// NewWithStore returns a new bitcoin address manager which keeps the known
+// addresses in the passed store.  The addresses in the peers file of previous
+// versions in the passed data directory are migrated into the store when it is
+// empty.  The store is closed when the address manager is stopped.
+// Use Start to begin processing asynchronous address updates.
+func NewWithStore(dataDir string, store Store,
+	lookupFunc func(string) ([]net.IP, error)) *AddrManager {
+
 	am := AddrManager{
+		store:          store,
 		peersFile:      filepath.Join(dataDir, "peers.json"),
 		lookupFunc:     lookupFunc,
 		rand:           rand.New(rand.NewSource(time.Now().UnixNano())),
 		quit:           make(chan struct{}),
 		localAddresses: make(map[string]*localAddress),
-		version:        serialisationVersion,
 	}
 	am.reset()
 	return &am
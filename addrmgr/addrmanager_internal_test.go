@@ -1,51 +1,66 @@
 package addrmgr
 
 import (
+	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"math/rand"
 	"net"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/btcsuite/btcd/wire"
 )
 
-// randAddr generates a *wire.NetAddress backed by a random IPv4/IPv6 address.
-func randAddr(t *testing.T) *wire.NetAddress {
+// randAddr generates a *wire.NetAddressV2 backed by a random routable
+// IPv4/IPv6 address.  Non-routable addresses are never generated since the
+// address manager ignores them.
+func randAddr(t *testing.T) *wire.NetAddressV2 {
 	t.Helper()
 
-	ipv4 := rand.Intn(2) == 0
-	var ip net.IP
-	if ipv4 {
-		var b [4]byte
-		if _, err := rand.Read(b[:]); err != nil {
-			t.Fatal(err)
-		}
-		ip = b[:]
-	} else {
-		var b [16]byte
-		if _, err := rand.Read(b[:]); err != nil {
-			t.Fatal(err)
+	for {
+		ipv4 := rand.Intn(2) == 0
+		var ip net.IP
+		if ipv4 {
+			var b [4]byte
+			if _, err := rand.Read(b[:]); err != nil {
+				t.Fatal(err)
+			}
+			ip = b[:]
+		} else {
+			var b [16]byte
+			if _, err := rand.Read(b[:]); err != nil {
+				t.Fatal(err)
+			}
+			ip = b[:]
 		}
-		ip = b[:]
-	}
 
-	return &wire.NetAddress{
-		Services: wire.ServiceFlag(rand.Uint64()),
-		IP:       ip,
-		Port:     uint16(rand.Uint32()),
+		addr := wire.NetAddressV2FromLegacy(&wire.NetAddress{
+			Services: wire.ServiceFlag(rand.Uint64()),
+			IP:       ip,
+			Port:     uint16(rand.Uint32()),
+		})
+		if IsRoutable(addr) {
+			return addr
+		}
 	}
 }
 
 // assertAddr ensures that the two addresses match. The timestamp is not
 // checked as it does not affect uniquely identifying a specific address.
-func assertAddr(t *testing.T, got, expected *wire.NetAddress) {
+func assertAddr(t *testing.T, got, expected *wire.NetAddressV2) {
 	if got.Services != expected.Services {
 		t.Fatalf("expected address services %v, got %v",
 			expected.Services, got.Services)
 	}
-	if !got.IP.Equal(expected.IP) {
-		t.Fatalf("expected address IP %v, got %v", expected.IP, got.IP)
+	if got.NetworkID != expected.NetworkID {
+		t.Fatalf("expected address network %v, got %v",
+			expected.NetworkID, got.NetworkID)
+	}
+	if !bytes.Equal(got.Addr, expected.Addr) {
+		t.Fatalf("expected address %v, got %v", expected.Host(),
+			got.Host())
 	}
 	if got.Port != expected.Port {
 		t.Fatalf("expected address port %d, got %d", expected.Port,
@@ -56,7 +71,7 @@ func assertAddr(t *testing.T, got, expected *wire.NetAddress) {
 // assertAddrs ensures that the manager's address cache matches the given
 // expected addresses.
 func assertAddrs(t *testing.T, addrMgr *AddrManager,
-	expectedAddrs map[string]*wire.NetAddress) {
+	expectedAddrs map[string]*wire.NetAddressV2) {
 
 	t.Helper()
 
@@ -96,7 +111,7 @@ func TestAddrManagerSerialization(t *testing.T) {
 	// We'll be adding 5 random addresses to the manager.
 	const numAddrs = 5
 
-	expectedAddrs := make(map[string]*wire.NetAddress, numAddrs)
+	expectedAddrs := make(map[string]*wire.NetAddressV2, numAddrs)
 	for i := 0; i < numAddrs; i++ {
 		addr := randAddr(t)
 		expectedAddrs[NetAddressKey(addr)] = addr
@@ -118,8 +133,60 @@ func TestAddrManagerSerialization(t *testing.T) {
 	assertAddrs(t, addrMgr, expectedAddrs)
 }
 
-// TestAddrManagerV1ToV2 ensures that we can properly upgrade the serialized
-// version of the address manager from v1 to v2.
+// writePeersFile writes the addresses of the passed address manager to a peers
+// file of the passed version as written by previous versions of the address
+// manager.
+func writePeersFile(t *testing.T, a *AddrManager, version int) {
+	t.Helper()
+
+	sam := new(serializedAddrManager)
+	sam.Version = version
+	copy(sam.Key[:], a.key[:])
+	for k, v := range a.addrIndex {
+		ska := &serializedKnownAddress{
+			Addr:        k,
+			Src:         NetAddressKey(v.srcAddr),
+			Attempts:    v.attempts,
+			TimeStamp:   v.na.Timestamp.Unix(),
+			LastAttempt: v.lastattempt.Unix(),
+			LastSuccess: v.lastsuccess.Unix(),
+		}
+		if version > 1 {
+			ska.Services = v.na.Services
+			ska.SrcServices = v.srcAddr.Services
+		}
+		if version > 2 {
+			ska.Network = v.na.NetworkID
+			ska.SrcNetwork = v.srcAddr.NetworkID
+		}
+		sam.Addresses = append(sam.Addresses, ska)
+	}
+	for i := range a.addrNew {
+		for k := range a.addrNew[i] {
+			sam.NewBuckets[i] = append(sam.NewBuckets[i], k)
+		}
+	}
+	for i := range a.addrTried {
+		for e := a.addrTried[i].Front(); e != nil; e = e.Next() {
+			ka := e.Value.(*KnownAddress)
+			sam.TriedBuckets[i] = append(sam.TriedBuckets[i],
+				NetAddressKey(ka.na))
+		}
+	}
+
+	w, err := os.Create(a.peersFile)
+	if err != nil {
+		t.Fatalf("unable to create peers file: %v", err)
+	}
+	defer w.Close()
+	if err := json.NewEncoder(w).Encode(sam); err != nil {
+		t.Fatalf("unable to write peers file: %v", err)
+	}
+}
+
+// TestAddrManagerV1ToV2 ensures that we can properly migrate the v1 peers file
+// of previous versions, which lacks the services of the addresses, into the
+// store.
 func TestAddrManagerV1ToV2(t *testing.T) {
 	t.Parallel()
 
@@ -133,26 +200,21 @@ func TestAddrManagerV1ToV2(t *testing.T) {
 
 	addrMgr := New(tempDir, nil)
 
-	// As we're interested in testing the upgrade path from v1 to v2, we'll
-	// override the manager's current version.
-	addrMgr.version = 1
-
 	// We'll be adding 5 random addresses to the manager. Since this is v1,
 	// each addresses' services will not be stored.
 	const numAddrs = 5
 
-	expectedAddrs := make(map[string]*wire.NetAddress, numAddrs)
+	expectedAddrs := make(map[string]*wire.NetAddressV2, numAddrs)
 	for i := 0; i < numAddrs; i++ {
 		addr := randAddr(t)
 		expectedAddrs[NetAddressKey(addr)] = addr
 		addrMgr.AddAddress(addr, randAddr(t))
 	}
 
-	// Then, we'll persist these addresses to disk and restart the address
-	// manager - overriding its version back to v1.
-	addrMgr.savePeers()
+	// Then, we'll persist these addresses to a v1 peers file and restart
+	// the address manager.
+	writePeersFile(t, addrMgr, 1)
 	addrMgr = New(tempDir, nil)
-	addrMgr.version = 1
 
 	// When we read all of the addresses back from disk, we should expect to
 	// find all of them, but their services will be set to a default of
@@ -180,15 +242,69 @@ func TestAddrManagerV1ToV2(t *testing.T) {
 		addrMgr.SetServices(addr, expectedAddr.Services)
 	}
 
-	// We'll also bump up the manager's version to v2, which should signal
-	// that it should include the address services when persisting its
-	// state.
-	addrMgr.version = 2
+	// The peers file should have been removed once its addresses were
+	// migrated into the store.
+	if _, err := os.Stat(addrMgr.peersFile); !os.IsNotExist(err) {
+		t.Fatalf("expected peers file to be removed, got %v", err)
+	}
+
+	// Finally, we'll save the updated services, recreate the manager, and
+	// ensure that the services were persisted correctly.
 	addrMgr.savePeers()
+	addrMgr = New(tempDir, nil)
+	addrMgr.loadPeers()
+	assertAddrs(t, addrMgr, expectedAddrs)
+}
+
+// TestAddrManagerSerializationOverlay ensures that addresses of the overlay
+// networks, which can only be represented by addrv2 messages, survive a
+// serialization round trip.  This is of particular interest for CJDNS
+// addresses since their string representation can't be distinguished from the
+// one of IPv6 addresses.
+func TestAddrManagerSerializationOverlay(t *testing.T) {
+	t.Parallel()
+
+	tempDir, err := ioutil.TempDir("", "addrmgr")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	addrMgr := New(tempDir, nil)
 
-	// Finally, we'll recreate the manager and ensure that the services were
-	// persisted correctly.
+	newAddr := func(netID wire.NetworkID, size int) *wire.NetAddressV2 {
+		addr := make([]byte, size)
+		if _, err := rand.Read(addr); err != nil {
+			t.Fatal(err)
+		}
+		if netID == wire.NetIDCJDNS {
+			addr[0] = 0xfc
+		}
+		return wire.NewNetAddressV2(time.Now(), wire.SFNodeNetwork,
+			netID, addr, uint16(rand.Uint32()))
+	}
+
+	expectedAddrs := make(map[string]*wire.NetAddressV2)
+	for _, addr := range []*wire.NetAddressV2{
+		newAddr(wire.NetIDTorV2, 10),
+		newAddr(wire.NetIDTorV3, 32),
+		newAddr(wire.NetIDI2P, 32),
+		newAddr(wire.NetIDCJDNS, 16),
+	} {
+		expectedAddrs[NetAddressKey(addr)] = addr
+		addrMgr.AddAddress(addr, newAddr(wire.NetIDCJDNS, 16))
+	}
+	assertAddrs(t, addrMgr, expectedAddrs)
+
+	addrMgr.savePeers()
 	addrMgr = New(tempDir, nil)
 	addrMgr.loadPeers()
 	assertAddrs(t, addrMgr, expectedAddrs)
+
+	for _, ka := range addrMgr.addrIndex {
+		if ka.srcAddr.NetworkID != wire.NetIDCJDNS {
+			t.Fatalf("expected source address network %v, got %v",
+				wire.NetIDCJDNS, ka.srcAddr.NetworkID)
+		}
+	}
 }
@@ -19,7 +19,7 @@ import (
 // naTest is used to describe a test to be performed against the NetAddressKey
 // method.
 type naTest struct {
-	in   wire.NetAddress
+	in   wire.NetAddressV2
 	want string
 }
 
@@ -93,7 +93,8 @@ func addNaTests() {
 
 func addNaTest(ip string, port uint16, want string) {
 	nip := net.ParseIP(ip)
-	na := *wire.NewNetAddressIPPort(nip, port, wire.SFNodeNetwork)
+	na := *wire.NetAddressV2FromLegacy(
+		wire.NewNetAddressIPPort(nip, port, wire.SFNodeNetwork))
 	test := naTest{na, want}
 	naTests = append(naTests, test)
 }
@@ -194,7 +195,8 @@ func TestAddLocalAddress(t *testing.T) {
 	}
 	amgr := addrmgr.New("testaddlocaladdress", nil)
 	for x, test := range tests {
-		result := amgr.AddLocalAddress(&test.address, test.priority)
+		na := wire.NetAddressV2FromLegacy(&test.address)
+		result := amgr.AddLocalAddress(na, test.priority)
 		if result == nil && !test.valid {
 			t.Errorf("TestAddLocalAddress test #%d failed: %s should have "+
 				"been accepted", x, test.address.IP)
@@ -257,7 +259,7 @@ func TestNeedMoreAddresses(t *testing.T) {
 	if !b {
 		t.Errorf("Expected that we need more addresses")
 	}
-	addrs := make([]*wire.NetAddress, addrsToAdd)
+	addrs := make([]*wire.NetAddressV2, addrsToAdd)
 
 	var err error
 	for i := 0; i < addrsToAdd; i++ {
@@ -268,7 +270,8 @@ func TestNeedMoreAddresses(t *testing.T) {
 		}
 	}
 
-	srcAddr := wire.NewNetAddressIPPort(net.IPv4(173, 144, 173, 111), 8333, 0)
+	srcAddr := wire.NetAddressV2FromLegacy(
+		wire.NewNetAddressIPPort(net.IPv4(173, 144, 173, 111), 8333, 0))
 
 	n.AddAddresses(addrs, srcAddr)
 	numAddrs := n.NumAddresses()
@@ -285,7 +288,7 @@ func TestNeedMoreAddresses(t *testing.T) {
 func TestGood(t *testing.T) {
 	n := addrmgr.New("testgood", lookupFunc)
 	addrsToAdd := 64 * 64
-	addrs := make([]*wire.NetAddress, addrsToAdd)
+	addrs := make([]*wire.NetAddressV2, addrsToAdd)
 
 	var err error
 	for i := 0; i < addrsToAdd; i++ {
@@ -296,7 +299,8 @@ func TestGood(t *testing.T) {
 		}
 	}
 
-	srcAddr := wire.NewNetAddressIPPort(net.IPv4(173, 144, 173, 111), 8333, 0)
+	srcAddr := wire.NetAddressV2FromLegacy(
+		wire.NewNetAddressIPPort(net.IPv4(173, 144, 173, 111), 8333, 0))
 
 	n.AddAddresses(addrs, srcAddr)
 	for _, addr := range addrs {
@@ -314,6 +318,35 @@ func TestGood(t *testing.T) {
 	}
 }
 
+func TestServices(t *testing.T) {
+	n := addrmgr.New("testservices", lookupFunc)
+	addr, err := n.DeserializeNetAddress("173.194.115.66:8333",
+		wire.SFNodeNetwork)
+	if err != nil {
+		t.Fatalf("Failed to turn address into a net address: %v", err)
+	}
+
+	// Unknown addresses don't advertise any services.
+	if services := n.Services(addr); services != 0 {
+		t.Fatalf("Services of unknown address: got %v, want 0", services)
+	}
+
+	srcAddr := wire.NetAddressV2FromLegacy(
+		wire.NewNetAddressIPPort(net.IPv4(173, 144, 173, 111), 8333, 0))
+	n.AddAddress(addr, srcAddr)
+	if services := n.Services(addr); services != wire.SFNodeNetwork {
+		t.Fatalf("Services of added address: got %v, want %v",
+			services, wire.SFNodeNetwork)
+	}
+
+	want := wire.SFNodeNetwork | wire.SFNodeP2PV2
+	n.SetServices(addr, want)
+	if services := n.Services(addr); services != want {
+		t.Fatalf("Services of updated address: got %v, want %v",
+			services, want)
+	}
+}
+
 func TestGetAddress(t *testing.T) {
 	n := addrmgr.New("testgetaddress", lookupFunc)
 
@@ -331,8 +364,8 @@ func TestGetAddress(t *testing.T) {
 	if ka == nil {
 		t.Fatalf("Did not get an address where there is one in the pool")
 	}
-	if ka.NetAddress().IP.String() != someIP {
-		t.Errorf("Wrong IP: got %v, want %v", ka.NetAddress().IP.String(), someIP)
+	if ka.NetAddress().IP().String() != someIP {
+		t.Errorf("Wrong IP: got %v, want %v", ka.NetAddress().IP().String(), someIP)
 	}
 
 	// Mark this as a good address and get it
@@ -341,8 +374,8 @@ func TestGetAddress(t *testing.T) {
 	if ka == nil {
 		t.Fatalf("Did not get an address where there is one in the pool")
 	}
-	if ka.NetAddress().IP.String() != someIP {
-		t.Errorf("Wrong IP: got %v, want %v", ka.NetAddress().IP.String(), someIP)
+	if ka.NetAddress().IP().String() != someIP {
+		t.Errorf("Wrong IP: got %v, want %v", ka.NetAddress().IP().String(), someIP)
 	}
 
 	numAddrs := n.NumAddresses()
@@ -405,38 +438,43 @@ func TestGetBestLocalAddress(t *testing.T) {
 
 	// Test against default when there's no address
 	for x, test := range tests {
-		got := amgr.GetBestLocalAddress(&test.remoteAddr)
-		if !test.want0.IP.Equal(got.IP) {
+		remoteAddr := wire.NetAddressV2FromLegacy(&test.remoteAddr)
+		got := amgr.GetBestLocalAddress(remoteAddr)
+		if !test.want0.IP.Equal(got.IP()) {
 			t.Errorf("TestGetBestLocalAddress test1 #%d failed for remote address %s: want %s got %s",
-				x, test.remoteAddr.IP, test.want1.IP, got.IP)
+				x, test.remoteAddr.IP, test.want1.IP, got.IP())
 			continue
 		}
 	}
 
 	for _, localAddr := range localAddrs {
-		amgr.AddLocalAddress(&localAddr, addrmgr.InterfacePrio)
+		na := wire.NetAddressV2FromLegacy(&localAddr)
+		amgr.AddLocalAddress(na, addrmgr.InterfacePrio)
 	}
 
 	// Test against want1
 	for x, test := range tests {
-		got := amgr.GetBestLocalAddress(&test.remoteAddr)
-		if !test.want1.IP.Equal(got.IP) {
+		remoteAddr := wire.NetAddressV2FromLegacy(&test.remoteAddr)
+		got := amgr.GetBestLocalAddress(remoteAddr)
+		if !test.want1.IP.Equal(got.IP()) {
 			t.Errorf("TestGetBestLocalAddress test1 #%d failed for remote address %s: want %s got %s",
-				x, test.remoteAddr.IP, test.want1.IP, got.IP)
+				x, test.remoteAddr.IP, test.want1.IP, got.IP())
 			continue
 		}
 	}
 
 	// Add a public IP to the list of local addresses.
 	localAddr := wire.NetAddress{IP: net.ParseIP("204.124.8.100")}
-	amgr.AddLocalAddress(&localAddr, addrmgr.InterfacePrio)
+	amgr.AddLocalAddress(wire.NetAddressV2FromLegacy(&localAddr),
+		addrmgr.InterfacePrio)
 
 	// Test against want2
 	for x, test := range tests {
-		got := amgr.GetBestLocalAddress(&test.remoteAddr)
-		if !test.want2.IP.Equal(got.IP) {
+		remoteAddr := wire.NetAddressV2FromLegacy(&test.remoteAddr)
+		got := amgr.GetBestLocalAddress(remoteAddr)
+		if !test.want2.IP.Equal(got.IP()) {
 			t.Errorf("TestGetBestLocalAddress test2 #%d failed for remote address %s: want %s got %s",
-				x, test.remoteAddr.IP, test.want2.IP, got.IP)
+				x, test.remoteAddr.IP, test.want2.IP, got.IP())
 			continue
 		}
 	}
@@ -0,0 +1,176 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package addrmgr
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/btcsuite/btcd/database"
+)
+
+const (
+	// dbStoreVersion is the current version of the database store format.
+	dbStoreVersion = 1
+)
+
+var (
+	// addrMgrBucketName is the name of the metadata bucket housing the
+	// state of the address manager.
+	addrMgrBucketName = []byte("addrmgr")
+
+	// addrsBucketName is the name of the bucket within the address manager
+	// bucket which maps address keys to the stored addresses.
+	addrsBucketName = []byte("addrs")
+
+	// versionKeyName is the key of the version of the database store
+	// format.
+	versionKeyName = []byte("version")
+
+	// keyKeyName is the key of the key of the address manager.
+	keyKeyName = []byte("key")
+)
+
+// DBStore is a Store which keeps the addresses in the metadata of a database,
+// typically the block database of the node.  Each address is kept in its own
+// entry, so updates only write the addresses which changed.
+type DBStore struct {
+	db database.DB
+}
+
+// Ensure DBStore implements the Store interface.
+var _ Store = (*DBStore)(nil)
+
+// NewDBStore returns a store which keeps the addresses in the passed database.
+// The database is not closed by the store.
+func NewDBStore(db database.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+// Load returns the addresses in the database.  Malformed entries are removed.
+//
+// This is part of the Store interface.
+func (s *DBStore) Load() (*StoreState, error) {
+	var state *StoreState
+	var corrupt [][]byte
+	err := s.db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(addrMgrBucketName)
+		if bucket == nil {
+			return nil
+		}
+
+		versionBytes := bucket.Get(versionKeyName)
+		if len(versionBytes) != 4 {
+			return fmt.Errorf("malformed address manager version")
+		}
+		version := binary.LittleEndian.Uint32(versionBytes)
+		if version > dbStoreVersion {
+			return fmt.Errorf("unknown address manager version %v",
+				version)
+		}
+
+		keyBytes := bucket.Get(keyKeyName)
+		if len(keyBytes) != 32 {
+			return fmt.Errorf("malformed address manager key")
+		}
+		state = new(StoreState)
+		copy(state.Key[:], keyBytes)
+
+		addrs := bucket.Bucket(addrsBucketName)
+		if addrs == nil {
+			return nil
+		}
+		return addrs.ForEach(func(k, v []byte) error {
+			sa := new(StoredAddress)
+			if err := json.Unmarshal(v, sa); err != nil ||
+				sa.Addr != string(k) {
+
+				log.Warnf("Discarding malformed address %q", k)
+				corrupt = append(corrupt, append([]byte(nil), k...))
+				return nil
+			}
+			state.Addresses = append(state.Addresses, sa)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(corrupt) > 0 {
+		err := s.db.Update(func(dbTx database.Tx) error {
+			addrs := dbTx.Metadata().Bucket(addrMgrBucketName).
+				Bucket(addrsBucketName)
+			for _, k := range corrupt {
+				if err := addrs.Delete(k); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return state, nil
+}
+
+// Update applies the passed changes to the database in a single transaction.
+//
+// This is part of the Store interface.
+func (s *DBStore) Update(batch *StoreBatch) error {
+	return s.db.Update(func(dbTx database.Tx) error {
+		bucket, err := dbTx.Metadata().CreateBucketIfNotExists(
+			addrMgrBucketName)
+		if err != nil {
+			return err
+		}
+
+		var versionBytes [4]byte
+		binary.LittleEndian.PutUint32(versionBytes[:], dbStoreVersion)
+		if err := bucket.Put(versionKeyName, versionBytes[:]); err != nil {
+			return err
+		}
+		if err := bucket.Put(keyKeyName, batch.Key[:]); err != nil {
+			return err
+		}
+
+		if batch.Reset && bucket.Bucket(addrsBucketName) != nil {
+			err := bucket.DeleteBucket(addrsBucketName)
+			if err != nil {
+				return err
+			}
+		}
+		addrs, err := bucket.CreateBucketIfNotExists(addrsBucketName)
+		if err != nil {
+			return err
+		}
+
+		for _, sa := range batch.Put {
+			v, err := json.Marshal(sa)
+			if err != nil {
+				return err
+			}
+			if err := addrs.Put([]byte(sa.Addr), v); err != nil {
+				return err
+			}
+		}
+		for _, key := range batch.Delete {
+			if err := addrs.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Close does nothing since the database is owned by the caller.
+//
+// This is part of the Store interface.
+func (s *DBStore) Close() error {
+	return nil
+}
@@ -34,5 +34,15 @@ provided by the caller about connected, known good, and attempted addresses to
 periodically purge peers which no longer appear to be good peers as well as
 bias the selection toward known good peers.  The general idea is to make a best
 effort at only providing usable addresses.
+
+Address Storage
+
+The known addresses are persisted through the Store interface so they survive
+restarts.  Only the addresses which changed since they were last saved are
+passed to the store, which allows large address managers to be persisted
+incrementally.  Two stores are provided: FileStore, which appends changes to a
+file and periodically compacts it, and DBStore, which keeps the addresses in
+the metadata of a database.  The peers.json file written by previous versions
+is migrated into the store on startup.
 */
 package addrmgr
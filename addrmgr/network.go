@@ -99,30 +99,59 @@ func ipNet(ip string, ones, bits int) net.IPNet {
 	return net.IPNet{IP: net.ParseIP(ip), Mask: net.CIDRMask(ones, bits)}
 }
 
+// ipAddr returns the IP address of the passed address when it is an IPv4 or
+// IPv6 address and nil otherwise.  CJDNS addresses are excluded on purpose
+// since they share their encoding with IPv6 addresses in fc00::/8, but must
+// not be treated as such.
+func ipAddr(na *wire.NetAddressV2) net.IP {
+	switch na.NetworkID {
+	case wire.NetIDIPv4, wire.NetIDIPv6:
+		return net.IP(na.Addr)
+	}
+	return nil
+}
+
 // IsIPv4 returns whether or not the given address is an IPv4 address.
-func IsIPv4(na *wire.NetAddress) bool {
-	return na.IP.To4() != nil
+func IsIPv4(na *wire.NetAddressV2) bool {
+	return ipAddr(na).To4() != nil
 }
 
 // IsLocal returns whether or not the given address is a local address.
-func IsLocal(na *wire.NetAddress) bool {
-	return na.IP.IsLoopback() || zero4Net.Contains(na.IP)
+func IsLocal(na *wire.NetAddressV2) bool {
+	ip := ipAddr(na)
+	return ip.IsLoopback() || zero4Net.Contains(ip)
+}
+
+// IsOnionCatTor returns whether or not the passed address is a Tor v2 onion
+// address.  These addresses are encoded in the IPv6 range used by bitcoin to
+// support Tor (fd87:d87e:eb43::/48) in legacy addr messages.  Note that this
+// range is the same range used by OnionCat, which is part of the RFC4193
+// unique local IPv6 range.
+func IsOnionCatTor(na *wire.NetAddressV2) bool {
+	return na.NetworkID == wire.NetIDTorV2
+}
+
+// IsTorV3 returns whether or not the passed address is a Tor v3 onion address.
+func IsTorV3(na *wire.NetAddressV2) bool {
+	return na.NetworkID == wire.NetIDTorV3
 }
 
-// IsOnionCatTor returns whether or not the passed address is in the IPv6 range
-// used by bitcoin to support Tor (fd87:d87e:eb43::/48).  Note that this range
-// is the same range used by OnionCat, which is part of the RFC4193 unique local
-// IPv6 range.
-func IsOnionCatTor(na *wire.NetAddress) bool {
-	return onionCatNet.Contains(na.IP)
+// IsI2P returns whether or not the passed address is an I2P address.
+func IsI2P(na *wire.NetAddressV2) bool {
+	return na.NetworkID == wire.NetIDI2P
+}
+
+// IsCJDNS returns whether or not the passed address is a CJDNS address.
+func IsCJDNS(na *wire.NetAddressV2) bool {
+	return na.NetworkID == wire.NetIDCJDNS
 }
 
 // IsRFC1918 returns whether or not the passed address is part of the IPv4
 // private network address space as defined by RFC1918 (10.0.0.0/8,
 // 172.16.0.0/12, or 192.168.0.0/16).
-func IsRFC1918(na *wire.NetAddress) bool {
+func IsRFC1918(na *wire.NetAddressV2) bool {
 	for _, rfc := range rfc1918Nets {
-		if rfc.Contains(na.IP) {
+		if rfc.Contains(ipAddr(na)) {
 			return true
 		}
 	}
@@ -131,58 +160,58 @@ func IsRFC1918(na *wire.NetAddress) bool {
 
 // IsRFC2544 returns whether or not the passed address is part of the IPv4
 // address space as defined by RFC2544 (198.18.0.0/15)
-func IsRFC2544(na *wire.NetAddress) bool {
-	return rfc2544Net.Contains(na.IP)
+func IsRFC2544(na *wire.NetAddressV2) bool {
+	return rfc2544Net.Contains(ipAddr(na))
 }
 
 // IsRFC3849 returns whether or not the passed address is part of the IPv6
 // documentation range as defined by RFC3849 (2001:DB8::/32).
-func IsRFC3849(na *wire.NetAddress) bool {
-	return rfc3849Net.Contains(na.IP)
+func IsRFC3849(na *wire.NetAddressV2) bool {
+	return rfc3849Net.Contains(ipAddr(na))
 }
 
 // IsRFC3927 returns whether or not the passed address is part of the IPv4
 // autoconfiguration range as defined by RFC3927 (169.254.0.0/16).
-func IsRFC3927(na *wire.NetAddress) bool {
-	return rfc3927Net.Contains(na.IP)
+func IsRFC3927(na *wire.NetAddressV2) bool {
+	return rfc3927Net.Contains(ipAddr(na))
 }
 
 // IsRFC3964 returns whether or not the passed address is part of the IPv6 to
 // IPv4 encapsulation range as defined by RFC3964 (2002::/16).
-func IsRFC3964(na *wire.NetAddress) bool {
-	return rfc3964Net.Contains(na.IP)
+func IsRFC3964(na *wire.NetAddressV2) bool {
+	return rfc3964Net.Contains(ipAddr(na))
 }
 
 // IsRFC4193 returns whether or not the passed address is part of the IPv6
 // unique local range as defined by RFC4193 (FC00::/7).
-func IsRFC4193(na *wire.NetAddress) bool {
-	return rfc4193Net.Contains(na.IP)
+func IsRFC4193(na *wire.NetAddressV2) bool {
+	return rfc4193Net.Contains(ipAddr(na))
 }
 
 // IsRFC4380 returns whether or not the passed address is part of the IPv6
 // teredo tunneling over UDP range as defined by RFC4380 (2001::/32).
-func IsRFC4380(na *wire.NetAddress) bool {
-	return rfc4380Net.Contains(na.IP)
+func IsRFC4380(na *wire.NetAddressV2) bool {
+	return rfc4380Net.Contains(ipAddr(na))
 }
 
 // IsRFC4843 returns whether or not the passed address is part of the IPv6
 // ORCHID range as defined by RFC4843 (2001:10::/28).
-func IsRFC4843(na *wire.NetAddress) bool {
-	return rfc4843Net.Contains(na.IP)
+func IsRFC4843(na *wire.NetAddressV2) bool {
+	return rfc4843Net.Contains(ipAddr(na))
 }
 
 // IsRFC4862 returns whether or not the passed address is part of the IPv6
 // stateless address autoconfiguration range as defined by RFC4862 (FE80::/64).
-func IsRFC4862(na *wire.NetAddress) bool {
-	return rfc4862Net.Contains(na.IP)
+func IsRFC4862(na *wire.NetAddressV2) bool {
+	return rfc4862Net.Contains(ipAddr(na))
 }
 
 // IsRFC5737 returns whether or not the passed address is part of the IPv4
 // documentation address space as defined by RFC5737 (192.0.2.0/24,
 // 198.51.100.0/24, 203.0.113.0/24)
-func IsRFC5737(na *wire.NetAddress) bool {
+func IsRFC5737(na *wire.NetAddressV2) bool {
 	for _, rfc := range rfc5737Net {
-		if rfc.Contains(na.IP) {
+		if rfc.Contains(ipAddr(na)) {
 			return true
 		}
 	}
@@ -192,90 +221,128 @@ func IsRFC5737(na *wire.NetAddress) bool {
 
 // IsRFC6052 returns whether or not the passed address is part of the IPv6
 // well-known prefix range as defined by RFC6052 (64:FF9B::/96).
-func IsRFC6052(na *wire.NetAddress) bool {
-	return rfc6052Net.Contains(na.IP)
+func IsRFC6052(na *wire.NetAddressV2) bool {
+	return rfc6052Net.Contains(ipAddr(na))
 }
 
 // IsRFC6145 returns whether or not the passed address is part of the IPv6 to
 // IPv4 translated address range as defined by RFC6145 (::FFFF:0:0:0/96).
-func IsRFC6145(na *wire.NetAddress) bool {
-	return rfc6145Net.Contains(na.IP)
+func IsRFC6145(na *wire.NetAddressV2) bool {
+	return rfc6145Net.Contains(ipAddr(na))
 }
 
 // IsRFC6598 returns whether or not the passed address is part of the IPv4
 // shared address space specified by RFC6598 (100.64.0.0/10)
-func IsRFC6598(na *wire.NetAddress) bool {
-	return rfc6598Net.Contains(na.IP)
+func IsRFC6598(na *wire.NetAddressV2) bool {
+	return rfc6598Net.Contains(ipAddr(na))
 }
 
 // IsValid returns whether or not the passed address is valid.  The address is
 // considered invalid under the following circumstances:
 // IPv4: It is either a zero or all bits set address.
 // IPv6: It is either a zero or RFC3849 documentation address.
-func IsValid(na *wire.NetAddress) bool {
-	// IsUnspecified returns if address is 0, so only all bits set, and
-	// RFC3849 need to be explicitly checked.
-	return na.IP != nil && !(na.IP.IsUnspecified() ||
-		na.IP.Equal(net.IPv4bcast))
+// CJDNS: It is not in the fc00::/8 range.
+// Other: It is of an unknown network or doesn't have the size mandated by its
+// network.
+func IsValid(na *wire.NetAddressV2) bool {
+	switch na.NetworkID {
+	case wire.NetIDIPv4, wire.NetIDIPv6:
+		// IsUnspecified returns if address is 0, so only all bits set,
+		// and RFC3849 need to be explicitly checked.
+		ip := ipAddr(na)
+		return ip != nil && !(ip.IsUnspecified() ||
+			ip.Equal(net.IPv4bcast))
+
+	case wire.NetIDCJDNS:
+		return len(na.Addr) == net.IPv6len && na.Addr[0] == 0xfc
+
+	case wire.NetIDTorV2:
+		return len(na.Addr) == 10
+
+	case wire.NetIDTorV3, wire.NetIDI2P:
+		return len(na.Addr) == 32
+	}
+
+	return false
 }
 
 // IsRoutable returns whether or not the passed address is routable over
 // the public internet.  This is true as long as the address is valid and is not
-// in any reserved ranges.
-func IsRoutable(na *wire.NetAddress) bool {
-	return IsValid(na) && !(IsRFC1918(na) || IsRFC2544(na) ||
+// in any reserved ranges.  Valid addresses of the overlay networks Tor, I2P,
+// and CJDNS are always considered routable.
+func IsRoutable(na *wire.NetAddressV2) bool {
+	if !IsValid(na) {
+		return false
+	}
+	if ipAddr(na) == nil {
+		return true
+	}
+	return !(IsRFC1918(na) || IsRFC2544(na) ||
 		IsRFC3927(na) || IsRFC4862(na) || IsRFC3849(na) ||
 		IsRFC4843(na) || IsRFC5737(na) || IsRFC6598(na) ||
-		IsLocal(na) || (IsRFC4193(na) && !IsOnionCatTor(na)))
+		IsLocal(na) || IsRFC4193(na))
 }
 
 // GroupKey returns a string representing the network group an address is part
 // of.  This is the /16 for IPv4, the /32 (/36 for he.net) for IPv6, the string
 // "local" for a local address, the string "tor:key" where key is the /4 of the
-// onion address for Tor address, and the string "unroutable" for an unroutable
-// address.
-func GroupKey(na *wire.NetAddress) string {
+// onion address for Tor address, the strings "torv3:key" and "i2p:key" where
+// key is the /4 of the public key for Tor v3 and I2P addresses, the string
+// "cjdns:key" where key is the /4 following the fc prefix for CJDNS addresses,
+// and the string "unroutable" for an unroutable address.
+func GroupKey(na *wire.NetAddressV2) string {
 	if IsLocal(na) {
 		return "local"
 	}
 	if !IsRoutable(na) {
 		return "unroutable"
 	}
+	switch na.NetworkID {
+	case wire.NetIDTorV2:
+		// group is keyed off the first 4 bits of the actual onion key.
+		return fmt.Sprintf("tor:%d", na.Addr[0]&((1<<4)-1))
+
+	case wire.NetIDTorV3:
+		return fmt.Sprintf("torv3:%d", na.Addr[0]&((1<<4)-1))
+
+	case wire.NetIDI2P:
+		return fmt.Sprintf("i2p:%d", na.Addr[0]&((1<<4)-1))
+
+	case wire.NetIDCJDNS:
+		return fmt.Sprintf("cjdns:%d", na.Addr[1]>>4)
+	}
+
+	ip := ipAddr(na)
 	if IsIPv4(na) {
-		return na.IP.Mask(net.CIDRMask(16, 32)).String()
+		return ip.Mask(net.CIDRMask(16, 32)).String()
 	}
 	if IsRFC6145(na) || IsRFC6052(na) {
 		// last four bytes are the ip address
-		ip := na.IP[12:16]
-		return ip.Mask(net.CIDRMask(16, 32)).String()
+		v4 := ip[12:16]
+		return v4.Mask(net.CIDRMask(16, 32)).String()
 	}
 
 	if IsRFC3964(na) {
-		ip := na.IP[2:6]
-		return ip.Mask(net.CIDRMask(16, 32)).String()
+		v4 := ip[2:6]
+		return v4.Mask(net.CIDRMask(16, 32)).String()
 
 	}
 	if IsRFC4380(na) {
 		// teredo tunnels have the last 4 bytes as the v4 address XOR
 		// 0xff.
-		ip := net.IP(make([]byte, 4))
-		for i, byte := range na.IP[12:16] {
-			ip[i] = byte ^ 0xff
+		v4 := net.IP(make([]byte, 4))
+		for i, byte := range ip[12:16] {
+			v4[i] = byte ^ 0xff
 		}
-		return ip.Mask(net.CIDRMask(16, 32)).String()
+		return v4.Mask(net.CIDRMask(16, 32)).String()
 	}
-	if IsOnionCatTor(na) {
-		// group is keyed off the first 4 bits of the actual onion key.
-		return fmt.Sprintf("tor:%d", na.IP[6]&((1<<4)-1))
-	}
-
 	// OK, so now we know ourselves to be a IPv6 address.
 	// bitcoind uses /32 for everything, except for Hurricane Electric's
 	// (he.net) IP range, which it uses /36 for.
 	bits := 32
-	if heNet.Contains(na.IP) {
+	if heNet.Contains(ip) {
 		bits = 36
 	}
 
-	return na.IP.Mask(net.CIDRMask(bits, 128)).String()
+	return ip.Mask(net.CIDRMask(bits, 128)).String()
 }
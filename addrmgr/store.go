@@ -0,0 +1,364 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package addrmgr
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// StoredAddress is the persisted form of a known address.  Besides the address
+// itself it records the buckets the address is in, so the state of the address
+// manager can be restored from the individual addresses.
+type StoredAddress struct {
+	Addr        string
+	Src         string
+	Network     wire.NetworkID
+	SrcNetwork  wire.NetworkID
+	Services    wire.ServiceFlag
+	SrcServices wire.ServiceFlag
+	TimeStamp   int64
+	Attempts    int
+	LastAttempt int64
+	LastSuccess int64
+
+	// NewBuckets holds the new buckets the address is in in ascending
+	// order.  It is empty for tried addresses.
+	NewBuckets []int
+
+	// Tried indicates the address is in the tried bucket TriedBucket.
+	Tried       bool
+	TriedBucket int
+}
+
+// equal returns whether the passed stored address is identical to this one.
+func (s *StoredAddress) equal(other *StoredAddress) bool {
+	if s.Addr != other.Addr || s.Src != other.Src ||
+		s.Network != other.Network || s.SrcNetwork != other.SrcNetwork ||
+		s.Services != other.Services ||
+		s.SrcServices != other.SrcServices ||
+		s.TimeStamp != other.TimeStamp || s.Attempts != other.Attempts ||
+		s.LastAttempt != other.LastAttempt ||
+		s.LastSuccess != other.LastSuccess || s.Tried != other.Tried ||
+		s.TriedBucket != other.TriedBucket ||
+		len(s.NewBuckets) != len(other.NewBuckets) {
+
+		return false
+	}
+	for i := range s.NewBuckets {
+		if s.NewBuckets[i] != other.NewBuckets[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// StoreState is the state of an address manager as loaded from a Store.
+type StoreState struct {
+	Key       [32]byte
+	Addresses []*StoredAddress
+}
+
+// StoreBatch houses a set of changes to apply to a Store atomically.
+type StoreBatch struct {
+	// Key is the key of the address manager.
+	Key [32]byte
+
+	// Reset indicates all stored addresses are to be removed before the
+	// changes are applied.
+	Reset bool
+
+	// Put holds the addresses to add or replace.
+	Put []*StoredAddress
+
+	// Delete holds the keys of the addresses to remove.
+	Delete []string
+}
+
+// Store is the interface of the storage backends of the address manager.  The
+// address manager only passes the addresses which changed since the last
+// update to the store, so backends are able to persist the state
+// incrementally instead of rewriting it as a whole.
+type Store interface {
+	// Load returns the persisted state or nil when nothing was persisted
+	// yet.  Backends should recover from partially written or otherwise
+	// corrupt entries where possible and only return an error when the
+	// state can't be recovered at all.
+	Load() (*StoreState, error)
+
+	// Update applies the passed changes.
+	Update(batch *StoreBatch) error
+
+	// Close releases the resources held by the store.
+	Close() error
+}
+
+const (
+	// fileStoreVersion is the current version of the file store format.
+	fileStoreVersion = 1
+
+	// minCompactRecords is the minimum number of records in the file of a
+	// file store before it is compacted.
+	minCompactRecords = 1000
+)
+
+// fileStoreHeader is the first line of the file of a file store.
+type fileStoreHeader struct {
+	Version int
+	Key     [32]byte
+}
+
+// fileStoreRecord is a change recorded in the file of a file store.
+type fileStoreRecord struct {
+	Put    *StoredAddress `json:",omitempty"`
+	Delete string         `json:",omitempty"`
+}
+
+// FileStore is a Store which keeps the addresses in a file.  Changes are
+// appended to the file as they are made, and the file is compacted by
+// rewriting it once most of its records are outdated.  A torn write at the end
+// of the file, such as one caused by a crash, only loses the changes of the
+// interrupted update.
+type FileStore struct {
+	mtx     sync.Mutex
+	path    string
+	file    *os.File
+	key     [32]byte
+	addrs   map[string]*StoredAddress
+	records int
+}
+
+// Ensure FileStore implements the Store interface.
+var _ Store = (*FileStore)(nil)
+
+// NewFileStore returns a file store which keeps the addresses in the file at
+// the passed path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{
+		path:  path,
+		addrs: make(map[string]*StoredAddress),
+	}
+}
+
+// Load returns the addresses in the file of the store.  Records following the
+// first malformed one are discarded and the file is truncated accordingly.
+//
+// This is part of the Store interface.
+func (s *FileStore) Load() (*StoreState, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(f)
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error reading header of %s: %v",
+			s.path, err)
+	}
+	var header fileStoreHeader
+	if err := json.Unmarshal(line, &header); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("malformed header in %s: %v", s.path,
+			err)
+	}
+	if header.Version > fileStoreVersion {
+		f.Close()
+		return nil, fmt.Errorf("unknown version %v in %s",
+			header.Version, s.path)
+	}
+
+	s.key = header.Key
+	s.addrs = make(map[string]*StoredAddress)
+	s.records = 0
+	offset := int64(len(line))
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			break
+		}
+
+		var record fileStoreRecord
+		if err == nil {
+			err = json.Unmarshal(line, &record)
+		}
+		if err != nil {
+			log.Warnf("Discarding malformed records at offset %d "+
+				"of %s: %v", offset, s.path, err)
+			if err := f.Truncate(offset); err != nil {
+				f.Close()
+				return nil, err
+			}
+			break
+		}
+
+		s.apply(&record)
+		s.records++
+		offset += int64(len(line))
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	s.file = f
+
+	state := &StoreState{
+		Key:       s.key,
+		Addresses: make([]*StoredAddress, 0, len(s.addrs)),
+	}
+	for _, sa := range s.addrs {
+		state.Addresses = append(state.Addresses, sa)
+	}
+	return state, nil
+}
+
+// apply applies the passed record to the in-memory view of the file.
+func (s *FileStore) apply(record *fileStoreRecord) {
+	if record.Put != nil {
+		s.addrs[record.Put.Addr] = record.Put
+	}
+	if record.Delete != "" {
+		delete(s.addrs, record.Delete)
+	}
+}
+
+// Update appends the passed changes to the file of the store.  The file is
+// rewritten instead when the batch resets the store, the key changed, or most
+// of the records in the file are outdated.
+//
+// This is part of the Store interface.
+func (s *FileStore) Update(batch *StoreBatch) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	rewrite := batch.Reset || s.file == nil || batch.Key != s.key
+	if batch.Reset {
+		s.addrs = make(map[string]*StoredAddress)
+	}
+	s.key = batch.Key
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, sa := range batch.Put {
+		record := fileStoreRecord{Put: sa}
+		s.apply(&record)
+		if err := enc.Encode(&record); err != nil {
+			return err
+		}
+	}
+	for _, key := range batch.Delete {
+		record := fileStoreRecord{Delete: key}
+		s.apply(&record)
+		if err := enc.Encode(&record); err != nil {
+			return err
+		}
+	}
+
+	numRecords := len(batch.Put) + len(batch.Delete)
+	if !rewrite && s.records+numRecords > minCompactRecords &&
+		s.records+numRecords > 2*len(s.addrs) {
+
+		rewrite = true
+	}
+	if rewrite {
+		return s.rewrite()
+	}
+
+	if _, err := s.file.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	s.records += numRecords
+	return s.file.Sync()
+}
+
+// rewrite atomically replaces the file of the store by one only containing the
+// current addresses.
+func (s *FileStore) rewrite() error {
+	tmpPath := s.path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(&fileStoreHeader{
+		Version: fileStoreVersion,
+		Key:     s.key,
+	})
+
+	// The addresses are written in a deterministic order to make the file
+	// easier to inspect and compare.
+	keys := make([]string, 0, len(s.addrs))
+	for key := range s.addrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err != nil {
+			break
+		}
+		err = enc.Encode(&fileStoreRecord{Put: s.addrs[key]})
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return err
+	}
+
+	s.file, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	s.records = len(s.addrs)
+	return nil
+}
+
+// Close closes the file of the store.
+//
+// This is part of the Store interface.
+func (s *FileStore) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
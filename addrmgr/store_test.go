@@ -0,0 +1,306 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package addrmgr
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/btcsuite/btcd/database"
+	_ "github.com/btcsuite/btcd/database/ffldb"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// storedAddr returns a stored address with the passed address key in the
+// passed new bucket.
+func storedAddr(i, bucket int) *StoredAddress {
+	return &StoredAddress{
+		Addr:       fmt.Sprintf("1.2.3.%d:8333", i),
+		Src:        "5.6.7.8:8333",
+		Services:   wire.SFNodeNetwork,
+		NewBuckets: []int{bucket},
+	}
+}
+
+// assertStoreState ensures the passed store holds exactly the passed key and
+// addresses.
+func assertStoreState(t *testing.T, store Store, key [32]byte,
+	want []*StoredAddress) {
+
+	t.Helper()
+
+	state, err := store.Load()
+	if err != nil {
+		t.Fatalf("unable to load store: %v", err)
+	}
+	if state == nil {
+		t.Fatalf("expected state, got none")
+	}
+	if state.Key != key {
+		t.Fatalf("unexpected key -- got %x, want %x", state.Key, key)
+	}
+	sort.Slice(state.Addresses, func(i, j int) bool {
+		return state.Addresses[i].Addr < state.Addresses[j].Addr
+	})
+	if len(state.Addresses) == 0 {
+		state.Addresses = nil
+	}
+	if !reflect.DeepEqual(state.Addresses, want) {
+		t.Fatalf("unexpected addresses -- got %d, want %d",
+			len(state.Addresses), len(want))
+	}
+}
+
+// testStore ensures the passed store persists batches of changes correctly.
+func testStore(t *testing.T, store Store) {
+	t.Helper()
+
+	state, err := store.Load()
+	if err != nil || state != nil {
+		t.Fatalf("unexpected state of empty store -- got %v, %v",
+			state, err)
+	}
+
+	key := [32]byte{1}
+	addrs := []*StoredAddress{storedAddr(1, 1), storedAddr(2, 2),
+		storedAddr(3, 3)}
+	err = store.Update(&StoreBatch{Key: key, Reset: true, Put: addrs})
+	if err != nil {
+		t.Fatalf("unable to update store: %v", err)
+	}
+	assertStoreState(t, store, key, addrs)
+
+	// Update one address and delete another.
+	updated := storedAddr(2, 5)
+	updated.Tried = true
+	updated.TriedBucket = 7
+	updated.NewBuckets = nil
+	err = store.Update(&StoreBatch{
+		Key:    key,
+		Put:    []*StoredAddress{updated},
+		Delete: []string{addrs[0].Addr},
+	})
+	if err != nil {
+		t.Fatalf("unable to update store: %v", err)
+	}
+	assertStoreState(t, store, key, []*StoredAddress{updated, addrs[2]})
+
+	// Resetting the store removes all previous addresses.
+	key = [32]byte{2}
+	err = store.Update(&StoreBatch{
+		Key:   key,
+		Reset: true,
+		Put:   []*StoredAddress{addrs[0]},
+	})
+	if err != nil {
+		t.Fatalf("unable to update store: %v", err)
+	}
+	assertStoreState(t, store, key, []*StoredAddress{addrs[0]})
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("unable to close store: %v", err)
+	}
+}
+
+// TestFileStore ensures the file store persists changes, recovers from torn
+// writes, and compacts its file.
+func TestFileStore(t *testing.T) {
+	t.Parallel()
+
+	tempDir, err := ioutil.TempDir("", "addrmgr")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	path := filepath.Join(tempDir, "peers.jsonl")
+	testStore(t, NewFileStore(path))
+
+	// Simulate a torn write by appending a partial record.  Only the
+	// partial record must be discarded.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("unable to open file: %v", err)
+	}
+	f.WriteString(`{"Put":{"Addr":"9.9.9.9:83`)
+	f.Close()
+
+	store := NewFileStore(path)
+	assertStoreState(t, store, [32]byte{2},
+		[]*StoredAddress{storedAddr(1, 1)})
+
+	// The store must remain usable after the recovery.
+	err = store.Update(&StoreBatch{
+		Key: [32]byte{2},
+		Put: []*StoredAddress{storedAddr(2, 2)},
+	})
+	if err != nil {
+		t.Fatalf("unable to update store: %v", err)
+	}
+	store.Close()
+	store = NewFileStore(path)
+	assertStoreState(t, store, [32]byte{2},
+		[]*StoredAddress{storedAddr(1, 1), storedAddr(2, 2)})
+
+	// Repeatedly updating the same address must eventually compact the
+	// file to a single record per address.
+	for i := 0; i < minCompactRecords+1; i++ {
+		err := store.Update(&StoreBatch{
+			Key: [32]byte{2},
+			Put: []*StoredAddress{storedAddr(2, i%newBucketCount)},
+		})
+		if err != nil {
+			t.Fatalf("unable to update store: %v", err)
+		}
+	}
+	if store.records >= minCompactRecords {
+		t.Fatalf("expected file to be compacted, has %d records",
+			store.records)
+	}
+	store.Close()
+
+	// A corrupt header can't be recovered from.
+	if err := ioutil.WriteFile(path, []byte("{\n"), 0644); err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+	if _, err := NewFileStore(path).Load(); err == nil {
+		t.Fatalf("expected error loading corrupt file")
+	}
+}
+
+// TestDBStore ensures the database store persists changes.
+func TestDBStore(t *testing.T) {
+	t.Parallel()
+
+	tempDir, err := ioutil.TempDir("", "addrmgr")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	db, err := database.Create("ffldb", filepath.Join(tempDir, "db"),
+		wire.MainNet)
+	if err != nil {
+		t.Fatalf("unable to create database: %v", err)
+	}
+	defer db.Close()
+
+	testStore(t, NewDBStore(db))
+}
+
+// TestAddrManagerIncrementalSave ensures the address manager only writes the
+// addresses which changed to its store.
+func TestAddrManagerIncrementalSave(t *testing.T) {
+	t.Parallel()
+
+	tempDir, err := ioutil.TempDir("", "addrmgr")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store := &recordingStore{Store: NewFileStore(
+		filepath.Join(tempDir, "peers.jsonl"))}
+	addrMgr := NewWithStore(tempDir, store, nil)
+	addrMgr.loadPeers()
+
+	const numAddrs = 5
+	var addrs []*wire.NetAddressV2
+	for i := 0; i < numAddrs; i++ {
+		addr := randAddr(t)
+		addrs = append(addrs, addr)
+		addrMgr.AddAddress(addr, randAddr(t))
+	}
+
+	tests := []struct {
+		name       string
+		change     func()
+		wantReset  bool
+		wantPut    int
+		wantDelete int
+	}{{
+		name:      "initial save",
+		change:    func() {},
+		wantReset: true,
+		wantPut:   numAddrs,
+	}, {
+		name:   "no changes",
+		change: func() {},
+	}, {
+		name:    "attempt",
+		change:  func() { addrMgr.Attempt(addrs[0]) },
+		wantPut: 1,
+	}, {
+		name:    "good",
+		change:  func() { addrMgr.Good(addrs[1]) },
+		wantPut: 1,
+	}, {
+		name: "removed",
+		change: func() {
+			addrMgr.mtx.Lock()
+			key := NetAddressKey(addrs[2])
+			for i := range addrMgr.addrNew {
+				delete(addrMgr.addrNew[i], key)
+			}
+			delete(addrMgr.addrIndex, key)
+			addrMgr.nNew--
+			addrMgr.mtx.Unlock()
+		},
+		wantDelete: 1,
+	}}
+
+	for _, test := range tests {
+		test.change()
+		store.batch = nil
+		addrMgr.savePeers()
+
+		batch := store.batch
+		if batch == nil {
+			batch = &StoreBatch{}
+		}
+		if batch.Reset != test.wantReset ||
+			len(batch.Put) != test.wantPut ||
+			len(batch.Delete) != test.wantDelete {
+
+			t.Fatalf("%s: unexpected batch -- got reset %v, %d "+
+				"puts, %d deletes, want reset %v, %d puts, "+
+				"%d deletes", test.name, batch.Reset,
+				len(batch.Put), len(batch.Delete),
+				test.wantReset, test.wantPut, test.wantDelete)
+		}
+	}
+
+	// The state must survive a restart.
+	expectedAddrs := make(map[string]*wire.NetAddressV2)
+	for i, addr := range addrs {
+		if i != 2 {
+			expectedAddrs[NetAddressKey(addr)] = addr
+		}
+	}
+	addrMgr = New(tempDir, nil)
+	addrMgr.loadPeers()
+	assertAddrs(t, addrMgr, expectedAddrs)
+	if addrMgr.numAddresses() != numAddrs-1 || addrMgr.nTried != 1 {
+		t.Fatalf("unexpected number of addresses -- got %d (%d tried)",
+			addrMgr.numAddresses(), addrMgr.nTried)
+	}
+}
+
+// recordingStore is a store which records the last batch it was passed.
+type recordingStore struct {
+	Store
+	batch *StoreBatch
+}
+
+// Update records the passed batch and passes it on to the wrapped store.
+func (s *recordingStore) Update(batch *StoreBatch) error {
+	s.batch = batch
+	return s.Store.Update(batch)
+}
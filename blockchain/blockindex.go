@@ -232,6 +232,11 @@ type blockIndex struct {
 	sync.RWMutex
 	index map[chainhash.Hash]*blockNode
 	dirty map[*blockNode]struct{}
+
+	// tips contains the nodes in the index which don't have any children.
+	// Every node in the index is an ancestor of one of them, so searching
+	// the tree only needs to start at the tips rather than at every node.
+	tips map[*blockNode]struct{}
 }
 
 // newBlockIndex returns a new empty instance of a block index.  The index will
@@ -243,6 +248,7 @@ func newBlockIndex(db database.DB, chainParams *chaincfg.Params) *blockIndex {
 		chainParams: chainParams,
 		index:       make(map[chainhash.Hash]*blockNode),
 		dirty:       make(map[*blockNode]struct{}),
+		tips:        make(map[*blockNode]struct{}),
 	}
 }
 
@@ -284,6 +290,8 @@ func (bi *blockIndex) AddNode(node *blockNode) {
 // This function is NOT safe for concurrent access.
 func (bi *blockIndex) addNode(node *blockNode) {
 	bi.index[node.hash] = node
+	delete(bi.tips, node.parent)
+	bi.tips[node] = struct{}{}
 }
 
 // NodeStatus provides concurrent-safe access to the status field of a node.
@@ -319,6 +327,69 @@ func (bi *blockIndex) UnsetStatusFlags(node *blockNode, flags blockStatus) {
 	bi.Unlock()
 }
 
+// Descendants returns all block nodes in the index which descend from the
+// passed node in no particular order.
+//
+// This function is safe for concurrent access.
+func (bi *blockIndex) Descendants(node *blockNode) []*blockNode {
+	bi.RLock()
+	defer bi.RUnlock()
+
+	// Every descendant is an ancestor of one of the tips, so walk back from
+	// the tips towards the height of the passed node.  Remember whether the
+	// nodes visited descend from it, so every node is only visited once.
+	descends := make(map[*blockNode]bool)
+	var descendants []*blockNode
+	var path []*blockNode
+	for tip := range bi.tips {
+		path = path[:0]
+		ancestor := tip
+		for ancestor.height > node.height {
+			if _, ok := descends[ancestor]; ok {
+				break
+			}
+			path = append(path, ancestor)
+			ancestor = ancestor.parent
+		}
+		result, ok := descends[ancestor]
+		if !ok {
+			result = ancestor == node
+		}
+		for _, pn := range path {
+			descends[pn] = result
+			if result {
+				descendants = append(descendants, pn)
+			}
+		}
+	}
+
+	return descendants
+}
+
+// BestValidTip returns the node with the most cumulative work in the index
+// which is not known to be invalid.  Ties are resolved in favor of the passed
+// node.
+//
+// This function is safe for concurrent access.
+func (bi *blockIndex) BestValidTip(tip *blockNode) *blockNode {
+	bi.RLock()
+	defer bi.RUnlock()
+
+	// The work of the nodes only decreases when walking back from a tip, so
+	// the first valid node found on each branch is the best one of that
+	// branch, and the walk stops once a branch can't beat the best node.
+	best := tip
+	for n := range bi.tips {
+		for ; n != nil && n.workSum.Cmp(best.workSum) > 0; n = n.parent {
+			if !n.status.KnownInvalid() && n.status.HaveData() {
+				best = n
+				break
+			}
+		}
+	}
+	return best
+}
+
 // flushToDB writes all dirty block nodes to the database. If all writes
 // succeed, this clears the dirty set.
 func (bi *blockIndex) flushToDB() error {
@@ -8,6 +8,7 @@ package blockchain
 import (
 	"container/list"
 	"fmt"
+	"math/big"
 	"sync"
 	"time"
 
@@ -19,12 +20,6 @@ import (
 	"github.com/btcsuite/btcutil"
 )
 
-const (
-	// maxOrphanBlocks is the maximum number of orphan blocks that can be
-	// queued.
-	maxOrphanBlocks = 100
-)
-
 // BlockLocator is used to help locate a specific block.  The algorithm for
 // building the block locator is to add the hashes in reverse order until
 // the genesis block is reached.  In order to keep the list of locator hashes
@@ -41,14 +36,6 @@ const (
 // [17a 16a 15 14 13 12 11 10 9 8 7 6 4 genesis]
 type BlockLocator []*chainhash.Hash
 
-// orphanBlock represents a block that we don't yet have the parent for.  It
-// is a normal block plus an expiration time to prevent caching the orphan
-// forever.
-type orphanBlock struct {
-	block      *btcutil.Block
-	expiration time.Time
-}
-
 // BestState houses information about the current best block and other info
 // related to the state of the main chain as it exists from the point of view of
 // the current best block.
@@ -67,6 +54,7 @@ type BestState struct {
 	NumTxns     uint64         // The number of txns in the block.
 	TotalTxns   uint64         // The total number of txns in the chain.
 	MedianTime  time.Time      // Median time as per CalcPastMedianTime.
+	WorkSum     *big.Int       // The total work of the chain.
 }
 
 // newBestState returns a new best stats instance for the given parameters.
@@ -82,6 +70,7 @@ func newBestState(node *blockNode, blockSize, blockWeight, numTxns,
 		NumTxns:     numTxns,
 		TotalTxns:   totalTxns,
 		MedianTime:  medianTime,
+		WorkSum:     node.workSum,
 	}
 }
 
@@ -98,9 +87,10 @@ type BlockChain struct {
 	db                  database.DB
 	chainParams         *chaincfg.Params
 	timeSource          MedianTimeSource
-	sigCache            *txscript.SigCache
+	sigCache            txscript.SignatureCache
 	indexManager        IndexManager
 	hashCache           *txscript.HashCache
+	scriptPool          *ScriptValidationPool
 
 	// The following fields are calculated based upon the provided chain
 	// parameters.  They are also set when the instance is created and
@@ -128,10 +118,8 @@ type BlockChain struct {
 
 	// These fields are related to handling of orphan blocks.  They are
 	// protected by a combination of the chain lock and the orphan lock.
-	orphanLock   sync.RWMutex
-	orphans      map[chainhash.Hash]*orphanBlock
-	prevOrphans  map[chainhash.Hash][]*orphanBlock
-	oldestOrphan *orphanBlock
+	orphanLock sync.RWMutex
+	orphans    *orphanPool
 
 	// These fields are related to checkpoint handling.  They are protected
 	// by the chain lock.
@@ -152,6 +140,17 @@ type BlockChain struct {
 	stateLock     sync.RWMutex
 	stateSnapshot *BestState
 
+	// utxoSnapshot is the utxo snapshot the chain state was loaded from,
+	// if any, and utxoSnapshotValidated indicates whether the blocks
+	// leading up to it were validated.  They are protected by the chain
+	// lock.
+	utxoSnapshot          *UtxoSnapshot
+	utxoSnapshotValidated bool
+
+	// utxoCache caches the changes to the utxo set made by connected
+	// blocks before they are written to the database.
+	utxoCache *utxoCache
+
 	// The following caches are used to efficiently keep track of the
 	// current deployment threshold state of each rule change deployment.
 	//
@@ -176,10 +175,10 @@ type BlockChain struct {
 	// activated.
 	unknownRulesWarned bool
 
-	// The notifications field stores a slice of callbacks to be executed on
-	// certain blockchain events.
+	// The notifications field stores a slice of subscriptions with the
+	// callbacks to be executed on certain blockchain events.
 	notificationsLock sync.RWMutex
-	notifications     []NotificationCallback
+	notifications     []subscription
 }
 
 // HaveBlock returns whether or not the chain instance has the block represented
@@ -209,7 +208,7 @@ func (b *BlockChain) IsKnownOrphan(hash *chainhash.Hash) bool {
 	// Protect concurrent access.  Using a read lock only so multiple
 	// readers can query without blocking each other.
 	b.orphanLock.RLock()
-	_, exists := b.orphans[*hash]
+	exists := b.orphans.has(hash)
 	b.orphanLock.RUnlock()
 
 	return exists
@@ -225,103 +224,17 @@ func (b *BlockChain) GetOrphanRoot(hash *chainhash.Hash) *chainhash.Hash {
 	b.orphanLock.RLock()
 	defer b.orphanLock.RUnlock()
 
-	// Keep looping while the parent of each orphaned block is
-	// known and is an orphan itself.
-	orphanRoot := hash
-	prevHash := hash
-	for {
-		orphan, exists := b.orphans[*prevHash]
-		if !exists {
-			break
-		}
-		orphanRoot = prevHash
-		prevHash = &orphan.block.MsgBlock().Header.PrevBlock
-	}
-
-	return orphanRoot
-}
-
-// removeOrphanBlock removes the passed orphan block from the orphan pool and
-// previous orphan index.
-func (b *BlockChain) removeOrphanBlock(orphan *orphanBlock) {
-	// Protect concurrent access.
-	b.orphanLock.Lock()
-	defer b.orphanLock.Unlock()
-
-	// Remove the orphan block from the orphan pool.
-	orphanHash := orphan.block.Hash()
-	delete(b.orphans, *orphanHash)
-
-	// Remove the reference from the previous orphan index too.  An indexing
-	// for loop is intentionally used over a range here as range does not
-	// reevaluate the slice on each iteration nor does it adjust the index
-	// for the modified slice.
-	prevHash := &orphan.block.MsgBlock().Header.PrevBlock
-	orphans := b.prevOrphans[*prevHash]
-	for i := 0; i < len(orphans); i++ {
-		hash := orphans[i].block.Hash()
-		if hash.IsEqual(orphanHash) {
-			copy(orphans[i:], orphans[i+1:])
-			orphans[len(orphans)-1] = nil
-			orphans = orphans[:len(orphans)-1]
-			i--
-		}
-	}
-	b.prevOrphans[*prevHash] = orphans
-
-	// Remove the map entry altogether if there are no longer any orphans
-	// which depend on the parent hash.
-	if len(b.prevOrphans[*prevHash]) == 0 {
-		delete(b.prevOrphans, *prevHash)
-	}
+	return b.orphans.root(hash)
 }
 
 // addOrphanBlock adds the passed block (which is already determined to be
-// an orphan prior calling this function) to the orphan pool.  It lazily cleans
-// up any expired blocks so a separate cleanup poller doesn't need to be run.
-// It also imposes a maximum limit on the number of outstanding orphan
-// blocks and will remove the oldest received orphan block if the limit is
-// exceeded.
+// an orphan prior calling this function) to the orphan pool.  The oldest
+// orphan blocks are removed if the limits on the orphan pool are exceeded.
 func (b *BlockChain) addOrphanBlock(block *btcutil.Block) {
-	// Remove expired orphan blocks.
-	for _, oBlock := range b.orphans {
-		if time.Now().After(oBlock.expiration) {
-			b.removeOrphanBlock(oBlock)
-			continue
-		}
-
-		// Update the oldest orphan block pointer so it can be discarded
-		// in case the orphan pool fills up.
-		if b.oldestOrphan == nil || oBlock.expiration.Before(b.oldestOrphan.expiration) {
-			b.oldestOrphan = oBlock
-		}
-	}
-
-	// Limit orphan blocks to prevent memory exhaustion.
-	if len(b.orphans)+1 > maxOrphanBlocks {
-		// Remove the oldest orphan to make room for the new one.
-		b.removeOrphanBlock(b.oldestOrphan)
-		b.oldestOrphan = nil
-	}
-
-	// Protect concurrent access.  This is intentionally done here instead
-	// of near the top since removeOrphanBlock does its own locking and
-	// the range iterator is not invalidated by removing map entries.
+	// Protect concurrent access.
 	b.orphanLock.Lock()
-	defer b.orphanLock.Unlock()
-
-	// Insert the block into the orphan map with an expiration time
-	// 1 hour from now.
-	expiration := time.Now().Add(time.Hour)
-	oBlock := &orphanBlock{
-		block:      block,
-		expiration: expiration,
-	}
-	b.orphans[*block.Hash()] = oBlock
-
-	// Add to previous hash lookup index for faster dependency lookups.
-	prevHash := &block.MsgBlock().Header.PrevBlock
-	b.prevOrphans[*prevHash] = append(b.prevOrphans[*prevHash], oBlock)
+	b.orphans.add(block, time.Now())
+	b.orphanLock.Unlock()
 }
 
 // SequenceLock represents the converted relative lock-time in seconds, and
@@ -597,6 +510,7 @@ func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 		curTotalTxns+numTxns, node.CalcPastMedianTime())
 
 	// Atomically insert info into the database.
+	flushUtxos := b.utxoCache.needsFlush()
 	err = b.db.Update(func(dbTx database.Tx) error {
 		// Update best block state.
 		err := dbPutBestState(dbTx, state, node.workSum)
@@ -611,12 +525,16 @@ func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 			return err
 		}
 
-		// Update the utxo set using the state of the utxo view.  This
-		// entails removing all of the utxos spent and adding the new
-		// ones created by the block.
-		err = dbPutUtxoView(dbTx, view)
-		if err != nil {
-			return err
+		// Update the utxo set using the state of the utxo view when the
+		// utxo cache needs to be flushed.  This entails removing all of
+		// the utxos spent and adding the new ones created by the block.
+		// Otherwise, the changes are only added to the utxo cache once
+		// the block is committed.
+		if flushUtxos {
+			err = b.utxoCache.flush(dbTx, view, &node.hash)
+			if err != nil {
+				return err
+			}
 		}
 
 		// Update the transaction spend journal by adding a record for
@@ -642,6 +560,14 @@ func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 		return err
 	}
 
+	// Add the modifications to the utxo cache unless they were written to
+	// the database along with the rest of the cache.
+	if flushUtxos {
+		b.utxoCache.reset()
+	} else {
+		b.utxoCache.commit(view)
+	}
+
 	// Prune fully spent entries and mark all entries in the view unmodified
 	// now that the modifications have been committed to the database.
 	view.commit()
@@ -662,7 +588,7 @@ func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 	// The caller would typically want to react with actions such as
 	// updating wallets.
 	b.chainLock.Unlock()
-	b.sendNotification(NTBlockConnected, block)
+	b.sendBlockConnectedNotification(block, stxos)
 	b.chainLock.Lock()
 
 	return nil
@@ -725,8 +651,10 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *btcutil.Block, view
 
 		// Update the utxo set using the state of the utxo view.  This
 		// entails restoring all of the utxos spent and removing the new
-		// ones created by the block.
-		err = dbPutUtxoView(dbTx, view)
+		// ones created by the block.  The utxo cache is always flushed
+		// along with it, so the utxo set in the database never needs to
+		// be recovered beyond the best chain.
+		err = b.utxoCache.flush(dbTx, view, &prevNode.hash)
 		if err != nil {
 			return err
 		}
@@ -760,6 +688,7 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *btcutil.Block, view
 	if err != nil {
 		return err
 	}
+	b.utxoCache.reset()
 
 	// Prune fully spent entries and mark all entries in the view unmodified
 	// now that the modifications have been committed to the database.
@@ -837,6 +766,15 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 		}
 	}
 
+	// The utxos restored when disconnecting blocks with legacy spend
+	// journal entries are looked up in the database, so make sure the utxo
+	// set in the database is consistent with the best chain.
+	if detachNodes.Len() != 0 {
+		if err := b.flushUtxoCache(); err != nil {
+			return err
+		}
+	}
+
 	// Track the old and new best chains heads.
 	oldBest := tip
 	newBest := tip
@@ -875,7 +813,7 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 
 		// Load all of the utxos referenced by the block that aren't
 		// already in the view.
-		err = view.fetchInputUtxos(b.db, block)
+		err = view.fetchInputUtxos(b.utxoCache, block)
 		if err != nil {
 			return err
 		}
@@ -942,7 +880,7 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 		// checkConnectBlock gets skipped, we still need to update the UTXO
 		// view.
 		if b.index.NodeStatus(n).KnownValid() {
-			err = view.fetchInputUtxos(b.db, block)
+			err = view.fetchInputUtxos(b.utxoCache, block)
 			if err != nil {
 				return err
 			}
@@ -994,7 +932,7 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 
 		// Load all of the utxos referenced by the block that aren't
 		// already in the view.
-		err := view.fetchInputUtxos(b.db, block)
+		err := view.fetchInputUtxos(b.utxoCache, block)
 		if err != nil {
 			return err
 		}
@@ -1021,7 +959,7 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 
 		// Load all of the utxos referenced by the block that aren't
 		// already in the view.
-		err := view.fetchInputUtxos(b.db, block)
+		err := view.fetchInputUtxos(b.utxoCache, block)
 		if err != nil {
 			return err
 		}
@@ -1120,7 +1058,7 @@ func (b *BlockChain) connectBestChain(node *blockNode, block *btcutil.Block, fla
 		// utxos, spend them, and add the new utxos being created by
 		// this block.
 		if fastAdd {
-			err := view.fetchInputUtxos(b.db, block)
+			err := view.fetchInputUtxos(b.utxoCache, block)
 			if err != nil {
 				return false, err
 			}
@@ -1254,6 +1192,14 @@ func (b *BlockChain) BestSnapshot() *BestState {
 	return snapshot
 }
 
+// ScriptValidationPool returns the pool of goroutines the chain validates the
+// scripts of blocks with.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ScriptValidationPool() *ScriptValidationPool {
+	return b.scriptPool
+}
+
 // HeaderByHash returns the block header identified by the given hash or an
 // error if it doesn't exist. Note that this will return headers from both the
 // main and side chains.
@@ -1267,6 +1213,22 @@ func (b *BlockChain) HeaderByHash(hash *chainhash.Hash) (wire.BlockHeader, error
 	return node.Header(), nil
 }
 
+// MedianTimeByHash returns the median time of the block identified by the
+// given hash, which is the median timestamp of the block and the blocks prior
+// to it used to validate block timestamps.  Note that this works for blocks of
+// both the main and side chains.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) MedianTimeByHash(hash *chainhash.Hash) (time.Time, error) {
+	node := b.index.LookupNode(hash)
+	if node == nil {
+		err := fmt.Errorf("block %s is not known", hash)
+		return time.Time{}, err
+	}
+
+	return node.CalcPastMedianTime(), nil
+}
+
 // MainChainHasBlock returns whether or not the block with the given hash is in
 // the main chain.
 //
@@ -1276,6 +1238,16 @@ func (b *BlockChain) MainChainHasBlock(hash *chainhash.Hash) bool {
 	return node != nil && b.bestChain.Contains(node)
 }
 
+// IsKnownInvalid returns whether or not the block with the given hash is
+// known to be invalid, either because it failed validation itself or because
+// one of its ancestors did.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) IsKnownInvalid(hash *chainhash.Hash) bool {
+	node := b.index.LookupNode(hash)
+	return node != nil && b.index.NodeStatus(node).KnownInvalid()
+}
+
 // BlockLocatorFromHash returns a block locator for the passed block hash.
 // See BlockLocator for details on the algorithm used to create a block locator.
 //
@@ -1682,7 +1654,7 @@ type Config struct {
 	//
 	// This field can be nil if the caller is not interested in using a
 	// signature cache.
-	SigCache *txscript.SigCache
+	SigCache txscript.SignatureCache
 
 	// IndexManager defines an index manager to use when initializing the
 	// chain and connecting and disconnecting blocks.
@@ -1700,6 +1672,29 @@ type Config struct {
 	// This field can be nil if the caller is not interested in using a
 	// signature cache.
 	HashCache *txscript.HashCache
+
+	// UtxoCacheMaxSize is the maximum number of bytes the changes to the
+	// utxo set are allowed to use in memory before they are written to the
+	// database.  A size of zero writes the utxo set to the database with
+	// every block.
+	UtxoCacheMaxSize uint64
+
+	// UtxoCacheFlushInterval is the maximum amount of time the changes to
+	// the utxo set are kept in memory before they are written to the
+	// database.  It bounds the number of blocks which are connected to the
+	// utxo set again after an unclean shutdown.
+	//
+	// An interval of zero only flushes the cache once it exceeds
+	// UtxoCacheMaxSize.
+	UtxoCacheFlushInterval time.Duration
+
+	// ScriptValidationPool defines the pool of goroutines used to validate
+	// the scripts of blocks.  It should be shared with the mempool, so
+	// validating blocks pre-empts validating transactions.
+	//
+	// This field can be nil to use a default pool with three goroutines per
+	// processor core.
+	ScriptValidationPool *ScriptValidationPool
 }
 
 // New returns a BlockChain instance using the provided configuration details.
@@ -1737,6 +1732,10 @@ func New(config *Config) (*BlockChain, error) {
 	targetTimespan := int64(params.TargetTimespan / time.Second)
 	targetTimePerBlock := int64(params.TargetTimePerBlock / time.Second)
 	adjustmentFactor := params.RetargetAdjustmentFactor
+	scriptPool := config.ScriptValidationPool
+	if scriptPool == nil {
+		scriptPool = defaultScriptValidationPool()
+	}
 	b := BlockChain{
 		checkpoints:         config.Checkpoints,
 		checkpointsByHeight: checkpointsByHeight,
@@ -1750,11 +1749,13 @@ func New(config *Config) (*BlockChain, error) {
 		blocksPerRetarget:   int32(targetTimespan / targetTimePerBlock),
 		index:               newBlockIndex(config.DB, params),
 		hashCache:           config.HashCache,
+		scriptPool:          scriptPool,
 		bestChain:           newChainView(nil),
-		orphans:             make(map[chainhash.Hash]*orphanBlock),
-		prevOrphans:         make(map[chainhash.Hash][]*orphanBlock),
+		orphans:             newOrphanPool(),
 		warningCaches:       newThresholdCaches(vbNumBits),
 		deploymentCaches:    newThresholdCaches(chaincfg.DefinedDeployments),
+		utxoCache: newUtxoCache(config.DB, config.UtxoCacheMaxSize,
+			config.UtxoCacheFlushInterval),
 	}
 
 	// Initialize the chain state from the passed database.  When the db
@@ -1769,6 +1770,12 @@ func New(config *Config) (*BlockChain, error) {
 		return nil, err
 	}
 
+	// Recover the utxo set when the utxo cache wasn't flushed before the
+	// last shutdown.
+	if err := b.initUtxoCache(config.Interrupt); err != nil {
+		return nil, err
+	}
+
 	// Initialize and catch up all of the currently active optional indexes
 	// as needed.
 	if config.IndexManager != nil {
@@ -277,6 +277,63 @@ func (b *BlockChain) FetchSpendJournal(targetBlock *btcutil.Block) ([]SpentTxOut
 	return spendEntries, nil
 }
 
+// FetchSpentOutputs returns the outputs spent by the transactions of the main
+// chain block with the passed hash as recorded in its spend journal.  The
+// returned slice contains an entry for every transaction of the block in block
+// order, each of which contains the outputs spent by the inputs of the
+// transaction in input order.  The entry for the coinbase transaction is empty
+// since it doesn't spend any outputs.
+//
+// NOTE: Legacy spend journal entries only have the coinbase flag and height set
+// for outputs which were the last unspent output of their transaction.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FetchSpentOutputs(hash *chainhash.Hash) ([][]SpentTxOut, error) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	node := b.index.LookupNode(hash)
+	if node == nil || !b.bestChain.Contains(node) {
+		str := fmt.Sprintf("block %s is not in the main chain", hash)
+		return nil, errNotInMainChain(str)
+	}
+
+	// There is no spend journal for the blocks up to the snapshot block of
+	// a chain state which was loaded from a utxo snapshot.
+	if b.utxoSnapshot != nil && node.height <= b.utxoSnapshot.Height {
+		return nil, fmt.Errorf("spent outputs of block %s are not "+
+			"available since the chain state was loaded from a "+
+			"utxo snapshot at height %d", hash, b.utxoSnapshot.Height)
+	}
+
+	var block *btcutil.Block
+	var stxos []SpentTxOut
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		block, err = dbFetchBlockByNode(dbTx, node)
+		if err != nil {
+			return err
+		}
+
+		stxos, err = dbFetchSpendJournalEntry(dbTx, block)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Split the spent outputs by the transactions spending them.
+	txns := block.MsgBlock().Transactions
+	spentOutputs := make([][]SpentTxOut, len(txns))
+	for txIdx := 1; txIdx < len(txns); txIdx++ {
+		numInputs := len(txns[txIdx].TxIn)
+		spentOutputs[txIdx] = stxos[:numInputs:numInputs]
+		stxos = stxos[numInputs:]
+	}
+
+	return spentOutputs, nil
+}
+
 // spentTxOutHeaderCode returns the calculated header code to be used when
 // serializing the provided stxo entry.
 func spentTxOutHeaderCode(stxo *SpentTxOut) uint64 {
@@ -999,6 +1056,55 @@ func dbPutBestState(dbTx database.Tx, snapshot *BestState, workSum *big.Int) err
 	return dbTx.Metadata().Put(chainStateKeyName, serializedData)
 }
 
+// dbCreateChainBuckets uses an existing database transaction to create the
+// buckets which house the chain state and store their versions.
+func dbCreateChainBuckets(dbTx database.Tx) error {
+	meta := dbTx.Metadata()
+
+	// Create the bucket that houses the block index data.
+	_, err := meta.CreateBucket(blockIndexBucketName)
+	if err != nil {
+		return err
+	}
+
+	// Create the bucket that houses the chain block hash to height
+	// index.
+	_, err = meta.CreateBucket(hashIndexBucketName)
+	if err != nil {
+		return err
+	}
+
+	// Create the bucket that houses the chain block height to hash
+	// index.
+	_, err = meta.CreateBucket(heightIndexBucketName)
+	if err != nil {
+		return err
+	}
+
+	// Create the bucket that houses the spend journal data and
+	// store its version.
+	_, err = meta.CreateBucket(spendJournalBucketName)
+	if err != nil {
+		return err
+	}
+	err = dbPutVersion(dbTx, utxoSetVersionKeyName,
+		latestUtxoSetBucketVersion)
+	if err != nil {
+		return err
+	}
+
+	// Create the bucket that houses the utxo set and store its
+	// version.  Note that the genesis block coinbase transaction is
+	// intentionally not inserted here since it is not spendable by
+	// consensus rules.
+	_, err = meta.CreateBucket(utxoSetBucketName)
+	if err != nil {
+		return err
+	}
+	return dbPutVersion(dbTx, spendJournalVersionKeyName,
+		latestSpendJournalBucketVersion)
+}
+
 // createChainState initializes both the database and the chain state to the
 // genesis block.  This includes creating the necessary buckets and inserting
 // the genesis block, so it must only be called on an uninitialized database.
@@ -1025,50 +1131,8 @@ func (b *BlockChain) createChainState() error {
 	// Create the initial the database chain state including creating the
 	// necessary index buckets and inserting the genesis block.
 	err := b.db.Update(func(dbTx database.Tx) error {
-		meta := dbTx.Metadata()
-
-		// Create the bucket that houses the block index data.
-		_, err := meta.CreateBucket(blockIndexBucketName)
-		if err != nil {
-			return err
-		}
-
-		// Create the bucket that houses the chain block hash to height
-		// index.
-		_, err = meta.CreateBucket(hashIndexBucketName)
-		if err != nil {
-			return err
-		}
-
-		// Create the bucket that houses the chain block height to hash
-		// index.
-		_, err = meta.CreateBucket(heightIndexBucketName)
-		if err != nil {
-			return err
-		}
-
-		// Create the bucket that houses the spend journal data and
-		// store its version.
-		_, err = meta.CreateBucket(spendJournalBucketName)
-		if err != nil {
-			return err
-		}
-		err = dbPutVersion(dbTx, utxoSetVersionKeyName,
-			latestUtxoSetBucketVersion)
-		if err != nil {
-			return err
-		}
-
-		// Create the bucket that houses the utxo set and store its
-		// version.  Note that the genesis block coinbase transaction is
-		// intentionally not inserted here since it is not spendable by
-		// consensus rules.
-		_, err = meta.CreateBucket(utxoSetBucketName)
-		if err != nil {
-			return err
-		}
-		err = dbPutVersion(dbTx, spendJournalVersionKeyName,
-			latestSpendJournalBucketVersion)
+		// Create the buckets that house the chain state.
+		err := dbCreateChainBuckets(dbTx)
 		if err != nil {
 			return err
 		}
@@ -1238,7 +1302,11 @@ func (b *BlockChain) initChainState() error {
 		b.stateSnapshot = newBestState(tip, blockSize, blockWeight,
 			numTxns, state.totalTxns, tip.CalcPastMedianTime())
 
-		return nil
+		// Load the utxo snapshot the chain state was loaded from, if
+		// any.
+		b.utxoSnapshot, b.utxoSnapshotValidated, err =
+			dbFetchUtxoSnapshot(dbTx)
+		return err
 	})
 	if err != nil {
 		return err
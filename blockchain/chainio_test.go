@@ -11,6 +11,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/database"
 	"github.com/btcsuite/btcd/wire"
 )
@@ -719,3 +720,76 @@ func TestBestChainStateDeserializeErrors(t *testing.T) {
 		}
 	}
 }
+
+// TestFetchSpentOutputs ensures the outputs spent by the transactions of a main
+// chain block are returned from its spend journal in transaction and input
+// order.
+func TestFetchSpentOutputs(t *testing.T) {
+	blocks, err := loadBlocks("blk_0_to_4.dat.bz2")
+	if err != nil {
+		t.Fatalf("Error loading file: %v", err)
+	}
+
+	db, teardown := snapshotTestDB(t, "fetchspentoutputs")
+	defer teardown()
+	chain := newSnapshotTestChain(t, db)
+	chain.TstSetCoinbaseMaturity(1)
+	txOuts := make(map[wire.OutPoint]*wire.TxOut)
+	for i, block := range blocks {
+		for _, tx := range block.MsgBlock().Transactions {
+			for txOutIdx, txOut := range tx.TxOut {
+				outpoint := wire.OutPoint{
+					Hash:  tx.TxHash(),
+					Index: uint32(txOutIdx),
+				}
+				txOuts[outpoint] = txOut
+			}
+		}
+		if i == 0 {
+			continue
+		}
+		if _, _, err := chain.ProcessBlock(block, BFNone); err != nil {
+			t.Fatalf("ProcessBlock fail on block %v: %v", i, err)
+		}
+	}
+
+	for i, block := range blocks {
+		spentOutputs, err := chain.FetchSpentOutputs(block.Hash())
+		if err != nil {
+			t.Fatalf("FetchSpentOutputs block %d: %v", i, err)
+		}
+		txns := block.MsgBlock().Transactions
+		if len(spentOutputs) != len(txns) {
+			t.Fatalf("block %d: got spent outputs for %d transactions, "+
+				"want %d", i, len(spentOutputs), len(txns))
+		}
+		if len(spentOutputs[0]) != 0 {
+			t.Fatalf("block %d: got spent outputs for coinbase", i)
+		}
+		for txIdx, tx := range txns[1:] {
+			stxos := spentOutputs[txIdx+1]
+			if len(stxos) != len(tx.TxIn) {
+				t.Fatalf("block %d tx %d: got %d spent outputs, "+
+					"want %d", i, txIdx+1, len(stxos),
+					len(tx.TxIn))
+			}
+			for txInIdx, txIn := range tx.TxIn {
+				want := txOuts[txIn.PreviousOutPoint]
+				got := stxos[txInIdx]
+				if got.Amount != want.Value ||
+					!bytes.Equal(got.PkScript, want.PkScript) {
+
+					t.Fatalf("block %d tx %d input %d: "+
+						"unexpected spent output %+v",
+						i, txIdx+1, txInIdx, got)
+				}
+			}
+		}
+	}
+
+	// Blocks which are not in the main chain have no spent outputs.
+	var unknown chainhash.Hash
+	if _, err := chain.FetchSpentOutputs(&unknown); err == nil {
+		t.Fatalf("FetchSpentOutputs: expected error for unknown block")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 )
 
@@ -310,3 +311,48 @@ func (b *BlockChain) CalcNextRequiredDifficulty(timestamp time.Time) (uint32, er
 	b.chainLock.Unlock()
 	return difficulty, err
 }
+
+// PermittedDifficultyTransition returns whether the difficulty bits of the
+// block at the passed height may follow the difficulty bits of its parent
+// according to the difficulty retarget rules of the passed network.  Since
+// the timestamps of the blocks involved in a retarget are unknown, a change at
+// a retarget interval is permitted as long as it is within the limits of the
+// maximum adjustment.  This allows verifying a chain of headers without having
+// all of its ancestors available.
+//
+// Networks which allow the special reduction of the required difficulty
+// permit any transition.
+func PermittedDifficultyTransition(params *chaincfg.Params, height int32,
+	oldBits, newBits uint32) bool {
+
+	if params.ReduceMinDifficulty {
+		return true
+	}
+
+	blocksPerRetarget := int32(params.TargetTimespan /
+		params.TargetTimePerBlock)
+	if height%blocksPerRetarget != 0 {
+		return oldBits == newBits
+	}
+
+	// calcTarget calculates the target difficulty for the passed timespan
+	// the same way calcNextRequiredDifficulty does, including the loss of
+	// precision of the compact representation.
+	targetTimespan := int64(params.TargetTimespan / time.Second)
+	oldTarget := CompactToBig(oldBits)
+	calcTarget := func(timespan int64) *big.Int {
+		target := new(big.Int).Mul(oldTarget, big.NewInt(timespan))
+		target.Div(target, big.NewInt(targetTimespan))
+		if target.Cmp(params.PowLimit) > 0 {
+			target.Set(params.PowLimit)
+		}
+		return CompactToBig(BigToCompact(target))
+	}
+
+	adjustmentFactor := params.RetargetAdjustmentFactor
+	largestTarget := calcTarget(targetTimespan * adjustmentFactor)
+	smallestTarget := calcTarget(targetTimespan / adjustmentFactor)
+	newTarget := CompactToBig(newBits)
+	return newTarget.Cmp(largestTarget) <= 0 &&
+		newTarget.Cmp(smallestTarget) >= 0
+}
@@ -7,6 +7,8 @@ package blockchain
 import (
 	"math/big"
 	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
 )
 
 // TestBigToCompact ensures BigToCompact converts big integers to the expected
@@ -73,3 +75,82 @@ func TestCalcWork(t *testing.T) {
 		}
 	}
 }
+
+// TestPermittedDifficultyTransition ensures difficulty transitions are only
+// permitted at retarget intervals and within the limits of the maximum
+// adjustment.
+func TestPermittedDifficultyTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  *chaincfg.Params
+		height  int32
+		oldBits uint32
+		newBits uint32
+		want    bool
+	}{{
+		name:    "unchanged between retargets",
+		params:  &chaincfg.MainNetParams,
+		height:  2017,
+		oldBits: 0x1b0404cb,
+		newBits: 0x1b0404cb,
+		want:    true,
+	}, {
+		name:    "changed between retargets",
+		params:  &chaincfg.MainNetParams,
+		height:  2017,
+		oldBits: 0x1b0404cb,
+		newBits: 0x1b0404ca,
+		want:    false,
+	}, {
+		name:    "maximum increase at retarget",
+		params:  &chaincfg.MainNetParams,
+		height:  2016,
+		oldBits: 0x1d00ffff,
+		newBits: 0x1c3fffc0,
+		want:    true,
+	}, {
+		name:    "excessive increase at retarget",
+		params:  &chaincfg.MainNetParams,
+		height:  2016,
+		oldBits: 0x1d00ffff,
+		newBits: 0x1c3fffbf,
+		want:    false,
+	}, {
+		name:    "maximum decrease at retarget",
+		params:  &chaincfg.MainNetParams,
+		height:  4032,
+		oldBits: 0x1b0404cb,
+		newBits: 0x1b10132c,
+		want:    true,
+	}, {
+		name:    "excessive decrease at retarget",
+		params:  &chaincfg.MainNetParams,
+		height:  4032,
+		oldBits: 0x1b0404cb,
+		newBits: 0x1b10132d,
+		want:    false,
+	}, {
+		name:    "decrease beyond pow limit at retarget",
+		params:  &chaincfg.MainNetParams,
+		height:  2016,
+		oldBits: 0x1d00ffff,
+		newBits: 0x1d010000,
+		want:    false,
+	}, {
+		name:    "any change with min difficulty reduction",
+		params:  &chaincfg.TestNet3Params,
+		height:  2017,
+		oldBits: 0x1b0404cb,
+		newBits: 0x1d00ffff,
+		want:    true,
+	}}
+
+	for _, test := range tests {
+		got := PermittedDifficultyTransition(test.params, test.height,
+			test.oldBits, test.newBits)
+		if got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got,
+				test.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/database"
+	"github.com/btcsuite/btcutil"
+)
+
+// chainIndexer is implemented by indexes which need access to the chain they
+// index.  The index manager provides the chain when it is initialized.
+type chainIndexer interface {
+	setChain(chain *blockchain.BlockChain)
+}
+
+// startBackgroundBuild starts catching up the indexes which are built in the
+// background with the passed chain, if any.
+func (m *Manager) startBackgroundBuild(chain *blockchain.BlockChain) {
+	var indexes []Indexer
+	for _, indexer := range m.enabledIndexes {
+		if indexBuildsInBackground(indexer) {
+			indexes = append(indexes, indexer)
+		}
+	}
+	if len(indexes) == 0 {
+		return
+	}
+
+	m.mtx.Lock()
+	m.tipHeight = chain.BestSnapshot().Height
+	m.mtx.Unlock()
+	m.wg.Add(1)
+	go m.backgroundBuildHandler(indexes)
+}
+
+// wakeBackgroundBuild wakes up the background build to catch up the indexes
+// with the blocks which were connected since it last caught up.
+func (m *Manager) wakeBackgroundBuild() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stop stops building the indexes which are built in the background and waits
+// for the current block to be indexed.  The progress is kept, so the indexes
+// continue to be built from where they stopped on the next start.
+func (m *Manager) Stop() {
+	close(m.quit)
+	m.wg.Wait()
+}
+
+// backgroundBuildHandler catches up the passed indexes with the main chain
+// whenever it is woken up until the manager is stopped.  It must be run as a
+// goroutine.
+func (m *Manager) backgroundBuildHandler(indexes []Indexer) {
+	defer m.wg.Done()
+
+	for {
+		err := m.catchUpBackground(indexes)
+		if err != nil && err != errInterruptRequested {
+			log.Warnf("Unable to build indexes in the background: %v",
+				err)
+		}
+
+		select {
+		case <-m.wake:
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// catchUpBackground connects the main chain blocks after the tips of the passed
+// indexes to them until they are caught up with the main chain.
+//
+// The chain connects blocks concurrently, so the blocks are only connected when
+// they are still part of the main chain as of the database transaction which
+// connects them.  The remaining blocks are connected the next time the
+// background build is woken up otherwise.
+func (m *Manager) catchUpBackground(indexes []Indexer) error {
+	heights := make([]int32, len(indexes))
+	err := m.db.View(func(dbTx database.Tx) error {
+		for i, indexer := range indexes {
+			_, height, err := dbFetchIndexerTip(dbTx, indexer.Key())
+			if err != nil {
+				return err
+			}
+			heights[i] = height
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	lowestHeight := heights[0]
+	for _, height := range heights[1:] {
+		if height < lowestHeight {
+			lowestHeight = height
+		}
+	}
+
+	bestHeight := m.chain.BestSnapshot().Height
+	if lowestHeight >= bestHeight {
+		return nil
+	}
+
+	log.Infof("Building indexes in the background from height %d to %d",
+		lowestHeight+1, bestHeight)
+	progressLogger := newBlockProgressLogger("Indexed in the background",
+		log)
+	for height := lowestHeight + 1; height <= bestHeight; {
+		if interruptRequested(m.quit) {
+			return errInterruptRequested
+		}
+
+		batch, err := loadCatchUpBatch(m.chain, indexes, heights,
+			height, bestHeight)
+		if err != nil {
+			return err
+		}
+
+		for i, indexer := range indexes {
+			err := m.db.Update(func(dbTx database.Tx) error {
+				for _, b := range batch {
+					if heights[i] >= b.block.Height() {
+						continue
+					}
+
+					connected, err := m.dbConnectBackground(
+						dbTx, indexer, b.block,
+						b.spentTxos)
+					if err != nil || !connected {
+						return err
+					}
+					heights[i] = b.block.Height()
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			// The remaining blocks are connected the next time the
+			// background build is woken up when a block is no longer
+			// part of the main chain.
+			if heights[i] < batch[len(batch)-1].block.Height() {
+				return nil
+			}
+		}
+
+		for _, b := range batch {
+			progressLogger.LogBlockHeight(b.block)
+		}
+		height += int32(len(batch))
+	}
+
+	log.Infof("Indexes built in the background caught up to height %d",
+		bestHeight)
+	return nil
+}
+
+// dbConnectBackground connects the passed block to the passed index which is
+// built in the background using the provided database transaction.  It returns
+// whether the block was connected, which is not the case when it is no longer
+// part of the main chain or the chain connected it to the index already.
+func (m *Manager) dbConnectBackground(dbTx database.Tx, indexer Indexer,
+	block *btcutil.Block, spentTxos []blockchain.SpentTxOut) (bool, error) {
+
+	tipHash, _, err := dbFetchIndexerTip(dbTx, indexer.Key())
+	if err != nil {
+		return false, err
+	}
+	if *tipHash != block.MsgBlock().Header.PrevBlock {
+		return false, nil
+	}
+
+	// The chain updates its view of the main chain only after the database
+	// transaction which connected or disconnected a block was committed.
+	// The height of the main chain as of the last database transaction of
+	// the chain is used to exclude blocks which were disconnected, but are
+	// still part of the view.
+	m.mtx.Lock()
+	tipHeight := m.tipHeight
+	m.mtx.Unlock()
+	if block.Height() > tipHeight || !m.chain.MainChainHasBlock(block.Hash()) {
+		return false, nil
+	}
+
+	err = dbIndexConnectBlock(dbTx, indexer, block, spentTxos)
+	return err == nil, err
+}
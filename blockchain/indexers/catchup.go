@@ -0,0 +1,80 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// maxCatchUpBatchBlocks is the maximum number of blocks connected to an
+	// index in a single database transaction while catching it up with
+	// the main chain.  Connecting many blocks at once greatly reduces the
+	// time needed to build large indexes from scratch.
+	maxCatchUpBatchBlocks = 500
+
+	// maxCatchUpBatchSize is the maximum total serialized size of the
+	// blocks connected to an index in a single database transaction while
+	// catching it up with the main chain.  It bounds the memory used by
+	// the pending database transaction with recent, large blocks.
+	maxCatchUpBatchSize = 32 * 1024 * 1024
+)
+
+// catchUpBlock houses a block connected to indexes while catching them up
+// along with the outputs it spends, which are only loaded when an index in
+// need of them is behind the block.
+type catchUpBlock struct {
+	block     *btcutil.Block
+	spentTxos []blockchain.SpentTxOut
+}
+
+// loadCatchUpBatch loads the next batch of main chain blocks to connect to the
+// passed indexes, which are at the passed heights, starting with the block at
+// the passed start height and ending no later than the block at the passed end
+// height.  The batch is bounded by maxCatchUpBatchBlocks and
+// maxCatchUpBatchSize, but always contains at least one block.
+func loadCatchUpBatch(chain *blockchain.BlockChain, indexes []Indexer,
+	heights []int32, startHeight, endHeight int32) ([]catchUpBlock, error) {
+
+	var batch []catchUpBlock
+	var batchSize int
+	for height := startHeight; height <= endHeight; height++ {
+		if len(batch) == maxCatchUpBatchBlocks ||
+			(len(batch) > 0 && batchSize >= maxCatchUpBatchSize) {
+
+			break
+		}
+
+		block, err := chain.BlockByHeight(height)
+		if err != nil {
+			return nil, err
+		}
+		batchSize += block.MsgBlock().SerializeSize()
+
+		// When an index which is behind the block requires all of the
+		// referenced txouts, they need to be retrieved from the spend
+		// journal.
+		var spentTxos []blockchain.SpentTxOut
+		for i, indexer := range indexes {
+			if heights[i] >= height || !indexNeedsInputs(indexer) {
+				continue
+			}
+
+			spentTxos, err = chain.FetchSpendJournal(block)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+
+		batch = append(batch, catchUpBlock{
+			block:     block,
+			spentTxos: spentTxos,
+		})
+	}
+
+	return batch, nil
+}
@@ -6,6 +6,7 @@ package indexers
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/btcsuite/btcd/blockchain"
 	"github.com/btcsuite/btcd/chaincfg"
@@ -74,10 +75,13 @@ func dbDeleteFilterIdxEntry(dbTx database.Tx, key []byte, h *chainhash.Hash) err
 	return idx.Delete(h[:])
 }
 
-// CfIndex implements a committed filter (cf) by hash index.
+// CfIndex implements a committed filter (cf) by hash index.  The index is
+// built in the background, so filters are only available for the blocks it
+// caught up to so far.
 type CfIndex struct {
 	db          database.DB
 	chainParams *chaincfg.Params
+	chain       *blockchain.BlockChain
 }
 
 // Ensure the CfIndex type implements the Indexer interface.
@@ -86,6 +90,9 @@ var _ Indexer = (*CfIndex)(nil)
 // Ensure the CfIndex type implements the NeedsInputser interface.
 var _ NeedsInputser = (*CfIndex)(nil)
 
+// Ensure the CfIndex type implements the BackgroundBuilder interface.
+var _ BackgroundBuilder = (*CfIndex)(nil)
+
 // NeedsInputs signals that the index requires the referenced inputs in order
 // to properly create the index.
 //
@@ -94,6 +101,21 @@ func (idx *CfIndex) NeedsInputs() bool {
 	return true
 }
 
+// BuildsInBackground signals that the index is caught up with the main chain in
+// the background.
+//
+// This implements the BackgroundBuilder interface.
+func (idx *CfIndex) BuildsInBackground() bool {
+	return true
+}
+
+// setChain sets the chain the index is built for.
+//
+// This implements the chainIndexer interface.
+func (idx *CfIndex) setChain(chain *blockchain.BlockChain) {
+	idx.chain = chain
+}
+
 // Init initializes the hash-based cf index. This is part of the Indexer
 // interface.
 func (idx *CfIndex) Init() error {
@@ -340,6 +362,72 @@ func (idx *CfIndex) FilterHashesByBlockHashes(blockHashes []*chainhash.Hash,
 	return idx.entriesByBlockHashes(cfHashKeys, filterType, blockHashes)
 }
 
+// FiltersByBlockRange invokes the passed function with the hash and serialized
+// contents of the basic or committed filter of each main chain block from
+// startHeight to endHeight inclusive in order of their height.  The filters are
+// fetched in a single database transaction, so the function must not block or
+// access the database.  A block without a filter, such as one the index did not
+// catch up to yet, results in an empty filter.
+func (idx *CfIndex) FiltersByBlockRange(startHeight, endHeight int32,
+	filterType wire.FilterType,
+	fn func(hash *chainhash.Hash, filter []byte) error) error {
+
+	if uint8(filterType) > maxFilterType {
+		return errors.New("unsupported filter type")
+	}
+	if startHeight < 0 || endHeight < startHeight {
+		return fmt.Errorf("invalid block range from height %d to %d",
+			startHeight, endHeight)
+	}
+
+	// Reject ranges past the main chain before calculating the exclusive
+	// end height, which would overflow otherwise.  The range is checked
+	// again once the hashes are fetched since the chain may be reorganized
+	// in the meantime.
+	errNotMainChain := fmt.Errorf("block range from height %d to %d is "+
+		"not part of the main chain", startHeight, endHeight)
+	if endHeight > idx.chain.BestSnapshot().Height {
+		return errNotMainChain
+	}
+	hashes, err := idx.chain.HeightRange(startHeight, endHeight+1)
+	if err != nil {
+		return err
+	}
+	if len(hashes) != int(endHeight-startHeight+1) {
+		return errNotMainChain
+	}
+
+	key := cfIndexKeys[filterType]
+	return idx.db.View(func(dbTx database.Tx) error {
+		for i := range hashes {
+			filter, err := dbFetchFilterIdxEntry(dbTx, key, &hashes[i])
+			if err != nil {
+				return err
+			}
+			if err := fn(&hashes[i], filter); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// BuildProgress returns the height of the block the index is caught up to along
+// with the height of the main chain.  The index is built once both are equal.
+func (idx *CfIndex) BuildProgress() (int32, int32, error) {
+	var height int32
+	err := idx.db.View(func(dbTx database.Tx) error {
+		var err error
+		_, height, err = dbFetchIndexerTip(dbTx, idx.Key())
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return height, idx.chain.BestSnapshot().Height, nil
+}
+
 // NewCfIndex returns a new instance of an indexer that is used to create a
 // mapping of the hashes of all blocks in the blockchain to their respective
 // committed filters.
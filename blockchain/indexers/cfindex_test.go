@@ -0,0 +1,268 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/database"
+	_ "github.com/btcsuite/btcd/database/ffldb"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/gcs/builder"
+)
+
+// generateTestBlocks returns a chain of the passed number of blocks with valid
+// proof of work on the passed params which builds on the passed block.  Each
+// block only contains a coinbase paying to an anyone-can-spend script.
+func generateTestBlocks(params *chaincfg.Params, prev *btcutil.Block,
+	num int) []*btcutil.Block {
+
+	blocks := make([]*btcutil.Block, 0, num)
+	for i := 0; i < num; i++ {
+		height := prev.Height() + 1
+		sigScript, err := txscript.NewScriptBuilder().
+			AddInt64(int64(height)).AddInt64(0).Script()
+		if err != nil {
+			panic(err)
+		}
+		coinbase := wire.NewMsgTx(1)
+		coinbase.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{},
+				wire.MaxPrevOutIndex),
+			SignatureScript: sigScript,
+			Sequence:        wire.MaxTxInSequenceNum,
+		})
+		coinbase.AddTxOut(wire.NewTxOut(blockchain.CalcBlockSubsidy(
+			height, params), []byte{txscript.OP_TRUE}))
+
+		txns := []*btcutil.Tx{btcutil.NewTx(coinbase)}
+		merkles := blockchain.BuildMerkleTreeStore(txns, false)
+		prevHeader := &prev.MsgBlock().Header
+		msgBlock := wire.NewMsgBlock(&wire.BlockHeader{
+			Version:    4,
+			PrevBlock:  *prev.Hash(),
+			MerkleRoot: *merkles[len(merkles)-1],
+			Timestamp:  prevHeader.Timestamp.Add(time.Minute),
+			Bits:       params.PowLimitBits,
+		})
+		msgBlock.AddTransaction(coinbase)
+		for blockchain.CheckProofOfWork(btcutil.NewBlock(msgBlock),
+			params.PowLimit) != nil {
+
+			msgBlock.Header.Nonce++
+		}
+
+		block := btcutil.NewBlock(msgBlock)
+		block.SetHeight(height)
+		blocks = append(blocks, block)
+		prev = block
+	}
+	return blocks
+}
+
+// newTestChain returns a chain on the passed database for the regression test
+// network which uses the passed index manager, if any.
+func newTestChain(t *testing.T, db database.DB, indexManager *Manager) *blockchain.BlockChain {
+	t.Helper()
+
+	config := &blockchain.Config{
+		DB:          db,
+		ChainParams: &chaincfg.RegressionNetParams,
+		TimeSource:  blockchain.NewMedianTime(),
+	}
+	if indexManager != nil {
+		config.IndexManager = indexManager
+	}
+	chain, err := blockchain.New(config)
+	if err != nil {
+		t.Fatalf("unable to create chain: %v", err)
+	}
+	return chain
+}
+
+// processTestBlocks processes the passed blocks with the passed chain and
+// flushes the utxo cache afterwards, so another chain can be created on the
+// same database.
+func processTestBlocks(t *testing.T, chain *blockchain.BlockChain,
+	blocks []*btcutil.Block) {
+
+	t.Helper()
+
+	for _, block := range blocks {
+		_, isOrphan, err := chain.ProcessBlock(block, blockchain.BFNone)
+		if err != nil || isOrphan {
+			t.Fatalf("unable to process block %d: %v (orphan %v)",
+				block.Height(), err, isOrphan)
+		}
+	}
+	if err := chain.FlushUtxoCache(); err != nil {
+		t.Fatalf("unable to flush utxo cache: %v", err)
+	}
+}
+
+// waitForCfIndex waits until the passed index caught up to the passed height.
+func waitForCfIndex(t *testing.T, idx *CfIndex, height int32) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		indexHeight, bestHeight, err := idx.BuildProgress()
+		if err != nil {
+			t.Fatalf("BuildProgress: unexpected error: %v", err)
+		}
+		if indexHeight == height && bestHeight == height {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("index caught up to height %d of %d, want %d",
+				indexHeight, bestHeight, height)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// checkFilters ensures the passed index returns the expected filters for all
+// of the passed blocks, which must start at the genesis block, and empty
+// filters for the blocks after the passed height the index caught up to.
+func checkFilters(t *testing.T, idx *CfIndex, blocks []*btcutil.Block,
+	indexHeight int32) {
+
+	t.Helper()
+
+	var height int32
+	err := idx.FiltersByBlockRange(0, int32(len(blocks)-1),
+		wire.GCSFilterRegular,
+		func(hash *chainhash.Hash, filter []byte) error {
+			block := blocks[height]
+			if *hash != *block.Hash() {
+				t.Fatalf("unexpected hash %v at height %d", hash,
+					height)
+			}
+
+			var want []byte
+			if height <= indexHeight {
+				f, err := builder.BuildBasicFilter(
+					block.MsgBlock(), nil)
+				if err != nil {
+					return err
+				}
+				want, err = f.NBytes()
+				if err != nil {
+					return err
+				}
+			}
+			if !bytes.Equal(filter, want) {
+				t.Fatalf("unexpected filter %x at height %d, "+
+					"want %x", filter, height, want)
+			}
+			height++
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("FiltersByBlockRange: unexpected error: %v", err)
+	}
+	if height != int32(len(blocks)) {
+		t.Fatalf("got filters for %d blocks, want %d", height,
+			len(blocks))
+	}
+}
+
+// TestCfIndexBackgroundBuild ensures the committed filter index is built in
+// the background, resumes building where it stopped, and only returns the
+// filters of the blocks it caught up to while it is still being built.
+func TestCfIndexBackgroundBuild(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	dbPath, err := ioutil.TempDir("", "cfindextest")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", filepath.Join(dbPath, "db"),
+		params.Net)
+	if err != nil {
+		t.Fatalf("unable to create database: %v", err)
+	}
+	defer db.Close()
+
+	genesis := btcutil.NewBlock(params.GenesisBlock)
+	genesis.SetHeight(0)
+	blocks := append([]*btcutil.Block{genesis},
+		generateTestBlocks(params, genesis, 20)...)
+
+	// Build the index for the first blocks while they are connected.
+	idx := NewCfIndex(db, params)
+	m := NewManager(db, []Indexer{idx})
+	chain := newTestChain(t, db, m)
+	processTestBlocks(t, chain, blocks[1:11])
+	waitForCfIndex(t, idx, 10)
+	m.Stop()
+
+	// Connect the remaining blocks while the index is disabled.  Only the
+	// filters of the blocks the index caught up to are returned then.
+	chain = newTestChain(t, db, nil)
+	processTestBlocks(t, chain, blocks[11:])
+	idx = NewCfIndex(db, params)
+	idx.setChain(chain)
+	indexHeight, bestHeight, err := idx.BuildProgress()
+	if err != nil {
+		t.Fatalf("BuildProgress: unexpected error: %v", err)
+	}
+	if indexHeight != 10 || bestHeight != 20 {
+		t.Fatalf("unexpected build progress %d of %d", indexHeight,
+			bestHeight)
+	}
+	checkFilters(t, idx, blocks, 10)
+
+	// The index continues to be built from where it stopped once it is
+	// enabled again.
+	idx = NewCfIndex(db, params)
+	m = NewManager(db, []Indexer{idx})
+	defer m.Stop()
+	newTestChain(t, db, m)
+	waitForCfIndex(t, idx, 20)
+	checkFilters(t, idx, blocks, 20)
+
+	// Invalid ranges and ranges which are not part of the main chain are
+	// rejected.
+	ranges := []struct {
+		start int32
+		end   int32
+	}{
+		{start: 5, end: 4},
+		{start: -1, end: 3},
+		{start: 0, end: 21},
+		{start: 21, end: 25},
+		{start: 0, end: math.MaxInt32},
+	}
+	for _, r := range ranges {
+		err := idx.FiltersByBlockRange(r.start, r.end,
+			wire.GCSFilterRegular,
+			func(*chainhash.Hash, []byte) error {
+				t.Fatalf("range from %d to %d: unexpected "+
+					"filter", r.start, r.end)
+				return nil
+			})
+		if err == nil {
+			t.Errorf("range from %d to %d: expected error", r.start,
+				r.end)
+		}
+	}
+	err = idx.FiltersByBlockRange(0, 1, wire.FilterType(maxFilterType+1),
+		func(*chainhash.Hash, []byte) error { return nil })
+	if err == nil {
+		t.Error("unsupported filter type: expected error")
+	}
+}
@@ -32,6 +32,15 @@ type NeedsInputser interface {
 	NeedsInputs() bool
 }
 
+// BackgroundBuilder provides a generic interface for an indexer to specify it
+// is caught up with the main chain in the background once the index manager is
+// initialized instead of while it is initialized.  The index manager only
+// connects blocks to such an index once it is caught up, so the index can be
+// built, or rebuilt after it was dropped, without delaying the start up.
+type BackgroundBuilder interface {
+	BuildsInBackground() bool
+}
+
 // Indexer provides a generic interface for an indexer that is managed by an
 // index manager such as the Manager type provided by this package.
 type Indexer interface {
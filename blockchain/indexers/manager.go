@@ -7,6 +7,7 @@ package indexers
 import (
 	"bytes"
 	"fmt"
+	"sync"
 
 	"github.com/btcsuite/btcd/blockchain"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
@@ -132,6 +133,21 @@ func dbIndexDisconnectBlock(dbTx database.Tx, indexer Indexer, block *btcutil.Bl
 type Manager struct {
 	db             database.DB
 	enabledIndexes []Indexer
+
+	// The following fields are used to catch up the indexes which are
+	// built in the background.  The tip height is the height of the main
+	// chain as of the last block connected or disconnected through the
+	// manager and is protected by the mutex.
+	chain     *blockchain.BlockChain
+	wake      chan struct{}
+	quit      chan struct{}
+	wg        sync.WaitGroup
+	mtx       sync.Mutex
+	tipHeight int32
+
+	// initialized is set once the manager is initialized, after which no
+	// more indexes can be added.  It is protected by the mutex.
+	initialized bool
 }
 
 // Ensure the Manager type implements the blockchain.IndexManager interface.
@@ -234,10 +250,15 @@ func (m *Manager) maybeCreateIndexes(dbTx database.Tx) error {
 //
 // This is part of the blockchain.IndexManager interface.
 func (m *Manager) Init(chain *blockchain.BlockChain, interrupt <-chan struct{}) error {
+	m.mtx.Lock()
+	m.initialized = true
+	m.mtx.Unlock()
+
 	// Nothing to do when no indexes are enabled.
 	if len(m.enabledIndexes) == 0 {
 		return nil
 	}
+	m.chain = chain
 
 	if interruptRequested(interrupt) {
 		return errInterruptRequested
@@ -268,6 +289,9 @@ func (m *Manager) Init(chain *blockchain.BlockChain, interrupt <-chan struct{})
 		if err := indexer.Init(); err != nil {
 			return err
 		}
+		if idx, ok := indexer.(chainIndexer); ok {
+			idx.setChain(chain)
+		}
 	}
 
 	// Rollback indexes to the main chain if their tip is an orphaned fork.
@@ -378,6 +402,12 @@ func (m *Manager) Init(chain *blockchain.BlockChain, interrupt <-chan struct{})
 
 			log.Debugf("Current %s tip (height %d, hash %v)",
 				indexer.Name(), height, hash)
+
+			// The indexes built in the background are caught up
+			// once the chain is initialized.
+			if indexBuildsInBackground(indexer) {
+				height = bestHeight
+			}
 			indexerHeights[i] = height
 			if height < lowestHeight {
 				lowestHeight = height
@@ -388,6 +418,7 @@ func (m *Manager) Init(chain *blockchain.BlockChain, interrupt <-chan struct{})
 	if err != nil {
 		return err
 	}
+	m.startBackgroundBuild(chain)
 
 	// Nothing to index if all of the indexes are caught up.
 	if lowestHeight == bestHeight {
@@ -402,10 +433,11 @@ func (m *Manager) Init(chain *blockchain.BlockChain, interrupt <-chan struct{})
 	// each block that needs to be indexed.
 	log.Infof("Catching up indexes from height %d to %d", lowestHeight,
 		bestHeight)
-	for height := lowestHeight + 1; height <= bestHeight; height++ {
-		// Load the block for the height since it is required to index
-		// it.
-		block, err := chain.BlockByHeight(height)
+	for height := lowestHeight + 1; height <= bestHeight; {
+		// Load the next batch of blocks since they are required to
+		// index them.
+		batch, err := loadCatchUpBatch(chain, m.enabledIndexes,
+			indexerHeights, height, bestHeight)
 		if err != nil {
 			return err
 		}
@@ -414,38 +446,39 @@ func (m *Manager) Init(chain *blockchain.BlockChain, interrupt <-chan struct{})
 			return errInterruptRequested
 		}
 
-		// Connect the block for all indexes that need it.
-		var spentTxos []blockchain.SpentTxOut
+		// Connect the blocks of the batch for all indexes that need
+		// them in a single database transaction per index.
 		for i, indexer := range m.enabledIndexes {
-			// Skip indexes that don't need to be updated with this
-			// block.
-			if indexerHeights[i] >= height {
-				continue
-			}
+			err := m.db.Update(func(dbTx database.Tx) error {
+				for _, b := range batch {
+					// Skip blocks the index already has.
+					if indexerHeights[i] >= b.block.Height() {
+						continue
+					}
 
-			// When the index requires all of the referenced txouts
-			// and they haven't been loaded yet, they need to be
-			// retrieved from the spend journal.
-			if spentTxos == nil && indexNeedsInputs(indexer) {
-				spentTxos, err = chain.FetchSpendJournal(block)
-				if err != nil {
-					return err
+					err := dbIndexConnectBlock(dbTx, indexer,
+						b.block, b.spentTxos)
+					if err != nil {
+						return err
+					}
 				}
-			}
-
-			err := m.db.Update(func(dbTx database.Tx) error {
-				return dbIndexConnectBlock(
-					dbTx, indexer, block, spentTxos,
-				)
+				return nil
 			})
 			if err != nil {
 				return err
 			}
-			indexerHeights[i] = height
+
+			lastHeight := batch[len(batch)-1].block.Height()
+			if indexerHeights[i] < lastHeight {
+				indexerHeights[i] = lastHeight
+			}
 		}
 
 		// Log indexing progress.
-		progressLogger.LogBlockHeight(block)
+		for _, b := range batch {
+			progressLogger.LogBlockHeight(b.block)
+		}
+		height += int32(len(batch))
 
 		if interruptRequested(interrupt) {
 			return errInterruptRequested
@@ -456,6 +489,16 @@ func (m *Manager) Init(chain *blockchain.BlockChain, interrupt <-chan struct{})
 	return nil
 }
 
+// indexBuildsInBackground returns whether or not the index is caught up with the
+// main chain in the background.
+func indexBuildsInBackground(index Indexer) bool {
+	if idx, ok := index.(BackgroundBuilder); ok {
+		return idx.BuildsInBackground()
+	}
+
+	return false
+}
+
 // indexNeedsInputs returns whether or not the index needs access to the txouts
 // referenced by the transaction inputs being indexed.
 func indexNeedsInputs(index Indexer) bool {
@@ -502,9 +545,27 @@ func dbFetchTx(dbTx database.Tx, hash *chainhash.Hash) (*wire.MsgTx, error) {
 func (m *Manager) ConnectBlock(dbTx database.Tx, block *btcutil.Block,
 	stxos []blockchain.SpentTxOut) error {
 
+	m.mtx.Lock()
+	m.tipHeight = block.Height()
+	m.mtx.Unlock()
+
 	// Call each of the currently active optional indexes with the block
 	// being connected so they can update accordingly.
 	for _, index := range m.enabledIndexes {
+		// Indexes built in the background are only updated once they
+		// are caught up.  Otherwise, the background build is woken up
+		// to index the block.
+		if indexBuildsInBackground(index) {
+			tipHash, _, err := dbFetchIndexerTip(dbTx, index.Key())
+			if err != nil {
+				return err
+			}
+			if *tipHash != block.MsgBlock().Header.PrevBlock {
+				m.wakeBackgroundBuild()
+				continue
+			}
+		}
+
 		err := dbIndexConnectBlock(dbTx, index, block, stxos)
 		if err != nil {
 			return err
@@ -522,9 +583,25 @@ func (m *Manager) ConnectBlock(dbTx database.Tx, block *btcutil.Block,
 func (m *Manager) DisconnectBlock(dbTx database.Tx, block *btcutil.Block,
 	stxo []blockchain.SpentTxOut) error {
 
+	m.mtx.Lock()
+	m.tipHeight = block.Height() - 1
+	m.mtx.Unlock()
+
 	// Call each of the currently active optional indexes with the block
 	// being disconnected so they can update accordingly.
 	for _, index := range m.enabledIndexes {
+		// Indexes built in the background which are not caught up yet
+		// don't contain the block.
+		if indexBuildsInBackground(index) {
+			tipHash, _, err := dbFetchIndexerTip(dbTx, index.Key())
+			if err != nil {
+				return err
+			}
+			if *tipHash != *block.Hash() {
+				continue
+			}
+		}
+
 		err := dbIndexDisconnectBlock(dbTx, index, block, stxo)
 		if err != nil {
 			return err
@@ -541,9 +618,98 @@ func NewManager(db database.DB, enabledIndexes []Indexer) *Manager {
 	return &Manager{
 		db:             db,
 		enabledIndexes: enabledIndexes,
+		wake:           make(chan struct{}, 1),
+		quit:           make(chan struct{}),
 	}
 }
 
+// AddIndex enables the passed index in addition to the indexes the manager was
+// created with.  This allows indexes implemented outside of this package to be
+// managed like the built-in ones.  It returns an error when the manager was
+// already initialized or an index with the same key is already enabled.
+func (m *Manager) AddIndex(indexer Indexer) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.initialized {
+		return fmt.Errorf("unable to add %s after the index manager "+
+			"was initialized", indexer.Name())
+	}
+	for _, enabled := range m.enabledIndexes {
+		if bytes.Equal(enabled.Key(), indexer.Key()) {
+			return fmt.Errorf("unable to add %s since %s uses the "+
+				"same key", indexer.Name(), enabled.Name())
+		}
+	}
+
+	m.enabledIndexes = append(m.enabledIndexes, indexer)
+	return nil
+}
+
+// IndexProgress describes how far an index is caught up with the main chain.
+type IndexProgress struct {
+	// Name is the human-readable name of the index.
+	Name string
+
+	// Height is the height of the last block connected to the index, which
+	// is -1 when no blocks are connected yet.
+	Height int32
+
+	// BestHeight is the height of the main chain.
+	BestHeight int32
+
+	// InBackground is true when the index is caught up with the main chain
+	// in the background.
+	InBackground bool
+}
+
+// Synced returns whether the index is caught up with the main chain.
+func (p *IndexProgress) Synced() bool {
+	return p.Height >= p.BestHeight
+}
+
+// Progress returns how far each of the enabled indexes is caught up with the
+// main chain.  The manager must be initialized.
+//
+// This function is safe for concurrent access.
+func (m *Manager) Progress() ([]IndexProgress, error) {
+	if m.chain == nil {
+		return nil, nil
+	}
+
+	bestHeight := m.chain.BestSnapshot().Height
+	progress := make([]IndexProgress, len(m.enabledIndexes))
+	err := m.db.View(func(dbTx database.Tx) error {
+		for i, indexer := range m.enabledIndexes {
+			_, height, err := dbFetchIndexerTip(dbTx, indexer.Key())
+			if err != nil {
+				return err
+			}
+
+			progress[i] = IndexProgress{
+				Name:         indexer.Name(),
+				Height:       height,
+				BestHeight:   bestHeight,
+				InBackground: indexBuildsInBackground(indexer),
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+// DropIndex drops the passed index from the provided database if it exists.
+// It is the counterpart of DropTxIndex and the like for indexes implemented
+// outside of this package.  The drop is resumed on the next start when it is
+// interrupted and the index is enabled.
+func DropIndex(db database.DB, indexer Indexer, interrupt <-chan struct{}) error {
+	return dropIndex(db, indexer.Key(), indexer.Name(), interrupt)
+}
+
 // dropIndex drops the passed index from the database.  Since indexes can be
 // massive, it deletes the index in multiple database transactions in order to
 // keep memory usage to reasonable levels.  It also marks the drop in progress
@@ -0,0 +1,86 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/database"
+	"github.com/btcsuite/btcutil"
+)
+
+// testIndex is an index which doesn't index anything, which is used to test
+// the management of indexes implemented outside of this package.
+type testIndex struct {
+	key string
+}
+
+func (idx *testIndex) Key() []byte                   { return []byte(idx.key) }
+func (idx *testIndex) Name() string                  { return idx.key + " index" }
+func (idx *testIndex) Create(dbTx database.Tx) error { return nil }
+func (idx *testIndex) Init() error                   { return nil }
+
+func (idx *testIndex) ConnectBlock(database.Tx, *btcutil.Block,
+	[]blockchain.SpentTxOut) error {
+
+	return nil
+}
+
+func (idx *testIndex) DisconnectBlock(database.Tx, *btcutil.Block,
+	[]blockchain.SpentTxOut) error {
+
+	return nil
+}
+
+// TestManagerAddIndex ensures indexes can only be added to a manager before it
+// is initialized and only when their key is not used yet.
+func TestManagerAddIndex(t *testing.T) {
+	m := NewManager(nil, []Indexer{&testIndex{key: "a"}})
+
+	if err := m.AddIndex(&testIndex{key: "b"}); err != nil {
+		t.Fatalf("unexpected error adding index: %v", err)
+	}
+	if err := m.AddIndex(&testIndex{key: "a"}); err == nil {
+		t.Fatal("added index with a key that is already used")
+	}
+	if len(m.enabledIndexes) != 2 {
+		t.Fatalf("unexpected number of enabled indexes: %d",
+			len(m.enabledIndexes))
+	}
+
+	// The manager must not accept more indexes once it is initialized,
+	// even when the initialization is interrupted.
+	interrupt := make(chan struct{})
+	close(interrupt)
+	if err := m.Init(nil, interrupt); err != errInterruptRequested {
+		t.Fatalf("unexpected error initializing manager: %v", err)
+	}
+	if err := m.AddIndex(&testIndex{key: "c"}); err == nil {
+		t.Fatal("added index after the manager was initialized")
+	}
+}
+
+// TestIndexProgressSynced ensures the sync state of an index is derived from
+// its height as expected.
+func TestIndexProgressSynced(t *testing.T) {
+	tests := []struct {
+		height int32
+		synced bool
+	}{
+		{height: -1, synced: false},
+		{height: 99, synced: false},
+		{height: 100, synced: true},
+	}
+
+	for _, test := range tests {
+		progress := IndexProgress{Height: test.height, BestHeight: 100}
+		if progress.Synced() != test.synced {
+			t.Errorf("unexpected sync state at height %d: got %v, "+
+				"want %v", test.height, progress.Synced(),
+				test.synced)
+		}
+	}
+}
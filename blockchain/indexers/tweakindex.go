@@ -0,0 +1,425 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/database"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// tweakIndexName is the human-readable name for the index.
+	tweakIndexName = "silent payments tweak index"
+
+	// annexTag is the first byte of the optional last witness item of a
+	// taproot spend which marks it as the annex as defined by BIP-341.
+	annexTag = 0x50
+
+	// TweakSize is the size of a serialized silent payments tweak, which
+	// is a compressed public key.
+	TweakSize = 33
+)
+
+var (
+	// tweakIndexKey is the key of the tweak index and the db bucket used
+	// to house it.
+	tweakIndexKey = []byte("sptweakbyhashidx")
+
+	// inputsTag is the tag of the hash which commits to the inputs of a
+	// silent payments transaction as defined by BIP-352.
+	inputsTag = []byte("BIP0352/Inputs")
+
+	// numsInternalKey is the x-only internal key H defined by BIP-341
+	// which has no known discrete logarithm.  Taproot script path spends
+	// using it as their internal key are not eligible for silent payments.
+	numsInternalKey = []byte{
+		0x50, 0x92, 0x9b, 0x74, 0xc1, 0xa0, 0x49, 0x54,
+		0xb7, 0x8b, 0x4b, 0x60, 0x35, 0xe9, 0x7a, 0x5e,
+		0x07, 0x8a, 0x5a, 0x0f, 0x28, 0xec, 0x96, 0xd5,
+		0x47, 0xbf, 0xee, 0x9a, 0xce, 0x80, 0x3a, 0xc0,
+	}
+)
+
+// -----------------------------------------------------------------------------
+// The tweak index consists of an entry for every block in the main chain.
+// Each entry holds the tweaks of the transactions of the block which are
+// eligible for silent payments in the order they appear in the block:
+//
+//   <block hash> = <tweak>...
+//
+//   Field           Type              Size
+//   block hash      chainhash.Hash    32
+//   tweak           compressed pubkey 33
+//
+// A block without eligible transactions has an empty entry.
+// -----------------------------------------------------------------------------
+
+// isPayToTaproot returns whether the passed public key script is a version 1
+// segregated witness program with a 32-byte x-only public key.
+func isPayToTaproot(pkScript []byte) bool {
+	return len(pkScript) == 34 && pkScript[0] == txscript.OP_1 &&
+		pkScript[1] == txscript.OP_DATA_32
+}
+
+// hasTaprootOutput returns whether the passed transaction pays to at least one
+// taproot output, which is required for it to contain silent payments.
+func hasTaprootOutput(tx *wire.MsgTx) bool {
+	for _, txOut := range tx.TxOut {
+		if isPayToTaproot(txOut.PkScript) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCompressedPubKey parses the passed serialized public key if it is a
+// valid compressed public key and returns nil otherwise.
+func parseCompressedPubKey(serialized []byte) *btcec.PublicKey {
+	if len(serialized) != btcec.PubKeyBytesLenCompressed {
+		return nil
+	}
+	pubKey, err := btcec.ParsePubKey(serialized, btcec.S256())
+	if err != nil {
+		return nil
+	}
+	return pubKey
+}
+
+// inputPubKey returns the public key an input spending an output with the
+// passed public key script contributes to the silent payments tweak of its
+// transaction as defined by BIP-352.  It returns nil for inputs which are not
+// eligible.
+func inputPubKey(txIn *wire.TxIn, pkScript []byte) *btcec.PublicKey {
+	witness := txIn.Witness
+	switch {
+	case isPayToTaproot(pkScript):
+		// Remove the annex, if any.
+		if len(witness) > 1 {
+			last := witness[len(witness)-1]
+			if len(last) > 0 && last[0] == annexTag {
+				witness = witness[:len(witness)-1]
+			}
+		}
+
+		// Script path spends with the NUMS point as their internal key
+		// can't be spent using the output key.
+		if len(witness) > 1 {
+			controlBlock := witness[len(witness)-1]
+			if len(controlBlock) >= 33 &&
+				bytes.Equal(controlBlock[1:33], numsInternalKey) {
+
+				return nil
+			}
+		}
+
+		// The x-only output key is lifted to the point with an even y
+		// coordinate.
+		var serialized [btcec.PubKeyBytesLenCompressed]byte
+		serialized[0] = 0x02
+		copy(serialized[1:], pkScript[2:])
+		return parseCompressedPubKey(serialized[:])
+
+	case txscript.IsPayToWitnessPubKeyHash(pkScript):
+		if len(witness) == 0 {
+			return nil
+		}
+		return parseCompressedPubKey(witness[len(witness)-1])
+
+	case txscript.IsPayToScriptHash(pkScript):
+		// Only nested pay-to-witness-pubkey-hash inputs are eligible.
+		pushes, err := txscript.PushedData(txIn.SignatureScript)
+		if err != nil || len(pushes) != 1 ||
+			!txscript.IsPayToWitnessPubKeyHash(pushes[0]) {
+
+			return nil
+		}
+		if len(witness) == 0 {
+			return nil
+		}
+		return parseCompressedPubKey(witness[len(witness)-1])
+
+	case txscript.GetScriptClass(pkScript) == txscript.PubKeyHashTy:
+		// The signature script may be malleated, so use the last 33
+		// bytes of it which hash to the public key hash of the script.
+		sigScript := txIn.SignatureScript
+		pubKeyHash := pkScript[3:23]
+		for i := len(sigScript); i >= btcec.PubKeyBytesLenCompressed; i-- {
+			serialized := sigScript[i-btcec.PubKeyBytesLenCompressed : i]
+			if bytes.Equal(btcutil.Hash160(serialized), pubKeyHash) {
+				return parseCompressedPubKey(serialized)
+			}
+		}
+		return nil
+	}
+
+	return nil
+}
+
+// spendsUnknownWitnessVersion returns whether the passed public key script is
+// a segregated witness program with a version greater than 1.  Transactions
+// spending such an output are not eligible for silent payments so that future
+// witness versions can define how they contribute to the tweak.
+func spendsUnknownWitnessVersion(pkScript []byte) bool {
+	if !txscript.IsWitnessProgram(pkScript) {
+		return false
+	}
+	version, _, err := txscript.ExtractWitnessProgramInfo(pkScript)
+	return err == nil && version > 1
+}
+
+// TxTweak returns the silent payments tweak of the passed transaction as
+// defined by BIP-352 given the public key scripts of the outputs it spends in
+// input order.  The tweak is the sum of the public keys of the eligible inputs
+// multiplied by the hash committing to the smallest outpoint the transaction
+// spends and that sum, serialized as a compressed public key.  Receivers of
+// silent payments scan the transaction by multiplying the tweak with their scan
+// key.
+//
+// It returns false when the transaction is not eligible for silent payments.
+func TxTweak(tx *wire.MsgTx, prevScripts [][]byte) ([]byte, bool) {
+	if blockchain.IsCoinBaseTx(tx) || len(prevScripts) != len(tx.TxIn) ||
+		!hasTaprootOutput(tx) {
+
+		return nil, false
+	}
+
+	curve := btcec.S256()
+	var sum *btcec.PublicKey
+	var smallestOutpoint []byte
+	for i, txIn := range tx.TxIn {
+		if spendsUnknownWitnessVersion(prevScripts[i]) {
+			return nil, false
+		}
+
+		var outpoint [chainhash.HashSize + 4]byte
+		copy(outpoint[:], txIn.PreviousOutPoint.Hash[:])
+		binary.LittleEndian.PutUint32(outpoint[chainhash.HashSize:],
+			txIn.PreviousOutPoint.Index)
+		if smallestOutpoint == nil ||
+			bytes.Compare(outpoint[:], smallestOutpoint) < 0 {
+
+			smallestOutpoint = outpoint[:]
+		}
+
+		pubKey := inputPubKey(txIn, prevScripts[i])
+		if pubKey == nil {
+			continue
+		}
+		if sum == nil {
+			sum = pubKey
+			continue
+		}
+		x, y := curve.Add(sum.X, sum.Y, pubKey.X, pubKey.Y)
+		sum = &btcec.PublicKey{Curve: curve, X: x, Y: y}
+	}
+
+	// The public keys may cancel each other out, in which case the sum is
+	// the point at infinity.
+	if sum == nil || (sum.X.Sign() == 0 && sum.Y.Sign() == 0) {
+		return nil, false
+	}
+
+	serializedSum := sum.SerializeCompressed()
+	inputHash := chainhash.TaggedHash(inputsTag, smallestOutpoint,
+		serializedSum)
+	tweakX, tweakY := curve.ScalarMult(sum.X, sum.Y, inputHash[:])
+	tweak := &btcec.PublicKey{Curve: curve, X: tweakX, Y: tweakY}
+	return tweak.SerializeCompressed(), true
+}
+
+// TweakIndex implements a silent payments tweak by block hash index.  It holds
+// the tweaks of the transactions eligible for silent payments as defined by
+// BIP-352 for every block in the main chain, so that light clients are able to
+// scan for silent payments without downloading full blocks.  The index is
+// built in the background, so tweaks are only available for the blocks it
+// caught up to so far.
+type TweakIndex struct {
+	db    database.DB
+	chain *blockchain.BlockChain
+}
+
+// Ensure the TweakIndex type implements the Indexer interface.
+var _ Indexer = (*TweakIndex)(nil)
+
+// Ensure the TweakIndex type implements the NeedsInputser interface.
+var _ NeedsInputser = (*TweakIndex)(nil)
+
+// Ensure the TweakIndex type implements the BackgroundBuilder interface.
+var _ BackgroundBuilder = (*TweakIndex)(nil)
+
+// NeedsInputs signals that the index requires the referenced inputs in order
+// to properly create the index.
+//
+// This implements the NeedsInputser interface.
+func (idx *TweakIndex) NeedsInputs() bool {
+	return true
+}
+
+// BuildsInBackground signals that the index is caught up with the main chain in
+// the background.
+//
+// This implements the BackgroundBuilder interface.
+func (idx *TweakIndex) BuildsInBackground() bool {
+	return true
+}
+
+// setChain sets the chain the index is built for.
+//
+// This implements the chainIndexer interface.
+func (idx *TweakIndex) setChain(chain *blockchain.BlockChain) {
+	idx.chain = chain
+}
+
+// Init initializes the tweak index.
+//
+// This is part of the Indexer interface.
+func (idx *TweakIndex) Init() error {
+	return nil // Nothing to do.
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *TweakIndex) Key() []byte {
+	return tweakIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *TweakIndex) Name() string {
+	return tweakIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the index.
+//
+// This is part of the Indexer interface.
+func (idx *TweakIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(tweakIndexKey)
+	return err
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds an entry with the tweaks of
+// the eligible transactions of the block.
+//
+// This is part of the Indexer interface.
+func (idx *TweakIndex) ConnectBlock(dbTx database.Tx, block *btcutil.Block,
+	stxos []blockchain.SpentTxOut) error {
+
+	tweaks := make([]byte, 0)
+	stxoIndex := 0
+	for _, tx := range block.MsgBlock().Transactions[1:] {
+		numInputs := len(tx.TxIn)
+		if stxoIndex+numInputs > len(stxos) {
+			return fmt.Errorf("missing spent outputs for block %v",
+				block.Hash())
+		}
+
+		prevScripts := make([][]byte, numInputs)
+		for i := range prevScripts {
+			prevScripts[i] = stxos[stxoIndex+i].PkScript
+		}
+		stxoIndex += numInputs
+
+		tweak, ok := TxTweak(tx, prevScripts)
+		if ok {
+			tweaks = append(tweaks, tweak...)
+		}
+	}
+
+	return dbTx.Metadata().Bucket(tweakIndexKey).Put(block.Hash()[:], tweaks)
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the entry of the
+// block.
+//
+// This is part of the Indexer interface.
+func (idx *TweakIndex) DisconnectBlock(dbTx database.Tx, block *btcutil.Block,
+	_ []blockchain.SpentTxOut) error {
+
+	return dbTx.Metadata().Bucket(tweakIndexKey).Delete(block.Hash()[:])
+}
+
+// TweaksByBlockRange invokes the passed function with the hash, the height and
+// the tweaks of the eligible transactions of each main chain block from
+// startHeight to endHeight inclusive in order of their height.  The tweaks are
+// fetched in a single database transaction, so the function must not block or
+// access the database.  A block the index did not catch up to yet results in
+// an error.
+func (idx *TweakIndex) TweaksByBlockRange(startHeight, endHeight int32,
+	fn func(hash *chainhash.Hash, height int32, tweaks [][]byte) error) error {
+
+	if startHeight < 0 || endHeight < startHeight {
+		return fmt.Errorf("invalid block range from height %d to %d",
+			startHeight, endHeight)
+	}
+	hashes, err := idx.chain.HeightRange(startHeight, endHeight+1)
+	if err != nil {
+		return err
+	}
+	if len(hashes) != int(endHeight-startHeight+1) {
+		return fmt.Errorf("block range from height %d to %d is not "+
+			"part of the main chain", startHeight, endHeight)
+	}
+
+	return idx.db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(tweakIndexKey)
+		for i := range hashes {
+			height := startHeight + int32(i)
+			entry := bucket.Get(hashes[i][:])
+			if entry == nil {
+				return fmt.Errorf("block %v (height %d) is not "+
+					"indexed yet", hashes[i], height)
+			}
+			if len(entry)%TweakSize != 0 {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("corrupt tweak "+
+						"index entry for block %v",
+						hashes[i]),
+				}
+			}
+
+			tweaks := make([][]byte, 0, len(entry)/TweakSize)
+			for offset := 0; offset < len(entry); offset += TweakSize {
+				tweak := make([]byte, TweakSize)
+				copy(tweak, entry[offset:offset+TweakSize])
+				tweaks = append(tweaks, tweak)
+			}
+			if err := fn(&hashes[i], height, tweaks); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// NewTweakIndex returns a new instance of an indexer that is used to create a
+// mapping of the hashes of all blocks in the blockchain to the silent payments
+// tweaks of their eligible transactions.
+//
+// It implements the Indexer interface which plugs into the IndexManager that
+// in turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewTweakIndex(db database.DB) *TweakIndex {
+	return &TweakIndex{db: db}
+}
+
+// DropTweakIndex drops the tweak index from the provided database if exists.
+func DropTweakIndex(db database.DB, interrupt <-chan struct{}) error {
+	return dropIndex(db, tweakIndexKey, tweakIndexName, interrupt)
+}
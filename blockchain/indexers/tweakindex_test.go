@@ -0,0 +1,266 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// tweakTestKey returns the private key with the passed scalar.
+func tweakTestKey(scalar byte) *btcec.PrivateKey {
+	var b [32]byte
+	b[31] = scalar
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), b[:])
+	return privKey
+}
+
+// negatedTweakTestKey returns the private key with the negation of the passed
+// scalar.
+func negatedTweakTestKey(scalar byte) *btcec.PrivateKey {
+	d := new(big.Int).Sub(btcec.S256().N, big.NewInt(int64(scalar)))
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), d.Bytes())
+	return privKey
+}
+
+// p2wpkhScript returns a pay-to-witness-pubkey-hash script paying to the passed
+// key.
+func p2wpkhScript(privKey *btcec.PrivateKey) []byte {
+	pubKeyHash := btcutil.Hash160(privKey.PubKey().SerializeCompressed())
+	return append([]byte{txscript.OP_0, txscript.OP_DATA_20}, pubKeyHash...)
+}
+
+// p2pkhScript returns a pay-to-pubkey-hash script paying to the passed key.
+func p2pkhScript(privKey *btcec.PrivateKey) []byte {
+	pubKeyHash := btcutil.Hash160(privKey.PubKey().SerializeCompressed())
+	script := []byte{txscript.OP_DUP, txscript.OP_HASH160,
+		txscript.OP_DATA_20}
+	script = append(script, pubKeyHash...)
+	return append(script, txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)
+}
+
+// p2trScript returns a pay-to-taproot script with the passed key as its output
+// key.
+func p2trScript(privKey *btcec.PrivateKey) []byte {
+	xOnly := privKey.PubKey().SerializeCompressed()[1:]
+	return append([]byte{txscript.OP_1, txscript.OP_DATA_32}, xOnly...)
+}
+
+// expectedTweak calculates the tweak of a transaction spending the passed
+// smallest outpoint with the eligible inputs owned by the passed keys directly
+// from the sum of the private keys.
+func expectedTweak(smallestOutpoint wire.OutPoint, privKeys []*btcec.PrivateKey,
+	taproot []bool) []byte {
+
+	curve := btcec.S256()
+	sum := new(big.Int)
+	for i, privKey := range privKeys {
+		d := new(big.Int).Set(privKey.D)
+		if taproot[i] && privKey.PubKey().Y.Bit(0) == 1 {
+			d.Sub(curve.N, d)
+		}
+		sum.Add(sum, d)
+	}
+	sum.Mod(sum, curve.N)
+
+	var outpoint [chainhash.HashSize + 4]byte
+	copy(outpoint[:], smallestOutpoint.Hash[:])
+	binary.LittleEndian.PutUint32(outpoint[chainhash.HashSize:],
+		smallestOutpoint.Index)
+	_, sumPubKey := btcec.PrivKeyFromBytes(curve, sum.Bytes())
+	inputHash := chainhash.TaggedHash(inputsTag, outpoint[:],
+		sumPubKey.SerializeCompressed())
+
+	tweak := new(big.Int).SetBytes(inputHash[:])
+	tweak.Mul(tweak, sum)
+	tweak.Mod(tweak, curve.N)
+	_, tweakPubKey := btcec.PrivKeyFromBytes(curve, tweak.Bytes())
+	return tweakPubKey.SerializeCompressed()
+}
+
+// TestTxTweak ensures the silent payments tweak of transactions is calculated
+// from their eligible inputs and that ineligible transactions have no tweak.
+func TestTxTweak(t *testing.T) {
+	t.Parallel()
+
+	key1 := tweakTestKey(1)
+	key2 := tweakTestKey(2)
+	key3 := tweakTestKey(3)
+	key4 := tweakTestKey(4)
+	oddKey := tweakTestKey(6)
+	negatedKey1 := negatedTweakTestKey(1)
+
+	dummySig := bytes.Repeat([]byte{0x30}, 71)
+	schnorrSig := bytes.Repeat([]byte{0x01}, 64)
+	outpoints := []wire.OutPoint{
+		{Hash: chainhash.Hash{0x02}, Index: 0},
+		{Hash: chainhash.Hash{0x01}, Index: 1},
+		{Hash: chainhash.Hash{0x01}, Index: 0},
+		{Hash: chainhash.Hash{0x03}, Index: 5},
+	}
+
+	// p2pkhSigScript returns a signature script spending a pay-to-pubkey-hash
+	// output of the passed key.
+	p2pkhSigScript := func(privKey *btcec.PrivateKey) []byte {
+		sigScript := append([]byte{byte(len(dummySig))}, dummySig...)
+		sigScript = append(sigScript, txscript.OP_DATA_33)
+		return append(sigScript, privKey.PubKey().SerializeCompressed()...)
+	}
+
+	// numsControlBlock is the control block of a script path spend using
+	// the NUMS point as its internal key.
+	numsControlBlock := append([]byte{0xc0}, numsInternalKey...)
+
+	tests := []struct {
+		name        string
+		txIns       []*wire.TxIn
+		prevScripts [][]byte
+		txOuts      []*wire.TxOut
+		keys        []*btcec.PrivateKey
+		taproot     []bool
+		eligible    bool
+	}{
+		{
+			name: "mixed input types",
+			txIns: []*wire.TxIn{{
+				PreviousOutPoint: outpoints[0],
+				Witness: wire.TxWitness{dummySig,
+					key1.PubKey().SerializeCompressed()},
+			}, {
+				PreviousOutPoint: outpoints[1],
+				Witness:          wire.TxWitness{schnorrSig},
+			}, {
+				PreviousOutPoint: outpoints[2],
+				SignatureScript:  p2pkhSigScript(key3),
+			}},
+			prevScripts: [][]byte{p2wpkhScript(key1),
+				p2trScript(key2), p2pkhScript(key3)},
+			txOuts:   []*wire.TxOut{{PkScript: p2trScript(key4)}},
+			keys:     []*btcec.PrivateKey{key1, key2, key3},
+			taproot:  []bool{false, true, false},
+			eligible: true,
+		},
+		{
+			name: "taproot input with odd output key and annex",
+			txIns: []*wire.TxIn{{
+				PreviousOutPoint: outpoints[3],
+				Witness: wire.TxWitness{schnorrSig,
+					{annexTag, 0x01}},
+			}},
+			prevScripts: [][]byte{p2trScript(oddKey)},
+			txOuts:      []*wire.TxOut{{PkScript: p2trScript(key4)}},
+			keys:        []*btcec.PrivateKey{oddKey},
+			taproot:     []bool{true},
+			eligible:    true,
+		},
+		{
+			name: "ineligible inputs are skipped",
+			txIns: []*wire.TxIn{{
+				PreviousOutPoint: outpoints[0],
+				Witness: wire.TxWitness{{0x01}, {txscript.OP_TRUE},
+					numsControlBlock},
+			}, {
+				PreviousOutPoint: outpoints[1],
+				Witness: wire.TxWitness{dummySig,
+					key2.PubKey().SerializeUncompressed()},
+			}, {
+				PreviousOutPoint: outpoints[3],
+				Witness: wire.TxWitness{dummySig,
+					key4.PubKey().SerializeCompressed()},
+			}},
+			prevScripts: [][]byte{p2trScript(key1),
+				p2wpkhScript(key2), p2wpkhScript(key4)},
+			txOuts:   []*wire.TxOut{{PkScript: p2trScript(key3)}},
+			keys:     []*btcec.PrivateKey{key4},
+			taproot:  []bool{false},
+			eligible: true,
+		},
+		{
+			name: "no taproot output",
+			txIns: []*wire.TxIn{{
+				PreviousOutPoint: outpoints[0],
+				Witness: wire.TxWitness{dummySig,
+					key1.PubKey().SerializeCompressed()},
+			}},
+			prevScripts: [][]byte{p2wpkhScript(key1)},
+			txOuts:      []*wire.TxOut{{PkScript: p2wpkhScript(key2)}},
+		},
+		{
+			name: "spends unknown witness version",
+			txIns: []*wire.TxIn{{
+				PreviousOutPoint: outpoints[0],
+				Witness: wire.TxWitness{dummySig,
+					key1.PubKey().SerializeCompressed()},
+			}, {
+				PreviousOutPoint: outpoints[1],
+			}},
+			prevScripts: [][]byte{p2wpkhScript(key1),
+				append([]byte{txscript.OP_2, txscript.OP_DATA_32},
+					make([]byte, 32)...)},
+			txOuts: []*wire.TxOut{{PkScript: p2trScript(key2)}},
+		},
+		{
+			name: "only ineligible inputs",
+			txIns: []*wire.TxIn{{
+				PreviousOutPoint: outpoints[0],
+				Witness: wire.TxWitness{{0x01}, {txscript.OP_TRUE},
+					numsControlBlock},
+			}},
+			prevScripts: [][]byte{p2trScript(key1)},
+			txOuts:      []*wire.TxOut{{PkScript: p2trScript(key2)}},
+		},
+		{
+			name: "input keys sum to infinity",
+			txIns: []*wire.TxIn{{
+				PreviousOutPoint: outpoints[0],
+				Witness: wire.TxWitness{dummySig,
+					key1.PubKey().SerializeCompressed()},
+			}, {
+				PreviousOutPoint: outpoints[1],
+				Witness: wire.TxWitness{dummySig,
+					negatedKey1.PubKey().SerializeCompressed()},
+			}},
+			prevScripts: [][]byte{p2wpkhScript(key1),
+				p2wpkhScript(negatedKey1)},
+			txOuts: []*wire.TxOut{{PkScript: p2trScript(key2)}},
+		},
+	}
+
+	for _, test := range tests {
+		tx := &wire.MsgTx{Version: 2, TxIn: test.txIns, TxOut: test.txOuts}
+		tweak, ok := TxTweak(tx, test.prevScripts)
+		if ok != test.eligible {
+			t.Errorf("%s: unexpected eligibility - got %v, want %v",
+				test.name, ok, test.eligible)
+			continue
+		}
+		if !test.eligible {
+			continue
+		}
+
+		smallest := test.txIns[0].PreviousOutPoint
+		for _, txIn := range test.txIns[1:] {
+			op := txIn.PreviousOutPoint
+			if bytes.Compare(op.Hash[:], smallest.Hash[:]) < 0 ||
+				(op.Hash == smallest.Hash && op.Index < smallest.Index) {
+
+				smallest = op
+			}
+		}
+		want := expectedTweak(smallest, test.keys, test.taproot)
+		if !bytes.Equal(tweak, want) {
+			t.Errorf("%s: unexpected tweak - got %x, want %x",
+				test.name, tweak, want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"container/list"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// activateBestChain reorganizes the chain to the chain with the most cumulative
+// work which is not known to be invalid.  Chains which fail to connect are
+// marked invalid and the chain with the next most work is tried instead.
+//
+// This function may modify node statuses in the block index without flushing.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) activateBestChain() error {
+	for {
+		tip := b.bestChain.Tip()
+		best := b.index.BestValidTip(tip)
+		if best == tip {
+			return nil
+		}
+
+		// No nodes are returned when the chain has an invalid ancestor
+		// which is not marked yet.  The best node is marked as having an
+		// invalid ancestor in that case, so the next best chain is tried.
+		detachNodes, attachNodes := b.getReorganizeNodes(best)
+		if attachNodes.Len() == 0 {
+			continue
+		}
+
+		log.Infof("REORGANIZE: Activating the best valid chain ending at "+
+			"block %v", best.hash)
+		err := b.reorganizeChain(detachNodes, attachNodes)
+		if err != nil {
+			// A block which fails validation is marked invalid along
+			// with its descendants before any of the chain is
+			// changed, so try the next best chain.
+			_, ok := err.(RuleError)
+			if ok && b.index.NodeStatus(best).KnownInvalid() {
+				continue
+			}
+			return err
+		}
+	}
+}
+
+// InvalidateBlock marks the block with the passed hash invalid and all of its
+// descendants as having an invalid ancestor.  When the block is part of the
+// main chain, it is disconnected along with all blocks after it and the chain
+// is reorganized to the remaining valid chain with the most cumulative work.
+// The status of the blocks is stored in the database, so it persists across
+// restarts until the block is reconsidered with ReconsiderBlock.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) InvalidateBlock(hash *chainhash.Hash) error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	node := b.index.LookupNode(hash)
+	if node == nil {
+		return fmt.Errorf("block %s is not known", hash)
+	}
+	if node.parent == nil {
+		return fmt.Errorf("genesis block %s can't be invalidated", hash)
+	}
+
+	// The blocks up to the snapshot block are not available to a chain
+	// state which was loaded from a utxo snapshot, so they can't be
+	// disconnected.
+	inMainChain := b.bestChain.Contains(node)
+	if inMainChain && b.utxoSnapshot != nil &&
+		node.height <= b.utxoSnapshot.Height {
+
+		return fmt.Errorf("block %s can't be invalidated since the chain "+
+			"state was loaded from a utxo snapshot at height %d", hash,
+			b.utxoSnapshot.Height)
+	}
+
+	// Disconnect the block and all blocks after it from the main chain.
+	if inMainChain {
+		detachNodes := list.New()
+		for n := b.bestChain.Tip(); n != node.parent; n = n.parent {
+			detachNodes.PushBack(n)
+		}
+		if err := b.reorganizeChain(detachNodes, list.New()); err != nil {
+			return err
+		}
+	}
+
+	b.index.SetStatusFlags(node, statusValidateFailed)
+	for _, n := range b.index.Descendants(node) {
+		b.index.SetStatusFlags(n, statusInvalidAncestor)
+	}
+
+	// A side chain might have more work than the main chain without the
+	// invalidated blocks now.
+	err := b.activateBestChain()
+	if writeErr := b.index.flushToDB(); writeErr != nil && err == nil {
+		err = writeErr
+	}
+	return err
+}
+
+// ReconsiderBlock removes the invalid status from the block with the passed
+// hash along with its ancestors and descendants, such as set by
+// InvalidateBlock, and reorganizes the chain to the chain with the most
+// cumulative work.  Blocks which were never validated are validated again and
+// marked invalid once more if they fail validation.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ReconsiderBlock(hash *chainhash.Hash) error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	node := b.index.LookupNode(hash)
+	if node == nil {
+		return fmt.Errorf("block %s is not known", hash)
+	}
+
+	const invalidFlags = statusValidateFailed | statusInvalidAncestor
+	for n := node; n != nil; n = n.parent {
+		if b.index.NodeStatus(n).KnownInvalid() {
+			b.index.UnsetStatusFlags(n, invalidFlags)
+		}
+	}
+	for _, n := range b.index.Descendants(node) {
+		if b.index.NodeStatus(n).KnownInvalid() {
+			b.index.UnsetStatusFlags(n, invalidFlags)
+		}
+	}
+
+	err := b.activateBestChain()
+	if writeErr := b.index.flushToDB(); writeErr != nil && err == nil {
+		err = writeErr
+	}
+	return err
+}
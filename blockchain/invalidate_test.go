@@ -0,0 +1,225 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+)
+
+// TestInvalidateBlock ensures invalidating and reconsidering blocks reorganizes
+// the chain to the best valid chain and the status of the blocks persists.
+func TestInvalidateBlock(t *testing.T) {
+	// Load up blocks such that there is a side chain.
+	// (genesis block) -> 1 -> 2 -> 3 -> 4
+	//                          \-> 3a
+	var blocks []*btcutil.Block
+	for _, file := range []string{"blk_0_to_4.dat.bz2", "blk_3A.dat.bz2"} {
+		blockTmp, err := loadBlocks(file)
+		if err != nil {
+			t.Fatalf("Error loading file: %v", err)
+		}
+		blocks = append(blocks, blockTmp...)
+	}
+
+	db, teardown := snapshotTestDB(t, "invalidateblock")
+	defer teardown()
+	chain := newSnapshotTestChain(t, db)
+	chain.TstSetCoinbaseMaturity(1)
+	for i := 1; i < len(blocks); i++ {
+		if _, _, err := chain.ProcessBlock(blocks[i], BFNone); err != nil {
+			t.Fatalf("ProcessBlock fail on block %v: %v", i, err)
+		}
+	}
+
+	// Unknown blocks and the genesis block can't be invalidated.
+	var unknown chainhash.Hash
+	if err := chain.InvalidateBlock(&unknown); err == nil {
+		t.Fatalf("invalidated unknown block")
+	}
+	if err := chain.ReconsiderBlock(&unknown); err == nil {
+		t.Fatalf("reconsidered unknown block")
+	}
+	if err := chain.InvalidateBlock(blocks[0].Hash()); err == nil {
+		t.Fatalf("invalidated genesis block")
+	}
+
+	tests := []struct {
+		name       string
+		reconsider bool
+		block      int
+		reopen     bool
+		wantTip    int
+	}{{
+		// The main chain is kept when it has as much work as the side
+		// chain.
+		name:    "invalidate tip",
+		block:   4,
+		wantTip: 3,
+	}, {
+		name:    "invalidate fork",
+		block:   3,
+		wantTip: 5,
+	}, {
+		// Reconsidering a block also reconsiders its ancestors.
+		name:       "reconsider tip",
+		reconsider: true,
+		block:      4,
+		wantTip:    4,
+	}, {
+		name:    "invalidate side chain",
+		block:   5,
+		wantTip: 4,
+	}, {
+		name:    "invalidate fork point",
+		block:   2,
+		reopen:  true,
+		wantTip: 1,
+	}, {
+		name:       "reconsider fork point",
+		reconsider: true,
+		block:      2,
+		wantTip:    4,
+	}}
+
+	for _, test := range tests {
+		var err error
+		if test.reconsider {
+			err = chain.ReconsiderBlock(blocks[test.block].Hash())
+		} else {
+			err = chain.InvalidateBlock(blocks[test.block].Hash())
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+
+		// The status of the blocks must persist when the chain is
+		// created again.
+		if test.reopen {
+			chain = newSnapshotTestChain(t, db)
+			chain.TstSetCoinbaseMaturity(1)
+		}
+
+		best := chain.BestSnapshot()
+		if best.Hash != *blocks[test.wantTip].Hash() {
+			t.Fatalf("%s: unexpected tip -- got %v, want %v", test.name,
+				best.Hash, blocks[test.wantTip].Hash())
+		}
+		node := chain.index.LookupNode(blocks[test.block].Hash())
+		if chain.index.NodeStatus(node).KnownInvalid() == test.reconsider {
+			t.Fatalf("%s: unexpected status %v", test.name,
+				chain.index.NodeStatus(node))
+		}
+		invalid := chain.IsKnownInvalid(blocks[test.block].Hash())
+		if invalid == test.reconsider {
+			t.Fatalf("%s: unexpected known invalid state %v",
+				test.name, invalid)
+		}
+	}
+}
+
+// TestBlockIndexTips ensures the block index keeps track of the nodes without
+// children and finds the descendants and the best valid tip from them.
+func TestBlockIndexTips(t *testing.T) {
+	// Create a block index with a side chain which has more work.
+	// (genesis block) -> 1 -> 2 -> 3 -> 4 -> 5
+	//                          \-> 3a -> 4a -> 5a -> 6a
+	params := &chaincfg.RegressionNetParams
+	index := newBlockIndex(nil, params)
+	genesis := newBlockNode(&params.GenesisBlock.Header, nil)
+	index.AddNode(genesis)
+	addNodes := func(parent *blockNode, num int) []*blockNode {
+		nodes := make([]*blockNode, 0, num)
+		for i := 0; i < num; i++ {
+			parent = newFakeNode(parent, 4, params.PowLimitBits,
+				time.Unix(int64(len(index.index)), 0))
+			parent.status = statusDataStored
+			index.AddNode(parent)
+			nodes = append(nodes, parent)
+		}
+		return nodes
+	}
+	mainNodes := addNodes(genesis, 5)
+	sideNodes := addNodes(mainNodes[1], 4)
+
+	if len(index.tips) != 2 {
+		t.Fatalf("unexpected number of tips %d", len(index.tips))
+	}
+	for _, tip := range []*blockNode{mainNodes[4], sideNodes[3]} {
+		if _, ok := index.tips[tip]; !ok {
+			t.Fatalf("node %d is not a tip", tip.height)
+		}
+	}
+
+	descendants := index.Descendants(mainNodes[1])
+	want := append(append([]*blockNode{}, mainNodes[2:]...), sideNodes...)
+	if len(descendants) != len(want) {
+		t.Fatalf("unexpected number of descendants %d, want %d",
+			len(descendants), len(want))
+	}
+	found := make(map[*blockNode]struct{})
+	for _, n := range descendants {
+		found[n] = struct{}{}
+	}
+	for _, n := range want {
+		if _, ok := found[n]; !ok {
+			t.Fatalf("node %d is not a descendant", n.height)
+		}
+	}
+	if len(index.Descendants(mainNodes[4])) != 0 {
+		t.Fatal("tip has descendants")
+	}
+
+	tests := []struct {
+		name    string
+		invalid *blockNode
+		noData  *blockNode
+		tip     *blockNode
+		want    *blockNode
+	}{{
+		name: "side chain with more work",
+		tip:  mainNodes[4],
+		want: sideNodes[3],
+	}, {
+		// Ties are resolved in favor of the passed tip.
+		name:    "invalid side chain tip",
+		invalid: sideNodes[3],
+		tip:     mainNodes[4],
+		want:    mainNodes[4],
+	}, {
+		name:    "invalid side chain",
+		invalid: sideNodes[2],
+		tip:     mainNodes[3],
+		want:    mainNodes[4],
+	}, {
+		name:    "side chain tip without data",
+		invalid: mainNodes[4],
+		noData:  sideNodes[3],
+		tip:     mainNodes[3],
+		want:    sideNodes[2],
+	}}
+	for _, test := range tests {
+		for _, n := range index.index {
+			n.status = statusDataStored
+		}
+		if test.invalid != nil {
+			test.invalid.status |= statusValidateFailed
+			for _, n := range index.Descendants(test.invalid) {
+				n.status |= statusInvalidAncestor
+			}
+		}
+		if test.noData != nil {
+			test.noData.status = statusNone
+		}
+		if got := index.BestValidTip(test.tip); got != test.want {
+			t.Errorf("%s: unexpected best valid tip %d, want %d",
+				test.name, got.height, test.want.height)
+		}
+	}
+}
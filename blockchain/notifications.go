@@ -6,6 +6,9 @@ package blockchain
 
 import (
 	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
 )
 
 // NotificationType represents the type of a notification message.
@@ -47,23 +50,114 @@ func (n NotificationType) String() string {
 	return fmt.Sprintf("Unknown Notification Type (%d)", int(n))
 }
 
+// TxSpendData houses the value flows of a transaction in a block connected to
+// the main chain.
+type TxSpendData struct {
+	// Fee is the fee paid by the transaction, which is zero for the
+	// coinbase transaction.
+	Fee int64
+
+	// TotalIn is the total value of the outputs spent by the transaction.
+	TotalIn int64
+
+	// PrevOuts are the outputs spent by the inputs of the transaction in
+	// input order.  It is nil for the coinbase transaction.
+	PrevOuts []*wire.TxOut
+}
+
+// BlockSpendData houses the fees and spent outputs of the transactions in a
+// block connected to the main chain.  They are already known when the block is
+// connected, which saves subscribers from deriving them again.
+type BlockSpendData struct {
+	// Txns houses the spend data of each transaction of the block in
+	// block order, so the first entry is for the coinbase transaction.
+	Txns []TxSpendData
+
+	// TotalFees is the sum of the fees paid by all transactions of the
+	// block.
+	TotalFees int64
+}
+
+// newBlockSpendData returns the spend data of the passed block given the
+// outputs it spends in the order they are spent, as recorded by the spend
+// journal.
+func newBlockSpendData(block *btcutil.Block, stxos []SpentTxOut) *BlockSpendData {
+	txns := block.MsgBlock().Transactions
+	data := BlockSpendData{Txns: make([]TxSpendData, len(txns))}
+
+	// The coinbase transaction doesn't spend any outputs, so the spent
+	// outputs start with the first input of the second transaction.
+	var stxoIdx int
+	for i, tx := range txns[1:] {
+		txData := &data.Txns[i+1]
+		txData.PrevOuts = make([]*wire.TxOut, 0, len(tx.TxIn))
+		for range tx.TxIn {
+			stxo := &stxos[stxoIdx]
+			txData.PrevOuts = append(txData.PrevOuts,
+				wire.NewTxOut(stxo.Amount, stxo.PkScript))
+			txData.TotalIn += stxo.Amount
+			stxoIdx++
+		}
+
+		var totalOut int64
+		for _, txOut := range tx.TxOut {
+			totalOut += txOut.Value
+		}
+		txData.Fee = txData.TotalIn - totalOut
+		data.TotalFees += txData.Fee
+	}
+
+	return &data
+}
+
 // Notification defines notification that is sent to the caller via the callback
 // function provided during the call to New and consists of a notification type
 // as well as associated data that depends on the type as follows:
 // 	- NTBlockAccepted:     *btcutil.Block
 // 	- NTBlockConnected:    *btcutil.Block
 // 	- NTBlockDisconnected: *btcutil.Block
+//
+// NTBlockConnected notifications also carry the spend data of the block for
+// subscribers which requested it with SubscribeOptions.IncludeSpendData.
 type Notification struct {
-	Type NotificationType
-	Data interface{}
+	Type      NotificationType
+	Data      interface{}
+	SpendData *BlockSpendData
+}
+
+// SubscribeOptions houses the options of a subscription to block chain
+// notifications.
+type SubscribeOptions struct {
+	// IncludeSpendData requests NTBlockConnected notifications to carry
+	// the fees and spent outputs of the transactions in the connected
+	// block.
+	IncludeSpendData bool
+}
+
+// subscription houses a callback subscribed to block chain notifications
+// along with its options.
+type subscription struct {
+	callback NotificationCallback
+	opts     SubscribeOptions
 }
 
 // Subscribe to block chain notifications. Registers a callback to be executed
 // when various events take place. See the documentation on Notification and
 // NotificationType for details on the types and contents of notifications.
 func (b *BlockChain) Subscribe(callback NotificationCallback) {
+	b.SubscribeWithOptions(callback, SubscribeOptions{})
+}
+
+// SubscribeWithOptions subscribes to block chain notifications like Subscribe
+// with the passed options.
+func (b *BlockChain) SubscribeWithOptions(callback NotificationCallback,
+	opts SubscribeOptions) {
+
 	b.notificationsLock.Lock()
-	b.notifications = append(b.notifications, callback)
+	b.notifications = append(b.notifications, subscription{
+		callback: callback,
+		opts:     opts,
+	})
 	b.notificationsLock.Unlock()
 }
 
@@ -74,8 +168,35 @@ func (b *BlockChain) sendNotification(typ NotificationType, data interface{}) {
 	// Generate and send the notification.
 	n := Notification{Type: typ, Data: data}
 	b.notificationsLock.RLock()
-	for _, callback := range b.notifications {
-		callback(&n)
+	for _, sub := range b.notifications {
+		sub.callback(&n)
+	}
+	b.notificationsLock.RUnlock()
+}
+
+// sendBlockConnectedNotification sends a NTBlockConnected notification for the
+// passed block, which spends the passed outputs.  The spend data of the block
+// is only computed when a subscriber requested it.
+func (b *BlockChain) sendBlockConnectedNotification(block *btcutil.Block,
+	stxos []SpentTxOut) {
+
+	n := Notification{Type: NTBlockConnected, Data: block}
+	var spendData *BlockSpendData
+	b.notificationsLock.RLock()
+	for _, sub := range b.notifications {
+		if !sub.opts.IncludeSpendData {
+			sub.callback(&n)
+			continue
+		}
+
+		if spendData == nil {
+			spendData = newBlockSpendData(block, stxos)
+		}
+		sub.callback(&Notification{
+			Type:      NTBlockConnected,
+			Data:      block,
+			SpendData: spendData,
+		})
 	}
 	b.notificationsLock.RUnlock()
 }
@@ -5,9 +5,12 @@
 package blockchain
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
 )
 
 // TestNotifications ensures that notification callbacks are fired on events.
@@ -49,3 +52,104 @@ func TestNotifications(t *testing.T) {
 			"times, found %d", numSubscribers, notificationCount)
 	}
 }
+
+// TestBlockConnectedSpendData ensures the spend data of connected blocks is
+// only included in the notifications of subscribers which requested it.
+func TestBlockConnectedSpendData(t *testing.T) {
+	blocks, err := loadBlocks("blk_0_to_4.dat.bz2")
+	if err != nil {
+		t.Fatalf("Error loading file: %v\n", err)
+	}
+
+	chain, teardownFunc, err := chainSetup("spenddatanotifications",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	var plain, withSpendData *Notification
+	chain.Subscribe(func(n *Notification) {
+		if n.Type == NTBlockConnected {
+			plain = n
+		}
+	})
+	chain.SubscribeWithOptions(func(n *Notification) {
+		if n.Type == NTBlockConnected {
+			withSpendData = n
+		}
+	}, SubscribeOptions{IncludeSpendData: true})
+
+	_, _, err = chain.ProcessBlock(blocks[1], BFNone)
+	if err != nil {
+		t.Fatalf("ProcessBlock fail on block 1: %v\n", err)
+	}
+
+	if plain == nil || plain.SpendData != nil {
+		t.Fatalf("unexpected notification without spend data: %v",
+			plain)
+	}
+	if withSpendData == nil || withSpendData.SpendData == nil ||
+		len(withSpendData.SpendData.Txns) != 1 {
+
+		t.Fatalf("unexpected notification with spend data: %v",
+			withSpendData)
+	}
+}
+
+// TestNewBlockSpendData ensures the spend data of a block is derived from the
+// outputs it spends as expected.
+func TestNewBlockSpendData(t *testing.T) {
+	coinbase := wire.NewMsgTx(1)
+	coinbase.AddTxIn(&wire.TxIn{})
+	coinbase.AddTxOut(wire.NewTxOut(5000000000, []byte{0x51}))
+
+	tx1 := wire.NewMsgTx(1)
+	tx1.AddTxIn(&wire.TxIn{})
+	tx1.AddTxIn(&wire.TxIn{})
+	tx1.AddTxOut(wire.NewTxOut(2500, []byte{0x51}))
+
+	tx2 := wire.NewMsgTx(1)
+	tx2.AddTxIn(&wire.TxIn{})
+	tx2.AddTxOut(wire.NewTxOut(900, []byte{0x51}))
+	tx2.AddTxOut(wire.NewTxOut(50, []byte{0x51}))
+
+	block := btcutil.NewBlock(&wire.MsgBlock{
+		Transactions: []*wire.MsgTx{coinbase, tx1, tx2},
+	})
+	stxos := []SpentTxOut{
+		{Amount: 1000, PkScript: []byte{0x01}},
+		{Amount: 2000, PkScript: []byte{0x02}},
+		{Amount: 1000, PkScript: []byte{0x03}},
+	}
+
+	data := newBlockSpendData(block, stxos)
+	if len(data.Txns) != 3 {
+		t.Fatalf("unexpected number of transactions: %d",
+			len(data.Txns))
+	}
+	if data.Txns[0].Fee != 0 || data.Txns[0].PrevOuts != nil {
+		t.Fatalf("unexpected coinbase spend data: %+v", data.Txns[0])
+	}
+
+	want := []TxSpendData{{
+		Fee:     500,
+		TotalIn: 3000,
+		PrevOuts: []*wire.TxOut{
+			wire.NewTxOut(1000, []byte{0x01}),
+			wire.NewTxOut(2000, []byte{0x02}),
+		},
+	}, {
+		Fee:      50,
+		TotalIn:  1000,
+		PrevOuts: []*wire.TxOut{wire.NewTxOut(1000, []byte{0x03})},
+	}}
+	if !reflect.DeepEqual(data.Txns[1:], want) {
+		t.Fatalf("unexpected spend data: got %+v, want %+v",
+			data.Txns[1:], want)
+	}
+	if data.TotalFees != 550 {
+		t.Fatalf("unexpected total fees: got %d, want 550",
+			data.TotalFees)
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"container/list"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// maxOrphanBlocks is the maximum number of orphan blocks that can be
+	// queued.
+	maxOrphanBlocks = 100
+
+	// maxOrphanBlocksSize is the maximum total serialized size of the
+	// queued orphan blocks.  Together with maxOrphanBlocks it bounds the
+	// memory peers can make the node spend on blocks which don't connect
+	// to the block chain.
+	maxOrphanBlocksSize = 32 * 1024 * 1024
+
+	// orphanExpiration is the time after which an orphan block is removed
+	// from the orphan pool when its parent didn't arrive in the meantime.
+	orphanExpiration = time.Hour
+)
+
+// orphanBlock represents a block that we don't yet have the parent for.  It
+// is a normal block plus an expiration time to prevent caching the orphan
+// forever.
+type orphanBlock struct {
+	block      *btcutil.Block
+	size       int64
+	expiration time.Time
+	element    *list.Element
+}
+
+// OrphanStats houses statistics about the orphan blocks held by the block
+// chain, which allow monitoring how much memory orphan blocks take and how
+// many of them are dropped before their parents arrive.
+type OrphanStats struct {
+	// Count and Size are the number and the total serialized size of the
+	// orphan blocks currently held.
+	Count int
+	Size  int64
+
+	// Added is the total number of orphan blocks which were added.
+	Added uint64
+
+	// Processed is the total number of orphan blocks which were removed
+	// to be processed once their parents arrived.
+	Processed uint64
+
+	// Evicted is the total number of orphan blocks which were removed to
+	// make room for new ones.
+	Evicted uint64
+
+	// Expired is the total number of orphan blocks which were removed
+	// since their parents didn't arrive in time.
+	Expired uint64
+}
+
+// orphanPool houses the orphan blocks of the block chain.  The pool is bounded
+// by both the number and the total size of the orphans, and the oldest orphans
+// are evicted first to make room for new ones.  Since all orphans expire after
+// the same duration, the oldest orphans are also the first to expire.
+//
+// The orphan pool is not safe for concurrent access.
+type orphanPool struct {
+	orphans     map[chainhash.Hash]*orphanBlock
+	prevOrphans map[chainhash.Hash][]*orphanBlock
+	order       *list.List
+	stats       OrphanStats
+}
+
+// newOrphanPool returns a new empty orphan pool.
+func newOrphanPool() *orphanPool {
+	return &orphanPool{
+		orphans:     make(map[chainhash.Hash]*orphanBlock),
+		prevOrphans: make(map[chainhash.Hash][]*orphanBlock),
+		order:       list.New(),
+	}
+}
+
+// has returns whether the block with the passed hash is in the orphan pool.
+func (p *orphanPool) has(hash *chainhash.Hash) bool {
+	_, exists := p.orphans[*hash]
+	return exists
+}
+
+// root returns the hash of the first orphan of the chain of orphans leading to
+// the block with the passed hash.  The passed hash is returned when the block
+// is not an orphan.
+func (p *orphanPool) root(hash *chainhash.Hash) *chainhash.Hash {
+	// Keep looping while the parent of each orphaned block is
+	// known and is an orphan itself.
+	orphanRoot := hash
+	prevHash := hash
+	for {
+		orphan, exists := p.orphans[*prevHash]
+		if !exists {
+			break
+		}
+		orphanRoot = prevHash
+		prevHash = &orphan.block.MsgBlock().Header.PrevBlock
+	}
+	return orphanRoot
+}
+
+// children returns the orphans which are children of the block with the passed
+// hash.  The returned slice must not be modified.
+func (p *orphanPool) children(hash *chainhash.Hash) []*orphanBlock {
+	return p.prevOrphans[*hash]
+}
+
+// remove removes the passed orphan block from the orphan pool and previous
+// orphan index.
+func (p *orphanPool) remove(orphan *orphanBlock) {
+	// Remove the orphan block from the orphan pool.
+	orphanHash := orphan.block.Hash()
+	delete(p.orphans, *orphanHash)
+	p.order.Remove(orphan.element)
+	p.stats.Count--
+	p.stats.Size -= orphan.size
+
+	// Remove the reference from the previous orphan index too.  An indexing
+	// for loop is intentionally used over a range here as range does not
+	// reevaluate the slice on each iteration nor does it adjust the index
+	// for the modified slice.
+	prevHash := &orphan.block.MsgBlock().Header.PrevBlock
+	orphans := p.prevOrphans[*prevHash]
+	for i := 0; i < len(orphans); i++ {
+		hash := orphans[i].block.Hash()
+		if hash.IsEqual(orphanHash) {
+			copy(orphans[i:], orphans[i+1:])
+			orphans[len(orphans)-1] = nil
+			orphans = orphans[:len(orphans)-1]
+			i--
+		}
+	}
+	p.prevOrphans[*prevHash] = orphans
+
+	// Remove the map entry altogether if there are no longer any orphans
+	// which depend on the parent hash.
+	if len(p.prevOrphans[*prevHash]) == 0 {
+		delete(p.prevOrphans, *prevHash)
+	}
+}
+
+// removeForProcessing removes the passed orphan block from the orphan pool
+// once its parent arrived so it can be processed.
+func (p *orphanPool) removeForProcessing(orphan *orphanBlock) {
+	p.remove(orphan)
+	p.stats.Processed++
+}
+
+// removeExpired removes the orphan blocks which expired before the passed
+// time.
+func (p *orphanPool) removeExpired(now time.Time) {
+	for e := p.order.Front(); e != nil; e = p.order.Front() {
+		orphan := e.Value.(*orphanBlock)
+		if !now.After(orphan.expiration) {
+			return
+		}
+		p.remove(orphan)
+		p.stats.Expired++
+	}
+}
+
+// add adds the passed block (which is already determined to be an orphan prior
+// calling this function) to the orphan pool.  It lazily cleans up any expired
+// blocks so a separate cleanup poller doesn't need to be run.  It also evicts
+// the oldest orphan blocks while the limits on the number and the total size of
+// the orphan blocks would be exceeded otherwise.
+func (p *orphanPool) add(block *btcutil.Block, now time.Time) {
+	p.removeExpired(now)
+
+	size := int64(block.MsgBlock().SerializeSize())
+	for p.order.Len() > 0 && (p.stats.Count+1 > maxOrphanBlocks ||
+		p.stats.Size+size > maxOrphanBlocksSize) {
+
+		p.remove(p.order.Front().Value.(*orphanBlock))
+		p.stats.Evicted++
+	}
+
+	orphan := &orphanBlock{
+		block:      block,
+		size:       size,
+		expiration: now.Add(orphanExpiration),
+	}
+	orphan.element = p.order.PushBack(orphan)
+	p.orphans[*block.Hash()] = orphan
+	p.stats.Count++
+	p.stats.Size += size
+	p.stats.Added++
+
+	// Add to previous hash lookup index for faster dependency lookups.
+	prevHash := &block.MsgBlock().Header.PrevBlock
+	p.prevOrphans[*prevHash] = append(p.prevOrphans[*prevHash], orphan)
+}
+
+// OrphanStats returns statistics about the orphan blocks held by the block
+// chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) OrphanStats() OrphanStats {
+	b.orphanLock.RLock()
+	stats := b.orphans.stats
+	b.orphanLock.RUnlock()
+	return stats
+}
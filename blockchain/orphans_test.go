@@ -0,0 +1,111 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// newOrphanTestBlock returns a block with the passed parent whose hash is made
+// unique by the passed nonce.  The block is padded with a coinbase signature
+// script of the passed size to control the serialized size of the block.
+func newOrphanTestBlock(prevHash *chainhash.Hash, nonce uint32, padding int) *btcutil.Block {
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{},
+			wire.MaxPrevOutIndex),
+		SignatureScript: make([]byte, padding),
+		Sequence:        wire.MaxTxInSequenceNum,
+	})
+	coinbase.AddTxOut(wire.NewTxOut(0, nil))
+
+	header := wire.BlockHeader{PrevBlock: *prevHash, Nonce: nonce}
+	msgBlock := wire.NewMsgBlock(&header)
+	msgBlock.AddTransaction(coinbase)
+	return btcutil.NewBlock(msgBlock)
+}
+
+// TestOrphanPool ensures the orphan pool tracks the orphans and their parents,
+// stays within its limits by evicting the oldest orphans and removes expired
+// orphans.
+func TestOrphanPool(t *testing.T) {
+	pool := newOrphanPool()
+	now := time.Unix(1600000000, 0)
+
+	// Build a chain of orphans and make sure their root is found.
+	parent := chainhash.Hash{0x01}
+	first := newOrphanTestBlock(&parent, 0, 0)
+	second := newOrphanTestBlock(first.Hash(), 1, 0)
+	pool.add(first, now)
+	pool.add(second, now)
+	if !pool.has(first.Hash()) || !pool.has(second.Hash()) {
+		t.Fatal("orphans are not in the pool")
+	}
+	if root := pool.root(second.Hash()); *root != *first.Hash() {
+		t.Fatalf("unexpected orphan root %v, want %v", root,
+			first.Hash())
+	}
+	children := pool.children(&parent)
+	if len(children) != 1 || children[0].block != first {
+		t.Fatalf("unexpected children of %v: %v", parent, children)
+	}
+
+	// Processing an orphan must remove it from the pool and the previous
+	// orphan index.
+	pool.removeForProcessing(children[0])
+	if pool.has(first.Hash()) || len(pool.children(&parent)) != 0 {
+		t.Fatal("processed orphan is still in the pool")
+	}
+	wantSize := int64(second.MsgBlock().SerializeSize())
+	if pool.stats.Count != 1 || pool.stats.Size != wantSize ||
+		pool.stats.Processed != 1 {
+
+		t.Fatalf("unexpected stats %+v", pool.stats)
+	}
+
+	// Fill the pool beyond the number limit and make sure the oldest
+	// orphans are evicted first.
+	for i := uint32(0); i < maxOrphanBlocks; i++ {
+		pool.add(newOrphanTestBlock(&parent, i+2, 0), now)
+	}
+	if pool.has(second.Hash()) {
+		t.Fatal("oldest orphan was not evicted")
+	}
+	if pool.stats.Count != maxOrphanBlocks || pool.stats.Evicted != 1 {
+		t.Fatalf("unexpected stats %+v", pool.stats)
+	}
+
+	// A large orphan must evict as many orphans as needed to stay within
+	// the size limit.
+	large := newOrphanTestBlock(&parent, 0, maxOrphanBlocksSize-1000)
+	pool.add(large, now)
+	if pool.stats.Size > maxOrphanBlocksSize {
+		t.Fatalf("orphan pool size %d exceeds limit %d",
+			pool.stats.Size, maxOrphanBlocksSize)
+	}
+	if !pool.has(large.Hash()) || pool.stats.Evicted <= 2 {
+		t.Fatalf("unexpected stats %+v", pool.stats)
+	}
+
+	// Adding an orphan after the others expired must remove them.
+	count := pool.stats.Count
+	later := newOrphanTestBlock(&parent, 1, 0)
+	pool.add(later, now.Add(orphanExpiration+time.Second))
+	if pool.stats.Count != 1 || !pool.has(later.Hash()) {
+		t.Fatalf("expired orphans were not removed: %+v", pool.stats)
+	}
+	if pool.stats.Expired != uint64(count) {
+		t.Fatalf("unexpected number of expired orphans %d, want %d",
+			pool.stats.Expired, count)
+	}
+	if len(pool.prevOrphans) != 1 || pool.order.Len() != 1 {
+		t.Fatal("orphan pool indexes were not cleaned up")
+	}
+}
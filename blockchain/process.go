@@ -100,8 +100,8 @@ func (b *BlockChain) processOrphans(hash *chainhash.Hash, flags BehaviorFlags) e
 		// intentionally used over a range here as range does not
 		// reevaluate the slice on each iteration nor does it adjust the
 		// index for the modified slice.
-		for i := 0; i < len(b.prevOrphans[*processHash]); i++ {
-			orphan := b.prevOrphans[*processHash][i]
+		for i := 0; i < len(b.orphans.children(processHash)); i++ {
+			orphan := b.orphans.children(processHash)[i]
 			if orphan == nil {
 				log.Warnf("Found a nil entry at index %d in the "+
 					"orphan dependency list for block %v", i,
@@ -111,7 +111,9 @@ func (b *BlockChain) processOrphans(hash *chainhash.Hash, flags BehaviorFlags) e
 
 			// Remove the orphan from the orphan pool.
 			orphanHash := orphan.block.Hash()
-			b.removeOrphanBlock(orphan)
+			b.orphanLock.Lock()
+			b.orphans.removeForProcessing(orphan)
+			b.orphanLock.Unlock()
 			i--
 
 			// Potentially accept the block into the block chain.
@@ -159,7 +161,7 @@ func (b *BlockChain) ProcessBlock(block *btcutil.Block, flags BehaviorFlags) (bo
 	}
 
 	// The block must not already exist as an orphan.
-	if _, exists := b.orphans[*blockHash]; exists {
+	if b.IsKnownOrphan(blockHash) {
 		str := fmt.Sprintf("already have block (orphan) %v", blockHash)
 		return false, false, ruleError(ErrDuplicateBlock, str)
 	}
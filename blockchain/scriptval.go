@@ -5,9 +5,11 @@
 package blockchain
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"runtime"
+	"sync"
 	"time"
 
 	"github.com/btcsuite/btcd/txscript"
@@ -15,6 +17,34 @@ import (
 	"github.com/btcsuite/btcutil"
 )
 
+// ValidationPriority identifies the lane of a script validation pool the
+// scripts to validate are queued in.
+type ValidationPriority uint8
+
+const (
+	// BlockPriority is the lane used to validate the scripts of blocks.
+	// The queued scripts of this lane are always validated first.
+	BlockPriority ValidationPriority = iota
+
+	// MempoolPriority is the lane used to validate the scripts of
+	// transactions which are not part of a block.
+	MempoolPriority
+
+	// numValidationPriorities is the number of validation priorities.
+	numValidationPriorities
+)
+
+var (
+	// errScriptPoolStopped is returned when scripts are validated with a
+	// script validation pool which was stopped.
+	errScriptPoolStopped = errors.New("script validation pool stopped")
+
+	// defaultScriptPool is the script validation pool used when none is
+	// configured.  It is started on first use by defaultScriptPoolOnce.
+	defaultScriptPool     *ScriptValidationPool
+	defaultScriptPoolOnce sync.Once
+)
+
 // txValidateItem holds a transaction along with which input to validate.
 type txValidateItem struct {
 	txInIndex int
@@ -23,17 +53,118 @@ type txValidateItem struct {
 	sigHashes *txscript.TxSigHashes
 }
 
+// scriptJob is a transaction input queued in a script validation pool along
+// with the validator it belongs to.
+type scriptJob struct {
+	item      *txValidateItem
+	validator *txValidator
+}
+
+// ScriptValidationPool is a persistent pool of goroutines which validate
+// transaction scripts.  A single pool is meant to be shared by block validation
+// and mempool acceptance.  The scripts queued in the lane of a higher priority
+// are always validated first, so validating a block pre-empts validating
+// transactions for the mempool.
+type ScriptValidationPool struct {
+	lanes    [numValidationPriorities]chan scriptJob
+	quit     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewScriptValidationPool returns a new script validation pool which validates
+// scripts using the passed number of goroutines.  A number of zero or less uses
+// three goroutines per processor core.
+//
+// The pool must be stopped with Stop once it is no longer needed.
+func NewScriptValidationPool(numWorkers int) *ScriptValidationPool {
+	// Limit the number of goroutines to do script validation based on the
+	// number of processor cores by default.  This helps ensure the system
+	// stays reasonably responsive under heavy load.
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU() * 3
+	}
+
+	p := &ScriptValidationPool{
+		quit: make(chan struct{}),
+	}
+	for i := range p.lanes {
+		p.lanes[i] = make(chan scriptJob)
+	}
+	p.wg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go p.worker()
+	}
+	return p
+}
+
+// defaultScriptValidationPool returns the script validation pool used when
+// none is configured.
+func defaultScriptValidationPool() *ScriptValidationPool {
+	defaultScriptPoolOnce.Do(func() {
+		defaultScriptPool = NewScriptValidationPool(0)
+	})
+	return defaultScriptPool
+}
+
+// worker validates the scripts queued in the lanes of the pool until the pool
+// is stopped.  The lanes are checked in order of their priority before waiting
+// for work in any of them.  It must be run as a goroutine.
+func (p *ScriptValidationPool) worker() {
+	defer p.wg.Done()
+
+out:
+	for {
+		for i := range p.lanes {
+			select {
+			case job := <-p.lanes[i]:
+				job.validator.validateHandler(job.item)
+				continue out
+			default:
+			}
+		}
+
+		select {
+		case job := <-p.lanes[BlockPriority]:
+			job.validator.validateHandler(job.item)
+		case job := <-p.lanes[MempoolPriority]:
+			job.validator.validateHandler(job.item)
+		case <-p.quit:
+			break out
+		}
+	}
+}
+
+// Stop stops the goroutines of the pool and waits for them to finish.  Scripts
+// validated with a stopped pool fail validation.
+func (p *ScriptValidationPool) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.quit)
+	})
+	p.wg.Wait()
+}
+
+// ValidateTransactionScripts validates the scripts for the passed transaction
+// in the mempool lane of the pool.
+func (p *ScriptValidationPool) ValidateTransactionScripts(tx *btcutil.Tx,
+	utxoView *UtxoViewpoint, flags txscript.ScriptFlags,
+	sigCache txscript.SignatureCache, hashCache *txscript.HashCache) error {
+
+	return validateTransactionScripts(tx, utxoView, flags, sigCache,
+		hashCache, p, MempoolPriority)
+}
+
 // txValidator provides a type which asynchronously validates transaction
-// inputs.  It provides several channels for communication and a processing
-// function that is intended to be in run multiple goroutines.
+// inputs using the goroutines of a script validation pool.
 type txValidator struct {
-	validateChan chan *txValidateItem
-	quitChan     chan struct{}
-	resultChan   chan error
-	utxoView     *UtxoViewpoint
-	flags        txscript.ScriptFlags
-	sigCache     *txscript.SigCache
-	hashCache    *txscript.HashCache
+	quitChan   chan struct{}
+	resultChan chan error
+	utxoView   *UtxoViewpoint
+	flags      txscript.ScriptFlags
+	sigCache   txscript.SignatureCache
+	hashCache  *txscript.HashCache
+	pool       *ScriptValidationPool
+	priority   ValidationPriority
 }
 
 // sendResult sends the result of a script pair validation on the internal
@@ -47,115 +178,93 @@ func (v *txValidator) sendResult(result error) {
 	}
 }
 
-// validateHandler consumes items to validate from the internal validate channel
-// and returns the result of the validation on the internal result channel. It
-// must be run as a goroutine.
-func (v *txValidator) validateHandler() {
-out:
-	for {
-		select {
-		case txVI := <-v.validateChan:
-			// Ensure the referenced input utxo is available.
-			txIn := txVI.txIn
-			utxo := v.utxoView.LookupEntry(txIn.PreviousOutPoint)
-			if utxo == nil {
-				str := fmt.Sprintf("unable to find unspent "+
-					"output %v referenced from "+
-					"transaction %s:%d",
-					txIn.PreviousOutPoint, txVI.tx.Hash(),
-					txVI.txInIndex)
-				err := ruleError(ErrMissingTxOut, str)
-				v.sendResult(err)
-				break out
-			}
-
-			// Create a new script engine for the script pair.
-			sigScript := txIn.SignatureScript
-			witness := txIn.Witness
-			pkScript := utxo.PkScript()
-			inputAmount := utxo.Amount()
-			vm, err := txscript.NewEngine(pkScript, txVI.tx.MsgTx(),
-				txVI.txInIndex, v.flags, v.sigCache, txVI.sigHashes,
-				inputAmount)
-			if err != nil {
-				str := fmt.Sprintf("failed to parse input "+
-					"%s:%d which references output %v - "+
-					"%v (input witness %x, input script "+
-					"bytes %x, prev output script bytes %x)",
-					txVI.tx.Hash(), txVI.txInIndex,
-					txIn.PreviousOutPoint, err, witness,
-					sigScript, pkScript)
-				err := ruleError(ErrScriptMalformed, str)
-				v.sendResult(err)
-				break out
-			}
+// validateHandler validates the passed item and returns the result of the
+// validation on the internal result channel.  It is run by the goroutines of
+// the script validation pool.
+func (v *txValidator) validateHandler(txVI *txValidateItem) {
+	// Skip the items of validations which were already aborted due to a
+	// validation error of another item.
+	select {
+	case <-v.quitChan:
+		return
+	default:
+	}
 
-			// Execute the script pair.
-			if err := vm.Execute(); err != nil {
-				str := fmt.Sprintf("failed to validate input "+
-					"%s:%d which references output %v - "+
-					"%v (input witness %x, input script "+
-					"bytes %x, prev output script bytes %x)",
-					txVI.tx.Hash(), txVI.txInIndex,
-					txIn.PreviousOutPoint, err, witness,
-					sigScript, pkScript)
-				err := ruleError(ErrScriptValidation, str)
-				v.sendResult(err)
-				break out
-			}
+	// Ensure the referenced input utxo is available.
+	txIn := txVI.txIn
+	utxo := v.utxoView.LookupEntry(txIn.PreviousOutPoint)
+	if utxo == nil {
+		str := fmt.Sprintf("unable to find unspent output %v "+
+			"referenced from transaction %s:%d",
+			txIn.PreviousOutPoint, txVI.tx.Hash(), txVI.txInIndex)
+		err := ruleError(ErrMissingTxOut, str)
+		v.sendResult(err)
+		return
+	}
 
-			// Validation succeeded.
-			v.sendResult(nil)
+	// Create a new script engine for the script pair.
+	sigScript := txIn.SignatureScript
+	witness := txIn.Witness
+	pkScript := utxo.PkScript()
+	inputAmount := utxo.Amount()
+	vm, err := txscript.NewEngine(pkScript, txVI.tx.MsgTx(),
+		txVI.txInIndex, v.flags, v.sigCache, txVI.sigHashes,
+		inputAmount)
+	if err != nil {
+		str := fmt.Sprintf("failed to parse input %s:%d which "+
+			"references output %v - %v (input witness %x, input "+
+			"script bytes %x, prev output script bytes %x)",
+			txVI.tx.Hash(), txVI.txInIndex, txIn.PreviousOutPoint,
+			err, witness, sigScript, pkScript)
+		err := ruleError(ErrScriptMalformed, str)
+		v.sendResult(err)
+		return
+	}
 
-		case <-v.quitChan:
-			break out
-		}
+	// Execute the script pair.
+	if err := vm.Execute(); err != nil {
+		str := fmt.Sprintf("failed to validate input %s:%d which "+
+			"references output %v - %v (input witness %x, input "+
+			"script bytes %x, prev output script bytes %x)",
+			txVI.tx.Hash(), txVI.txInIndex, txIn.PreviousOutPoint,
+			err, witness, sigScript, pkScript)
+		err := ruleError(ErrScriptValidation, str)
+		v.sendResult(err)
+		return
 	}
+
+	// Validation succeeded.
+	v.sendResult(nil)
 }
 
-// Validate validates the scripts for all of the passed transaction inputs using
-// multiple goroutines.
+// Validate validates the scripts for all of the passed transaction inputs by
+// queueing them in the lane of the script validation pool for the priority of
+// the validator.
 func (v *txValidator) Validate(items []*txValidateItem) error {
 	if len(items) == 0 {
 		return nil
 	}
 
-	// Limit the number of goroutines to do script validation based on the
-	// number of processor cores.  This helps ensure the system stays
-	// reasonably responsive under heavy load.
-	maxGoRoutines := runtime.NumCPU() * 3
-	if maxGoRoutines <= 0 {
-		maxGoRoutines = 1
-	}
-	if maxGoRoutines > len(items) {
-		maxGoRoutines = len(items)
-	}
-
-	// Start up validation handlers that are used to asynchronously
-	// validate each transaction input.
-	for i := 0; i < maxGoRoutines; i++ {
-		go v.validateHandler()
-	}
-
 	// Validate each of the inputs.  The quit channel is closed when any
-	// errors occur so all processing goroutines exit regardless of which
-	// input had the validation error.
+	// errors occur so the pool skips the remaining inputs regardless of
+	// which input had the validation error.
 	numInputs := len(items)
 	currentItem := 0
 	processedItems := 0
+	lane := v.pool.lanes[v.priority]
 	for processedItems < numInputs {
 		// Only send items while there are still items that need to
 		// be processed.  The select statement will never select a nil
 		// channel.
-		var validateChan chan *txValidateItem
-		var item *txValidateItem
+		var validateChan chan scriptJob
+		var job scriptJob
 		if currentItem < numInputs {
-			validateChan = v.validateChan
-			item = items[currentItem]
+			validateChan = lane
+			job = scriptJob{item: items[currentItem], validator: v}
 		}
 
 		select {
-		case validateChan <- item:
+		case validateChan <- job:
 			currentItem++
 
 		case err := <-v.resultChan:
@@ -164,6 +273,10 @@ func (v *txValidator) Validate(items []*txValidateItem) error {
 				close(v.quitChan)
 				return err
 			}
+
+		case <-v.pool.quit:
+			close(v.quitChan)
+			return errScriptPoolStopped
 		}
 	}
 
@@ -172,26 +285,41 @@ func (v *txValidator) Validate(items []*txValidateItem) error {
 }
 
 // newTxValidator returns a new instance of txValidator to be used for
-// validating transaction scripts asynchronously.
+// validating transaction scripts asynchronously in the lane of the passed
+// script validation pool for the passed priority.
 func newTxValidator(utxoView *UtxoViewpoint, flags txscript.ScriptFlags,
-	sigCache *txscript.SigCache, hashCache *txscript.HashCache) *txValidator {
+	sigCache txscript.SignatureCache, hashCache *txscript.HashCache,
+	pool *ScriptValidationPool, priority ValidationPriority) *txValidator {
+
 	return &txValidator{
-		validateChan: make(chan *txValidateItem),
-		quitChan:     make(chan struct{}),
-		resultChan:   make(chan error),
-		utxoView:     utxoView,
-		sigCache:     sigCache,
-		hashCache:    hashCache,
-		flags:        flags,
+		quitChan:   make(chan struct{}),
+		resultChan: make(chan error),
+		utxoView:   utxoView,
+		sigCache:   sigCache,
+		hashCache:  hashCache,
+		flags:      flags,
+		pool:       pool,
+		priority:   priority,
 	}
 }
 
 // ValidateTransactionScripts validates the scripts for the passed transaction
-// using multiple goroutines.
+// in the mempool lane of the default script validation pool.
 func ValidateTransactionScripts(tx *btcutil.Tx, utxoView *UtxoViewpoint,
-	flags txscript.ScriptFlags, sigCache *txscript.SigCache,
+	flags txscript.ScriptFlags, sigCache txscript.SignatureCache,
 	hashCache *txscript.HashCache) error {
 
+	return validateTransactionScripts(tx, utxoView, flags, sigCache,
+		hashCache, defaultScriptValidationPool(), MempoolPriority)
+}
+
+// validateTransactionScripts validates the scripts for the passed transaction
+// in the lane of the passed script validation pool for the passed priority.
+func validateTransactionScripts(tx *btcutil.Tx, utxoView *UtxoViewpoint,
+	flags txscript.ScriptFlags, sigCache txscript.SignatureCache,
+	hashCache *txscript.HashCache, pool *ScriptValidationPool,
+	priority ValidationPriority) error {
+
 	// First determine if segwit is active according to the scriptFlags. If
 	// it isn't then we don't need to interact with the HashCache.
 	segwitActive := flags&txscript.ScriptVerifyWitness == txscript.ScriptVerifyWitness
@@ -234,15 +362,16 @@ func ValidateTransactionScripts(tx *btcutil.Tx, utxoView *UtxoViewpoint,
 	}
 
 	// Validate all of the inputs.
-	validator := newTxValidator(utxoView, flags, sigCache, hashCache)
+	validator := newTxValidator(utxoView, flags, sigCache, hashCache, pool,
+		priority)
 	return validator.Validate(txValItems)
 }
 
 // checkBlockScripts executes and validates the scripts for all transactions in
-// the passed block using multiple goroutines.
+// the passed block in the block lane of the passed script validation pool.
 func checkBlockScripts(block *btcutil.Block, utxoView *UtxoViewpoint,
-	scriptFlags txscript.ScriptFlags, sigCache *txscript.SigCache,
-	hashCache *txscript.HashCache) error {
+	scriptFlags txscript.ScriptFlags, sigCache txscript.SignatureCache,
+	hashCache *txscript.HashCache, pool *ScriptValidationPool) error {
 
 	// First determine if segwit is active according to the scriptFlags. If
 	// it isn't then we don't need to interact with the HashCache.
@@ -295,7 +424,8 @@ func checkBlockScripts(block *btcutil.Block, utxoView *UtxoViewpoint,
 	}
 
 	// Validate all of the inputs.
-	validator := newTxValidator(utxoView, scriptFlags, sigCache, hashCache)
+	validator := newTxValidator(utxoView, scriptFlags, sigCache, hashCache,
+		pool, BlockPriority)
 	start := time.Now()
 	if err := validator.Validate(txValItems); err != nil {
 		return err
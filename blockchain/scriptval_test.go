@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
 )
 
 // TestCheckBlockScripts ensures that validating the all of the scripts in a
@@ -38,9 +39,55 @@ func TestCheckBlockScripts(t *testing.T) {
 	}
 
 	scriptFlags := txscript.ScriptBip16
-	err = checkBlockScripts(blocks[0], view, scriptFlags, nil, nil)
+	err = checkBlockScripts(blocks[0], view, scriptFlags, nil, nil,
+		defaultScriptValidationPool())
 	if err != nil {
 		t.Errorf("Transaction script validation failed: %v\n", err)
 		return
 	}
 }
+
+// TestScriptValidationPool ensures a script validation pool validates scripts
+// queued in all of its lanes concurrently and fails validation once stopped.
+func TestScriptValidationPool(t *testing.T) {
+	testBlockNum := 277647
+	blocks, err := loadBlocks(fmt.Sprintf("%d.dat.bz2", testBlockNum))
+	if err != nil {
+		t.Fatalf("Error loading file: %v", err)
+	}
+	storeDataFile := fmt.Sprintf("%d.utxostore.bz2", testBlockNum)
+	view, err := loadUtxoView(storeDataFile)
+	if err != nil {
+		t.Fatalf("Error loading txstore: %v", err)
+	}
+	block := blocks[0]
+	scriptFlags := txscript.ScriptBip16
+
+	// Validate the block in the block lane while its transactions are
+	// validated in the mempool lane.
+	pool := NewScriptValidationPool(2)
+	results := make(chan error, len(block.Transactions()))
+	for _, tx := range block.Transactions()[1:] {
+		go func(tx *btcutil.Tx) {
+			results <- pool.ValidateTransactionScripts(tx, view,
+				scriptFlags, nil, nil)
+		}(tx)
+	}
+	err = checkBlockScripts(block, view, scriptFlags, nil, nil, pool)
+	if err != nil {
+		t.Fatalf("checkBlockScripts: %v", err)
+	}
+	for range block.Transactions()[1:] {
+		if err := <-results; err != nil {
+			t.Fatalf("ValidateTransactionScripts: %v", err)
+		}
+	}
+
+	// Scripts can't be validated once the pool is stopped.
+	pool.Stop()
+	err = checkBlockScripts(block, view, scriptFlags, nil, nil, pool)
+	if err != errScriptPoolStopped {
+		t.Fatalf("unexpected error with stopped pool -- got %v, want %v",
+			err, errScriptPoolStopped)
+	}
+}
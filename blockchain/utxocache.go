@@ -0,0 +1,361 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/database"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// utxoCacheEntryOverhead is the approximate number of bytes a cached
+	// utxo entry uses in addition to its public key script.  It accounts
+	// for the outpoint (36 bytes), the pointer to the entry (8 bytes), the
+	// entry itself (40 bytes) and the overhead of the map (16 bytes).
+	utxoCacheEntryOverhead = 36 + 8 + 40 + 16
+)
+
+var (
+	// utxoStateConsistencyKeyName is the name of the db key used to store
+	// the hash of the block the utxo set in the database is consistent
+	// with.  A missing key means the utxo set is consistent with the best
+	// chain since the database was never used with a utxo cache.
+	utxoStateConsistencyKeyName = []byte("utxostateconsistency")
+)
+
+// dbPutUtxoStateConsistency uses an existing database transaction to store the
+// hash of the block the utxo set in the database is consistent with.
+func dbPutUtxoStateConsistency(dbTx database.Tx, hash *chainhash.Hash) error {
+	return dbTx.Metadata().Put(utxoStateConsistencyKeyName, hash[:])
+}
+
+// dbFetchUtxoStateConsistency uses an existing database transaction to fetch
+// the hash of the block the utxo set in the database is consistent with.  It
+// returns nil when the utxo set is consistent with the best chain.
+func dbFetchUtxoStateConsistency(dbTx database.Tx) (*chainhash.Hash, error) {
+	serialized := dbTx.Metadata().Get(utxoStateConsistencyKeyName)
+	if serialized == nil {
+		return nil, nil
+	}
+	if len(serialized) != chainhash.HashSize {
+		return nil, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt utxo state consistency hash",
+		}
+	}
+
+	var hash chainhash.Hash
+	copy(hash[:], serialized)
+	return &hash, nil
+}
+
+// utxoCache caches the utxo set between the utxo views used to connect blocks
+// and the utxo set stored in the database.  The changes to the utxo set made by
+// connected blocks are kept in memory and only written to the database in
+// batches once the cache exceeds its memory budget, the flush interval elapsed,
+// or the database must reflect the best chain such as before blocks are
+// disconnected.
+//
+// The best chain state is written to the database with every block while the
+// utxo set lags behind, so every flush also stores the hash of the block the
+// utxo set in the database is consistent with.  The blocks after it are
+// connected to the utxo set again on start up when the cache was not flushed
+// before shutting down.
+type utxoCache struct {
+	db            database.DB
+	maxSize       uint64
+	flushInterval time.Duration
+
+	// mtx protects the fields below.  The cache is only modified and
+	// flushed with the chain lock held for writes, but entries loaded from
+	// the database are also added to it by readers which only hold the
+	// chain lock for reads.
+	mtx       sync.Mutex
+	entries   map[wire.OutPoint]*UtxoEntry
+	totalSize uint64
+	lastFlush time.Time
+}
+
+// newUtxoCache returns a new empty utxo cache for the passed database which is
+// flushed once it uses more than maxSize bytes or flushInterval elapsed.
+func newUtxoCache(db database.DB, maxSize uint64,
+	flushInterval time.Duration) *utxoCache {
+
+	return &utxoCache{
+		db:            db,
+		maxSize:       maxSize,
+		flushInterval: flushInterval,
+		entries:       make(map[wire.OutPoint]*UtxoEntry),
+		lastFlush:     time.Now(),
+	}
+}
+
+// cachedEntrySize returns the approximate number of bytes the passed entry uses
+// in the cache.
+func cachedEntrySize(entry *UtxoEntry) uint64 {
+	return utxoCacheEntryOverhead + uint64(len(entry.pkScript))
+}
+
+// fetchEntries returns the utxo entries for the passed outpoints from the point
+// of view of the end of the main chain.  Entries which are not cached yet are
+// loaded from the database and added to the cache.  Spent outputs, or those
+// which otherwise don't exist, result in nil entries.
+//
+// The returned entries are copies of the cached ones, so they may be modified
+// freely.
+func (c *utxoCache) fetchEntries(outpoints map[wire.OutPoint]struct{}) (map[wire.OutPoint]*UtxoEntry, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	entries := make(map[wire.OutPoint]*UtxoEntry, len(outpoints))
+	var missing []wire.OutPoint
+	for outpoint := range outpoints {
+		cached, ok := c.entries[outpoint]
+		if !ok {
+			missing = append(missing, outpoint)
+			continue
+		}
+		if cached.IsSpent() {
+			entries[outpoint] = nil
+			continue
+		}
+
+		entries[outpoint] = &UtxoEntry{
+			amount:      cached.amount,
+			pkScript:    cached.pkScript,
+			blockHeight: cached.blockHeight,
+			packedFlags: cached.packedFlags & tfCoinBase,
+		}
+	}
+	if len(missing) == 0 {
+		return entries, nil
+	}
+
+	err := c.db.View(func(dbTx database.Tx) error {
+		for _, outpoint := range missing {
+			entry, err := dbFetchUtxoEntry(dbTx, outpoint)
+			if err != nil {
+				return err
+			}
+			entries[outpoint] = entry
+			if entry == nil {
+				continue
+			}
+
+			cached := entry.Clone()
+			c.entries[outpoint] = cached
+			c.totalSize += cachedEntrySize(cached)
+		}
+
+		return nil
+	})
+	return entries, err
+}
+
+// commit adds the modifications of the passed view, which was used to connect
+// blocks to the end of the main chain, to the cache.
+//
+// NOTE: The outputs the view adds are assumed to not exist in the database
+// unless they are cached as spent.  The consensus rules only allow duplicate
+// transactions once the previous one is fully spent (BIP0030).
+func (c *utxoCache) commit(view *UtxoViewpoint) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for outpoint, entry := range view.entries {
+		// Only the modified entries need to be added to the cache.
+		if entry == nil || !entry.isModified() {
+			continue
+		}
+
+		cached := c.entries[outpoint]
+		if cached != nil {
+			c.totalSize -= cachedEntrySize(cached)
+		}
+
+		// Spent outputs which were never written to the database are
+		// simply removed.  Otherwise, they are kept as spent, so they
+		// are removed from the database on the next flush.
+		if entry.IsSpent() {
+			if cached != nil && cached.isFresh() {
+				delete(c.entries, outpoint)
+				continue
+			}
+
+			cached = &UtxoEntry{packedFlags: tfSpent | tfModified}
+			c.entries[outpoint] = cached
+			c.totalSize += cachedEntrySize(cached)
+			continue
+		}
+
+		fresh := cached == nil || cached.isFresh()
+		cached = &UtxoEntry{
+			amount:      entry.amount,
+			pkScript:    entry.pkScript,
+			blockHeight: entry.blockHeight,
+			packedFlags: entry.packedFlags&tfCoinBase | tfModified,
+		}
+		if fresh {
+			cached.packedFlags |= tfFresh
+		}
+		c.entries[outpoint] = cached
+		c.totalSize += cachedEntrySize(cached)
+	}
+}
+
+// needsFlush returns whether the cache exceeds its memory budget or the flush
+// interval elapsed since it was last flushed.
+func (c *utxoCache) needsFlush() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.totalSize >= c.maxSize {
+		return true
+	}
+	return c.flushInterval > 0 && time.Since(c.lastFlush) >= c.flushInterval
+}
+
+// flush writes the modified entries of the cache followed by those of the
+// passed view, if any, to the database using the passed transaction and marks
+// the utxo set in the database consistent with the passed block.  The cache
+// must be reset once the transaction was committed.
+func (c *utxoCache) flush(dbTx database.Tx, view *UtxoViewpoint,
+	hash *chainhash.Hash) error {
+
+	c.mtx.Lock()
+	err := dbPutUtxoView(dbTx, &UtxoViewpoint{entries: c.entries})
+	c.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if view != nil {
+		if err := dbPutUtxoView(dbTx, view); err != nil {
+			return err
+		}
+	}
+
+	return dbPutUtxoStateConsistency(dbTx, hash)
+}
+
+// reset removes all entries from the cache after it was flushed.
+func (c *utxoCache) reset() {
+	c.mtx.Lock()
+	c.entries = make(map[wire.OutPoint]*UtxoEntry)
+	c.totalSize = 0
+	c.lastFlush = time.Now()
+	c.mtx.Unlock()
+}
+
+// flushUtxoCache writes the utxo cache to the database and marks the utxo set
+// in the database consistent with the current best chain.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) flushUtxoCache() error {
+	tip := b.bestChain.Tip()
+	err := b.db.Update(func(dbTx database.Tx) error {
+		return b.utxoCache.flush(dbTx, nil, &tip.hash)
+	})
+	if err != nil {
+		return err
+	}
+
+	b.utxoCache.reset()
+	return nil
+}
+
+// FlushUtxoCache writes the changes to the utxo set which are only cached in
+// memory to the database.  It should be called before shutting down, since
+// otherwise the blocks connected since the last flush are connected to the
+// utxo set again on the next start.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FlushUtxoCache() error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	return b.flushUtxoCache()
+}
+
+// initUtxoCache makes the utxo set in the database consistent with the best
+// chain by connecting the blocks after the one the utxo set was last flushed at
+// to the utxo set again.  This is only needed when the utxo cache was not
+// flushed before shutting down.
+func (b *BlockChain) initUtxoCache(interrupt <-chan struct{}) error {
+	var consistentHash *chainhash.Hash
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		consistentHash, err = dbFetchUtxoStateConsistency(dbTx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// The utxo set is consistent with the best chain when the cache was
+	// never flushed before, so store it now in order to recover from an
+	// unclean shutdown before the first flush.
+	tip := b.bestChain.Tip()
+	if consistentHash == nil {
+		return b.db.Update(func(dbTx database.Tx) error {
+			return dbPutUtxoStateConsistency(dbTx, &tip.hash)
+		})
+	}
+	if *consistentHash == tip.hash {
+		return nil
+	}
+	node := b.index.LookupNode(consistentHash)
+	if node == nil || !b.bestChain.Contains(node) {
+		return AssertError(fmt.Sprintf("utxo set is consistent with "+
+			"block %v which is not in the main chain", consistentHash))
+	}
+
+	log.Infof("Recovering the utxo set by connecting blocks %d to %d "+
+		"again", node.height+1, tip.height)
+	for node = b.bestChain.Next(node); node != nil; node = b.bestChain.Next(node) {
+		if interruptRequested(interrupt) {
+			return errInterruptRequested
+		}
+
+		var block *btcutil.Block
+		err := b.db.View(func(dbTx database.Tx) error {
+			var err error
+			block, err = dbFetchBlockByNode(dbTx, node)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		view := NewUtxoViewpoint()
+		view.SetBestHash(&node.parent.hash)
+		if err := view.fetchInputUtxos(b.utxoCache, block); err != nil {
+			return err
+		}
+		if err := view.connectTransactions(block, nil); err != nil {
+			return err
+		}
+		b.utxoCache.commit(view)
+
+		if !b.utxoCache.needsFlush() {
+			continue
+		}
+		err = b.db.Update(func(dbTx database.Tx) error {
+			return b.utxoCache.flush(dbTx, nil, &node.hash)
+		})
+		if err != nil {
+			return err
+		}
+		b.utxoCache.reset()
+	}
+
+	return b.flushUtxoCache()
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/database"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// TestUtxoCache ensures the changes to the utxo set are kept in the utxo cache
+// until it is flushed and are recovered when the cache was not flushed before
+// the chain is created again.
+func TestUtxoCache(t *testing.T) {
+	blocks, err := loadBlocks("blk_0_to_4.dat.bz2")
+	if err != nil {
+		t.Fatalf("Error loading file: %v", err)
+	}
+
+	db, teardown := snapshotTestDB(t, "utxocache")
+	defer teardown()
+	newChain := func() *BlockChain {
+		paramsCopy := chaincfg.MainNetParams
+		chain, err := New(&Config{
+			DB:               db,
+			ChainParams:      &paramsCopy,
+			TimeSource:       NewMedianTime(),
+			SigCache:         txscript.NewSigCache(1000),
+			UtxoCacheMaxSize: 1 << 20,
+		})
+		if err != nil {
+			t.Fatalf("failed to create chain instance: %v", err)
+		}
+		chain.TstSetCoinbaseMaturity(1)
+		return chain
+	}
+
+	// dbUtxo returns the utxo for the passed outpoint stored in the
+	// database.
+	dbUtxo := func(outpoint wire.OutPoint) *UtxoEntry {
+		var entry *UtxoEntry
+		err := db.View(func(dbTx database.Tx) error {
+			var err error
+			entry, err = dbFetchUtxoEntry(dbTx, outpoint)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("dbFetchUtxoEntry: %v", err)
+		}
+		return entry
+	}
+
+	chain := newChain()
+	for i := 1; i < len(blocks); i++ {
+		if _, _, err := chain.ProcessBlock(blocks[i], BFNone); err != nil {
+			t.Fatalf("ProcessBlock fail on block %v: %v", i, err)
+		}
+	}
+
+	// The coinbase of the last block is only cached.
+	coinbase := wire.OutPoint{
+		Hash: *blocks[len(blocks)-1].Transactions()[0].Hash(),
+	}
+	entry, err := chain.FetchUtxoEntry(coinbase)
+	if err != nil || entry == nil {
+		t.Fatalf("FetchUtxoEntry: unexpected entry %v (err %v)", entry,
+			err)
+	}
+	if dbUtxo(coinbase) != nil {
+		t.Fatalf("utxo %v written to the database before flushing",
+			coinbase)
+	}
+
+	// Creating the chain again without flushing the cache must recover the
+	// utxo set in the database.
+	chain = newChain()
+	if dbUtxo(coinbase) == nil {
+		t.Fatalf("utxo %v not recovered", coinbase)
+	}
+	best := chain.BestSnapshot()
+	if best.Hash != *blocks[len(blocks)-1].Hash() {
+		t.Fatalf("unexpected best block %v", best.Hash)
+	}
+
+	// The outputs spent by the blocks must not be part of the utxo set in
+	// the database once the cache is flushed.
+	spentOutpoints := make(map[wire.OutPoint]struct{})
+	for _, block := range blocks[1:] {
+		for _, tx := range block.Transactions()[1:] {
+			for _, txIn := range tx.MsgTx().TxIn {
+				spentOutpoints[txIn.PreviousOutPoint] = struct{}{}
+			}
+		}
+	}
+	if err := chain.FlushUtxoCache(); err != nil {
+		t.Fatalf("FlushUtxoCache: %v", err)
+	}
+	for outpoint := range spentOutpoints {
+		if entry := dbUtxo(outpoint); entry != nil {
+			t.Fatalf("spent utxo %v still in the database", outpoint)
+		}
+	}
+}
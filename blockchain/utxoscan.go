@@ -0,0 +1,123 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/database"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// utxoScanProgressInterval is the number of utxos scanned between progress
+// reports of ScanUtxoSet.
+const utxoScanProgressInterval = 10000
+
+// ErrUtxoScanInterrupted is returned by ScanUtxoSet when the scan is
+// interrupted before all utxos were scanned.
+var ErrUtxoScanInterrupted = errors.New("utxo set scan interrupted")
+
+// UtxoScanFunc is the function invoked by ScanUtxoSet for every unspent
+// transaction output.  Returning an error stops the scan.
+type UtxoScanFunc func(outpoint wire.OutPoint, entry *UtxoEntry) error
+
+// UtxoScanProgressFunc is the function invoked by ScanUtxoSet to report the
+// estimated fraction of the utxo set which has been scanned so far.
+type UtxoScanProgressFunc func(progress float64)
+
+// utxoScanProgress estimates the fraction of the utxo set which has been
+// scanned when the scan reached the passed utxo set key.  Since the utxos are
+// ordered by the hashes of their transactions, which are uniformly
+// distributed, the first bytes of the hash are a good estimate.
+func utxoScanProgress(key []byte) float64 {
+	return float64(binary.BigEndian.Uint16(key)) / 65536
+}
+
+// ScanUtxoSet invokes the passed function with every unspent transaction output
+// in the utxo set at the current best block and returns the hash and height of
+// that block.  The estimated progress of the scan is periodically reported to
+// the passed progress function, when it is not nil.
+//
+// The scan is done from a single database transaction, so it is consistent
+// without preventing blocks from being connected in the meantime.  It stops
+// with ErrUtxoScanInterrupted once the passed interrupt channel is closed, in
+// which case the hash and height of the scanned block are still returned.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ScanUtxoSet(fn UtxoScanFunc,
+	progress UtxoScanProgressFunc,
+	interrupt <-chan struct{}) (*chainhash.Hash, int32, error) {
+
+	// The utxo set in the database must be consistent with the best chain
+	// state, so flush the utxo cache first.
+	b.chainLock.Lock()
+	locked := true
+	unlock := func() {
+		if locked {
+			locked = false
+			b.chainLock.Unlock()
+		}
+	}
+	defer unlock()
+	if err := b.flushUtxoCache(); err != nil {
+		return nil, 0, err
+	}
+
+	var hash chainhash.Hash
+	var height int32
+	err := b.db.View(func(dbTx database.Tx) error {
+		unlock()
+
+		state, err := deserializeBestChainState(
+			dbTx.Metadata().Get(chainStateKeyName))
+		if err != nil {
+			return err
+		}
+		hash, height = state.hash, int32(state.height)
+
+		var numScanned int
+		utxoBucket := dbTx.Metadata().Bucket(utxoSetBucketName)
+		return utxoBucket.ForEach(func(k, v []byte) error {
+			numScanned++
+			if numScanned%utxoScanProgressInterval == 0 {
+				if interruptRequested(interrupt) {
+					return ErrUtxoScanInterrupted
+				}
+				if progress != nil {
+					progress(utxoScanProgress(k))
+				}
+			}
+
+			if len(k) <= chainhash.HashSize {
+				return AssertError(fmt.Sprintf("invalid utxo "+
+					"key %x", k))
+			}
+			var outpoint wire.OutPoint
+			copy(outpoint.Hash[:], k)
+			index, _ := deserializeVLQ(k[chainhash.HashSize:])
+			outpoint.Index = uint32(index)
+
+			entry, err := deserializeUtxoEntry(v)
+			if err != nil {
+				return err
+			}
+			return fn(outpoint, entry)
+		})
+	})
+	if err == ErrUtxoScanInterrupted {
+		return &hash, height, err
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if progress != nil {
+		progress(1)
+	}
+	return &hash, height, nil
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// TestScanUtxoSet ensures scanning the utxo set visits every unspent output of
+// the best chain and stops when the scan function returns an error.
+func TestScanUtxoSet(t *testing.T) {
+	blocks, err := loadBlocks("blk_0_to_4.dat.bz2")
+	if err != nil {
+		t.Fatalf("Error loading file: %v", err)
+	}
+
+	chain, teardownFunc, err := chainSetup("utxoscan",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+	chain.TstSetCoinbaseMaturity(1)
+	for i := 1; i < len(blocks); i++ {
+		if _, _, err := chain.ProcessBlock(blocks[i], BFNone); err != nil {
+			t.Fatalf("ProcessBlock fail on block %v: %v", i, err)
+		}
+	}
+
+	// Every scanned output must match the one in the utxo set.
+	var numScanned int
+	var lastProgress float64
+	scanFn := func(outpoint wire.OutPoint, entry *UtxoEntry) error {
+		numScanned++
+		want, err := chain.FetchUtxoEntry(outpoint)
+		if err != nil {
+			return err
+		}
+		if want == nil || want.Amount() != entry.Amount() ||
+			want.BlockHeight() != entry.BlockHeight() {
+
+			t.Errorf("unexpected utxo %v: %+v", outpoint, entry)
+		}
+		return nil
+	}
+	progressFn := func(progress float64) {
+		lastProgress = progress
+	}
+	hash, height, err := chain.ScanUtxoSet(scanFn, progressFn, nil)
+	if err != nil {
+		t.Fatalf("ScanUtxoSet: unexpected error: %v", err)
+	}
+	best := chain.BestSnapshot()
+	if *hash != best.Hash || height != best.Height {
+		t.Fatalf("unexpected scanned block %v at height %d", hash, height)
+	}
+	if numScanned == 0 || lastProgress != 1 {
+		t.Fatalf("unexpected scan of %d utxos with progress %v",
+			numScanned, lastProgress)
+	}
+
+	// An error returned by the scan function must stop the scan.
+	errStop := errors.New("stop")
+	numScanned = 0
+	_, _, err = chain.ScanUtxoSet(func(wire.OutPoint, *UtxoEntry) error {
+		numScanned++
+		return errStop
+	}, nil, nil)
+	if err != errStop || numScanned != 1 {
+		t.Fatalf("unexpected result of stopped scan: %d, %v",
+			numScanned, err)
+	}
+}
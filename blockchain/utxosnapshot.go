@@ -0,0 +1,668 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/database"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// utxoSnapshotVersion is the current version of the utxo snapshot
+	// format.
+	utxoSnapshotVersion = 1
+
+	// utxoSnapshotBatchSize is the number of entries written to the
+	// database per transaction while loading a utxo snapshot.  Loading
+	// the snapshot in batches bounds the memory used by pending database
+	// writes.
+	utxoSnapshotBatchSize = 50000
+
+	// utxoSnapshotHeaderSize is the size of the fixed fields at the start
+	// of a utxo snapshot.
+	utxoSnapshotHeaderSize = 4 + 2 + 4 + chainhash.HashSize + 4 + 8
+
+	// utxoSnapshotStateSize is the size of the serialized utxo snapshot
+	// state stored in the database.
+	utxoSnapshotStateSize = chainhash.HashSize + 4 + 8 + 8 +
+		chainhash.HashSize + 1
+)
+
+var (
+	// utxoSnapshotMagic is the magic which starts every utxo snapshot.
+	utxoSnapshotMagic = [4]byte{'u', 't', 'x', 'o'}
+
+	// utxoSnapshotKeyName is the name of the db key used to store the
+	// utxo snapshot the chain state was loaded from.
+	utxoSnapshotKeyName = []byte("utxosnapshot")
+)
+
+// -----------------------------------------------------------------------------
+// A utxo snapshot serializes the utxo set at a block along with everything
+// needed to bootstrap a chain state from it.
+//
+// The serialized format is:
+//
+//   <magic><version><net><block hash><height><total txns><headers><block>
+//   <coins><terminator><num coins><utxo hash>
+//
+//   Field             Type               Size
+//   magic             [4]byte            4 bytes
+//   version           uint16             2 bytes
+//   net               wire.BitcoinNet    4 bytes
+//   block hash        chainhash.Hash     chainhash.HashSize
+//   height            uint32             4 bytes
+//   total txns        uint64             8 bytes
+//   headers           []wire.BlockHeader 80 bytes * height
+//   block             wire.MsgBlock      variable
+//   coins             []coin             variable
+//   terminator        VarInt             1 byte (always 0)
+//   num coins         uint64             8 bytes
+//   utxo hash         chainhash.Hash     chainhash.HashSize
+//
+// The headers are the headers of the blocks at heights 1 through height, and
+// the block is the full block at height.
+//
+// Each coin is the key and the value of an entry in the utxo set bucket, both
+// prefixed by their length as a VarInt.  The coins are ordered by their key.
+// The utxo hash is the double SHA256 of the serialized coins.
+// -----------------------------------------------------------------------------
+
+// UtxoSnapshot describes the utxo set at a block as serialized by
+// WriteUtxoSnapshot.
+type UtxoSnapshot struct {
+	// BlockHash is the hash of the block the utxo set is at.
+	BlockHash chainhash.Hash
+
+	// Height is the height of the block the utxo set is at.
+	Height int32
+
+	// TotalTxns is the total number of transactions in the chain up to
+	// and including the block.
+	TotalTxns uint64
+
+	// NumCoins is the number of unspent outputs in the utxo set.
+	NumCoins uint64
+
+	// UtxoHash commits to the serialized utxo set.
+	UtxoHash chainhash.Hash
+}
+
+// utxoSetHasher calculates the utxo hash of serialized coins.
+type utxoSetHasher struct {
+	hash.Hash
+}
+
+// newUtxoSetHasher returns a hasher for the utxo hash of a utxo snapshot.
+func newUtxoSetHasher() *utxoSetHasher {
+	return &utxoSetHasher{Hash: sha256.New()}
+}
+
+// utxoHash returns the utxo hash of the coins written to the hasher.
+func (h *utxoSetHasher) utxoHash() chainhash.Hash {
+	return chainhash.Hash(sha256.Sum256(h.Sum(nil)))
+}
+
+// writeSnapshotCoin serializes the passed utxo set entry to the passed writer.
+func writeSnapshotCoin(w io.Writer, key, value []byte) error {
+	if err := wire.WriteVarBytes(w, 0, key); err != nil {
+		return err
+	}
+	return wire.WriteVarBytes(w, 0, value)
+}
+
+// readSnapshotCoin reads a utxo set entry serialized by writeSnapshotCoin from
+// the passed reader.  A nil key is returned for the terminator.
+func readSnapshotCoin(r io.Reader, snapshotHeight int32) ([]byte, []byte, error) {
+	keyLen, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	if keyLen == 0 {
+		return nil, nil, nil
+	}
+	if keyLen <= chainhash.HashSize ||
+		keyLen > uint64(chainhash.HashSize+maxUint32VLQSerializeSize) {
+
+		return nil, nil, fmt.Errorf("invalid utxo key length %d", keyLen)
+	}
+	key := make([]byte, keyLen)
+	if _, err := io.ReadFull(r, key); err != nil {
+		return nil, nil, err
+	}
+	idx, size := deserializeVLQ(key[chainhash.HashSize:])
+	if size != len(key)-chainhash.HashSize || idx > 1<<32-1 {
+		return nil, nil, fmt.Errorf("invalid utxo key %x", key)
+	}
+
+	value, err := wire.ReadVarBytes(r, 0, wire.MaxBlockPayload, "utxo")
+	if err != nil {
+		return nil, nil, err
+	}
+	entry, err := deserializeUtxoEntry(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	if entry.BlockHeight() > snapshotHeight {
+		return nil, nil, fmt.Errorf("utxo %x created at height %d "+
+			"after the snapshot block", key, entry.BlockHeight())
+	}
+	return key, value, nil
+}
+
+// WriteUtxoSnapshot serializes the utxo set at the current best block to the
+// passed writer in a format that can be loaded with LoadUtxoSnapshot.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) WriteUtxoSnapshot(w io.Writer) (*UtxoSnapshot, error) {
+	bw := bufio.NewWriter(w)
+
+	// The utxo set in the database must be consistent with the best chain
+	// state, so flush the utxo cache first.
+	b.chainLock.Lock()
+	locked := true
+	unlock := func() {
+		if locked {
+			locked = false
+			b.chainLock.Unlock()
+		}
+	}
+	defer unlock()
+	if err := b.flushUtxoCache(); err != nil {
+		return nil, err
+	}
+
+	// The snapshot is serialized from a single database transaction, so
+	// it is consistent without preventing blocks from being connected in
+	// the meantime.
+	var snapshot *UtxoSnapshot
+	err := b.db.View(func(dbTx database.Tx) error {
+		unlock()
+
+		state, err := deserializeBestChainState(
+			dbTx.Metadata().Get(chainStateKeyName))
+		if err != nil {
+			return err
+		}
+		snapshot = &UtxoSnapshot{
+			BlockHash: state.hash,
+			Height:    int32(state.height),
+			TotalTxns: state.totalTxns,
+		}
+
+		var header [utxoSnapshotHeaderSize]byte
+		copy(header[0:4], utxoSnapshotMagic[:])
+		binary.LittleEndian.PutUint16(header[4:6], utxoSnapshotVersion)
+		binary.LittleEndian.PutUint32(header[6:10],
+			uint32(b.chainParams.Net))
+		copy(header[10:42], state.hash[:])
+		binary.LittleEndian.PutUint32(header[42:46], state.height)
+		binary.LittleEndian.PutUint64(header[46:54], state.totalTxns)
+		if _, err := bw.Write(header[:]); err != nil {
+			return err
+		}
+
+		for height := int32(1); height <= snapshot.Height; height++ {
+			header, err := dbFetchHeaderByHeight(dbTx, height)
+			if err != nil {
+				return err
+			}
+			if err := header.Serialize(bw); err != nil {
+				return err
+			}
+		}
+
+		blockBytes, err := dbTx.FetchBlock(&state.hash)
+		if err != nil {
+			return err
+		}
+		if _, err := bw.Write(blockBytes); err != nil {
+			return err
+		}
+
+		hasher := newUtxoSetHasher()
+		coinWriter := io.MultiWriter(bw, hasher)
+		utxoBucket := dbTx.Metadata().Bucket(utxoSetBucketName)
+		err = utxoBucket.ForEach(func(k, v []byte) error {
+			snapshot.NumCoins++
+			return writeSnapshotCoin(coinWriter, k, v)
+		})
+		if err != nil {
+			return err
+		}
+		snapshot.UtxoHash = hasher.utxoHash()
+
+		var trailer [9 + chainhash.HashSize]byte
+		binary.LittleEndian.PutUint64(trailer[1:9], snapshot.NumCoins)
+		copy(trailer[9:], snapshot.UtxoHash[:])
+		_, err = bw.Write(trailer[:])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bw.Flush(); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// LoadUtxoSnapshot initializes the chain state of the passed database, which
+// must not have been initialized yet, from a utxo snapshot serialized by
+// WriteUtxoSnapshot.  The hash of the utxo set in the snapshot must match the
+// passed utxo hash, which must come from a trusted source.
+//
+// The chain state loaded from the snapshot lacks the blocks before the
+// snapshot block, so the blocks must be validated in the background by a
+// separate chain which is passed to VerifyUtxoSnapshot once it reached the
+// snapshot block.  Reorganizations to blocks before the snapshot block are not
+// possible.
+func LoadUtxoSnapshot(db database.DB, params *chaincfg.Params, r io.Reader,
+	utxoHash *chainhash.Hash) (*UtxoSnapshot, error) {
+
+	// Remove the buckets left behind by a previous attempt to load a
+	// snapshot.  The chain state is only stored once the snapshot was
+	// loaded completely, so its absence means there was no such attempt
+	// or it was interrupted.
+	err := db.Update(func(dbTx database.Tx) error {
+		if dbTx.Metadata().Get(chainStateKeyName) != nil {
+			return errors.New("database already contains a chain state")
+		}
+		if err := dbRemoveChainBuckets(dbTx); err != nil {
+			return err
+		}
+		return dbCreateChainBuckets(dbTx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := loadUtxoSnapshot(db, params, bufio.NewReader(r),
+		utxoHash)
+	if err != nil {
+		// Remove the partially loaded chain state, so the database can
+		// be initialized from scratch.
+		cleanupErr := db.Update(dbRemoveChainBuckets)
+		if cleanupErr != nil {
+			log.Errorf("Failed to remove partially loaded utxo "+
+				"snapshot: %v", cleanupErr)
+		}
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// dbRemoveChainBuckets uses an existing database transaction to remove the
+// buckets which house the chain state.
+func dbRemoveChainBuckets(dbTx database.Tx) error {
+	meta := dbTx.Metadata()
+	buckets := [][]byte{blockIndexBucketName, hashIndexBucketName,
+		heightIndexBucketName, spendJournalBucketName, utxoSetBucketName}
+	for _, bucket := range buckets {
+		if meta.Bucket(bucket) == nil {
+			continue
+		}
+		if err := meta.DeleteBucket(bucket); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadUtxoSnapshot loads the utxo snapshot from the passed reader into the
+// chain buckets of the passed database as described by LoadUtxoSnapshot.
+func loadUtxoSnapshot(db database.DB, params *chaincfg.Params,
+	br *bufio.Reader, utxoHash *chainhash.Hash) (*UtxoSnapshot, error) {
+
+	var header [utxoSnapshotHeaderSize]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header[0:4], utxoSnapshotMagic[:]) {
+		return nil, errors.New("not a utxo snapshot")
+	}
+	version := binary.LittleEndian.Uint16(header[4:6])
+	if version != utxoSnapshotVersion {
+		return nil, fmt.Errorf("unsupported utxo snapshot version %d",
+			version)
+	}
+	net := wire.BitcoinNet(binary.LittleEndian.Uint32(header[6:10]))
+	if net != params.Net {
+		return nil, fmt.Errorf("utxo snapshot is for network %v, not %v",
+			net, params.Net)
+	}
+	snapshot := &UtxoSnapshot{
+		Height:    int32(binary.LittleEndian.Uint32(header[42:46])),
+		TotalTxns: binary.LittleEndian.Uint64(header[46:54]),
+	}
+	copy(snapshot.BlockHash[:], header[10:42])
+	if snapshot.Height < 1 {
+		return nil, errors.New("utxo snapshot is at the genesis block")
+	}
+
+	log.Infof("Loading utxo snapshot at block %v (height %d)",
+		snapshot.BlockHash, snapshot.Height)
+
+	// Read the headers and verify they form a valid chain which matches
+	// the checkpoints.  The blocks before the snapshot block are assumed to
+	// be valid until they were validated in the background.
+	genesisBlock := btcutil.NewBlock(params.GenesisBlock)
+	genesisBlock.SetHeight(0)
+	node := newBlockNode(&params.GenesisBlock.Header, nil)
+	node.status = statusDataStored | statusValid
+	nodes := []*blockNode{node}
+	checkpoints := make(map[int32]*chainhash.Hash)
+	for _, checkpoint := range params.Checkpoints {
+		checkpoints[checkpoint.Height] = checkpoint.Hash
+	}
+	for height := int32(1); height <= snapshot.Height; height++ {
+		var header wire.BlockHeader
+		if err := header.Deserialize(br); err != nil {
+			return nil, err
+		}
+		if header.PrevBlock != node.hash {
+			return nil, fmt.Errorf("header at height %d does not "+
+				"connect to the previous header", height)
+		}
+		if err := CheckHeaderProofOfWork(&header, params.PowLimit); err != nil {
+			return nil, err
+		}
+		if !PermittedDifficultyTransition(params, height, node.bits,
+			header.Bits) {
+
+			return nil, fmt.Errorf("header at height %d has invalid "+
+				"difficulty bits %08x", height, header.Bits)
+		}
+
+		node = newBlockNode(&header, node)
+		node.status = statusValid
+		if hash, ok := checkpoints[height]; ok && node.hash != *hash {
+			return nil, fmt.Errorf("header at height %d does not "+
+				"match the checkpoint %v", height, hash)
+		}
+		nodes = append(nodes, node)
+	}
+	if node.hash != snapshot.BlockHash {
+		return nil, fmt.Errorf("headers end at block %v instead of "+
+			"the snapshot block %v", node.hash, snapshot.BlockHash)
+	}
+
+	var msgBlock wire.MsgBlock
+	if err := msgBlock.Deserialize(br); err != nil {
+		return nil, err
+	}
+	block := btcutil.NewBlock(&msgBlock)
+	block.SetHeight(snapshot.Height)
+	if *block.Hash() != snapshot.BlockHash {
+		return nil, fmt.Errorf("snapshot block %v does not match the "+
+			"snapshot block hash %v", block.Hash(), snapshot.BlockHash)
+	}
+	err := CheckBlockSanity(block, params.PowLimit, NewMedianTime())
+	if err != nil {
+		return nil, err
+	}
+	node.status |= statusDataStored
+
+	// Store the block index along with the main chain indexes in batches.
+	for i := 0; i < len(nodes); i += utxoSnapshotBatchSize {
+		batch := nodes[i:]
+		if len(batch) > utxoSnapshotBatchSize {
+			batch = batch[:utxoSnapshotBatchSize]
+		}
+		err := db.Update(func(dbTx database.Tx) error {
+			for _, node := range batch {
+				if err := dbStoreBlockNode(dbTx, node); err != nil {
+					return err
+				}
+				err := dbPutBlockIndex(dbTx, &node.hash, node.height)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Read the coins, verify they are well formed and store them in the
+	// utxo set in batches.
+	hasher := newUtxoSetHasher()
+	var lastKey []byte
+	for done := false; !done; {
+		err := db.Update(func(dbTx database.Tx) error {
+			utxoBucket := dbTx.Metadata().Bucket(utxoSetBucketName)
+			for i := 0; i < utxoSnapshotBatchSize; i++ {
+				key, value, err := readSnapshotCoin(br,
+					snapshot.Height)
+				if err != nil {
+					return err
+				}
+				if key == nil {
+					done = true
+					return nil
+				}
+				if bytes.Compare(key, lastKey) <= 0 {
+					return fmt.Errorf("utxo %x is out of "+
+						"order", key)
+				}
+				lastKey = key
+
+				err = writeSnapshotCoin(hasher, key, value)
+				if err != nil {
+					return err
+				}
+				if err := utxoBucket.Put(key, value); err != nil {
+					return err
+				}
+				snapshot.NumCoins++
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	snapshot.UtxoHash = hasher.utxoHash()
+
+	var trailer [8 + chainhash.HashSize]byte
+	if _, err := io.ReadFull(br, trailer[:]); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint64(trailer[0:8]) != snapshot.NumCoins ||
+		!bytes.Equal(trailer[8:], snapshot.UtxoHash[:]) {
+
+		return nil, errors.New("utxo snapshot is corrupt")
+	}
+	if snapshot.UtxoHash != *utxoHash {
+		return nil, fmt.Errorf("utxo hash %v of the snapshot does not "+
+			"match the expected utxo hash %v", snapshot.UtxoHash,
+			utxoHash)
+	}
+
+	// Finally store the blocks and the chain state.  The chain state is
+	// stored last since it marks the database as initialized.
+	err = db.Update(func(dbTx database.Tx) error {
+		if err := dbStoreBlock(dbTx, genesisBlock); err != nil {
+			return err
+		}
+		if err := dbStoreBlock(dbTx, block); err != nil {
+			return err
+		}
+		err := dbPutUtxoSnapshot(dbTx, snapshot, false)
+		if err != nil {
+			return err
+		}
+		return dbTx.Metadata().Put(chainStateKeyName,
+			serializeBestChainState(bestChainState{
+				hash:      snapshot.BlockHash,
+				height:    uint32(snapshot.Height),
+				totalTxns: snapshot.TotalTxns,
+				workSum:   node.workSum,
+			}))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Loaded utxo snapshot with %d coins", snapshot.NumCoins)
+	return snapshot, nil
+}
+
+// dbPutUtxoSnapshot uses an existing database transaction to store the utxo
+// snapshot the chain state was loaded from along with whether the blocks
+// leading up to it were validated.
+func dbPutUtxoSnapshot(dbTx database.Tx, snapshot *UtxoSnapshot,
+	validated bool) error {
+
+	serialized := make([]byte, utxoSnapshotStateSize)
+	copy(serialized[0:32], snapshot.BlockHash[:])
+	byteOrder.PutUint32(serialized[32:36], uint32(snapshot.Height))
+	byteOrder.PutUint64(serialized[36:44], snapshot.TotalTxns)
+	byteOrder.PutUint64(serialized[44:52], snapshot.NumCoins)
+	copy(serialized[52:84], snapshot.UtxoHash[:])
+	if validated {
+		serialized[84] = 1
+	}
+	return dbTx.Metadata().Put(utxoSnapshotKeyName, serialized)
+}
+
+// dbFetchUtxoSnapshot uses an existing database transaction to fetch the utxo
+// snapshot the chain state was loaded from along with whether the blocks
+// leading up to it were validated.  A nil snapshot is returned when the chain
+// state was not loaded from a snapshot.
+func dbFetchUtxoSnapshot(dbTx database.Tx) (*UtxoSnapshot, bool, error) {
+	serialized := dbTx.Metadata().Get(utxoSnapshotKeyName)
+	if serialized == nil {
+		return nil, false, nil
+	}
+	if len(serialized) != utxoSnapshotStateSize {
+		return nil, false, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt utxo snapshot state",
+		}
+	}
+
+	snapshot := &UtxoSnapshot{
+		Height:    int32(byteOrder.Uint32(serialized[32:36])),
+		TotalTxns: byteOrder.Uint64(serialized[36:44]),
+		NumCoins:  byteOrder.Uint64(serialized[44:52]),
+	}
+	copy(snapshot.BlockHash[:], serialized[0:32])
+	copy(snapshot.UtxoHash[:], serialized[52:84])
+	return snapshot, serialized[84] == 1, nil
+}
+
+// FetchUtxoSnapshot returns the utxo snapshot the chain state in the passed
+// database was loaded from along with whether the blocks leading up to it were
+// validated.  A nil snapshot is returned when the chain state was not loaded
+// from a snapshot.
+func FetchUtxoSnapshot(db database.DB) (*UtxoSnapshot, bool, error) {
+	var snapshot *UtxoSnapshot
+	var validated bool
+	err := db.View(func(dbTx database.Tx) error {
+		var err error
+		snapshot, validated, err = dbFetchUtxoSnapshot(dbTx)
+		return err
+	})
+	return snapshot, validated, err
+}
+
+// UtxoSnapshot returns the utxo snapshot the chain state was loaded from along
+// with whether the blocks leading up to it were validated.  A nil snapshot is
+// returned when the chain state was not loaded from a snapshot.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) UtxoSnapshot() (*UtxoSnapshot, bool) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+	return b.utxoSnapshot, b.utxoSnapshotValidated
+}
+
+// utxoSetHash returns the number of coins in the utxo set along with the utxo
+// hash of the set as it is calculated for a utxo snapshot.
+//
+// This function MUST be called with the chain lock held (for writes).
+func (b *BlockChain) utxoSetHash() (uint64, chainhash.Hash, error) {
+	var numCoins uint64
+	hasher := newUtxoSetHasher()
+	if err := b.flushUtxoCache(); err != nil {
+		return 0, chainhash.Hash{}, err
+	}
+	err := b.db.View(func(dbTx database.Tx) error {
+		utxoBucket := dbTx.Metadata().Bucket(utxoSetBucketName)
+		return utxoBucket.ForEach(func(k, v []byte) error {
+			numCoins++
+			return writeSnapshotCoin(hasher, k, v)
+		})
+	})
+	return numCoins, hasher.utxoHash(), err
+}
+
+// VerifyUtxoSnapshot verifies the utxo snapshot the chain state was loaded
+// from against the passed background chain, which must have validated the
+// blocks up to and including the snapshot block.  The snapshot is marked as
+// validated when the utxo set of the background chain at the snapshot block
+// matches it.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) VerifyUtxoSnapshot(background *BlockChain) error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	snapshot := b.utxoSnapshot
+	if snapshot == nil {
+		return errors.New("chain state was not loaded from a utxo " +
+			"snapshot")
+	}
+	if b.utxoSnapshotValidated {
+		return nil
+	}
+
+	background.chainLock.Lock()
+	tip := background.bestChain.Tip()
+	if tip.hash != snapshot.BlockHash {
+		background.chainLock.Unlock()
+		return fmt.Errorf("background chain is at block %v instead of "+
+			"the snapshot block %v", tip.hash, snapshot.BlockHash)
+	}
+	numCoins, utxoHash, err := background.utxoSetHash()
+	background.chainLock.Unlock()
+	if err != nil {
+		return err
+	}
+	if numCoins != snapshot.NumCoins || utxoHash != snapshot.UtxoHash {
+		return fmt.Errorf("utxo set of the background chain (%d coins, "+
+			"hash %v) does not match the utxo snapshot (%d coins, "+
+			"hash %v)", numCoins, utxoHash, snapshot.NumCoins,
+			snapshot.UtxoHash)
+	}
+
+	err = b.db.Update(func(dbTx database.Tx) error {
+		return dbPutUtxoSnapshot(dbTx, snapshot, true)
+	})
+	if err != nil {
+		return err
+	}
+	b.utxoSnapshotValidated = true
+
+	log.Infof("Validated the blocks leading up to the utxo snapshot at "+
+		"block %v (height %d)", snapshot.BlockHash, snapshot.Height)
+	return nil
+}
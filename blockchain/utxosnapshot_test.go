@@ -0,0 +1,206 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/database"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// TestUtxoSnapshot ensures a utxo snapshot written by one chain bootstraps the
+// chain state of a new database and is verified against a background chain.
+func TestUtxoSnapshot(t *testing.T) {
+	blocks, err := loadBlocks("blk_0_to_4.dat.bz2")
+	if err != nil {
+		t.Fatalf("Error loading file: %v", err)
+	}
+
+	// Create a chain with the blocks to write the snapshot from.
+	chain, teardownFunc, err := chainSetup("utxosnapshot",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+	chain.TstSetCoinbaseMaturity(1)
+	for i := 1; i < len(blocks); i++ {
+		if _, _, err := chain.ProcessBlock(blocks[i], BFNone); err != nil {
+			t.Fatalf("ProcessBlock fail on block %v: %v", i, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	snapshot, err := chain.WriteUtxoSnapshot(&buf)
+	if err != nil {
+		t.Fatalf("WriteUtxoSnapshot: %v", err)
+	}
+	best := chain.BestSnapshot()
+	if snapshot.BlockHash != best.Hash || snapshot.Height != best.Height ||
+		snapshot.TotalTxns != best.TotalTxns || snapshot.NumCoins == 0 {
+
+		t.Fatalf("unexpected snapshot %+v", snapshot)
+	}
+	serialized := buf.Bytes()
+
+	// Corrupt snapshots and unexpected utxo hashes must be rejected
+	// without leaving a chain state behind.
+	var wrongHash chainhash.Hash
+	corrupt := append([]byte(nil), serialized...)
+	corrupt[len(corrupt)-chainhash.HashSize-12] ^= 0x01
+	tests := []struct {
+		name       string
+		serialized []byte
+		utxoHash   *chainhash.Hash
+	}{{
+		name:       "wrong utxo hash",
+		serialized: serialized,
+		utxoHash:   &wrongHash,
+	}, {
+		name:       "truncated",
+		serialized: serialized[:len(serialized)-1],
+		utxoHash:   &snapshot.UtxoHash,
+	}, {
+		name:       "corrupt coin",
+		serialized: corrupt,
+		utxoHash:   &snapshot.UtxoHash,
+	}}
+
+	db, dbTeardown := snapshotTestDB(t, "utxosnapshotload")
+	defer dbTeardown()
+	for _, test := range tests {
+		_, err := LoadUtxoSnapshot(db, &chaincfg.MainNetParams,
+			bytes.NewReader(test.serialized), test.utxoHash)
+		if err == nil {
+			t.Fatalf("%s: expected error loading snapshot", test.name)
+		}
+	}
+
+	// Load the snapshot and ensure the new chain matches the original one.
+	loaded, err := LoadUtxoSnapshot(db, &chaincfg.MainNetParams,
+		bytes.NewReader(serialized), &snapshot.UtxoHash)
+	if err != nil {
+		t.Fatalf("LoadUtxoSnapshot: %v", err)
+	}
+	if *loaded != *snapshot {
+		t.Fatalf("unexpected loaded snapshot -- got %+v, want %+v",
+			loaded, snapshot)
+	}
+	snapshotChain := newSnapshotTestChain(t, db)
+	loadedBest := snapshotChain.BestSnapshot()
+	if loadedBest.Hash != best.Hash || loadedBest.Height != best.Height ||
+		loadedBest.WorkSum.Cmp(best.WorkSum) != 0 {
+
+		t.Fatalf("unexpected best state -- got %v (height %d), want "+
+			"%v (height %d)", loadedBest.Hash, loadedBest.Height,
+			best.Hash, best.Height)
+	}
+	for _, block := range blocks[1:] {
+		for _, tx := range block.Transactions() {
+			for i := range tx.MsgTx().TxOut {
+				outpoint := wire.OutPoint{
+					Hash:  *tx.Hash(),
+					Index: uint32(i),
+				}
+				want, err := chain.FetchUtxoEntry(outpoint)
+				if err != nil {
+					t.Fatalf("FetchUtxoEntry: %v", err)
+				}
+				got, err := snapshotChain.FetchUtxoEntry(outpoint)
+				if err != nil {
+					t.Fatalf("FetchUtxoEntry: %v", err)
+				}
+				if (got == nil) != (want == nil) || (got != nil &&
+					(got.Amount() != want.Amount() ||
+						got.BlockHeight() != want.BlockHeight())) {
+
+					t.Fatalf("mismatched utxo %v", outpoint)
+				}
+			}
+		}
+	}
+	if _, validated := snapshotChain.UtxoSnapshot(); validated {
+		t.Fatalf("snapshot unexpectedly validated")
+	}
+
+	// A chain state can't be loaded into an initialized database.
+	_, err = LoadUtxoSnapshot(db, &chaincfg.MainNetParams,
+		bytes.NewReader(serialized), &snapshot.UtxoHash)
+	if err == nil {
+		t.Fatalf("expected error loading snapshot into initialized db")
+	}
+
+	// Validate the blocks leading up to the snapshot with a background
+	// chain.  The snapshot can only be verified once the background chain
+	// reached the snapshot block.
+	bgDB, bgTeardown := snapshotTestDB(t, "utxosnapshotbg")
+	defer bgTeardown()
+	background := newSnapshotTestChain(t, bgDB)
+	background.TstSetCoinbaseMaturity(1)
+	for i := 1; i < len(blocks); i++ {
+		if err := snapshotChain.VerifyUtxoSnapshot(background); err == nil {
+			t.Fatalf("verified snapshot at height %d", i-1)
+		}
+		_, _, err := background.ProcessBlock(blocks[i], BFNone)
+		if err != nil {
+			t.Fatalf("ProcessBlock fail on block %v: %v", i, err)
+		}
+	}
+	if err := snapshotChain.VerifyUtxoSnapshot(background); err != nil {
+		t.Fatalf("VerifyUtxoSnapshot: %v", err)
+	}
+
+	// The validation must persist.
+	_, validated, err := FetchUtxoSnapshot(db)
+	if err != nil || !validated {
+		t.Fatalf("snapshot not validated after verification: %v", err)
+	}
+}
+
+// snapshotTestDB returns a new empty database for the utxo snapshot tests
+// along with a function to remove it.
+func snapshotTestDB(t *testing.T, dbName string) (database.DB, func()) {
+	t.Helper()
+
+	tempDir, err := ioutil.TempDir("", dbName)
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	db, err := database.Create(testDbType, filepath.Join(tempDir, "db"),
+		blockDataNet)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("error creating db: %v", err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+// newSnapshotTestChain returns a chain instance for the passed database.
+func newSnapshotTestChain(t *testing.T, db database.DB) *BlockChain {
+	t.Helper()
+
+	paramsCopy := chaincfg.MainNetParams
+	chain, err := New(&Config{
+		DB:          db,
+		ChainParams: &paramsCopy,
+		TimeSource:  NewMedianTime(),
+		SigCache:    txscript.NewSigCache(1000),
+	})
+	if err != nil {
+		t.Fatalf("failed to create chain instance: %v", err)
+	}
+	return chain
+}
@@ -15,6 +15,7 @@ import (
 	"runtime/debug"
 	"runtime/pprof"
 
+	"github.com/btcsuite/btcd/blockchain"
 	"github.com/btcsuite/btcd/blockchain/indexers"
 	"github.com/btcsuite/btcd/database"
 	"github.com/btcsuite/btcd/limits"
@@ -143,10 +144,47 @@ func btcdMain(serverChan chan<- *server) error {
 
 		return nil
 	}
+	if cfg.DropTweakIndex {
+		if err := indexers.DropTweakIndex(db, interrupt); err != nil {
+			btcdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
+
+	// Bootstrap the chain state from a utxo snapshot if requested.
+	if cfg.LoadUtxoSnapshot != "" {
+		if err := loadUtxoSnapshot(db); err != nil {
+			btcdLog.Errorf("%v", err)
+			return err
+		}
+	}
+
+	// Load the database used to validate the utxo snapshot the chain state
+	// was loaded from in the background when needed.
+	backgroundDB, err := loadBackgroundDB(db)
+	if err != nil {
+		btcdLog.Errorf("%v", err)
+		return err
+	}
+	if backgroundDB != nil {
+		defer func() {
+			btcdLog.Infof("Gracefully shutting down the background " +
+				"database...")
+			backgroundDB.Close()
+		}()
+	}
+
+	// Return now if an interrupt signal was triggered.
+	if interruptRequested(interrupt) {
+		return nil
+	}
 
 	// Create server and start it.
 	server, err := newServer(cfg.Listeners, cfg.AgentBlacklist,
-		cfg.AgentWhitelist, db, activeNetParams.Params, interrupt)
+		cfg.AgentWhitelist, db, backgroundDB, activeNetParams.Params,
+		interrupt)
 	if err != nil {
 		// TODO: this logging could do with some beautifying.
 		btcdLog.Errorf("Unable to start server on %v: %v",
@@ -296,6 +334,98 @@ func loadBlockDB() (database.DB, error) {
 	return db, nil
 }
 
+// loadUtxoSnapshot bootstraps the chain state of the passed block database from
+// the utxo snapshot specified with the --loadutxosnapshot option.  Nothing is
+// done when the chain state was already loaded from a snapshot on a previous
+// run.
+func loadUtxoSnapshot(db database.DB) error {
+	snapshot, _, err := blockchain.FetchUtxoSnapshot(db)
+	if err != nil {
+		return err
+	}
+	if snapshot != nil {
+		btcdLog.Infof("Chain state already loaded from the utxo "+
+			"snapshot at block %v", snapshot.BlockHash)
+		return nil
+	}
+
+	f, err := os.Open(cfg.LoadUtxoSnapshot)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	btcdLog.Infof("Loading utxo snapshot from '%s'", cfg.LoadUtxoSnapshot)
+	snapshot, err = blockchain.LoadUtxoSnapshot(db, activeNetParams.Params,
+		f, cfg.utxoSnapshotHash)
+	if err != nil {
+		return fmt.Errorf("unable to load utxo snapshot: %v", err)
+	}
+	btcdLog.Infof("Loaded %d unspent outputs at block %v (height %d) "+
+		"from the utxo snapshot", snapshot.NumCoins, snapshot.BlockHash,
+		snapshot.Height)
+	return nil
+}
+
+// loadBackgroundDB loads (or creates when needed) the database of the chain
+// which validates the blocks leading up to the utxo snapshot the chain state of
+// the passed block database was loaded from.  It returns nil when the chain
+// state wasn't loaded from a snapshot or the snapshot was already validated, in
+// which case a leftover background database is removed.
+func loadBackgroundDB(db database.DB) (database.DB, error) {
+	snapshot, validated, err := blockchain.FetchUtxoSnapshot(db)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		return nil, nil
+	}
+
+	// The memdb backend does not have a file path associated with it, so
+	// handle it uniquely.
+	if cfg.DbType == "memdb" {
+		if validated {
+			return nil, nil
+		}
+		return database.Create(cfg.DbType)
+	}
+
+	dbPath := blockDbPath(cfg.DbType + "_background")
+	if validated {
+		if fileExists(dbPath) {
+			btcdLog.Infof("Removing background database '%s' of "+
+				"the validated utxo snapshot", dbPath)
+			if err := os.RemoveAll(dbPath); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+
+	removeRegressionDB(dbPath)
+
+	btcdLog.Infof("Loading background database from '%s'", dbPath)
+	backgroundDB, err := database.Open(cfg.DbType, dbPath,
+		activeNetParams.Net)
+	if err != nil {
+		// Return the error if it's not because the database doesn't
+		// exist.
+		if dbErr, ok := err.(database.Error); !ok || dbErr.ErrorCode !=
+			database.ErrDbDoesNotExist {
+
+			return nil, err
+		}
+
+		backgroundDB, err = database.Create(cfg.DbType, dbPath,
+			activeNetParams.Net)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return backgroundDB, nil
+}
+
 func main() {
 	// Block and transaction processing can cause bursty allocations.  This
 	// limits the garbage collector from excessively overallocating during
@@ -6,6 +6,7 @@ package btcec
 
 import (
 	"encoding/hex"
+	"math/big"
 	"testing"
 )
 
@@ -77,6 +78,38 @@ func BenchmarkScalarMult(b *testing.B) {
 	}
 }
 
+// benchmarkMultiScalarMult benchmarks the secp256k1 curve MultiScalarMult
+// function with the given number of points.
+func benchmarkMultiScalarMult(b *testing.B, numPoints int) {
+	curve := S256()
+	k := fromHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575")
+	points := make([]*PublicKey, numPoints)
+	ks := make([][]byte, numPoints)
+	for i := range points {
+		k.Add(k, big.NewInt(int64(i)))
+		x, y := curve.ScalarBaseMult(k.Bytes())
+		points[i] = &PublicKey{Curve: curve, X: x, Y: y}
+		ks[i] = k.Bytes()
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		curve.MultiScalarMult(points, ks)
+	}
+}
+
+// BenchmarkMultiScalarMult16 benchmarks MultiScalarMult with 16 points, which
+// uses Strauss' algorithm.
+func BenchmarkMultiScalarMult16(b *testing.B) {
+	benchmarkMultiScalarMult(b, 16)
+}
+
+// BenchmarkMultiScalarMult256 benchmarks MultiScalarMult with 256 points,
+// which uses Pippenger's algorithm.
+func BenchmarkMultiScalarMult256(b *testing.B) {
+	benchmarkMultiScalarMult(b, 256)
+}
+
 // BenchmarkNAF benchmarks the NAF function.
 func BenchmarkNAF(b *testing.B) {
 	k := fromHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575")
@@ -43,6 +43,10 @@ var (
 // GenerateSharedSecret generates a shared secret based on a private key and a
 // public key using Diffie-Hellman key exchange (ECDH) (RFC 4753).
 // RFC5903 Section 9 states we should only return x.
+//
+// The returned value omits leading zero bytes of x and is computed with a
+// variable time scalar multiplication.  New code should prefer ECDHXOnly or
+// one of the other ECDH functions.
 func GenerateSharedSecret(privkey *PrivateKey, pubkey *PublicKey) []byte {
 	x, _ := pubkey.Curve.ScalarMult(pubkey.X, pubkey.Y, privkey.D.Bytes())
 	return x.Bytes()
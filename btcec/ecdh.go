@@ -0,0 +1,216 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcec
+
+import (
+	"crypto/sha256"
+)
+
+// ECDHHashFunc derives a shared secret from the affine coordinates of the
+// shared point of an ECDH exchange.  Both coordinates are passed as 32-byte
+// big endian values.
+type ECDHHashFunc func(x, y *[32]byte) []byte
+
+// ECDHHashSHA256 is the ECDHHashFunc used by ECDH.  It returns the SHA-256
+// hash of the compressed serialization of the shared point, which matches the
+// default of libsecp256k1 and the ECDH used by the Lightning Network.
+func ECDHHashSHA256(x, y *[32]byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{pubkeyCompressed | y[31]&1})
+	h.Write(x[:])
+	return h.Sum(nil)
+}
+
+// ECDHHashXOnly is an ECDHHashFunc that returns the x coordinate of the
+// shared point without hashing it.
+func ECDHHashXOnly(x, _ *[32]byte) []byte {
+	secret := make([]byte, 32)
+	copy(secret, x[:])
+	return secret
+}
+
+// ECDHHashCompressed is an ECDHHashFunc that returns the compressed
+// serialization of the shared point without hashing it.
+func ECDHHashCompressed(x, y *[32]byte) []byte {
+	secret := make([]byte, PubKeyBytesLenCompressed)
+	secret[0] = pubkeyCompressed | y[31]&1
+	copy(secret[1:], x[:])
+	return secret
+}
+
+// ECDH performs an elliptic curve Diffie-Hellman key exchange between the
+// passed private and public key and returns the SHA-256 hash of the
+// compressed shared point.
+//
+// Unlike GenerateSharedSecret, the scalar multiplication is performed with a
+// fixed sequence of field operations that doesn't depend on the value of the
+// private key.
+func ECDH(privKey *PrivateKey, pubKey *PublicKey) [32]byte {
+	var secret [32]byte
+	copy(secret[:], ECDHWithHash(privKey, pubKey, ECDHHashSHA256))
+	return secret
+}
+
+// ECDHXOnly performs an elliptic curve Diffie-Hellman key exchange between the
+// passed private and public key and returns the unhashed x coordinate of the
+// shared point as a zero-padded 32-byte value.
+func ECDHXOnly(privKey *PrivateKey, pubKey *PublicKey) [32]byte {
+	var secret [32]byte
+	copy(secret[:], ECDHWithHash(privKey, pubKey, ECDHHashXOnly))
+	return secret
+}
+
+// ECDHCompressed performs an elliptic curve Diffie-Hellman key exchange
+// between the passed private and public key and returns the unhashed
+// compressed serialization of the shared point.
+func ECDHCompressed(privKey *PrivateKey, pubKey *PublicKey) [33]byte {
+	var secret [33]byte
+	copy(secret[:], ECDHWithHash(privKey, pubKey, ECDHHashCompressed))
+	return secret
+}
+
+// ECDHWithHash performs an elliptic curve Diffie-Hellman key exchange between
+// the passed private and public key and derives the shared secret from the
+// shared point with the provided hash function.
+func ECDHWithHash(privKey *PrivateKey, pubKey *PublicKey,
+	hashFn ECDHHashFunc) []byte {
+
+	var k [32]byte
+	dBytes := privKey.D.Bytes()
+	copy(k[32-len(dBytes):], dBytes)
+
+	px, py := S256().bigAffineToField(pubKey.X, pubKey.Y)
+	var x, y [32]byte
+	ladderMult(&k, px, py, &x, &y)
+	secret := hashFn(&x, &y)
+
+	// Clear the intermediate secrets.
+	for i := range k {
+		k[i] = 0
+	}
+	for i := range x {
+		x[i], y[i] = 0, 0
+	}
+	return secret
+}
+
+// projPoint is a point in homogeneous projective coordinates where the affine
+// point is (X/Z, Y/Z) and the point at infinity is (0, 1, 0).  All field
+// values are kept normalized.
+type projPoint struct {
+	x, y, z fieldVal
+}
+
+// condSwap swaps the two field values when swap is 1 and leaves them as is
+// when it is 0 without branching on its value.
+func condSwap(a, b *fieldVal, swap uint32) {
+	mask := -swap
+	for i := range a.n {
+		t := mask & (a.n[i] ^ b.n[i])
+		a.n[i] ^= t
+		b.n[i] ^= t
+	}
+}
+
+// fieldSub sets r = a - b for normalized a and b and normalizes the result.
+func fieldSub(r, a, b *fieldVal) {
+	var negB fieldVal
+	negB.NegateVal(b, 1)
+	r.Add2(a, &negB).Normalize()
+}
+
+// fieldAdd sets r = a + b and normalizes the result.
+func fieldAdd(r, a, b *fieldVal) {
+	r.Add2(a, b).Normalize()
+}
+
+// fieldMul sets r = a * b and normalizes the result.
+func fieldMul(r, a, b *fieldVal) {
+	r.Mul2(a, b).Normalize()
+}
+
+// addProjective sets r = p + q using the complete addition formula for prime
+// order short Weierstrass curves with a = 0 by Renes, Costello and Batina
+// (algorithm 7 of https://eprint.iacr.org/2015/1060).  The formula has no
+// exceptional cases, so it handles doubling and the point at infinity with
+// the same sequence of operations.  r may alias p or q.
+func addProjective(r, p, q *projPoint) {
+	var t0, t1, t2, t3, t4, x3, y3, z3 fieldVal
+
+	fieldMul(&t0, &p.x, &q.x) // t0 = X1*X2
+	fieldMul(&t1, &p.y, &q.y) // t1 = Y1*Y2
+	fieldMul(&t2, &p.z, &q.z) // t2 = Z1*Z2
+	fieldAdd(&t3, &p.x, &p.y) // t3 = X1+Y1
+	fieldAdd(&t4, &q.x, &q.y) // t4 = X2+Y2
+	fieldMul(&t3, &t3, &t4)   // t3 = t3*t4
+	fieldAdd(&t4, &t0, &t1)   // t4 = t0+t1
+	fieldSub(&t3, &t3, &t4)   // t3 = t3-t4
+	fieldAdd(&t4, &p.y, &p.z) // t4 = Y1+Z1
+	fieldAdd(&x3, &q.y, &q.z) // X3 = Y2+Z2
+	fieldMul(&t4, &t4, &x3)   // t4 = t4*X3
+	fieldAdd(&x3, &t1, &t2)   // X3 = t1+t2
+	fieldSub(&t4, &t4, &x3)   // t4 = t4-X3
+	fieldAdd(&x3, &p.x, &p.z) // X3 = X1+Z1
+	fieldAdd(&y3, &q.x, &q.z) // Y3 = X2+Z2
+	fieldMul(&x3, &x3, &y3)   // X3 = X3*Y3
+	fieldAdd(&y3, &t0, &t2)   // Y3 = t0+t2
+	fieldSub(&y3, &x3, &y3)   // Y3 = X3-Y3
+	fieldAdd(&x3, &t0, &t0)   // X3 = t0+t0
+	fieldAdd(&t0, &x3, &t0)   // t0 = X3+t0
+	t2.MulInt(21).Normalize() // t2 = b3*t2
+	fieldAdd(&z3, &t1, &t2)   // Z3 = t1+t2
+	fieldSub(&t1, &t1, &t2)   // t1 = t1-t2
+	y3.MulInt(21).Normalize() // Y3 = b3*Y3
+	fieldMul(&x3, &t4, &y3)   // X3 = t4*Y3
+	fieldMul(&t2, &t3, &t1)   // t2 = t3*t1
+	fieldSub(&x3, &t2, &x3)   // X3 = t2-X3
+	fieldMul(&y3, &y3, &t0)   // Y3 = Y3*t0
+	fieldMul(&t1, &t1, &z3)   // t1 = t1*Z3
+	fieldAdd(&y3, &t1, &y3)   // Y3 = t1+Y3
+	fieldMul(&t0, &t0, &t3)   // t0 = t0*t3
+	fieldMul(&z3, &z3, &t4)   // Z3 = Z3*t4
+	fieldAdd(&z3, &z3, &t0)   // Z3 = Z3+t0
+
+	r.x.Set(&x3)
+	r.y.Set(&y3)
+	r.z.Set(&z3)
+}
+
+// ladderMult computes k*(px, py) using a Montgomery ladder over the complete
+// projective addition formula and stores the affine result as 32-byte big
+// endian values in x and y.  Every bit of k results in the same sequence of
+// field operations and the ladder state is swapped with masks rather than
+// branches, so the timing does not depend on the value of k.
+func ladderMult(k *[32]byte, px, py *fieldVal, x, y *[32]byte) {
+	var r0, r1 projPoint
+	r0.y.SetInt(1)
+	r1.x.Set(px).Normalize()
+	r1.y.Set(py).Normalize()
+	r1.z.SetInt(1)
+
+	for i := 0; i < 256; i++ {
+		bit := uint32(k[i/8]>>(7-uint(i%8))) & 1
+
+		condSwap(&r0.x, &r1.x, bit)
+		condSwap(&r0.y, &r1.y, bit)
+		condSwap(&r0.z, &r1.z, bit)
+
+		addProjective(&r1, &r0, &r1)
+		addProjective(&r0, &r0, &r0)
+
+		condSwap(&r0.x, &r1.x, bit)
+		condSwap(&r0.y, &r1.y, bit)
+		condSwap(&r0.z, &r1.z, bit)
+	}
+
+	// Convert back to affine coordinates.  The inverse is computed via
+	// exponentiation with a fixed exponent.
+	var zInv, ax, ay fieldVal
+	zInv.Set(&r0.z).Inverse()
+	fieldMul(&ax, &r0.x, &zInv)
+	fieldMul(&ay, &r0.y, &zInv)
+	ax.PutBytes(x)
+	ay.PutBytes(y)
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcec
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+)
+
+// TestECDH ensures the ECDH variants agree with each other, are symmetric
+// and match the shared point computed with ScalarMult.
+func TestECDH(t *testing.T) {
+	curve := S256()
+
+	// Include private keys with leading zero bytes and the edge values
+	// 1 and N-1 besides random keys.
+	nMinusOne := new(big.Int).Sub(curve.N, big.NewInt(1))
+	privKeys := []*PrivateKey{
+		privKeyFromInt(big.NewInt(1)),
+		privKeyFromInt(nMinusOne),
+		privKeyFromInt(new(big.Int).Rsh(curve.N, 16)),
+	}
+	for i := 0; i < 5; i++ {
+		privKey, err := NewPrivateKey(curve)
+		if err != nil {
+			t.Fatalf("unable to generate private key: %v", err)
+		}
+		privKeys = append(privKeys, privKey)
+	}
+
+	for i, priv1 := range privKeys {
+		priv2 := privKeys[(i+1)%len(privKeys)]
+		pub2 := priv2.PubKey()
+
+		// Compute the expected shared point with the variable time
+		// scalar multiplication.
+		wantX, wantY := curve.ScalarMult(pub2.X, pub2.Y, priv1.D.Bytes())
+		want := (&PublicKey{Curve: curve, X: wantX, Y: wantY}).
+			SerializeCompressed()
+
+		compressed := ECDHCompressed(priv1, pub2)
+		if !bytes.Equal(compressed[:], want) {
+			t.Errorf("key %d: ECDHCompressed got %x, want %x", i,
+				compressed, want)
+		}
+
+		xOnly := ECDHXOnly(priv1, pub2)
+		if !bytes.Equal(xOnly[:], want[1:]) {
+			t.Errorf("key %d: ECDHXOnly got %x, want %x", i, xOnly,
+				want[1:])
+		}
+
+		hashed := ECDH(priv1, pub2)
+		if wantHash := sha256.Sum256(want); hashed != wantHash {
+			t.Errorf("key %d: ECDH got %x, want %x", i, hashed,
+				wantHash)
+		}
+
+		// The exchange must be symmetric.
+		if other := ECDH(priv2, priv1.PubKey()); other != hashed {
+			t.Errorf("key %d: ECDH not symmetric", i)
+		}
+	}
+}
+
+// privKeyFromInt returns the private key for the passed scalar.
+func privKeyFromInt(d *big.Int) *PrivateKey {
+	privKey, _ := PrivKeyFromBytes(S256(), d.Bytes())
+	return privKey
+}
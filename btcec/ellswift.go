@@ -0,0 +1,298 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcec
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/big"
+)
+
+// EllswiftPubKeyLen is the length of an ElligatorSwift encoded public key as
+// defined by BIP-324.
+const EllswiftPubKeyLen = 64
+
+// bip324ECDHTag is the tag of the BIP-340 style tagged hash used by BIP-324 to
+// derive the shared secret from an x-only ECDH exchange.
+const bip324ECDHTag = "bip324_ellswift_xonly_ecdh"
+
+// maxEllswiftEncodeAttempts is the number of random u values tried when
+// encoding a public key before giving up.  Each attempt succeeds with a
+// probability of roughly 1/4, so reaching the limit indicates a broken source
+// of randomness rather than bad luck.
+const maxEllswiftEncodeAttempts = 512
+
+var (
+	// ellswiftC1 is a square root of -3 mod p.  It is computed the same
+	// way as the BIP-324 reference implementation so that the mapping
+	// matches it exactly.
+	ellswiftC1 = func() *big.Int {
+		p := S256().P
+		minus3 := new(big.Int).Sub(p, big.NewInt(3))
+		return new(big.Int).Exp(minus3, S256().QPlus1Div4(), p)
+	}()
+
+	// ellswiftHalf is the multiplicative inverse of 2 mod p.
+	ellswiftHalf = new(big.Int).Rsh(new(big.Int).Add(S256().P, big.NewInt(1)), 1)
+
+	// errEllswiftEncode is returned when no encoding was found within
+	// maxEllswiftEncodeAttempts attempts.
+	errEllswiftEncode = errors.New("unable to find ElligatorSwift encoding")
+)
+
+// feMod reduces a mod the field prime in place and returns it.
+func feMod(a *big.Int) *big.Int {
+	return a.Mod(a, S256().P)
+}
+
+// feMul returns a*b mod p as a new value.
+func feMul(a, b *big.Int) *big.Int {
+	return feMod(new(big.Int).Mul(a, b))
+}
+
+// feAdd returns a+b mod p as a new value.
+func feAdd(a, b *big.Int) *big.Int {
+	return feMod(new(big.Int).Add(a, b))
+}
+
+// feSub returns a-b mod p as a new value.
+func feSub(a, b *big.Int) *big.Int {
+	return feMod(new(big.Int).Sub(a, b))
+}
+
+// feNeg returns -a mod p as a new value.
+func feNeg(a *big.Int) *big.Int {
+	return feMod(new(big.Int).Neg(a))
+}
+
+// feInv returns the multiplicative inverse of a mod p as a new value.  Like
+// the BIP-324 reference implementation, the inverse of zero is zero.
+func feInv(a *big.Int) *big.Int {
+	p := S256().P
+	return new(big.Int).Exp(a, new(big.Int).Sub(p, big.NewInt(2)), p)
+}
+
+// feSqrt returns a square root of a mod p, or nil when a is not a square.
+func feSqrt(a *big.Int) *big.Int {
+	r := new(big.Int).Exp(a, S256().QPlus1Div4(), S256().P)
+	if feMul(r, r).Cmp(a) != 0 {
+		return nil
+	}
+	return r
+}
+
+// feCurveRHS returns x^3 + 7 mod p.
+func feCurveRHS(x *big.Int) *big.Int {
+	return feAdd(feMul(feMul(x, x), x), big.NewInt(7))
+}
+
+// isValidX returns whether x is the x coordinate of a point on the curve.
+func isValidX(x *big.Int) bool {
+	return big.Jacobi(feCurveRHS(x), S256().P) >= 0
+}
+
+// xSwiftEC maps the field elements u and t to the x coordinate of a point on
+// the curve as specified by the XSwiftEC function of BIP-324.  Both inputs
+// must already be reduced mod p.
+func xSwiftEC(u, t *big.Int) *big.Int {
+	one := big.NewInt(1)
+	if u.Sign() == 0 {
+		u = one
+	}
+	if t.Sign() == 0 {
+		t = one
+	}
+
+	// Map the one remaining pair for which the formulas below divide by
+	// zero to a distinct t.
+	u3plus7 := feCurveRHS(u)
+	if feAdd(u3plus7, feMul(t, t)).Sign() == 0 {
+		t = feAdd(t, t)
+	}
+
+	// X = (u^3 + 7 - t^2) / (2t)
+	// Y = (X + t) / (c1 * u)
+	x := feMul(feSub(u3plus7, feMul(t, t)), feInv(feAdd(t, t)))
+	y := feMul(feAdd(x, t), feInv(feMul(ellswiftC1, u)))
+
+	// The first of the three candidates that is on the curve is used.
+	// At least one of them always is.
+	c := feAdd(u, feMul(big.NewInt(4), feMul(y, y)))
+	if isValidX(c) {
+		return c
+	}
+	xy := feMul(x, feInv(y))
+	c = feMul(feSub(feNeg(xy), u), ellswiftHalf)
+	if isValidX(c) {
+		return c
+	}
+	return feMul(feSub(xy, u), ellswiftHalf)
+}
+
+// xSwiftECInv returns a t such that xSwiftEC(u, t) == x, or nil when none
+// exists for the given case.  The case selects one of the eight preimage
+// branches of the XSwiftECInv function of BIP-324.
+func xSwiftECInv(x, u *big.Int, c int) *big.Int {
+	one := big.NewInt(1)
+
+	var s, v *big.Int
+	if c&2 == 0 {
+		// The candidate is only reachable through the first or second
+		// branch of xSwiftEC when -x-u isn't itself a valid x.
+		if isValidX(feSub(feNeg(x), u)) {
+			return nil
+		}
+		v = x
+		denom := feAdd(feAdd(feMul(u, u), feMul(u, v)), feMul(v, v))
+		s = feNeg(feMul(feCurveRHS(u), feInv(denom)))
+	} else {
+		s = feSub(x, u)
+		if s.Sign() == 0 {
+			return nil
+		}
+		// r = sqrt(-s * (4(u^3 + 7) + 3su^2))
+		uu := feMul(u, u)
+		inner := feAdd(feMul(big.NewInt(4), feCurveRHS(u)),
+			feMul(big.NewInt(3), feMul(s, uu)))
+		r := feSqrt(feMul(feNeg(s), inner))
+		if r == nil {
+			return nil
+		}
+		if c&1 != 0 && r.Sign() == 0 {
+			return nil
+		}
+		v = feMul(feSub(feMul(r, feInv(s)), u), ellswiftHalf)
+	}
+
+	w := feSqrt(s)
+	if w == nil {
+		return nil
+	}
+
+	// t = +-w * (u * (1 -+ c1) / 2 + v)
+	var factor *big.Int
+	if c&1 == 0 {
+		factor = feSub(one, ellswiftC1)
+	} else {
+		factor = feAdd(one, ellswiftC1)
+	}
+	t := feMul(w, feAdd(feMul(feMul(u, factor), ellswiftHalf), v))
+	switch c & 5 {
+	case 0, 5:
+		t = feNeg(t)
+	}
+	return t
+}
+
+// ellswiftEncode encodes the passed public key using random bytes from rng.
+func ellswiftEncode(pubKey *PublicKey, rng io.Reader) ([EllswiftPubKeyLen]byte,
+	error) {
+
+	var enc [EllswiftPubKeyLen]byte
+	var buf [33]byte
+	for i := 0; i < maxEllswiftEncodeAttempts; i++ {
+		if _, err := io.ReadFull(rng, buf[:]); err != nil {
+			return enc, err
+		}
+		u := feMod(new(big.Int).SetBytes(buf[:32]))
+		if u.Sign() == 0 {
+			continue
+		}
+		t := xSwiftECInv(pubKey.X, u, int(buf[32]&7))
+		if t == nil {
+			continue
+		}
+
+		// The decoder derives the parity of the y coordinate from t,
+		// which doesn't affect the decoded x coordinate.
+		if isOdd(t) != isOdd(pubKey.Y) {
+			t = feNeg(t)
+		}
+
+		ub, tb := u.Bytes(), t.Bytes()
+		copy(enc[32-len(ub):32], ub)
+		copy(enc[64-len(tb):], tb)
+		return enc, nil
+	}
+	return enc, errEllswiftEncode
+}
+
+// EllswiftEncode returns a random ElligatorSwift encoding of the passed public
+// key as defined by BIP-324.  The 64-byte encoding is indistinguishable from
+// uniformly random bytes and decodes to the same public key, including the
+// parity of its y coordinate.
+func EllswiftEncode(pubKey *PublicKey) ([EllswiftPubKeyLen]byte, error) {
+	return ellswiftEncode(pubKey, rand.Reader)
+}
+
+// EllswiftDecode decodes the passed 64-byte ElligatorSwift encoding into a
+// public key.  Every 64-byte string is a valid encoding, so this can't fail.
+func EllswiftDecode(enc *[EllswiftPubKeyLen]byte) *PublicKey {
+	u := feMod(new(big.Int).SetBytes(enc[:32]))
+	t := feMod(new(big.Int).SetBytes(enc[32:]))
+	x := xSwiftEC(u, t)
+
+	// The result is known to be a valid x coordinate, so decompressing it
+	// can't fail.
+	y, _ := decompressPoint(S256(), x, isOdd(t))
+	return &PublicKey{Curve: S256(), X: x, Y: y}
+}
+
+// NewEllswiftPrivateKey generates a new private key along with a random
+// ElligatorSwift encoding of its public key.
+func NewEllswiftPrivateKey() (*PrivateKey, [EllswiftPubKeyLen]byte, error) {
+	privKey, err := NewPrivateKey(S256())
+	if err != nil {
+		return nil, [EllswiftPubKeyLen]byte{}, err
+	}
+	enc, err := EllswiftEncode(privKey.PubKey())
+	if err != nil {
+		return nil, [EllswiftPubKeyLen]byte{}, err
+	}
+	return privKey, enc, nil
+}
+
+// EllswiftECDHXOnly performs an x-only ECDH exchange between the passed
+// private key and ElligatorSwift encoded public key and returns the x
+// coordinate of the shared point.  It runs in constant time with respect to
+// the private key.
+func EllswiftECDHXOnly(privKey *PrivateKey,
+	theirs *[EllswiftPubKeyLen]byte) [32]byte {
+
+	return ECDHXOnly(privKey, EllswiftDecode(theirs))
+}
+
+// EllswiftBIP324ECDH derives the BIP-324 v2 transport shared secret from the
+// passed private key and the ElligatorSwift encoded public keys of both
+// parties.  The initiator flag states whether ours belongs to the party that
+// initiated the connection, which fixes the order in which the encodings are
+// hashed so both parties arrive at the same secret.
+func EllswiftBIP324ECDH(privKey *PrivateKey, theirs,
+	ours *[EllswiftPubKeyLen]byte, initiator bool) [32]byte {
+
+	x := EllswiftECDHXOnly(privKey, theirs)
+
+	tagHash := sha256.Sum256([]byte(bip324ECDHTag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	if initiator {
+		h.Write(ours[:])
+		h.Write(theirs[:])
+	} else {
+		h.Write(theirs[:])
+		h.Write(ours[:])
+	}
+	h.Write(x[:])
+
+	var secret [32]byte
+	h.Sum(secret[:0])
+	for i := range x {
+		x[i] = 0
+	}
+	return secret
+}
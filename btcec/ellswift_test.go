@@ -0,0 +1,158 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// TestEllswiftDecode ensures decoding ElligatorSwift encodings produces the
+// expected x coordinates, including for inputs that aren't reduced mod p and
+// the test vectors of BIP-324.
+func TestEllswiftDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		enc  string
+		x    string
+	}{{
+		name: "all zero",
+		enc: "0000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		x: "edd1fd3e327ce90cc7a3542614289aee9682003e9cf7dcc9cf2ca9743be5aa0c",
+	}, {
+		name: "u and t equal to p",
+		enc: "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f" +
+			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f",
+		x: "edd1fd3e327ce90cc7a3542614289aee9682003e9cf7dcc9cf2ca9743be5aa0c",
+	}, {
+		name: "BIP-324 ellswift_decode vector, u = 0",
+		enc: "0000000000000000000000000000000000000000000000000000000000000000" +
+			"01d3475bf7655b0fb2d852921035b2ef607f49069b97454e6795251062741771",
+		x: "b5da00b73cd6560520e7c364086e7cd23a34bf60d0e707be9fc34d4cd5fdfa2c",
+	}, {
+		name: "BIP-324 ellswift_decode vector, u = 0, odd t",
+		enc: "0000000000000000000000000000000000000000000000000000000000000000" +
+			"82277c4a71f9d22e66ece523f8fa08741a7c0912c66a69ce68514bfd3515b49f",
+		x: "f482f2e241753ad0fb89150d8491dc1e34ff0b8acfbb442cfe999e2e5e6fd1d2",
+	}, {
+		name: "BIP-324 ellswift_decode vector, u = 0, even t",
+		enc: "0000000000000000000000000000000000000000000000000000000000000000" +
+			"8421cc930e77c9f514b6915c3dbe2a94c6d8f690b5b739864ba6789fb8a55dd0",
+		x: "9f59c40275f5085a006f05dae77eb98c6fd0db1ab4a72ac47eae90a4fc9e57e0",
+	}, {
+		name: "BIP-324 packet vector 0, in_ellswift_ours",
+		enc: "ec0adff257bbfe500c188c80b4fdd640f6b45a482bbc15fc7cef5931deff0aa1" +
+			"86f6eb9bba7b85dc4dcc28b28722de1e3d9108b985e2967045668f66098e475b",
+		x: "19e965bc20fc40614e33f2f82d4eeff81b5e7516b12a5c6c0d6053527eba0923",
+	}}
+
+	for _, test := range tests {
+		var enc [EllswiftPubKeyLen]byte
+		copy(enc[:], hexToBytes(test.enc))
+		pubKey := EllswiftDecode(&enc)
+		if !S256().IsOnCurve(pubKey.X, pubKey.Y) {
+			t.Errorf("%s: decoded point is not on the curve", test.name)
+			continue
+		}
+		x := hex.EncodeToString(pubKey.X.Bytes())
+		if x != test.x {
+			t.Errorf("%s: unexpected x -- got %s, want %s", test.name,
+				x, test.x)
+		}
+	}
+}
+
+// TestXSwiftECInv ensures every preimage produced by the inverse mapping maps
+// back to the original x coordinate.
+func TestXSwiftECInv(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var buf [32]byte
+	found := 0
+	for i := 0; i < 64; i++ {
+		rng.Read(buf[:])
+		privKey, _ := PrivKeyFromBytes(S256(), buf[:])
+		x := privKey.X
+
+		rng.Read(buf[:])
+		u := feMod(new(big.Int).SetBytes(buf[:]))
+		for c := 0; c < 8; c++ {
+			tVal := xSwiftECInv(x, u, c)
+			if tVal == nil {
+				continue
+			}
+			found++
+			if got := xSwiftEC(u, tVal); got.Cmp(x) != 0 {
+				t.Fatalf("case %d: xSwiftEC(u, t) = %x, want %x", c,
+					got, x)
+			}
+		}
+	}
+	if found == 0 {
+		t.Fatal("no preimages found")
+	}
+}
+
+// TestEllswiftEncodeRoundTrip ensures encoding a public key and decoding the
+// result gives back the same key, including the parity of its y coordinate.
+func TestEllswiftEncodeRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	var buf [32]byte
+	for i := 0; i < 32; i++ {
+		rng.Read(buf[:])
+		_, pubKey := PrivKeyFromBytes(S256(), buf[:])
+
+		enc, err := ellswiftEncode(pubKey, rng)
+		if err != nil {
+			t.Fatalf("unable to encode: %v", err)
+		}
+		got := EllswiftDecode(&enc)
+		if !got.IsEqual(pubKey) {
+			t.Fatalf("round trip mismatch -- got %x, want %x",
+				got.SerializeCompressed(),
+				pubKey.SerializeCompressed())
+		}
+	}
+}
+
+// TestEllswiftBIP324ECDH ensures both sides of a BIP-324 key exchange derive
+// the same shared secret and that it differs from the x-only ECDH result.
+func TestEllswiftBIP324ECDH(t *testing.T) {
+	privA, encA, err := NewEllswiftPrivateKey()
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	privB, encB, err := NewEllswiftPrivateKey()
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+
+	xA := EllswiftECDHXOnly(privA, &encB)
+	xB := EllswiftECDHXOnly(privB, &encA)
+	if xA != xB {
+		t.Fatalf("x-only secrets mismatch: %x != %x", xA, xB)
+	}
+	if want := ECDHXOnly(privA, privB.PubKey()); xA != want {
+		t.Fatalf("x-only secret mismatch: %x != %x", xA, want)
+	}
+
+	secretA := EllswiftBIP324ECDH(privA, &encB, &encA, true)
+	secretB := EllswiftBIP324ECDH(privB, &encA, &encB, false)
+	if secretA != secretB {
+		t.Fatalf("shared secrets mismatch: %x != %x", secretA, secretB)
+	}
+	if bytes.Equal(secretA[:], xA[:]) {
+		t.Fatal("shared secret is not hashed")
+	}
+
+	// Both parties claiming to be the initiator must not agree.
+	secretB = EllswiftBIP324ECDH(privB, &encA, &encB, true)
+	if secretA == secretB {
+		t.Fatal("shared secrets match despite inconsistent roles")
+	}
+}
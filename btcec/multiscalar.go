@@ -0,0 +1,202 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcec
+
+import (
+	"math/big"
+)
+
+// pippengerThreshold is the number of points at which MultiScalarMult
+// switches from Strauss' algorithm to Pippenger's bucket method.  Below it,
+// the per-window bucket accumulation of Pippenger's method costs more than
+// the additional point additions Strauss' algorithm performs.  The value is
+// in line with the crossover point libsecp256k1 uses.
+const pippengerThreshold = 88
+
+// MultiScalarMult returns the sum of ks[i]*points[i] for all i where every k
+// is a big endian integer.  This is considerably faster than computing each
+// product with ScalarMult and adding up the results since all products share
+// a single chain of point doublings.
+//
+// Strauss' algorithm with the GLV endomorphism is used for small inputs and
+// Pippenger's bucket method for large ones.  Like ScalarMult, this function
+// does not run in constant time and must not be used with secret scalars.
+//
+// The function panics if the number of points and scalars differ.  The point
+// at infinity (0, 0) is returned when no points are passed.
+func (curve *KoblitzCurve) MultiScalarMult(points []*PublicKey,
+	ks [][]byte) (*big.Int, *big.Int) {
+
+	if len(points) != len(ks) {
+		panic("btcec: MultiScalarMult called with a different number " +
+			"of points and scalars")
+	}
+
+	var qx, qy, qz fieldVal
+	if len(points) < pippengerThreshold {
+		curve.straussMult(points, ks, &qx, &qy, &qz)
+	} else {
+		curve.pippengerMult(points, ks, &qx, &qy, &qz)
+	}
+	return curve.fieldJacobianToBigAffine(&qx, &qy, &qz)
+}
+
+// nafTerm is a single point along with the NAF representation of the scalar
+// it is multiplied with.
+type nafTerm struct {
+	x, y, yNeg *fieldVal
+	pos, neg   []byte
+}
+
+// straussMult computes the multi-scalar multiplication using Strauss'
+// interleaving method and stores the Jacobian result in (qx, qy, qz).  Every
+// scalar is split into two half-length scalars using the endomorphism in the
+// same way ScalarMult does, after which all NAF terms are processed
+// left-to-right with a single shared doubling per bit.
+func (curve *KoblitzCurve) straussMult(points []*PublicKey, ks [][]byte,
+	qx, qy, qz *fieldVal) {
+
+	terms := make([]nafTerm, 0, 2*len(points))
+	maxLen := 0
+	for i, point := range points {
+		k1, k2, signK1, signK2 := curve.splitK(curve.moduloReduce(ks[i]))
+
+		// P1 is the point itself and P2 is ϕ(P) = (βx, y).
+		p1x, p1y := curve.bigAffineToField(point.X, point.Y)
+		p1yNeg := new(fieldVal).NegateVal(p1y, 1)
+		p2x := new(fieldVal).Mul2(p1x, curve.beta)
+		p2y := new(fieldVal).Set(p1y)
+		p2yNeg := new(fieldVal).NegateVal(p2y, 1)
+		if signK1 == -1 {
+			p1y, p1yNeg = p1yNeg, p1y
+		}
+		if signK2 == -1 {
+			p2y, p2yNeg = p2yNeg, p2y
+		}
+
+		k1Pos, k1Neg := NAF(k1)
+		k2Pos, k2Neg := NAF(k2)
+		terms = append(terms,
+			nafTerm{x: p1x, y: p1y, yNeg: p1yNeg, pos: k1Pos, neg: k1Neg},
+			nafTerm{x: p2x, y: p2y, yNeg: p2yNeg, pos: k2Pos, neg: k2Neg},
+		)
+		if len(k1Pos) > maxLen {
+			maxLen = len(k1Pos)
+		}
+		if len(k2Pos) > maxLen {
+			maxLen = len(k2Pos)
+		}
+	}
+
+	// Add left-to-right using the NAF digits of all terms.  Shorter NAF
+	// representations are treated as if padded with leading zeros.
+	one := new(fieldVal).SetInt(1)
+	for i := 0; i < maxLen; i++ {
+		for bit := 7; bit >= 0; bit-- {
+			// Q = 2 * Q
+			curve.doubleJacobian(qx, qy, qz, qx, qy, qz)
+
+			for t := range terms {
+				term := &terms[t]
+				idx := i - (maxLen - len(term.pos))
+				if idx < 0 {
+					continue
+				}
+				mask := byte(1) << uint(bit)
+				switch {
+				case term.pos[idx]&mask != 0:
+					curve.addJacobian(qx, qy, qz, term.x,
+						term.y, one, qx, qy, qz)
+				case term.neg[idx]&mask != 0:
+					curve.addJacobian(qx, qy, qz, term.x,
+						term.yNeg, one, qx, qy, qz)
+				}
+			}
+		}
+	}
+}
+
+// pippengerWindow returns the window size in bits to use with Pippenger's
+// method for the given number of points.  The window roughly grows with the
+// logarithm of the number of points, which balances the number of bucket
+// additions against the number of buckets to sum up per window.
+func pippengerWindow(numPoints int) uint {
+	switch {
+	case numPoints < 200:
+		return 5
+	case numPoints < 800:
+		return 6
+	case numPoints < 3000:
+		return 7
+	case numPoints < 10000:
+		return 8
+	default:
+		return 9
+	}
+}
+
+// pippengerMult computes the multi-scalar multiplication using Pippenger's
+// bucket method and stores the Jacobian result in (qx, qy, qz).
+func (curve *KoblitzCurve) pippengerMult(points []*PublicKey, ks [][]byte,
+	qx, qy, qz *fieldVal) {
+
+	// Reduce all scalars and convert all points to field values up front.
+	type affine struct{ x, y *fieldVal }
+	scalars := make([]*big.Int, len(points))
+	fieldPoints := make([]affine, len(points))
+	for i, point := range points {
+		scalars[i] = new(big.Int).SetBytes(ks[i])
+		scalars[i].Mod(scalars[i], curve.N)
+		x, y := curve.bigAffineToField(point.X, point.Y)
+		fieldPoints[i] = affine{x, y}
+	}
+
+	window := pippengerWindow(len(points))
+	numBuckets := 1<<window - 1
+	bx := make([]fieldVal, numBuckets)
+	by := make([]fieldVal, numBuckets)
+	bz := make([]fieldVal, numBuckets)
+	one := new(fieldVal).SetInt(1)
+
+	bitLen := curve.N.BitLen()
+	numWindows := (bitLen + int(window) - 1) / int(window)
+	for w := numWindows - 1; w >= 0; w-- {
+		// Shift the accumulated result by the window size.
+		for i := uint(0); i < window; i++ {
+			curve.doubleJacobian(qx, qy, qz, qx, qy, qz)
+		}
+
+		// Sort every point into the bucket for its window digit.
+		for i := range bx {
+			bx[i].Zero()
+			by[i].Zero()
+			bz[i].Zero()
+		}
+		for i, k := range scalars {
+			digit := 0
+			for b := int(window) - 1; b >= 0; b-- {
+				digit = digit<<1 | int(k.Bit(w*int(window)+b))
+			}
+			if digit == 0 {
+				continue
+			}
+			p := &fieldPoints[i]
+			curve.addJacobian(&bx[digit-1], &by[digit-1],
+				&bz[digit-1], p.x, p.y, one, &bx[digit-1],
+				&by[digit-1], &bz[digit-1])
+		}
+
+		// Compute sum(d * bucket[d]) using running sums, which only
+		// needs two additions per bucket.
+		var sx, sy, sz, ax, ay, az fieldVal
+		for d := numBuckets - 1; d >= 0; d-- {
+			curve.addJacobian(&sx, &sy, &sz, &bx[d], &by[d], &bz[d],
+				&sx, &sy, &sz)
+			curve.addJacobian(&ax, &ay, &az, &sx, &sy, &sz, &ax, &ay,
+				&az)
+		}
+		curve.addJacobian(qx, qy, qz, &ax, &ay, &az, qx, qy, qz)
+	}
+}
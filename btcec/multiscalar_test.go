@@ -0,0 +1,83 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcec
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// naiveMultiScalarMult computes the sum of ks[i]*points[i] using individual
+// scalar multiplications and point additions.
+func naiveMultiScalarMult(points []*PublicKey, ks [][]byte) (*big.Int, *big.Int) {
+	curve := S256()
+	x, y := new(big.Int), new(big.Int)
+	for i, point := range points {
+		px, py := curve.ScalarMult(point.X, point.Y, ks[i])
+		x, y = curve.Add(x, y, px, py)
+	}
+	return x, y
+}
+
+// TestMultiScalarMult ensures the multi-scalar multiplication agrees with
+// summing up individual scalar multiplications for both the Strauss and the
+// Pippenger code paths.
+func TestMultiScalarMult(t *testing.T) {
+	curve := S256()
+	rng := rand.New(rand.NewSource(1))
+
+	randPoint := func() *PublicKey {
+		k := make([]byte, 32)
+		rng.Read(k)
+		x, y := curve.ScalarBaseMult(k)
+		return &PublicKey{Curve: curve, X: x, Y: y}
+	}
+	randScalar := func() []byte {
+		k := make([]byte, 32)
+		rng.Read(k)
+		return k
+	}
+
+	for _, n := range []int{0, 1, 2, 7, pippengerThreshold - 1,
+		pippengerThreshold, 250} {
+
+		points := make([]*PublicKey, n)
+		ks := make([][]byte, n)
+		for i := range points {
+			points[i] = randPoint()
+			ks[i] = randScalar()
+		}
+
+		// Exercise a few edge cases on the larger inputs: a zero
+		// scalar, a scalar larger than the group order and a point
+		// cancelled out by its negation.
+		if n > 4 {
+			ks[0] = nil
+			ks[1] = new(big.Int).Add(curve.N, big.NewInt(5)).Bytes()
+			negY := new(big.Int).Sub(curve.P, points[2].Y)
+			points[3] = &PublicKey{Curve: curve, X: points[2].X, Y: negY}
+			ks[3] = ks[2]
+		}
+
+		gotX, gotY := curve.MultiScalarMult(points, ks)
+		wantX, wantY := naiveMultiScalarMult(points, ks)
+		if gotX.Cmp(wantX) != 0 || gotY.Cmp(wantY) != 0 {
+			t.Errorf("n=%d: got (%x, %x), want (%x, %x)", n, gotX,
+				gotY, wantX, wantY)
+		}
+	}
+}
+
+// TestMultiScalarMultMismatch ensures MultiScalarMult panics when the number
+// of points and scalars differ.
+func TestMultiScalarMultMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("MultiScalarMult did not panic")
+		}
+	}()
+	S256().MultiScalarMult([]*PublicKey{{}}, nil)
+}
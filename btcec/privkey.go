@@ -59,7 +59,22 @@ func (p *PrivateKey) ToECDSA() *ecdsa.PrivateKey {
 // is deterministic (same message and same key yield the same signature) and canonical
 // in accordance with RFC6979 and BIP0062.
 func (p *PrivateKey) Sign(hash []byte) (*Signature, error) {
-	return signRFC6979(p, hash)
+	return signRFC6979(p, hash, nil)
+}
+
+// SignWithOptions generates an ECDSA signature for the provided hash like Sign
+// does, except the derivation of the nonce is altered by the passed options,
+// such as extra entropy or an explicit nonce counter.  Without any options, the
+// signature is the same one Sign produces.
+func (p *PrivateKey) SignWithOptions(hash []byte, opts ...SignOption) (*Signature, error) {
+	return signRFC6979WithOptions(p, hash, opts...)
+}
+
+// SignLowR generates an ECDSA signature for the provided hash like Sign does,
+// except the nonce is ground until the R value of the signature is below 2^255.
+// Such a signature serializes to at most 70 bytes in DER format.
+func (p *PrivateKey) SignLowR(hash []byte) (*Signature, error) {
+	return signRFC6979WithOptions(p, hash, WithLowR())
 }
 
 // PrivKeyBytesLen defines the length in bytes of a serialized private key.
@@ -0,0 +1,25 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package schnorr implements BIP-340 schnorr signatures over the secp256k1 curve
+of the btcec package.
+
+Sign creates a signature of a 32-byte message.  BIP-340 mixes 32 bytes of
+auxiliary randomness into the nonce to protect against side channels, which
+are read from crypto/rand by default.  Callers which need reproducible
+signatures, such as cross-implementation test suites, can provide the
+randomness with the WithAuxRand option or read it from their own source with
+the WithAuxRandReader option:
+
+	sig, err := schnorr.Sign(privKey, &msg, schnorr.WithAuxRand(aux))
+
+The nonce derivation is exposed as DeriveNonce so audits can check the nonce
+used for a signature given the same inputs, and Verify checks a signature
+against a 32-byte x-only public key as returned by SerializePubKey.
+
+Just like the ECDSA signing of the btcec package, the arithmetic is performed
+with math/big and is therefore not constant time.
+*/
+package schnorr
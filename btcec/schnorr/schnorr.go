@@ -0,0 +1,231 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package schnorr
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+const (
+	// PubKeyBytesLen is the number of bytes of a serialized x-only public
+	// key.
+	PubKeyBytesLen = 32
+
+	// SignatureSize is the number of bytes of a serialized signature.
+	SignatureSize = 64
+)
+
+// The tags of the tagged hashes used by BIP-340 signatures.
+var (
+	auxTag       = []byte("BIP0340/aux")
+	nonceTag     = []byte("BIP0340/nonce")
+	challengeTag = []byte("BIP0340/challenge")
+)
+
+var (
+	// ErrInvalidPrivKey is returned when the private key is zero or not
+	// less than the order of the curve.
+	ErrInvalidPrivKey = errors.New("invalid private key")
+
+	// ErrZeroNonce is returned when the derived nonce is zero, which only
+	// happens with a negligible probability for valid private keys.
+	ErrZeroNonce = errors.New("derived nonce is zero")
+)
+
+// scalarBytes returns the passed integer, which must not exceed 32 bytes, as a
+// 32-byte big-endian byte array.
+func scalarBytes(v *big.Int) [32]byte {
+	var b [32]byte
+	vBytes := v.Bytes()
+	copy(b[32-len(vBytes):], vBytes)
+	return b
+}
+
+// SerializePubKey returns the 32-byte x-only encoding of the passed public key
+// as used by BIP-340.
+func SerializePubKey(pubKey *btcec.PublicKey) [PubKeyBytesLen]byte {
+	return scalarBytes(pubKey.X)
+}
+
+// signOptions houses the options which modify the behavior of Sign.
+type signOptions struct {
+	auxRand    *[32]byte
+	randReader io.Reader
+}
+
+// SignOption is a functional option which modifies the behavior of Sign.
+type SignOption func(*signOptions)
+
+// WithAuxRand returns an option which makes Sign use the passed auxiliary
+// randomness instead of reading it from crypto/rand.  Signatures are
+// deterministic when the same randomness is used, so this is mostly useful for
+// test vectors.
+func WithAuxRand(aux [32]byte) SignOption {
+	return func(o *signOptions) {
+		o.auxRand = &aux
+	}
+}
+
+// WithAuxRandReader returns an option which makes Sign read the auxiliary
+// randomness from the passed reader instead of crypto/rand.
+func WithAuxRandReader(r io.Reader) SignOption {
+	return func(o *signOptions) {
+		o.randReader = r
+	}
+}
+
+// privKeyScalar returns the secret scalar of the passed private key negated as
+// required by BIP-340 so its public key has an even y coordinate along with the
+// x-only public key.
+func privKeyScalar(privKey *btcec.PrivateKey) (*big.Int, [PubKeyBytesLen]byte, error) {
+	n := btcec.S256().N
+	d := new(big.Int).Set(privKey.D)
+	if d.Sign() == 0 || d.Cmp(n) >= 0 {
+		return nil, [PubKeyBytesLen]byte{}, ErrInvalidPrivKey
+	}
+	pubKey := privKey.PubKey()
+	if pubKey.Y.Bit(0) == 1 {
+		d.Sub(n, d)
+	}
+	return d, SerializePubKey(pubKey), nil
+}
+
+// deriveNonce returns the nonce for the passed secret scalar, which must
+// already be negated as required by BIP-340, x-only public key, message and
+// auxiliary randomness.
+func deriveNonce(d *big.Int, pubKeyX, msg, aux *[32]byte) (*big.Int, error) {
+	dBytes := scalarBytes(d)
+	t := chainhash.TaggedHash(auxTag, aux[:])
+	for i := range t {
+		t[i] ^= dBytes[i]
+	}
+
+	nonceHash := chainhash.TaggedHash(nonceTag, t[:], pubKeyX[:], msg[:])
+	k := new(big.Int).SetBytes(nonceHash[:])
+	k.Mod(k, btcec.S256().N)
+	if k.Sign() == 0 {
+		return nil, ErrZeroNonce
+	}
+	return k, nil
+}
+
+// DeriveNonce returns the nonce BIP-340 derives to sign the passed message with
+// the private key and auxiliary randomness.  The returned nonce is the value
+// before it is negated to give the nonce point an even y coordinate, so the
+// x coordinate of the nonce point is the first half of the signature Sign
+// creates for the same inputs.
+//
+// This is exposed so audits can verify the nonce used for a signature and must
+// never be used to share the nonce, since it reveals the private key together
+// with the signature.
+func DeriveNonce(privKey *btcec.PrivateKey, msg, aux *[32]byte) ([32]byte, error) {
+	d, pubKeyX, err := privKeyScalar(privKey)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	k, err := deriveNonce(d, &pubKeyX, msg, aux)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return scalarBytes(k), nil
+}
+
+// Sign creates a BIP-340 signature of the passed message with the private key.
+// The auxiliary randomness is read from crypto/rand unless it is provided with
+// the WithAuxRand or WithAuxRandReader options.
+//
+// NOTE: Just like the ECDSA signing of the btcec package, the arithmetic is
+// performed with math/big and is therefore not constant time.
+func Sign(privKey *btcec.PrivateKey, msg *[32]byte,
+	opts ...SignOption) ([SignatureSize]byte, error) {
+
+	var sig [SignatureSize]byte
+	options := signOptions{randReader: rand.Reader}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	aux := options.auxRand
+	if aux == nil {
+		aux = new([32]byte)
+		if _, err := io.ReadFull(options.randReader, aux[:]); err != nil {
+			return sig, err
+		}
+	}
+
+	d, pubKeyX, err := privKeyScalar(privKey)
+	if err != nil {
+		return sig, err
+	}
+	k, err := deriveNonce(d, &pubKeyX, msg, aux)
+	if err != nil {
+		return sig, err
+	}
+
+	curve := btcec.S256()
+	n := curve.N
+	kBytes := scalarBytes(k)
+	rx, ry := curve.ScalarBaseMult(kBytes[:])
+	if ry.Bit(0) == 1 {
+		k.Sub(n, k)
+	}
+	rBytes := scalarBytes(rx)
+	copy(sig[:32], rBytes[:])
+
+	challenge := chainhash.TaggedHash(challengeTag, sig[:32], pubKeyX[:],
+		msg[:])
+	e := new(big.Int).SetBytes(challenge[:])
+	e.Mod(e, n)
+
+	s := e.Mul(e, d)
+	s.Add(s, k)
+	s.Mod(s, n)
+	sBytes := scalarBytes(s)
+	copy(sig[32:], sBytes[:])
+
+	return sig, nil
+}
+
+// Verify returns whether the passed BIP-340 signature of the message is valid
+// for the x-only public key.
+func Verify(pubKeyX *[PubKeyBytesLen]byte, msg *[32]byte,
+	sig *[SignatureSize]byte) bool {
+
+	curve := btcec.S256()
+
+	pubKey, err := btcec.ParsePubKey(append([]byte{0x02}, pubKeyX[:]...),
+		curve)
+	if err != nil {
+		return false
+	}
+
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if r.Cmp(curve.P) >= 0 || s.Cmp(curve.N) >= 0 {
+		return false
+	}
+
+	challenge := chainhash.TaggedHash(challengeTag, sig[:32], pubKeyX[:],
+		msg[:])
+	e := new(big.Int).SetBytes(challenge[:])
+	e.Mod(e, curve.N)
+
+	// R = s*G - e*P, which is computed as s*G + (n-e)*P.
+	e.Sub(curve.N, e)
+	sx, sy := curve.ScalarBaseMult(sig[32:])
+	ex, ey := curve.ScalarMult(pubKey.X, pubKey.Y, e.Bytes())
+	rx, ry := curve.Add(sx, sy, ex, ey)
+	if rx.Sign() == 0 && ry.Sign() == 0 {
+		return false
+	}
+
+	return ry.Bit(0) == 0 && rx.Cmp(r) == 0
+}
@@ -0,0 +1,271 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package schnorr
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+// hexToArray32 converts the passed hex string into a 32-byte array and panics
+// if there is an error.  This is only provided for the hard-coded constants so
+// errors in the source code can be detected.  It will only (and must only) be
+// called with hard-coded values.
+func hexToArray32(s string) [32]byte {
+	var a [32]byte
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(a) {
+		panic("invalid hex in source file: " + s)
+	}
+	copy(a[:], b)
+	return a
+}
+
+// TestSign ensures signatures match the signing test vectors of BIP-340 no
+// matter how the auxiliary randomness is provided, are accepted by Verify and
+// use the nonce returned by DeriveNonce.
+func TestSign(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		privKey string
+		pubKey  string
+		aux     string
+		msg     string
+		sig     string
+	}{
+		{
+			privKey: "0000000000000000000000000000000000000000000000000000000000000003",
+			pubKey:  "f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+			aux:     "0000000000000000000000000000000000000000000000000000000000000000",
+			msg:     "0000000000000000000000000000000000000000000000000000000000000000",
+			sig:     "e907831f80848d1069a5371b402410364bdf1c5f8307b0084c55f1ce2dca821525f66a4a85ea8b71e482a74f382d2ce5ebeee8fdb2172f477df4900d310536c0",
+		},
+		{
+			privKey: "b7e151628aed2a6abf7158809cf4f3c762e7160f38b4da56a784d9045190cfef",
+			pubKey:  "dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+			aux:     "0000000000000000000000000000000000000000000000000000000000000001",
+			msg:     "243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+			sig:     "6896bd60eeae296db48a229ff71dfe071bde413e6d43f917dc8dcf8c78de33418906d11ac976abccb20b091292bff4ea897efcb639ea871cfa95f6de339e4b0a",
+		},
+		{
+			privKey: "c90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74020bbea63b14e5c9",
+			pubKey:  "dd308afec5777e13121fa72b9cc1b7cc0139715309b086c960e18fd969774eb8",
+			aux:     "c87aa53824b4d7ae2eb035a2b5bbbccc080e76cdc6d1692c4b0b62d798e6d906",
+			msg:     "7e2d58d8b3bcdf1abadec7829054f90dda9805aab56c77333024b9d0a508b75c",
+			sig:     "5831aaeed7b44bb74e5eab94ba9d4294c49bcf2a60728d8b4c200f50dd313c1bab745879a5ad954a72c45a91c3a51d3c7adea98d82f8481e0e1e03674a6f3fb7",
+		},
+		{
+			privKey: "0b432b2677937381aef05bb02a66ecd012773062cf3fa2549e44f58ed2401710",
+			pubKey:  "25d1dff95105f5253c4022f628a996ad3a0d95fbf21d468a1b33f8c160d8f517",
+			aux:     "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			msg:     "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			sig:     "7eb0509757e246f19449885651611cb965ecc1a187dd51b64fda1edc9637d5ec97582b9cb13db3933705b32ba982af5af25fd78881ebb32771fc5922efc66ea3",
+		},
+	}
+
+	for i, test := range tests {
+		keyBytes := hexToArray32(test.privKey)
+		privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), keyBytes[:])
+		aux := hexToArray32(test.aux)
+		msg := hexToArray32(test.msg)
+
+		pubKeyX := SerializePubKey(privKey.PubKey())
+		if got := hex.EncodeToString(pubKeyX[:]); got != test.pubKey {
+			t.Errorf("#%d: mismatched public key: got %s, want %s",
+				i, got, test.pubKey)
+			continue
+		}
+
+		sig, err := Sign(privKey, &msg, WithAuxRand(aux))
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got := hex.EncodeToString(sig[:]); got != test.sig {
+			t.Errorf("#%d: mismatched signature: got %s, want %s",
+				i, got, test.sig)
+			continue
+		}
+
+		readerSig, err := Sign(privKey, &msg,
+			WithAuxRandReader(bytes.NewReader(aux[:])))
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if readerSig != sig {
+			t.Errorf("#%d: mismatched signature with randomness "+
+				"from reader: got %x, want %s", i, readerSig,
+				test.sig)
+			continue
+		}
+
+		nonce, err := DeriveNonce(privKey, &msg, &aux)
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		rx, _ := btcec.S256().ScalarBaseMult(nonce[:])
+		if r := scalarBytes(rx); !bytes.Equal(r[:], sig[:32]) {
+			t.Errorf("#%d: nonce point %x doesn't match signature",
+				i, r)
+			continue
+		}
+
+		if !Verify(&pubKeyX, &msg, &sig) {
+			t.Errorf("#%d: valid signature rejected", i)
+		}
+		msg[0] ^= 0x01
+		if Verify(&pubKeyX, &msg, &sig) {
+			t.Errorf("#%d: signature of other message accepted", i)
+		}
+	}
+}
+
+// TestVerify ensures signatures are verified according to the
+// verification test vectors of BIP-340 with 32-byte messages.
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		pubKey string
+		msg    string
+		sig    string
+		valid  bool
+	}{
+		{
+			name:   "valid signature with leading zeros",
+			pubKey: "d69c3509bb99e412e68b0fe8544e72837dfa30746d8be2aa65975f29d22dc7b9",
+			msg:    "4df3c3f68fcc83b27e9d42c90431a72499f17875c81a599b566c9889b9696703",
+			sig:    "00000000000000000000003b78ce563f89a0ed9414f5aa28ad0d96d6795f9c6376afb1548af603b3eb45c9f8207dee1060cb71c04e80f593060b07d28308d7f4",
+			valid:  true,
+		},
+		{
+			name:   "public key not on the curve",
+			pubKey: "eefdea4cdb677750a420fee807eacf21eb9898ae79b9768766e4faa04a2d4a34",
+			msg:    "243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+			sig:    "6cff5c3ba86c69ea4b7376f31a9bcb4f74c1976089b2d9963da2e5543e17776969e89b4c5564d00349106b8497785dd7d1d713a8ae82b32fa79d5f7fc407d39b",
+			valid:  false,
+		},
+		{
+			name:   "has_even_y(R) is false",
+			pubKey: "dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+			msg:    "243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+			sig:    "fff97bd5755eeea420453a14355235d382f6472f8568a18b2f057a14602975563cc27944640ac607cd107ae10923d9ef7a73c643e166be5ebeafa34b1ac553e2",
+			valid:  false,
+		},
+		{
+			name:   "negated message",
+			pubKey: "dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+			msg:    "243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+			sig:    "1fa62e331edbc21c394792d2ab1100a7b432b013df3f6ff4f99fcb33e0e1515f28890b3edb6e7189b630448b515ce4f8622a954cfe545735aaea5134fccdb2bd",
+			valid:  false,
+		},
+		{
+			name:   "negated s value",
+			pubKey: "dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+			msg:    "243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+			sig:    "6cff5c3ba86c69ea4b7376f31a9bcb4f74c1976089b2d9963da2e5543e177769961764b3aa9b2ffcb6ef947b6887a226e8d7c93e00c5ed0c1834ff0d0c2e6da6",
+			valid:  false,
+		},
+		{
+			name:   "sG - eP is infinite with x(inf) as 0",
+			pubKey: "dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+			msg:    "243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+			sig:    "0000000000000000000000000000000000000000000000000000000000000000123dda8328af9c23a94c1feecfd123ba4fb73476f0d594dcb65c6425bd186051",
+			valid:  false,
+		},
+		{
+			name:   "sG - eP is infinite with x(inf) as 1",
+			pubKey: "dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+			msg:    "243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+			sig:    "00000000000000000000000000000000000000000000000000000000000000017615fbaf5ae28864013c099742deadb4dba87f11ac6754f93780d5a1837cf197",
+			valid:  false,
+		},
+		{
+			name:   "sig[0:32] is not an X coordinate on the curve",
+			pubKey: "dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+			msg:    "243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+			sig:    "4a298dacae57395a15d0795ddbfd1dcb564da82b0f269bc70a74f8220429ba1d69e89b4c5564d00349106b8497785dd7d1d713a8ae82b32fa79d5f7fc407d39b",
+			valid:  false,
+		},
+		{
+			name:   "sig[0:32] is equal to field size",
+			pubKey: "dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+			msg:    "243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+			sig:    "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f69e89b4c5564d00349106b8497785dd7d1d713a8ae82b32fa79d5f7fc407d39b",
+			valid:  false,
+		},
+		{
+			name:   "sig[32:64] is equal to curve order",
+			pubKey: "dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+			msg:    "243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+			sig:    "6cff5c3ba86c69ea4b7376f31a9bcb4f74c1976089b2d9963da2e5543e177769fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141",
+			valid:  false,
+		},
+		{
+			name:   "public key exceeds the field size",
+			pubKey: "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc30",
+			msg:    "243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+			sig:    "6cff5c3ba86c69ea4b7376f31a9bcb4f74c1976089b2d9963da2e5543e17776969e89b4c5564d00349106b8497785dd7d1d713a8ae82b32fa79d5f7fc407d39b",
+			valid:  false,
+		},
+	}
+
+	for _, test := range tests {
+		pubKeyX := hexToArray32(test.pubKey)
+		msg := hexToArray32(test.msg)
+		sigBytes, err := hex.DecodeString(test.sig)
+		if err != nil || len(sigBytes) != 64 {
+			t.Fatalf("%s: invalid test signature", test.name)
+		}
+		var sig [64]byte
+		copy(sig[:], sigBytes)
+
+		if got := Verify(&pubKeyX, &msg, &sig); got != test.valid {
+			t.Errorf("%s: got valid %v, want %v", test.name, got,
+				test.valid)
+		}
+	}
+}
+
+// TestSignErrors ensures Sign rejects invalid private keys and fails when the
+// auxiliary randomness can't be read.
+func TestSignErrors(t *testing.T) {
+	t.Parallel()
+
+	var msg [32]byte
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to create private key: %v", err)
+	}
+	_, err = Sign(privKey, &msg, WithAuxRandReader(bytes.NewReader(nil)))
+	if err == nil {
+		t.Fatal("signing with exhausted randomness reader succeeded")
+	}
+
+	// Signatures with randomness from crypto/rand are valid.
+	sig, err := Sign(privKey, &msg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pubKeyX := SerializePubKey(privKey.PubKey())
+	if !Verify(&pubKeyX, &msg, &sig) {
+		t.Fatal("valid signature rejected")
+	}
+
+	zeroKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), make([]byte, 32))
+	if _, err := Sign(zeroKey, &msg); err != ErrInvalidPrivKey {
+		t.Fatalf("unexpected error for zero private key: %v", err)
+	}
+	if _, err := DeriveNonce(zeroKey, &msg, &msg); err != ErrInvalidPrivKey {
+		t.Fatalf("unexpected error for zero private key: %v", err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"crypto/elliptic"
 	"crypto/hmac"
 	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"hash"
@@ -374,6 +375,27 @@ func SignCompact(curve *KoblitzCurve, key *PrivateKey,
 		return nil, err
 	}
 
+	return serializeCompact(curve, key, sig, hash, isCompressedKey)
+}
+
+// SignCompactLowR produces a compact signature of the data in hash with the
+// given private key like SignCompact, except the nonce is ground the same way
+// as SignLowR does, so the R value of the signature is always below 2^255.
+func SignCompactLowR(curve *KoblitzCurve, key *PrivateKey,
+	hash []byte, isCompressedKey bool) ([]byte, error) {
+	sig, err := key.SignWithOptions(hash, WithLowR())
+	if err != nil {
+		return nil, err
+	}
+
+	return serializeCompact(curve, key, sig, hash, isCompressedKey)
+}
+
+// serializeCompact returns the passed signature of the data in hash with the
+// given private key in the compact format described by SignCompact.
+func serializeCompact(curve *KoblitzCurve, key *PrivateKey, sig *Signature,
+	hash []byte, isCompressedKey bool) ([]byte, error) {
+
 	// bitcoind checks the bit length of R and S here. The ecdsa signature
 	// algorithm returns R and S mod N therefore they will be the bitsize of
 	// the curve, and thus correctly sized.
@@ -437,13 +459,93 @@ func RecoverCompact(curve *KoblitzCurve, signature,
 	return key, ((signature[0] - 27) & 4) == 4, nil
 }
 
+// SignOption is a functional option argument to SignWithOptions.
+type SignOption func(*signOptions)
+
+// signOptions houses the options which alter the nonce derivation when signing.
+type signOptions struct {
+	extraEntropy *[32]byte
+	nonceCounter uint32
+	lowR         bool
+}
+
+// WithExtraEntropy mixes the passed entropy into the RFC 6979 nonce derivation
+// as additional data as described in section 3.6 of RFC 6979.  The signature
+// is still deterministic for the same entropy.
+func WithExtraEntropy(entropy [32]byte) SignOption {
+	return func(o *signOptions) {
+		o.extraEntropy = &entropy
+	}
+}
+
+// WithNonceCounter mixes the passed counter into the RFC 6979 nonce derivation
+// as additional data.  A non-zero counter is encoded as a 32-byte little-endian
+// integer, which derives the same nonces as Bitcoin Core.  A zero counter does
+// not alter the nonce.
+func WithNonceCounter(counter uint32) SignOption {
+	return func(o *signOptions) {
+		o.nonceCounter = counter
+	}
+}
+
+// WithLowR grinds the nonce by incrementing the nonce counter until the R value
+// of the signature is below 2^255.  Such a signature serializes to at most 70
+// bytes in DER format, one byte less than half of all other signatures, which
+// is what Bitcoin Core does when signing transactions.
+func WithLowR() SignOption {
+	return func(o *signOptions) {
+		o.lowR = true
+	}
+}
+
+// additionalData returns the additional data to mix into the RFC 6979 nonce
+// derivation for the options and passed nonce counter, which is the extra
+// entropy followed by the encoded counter, if any.
+func (o *signOptions) additionalData(counter uint32) []byte {
+	var data []byte
+	if o.extraEntropy != nil {
+		data = append(data, o.extraEntropy[:]...)
+	}
+	if counter != 0 {
+		var encodedCounter [32]byte
+		binary.LittleEndian.PutUint32(encodedCounter[:], counter)
+		data = append(data, encodedCounter[:]...)
+	}
+	return data
+}
+
+// signRFC6979WithOptions generates a deterministic ECDSA signature according to
+// RFC 6979 and BIP 62 with the nonce derivation altered by the passed options.
+func signRFC6979WithOptions(privateKey *PrivateKey, hash []byte,
+	opts ...SignOption) (*Signature, error) {
+
+	var options signOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	counter := options.nonceCounter
+	for {
+		sig, err := signRFC6979(privateKey, hash,
+			options.additionalData(counter))
+		if err != nil {
+			return nil, err
+		}
+		if !options.lowR || sig.R.BitLen() < 256 {
+			return sig, nil
+		}
+		counter++
+	}
+}
+
 // signRFC6979 generates a deterministic ECDSA signature according to RFC 6979 and BIP 62.
-func signRFC6979(privateKey *PrivateKey, hash []byte) (*Signature, error) {
+// The optional extra data is mixed into the nonce derivation as additional data.
+func signRFC6979(privateKey *PrivateKey, hash, extra []byte) (*Signature, error) {
 
 	privkey := privateKey.ToECDSA()
 	N := S256().N
 	halfOrder := S256().halfOrder
-	k := nonceRFC6979(privkey.D, hash)
+	k := nonceRFC6979(privkey.D, hash, extra)
 	inv := new(big.Int).ModInverse(k, N)
 	r, _ := privkey.Curve.ScalarBaseMult(k.Bytes())
 	r.Mod(r, N)
@@ -469,7 +571,9 @@ func signRFC6979(privateKey *PrivateKey, hash []byte) (*Signature, error) {
 
 // nonceRFC6979 generates an ECDSA nonce (`k`) deterministically according to RFC 6979.
 // It takes a 32-byte hash as an input and returns 32-byte nonce to be used in ECDSA algorithm.
-func nonceRFC6979(privkey *big.Int, hash []byte) *big.Int {
+// The optional extra data is appended to the input as additional data as described in
+// section 3.6 of RFC 6979.
+func nonceRFC6979(privkey *big.Int, hash, extra []byte) *big.Int {
 
 	curve := S256()
 	q := curve.Params().N
@@ -480,6 +584,7 @@ func nonceRFC6979(privkey *big.Int, hash []byte) *big.Int {
 	holen := alg().Size()
 	rolen := (qlen + 7) >> 3
 	bx := append(int2octets(x, rolen), bits2octets(hash, curve, rolen)...)
+	bx = append(bx, extra...)
 
 	// Step B
 	v := bytes.Repeat(oneInitializer, holen)
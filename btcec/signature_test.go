@@ -679,7 +679,7 @@ func TestRFC6979(t *testing.T) {
 		hash := sha256.Sum256([]byte(test.msg))
 
 		// Ensure deterministically generated nonce is the expected value.
-		gotNonce := nonceRFC6979(privKey.D, hash[:]).Bytes()
+		gotNonce := nonceRFC6979(privKey.D, hash[:], nil).Bytes()
 		wantNonce := decodeHex(test.nonce)
 		if !bytes.Equal(gotNonce, wantNonce) {
 			t.Errorf("NonceRFC6979 #%d (%s): Nonce is incorrect: "+
@@ -706,6 +706,104 @@ func TestRFC6979(t *testing.T) {
 	}
 }
 
+// TestSignWithOptions ensures the signing options alter the nonce derivation
+// as expected while still producing valid deterministic signatures.
+func TestSignWithOptions(t *testing.T) {
+	privKey, _ := PrivKeyFromBytes(S256(), decodeHex("cca9fbcc1b41e5a95d"+
+		"369eaa6ddcff73b61a4efaa279cfc6567e8daa39cbaf50"))
+	hash := sha256.Sum256([]byte("sample"))
+
+	sign := func(opts ...SignOption) *Signature {
+		t.Helper()
+		sig, err := privKey.SignWithOptions(hash[:], opts...)
+		if err != nil {
+			t.Fatalf("unexpected error signing: %v", err)
+		}
+		if !sig.Verify(hash[:], privKey.PubKey()) {
+			t.Fatalf("invalid signature %x", sig.Serialize())
+		}
+		return sig
+	}
+
+	// Signing without options or with a zero nonce counter must produce
+	// the same signature as Sign.
+	defaultSig, err := privKey.Sign(hash[:])
+	if err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+	if !sign().IsEqual(defaultSig) ||
+		!sign(WithNonceCounter(0)).IsEqual(defaultSig) {
+
+		t.Fatalf("signature without options differs from Sign")
+	}
+
+	// Extra entropy and nonce counters must derive different, but still
+	// deterministic, signatures.
+	entropy := sha256.Sum256([]byte("entropy"))
+	entropySig := sign(WithExtraEntropy(entropy))
+	counterSig := sign(WithNonceCounter(1))
+	if entropySig.IsEqual(defaultSig) || counterSig.IsEqual(defaultSig) ||
+		entropySig.IsEqual(counterSig) {
+
+		t.Fatalf("signing options did not alter the signature")
+	}
+	if !sign(WithExtraEntropy(entropy)).IsEqual(entropySig) ||
+		!sign(WithNonceCounter(1)).IsEqual(counterSig) {
+
+		t.Fatalf("signing with options is not deterministic")
+	}
+
+	// The nonce counter is encoded as additional data the same way as
+	// Bitcoin Core does.
+	var extra [32]byte
+	extra[0] = 1
+	wantNonce := nonceRFC6979(privKey.D, hash[:], extra[:])
+	wantR, _ := S256().ScalarBaseMult(wantNonce.Bytes())
+	wantR.Mod(wantR, S256().N)
+	if counterSig.R.Cmp(wantR) != 0 {
+		t.Fatalf("unexpected R for nonce counter: %x, want %x",
+			counterSig.R, wantR)
+	}
+}
+
+// TestSignLowR ensures low R signatures always have an R value below 2^255 and
+// serialize to at most 70 bytes.
+func TestSignLowR(t *testing.T) {
+	privKey, _ := PrivKeyFromBytes(S256(), decodeHex("e91671c46231f833a6"+
+		"406ccbea0e3e392c76c167bac1cb013f6f1013980455c2"))
+	for i := 0; i < 32; i++ {
+		hash := sha256.Sum256([]byte{byte(i)})
+		sig, err := privKey.SignLowR(hash[:])
+		if err != nil {
+			t.Fatalf("unexpected error signing #%d: %v", i, err)
+		}
+		if sig.R.BitLen() >= 256 || len(sig.Serialize()) > 70 {
+			t.Fatalf("signature #%d is not low R: %x", i,
+				sig.Serialize())
+		}
+		if !sig.Verify(hash[:], privKey.PubKey()) {
+			t.Fatalf("invalid signature #%d: %x", i, sig.Serialize())
+		}
+
+		compactSig, err := SignCompactLowR(S256(), privKey, hash[:],
+			true)
+		if err != nil {
+			t.Fatalf("unexpected error signing compact #%d: %v", i,
+				err)
+		}
+		if compactSig[1] >= 0x80 {
+			t.Fatalf("compact signature #%d is not low R: %x", i,
+				compactSig)
+		}
+		pubKey, compressed, err := RecoverCompact(S256(), compactSig,
+			hash[:])
+		if err != nil || !compressed || !pubKey.IsEqual(privKey.PubKey()) {
+			t.Fatalf("unable to recover key from compact signature "+
+				"#%d: %v", i, err)
+		}
+	}
+}
+
 func TestSignatureIsEqual(t *testing.T) {
 	sig1 := &Signature{
 		R: fromHex("0082235e21a2300022738dabb8e1bbd9d19cfb1e7ab8c30a23b0afbb8d178abcf3"),
@@ -122,6 +122,39 @@ func NewDeriveAddressesCmd(descriptor string, descriptorRange *DescriptorRange)
 	}
 }
 
+// PsbtDescriptor is an output descriptor, which may be ranged, used to
+// provide information about the inputs and outputs of a PSBT.
+type PsbtDescriptor struct {
+	Descriptor string           `json:"desc"`
+	Range      *DescriptorRange `json:"range,omitempty"`
+}
+
+// DescriptorProcessPsbtCmd defines the descriptorprocesspsbt JSON-RPC command.
+type DescriptorProcessPsbtCmd struct {
+	Psbt        string
+	Descriptors []PsbtDescriptor
+	SighashType *string `jsonrpcdefault:"\"DEFAULT\""`
+	Bip32Derivs *bool   `jsonrpcdefault:"true"`
+	Finalize    *bool   `jsonrpcdefault:"true"`
+}
+
+// NewDescriptorProcessPsbtCmd returns a new instance which can be used to
+// issue a descriptorprocesspsbt JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewDescriptorProcessPsbtCmd(psbt string, descriptors []PsbtDescriptor,
+	sighashType *string, bip32Derivs, finalize *bool) *DescriptorProcessPsbtCmd {
+
+	return &DescriptorProcessPsbtCmd{
+		Psbt:        psbt,
+		Descriptors: descriptors,
+		SighashType: sighashType,
+		Bip32Derivs: bip32Derivs,
+		Finalize:    finalize,
+	}
+}
+
 // ChangeType defines the different output types to use for the change address
 // of a transaction built by the node.
 type ChangeType string
@@ -135,6 +168,19 @@ var (
 	ChangeTypeBech32 ChangeType = "bech32"
 )
 
+// DumpTxOutSetCmd defines the dumptxoutset JSON-RPC command.
+type DumpTxOutSetCmd struct {
+	Path string
+}
+
+// NewDumpTxOutSetCmd returns a new instance which can be used to issue a
+// dumptxoutset JSON-RPC command.
+func NewDumpTxOutSetCmd(path string) *DumpTxOutSetCmd {
+	return &DumpTxOutSetCmd{
+		Path: path,
+	}
+}
+
 // FundRawTransactionOpts are the different options that can be passed to rawtransaction
 type FundRawTransactionOpts struct {
 	ChangeAddress          *string               `json:"changeAddress,omitempty"`
@@ -619,6 +665,15 @@ func NewGetNodeAddressesCmd(count *int32) *GetNodeAddressesCmd {
 	}
 }
 
+// GetOrphanInfoCmd defines the getorphaninfo JSON-RPC command.
+type GetOrphanInfoCmd struct{}
+
+// NewGetOrphanInfoCmd returns a new instance which can be used to issue a
+// getorphaninfo JSON-RPC command.
+func NewGetOrphanInfoCmd() *GetOrphanInfoCmd {
+	return &GetOrphanInfoCmd{}
+}
+
 // GetPeerInfoCmd defines the getpeerinfo JSON-RPC command.
 type GetPeerInfoCmd struct{}
 
@@ -665,6 +720,24 @@ func NewGetRawTransactionCmd(txHash string, verbose *int) *GetRawTransactionCmd
 	}
 }
 
+// GetSilentPaymentTweaksCmd defines the getsilentpaymenttweaks JSON-RPC
+// command.
+type GetSilentPaymentTweaksCmd struct {
+	StartHeight int32
+	EndHeight   int32
+}
+
+// NewGetSilentPaymentTweaksCmd returns a new instance which can be used to
+// issue a getsilentpaymenttweaks JSON-RPC command.
+func NewGetSilentPaymentTweaksCmd(startHeight,
+	endHeight int32) *GetSilentPaymentTweaksCmd {
+
+	return &GetSilentPaymentTweaksCmd{
+		StartHeight: startHeight,
+		EndHeight:   endHeight,
+	}
+}
+
 // GetTxOutCmd defines the gettxout JSON-RPC command.
 type GetTxOutCmd struct {
 	Txid           string
@@ -792,6 +865,61 @@ func NewReconsiderBlockCmd(blockHash string) *ReconsiderBlockCmd {
 	}
 }
 
+// SaveMempoolCmd defines the savemempool JSON-RPC command.
+type SaveMempoolCmd struct{}
+
+// NewSaveMempoolCmd returns a new instance which can be used to issue a
+// savemempool JSON-RPC command.
+func NewSaveMempoolCmd() *SaveMempoolCmd {
+	return &SaveMempoolCmd{}
+}
+
+// ScanObject is an output descriptor, which may be ranged, whose output scripts
+// are searched for by the scantxoutset command.  Raw output scripts are
+// searched for with raw() descriptors.
+type ScanObject struct {
+	Descriptor string           `json:"desc"`
+	Range      *DescriptorRange `json:"range,omitempty"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for ScanObject.  A
+// scan object can be given as either a descriptor string or an object with the
+// descriptor and its range.
+func (o *ScanObject) UnmarshalJSON(data []byte) error {
+	var desc string
+	if err := json.Unmarshal(data, &desc); err == nil {
+		*o = ScanObject{Descriptor: desc}
+		return nil
+	}
+
+	// Use a type without the UnmarshalJSON method to prevent recursion.
+	type scanObject ScanObject
+	var obj scanObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*o = ScanObject(obj)
+	return nil
+}
+
+// ScanTxOutSetCmd defines the scantxoutset JSON-RPC command.
+type ScanTxOutSetCmd struct {
+	Action      string
+	ScanObjects *[]ScanObject
+}
+
+// NewScanTxOutSetCmd returns a new instance which can be used to issue a
+// scantxoutset JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewScanTxOutSetCmd(action string, scanObjects *[]ScanObject) *ScanTxOutSetCmd {
+	return &ScanTxOutSetCmd{
+		Action:      action,
+		ScanObjects: scanObjects,
+	}
+}
+
 // SearchRawTransactionsCmd defines the searchrawtransactions JSON-RPC command.
 type SearchRawTransactionsCmd struct {
 	Address     string
@@ -973,6 +1101,45 @@ func NewSubmitBlockCmd(hexBlock string, options *SubmitBlockOptions) *SubmitBloc
 	}
 }
 
+// SubmitPackageCmd defines the submitpackage JSON-RPC command.
+type SubmitPackageCmd struct {
+	RawTxs []string
+}
+
+// NewSubmitPackageCmd returns a new instance which can be used to issue a
+// submitpackage JSON-RPC command.  The package consists of a child
+// transaction, which must be the last one, and its parents.
+func NewSubmitPackageCmd(rawTxs []string) *SubmitPackageCmd {
+	return &SubmitPackageCmd{
+		RawTxs: rawTxs,
+	}
+}
+
+// TestMempoolAcceptCmd defines the testmempoolaccept JSON-RPC command.
+type TestMempoolAcceptCmd struct {
+	// RawTxns is a list of raw transactions in hex format.
+	RawTxns []string
+
+	// MaxFeeRate is the maximum fee rate in BTC/kvB that is accepted.
+	// Transactions paying a higher fee rate are rejected.  Set to 0 to
+	// accept any fee rate.
+	MaxFeeRate *float64 `jsonrpcdefault:"0.1"`
+}
+
+// NewTestMempoolAcceptCmd returns a new instance which can be used to issue a
+// testmempoolaccept JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewTestMempoolAcceptCmd(rawTxns []string,
+	maxFeeRate *float64) *TestMempoolAcceptCmd {
+
+	return &TestMempoolAcceptCmd{
+		RawTxns:    rawTxns,
+		MaxFeeRate: maxFeeRate,
+	}
+}
+
 // UptimeCmd defines the uptime JSON-RPC command.
 type UptimeCmd struct{}
 
@@ -981,6 +1148,26 @@ func NewUptimeCmd() *UptimeCmd {
 	return &UptimeCmd{}
 }
 
+// UtxoUpdatePsbtCmd defines the utxoupdatepsbt JSON-RPC command.
+type UtxoUpdatePsbtCmd struct {
+	Psbt        string
+	Descriptors *[]PsbtDescriptor
+}
+
+// NewUtxoUpdatePsbtCmd returns a new instance which can be used to issue a
+// utxoupdatepsbt JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewUtxoUpdatePsbtCmd(psbt string,
+	descriptors *[]PsbtDescriptor) *UtxoUpdatePsbtCmd {
+
+	return &UtxoUpdatePsbtCmd{
+		Psbt:        psbt,
+		Descriptors: descriptors,
+	}
+}
+
 // ValidateAddressCmd defines the validateaddress JSON-RPC command.
 type ValidateAddressCmd struct {
 	Address string
@@ -1051,6 +1238,8 @@ func init() {
 	MustRegisterCmd("decoderawtransaction", (*DecodeRawTransactionCmd)(nil), flags)
 	MustRegisterCmd("decodescript", (*DecodeScriptCmd)(nil), flags)
 	MustRegisterCmd("deriveaddresses", (*DeriveAddressesCmd)(nil), flags)
+	MustRegisterCmd("descriptorprocesspsbt", (*DescriptorProcessPsbtCmd)(nil), flags)
+	MustRegisterCmd("dumptxoutset", (*DumpTxOutSetCmd)(nil), flags)
 	MustRegisterCmd("fundrawtransaction", (*FundRawTransactionCmd)(nil), flags)
 	MustRegisterCmd("getaddednodeinfo", (*GetAddedNodeInfoCmd)(nil), flags)
 	MustRegisterCmd("getbestblockhash", (*GetBestBlockHashCmd)(nil), flags)
@@ -1079,9 +1268,11 @@ func init() {
 	MustRegisterCmd("getnettotals", (*GetNetTotalsCmd)(nil), flags)
 	MustRegisterCmd("getnetworkhashps", (*GetNetworkHashPSCmd)(nil), flags)
 	MustRegisterCmd("getnodeaddresses", (*GetNodeAddressesCmd)(nil), flags)
+	MustRegisterCmd("getorphaninfo", (*GetOrphanInfoCmd)(nil), flags)
 	MustRegisterCmd("getpeerinfo", (*GetPeerInfoCmd)(nil), flags)
 	MustRegisterCmd("getrawmempool", (*GetRawMempoolCmd)(nil), flags)
 	MustRegisterCmd("getrawtransaction", (*GetRawTransactionCmd)(nil), flags)
+	MustRegisterCmd("getsilentpaymenttweaks", (*GetSilentPaymentTweaksCmd)(nil), flags)
 	MustRegisterCmd("gettxout", (*GetTxOutCmd)(nil), flags)
 	MustRegisterCmd("gettxoutproof", (*GetTxOutProofCmd)(nil), flags)
 	MustRegisterCmd("gettxoutsetinfo", (*GetTxOutSetInfoCmd)(nil), flags)
@@ -1091,13 +1282,18 @@ func init() {
 	MustRegisterCmd("ping", (*PingCmd)(nil), flags)
 	MustRegisterCmd("preciousblock", (*PreciousBlockCmd)(nil), flags)
 	MustRegisterCmd("reconsiderblock", (*ReconsiderBlockCmd)(nil), flags)
+	MustRegisterCmd("savemempool", (*SaveMempoolCmd)(nil), flags)
+	MustRegisterCmd("scantxoutset", (*ScanTxOutSetCmd)(nil), flags)
 	MustRegisterCmd("searchrawtransactions", (*SearchRawTransactionsCmd)(nil), flags)
 	MustRegisterCmd("sendrawtransaction", (*SendRawTransactionCmd)(nil), flags)
 	MustRegisterCmd("setgenerate", (*SetGenerateCmd)(nil), flags)
 	MustRegisterCmd("signmessagewithprivkey", (*SignMessageWithPrivKeyCmd)(nil), flags)
 	MustRegisterCmd("stop", (*StopCmd)(nil), flags)
 	MustRegisterCmd("submitblock", (*SubmitBlockCmd)(nil), flags)
+	MustRegisterCmd("submitpackage", (*SubmitPackageCmd)(nil), flags)
+	MustRegisterCmd("testmempoolaccept", (*TestMempoolAcceptCmd)(nil), flags)
 	MustRegisterCmd("uptime", (*UptimeCmd)(nil), flags)
+	MustRegisterCmd("utxoupdatepsbt", (*UtxoUpdatePsbtCmd)(nil), flags)
 	MustRegisterCmd("validateaddress", (*ValidateAddressCmd)(nil), flags)
 	MustRegisterCmd("verifychain", (*VerifyChainCmd)(nil), flags)
 	MustRegisterCmd("verifymessage", (*VerifyMessageCmd)(nil), flags)
@@ -265,6 +265,67 @@ func TestChainSvrCmds(t *testing.T) {
 				Range:      &btcjson.DescriptorRange{Value: []int{0, 2}},
 			},
 		},
+		{
+			name: "descriptorprocesspsbt",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("descriptorprocesspsbt", "1234",
+					[]btcjson.PsbtDescriptor{{Descriptor: "00"}})
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewDescriptorProcessPsbtCmd("1234",
+					[]btcjson.PsbtDescriptor{{Descriptor: "00"}},
+					nil, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"descriptorprocesspsbt","params":["1234",[{"desc":"00"}]],"id":1}`,
+			unmarshalled: &btcjson.DescriptorProcessPsbtCmd{
+				Psbt:        "1234",
+				Descriptors: []btcjson.PsbtDescriptor{{Descriptor: "00"}},
+				SighashType: btcjson.String("DEFAULT"),
+				Bip32Derivs: btcjson.Bool(true),
+				Finalize:    btcjson.Bool(true),
+			},
+		},
+		{
+			name: "descriptorprocesspsbt optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("descriptorprocesspsbt", "1234",
+					[]btcjson.PsbtDescriptor{{
+						Descriptor: "00",
+						Range:      &btcjson.DescriptorRange{Value: []int{0, 2}},
+					}}, btcjson.String("ALL"), btcjson.Bool(false),
+					btcjson.Bool(false))
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewDescriptorProcessPsbtCmd("1234",
+					[]btcjson.PsbtDescriptor{{
+						Descriptor: "00",
+						Range:      &btcjson.DescriptorRange{Value: []int{0, 2}},
+					}}, btcjson.String("ALL"), btcjson.Bool(false),
+					btcjson.Bool(false))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"descriptorprocesspsbt","params":["1234",[{"desc":"00","range":[0,2]}],"ALL",false,false],"id":1}`,
+			unmarshalled: &btcjson.DescriptorProcessPsbtCmd{
+				Psbt: "1234",
+				Descriptors: []btcjson.PsbtDescriptor{{
+					Descriptor: "00",
+					Range:      &btcjson.DescriptorRange{Value: []int{0, 2}},
+				}},
+				SighashType: btcjson.String("ALL"),
+				Bip32Derivs: btcjson.Bool(false),
+				Finalize:    btcjson.Bool(false),
+			},
+		},
+		{
+			name: "dumptxoutset",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("dumptxoutset", "utxo.dat")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewDumpTxOutSetCmd("utxo.dat")
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"dumptxoutset","params":["utxo.dat"],"id":1}`,
+			unmarshalled: &btcjson.DumpTxOutSetCmd{Path: "utxo.dat"},
+		},
 		{
 			name: "getaddednodeinfo",
 			newCmd: func() (interface{}, error) {
@@ -824,6 +885,17 @@ func TestChainSvrCmds(t *testing.T) {
 				Count: btcjson.Int32(10),
 			},
 		},
+		{
+			name: "getorphaninfo",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getorphaninfo")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetOrphanInfoCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getorphaninfo","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetOrphanInfoCmd{},
+		},
 		{
 			name: "getpeerinfo",
 			newCmd: func() (interface{}, error) {
@@ -889,6 +961,20 @@ func TestChainSvrCmds(t *testing.T) {
 				Verbose: btcjson.Int(1),
 			},
 		},
+		{
+			name: "getsilentpaymenttweaks",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getsilentpaymenttweaks", 100, 200)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetSilentPaymentTweaksCmd(100, 200)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getsilentpaymenttweaks","params":[100,200],"id":1}`,
+			unmarshalled: &btcjson.GetSilentPaymentTweaksCmd{
+				StartHeight: 100,
+				EndHeight:   200,
+			},
+		},
 		{
 			name: "gettxout",
 			newCmd: func() (interface{}, error) {
@@ -1062,6 +1148,56 @@ func TestChainSvrCmds(t *testing.T) {
 				BlockHash: "123",
 			},
 		},
+		{
+			name: "savemempool",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("savemempool")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewSaveMempoolCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"savemempool","params":[],"id":1}`,
+			unmarshalled: &btcjson.SaveMempoolCmd{},
+		},
+		{
+			name: "scantxoutset",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("scantxoutset", "status")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewScanTxOutSetCmd("status", nil)
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"scantxoutset","params":["status"],"id":1}`,
+			unmarshalled: &btcjson.ScanTxOutSetCmd{Action: "status"},
+		},
+		{
+			name: "scantxoutset scanobjects",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("scantxoutset", "start",
+					`["raw(00)",{"desc":"raw(51)","range":5}]`)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewScanTxOutSetCmd("start",
+					&[]btcjson.ScanObject{
+						{Descriptor: "raw(00)"},
+						{
+							Descriptor: "raw(51)",
+							Range:      &btcjson.DescriptorRange{Value: 5},
+						},
+					})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"scantxoutset","params":["start",[{"desc":"raw(00)"},{"desc":"raw(51)","range":5}]],"id":1}`,
+			unmarshalled: &btcjson.ScanTxOutSetCmd{
+				Action: "start",
+				ScanObjects: &[]btcjson.ScanObject{
+					{Descriptor: "raw(00)"},
+					{
+						Descriptor: "raw(51)",
+						Range:      &btcjson.DescriptorRange{Value: 5},
+					},
+				},
+			},
+		},
 		{
 			name: "searchrawtransactions",
 			newCmd: func() (interface{}, error) {
@@ -1355,6 +1491,48 @@ func TestChainSvrCmds(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "submitpackage",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("submitpackage", []string{"1122", "3344"})
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewSubmitPackageCmd([]string{"1122", "3344"})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"submitpackage","params":[["1122","3344"]],"id":1}`,
+			unmarshalled: &btcjson.SubmitPackageCmd{
+				RawTxs: []string{"1122", "3344"},
+			},
+		},
+		{
+			name: "testmempoolaccept",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("testmempoolaccept", []string{"1122"})
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewTestMempoolAcceptCmd([]string{"1122"}, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"testmempoolaccept","params":[["1122"]],"id":1}`,
+			unmarshalled: &btcjson.TestMempoolAcceptCmd{
+				RawTxns:    []string{"1122"},
+				MaxFeeRate: btcjson.Float64(0.1),
+			},
+		},
+		{
+			name: "testmempoolaccept optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("testmempoolaccept", []string{"1122"}, 0.5)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewTestMempoolAcceptCmd([]string{"1122"},
+					btcjson.Float64(0.5))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"testmempoolaccept","params":[["1122"],0.5],"id":1}`,
+			unmarshalled: &btcjson.TestMempoolAcceptCmd{
+				RawTxns:    []string{"1122"},
+				MaxFeeRate: btcjson.Float64(0.5),
+			},
+		},
 		{
 			name: "uptime",
 			newCmd: func() (interface{}, error) {
@@ -1366,6 +1544,42 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"uptime","params":[],"id":1}`,
 			unmarshalled: &btcjson.UptimeCmd{},
 		},
+		{
+			name: "utxoupdatepsbt",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("utxoupdatepsbt", "1234")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewUtxoUpdatePsbtCmd("1234", nil)
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"utxoupdatepsbt","params":["1234"],"id":1}`,
+			unmarshalled: &btcjson.UtxoUpdatePsbtCmd{Psbt: "1234"},
+		},
+		{
+			name: "utxoupdatepsbt descriptors",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("utxoupdatepsbt", "1234",
+					[]btcjson.PsbtDescriptor{{
+						Descriptor: "00",
+						Range:      &btcjson.DescriptorRange{Value: 2},
+					}})
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewUtxoUpdatePsbtCmd("1234",
+					&[]btcjson.PsbtDescriptor{{
+						Descriptor: "00",
+						Range:      &btcjson.DescriptorRange{Value: 2},
+					}})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"utxoupdatepsbt","params":["1234",[{"desc":"00","range":2}]],"id":1}`,
+			unmarshalled: &btcjson.UtxoUpdatePsbtCmd{
+				Psbt: "1234",
+				Descriptors: &[]btcjson.PsbtDescriptor{{
+					Descriptor: "00",
+					Range:      &btcjson.DescriptorRange{Value: 2},
+				}},
+			},
+		},
 		{
 			name: "validateaddress",
 			newCmd: func() (interface{}, error) {
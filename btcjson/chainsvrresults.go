@@ -35,34 +35,39 @@ type GetBlockHeaderVerboseResult struct {
 
 // GetBlockStatsResult models the data from the getblockstats command.
 type GetBlockStatsResult struct {
-	AverageFee         int64   `json:"avgfee"`
-	AverageFeeRate     int64   `json:"avgfeerate"`
-	AverageTxSize      int64   `json:"avgtxsize"`
-	FeeratePercentiles []int64 `json:"feerate_percentiles"`
-	Hash               string  `json:"blockhash"`
-	Height             int64   `json:"height"`
-	Ins                int64   `json:"ins"`
-	MaxFee             int64   `json:"maxfee"`
-	MaxFeeRate         int64   `json:"maxfeerate"`
-	MaxTxSize          int64   `json:"maxtxsize"`
-	MedianFee          int64   `json:"medianfee"`
-	MedianTime         int64   `json:"mediantime"`
-	MedianTxSize       int64   `json:"mediantxsize"`
-	MinFee             int64   `json:"minfee"`
-	MinFeeRate         int64   `json:"minfeerate"`
-	MinTxSize          int64   `json:"mintxsize"`
-	Outs               int64   `json:"outs"`
-	SegWitTotalSize    int64   `json:"swtotal_size"`
-	SegWitTotalWeight  int64   `json:"swtotal_weight"`
-	SegWitTxs          int64   `json:"swtxs"`
-	Subsidy            int64   `json:"subsidy"`
-	Time               int64   `json:"time"`
-	TotalOut           int64   `json:"total_out"`
-	TotalSize          int64   `json:"total_size"`
-	TotalWeight        int64   `json:"total_weight"`
-	Txs                int64   `json:"txs"`
-	UTXOIncrease       int64   `json:"utxo_increase"`
-	UTXOSizeIncrease   int64   `json:"utxo_size_inc"`
+	AverageFee             int64   `json:"avgfee"`
+	AverageFeeRate         int64   `json:"avgfeerate"`
+	AverageTxSize          int64   `json:"avgtxsize"`
+	FeeratePercentiles     []int64 `json:"feerate_percentiles"`
+	Hash                   string  `json:"blockhash"`
+	Height                 int64   `json:"height"`
+	Ins                    int64   `json:"ins"`
+	MaxFee                 int64   `json:"maxfee"`
+	MaxFeeRate             int64   `json:"maxfeerate"`
+	MaxTxSize              int64   `json:"maxtxsize"`
+	MedianFee              int64   `json:"medianfee"`
+	MedianTime             int64   `json:"mediantime"`
+	MedianTxSize           int64   `json:"mediantxsize"`
+	MinFee                 int64   `json:"minfee"`
+	MinFeeRate             int64   `json:"minfeerate"`
+	MinTxSize              int64   `json:"mintxsize"`
+	Outs                   int64   `json:"outs"`
+	SegWitTotalSize        int64   `json:"swtotal_size"`
+	SegWitTotalWeight      int64   `json:"swtotal_weight"`
+	SegWitTxs              int64   `json:"swtxs"`
+	Subsidy                int64   `json:"subsidy"`
+	TaprootOuts            int64   `json:"taproot_outs"`
+	TaprootTxs             int64   `json:"taproot_txs"`
+	Time                   int64   `json:"time"`
+	TotalFee               int64   `json:"totalfee"`
+	TotalOut               int64   `json:"total_out"`
+	TotalSize              int64   `json:"total_size"`
+	TotalWeight            int64   `json:"total_weight"`
+	Txs                    int64   `json:"txs"`
+	UTXOIncrease           int64   `json:"utxo_increase"`
+	UTXOIncreaseActual     int64   `json:"utxo_increase_actual"`
+	UTXOSizeIncrease       int64   `json:"utxo_size_inc"`
+	UTXOSizeIncreaseActual int64   `json:"utxo_size_inc_actual"`
 }
 
 // GetBlockVerboseResult models the data from the getblock command when the
@@ -145,6 +150,16 @@ type DecodeScriptResult struct {
 	P2sh      string   `json:"p2sh,omitempty"`
 }
 
+// DumpTxOutSetResult models the data returned from the dumptxoutset command.
+type DumpTxOutSetResult struct {
+	CoinsWritten uint64 `json:"coins_written"`
+	BaseHash     string `json:"base_hash"`
+	BaseHeight   int32  `json:"base_height"`
+	Path         string `json:"path"`
+	TxOutSetHash string `json:"txoutset_hash"`
+	NChainTx     uint64 `json:"nchaintx"`
+}
+
 // GetAddedNodeInfoResultAddr models the data of the addresses portion of the
 // getaddednodeinfo command.
 type GetAddedNodeInfoResultAddr struct {
@@ -336,6 +351,20 @@ type GetMempoolInfoResult struct {
 	Bytes int64 `json:"bytes"`
 }
 
+// GetOrphanInfoResult models the data returned from the getorphaninfo
+// command.
+type GetOrphanInfoResult struct {
+	Orphans           int    `json:"orphans"`
+	Bytes             int64  `json:"bytes"`
+	Added             uint64 `json:"added"`
+	Processed         uint64 `json:"processed"`
+	Evicted           uint64 `json:"evicted"`
+	Expired           uint64 `json:"expired"`
+	PendingHeaders    int    `json:"pendingheaders"`
+	EvictedHeaders    uint64 `json:"evictedheaders"`
+	UnconnectedBlocks uint64 `json:"unconnectedblocks"`
+}
+
 // NetworksResult models the networks data from the getnetworkinfo command.
 type NetworksResult struct {
 	Name                      string `json:"name"`
@@ -431,6 +460,19 @@ type ScriptPubKeyResult struct {
 	Addresses []string `json:"addresses,omitempty"`
 }
 
+// GetSilentPaymentTweaksResult models the data of a block returned from the
+// getsilentpaymenttweaks command.
+type GetSilentPaymentTweaksResult struct {
+	Hash   string   `json:"hash"`
+	Height int32    `json:"height"`
+	Tweaks []string `json:"tweaks"`
+}
+
+// SaveMempoolResult models the data returned from the savemempool command.
+type SaveMempoolResult struct {
+	Filename string `json:"filename"`
+}
+
 // GetTxOutResult models the data from the gettxout command.
 type GetTxOutResult struct {
 	BestBlock     string             `json:"bestblock"`
@@ -505,6 +547,35 @@ type GetNetTotalsResult struct {
 	TimeMillis     int64  `json:"timemillis"`
 }
 
+// ScanTxOutSetUnspent models an unspent transaction output found by the
+// scantxoutset command.
+type ScanTxOutSetUnspent struct {
+	TxID         string  `json:"txid"`
+	Vout         uint32  `json:"vout"`
+	ScriptPubKey string  `json:"scriptPubKey"`
+	Desc         string  `json:"desc"`
+	Amount       float64 `json:"amount"`
+	Coinbase     bool    `json:"coinbase"`
+	Height       int32   `json:"height"`
+}
+
+// ScanTxOutSetResult models the data from the scantxoutset command with the
+// start action.
+type ScanTxOutSetResult struct {
+	Success     bool                  `json:"success"`
+	TxOuts      uint64                `json:"txouts"`
+	Height      int32                 `json:"height"`
+	BestBlock   string                `json:"bestblock"`
+	Unspents    []ScanTxOutSetUnspent `json:"unspents"`
+	TotalAmount float64               `json:"total_amount"`
+}
+
+// ScanTxOutSetStatusResult models the data from the scantxoutset command with
+// the status action.
+type ScanTxOutSetStatusResult struct {
+	Progress float64 `json:"progress"`
+}
+
 // ScriptSig models a signature script.  It is defined separately since it only
 // applies to non-coinbase.  Therefore the field in the Vin structure needs
 // to be a pointer.
@@ -517,12 +588,13 @@ type ScriptSig struct {
 // getrawtransaction, decoderawtransaction, and searchrawtransaction use the
 // same structure.
 type Vin struct {
-	Coinbase  string     `json:"coinbase"`
-	Txid      string     `json:"txid"`
-	Vout      uint32     `json:"vout"`
-	ScriptSig *ScriptSig `json:"scriptSig"`
-	Sequence  uint32     `json:"sequence"`
-	Witness   []string   `json:"txinwitness"`
+	Coinbase  string             `json:"coinbase"`
+	Txid      string             `json:"txid"`
+	Vout      uint32             `json:"vout"`
+	ScriptSig *ScriptSig         `json:"scriptSig"`
+	Sequence  uint32             `json:"sequence"`
+	Witness   []string           `json:"txinwitness"`
+	PrevOut   *SpentOutputResult `json:"prevout,omitempty"`
 }
 
 // IsCoinBase returns a bool to show if a Vin is a Coinbase one or not.
@@ -553,35 +625,49 @@ func (v *Vin) MarshalJSON() ([]byte, error) {
 
 	if v.HasWitness() {
 		txStruct := struct {
-			Txid      string     `json:"txid"`
-			Vout      uint32     `json:"vout"`
-			ScriptSig *ScriptSig `json:"scriptSig"`
-			Witness   []string   `json:"txinwitness"`
-			Sequence  uint32     `json:"sequence"`
+			Txid      string             `json:"txid"`
+			Vout      uint32             `json:"vout"`
+			ScriptSig *ScriptSig         `json:"scriptSig"`
+			Witness   []string           `json:"txinwitness"`
+			PrevOut   *SpentOutputResult `json:"prevout,omitempty"`
+			Sequence  uint32             `json:"sequence"`
 		}{
 			Txid:      v.Txid,
 			Vout:      v.Vout,
 			ScriptSig: v.ScriptSig,
 			Witness:   v.Witness,
+			PrevOut:   v.PrevOut,
 			Sequence:  v.Sequence,
 		}
 		return json.Marshal(txStruct)
 	}
 
 	txStruct := struct {
-		Txid      string     `json:"txid"`
-		Vout      uint32     `json:"vout"`
-		ScriptSig *ScriptSig `json:"scriptSig"`
-		Sequence  uint32     `json:"sequence"`
+		Txid      string             `json:"txid"`
+		Vout      uint32             `json:"vout"`
+		ScriptSig *ScriptSig         `json:"scriptSig"`
+		PrevOut   *SpentOutputResult `json:"prevout,omitempty"`
+		Sequence  uint32             `json:"sequence"`
 	}{
 		Txid:      v.Txid,
 		Vout:      v.Vout,
 		ScriptSig: v.ScriptSig,
+		PrevOut:   v.PrevOut,
 		Sequence:  v.Sequence,
 	}
 	return json.Marshal(txStruct)
 }
 
+// SpentOutputResult models the output spent by a transaction input.  It is
+// returned by getblock with verbosity 3 and getrawtransaction with verbosity
+// 2.
+type SpentOutputResult struct {
+	Generated    bool               `json:"generated"`
+	Height       int32              `json:"height"`
+	Value        float64            `json:"value"`
+	ScriptPubKey ScriptPubKeyResult `json:"scriptPubKey"`
+}
+
 // PrevOut represents previous output for an input Vin.
 type PrevOut struct {
 	Addresses []string `json:"addresses,omitempty"`
@@ -706,20 +792,21 @@ type InfoChainResult struct {
 
 // TxRawResult models the data from the getrawtransaction command.
 type TxRawResult struct {
-	Hex           string `json:"hex"`
-	Txid          string `json:"txid"`
-	Hash          string `json:"hash,omitempty"`
-	Size          int32  `json:"size,omitempty"`
-	Vsize         int32  `json:"vsize,omitempty"`
-	Weight        int32  `json:"weight,omitempty"`
-	Version       uint32 `json:"version"`
-	LockTime      uint32 `json:"locktime"`
-	Vin           []Vin  `json:"vin"`
-	Vout          []Vout `json:"vout"`
-	BlockHash     string `json:"blockhash,omitempty"`
-	Confirmations uint64 `json:"confirmations,omitempty"`
-	Time          int64  `json:"time,omitempty"`
-	Blocktime     int64  `json:"blocktime,omitempty"`
+	Hex           string  `json:"hex"`
+	Txid          string  `json:"txid"`
+	Hash          string  `json:"hash,omitempty"`
+	Size          int32   `json:"size,omitempty"`
+	Vsize         int32   `json:"vsize,omitempty"`
+	Weight        int32   `json:"weight,omitempty"`
+	Version       uint32  `json:"version"`
+	LockTime      uint32  `json:"locktime"`
+	Vin           []Vin   `json:"vin"`
+	Vout          []Vout  `json:"vout"`
+	Fee           float64 `json:"fee,omitempty"`
+	BlockHash     string  `json:"blockhash,omitempty"`
+	Confirmations uint64  `json:"confirmations,omitempty"`
+	Time          int64   `json:"time,omitempty"`
+	Blocktime     int64   `json:"blocktime,omitempty"`
 }
 
 // SearchRawTransactionsResult models the data from the searchrawtransaction
@@ -750,6 +837,59 @@ type TxRawDecodeResult struct {
 	Vout     []Vout `json:"vout"`
 }
 
+// MempoolFeesResult models the fees of a transaction returned by the
+// testmempoolaccept and submitpackage commands.
+type MempoolFeesResult struct {
+	// Base is the fee paid by the transaction in BTC.
+	Base float64 `json:"base"`
+
+	// EffectiveFeeRate is the fee rate in BTC/kvB the transaction was
+	// validated with.  It is the fee rate of the package for transactions
+	// accepted as part of one.
+	EffectiveFeeRate float64 `json:"effective-feerate,omitempty"`
+
+	// EffectiveIncludes are the wtxids of the transactions whose fees and
+	// sizes are included in the effective fee rate.
+	EffectiveIncludes []string `json:"effective-includes,omitempty"`
+}
+
+// TestMempoolAcceptResult models the data of a transaction returned by the
+// testmempoolaccept command.
+type TestMempoolAcceptResult struct {
+	Txid         string             `json:"txid"`
+	Wtxid        string             `json:"wtxid"`
+	PackageError string             `json:"package-error,omitempty"`
+	Allowed      bool               `json:"allowed"`
+	Vsize        int32              `json:"vsize,omitempty"`
+	Fees         *MempoolFeesResult `json:"fees,omitempty"`
+	RejectReason string             `json:"reject-reason,omitempty"`
+}
+
+// SubmitPackageTxResult models the data of a transaction returned by the
+// submitpackage command.
+type SubmitPackageTxResult struct {
+	Txid       string             `json:"txid"`
+	OtherWtxid string             `json:"other-wtxid,omitempty"`
+	Vsize      int32              `json:"vsize,omitempty"`
+	Fees       *MempoolFeesResult `json:"fees,omitempty"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// SubmitPackageResult models the data returned by the submitpackage command.
+type SubmitPackageResult struct {
+	// PackageMsg is "success" when the package was accepted, or the
+	// reason it was rejected otherwise.
+	PackageMsg string `json:"package_msg"`
+
+	// TxResults contains the results of the transactions of the package
+	// keyed by their wtxid.
+	TxResults map[string]SubmitPackageTxResult `json:"tx-results"`
+
+	// ReplacedTransactions are the txids of the transactions replaced by
+	// the package.
+	ReplacedTransactions []string `json:"replaced-transactions"`
+}
+
 // ValidateAddressChainResult models the data returned by the chain server
 // validateaddress command.
 //
@@ -832,6 +972,14 @@ type GetDescriptorInfoResult struct {
 // DeriveAddressesResult models the data from the deriveaddresses command.
 type DeriveAddressesResult []string
 
+// DescriptorProcessPsbtResult models the data from the descriptorprocesspsbt
+// command.
+type DescriptorProcessPsbtResult struct {
+	Psbt     string `json:"psbt"`
+	Complete bool   `json:"complete"`
+	Hex      string `json:"hex,omitempty"`
+}
+
 // LoadWalletResult models the data from the loadwallet command
 type LoadWalletResult struct {
 	Name    string `json:"name"`
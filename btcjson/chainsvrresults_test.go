@@ -47,6 +47,30 @@ func TestChainSvrCustomResults(t *testing.T) {
 			},
 			expected: `{"txid":"123","vout":1,"scriptSig":{"asm":"0","hex":"00"},"sequence":4294967295}`,
 		},
+		{
+			name: "custom vin marshal with prevout",
+			result: &btcjson.Vin{
+				Txid: "123",
+				Vout: 1,
+				ScriptSig: &btcjson.ScriptSig{
+					Asm: "",
+					Hex: "",
+				},
+				Sequence: 4294967295,
+				Witness:  []string{"01"},
+				PrevOut: &btcjson.SpentOutputResult{
+					Generated: true,
+					Height:    100,
+					Value:     50,
+					ScriptPubKey: btcjson.ScriptPubKeyResult{
+						Asm:  "OP_TRUE",
+						Hex:  "51",
+						Type: "nonstandard",
+					},
+				},
+			},
+			expected: `{"txid":"123","vout":1,"scriptSig":{"asm":"","hex":""},"txinwitness":["01"],"prevout":{"generated":true,"height":100,"value":50,"scriptPubKey":{"asm":"OP_TRUE","hex":"51","type":"nonstandard"}},"sequence":4294967295}`,
+		},
 		{
 			name: "custom vinprevout marshal with coinbase",
 			result: &btcjson.VinPrevOut{
@@ -102,6 +102,39 @@ type OutPoint struct {
 	Index uint32 `json:"index"`
 }
 
+// FilterDescriptor describes an output descriptor whose output scripts are
+// added to a transaction filter.  Ranged descriptors are expanded over the
+// child indexes of their range, which defaults to [0,999].
+type FilterDescriptor struct {
+	Descriptor string           `json:"desc"`
+	Range      *DescriptorRange `json:"range,omitempty"`
+}
+
+// SilentPaymentKey describes the keys of a silent payments address as defined
+// by BIP-352 to add to a transaction filter.  The private scan key is required
+// to detect the outputs paying to the address.
+type SilentPaymentKey struct {
+	ScanKey  string `json:"scankey"`
+	SpendKey string `json:"spendkey"`
+}
+
+// LoadTxFilterOptions defines the optional elements of a transaction filter
+// loaded by the loadtxfilter command besides addresses and outpoints.
+type LoadTxFilterOptions struct {
+	// Scripts are hex encoded output scripts to add to the filter.  They
+	// allow matching outputs which have no address encoding, such as
+	// taproot outputs.
+	Scripts []string `json:"scripts,omitempty"`
+
+	// Descriptors are output descriptors whose output scripts are added
+	// to the filter.
+	Descriptors []FilterDescriptor `json:"descriptors,omitempty"`
+
+	// SilentPaymentKeys are silent payments addresses whose outputs are
+	// matched by the filter.
+	SilentPaymentKeys []SilentPaymentKey `json:"silentpaymentkeys,omitempty"`
+}
+
 // LoadTxFilterCmd defines the loadtxfilter request parameters to load or
 // reload a transaction filter.
 //
@@ -111,6 +144,7 @@ type LoadTxFilterCmd struct {
 	Reload    bool
 	Addresses []string
 	OutPoints []OutPoint
+	Options   *LoadTxFilterOptions
 }
 
 // NewLoadTxFilterCmd returns a new instance which can be used to issue a
@@ -126,6 +160,22 @@ func NewLoadTxFilterCmd(reload bool, addresses []string, outPoints []OutPoint) *
 	}
 }
 
+// NewLoadTxFilterWithOptionsCmd returns a new instance which can be used to
+// issue a loadtxfilter JSON-RPC command which also adds output scripts,
+// descriptors or silent payments addresses to the filter.
+//
+// NOTE: This is a btcd extension and requires a websocket connection.
+func NewLoadTxFilterWithOptionsCmd(reload bool, addresses []string,
+	outPoints []OutPoint, options *LoadTxFilterOptions) *LoadTxFilterCmd {
+
+	return &LoadTxFilterCmd{
+		Reload:    reload,
+		Addresses: addresses,
+		OutPoints: outPoints,
+		Options:   options,
+	}
+}
+
 // NotifySpentCmd defines the notifyspent JSON-RPC command.
 //
 // Deprecated: Use LoadTxFilterCmd instead.
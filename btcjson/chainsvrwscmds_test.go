@@ -213,6 +213,45 @@ func TestChainSvrWsCmds(t *testing.T) {
 				OutPoints: []btcjson.OutPoint{{Hash: "0000000000000000000000000000000000000000000000000000000000000123", Index: 0}},
 			},
 		},
+		{
+			name: "loadtxfilter with options",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("loadtxfilter", true, `[]`, `[]`,
+					`{"scripts":["5120aa"],"descriptors":[{"desc":"pkh(02aa)","range":[0,5]}],"silentpaymentkeys":[{"scankey":"01","spendkey":"02"}]}`)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewLoadTxFilterWithOptionsCmd(true,
+					[]string{}, []btcjson.OutPoint{},
+					&btcjson.LoadTxFilterOptions{
+						Scripts: []string{"5120aa"},
+						Descriptors: []btcjson.FilterDescriptor{{
+							Descriptor: "pkh(02aa)",
+							Range:      &btcjson.DescriptorRange{Value: []int{0, 5}},
+						}},
+						SilentPaymentKeys: []btcjson.SilentPaymentKey{{
+							ScanKey:  "01",
+							SpendKey: "02",
+						}},
+					})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"loadtxfilter","params":[true,[],[],{"scripts":["5120aa"],"descriptors":[{"desc":"pkh(02aa)","range":[0,5]}],"silentpaymentkeys":[{"scankey":"01","spendkey":"02"}]}],"id":1}`,
+			unmarshalled: &btcjson.LoadTxFilterCmd{
+				Reload:    true,
+				Addresses: []string{},
+				OutPoints: []btcjson.OutPoint{},
+				Options: &btcjson.LoadTxFilterOptions{
+					Scripts: []string{"5120aa"},
+					Descriptors: []btcjson.FilterDescriptor{{
+						Descriptor: "pkh(02aa)",
+						Range:      &btcjson.DescriptorRange{Value: []int{0, 5}},
+					}},
+					SilentPaymentKeys: []btcjson.SilentPaymentKey{{
+						ScanKey:  "01",
+						SpendKey: "02",
+					}},
+				},
+			},
+		},
 		{
 			name: "rescanblocks",
 			newCmd: func() (interface{}, error) {
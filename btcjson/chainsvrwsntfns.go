@@ -61,6 +61,16 @@ const (
 	// Deprecated: Not used with rescanblocks command.
 	RescanProgressNtfnMethod = "rescanprogress"
 
+	// ScanTxOutSetMatchNtfnMethod is the method used for notifications
+	// from the chain server that a scantxoutset command started by the
+	// client found an unspent transaction output.
+	ScanTxOutSetMatchNtfnMethod = "scantxoutsetmatch"
+
+	// ScanTxOutSetProgressNtfnMethod is the method used for notifications
+	// from the chain server that a scantxoutset command started by the
+	// client has made progress.
+	ScanTxOutSetProgressNtfnMethod = "scantxoutsetprogress"
+
 	// TxAcceptedNtfnMethod is the method used for notifications from the
 	// chain server that a transaction has been accepted into the mempool.
 	TxAcceptedNtfnMethod = "txaccepted"
@@ -75,6 +85,11 @@ const (
 	// from the chain server that inform a client that a transaction that
 	// matches the loaded filter was accepted by the mempool.
 	RelevantTxAcceptedNtfnMethod = "relevanttxaccepted"
+
+	// TxReplacedNtfnMethod is the method used for notifications from the
+	// chain server that a transaction has been evicted from the mempool by
+	// a replacement transaction.
+	TxReplacedNtfnMethod = "txreplaced"
 )
 
 // BlockConnectedNtfn defines the blockconnected JSON-RPC notification.
@@ -245,6 +260,33 @@ func NewRescanProgressNtfn(hash string, height int32, time int64) *RescanProgres
 	}
 }
 
+// ScanTxOutSetMatchNtfn defines the scantxoutsetmatch JSON-RPC notification.
+type ScanTxOutSetMatchNtfn struct {
+	Unspent ScanTxOutSetUnspent
+}
+
+// NewScanTxOutSetMatchNtfn returns a new instance which can be used to issue a
+// scantxoutsetmatch JSON-RPC notification.
+func NewScanTxOutSetMatchNtfn(unspent ScanTxOutSetUnspent) *ScanTxOutSetMatchNtfn {
+	return &ScanTxOutSetMatchNtfn{
+		Unspent: unspent,
+	}
+}
+
+// ScanTxOutSetProgressNtfn defines the scantxoutsetprogress JSON-RPC
+// notification.
+type ScanTxOutSetProgressNtfn struct {
+	Progress float64
+}
+
+// NewScanTxOutSetProgressNtfn returns a new instance which can be used to issue
+// a scantxoutsetprogress JSON-RPC notification.
+func NewScanTxOutSetProgressNtfn(progress float64) *ScanTxOutSetProgressNtfn {
+	return &ScanTxOutSetProgressNtfn{
+		Progress: progress,
+	}
+}
+
 // TxAcceptedNtfn defines the txaccepted JSON-RPC notification.
 type TxAcceptedNtfn struct {
 	TxID   string
@@ -285,6 +327,21 @@ func NewRelevantTxAcceptedNtfn(txHex string) *RelevantTxAcceptedNtfn {
 	return &RelevantTxAcceptedNtfn{Transaction: txHex}
 }
 
+// TxReplacedNtfn defines the txreplaced JSON-RPC notification.
+type TxReplacedNtfn struct {
+	ReplacedTxID    string
+	ReplacementTxID string
+}
+
+// NewTxReplacedNtfn returns a new instance which can be used to issue a
+// txreplaced JSON-RPC notification.
+func NewTxReplacedNtfn(replacedTxID, replacementTxID string) *TxReplacedNtfn {
+	return &TxReplacedNtfn{
+		ReplacedTxID:    replacedTxID,
+		ReplacementTxID: replacementTxID,
+	}
+}
+
 func init() {
 	// The commands in this file are only usable by websockets and are
 	// notifications.
@@ -298,7 +355,10 @@ func init() {
 	MustRegisterCmd(RedeemingTxNtfnMethod, (*RedeemingTxNtfn)(nil), flags)
 	MustRegisterCmd(RescanFinishedNtfnMethod, (*RescanFinishedNtfn)(nil), flags)
 	MustRegisterCmd(RescanProgressNtfnMethod, (*RescanProgressNtfn)(nil), flags)
+	MustRegisterCmd(ScanTxOutSetMatchNtfnMethod, (*ScanTxOutSetMatchNtfn)(nil), flags)
+	MustRegisterCmd(ScanTxOutSetProgressNtfnMethod, (*ScanTxOutSetProgressNtfn)(nil), flags)
 	MustRegisterCmd(TxAcceptedNtfnMethod, (*TxAcceptedNtfn)(nil), flags)
 	MustRegisterCmd(TxAcceptedVerboseNtfnMethod, (*TxAcceptedVerboseNtfn)(nil), flags)
 	MustRegisterCmd(RelevantTxAcceptedNtfnMethod, (*RelevantTxAcceptedNtfn)(nil), flags)
+	MustRegisterCmd(TxReplacedNtfnMethod, (*TxReplacedNtfn)(nil), flags)
 }
@@ -225,6 +225,62 @@ func TestChainSvrWsNtfns(t *testing.T) {
 				Transaction: "001122",
 			},
 		},
+		{
+			name: "scantxoutsetmatch",
+			newNtfn: func() (interface{}, error) {
+				return btcjson.NewCmd("scantxoutsetmatch", `{"txid":"123","vout":1,"scriptPubKey":"51","desc":"raw(51)","amount":1.5,"coinbase":true,"height":100}`)
+			},
+			staticNtfn: func() interface{} {
+				return btcjson.NewScanTxOutSetMatchNtfn(btcjson.ScanTxOutSetUnspent{
+					TxID:         "123",
+					Vout:         1,
+					ScriptPubKey: "51",
+					Desc:         "raw(51)",
+					Amount:       1.5,
+					Coinbase:     true,
+					Height:       100,
+				})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"scantxoutsetmatch","params":[{"txid":"123","vout":1,"scriptPubKey":"51","desc":"raw(51)","amount":1.5,"coinbase":true,"height":100}],"id":null}`,
+			unmarshalled: &btcjson.ScanTxOutSetMatchNtfn{
+				Unspent: btcjson.ScanTxOutSetUnspent{
+					TxID:         "123",
+					Vout:         1,
+					ScriptPubKey: "51",
+					Desc:         "raw(51)",
+					Amount:       1.5,
+					Coinbase:     true,
+					Height:       100,
+				},
+			},
+		},
+		{
+			name: "scantxoutsetprogress",
+			newNtfn: func() (interface{}, error) {
+				return btcjson.NewCmd("scantxoutsetprogress", 42.5)
+			},
+			staticNtfn: func() interface{} {
+				return btcjson.NewScanTxOutSetProgressNtfn(42.5)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"scantxoutsetprogress","params":[42.5],"id":null}`,
+			unmarshalled: &btcjson.ScanTxOutSetProgressNtfn{
+				Progress: 42.5,
+			},
+		},
+		{
+			name: "txreplaced",
+			newNtfn: func() (interface{}, error) {
+				return btcjson.NewCmd("txreplaced", "123", "456")
+			},
+			staticNtfn: func() interface{} {
+				return btcjson.NewTxReplacedNtfn("123", "456")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"txreplaced","params":["123","456"],"id":null}`,
+			unmarshalled: &btcjson.TxReplacedNtfn{
+				ReplacedTxID:    "123",
+				ReplacementTxID: "456",
+			},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
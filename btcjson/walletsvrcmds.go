@@ -997,6 +997,64 @@ func NewImportMultiCmd(requests []ImportMultiRequest, options *ImportMultiOption
 	}
 }
 
+// ImportDescriptorsRequest defines the request struct to be passed to the
+// ImportDescriptorsCmd, as an array.
+type ImportDescriptorsRequest struct {
+	// Descriptor to import.
+	Descriptor string `json:"desc"`
+
+	// States whether the descriptor is used to generate new addresses.
+	Active *bool `json:"active,omitempty"`
+
+	// If the descriptor is ranged, this specifies the end (as an int) or
+	// the range (as []int{begin, end}) to import.
+	Range *DescriptorRange `json:"range,omitempty"`
+
+	// If the descriptor is ranged, this specifies the next index to
+	// generate addresses from.
+	NextIndex *int `json:"next_index,omitempty"`
+
+	// Creation time of the descriptor in seconds since epoch, or the
+	// string "now" to bypass scanning.
+	Timestamp TimestampOrNow `json:"timestamp"`
+
+	// States whether matching outputs should be treated as change.
+	Internal *bool `json:"internal,omitempty"`
+
+	// Label to assign to the address. Only allowed when Internal is false
+	// and the descriptor is not active.
+	Label *string `json:"label,omitempty"`
+}
+
+// ImportDescriptorsCmd defines the importdescriptors JSON-RPC command.
+type ImportDescriptorsCmd struct {
+	Requests []ImportDescriptorsRequest
+}
+
+// NewImportDescriptorsCmd returns a new instance which can be used to issue
+// an importdescriptors JSON-RPC command.
+func NewImportDescriptorsCmd(requests []ImportDescriptorsRequest) *ImportDescriptorsCmd {
+	return &ImportDescriptorsCmd{
+		Requests: requests,
+	}
+}
+
+// ListDescriptorsCmd defines the listdescriptors JSON-RPC command.
+type ListDescriptorsCmd struct {
+	Private *bool `jsonrpcdefault:"false"`
+}
+
+// NewListDescriptorsCmd returns a new instance which can be used to issue a
+// listdescriptors JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional. Passing nil
+// for optional parameters will use the default value.
+func NewListDescriptorsCmd(private *bool) *ListDescriptorsCmd {
+	return &ListDescriptorsCmd{
+		Private: private,
+	}
+}
+
 // PsbtInput represents an input to include in the PSBT created by the
 // WalletCreateFundedPsbtCmd command.
 type PsbtInput struct {
@@ -1105,11 +1163,13 @@ func init() {
 	MustRegisterCmd("getreceivedbyaddress", (*GetReceivedByAddressCmd)(nil), flags)
 	MustRegisterCmd("gettransaction", (*GetTransactionCmd)(nil), flags)
 	MustRegisterCmd("getwalletinfo", (*GetWalletInfoCmd)(nil), flags)
+	MustRegisterCmd("importdescriptors", (*ImportDescriptorsCmd)(nil), flags)
 	MustRegisterCmd("importmulti", (*ImportMultiCmd)(nil), flags)
 	MustRegisterCmd("importprivkey", (*ImportPrivKeyCmd)(nil), flags)
 	MustRegisterCmd("keypoolrefill", (*KeyPoolRefillCmd)(nil), flags)
 	MustRegisterCmd("listaccounts", (*ListAccountsCmd)(nil), flags)
 	MustRegisterCmd("listaddressgroupings", (*ListAddressGroupingsCmd)(nil), flags)
+	MustRegisterCmd("listdescriptors", (*ListDescriptorsCmd)(nil), flags)
 	MustRegisterCmd("listlockunspent", (*ListLockUnspentCmd)(nil), flags)
 	MustRegisterCmd("listreceivedbyaccount", (*ListReceivedByAccountCmd)(nil), flags)
 	MustRegisterCmd("listreceivedbyaddress", (*ListReceivedByAddressCmd)(nil), flags)
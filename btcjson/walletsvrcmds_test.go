@@ -628,6 +628,32 @@ func TestWalletSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"listaddressgroupings","params":[],"id":1}`,
 			unmarshalled: &btcjson.ListAddressGroupingsCmd{},
 		},
+		{
+			name: "listdescriptors",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("listdescriptors")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewListDescriptorsCmd(nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"listdescriptors","params":[],"id":1}`,
+			unmarshalled: &btcjson.ListDescriptorsCmd{
+				Private: btcjson.Bool(false),
+			},
+		},
+		{
+			name: "listdescriptors private",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("listdescriptors", true)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewListDescriptorsCmd(btcjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"listdescriptors","params":[true],"id":1}`,
+			unmarshalled: &btcjson.ListDescriptorsCmd{
+				Private: btcjson.Bool(true),
+			},
+		},
 		{
 			name: "listlockunspent",
 			newCmd: func() (interface{}, error) {
@@ -1467,6 +1493,44 @@ func TestWalletSvrCmds(t *testing.T) {
 				NewPassphrase: "new",
 			},
 		},
+		{
+			name: "importdescriptors",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd(
+					"importdescriptors",
+					[]btcjson.ImportDescriptorsRequest{
+						{
+							Descriptor: "123",
+							Active:     btcjson.Bool(true),
+							Range:      &btcjson.DescriptorRange{Value: []int{0, 100}},
+							Timestamp:  btcjson.TimestampOrNow{Value: "now"},
+						},
+					},
+				)
+			},
+			staticCmd: func() interface{} {
+				requests := []btcjson.ImportDescriptorsRequest{
+					{
+						Descriptor: "123",
+						Active:     btcjson.Bool(true),
+						Range:      &btcjson.DescriptorRange{Value: []int{0, 100}},
+						Timestamp:  btcjson.TimestampOrNow{Value: "now"},
+					},
+				}
+				return btcjson.NewImportDescriptorsCmd(requests)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"importdescriptors","params":[[{"desc":"123","active":true,"range":[0,100],"timestamp":"now"}]],"id":1}`,
+			unmarshalled: &btcjson.ImportDescriptorsCmd{
+				Requests: []btcjson.ImportDescriptorsRequest{
+					{
+						Descriptor: "123",
+						Active:     btcjson.Bool(true),
+						Range:      &btcjson.DescriptorRange{Value: []int{0, 100}},
+						Timestamp:  btcjson.TimestampOrNow{Value: "now"},
+					},
+				},
+			},
+		},
 		{
 			name: "importmulti with descriptor + options",
 			newCmd: func() (interface{}, error) {
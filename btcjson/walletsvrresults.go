@@ -370,6 +370,36 @@ type ImportMultiResults []struct {
 	Warnings *[]string `json:"warnings,omitempty"`
 }
 
+// ImportDescriptorsResults is a slice that models the result of the
+// importdescriptors command.
+//
+// Each item in the slice contains the execution result corresponding to the
+// input requests of type btcjson.ImportDescriptorsRequest, passed to the
+// ImportDescriptors[Async] function.
+type ImportDescriptorsResults []struct {
+	Success  bool      `json:"success"`
+	Error    *RPCError `json:"error,omitempty"`
+	Warnings *[]string `json:"warnings,omitempty"`
+}
+
+// WalletDescriptor models a descriptor of a wallet returned by the
+// listdescriptors command.
+type WalletDescriptor struct {
+	Descriptor string           `json:"desc"`
+	Timestamp  int64            `json:"timestamp"`
+	Active     bool             `json:"active"`
+	Internal   *bool            `json:"internal,omitempty"`
+	Range      *DescriptorRange `json:"range,omitempty"`
+	Next       *int             `json:"next,omitempty"`
+}
+
+// ListDescriptorsResult models the data returned from the listdescriptors
+// command.
+type ListDescriptorsResult struct {
+	WalletName  string             `json:"wallet_name"`
+	Descriptors []WalletDescriptor `json:"descriptors"`
+}
+
 // WalletCreateFundedPsbtResult models the data returned from the
 // walletcreatefundedpsbtresult command.
 type WalletCreateFundedPsbtResult struct {
@@ -383,4 +413,5 @@ type WalletCreateFundedPsbtResult struct {
 type WalletProcessPsbtResult struct {
 	Psbt     string `json:"psbt"`
 	Complete bool   `json:"complete"`
+	Hex      string `json:"hex,omitempty"`
 }
@@ -5,7 +5,13 @@
 
 package chainhash
 
-import "crypto/sha256"
+import (
+	"crypto/sha256"
+	"encoding"
+	"fmt"
+	"hash"
+	"sync"
+)
 
 // HashB calculates hash(b) and returns the resulting bytes.
 func HashB(b []byte) []byte {
@@ -31,3 +37,75 @@ func DoubleHashH(b []byte) Hash {
 	first := sha256.Sum256(b)
 	return Hash(sha256.Sum256(first[:]))
 }
+
+// taggedMidstates caches the marshaled SHA-256 state after writing the
+// sha256(tag) || sha256(tag) prefix of a tagged hash, keyed by tag.
+var taggedMidstates sync.Map
+
+// taggedMidstate returns the marshaled SHA-256 state after processing the
+// prefix for the passed tag, computing and caching it on first use.  Since
+// the prefix is exactly one 64-byte block, the state is a pure midstate with
+// no buffered data.
+func taggedMidstate(tag []byte) []byte {
+	if state, ok := taggedMidstates.Load(string(tag)); ok {
+		return state.([]byte)
+	}
+
+	tagHash := sha256.Sum256(tag)
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+
+	// The standard library implementation always supports marshaling, so
+	// this can't fail.
+	state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		panic(fmt.Sprintf("unable to marshal sha256 state: %v", err))
+	}
+	taggedMidstates.Store(string(tag), state)
+	return state
+}
+
+// taggedHashWriter is a hash.Hash computing a BIP-340 tagged hash.  It
+// resets to the cached midstate of its tag rather than the empty state.
+type taggedHashWriter struct {
+	hash.Hash
+	midstate []byte
+}
+
+// Reset resets the writer to the state right after the tag prefix.
+func (w *taggedHashWriter) Reset() {
+	// The midstate was produced by the same implementation, so this can't
+	// fail.
+	err := w.Hash.(encoding.BinaryUnmarshaler).UnmarshalBinary(w.midstate)
+	if err != nil {
+		panic(fmt.Sprintf("unable to restore sha256 state: %v", err))
+	}
+}
+
+// NewTaggedHashWriter returns a hash.Hash that computes the BIP-340 tagged
+// hash sha256(sha256(tag) || sha256(tag) || msg) of everything written to
+// it.  The state after the tag prefix is computed once per tag and cached, so
+// creating a writer for a previously used tag requires no hashing at all.
+// Calling Reset on the returned writer restores it to that state.
+func NewTaggedHashWriter(tag []byte) hash.Hash {
+	w := &taggedHashWriter{
+		Hash:     sha256.New(),
+		midstate: taggedMidstate(tag),
+	}
+	w.Reset()
+	return w
+}
+
+// TaggedHash implements the tagged hash scheme described in BIP-340.  It
+// returns sha256(sha256(tag) || sha256(tag) || msgs...).
+func TaggedHash(tag []byte, msgs ...[]byte) *Hash {
+	w := NewTaggedHashWriter(tag)
+	for _, msg := range msgs {
+		w.Write(msg)
+	}
+
+	var hash Hash
+	w.Sum(hash[:0])
+	return &hash
+}
@@ -5,6 +5,8 @@
 package chainhash
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"testing"
 )
@@ -134,3 +136,74 @@ func TestDoubleHashFuncs(t *testing.T) {
 		}
 	}
 }
+
+// naiveTaggedHash computes a BIP-340 tagged hash without any midstate caching.
+func naiveTaggedHash(tag []byte, msgs ...[]byte) []byte {
+	tagHash := sha256.Sum256(tag)
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, msg := range msgs {
+		h.Write(msg)
+	}
+	return h.Sum(nil)
+}
+
+// TestTaggedHash ensures the tagged hash functions, which make use of cached
+// tag midstates, produce the same results as the naive computation.
+func TestTaggedHash(t *testing.T) {
+	tests := []struct {
+		tag  string
+		msgs []string
+	}{
+		{"", nil},
+		{"BIP0340/challenge", []string{"abc"}},
+		{"BIP0340/aux", []string{"", "a", "bc"}},
+		{"TapLeaf", []string{string(bytes.Repeat([]byte{0x42}, 200))}},
+		{"TapLeaf", []string{"abcdefghij"}},
+		{string(bytes.Repeat([]byte("tag"), 50)), []string{"x"}},
+	}
+
+	for _, test := range tests {
+		tag := []byte(test.tag)
+		msgs := make([][]byte, 0, len(test.msgs))
+		for _, msg := range test.msgs {
+			msgs = append(msgs, []byte(msg))
+		}
+		want := naiveTaggedHash(tag, msgs...)
+
+		got := TaggedHash(tag, msgs...)
+		if !bytes.Equal(got[:], want) {
+			t.Errorf("TaggedHash(%q) = %x, want %x", test.tag,
+				got[:], want)
+			continue
+		}
+
+		// Ensure the writer produces the same result both when fresh
+		// and after being reset following unrelated writes.
+		w := NewTaggedHashWriter(tag)
+		for i := 0; i < 2; i++ {
+			for _, msg := range msgs {
+				w.Write(msg)
+			}
+			if sum := w.Sum(nil); !bytes.Equal(sum, want) {
+				t.Errorf("NewTaggedHashWriter(%q) pass %d = %x, "+
+					"want %x", test.tag, i, sum, want)
+			}
+			w.Write([]byte("garbage"))
+			w.Reset()
+		}
+	}
+}
+
+// BenchmarkTaggedHash benchmarks how long it takes to compute a tagged hash of
+// a 32-byte message with a cached tag midstate.
+func BenchmarkTaggedHash(b *testing.B) {
+	tag := []byte("BIP0340/challenge")
+	msg := bytes.Repeat([]byte{0x01}, 32)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		TaggedHash(tag, msg)
+	}
+}
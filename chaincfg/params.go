@@ -151,6 +151,10 @@ type Params struct {
 	// as one method to discover peers.
 	DNSSeeds []DNSSeed
 
+	// SignetChallenge defines the block challenge script of a signet
+	// network.  It is nil for all other networks.
+	SignetChallenge []byte
+
 	// GenesisBlock defines the first block of the chain.
 	GenesisBlock *wire.MsgBlock
 
@@ -616,10 +620,10 @@ var SigNetParams = CustomSignetParams(
 	DefaultSignetChallenge, DefaultSignetDNSSeeds,
 )
 
-// CustomSignetParams creates network parameters for a custom signet network
-// from a challenge. The challenge is the binary compiled version of the block
-// challenge script.
-func CustomSignetParams(challenge []byte, dnsSeeds []DNSSeed) Params {
+// SignetNet returns the magic bytes identifying the signet network defined by
+// the passed challenge on the wire.  The challenge is the binary compiled
+// version of the block challenge script.
+func SignetNet(challenge []byte) wire.BitcoinNet {
 	// The message start is defined as the first four bytes of the sha256d
 	// of the challenge script, as a single push (i.e. prefixed with the
 	// challenge script length).
@@ -630,12 +634,19 @@ func CustomSignetParams(challenge []byte, dnsSeeds []DNSSeed) Params {
 
 	// We use little endian encoding of the hash prefix to be in line with
 	// the other wire network identities.
-	net := binary.LittleEndian.Uint32(hashDouble[0:4])
+	return wire.BitcoinNet(binary.LittleEndian.Uint32(hashDouble[0:4]))
+}
+
+// CustomSignetParams creates network parameters for a custom signet network
+// from a challenge. The challenge is the binary compiled version of the block
+// challenge script.
+func CustomSignetParams(challenge []byte, dnsSeeds []DNSSeed) Params {
 	return Params{
-		Name:        "signet",
-		Net:         wire.BitcoinNet(net),
-		DefaultPort: "38333",
-		DNSSeeds:    dnsSeeds,
+		Name:            "signet",
+		Net:             SignetNet(challenge),
+		DefaultPort:     "38333",
+		DNSSeeds:        dnsSeeds,
+		SignetChallenge: challenge,
 
 		// Chain parameters
 		GenesisBlock:             &sigNetGenesisBlock,
@@ -716,6 +727,10 @@ var (
 	// network or previously-registered into this package.
 	ErrDuplicateNet = errors.New("duplicate Bitcoin network")
 
+	// ErrInvalidCustomNet describes an error where the parameters for a
+	// custom Bitcoin network are missing a required value.
+	ErrInvalidCustomNet = errors.New("invalid custom Bitcoin network")
+
 	// ErrUnknownHDKeyID describes an error where the provided id which
 	// is intended to identify the network for a hierarchical deterministic
 	// private extended key is not registered.
@@ -732,6 +747,7 @@ var (
 	scriptHashAddrIDs    = make(map[byte]struct{})
 	bech32SegwitPrefixes = make(map[string]struct{})
 	hdPrivToPubKeyIDs    = make(map[[4]byte][]byte)
+	customNets           = make(map[string]*Params)
 )
 
 // String returns the hostname of the DNS seed in human-readable form.
@@ -767,6 +783,47 @@ func Register(params *Params) error {
 	return nil
 }
 
+// RegisterCustomNetwork registers the parameters for a custom Bitcoin network,
+// such as a custom signet, so it can be looked up by name with CustomNetwork.
+// The network is also registered with Register, which makes its address
+// encoding magics known to library packages.
+//
+// This may error with ErrInvalidCustomNet if the parameters lack a name,
+// default port, or genesis block, and with ErrDuplicateNet if the network or a
+// network with the same name is already registered.
+//
+// Like Register, this should be called by a main package as early as possible
+// and is not safe for concurrent access.
+func RegisterCustomNetwork(params *Params) error {
+	if params.Name == "" || params.DefaultPort == "" ||
+		params.GenesisBlock == nil || params.GenesisHash == nil {
+
+		return ErrInvalidCustomNet
+	}
+	switch params.Name {
+	case MainNetParams.Name, TestNet3Params.Name,
+		RegressionNetParams.Name, SimNetParams.Name:
+
+		return ErrDuplicateNet
+	}
+	if _, ok := customNets[params.Name]; ok {
+		return ErrDuplicateNet
+	}
+
+	if err := Register(params); err != nil {
+		return err
+	}
+	customNets[params.Name] = params
+	return nil
+}
+
+// CustomNetwork returns the parameters of the custom Bitcoin network registered
+// with RegisterCustomNetwork under the passed name, if any.
+func CustomNetwork(name string) (*Params, bool) {
+	params, ok := customNets[name]
+	return params, ok
+}
+
 // mustRegister performs the same function as Register except it panics if there
 // is an error.  This should only be called from package init functions.
 func mustRegister(params *Params) {
@@ -104,6 +104,25 @@ func TestSigNetPowLimit(t *testing.T) {
 	}
 }
 
+// TestSignetNet ensures the magic bytes of signet networks are derived from
+// their challenge as expected.
+func TestSignetNet(t *testing.T) {
+	// The default signet uses the message start 0x0a03cf40.
+	if net := SignetNet(DefaultSignetChallenge); net != 0x40cf030a {
+		t.Fatalf("unexpected default signet magic: %08x", uint32(net))
+	}
+
+	params := CustomSignetParams([]byte{0x51}, nil)
+	if params.Net != SignetNet([]byte{0x51}) || params.Net == SigNetParams.Net {
+		t.Fatalf("unexpected custom signet magic: %08x",
+			uint32(params.Net))
+	}
+	if !bytes.Equal(params.SignetChallenge, []byte{0x51}) {
+		t.Fatalf("unexpected custom signet challenge: %x",
+			params.SignetChallenge)
+	}
+}
+
 // compactToBig is a copy of the blockchain.CompactToBig function. We copy it
 // here so we don't run into a circular dependency just because of a test.
 func compactToBig(compact uint32) *big.Int {
@@ -506,3 +506,37 @@ func TestRegister(t *testing.T) {
 		}
 	}
 }
+
+// TestRegisterCustomNetwork ensures custom networks are registered and looked
+// up by name as expected.
+func TestRegisterCustomNetwork(t *testing.T) {
+	customNet := CustomSignetParams([]byte{0x51}, []DNSSeed{
+		{Host: "seed.example.com"},
+	})
+	customNet.Name = "customsignet"
+
+	if _, ok := CustomNetwork(customNet.Name); ok {
+		t.Fatalf("found unregistered custom network")
+	}
+	invalidNet := customNet
+	invalidNet.GenesisBlock = nil
+	if err := RegisterCustomNetwork(&invalidNet); err != ErrInvalidCustomNet {
+		t.Fatalf("unexpected error registering invalid network: %v", err)
+	}
+	duplicateName := customNet
+	duplicateName.Name = MainNetParams.Name
+	if err := RegisterCustomNetwork(&duplicateName); err != ErrDuplicateNet {
+		t.Fatalf("unexpected error registering duplicate name: %v", err)
+	}
+
+	if err := RegisterCustomNetwork(&customNet); err != nil {
+		t.Fatalf("failed to register custom network: %v", err)
+	}
+	params, ok := CustomNetwork(customNet.Name)
+	if !ok || params != &customNet {
+		t.Fatalf("unexpected custom network: %v, %v", params, ok)
+	}
+	if err := RegisterCustomNetwork(&customNet); err != ErrDuplicateNet {
+		t.Fatalf("unexpected error registering network twice: %v", err)
+	}
+}
@@ -101,6 +101,7 @@ type config struct {
 	ProxyUser      string `long:"proxyuser" description:"Username for proxy server"`
 	RegressionTest bool   `long:"regtest" description:"Connect to the regression test network"`
 	RPCCert        string `short:"c" long:"rpccert" description:"RPC server certificate chain for validation"`
+	RPCCookieFile  string `long:"rpccookiefile" description:"RPC authentication cookie file, used when no RPC username is given (default: .cookie in the btcd data directory of the network)"`
 	RPCPassword    string `short:"P" long:"rpcpass" default-mask:"-" description:"RPC password"`
 	RPCServer      string `short:"s" long:"rpcserver" description:"RPC server to connect to"`
 	RPCUser        string `short:"u" long:"rpcuser" description:"RPC username"`
@@ -302,6 +303,27 @@ func loadConfig() (*config, []string, error) {
 	// Handle environment variable expansion in the RPC certificate path.
 	cfg.RPCCert = cleanAndExpandPath(cfg.RPCCert)
 
+	// Authenticate with the cookie written by btcd when no RPC username is
+	// given.  A missing cookie file is only an error when it was specified.
+	if cfg.RPCUser == "" && !cfg.Wallet {
+		cookieFile := cfg.RPCCookieFile
+		if cookieFile == "" {
+			cookieFile = filepath.Join(btcdHomeDir, "data",
+				netName(network), ".cookie")
+		}
+		cookieFile = cleanAndExpandPath(cookieFile)
+		user, pass, err := readCookieFile(cookieFile)
+		switch {
+		case err == nil:
+			cfg.RPCUser, cfg.RPCPassword = user, pass
+		case cfg.RPCCookieFile != "" || !os.IsNotExist(err):
+			err := fmt.Errorf("loadConfig: unable to read RPC "+
+				"cookie file: %v", err)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+
 	// Add default port to RPC server based on --testnet and --wallet flags
 	// if needed.
 	cfg.RPCServer, err = normalizeAddress(cfg.RPCServer, network, cfg.Wallet)
@@ -312,6 +334,29 @@ func loadConfig() (*config, []string, error) {
 	return &cfg, remainingArgs, nil
 }
 
+// netName returns the name of the data directory btcd uses for the passed
+// network.
+func netName(chainParams *chaincfg.Params) string {
+	if chainParams == &chaincfg.TestNet3Params {
+		return "testnet"
+	}
+	return chainParams.Name
+}
+
+// readCookieFile returns the username and password of the RPC authentication
+// cookie file at the passed path.
+func readCookieFile(path string) (string, string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	parts := strings.SplitN(strings.TrimSpace(string(content)), ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("malformed cookie file %s", path)
+	}
+	return parts[0], parts[1], nil
+}
+
 // createDefaultConfig creates a basic config file at the given destination path.
 // For this it tries to read the config file for the RPC server (either btcd or
 // btcwallet), and extract the RPC user and password from it.
@@ -9,9 +9,9 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
-	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"net"
 	"os"
 	"path/filepath"
@@ -28,9 +28,11 @@ import (
 	"github.com/btcsuite/btcd/database"
 	_ "github.com/btcsuite/btcd/database/ffldb"
 	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/mining/sv2"
+	"github.com/btcsuite/btcd/netsync"
 	"github.com/btcsuite/btcd/peer"
+	"github.com/btcsuite/btcd/zmq"
 	"github.com/btcsuite/btcutil"
-	"github.com/btcsuite/go-socks/socks"
 	flags "github.com/jessevdk/go-flags"
 )
 
@@ -40,6 +42,7 @@ const (
 	defaultLogLevel              = "info"
 	defaultLogDirname            = "logs"
 	defaultLogFilename           = "btcd.log"
+	defaultCookieFilename        = ".cookie"
 	defaultMaxPeers              = 125
 	defaultBanDuration           = time.Hour * 24
 	defaultBanThreshold          = 100
@@ -48,6 +51,8 @@ const (
 	defaultMaxRPCWebsockets      = 25
 	defaultMaxRPCConcurrentReqs  = 20
 	defaultDbType                = "ffldb"
+	defaultPeerStore             = "file"
+	defaultSyncMode              = "checkpoints"
 	defaultFreeTxRelayLimit      = 15.0
 	defaultTrickleInterval       = peer.DefaultTrickleInterval
 	defaultBlockMinSize          = 0
@@ -59,10 +64,17 @@ const (
 	blockMaxWeightMin            = 4000
 	blockMaxWeightMax            = blockchain.MaxBlockWeight - 4000
 	defaultGenerate              = false
+	defaultGBTFeeDelta           = 0.001
+	defaultSV2Interval           = sv2.DefaultInterval
 	defaultMaxOrphanTransactions = 100
 	defaultMaxOrphanTxSize       = 100000
 	defaultSigCacheMaxSize       = 100000
+	defaultUtxoCacheMaxSizeMiB   = 250
+	defaultUtxoFlushInterval     = time.Hour
 	sampleConfigFilename         = "sample-btcd.conf"
+	mempoolFilename              = "mempool.dat"
+	sv2StaticKeyFilename         = "sv2_static_key"
+	sv2AuthorityKeyFilename      = "sv2_authority_key"
 	defaultTxIndex               = false
 	defaultAddrIndex             = false
 )
@@ -105,7 +117,7 @@ type config struct {
 	BlockMinSize         uint32        `long:"blockminsize" description:"Mininum block size in bytes to be used when creating a block"`
 	BlockMaxWeight       uint32        `long:"blockmaxweight" description:"Maximum block weight to be used when creating a block"`
 	BlockMinWeight       uint32        `long:"blockminweight" description:"Mininum block weight to be used when creating a block"`
-	BlockPrioritySize    uint32        `long:"blockprioritysize" description:"Size in bytes for high-priority/low-fee transactions when creating a block"`
+	BlockPrioritySize    uint32        `long:"blockprioritysize" description:"Deprecated: Has no effect since transactions are selected by fee rate when creating a block"`
 	BlocksOnly           bool          `long:"blocksonly" description:"Do not accept transactions from remote peers."`
 	ConfigFile           string        `short:"C" long:"configfile" description:"Path to configuration file"`
 	ConnectPeers         []string      `long:"connect" description:"Connect only to the specified peers at startup"`
@@ -115,15 +127,24 @@ type config struct {
 	DebugLevel           string        `short:"d" long:"debuglevel" description:"Logging level for all subsystems {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
 	DropAddrIndex        bool          `long:"dropaddrindex" description:"Deletes the address-based transaction index from the database on start up and then exits."`
 	DropCfIndex          bool          `long:"dropcfindex" description:"Deletes the index used for committed filtering (CF) support from the database on start up and then exits."`
+	DropTweakIndex       bool          `long:"droptweakindex" description:"Deletes the silent payments tweak index from the database on start up and then exits."`
 	DropTxIndex          bool          `long:"droptxindex" description:"Deletes the hash-based transaction index from the database on start up and then exits."`
 	ExternalIPs          []string      `long:"externalip" description:"Add an ip to the list of local addresses we claim to listen on to peers"`
+	GBTFeeDelta          float64       `long:"gbtfeedelta" description:"Minimum fees in BTC paid by transactions accepted to the mempool since the last getblocktemplate template that cause a new template to be generated and long poll clients to be notified right away -- Set to 0 to only generate a new template after one minute"`
 	Generate             bool          `long:"generate" description:"Generate (mine) bitcoins using the CPU"`
+	LimitAncestorCount   uint          `long:"limitancestorcount" description:"Do not accept transactions with more unconfirmed ancestors than this, including themselves, into the memory pool -- 0 to disable"`
+	LimitAncestorSize    uint          `long:"limitancestorsize" description:"Do not accept transactions whose virtual size along with their unconfirmed ancestors exceeds this many kilobytes into the memory pool -- 0 to disable"`
+	LimitDescendantCount uint          `long:"limitdescendantcount" description:"Do not accept transactions into the memory pool which would give any of their unconfirmed ancestors more descendants than this, including themselves -- 0 to disable"`
+	LimitDescendantSize  uint          `long:"limitdescendantsize" description:"Do not accept transactions into the memory pool which would make the virtual size of any of their unconfirmed ancestors along with their descendants exceed this many kilobytes -- 0 to disable"`
 	FreeTxRelayLimit     float64       `long:"limitfreerelay" description:"Limit relay of transactions with no transaction fee to the given amount in thousands of bytes per minute"`
 	Listeners            []string      `long:"listen" description:"Add an interface/port to listen for connections (default all interfaces port: 8333, testnet: 18333)"`
+	LoadUtxoSnapshot     string        `long:"loadutxosnapshot" description:"Bootstrap the chain state of a new node from the utxo snapshot at the given path and validate the blocks leading up to it in the background -- Requires --utxosnapshothash"`
 	LogDir               string        `long:"logdir" description:"Directory to log output."`
 	MaxOrphanTxs         int           `long:"maxorphantx" description:"Max number of orphan transactions to keep in memory"`
 	MaxPeers             int           `long:"maxpeers" description:"Max number of inbound and outbound peers"`
+	MempoolFullRBF       bool          `long:"mempoolfullrbf" description:"Accept transactions that replace existing transactions within the mempool even if those do not signal replaceability through the Replace-By-Fee (RBF) signaling policy."`
 	MiningAddrs          []string      `long:"miningaddr" description:"Add the specified payment address to the list of addresses to use for generated blocks -- At least one address is required if the generate option is set"`
+	MinimumChainWork     string        `long:"minimumchainwork" description:"Minimum total work in hex the chain of a peer must have for its headers to be downloaded when using the headerspresync sync mode"`
 	MinRelayTxFee        float64       `long:"minrelaytxfee" description:"The minimum transaction fee in BTC/kB to be considered a non-zero fee."`
 	DisableBanning       bool          `long:"nobanning" description:"Disable banning of misbehaving peers"`
 	NoCFilters           bool          `long:"nocfilters" description:"Disable committed filtering (CF) support"`
@@ -132,13 +153,16 @@ type config struct {
 	DisableListen        bool          `long:"nolisten" description:"Disable listening for incoming connections -- NOTE: Listening is automatically disabled if the --connect or --proxy options are used without also specifying listen interfaces via --listen"`
 	NoOnion              bool          `long:"noonion" description:"Disable connecting to tor hidden services"`
 	NoPeerBloomFilters   bool          `long:"nopeerbloomfilters" description:"Disable bloom filtering support"`
+	NoPersistMempool     bool          `long:"nopersistmempool" description:"Do not save the transactions in the memory pool on shutdown and load them on startup"`
 	NoRelayPriority      bool          `long:"norelaypriority" description:"Do not require free or low-fee transactions to have high priority for relaying"`
 	NoWinService         bool          `long:"nowinservice" description:"Do not start as a background service on Windows -- NOTE: This flag only works on the command line, not in the config file"`
-	DisableRPC           bool          `long:"norpc" description:"Disable built-in RPC server -- NOTE: The RPC server is disabled by default if no rpcuser/rpcpass or rpclimituser/rpclimitpass is specified"`
+	DisableRPC           bool          `long:"norpc" description:"Disable built-in RPC server -- NOTE: The RPC server is disabled by default if no rpcuser/rpcpass, rpclimituser/rpclimitpass, rpcauth or rpcclientca is specified and rpccookie is not set"`
 	DisableTLS           bool          `long:"notls" description:"Disable TLS for the RPC server -- NOTE: This is only allowed if the RPC server is bound to localhost"`
 	OnionProxy           string        `long:"onion" description:"Connect to tor hidden services via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
 	OnionProxyPass       string        `long:"onionpass" default-mask:"-" description:"Password for onion proxy server"`
 	OnionProxyUser       string        `long:"onionuser" description:"Username for onion proxy server"`
+	I2PProxy             string        `long:"i2pproxy" description:"Connect to I2P peers via the SOCKS5 proxy of an I2P router (eg. 127.0.0.1:4447)"`
+	PeerStore            string        `long:"peerstore" description:"Storage backend for the addresses of known peers {file, db} -- db keeps them in the block database"`
 	Profile              string        `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
 	Proxy                string        `long:"proxy" description:"Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
 	ProxyPass            string        `long:"proxypass" default-mask:"-" description:"Password for proxy server"`
@@ -147,7 +171,12 @@ type config struct {
 	RejectNonStd         bool          `long:"rejectnonstd" description:"Reject non-standard transactions regardless of the default settings for the active network."`
 	RejectReplacement    bool          `long:"rejectreplacement" description:"Reject transactions that attempt to replace existing transactions within the mempool through the Replace-By-Fee (RBF) signaling policy."`
 	RelayNonStd          bool          `long:"relaynonstd" description:"Relay non-standard transactions regardless of the default settings for the active network."`
+	Rest                 bool          `long:"rest" description:"Accept unauthenticated REST requests for blocks, headers, committed filters and transactions from local clients on the RPC listeners"`
+	RPCAuth              []string      `long:"rpcauth" description:"Add an RPC user authenticated by a salted password hash of the form <user>:<salt>$<hash>, where hash is the hex-encoded HMAC-SHA256 of the password keyed by the salt -- Compatible with the rpcauth option of Bitcoin Core"`
 	RPCCert              string        `long:"rpccert" description:"File containing the certificate file"`
+	RPCClientCA          string        `long:"rpcclientca" description:"File containing the certificate authorities which sign RPC client certificates -- Clients presenting a valid certificate are authenticated as the user named by its common name, which may only call the limited set of methods unless whitelisted"`
+	RPCCookie            bool          `long:"rpccookie" description:"Write an RPC authentication cookie file for clients such as btcctl when --rpcuser and --rpcpass are not set -- NOTE: This enables the RPC server"`
+	RPCCookieFile        string        `long:"rpccookiefile" description:"File containing the RPC authentication cookie, which is written when --rpccookie is set (default: .cookie in the data directory)"`
 	RPCKey               string        `long:"rpckey" description:"File containing the certificate key"`
 	RPCLimitPass         string        `long:"rpclimitpass" default-mask:"-" description:"Password for limited RPC connections"`
 	RPCLimitUser         string        `long:"rpclimituser" description:"Username for limited RPC connections"`
@@ -158,26 +187,50 @@ type config struct {
 	RPCQuirks            bool          `long:"rpcquirks" description:"Mirror some JSON-RPC quirks of Bitcoin Core -- NOTE: Discouraged unless interoperability issues need to be worked around"`
 	RPCPass              string        `short:"P" long:"rpcpass" default-mask:"-" description:"Password for RPC connections"`
 	RPCUser              string        `short:"u" long:"rpcuser" description:"Username for RPC connections"`
+	RPCWhitelist         []string      `long:"rpcwhitelist" description:"Restrict an RPC user to a set of methods of the form <user>:<method>,<method>,... -- The permission tiers @readonly, @wallet and @admin may be given in place of methods"`
+	ScriptWorkers        int           `long:"scriptworkers" description:"The number of goroutines validating transaction scripts for blocks and the mempool -- 0 uses three per processor core"`
 	SigCacheMaxSize      uint          `long:"sigcachemaxsize" description:"The maximum number of entries in the signature verification cache"`
 	SimNet               bool          `long:"simnet" description:"Use the simulation test network"`
+	CustomNet            string        `long:"customnet" description:"Use the custom network registered with chaincfg.RegisterCustomNetwork under this name"`
 	SigNet               bool          `long:"signet" description:"Use the signet test network"`
 	SigNetChallenge      string        `long:"signetchallenge" description:"Connect to a custom signet network defined by this challenge instead of using the global default signet test network -- Can be specified multiple times"`
 	SigNetSeedNode       []string      `long:"signetseednode" description:"Specify a seed node for the signet network instead of using the global default signet network seed nodes"`
+	SV2                  bool          `long:"sv2" description:"Enable the Stratum V2 template provider which serves block templates to Stratum V2 pools and miners"`
+	SV2FeeDelta          float64       `long:"sv2feedelta" description:"Minimum increase in BTC of the fees of a block template over the last one sent to a Stratum V2 client for the new template to be sent"`
+	SV2Interval          time.Duration `long:"sv2interval" description:"Interval at which to check for block templates with higher fees for Stratum V2 clients"`
+	SV2Listeners         []string      `long:"sv2listen" description:"Add an interface/port to listen for Stratum V2 connections (default port: 8336, testnet: 18336)"`
+	SyncMode             string        `long:"syncmode" description:"Mode used to download the headers of the chain during the initial block download {checkpoints, headerspresync} -- headerspresync doesn't rely on checkpoints"`
 	TestNet3             bool          `long:"testnet" description:"Use the test network"`
 	TorIsolation         bool          `long:"torisolation" description:"Enable Tor stream isolation by randomizing user credentials for each connection."`
 	TrickleInterval      time.Duration `long:"trickleinterval" description:"Minimum time between attempts to send new inventory to a connected peer"`
+	TweakIndex           bool          `long:"tweakindex" description:"Maintain an index of the BIP-352 silent payments tweaks of the transactions of each block which makes the getsilentpaymenttweaks RPC available"`
 	TxIndex              bool          `long:"txindex" description:"Maintain a full hash-based transaction index which makes all transactions available via the getrawtransaction RPC"`
 	UserAgentComments    []string      `long:"uacomment" description:"Comment to add to the user agent -- See BIP 14 for more information."`
 	Upnp                 bool          `long:"upnp" description:"Use UPnP to map our listening port outside of NAT"`
+	UtxoFlushInterval    time.Duration `long:"utxocacheflushinterval" description:"The maximum time the changes to the utxo set are kept in memory before they are written to the database -- Bounds the blocks to connect again after an unclean shutdown; 0 to disable"`
+	UtxoCacheMaxSizeMiB  uint          `long:"utxocachemaxsize" description:"The maximum size in MiB of the changes to the utxo set kept in memory before they are written to the database"`
+	UtxoSnapshotHash     string        `long:"utxosnapshothash" description:"The expected utxo set hash of the snapshot loaded with --loadutxosnapshot as reported by the dumptxoutset RPC of a trusted node"`
+	V2Transport          bool          `long:"v2transport" description:"Use the BIP-324 encrypted transport for peer connections -- NOTE: Outbound connections only use it for peers which advertise support for it and are retried with the plaintext protocol if it fails"`
 	ShowVersion          bool          `short:"V" long:"version" description:"Display version information and exit"`
 	Whitelists           []string      `long:"whitelist" description:"Add an IP network or IP that will not be banned. (eg. 192.168.1.0/24 or ::1)"`
+	ZMQPubHashBlock      string        `long:"zmqpubhashblock" description:"Publish the hashes of connected blocks on the given ZMQ endpoint (eg. tcp://127.0.0.1:28332)"`
+	ZMQPubHashTx         string        `long:"zmqpubhashtx" description:"Publish the hashes of transactions added to the mempool or included in connected and disconnected blocks on the given ZMQ endpoint"`
+	ZMQPubRawBlock       string        `long:"zmqpubrawblock" description:"Publish connected blocks on the given ZMQ endpoint"`
+	ZMQPubRawTx          string        `long:"zmqpubrawtx" description:"Publish transactions added to the mempool or included in connected and disconnected blocks on the given ZMQ endpoint"`
+	ZMQPubSequence       string        `long:"zmqpubsequence" description:"Publish the hashes of connected and disconnected blocks and of transactions added to and removed from the mempool on the given ZMQ endpoint"`
 	lookup               func(string) ([]net.IP, error)
-	oniondial            func(string, string, time.Duration) (net.Conn, error)
-	dial                 func(string, string, time.Duration) (net.Conn, error)
+	dial                 connmgr.DialFunc
+	dialer               *connmgr.RoutingDialer
 	addCheckpoints       []chaincfg.Checkpoint
 	miningAddrs          []btcutil.Address
 	minRelayTxFee        btcutil.Amount
+	gbtFeeDelta          btcutil.Amount
+	sv2FeeDelta          btcutil.Amount
+	zmqEndpoints         map[string]string
 	whitelists           []*net.IPNet
+	syncMode             netsync.SyncMode
+	minimumChainWork     *big.Int
+	utxoSnapshotHash     *chainhash.Hash
 }
 
 // serviceOptions defines the configuration options for the daemon as a service on
@@ -422,10 +475,16 @@ func loadConfig() (*config, []string, error) {
 		DataDir:              defaultDataDir,
 		LogDir:               defaultLogDir,
 		DbType:               defaultDbType,
+		PeerStore:            defaultPeerStore,
+		SyncMode:             defaultSyncMode,
 		RPCKey:               defaultRPCKeyFile,
 		RPCCert:              defaultRPCCertFile,
 		MinRelayTxFee:        mempool.DefaultMinRelayTxFee.ToBTC(),
 		FreeTxRelayLimit:     defaultFreeTxRelayLimit,
+		LimitAncestorCount:   mempool.DefaultMaxAncestorCount,
+		LimitAncestorSize:    mempool.DefaultMaxAncestorSize / 1000,
+		LimitDescendantCount: mempool.DefaultMaxDescendantCount,
+		LimitDescendantSize:  mempool.DefaultMaxDescendantSize / 1000,
 		TrickleInterval:      defaultTrickleInterval,
 		BlockMinSize:         defaultBlockMinSize,
 		BlockMaxSize:         defaultBlockMaxSize,
@@ -434,6 +493,11 @@ func loadConfig() (*config, []string, error) {
 		BlockPrioritySize:    mempool.DefaultBlockPrioritySize,
 		MaxOrphanTxs:         defaultMaxOrphanTransactions,
 		SigCacheMaxSize:      defaultSigCacheMaxSize,
+		UtxoCacheMaxSizeMiB:  defaultUtxoCacheMaxSizeMiB,
+		UtxoFlushInterval:    defaultUtxoFlushInterval,
+		GBTFeeDelta:          defaultGBTFeeDelta,
+		SV2FeeDelta:          sv2.DefaultFeeDelta.ToBTC(),
+		SV2Interval:          defaultSV2Interval,
 		Generate:             defaultGenerate,
 		TxIndex:              defaultTxIndex,
 		AddrIndex:            defaultAddrIndex,
@@ -479,7 +543,8 @@ func loadConfig() (*config, []string, error) {
 	// Load additional config from file.
 	var configFileError error
 	parser := newConfigParser(&cfg, &serviceOpts, flags.Default)
-	if !(preCfg.RegressionTest || preCfg.SimNet || preCfg.SigNet) ||
+	if !(preCfg.RegressionTest || preCfg.SimNet || preCfg.SigNet ||
+		preCfg.CustomNet != "") ||
 		preCfg.ConfigFile != defaultConfigFile {
 
 		if _, err := os.Stat(preCfg.ConfigFile); os.IsNotExist(err) {
@@ -593,10 +658,29 @@ func loadConfig() (*config, []string, error) {
 		)
 		activeNetParams.Params = &chainParams
 	}
+	if cfg.CustomNet != "" {
+		numNets++
+		chainParams, ok := chaincfg.CustomNetwork(cfg.CustomNet)
+		if !ok {
+			str := "%s: The custom network %q is not registered"
+			err := fmt.Errorf(str, funcName, cfg.CustomNet)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		customParams, err := customNetParams(chainParams)
+		if err != nil {
+			err := fmt.Errorf("%s: %v", funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		activeNetParams = customParams
+	}
 	if numNets > 1 {
-		str := "%s: The testnet, regtest, segnet, signet and simnet " +
-			"params can't be used together -- choose one of the " +
-			"five"
+		str := "%s: The testnet, regtest, segnet, signet, simnet and " +
+			"custom network params can't be used together -- " +
+			"choose one of the six"
 		err := fmt.Errorf(str, funcName)
 		fmt.Fprintln(os.Stderr, err)
 		fmt.Fprintln(os.Stderr, usageMessage)
@@ -665,6 +749,71 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Validate the storage backend of the address manager.
+	if cfg.PeerStore != "file" && cfg.PeerStore != "db" {
+		str := "%s: The specified peer store [%v] is invalid -- " +
+			"supported stores [file db]"
+		err := fmt.Errorf(str, funcName, cfg.PeerStore)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Validate the sync mode.
+	switch cfg.SyncMode {
+	case netsync.SyncModeCheckpoints.String():
+		cfg.syncMode = netsync.SyncModeCheckpoints
+	case netsync.SyncModeHeadersPresync.String():
+		cfg.syncMode = netsync.SyncModeHeadersPresync
+	default:
+		str := "%s: The specified sync mode [%v] is invalid -- " +
+			"supported modes [%v %v]"
+		err := fmt.Errorf(str, funcName, cfg.SyncMode,
+			netsync.SyncModeCheckpoints, netsync.SyncModeHeadersPresync)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Parse the minimum chain work.
+	if cfg.MinimumChainWork != "" {
+		work, ok := new(big.Int).SetString(
+			strings.TrimPrefix(cfg.MinimumChainWork, "0x"), 16)
+		if !ok || work.Sign() < 0 {
+			str := "%s: The specified minimum chain work [%v] is " +
+				"not a valid hex number"
+			err := fmt.Errorf(str, funcName, cfg.MinimumChainWork)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.minimumChainWork = work
+	}
+
+	// A utxo snapshot can only be loaded along with the hash of the utxo
+	// set it is expected to contain.
+	if cfg.LoadUtxoSnapshot != "" {
+		if cfg.UtxoSnapshotHash == "" {
+			str := "%s: The --loadutxosnapshot option requires " +
+				"the --utxosnapshothash option"
+			err := fmt.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		hash, err := chainhash.NewHashFromStr(cfg.UtxoSnapshotHash)
+		if err != nil {
+			str := "%s: The specified utxo snapshot hash [%v] is " +
+				"invalid: %v"
+			err := fmt.Errorf(str, funcName, cfg.UtxoSnapshotHash, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.LoadUtxoSnapshot = cleanAndExpandPath(cfg.LoadUtxoSnapshot)
+		cfg.utxoSnapshotHash = hash
+	}
+
 	// Validate profile port number
 	if cfg.Profile != "" {
 		profilePort, err := strconv.Atoi(cfg.Profile)
@@ -768,9 +917,49 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
-	// The RPC server is disabled if no username or password is provided.
+	// Validate the RPC users and their permissions.
+	for _, option := range cfg.RPCAuth {
+		if _, _, err := parseRPCAuth(option); err != nil {
+			err := fmt.Errorf("%s: %v", funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+	for _, option := range cfg.RPCWhitelist {
+		if _, _, err := parseRPCWhitelist(option); err != nil {
+			err := fmt.Errorf("%s: %v", funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
+	// Client certificates can only be verified over TLS.
+	if cfg.RPCClientCA != "" && cfg.DisableTLS {
+		str := "%s: the --rpcclientca and --notls options may not be " +
+			"used together"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// The authentication cookie is written to the data directory by
+	// default.
+	if cfg.RPCCookieFile == "" {
+		cfg.RPCCookieFile = filepath.Join(cfg.DataDir, defaultCookieFilename)
+	} else {
+		cfg.RPCCookieFile = cleanAndExpandPath(cfg.RPCCookieFile)
+	}
+
+	// The RPC server is disabled if no users are configured and cookie
+	// authentication is not enabled.
 	if (cfg.RPCUser == "" || cfg.RPCPass == "") &&
-		(cfg.RPCLimitUser == "" || cfg.RPCLimitPass == "") {
+		(cfg.RPCLimitUser == "" || cfg.RPCLimitPass == "") &&
+		len(cfg.RPCAuth) == 0 && cfg.RPCClientCA == "" &&
+		!cfg.RPCCookie {
+
 		cfg.DisableRPC = true
 	}
 
@@ -800,6 +989,15 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	if cfg.ScriptWorkers < 0 {
+		str := "%s: The scriptworkers option may not be less than 0 " +
+			"-- parsed [%d]"
+		err := fmt.Errorf(str, funcName, cfg.ScriptWorkers)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Validate the the minrelaytxfee.
 	cfg.minRelayTxFee, err = btcutil.NewAmount(cfg.MinRelayTxFee)
 	if err != nil {
@@ -810,6 +1008,78 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Validate the gbtfeedelta.
+	cfg.gbtFeeDelta, err = btcutil.NewAmount(cfg.GBTFeeDelta)
+	if err != nil {
+		str := "%s: invalid gbtfeedelta: %v"
+		err := fmt.Errorf(str, funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+	if cfg.gbtFeeDelta < 0 {
+		str := "%s: The gbtfeedelta option must not be negative " +
+			"-- parsed [%v]"
+		err := fmt.Errorf(str, funcName, cfg.GBTFeeDelta)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Validate the Stratum V2 template provider options.
+	cfg.sv2FeeDelta, err = btcutil.NewAmount(cfg.SV2FeeDelta)
+	if err != nil {
+		str := "%s: invalid sv2feedelta: %v"
+		err := fmt.Errorf(str, funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+	if cfg.sv2FeeDelta < 0 {
+		str := "%s: The sv2feedelta option must not be negative " +
+			"-- parsed [%v]"
+		err := fmt.Errorf(str, funcName, cfg.SV2FeeDelta)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+	if cfg.SV2Interval <= 0 {
+		str := "%s: The sv2interval option must be positive " +
+			"-- parsed [%v]"
+		err := fmt.Errorf(str, funcName, cfg.SV2Interval)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Default the Stratum V2 template provider to listen on localhost
+	// only.
+	if cfg.SV2 && len(cfg.SV2Listeners) == 0 {
+		addrs, err := net.LookupHost("localhost")
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg.SV2Listeners = make([]string, 0, len(addrs))
+		for _, addr := range addrs {
+			addr = net.JoinHostPort(addr, activeNetParams.sv2Port)
+			cfg.SV2Listeners = append(cfg.SV2Listeners, addr)
+		}
+	}
+
+	// Collect the endpoints of the published ZMQ topics.
+	cfg.zmqEndpoints = make(map[string]string)
+	for topic, endpoint := range map[string]string{
+		zmq.TopicHashBlock: cfg.ZMQPubHashBlock,
+		zmq.TopicHashTx:    cfg.ZMQPubHashTx,
+		zmq.TopicRawBlock:  cfg.ZMQPubRawBlock,
+		zmq.TopicRawTx:     cfg.ZMQPubRawTx,
+		zmq.TopicSequence:  cfg.ZMQPubSequence,
+	} {
+		if endpoint != "" {
+			cfg.zmqEndpoints[topic] = endpoint
+		}
+	}
+
 	// Limit the max block size to a sane value.
 	if cfg.BlockMaxSize < blockMaxSizeMin || cfg.BlockMaxSize >
 		blockMaxSizeMax {
@@ -912,6 +1182,26 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// --tweakindex and --droptweakindex do not mix.
+	if cfg.TweakIndex && cfg.DropTweakIndex {
+		err := fmt.Errorf("%s: the --tweakindex and --droptweakindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --mempoolfullrbf and --rejectreplacement do not mix.
+	if cfg.MempoolFullRBF && cfg.RejectReplacement {
+		err := fmt.Errorf("%s: the --mempoolfullrbf and "+
+			"--rejectreplacement options may not be activated at "+
+			"the same time", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Check mining addresses are valid and saved parsed versions.
 	cfg.miningAddrs = make([]btcutil.Address, 0, len(cfg.MiningAddrs))
 	for _, strAddr := range cfg.MiningAddrs {
@@ -954,6 +1244,11 @@ func loadConfig() (*config, []string, error) {
 	cfg.RPCListeners = normalizeAddresses(cfg.RPCListeners,
 		activeNetParams.rpcPort)
 
+	// Add default port to all Stratum V2 listener addresses if needed and
+	// remove duplicate addresses.
+	cfg.SV2Listeners = normalizeAddresses(cfg.SV2Listeners,
+		activeNetParams.sv2Port)
+
 	// Only allow TLS to be disabled if the RPC is bound to localhost
 	// addresses.
 	if !cfg.DisableRPC && cfg.DisableTLS {
@@ -1050,13 +1345,8 @@ func loadConfig() (*config, []string, error) {
 				"overriding specified proxy user credentials")
 		}
 
-		proxy := &socks.Proxy{
-			Addr:         cfg.Proxy,
-			Username:     cfg.ProxyUser,
-			Password:     cfg.ProxyPass,
-			TorIsolation: torIsolation,
-		}
-		cfg.dial = proxy.DialTimeout
+		cfg.dial = connmgr.ProxyDialer(cfg.Proxy, cfg.ProxyUser,
+			cfg.ProxyPass, torIsolation)
 
 		// Treat the proxy as tor and perform DNS resolution through it
 		// unless the --noonion flag is set or there is an
@@ -1068,12 +1358,15 @@ func loadConfig() (*config, []string, error) {
 		}
 	}
 
-	// Setup onion address dial function depending on the specified options.
-	// The default is to use the same dial function selected above.  However,
-	// when an onion-specific proxy is specified, the onion address dial
-	// function is set to use the onion-specific proxy while leaving the
-	// normal dial function as selected above.  This allows .onion address
-	// traffic to be routed through a different proxy than normal traffic.
+	// Setup the dialer which routes connections depending on the network of
+	// the address and the specified options.  The default is to use the
+	// dial function selected above for all networks.  However, when an
+	// onion-specific proxy is specified, .onion addresses are dialed
+	// through the onion-specific proxy while leaving the normal dial
+	// function as selected above.  This allows .onion address traffic to
+	// be routed through a different proxy than normal traffic.  I2P
+	// addresses can only be dialed through an I2P-specific proxy.
+	cfg.dialer = connmgr.NewRoutingDialer(cfg.dial, defaultConnectTimeout)
 	if cfg.OnionProxy != "" {
 		_, _, err := net.SplitHostPort(cfg.OnionProxy)
 		if err != nil {
@@ -1093,15 +1386,9 @@ func loadConfig() (*config, []string, error) {
 				"credentials ")
 		}
 
-		cfg.oniondial = func(network, addr string, timeout time.Duration) (net.Conn, error) {
-			proxy := &socks.Proxy{
-				Addr:         cfg.OnionProxy,
-				Username:     cfg.OnionProxyUser,
-				Password:     cfg.OnionProxyPass,
-				TorIsolation: cfg.TorIsolation,
-			}
-			return proxy.DialTimeout(network, addr, timeout)
-		}
+		cfg.dialer.SetRoute(connmgr.NetworkOnion, connmgr.ProxyDialer(
+			cfg.OnionProxy, cfg.OnionProxyUser, cfg.OnionProxyPass,
+			cfg.TorIsolation))
 
 		// When configured in bridge mode (both --onion and --proxy are
 		// configured), it means that the proxy configured by --proxy is
@@ -1112,16 +1399,28 @@ func loadConfig() (*config, []string, error) {
 				return connmgr.TorLookupIP(host, cfg.OnionProxy)
 			}
 		}
-	} else {
-		cfg.oniondial = cfg.dial
 	}
 
-	// Specifying --noonion means the onion address dial function results in
-	// an error.
+	// Specifying --noonion means dialing onion addresses results in an
+	// error.
 	if cfg.NoOnion {
-		cfg.oniondial = func(a, b string, t time.Duration) (net.Conn, error) {
-			return nil, errors.New("tor has been disabled")
+		cfg.dialer.DisableNetwork(connmgr.NetworkOnion)
+	}
+
+	if cfg.I2PProxy != "" {
+		_, _, err := net.SplitHostPort(cfg.I2PProxy)
+		if err != nil {
+			str := "%s: I2P proxy address '%s' is invalid: %v"
+			err := fmt.Errorf(str, funcName, cfg.I2PProxy, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
 		}
+
+		cfg.dialer.SetRoute(connmgr.NetworkI2P, connmgr.ProxyDialer(
+			cfg.I2PProxy, "", "", false))
+	} else {
+		cfg.dialer.DisableNetwork(connmgr.NetworkI2P)
 	}
 
 	// Warn about missing config file only after all other configuration is
@@ -1207,11 +1506,7 @@ func createDefaultConfigFile(destinationPath string) error {
 // one was specified, but will otherwise use the normal dial function (which
 // could itself use a proxy or not).
 func btcdDial(addr net.Addr) (net.Conn, error) {
-	if strings.Contains(addr.String(), ".onion:") {
-		return cfg.oniondial(addr.Network(), addr.String(),
-			defaultConnectTimeout)
-	}
-	return cfg.dial(addr.Network(), addr.String(), defaultConnectTimeout)
+	return cfg.dialer.Dial(addr)
 }
 
 // btcdLookup resolves the IP of the given host using the correct DNS lookup
@@ -0,0 +1,183 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package connmgr
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/go-socks/socks"
+)
+
+// ErrNetworkDisabled is returned when dialing an address of a network which
+// has been disabled.
+var ErrNetworkDisabled = errors.New("network disabled")
+
+// Network identifies the network of an address for the purposes of routing
+// connections to it.
+type Network uint8
+
+const (
+	// NetworkIPv4 is the network of IPv4 addresses.
+	NetworkIPv4 Network = iota
+
+	// NetworkIPv6 is the network of IPv6 addresses.
+	NetworkIPv6
+
+	// NetworkOnion is the network of Tor .onion addresses.
+	NetworkOnion
+
+	// NetworkI2P is the network of I2P .i2p addresses.
+	NetworkI2P
+
+	// NetworkUnknown is the network of addresses which aren't IP addresses
+	// and belong to none of the other networks, such as unresolved host
+	// names.
+	NetworkUnknown
+)
+
+// String returns the Network in human-readable form.
+func (n Network) String() string {
+	switch n {
+	case NetworkIPv4:
+		return "ipv4"
+	case NetworkIPv6:
+		return "ipv6"
+	case NetworkOnion:
+		return "onion"
+	case NetworkI2P:
+		return "i2p"
+	case NetworkUnknown:
+		return "unknown"
+	}
+	return fmt.Sprintf("Unknown Network (%d)", uint8(n))
+}
+
+// AddrNetwork returns the network the passed address belongs to.
+func AddrNetwork(addr net.Addr) Network {
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		if tcpAddr.IP.To4() != nil {
+			return NetworkIPv4
+		}
+		return NetworkIPv6
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		host = addr.String()
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.To4() != nil {
+			return NetworkIPv4
+		}
+		return NetworkIPv6
+	}
+
+	host = strings.ToLower(host)
+	switch {
+	case strings.HasSuffix(host, ".onion"):
+		return NetworkOnion
+	case strings.HasSuffix(host, ".i2p"):
+		return NetworkI2P
+	}
+	return NetworkUnknown
+}
+
+// DialFunc connects to the address on the named network within the passed
+// timeout.  It has the signature of net.DialTimeout.
+type DialFunc func(network, address string, timeout time.Duration) (net.Conn, error)
+
+// ProxyDialer returns a DialFunc which connects through the SOCKS5 proxy at the
+// passed address using the passed credentials.  When isolation is true, the
+// credentials are replaced by random ones for each connection, which makes Tor
+// use a separate circuit for each of them.
+func ProxyDialer(proxyAddr, username, password string, isolation bool) DialFunc {
+	proxy := &socks.Proxy{
+		Addr:         proxyAddr,
+		Username:     username,
+		Password:     password,
+		TorIsolation: isolation,
+	}
+	return proxy.DialTimeout
+}
+
+// route houses how connections to the addresses of a network are made.
+type route struct {
+	dial     DialFunc
+	disabled bool
+}
+
+// RoutingDialer dials addresses using the dial function configured for their
+// network, which allows routing connections to different networks through
+// different proxies, for example Tor for onion peers and direct connections
+// for clearnet peers.  Networks without a route use the fallback dial function.
+//
+// The routes may be changed at any time and apply to all connections made
+// afterwards.  RoutingDialer is safe for concurrent access.
+type RoutingDialer struct {
+	mtx      sync.RWMutex
+	routes   map[Network]route
+	fallback DialFunc
+	timeout  time.Duration
+}
+
+// NewRoutingDialer returns a new RoutingDialer which uses the passed fallback
+// dial function for networks without a route and the passed timeout for all
+// connections.  A nil fallback means net.DialTimeout is used.
+func NewRoutingDialer(fallback DialFunc, timeout time.Duration) *RoutingDialer {
+	if fallback == nil {
+		fallback = net.DialTimeout
+	}
+	return &RoutingDialer{
+		routes:   make(map[Network]route),
+		fallback: fallback,
+		timeout:  timeout,
+	}
+}
+
+// SetRoute sets the dial function used to connect to addresses of the passed
+// network.  A nil dial function removes the route, so the fallback dial
+// function is used again.
+func (d *RoutingDialer) SetRoute(network Network, dial DialFunc) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if dial == nil {
+		delete(d.routes, network)
+		return
+	}
+	d.routes[network] = route{dial: dial}
+}
+
+// DisableNetwork makes all connection attempts to addresses of the passed
+// network fail with ErrNetworkDisabled until a new route is set.
+func (d *RoutingDialer) DisableNetwork(network Network) {
+	d.mtx.Lock()
+	d.routes[network] = route{disabled: true}
+	d.mtx.Unlock()
+}
+
+// Dial connects to the passed address using the dial function configured for
+// its network.  It has the signature required by the Dial field of Config.
+func (d *RoutingDialer) Dial(addr net.Addr) (net.Conn, error) {
+	network := AddrNetwork(addr)
+
+	d.mtx.RLock()
+	r, ok := d.routes[network]
+	fallback, timeout := d.fallback, d.timeout
+	d.mtx.RUnlock()
+
+	switch {
+	case ok && r.disabled:
+		return nil, ErrNetworkDisabled
+	case ok:
+		return r.dial(addr.Network(), addr.String(), timeout)
+	}
+	return fallback(addr.Network(), addr.String(), timeout)
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package connmgr
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// TestAddrNetwork ensures the network of addresses is determined correctly.
+func TestAddrNetwork(t *testing.T) {
+	tests := []struct {
+		addr net.Addr
+		want Network
+	}{
+		{&net.TCPAddr{IP: net.ParseIP("1.2.3.4"), Port: 8333}, NetworkIPv4},
+		{&net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 8333}, NetworkIPv6},
+		{mockAddr{"tcp", "1.2.3.4:8333"}, NetworkIPv4},
+		{mockAddr{"tcp", "[2001:db8::1]:8333"}, NetworkIPv6},
+		{mockAddr{"onion", "3g2upl4pq6kufc4m.onion:8333"}, NetworkOnion},
+		{mockAddr{"onion", "EXAMPLE.ONION:8333"}, NetworkOnion},
+		{mockAddr{"i2p", "example.b32.i2p:0"}, NetworkI2P},
+		{mockAddr{"tcp", "seed.example.com:8333"}, NetworkUnknown},
+	}
+
+	for _, test := range tests {
+		if got := AddrNetwork(test.addr); got != test.want {
+			t.Errorf("AddrNetwork(%v): got %v, want %v", test.addr,
+				got, test.want)
+		}
+	}
+}
+
+// TestRoutingDialer ensures the routing dialer dials addresses using the dial
+// function of their network and that routes can be changed at runtime.
+func TestRoutingDialer(t *testing.T) {
+	var dialed string
+	dialer := func(name string) DialFunc {
+		return func(network, addr string, timeout time.Duration) (net.Conn, error) {
+			if timeout != time.Second {
+				t.Fatalf("unexpected timeout %v", timeout)
+			}
+			dialed = name
+			return &mockConn{rAddr: mockAddr{network, addr}}, nil
+		}
+	}
+
+	d := NewRoutingDialer(dialer("direct"), time.Second)
+	d.SetRoute(NetworkOnion, dialer("tor"))
+	d.DisableNetwork(NetworkI2P)
+
+	clearnet := &net.TCPAddr{IP: net.ParseIP("1.2.3.4"), Port: 8333}
+	onion := mockAddr{"onion", "3g2upl4pq6kufc4m.onion:8333"}
+	i2p := mockAddr{"i2p", "example.b32.i2p:0"}
+
+	tests := []struct {
+		name    string
+		setup   func()
+		addr    net.Addr
+		want    string
+		wantErr error
+	}{{
+		name: "clearnet uses fallback",
+		addr: clearnet,
+		want: "direct",
+	}, {
+		name: "onion uses route",
+		addr: onion,
+		want: "tor",
+	}, {
+		name:    "disabled network",
+		addr:    i2p,
+		wantErr: ErrNetworkDisabled,
+	}, {
+		name:  "enabled network",
+		setup: func() { d.SetRoute(NetworkI2P, dialer("i2p")) },
+		addr:  i2p,
+		want:  "i2p",
+	}, {
+		name:  "clearnet routed through tor",
+		setup: func() { d.SetRoute(NetworkIPv4, dialer("tor")) },
+		addr:  clearnet,
+		want:  "tor",
+	}, {
+		name:  "removed route uses fallback",
+		setup: func() { d.SetRoute(NetworkOnion, nil) },
+		addr:  onion,
+		want:  "direct",
+	}}
+
+	for _, test := range tests {
+		if test.setup != nil {
+			test.setup()
+		}
+
+		dialed = ""
+		conn, err := d.Dial(test.addr)
+		if err != test.wantErr {
+			t.Fatalf("%s: unexpected error -- got %v, want %v",
+				test.name, err, test.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if dialed != test.want {
+			t.Fatalf("%s: dialed with %q, want %q", test.name,
+				dialed, test.want)
+		}
+		if conn.RemoteAddr().String() != test.addr.String() {
+			t.Fatalf("%s: dialed %v, want %v", test.name,
+				conn.RemoteAddr(), test.addr)
+		}
+	}
+}
@@ -88,6 +88,14 @@ func realMain() error {
 	parser.AddCommand("fetchblockregion",
 		"Fetch the specified block region from the database", "",
 		&blockRegionCfg)
+	parser.AddCommand("storagestats",
+		"Show statistics of the storage backing the database metadata",
+		"", &storageStatsCfg)
+	parser.AddCommand("migrate",
+		"Migrate the block database to another backend or directory",
+		"Copy all blocks and metadata of the block database into a "+
+			"new database of the type given by --dstdbtype in the "+
+			"data directory given by --dstdatadir.", &migrateCfg)
 
 	// Parse command line and invoke the Execute function for the specified
 	// command.
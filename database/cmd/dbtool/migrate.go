@@ -0,0 +1,293 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/database"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// migrateBatchKeys is the maximum number of metadata keys copied to
+	// the destination database in a single transaction.
+	migrateBatchKeys = 50000
+
+	// migrateBatchBlocks is the maximum number of blocks copied to the
+	// destination database in a single transaction.
+	migrateBatchBlocks = 500
+
+	// migrateBatchSize is the maximum total size of the blocks copied to
+	// the destination database in a single transaction.
+	migrateBatchSize = 32 * 1024 * 1024
+)
+
+// errMigrateInterrupted is returned when the migration is interrupted.
+var errMigrateInterrupted = errors.New("migration interrupted")
+
+// migrateCmd defines the configuration options for the migrate command.
+type migrateCmd struct {
+	DstDataDir string `long:"dstdatadir" description:"Location of the btcd data directory to create the destination database in (default: --datadir)"`
+	DstDbType  string `long:"dstdbtype" description:"Database backend to migrate the block database to"`
+}
+
+var (
+	// migrateCfg defines the configuration options for the command.
+	migrateCfg = migrateCmd{}
+)
+
+// migrateInterrupted returns whether or not the passed interrupt channel has
+// been closed.
+func migrateInterrupted(interrupt <-chan struct{}) bool {
+	select {
+	case <-interrupt:
+		return true
+	default:
+		return false
+	}
+}
+
+// migrateBucket copies all keys and nested buckets of the metadata bucket at
+// the passed path from the source to the destination database in batches of
+// at most migrateBatchKeys entries.  Top-level keys with the passed internal
+// prefix are skipped since they are managed by the source database backend.
+func migrateBucket(src, dst database.DB, path [][]byte, internalPrefix []byte,
+	interrupt <-chan struct{}) error {
+
+	bucketAtPath := func(tx database.Tx) database.Bucket {
+		bucket := tx.Metadata()
+		for _, name := range path {
+			bucket = bucket.Bucket(name)
+		}
+		return bucket
+	}
+
+	var nested [][]byte
+	var seek []byte
+	for done := false; !done; {
+		if migrateInterrupted(interrupt) {
+			return errMigrateInterrupted
+		}
+
+		err := src.View(func(srcTx database.Tx) error {
+			return dst.Update(func(dstTx database.Tx) error {
+				srcBucket := bucketAtPath(srcTx)
+				dstBucket := bucketAtPath(dstTx)
+
+				cursor := srcBucket.Cursor()
+				ok := cursor.First()
+				if seek != nil {
+					ok = cursor.Seek(seek)
+				}
+				var numKeys int
+				for ; ok; ok = cursor.Next() {
+					key := cursor.Key()
+					if numKeys == migrateBatchKeys {
+						seek = append([]byte(nil), key...)
+						return nil
+					}
+					if len(path) == 0 &&
+						bytes.HasPrefix(key, internalPrefix) {
+
+						continue
+					}
+					numKeys++
+
+					// Nested buckets are copied once all keys
+					// of this bucket are.
+					value := cursor.Value()
+					if value == nil {
+						_, err := dstBucket.CreateBucketIfNotExists(key)
+						if err != nil {
+							return err
+						}
+						nested = append(nested,
+							append([]byte(nil), key...))
+						continue
+					}
+					if err := dstBucket.Put(key, value); err != nil {
+						return err
+					}
+				}
+
+				done = true
+				return nil
+			})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range nested {
+		nestedPath := append(append([][]byte(nil), path...), name)
+		err := migrateBucket(src, dst, nestedPath, nil, interrupt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateBlocks copies the blocks with the passed hashes from the source to
+// the destination database in batches bounded by migrateBatchBlocks and
+// migrateBatchSize.  Blocks which already exist in the destination database
+// are skipped.
+func migrateBlocks(src, dst database.DB, hashes []chainhash.Hash,
+	interrupt <-chan struct{}) error {
+
+	numMigrated := 0
+	for len(hashes) > 0 {
+		if migrateInterrupted(interrupt) {
+			return errMigrateInterrupted
+		}
+
+		err := src.View(func(srcTx database.Tx) error {
+			return dst.Update(func(dstTx database.Tx) error {
+				var batchSize int
+				for i := 0; i < migrateBatchBlocks && len(hashes) > 0 &&
+					batchSize < migrateBatchSize; i++ {
+
+					hash := &hashes[0]
+					hashes = hashes[1:]
+
+					exists, err := dstTx.HasBlock(hash)
+					if err != nil {
+						return err
+					}
+					if exists {
+						continue
+					}
+
+					blockBytes, err := srcTx.FetchBlock(hash)
+					if err != nil {
+						return err
+					}
+					batchSize += len(blockBytes)
+					block, err := btcutil.NewBlockFromBytes(blockBytes)
+					if err != nil {
+						return err
+					}
+					if err := dstTx.StoreBlock(block); err != nil {
+						return err
+					}
+					numMigrated++
+				}
+				return nil
+			})
+		})
+		if err != nil {
+			return err
+		}
+		log.Infof("Migrated %d blocks (%d remaining)", numMigrated,
+			len(hashes))
+	}
+	return nil
+}
+
+// Execute is the main entry point for the command.  It's invoked by the parser.
+func (cmd *migrateCmd) Execute(args []string) error {
+	// Setup the global config options and ensure they are valid.
+	if err := setupGlobalConfig(); err != nil {
+		return err
+	}
+
+	// Validate the destination database options.  The destination data
+	// directory is namespaced per network the same way as the source one.
+	dstDbType := cmd.DstDbType
+	if dstDbType == "" {
+		dstDbType = cfg.DbType
+	}
+	if !validDbType(dstDbType) {
+		str := "The specified destination database type [%v] is " +
+			"invalid -- supported types %v"
+		return fmt.Errorf(str, dstDbType, knownDbTypes)
+	}
+	dstDataDir := cfg.DataDir
+	if cmd.DstDataDir != "" {
+		dstDataDir = filepath.Join(cmd.DstDataDir,
+			netName(activeNetParams))
+	}
+	srcPath := filepath.Join(cfg.DataDir, blockDbNamePrefix+"_"+cfg.DbType)
+	dstPath := filepath.Join(dstDataDir, blockDbNamePrefix+"_"+dstDbType)
+	if filepath.Clean(srcPath) == filepath.Clean(dstPath) {
+		return errors.New("the source and destination databases must " +
+			"differ in type or data directory")
+	}
+	if fileExists(dstPath) {
+		return fmt.Errorf("destination database '%s' already exists",
+			dstPath)
+	}
+
+	// Load the source block database.
+	src, err := loadBlockDB()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	log.Infof("Creating destination database in '%s'", dstPath)
+	if err := os.MkdirAll(dstDataDir, 0700); err != nil {
+		return err
+	}
+	dst, err := database.Create(dstDbType, dstPath, activeNetParams.Net)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	interrupt := make(chan struct{})
+	addInterruptHandler(func() {
+		close(interrupt)
+	})
+
+	// NOTE: This code will only work for an ffldb source.  Ideally the
+	// database interface would provide a means to iterate the stored
+	// blocks.
+	blockIdxName := []byte("ffldb-blockidx")
+	var hashes []chainhash.Hash
+	err = src.View(func(tx database.Tx) error {
+		blockIdxBucket := tx.Metadata().Bucket(blockIdxName)
+		if blockIdxBucket == nil {
+			return fmt.Errorf("source database of type %s is not "+
+				"supported", src.Type())
+		}
+		return blockIdxBucket.ForEach(func(k, v []byte) error {
+			var hash chainhash.Hash
+			copy(hash[:], k)
+			hashes = append(hashes, hash)
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	startTime := time.Now()
+	log.Infof("Migrating %d blocks...", len(hashes))
+	if err := migrateBlocks(src, dst, hashes, interrupt); err != nil {
+		return err
+	}
+
+	// Copy the metadata, skipping the keys internal to the source
+	// backend, which are prefixed with its type.
+	log.Infof("Migrating metadata...")
+	internalPrefix := []byte(src.Type() + "-")
+	err = migrateBucket(src, dst, nil, internalPrefix, interrupt)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Migrated block database to '%s' in %v", dstPath,
+		time.Since(startTime))
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/database"
+)
+
+// storageStatsCmd defines the configuration options for the storagestats
+// command.
+type storageStatsCmd struct{}
+
+var (
+	// storageStatsCfg defines the configuration options for the command.
+	storageStatsCfg = storageStatsCmd{}
+)
+
+// Execute is the main entry point for the command.  It's invoked by the parser.
+func (cmd *storageStatsCmd) Execute(args []string) error {
+	// Setup the global config options and ensure they are valid.
+	if err := setupGlobalConfig(); err != nil {
+		return err
+	}
+
+	// Load the block database.
+	db, err := loadBlockDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	reporter, ok := db.(database.StatsReporter)
+	if !ok {
+		return fmt.Errorf("database type %s does not report storage "+
+			"statistics", db.Type())
+	}
+	stats, err := reporter.StorageStats()
+	if err != nil {
+		return err
+	}
+
+	for i, level := range stats.Levels {
+		log.Infof("Level #%d: %d tables, %d bytes, compactions read %d "+
+			"bytes, wrote %d bytes in %v", i, level.Tables,
+			level.Size, level.CompactionRead, level.CompactionWrite,
+			level.CompactionDuration)
+	}
+	log.Infof("Write stalls: %d (%v), paused: %v", stats.WriteStalls,
+		stats.WriteStallDuration, stats.WritePaused)
+	log.Infof("Disk I/O: read %d bytes, wrote %d bytes", stats.BytesRead,
+		stats.BytesWritten)
+	return nil
+}
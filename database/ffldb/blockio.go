@@ -373,8 +373,9 @@ func (s *blockStore) blockFile(fileNum uint32) (*lockableFile, error) {
 // event of failure.
 //
 // NOTE: This function MUST be called with the write cursor current file lock
-// held and must only be called during a write transaction so it is effectively
-// locked for writes.  Also, the write cursor current file must NOT be nil.
+// held (at least for reads) and must only be called during a write transaction
+// so it is effectively locked for writes.  Also, the write cursor current file
+// must NOT be nil.
 func (s *blockStore) writeData(data []byte, fieldName string) error {
 	wc := s.writeCursor
 	n, err := wc.curFile.file.WriteAt(data, int64(wc.curOffset))
@@ -439,22 +440,28 @@ func (s *blockStore) writeBlock(rawBlock []byte) (blockLocation, error) {
 		wc.Unlock()
 	}
 
-	// All writes are done under the write lock for the file to ensure any
-	// readers are finished and blocked first.
-	wc.curFile.Lock()
-	defer wc.curFile.Unlock()
-
 	// Open the current file if needed.  This will typically only be the
 	// case when moving to the next file to write to or on initial database
 	// load.  However, it might also be the case if rollbacks happened after
 	// file writes started during a transaction commit.
+	wc.curFile.Lock()
 	if wc.curFile.file == nil {
 		file, err := s.openWriteFileFunc(wc.curFileNum)
 		if err != nil {
+			wc.curFile.Unlock()
 			return blockLocation{}, err
 		}
 		wc.curFile.file = file
 	}
+	wc.curFile.Unlock()
+
+	// The block is appended after all of the blocks any transaction is
+	// able to reference, so readers of the blocks already in the file do
+	// not need to be blocked while it is written.  Thus, writes are only
+	// done under the read lock for the file, which prevents it from being
+	// closed underneath them.
+	wc.curFile.RLock()
+	defer wc.curFile.RUnlock()
 
 	// Bitcoin network.
 	origOffset := wc.curOffset
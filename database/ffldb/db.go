@@ -1737,6 +1737,9 @@ type db struct {
 // Enforce db implements the database.DB interface.
 var _ database.DB = (*db)(nil)
 
+// Enforce db implements the database.StatsReporter interface.
+var _ database.StatsReporter = (*db)(nil)
+
 // Type returns the database driver type the current database instance was
 // created with.
 //
@@ -1941,6 +1944,44 @@ func (db *db) Close() error {
 	return closeErr
 }
 
+// StorageStats returns the current statistics of the leveldb database backing
+// the metadata, including the compaction activity of each level.
+//
+// This function is part of the database.StatsReporter interface
+// implementation.
+func (db *db) StorageStats() (*database.StorageStats, error) {
+	db.closeLock.RLock()
+	defer db.closeLock.RUnlock()
+
+	if db.closed {
+		return nil, makeDbErr(database.ErrDbNotOpen, errDbNotOpenStr, nil)
+	}
+
+	var ldbStats leveldb.DBStats
+	if err := db.cache.ldb.Stats(&ldbStats); err != nil {
+		return nil, convertErr("failed to fetch leveldb stats", err)
+	}
+
+	stats := database.StorageStats{
+		Levels:             make([]database.LevelStats, 0, len(ldbStats.LevelSizes)),
+		WriteStalls:        int64(ldbStats.WriteDelayCount),
+		WriteStallDuration: ldbStats.WriteDelayDuration,
+		WritePaused:        ldbStats.WritePaused,
+		BytesRead:          ldbStats.IORead,
+		BytesWritten:       ldbStats.IOWrite,
+	}
+	for i := range ldbStats.LevelSizes {
+		stats.Levels = append(stats.Levels, database.LevelStats{
+			Tables:             ldbStats.LevelTablesCounts[i],
+			Size:               ldbStats.LevelSizes[i],
+			CompactionRead:     ldbStats.LevelRead[i],
+			CompactionWrite:    ldbStats.LevelWrite[i],
+			CompactionDuration: ldbStats.LevelDurations[i],
+		})
+	}
+	return &stats, nil
+}
+
 // filesExists reports whether the named file or directory exists.
 func fileExists(name string) bool {
 	if _, err := os.Stat(name); err != nil {
@@ -396,22 +396,28 @@ type dbCache struct {
 //
 // The snapshot must be released after use by calling Release.
 func (c *dbCache) Snapshot() (*dbCacheSnapshot, error) {
+	// Since the cached keys to be added and removed use an immutable treap,
+	// a snapshot is simply obtaining the root of the tree under the lock
+	// which is used to atomically swap the root.
+	//
+	// The snapshot of the underlying database is obtained under the same
+	// lock to ensure the cache is not cleared by a concurrent flush in
+	// between.  Otherwise, the snapshot could be missing the flushed keys
+	// when the database snapshot predates the flush while the cache
+	// snapshot is taken after it.
+	c.cacheLock.RLock()
+	defer c.cacheLock.RUnlock()
 	dbSnapshot, err := c.ldb.GetSnapshot()
 	if err != nil {
 		str := "failed to open transaction"
 		return nil, convertErr(str, err)
 	}
 
-	// Since the cached keys to be added and removed use an immutable treap,
-	// a snapshot is simply obtaining the root of the tree under the lock
-	// which is used to atomically swap the root.
-	c.cacheLock.RLock()
 	cacheSnapshot := &dbCacheSnapshot{
 		dbSnapshot:    dbSnapshot,
 		pendingKeys:   c.cachedKeys,
 		pendingRemove: c.cachedRemove,
 	}
-	c.cacheLock.RUnlock()
 	return cacheSnapshot, nil
 }
 
@@ -284,3 +284,48 @@ func TestInterface(t *testing.T) {
 		testInterface(t, db)
 	})
 }
+
+// TestStorageStats ensures the database reports the statistics of its
+// underlying storage while open and an error once closed.
+func TestStorageStats(t *testing.T) {
+	t.Parallel()
+
+	// Create a new database to run tests against.
+	dbPath := filepath.Join(os.TempDir(), "ffldb-storagestatstest")
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Errorf("Failed to create test database (%s) %v", dbType, err)
+		return
+	}
+	defer os.RemoveAll(dbPath)
+
+	reporter, ok := db.(database.StatsReporter)
+	if !ok {
+		db.Close()
+		t.Errorf("StorageStats: database does not report stats")
+		return
+	}
+	stats, err := reporter.StorageStats()
+	if err != nil {
+		db.Close()
+		t.Errorf("StorageStats: unexpected error: %v", err)
+		return
+	}
+	if stats.WritePaused {
+		db.Close()
+		t.Errorf("StorageStats: unexpected paused writes")
+		return
+	}
+
+	// Ensure fetching the stats of a closed database fails with the
+	// expected error.
+	if err := db.Close(); err != nil {
+		t.Errorf("Close: unexpected error: %v", err)
+		return
+	}
+	_, err = reporter.StorageStats()
+	if !checkDbError(t, "StorageStats", err, database.ErrDbNotOpen) {
+		return
+	}
+}
@@ -15,9 +15,11 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/database"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
@@ -704,3 +706,141 @@ func TestFailureScenarios(t *testing.T) {
 	// Test various corruption scenarios.
 	testCorruption(tc)
 }
+
+// TestSnapshotIsolation ensures read-only transactions started concurrently
+// with write transactions which store blocks and flush the database cache
+// always observe a consistent snapshot of the database.
+func TestSnapshotIsolation(t *testing.T) {
+	t.Parallel()
+
+	// Create a new database to run tests against.
+	dbPath := filepath.Join(os.TempDir(), "ffldb-snapshotisolation")
+	_ = os.RemoveAll(dbPath)
+	idb, err := database.Create(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Errorf("Failed to create test database (%s) %v", dbType, err)
+		return
+	}
+	defer os.RemoveAll(dbPath)
+	defer idb.Close()
+
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Errorf("loadBlocks: Unexpected error: %v", err)
+		return
+	}
+	blocks = blocks[:100]
+
+	// The hashes are computed up front since the readers would race with
+	// the writer caching them otherwise.
+	hashes := make([]chainhash.Hash, len(blocks))
+	for i, block := range blocks {
+		hashes[i] = *block.Hash()
+	}
+
+	// Force the database cache to be flushed on every commit.
+	idb.(*db).cache.flushInterval = 0
+
+	// Each write transaction stores the next block along with its index
+	// under two separate keys.
+	keyA, keyB := []byte("blockidxa"), []byte("blockidxb")
+	storeBlock := func(i int) error {
+		return idb.Update(func(tx database.Tx) error {
+			if err := tx.StoreBlock(blocks[i]); err != nil {
+				return err
+			}
+			var idx [4]byte
+			binary.BigEndian.PutUint32(idx[:], uint32(i))
+			if err := tx.Metadata().Put(keyA, idx[:]); err != nil {
+				return err
+			}
+			return tx.Metadata().Put(keyB, idx[:])
+		})
+	}
+	if err := storeBlock(0); err != nil {
+		t.Errorf("storeBlock: Unexpected error: %v", err)
+		return
+	}
+
+	// checkSnapshot ensures the passed transaction observes the keys and
+	// block stored by the same write transaction and returns the block
+	// index.
+	checkSnapshot := func(tx database.Tx) (int, error) {
+		idxA := tx.Metadata().Get(keyA)
+		idxB := tx.Metadata().Get(keyB)
+		if len(idxA) != 4 || string(idxA) != string(idxB) {
+			return 0, fmt.Errorf("inconsistent snapshot: %x != %x",
+				idxA, idxB)
+		}
+		idx := int(binary.BigEndian.Uint32(idxA))
+		if _, err := tx.FetchBlock(&hashes[idx]); err != nil {
+			return 0, err
+		}
+		if idx+1 < len(blocks) {
+			exists, err := tx.HasBlock(&hashes[idx+1])
+			if err != nil {
+				return 0, err
+			}
+			if exists {
+				return 0, fmt.Errorf("block %d stored after "+
+					"snapshot exists", idx+1)
+			}
+		}
+		return idx, nil
+	}
+
+	// Keep a read-only transaction open during all of the writes.
+	oldTx, err := idb.Begin(false)
+	if err != nil {
+		t.Errorf("Begin: Unexpected error: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	quit := make(chan struct{})
+	errChan := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-quit:
+					return
+				default:
+				}
+
+				err := idb.View(func(tx database.Tx) error {
+					_, err := checkSnapshot(tx)
+					return err
+				})
+				if err != nil {
+					errChan <- err
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 1; i < len(blocks); i++ {
+		if err := storeBlock(i); err != nil {
+			t.Errorf("storeBlock #%d: Unexpected error: %v", i, err)
+			break
+		}
+	}
+	close(quit)
+	wg.Wait()
+	close(errChan)
+	for err := range errChan {
+		t.Errorf("View: Unexpected error: %v", err)
+	}
+
+	idx, err := checkSnapshot(oldTx)
+	if err != nil || idx != 0 {
+		t.Errorf("checkSnapshot: unexpected result on transaction "+
+			"started before writes: %d, %v", idx, err)
+	}
+	if err := oldTx.Rollback(); err != nil {
+		t.Errorf("Rollback: Unexpected error: %v", err)
+	}
+}
@@ -8,6 +8,8 @@
 package database
 
 import (
+	"time"
+
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcutil"
 )
@@ -435,6 +437,11 @@ type DB interface {
 	// transaction can be started at a time.  The call will block when
 	// starting a read-write transaction when one is already open.
 	//
+	// Every transaction operates on a consistent snapshot of the database
+	// taken when it is started.  Read-only transactions run concurrently
+	// with a read-write transaction and do not observe any of its changes,
+	// even once it is committed.
+	//
 	// NOTE: The transaction must be closed by calling Rollback or Commit on
 	// it when it is no longer needed.  Failure to do so can result in
 	// unclaimed memory and/or inablity to close the database due to locks
@@ -464,3 +471,49 @@ type DB interface {
 	// back or committed).
 	Close() error
 }
+
+// LevelStats houses the statistics of a single level of the log-structured
+// merge tree backing the metadata of a database.
+type LevelStats struct {
+	// Tables is the number of tables in the level and Size is their total
+	// size in bytes.
+	Tables int
+	Size   int64
+
+	// CompactionRead and CompactionWrite are the total number of bytes read
+	// and written by compactions into the level and CompactionDuration is
+	// the total time spent compacting it.
+	CompactionRead     int64
+	CompactionWrite    int64
+	CompactionDuration time.Duration
+}
+
+// StorageStats houses runtime statistics about the key/value store backing the
+// metadata of a database.  They are mainly useful to diagnose compaction
+// stalls and excessive disk usage on large chains.
+type StorageStats struct {
+	// Levels houses the statistics for the levels of the tree in increasing
+	// order.  Levels without any tables or compaction activity are
+	// omitted.
+	Levels []LevelStats
+
+	// WriteStalls is the number of times writes were delayed while waiting
+	// on compactions and WriteStallDuration is the total time spent
+	// waiting.  WritePaused reports whether writes are currently paused.
+	WriteStalls        int64
+	WriteStallDuration time.Duration
+	WritePaused        bool
+
+	// BytesRead and BytesWritten are the total number of bytes read from
+	// and written to disk by the store.
+	BytesRead    uint64
+	BytesWritten uint64
+}
+
+// StatsReporter is an optional interface a DB may implement to report
+// statistics about its underlying storage while it is open.
+type StatsReporter interface {
+	// StorageStats returns the current statistics of the key/value store
+	// backing the metadata of the database.
+	StorageStats() (*StorageStats, error)
+}
@@ -40,13 +40,15 @@ Application Options:
                               block (default: 3000000)
       --blockminweight=       Mininum block weight to be used when creating a
                               block
-      --blockprioritysize=    Size in bytes for high-priority/low-fee
-                              transactions when creating a block (default:
-                              50000)
+      --blockprioritysize=    Deprecated: Has no effect since transactions are
+                              selected by fee rate when creating a block
+                              (default: 50000)
       --blocksonly            Do not accept transactions from remote peers.
   -C, --configfile=           Path to configuration file
       --connect=              Connect only to the specified peers at startup
       --cpuprofile=           Write CPU profile to the specified file
+      --customnet=            Use the custom network registered with
+                              chaincfg.RegisterCustomNetwork under this name
   -b, --datadir=              Directory to store data
       --dbtype=               Database backend to use for the Block Chain
                               (default: ffldb)
@@ -62,26 +64,63 @@ Application Options:
       --dropcfindex           Deletes the index used for committed filtering
                               (CF) support from the database on start up and
                               then exits.
+      --droptweakindex        Deletes the silent payments tweak index from the
+                              database on start up and then exits.
       --droptxindex           Deletes the hash-based transaction index from the
                               database on start up and then exits.
       --externalip=           Add an ip to the list of local addresses we claim
                               to listen on to peers
+      --gbtfeedelta=          Minimum fees in BTC paid by transactions accepted
+                              to the mempool since the last getblocktemplate
+                              template that cause a new template to be
+                              generated and long poll clients to be notified
+                              right away -- Set to 0 to only generate a new
+                              template after one minute (default: 0.001)
       --generate              Generate (mine) bitcoins using the CPU
+      --i2pproxy=             Connect to I2P peers via the SOCKS5 proxy of an
+                              I2P router (eg. 127.0.0.1:4447)
+      --limitancestorcount=   Do not accept transactions with more unconfirmed
+                              ancestors than this, including themselves, into
+                              the memory pool -- 0 to disable (default: 25)
+      --limitancestorsize=    Do not accept transactions whose virtual size
+                              along with their unconfirmed ancestors exceeds
+                              this many kilobytes into the memory pool -- 0 to
+                              disable (default: 101)
+      --limitdescendantcount= Do not accept transactions into the memory pool
+                              which would give any of their unconfirmed
+                              ancestors more descendants than this, including
+                              themselves -- 0 to disable (default: 25)
+      --limitdescendantsize=  Do not accept transactions into the memory pool
+                              which would make the virtual size of any of their
+                              unconfirmed ancestors along with their
+                              descendants exceed this many kilobytes -- 0 to
+                              disable (default: 101)
       --limitfreerelay=       Limit relay of transactions with no transaction
                               fee to the given amount in thousands of bytes per
                               minute (default: 15)
       --listen=               Add an interface/port to listen for connections
                               (default all interfaces port: 8333, testnet:
                               18333, signet: 38333)
+      --loadutxosnapshot=     Bootstrap the chain state of a new node from the
+                              utxo snapshot at the given path and validate the
+                              blocks leading up to it in the background --
+                              Requires --utxosnapshothash
       --logdir=               Directory to log output
       --maxorphantx=          Max number of orphan transactions to keep in
                               memory (default: 100)
       --maxpeers=             Max number of inbound and outbound peers
                               (default: 125)
+      --mempoolfullrbf        Accept transactions that replace existing
+                              transactions within the mempool even if those do
+                              not signal replaceability through the
+                              Replace-By-Fee (RBF) signaling policy.
       --miningaddr=           Add the specified payment address to the list of
                               addresses to use for generated blocks -- At least
                               one address is required if the generate option is
                               set
+      --minimumchainwork=     Minimum total work in hex the chain of a peer
+                              must have for its headers to be downloaded when
+                              using the headerspresync sync mode
       --minrelaytxfee=        The minimum transaction fee in BTC/kB to be
                               considered a non-zero fee. (default: 1e-05)
       --nobanning             Disable banning of misbehaving peers
@@ -95,12 +134,15 @@ Application Options:
                               also specifying listen interfaces via --listen
       --noonion               Disable connecting to tor hidden services
       --nopeerbloomfilters    Disable bloom filtering support
+      --nopersistmempool      Do not save the transactions in the memory pool
+                              on shutdown and load them on startup
       --norelaypriority       Do not require free or low-fee transactions to
                               have high priority for relaying
       --norpc                 Disable built-in RPC server -- NOTE: The RPC
                               server is disabled by default if no
-                              rpcuser/rpcpass or rpclimituser/rpclimitpass is
-                              specified
+                              rpcuser/rpcpass, rpclimituser/rpclimitpass,
+                              rpcauth or rpcclientca is specified and
+                              rpccookie is not set
       --notls                 Disable TLS for the RPC server -- NOTE: This is
                               only allowed if the RPC server is bound to
                               localhost
@@ -108,6 +150,9 @@ Application Options:
                               (eg. 127.0.0.1:9050)
       --onionpass=            Password for onion proxy server
       --onionuser=            Username for onion proxy server
+      --peerstore=            Storage backend for the addresses of known peers
+                              {file, db} -- db keeps them in the block
+                              database (default: file)
       --profile=              Enable HTTP profiling on given port -- NOTE port
                               must be between 1024 and 65536
       --proxy=                Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)
@@ -118,7 +163,28 @@ Application Options:
                               the default settings for the active network.
       --relaynonstd           Relay non-standard transactions regardless of the
                               default settings for the active network.
+      --rest                  Accept unauthenticated REST requests for blocks,
+                              headers, committed filters and transactions from
+                              local clients on the RPC listeners
+      --rpcauth=              Add an RPC user authenticated by a salted
+                              password hash of the form <user>:<salt>$<hash>,
+                              where hash is the hex-encoded HMAC-SHA256 of the
+                              password keyed by the salt -- Compatible with the
+                              rpcauth option of Bitcoin Core
       --rpccert=              File containing the certificate file
+      --rpcclientca=          File containing the certificate authorities
+                              which sign RPC client certificates -- Clients
+                              presenting a valid certificate are authenticated
+                              as the user named by its common name, which may
+                              only call the limited set of methods unless
+                              whitelisted
+      --rpccookie             Write an RPC authentication cookie file for
+                              clients such as btcctl when --rpcuser and
+                              --rpcpass are not set -- NOTE: This enables the
+                              RPC server
+      --rpccookiefile=        File containing the RPC authentication cookie,
+                              which is written when --rpccookie is set
+                              (default: .cookie in the data directory)
       --rpckey=               File containing the certificate key
       --rpclimitpass=         Password for limited RPC connections
       --rpclimituser=         Username for limited RPC connections
@@ -135,23 +201,75 @@ Application Options:
                               need to be worked around
   -P, --rpcpass=              Password for RPC connections
   -u, --rpcuser=              Username for RPC connections
+      --scriptworkers=        The number of goroutines validating transaction
+                              scripts for blocks and the mempool -- 0 uses
+                              three per processor core
       --sigcachemaxsize=      The maximum number of entries in the signature
                               verification cache (default: 100000)
       --simnet                Use the simulation test network
+      --sv2                   Enable the Stratum V2 template provider which
+                              serves block templates to Stratum V2 pools and
+                              miners
+      --sv2feedelta=          Minimum increase in BTC of the fees of a block
+                              template over the last one sent to a Stratum V2
+                              client for the new template to be sent (default:
+                              1e-05)
+      --sv2interval=          Interval at which to check for block templates
+                              with higher fees for Stratum V2 clients (default:
+                              30s)
+      --sv2listen=            Add an interface/port to listen for Stratum V2
+                              connections (default port: 8336, testnet: 18336)
+      --syncmode=             Mode used to download the headers of the chain
+                              during the initial block download {checkpoints,
+                              headerspresync} -- headerspresync doesn't rely
+                              on checkpoints (default: checkpoints)
       --testnet               Use the test network
       --torisolation          Enable Tor stream isolation by randomizing user
                               credentials for each connection.
       --trickleinterval=      Minimum time between attempts to send new
                               inventory to a connected peer (default: 10s)
+      --tweakindex            Maintain an index of the BIP-352 silent payments
+                              tweaks of the transactions of each block which
+                              makes the getsilentpaymenttweaks RPC available
       --txindex               Maintain a full hash-based transaction index
                               which makes all transactions available via the
                               getrawtransaction RPC
       --uacomment=            Comment to add to the user agent -- See BIP 14
                               for more information.
       --upnp                  Use UPnP to map our listening port outside of NAT
+      --utxocacheflushinterval=
+                              The maximum time the changes to the utxo set are
+                              kept in memory before they are written to the
+                              database -- Bounds the blocks to connect again
+                              after an unclean shutdown; 0 to disable
+                              (default: 1h0m0s)
+      --utxocachemaxsize=     The maximum size in MiB of the changes to the
+                              utxo set kept in memory before they are written
+                              to the database (default: 250)
+      --utxosnapshothash=     The expected utxo set hash of the snapshot loaded
+                              with --loadutxosnapshot as reported by the
+                              dumptxoutset RPC of a trusted node
+      --v2transport           Use the BIP-324 encrypted transport for peer
+                              connections -- NOTE: Outbound connections only
+                              use it for peers which advertise support for it
+                              and are retried with the plaintext protocol if
+                              it fails
   -V, --version               Display version information and exit
       --whitelist=            Add an IP network or IP that will not be banned.
                               (eg. 192.168.1.0/24 or ::1)
+      --zmqpubhashblock=      Publish the hashes of connected blocks on the
+                              given ZMQ endpoint (eg. tcp://127.0.0.1:28332)
+      --zmqpubhashtx=         Publish the hashes of transactions added to the
+                              mempool or included in connected and
+                              disconnected blocks on the given ZMQ endpoint
+      --zmqpubrawblock=       Publish connected blocks on the given ZMQ
+                              endpoint
+      --zmqpubrawtx=          Publish transactions added to the mempool or
+                              included in connected and disconnected blocks on
+                              the given ZMQ endpoint
+      --zmqpubsequence=       Publish the hashes of connected and disconnected
+                              blocks and of transactions added to and removed
+                              from the mempool on the given ZMQ endpoint
 
 Help Options:
   -h, --help           Show this help message
@@ -0,0 +1,413 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// DefaultMaxAncestorCount is the default maximum number of unconfirmed
+	// ancestors a transaction in the pool may have, including itself.
+	DefaultMaxAncestorCount = 25
+
+	// DefaultMaxAncestorSize is the default maximum virtual size of a
+	// transaction in the pool along with its unconfirmed ancestors.
+	DefaultMaxAncestorSize = 101000
+
+	// DefaultMaxDescendantCount is the default maximum number of
+	// unconfirmed descendants a transaction in the pool may have, including
+	// itself.
+	DefaultMaxDescendantCount = 25
+
+	// DefaultMaxDescendantSize is the default maximum virtual size of a
+	// transaction in the pool along with its unconfirmed descendants.
+	DefaultMaxDescendantSize = 101000
+)
+
+// MempoolEntry describes a transaction in the memory pool along with the
+// aggregated data of the unconfirmed transactions in the pool it depends on,
+// its ancestors, and of the ones depending on it, its descendants.  The counts,
+// sizes and fees of both include the transaction itself.
+type MempoolEntry struct {
+	TxDesc
+
+	// VSize is the virtual size of the transaction.
+	VSize int64
+
+	// AncestorCount, AncestorSize and AncestorFees are the number, the
+	// total virtual size and the total fees of the transaction and its
+	// ancestors.
+	AncestorCount int
+	AncestorSize  int64
+	AncestorFees  int64
+
+	// DescendantCount, DescendantSize and DescendantFees are the number,
+	// the total virtual size and the total fees of the transaction and its
+	// descendants.
+	DescendantCount int
+	DescendantSize  int64
+	DescendantFees  int64
+
+	// Depends holds the hashes of the transactions in the pool the
+	// transaction spends outputs of.
+	Depends []*chainhash.Hash
+
+	// SpentBy holds the hashes of the transactions in the pool which spend
+	// outputs of the transaction.
+	SpentBy []*chainhash.Hash
+}
+
+// poolAncestors returns the unconfirmed ancestors of the passed transaction,
+// which does not have to be in the pool, from the ancestor sets of its parents.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) poolAncestors(tx *btcutil.Tx) map[chainhash.Hash]*TxDesc {
+	ancestors := make(map[chainhash.Hash]*TxDesc)
+	for _, txIn := range tx.MsgTx().TxIn {
+		parent, ok := mp.pool[txIn.PreviousOutPoint.Hash]
+		if !ok {
+			continue
+		}
+		ancestors[*parent.Tx.Hash()] = parent
+		for hash, ancestor := range parent.ancestors {
+			ancestors[hash] = ancestor
+		}
+	}
+	return ancestors
+}
+
+// poolDescendants returns the unconfirmed descendants of the passed
+// transaction, which does not have to be in the pool, from the descendant sets
+// of its children.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) poolDescendants(tx *btcutil.Tx) map[chainhash.Hash]*TxDesc {
+	descendants := make(map[chainhash.Hash]*TxDesc)
+	op := wire.OutPoint{Hash: *tx.Hash()}
+	for i := range tx.MsgTx().TxOut {
+		op.Index = uint32(i)
+		child, ok := mp.outpoints[op]
+		if !ok {
+			continue
+		}
+		childDesc := mp.pool[*child.Hash()]
+		descendants[*child.Hash()] = childDesc
+		for hash, descendant := range childDesc.descendants {
+			descendants[hash] = descendant
+		}
+	}
+	return descendants
+}
+
+// walkAncestors returns the unconfirmed ancestors of the passed transaction by
+// walking the transactions in the pool it depends on.  Unlike poolAncestors,
+// it does not rely on the ancestor sets of the transactions in the pool.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) walkAncestors(tx *btcutil.Tx) map[chainhash.Hash]*TxDesc {
+	ancestors := make(map[chainhash.Hash]*TxDesc)
+	queue := []*btcutil.Tx{tx}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, txIn := range next.MsgTx().TxIn {
+			hash := txIn.PreviousOutPoint.Hash
+			parent, ok := mp.pool[hash]
+			if !ok {
+				continue
+			}
+			if _, ok := ancestors[hash]; ok {
+				continue
+			}
+			ancestors[hash] = parent
+			queue = append(queue, parent.Tx)
+		}
+	}
+	return ancestors
+}
+
+// walkDescendants returns the unconfirmed descendants of the passed
+// transaction by walking the transactions in the pool depending on it.  Unlike
+// poolDescendants, it does not rely on the descendant sets of the transactions
+// in the pool.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) walkDescendants(tx *btcutil.Tx) map[chainhash.Hash]*TxDesc {
+	descendants := make(map[chainhash.Hash]*TxDesc)
+	queue := []*btcutil.Tx{tx}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		op := wire.OutPoint{Hash: *next.Hash()}
+		for i := range next.MsgTx().TxOut {
+			op.Index = uint32(i)
+			child, ok := mp.outpoints[op]
+			if !ok {
+				continue
+			}
+			if _, ok := descendants[*child.Hash()]; ok {
+				continue
+			}
+			descendants[*child.Hash()] = mp.pool[*child.Hash()]
+			queue = append(queue, child)
+		}
+	}
+	return descendants
+}
+
+// addToAncestry links the passed transaction, which was just added to the
+// pool, with its unconfirmed ancestors and descendants.  A transaction added
+// back to the pool from a disconnected block may already have descendants in
+// the pool, which then also become descendants of its ancestors.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) addToAncestry(txD *TxDesc) {
+	txD.ancestors = mp.poolAncestors(txD.Tx)
+	txD.descendants = mp.poolDescendants(txD.Tx)
+
+	txHash := *txD.Tx.Hash()
+	for _, ancestor := range txD.ancestors {
+		ancestor.descendants[txHash] = txD
+		for hash, descendant := range txD.descendants {
+			ancestor.descendants[hash] = descendant
+		}
+	}
+	for _, descendant := range txD.descendants {
+		descendant.ancestors[txHash] = txD
+		for hash, ancestor := range txD.ancestors {
+			descendant.ancestors[hash] = ancestor
+		}
+	}
+}
+
+// removeFromAncestry unlinks the passed transaction, which was just removed
+// from the pool, from its unconfirmed ancestors and descendants.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) removeFromAncestry(txD *TxDesc) {
+	txHash := *txD.Tx.Hash()
+	for _, ancestor := range txD.ancestors {
+		delete(ancestor.descendants, txHash)
+	}
+	for _, descendant := range txD.descendants {
+		delete(descendant.ancestors, txHash)
+	}
+
+	// Transactions are usually removed along with their descendants or
+	// after their ancestors were mined.  Otherwise, the transaction may have
+	// been the only link between some of its ancestors and descendants, so
+	// their sets are determined again.
+	if len(txD.ancestors) == 0 || len(txD.descendants) == 0 {
+		return
+	}
+	for _, ancestor := range txD.ancestors {
+		ancestor.descendants = mp.walkDescendants(ancestor.Tx)
+	}
+	for _, descendant := range txD.descendants {
+		descendant.ancestors = mp.walkAncestors(descendant.Tx)
+	}
+}
+
+// checkAncestryLimits ensures adding the passed transaction with the passed
+// virtual size to the pool does not exceed the ancestor and descendant limits
+// of the policy, either for the transaction or for any of its unconfirmed
+// ancestors.  The passed conflicts are replaced by the transaction, so they
+// are evicted along with all of their descendants and none of them are counted
+// as descendants of its ancestors.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) checkAncestryLimits(tx *btcutil.Tx, vsize int64,
+	conflicts map[chainhash.Hash]*btcutil.Tx) error {
+
+	evicted := make(map[chainhash.Hash]struct{}, len(conflicts))
+	for hash := range conflicts {
+		evicted[hash] = struct{}{}
+		if conflict, ok := mp.pool[hash]; ok {
+			for descendantHash := range conflict.descendants {
+				evicted[descendantHash] = struct{}{}
+			}
+		}
+	}
+
+	policy := &mp.cfg.Policy
+	ancestors := mp.poolAncestors(tx)
+	if policy.MaxAncestorCount > 0 &&
+		len(ancestors)+1 > policy.MaxAncestorCount {
+
+		str := fmt.Sprintf("transaction %v has too many unconfirmed "+
+			"ancestors: %d > %d", tx.Hash(), len(ancestors)+1,
+			policy.MaxAncestorCount)
+		return txRuleError(wire.RejectNonstandard, str)
+	}
+	if policy.MaxAncestorSize > 0 {
+		ancestorSize := vsize
+		for _, ancestor := range ancestors {
+			ancestorSize += ancestor.vsize
+		}
+		if ancestorSize > int64(policy.MaxAncestorSize) {
+			str := fmt.Sprintf("transaction %v exceeds the size "+
+				"limit of its unconfirmed ancestors: %d > %d",
+				tx.Hash(), ancestorSize, policy.MaxAncestorSize)
+			return txRuleError(wire.RejectNonstandard, str)
+		}
+	}
+
+	for ancestorHash, ancestor := range ancestors {
+		descendantCount := 2
+		descendantSize := ancestor.vsize + vsize
+		for hash, descendant := range ancestor.descendants {
+			if _, ok := evicted[hash]; ok {
+				continue
+			}
+			descendantCount++
+			descendantSize += descendant.vsize
+		}
+
+		if policy.MaxDescendantCount > 0 &&
+			descendantCount > policy.MaxDescendantCount {
+
+			str := fmt.Sprintf("transaction %v would exceed the "+
+				"descendant count limit of unconfirmed "+
+				"ancestor %v: %d > %d", tx.Hash(), ancestorHash,
+				descendantCount, policy.MaxDescendantCount)
+			return txRuleError(wire.RejectNonstandard, str)
+		}
+		if policy.MaxDescendantSize > 0 &&
+			descendantSize > int64(policy.MaxDescendantSize) {
+
+			str := fmt.Sprintf("transaction %v would exceed the "+
+				"descendant size limit of unconfirmed "+
+				"ancestor %v: %d > %d", tx.Hash(), ancestorHash,
+				descendantSize, policy.MaxDescendantSize)
+			return txRuleError(wire.RejectNonstandard, str)
+		}
+	}
+
+	return nil
+}
+
+// sortedDescs returns the passed transactions of the pool ordered such that
+// every transaction comes after the transactions it depends on.
+func sortedDescs(descs map[chainhash.Hash]*TxDesc) []*TxDesc {
+	sorted := make([]*TxDesc, 0, len(descs))
+	for _, desc := range descs {
+		sorted = append(sorted, desc)
+	}
+
+	// A transaction has fewer ancestors than any of its descendants, so
+	// ordering by the number of ancestors orders dependencies first.
+	sort.Slice(sorted, func(i, j int) bool {
+		return len(sorted[i].ancestors) < len(sorted[j].ancestors)
+	})
+	return sorted
+}
+
+// MempoolEntry returns the entry of the passed transaction in the pool along
+// with the aggregated data of its unconfirmed ancestors and descendants.  An
+// error is returned if the transaction is not in the pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) MempoolEntry(txHash *chainhash.Hash) (*MempoolEntry, error) {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	txD, exists := mp.pool[*txHash]
+	if !exists {
+		return nil, fmt.Errorf("transaction %v is not in the pool",
+			txHash)
+	}
+
+	desc := *txD
+	desc.ancestors, desc.descendants = nil, nil
+	entry := &MempoolEntry{
+		TxDesc:          desc,
+		VSize:           txD.vsize,
+		AncestorCount:   len(txD.ancestors) + 1,
+		AncestorSize:    txD.vsize,
+		AncestorFees:    txD.Fee,
+		DescendantCount: len(txD.descendants) + 1,
+		DescendantSize:  txD.vsize,
+		DescendantFees:  txD.Fee,
+		Depends:         make([]*chainhash.Hash, 0),
+		SpentBy:         make([]*chainhash.Hash, 0),
+	}
+	for _, ancestor := range txD.ancestors {
+		entry.AncestorSize += ancestor.vsize
+		entry.AncestorFees += ancestor.Fee
+	}
+	for _, descendant := range txD.descendants {
+		entry.DescendantSize += descendant.vsize
+		entry.DescendantFees += descendant.Fee
+	}
+
+	seen := make(map[chainhash.Hash]struct{})
+	for _, txIn := range txD.Tx.MsgTx().TxIn {
+		hash := txIn.PreviousOutPoint.Hash
+		if _, ok := mp.pool[hash]; !ok {
+			continue
+		}
+		if _, ok := seen[hash]; ok {
+			continue
+		}
+		seen[hash] = struct{}{}
+		hashCopy := hash
+		entry.Depends = append(entry.Depends, &hashCopy)
+	}
+	op := wire.OutPoint{Hash: *txHash}
+	for i := range txD.Tx.MsgTx().TxOut {
+		op.Index = uint32(i)
+		child, ok := mp.outpoints[op]
+		if !ok {
+			continue
+		}
+		if _, ok := seen[*child.Hash()]; ok {
+			continue
+		}
+		seen[*child.Hash()] = struct{}{}
+		entry.SpentBy = append(entry.SpentBy, child.Hash())
+	}
+
+	return entry, nil
+}
+
+// Ancestors returns the unconfirmed ancestors of the passed transaction in the
+// pool ordered such that every transaction comes after the transactions it
+// depends on.  An error is returned if the transaction is not in the pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Ancestors(txHash *chainhash.Hash) ([]*TxDesc, error) {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	txD, exists := mp.pool[*txHash]
+	if !exists {
+		return nil, fmt.Errorf("transaction %v is not in the pool",
+			txHash)
+	}
+	return sortedDescs(txD.ancestors), nil
+}
+
+// Descendants returns the unconfirmed descendants of the passed transaction in
+// the pool ordered such that every transaction comes after the transactions it
+// depends on.  An error is returned if the transaction is not in the pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Descendants(txHash *chainhash.Hash) ([]*TxDesc, error) {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	txD, exists := mp.pool[*txHash]
+	if !exists {
+		return nil, fmt.Errorf("transaction %v is not in the pool",
+			txHash)
+	}
+	return sortedDescs(txD.descendants), nil
+}
@@ -40,6 +40,8 @@ be an exhaustive list.
    - Reject non-fully-spent duplicate transactions
    - Reject coinbase transactions
    - Reject double spends (both from the chain and other transactions in pool)
+   - Replace-By-Fee (BIP-125) replacement of conflicting transactions along
+     with their descendants
    - Reject invalid transactions according to the network consensus rules
    - Full script execution and validation with signature cache support
    - Individual transaction query support
@@ -48,6 +50,13 @@ be an exhaustive list.
    - Automatic addition of orphan transactions that are no longer orphans as new
      transactions are added to the pool
    - Individual orphan transaction query support
+ - Package acceptance of a child along with its parents based on the fee rate
+   of the package (child pays for parent)
+   - Automatic package acceptance of transactions with an orphan paying for them
+ - Saving and loading of the transactions in the pool, so they persist across
+   restarts
+ - Fee estimation based on how quickly transactions with different fee rates
+   were confirmed over a short, a medium and a long horizon
  - Configurable transaction acceptance policy
    - Option to accept or reject standard transactions
    - Option to accept or reject transactions based on priority calculations
@@ -56,11 +65,14 @@ be an exhaustive list.
    - Max signature operations per transaction
    - Max orphan transaction size
    - Max number of orphan transactions allowed
+   - Max number and size of unconfirmed ancestors and descendants
+   - Option to reject replacements or to accept them regardless of signaling
  - Additional metadata tracking for each transaction
    - Timestamp when the transaction was added to the pool
    - Most recent block height when the transaction was added to the pool
    - The fee the transaction pays
    - The starting priority for the transaction
+   - The unconfirmed ancestors and descendants of the transaction
  - Manual control of transaction removal
    - Recursive removal of all dependent transactions
 
@@ -6,6 +6,7 @@ package mempool
 
 import (
 	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 )
 
@@ -100,6 +101,14 @@ func extractRejectCode(err error) (wire.RejectCode, bool) {
 	case TxRuleError:
 		return err.RejectCode, true
 
+	case txscript.Error:
+		// Convert the standardness policy error to a reject code.
+		code := wire.RejectNonstandard
+		if err.ErrorCode == txscript.ErrDustOutput {
+			code = wire.RejectDust
+		}
+		return code, true
+
 	case nil:
 		return wire.RejectInvalid, false
 	}
@@ -178,6 +178,10 @@ type FeeEstimator struct {
 	// Transactions that have been removed from the bins. This allows us to
 	// revert in case of an orphaned block.
 	dropped []*registeredBlock
+
+	// policy tracks the confirmations of observed transactions in
+	// decaying fee rate buckets for EstimateSmartFee.
+	policy *policyEstimator
 }
 
 // NewFeeEstimator creates a FeeEstimator for which at most maxRollback blocks
@@ -192,6 +196,7 @@ func NewFeeEstimator(maxRollback, minRegisteredBlocks uint32) *FeeEstimator {
 		maxReplacements:     estimateFeeMaxReplacements,
 		observed:            make(map[chainhash.Hash]*observedTransaction),
 		dropped:             make([]*registeredBlock, 0, maxRollback),
+		policy:              newPolicyEstimator(),
 	}
 }
 
@@ -207,16 +212,34 @@ func (ef *FeeEstimator) ObserveTransaction(t *TxDesc) {
 	}
 
 	hash := *t.Tx.Hash()
+	size := uint32(GetTxVirtualSize(t.Tx))
+	feeRate := NewSatoshiPerByte(btcutil.Amount(t.Fee), size)
 	if _, ok := ef.observed[hash]; !ok {
-		size := uint32(GetTxVirtualSize(t.Tx))
-
 		ef.observed[hash] = &observedTransaction{
 			hash:     hash,
-			feeRate:  NewSatoshiPerByte(btcutil.Amount(t.Fee), size),
+			feeRate:  feeRate,
 			observed: t.Height,
 			mined:    mining.UnminedHeight,
 		}
 	}
+
+	// The fee rate of transactions with unconfirmed parents doesn't
+	// reflect how quickly they are confirmed, so they are not tracked for
+	// smart fee estimation.
+	if len(t.ancestors) == 0 {
+		ef.policy.processTransaction(&hash, t.Height, feeRate)
+	}
+}
+
+// RemoveTransaction informs the fee estimator that an observed transaction was
+// removed from the mempool without being mined, for example because it was
+// replaced.  It is recorded as having failed to confirm for smart fee
+// estimation.
+func (ef *FeeEstimator) RemoveTransaction(hash *chainhash.Hash) {
+	ef.mtx.Lock()
+	defer ef.mtx.Unlock()
+
+	ef.policy.removeTransaction(hash, false)
 }
 
 // RegisterBlock informs the fee estimator of a new block to take into account.
@@ -237,6 +260,9 @@ func (ef *FeeEstimator) RegisterBlock(block *btcutil.Block) error {
 	ef.lastKnownHeight = height
 	ef.numBlocksRegistered++
 
+	// Record the confirmations for smart fee estimation.
+	ef.policy.processBlock(height, block.Transactions())
+
 	// Randomly order txs in block.
 	transactions := make(map[*btcutil.Tx]struct{})
 	for _, t := range block.Transactions() {
@@ -342,7 +368,9 @@ func (ef *FeeEstimator) LastKnownHeight() int32 {
 // Note: not everything can be rolled back because some transactions are
 // deleted if they have been observed too long ago. That means the result
 // of Rollback won't always be exactly the same as if the last block had not
-// happened, but it should be close enough.
+// happened, but it should be close enough.  The statistics used by
+// EstimateSmartFee are not rolled back at all, since they decay with every
+// block, and blocks replacing the rolled back ones are not recorded for them.
 func (ef *FeeEstimator) Rollback(hash *chainhash.Hash) error {
 	ef.mtx.Lock()
 	defer ef.mtx.Unlock()
@@ -574,11 +602,40 @@ func (ef *FeeEstimator) EstimateFee(numBlocks uint32) (BtcPerKilobyte, error) {
 	return ef.cached[int(numBlocks)-1].ToBtcPerKb(), nil
 }
 
+// EstimateSmartFee estimates the fee per kilobyte a transaction needs to pay
+// to be confirmed within confTarget blocks from now.  The estimate is based on
+// how many blocks it took for transactions observed in the mempool to be
+// confirmed depending on their fee rate, using the statistics of a short, a
+// medium and a long horizon.  In the conservative mode, the estimate also
+// considers the fee rates required over the longer history.
+//
+// It returns the estimate along with the confirmation target it was made for,
+// which is lower than the requested target if not enough blocks have been
+// observed for it.
+func (ef *FeeEstimator) EstimateSmartFee(confTarget uint32,
+	mode EstimateMode) (BtcPerKilobyte, uint32, error) {
+
+	ef.mtx.Lock()
+	defer ef.mtx.Unlock()
+
+	if confTarget == 0 || confTarget > MaxConfirmationTarget {
+		return -1, 0, fmt.Errorf("confirmation target must be "+
+			"between 1 and %d", MaxConfirmationTarget)
+	}
+
+	feeRate, target := ef.policy.estimateSmartFee(int32(confTarget), mode)
+	if feeRate < 0 {
+		return -1, 0, errors.New("insufficient data or no feerate found")
+	}
+
+	return SatoshiPerByte(feeRate).ToBtcPerKb(), uint32(target), nil
+}
+
 // In case the format for the serialized version of the FeeEstimator changes,
 // we use a version number. If the version number changes, it does not make
 // sense to try to upgrade a previous version to a new version. Instead, just
 // start fee estimation over.
-const estimateFeeSaveVersion = 1
+const estimateFeeSaveVersion = 2
 
 func deserializeRegisteredBlock(r io.Reader, txs map[uint32]*observedTransaction) (*registeredBlock, error) {
 	var lenTransactions uint32
@@ -671,6 +728,9 @@ func (ef *FeeEstimator) Save() FeeEstimatorState {
 		registered.serialize(w, observed)
 	}
 
+	// Smart fee estimation statistics.
+	ef.policy.serialize(w)
+
 	// Commit the tx and return.
 	return FeeEstimatorState(w.Bytes())
 }
@@ -745,5 +805,11 @@ func RestoreFeeEstimator(data FeeEstimatorState) (*FeeEstimator, error) {
 		}
 	}
 
+	// Read smart fee estimation statistics.
+	ef.policy, err = deserializePolicyEstimator(r)
+	if err != nil {
+		return nil, err
+	}
+
 	return ef, nil
 }
@@ -6,6 +6,7 @@ package mempool
 
 import (
 	"bytes"
+	"math"
 	"math/rand"
 	"testing"
 
@@ -26,6 +27,7 @@ func newTestFeeEstimator(binSize, maxReplacements, maxRollback uint32) *FeeEstim
 		maxReplacements:     int32(maxReplacements),
 		observed:            make(map[chainhash.Hash]*observedTransaction),
 		dropped:             make([]*registeredBlock, 0, maxRollback),
+		policy:              newPolicyEstimator(),
 	}
 }
 
@@ -422,3 +424,118 @@ func TestDatabase(t *testing.T) {
 		eft.checkSaveAndRestore(estimateHistory[len(estimateHistory)-round-1])
 	}
 }
+
+// TestEstimateSmartFee tests the estimates of EstimateSmartFee as transactions
+// with different fee rates are confirmed after different numbers of blocks.
+func TestEstimateSmartFee(t *testing.T) {
+	const (
+		highFeeRate = 50
+		lowFeeRate  = 5
+		lowDelay    = 10
+		txPerBlock  = 10
+		numBlocks   = 200
+	)
+
+	ef := newTestFeeEstimator(estimateFeeBinSize,
+		estimateFeeMaxReplacements, 1)
+	eft := estimateFeeTester{ef: ef, t: t}
+
+	// Nothing can be estimated before any transactions were confirmed.
+	_, _, err := ef.EstimateSmartFee(2, EstimateConservative)
+	if err == nil {
+		t.Fatalf("estimated fee without any data")
+	}
+
+	// Targets outside of the tracked range are rejected.
+	for _, target := range []uint32{0, 1009} {
+		_, _, err := ef.EstimateSmartFee(target, EstimateEconomical)
+		if err == nil {
+			t.Fatalf("estimated fee for target %d", target)
+		}
+	}
+
+	// Transactions with a high fee rate are confirmed in the next block,
+	// while transactions with a low fee rate take lowDelay blocks.
+	var pending [][]*TxDesc
+	for i := 0; i < numBlocks; i++ {
+		var high, low []*TxDesc
+		for j := 0; j < txPerBlock; j++ {
+			txD := eft.testTx(0)
+			size := GetTxVirtualSize(txD.Tx)
+			txD.Fee = highFeeRate * size
+			high = append(high, txD)
+			ef.ObserveTransaction(txD)
+
+			txD = eft.testTx(0)
+			txD.Fee = lowFeeRate * size
+			low = append(low, txD)
+			ef.ObserveTransaction(txD)
+		}
+		pending = append(pending, low)
+
+		var txs []*wire.MsgTx
+		for _, txD := range high {
+			txs = append(txs, txD.Tx.MsgTx())
+		}
+		if len(pending) == lowDelay {
+			for _, txD := range pending[0] {
+				txs = append(txs, txD.Tx.MsgTx())
+			}
+			pending = pending[1:]
+		}
+		eft.newBlock(txs)
+	}
+
+	// Estimates must also be sufficient for half the target, so the low
+	// fee rate is only estimated once half the target covers its delay.
+	tests := []struct {
+		target     uint32
+		mode       EstimateMode
+		wantTarget uint32
+		want       SatoshiPerByte
+	}{
+		{1, EstimateEconomical, 2, highFeeRate},
+		{2, EstimateConservative, 2, highFeeRate},
+		{6, EstimateEconomical, 6, highFeeRate},
+		{12, EstimateEconomical, 12, highFeeRate},
+		{20, EstimateEconomical, 20, lowFeeRate},
+		{30, EstimateEconomical, 30, lowFeeRate},
+		{30, EstimateConservative, 30, lowFeeRate},
+		{1008, EstimateEconomical, (numBlocks - 1) / 2, lowFeeRate},
+	}
+	for _, test := range tests {
+		feeRate, target, err := ef.EstimateSmartFee(test.target,
+			test.mode)
+		if err != nil {
+			t.Fatalf("unable to estimate %v fee for target %d: %v",
+				test.mode, test.target, err)
+		}
+		if target != test.wantTarget {
+			t.Fatalf("unexpected target of %v estimate for target "+
+				"%d: got %d, want %d", test.mode, test.target,
+				target, test.wantTarget)
+		}
+		// The averages decay with every block, so allow for rounding
+		// errors.
+		if math.Abs(float64(feeRate-test.want.ToBtcPerKb())) > 1e-12 {
+			t.Fatalf("unexpected %v estimate for target %d: got "+
+				"%v, want %v", test.mode, test.target, feeRate,
+				test.want.ToBtcPerKb())
+		}
+	}
+
+	// The estimates are the same once the state is restored.
+	restored, err := RestoreFeeEstimator(ef.Save())
+	if err != nil {
+		t.Fatalf("unable to restore fee estimator: %v", err)
+	}
+	for _, test := range tests {
+		want, _, _ := ef.EstimateSmartFee(test.target, test.mode)
+		got, _, err := restored.EstimateSmartFee(test.target, test.mode)
+		if err != nil || got != want {
+			t.Fatalf("unexpected %v estimate for target %d after "+
+				"restore: got %v (%v), want %v", test.mode,
+				test.target, got, err, want)
+		}
+	}
+}
@@ -90,11 +90,17 @@ type Config struct {
 	IsDeploymentActive func(deploymentID uint32) (bool, error)
 
 	// SigCache defines a signature cache to use.
-	SigCache *txscript.SigCache
+	SigCache txscript.SignatureCache
 
 	// HashCache defines the transaction hash mid-state cache to use.
 	HashCache *txscript.HashCache
 
+	// ScriptValidationPool defines the pool of goroutines used to validate
+	// the scripts of transactions.  It should be the pool used by the
+	// chain, so validating blocks pre-empts validating transactions.
+	// This can be nil to use the default pool.
+	ScriptValidationPool *blockchain.ScriptValidationPool
+
 	// AddrIndex defines the optional address index instance to use for
 	// indexing the unconfirmed transactions in the memory pool.
 	// This can be nil if the address index is not enabled.
@@ -103,6 +109,13 @@ type Config struct {
 	// FeeEstimatator provides a feeEstimator. If it is not nil, the mempool
 	// records all new transactions it observes into the feeEstimator.
 	FeeEstimator *FeeEstimator
+
+	// TxReplaced defines the function to call when a transaction in the
+	// pool is evicted by a replacement transaction, either because it
+	// conflicts with the replacement or because it descends from such a
+	// transaction.  It is called once the mempool lock is released.  This
+	// can be nil.
+	TxReplaced func(replaced, replacement *btcutil.Tx)
 }
 
 // Policy houses the policy (configuration parameters) which is used to
@@ -148,6 +161,31 @@ type Policy struct {
 	// transactions using the Replace-By-Fee (RBF) signaling policy into
 	// the mempool.
 	RejectReplacement bool
+
+	// FullRBF, if true, accepts replacement transactions regardless of
+	// whether the transactions they replace signal replaceability.  The
+	// remaining rules of the Replace-By-Fee (RBF) policy still apply.
+	FullRBF bool
+
+	// MaxAncestorCount is the maximum number of unconfirmed ancestors a
+	// transaction may have, including itself.  Zero disables the limit.
+	MaxAncestorCount int
+
+	// MaxAncestorSize is the maximum virtual size of a transaction along
+	// with its unconfirmed ancestors.  Zero disables the limit.
+	MaxAncestorSize int
+
+	// MaxDescendantCount is the maximum number of unconfirmed descendants
+	// a transaction may have, including itself.  A transaction is rejected
+	// when it would exceed the limit of any of its ancestors.  Zero
+	// disables the limit.
+	MaxDescendantCount int
+
+	// MaxDescendantSize is the maximum virtual size of a transaction along
+	// with its unconfirmed descendants.  A transaction is rejected when it
+	// would exceed the limit of any of its ancestors.  Zero disables the
+	// limit.
+	MaxDescendantSize int
 }
 
 // TxDesc is a descriptor containing a transaction in the mempool along with
@@ -158,6 +196,16 @@ type TxDesc struct {
 	// StartingPriority is the priority of the transaction when it was added
 	// to the pool.
 	StartingPriority float64
+
+	// vsize is the virtual size of the transaction.
+	vsize int64
+
+	// ancestors and descendants are the unconfirmed transactions in the
+	// pool the transaction depends on and the ones depending on it,
+	// respectively.  They are maintained as transactions are added to and
+	// removed from the pool.
+	ancestors   map[chainhash.Hash]*TxDesc
+	descendants map[chainhash.Hash]*TxDesc
 }
 
 // orphanTx is normal transaction that references an ancestor transaction
@@ -169,6 +217,13 @@ type orphanTx struct {
 	expiration time.Time
 }
 
+// txReplacement houses a transaction which was evicted from the pool by a
+// replacement transaction.
+type txReplacement struct {
+	replaced    *btcutil.Tx
+	replacement *btcutil.Tx
+}
+
 // TxPool is used as a source of transactions that need to be mined into blocks
 // and relayed to other peers.  It is safe for concurrent access from multiple
 // peers.
@@ -190,6 +245,10 @@ type TxPool struct {
 	// the scan will only run when an orphan is added to the pool as opposed
 	// to on an unconditional timer.
 	nextExpireScan time.Time
+
+	// replacements are the transactions which were replaced since the
+	// replacements were last signaled.
+	replacements []txReplacement
 }
 
 // Ensure the TxPool type implements the mining.TxSource interface.
@@ -491,6 +550,7 @@ func (mp *TxPool) removeTransaction(tx *btcutil.Tx, removeRedeemers bool) {
 			delete(mp.outpoints, txIn.PreviousOutPoint)
 		}
 		delete(mp.pool, *txHash)
+		mp.removeFromAncestry(txDesc)
 		atomic.StoreInt64(&mp.lastUpdated, time.Now().Unix())
 	}
 }
@@ -536,21 +596,24 @@ func (mp *TxPool) RemoveDoubleSpends(tx *btcutil.Tx) {
 func (mp *TxPool) addTransaction(utxoView *blockchain.UtxoViewpoint, tx *btcutil.Tx, height int32, fee int64) *TxDesc {
 	// Add the transaction to the pool and mark the referenced outpoints
 	// as spent by the pool.
+	vsize := GetTxVirtualSize(tx)
 	txD := &TxDesc{
 		TxDesc: mining.TxDesc{
 			Tx:       tx,
 			Added:    time.Now(),
 			Height:   height,
 			Fee:      fee,
-			FeePerKB: fee * 1000 / GetTxVirtualSize(tx),
+			FeePerKB: fee * 1000 / vsize,
 		},
 		StartingPriority: mining.CalcPriority(tx.MsgTx(), utxoView, height),
+		vsize:            vsize,
 	}
 
 	mp.pool[*tx.Hash()] = txD
 	for _, txIn := range tx.MsgTx().TxIn {
 		mp.outpoints[txIn.PreviousOutPoint] = tx
 	}
+	mp.addToAncestry(txD)
 	atomic.StoreInt64(&mp.lastUpdated, time.Now().Unix())
 
 	// Add unconfirmed address index entries associated with the transaction
@@ -585,9 +648,11 @@ func (mp *TxPool) checkPoolDoubleSpend(tx *btcutil.Tx) (bool, error) {
 		}
 
 		// Reject the transaction if we don't accept replacement
-		// transactions or if it doesn't signal replacement.
+		// transactions or if the conflict doesn't signal replacement
+		// and full replacement is disabled.
 		if mp.cfg.Policy.RejectReplacement ||
-			!mp.signalsReplacement(conflict, nil) {
+			(!mp.cfg.Policy.FullRBF &&
+				!mp.signalsReplacement(conflict, nil)) {
 			str := fmt.Sprintf("output %v already spent by "+
 				"transaction %v in the memory pool",
 				txIn.PreviousOutPoint, conflict.Hash())
@@ -919,12 +984,40 @@ func (mp *TxPool) validateReplacement(tx *btcutil.Tx,
 	return conflicts, nil
 }
 
-// maybeAcceptTransaction is the internal function which implements the public
-// MaybeAcceptTransaction.  See the comment for MaybeAcceptTransaction for
-// more details.
+// MempoolAcceptResult describes the outcome of checking whether a transaction
+// would be accepted to the memory pool.
+type MempoolAcceptResult struct {
+	// TxFee is the fee paid by the transaction.
+	TxFee btcutil.Amount
+
+	// TxSize is the virtual size of the transaction.
+	TxSize int64
+
+	// Conflicts are the transactions in the pool, along with their
+	// descendants, which the transaction replaces.
+	Conflicts map[chainhash.Hash]*btcutil.Tx
+
+	// MissingParents are the hashes of the transactions whose outputs are
+	// spent by the transaction, but are neither in the pool nor unspent in
+	// the main chain.  The remaining fields are not set when the
+	// transaction is an orphan.
+	MissingParents []*chainhash.Hash
+
+	// utxoView and bestHeight are used to add the transaction to the pool.
+	utxoView   *blockchain.UtxoViewpoint
+	bestHeight int32
+}
+
+// checkMempoolAcceptance performs all checks of maybeAcceptTransaction without
+// modifying the pool, except for the state of the rate limiter when rateLimit
+// is set.  See the comment for maybeAcceptTransaction for the meaning of the
+// parameters.
 //
-// This function MUST be called with the mempool lock held (for writes).
-func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejectDupOrphans bool) ([]*chainhash.Hash, *TxDesc, error) {
+// This function MUST be called with the mempool lock held (for reads, or for
+// writes when rateLimit is set).
+func (mp *TxPool) checkMempoolAcceptance(tx *btcutil.Tx, isNew, rateLimit,
+	rejectDupOrphans bool, pkgFeePerKB int64) (*MempoolAcceptResult, error) {
+
 	txHash := tx.Hash()
 
 	// If a transaction has witness data, and segwit isn't active yet, If
@@ -933,7 +1026,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 	if tx.MsgTx().HasWitness() {
 		segwitActive, err := mp.cfg.IsDeploymentActive(chaincfg.DeploymentSegwit)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
 		if !segwitActive {
@@ -945,7 +1038,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 			}
 			str := fmt.Sprintf("transaction %v has witness data, "+
 				"but segwit isn't active yet%s", txHash, simnetHint)
-			return nil, nil, txRuleError(wire.RejectNonstandard, str)
+			return nil, txRuleError(wire.RejectNonstandard, str)
 		}
 	}
 
@@ -957,7 +1050,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 		mp.isOrphanInPool(txHash)) {
 
 		str := fmt.Sprintf("already have transaction %v", txHash)
-		return nil, nil, txRuleError(wire.RejectDuplicate, str)
+		return nil, txRuleError(wire.RejectDuplicate, str)
 	}
 
 	// Perform preliminary sanity checks on the transaction.  This makes
@@ -966,16 +1059,16 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 	err := blockchain.CheckTransactionSanity(tx)
 	if err != nil {
 		if cerr, ok := err.(blockchain.RuleError); ok {
-			return nil, nil, chainRuleError(cerr)
+			return nil, chainRuleError(cerr)
 		}
-		return nil, nil, err
+		return nil, err
 	}
 
 	// A standalone transaction must not be a coinbase transaction.
 	if blockchain.IsCoinBase(tx) {
 		str := fmt.Sprintf("transaction %v is an individual coinbase",
 			txHash)
-		return nil, nil, txRuleError(wire.RejectInvalid, str)
+		return nil, txRuleError(wire.RejectInvalid, str)
 	}
 
 	// Get the current height of the main chain.  A standalone transaction
@@ -1002,7 +1095,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 			}
 			str := fmt.Sprintf("transaction %v is not standard: %v",
 				txHash, err)
-			return nil, nil, txRuleError(rejectCode, str)
+			return nil, txRuleError(rejectCode, str)
 		}
 	}
 
@@ -1017,7 +1110,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 	// spend data and prevents double spends.
 	isReplacement, err := mp.checkPoolDoubleSpend(tx)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	// Fetch all of the unspent transaction outputs referenced by the inputs
@@ -1027,9 +1120,9 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 	utxoView, err := mp.fetchInputUtxos(tx)
 	if err != nil {
 		if cerr, ok := err.(blockchain.RuleError); ok {
-			return nil, nil, chainRuleError(cerr)
+			return nil, chainRuleError(cerr)
 		}
-		return nil, nil, err
+		return nil, err
 	}
 
 	// Don't allow the transaction if it exists in the main chain and is not
@@ -1039,7 +1132,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 		prevOut.Index = uint32(txOutIdx)
 		entry := utxoView.LookupEntry(prevOut)
 		if entry != nil && !entry.IsSpent() {
-			return nil, nil, txRuleError(wire.RejectDuplicate,
+			return nil, txRuleError(wire.RejectDuplicate,
 				"transaction already exists")
 		}
 		utxoView.RemoveEntry(prevOut)
@@ -1061,7 +1154,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 		}
 	}
 	if len(missingParents) > 0 {
-		return missingParents, nil, nil
+		return &MempoolAcceptResult{MissingParents: missingParents}, nil
 	}
 
 	// Don't allow the transaction into the mempool unless its sequence
@@ -1070,13 +1163,13 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 	sequenceLock, err := mp.cfg.CalcSequenceLock(tx, utxoView)
 	if err != nil {
 		if cerr, ok := err.(blockchain.RuleError); ok {
-			return nil, nil, chainRuleError(cerr)
+			return nil, chainRuleError(cerr)
 		}
-		return nil, nil, err
+		return nil, err
 	}
 	if !blockchain.SequenceLockActive(sequenceLock, nextBlockHeight,
 		medianTimePast) {
-		return nil, nil, txRuleError(wire.RejectNonstandard,
+		return nil, txRuleError(wire.RejectNonstandard,
 			"transaction's sequence locks on inputs not met")
 	}
 
@@ -1088,9 +1181,9 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 		utxoView, mp.cfg.ChainParams)
 	if err != nil {
 		if cerr, ok := err.(blockchain.RuleError); ok {
-			return nil, nil, chainRuleError(cerr)
+			return nil, chainRuleError(cerr)
 		}
-		return nil, nil, err
+		return nil, err
 	}
 
 	// Don't allow transactions with non-standard inputs if the network
@@ -1107,7 +1200,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 			}
 			str := fmt.Sprintf("transaction %v has a non-standard "+
 				"input: %v", txHash, err)
-			return nil, nil, txRuleError(rejectCode, str)
+			return nil, txRuleError(rejectCode, str)
 		}
 	}
 
@@ -1124,14 +1217,14 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 	sigOpCost, err := blockchain.GetSigOpCost(tx, false, utxoView, true, true)
 	if err != nil {
 		if cerr, ok := err.(blockchain.RuleError); ok {
-			return nil, nil, chainRuleError(cerr)
+			return nil, chainRuleError(cerr)
 		}
-		return nil, nil, err
+		return nil, err
 	}
 	if sigOpCost > mp.cfg.Policy.MaxSigOpCostPerTx {
 		str := fmt.Sprintf("transaction %v sigop cost is too high: %d > %d",
 			txHash, sigOpCost, mp.cfg.Policy.MaxSigOpCostPerTx)
-		return nil, nil, txRuleError(wire.RejectNonstandard, str)
+		return nil, txRuleError(wire.RejectNonstandard, str)
 	}
 
 	// Don't allow transactions with fees too low to get into a mined block.
@@ -1148,31 +1241,35 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 	serializedSize := GetTxVirtualSize(tx)
 	minFee := calcMinRequiredTxRelayFee(serializedSize,
 		mp.cfg.Policy.MinRelayTxFee)
-	if serializedSize >= (DefaultBlockPrioritySize-1000) && txFee < minFee {
+	relayFee := txFee
+	if pkgFee := pkgFeePerKB * serializedSize / 1000; pkgFee > relayFee {
+		relayFee = pkgFee
+	}
+	if serializedSize >= (DefaultBlockPrioritySize-1000) && relayFee < minFee {
 		str := fmt.Sprintf("transaction %v has %d fees which is under "+
 			"the required amount of %d", txHash, txFee,
 			minFee)
-		return nil, nil, txRuleError(wire.RejectInsufficientFee, str)
+		return nil, txRuleError(wire.RejectInsufficientFee, str)
 	}
 
 	// Require that free transactions have sufficient priority to be mined
 	// in the next block.  Transactions which are being added back to the
 	// memory pool from blocks that have been disconnected during a reorg
 	// are exempted.
-	if isNew && !mp.cfg.Policy.DisableRelayPriority && txFee < minFee {
+	if isNew && !mp.cfg.Policy.DisableRelayPriority && relayFee < minFee {
 		currentPriority := mining.CalcPriority(tx.MsgTx(), utxoView,
 			nextBlockHeight)
 		if currentPriority <= mining.MinHighPriority {
 			str := fmt.Sprintf("transaction %v has insufficient "+
 				"priority (%g <= %g)", txHash,
 				currentPriority, mining.MinHighPriority)
-			return nil, nil, txRuleError(wire.RejectInsufficientFee, str)
+			return nil, txRuleError(wire.RejectInsufficientFee, str)
 		}
 	}
 
 	// Free-to-relay transactions are rate limited here to prevent
 	// penny-flooding with tiny transactions as a form of attack.
-	if rateLimit && txFee < minFee {
+	if rateLimit && relayFee < minFee {
 		nowUnix := time.Now().Unix()
 		// Decay passed data with an exponentially decaying ~10 minute
 		// window - matches bitcoind handling.
@@ -1184,7 +1281,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 		if mp.pennyTotal >= mp.cfg.Policy.FreeTxRelayLimit*10*1000 {
 			str := fmt.Sprintf("transaction %v has been rejected "+
 				"by the rate limiter due to low fees", txHash)
-			return nil, nil, txRuleError(wire.RejectInsufficientFee, str)
+			return nil, txRuleError(wire.RejectInsufficientFee, str)
 		}
 		oldTotal := mp.pennyTotal
 
@@ -1200,39 +1297,91 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 	if isReplacement {
 		conflicts, err = mp.validateReplacement(tx, txFee)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
+		}
+	}
+
+	// Don't allow the transaction to exceed the limits of unconfirmed
+	// ancestors and descendants.  Transactions which are being added back
+	// to the memory pool from blocks that have been disconnected during a
+	// reorg are exempted.
+	if isNew {
+		err := mp.checkAncestryLimits(tx, serializedSize, conflicts)
+		if err != nil {
+			return nil, err
 		}
 	}
 
 	// Verify crypto signatures for each input and reject the transaction if
 	// any don't verify.
-	err = blockchain.ValidateTransactionScripts(tx, utxoView,
-		txscript.StandardVerifyFlags, mp.cfg.SigCache,
-		mp.cfg.HashCache)
+	validateScripts := blockchain.ValidateTransactionScripts
+	if mp.cfg.ScriptValidationPool != nil {
+		validateScripts = mp.cfg.ScriptValidationPool.ValidateTransactionScripts
+	}
+	err = validateScripts(tx, utxoView, txscript.StandardVerifyFlags,
+		mp.cfg.SigCache, mp.cfg.HashCache)
 	if err != nil {
 		if cerr, ok := err.(blockchain.RuleError); ok {
-			return nil, nil, chainRuleError(cerr)
+			return nil, chainRuleError(cerr)
 		}
+		return nil, err
+	}
+
+	return &MempoolAcceptResult{
+		TxFee:      btcutil.Amount(txFee),
+		TxSize:     serializedSize,
+		Conflicts:  conflicts,
+		utxoView:   utxoView,
+		bestHeight: bestHeight,
+	}, nil
+}
+
+// maybeAcceptTransaction is the internal function which implements the public
+// MaybeAcceptTransaction.  See the comment for MaybeAcceptTransaction for
+// more details.
+//
+// The package fee rate is the fee rate in satoshi/kB of the package the
+// transaction is accepted as part of, if any.  The fee related policy checks
+// treat the transaction as paying at least that rate, so it can be paid for by
+// the other transactions in the package.  It is zero otherwise.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejectDupOrphans bool, pkgFeePerKB int64) ([]*chainhash.Hash, *TxDesc, error) {
+	result, err := mp.checkMempoolAcceptance(tx, isNew, rateLimit,
+		rejectDupOrphans, pkgFeePerKB)
+	if err != nil {
 		return nil, nil, err
 	}
+	if len(result.MissingParents) > 0 {
+		return result.MissingParents, nil, nil
+	}
 
 	// Now that we've deemed the transaction as valid, we can add it to the
 	// mempool. If it ended up replacing any transactions, we'll remove them
 	// first.
-	for _, conflict := range conflicts {
+	for _, conflict := range result.Conflicts {
 		log.Debugf("Replacing transaction %v (fee_rate=%v sat/kb) "+
 			"with %v (fee_rate=%v sat/kb)\n", conflict.Hash(),
 			mp.pool[*conflict.Hash()].FeePerKB, tx.Hash(),
-			txFee*1000/serializedSize)
+			int64(result.TxFee)*1000/result.TxSize)
 
 		// The conflict set should already include the descendants for
 		// each one, so we don't need to remove the redeemers within
 		// this call as they'll be removed eventually.
 		mp.removeTransaction(conflict, false)
+
+		if mp.cfg.FeeEstimator != nil {
+			mp.cfg.FeeEstimator.RemoveTransaction(conflict.Hash())
+		}
+		if mp.cfg.TxReplaced != nil {
+			mp.replacements = append(mp.replacements,
+				txReplacement{replaced: conflict, replacement: tx})
+		}
 	}
-	txD := mp.addTransaction(utxoView, tx, bestHeight, txFee)
+	txD := mp.addTransaction(result.utxoView, tx, result.bestHeight,
+		int64(result.TxFee))
 
-	log.Debugf("Accepted transaction %v (pool size: %v)", txHash,
+	log.Debugf("Accepted transaction %v (pool size: %v)", tx.Hash(),
 		len(mp.pool))
 
 	return nil, txD, nil
@@ -1252,12 +1401,47 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 func (mp *TxPool) MaybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit bool) ([]*chainhash.Hash, *TxDesc, error) {
 	// Protect concurrent access.
 	mp.mtx.Lock()
-	hashes, txD, err := mp.maybeAcceptTransaction(tx, isNew, rateLimit, true)
+	hashes, txD, err := mp.maybeAcceptTransaction(tx, isNew, rateLimit, true,
+		0)
 	mp.mtx.Unlock()
 
+	mp.signalReplacements()
 	return hashes, txD, err
 }
 
+// CheckMempoolAcceptance performs all checks to determine whether the passed
+// transaction would be accepted to the memory pool without adding it.  The
+// transaction is checked like a new transaction received from the network,
+// except that it isn't subject to the rate limiter for free transactions.
+//
+// A transaction which spends outputs that are neither in the pool nor unspent
+// in the main chain is reported via the missing parents of the result rather
+// than an error.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) CheckMempoolAcceptance(tx *btcutil.Tx) (*MempoolAcceptResult, error) {
+	// Protect concurrent access.
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	return mp.checkMempoolAcceptance(tx, true, false, true, 0)
+}
+
+// signalReplacements passes the transactions which were replaced since the
+// replacements were last signaled to the configured TxReplaced function.
+//
+// This function MUST NOT be called with the mempool lock held.
+func (mp *TxPool) signalReplacements() {
+	mp.mtx.Lock()
+	replacements := mp.replacements
+	mp.replacements = nil
+	mp.mtx.Unlock()
+
+	for _, r := range replacements {
+		mp.cfg.TxReplaced(r.replaced, r.replacement)
+	}
+}
+
 // processOrphans is the internal function which implements the public
 // ProcessOrphans.  See the comment for ProcessOrphans for more details.
 //
@@ -1295,7 +1479,7 @@ func (mp *TxPool) processOrphans(acceptedTx *btcutil.Tx) []*TxDesc {
 			// Potentially accept an orphan into the tx pool.
 			for _, tx := range orphans {
 				missing, txD, err := mp.maybeAcceptTransaction(
-					tx, true, true, false)
+					tx, true, true, false, 0)
 				if err != nil {
 					// The orphan is now invalid, so there
 					// is no way any other orphans which
@@ -1356,6 +1540,7 @@ func (mp *TxPool) ProcessOrphans(acceptedTx *btcutil.Tx) []*TxDesc {
 	acceptedTxns := mp.processOrphans(acceptedTx)
 	mp.mtx.Unlock()
 
+	mp.signalReplacements()
 	return acceptedTxns
 }
 
@@ -1373,14 +1558,27 @@ func (mp *TxPool) ProcessOrphans(acceptedTx *btcutil.Tx) []*TxDesc {
 func (mp *TxPool) ProcessTransaction(tx *btcutil.Tx, allowOrphan, rateLimit bool, tag Tag) ([]*TxDesc, error) {
 	log.Tracef("Processing transaction %v", tx.Hash())
 
+	// Signal the transactions which were replaced once the lock is
+	// released.
+	defer mp.signalReplacements()
+
 	// Protect concurrent access.
 	mp.mtx.Lock()
 	defer mp.mtx.Unlock()
 
 	// Potentially accept the transaction to the memory pool.
 	missingParents, txD, err := mp.maybeAcceptTransaction(tx, true, rateLimit,
-		true)
+		true, 0)
 	if err != nil {
+		// A transaction which doesn't pay sufficient fees on its own
+		// may still be accepted along with an orphan paying for it.
+		code, _ := extractRejectCode(err)
+		if code == wire.RejectInsufficientFee {
+			acceptedTxs := mp.acceptWithOrphanChild(tx)
+			if acceptedTxs != nil {
+				return acceptedTxs, nil
+			}
+		}
 		return nil, err
 	}
 
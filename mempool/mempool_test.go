@@ -560,7 +560,7 @@ func TestOrphanReject(t *testing.T) {
 
 		// Ensure no transactions were reported as accepted.
 		if len(acceptedTxns) != 0 {
-			t.Fatal("ProcessTransaction: reported %d accepted "+
+			t.Fatalf("ProcessTransaction: reported %d accepted "+
 				"transactions from failed orphan attempt",
 				len(acceptedTxns))
 		}
@@ -1452,6 +1452,386 @@ func TestAncestorsDescendants(t *testing.T) {
 	}
 }
 
+// TestMempoolEntry ensures the ancestors and descendants of the transactions in
+// the pool are maintained as transactions are added and removed and are
+// reflected in their entries.
+func TestMempoolEntry(t *testing.T) {
+	t.Parallel()
+
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	ctx := &testContext{t, harness}
+
+	// We'll be creating the following chain of unconfirmed transactions:
+	//
+	//       B ----
+	//     /        \
+	//   A            E
+	//     \        /
+	//       C -- D
+	//
+	// where B and C spend A, D spends C, and E spends B and D.
+	a := ctx.addSignedTx(outputs[:1], 2, 1000, false, false)
+	b := ctx.addSignedTx([]spendableOutput{txOutToSpendableOut(a, 0)},
+		1, 2000, false, false)
+	c := ctx.addSignedTx([]spendableOutput{txOutToSpendableOut(a, 1)},
+		1, 3000, false, false)
+	d := ctx.addSignedTx([]spendableOutput{txOutToSpendableOut(c, 0)},
+		1, 4000, false, false)
+	e := ctx.addSignedTx([]spendableOutput{
+		txOutToSpendableOut(b, 0), txOutToSpendableOut(d, 0),
+	}, 1, 5000, false, false)
+
+	vsize := func(tx *btcutil.Tx) int64 {
+		return GetTxVirtualSize(tx)
+	}
+	hashes := func(txns ...*btcutil.Tx) []chainhash.Hash {
+		result := make([]chainhash.Hash, 0, len(txns))
+		for _, tx := range txns {
+			result = append(result, *tx.Hash())
+		}
+		return result
+	}
+
+	type expectedEntry struct {
+		tx              *btcutil.Tx
+		ancestorCount   int
+		ancestorSize    int64
+		ancestorFees    int64
+		descendantCount int
+		descendantSize  int64
+		descendantFees  int64
+		depends         []chainhash.Hash
+		spentBy         []chainhash.Hash
+	}
+	checkEntries := func(desc string, expected []expectedEntry) {
+		t.Helper()
+
+		for _, want := range expected {
+			entry, err := harness.txPool.MempoolEntry(want.tx.Hash())
+			if err != nil {
+				t.Fatalf("%s: MempoolEntry: unexpected error: %v",
+					desc, err)
+			}
+			if entry.AncestorCount != want.ancestorCount ||
+				entry.AncestorSize != want.ancestorSize ||
+				entry.AncestorFees != want.ancestorFees {
+
+				t.Fatalf("%s: unexpected ancestors of %v - got "+
+					"%d/%d/%d, want %d/%d/%d", desc,
+					want.tx.Hash(), entry.AncestorCount,
+					entry.AncestorSize, entry.AncestorFees,
+					want.ancestorCount, want.ancestorSize,
+					want.ancestorFees)
+			}
+			if entry.DescendantCount != want.descendantCount ||
+				entry.DescendantSize != want.descendantSize ||
+				entry.DescendantFees != want.descendantFees {
+
+				t.Fatalf("%s: unexpected descendants of %v - "+
+					"got %d/%d/%d, want %d/%d/%d", desc,
+					want.tx.Hash(), entry.DescendantCount,
+					entry.DescendantSize, entry.DescendantFees,
+					want.descendantCount, want.descendantSize,
+					want.descendantFees)
+			}
+
+			gotHashes := func(hashes []*chainhash.Hash) map[chainhash.Hash]struct{} {
+				result := make(map[chainhash.Hash]struct{})
+				for _, hash := range hashes {
+					result[*hash] = struct{}{}
+				}
+				return result
+			}
+			for _, pair := range []struct {
+				name string
+				got  []*chainhash.Hash
+				want []chainhash.Hash
+			}{
+				{"depends", entry.Depends, want.depends},
+				{"spent by", entry.SpentBy, want.spentBy},
+			} {
+				got := gotHashes(pair.got)
+				if len(got) != len(pair.want) ||
+					len(pair.got) != len(pair.want) {
+
+					t.Fatalf("%s: unexpected %s of %v - got "+
+						"%d, want %d", desc, pair.name,
+						want.tx.Hash(), len(pair.got),
+						len(pair.want))
+				}
+				for _, hash := range pair.want {
+					if _, ok := got[hash]; !ok {
+						t.Fatalf("%s: %s of %v does not "+
+							"include %v", desc,
+							pair.name, want.tx.Hash(),
+							hash)
+					}
+				}
+			}
+		}
+	}
+
+	all := vsize(a) + vsize(b) + vsize(c) + vsize(d) + vsize(e)
+	fullPool := []expectedEntry{{
+		tx:              a,
+		ancestorCount:   1,
+		ancestorSize:    vsize(a),
+		ancestorFees:    1000,
+		descendantCount: 5,
+		descendantSize:  all,
+		descendantFees:  15000,
+		spentBy:         hashes(b, c),
+	}, {
+		tx:              b,
+		ancestorCount:   2,
+		ancestorSize:    vsize(a) + vsize(b),
+		ancestorFees:    3000,
+		descendantCount: 2,
+		descendantSize:  vsize(b) + vsize(e),
+		descendantFees:  7000,
+		depends:         hashes(a),
+		spentBy:         hashes(e),
+	}, {
+		tx:              d,
+		ancestorCount:   3,
+		ancestorSize:    vsize(a) + vsize(c) + vsize(d),
+		ancestorFees:    8000,
+		descendantCount: 2,
+		descendantSize:  vsize(d) + vsize(e),
+		descendantFees:  9000,
+		depends:         hashes(c),
+		spentBy:         hashes(e),
+	}, {
+		tx:              e,
+		ancestorCount:   5,
+		ancestorSize:    all,
+		ancestorFees:    15000,
+		descendantCount: 1,
+		descendantSize:  vsize(e),
+		descendantFees:  5000,
+		depends:         hashes(b, d),
+	}}
+	checkEntries("full pool", fullPool)
+
+	// The ancestors and descendants are ordered such that every
+	// transaction comes after the ones it depends on.
+	descendants, err := harness.txPool.Descendants(a.Hash())
+	if err != nil {
+		t.Fatalf("Descendants: unexpected error: %v", err)
+	}
+	ancestors, err := harness.txPool.Ancestors(e.Hash())
+	if err != nil {
+		t.Fatalf("Ancestors: unexpected error: %v", err)
+	}
+	for _, sorted := range [][]*TxDesc{descendants, ancestors} {
+		if len(sorted) != 4 {
+			t.Fatalf("unexpected number of transactions - got %d, "+
+				"want 4", len(sorted))
+		}
+		seen := map[chainhash.Hash]struct{}{*a.Hash(): {}}
+		for _, desc := range sorted {
+			for _, txIn := range desc.Tx.MsgTx().TxIn {
+				hash := txIn.PreviousOutPoint.Hash
+				if !harness.txPool.IsTransactionInPool(&hash) {
+					continue
+				}
+				if _, ok := seen[hash]; !ok {
+					t.Fatalf("%v is ordered before its "+
+						"parent %v", desc.Tx.Hash(), hash)
+				}
+			}
+			seen[*desc.Tx.Hash()] = struct{}{}
+		}
+	}
+
+	// Removing A without its descendants, as done when it is mined, must
+	// remove it from their ancestors.
+	harness.txPool.RemoveTransaction(a, false)
+	checkEntries("without A", []expectedEntry{{
+		tx:              b,
+		ancestorCount:   1,
+		ancestorSize:    vsize(b),
+		ancestorFees:    2000,
+		descendantCount: 2,
+		descendantSize:  vsize(b) + vsize(e),
+		descendantFees:  7000,
+		spentBy:         hashes(e),
+	}, {
+		tx:              e,
+		ancestorCount:   4,
+		ancestorSize:    all - vsize(a),
+		ancestorFees:    14000,
+		descendantCount: 1,
+		descendantSize:  vsize(e),
+		descendantFees:  5000,
+		depends:         hashes(b, d),
+	}})
+
+	// Adding A back, as done when the block it was mined in is
+	// disconnected, must link it with its descendants still in the pool.
+	_, _, err = harness.txPool.MaybeAcceptTransaction(a, false, false)
+	if err != nil {
+		t.Fatalf("MaybeAcceptTransaction: unexpected error: %v", err)
+	}
+	checkEntries("A added back", fullPool)
+
+	// Removing C, which is the only link between A and D, without its
+	// descendants must also unlink A and D.
+	harness.txPool.RemoveTransaction(c, false)
+	checkEntries("without C", []expectedEntry{{
+		tx:              a,
+		ancestorCount:   1,
+		ancestorSize:    vsize(a),
+		ancestorFees:    1000,
+		descendantCount: 3,
+		descendantSize:  vsize(a) + vsize(b) + vsize(e),
+		descendantFees:  8000,
+		spentBy:         hashes(b),
+	}, {
+		tx:              d,
+		ancestorCount:   1,
+		ancestorSize:    vsize(d),
+		ancestorFees:    4000,
+		descendantCount: 2,
+		descendantSize:  vsize(d) + vsize(e),
+		descendantFees:  9000,
+		spentBy:         hashes(e),
+	}})
+
+	// Removing A along with its descendants must leave only D.
+	harness.txPool.RemoveTransaction(a, true)
+	checkEntries("only D", []expectedEntry{{
+		tx:              d,
+		ancestorCount:   1,
+		ancestorSize:    vsize(d),
+		ancestorFees:    4000,
+		descendantCount: 1,
+		descendantSize:  vsize(d),
+		descendantFees:  4000,
+	}})
+	if _, err := harness.txPool.MempoolEntry(e.Hash()); err == nil {
+		t.Fatal("MempoolEntry: expected error for removed transaction")
+	}
+}
+
+// TestAncestryLimits ensures transactions which would exceed the limits of
+// unconfirmed ancestors and descendants of the policy are rejected.
+func TestAncestryLimits(t *testing.T) {
+	t.Parallel()
+
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	ctx := &testContext{t, harness}
+	policy := &harness.txPool.cfg.Policy
+
+	// processTx processes a transaction spending the passed outputs and
+	// ensures it is rejected with the passed error, if any.
+	processTx := func(inputs []spendableOutput, numOutputs uint32,
+		wantErr string) *btcutil.Tx {
+
+		t.Helper()
+
+		tx, err := harness.CreateSignedTx(inputs, numOutputs, 0, false)
+		if err != nil {
+			t.Fatalf("unable to create transaction: %v", err)
+		}
+		_, err = harness.txPool.ProcessTransaction(tx, false, false, 0)
+		switch {
+		case wantErr == "" && err != nil:
+			t.Fatalf("ProcessTransaction: unexpected error: %v", err)
+		case wantErr != "" && err == nil:
+			t.Fatalf("ProcessTransaction: expected error %q", wantErr)
+		case wantErr != "" && !strings.Contains(err.Error(), wantErr):
+			t.Fatalf("ProcessTransaction: unexpected error - got "+
+				"%v, want %q", err, wantErr)
+		}
+		if wantErr == "" {
+			testPoolMembership(ctx, tx, false, true)
+		}
+		return tx
+	}
+
+	// A chain of three transactions reaches an ancestor count limit of
+	// three, so a fourth one is rejected.
+	policy.MaxAncestorCount = 3
+	a := processTx(outputs[:1], 1, "")
+	b := processTx([]spendableOutput{txOutToSpendableOut(a, 0)}, 1, "")
+	c := processTx([]spendableOutput{txOutToSpendableOut(b, 0)}, 1, "")
+	processTx([]spendableOutput{txOutToSpendableOut(c, 0)}, 1,
+		"too many unconfirmed ancestors")
+
+	// The chain reaches an ancestor size limit of its current size, so
+	// another transaction is rejected.
+	policy.MaxAncestorCount = 0
+	policy.MaxAncestorSize = int(GetTxVirtualSize(a) +
+		GetTxVirtualSize(b) + GetTxVirtualSize(c))
+	processTx([]spendableOutput{txOutToSpendableOut(c, 0)}, 1,
+		"size limit of its unconfirmed ancestors")
+	policy.MaxAncestorSize = 0
+
+	// A transaction with three children reaches a descendant count limit
+	// of four, so a fourth child is rejected.  The limit applies to every
+	// ancestor, so a grandchild is rejected as well.
+	policy.MaxDescendantCount = 4
+	coinbase := ctx.addCoinbaseTx(1)
+	parent := processTx([]spendableOutput{txOutToSpendableOut(coinbase, 0)},
+		4, "")
+	child := processTx([]spendableOutput{txOutToSpendableOut(parent, 0)},
+		1, "")
+	processTx([]spendableOutput{txOutToSpendableOut(parent, 1)}, 1, "")
+	processTx([]spendableOutput{txOutToSpendableOut(parent, 2)}, 1, "")
+	processTx([]spendableOutput{txOutToSpendableOut(parent, 3)}, 1,
+		"descendant count limit")
+	processTx([]spendableOutput{txOutToSpendableOut(child, 0)}, 1,
+		"descendant count limit")
+	policy.MaxDescendantCount = 0
+
+	// The parent and its children reach a descendant size limit of their
+	// current size, so another child is rejected.
+	descendantSize := GetTxVirtualSize(parent) + 3*GetTxVirtualSize(child)
+	policy.MaxDescendantSize = int(descendantSize)
+	processTx([]spendableOutput{txOutToSpendableOut(parent, 3)}, 1,
+		"descendant size limit")
+	policy.MaxDescendantSize = 0
+
+	// A replacement only counts towards the descendants of its ancestors
+	// after the transactions it replaces were evicted along with their
+	// descendants.
+	coinbase = ctx.addCoinbaseTx(1)
+	parent = processTx([]spendableOutput{txOutToSpendableOut(coinbase, 0)},
+		1, "")
+	parentOut := txOutToSpendableOut(parent, 0)
+	child = ctx.addSignedTx([]spendableOutput{parentOut}, 1, 1000, true,
+		false)
+	grandchild := ctx.addSignedTx(
+		[]spendableOutput{txOutToSpendableOut(child, 0)}, 1, 1000, true,
+		false)
+	policy.MaxDescendantCount = 2
+	replacement, err := harness.CreateSignedTx(
+		[]spendableOutput{parentOut}, 1, 100000, true)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	conflicts := map[chainhash.Hash]*btcutil.Tx{*child.Hash(): child}
+	err = harness.txPool.checkAncestryLimits(replacement,
+		GetTxVirtualSize(replacement), conflicts)
+	if err != nil {
+		t.Fatalf("checkAncestryLimits: unexpected error: %v", err)
+	}
+	_, err = harness.txPool.ProcessTransaction(replacement, false, false, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: unexpected error: %v", err)
+	}
+	testPoolMembership(ctx, child, false, false)
+	testPoolMembership(ctx, grandchild, false, false)
+	testPoolMembership(ctx, replacement, false, true)
+}
+
 // TestRBF tests the different cases required for a transaction to properly
 // replace its conflicts given that they all signal replacement.
 func TestRBF(t *testing.T) {
@@ -1524,6 +1904,38 @@ func TestRBF(t *testing.T) {
 			},
 			err: "already spent by transaction",
 		},
+		{
+			// A transaction can replace another which doesn't
+			// signal replacement if the mempool's policy accepts
+			// full replacements.
+			name: "full replacement policy",
+			setup: func(ctx *testContext) (*btcutil.Tx, []*btcutil.Tx) {
+				ctx.harness.txPool.cfg.Policy.FullRBF = true
+
+				coinbase := ctx.addCoinbaseTx(1)
+
+				// Create a transaction that spends the coinbase
+				// output and doesn't signal for replacement.
+				coinbaseOut := txOutToSpendableOut(coinbase, 0)
+				outs := []spendableOutput{coinbaseOut}
+				parent := ctx.addSignedTx(
+					outs, 1, defaultFee, false, false,
+				)
+
+				// The replacement transaction should replace
+				// it since it has a higher fee.
+				tx, err := ctx.harness.CreateSignedTx(
+					outs, 1, defaultFee*2, false,
+				)
+				if err != nil {
+					ctx.t.Fatalf("unable to create "+
+						"transaction: %v", err)
+				}
+
+				return tx, []*btcutil.Tx{parent}
+			},
+			err: "",
+		},
 		{
 			// A transaction cannot replace another if doing so
 			// would cause more than 100 transactions being
@@ -1772,6 +2184,19 @@ func TestRBF(t *testing.T) {
 			ctx := &testContext{t, harness}
 			replacementTx, replacedTxs := testCase.setup(ctx)
 
+			// Keep track of the transactions the mempool signals
+			// as replaced.
+			signaled := make(map[chainhash.Hash]struct{})
+			harness.txPool.cfg.TxReplaced = func(replaced,
+				replacement *btcutil.Tx) {
+
+				if replacement != replacementTx {
+					t.Fatalf("unexpected replacement %v",
+						replacement.Hash())
+				}
+				signaled[*replaced.Hash()] = struct{}{}
+			}
+
 			// Attempt to process the replacement transaction. If
 			// it's not a valid one, we should see the error
 			// expected by the test.
@@ -1804,9 +2229,87 @@ func TestRBF(t *testing.T) {
 				testPoolMembership(ctx, tx, false, !valid)
 			}
 			testPoolMembership(ctx, replacementTx, false, valid)
+
+			// Each of the replaced transactions should have been
+			// signaled as such.
+			if valid && len(signaled) != len(replacedTxs) {
+				t.Fatalf("expected %d replaced transactions to "+
+					"be signaled, got %d", len(replacedTxs),
+					len(signaled))
+			}
+			for _, tx := range replacedTxs {
+				if _, ok := signaled[*tx.Hash()]; valid && !ok {
+					t.Fatalf("replaced transaction %v was "+
+						"not signaled", tx.Hash())
+				}
+			}
 		})
 		if !success {
 			break
 		}
 	}
 }
+
+// TestCheckMempoolAcceptance ensures transactions are checked for acceptance
+// without being added to the pool.
+func TestCheckMempoolAcceptance(t *testing.T) {
+	t.Parallel()
+
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	ctx := &testContext{t, harness}
+
+	// A valid transaction is reported with its fee and size, but isn't
+	// added to the pool.
+	tx, err := harness.CreateSignedTx(outputs[:1], 1, 1000, false)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	result, err := harness.txPool.CheckMempoolAcceptance(tx)
+	if err != nil {
+		t.Fatalf("CheckMempoolAcceptance: unexpected error: %v", err)
+	}
+	if result.TxFee != 1000 || result.TxSize != GetTxVirtualSize(tx) ||
+		len(result.MissingParents) != 0 {
+
+		t.Fatalf("CheckMempoolAcceptance: unexpected result %+v", result)
+	}
+	testPoolMembership(ctx, tx, false, false)
+
+	// A transaction spending an unknown transaction is reported as an
+	// orphan.
+	child, err := harness.CreateSignedTx(
+		[]spendableOutput{txOutToSpendableOut(tx, 0)}, 1, 1000, false,
+	)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	result, err = harness.txPool.CheckMempoolAcceptance(child)
+	if err != nil {
+		t.Fatalf("CheckMempoolAcceptance: unexpected error: %v", err)
+	}
+	if len(result.MissingParents) != 1 ||
+		!result.MissingParents[0].IsEqual(tx.Hash()) {
+
+		t.Fatalf("CheckMempoolAcceptance: unexpected missing parents "+
+			"%v", result.MissingParents)
+	}
+	testPoolMembership(ctx, child, false, false)
+
+	// Once the parent is in the pool, the child would be accepted, while
+	// the parent is rejected as a duplicate.
+	_, err = harness.txPool.ProcessTransaction(tx, false, false, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: unexpected error: %v", err)
+	}
+	if _, err := harness.txPool.CheckMempoolAcceptance(child); err != nil {
+		t.Fatalf("CheckMempoolAcceptance: unexpected error: %v", err)
+	}
+	_, err = harness.txPool.CheckMempoolAcceptance(tx)
+	if code, _ := extractRejectCode(err); code != wire.RejectDuplicate {
+		t.Fatalf("CheckMempoolAcceptance: unexpected error for "+
+			"duplicate: %v", err)
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// MaxPackageCount is the maximum number of transactions a package may
+	// contain.
+	MaxPackageCount = 25
+
+	// MaxPackageSize is the maximum total virtual size of the transactions
+	// of a package.
+	MaxPackageSize = 101000
+)
+
+// checkPackageTopology ensures the passed transactions form a package which
+// consists of a child transaction, which must be the last one, and its parents.
+// The parents must be sorted such that no transaction spends a transaction
+// which comes after it, and no two transactions may spend the same output.
+func checkPackageTopology(txns []*btcutil.Tx) error {
+	if len(txns) == 0 {
+		return txRuleError(wire.RejectInvalid, "package is empty")
+	}
+	if len(txns) > MaxPackageCount {
+		str := fmt.Sprintf("package contains %d transactions which is "+
+			"more than the maximum of %d", len(txns),
+			MaxPackageCount)
+		return txRuleError(wire.RejectNonstandard, str)
+	}
+
+	var size int64
+	positions := make(map[chainhash.Hash]int, len(txns))
+	spent := make(map[wire.OutPoint]struct{})
+	for i, tx := range txns {
+		if _, ok := positions[*tx.Hash()]; ok {
+			str := fmt.Sprintf("package contains transaction %v "+
+				"more than once", tx.Hash())
+			return txRuleError(wire.RejectInvalid, str)
+		}
+		positions[*tx.Hash()] = i
+		size += GetTxVirtualSize(tx)
+
+		for _, txIn := range tx.MsgTx().TxIn {
+			prevOut := txIn.PreviousOutPoint
+			if _, ok := spent[prevOut]; ok {
+				str := fmt.Sprintf("package transaction %v "+
+					"double spends output %v", tx.Hash(),
+					prevOut)
+				return txRuleError(wire.RejectInvalid, str)
+			}
+			spent[prevOut] = struct{}{}
+		}
+	}
+	if size > MaxPackageSize {
+		str := fmt.Sprintf("package has a virtual size of %d which "+
+			"is more than the maximum of %d", size, MaxPackageSize)
+		return txRuleError(wire.RejectNonstandard, str)
+	}
+
+	// Transactions may only spend the transactions of the package which
+	// come before them.
+	for i, tx := range txns {
+		for _, txIn := range tx.MsgTx().TxIn {
+			pos, ok := positions[txIn.PreviousOutPoint.Hash]
+			if ok && pos >= i {
+				str := fmt.Sprintf("package transaction %v "+
+					"spends transaction %v which does not "+
+					"come before it", tx.Hash(),
+					txIn.PreviousOutPoint.Hash)
+				return txRuleError(wire.RejectInvalid, str)
+			}
+		}
+	}
+
+	// Every transaction but the last one must be a parent of the child.
+	child := txns[len(txns)-1]
+	parents := make(map[chainhash.Hash]struct{})
+	for _, txIn := range child.MsgTx().TxIn {
+		parents[txIn.PreviousOutPoint.Hash] = struct{}{}
+	}
+	for _, tx := range txns[:len(txns)-1] {
+		if _, ok := parents[*tx.Hash()]; !ok {
+			str := fmt.Sprintf("package transaction %v is not a "+
+				"parent of child transaction %v", tx.Hash(),
+				child.Hash())
+			return txRuleError(wire.RejectInvalid, str)
+		}
+	}
+
+	return nil
+}
+
+// packageFee returns the total fee and virtual size of the passed package
+// transactions.  The outputs they spend must either be unspent in the main
+// chain, be outputs of transactions in the pool or be outputs of transactions
+// of the package.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) packageFee(txns []*btcutil.Tx) (int64, int64, error) {
+	pkgTxns := make(map[chainhash.Hash]*btcutil.Tx, len(txns))
+	for _, tx := range txns {
+		pkgTxns[*tx.Hash()] = tx
+	}
+
+	var fee, size int64
+	for _, tx := range txns {
+		utxoView, err := mp.fetchInputUtxos(tx)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		for _, txIn := range tx.MsgTx().TxIn {
+			prevOut := txIn.PreviousOutPoint
+			if pkgTx, ok := pkgTxns[prevOut.Hash]; ok {
+				txOuts := pkgTx.MsgTx().TxOut
+				if prevOut.Index < uint32(len(txOuts)) {
+					fee += txOuts[prevOut.Index].Value
+					continue
+				}
+			}
+
+			entry := utxoView.LookupEntry(prevOut)
+			if entry == nil || entry.IsSpent() {
+				str := fmt.Sprintf("package transaction %v "+
+					"references outputs of unknown or "+
+					"fully-spent transaction %v", tx.Hash(),
+					prevOut.Hash)
+				return 0, 0, txRuleError(wire.RejectDuplicate,
+					str)
+			}
+			fee += entry.Amount()
+		}
+		for _, txOut := range tx.MsgTx().TxOut {
+			fee -= txOut.Value
+		}
+		size += GetTxVirtualSize(tx)
+	}
+
+	return fee, size, nil
+}
+
+// processTransactionPackage is the internal function which implements the
+// public ProcessTransactionPackage.  See the comment for
+// ProcessTransactionPackage for more details.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) processTransactionPackage(txns []*btcutil.Tx) ([]*TxDesc, error) {
+	err := checkPackageTopology(txns)
+	if err != nil {
+		return nil, err
+	}
+
+	// Transactions of the package which are already in the pool are
+	// neither accepted again nor considered for the fee rate of the
+	// package.
+	newTxns := make([]*btcutil.Tx, 0, len(txns))
+	for _, tx := range txns {
+		if !mp.isTransactionInPool(tx.Hash()) {
+			newTxns = append(newTxns, tx)
+		}
+	}
+	if len(newTxns) == 0 {
+		return nil, nil
+	}
+
+	// Replacing transactions in the pool is not supported for packages,
+	// since the replaced transactions could not be restored if a later
+	// transaction of the package turns out to be invalid.
+	for _, tx := range newTxns {
+		for _, txIn := range tx.MsgTx().TxIn {
+			conflict, ok := mp.outpoints[txIn.PreviousOutPoint]
+			if !ok {
+				continue
+			}
+			str := fmt.Sprintf("package transaction %v spends "+
+				"output %v already spent by transaction %v in "+
+				"the memory pool", tx.Hash(),
+				txIn.PreviousOutPoint, conflict.Hash())
+			return nil, txRuleError(wire.RejectDuplicate, str)
+		}
+	}
+
+	// The transactions must pay at least the minimum relay fee rate as a
+	// whole.
+	pkgFee, pkgSize, err := mp.packageFee(newTxns)
+	if err != nil {
+		return nil, err
+	}
+	pkgFeePerKB := pkgFee * 1000 / pkgSize
+	if pkgFeePerKB < int64(mp.cfg.Policy.MinRelayTxFee) {
+		str := fmt.Sprintf("package has a fee rate of %d which is "+
+			"under the required fee rate of %d", pkgFeePerKB,
+			int64(mp.cfg.Policy.MinRelayTxFee))
+		return nil, txRuleError(wire.RejectInsufficientFee, str)
+	}
+
+	// Accept the transactions in order, so the parents are in the pool
+	// when the child is validated.  The transactions accepted so far are
+	// removed again if any of them is rejected.
+	acceptedTxns := make([]*TxDesc, 0, len(newTxns))
+	for _, tx := range newTxns {
+		missingParents, txD, err := mp.maybeAcceptTransaction(tx, true,
+			true, false, pkgFeePerKB)
+		if err == nil && len(missingParents) > 0 {
+			str := fmt.Sprintf("package transaction %v references "+
+				"outputs of unknown or fully-spent transaction "+
+				"%v", tx.Hash(), missingParents[0])
+			err = txRuleError(wire.RejectDuplicate, str)
+		}
+		if err != nil {
+			for i := len(acceptedTxns) - 1; i >= 0; i-- {
+				mp.removeTransaction(acceptedTxns[i].Tx, false)
+			}
+			return nil, err
+		}
+		acceptedTxns = append(acceptedTxns, txD)
+	}
+
+	// The package may have resolved orphans, which includes the package
+	// transactions themselves if they were previously received on their
+	// own.
+	for i := 0; i < len(newTxns); i++ {
+		tx := acceptedTxns[i].Tx
+		mp.removeOrphan(tx, false)
+		acceptedTxns = append(acceptedTxns, mp.processOrphans(tx)...)
+	}
+
+	log.Debugf("Accepted package of %d transactions with a fee rate of "+
+		"%d sat/kb (pool size: %v)", len(newTxns), pkgFeePerKB,
+		len(mp.pool))
+
+	return acceptedTxns, nil
+}
+
+// acceptWithOrphanChild attempts to accept the passed transaction, which was
+// rejected due to insufficient fees, as a package along with each orphan
+// transaction spending it until one of the packages is accepted.  This allows
+// a child which was received before its parent to pay for it.
+//
+// It returns the transactions added to the mempool if a package was accepted
+// and nil otherwise.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) acceptWithOrphanChild(tx *btcutil.Tx) []*TxDesc {
+	prevOut := wire.OutPoint{Hash: *tx.Hash()}
+	for txOutIdx := range tx.MsgTx().TxOut {
+		prevOut.Index = uint32(txOutIdx)
+		for _, orphan := range mp.orphansByPrev[prevOut] {
+			pkg := []*btcutil.Tx{tx, orphan}
+			acceptedTxs, err := mp.processTransactionPackage(pkg)
+			if err == nil {
+				return acceptedTxs
+			}
+			log.Debugf("Unable to accept transaction %v along with "+
+				"orphan %v: %v", tx.Hash(), orphan.Hash(), err)
+		}
+	}
+
+	return nil
+}
+
+// ProcessTransactionPackage evaluates the passed package of transactions for
+// acceptance into the memory pool as a whole.  The package consists of a child
+// transaction, which must be the last one, and its parents sorted such that no
+// transaction spends a transaction which comes after it.
+//
+// The fee related policy of the pool is applied to the package fee rate, which
+// is the total fee of the transactions of the package divided by their total
+// virtual size, rather than to the fee rate of each transaction.  This allows
+// a child to pay for parents which would not be accepted on their own.  All
+// other rules apply to each transaction of the package individually.  Either
+// all of the transactions are accepted or none of them.  Transactions which
+// are already in the pool are skipped, and transactions of the package may not
+// replace transactions in the pool.
+//
+// It returns a slice of transactions added to the mempool, which includes
+// orphan transactions that were accepted as a result of the package being
+// accepted.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) ProcessTransactionPackage(txns []*btcutil.Tx) ([]*TxDesc, error) {
+	log.Tracef("Processing package of %d transactions", len(txns))
+
+	// Signal the transactions which were replaced once the lock is
+	// released.
+	defer mp.signalReplacements()
+
+	// Protect concurrent access.
+	mp.mtx.Lock()
+	defer mp.mtx.Unlock()
+
+	return mp.processTransactionPackage(txns)
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+)
+
+// TestProcessTransactionPackage ensures packages of transactions are accepted
+// or rejected as a whole based on their package fee rate and the validity of
+// each of their transactions.
+func TestProcessTransactionPackage(t *testing.T) {
+	t.Parallel()
+
+	const highFee = 100000
+
+	// createTx creates a transaction spending the passed outputs with the
+	// passed fee without adding it to the pool.
+	createTx := func(ctx *testContext, outs []spendableOutput,
+		fee btcutil.Amount) *btcutil.Tx {
+
+		ctx.t.Helper()
+
+		tx, err := ctx.harness.CreateSignedTx(outs, 1, fee, false)
+		if err != nil {
+			ctx.t.Fatalf("unable to create transaction: %v", err)
+		}
+		return tx
+	}
+
+	// createParent creates a transaction without fees spending a new
+	// coinbase output, which would not be accepted on its own.
+	createParent := func(ctx *testContext) *btcutil.Tx {
+		coinbase := ctx.addCoinbaseTx(1)
+		outs := []spendableOutput{txOutToSpendableOut(coinbase, 0)}
+		parent := createTx(ctx, outs, 0)
+
+		_, err := ctx.harness.txPool.ProcessTransaction(
+			parent, false, true, 0,
+		)
+		if err == nil {
+			ctx.t.Fatalf("parent without fees accepted on its own")
+		}
+		return parent
+	}
+
+	// createChild creates a transaction spending the first output of each
+	// of the passed parents with the passed fee.
+	createChild := func(ctx *testContext, fee btcutil.Amount,
+		parents ...*btcutil.Tx) *btcutil.Tx {
+
+		var outs []spendableOutput
+		for _, parent := range parents {
+			outs = append(outs, txOutToSpendableOut(parent, 0))
+		}
+		return createTx(ctx, outs, fee)
+	}
+
+	tests := []struct {
+		name string
+
+		// setup returns the package and the transactions which are
+		// expected to be accepted as a result of processing it.
+		setup func(ctx *testContext) ([]*btcutil.Tx, []*btcutil.Tx)
+		err   string
+	}{
+		{
+			name: "child pays for parent",
+			setup: func(ctx *testContext) ([]*btcutil.Tx, []*btcutil.Tx) {
+				parent := createParent(ctx)
+				child := createChild(ctx, highFee, parent)
+				pkg := []*btcutil.Tx{parent, child}
+				return pkg, pkg
+			},
+		},
+		{
+			name: "child pays for multiple parents",
+			setup: func(ctx *testContext) ([]*btcutil.Tx, []*btcutil.Tx) {
+				parent1 := createParent(ctx)
+				parent2 := createParent(ctx)
+				child := createChild(ctx, highFee, parent1, parent2)
+				pkg := []*btcutil.Tx{parent1, parent2, child}
+				return pkg, pkg
+			},
+		},
+		{
+			name: "insufficient package fee rate",
+			setup: func(ctx *testContext) ([]*btcutil.Tx, []*btcutil.Tx) {
+				parent := createParent(ctx)
+				child := createChild(ctx, 100, parent)
+				return []*btcutil.Tx{parent, child}, nil
+			},
+			err: "package has a fee rate",
+		},
+		{
+			name: "invalid child",
+			setup: func(ctx *testContext) ([]*btcutil.Tx, []*btcutil.Tx) {
+				parent := createParent(ctx)
+				child := createChild(ctx, highFee, parent)
+
+				// Strip the signature of the child, so it is
+				// only rejected once its parent was accepted.
+				msgTx := child.MsgTx().Copy()
+				msgTx.TxIn[0].SignatureScript = nil
+				child = btcutil.NewTx(msgTx)
+
+				return []*btcutil.Tx{parent, child}, nil
+			},
+			err: "failed to validate input",
+		},
+		{
+			name: "parent already in pool",
+			setup: func(ctx *testContext) ([]*btcutil.Tx, []*btcutil.Tx) {
+				coinbase := ctx.addCoinbaseTx(1)
+				outs := []spendableOutput{
+					txOutToSpendableOut(coinbase, 0),
+				}
+				parent := ctx.addSignedTx(outs, 1, highFee,
+					false, false)
+				child := createChild(ctx, highFee, parent)
+
+				pkg := []*btcutil.Tx{parent, child}
+				return pkg, []*btcutil.Tx{child}
+			},
+		},
+		{
+			name: "child in orphan pool",
+			setup: func(ctx *testContext) ([]*btcutil.Tx, []*btcutil.Tx) {
+				parent := createParent(ctx)
+				child := createChild(ctx, highFee, parent)
+
+				_, err := ctx.harness.txPool.ProcessTransaction(
+					child, true, true, 0,
+				)
+				if err != nil {
+					ctx.t.Fatalf("unable to add orphan: %v",
+						err)
+				}
+				testPoolMembership(ctx, child, true, false)
+
+				pkg := []*btcutil.Tx{parent, child}
+				return pkg, pkg
+			},
+		},
+		{
+			name: "transaction is not a parent",
+			setup: func(ctx *testContext) ([]*btcutil.Tx, []*btcutil.Tx) {
+				parent := createParent(ctx)
+				other := createParent(ctx)
+				child := createChild(ctx, highFee, parent)
+				return []*btcutil.Tx{parent, other, child}, nil
+			},
+			err: "is not a parent",
+		},
+		{
+			name: "unsorted package",
+			setup: func(ctx *testContext) ([]*btcutil.Tx, []*btcutil.Tx) {
+				parent := createParent(ctx)
+				child := createChild(ctx, highFee, parent)
+				return []*btcutil.Tx{child, parent}, nil
+			},
+			err: "does not come before it",
+		},
+		{
+			name: "conflicts with pool",
+			setup: func(ctx *testContext) ([]*btcutil.Tx, []*btcutil.Tx) {
+				coinbase := ctx.addCoinbaseTx(1)
+				outs := []spendableOutput{
+					txOutToSpendableOut(coinbase, 0),
+				}
+				ctx.addSignedTx(outs, 1, highFee, true, false)
+
+				parent := createTx(ctx, outs, highFee*2)
+				child := createChild(ctx, highFee, parent)
+				return []*btcutil.Tx{parent, child}, nil
+			},
+			err: "already spent by transaction",
+		},
+	}
+
+	for _, test := range tests {
+		success := t.Run(test.name, func(t *testing.T) {
+			harness, _, err := newPoolHarness(&chaincfg.MainNetParams)
+			if err != nil {
+				t.Fatalf("unable to create test pool: %v", err)
+			}
+
+			// Don't relay any transactions without sufficient
+			// fees on their own.
+			harness.txPool.cfg.Policy.FreeTxRelayLimit = 0
+
+			ctx := &testContext{t, harness}
+			pkg, wantAccepted := test.setup(ctx)
+
+			acceptedTxns, err := harness.txPool.ProcessTransactionPackage(
+				pkg,
+			)
+			if test.err == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.err != "" {
+				if err == nil {
+					t.Fatalf("expected error: %v", test.err)
+				}
+				if !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected error: %v\ngot: %v",
+						test.err, err)
+				}
+			}
+
+			if len(acceptedTxns) != len(wantAccepted) {
+				t.Fatalf("expected %d accepted transactions, "+
+					"got %d", len(wantAccepted),
+					len(acceptedTxns))
+			}
+			for i, txD := range acceptedTxns {
+				if *txD.Tx.Hash() != *wantAccepted[i].Hash() {
+					t.Fatalf("unexpected accepted transaction "+
+						"%d: got %v, want %v", i,
+						txD.Tx.Hash(), wantAccepted[i].Hash())
+				}
+			}
+
+			// Either all transactions of the package are in the
+			// pool or none of them, unless they were in the pool
+			// before.
+			valid := test.err == ""
+			for _, tx := range pkg {
+				testPoolMembership(ctx, tx, false, valid)
+			}
+		})
+		if !success {
+			break
+		}
+	}
+}
+
+// TestOrphanPaysForParent ensures a transaction which doesn't pay sufficient
+// fees on its own is accepted along with an orphan paying for it.
+func TestOrphanPaysForParent(t *testing.T) {
+	t.Parallel()
+
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	harness.txPool.cfg.Policy.FreeTxRelayLimit = 0
+	ctx := &testContext{t, harness}
+
+	parent, err := harness.CreateSignedTx(outputs, 1, 0, false)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	outs := []spendableOutput{txOutToSpendableOut(parent, 0)}
+	lowFeeChild, err := harness.CreateSignedTx(outs, 1, 100, false)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	child, err := harness.CreateSignedTx(outs, 2, 100000, false)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+
+	// Add both children to the orphan pool.  Only the second one pays
+	// sufficient fees for the parent.
+	for _, tx := range []*btcutil.Tx{lowFeeChild, child} {
+		_, err := harness.txPool.ProcessTransaction(tx, true, true, 0)
+		if err != nil {
+			t.Fatalf("unable to add orphan: %v", err)
+		}
+		testPoolMembership(ctx, tx, true, false)
+	}
+
+	acceptedTxns, err := harness.txPool.ProcessTransaction(parent, false,
+		true, 0)
+	if err != nil {
+		t.Fatalf("unable to process parent: %v", err)
+	}
+	if len(acceptedTxns) != 2 {
+		t.Fatalf("expected 2 accepted transactions, got %d",
+			len(acceptedTxns))
+	}
+	testPoolMembership(ctx, parent, false, true)
+	testPoolMembership(ctx, child, false, true)
+
+	// The other child double spends the accepted one, so it can never be
+	// accepted and is no longer an orphan.
+	testPoolMembership(ctx, lowFeeChild, false, false)
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/btcsuite/btcd/mining"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// mempoolFileVersion is the version of the format the transactions of
+	// the pool are saved with.
+	mempoolFileVersion = 1
+
+	// maxSavedTxns is the maximum number of transactions read from saved
+	// transactions of a pool.  It protects against allocating excessive
+	// memory for corrupted data.
+	maxSavedTxns = 10000000
+)
+
+// Save writes the transactions in the pool to the passed writer, so they can
+// be loaded into a pool again with Load.  The transactions are written such
+// that every transaction comes after the transactions it depends on along with
+// the time they were added to the pool and the fee they pay.
+//
+// The format is a uint32 version followed by a variable length integer count
+// of the transactions.  Each transaction is serialized with its witness data
+// and followed by the unix time it was added and its fee in satoshi, both as
+// int64.  All integers are little endian.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Save(w io.Writer) error {
+	// Copy the data to write while holding the lock, so it is not
+	// modified while the transactions are written.
+	mp.mtx.RLock()
+	descs := sortedDescs(mp.pool)
+	saved := make([]mining.TxDesc, len(descs))
+	for i, desc := range descs {
+		saved[i] = desc.TxDesc
+	}
+	mp.mtx.RUnlock()
+
+	var buf [8]byte
+	binary.LittleEndian.PutUint32(buf[:4], mempoolFileVersion)
+	if _, err := w.Write(buf[:4]); err != nil {
+		return err
+	}
+	err := wire.WriteVarInt(w, 0, uint64(len(saved)))
+	if err != nil {
+		return err
+	}
+
+	for _, desc := range saved {
+		if err := desc.Tx.MsgTx().Serialize(w); err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint64(buf[:], uint64(desc.Added.Unix()))
+		if _, err := w.Write(buf[:]); err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint64(buf[:], uint64(desc.Fee))
+		if _, err := w.Write(buf[:]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Load reads transactions written by Save from the passed reader and attempts
+// to accept each of them into the pool.  The transactions are validated
+// against the current state of the main chain, so transactions which were
+// mined or became invalid in the meantime, as well as transactions depending on
+// those, are skipped.  The fee of each transaction is recalculated during
+// validation, while the time it was added to the pool is restored.
+//
+// Loading stops early without an error when the passed interrupt channel is
+// closed.  It returns the number of transactions accepted into the pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Load(r io.Reader, interrupt <-chan struct{}) (int, error) {
+	// Signal the transactions which were replaced by saved transactions
+	// once loading is done.
+	defer mp.signalReplacements()
+
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[:4]); err != nil {
+		return 0, err
+	}
+	version := binary.LittleEndian.Uint32(buf[:4])
+	if version != mempoolFileVersion {
+		return 0, fmt.Errorf("unsupported mempool file version %d",
+			version)
+	}
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return 0, err
+	}
+	if count > maxSavedTxns {
+		return 0, fmt.Errorf("too many saved transactions: %d", count)
+	}
+
+	var accepted int
+	for i := uint64(0); i < count; i++ {
+		select {
+		case <-interrupt:
+			return accepted, nil
+		default:
+		}
+
+		var msgTx wire.MsgTx
+		if err := msgTx.Deserialize(r); err != nil {
+			return accepted, err
+		}
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return accepted, err
+		}
+		added := time.Unix(int64(binary.LittleEndian.Uint64(buf[:])), 0)
+
+		// The fee is recalculated when the transaction is validated.
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return accepted, err
+		}
+
+		tx := btcutil.NewTx(&msgTx)
+		mp.mtx.Lock()
+		missingParents, txD, err := mp.maybeAcceptTransaction(tx, true,
+			false, true, 0)
+		if err == nil && len(missingParents) == 0 {
+			txD.Added = added
+			accepted++
+		}
+		mp.mtx.Unlock()
+
+		switch {
+		case err != nil:
+			log.Debugf("Skipping saved transaction %v: %v",
+				tx.Hash(), err)
+		case len(missingParents) > 0:
+			log.Debugf("Skipping saved transaction %v: missing "+
+				"parent %v", tx.Hash(), missingParents[0])
+		}
+	}
+
+	return accepted, nil
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// TestSaveLoad ensures the transactions of a pool which are saved are loaded
+// again with their metadata once they are still valid.
+func TestSaveLoad(t *testing.T) {
+	t.Parallel()
+
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	tc := &testContext{t, harness}
+
+	chainedTxns, err := harness.CreateTxChain(outputs[0], 3)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+	added := time.Unix(time.Now().Unix()-3600, 0)
+	for _, tx := range chainedTxns {
+		_, txD, err := harness.txPool.MaybeAcceptTransaction(tx, true,
+			false)
+		if err != nil {
+			t.Fatalf("unable to accept transaction: %v", err)
+		}
+		txD.Added = added
+	}
+
+	var buf bytes.Buffer
+	if err := harness.txPool.Save(&buf); err != nil {
+		t.Fatalf("unable to save pool: %v", err)
+	}
+	saved := buf.Bytes()
+
+	// Loading the transactions into an empty pool restores all of them
+	// along with the time they were added.
+	harness.txPool.RemoveTransaction(chainedTxns[0], true)
+	if harness.txPool.Count() != 0 {
+		t.Fatalf("pool is not empty")
+	}
+	n, err := harness.txPool.Load(bytes.NewReader(saved), nil)
+	if err != nil {
+		t.Fatalf("unable to load pool: %v", err)
+	}
+	if n != len(chainedTxns) {
+		t.Fatalf("expected %d loaded transactions, got %d",
+			len(chainedTxns), n)
+	}
+	for _, txD := range harness.txPool.TxDescs() {
+		if !txD.Added.Equal(added) {
+			t.Fatalf("unexpected added time of %v: got %v, want %v",
+				txD.Tx.Hash(), txD.Added, added)
+		}
+	}
+
+	// Mine the first transaction and load the transactions again.  Only
+	// the remaining transactions are accepted.
+	harness.txPool.RemoveTransaction(chainedTxns[0], true)
+	harness.chain.utxos.LookupEntry(outputs[0].outPoint).Spend()
+	harness.chain.utxos.AddTxOuts(chainedTxns[0],
+		harness.chain.BestHeight()+1)
+	harness.chain.SetHeight(harness.chain.BestHeight() + 1)
+
+	n, err = harness.txPool.Load(bytes.NewReader(saved), nil)
+	if err != nil {
+		t.Fatalf("unable to load pool: %v", err)
+	}
+	if n != len(chainedTxns)-1 {
+		t.Fatalf("expected %d loaded transactions, got %d",
+			len(chainedTxns)-1, n)
+	}
+	testPoolMembership(tc, chainedTxns[0], false, false)
+	for _, tx := range chainedTxns[1:] {
+		testPoolMembership(tc, tx, false, true)
+	}
+
+	// Loading stops once interrupted.
+	harness.txPool.RemoveTransaction(chainedTxns[1], true)
+	interrupt := make(chan struct{})
+	close(interrupt)
+	n, err = harness.txPool.Load(bytes.NewReader(saved), interrupt)
+	if err != nil {
+		t.Fatalf("unable to load pool: %v", err)
+	}
+	if n != 0 || harness.txPool.Count() != 0 {
+		t.Fatalf("transactions loaded after interrupt")
+	}
+
+	// Unknown versions are rejected.
+	unknown := append([]byte{0x02, 0, 0, 0}, saved[4:]...)
+	_, err = harness.txPool.Load(bytes.NewReader(unknown), nil)
+	if err == nil {
+		t.Fatalf("loaded unknown version")
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"testing"
 	"time"
 
-	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
@@ -41,13 +40,13 @@ func TestCalcMinRequiredTxRelayFee(t *testing.T) {
 		},
 		{
 			"max standard tx size with default minimum relay fee",
-			maxStandardTxWeight / 4,
+			txscript.MaxStandardTxWeight / 4,
 			DefaultMinRelayTxFee,
 			100000,
 		},
 		{
 			"max standard tx size with max satoshi relay fee",
-			maxStandardTxWeight / 4,
+			txscript.MaxStandardTxWeight / 4,
 			btcutil.MaxSatoshi,
 			btcutil.MaxSatoshi,
 		},
@@ -94,189 +93,6 @@ func TestCalcMinRequiredTxRelayFee(t *testing.T) {
 	}
 }
 
-// TestCheckPkScriptStandard tests the checkPkScriptStandard API.
-func TestCheckPkScriptStandard(t *testing.T) {
-	var pubKeys [][]byte
-	for i := 0; i < 4; i++ {
-		pk, err := btcec.NewPrivateKey(btcec.S256())
-		if err != nil {
-			t.Fatalf("TestCheckPkScriptStandard NewPrivateKey failed: %v",
-				err)
-			return
-		}
-		pubKeys = append(pubKeys, pk.PubKey().SerializeCompressed())
-	}
-
-	tests := []struct {
-		name       string // test description.
-		script     *txscript.ScriptBuilder
-		isStandard bool
-	}{
-		{
-			"key1 and key2",
-			txscript.NewScriptBuilder().AddOp(txscript.OP_2).
-				AddData(pubKeys[0]).AddData(pubKeys[1]).
-				AddOp(txscript.OP_2).AddOp(txscript.OP_CHECKMULTISIG),
-			true,
-		},
-		{
-			"key1 or key2",
-			txscript.NewScriptBuilder().AddOp(txscript.OP_1).
-				AddData(pubKeys[0]).AddData(pubKeys[1]).
-				AddOp(txscript.OP_2).AddOp(txscript.OP_CHECKMULTISIG),
-			true,
-		},
-		{
-			"escrow",
-			txscript.NewScriptBuilder().AddOp(txscript.OP_2).
-				AddData(pubKeys[0]).AddData(pubKeys[1]).
-				AddData(pubKeys[2]).
-				AddOp(txscript.OP_3).AddOp(txscript.OP_CHECKMULTISIG),
-			true,
-		},
-		{
-			"one of four",
-			txscript.NewScriptBuilder().AddOp(txscript.OP_1).
-				AddData(pubKeys[0]).AddData(pubKeys[1]).
-				AddData(pubKeys[2]).AddData(pubKeys[3]).
-				AddOp(txscript.OP_4).AddOp(txscript.OP_CHECKMULTISIG),
-			false,
-		},
-		{
-			"malformed1",
-			txscript.NewScriptBuilder().AddOp(txscript.OP_3).
-				AddData(pubKeys[0]).AddData(pubKeys[1]).
-				AddOp(txscript.OP_2).AddOp(txscript.OP_CHECKMULTISIG),
-			false,
-		},
-		{
-			"malformed2",
-			txscript.NewScriptBuilder().AddOp(txscript.OP_2).
-				AddData(pubKeys[0]).AddData(pubKeys[1]).
-				AddOp(txscript.OP_3).AddOp(txscript.OP_CHECKMULTISIG),
-			false,
-		},
-		{
-			"malformed3",
-			txscript.NewScriptBuilder().AddOp(txscript.OP_0).
-				AddData(pubKeys[0]).AddData(pubKeys[1]).
-				AddOp(txscript.OP_2).AddOp(txscript.OP_CHECKMULTISIG),
-			false,
-		},
-		{
-			"malformed4",
-			txscript.NewScriptBuilder().AddOp(txscript.OP_1).
-				AddData(pubKeys[0]).AddData(pubKeys[1]).
-				AddOp(txscript.OP_0).AddOp(txscript.OP_CHECKMULTISIG),
-			false,
-		},
-		{
-			"malformed5",
-			txscript.NewScriptBuilder().AddOp(txscript.OP_1).
-				AddData(pubKeys[0]).AddData(pubKeys[1]).
-				AddOp(txscript.OP_CHECKMULTISIG),
-			false,
-		},
-		{
-			"malformed6",
-			txscript.NewScriptBuilder().AddOp(txscript.OP_1).
-				AddData(pubKeys[0]).AddData(pubKeys[1]),
-			false,
-		},
-	}
-
-	for _, test := range tests {
-		script, err := test.script.Script()
-		if err != nil {
-			t.Fatalf("TestCheckPkScriptStandard test '%s' "+
-				"failed: %v", test.name, err)
-			continue
-		}
-		scriptClass := txscript.GetScriptClass(script)
-		got := checkPkScriptStandard(script, scriptClass)
-		if (test.isStandard && got != nil) ||
-			(!test.isStandard && got == nil) {
-
-			t.Fatalf("TestCheckPkScriptStandard test '%s' failed",
-				test.name)
-			return
-		}
-	}
-}
-
-// TestDust tests the isDust API.
-func TestDust(t *testing.T) {
-	pkScript := []byte{0x76, 0xa9, 0x21, 0x03, 0x2f, 0x7e, 0x43,
-		0x0a, 0xa4, 0xc9, 0xd1, 0x59, 0x43, 0x7e, 0x84, 0xb9,
-		0x75, 0xdc, 0x76, 0xd9, 0x00, 0x3b, 0xf0, 0x92, 0x2c,
-		0xf3, 0xaa, 0x45, 0x28, 0x46, 0x4b, 0xab, 0x78, 0x0d,
-		0xba, 0x5e, 0x88, 0xac}
-
-	tests := []struct {
-		name     string // test description
-		txOut    wire.TxOut
-		relayFee btcutil.Amount // minimum relay transaction fee.
-		isDust   bool
-	}{
-		{
-			// Any value is allowed with a zero relay fee.
-			"zero value with zero relay fee",
-			wire.TxOut{Value: 0, PkScript: pkScript},
-			0,
-			false,
-		},
-		{
-			// Zero value is dust with any relay fee"
-			"zero value with very small tx fee",
-			wire.TxOut{Value: 0, PkScript: pkScript},
-			1,
-			true,
-		},
-		{
-			"38 byte public key script with value 584",
-			wire.TxOut{Value: 584, PkScript: pkScript},
-			1000,
-			true,
-		},
-		{
-			"38 byte public key script with value 585",
-			wire.TxOut{Value: 585, PkScript: pkScript},
-			1000,
-			false,
-		},
-		{
-			// Maximum allowed value is never dust.
-			"max satoshi amount is never dust",
-			wire.TxOut{Value: btcutil.MaxSatoshi, PkScript: pkScript},
-			btcutil.MaxSatoshi,
-			false,
-		},
-		{
-			// Maximum int64 value causes overflow.
-			"maximum int64 value",
-			wire.TxOut{Value: 1<<63 - 1, PkScript: pkScript},
-			1<<63 - 1,
-			true,
-		},
-		{
-			// Unspendable pkScript due to an invalid public key
-			// script.
-			"unspendable pkScript",
-			wire.TxOut{Value: 5000, PkScript: []byte{0x01}},
-			0, // no relay fee
-			true,
-		},
-	}
-	for _, test := range tests {
-		res := isDust(&test.txOut, test.relayFee)
-		if res != test.isDust {
-			t.Fatalf("Dust test '%s' failed: want %v got %v",
-				test.name, test.isDust, res)
-			continue
-		}
-	}
-}
-
 // TestCheckTransactionStandard tests the checkTransactionStandard API.
 func TestCheckTransactionStandard(t *testing.T) {
 	// Create some dummy, but otherwise standard, data for transactions.
@@ -360,7 +176,7 @@ func TestCheckTransactionStandard(t *testing.T) {
 				TxOut: []*wire.TxOut{{
 					Value: 0,
 					PkScript: bytes.Repeat([]byte{0x00},
-						(maxStandardTxWeight/4)+1),
+						(txscript.MaxStandardTxWeight/4)+1),
 				}},
 				LockTime: 0,
 			},
@@ -375,7 +191,7 @@ func TestCheckTransactionStandard(t *testing.T) {
 				TxIn: []*wire.TxIn{{
 					PreviousOutPoint: dummyPrevOut,
 					SignatureScript: bytes.Repeat([]byte{0x00},
-						maxStandardSigScriptSize+1),
+						txscript.MaxStandardSigScriptSize+1),
 					Sequence: wire.MaxTxInSequenceNum,
 				}},
 				TxOut:    []*wire.TxOut{&dummyTxOut},
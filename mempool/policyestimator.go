@@ -0,0 +1,734 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// shortBlockPeriods, shortScale and shortDecay define the short
+	// horizon of the policy estimator.  It tracks confirmations within up
+	// to 12 blocks with a half life of about 18 blocks.
+	shortBlockPeriods = 12
+	shortScale        = 1
+	shortDecay        = .962
+
+	// medBlockPeriods, medScale and medDecay define the medium horizon of
+	// the policy estimator.  It tracks confirmations within up to 48
+	// blocks in periods of 2 blocks with a half life of about 144 blocks.
+	medBlockPeriods = 24
+	medScale        = 2
+	medDecay        = .9952
+
+	// longBlockPeriods, longScale and longDecay define the long horizon of
+	// the policy estimator.  It tracks confirmations within up to 1008
+	// blocks in periods of 24 blocks with a half life of about 1008 blocks.
+	longBlockPeriods = 42
+	longScale        = 24
+	longDecay        = .99931
+
+	// halfSuccessPct, successPct and doubleSuccessPct are the fractions of
+	// transactions of a fee rate range that must have been confirmed
+	// within half the target, the target and double the target,
+	// respectively, for the range to be considered sufficient.
+	halfSuccessPct   = .6
+	successPct       = .85
+	doubleSuccessPct = .95
+
+	// sufficientFeeTxs and sufficientTxsShort are the average number of
+	// transactions per block a fee rate range must contain for its
+	// confirmation statistics to be used in the medium and long horizon
+	// and in the short horizon, respectively.
+	sufficientFeeTxs   = 0.1
+	sufficientTxsShort = 0.5
+
+	// minBucketFeeRate and maxBucketFeeRate are the lowest and the highest
+	// fee rates in satoshi per byte which are tracked in their own bucket.
+	// The boundaries of the buckets in between grow by feeSpacing.  Higher
+	// fee rates are tracked in a final bucket without an upper boundary.
+	minBucketFeeRate = 1
+	maxBucketFeeRate = 10000
+	feeSpacing       = 1.05
+
+	// MaxConfirmationTarget is the highest confirmation target
+	// EstimateSmartFee provides estimates for.
+	MaxConfirmationTarget = longBlockPeriods * longScale
+)
+
+// EstimateMode defines the estimation modes of EstimateSmartFee.
+type EstimateMode uint8
+
+const (
+	// EstimateEconomical returns estimates which respond quickly to
+	// short-term drops in the fee rates required for confirmation.
+	EstimateEconomical EstimateMode = iota
+
+	// EstimateConservative returns estimates which also consider the fee
+	// rates required for confirmation over a longer history, so they are
+	// less likely to be insufficient.
+	EstimateConservative
+)
+
+// String returns the EstimateMode as a human-readable name.
+func (m EstimateMode) String() string {
+	switch m {
+	case EstimateEconomical:
+		return "economical"
+	case EstimateConservative:
+		return "conservative"
+	}
+
+	return fmt.Sprintf("unknown estimate mode (%d)", uint8(m))
+}
+
+// txConfirmStats tracks the number of transactions per fee rate bucket which
+// were confirmed or failed to confirm within each period of a horizon.  All
+// tracked values decay exponentially with every block, so more recent blocks
+// have a greater weight.
+type txConfirmStats struct {
+	// buckets are the upper boundaries of the fee rate buckets.
+	buckets []float64
+
+	// decay is the factor all averages are multiplied by with every block.
+	decay float64
+
+	// scale is the number of blocks per period.
+	scale int32
+
+	// txCtAvg and feeRateAvg are the decaying number of confirmed
+	// transactions and the decaying sum of their fee rates per bucket.
+	txCtAvg    []float64
+	feeRateAvg []float64
+
+	// confAvg and failAvg are the decaying number of transactions per
+	// period and bucket which were confirmed within the period and which
+	// were removed from the pool unconfirmed after the period,
+	// respectively.
+	confAvg [][]float64
+	failAvg [][]float64
+
+	// unconfTxs is the number of unconfirmed transactions per bucket which
+	// entered the pool at each of the heights up to maxConfirms blocks
+	// ago, indexed by height modulo maxConfirms.  oldUnconfTxs is the
+	// number of unconfirmed transactions per bucket which entered the pool
+	// before that.
+	unconfTxs    [][]int
+	oldUnconfTxs []int
+}
+
+// newTxConfirmStats returns confirmation statistics for the passed buckets
+// which are tracked for the passed number of periods of scale blocks each.
+func newTxConfirmStats(buckets []float64, periods, scale int32,
+	decay float64) *txConfirmStats {
+
+	numBuckets := len(buckets)
+	stats := &txConfirmStats{
+		buckets:      buckets,
+		decay:        decay,
+		scale:        scale,
+		txCtAvg:      make([]float64, numBuckets),
+		feeRateAvg:   make([]float64, numBuckets),
+		confAvg:      make([][]float64, periods),
+		failAvg:      make([][]float64, periods),
+		unconfTxs:    make([][]int, periods*scale),
+		oldUnconfTxs: make([]int, numBuckets),
+	}
+	for i := range stats.confAvg {
+		stats.confAvg[i] = make([]float64, numBuckets)
+		stats.failAvg[i] = make([]float64, numBuckets)
+	}
+	for i := range stats.unconfTxs {
+		stats.unconfTxs[i] = make([]int, numBuckets)
+	}
+
+	return stats
+}
+
+// maxConfirms returns the highest confirmation target the statistics can be
+// used for.
+func (s *txConfirmStats) maxConfirms() int32 {
+	return s.scale * int32(len(s.confAvg))
+}
+
+// unconfIndex returns the index into unconfTxs for transactions which entered
+// the pool at the passed height.
+func (s *txConfirmStats) unconfIndex(height int32) int {
+	bins := int32(len(s.unconfTxs))
+	return int((height%bins + bins) % bins)
+}
+
+// clearCurrent moves the unconfirmed transactions which entered the pool
+// maxConfirms blocks before the passed height to the old unconfirmed
+// transactions, so their slot can be used for transactions entering the pool
+// at the passed height.
+func (s *txConfirmStats) clearCurrent(height int32) {
+	current := s.unconfTxs[s.unconfIndex(height)]
+	for i := range current {
+		s.oldUnconfTxs[i] += current[i]
+		current[i] = 0
+	}
+}
+
+// updateMovingAverages applies the decay to all averages once a new block is
+// processed.
+func (s *txConfirmStats) updateMovingAverages() {
+	for i := range s.buckets {
+		for j := range s.confAvg {
+			s.confAvg[j][i] *= s.decay
+			s.failAvg[j][i] *= s.decay
+		}
+		s.txCtAvg[i] *= s.decay
+		s.feeRateAvg[i] *= s.decay
+	}
+}
+
+// record records a transaction of the passed bucket and fee rate which was
+// confirmed blocksToConfirm blocks after entering the pool.
+func (s *txConfirmStats) record(blocksToConfirm int32, bucket int,
+	feeRate float64) {
+
+	if blocksToConfirm < 1 {
+		return
+	}
+
+	periodsToConfirm := int((blocksToConfirm + s.scale - 1) / s.scale)
+	for i := periodsToConfirm; i <= len(s.confAvg); i++ {
+		s.confAvg[i-1][bucket]++
+	}
+	s.txCtAvg[bucket]++
+	s.feeRateAvg[bucket] += feeRate
+}
+
+// newTx records an unconfirmed transaction of the passed bucket which entered
+// the pool at the passed height.
+func (s *txConfirmStats) newTx(height int32, bucket int) {
+	s.unconfTxs[s.unconfIndex(height)][bucket]++
+}
+
+// removeTx removes an unconfirmed transaction of the passed bucket which
+// entered the pool at entryHeight.  A transaction which is removed without
+// being confirmed is recorded as failed for all periods that passed since it
+// entered the pool.
+func (s *txConfirmStats) removeTx(entryHeight, bestHeight int32, bucket int,
+	inBlock bool) {
+
+	blocksAgo := bestHeight - entryHeight
+	if blocksAgo < 0 {
+		return
+	}
+
+	if blocksAgo >= int32(len(s.unconfTxs)) {
+		if s.oldUnconfTxs[bucket] > 0 {
+			s.oldUnconfTxs[bucket]--
+		}
+	} else {
+		unconf := s.unconfTxs[s.unconfIndex(entryHeight)]
+		if unconf[bucket] > 0 {
+			unconf[bucket]--
+		}
+	}
+
+	if !inBlock && blocksAgo >= s.scale {
+		periodsAgo := int(blocksAgo / s.scale)
+		for i := 0; i < periodsAgo && i < len(s.failAvg); i++ {
+			s.failAvg[i][bucket]++
+		}
+	}
+}
+
+// estimateMedianVal returns the median fee rate of the lowest range of
+// buckets in which at least successBreakPoint of the transactions were
+// confirmed within confTarget blocks, or -1 if there is no such range.
+//
+// The buckets are grouped into ranges from the highest fee rate down, such
+// that each range contains at least sufficientTxVal transactions per block on
+// average.  Transactions which are still unconfirmed after confTarget blocks
+// or which failed to confirm count against the range.
+func (s *txConfirmStats) estimateMedianVal(confTarget int32,
+	sufficientTxVal, successBreakPoint float64, height int32) float64 {
+
+	var nConf, totalNum, failNum float64
+	var extraNum int
+
+	periodTarget := int((confTarget + s.scale - 1) / s.scale)
+	maxBucket := len(s.buckets) - 1
+	curNearBucket, curFarBucket := maxBucket, maxBucket
+	bestNearBucket, bestFarBucket := maxBucket, maxBucket
+
+	foundAnswer := false
+	newBucketRange := true
+	for bucket := maxBucket; bucket >= 0; bucket-- {
+		if newBucketRange {
+			curNearBucket = bucket
+			newBucketRange = false
+		}
+		curFarBucket = bucket
+
+		nConf += s.confAvg[periodTarget-1][bucket]
+		totalNum += s.txCtAvg[bucket]
+		failNum += s.failAvg[periodTarget-1][bucket]
+		for confct := confTarget; confct < s.maxConfirms(); confct++ {
+			idx := s.unconfIndex(height - confct)
+			extraNum += s.unconfTxs[idx][bucket]
+		}
+		extraNum += s.oldUnconfTxs[bucket]
+
+		// Keep adding buckets to the range until it contains enough
+		// transactions to be meaningful.
+		if totalNum < sufficientTxVal/(1-s.decay) {
+			continue
+		}
+
+		// Keep extending a failing range, which may pass once it
+		// contains more buckets with lower fee rates.
+		curPct := nConf / (totalNum + failNum + float64(extraNum))
+		if curPct < successBreakPoint {
+			continue
+		}
+
+		// The range passes, so start a new range with the next bucket.
+		foundAnswer = true
+		nConf, totalNum, failNum, extraNum = 0, 0, 0, 0
+		bestNearBucket, bestFarBucket = curNearBucket, curFarBucket
+		newBucketRange = true
+	}
+	if !foundAnswer {
+		return -1
+	}
+
+	// Find the bucket containing the median transaction of the passing
+	// range with the lowest fee rates and return its average fee rate.
+	minBucket, maxBucket := bestFarBucket, bestNearBucket
+	var txSum float64
+	for i := minBucket; i <= maxBucket; i++ {
+		txSum += s.txCtAvg[i]
+	}
+	if txSum == 0 {
+		return -1
+	}
+	txSum /= 2
+	for i := minBucket; i <= maxBucket; i++ {
+		if s.txCtAvg[i] < txSum {
+			txSum -= s.txCtAvg[i]
+			continue
+		}
+		return s.feeRateAvg[i] / s.txCtAvg[i]
+	}
+
+	return -1
+}
+
+// serialize writes the decaying averages of the statistics to w.  The
+// unconfirmed transactions are not written since they can't be matched to the
+// transactions in the pool once restored.
+func (s *txConfirmStats) serialize(w io.Writer) error {
+	err := binary.Write(w, binary.BigEndian, uint32(len(s.confAvg)))
+	if err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, s.txCtAvg); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, s.feeRateAvg); err != nil {
+		return err
+	}
+	for i := range s.confAvg {
+		err := binary.Write(w, binary.BigEndian, s.confAvg[i])
+		if err != nil {
+			return err
+		}
+		err = binary.Write(w, binary.BigEndian, s.failAvg[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deserialize reads the decaying averages written by serialize from r.
+func (s *txConfirmStats) deserialize(r io.Reader) error {
+	var periods uint32
+	if err := binary.Read(r, binary.BigEndian, &periods); err != nil {
+		return err
+	}
+	if periods != uint32(len(s.confAvg)) {
+		return fmt.Errorf("unexpected number of periods: expected %d "+
+			"found %d", len(s.confAvg), periods)
+	}
+	if err := binary.Read(r, binary.BigEndian, s.txCtAvg); err != nil {
+		return err
+	}
+	if err := binary.Read(r, binary.BigEndian, s.feeRateAvg); err != nil {
+		return err
+	}
+	for i := range s.confAvg {
+		err := binary.Read(r, binary.BigEndian, s.confAvg[i])
+		if err != nil {
+			return err
+		}
+		err = binary.Read(r, binary.BigEndian, s.failAvg[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// trackedTx houses the data the policy estimator tracks for an unconfirmed
+// transaction.
+type trackedTx struct {
+	height  int32
+	bucket  int
+	feeRate float64
+}
+
+// policyEstimator estimates fee rates from how many blocks it took for the
+// transactions observed in the pool to be confirmed depending on their fee
+// rate.  The confirmations are tracked in exponentially decaying fee rate
+// buckets over a short, a medium and a long horizon, so estimates respond
+// quickly to changes while estimates for higher targets still have enough
+// data to be meaningful.
+//
+// The policy estimator is not safe for concurrent access.  It is protected by
+// the mutex of the FeeEstimator it belongs to.
+type policyEstimator struct {
+	buckets []float64
+
+	shortStats *txConfirmStats
+	feeStats   *txConfirmStats
+	longStats  *txConfirmStats
+
+	// tracked are the unconfirmed transactions which are tracked.
+	tracked map[chainhash.Hash]trackedTx
+
+	// bestSeenHeight is the height of the last processed block and
+	// firstRecordedHeight is the height of the first block which confirmed
+	// a tracked transaction.
+	bestSeenHeight      int32
+	firstRecordedHeight int32
+}
+
+// newPolicyEstimator returns a policy estimator without any data.
+func newPolicyEstimator() *policyEstimator {
+	var buckets []float64
+	for rate := float64(minBucketFeeRate); rate <= maxBucketFeeRate; rate *= feeSpacing {
+		buckets = append(buckets, rate)
+	}
+	buckets = append(buckets, math.Inf(1))
+
+	return &policyEstimator{
+		buckets: buckets,
+		shortStats: newTxConfirmStats(buckets, shortBlockPeriods,
+			shortScale, shortDecay),
+		feeStats: newTxConfirmStats(buckets, medBlockPeriods, medScale,
+			medDecay),
+		longStats: newTxConfirmStats(buckets, longBlockPeriods,
+			longScale, longDecay),
+		tracked: make(map[chainhash.Hash]trackedTx),
+	}
+}
+
+// allStats returns the statistics of all horizons.
+func (pe *policyEstimator) allStats() []*txConfirmStats {
+	return []*txConfirmStats{pe.shortStats, pe.feeStats, pe.longStats}
+}
+
+// processTransaction starts tracking the passed transaction which entered the
+// pool at the passed height.  Only transactions entering the pool at the
+// height of the last processed block are tracked, since the number of blocks
+// it takes to confirm them is unknown otherwise.
+func (pe *policyEstimator) processTransaction(hash *chainhash.Hash,
+	height int32, feeRate SatoshiPerByte) {
+
+	if _, ok := pe.tracked[*hash]; ok {
+		return
+	}
+	if height != pe.bestSeenHeight {
+		return
+	}
+
+	bucket := sort.SearchFloat64s(pe.buckets, float64(feeRate))
+	for _, stats := range pe.allStats() {
+		stats.newTx(height, bucket)
+	}
+	pe.tracked[*hash] = trackedTx{
+		height:  height,
+		bucket:  bucket,
+		feeRate: float64(feeRate),
+	}
+}
+
+// removeTransaction stops tracking the passed transaction.  A transaction
+// which is not removed due to being confirmed is recorded as failed.
+func (pe *policyEstimator) removeTransaction(hash *chainhash.Hash,
+	inBlock bool) (trackedTx, bool) {
+
+	tx, ok := pe.tracked[*hash]
+	if !ok {
+		return tx, false
+	}
+
+	for _, stats := range pe.allStats() {
+		stats.removeTx(tx.height, pe.bestSeenHeight, tx.bucket, inBlock)
+	}
+	delete(pe.tracked, *hash)
+
+	return tx, true
+}
+
+// processBlock records the confirmation of the tracked transactions among the
+// passed transactions of the block at the passed height.  Blocks at or below
+// the height of the last processed block are ignored, since the decay applied
+// for a block can't be reverted.
+func (pe *policyEstimator) processBlock(height int32, txns []*btcutil.Tx) {
+	if height <= pe.bestSeenHeight {
+		return
+	}
+	pe.bestSeenHeight = height
+
+	for _, stats := range pe.allStats() {
+		stats.clearCurrent(height)
+		stats.updateMovingAverages()
+	}
+
+	var counted int
+	for _, tx := range txns {
+		tracked, ok := pe.removeTransaction(tx.Hash(), true)
+		if !ok {
+			continue
+		}
+
+		blocksToConfirm := height - tracked.height
+		if blocksToConfirm <= 0 {
+			continue
+		}
+		for _, stats := range pe.allStats() {
+			stats.record(blocksToConfirm, tracked.bucket,
+				tracked.feeRate)
+		}
+		counted++
+	}
+
+	// Transactions which are still unconfirmed after the highest target
+	// that is tracked are recorded as failed.  This also ensures
+	// transactions which were removed from the pool for other reasons are
+	// not tracked forever.
+	maxConfirms := pe.longStats.maxConfirms()
+	for hash, tx := range pe.tracked {
+		if height-tx.height >= maxConfirms {
+			pe.removeTransaction(&hash, false)
+		}
+	}
+
+	if counted > 0 && pe.firstRecordedHeight == 0 {
+		pe.firstRecordedHeight = height
+	}
+}
+
+// maxUsableEstimate returns the highest confirmation target for which enough
+// blocks were processed to provide estimates.
+func (pe *policyEstimator) maxUsableEstimate() int32 {
+	var blockSpan int32
+	if pe.firstRecordedHeight != 0 {
+		blockSpan = pe.bestSeenHeight - pe.firstRecordedHeight
+	}
+
+	maxUsable := blockSpan / 2
+	if maxConfirms := pe.longStats.maxConfirms(); maxUsable > maxConfirms {
+		maxUsable = maxConfirms
+	}
+	return maxUsable
+}
+
+// estimateCombinedFee returns the fee rate at which successThreshold of the
+// transactions were confirmed within confTarget blocks using the horizon with
+// the shortest history which covers the target.  When checkShorterHorizon is
+// set, a lower estimate for the highest target of a shorter horizon is
+// returned instead if there is one.  It returns -1 if there is no estimate.
+func (pe *policyEstimator) estimateCombinedFee(confTarget int32,
+	successThreshold float64, checkShorterHorizon bool) float64 {
+
+	if confTarget < 1 || confTarget > pe.longStats.maxConfirms() {
+		return -1
+	}
+
+	var estimate float64
+	switch {
+	case confTarget <= pe.shortStats.maxConfirms():
+		estimate = pe.shortStats.estimateMedianVal(confTarget,
+			sufficientTxsShort, successThreshold, pe.bestSeenHeight)
+
+	case confTarget <= pe.feeStats.maxConfirms():
+		estimate = pe.feeStats.estimateMedianVal(confTarget,
+			sufficientFeeTxs, successThreshold, pe.bestSeenHeight)
+
+	default:
+		estimate = pe.longStats.estimateMedianVal(confTarget,
+			sufficientFeeTxs, successThreshold, pe.bestSeenHeight)
+	}
+
+	if !checkShorterHorizon {
+		return estimate
+	}
+
+	// A lower fee rate which was sufficient for a lower target is also
+	// sufficient for a higher one.
+	if confTarget > pe.feeStats.maxConfirms() {
+		medMax := pe.feeStats.estimateMedianVal(
+			pe.feeStats.maxConfirms(), sufficientFeeTxs,
+			successThreshold, pe.bestSeenHeight,
+		)
+		if medMax > 0 && (estimate == -1 || medMax < estimate) {
+			estimate = medMax
+		}
+	}
+	if confTarget > pe.shortStats.maxConfirms() {
+		shortMax := pe.shortStats.estimateMedianVal(
+			pe.shortStats.maxConfirms(), sufficientTxsShort,
+			successThreshold, pe.bestSeenHeight,
+		)
+		if shortMax > 0 && (estimate == -1 || shortMax < estimate) {
+			estimate = shortMax
+		}
+	}
+
+	return estimate
+}
+
+// estimateConservativeFee returns the highest fee rate at which
+// doubleSuccessPct of the transactions were confirmed within doubleTarget
+// blocks in the medium and the long horizon.  It returns -1 if there is no
+// estimate.
+func (pe *policyEstimator) estimateConservativeFee(doubleTarget int32) float64 {
+	estimate := float64(-1)
+	if doubleTarget <= pe.shortStats.maxConfirms() {
+		estimate = pe.feeStats.estimateMedianVal(doubleTarget,
+			sufficientFeeTxs, doubleSuccessPct, pe.bestSeenHeight)
+	}
+	if doubleTarget <= pe.feeStats.maxConfirms() {
+		longEstimate := pe.longStats.estimateMedianVal(doubleTarget,
+			sufficientFeeTxs, doubleSuccessPct, pe.bestSeenHeight)
+		if longEstimate > estimate {
+			estimate = longEstimate
+		}
+	}
+
+	return estimate
+}
+
+// estimateSmartFee returns the fee rate in satoshi per byte a transaction
+// needs to be confirmed within confTarget blocks along with the target the
+// estimate is for, which is lower than the requested target if not enough
+// blocks were processed for it.  The fee rate is -1 if there is no estimate.
+func (pe *policyEstimator) estimateSmartFee(confTarget int32,
+	mode EstimateMode) (float64, int32) {
+
+	// Estimates for the next block are unreliable, since whether the
+	// transaction is confirmed depends on whether it reaches the miner of
+	// the block in time.
+	if confTarget == 1 {
+		confTarget = 2
+	}
+	if maxUsable := pe.maxUsableEstimate(); confTarget > maxUsable {
+		confTarget = maxUsable
+	}
+	if confTarget <= 1 {
+		return -1, confTarget
+	}
+
+	// The estimate must be sufficient for half the target with a lower
+	// threshold, for the target and for double the target with a higher
+	// threshold.
+	median := pe.estimateCombinedFee(confTarget/2, halfSuccessPct, true)
+	actual := pe.estimateCombinedFee(confTarget, successPct, true)
+	if actual > median {
+		median = actual
+	}
+	doubleTarget := 2 * confTarget
+	if maxConfirms := pe.longStats.maxConfirms(); doubleTarget > maxConfirms {
+		doubleTarget = maxConfirms
+	}
+	conservative := mode == EstimateConservative
+	double := pe.estimateCombinedFee(doubleTarget, doubleSuccessPct,
+		!conservative)
+	if double > median {
+		median = double
+	}
+
+	if conservative || median == -1 {
+		consEstimate := pe.estimateConservativeFee(2 * confTarget)
+		if consEstimate > median {
+			median = consEstimate
+		}
+	}
+
+	if median < 0 {
+		return -1, confTarget
+	}
+	return median, confTarget
+}
+
+// serialize writes the state of the policy estimator to w.
+func (pe *policyEstimator) serialize(w io.Writer) error {
+	err := binary.Write(w, binary.BigEndian, pe.bestSeenHeight)
+	if err != nil {
+		return err
+	}
+	err = binary.Write(w, binary.BigEndian, pe.firstRecordedHeight)
+	if err != nil {
+		return err
+	}
+	err = binary.Write(w, binary.BigEndian, uint32(len(pe.buckets)))
+	if err != nil {
+		return err
+	}
+	for _, stats := range pe.allStats() {
+		if err := stats.serialize(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deserializePolicyEstimator restores a policy estimator written by serialize
+// from r.
+func deserializePolicyEstimator(r io.Reader) (*policyEstimator, error) {
+	pe := newPolicyEstimator()
+
+	err := binary.Read(r, binary.BigEndian, &pe.bestSeenHeight)
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Read(r, binary.BigEndian, &pe.firstRecordedHeight)
+	if err != nil {
+		return nil, err
+	}
+	var numBuckets uint32
+	if err := binary.Read(r, binary.BigEndian, &numBuckets); err != nil {
+		return nil, err
+	}
+	if numBuckets != uint32(len(pe.buckets)) {
+		return nil, fmt.Errorf("unexpected number of fee rate buckets: "+
+			"expected %d found %d", len(pe.buckets), numBuckets)
+	}
+	for _, stats := range pe.allStats() {
+		if err := stats.deserialize(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return pe, nil
+}
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"container/heap"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/btcsuite/btcd/blockchain"
@@ -72,115 +73,373 @@ type TxSource interface {
 	HaveTransaction(hash *chainhash.Hash) bool
 }
 
-// txPrioItem houses a transaction along with extra information that allows the
-// transaction to be prioritized and track dependencies on other transactions
-// which have not been mined into a block yet.
-type txPrioItem struct {
-	tx       *btcutil.Tx
-	fee      int64
-	priority float64
-	feePerKB int64
-
-	// dependsOn holds a map of transaction hashes which this one depends
-	// on.  It will only be set when the transaction references other
-	// transactions in the source pool and hence must come after them in
-	// a block.
-	dependsOn map[chainhash.Hash]struct{}
+// txPackageItem houses a transaction which is a candidate for inclusion in a
+// block template along with the data required to select it by the fee rate of
+// its package, which consists of the transaction and its ancestors in the
+// source pool that have not been included in the block yet.
+type txPackageItem struct {
+	tx        *btcutil.Tx
+	fee       int64
+	weight    int64
+	sigOpCost int64
+
+	// utxos contains the outputs spent by the transaction, including the
+	// outputs of its parents in the source pool.
+	utxos *blockchain.UtxoViewpoint
+
+	// parents and children are the candidate transactions in the source
+	// pool the transaction spends outputs of and the ones spending its
+	// outputs, respectively.
+	parents  map[chainhash.Hash]*txPackageItem
+	children map[chainhash.Hash]*txPackageItem
+
+	// numAncestors is the total number of ancestors of the transaction in
+	// the source pool.  Since every ancestor of a transaction has fewer
+	// ancestors than the transaction itself, it is used to sort the
+	// transactions of a package such that parents come before children.
+	numAncestors int
+
+	// ancestorFee, ancestorWeight and ancestorSigOpCost are the totals of
+	// the transaction and its ancestors which have not been included in
+	// the block yet.
+	ancestorFee       int64
+	ancestorWeight    int64
+	ancestorSigOpCost int64
+
+	// generation is incremented whenever the ancestor totals change, so
+	// outdated entries in the package heap can be detected.
+	generation int
+
+	// validated, included and failed track whether the scripts of the
+	// transaction were validated, whether it was included in the block and
+	// whether it can't be included at all, respectively.
+	validated bool
+	included  bool
+	failed    bool
 }
 
-// txPriorityQueueLessFunc describes a function that can be used as a compare
-// function for a transaction priority queue (txPriorityQueue).
-type txPriorityQueueLessFunc func(*txPriorityQueue, int, int) bool
+// ancestorFeePerKB returns the fee rate of the package of the transaction in
+// Satoshi per 1000 virtual bytes.
+func (item *txPackageItem) ancestorFeePerKB() int64 {
+	vsize := (item.ancestorWeight + blockchain.WitnessScaleFactor - 1) /
+		blockchain.WitnessScaleFactor
+	return item.ancestorFee * 1000 / vsize
+}
 
-// txPriorityQueue implements a priority queue of txPrioItem elements that
-// supports an arbitrary compare function as defined by txPriorityQueueLessFunc.
-type txPriorityQueue struct {
-	lessFunc txPriorityQueueLessFunc
-	items    []*txPrioItem
+// txPackageEntry is an entry of a txPackageHeap.  It records the package fee
+// rate of the item at the time it was pushed.  The entry is outdated once the
+// generation of the item changed.
+type txPackageEntry struct {
+	item       *txPackageItem
+	feeRate    float64
+	generation int
 }
 
-// Len returns the number of items in the priority queue.  It is part of the
+// txPackageHeap implements a priority queue of txPackageEntry elements which
+// returns the entry with the highest package fee rate first.  Entries with the
+// same fee rate are ordered by transaction hash, so the selection is
+// deterministic.
+type txPackageHeap []txPackageEntry
+
+// Len returns the number of entries in the heap.  It is part of the
 // heap.Interface implementation.
-func (pq *txPriorityQueue) Len() int {
-	return len(pq.items)
+func (h txPackageHeap) Len() int {
+	return len(h)
 }
 
-// Less returns whether the item in the priority queue with index i should sort
-// before the item with index j by deferring to the assigned less function.  It
-// is part of the heap.Interface implementation.
-func (pq *txPriorityQueue) Less(i, j int) bool {
-	return pq.lessFunc(pq, i, j)
+// Less returns whether the entry with index i should sort before the entry
+// with index j.  It is part of the heap.Interface implementation.
+func (h txPackageHeap) Less(i, j int) bool {
+	if h[i].feeRate != h[j].feeRate {
+		return h[i].feeRate > h[j].feeRate
+	}
+	return bytes.Compare(h[i].item.tx.Hash()[:], h[j].item.tx.Hash()[:]) < 0
 }
 
-// Swap swaps the items at the passed indices in the priority queue.  It is
-// part of the heap.Interface implementation.
-func (pq *txPriorityQueue) Swap(i, j int) {
-	pq.items[i], pq.items[j] = pq.items[j], pq.items[i]
+// Swap swaps the entries at the passed indices.  It is part of the
+// heap.Interface implementation.
+func (h txPackageHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
 }
 
-// Push pushes the passed item onto the priority queue.  It is part of the
+// Push pushes the passed entry onto the heap.  It is part of the
 // heap.Interface implementation.
-func (pq *txPriorityQueue) Push(x interface{}) {
-	pq.items = append(pq.items, x.(*txPrioItem))
+func (h *txPackageHeap) Push(x interface{}) {
+	*h = append(*h, x.(txPackageEntry))
+}
+
+// Pop removes the entry with the highest package fee rate from the heap and
+// returns it.  It is part of the heap.Interface implementation.
+func (h *txPackageHeap) Pop() interface{} {
+	n := len(*h)
+	entry := (*h)[n-1]
+	(*h)[n-1] = txPackageEntry{}
+	*h = (*h)[0 : n-1]
+	return entry
 }
 
-// Pop removes the highest priority item (according to Less) from the priority
-// queue and returns it.  It is part of the heap.Interface implementation.
-func (pq *txPriorityQueue) Pop() interface{} {
-	n := len(pq.items)
-	item := pq.items[n-1]
-	pq.items[n-1] = nil
-	pq.items = pq.items[0 : n-1]
-	return item
+// pushItem pushes an entry for the passed item with its current package fee
+// rate onto the heap.
+func (h *txPackageHeap) pushItem(item *txPackageItem) {
+	heap.Push(h, txPackageEntry{
+		item:       item,
+		feeRate:    float64(item.ancestorFee) / float64(item.ancestorWeight),
+		generation: item.generation,
+	})
 }
 
-// SetLessFunc sets the compare function for the priority queue to the provided
-// function.  It also invokes heap.Init on the priority queue using the new
-// function so it can immediately be used with heap.Push/Pop.
-func (pq *txPriorityQueue) SetLessFunc(lessFunc txPriorityQueueLessFunc) {
-	pq.lessFunc = lessFunc
-	heap.Init(pq)
+// txPackageSelector selects the transactions of a block template by the fee
+// rate of their packages.  Since a transaction is selected along with its
+// ancestors, a child paying a high fee gets its parents paying a low fee
+// included (child pays for parent).
+type txPackageSelector struct {
+	policy *Policy
+
+	// blockWeight and blockSigOpCost are the weight and the signature
+	// operation cost of the block with the transactions selected so far.
+	blockWeight    int64
+	blockSigOpCost int64
+
+	// witnessCommitmentWeight is the weight the witness commitment adds to
+	// the coinbase transaction once a transaction with witness data is
+	// selected.
+	witnessCommitmentWeight int64
+	witnessIncluded         bool
+
+	// validate validates the scripts of the transaction of the passed item.
+	validate func(item *txPackageItem) error
+
+	// selected are the selected transactions in the order they must appear
+	// in the block and totalFees is the sum of their fees.
+	selected  []*txPackageItem
+	totalFees int64
 }
 
-// txPQByPriority sorts a txPriorityQueue by transaction priority and then fees
-// per kilobyte.
-func txPQByPriority(pq *txPriorityQueue, i, j int) bool {
-	// Using > here so that pop gives the highest priority item as opposed
-	// to the lowest.  Sort by priority first, then fee.
-	if pq.items[i].priority == pq.items[j].priority {
-		return pq.items[i].feePerKB > pq.items[j].feePerKB
+// markFailed marks the passed item and all of its descendants as failed, so
+// they are not selected.
+func (s *txPackageSelector) markFailed(item *txPackageItem) {
+	if item.failed {
+		return
 	}
-	return pq.items[i].priority > pq.items[j].priority
+	item.failed = true
+	for _, child := range item.children {
+		log.Tracef("Skipping tx %s since it depends on %s",
+			child.tx.Hash(), item.tx.Hash())
+		s.markFailed(child)
+	}
+}
 
+// packageOf returns the transactions of the package of the passed item, which
+// are the item and its ancestors which have not been included yet, sorted such
+// that parents come before children.
+func (s *txPackageSelector) packageOf(item *txPackageItem) []*txPackageItem {
+	members := make(map[chainhash.Hash]*txPackageItem)
+	var collect func(item *txPackageItem)
+	collect = func(item *txPackageItem) {
+		if item.included {
+			return
+		}
+		if _, ok := members[*item.tx.Hash()]; ok {
+			return
+		}
+		members[*item.tx.Hash()] = item
+		for _, parent := range item.parents {
+			collect(parent)
+		}
+	}
+	collect(item)
+
+	pkg := make([]*txPackageItem, 0, len(members))
+	for _, member := range members {
+		pkg = append(pkg, member)
+	}
+	sort.Slice(pkg, func(i, j int) bool {
+		if pkg[i].numAncestors != pkg[j].numAncestors {
+			return pkg[i].numAncestors < pkg[j].numAncestors
+		}
+		return bytes.Compare(pkg[i].tx.Hash()[:], pkg[j].tx.Hash()[:]) < 0
+	})
+	return pkg
 }
 
-// txPQByFee sorts a txPriorityQueue by fees per kilobyte and then transaction
-// priority.
-func txPQByFee(pq *txPriorityQueue, i, j int) bool {
-	// Using > here so that pop gives the highest fee item as opposed
-	// to the lowest.  Sort by fee first, then priority.
-	if pq.items[i].feePerKB == pq.items[j].feePerKB {
-		return pq.items[i].priority > pq.items[j].priority
+// initAncestors calculates the number of ancestors and the ancestor totals of
+// the passed items, none of which may have been included yet.
+func initAncestors(items []*txPackageItem) {
+	for _, item := range items {
+		ancestors := make(map[chainhash.Hash]*txPackageItem)
+		var collect func(item *txPackageItem)
+		collect = func(item *txPackageItem) {
+			for hash, parent := range item.parents {
+				if _, ok := ancestors[hash]; ok {
+					continue
+				}
+				ancestors[hash] = parent
+				collect(parent)
+			}
+		}
+		collect(item)
+
+		item.numAncestors = len(ancestors)
+		item.ancestorFee = item.fee
+		item.ancestorWeight = item.weight
+		item.ancestorSigOpCost = item.sigOpCost
+		for _, ancestor := range ancestors {
+			item.ancestorFee += ancestor.fee
+			item.ancestorWeight += ancestor.weight
+			item.ancestorSigOpCost += ancestor.sigOpCost
+		}
 	}
-	return pq.items[i].feePerKB > pq.items[j].feePerKB
 }
 
-// newTxPriorityQueue returns a new transaction priority queue that reserves the
-// passed amount of space for the elements.  The new priority queue uses either
-// the txPQByPriority or the txPQByFee compare function depending on the
-// sortByFee parameter and is already initialized for use with heap.Push/Pop.
-// The priority queue can grow larger than the reserved space, but extra copies
-// of the underlying array can be avoided by reserving a sane value.
-func newTxPriorityQueue(reserve int, sortByFee bool) *txPriorityQueue {
-	pq := &txPriorityQueue{
-		items: make([]*txPrioItem, 0, reserve),
+// include adds the passed package to the selected transactions and updates the
+// ancestor totals of the descendants of its transactions.  The updated
+// descendants are pushed onto the passed heap with their new package fee rate.
+func (s *txPackageSelector) include(pkg []*txPackageItem, hasWitness bool,
+	h *txPackageHeap) {
+
+	for _, item := range pkg {
+		item.included = true
+		s.selected = append(s.selected, item)
+		s.blockWeight += item.weight
+		s.blockSigOpCost += item.sigOpCost
+		s.totalFees += item.fee
+
+		log.Tracef("Adding tx %s (fee %d, ancestor feePerKB %d)",
+			item.tx.Hash(), item.fee, item.ancestorFeePerKB())
 	}
-	if sortByFee {
-		pq.SetLessFunc(txPQByFee)
-	} else {
-		pq.SetLessFunc(txPQByPriority)
+	if hasWitness && !s.witnessIncluded {
+		s.blockWeight += s.witnessCommitmentWeight
+		s.witnessIncluded = true
+	}
+
+	// The included transactions are no longer part of the packages of
+	// their descendants.
+	modified := make(map[chainhash.Hash]*txPackageItem)
+	for _, item := range pkg {
+		visited := make(map[chainhash.Hash]struct{})
+		var update func(item *txPackageItem)
+		update = func(ancestor *txPackageItem) {
+			for hash, child := range ancestor.children {
+				if _, ok := visited[hash]; ok {
+					continue
+				}
+				visited[hash] = struct{}{}
+
+				// Descendants of included children are walked
+				// as well since the item is their ancestor too,
+				// but the included children themselves are no
+				// longer part of any package.
+				if !child.included {
+					child.ancestorFee -= item.fee
+					child.ancestorWeight -= item.weight
+					child.ancestorSigOpCost -= item.sigOpCost
+					modified[hash] = child
+				}
+				update(child)
+			}
+		}
+		update(item)
+	}
+	for _, item := range modified {
+		item.generation++
+		if !item.failed {
+			h.pushItem(item)
+		}
+	}
+}
+
+// selectPackages selects transactions from the passed items by the fee rate of
+// their packages until no more packages fit into the block.
+//
+// Packages which would cause the block to exceed the BlockMaxWeight policy
+// setting or the maximum allowed signature operations per block are skipped.
+// Packages with a fee rate below the TxMinFreeFee policy setting are skipped
+// as well, unless the block is smaller than the BlockMinWeight policy setting.
+// Transactions which fail validation are skipped along with all of their
+// descendants.
+func (s *txPackageSelector) selectPackages(items []*txPackageItem) {
+	h := make(txPackageHeap, 0, len(items))
+	for _, item := range items {
+		if !item.failed {
+			h.pushItem(item)
+		}
+	}
+
+	for h.Len() > 0 {
+		entry := heap.Pop(&h).(txPackageEntry)
+		item := entry.item
+		if item.included || item.failed ||
+			entry.generation != item.generation {
+
+			continue
+		}
+
+		pkg := s.packageOf(item)
+		pkgWeight := item.ancestorWeight
+		hasWitness := false
+		for _, pkgItem := range pkg {
+			if pkgItem.tx.HasWitness() {
+				hasWitness = true
+				break
+			}
+		}
+		if hasWitness && !s.witnessIncluded {
+			pkgWeight += s.witnessCommitmentWeight
+		}
+
+		// Enforce maximum block weight and signature operation cost.
+		blockPlusPkgWeight := s.blockWeight + pkgWeight
+		if blockPlusPkgWeight >= int64(s.policy.BlockMaxWeight) {
+			log.Tracef("Skipping tx %s because its package would "+
+				"exceed the max block weight", item.tx.Hash())
+			continue
+		}
+		if s.blockSigOpCost+item.ancestorSigOpCost >
+			blockchain.MaxBlockSigOpsCost {
+
+			log.Tracef("Skipping tx %s because its package would "+
+				"exceed the maximum sigops per block",
+				item.tx.Hash())
+			continue
+		}
+
+		// Skip low-fee packages once the block is larger than the
+		// minimum block weight.
+		feePerKB := item.ancestorFeePerKB()
+		if feePerKB < int64(s.policy.TxMinFreeFee) &&
+			blockPlusPkgWeight >= int64(s.policy.BlockMinWeight) {
+
+			log.Tracef("Skipping tx %s with ancestor feePerKB %d "+
+				"< TxMinFreeFee %d and block weight %d >= "+
+				"minBlockWeight %d", item.tx.Hash(), feePerKB,
+				s.policy.TxMinFreeFee, blockPlusPkgWeight,
+				s.policy.BlockMinWeight)
+			continue
+		}
+
+		// Ensure all transactions of the package are valid before any
+		// of them is included.
+		valid := true
+		for _, pkgItem := range pkg {
+			if pkgItem.validated {
+				continue
+			}
+			if err := s.validate(pkgItem); err != nil {
+				log.Tracef("Skipping tx %s due to error in "+
+					"ValidateTransactionScripts: %v",
+					pkgItem.tx.Hash(), err)
+				s.markFailed(pkgItem)
+				valid = false
+				break
+			}
+			pkgItem.validated = true
+		}
+		if !valid {
+			continue
+		}
+
+		s.include(pkg, hasWitness, &h)
 	}
-	return pq
 }
 
 // BlockTemplate houses a block that has yet to be solved along with additional
@@ -219,21 +478,6 @@ type BlockTemplate struct {
 	WitnessCommitment []byte
 }
 
-// mergeUtxoView adds all of the entries in viewB to viewA.  The result is that
-// viewA will contain all of its original entries plus all of the entries
-// in viewB.  It will replace any entries in viewB which also exist in viewA
-// if the entry in viewA is spent.
-func mergeUtxoView(viewA *blockchain.UtxoViewpoint, viewB *blockchain.UtxoViewpoint) {
-	viewAEntries := viewA.Entries()
-	for outpoint, entryB := range viewB.Entries() {
-		if entryA, exists := viewAEntries[outpoint]; !exists ||
-			entryA == nil || entryA.IsSpent() {
-
-			viewAEntries[outpoint] = entryB
-		}
-	}
-}
-
 // standardCoinbaseScript returns a standard script suitable for use as the
 // signature script of the coinbase transaction of a new block.  In particular,
 // it starts with the block height that is required by version 2 blocks and adds
@@ -286,34 +530,6 @@ func createCoinbaseTx(params *chaincfg.Params, coinbaseScript []byte, nextBlockH
 	return btcutil.NewTx(tx), nil
 }
 
-// spendTransaction updates the passed view by marking the inputs to the passed
-// transaction as spent.  It also adds all outputs in the passed transaction
-// which are not provably unspendable as available unspent transaction outputs.
-func spendTransaction(utxoView *blockchain.UtxoViewpoint, tx *btcutil.Tx, height int32) error {
-	for _, txIn := range tx.MsgTx().TxIn {
-		entry := utxoView.LookupEntry(txIn.PreviousOutPoint)
-		if entry != nil {
-			entry.Spend()
-		}
-	}
-
-	utxoView.AddTxOuts(tx, height)
-	return nil
-}
-
-// logSkippedDeps logs any dependencies which are also skipped as a result of
-// skipping a transaction while generating a block template at the trace level.
-func logSkippedDeps(tx *btcutil.Tx, deps map[chainhash.Hash]*txPrioItem) {
-	if deps == nil {
-		return
-	}
-
-	for _, item := range deps {
-		log.Tracef("Skipping tx %s since it depends on %s\n",
-			item.tx.Hash(), tx.Hash())
-	}
-}
-
 // MinimumMedianTime returns the minimum allowed timestamp for a block building
 // on the end of the provided best chain.  In particular, it is one second after
 // the median timestamp of the last several blocks per the chain consensus
@@ -351,8 +567,9 @@ type BlkTmplGenerator struct {
 	txSource    TxSource
 	chain       *blockchain.BlockChain
 	timeSource  blockchain.MedianTimeSource
-	sigCache    *txscript.SigCache
+	sigCache    txscript.SignatureCache
 	hashCache   *txscript.HashCache
+	notifier    *templateNotifier
 }
 
 // NewBlkTmplGenerator returns a new block template generator for the given
@@ -364,7 +581,7 @@ type BlkTmplGenerator struct {
 func NewBlkTmplGenerator(policy *Policy, params *chaincfg.Params,
 	txSource TxSource, chain *blockchain.BlockChain,
 	timeSource blockchain.MedianTimeSource,
-	sigCache *txscript.SigCache,
+	sigCache txscript.SignatureCache,
 	hashCache *txscript.HashCache) *BlkTmplGenerator {
 
 	return &BlkTmplGenerator{
@@ -375,6 +592,7 @@ func NewBlkTmplGenerator(policy *Policy, params *chaincfg.Params,
 		timeSource:  timeSource,
 		sigCache:    sigCache,
 		hashCache:   hashCache,
+		notifier:    newTemplateNotifier(),
 	}
 }
 
@@ -387,60 +605,55 @@ func NewBlkTmplGenerator(policy *Policy, params *chaincfg.Params,
 // coinbase which will replace the one generated for the block template.  Thus
 // the need to have configured address can be avoided.
 //
-// The transactions selected and included are prioritized according to several
-// factors.  First, each transaction has a priority calculated based on its
-// value, age of inputs, and size.  Transactions which consist of larger
-// amounts, older inputs, and small sizes have the highest priority.  Second, a
-// fee per kilobyte is calculated for each transaction.  Transactions with a
-// higher fee per kilobyte are preferred.  Finally, the block generation related
-// policy settings are all taken into account.
-//
-// Transactions which only spend outputs from other transactions already in the
-// block chain are immediately added to a priority queue which either
-// prioritizes based on the priority (then fee per kilobyte) or the fee per
-// kilobyte (then priority) depending on whether or not the BlockPrioritySize
-// policy setting allots space for high-priority transactions.  Transactions
-// which spend outputs from other transactions in the source pool are added to a
-// dependency map so they can be added to the priority queue once the
-// transactions they depend on have been included.
+// The transactions are selected by the fee rate of their package, which
+// consists of the transaction and its unconfirmed ancestors in the source pool
+// that have not been selected yet.  The fee rate of a package is the total fee
+// of its transactions divided by their total virtual size.  Packages are
+// selected with the highest fee rate first, and a transaction is always
+// selected along with its ancestors, which come before it in the block.  Thus a
+// child transaction paying a high fee gets its parents included even if they
+// pay a low fee on their own (child pays for parent).  Once a package is
+// selected, the packages of the descendants of its transactions no longer
+// contain them, so their fee rates are updated accordingly.
 //
-// Once the high-priority area (if configured) has been filled with
-// transactions, or the priority falls below what is considered high-priority,
-// the priority queue is updated to prioritize by fees per kilobyte (then
-// priority).
-//
-// When the fees per kilobyte drop below the TxMinFreeFee policy setting, the
-// transaction will be skipped unless the BlockMinSize policy setting is
+// When the fee rate of a package drops below the TxMinFreeFee policy setting,
+// the package will be skipped unless the BlockMinWeight policy setting is
 // nonzero, in which case the block will be filled with the low-fee/free
-// transactions until the block size reaches that minimum size.
+// packages until the block weight reaches that minimum weight.
 //
-// Any transactions which would cause the block to exceed the BlockMaxSize
-// policy setting, exceed the maximum allowed signature operations per block, or
-// otherwise cause the block to be invalid are skipped.
+// Any packages which would cause the block to exceed the BlockMaxWeight policy
+// setting, exceed the maximum allowed signature operations per block, or
+// otherwise cause the block to be invalid are skipped.  Transactions spending
+// taproot outputs don't add to the signature operation cost, since signature
+// operations in tapscripts are limited per input instead.
 //
 // Given the above, a block generated by this function is of the following form:
 //
-//   -----------------------------------  --  --
-//  |      Coinbase Transaction         |   |   |
-//  |-----------------------------------|   |   |
-//  |                                   |   |   | ----- policy.BlockPrioritySize
-//  |   High-priority Transactions      |   |   |
-//  |                                   |   |   |
-//  |-----------------------------------|   | --
-//  |                                   |   |
+//   -----------------------------------  --
+//  |      Coinbase Transaction         |   |
+//  |-----------------------------------|   |
 //  |                                   |   |
-//  |                                   |   |--- policy.BlockMaxSize
-//  |  Transactions prioritized by fee  |   |
+//  |  Packages prioritized by fee rate |   |
 //  |  until <= policy.TxMinFreeFee     |   |
-//  |                                   |   |
-//  |                                   |   |
+//  |                                   |   |--- policy.BlockMaxWeight
 //  |                                   |   |
 //  |-----------------------------------|   |
-//  |  Low-fee/Non high-priority (free) |   |
-//  |  transactions (while block size   |   |
-//  |  <= policy.BlockMinSize)          |   |
+//  |  Low-fee/free packages (while     |   |
+//  |  block weight                     |   |
+//  |  <= policy.BlockMinWeight)        |   |
 //   -----------------------------------  --
 func (g *BlkTmplGenerator) NewBlockTemplate(payToAddress btcutil.Address) (*BlockTemplate, error) {
+	return g.NewReservedBlockTemplate(payToAddress, 0, 0)
+}
+
+// NewReservedBlockTemplate returns a new block template just like
+// NewBlockTemplate, except that it leaves room for the passed additional weight
+// and signature operation cost in the block.  This is useful for callers that
+// replace the coinbase of the template with their own, which may be larger or
+// contain more signature operations than the generated one.
+func (g *BlkTmplGenerator) NewReservedBlockTemplate(payToAddress btcutil.Address,
+	reservedWeight, reservedSigOpCost int64) (*BlockTemplate, error) {
+
 	// Extend the most recently known best block.
 	best := g.chain.BestSnapshot()
 	nextBlockHeight := best.Height + 1
@@ -465,46 +678,25 @@ func (g *BlkTmplGenerator) NewBlockTemplate(payToAddress btcutil.Address) (*Bloc
 	}
 	coinbaseSigOpCost := int64(blockchain.CountSigOps(coinbaseTx)) * blockchain.WitnessScaleFactor
 
-	// Get the current source transactions and create a priority queue to
-	// hold the transactions which are ready for inclusion into a block
-	// along with some priority related and fee metadata.  Reserve the same
-	// number of items that are available for the priority queue.  Also,
-	// choose the initial sort order for the priority queue based on whether
-	// or not there is an area allocated for high-priority transactions.
+	// Query the version bits state to see if segwit has been activated, if
+	// so then this means that we'll include any transactions with witness
+	// data in the mempool, and also add the witness commitment as an
+	// OP_RETURN output in the coinbase transaction.
+	segwitState, err := g.chain.ThresholdState(chaincfg.DeploymentSegwit)
+	if err != nil {
+		return nil, err
+	}
+	segwitActive := segwitState == blockchain.ThresholdActive
+
+	// Get the current source transactions and create a package item for
+	// each of them which may be included in the block.
 	sourceTxns := g.txSource.MiningDescs()
-	sortedByFee := g.policy.BlockPrioritySize == 0
-	priorityQueue := newTxPriorityQueue(len(sourceTxns), sortedByFee)
-
-	// Create a slice to hold the transactions to be included in the
-	// generated block with reserved space.  Also create a utxo view to
-	// house all of the input transactions so multiple lookups can be
-	// avoided.
-	blockTxns := make([]*btcutil.Tx, 0, len(sourceTxns))
-	blockTxns = append(blockTxns, coinbaseTx)
-	blockUtxos := blockchain.NewUtxoViewpoint()
-
-	// dependers is used to track transactions which depend on another
-	// transaction in the source pool.  This, in conjunction with the
-	// dependsOn map kept with each dependent transaction helps quickly
-	// determine which dependent transactions are now eligible for inclusion
-	// in the block once each transaction has been included.
-	dependers := make(map[chainhash.Hash]map[chainhash.Hash]*txPrioItem)
-
-	// Create slices to hold the fees and number of signature operations
-	// for each of the selected transactions and add an entry for the
-	// coinbase.  This allows the code below to simply append details about
-	// a transaction as it is selected for inclusion in the final block.
-	// However, since the total fees aren't known yet, use a dummy value for
-	// the coinbase fee which will be updated later.
-	txFees := make([]int64, 0, len(sourceTxns))
-	txSigOpCosts := make([]int64, 0, len(sourceTxns))
-	txFees = append(txFees, -1) // Updated once known
-	txSigOpCosts = append(txSigOpCosts, coinbaseSigOpCost)
+	items := make(map[chainhash.Hash]*txPackageItem, len(sourceTxns))
+	candidates := make([]*txPackageItem, 0, len(sourceTxns))
 
 	log.Debugf("Considering %d transactions for inclusion to new block",
 		len(sourceTxns))
 
-mempoolLoop:
 	for _, txDesc := range sourceTxns {
 		// A block can't have more than one coinbase or contain
 		// non-finalized transactions.
@@ -520,6 +712,14 @@ mempoolLoop:
 			continue
 		}
 
+		// If segregated witness has not been activated yet, then we
+		// shouldn't include any witness transactions in the block.
+		if !segwitActive && tx.HasWitness() {
+			log.Tracef("Skipping witness tx %s since segwit is not "+
+				"active", tx.Hash())
+			continue
+		}
+
 		// Fetch all of the utxos referenced by the this transaction.
 		// NOTE: This intentionally does not fetch inputs from the
 		// mempool since a transaction which depends on other
@@ -532,268 +732,142 @@ mempoolLoop:
 			continue
 		}
 
-		// Setup dependencies for any transactions which reference
-		// other transactions in the mempool so they can be properly
-		// ordered below.
-		prioItem := &txPrioItem{tx: tx}
-		for _, txIn := range tx.MsgTx().TxIn {
-			originHash := &txIn.PreviousOutPoint.Hash
-			entry := utxos.LookupEntry(txIn.PreviousOutPoint)
-			if entry == nil || entry.IsSpent() {
-				if !g.txSource.HaveTransaction(originHash) {
-					log.Tracef("Skipping tx %s because it "+
-						"references unspent output %s "+
-						"which is not available",
-						tx.Hash(), txIn.PreviousOutPoint)
-					continue mempoolLoop
-				}
-
-				// The transaction is referencing another
-				// transaction in the source pool, so setup an
-				// ordering dependency.
-				deps, exists := dependers[*originHash]
-				if !exists {
-					deps = make(map[chainhash.Hash]*txPrioItem)
-					dependers[*originHash] = deps
-				}
-				deps[*prioItem.tx.Hash()] = prioItem
-				if prioItem.dependsOn == nil {
-					prioItem.dependsOn = make(
-						map[chainhash.Hash]struct{})
-				}
-				prioItem.dependsOn[*originHash] = struct{}{}
-
-				// Skip the check below. We already know the
-				// referenced transaction is available.
-				continue
-			}
-		}
-
-		// Calculate the final transaction priority using the input
-		// value age sum as well as the adjusted transaction size.  The
-		// formula is: sum(inputValue * inputAge) / adjustedTxSize
-		prioItem.priority = CalcPriority(tx.MsgTx(), utxos,
-			nextBlockHeight)
-
-		// Calculate the fee in Satoshi/kB.
-		prioItem.feePerKB = txDesc.FeePerKB
-		prioItem.fee = txDesc.Fee
-
-		// Add the transaction to the priority queue to mark it ready
-		// for inclusion in the block unless it has dependencies.
-		if prioItem.dependsOn == nil {
-			heap.Push(priorityQueue, prioItem)
+		item := &txPackageItem{
+			tx:     tx,
+			fee:    txDesc.Fee,
+			weight: blockchain.GetTransactionWeight(tx),
+			utxos:  utxos,
 		}
-
-		// Merge the referenced outputs from the input transactions to
-		// this transaction into the block utxo view.  This allows the
-		// code below to avoid a second lookup.
-		mergeUtxoView(blockUtxos, utxos)
+		items[*tx.Hash()] = item
+		candidates = append(candidates, item)
 	}
 
-	log.Tracef("Priority queue len %d, dependers len %d",
-		priorityQueue.Len(), len(dependers))
-
-	// The starting block size is the size of the block header plus the max
-	// possible transaction count size, plus the size of the coinbase
-	// transaction.
-	blockWeight := uint32((blockHeaderOverhead * blockchain.WitnessScaleFactor) +
-		blockchain.GetTransactionWeight(coinbaseTx))
-	blockSigOpCost := coinbaseSigOpCost
-	totalFees := int64(0)
-
-	// Query the version bits state to see if segwit has been activated, if
-	// so then this means that we'll include any transactions with witness
-	// data in the mempool, and also add the witness commitment as an
-	// OP_RETURN output in the coinbase transaction.
-	segwitState, err := g.chain.ThresholdState(chaincfg.DeploymentSegwit)
-	if err != nil {
-		return nil, err
+	// Create a selector starting with a block that only contains the
+	// coinbase.  The starting block weight is the weight of the block
+	// header plus the max possible transaction count size, plus the weight
+	// of the coinbase transaction.
+	selector := &txPackageSelector{
+		policy: g.policy,
+		blockWeight: blockHeaderOverhead*blockchain.WitnessScaleFactor +
+			blockchain.GetTransactionWeight(coinbaseTx) + reservedWeight,
+		blockSigOpCost: coinbaseSigOpCost + reservedSigOpCost,
+		validate: func(item *txPackageItem) error {
+			return g.chain.ScriptValidationPool().ValidateTransactionScripts(
+				item.tx, item.utxos, txscript.StandardVerifyFlags,
+				g.sigCache, g.hashCache)
+		},
 	}
-	segwitActive := segwitState == blockchain.ThresholdActive
-
-	witnessIncluded := false
-
-	// Choose which transactions make it into the block.
-	for priorityQueue.Len() > 0 {
-		// Grab the highest priority (or highest fee per kilobyte
-		// depending on the sort order) transaction.
-		prioItem := heap.Pop(priorityQueue).(*txPrioItem)
-		tx := prioItem.tx
-
-		switch {
-		// If segregated witness has not been activated yet, then we
-		// shouldn't include any witness transactions in the block.
-		case !segwitActive && tx.HasWitness():
-			continue
-
-		// Otherwise, Keep track of if we've included a transaction
-		// with witness data or not. If so, then we'll need to include
-		// the witness commitment as the last output in the coinbase
-		// transaction.
-		case segwitActive && !witnessIncluded && tx.HasWitness():
-			// If we're about to include a transaction bearing
-			// witness data, then we'll also need to include a
-			// witness commitment in the coinbase transaction.
-			// Therefore, we account for the additional weight
-			// within the block with a model coinbase tx with a
-			// witness commitment.
-			coinbaseCopy := btcutil.NewTx(coinbaseTx.MsgTx().Copy())
-			coinbaseCopy.MsgTx().TxIn[0].Witness = [][]byte{
-				bytes.Repeat([]byte("a"),
-					blockchain.CoinbaseWitnessDataLen),
-			}
-			coinbaseCopy.MsgTx().AddTxOut(&wire.TxOut{
-				PkScript: bytes.Repeat([]byte("a"),
-					blockchain.CoinbaseWitnessPkScriptLength),
-			})
-
-			// In order to accurately account for the weight
-			// addition due to this coinbase transaction, we'll add
-			// the difference of the transaction before and after
-			// the addition of the commitment to the block weight.
-			weightDiff := blockchain.GetTransactionWeight(coinbaseCopy) -
-				blockchain.GetTransactionWeight(coinbaseTx)
-
-			blockWeight += uint32(weightDiff)
 
-			witnessIncluded = true
+	// If we include a transaction bearing witness data, then we'll also
+	// need to include a witness commitment in the coinbase transaction.
+	// Therefore, we account for the additional weight within the block
+	// with a model coinbase tx with a witness commitment.
+	if segwitActive {
+		coinbaseCopy := btcutil.NewTx(coinbaseTx.MsgTx().Copy())
+		coinbaseCopy.MsgTx().TxIn[0].Witness = [][]byte{
+			bytes.Repeat([]byte("a"),
+				blockchain.CoinbaseWitnessDataLen),
 		}
+		coinbaseCopy.MsgTx().AddTxOut(&wire.TxOut{
+			PkScript: bytes.Repeat([]byte("a"),
+				blockchain.CoinbaseWitnessPkScriptLength),
+		})
+		selector.witnessCommitmentWeight =
+			blockchain.GetTransactionWeight(coinbaseCopy) -
+				blockchain.GetTransactionWeight(coinbaseTx)
+	}
 
-		// Grab any transactions which depend on this one.
-		deps := dependers[*tx.Hash()]
-
-		// Enforce maximum block size.  Also check for overflow.
-		txWeight := uint32(blockchain.GetTransactionWeight(tx))
-		blockPlusTxWeight := blockWeight + txWeight
-		if blockPlusTxWeight < blockWeight ||
-			blockPlusTxWeight >= g.policy.BlockMaxWeight {
+	// Link the transactions which spend outputs of other transactions in
+	// the source pool to them and add those outputs to their utxo view, so
+	// they can be validated before their parents are included.
+	for _, item := range candidates {
+		for _, txIn := range item.tx.MsgTx().TxIn {
+			prevOut := txIn.PreviousOutPoint
+			entry := item.utxos.LookupEntry(prevOut)
+			if entry != nil && !entry.IsSpent() {
+				continue
+			}
 
-			log.Tracef("Skipping tx %s because it would exceed "+
-				"the max block weight", tx.Hash())
-			logSkippedDeps(tx, deps)
-			continue
-		}
+			parent, ok := items[prevOut.Hash]
+			if !ok {
+				log.Tracef("Skipping tx %s because it references "+
+					"unspent output %s which is not available",
+					item.tx.Hash(), prevOut)
+				item.failed = true
+				break
+			}
+			item.utxos.AddTxOut(parent.tx, prevOut.Index,
+				nextBlockHeight)
 
-		// Enforce maximum signature operation cost per block.  Also
-		// check for overflow.
-		sigOpCost, err := blockchain.GetSigOpCost(tx, false,
-			blockUtxos, true, segwitActive)
-		if err != nil {
-			log.Tracef("Skipping tx %s due to error in "+
-				"GetSigOpCost: %v", tx.Hash(), err)
-			logSkippedDeps(tx, deps)
-			continue
-		}
-		if blockSigOpCost+int64(sigOpCost) < blockSigOpCost ||
-			blockSigOpCost+int64(sigOpCost) > blockchain.MaxBlockSigOpsCost {
-			log.Tracef("Skipping tx %s because it would "+
-				"exceed the maximum sigops per block", tx.Hash())
-			logSkippedDeps(tx, deps)
-			continue
+			if item.parents == nil {
+				item.parents = make(map[chainhash.Hash]*txPackageItem)
+			}
+			item.parents[prevOut.Hash] = parent
+			if parent.children == nil {
+				parent.children = make(map[chainhash.Hash]*txPackageItem)
+			}
+			parent.children[*item.tx.Hash()] = item
 		}
+	}
 
-		// Skip free transactions once the block is larger than the
-		// minimum block size.
-		if sortedByFee &&
-			prioItem.feePerKB < int64(g.policy.TxMinFreeFee) &&
-			blockPlusTxWeight >= g.policy.BlockMinWeight {
-
-			log.Tracef("Skipping tx %s with feePerKB %d "+
-				"< TxMinFreeFee %d and block weight %d >= "+
-				"minBlockWeight %d", tx.Hash(), prioItem.feePerKB,
-				g.policy.TxMinFreeFee, blockPlusTxWeight,
-				g.policy.BlockMinWeight)
-			logSkippedDeps(tx, deps)
+	// Ensure the transaction inputs pass all of the necessary
+	// preconditions and calculate the signature operation cost of each
+	// transaction.  Transactions which fail are skipped along with all of
+	// their descendants.
+	for _, item := range candidates {
+		if item.failed {
+			selector.markFailed(item)
 			continue
 		}
 
-		// Prioritize by fee per kilobyte once the block is larger than
-		// the priority size or there are no more high-priority
-		// transactions.
-		if !sortedByFee && (blockPlusTxWeight >= g.policy.BlockPrioritySize ||
-			prioItem.priority <= MinHighPriority) {
-
-			log.Tracef("Switching to sort by fees per "+
-				"kilobyte blockSize %d >= BlockPrioritySize "+
-				"%d || priority %.2f <= minHighPriority %.2f",
-				blockPlusTxWeight, g.policy.BlockPrioritySize,
-				prioItem.priority, MinHighPriority)
-
-			sortedByFee = true
-			priorityQueue.SetLessFunc(txPQByFee)
-
-			// Put the transaction back into the priority queue and
-			// skip it so it is re-priortized by fees if it won't
-			// fit into the high-priority section or the priority
-			// is too low.  Otherwise this transaction will be the
-			// final one in the high-priority section, so just fall
-			// though to the code below so it is added now.
-			if blockPlusTxWeight > g.policy.BlockPrioritySize ||
-				prioItem.priority < MinHighPriority {
-
-				heap.Push(priorityQueue, prioItem)
-				continue
-			}
-		}
-
-		// Ensure the transaction inputs pass all of the necessary
-		// preconditions before allowing it to be added to the block.
-		_, err = blockchain.CheckTransactionInputs(tx, nextBlockHeight,
-			blockUtxos, g.chainParams)
+		tx := item.tx
+		_, err := blockchain.CheckTransactionInputs(tx, nextBlockHeight,
+			item.utxos, g.chainParams)
 		if err != nil {
 			log.Tracef("Skipping tx %s due to error in "+
 				"CheckTransactionInputs: %v", tx.Hash(), err)
-			logSkippedDeps(tx, deps)
+			selector.markFailed(item)
 			continue
 		}
-		err = blockchain.ValidateTransactionScripts(tx, blockUtxos,
-			txscript.StandardVerifyFlags, g.sigCache,
-			g.hashCache)
+		sigOpCost, err := blockchain.GetSigOpCost(tx, false,
+			item.utxos, true, segwitActive)
 		if err != nil {
 			log.Tracef("Skipping tx %s due to error in "+
-				"ValidateTransactionScripts: %v", tx.Hash(), err)
-			logSkippedDeps(tx, deps)
+				"GetSigOpCost: %v", tx.Hash(), err)
+			selector.markFailed(item)
 			continue
 		}
+		item.sigOpCost = int64(sigOpCost)
+	}
 
-		// Spend the transaction inputs in the block utxo view and add
-		// an entry for it to ensure any transactions which reference
-		// this one have it available as an input and can ensure they
-		// aren't double spending.
-		spendTransaction(blockUtxos, tx, nextBlockHeight)
-
-		// Add the transaction to the block, increment counters, and
-		// save the fees and signature operation counts to the block
-		// template.
-		blockTxns = append(blockTxns, tx)
-		blockWeight += txWeight
-		blockSigOpCost += int64(sigOpCost)
-		totalFees += prioItem.fee
-		txFees = append(txFees, prioItem.fee)
-		txSigOpCosts = append(txSigOpCosts, int64(sigOpCost))
-
-		log.Tracef("Adding tx %s (priority %.2f, feePerKB %.2f)",
-			prioItem.tx.Hash(), prioItem.priority, prioItem.feePerKB)
-
-		// Add transactions which depend on this one (and also do not
-		// have any other unsatisified dependencies) to the priority
-		// queue.
-		for _, item := range deps {
-			// Add the transaction to the priority queue if there
-			// are no more dependencies after this one.
-			delete(item.dependsOn, *tx.Hash())
-			if len(item.dependsOn) == 0 {
-				heap.Push(priorityQueue, item)
-			}
-		}
+	// Choose which transactions make it into the block.
+	initAncestors(candidates)
+	selector.selectPackages(candidates)
+
+	// Create slices to hold the selected transactions along with their
+	// fees and number of signature operations, starting with the coinbase.
+	numTxns := len(selector.selected) + 1
+	blockTxns := make([]*btcutil.Tx, 0, numTxns)
+	blockTxns = append(blockTxns, coinbaseTx)
+	txFees := make([]int64, 0, numTxns)
+	txFees = append(txFees, -1) // Updated below
+	txSigOpCosts := make([]int64, 0, numTxns)
+	txSigOpCosts = append(txSigOpCosts, coinbaseSigOpCost)
+	for _, item := range selector.selected {
+		blockTxns = append(blockTxns, item.tx)
+		txFees = append(txFees, item.fee)
+		txSigOpCosts = append(txSigOpCosts, item.sigOpCost)
 	}
+	blockWeight := selector.blockWeight - reservedWeight
+	blockSigOpCost := selector.blockSigOpCost - reservedSigOpCost
+	totalFees := selector.totalFees
+	witnessIncluded := selector.witnessIncluded
 
 	// Now that the actual transactions have been selected, update the
 	// block weight for the real transaction count and coinbase value with
 	// the total fees accordingly.
 	blockWeight -= wire.MaxVarIntPayload -
-		(uint32(wire.VarIntSerializeSize(uint64(len(blockTxns)))) *
+		(int64(wire.VarIntSerializeSize(uint64(len(blockTxns)))) *
 			blockchain.WitnessScaleFactor)
 	coinbaseTx.MsgTx().TxOut[0].Value += totalFees
 	txFees[0] = -totalFees
@@ -852,14 +926,26 @@ mempoolLoop:
 		"%064x)", len(msgBlock.Transactions), totalFees, blockSigOpCost,
 		blockWeight, blockchain.CompactToBig(msgBlock.Header.Bits))
 
-	return &BlockTemplate{
+	template := &BlockTemplate{
 		Block:             &msgBlock,
 		Fees:              txFees,
 		SigOpCosts:        txSigOpCosts,
 		Height:            nextBlockHeight,
 		ValidPayAddress:   payToAddress != nil,
 		WitnessCommitment: witnessCommitment,
-	}, nil
+	}
+	g.notifier.notify(template)
+
+	return template, nil
+}
+
+// Subscribe returns a new subscription which receives every block template
+// created by the generator from now on.  The subscription must be cancelled
+// with Unsubscribe once it is no longer needed.
+//
+// This function is safe for concurrent access.
+func (g *BlkTmplGenerator) Subscribe() *TemplateSubscription {
+	return g.notifier.subscribe()
 }
 
 // AddWitnessCommitment adds the witness commitment as an OP_RETURN outpout
@@ -6,34 +6,44 @@ package mining
 
 import (
 	"container/heap"
+	"errors"
 	"math/rand"
 	"testing"
 
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 )
 
-// TestTxFeePrioHeap ensures the priority queue for transaction fees and
-// priorities works as expected.
-func TestTxFeePrioHeap(t *testing.T) {
-	// Create some fake priority items that exercise the expected sort
-	// edge conditions.
-	testItems := []*txPrioItem{
-		{feePerKB: 5678, priority: 3},
-		{feePerKB: 5678, priority: 1},
-		{feePerKB: 5678, priority: 1}, // Duplicate fee and prio
-		{feePerKB: 5678, priority: 5},
-		{feePerKB: 5678, priority: 2},
-		{feePerKB: 1234, priority: 3},
-		{feePerKB: 1234, priority: 1},
-		{feePerKB: 1234, priority: 5},
-		{feePerKB: 1234, priority: 5}, // Duplicate fee and prio
-		{feePerKB: 1234, priority: 2},
-		{feePerKB: 10000, priority: 0}, // Higher fee, smaller prio
-		{feePerKB: 0, priority: 10000}, // Higher prio, lower fee
+// newTestPackageItem returns a package item for a unique transaction with the
+// passed fee, weight and signature operation cost which spends outputs of the
+// passed parents.
+func newTestPackageItem(version int32, fee, weight, sigOpCost int64,
+	parents ...*txPackageItem) *txPackageItem {
+
+	item := &txPackageItem{
+		tx:        btcutil.NewTx(&wire.MsgTx{Version: version}),
+		fee:       fee,
+		weight:    weight,
+		sigOpCost: sigOpCost,
+	}
+	for _, parent := range parents {
+		if item.parents == nil {
+			item.parents = make(map[chainhash.Hash]*txPackageItem)
+		}
+		item.parents[*parent.tx.Hash()] = parent
+		if parent.children == nil {
+			parent.children = make(map[chainhash.Hash]*txPackageItem)
+		}
+		parent.children[*item.tx.Hash()] = item
 	}
+	return item
+}
 
-	// Add random data in addition to the edge conditions already manually
-	// specified.
+// TestTxPackageHeap ensures the priority queue for packages returns the
+// entries with the highest package fee rate first.
+func TestTxPackageHeap(t *testing.T) {
 	randSeed := rand.Int63()
 	defer func() {
 		if t.Failed() {
@@ -41,70 +51,251 @@ func TestTxFeePrioHeap(t *testing.T) {
 		}
 	}()
 	prng := rand.New(rand.NewSource(randSeed))
+
+	var h txPackageHeap
 	for i := 0; i < 1000; i++ {
-		testItems = append(testItems, &txPrioItem{
-			feePerKB: int64(prng.Float64() * btcutil.SatoshiPerBitcoin),
-			priority: prng.Float64() * 100,
-		})
+		item := newTestPackageItem(int32(i),
+			prng.Int63n(btcutil.SatoshiPerBitcoin),
+			prng.Int63n(400000)+1, 0)
+		initAncestors([]*txPackageItem{item})
+		h.pushItem(item)
 	}
 
-	// Test sorting by fee per KB then priority.
-	var highest *txPrioItem
-	priorityQueue := newTxPriorityQueue(len(testItems), true)
-	for i := 0; i < len(testItems); i++ {
-		prioItem := testItems[i]
-		if highest == nil {
-			highest = prioItem
+	prev := heap.Pop(&h).(txPackageEntry)
+	for h.Len() > 0 {
+		entry := heap.Pop(&h).(txPackageEntry)
+		if entry.feeRate > prev.feeRate {
+			t.Fatalf("entry with fee rate %v popped after entry "+
+				"with fee rate %v", entry.feeRate, prev.feeRate)
 		}
-		if prioItem.feePerKB >= highest.feePerKB &&
-			prioItem.priority > highest.priority {
-
-			highest = prioItem
-		}
-		heap.Push(priorityQueue, prioItem)
+		prev = entry
 	}
+}
 
-	for i := 0; i < len(testItems); i++ {
-		prioItem := heap.Pop(priorityQueue).(*txPrioItem)
-		if prioItem.feePerKB >= highest.feePerKB &&
-			prioItem.priority > highest.priority {
+// TestSelectPackages ensures transactions are selected by the fee rate of
+// their packages subject to the block limits of the policy.
+func TestSelectPackages(t *testing.T) {
+	// All transactions have a weight of 400, which is a virtual size of
+	// 100 bytes, so a fee of 1000 is a fee rate of 10 sat/byte.
+	const weight = 400
 
-			t.Fatalf("fee sort: item (fee per KB: %v, "+
-				"priority: %v) higher than than prev "+
-				"(fee per KB: %v, priority %v)",
-				prioItem.feePerKB, prioItem.priority,
-				highest.feePerKB, highest.priority)
-		}
-		highest = prioItem
+	tests := []struct {
+		name string
+
+		// setup returns the candidate transactions and the ones
+		// expected to be selected in order.
+		setup func() ([]*txPackageItem, []*txPackageItem)
+
+		maxWeight int64
+		minWeight int64
+
+		// invalid are the candidates which fail validation.
+		invalid map[int]struct{}
+	}{
+		{
+			name: "child pays for parent",
+			setup: func() ([]*txPackageItem, []*txPackageItem) {
+				parent := newTestPackageItem(1, 0, weight, 0)
+				child := newTestPackageItem(2, 3000, weight, 0,
+					parent)
+				other := newTestPackageItem(3, 1000, weight, 0)
+				return []*txPackageItem{other, child, parent},
+					[]*txPackageItem{parent, child, other}
+			},
+		},
+		{
+			name: "child with lower fee rate than parent",
+			setup: func() ([]*txPackageItem, []*txPackageItem) {
+				parent := newTestPackageItem(1, 2000, weight, 0)
+				child := newTestPackageItem(2, 500, weight, 0,
+					parent)
+				other := newTestPackageItem(3, 1000, weight, 0)
+				return []*txPackageItem{child, other, parent},
+					[]*txPackageItem{parent, other, child}
+			},
+		},
+		{
+			name: "child with multiple parents",
+			setup: func() ([]*txPackageItem, []*txPackageItem) {
+				parent1 := newTestPackageItem(1, 1000, weight, 0)
+				parent2 := newTestPackageItem(2, 0, weight, 0,
+					parent1)
+				child := newTestPackageItem(3, 5000, weight, 0,
+					parent1, parent2)
+				other := newTestPackageItem(4, 1500, weight, 0)
+				return []*txPackageItem{other, child, parent2,
+						parent1},
+					[]*txPackageItem{parent1, parent2, child,
+						other}
+			},
+		},
+		{
+			name: "package exceeding the max block weight",
+			setup: func() ([]*txPackageItem, []*txPackageItem) {
+				parent := newTestPackageItem(1, 0, weight, 0)
+				child := newTestPackageItem(2, 5000, weight, 0,
+					parent)
+				other := newTestPackageItem(3, 1000, weight, 0)
+				return []*txPackageItem{parent, child, other},
+					[]*txPackageItem{other}
+			},
+			maxWeight: 2 * weight,
+		},
+		{
+			name: "package exceeding the max sigops",
+			setup: func() ([]*txPackageItem, []*txPackageItem) {
+				parent := newTestPackageItem(1, 0, weight,
+					blockchain.MaxBlockSigOpsCost/2)
+				child := newTestPackageItem(2, 5000, weight,
+					blockchain.MaxBlockSigOpsCost/2+1, parent)
+				other := newTestPackageItem(3, 1000, weight, 1)
+				return []*txPackageItem{parent, child, other},
+					[]*txPackageItem{other}
+			},
+		},
+		{
+			name: "low fee transactions",
+			setup: func() ([]*txPackageItem, []*txPackageItem) {
+				free := newTestPackageItem(1, 0, weight, 0)
+				low := newTestPackageItem(2, 50, weight, 0)
+				other := newTestPackageItem(3, 1000, weight, 0)
+				return []*txPackageItem{free, low, other},
+					[]*txPackageItem{other}
+			},
+		},
+		{
+			name: "low fee transactions below min block weight",
+			setup: func() ([]*txPackageItem, []*txPackageItem) {
+				free := newTestPackageItem(1, 0, weight, 0)
+				low := newTestPackageItem(2, 50, weight, 0)
+				other := newTestPackageItem(3, 1000, weight, 0)
+				return []*txPackageItem{free, low, other},
+					[]*txPackageItem{other, low}
+			},
+			minWeight: 3 * weight,
+		},
+		{
+			name: "invalid parent",
+			setup: func() ([]*txPackageItem, []*txPackageItem) {
+				parent := newTestPackageItem(1, 1000, weight, 0)
+				child := newTestPackageItem(2, 5000, weight, 0,
+					parent)
+				grandchild := newTestPackageItem(3, 5000, weight,
+					0, child)
+				other := newTestPackageItem(4, 500, weight, 0)
+				return []*txPackageItem{parent, child,
+						grandchild, other},
+					[]*txPackageItem{other}
+			},
+			invalid: map[int]struct{}{1: {}},
+		},
+		{
+			name: "invalid child",
+			setup: func() ([]*txPackageItem, []*txPackageItem) {
+				parent := newTestPackageItem(1, 1000, weight, 0)
+				child := newTestPackageItem(2, 5000, weight, 0,
+					parent)
+				other := newTestPackageItem(3, 500, weight, 0)
+				return []*txPackageItem{parent, child, other},
+					[]*txPackageItem{parent, other}
+			},
+			invalid: map[int]struct{}{2: {}},
+		},
 	}
 
-	// Test sorting by priority then fee per KB.
-	highest = nil
-	priorityQueue = newTxPriorityQueue(len(testItems), false)
-	for i := 0; i < len(testItems); i++ {
-		prioItem := testItems[i]
-		if highest == nil {
-			highest = prioItem
+	for _, test := range tests {
+		candidates, want := test.setup()
+
+		maxWeight := test.maxWeight
+		if maxWeight == 0 {
+			maxWeight = blockchain.MaxBlockWeight
+		}
+		selector := &txPackageSelector{
+			policy: &Policy{
+				BlockMaxWeight: uint32(maxWeight),
+				BlockMinWeight: uint32(test.minWeight),
+				TxMinFreeFee:   1000,
+			},
+			validate: func(item *txPackageItem) error {
+				version := int(item.tx.MsgTx().Version)
+				if _, ok := test.invalid[version]; ok {
+					return errors.New("invalid")
+				}
+				return nil
+			},
 		}
-		if prioItem.priority >= highest.priority &&
-			prioItem.feePerKB > highest.feePerKB {
+		initAncestors(candidates)
+		selector.selectPackages(candidates)
 
-			highest = prioItem
+		if len(selector.selected) != len(want) {
+			t.Fatalf("%s: expected %d selected transactions, got %d",
+				test.name, len(want), len(selector.selected))
+		}
+		var wantFees, wantWeight int64
+		for i, item := range selector.selected {
+			if item != want[i] {
+				t.Fatalf("%s: unexpected transaction %d: got "+
+					"version %d, want version %d", test.name,
+					i, item.tx.MsgTx().Version,
+					want[i].tx.MsgTx().Version)
+			}
+			wantFees += item.fee
+			wantWeight += item.weight
+		}
+		if selector.totalFees != wantFees {
+			t.Fatalf("%s: unexpected total fees: got %d, want %d",
+				test.name, selector.totalFees, wantFees)
+		}
+		if selector.blockWeight != wantWeight {
+			t.Fatalf("%s: unexpected block weight: got %d, want %d",
+				test.name, selector.blockWeight, wantWeight)
 		}
-		heap.Push(priorityQueue, prioItem)
 	}
+}
+
+// TestIncludeUpdatesDescendants ensures including a package updates the
+// ancestor totals of all descendants of its transactions, including the
+// descendants of transactions which were included with the same package.
+func TestIncludeUpdatesDescendants(t *testing.T) {
+	const weight = 400
+	a := newTestPackageItem(1, 100, weight, 1)
+	b := newTestPackageItem(2, 100, weight, 1, a)
+	c := newTestPackageItem(3, 1000, weight, 1, b)
+	d := newTestPackageItem(4, 500, weight, 1, c)
+	initAncestors([]*txPackageItem{a, b, c, d})
+
+	selector := &txPackageSelector{policy: &Policy{}}
+	var h txPackageHeap
+	selector.include(selector.packageOf(b), false, &h)
 
-	for i := 0; i < len(testItems); i++ {
-		prioItem := heap.Pop(priorityQueue).(*txPrioItem)
-		if prioItem.priority >= highest.priority &&
-			prioItem.feePerKB > highest.feePerKB {
+	// Only the transactions which weren't included are left in the
+	// packages of the descendants.
+	tests := []struct {
+		item      *txPackageItem
+		fee       int64
+		weight    int64
+		sigOpCost int64
+	}{
+		{item: c, fee: 1000, weight: weight, sigOpCost: 1},
+		{item: d, fee: 1500, weight: 2 * weight, sigOpCost: 2},
+	}
+	for _, test := range tests {
+		version := test.item.tx.MsgTx().Version
+		if test.item.ancestorFee != test.fee ||
+			test.item.ancestorWeight != test.weight ||
+			test.item.ancestorSigOpCost != test.sigOpCost {
 
-			t.Fatalf("priority sort: item (fee per KB: %v, "+
-				"priority: %v) higher than than prev "+
-				"(fee per KB: %v, priority %v)",
-				prioItem.feePerKB, prioItem.priority,
-				highest.feePerKB, highest.priority)
+			t.Errorf("unexpected ancestor totals of version %d: "+
+				"fee %d, weight %d, sigops %d", version,
+				test.item.ancestorFee, test.item.ancestorWeight,
+				test.item.ancestorSigOpCost)
+		}
+		if test.item.generation != 1 {
+			t.Errorf("unexpected generation of version %d: %d",
+				version, test.item.generation)
 		}
-		highest = prioItem
+	}
+	if h.Len() != 2 {
+		t.Fatalf("unexpected number of updated packages %d", h.Len())
 	}
 }
@@ -39,6 +39,10 @@ type Policy struct {
 
 	// BlockPrioritySize is the size in bytes for high-priority / low-fee
 	// transactions to be used when generating a block template.
+	//
+	// Deprecated: Block templates no longer reserve space for high-priority
+	// transactions, since transactions are selected by the fee rate of
+	// their packages.  This field has no effect.
 	BlockPrioritySize uint32
 
 	// TxMinFreeFee is the minimum fee in Satoshi/1000 bytes that is
@@ -0,0 +1,126 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mining
+
+import (
+	"sync"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// TemplateSubscription delivers the block templates created by a block
+// template generator to a subscriber.  Only the most recent template is kept
+// when the subscriber has not received the previous one yet, since a newer
+// template always supersedes the older ones.
+//
+// The delivered templates are shared by all subscribers and therefore must be
+// treated as read-only.
+//
+// This type is safe for concurrent access.
+type TemplateSubscription struct {
+	id        uint64
+	templates chan *BlockTemplate
+	notifier  *templateNotifier
+}
+
+// Templates returns the channel the block templates are delivered on.  The
+// channel is closed once the subscription is cancelled.
+func (s *TemplateSubscription) Templates() <-chan *BlockTemplate {
+	return s.templates
+}
+
+// Unsubscribe cancels the subscription and closes the channel returned by
+// Templates.  It is safe to call more than once.
+func (s *TemplateSubscription) Unsubscribe() {
+	s.notifier.unsubscribe(s)
+}
+
+// templateNotifier keeps track of the active template subscriptions and
+// delivers new block templates to them.
+type templateNotifier struct {
+	mtx           sync.Mutex
+	nextID        uint64
+	subscriptions map[uint64]*TemplateSubscription
+}
+
+// newTemplateNotifier returns a new template notifier without any
+// subscriptions.
+func newTemplateNotifier() *templateNotifier {
+	return &templateNotifier{
+		subscriptions: make(map[uint64]*TemplateSubscription),
+	}
+}
+
+// subscribe registers and returns a new subscription.
+func (n *templateNotifier) subscribe() *TemplateSubscription {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	sub := &TemplateSubscription{
+		id:        n.nextID,
+		templates: make(chan *BlockTemplate, 1),
+		notifier:  n,
+	}
+	n.subscriptions[sub.id] = sub
+	n.nextID++
+
+	return sub
+}
+
+// unsubscribe removes the passed subscription and closes its channel unless
+// it was already removed.
+func (n *templateNotifier) unsubscribe(sub *TemplateSubscription) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	if _, ok := n.subscriptions[sub.id]; !ok {
+		return
+	}
+	delete(n.subscriptions, sub.id)
+	close(sub.templates)
+}
+
+// notify delivers a copy of the passed block template to all subscriptions.
+// A template which has not been received by a subscriber yet is replaced, so
+// this never blocks on slow subscribers.
+func (n *templateNotifier) notify(template *BlockTemplate) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	if len(n.subscriptions) == 0 {
+		return
+	}
+
+	// The callers of the generator are free to update the header and the
+	// coinbase of the returned template, so hand out a copy which is not
+	// affected by those changes.
+	template = copyBlockTemplate(template)
+	for _, sub := range n.subscriptions {
+		// Drop the stale template if there is one.  Since all sends
+		// happen with the notifier locked, there is room for the new
+		// template afterwards.
+		select {
+		case <-sub.templates:
+		default:
+		}
+		sub.templates <- template
+	}
+}
+
+// copyBlockTemplate returns a copy of the passed block template which has its
+// own block header and coinbase transaction.  The remaining transactions are
+// shared since they are never modified.
+func copyBlockTemplate(template *BlockTemplate) *BlockTemplate {
+	msgBlock := *template.Block
+	msgBlock.Transactions = make([]*wire.MsgTx, len(template.Block.Transactions))
+	copy(msgBlock.Transactions, template.Block.Transactions)
+	if len(msgBlock.Transactions) > 0 {
+		msgBlock.Transactions[0] = msgBlock.Transactions[0].Copy()
+	}
+
+	templateCopy := *template
+	templateCopy.Block = &msgBlock
+	return &templateCopy
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mining
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// newTestTemplate returns a block template with a coinbase transaction and
+// the passed height for use in the subscription tests.
+func newTestTemplate(height int32) *BlockTemplate {
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: wire.MaxPrevOutIndex},
+		[]byte{0x51}, nil))
+	coinbase.AddTxOut(wire.NewTxOut(5000000000, []byte{0x51}))
+
+	return &BlockTemplate{
+		Block: &wire.MsgBlock{
+			Header:       wire.BlockHeader{Version: 1},
+			Transactions: []*wire.MsgTx{coinbase},
+		},
+		Height: height,
+	}
+}
+
+// TestTemplateSubscription ensures block templates are delivered to the
+// subscribers, that stale templates are replaced by newer ones and that
+// cancelled subscriptions no longer receive any templates.
+func TestTemplateSubscription(t *testing.T) {
+	t.Parallel()
+
+	notifier := newTemplateNotifier()
+
+	// Notifying without subscribers must not block.
+	notifier.notify(newTestTemplate(1))
+
+	sub1 := notifier.subscribe()
+	sub2 := notifier.subscribe()
+
+	// Only the latest template must be delivered when the previous one
+	// was not received yet.
+	notifier.notify(newTestTemplate(2))
+	notifier.notify(newTestTemplate(3))
+	for i, sub := range []*TemplateSubscription{sub1, sub2} {
+		select {
+		case template := <-sub.Templates():
+			if template.Height != 3 {
+				t.Fatalf("subscription %d: unexpected template "+
+					"height - got %d, want 3", i,
+					template.Height)
+			}
+		default:
+			t.Fatalf("subscription %d: no template delivered", i)
+		}
+	}
+
+	// The delivered template must not be affected by updates to the
+	// header or coinbase of the original template.
+	template := newTestTemplate(4)
+	notifier.notify(template)
+	template.Block.Header.Nonce = 1
+	template.Block.Transactions[0].TxIn[0].SignatureScript = []byte{0x52}
+	delivered := <-sub1.Templates()
+	if delivered.Block.Header.Nonce != 0 {
+		t.Fatalf("delivered template header was modified")
+	}
+	if delivered.Block.Transactions[0].TxIn[0].SignatureScript[0] != 0x51 {
+		t.Fatalf("delivered template coinbase was modified")
+	}
+
+	// Cancelling a subscription must close its channel and may be done
+	// more than once.
+	sub1.Unsubscribe()
+	sub1.Unsubscribe()
+	if _, ok := <-sub1.Templates(); ok {
+		t.Fatalf("channel of cancelled subscription not closed")
+	}
+
+	// The remaining subscription must still receive templates.
+	notifier.notify(newTestTemplate(5))
+	if template := <-sub2.Templates(); template.Height != 5 {
+		t.Fatalf("unexpected template height - got %d, want 5",
+			template.Height)
+	}
+	sub2.Unsubscribe()
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sv2
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// The maximum lengths of the variable length data types of the protocol.
+const (
+	maxBytes255 = 1<<8 - 1
+	maxBytes64K = 1<<16 - 1
+	maxBytes16M = 1<<24 - 1
+)
+
+// encoder serializes the data types of the Stratum V2 binary protocol.  All
+// integers are encoded in little-endian byte order.  The first error is
+// recorded and causes all further writes to be ignored, so callers only need
+// to check it once after encoding a message.
+type encoder struct {
+	buf []byte
+	err error
+}
+
+// u8 encodes a U8 or BOOL value.
+func (e *encoder) u8(v uint8) {
+	e.buf = append(e.buf, v)
+}
+
+// boolean encodes a BOOL value.
+func (e *encoder) boolean(v bool) {
+	if v {
+		e.u8(1)
+		return
+	}
+	e.u8(0)
+}
+
+// u16 encodes a U16 value.
+func (e *encoder) u16(v uint16) {
+	e.buf = append(e.buf, byte(v), byte(v>>8))
+}
+
+// u24 encodes a U24 value.
+func (e *encoder) u24(v uint32) {
+	e.buf = append(e.buf, byte(v), byte(v>>8), byte(v>>16))
+}
+
+// u32 encodes a U32 value.
+func (e *encoder) u32(v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	e.buf = append(e.buf, b[:]...)
+}
+
+// u64 encodes a U64 value.
+func (e *encoder) u64(v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	e.buf = append(e.buf, b[:]...)
+}
+
+// u256 encodes a U256 value.
+func (e *encoder) u256(v *[32]byte) {
+	e.buf = append(e.buf, v[:]...)
+}
+
+// bytes255 encodes a B0_255 or STR0_255 value.
+func (e *encoder) bytes255(field string, v []byte) {
+	if len(v) > maxBytes255 {
+		e.fail(field, len(v), maxBytes255)
+		return
+	}
+	e.u8(uint8(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+// bytes64K encodes a B0_64K value.
+func (e *encoder) bytes64K(field string, v []byte) {
+	if len(v) > maxBytes64K {
+		e.fail(field, len(v), maxBytes64K)
+		return
+	}
+	e.u16(uint16(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+// bytes16M encodes a B0_16M value.
+func (e *encoder) bytes16M(field string, v []byte) {
+	if len(v) > maxBytes16M {
+		e.fail(field, len(v), maxBytes16M)
+		return
+	}
+	e.u24(uint32(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+// fail records an error about a field exceeding its maximum length unless an
+// error was already recorded.
+func (e *encoder) fail(field string, length, max int) {
+	if e.err == nil {
+		e.err = messageError(fmt.Sprintf("%s is too long: %d > %d",
+			field, length, max))
+	}
+}
+
+// decoder deserializes the data types of the Stratum V2 binary protocol.
+// Just like the encoder, the first error is recorded and all further reads
+// return zero values.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+// next returns the next n bytes of the buffer, or nil when there are not
+// enough bytes left.
+func (d *decoder) next(field string, n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.buf) < n {
+		d.err = messageError(fmt.Sprintf("not enough data to read %s",
+			field))
+		return nil
+	}
+	b := d.buf[:n:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+// u8 decodes a U8 value.
+func (d *decoder) u8(field string) uint8 {
+	b := d.next(field, 1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+// boolean decodes a BOOL value.
+func (d *decoder) boolean(field string) bool {
+	return d.u8(field)&1 == 1
+}
+
+// u16 decodes a U16 value.
+func (d *decoder) u16(field string) uint16 {
+	b := d.next(field, 2)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(b)
+}
+
+// u24 decodes a U24 value.
+func (d *decoder) u24(field string) uint32 {
+	b := d.next(field, 3)
+	if b == nil {
+		return 0
+	}
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+// u32 decodes a U32 value.
+func (d *decoder) u32(field string) uint32 {
+	b := d.next(field, 4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+// u64 decodes a U64 value.
+func (d *decoder) u64(field string) uint64 {
+	b := d.next(field, 8)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+// u256 decodes a U256 value.
+func (d *decoder) u256(field string) [32]byte {
+	var v [32]byte
+	copy(v[:], d.next(field, 32))
+	return v
+}
+
+// bytes255 decodes a B0_255 or STR0_255 value.
+func (d *decoder) bytes255(field string) []byte {
+	return d.next(field, int(d.u8(field)))
+}
+
+// bytes64K decodes a B0_64K value.
+func (d *decoder) bytes64K(field string) []byte {
+	return d.next(field, int(d.u16(field)))
+}
+
+// bytes16M decodes a B0_16M value.
+func (d *decoder) bytes16M(field string) []byte {
+	return d.next(field, int(d.u24(field)))
+}
+
+// finish returns the recorded error, if any, or an error when there is
+// unexpected data left in the buffer.
+func (d *decoder) finish() error {
+	if d.err != nil {
+		return d.err
+	}
+	if len(d.buf) != 0 {
+		return messageError(fmt.Sprintf("%d unexpected trailing bytes",
+			len(d.buf)))
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sv2
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/btcec/schnorr"
+	"github.com/btcsuite/btcutil/base58"
+)
+
+const (
+	// frameHeaderLen is the length of the header of a frame, which
+	// consists of the extension type, the message type and the length of
+	// the payload.
+	frameHeaderLen = 2 + 1 + 3
+
+	// maxChunkLen is the maximum length of an encrypted chunk of a payload.
+	// Payloads are split into chunks of at most this size including the
+	// authentication tag.
+	maxChunkLen = 1<<16 - 1
+
+	// maxChunkPlaintextLen is the maximum length of the plaintext of a
+	// chunk.
+	maxChunkPlaintextLen = maxChunkLen - macLen
+
+	// channelMsgBit is the bit of the extension type which marks messages
+	// addressed to a specific channel.
+	channelMsgBit = 0x8000
+
+	// authorityKeyVersion is the version of encoded authority keys.  It is
+	// serialized as a little-endian uint16 in front of the key.
+	authorityKeyVersion = 1
+)
+
+// Conn is an encrypted Stratum V2 connection.  It is created by performing the
+// Noise handshake with ServerHandshake or ClientHandshake and is used to
+// exchange the messages of the Template Distribution protocol afterwards.
+//
+// Reading and writing messages are safe for concurrent access with each
+// other, but concurrent reads are not.
+type Conn struct {
+	conn net.Conn
+
+	sendMtx sync.Mutex
+	send    *cipherState
+	recv    *cipherState
+}
+
+// ServerHandshake performs the responder side of the Noise handshake on the
+// passed connection.  The static key is the long-term key of the server, which
+// is authorized by the certificate created with the authority key.  Clients
+// must know the x-only public authority key to verify the certificate.
+func ServerHandshake(conn net.Conn, staticKey,
+	authorityKey *btcec.PrivateKey) (*Conn, error) {
+
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	ss := newSymmetricState()
+
+	// -> e
+	var theirEphemeral [actOneLen]byte
+	if _, err := io.ReadFull(conn, theirEphemeral[:]); err != nil {
+		return nil, err
+	}
+	ss.mixHash(theirEphemeral[:])
+	if _, err := ss.decryptAndHash(nil); err != nil {
+		return nil, err
+	}
+
+	// <- e, ee, s, es, SIGNATURE_NOISE_MESSAGE
+	ephemeralKey, ourEphemeral, err := btcec.NewEllswiftPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	ss.mixHash(ourEphemeral[:])
+	err = ss.mixKey(ellswiftECDH(ephemeralKey, &theirEphemeral,
+		&ourEphemeral, false))
+	if err != nil {
+		return nil, err
+	}
+
+	ourStatic, err := btcec.EllswiftEncode(staticKey.PubKey())
+	if err != nil {
+		return nil, err
+	}
+	encStatic, err := ss.encryptAndHash(ourStatic[:])
+	if err != nil {
+		return nil, err
+	}
+	err = ss.mixKey(ellswiftECDH(staticKey, &theirEphemeral, &ourStatic,
+		false))
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := newCertificate(staticKey.PubKey(), authorityKey, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	encCert, err := ss.encryptAndHash(cert.serialize())
+	if err != nil {
+		return nil, err
+	}
+
+	actTwo := make([]byte, 0, actTwoLen)
+	actTwo = append(actTwo, ourEphemeral[:]...)
+	actTwo = append(actTwo, encStatic...)
+	actTwo = append(actTwo, encCert...)
+	if _, err := conn.Write(actTwo); err != nil {
+		return nil, err
+	}
+
+	initiator, responder, err := ss.split()
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{conn: conn, send: responder, recv: initiator}, nil
+}
+
+// ClientHandshake performs the initiator side of the Noise handshake on the
+// passed connection.  The certificate of the server must be signed by the
+// passed x-only public authority key.
+func ClientHandshake(conn net.Conn, authorityKey *[32]byte) (*Conn, error) {
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	ss := newSymmetricState()
+
+	// -> e
+	ephemeralKey, ourEphemeral, err := btcec.NewEllswiftPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	ss.mixHash(ourEphemeral[:])
+	if _, err := ss.encryptAndHash(nil); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(ourEphemeral[:]); err != nil {
+		return nil, err
+	}
+
+	// <- e, ee, s, es, SIGNATURE_NOISE_MESSAGE
+	actTwo := make([]byte, actTwoLen)
+	if _, err := io.ReadFull(conn, actTwo); err != nil {
+		return nil, err
+	}
+	var theirEphemeral [btcec.EllswiftPubKeyLen]byte
+	copy(theirEphemeral[:], actTwo)
+	actTwo = actTwo[btcec.EllswiftPubKeyLen:]
+	ss.mixHash(theirEphemeral[:])
+	err = ss.mixKey(ellswiftECDH(ephemeralKey, &theirEphemeral,
+		&ourEphemeral, true))
+	if err != nil {
+		return nil, err
+	}
+
+	staticLen := btcec.EllswiftPubKeyLen + macLen
+	static, err := ss.decryptAndHash(actTwo[:staticLen])
+	if err != nil {
+		return nil, err
+	}
+	actTwo = actTwo[staticLen:]
+	var theirStatic [btcec.EllswiftPubKeyLen]byte
+	copy(theirStatic[:], static)
+	err = ss.mixKey(ellswiftECDH(ephemeralKey, &theirStatic,
+		&ourEphemeral, true))
+	if err != nil {
+		return nil, err
+	}
+
+	certBytes, err := ss.decryptAndHash(actTwo)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := parseCertificate(certBytes)
+	if err != nil {
+		return nil, err
+	}
+	staticKey := btcec.EllswiftDecode(&theirStatic)
+	if err := cert.verify(staticKey, authorityKey, time.Now()); err != nil {
+		return nil, err
+	}
+
+	initiator, responder, err := ss.split()
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{conn: conn, send: initiator, recv: responder}, nil
+}
+
+// EncodeAuthorityKey returns the x-only public authority key in the base58check
+// encoding used by Stratum V2 software to configure the authority key of a
+// server.
+func EncodeAuthorityKey(pubKey *btcec.PublicKey) string {
+	x := schnorr.SerializePubKey(pubKey)
+	return base58.CheckEncode(append([]byte{authorityKeyVersion >> 8},
+		x[:]...), authorityKeyVersion&0xff)
+}
+
+// DecodeAuthorityKey decodes an authority key encoded with EncodeAuthorityKey
+// into the x-only public key expected by ClientHandshake.
+func DecodeAuthorityKey(s string) (*[32]byte, error) {
+	decoded, version, err := base58.CheckDecode(s)
+	if err != nil {
+		return nil, err
+	}
+	if version != authorityKeyVersion&0xff || len(decoded) != 33 ||
+		decoded[0] != authorityKeyVersion>>8 {
+
+		return nil, errors.New("unsupported authority key encoding")
+	}
+	var key [32]byte
+	copy(key[:], decoded[1:])
+	return &key, nil
+}
+
+// WriteMessage encrypts and sends the passed message.
+func (c *Conn) WriteMessage(msg Message) error {
+	payload, err := encodeMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	// The header is encrypted on its own, followed by the payload split
+	// into chunks.
+	var header [frameHeaderLen]byte
+	header[2] = msg.MsgType()
+	header[3] = byte(len(payload))
+	header[4] = byte(len(payload) >> 8)
+	header[5] = byte(len(payload) >> 16)
+	numChunks := (len(payload) + maxChunkPlaintextLen - 1) /
+		maxChunkPlaintextLen
+	frame := make([]byte, 0, frameHeaderLen+len(payload)+
+		(numChunks+1)*macLen)
+	frame, err = c.send.encrypt(frame, nil, header[:])
+	if err != nil {
+		return err
+	}
+	for len(payload) > 0 {
+		chunkLen := len(payload)
+		if chunkLen > maxChunkPlaintextLen {
+			chunkLen = maxChunkPlaintextLen
+		}
+		frame, err = c.send.encrypt(frame, nil, payload[:chunkLen])
+		if err != nil {
+			return err
+		}
+		payload = payload[chunkLen:]
+	}
+
+	_, err = c.conn.Write(frame)
+	return err
+}
+
+// ReadMessage reads and decrypts the next message.  A MessageError is returned
+// for messages that can't be authenticated, have an unknown type or a
+// malformed payload.
+func (c *Conn) ReadMessage() (Message, error) {
+	encHeader := make([]byte, frameHeaderLen+macLen)
+	if _, err := io.ReadFull(c.conn, encHeader); err != nil {
+		return nil, err
+	}
+	header, err := c.recv.decrypt(nil, nil, encHeader)
+	if err != nil {
+		return nil, err
+	}
+	extensionType := uint16(header[0]) | uint16(header[1])<<8
+	msgType := header[2]
+	payloadLen := int(header[3]) | int(header[4])<<8 | int(header[5])<<16
+
+	numChunks := (payloadLen + maxChunkPlaintextLen - 1) /
+		maxChunkPlaintextLen
+	encPayload := make([]byte, payloadLen+numChunks*macLen)
+	if _, err := io.ReadFull(c.conn, encPayload); err != nil {
+		return nil, err
+	}
+	payload := make([]byte, 0, payloadLen)
+	for len(encPayload) > 0 {
+		chunkLen := len(encPayload)
+		if chunkLen > maxChunkLen {
+			chunkLen = maxChunkLen
+		}
+		payload, err = c.recv.decrypt(payload, nil, encPayload[:chunkLen])
+		if err != nil {
+			return nil, err
+		}
+		encPayload = encPayload[chunkLen:]
+	}
+
+	// None of the supported messages belong to an extension.
+	if extensionType&^channelMsgBit != 0 {
+		return nil, messageError(fmt.Sprintf("unsupported extension "+
+			"type %#04x", extensionType))
+	}
+	return decodeMessage(msgType, payload)
+}
+
+// RemoteAddr returns the address of the remote party.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
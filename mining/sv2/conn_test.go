@@ -0,0 +1,153 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sv2
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/btcec/schnorr"
+)
+
+// handshake performs the Noise handshake over an in-memory connection and
+// returns both ends.  The client uses the passed authority key to verify the
+// certificate of the server.
+func handshake(t *testing.T, authorityKey *btcec.PrivateKey,
+	clientAuthority *[32]byte) (*Conn, *Conn, error) {
+
+	staticKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to create static key: %v", err)
+	}
+
+	serverPipe, clientPipe := net.Pipe()
+	type result struct {
+		conn *Conn
+		err  error
+	}
+	serverResult := make(chan result, 1)
+	go func() {
+		conn, err := ServerHandshake(serverPipe, staticKey, authorityKey)
+		if err != nil {
+			serverPipe.Close()
+		}
+		serverResult <- result{conn, err}
+	}()
+
+	client, err := ClientHandshake(clientPipe, clientAuthority)
+	if err != nil {
+		clientPipe.Close()
+		<-serverResult
+		return nil, nil, err
+	}
+	res := <-serverResult
+	if res.err != nil {
+		t.Fatalf("server handshake failed: %v", res.err)
+	}
+	return res.conn, client, nil
+}
+
+// TestHandshake ensures both parties derive the same keys and can exchange
+// messages, including ones that span multiple chunks, and that clients reject
+// servers whose certificate isn't signed by the expected authority.
+func TestHandshake(t *testing.T) {
+	t.Parallel()
+
+	authorityKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to create authority key: %v", err)
+	}
+	authorityPubKey := schnorr.SerializePubKey(authorityKey.PubKey())
+
+	server, client, err := handshake(t, authorityKey, &authorityPubKey)
+	if err != nil {
+		t.Fatalf("client handshake failed: %v", err)
+	}
+	defer server.Close()
+	defer client.Close()
+
+	msgs := []Message{
+		&SetupConnection{
+			Protocol:   ProtocolTemplateDistribution,
+			MinVersion: ProtocolVersion,
+			MaxVersion: ProtocolVersion,
+		},
+		&RequestTransactionDataSuccess{
+			TemplateID: 1,
+			ExcessData: []byte{},
+			TransactionList: [][]byte{
+				bytes.Repeat([]byte{0x01}, 100000),
+				bytes.Repeat([]byte{0x02}, 70000),
+			},
+		},
+	}
+	for i, msg := range msgs {
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- client.WriteMessage(msg)
+		}()
+		got, err := server.ReadMessage()
+		if err != nil {
+			t.Fatalf("#%d: unable to read message: %v", i, err)
+		}
+		if err := <-errChan; err != nil {
+			t.Fatalf("#%d: unable to write message: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, msg) {
+			t.Errorf("#%d: mismatched message: got %#v, want %#v",
+				i, got, msg)
+		}
+	}
+
+	// The server can respond as well.
+	go server.WriteMessage(&SetupConnectionSuccess{UsedVersion: 2})
+	got, err := client.ReadMessage()
+	if err != nil {
+		t.Fatalf("unable to read response: %v", err)
+	}
+	if _, ok := got.(*SetupConnectionSuccess); !ok {
+		t.Errorf("unexpected response %T", got)
+	}
+
+	// A client expecting a different authority rejects the server.
+	otherKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to create key: %v", err)
+	}
+	otherPubKey := schnorr.SerializePubKey(otherKey.PubKey())
+	_, _, err = handshake(t, authorityKey, &otherPubKey)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("unexpected error for wrong authority: %v", err)
+	}
+}
+
+// TestAuthorityKeyEncoding ensures encoded authority keys decode to the x-only
+// public key and that other encodings are rejected.
+func TestAuthorityKeyEncoding(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to create key: %v", err)
+	}
+	encoded := EncodeAuthorityKey(privKey.PubKey())
+	decoded, err := DecodeAuthorityKey(encoded)
+	if err != nil {
+		t.Fatalf("unable to decode authority key: %v", err)
+	}
+	if *decoded != schnorr.SerializePubKey(privKey.PubKey()) {
+		t.Errorf("mismatched authority key: got %x, want %x", *decoded,
+			schnorr.SerializePubKey(privKey.PubKey()))
+	}
+
+	// A bitcoin address uses the same encoding with a different version.
+	_, err = DecodeAuthorityKey("1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2")
+	if err == nil {
+		t.Errorf("address decoded as authority key")
+	}
+}
@@ -0,0 +1,23 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package sv2 implements a Stratum V2 template provider.
+
+The template provider serves block templates to Stratum V2 pools and miners
+using the Template Distribution protocol and accepts the blocks they solve.
+Connections are encrypted and authenticated with the Noise protocol as
+described by the Stratum V2 specification.  The server proves its identity
+with a certificate signed by an authority key, whose x-only public key the
+clients must be configured with.
+
+Once a client has set up the connection, it announces the maximum size and
+signature operations of the coinbase outputs it adds with a
+CoinbaseOutputConstraints message.  The provider leaves room for them in all
+templates sent to the client afterwards.  When a block is connected, the
+provider sends a future template for the new tip followed by a SetNewPrevHash
+message.  In between, it periodically sends templates for the same tip when
+their fees exceed the ones of the last template by a configurable delta.
+*/
+package sv2
@@ -0,0 +1,25 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sv2
+
+// MessageError describes an issue with a message such as a malformed payload,
+// an unknown message type or a frame that failed authentication.
+//
+// This provides a mechanism for the caller to type assert the error to
+// differentiate between general io errors such as io.EOF and issues that
+// resulted from malformed messages.
+type MessageError struct {
+	Description string // Human readable description of the issue
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e *MessageError) Error() string {
+	return e.Description
+}
+
+// messageError creates an error for the given description.
+func messageError(desc string) *MessageError {
+	return &MessageError{Description: desc}
+}
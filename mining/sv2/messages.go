@@ -0,0 +1,432 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sv2
+
+import (
+	"fmt"
+)
+
+// ProtocolTemplateDistribution is the identifier of the Template Distribution
+// protocol used in the SetupConnection message.
+const ProtocolTemplateDistribution uint8 = 2
+
+// ProtocolVersion is the version of the Stratum V2 protocols implemented by
+// this package.
+const ProtocolVersion uint16 = 2
+
+// The message types of the common and the Template Distribution protocol
+// messages.
+const (
+	MsgTypeSetupConnection               uint8 = 0x00
+	MsgTypeSetupConnectionSuccess        uint8 = 0x01
+	MsgTypeSetupConnectionError          uint8 = 0x02
+	MsgTypeCoinbaseOutputConstraints     uint8 = 0x70
+	MsgTypeNewTemplate                   uint8 = 0x71
+	MsgTypeSetNewPrevHash                uint8 = 0x72
+	MsgTypeRequestTransactionData        uint8 = 0x73
+	MsgTypeRequestTransactionDataSuccess uint8 = 0x74
+	MsgTypeRequestTransactionDataError   uint8 = 0x75
+	MsgTypeSubmitSolution                uint8 = 0x76
+)
+
+// defaultCoinbaseOutputMaxAdditionalSigOps is the number of signature
+// operations assumed for the coinbase outputs of a client which sends the
+// older CoinbaseOutputDataSize message that lacks the field.
+const defaultCoinbaseOutputMaxAdditionalSigOps = 400
+
+// Message is a message of the Stratum V2 common or Template Distribution
+// protocol.
+type Message interface {
+	// MsgType returns the message type of the message.
+	MsgType() uint8
+
+	// encode serializes the payload of the message.
+	encode(e *encoder)
+
+	// decode deserializes the payload of the message.
+	decode(d *decoder)
+}
+
+// SetupConnection is sent by a client to initiate a connection.
+type SetupConnection struct {
+	Protocol        uint8
+	MinVersion      uint16
+	MaxVersion      uint16
+	Flags           uint32
+	EndpointHost    string
+	EndpointPort    uint16
+	Vendor          string
+	HardwareVersion string
+	Firmware        string
+	DeviceID        string
+}
+
+// MsgType returns the message type of the message.
+func (m *SetupConnection) MsgType() uint8 {
+	return MsgTypeSetupConnection
+}
+
+func (m *SetupConnection) encode(e *encoder) {
+	e.u8(m.Protocol)
+	e.u16(m.MinVersion)
+	e.u16(m.MaxVersion)
+	e.u32(m.Flags)
+	e.bytes255("endpoint_host", []byte(m.EndpointHost))
+	e.u16(m.EndpointPort)
+	e.bytes255("vendor", []byte(m.Vendor))
+	e.bytes255("hardware_version", []byte(m.HardwareVersion))
+	e.bytes255("firmware", []byte(m.Firmware))
+	e.bytes255("device_id", []byte(m.DeviceID))
+}
+
+func (m *SetupConnection) decode(d *decoder) {
+	m.Protocol = d.u8("protocol")
+	m.MinVersion = d.u16("min_version")
+	m.MaxVersion = d.u16("max_version")
+	m.Flags = d.u32("flags")
+	m.EndpointHost = string(d.bytes255("endpoint_host"))
+	m.EndpointPort = d.u16("endpoint_port")
+	m.Vendor = string(d.bytes255("vendor"))
+	m.HardwareVersion = string(d.bytes255("hardware_version"))
+	m.Firmware = string(d.bytes255("firmware"))
+	m.DeviceID = string(d.bytes255("device_id"))
+}
+
+// SetupConnectionSuccess is sent in response to a SetupConnection message when
+// the server accepts the connection.
+type SetupConnectionSuccess struct {
+	UsedVersion uint16
+	Flags       uint32
+}
+
+// MsgType returns the message type of the message.
+func (m *SetupConnectionSuccess) MsgType() uint8 {
+	return MsgTypeSetupConnectionSuccess
+}
+
+func (m *SetupConnectionSuccess) encode(e *encoder) {
+	e.u16(m.UsedVersion)
+	e.u32(m.Flags)
+}
+
+func (m *SetupConnectionSuccess) decode(d *decoder) {
+	m.UsedVersion = d.u16("used_version")
+	m.Flags = d.u32("flags")
+}
+
+// SetupConnectionError is sent in response to a SetupConnection message when
+// the server rejects the connection.
+type SetupConnectionError struct {
+	Flags     uint32
+	ErrorCode string
+}
+
+// MsgType returns the message type of the message.
+func (m *SetupConnectionError) MsgType() uint8 {
+	return MsgTypeSetupConnectionError
+}
+
+func (m *SetupConnectionError) encode(e *encoder) {
+	e.u32(m.Flags)
+	e.bytes255("error_code", []byte(m.ErrorCode))
+}
+
+func (m *SetupConnectionError) decode(d *decoder) {
+	m.Flags = d.u32("flags")
+	m.ErrorCode = string(d.bytes255("error_code"))
+}
+
+// CoinbaseOutputConstraints is sent by a client to announce the maximum size
+// and signature operations of the coinbase outputs it adds, so the server can
+// leave room for them in the templates.  The server doesn't send any templates
+// before it received this message.
+type CoinbaseOutputConstraints struct {
+	MaxAdditionalSize   uint32
+	MaxAdditionalSigOps uint16
+}
+
+// MsgType returns the message type of the message.
+func (m *CoinbaseOutputConstraints) MsgType() uint8 {
+	return MsgTypeCoinbaseOutputConstraints
+}
+
+func (m *CoinbaseOutputConstraints) encode(e *encoder) {
+	e.u32(m.MaxAdditionalSize)
+	e.u16(m.MaxAdditionalSigOps)
+}
+
+func (m *CoinbaseOutputConstraints) decode(d *decoder) {
+	m.MaxAdditionalSize = d.u32("coinbase_output_max_additional_size")
+
+	// Earlier revisions of the protocol named this message
+	// CoinbaseOutputDataSize and didn't include the signature operations.
+	if d.err == nil && len(d.buf) == 0 {
+		m.MaxAdditionalSigOps = defaultCoinbaseOutputMaxAdditionalSigOps
+		return
+	}
+	m.MaxAdditionalSigOps = d.u16("coinbase_output_max_additional_sigops")
+}
+
+// NewTemplate is sent by the server to provide a new block template.  Future
+// templates are for a block building on a previous block which is announced
+// by a later SetNewPrevHash message referring to the template.
+type NewTemplate struct {
+	TemplateID               uint64
+	FutureTemplate           bool
+	Version                  uint32
+	CoinbaseTxVersion        uint32
+	CoinbasePrefix           []byte
+	CoinbaseTxInputSequence  uint32
+	CoinbaseTxValueRemaining uint64
+	CoinbaseTxOutputsCount   uint32
+	CoinbaseTxOutputs        []byte
+	CoinbaseTxLocktime       uint32
+	MerklePath               [][32]byte
+}
+
+// MsgType returns the message type of the message.
+func (m *NewTemplate) MsgType() uint8 {
+	return MsgTypeNewTemplate
+}
+
+func (m *NewTemplate) encode(e *encoder) {
+	e.u64(m.TemplateID)
+	e.boolean(m.FutureTemplate)
+	e.u32(m.Version)
+	e.u32(m.CoinbaseTxVersion)
+	e.bytes255("coinbase_prefix", m.CoinbasePrefix)
+	e.u32(m.CoinbaseTxInputSequence)
+	e.u64(m.CoinbaseTxValueRemaining)
+	e.u32(m.CoinbaseTxOutputsCount)
+	e.bytes64K("coinbase_tx_outputs", m.CoinbaseTxOutputs)
+	e.u32(m.CoinbaseTxLocktime)
+	if len(m.MerklePath) > maxBytes255 {
+		e.fail("merkle_path", len(m.MerklePath), maxBytes255)
+		return
+	}
+	e.u8(uint8(len(m.MerklePath)))
+	for i := range m.MerklePath {
+		e.u256(&m.MerklePath[i])
+	}
+}
+
+func (m *NewTemplate) decode(d *decoder) {
+	m.TemplateID = d.u64("template_id")
+	m.FutureTemplate = d.boolean("future_template")
+	m.Version = d.u32("version")
+	m.CoinbaseTxVersion = d.u32("coinbase_tx_version")
+	m.CoinbasePrefix = d.bytes255("coinbase_prefix")
+	m.CoinbaseTxInputSequence = d.u32("coinbase_tx_input_sequence")
+	m.CoinbaseTxValueRemaining = d.u64("coinbase_tx_value_remaining")
+	m.CoinbaseTxOutputsCount = d.u32("coinbase_tx_outputs_count")
+	m.CoinbaseTxOutputs = d.bytes64K("coinbase_tx_outputs")
+	m.CoinbaseTxLocktime = d.u32("coinbase_tx_locktime")
+	count := d.u8("merkle_path")
+	m.MerklePath = make([][32]byte, 0, count)
+	for i := uint8(0); i < count && d.err == nil; i++ {
+		m.MerklePath = append(m.MerklePath, d.u256("merkle_path"))
+	}
+}
+
+// SetNewPrevHash is sent by the server when a new block was found.  It refers
+// to a future template previously sent for the new block, which is the one to
+// work on from now on.
+type SetNewPrevHash struct {
+	TemplateID      uint64
+	PrevHash        [32]byte
+	HeaderTimestamp uint32
+	NBits           uint32
+	Target          [32]byte
+}
+
+// MsgType returns the message type of the message.
+func (m *SetNewPrevHash) MsgType() uint8 {
+	return MsgTypeSetNewPrevHash
+}
+
+func (m *SetNewPrevHash) encode(e *encoder) {
+	e.u64(m.TemplateID)
+	e.u256(&m.PrevHash)
+	e.u32(m.HeaderTimestamp)
+	e.u32(m.NBits)
+	e.u256(&m.Target)
+}
+
+func (m *SetNewPrevHash) decode(d *decoder) {
+	m.TemplateID = d.u64("template_id")
+	m.PrevHash = d.u256("prev_hash")
+	m.HeaderTimestamp = d.u32("header_timestamp")
+	m.NBits = d.u32("n_bits")
+	m.Target = d.u256("target")
+}
+
+// RequestTransactionData is sent by a client to request the transactions of a
+// template.
+type RequestTransactionData struct {
+	TemplateID uint64
+}
+
+// MsgType returns the message type of the message.
+func (m *RequestTransactionData) MsgType() uint8 {
+	return MsgTypeRequestTransactionData
+}
+
+func (m *RequestTransactionData) encode(e *encoder) {
+	e.u64(m.TemplateID)
+}
+
+func (m *RequestTransactionData) decode(d *decoder) {
+	m.TemplateID = d.u64("template_id")
+}
+
+// RequestTransactionDataSuccess is sent in response to a
+// RequestTransactionData message with the serialized transactions of the
+// template, excluding the coinbase.
+type RequestTransactionDataSuccess struct {
+	TemplateID      uint64
+	ExcessData      []byte
+	TransactionList [][]byte
+}
+
+// MsgType returns the message type of the message.
+func (m *RequestTransactionDataSuccess) MsgType() uint8 {
+	return MsgTypeRequestTransactionDataSuccess
+}
+
+func (m *RequestTransactionDataSuccess) encode(e *encoder) {
+	e.u64(m.TemplateID)
+	e.bytes64K("excess_data", m.ExcessData)
+	if len(m.TransactionList) > maxBytes64K {
+		e.fail("transaction_list", len(m.TransactionList), maxBytes64K)
+		return
+	}
+	e.u16(uint16(len(m.TransactionList)))
+	for _, tx := range m.TransactionList {
+		e.bytes16M("transaction_list", tx)
+	}
+}
+
+func (m *RequestTransactionDataSuccess) decode(d *decoder) {
+	m.TemplateID = d.u64("template_id")
+	m.ExcessData = d.bytes64K("excess_data")
+	count := d.u16("transaction_list")
+	m.TransactionList = make([][]byte, 0, count)
+	for i := uint16(0); i < count && d.err == nil; i++ {
+		m.TransactionList = append(m.TransactionList,
+			d.bytes16M("transaction_list"))
+	}
+}
+
+// RequestTransactionDataError is sent in response to a RequestTransactionData
+// message when the transactions of the template are not available.
+type RequestTransactionDataError struct {
+	TemplateID uint64
+	ErrorCode  string
+}
+
+// MsgType returns the message type of the message.
+func (m *RequestTransactionDataError) MsgType() uint8 {
+	return MsgTypeRequestTransactionDataError
+}
+
+func (m *RequestTransactionDataError) encode(e *encoder) {
+	e.u64(m.TemplateID)
+	e.bytes255("error_code", []byte(m.ErrorCode))
+}
+
+func (m *RequestTransactionDataError) decode(d *decoder) {
+	m.TemplateID = d.u64("template_id")
+	m.ErrorCode = string(d.bytes255("error_code"))
+}
+
+// SubmitSolution is sent by a client when it found a block for a template.
+// The coinbase transaction is the full serialized coinbase created by the
+// client.
+type SubmitSolution struct {
+	TemplateID      uint64
+	Version         uint32
+	HeaderTimestamp uint32
+	HeaderNonce     uint32
+	CoinbaseTx      []byte
+}
+
+// MsgType returns the message type of the message.
+func (m *SubmitSolution) MsgType() uint8 {
+	return MsgTypeSubmitSolution
+}
+
+func (m *SubmitSolution) encode(e *encoder) {
+	e.u64(m.TemplateID)
+	e.u32(m.Version)
+	e.u32(m.HeaderTimestamp)
+	e.u32(m.HeaderNonce)
+	e.bytes64K("coinbase_tx", m.CoinbaseTx)
+}
+
+func (m *SubmitSolution) decode(d *decoder) {
+	m.TemplateID = d.u64("template_id")
+	m.Version = d.u32("version")
+	m.HeaderTimestamp = d.u32("header_timestamp")
+	m.HeaderNonce = d.u32("header_nonce")
+	m.CoinbaseTx = d.bytes64K("coinbase_tx")
+}
+
+// makeEmptyMessage returns a message of the passed type which is ready to be
+// decoded into.
+func makeEmptyMessage(msgType uint8) (Message, error) {
+	switch msgType {
+	case MsgTypeSetupConnection:
+		return &SetupConnection{}, nil
+	case MsgTypeSetupConnectionSuccess:
+		return &SetupConnectionSuccess{}, nil
+	case MsgTypeSetupConnectionError:
+		return &SetupConnectionError{}, nil
+	case MsgTypeCoinbaseOutputConstraints:
+		return &CoinbaseOutputConstraints{}, nil
+	case MsgTypeNewTemplate:
+		return &NewTemplate{}, nil
+	case MsgTypeSetNewPrevHash:
+		return &SetNewPrevHash{}, nil
+	case MsgTypeRequestTransactionData:
+		return &RequestTransactionData{}, nil
+	case MsgTypeRequestTransactionDataSuccess:
+		return &RequestTransactionDataSuccess{}, nil
+	case MsgTypeRequestTransactionDataError:
+		return &RequestTransactionDataError{}, nil
+	case MsgTypeSubmitSolution:
+		return &SubmitSolution{}, nil
+	}
+
+	return nil, messageError(fmt.Sprintf("unknown message type %#02x",
+		msgType))
+}
+
+// encodeMessage serializes the payload of the passed message.
+func encodeMessage(msg Message) ([]byte, error) {
+	var e encoder
+	msg.encode(&e)
+	if e.err != nil {
+		return nil, e.err
+	}
+	if len(e.buf) > maxBytes16M {
+		return nil, messageError(fmt.Sprintf("payload of message type "+
+			"%#02x is too large: %d bytes", msg.MsgType(), len(e.buf)))
+	}
+	return e.buf, nil
+}
+
+// decodeMessage deserializes a message of the passed type from its payload.
+func decodeMessage(msgType uint8, payload []byte) (Message, error) {
+	msg, err := makeEmptyMessage(msgType)
+	if err != nil {
+		return nil, err
+	}
+	d := decoder{buf: payload}
+	msg.decode(&d)
+	if err := d.finish(); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sv2
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestMessageRoundTrip ensures all messages survive encoding and decoding.
+func TestMessageRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []Message{
+		&SetupConnection{
+			Protocol:        ProtocolTemplateDistribution,
+			MinVersion:      2,
+			MaxVersion:      2,
+			Flags:           1,
+			EndpointHost:    "127.0.0.1",
+			EndpointPort:    8336,
+			Vendor:          "btcd",
+			HardwareVersion: "",
+			Firmware:        "0.24.0",
+			DeviceID:        "device",
+		},
+		&SetupConnectionSuccess{UsedVersion: 2, Flags: 3},
+		&SetupConnectionError{Flags: 1, ErrorCode: "unsupported-protocol"},
+		&CoinbaseOutputConstraints{
+			MaxAdditionalSize:   100,
+			MaxAdditionalSigOps: 20,
+		},
+		&NewTemplate{
+			TemplateID:               1,
+			FutureTemplate:           true,
+			Version:                  0x20000000,
+			CoinbaseTxVersion:        2,
+			CoinbasePrefix:           []byte{0x03, 0x01, 0x02, 0x03},
+			CoinbaseTxInputSequence:  0xffffffff,
+			CoinbaseTxValueRemaining: 5000000000,
+			CoinbaseTxOutputsCount:   1,
+			CoinbaseTxOutputs:        []byte{0x00, 0x01, 0x6a},
+			CoinbaseTxLocktime:       0,
+			MerklePath:               [][32]byte{{0x01}, {0x02}},
+		},
+		&SetNewPrevHash{
+			TemplateID:      1,
+			PrevHash:        [32]byte{0x03},
+			HeaderTimestamp: 1700000000,
+			NBits:           0x207fffff,
+			Target:          [32]byte{31: 0x7f},
+		},
+		&RequestTransactionData{TemplateID: 2},
+		&RequestTransactionDataSuccess{
+			TemplateID:      2,
+			ExcessData:      []byte{},
+			TransactionList: [][]byte{{0x01, 0x02}, {0x03}},
+		},
+		&RequestTransactionDataError{
+			TemplateID: 2,
+			ErrorCode:  "stale-template-id",
+		},
+		&SubmitSolution{
+			TemplateID:      2,
+			Version:         0x20000000,
+			HeaderTimestamp: 1700000000,
+			HeaderNonce:     42,
+			CoinbaseTx:      []byte{0x01, 0x02, 0x03},
+		},
+	}
+
+	for i, msg := range tests {
+		payload, err := encodeMessage(msg)
+		if err != nil {
+			t.Errorf("#%d: unable to encode %T: %v", i, msg, err)
+			continue
+		}
+		decoded, err := decodeMessage(msg.MsgType(), payload)
+		if err != nil {
+			t.Errorf("#%d: unable to decode %T: %v", i, msg, err)
+			continue
+		}
+		if !reflect.DeepEqual(decoded, msg) {
+			t.Errorf("#%d: mismatched message: got %#v, want %#v",
+				i, decoded, msg)
+		}
+	}
+}
+
+// TestMessageErrors ensures malformed messages are rejected.
+func TestMessageErrors(t *testing.T) {
+	t.Parallel()
+
+	// Strings that exceed the length of their field can't be encoded.
+	_, err := encodeMessage(&SetupConnectionError{
+		ErrorCode: strings.Repeat("x", 256),
+	})
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("oversized field: unexpected error %v", err)
+	}
+
+	// Truncated payloads and trailing bytes are rejected.
+	payload, err := encodeMessage(&RequestTransactionData{TemplateID: 1})
+	if err != nil {
+		t.Fatalf("unable to encode message: %v", err)
+	}
+	for _, p := range [][]byte{payload[:7], append(payload, 0x00)} {
+		_, err := decodeMessage(MsgTypeRequestTransactionData, p)
+		if _, ok := err.(*MessageError); !ok {
+			t.Errorf("malformed payload %x: unexpected error %v",
+				p, err)
+		}
+	}
+
+	// Unknown message types are rejected.
+	_, err = decodeMessage(0xff, nil)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("unknown message type: unexpected error %v", err)
+	}
+
+	// The older form of CoinbaseOutputConstraints without the signature
+	// operations is accepted.
+	msg, err := decodeMessage(MsgTypeCoinbaseOutputConstraints,
+		[]byte{0x64, 0x00, 0x00, 0x00})
+	if err != nil {
+		t.Fatalf("unable to decode old coinbase output constraints: %v",
+			err)
+	}
+	want := &CoinbaseOutputConstraints{
+		MaxAdditionalSize:   100,
+		MaxAdditionalSigOps: defaultCoinbaseOutputMaxAdditionalSigOps,
+	}
+	if !reflect.DeepEqual(msg, want) {
+		t.Errorf("mismatched old coinbase output constraints: got %#v, "+
+			"want %#v", msg, want)
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sv2
+
+import (
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/btcec/schnorr"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// noiseProtocolName is the name of the Noise protocol used to encrypt
+	// Stratum V2 connections.  Its hash initializes the handshake state.
+	noiseProtocolName = "Noise_NX_Secp256k1+EllSwift_ChaChaPoly_SHA256"
+
+	// macLen is the length of the authentication tag appended to every
+	// encrypted message.
+	macLen = 16
+
+	// certificateLen is the length of a serialized certificate, which is
+	// the SIGNATURE_NOISE_MESSAGE sent by the responder.
+	certificateLen = 2 + 4 + 4 + 64
+
+	// actOneLen is the length of the first handshake message, which is the
+	// ephemeral key of the initiator.
+	actOneLen = btcec.EllswiftPubKeyLen
+
+	// actTwoLen is the length of the second handshake message, which holds
+	// the ephemeral key of the responder along with its encrypted static
+	// key and certificate.
+	actTwoLen = btcec.EllswiftPubKeyLen + btcec.EllswiftPubKeyLen + macLen +
+		certificateLen + macLen
+
+	// certificateVersion is the version of the certificates created and
+	// accepted by this package.
+	certificateVersion = 0
+
+	// certificateSkew is how long before and after the time of the
+	// handshake the certificates created by the responder are valid.  It
+	// accounts for clocks that are not in sync.
+	certificateSkew = time.Hour
+
+	// handshakeTimeout is the maximum duration of a handshake.
+	handshakeTimeout = 10 * time.Second
+)
+
+var (
+	// errAuthFailed is returned when a message can't be decrypted.
+	errAuthFailed = messageError("message authentication failed")
+
+	// errNonceExhausted is returned when a cipher state ran out of nonces.
+	errNonceExhausted = errors.New("nonces exhausted")
+)
+
+// cipherState encrypts or decrypts the messages sent in one direction of a
+// connection.
+type cipherState struct {
+	aead  cipher.AEAD
+	nonce uint64
+}
+
+// newCipherState returns a cipher state for the passed key.
+func newCipherState(key []byte) (*cipherState, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	return &cipherState{aead: aead}, nil
+}
+
+// nextNonce returns the nonce for the next message and increments the
+// counter.  The nonce consists of 32 bits of zeros followed by the
+// little-endian counter as required by Noise.
+func (c *cipherState) nextNonce() ([]byte, error) {
+	if c.nonce == ^uint64(0) {
+		return nil, errNonceExhausted
+	}
+	var nonce [chacha20poly1305.NonceSize]byte
+	binary.LittleEndian.PutUint64(nonce[4:], c.nonce)
+	c.nonce++
+	return nonce[:], nil
+}
+
+// encrypt encrypts and authenticates the plaintext along with the associated
+// data and appends the result to dst.
+func (c *cipherState) encrypt(dst, ad, plaintext []byte) ([]byte, error) {
+	nonce, err := c.nextNonce()
+	if err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(dst, nonce, plaintext, ad), nil
+}
+
+// decrypt authenticates and decrypts the ciphertext along with the associated
+// data and appends the result to dst.
+func (c *cipherState) decrypt(dst, ad, ciphertext []byte) ([]byte, error) {
+	nonce, err := c.nextNonce()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := c.aead.Open(dst, nonce, ciphertext, ad)
+	if err != nil {
+		return nil, errAuthFailed
+	}
+	return plaintext, nil
+}
+
+// symmetricState is the state of the Noise handshake which is shared by both
+// parties, consisting of the chaining key, the handshake hash and the current
+// cipher state.
+type symmetricState struct {
+	ck [32]byte
+	h  [32]byte
+	cs *cipherState
+}
+
+// newSymmetricState returns the initial handshake state.  The protocol name
+// is longer than the hash, so it is hashed to initialize the state, and the
+// empty prologue is mixed into the handshake hash.
+func newSymmetricState() *symmetricState {
+	s := &symmetricState{h: sha256.Sum256([]byte(noiseProtocolName))}
+	s.ck = s.h
+	s.mixHash(nil)
+	return s
+}
+
+// mixHash mixes the passed data into the handshake hash.
+func (s *symmetricState) mixHash(data []byte) {
+	h := sha256.New()
+	h.Write(s.h[:])
+	h.Write(data)
+	h.Sum(s.h[:0])
+}
+
+// hkdf2 derives two keys from the chaining key and the input key material as
+// defined by Noise.
+func hkdf2(ck *[32]byte, ikm []byte) ([32]byte, [32]byte) {
+	var out1, out2 [32]byte
+	r := hkdf.New(sha256.New, ikm, ck[:], nil)
+	io.ReadFull(r, out1[:])
+	io.ReadFull(r, out2[:])
+	return out1, out2
+}
+
+// mixKey mixes the passed input key material into the chaining key and
+// derives a new cipher state from it.
+func (s *symmetricState) mixKey(ikm []byte) error {
+	ck, key := hkdf2(&s.ck, ikm)
+	cs, err := newCipherState(key[:])
+	if err != nil {
+		return err
+	}
+	s.ck = ck
+	s.cs = cs
+	return nil
+}
+
+// encryptAndHash encrypts the plaintext with the handshake hash as associated
+// data, if there is a key yet, and mixes the result into the handshake hash.
+func (s *symmetricState) encryptAndHash(plaintext []byte) ([]byte, error) {
+	ciphertext := plaintext
+	if s.cs != nil {
+		var err error
+		ciphertext, err = s.cs.encrypt(nil, s.h[:], plaintext)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.mixHash(ciphertext)
+	return ciphertext, nil
+}
+
+// decryptAndHash is the counterpart of encryptAndHash.
+func (s *symmetricState) decryptAndHash(ciphertext []byte) ([]byte, error) {
+	plaintext := ciphertext
+	if s.cs != nil {
+		var err error
+		plaintext, err = s.cs.decrypt(nil, s.h[:], ciphertext)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.mixHash(ciphertext)
+	return plaintext, nil
+}
+
+// split derives the cipher states for the messages sent by the initiator and
+// the responder once the handshake is complete.
+func (s *symmetricState) split() (*cipherState, *cipherState, error) {
+	k1, k2 := hkdf2(&s.ck, nil)
+	initiator, err := newCipherState(k1[:])
+	if err != nil {
+		return nil, nil, err
+	}
+	responder, err := newCipherState(k2[:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return initiator, responder, nil
+}
+
+// certificate is the SIGNATURE_NOISE_MESSAGE sent by the responder during the
+// handshake.  It proves that the static key of the responder was authorized
+// by the authority key known to the initiator for the given period of time.
+type certificate struct {
+	version       uint16
+	validFrom     uint32
+	notValidAfter uint32
+	signature     [64]byte
+}
+
+// sigHash returns the hash signed by the authority key, which commits to the
+// validity of the certificate and the x-only static key of the responder.
+func (c *certificate) sigHash(staticKey *btcec.PublicKey) [32]byte {
+	var buf [2 + 4 + 4 + 32]byte
+	binary.LittleEndian.PutUint16(buf[0:], c.version)
+	binary.LittleEndian.PutUint32(buf[2:], c.validFrom)
+	binary.LittleEndian.PutUint32(buf[6:], c.notValidAfter)
+	x := schnorr.SerializePubKey(staticKey)
+	copy(buf[10:], x[:])
+	return sha256.Sum256(buf[:])
+}
+
+// serialize returns the serialized certificate.
+func (c *certificate) serialize() []byte {
+	buf := make([]byte, certificateLen)
+	binary.LittleEndian.PutUint16(buf[0:], c.version)
+	binary.LittleEndian.PutUint32(buf[2:], c.validFrom)
+	binary.LittleEndian.PutUint32(buf[6:], c.notValidAfter)
+	copy(buf[10:], c.signature[:])
+	return buf
+}
+
+// parseCertificate deserializes a certificate.
+func parseCertificate(buf []byte) (*certificate, error) {
+	if len(buf) != certificateLen {
+		return nil, messageError(fmt.Sprintf("invalid certificate "+
+			"length %d", len(buf)))
+	}
+	c := &certificate{
+		version:       binary.LittleEndian.Uint16(buf[0:]),
+		validFrom:     binary.LittleEndian.Uint32(buf[2:]),
+		notValidAfter: binary.LittleEndian.Uint32(buf[6:]),
+	}
+	copy(c.signature[:], buf[10:])
+	return c, nil
+}
+
+// newCertificate returns a certificate for the passed static key signed by
+// the authority key which is valid around the passed time.
+func newCertificate(staticKey *btcec.PublicKey,
+	authorityKey *btcec.PrivateKey, now time.Time) (*certificate, error) {
+
+	c := &certificate{
+		version:       certificateVersion,
+		validFrom:     uint32(now.Add(-certificateSkew).Unix()),
+		notValidAfter: uint32(now.Add(certificateSkew).Unix()),
+	}
+
+	sigHash := c.sigHash(staticKey)
+	sig, err := schnorr.Sign(authorityKey, &sigHash)
+	if err != nil {
+		return nil, err
+	}
+	c.signature = sig
+	return c, nil
+}
+
+// verify ensures the certificate authorizes the passed static key at the
+// passed time and is signed by the x-only authority key.
+func (c *certificate) verify(staticKey *btcec.PublicKey,
+	authorityKey *[32]byte, now time.Time) error {
+
+	if c.version != certificateVersion {
+		return messageError(fmt.Sprintf("unsupported certificate "+
+			"version %d", c.version))
+	}
+	unixNow := now.Unix()
+	if unixNow < int64(c.validFrom) || unixNow > int64(c.notValidAfter) {
+		return messageError("certificate is not valid at this time")
+	}
+	sigHash := c.sigHash(staticKey)
+	if !schnorr.Verify(authorityKey, &sigHash, &c.signature) {
+		return messageError("invalid certificate signature")
+	}
+	return nil
+}
+
+// ellswiftECDH returns the shared secret of the passed private key and the
+// ElligatorSwift encoded public key of the remote party.  It uses the BIP-324
+// hash function, which requires knowing which party initiated the connection.
+func ellswiftECDH(privKey *btcec.PrivateKey, theirs,
+	ours *[btcec.EllswiftPubKeyLen]byte, initiator bool) []byte {
+
+	secret := btcec.EllswiftBIP324ECDH(privKey, theirs, ours, initiator)
+	return secret[:]
+}
@@ -0,0 +1,738 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sv2
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mining"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// DefaultInterval is the default interval at which the provider checks
+	// whether templates with higher fees are available.
+	DefaultInterval = 30 * time.Second
+
+	// DefaultFeeDelta is the default increase of the fees of a template
+	// over the last one sent to a client which is required to send it.
+	DefaultFeeDelta = btcutil.Amount(1000)
+)
+
+// The error codes sent to clients in SetupConnectionError and
+// RequestTransactionDataError messages.
+const (
+	errCodeUnsupportedProtocol = "unsupported-protocol"
+	errCodeVersionMismatch     = "protocol-version-mismatch"
+	errCodeTemplateNotFound    = "template-id-not-found"
+	errCodeStaleTemplate       = "stale-template-id"
+)
+
+// errProtocolViolation is returned when a client sends a message it isn't
+// supposed to send at that point of the connection.
+var errProtocolViolation = errors.New("protocol violation")
+
+// Config is a descriptor containing the template provider configuration.
+type Config struct {
+	// Listeners defines a slice of listeners for which the provider will
+	// accept Stratum V2 connections.
+	Listeners []net.Listener
+
+	// Chain is the chain the templates build on.  The provider subscribes
+	// to its notifications to send new templates as soon as a block is
+	// connected.
+	Chain *blockchain.BlockChain
+
+	// BlockTemplateGenerator identifies the instance to use in order to
+	// generate the block templates sent to the clients.
+	BlockTemplateGenerator *mining.BlkTmplGenerator
+
+	// ProcessBlock defines the function to call with any solved blocks.
+	// It typically must run the provided block through the same set of
+	// rules and handling as any other block coming from the network.
+	ProcessBlock func(*btcutil.Block, blockchain.BehaviorFlags) (bool, error)
+
+	// IsCurrent defines the function to use to obtain whether or not the
+	// block chain is current.  No templates are sent while the chain is
+	// not current since there is no point in mining on an old block.
+	IsCurrent func() bool
+
+	// StaticKey is the long-term key the provider uses for the Noise
+	// handshake.
+	StaticKey *btcec.PrivateKey
+
+	// AuthorityKey is the key which signs the certificates for the static
+	// key.  Clients must be configured with its x-only public key.
+	AuthorityKey *btcec.PrivateKey
+
+	// Interval is the interval at which the provider checks whether a
+	// template with higher fees than the last one sent to each client is
+	// available.  DefaultInterval is used when it is zero.
+	Interval time.Duration
+
+	// FeeDelta is the minimum increase of the fees of a template over the
+	// last one sent to a client for the template to be sent.
+	FeeDelta btcutil.Amount
+}
+
+// TemplateProvider provides block templates to Stratum V2 pools and miners
+// using the Template Distribution protocol and accepts the blocks they solve.
+type TemplateProvider struct {
+	cfg Config
+
+	mtx            sync.Mutex
+	clients        map[*client]struct{}
+	nextTemplateID uint64
+	started        bool
+	shutdown       bool
+
+	newBlock chan struct{}
+	quit     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New returns a new Stratum V2 template provider for the provided
+// configuration.  Use Start to begin accepting connections.
+func New(cfg *Config) *TemplateProvider {
+	p := &TemplateProvider{
+		cfg:      *cfg,
+		clients:  make(map[*client]struct{}),
+		newBlock: make(chan struct{}, 1),
+		quit:     make(chan struct{}),
+	}
+	if p.cfg.Interval <= 0 {
+		p.cfg.Interval = DefaultInterval
+	}
+	p.cfg.Chain.Subscribe(p.handleBlockchainNotification)
+	return p
+}
+
+// Start begins accepting connections on the configured listeners and sending
+// templates to the connected clients.
+//
+// This function is safe for concurrent access.
+func (p *TemplateProvider) Start() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.started || p.shutdown {
+		return
+	}
+	p.started = true
+
+	for _, listener := range p.cfg.Listeners {
+		p.wg.Add(1)
+		go p.listenHandler(listener)
+	}
+	p.wg.Add(1)
+	go p.templateHandler()
+}
+
+// Stop closes the listeners and all client connections and waits for the
+// provider to shut down.
+//
+// This function is safe for concurrent access.
+func (p *TemplateProvider) Stop() {
+	p.mtx.Lock()
+	if p.shutdown {
+		p.mtx.Unlock()
+		return
+	}
+	p.shutdown = true
+	close(p.quit)
+	for _, listener := range p.cfg.Listeners {
+		listener.Close()
+	}
+	for c := range p.clients {
+		c.conn.Close()
+	}
+	p.mtx.Unlock()
+
+	p.wg.Wait()
+}
+
+// handleBlockchainNotification signals the template handler when a block is
+// connected to the main chain.
+func (p *TemplateProvider) handleBlockchainNotification(
+	notification *blockchain.Notification) {
+
+	if notification.Type != blockchain.NTBlockConnected {
+		return
+	}
+	select {
+	case p.newBlock <- struct{}{}:
+	default:
+	}
+}
+
+// listenHandler accepts connections on the passed listener until the
+// provider is stopped.
+//
+// This function MUST be run as a goroutine.
+func (p *TemplateProvider) listenHandler(listener net.Listener) {
+	defer p.wg.Done()
+
+	log.Infof("Stratum V2 template provider listening on %s",
+		listener.Addr())
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-p.quit:
+			default:
+				log.Errorf("Can't accept Stratum V2 "+
+					"connection: %v", err)
+			}
+			return
+		}
+
+		p.wg.Add(1)
+		go p.connHandler(conn)
+	}
+}
+
+// connHandler performs the handshake on a new connection and handles the
+// messages of the client until it disconnects.
+//
+// This function MUST be run as a goroutine.
+func (p *TemplateProvider) connHandler(netConn net.Conn) {
+	defer p.wg.Done()
+
+	conn, err := ServerHandshake(netConn, p.cfg.StaticKey,
+		p.cfg.AuthorityKey)
+	if err != nil {
+		log.Debugf("Stratum V2 handshake with %s failed: %v",
+			netConn.RemoteAddr(), err)
+		netConn.Close()
+		return
+	}
+
+	c := &client{
+		provider:  p,
+		conn:      conn,
+		templates: make(map[uint64]*mining.BlockTemplate),
+	}
+	p.mtx.Lock()
+	if p.shutdown {
+		p.mtx.Unlock()
+		conn.Close()
+		return
+	}
+	p.clients[c] = struct{}{}
+	p.mtx.Unlock()
+
+	log.Debugf("New Stratum V2 client %s", conn.RemoteAddr())
+	err = c.inHandler()
+	log.Debugf("Stratum V2 client %s disconnected: %v", conn.RemoteAddr(),
+		err)
+
+	p.mtx.Lock()
+	delete(p.clients, c)
+	p.mtx.Unlock()
+	conn.Close()
+}
+
+// templateHandler sends new templates to the clients when a block is
+// connected and periodically when templates with higher fees are available.
+//
+// This function MUST be run as a goroutine.
+func (p *TemplateProvider) templateHandler() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.newBlock:
+		case <-ticker.C:
+		case <-p.quit:
+			return
+		}
+
+		if !p.cfg.IsCurrent() {
+			continue
+		}
+
+		p.mtx.Lock()
+		clients := make([]*client, 0, len(p.clients))
+		for c := range p.clients {
+			clients = append(clients, c)
+		}
+		p.mtx.Unlock()
+
+		for _, c := range clients {
+			if err := c.updateTemplate(); err != nil {
+				log.Debugf("Unable to send template to Stratum "+
+					"V2 client %s: %v", c.conn.RemoteAddr(),
+					err)
+				c.conn.Close()
+			}
+		}
+	}
+}
+
+// newTemplateID returns a new unique template id.
+func (p *TemplateProvider) newTemplateID() uint64 {
+	p.mtx.Lock()
+	p.nextTemplateID++
+	id := p.nextTemplateID
+	p.mtx.Unlock()
+	return id
+}
+
+// client houses the state of a connected Stratum V2 client.
+type client struct {
+	provider *TemplateProvider
+	conn     *Conn
+
+	// The following fields are protected by mtx.  The templates are the
+	// templates sent to the client which build on the previous block
+	// announced last, keyed by their id.
+	mtx          sync.Mutex
+	setupDone    bool
+	constraints  *CoinbaseOutputConstraints
+	templates    map[uint64]*mining.BlockTemplate
+	prevHash     chainhash.Hash
+	fees         int64
+	lastTxUpdate time.Time
+}
+
+// inHandler reads and handles the messages of the client until the connection
+// is closed or the client violates the protocol.
+func (c *client) inHandler() error {
+	for {
+		msg, err := c.conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		c.mtx.Lock()
+		setupDone := c.setupDone
+		c.mtx.Unlock()
+
+		switch msg := msg.(type) {
+		case *SetupConnection:
+			if setupDone {
+				return errProtocolViolation
+			}
+			if err := c.handleSetupConnection(msg); err != nil {
+				return err
+			}
+
+		case *CoinbaseOutputConstraints:
+			if !setupDone {
+				return errProtocolViolation
+			}
+			if err := c.handleCoinbaseOutputConstraints(msg); err != nil {
+				return err
+			}
+
+		case *RequestTransactionData:
+			if !setupDone {
+				return errProtocolViolation
+			}
+			if err := c.handleRequestTransactionData(msg); err != nil {
+				return err
+			}
+
+		case *SubmitSolution:
+			if !setupDone {
+				return errProtocolViolation
+			}
+			c.handleSubmitSolution(msg)
+
+		default:
+			// The remaining messages are only sent by the server.
+			return errProtocolViolation
+		}
+	}
+}
+
+// handleSetupConnection accepts the connection when the client asks for the
+// Template Distribution protocol in a supported version.  An error is returned
+// when the connection is rejected.
+func (c *client) handleSetupConnection(msg *SetupConnection) error {
+	var errCode string
+	switch {
+	case msg.Protocol != ProtocolTemplateDistribution:
+		errCode = errCodeUnsupportedProtocol
+	case msg.MinVersion > ProtocolVersion || msg.MaxVersion < ProtocolVersion:
+		errCode = errCodeVersionMismatch
+	}
+	if errCode != "" {
+		err := c.conn.WriteMessage(&SetupConnectionError{
+			ErrorCode: errCode,
+		})
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("connection rejected: %s", errCode)
+	}
+
+	c.mtx.Lock()
+	c.setupDone = true
+	c.mtx.Unlock()
+
+	log.Debugf("Stratum V2 client %s set up connection (vendor %q, "+
+		"firmware %q)", c.conn.RemoteAddr(), msg.Vendor, msg.Firmware)
+	return c.conn.WriteMessage(&SetupConnectionSuccess{
+		UsedVersion: ProtocolVersion,
+	})
+}
+
+// handleCoinbaseOutputConstraints stores the constraints of the coinbase
+// outputs of the client and sends it a new template which respects them.
+func (c *client) handleCoinbaseOutputConstraints(
+	msg *CoinbaseOutputConstraints) error {
+
+	maxSize := uint32(blockchain.MaxBlockWeight / blockchain.WitnessScaleFactor)
+	if msg.MaxAdditionalSize >= maxSize {
+		return fmt.Errorf("coinbase output size %d exceeds the block "+
+			"size", msg.MaxAdditionalSize)
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.constraints = msg
+
+	// Force a new template even if the chain tip didn't change since
+	// earlier templates may not leave enough room for the coinbase
+	// outputs.
+	c.prevHash = chainhash.Hash{}
+	if !c.provider.cfg.IsCurrent() {
+		return nil
+	}
+	return c.sendTemplate()
+}
+
+// handleRequestTransactionData responds with the transactions of the
+// requested template.
+func (c *client) handleRequestTransactionData(
+	msg *RequestTransactionData) error {
+
+	c.mtx.Lock()
+	template, ok := c.templates[msg.TemplateID]
+	c.mtx.Unlock()
+
+	if !ok {
+		return c.conn.WriteMessage(&RequestTransactionDataError{
+			TemplateID: msg.TemplateID,
+			ErrorCode:  errCodeTemplateNotFound,
+		})
+	}
+	best := c.provider.cfg.BlockTemplateGenerator.BestSnapshot()
+	if template.Block.Header.PrevBlock != best.Hash {
+		return c.conn.WriteMessage(&RequestTransactionDataError{
+			TemplateID: msg.TemplateID,
+			ErrorCode:  errCodeStaleTemplate,
+		})
+	}
+
+	txns := template.Block.Transactions[1:]
+	reply := &RequestTransactionDataSuccess{
+		TemplateID:      msg.TemplateID,
+		TransactionList: make([][]byte, 0, len(txns)),
+	}
+	for _, tx := range txns {
+		var buf bytes.Buffer
+		buf.Grow(tx.SerializeSize())
+		if err := tx.Serialize(&buf); err != nil {
+			return err
+		}
+		reply.TransactionList = append(reply.TransactionList,
+			buf.Bytes())
+	}
+	return c.conn.WriteMessage(reply)
+}
+
+// handleSubmitSolution assembles the block solved by the client and processes
+// it like any other block.  Failures are only logged since the protocol has
+// no way to report them.
+func (c *client) handleSubmitSolution(msg *SubmitSolution) {
+	c.mtx.Lock()
+	template, ok := c.templates[msg.TemplateID]
+	c.mtx.Unlock()
+
+	if !ok {
+		log.Debugf("Block submitted via Stratum V2 for unknown "+
+			"template %d", msg.TemplateID)
+		return
+	}
+
+	block, err := solvedBlock(template, msg)
+	if err != nil {
+		log.Debugf("Block submitted via Stratum V2 is malformed: %v",
+			err)
+		return
+	}
+
+	// Ensure the block is not stale since a new block could have shown up
+	// while the solution was being found.
+	msgBlock := block.MsgBlock()
+	best := c.provider.cfg.BlockTemplateGenerator.BestSnapshot()
+	if !msgBlock.Header.PrevBlock.IsEqual(&best.Hash) {
+		log.Debugf("Block submitted via Stratum V2 with previous "+
+			"block %s is stale", msgBlock.Header.PrevBlock)
+		return
+	}
+
+	// Process this block using the same rules as blocks coming from other
+	// nodes.  This will in turn relay it to the network like normal.
+	isOrphan, err := c.provider.cfg.ProcessBlock(block, blockchain.BFNone)
+	if err != nil {
+		// Anything other than a rule violation is an unexpected error,
+		// so log that error as an internal error.
+		if _, ok := err.(blockchain.RuleError); !ok {
+			log.Errorf("Unexpected error while processing "+
+				"block submitted via Stratum V2: %v", err)
+			return
+		}
+
+		log.Infof("Block submitted via Stratum V2 rejected: %v", err)
+		return
+	}
+	if isOrphan {
+		log.Debugf("Block submitted via Stratum V2 is an orphan")
+		return
+	}
+
+	// The block was accepted.
+	var amount int64
+	for _, txOut := range msgBlock.Transactions[0].TxOut {
+		amount += txOut.Value
+	}
+	log.Infof("Block submitted via Stratum V2 accepted (hash %s, "+
+		"amount %v)", block.Hash(), btcutil.Amount(amount))
+}
+
+// updateTemplate sends a new template to the client when the chain tip
+// changed or when a template with sufficiently higher fees is available.
+func (c *client) updateTemplate() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	// No templates are sent before the client announced its coinbase
+	// output constraints.
+	if c.constraints == nil {
+		return nil
+	}
+
+	g := c.provider.cfg.BlockTemplateGenerator
+	if g.BestSnapshot().Hash != c.prevHash {
+		return c.sendTemplate()
+	}
+	if g.TxSource().LastUpdated() == c.lastTxUpdate {
+		return nil
+	}
+	return c.sendTemplate()
+}
+
+// sendTemplate generates a new template for the client and sends it.  When the
+// template builds on a different block than the previous one, it is sent as a
+// future template followed by a SetNewPrevHash message, and the templates for
+// the old chain tip are discarded.  Otherwise the template is only sent when
+// its fees exceed the ones of the last template by the configured delta.
+//
+// This function MUST be called with the client lock held.
+func (c *client) sendTemplate() error {
+	p := c.provider
+	g := p.cfg.BlockTemplateGenerator
+
+	// The reserved weight and signature operation cost account for the
+	// non-witness outputs the client adds to the coinbase.
+	reservedWeight := int64(c.constraints.MaxAdditionalSize) *
+		blockchain.WitnessScaleFactor
+	reservedSigOpCost := int64(c.constraints.MaxAdditionalSigOps) *
+		blockchain.WitnessScaleFactor
+	lastTxUpdate := g.TxSource().LastUpdated()
+	template, err := g.NewReservedBlockTemplate(nil, reservedWeight,
+		reservedSigOpCost)
+	if err != nil {
+		return err
+	}
+
+	prevHash := template.Block.Header.PrevBlock
+	newPrevHash := prevHash != c.prevHash
+	fees := -template.Fees[0]
+	if !newPrevHash && fees < c.fees+int64(p.cfg.FeeDelta) {
+		c.lastTxUpdate = lastTxUpdate
+		return nil
+	}
+
+	id := p.newTemplateID()
+	msg, err := newTemplateMsg(id, template, newPrevHash)
+	if err != nil {
+		return err
+	}
+	if err := c.conn.WriteMessage(msg); err != nil {
+		return err
+	}
+	if newPrevHash {
+		err := c.conn.WriteMessage(newPrevHashMsg(id, template))
+		if err != nil {
+			return err
+		}
+		c.templates = make(map[uint64]*mining.BlockTemplate)
+		c.prevHash = prevHash
+	}
+	c.templates[id] = template
+	c.fees = fees
+	c.lastTxUpdate = lastTxUpdate
+
+	log.Debugf("Sent template %d with %d transactions and fees %v to "+
+		"Stratum V2 client %s", id, len(template.Block.Transactions)-1,
+		btcutil.Amount(fees), c.conn.RemoteAddr())
+	return nil
+}
+
+// newTemplateMsg returns the NewTemplate message for the passed template.
+// The coinbase of the template pays the block reward to the first output and
+// has the witness commitment, if any, as its second output.  The client
+// replaces the first output with its own outputs, which is why only the
+// remaining outputs are part of the message.
+func newTemplateMsg(id uint64, template *mining.BlockTemplate,
+	future bool) (*NewTemplate, error) {
+
+	msgBlock := template.Block
+	coinbase := msgBlock.Transactions[0]
+
+	// The coinbase script of the client must start with the height of the
+	// block as required by BIP0034.
+	prefix, err := txscript.NewScriptBuilder().
+		AddInt64(int64(template.Height)).Script()
+	if err != nil {
+		return nil, err
+	}
+
+	var outputs bytes.Buffer
+	for _, txOut := range coinbase.TxOut[1:] {
+		err := wire.WriteTxOut(&outputs, 0, coinbase.Version, txOut)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &NewTemplate{
+		TemplateID:               id,
+		FutureTemplate:           future,
+		Version:                  uint32(msgBlock.Header.Version),
+		CoinbaseTxVersion:        uint32(coinbase.Version),
+		CoinbasePrefix:           prefix,
+		CoinbaseTxInputSequence:  coinbase.TxIn[0].Sequence,
+		CoinbaseTxValueRemaining: uint64(coinbase.TxOut[0].Value),
+		CoinbaseTxOutputsCount:   uint32(len(coinbase.TxOut) - 1),
+		CoinbaseTxOutputs:        outputs.Bytes(),
+		CoinbaseTxLocktime:       coinbase.LockTime,
+		MerklePath:               merklePath(msgBlock.Transactions),
+	}, nil
+}
+
+// newPrevHashMsg returns the SetNewPrevHash message which activates the passed
+// template.
+func newPrevHashMsg(id uint64, template *mining.BlockTemplate) *SetNewPrevHash {
+	header := &template.Block.Header
+
+	// The target is a little-endian 256-bit integer.
+	var target [32]byte
+	targetBytes := blockchain.CompactToBig(header.Bits).Bytes()
+	for i, b := range targetBytes {
+		target[len(targetBytes)-1-i] = b
+	}
+
+	return &SetNewPrevHash{
+		TemplateID:      id,
+		PrevHash:        header.PrevBlock,
+		HeaderTimestamp: uint32(header.Timestamp.Unix()),
+		NBits:           header.Bits,
+		Target:          target,
+	}
+}
+
+// merklePath returns the hashes needed to compute the merkle root of the
+// passed transactions from the hash of the first one, which is the coinbase.
+// Since the client creates the coinbase, it is the only hash that isn't known
+// in advance.
+func merklePath(txns []*wire.MsgTx) [][32]byte {
+	hashes := make([]*chainhash.Hash, 0, len(txns)-1)
+	for _, tx := range txns[1:] {
+		hash := tx.TxHash()
+		hashes = append(hashes, &hash)
+	}
+
+	// At each level of the tree, the first hash is the sibling of the
+	// branch containing the coinbase, and the remaining hashes are
+	// combined into the next level.  A lone hash is combined with itself.
+	var path [][32]byte
+	for len(hashes) > 0 {
+		path = append(path, *hashes[0])
+		rest := hashes[1:]
+		next := make([]*chainhash.Hash, 0, (len(rest)+1)/2)
+		for i := 0; i < len(rest); i += 2 {
+			right := rest[i]
+			if i+1 < len(rest) {
+				right = rest[i+1]
+			}
+			next = append(next, blockchain.HashMerkleBranches(rest[i],
+				right))
+		}
+		hashes = next
+	}
+	return path
+}
+
+// solvedBlock assembles the block solved by a client from the template and the
+// header fields and coinbase of the solution.
+func solvedBlock(template *mining.BlockTemplate,
+	msg *SubmitSolution) (*btcutil.Block, error) {
+
+	var coinbase wire.MsgTx
+	if err := coinbase.Deserialize(bytes.NewReader(msg.CoinbaseTx)); err != nil {
+		return nil, err
+	}
+	if len(coinbase.TxIn) != 1 {
+		return nil, fmt.Errorf("coinbase has %d inputs",
+			len(coinbase.TxIn))
+	}
+
+	// Clients aren't required to include the witness nonce of the
+	// coinbase, so it is taken from the template when the block commits
+	// to witness data.
+	templateCoinbase := template.Block.Transactions[0]
+	if template.WitnessCommitment != nil && !coinbase.HasWitness() {
+		coinbase.TxIn[0].Witness = templateCoinbase.TxIn[0].Witness
+	}
+
+	msgBlock := &wire.MsgBlock{
+		Header: wire.BlockHeader{
+			Version:   int32(msg.Version),
+			PrevBlock: template.Block.Header.PrevBlock,
+			Timestamp: time.Unix(int64(msg.HeaderTimestamp), 0),
+			Bits:      template.Block.Header.Bits,
+			Nonce:     msg.HeaderNonce,
+		},
+		Transactions: make([]*wire.MsgTx, 0,
+			len(template.Block.Transactions)),
+	}
+	msgBlock.Transactions = append(msgBlock.Transactions, &coinbase)
+	msgBlock.Transactions = append(msgBlock.Transactions,
+		template.Block.Transactions[1:]...)
+
+	block := btcutil.NewBlock(msgBlock)
+	merkles := blockchain.BuildMerkleTreeStore(block.Transactions(), false)
+	msgBlock.Header.MerkleRoot = *merkles[len(merkles)-1]
+	return block, nil
+}
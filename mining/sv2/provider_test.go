@@ -0,0 +1,47 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sv2
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// TestMerklePath ensures the merkle root computed from the hash of the
+// coinbase and the merkle path matches the merkle root of the block for
+// various numbers of transactions.
+func TestMerklePath(t *testing.T) {
+	t.Parallel()
+
+	for numTxns := 1; numTxns <= 9; numTxns++ {
+		txns := make([]*wire.MsgTx, 0, numTxns)
+		for i := 0; i < numTxns; i++ {
+			tx := wire.NewMsgTx(wire.TxVersion)
+			tx.LockTime = uint32(i)
+			txns = append(txns, tx)
+		}
+
+		utilTxns := make([]*btcutil.Tx, 0, numTxns)
+		for _, tx := range txns {
+			utilTxns = append(utilTxns, btcutil.NewTx(tx))
+		}
+		merkles := blockchain.BuildMerkleTreeStore(utilTxns, false)
+		want := merkles[len(merkles)-1]
+
+		root := txns[0].TxHash()
+		for _, hash := range merklePath(txns) {
+			sibling := chainhash.Hash(hash)
+			root = *blockchain.HashMerkleBranches(&root, &sibling)
+		}
+		if !root.IsEqual(want) {
+			t.Errorf("%d transactions: mismatched merkle root: got "+
+				"%v, want %v", numTxns, root, want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package netsync
+
+import (
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	peerpkg "github.com/btcsuite/btcd/peer"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// maxBackgroundRequests is the maximum number of blocks requested for
+	// the background chain at a time.
+	maxBackgroundRequests = 16
+)
+
+// fetchBackgroundBlocks requests the next blocks leading up to the utxo
+// snapshot the chain was loaded from for the background chain.  The blocks are
+// only downloaded once the chain is current, so validating them doesn't slow
+// down the sync of the chain.
+func (sm *SyncManager) fetchBackgroundBlocks() {
+	if sm.backgroundChain == nil || !sm.current() {
+		return
+	}
+	snapshot, _ := sm.chain.UtxoSnapshot()
+
+	// Spread the requests over the sync candidates.
+	peers := make(map[*peerpkg.Peer]int)
+	for peer, state := range sm.peerStates {
+		if state.syncCandidate {
+			peers[peer] = 0
+		}
+	}
+	for _, req := range sm.backgroundRequests {
+		if _, ok := peers[req.peer]; ok {
+			peers[req.peer]++
+		}
+	}
+
+	requests := make(map[*peerpkg.Peer]*wire.MsgGetData)
+	now := time.Now()
+	best := sm.backgroundChain.BestSnapshot()
+	for height := best.Height + 1; height <= snapshot.Height &&
+		len(sm.backgroundRequests) < maxBackgroundRequests; height++ {
+
+		hash, err := sm.chain.BlockHashByHeight(height)
+		if err != nil {
+			log.Errorf("Failed to get the hash of block %d for "+
+				"the background chain: %v", height, err)
+			return
+		}
+		if _, ok := sm.backgroundRequests[*hash]; ok {
+			continue
+		}
+		if sm.backgroundChain.IsKnownOrphan(hash) {
+			continue
+		}
+
+		var peer *peerpkg.Peer
+		for p, numInFlight := range peers {
+			if p.LastBlock() < height {
+				continue
+			}
+			if peer == nil || numInFlight < peers[peer] {
+				peer = p
+			}
+		}
+		if peer == nil {
+			break
+		}
+		peers[peer]++
+
+		sm.requestedBlocks[*hash] = struct{}{}
+		sm.peerStates[peer].requestedBlocks[*hash] = struct{}{}
+		sm.backgroundRequests[*hash] = &blockRequest{
+			peer:      peer,
+			height:    height,
+			requested: now,
+		}
+
+		iv := wire.NewInvVect(wire.InvTypeBlock, hash)
+		if peer.IsWitnessEnabled() {
+			iv.Type = wire.InvTypeWitnessBlock
+		}
+		gdmsg, ok := requests[peer]
+		if !ok {
+			gdmsg = wire.NewMsgGetDataSizeHint(maxBackgroundRequests)
+			requests[peer] = gdmsg
+		}
+		gdmsg.AddInvVect(iv)
+	}
+	for peer, gdmsg := range requests {
+		peer.QueueMessage(gdmsg, nil)
+	}
+}
+
+// removeBackgroundRequests removes the blocks requested for the background
+// chain for which the passed function returns true, so they are requested
+// again.
+func (sm *SyncManager) removeBackgroundRequests(remove func(*blockRequest) bool) {
+	for hash, req := range sm.backgroundRequests {
+		if !remove(req) {
+			continue
+		}
+		delete(sm.backgroundRequests, hash)
+		delete(sm.requestedBlocks, hash)
+		if state, ok := sm.peerStates[req.peer]; ok {
+			delete(state.requestedBlocks, hash)
+		}
+	}
+}
+
+// handleBackgroundStall requests the blocks for the background chain again
+// which have been requested for longer than maxStallDuration.
+func (sm *SyncManager) handleBackgroundStall() {
+	if sm.backgroundChain == nil {
+		return
+	}
+	sm.removeBackgroundRequests(func(req *blockRequest) bool {
+		return time.Since(req.requested) > maxStallDuration
+	})
+	sm.fetchBackgroundBlocks()
+}
+
+// processBackgroundBlock processes a block requested for the background chain
+// and verifies the utxo snapshot the chain was loaded from once the background
+// chain reached the snapshot block.
+func (sm *SyncManager) processBackgroundBlock(block *btcutil.Block,
+	peer *peerpkg.Peer) {
+
+	_, _, err := sm.backgroundChain.ProcessBlock(block, blockchain.BFNone)
+	if err != nil {
+		log.Warnf("Failed to process block %v from %s for the "+
+			"background chain: %v -- disconnecting", block.Hash(),
+			peer, err)
+		peer.Disconnect()
+		return
+	}
+
+	snapshot, _ := sm.chain.UtxoSnapshot()
+	best := sm.backgroundChain.BestSnapshot()
+	if best.Height%10000 == 0 {
+		log.Infof("Validated blocks up to height %d of %d in the "+
+			"background", best.Height, snapshot.Height)
+	}
+	if best.Height < snapshot.Height {
+		sm.fetchBackgroundBlocks()
+		return
+	}
+
+	// Verify the utxo snapshot against the background chain now that it
+	// reached the snapshot block.
+	err = sm.chain.VerifyUtxoSnapshot(sm.backgroundChain)
+	if err != nil {
+		log.Errorf("Failed to verify utxo snapshot: %v", err)
+	}
+	sm.backgroundChain = nil
+	sm.removeBackgroundRequests(func(*blockRequest) bool { return true })
+	if sm.backgroundChainDone != nil {
+		sm.backgroundChainDone(err)
+	}
+}
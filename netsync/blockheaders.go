@@ -0,0 +1,286 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package netsync
+
+import (
+	"container/list"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	peerpkg "github.com/btcsuite/btcd/peer"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// maxPendingHeaders is the maximum number of headers of blocks which
+	// aren't connected to the block chain yet that are kept in memory
+	// outside of headers-first mode.
+	maxPendingHeaders = 2 * wire.MaxBlockHeadersPerMsg
+
+	// maxPendingBlockRequests is the maximum number of blocks of pending
+	// headers which may be requested from a single peer at once.
+	maxPendingBlockRequests = 128
+
+	// maxUnconnectingHeaders is the maximum number of consecutive headers
+	// messages which don't connect to any known block a peer may send
+	// before it is disconnected.
+	maxUnconnectingHeaders = 10
+)
+
+// pendingHeader houses a header of a block which isn't connected to the block
+// chain yet along with the peer which sent it.
+type pendingHeader struct {
+	hash    chainhash.Hash
+	peer    *peerpkg.Peer
+	element *list.Element
+}
+
+// pendingHeaders houses the headers of blocks which were announced outside of
+// headers-first mode and aren't connected to the block chain yet.  Each header
+// connects to either a known block or another pending header when it is added,
+// so the blocks are only requested and kept as orphans once their headers are
+// known to build on the block chain.  The number of headers is bounded and the
+// oldest headers are evicted first to make room for new ones.
+//
+// The pending headers are not safe for concurrent access.
+type pendingHeaders struct {
+	headers map[chainhash.Hash]*pendingHeader
+	order   *list.List
+	evicted uint64
+}
+
+// newPendingHeaders returns a new empty set of pending headers.
+func newPendingHeaders() *pendingHeaders {
+	return &pendingHeaders{
+		headers: make(map[chainhash.Hash]*pendingHeader),
+		order:   list.New(),
+	}
+}
+
+// has returns whether the header with the passed hash is pending.
+func (h *pendingHeaders) has(hash *chainhash.Hash) bool {
+	_, exists := h.headers[*hash]
+	return exists
+}
+
+// add adds the header with the passed hash received from the passed peer.  The
+// oldest header is evicted when the limit on the number of pending headers
+// would be exceeded otherwise.
+func (h *pendingHeaders) add(hash *chainhash.Hash, peer *peerpkg.Peer) {
+	if h.has(hash) {
+		return
+	}
+	if h.order.Len()+1 > maxPendingHeaders {
+		h.remove(&h.order.Front().Value.(*pendingHeader).hash)
+		h.evicted++
+	}
+	header := &pendingHeader{hash: *hash, peer: peer}
+	header.element = h.order.PushBack(header)
+	h.headers[*hash] = header
+}
+
+// remove removes the header with the passed hash if it is pending.
+func (h *pendingHeaders) remove(hash *chainhash.Hash) {
+	header, exists := h.headers[*hash]
+	if !exists {
+		return
+	}
+	h.order.Remove(header.element)
+	delete(h.headers, *hash)
+}
+
+// PendingHeaderStats houses statistics about the headers of blocks which
+// aren't connected to the block chain yet.
+type PendingHeaderStats struct {
+	// Count is the number of pending headers.
+	Count int
+
+	// Evicted is the total number of pending headers which were removed to
+	// make room for new ones.
+	Evicted uint64
+
+	// UnconnectedBlocks is the total number of blocks which were dropped
+	// since neither the block nor its header connected to the block chain.
+	UnconnectedBlocks uint64
+}
+
+// blockConnects returns whether the passed block builds on a known block or
+// its header is pending, which means the header is known to build on the
+// block chain.
+func (sm *SyncManager) blockConnects(block *btcutil.Block) bool {
+	if sm.pendingHeaders.has(block.Hash()) {
+		return true
+	}
+	prevHash := &block.MsgBlock().Header.PrevBlock
+	haveParent, err := sm.chain.HaveBlock(prevHash)
+	if err != nil {
+		log.Warnf("Unable to check existence of block %v: %v",
+			prevHash, err)
+		return false
+	}
+	return haveParent
+}
+
+// requestHeaders requests the headers from the latest known block up to the
+// passed stop hash from the passed peer.  Several requests may be in flight at
+// once, such as when multiple orphan blocks are received, so the number of
+// outstanding requests is tracked to accept a reply to each of them.
+func (sm *SyncManager) requestHeaders(peer *peerpkg.Peer, state *peerSyncState,
+	stopHash *chainhash.Hash) {
+
+	locator, err := sm.chain.LatestBlockLocator()
+	if err != nil {
+		log.Warnf("Failed to get block locator for the latest block: "+
+			"%v", err)
+		return
+	}
+	err = peer.PushGetHeadersMsg(locator, stopHash)
+	if err != nil {
+		log.Warnf("Failed to send getheaders message to peer %s: %v",
+			peer.Addr(), err)
+		return
+	}
+	state.requestedHeaders++
+}
+
+// handlePendingHeadersMsg handles headers messages requested outside of
+// headers-first mode.  The headers must connect to each other and the first one
+// must connect to a known block or a pending header.  The blocks of the headers
+// are requested once they were added to the pending headers.
+func (sm *SyncManager) handlePendingHeadersMsg(peer *peerpkg.Peer,
+	state *peerSyncState, msg *wire.MsgHeaders) {
+
+	state.requestedHeaders--
+	numHeaders := len(msg.Headers)
+	if numHeaders == 0 {
+		return
+	}
+
+	// Request the headers leading up to the received ones when they don't
+	// connect.  This happens when blocks were announced from a chain the
+	// peer didn't yet send the headers for, but a peer which keeps sending
+	// unconnecting headers is misbehaving.
+	prevHash := msg.Headers[0].PrevBlock
+	if !sm.pendingHeaders.has(&prevHash) {
+		haveParent, err := sm.chain.HaveBlock(&prevHash)
+		if err != nil {
+			log.Warnf("Unable to check existence of block %v: %v",
+				prevHash, err)
+			return
+		}
+		if !haveParent {
+			state.unconnectingHeaders++
+			if state.unconnectingHeaders >= maxUnconnectingHeaders {
+				log.Warnf("Received %d unconnecting headers "+
+					"messages from peer %s -- disconnecting",
+					state.unconnectingHeaders, peer.Addr())
+				peer.Disconnect()
+				return
+			}
+			lastHash := msg.Headers[numHeaders-1].BlockHash()
+			sm.requestHeaders(peer, state, &lastHash)
+			return
+		}
+	}
+	state.unconnectingHeaders = 0
+
+	// Add all of the headers of unknown blocks ensuring each one connects
+	// to the previous and has a valid proof of work.
+	for _, header := range msg.Headers {
+		if header.PrevBlock != prevHash {
+			log.Warnf("Received block header that does not "+
+				"properly connect to the previous one from "+
+				"peer %s -- disconnecting", peer.Addr())
+			peer.Disconnect()
+			return
+		}
+		err := blockchain.CheckHeaderProofOfWork(header,
+			sm.chainParams.PowLimit)
+		if err != nil {
+			log.Warnf("Received invalid block header from peer "+
+				"%s: %v -- disconnecting", peer.Addr(), err)
+			peer.Disconnect()
+			return
+		}
+
+		prevHash = header.BlockHash()
+		haveBlock, err := sm.chain.HaveBlock(&prevHash)
+		if err != nil {
+			log.Warnf("Unable to check existence of block %v: %v",
+				prevHash, err)
+			return
+		}
+		if !haveBlock {
+			sm.pendingHeaders.add(&prevHash, peer)
+		}
+	}
+
+	// Request more headers from the peer when the message was full since
+	// there are likely more of them.
+	if numHeaders == wire.MaxBlockHeadersPerMsg {
+		locator := blockchain.BlockLocator([]*chainhash.Hash{&prevHash})
+		err := peer.PushGetHeadersMsg(locator, &zeroHash)
+		if err != nil {
+			log.Warnf("Failed to send getheaders message to "+
+				"peer %s: %v", peer.Addr(), err)
+		} else {
+			state.requestedHeaders++
+		}
+	}
+
+	sm.fetchPendingBlocks()
+}
+
+// fetchPendingBlocks removes the pending headers of blocks which are known by
+// now and requests the blocks of the remaining ones from the peers which sent
+// the headers, oldest first.
+func (sm *SyncManager) fetchPendingBlocks() {
+	requests := make(map[*peerpkg.Peer]*wire.MsgGetData)
+	for e := sm.pendingHeaders.order.Front(); e != nil; {
+		header := e.Value.(*pendingHeader)
+		e = e.Next()
+
+		haveBlock, err := sm.chain.HaveBlock(&header.hash)
+		if err != nil {
+			log.Warnf("Unable to check existence of block %v: %v",
+				header.hash, err)
+			return
+		}
+		if haveBlock {
+			sm.pendingHeaders.remove(&header.hash)
+			continue
+		}
+		if _, exists := sm.requestedBlocks[header.hash]; exists {
+			continue
+		}
+
+		// Only request blocks from peers which are still connected and
+		// don't have too many blocks in flight already.
+		state, exists := sm.peerStates[header.peer]
+		if !exists || len(state.requestedBlocks) >=
+			maxPendingBlockRequests {
+
+			continue
+		}
+
+		gdmsg, ok := requests[header.peer]
+		if !ok {
+			gdmsg = wire.NewMsgGetData()
+			requests[header.peer] = gdmsg
+		}
+		invType := wire.InvTypeBlock
+		if header.peer.IsWitnessEnabled() {
+			invType = wire.InvTypeWitnessBlock
+		}
+		gdmsg.AddInvVect(wire.NewInvVect(invType, &header.hash))
+		limitAdd(sm.requestedBlocks, header.hash, maxRequestedBlocks)
+		limitAdd(state.requestedBlocks, header.hash, maxRequestedBlocks)
+	}
+
+	for peer, gdmsg := range requests {
+		peer.QueueMessage(gdmsg, nil)
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package netsync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	peerpkg "github.com/btcsuite/btcd/peer"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// testHeaders returns the passed number of headers with valid proof of work on
+// the regression test network which build on the block with the passed hash.
+func testHeaders(sm *SyncManager, prevHash chainhash.Hash, num int) []*wire.BlockHeader {
+	headers := make([]*wire.BlockHeader, 0, num)
+	timestamp := sm.chainParams.GenesisBlock.Header.Timestamp
+	for i := 0; i < num; i++ {
+		timestamp = timestamp.Add(time.Minute)
+		header := &wire.BlockHeader{
+			Version:   4,
+			PrevBlock: prevHash,
+			Timestamp: timestamp,
+			Bits:      sm.chainParams.PowLimitBits,
+		}
+		for blockchain.CheckHeaderProofOfWork(header,
+			sm.chainParams.PowLimit) != nil {
+
+			header.Nonce++
+		}
+		headers = append(headers, header)
+		prevHash = header.BlockHash()
+	}
+	return headers
+}
+
+// sendHeaders passes a headers message with the passed headers from the passed
+// peer to the sync manager.
+func sendHeaders(sm *SyncManager, peer *peerpkg.Peer, headers []*wire.BlockHeader) {
+	msg := wire.NewMsgHeaders()
+	for _, header := range headers {
+		msg.AddBlockHeader(header)
+	}
+	sm.handleHeadersMsg(&headersMsg{headers: msg, peer: peer})
+}
+
+// peerDisconnected returns whether the passed peer was disconnected.
+func peerDisconnected(peer *peerpkg.Peer) bool {
+	done := make(chan struct{})
+	go func() {
+		peer.WaitForDisconnect()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(50 * time.Millisecond):
+		return false
+	}
+}
+
+// TestPendingHeadersEviction ensures the number of pending headers is bounded
+// and the oldest headers are evicted first.
+func TestPendingHeadersEviction(t *testing.T) {
+	const numExtra = 5
+	headers := newPendingHeaders()
+	hashes := make([]chainhash.Hash, maxPendingHeaders+numExtra)
+	for i := range hashes {
+		hashes[i] = chainhash.Hash{byte(i), byte(i >> 8), 0xff}
+		headers.add(&hashes[i], nil)
+	}
+
+	// Adding a header which is already pending has no effect.
+	headers.add(&hashes[len(hashes)-1], nil)
+	if headers.order.Len() != maxPendingHeaders ||
+		len(headers.headers) != maxPendingHeaders {
+
+		t.Fatalf("unexpected number of pending headers %d",
+			headers.order.Len())
+	}
+	if headers.evicted != numExtra {
+		t.Fatalf("unexpected number of evicted headers %d, want %d",
+			headers.evicted, numExtra)
+	}
+	for i := range hashes {
+		if headers.has(&hashes[i]) != (i >= numExtra) {
+			t.Fatalf("unexpected pending state of header %d", i)
+		}
+	}
+	front := headers.order.Front().Value.(*pendingHeader)
+	if front.hash != hashes[numExtra] {
+		t.Fatal("oldest remaining header is not first")
+	}
+
+	headers.remove(&hashes[numExtra])
+	headers.remove(&hashes[0])
+	if headers.has(&hashes[numExtra]) ||
+		headers.order.Len() != maxPendingHeaders-1 {
+
+		t.Fatal("header was not removed")
+	}
+}
+
+// TestHandlePendingHeaders ensures headers requested outside of headers-first
+// mode are added to the pending headers and their blocks are requested when
+// they connect, and misbehaving peers are disconnected.
+func TestHandlePendingHeaders(t *testing.T) {
+	sm, teardown := newTestSyncManager(t, 0)
+	defer teardown()
+	sm.headersFirstMode = false
+	sm.fetchingBlocks = false
+	genesisHash := *sm.chainParams.GenesisHash
+
+	// Headers which connect to a known block are added and their blocks
+	// are requested from the peer which sent them.
+	peer := addTestPeer(t, sm, 0)
+	state := sm.peerStates[peer]
+	headers := testHeaders(sm, genesisHash, 3)
+	stopHash := headers[2].BlockHash()
+	sm.requestHeaders(peer, state, &stopHash)
+	sendHeaders(sm, peer, headers)
+	if peerDisconnected(peer) {
+		t.Fatal("peer sending connecting headers was disconnected")
+	}
+	if state.requestedHeaders != 0 {
+		t.Fatalf("unexpected number of requested headers %d",
+			state.requestedHeaders)
+	}
+	for _, header := range headers {
+		hash := header.BlockHash()
+		if !sm.pendingHeaders.has(&hash) {
+			t.Fatalf("header %v is not pending", hash)
+		}
+		if _, ok := state.requestedBlocks[hash]; !ok {
+			t.Fatalf("block %v was not requested", hash)
+		}
+	}
+
+	// Headers which connect to a pending header are added as well.
+	more := testHeaders(sm, stopHash, 2)
+	sm.requestHeaders(peer, state, &zeroHash)
+	sendHeaders(sm, peer, more)
+	hash := more[1].BlockHash()
+	if !sm.pendingHeaders.has(&hash) {
+		t.Fatal("header connecting to a pending header is not pending")
+	}
+
+	// A reply to every one of several requests in flight is accepted, but
+	// unrequested headers lead to a disconnect.
+	peer = addTestPeer(t, sm, 0)
+	state = sm.peerStates[peer]
+	for i := range headers {
+		stopHash := headers[i].BlockHash()
+		sm.requestHeaders(peer, state, &stopHash)
+	}
+	for i := range headers {
+		sendHeaders(sm, peer, headers[:i+1])
+		if peerDisconnected(peer) {
+			t.Fatalf("peer was disconnected after reply %d", i)
+		}
+	}
+	sendHeaders(sm, peer, headers)
+	if !peerDisconnected(peer) {
+		t.Fatal("peer sending unrequested headers was not " +
+			"disconnected")
+	}
+
+	// Unconnecting headers lead to a request of the headers leading up to
+	// them, but a peer which keeps sending them is disconnected.
+	peer = addTestPeer(t, sm, 0)
+	state = sm.peerStates[peer]
+	unconnecting := testHeaders(sm, chainhash.Hash{0x01}, 1)
+	for i := 1; i < maxUnconnectingHeaders; i++ {
+		sm.requestHeaders(peer, state, &zeroHash)
+		sendHeaders(sm, peer, unconnecting)
+		if peerDisconnected(peer) {
+			t.Fatalf("peer disconnected after %d unconnecting "+
+				"headers messages", i)
+		}
+		if state.unconnectingHeaders != i {
+			t.Fatalf("unexpected number of unconnecting headers "+
+				"%d, want %d", state.unconnectingHeaders, i)
+		}
+		if state.requestedHeaders != 1 {
+			t.Fatal("headers leading up to the unconnecting " +
+				"headers were not requested")
+		}
+		sm.handlePendingHeadersMsg(peer, state, wire.NewMsgHeaders())
+	}
+	unconnectingHash := unconnecting[0].BlockHash()
+	if sm.pendingHeaders.has(&unconnectingHash) {
+		t.Fatal("unconnecting header is pending")
+	}
+
+	// Connecting headers reset the number of unconnecting headers.
+	sm.requestHeaders(peer, state, &zeroHash)
+	sendHeaders(sm, peer, headers[:1])
+	if state.unconnectingHeaders != 0 {
+		t.Fatal("connecting headers didn't reset the number of " +
+			"unconnecting headers")
+	}
+	for i := 0; i < maxUnconnectingHeaders; i++ {
+		sm.requestHeaders(peer, state, &zeroHash)
+		sendHeaders(sm, peer, unconnecting)
+	}
+	if !peerDisconnected(peer) {
+		t.Fatal("peer sending unconnecting headers was not " +
+			"disconnected")
+	}
+
+	// Headers which don't connect to each other or have invalid proof of
+	// work lead to a disconnect.
+	invalid := testHeaders(sm, genesisHash, 3)
+	invalid[2].PrevBlock = invalid[0].BlockHash()
+	badPoW := testHeaders(sm, genesisHash, 1)
+	badPoW[0].Bits = sm.chainParams.PowLimitBits - 1
+	for badPoW[0].Nonce = 0; blockchain.CheckHeaderProofOfWork(
+		badPoW[0], sm.chainParams.PowLimit) == nil; {
+
+		badPoW[0].Nonce++
+	}
+	for _, test := range [][]*wire.BlockHeader{invalid, badPoW} {
+		peer := addTestPeer(t, sm, 0)
+		sm.requestHeaders(peer, sm.peerStates[peer], &zeroHash)
+		sendHeaders(sm, peer, test)
+		if !peerDisconnected(peer) {
+			t.Fatal("peer sending invalid headers was not " +
+				"disconnected")
+		}
+	}
+	badHash := invalid[2].BlockHash()
+	if sm.pendingHeaders.has(&badHash) {
+		t.Fatal("header which doesn't connect to the previous one is " +
+			"pending")
+	}
+}
+
+// TestFetchPendingBlocks ensures the blocks of pending headers are requested
+// from the peers which sent them within the limit of blocks in flight per peer,
+// and known or already requested blocks are skipped.
+func TestFetchPendingBlocks(t *testing.T) {
+	sm, teardown := newTestSyncManager(t, 0)
+	defer teardown()
+	sm.headersFirstMode = false
+	sm.fetchingBlocks = false
+
+	peer := addTestPeer(t, sm, 0)
+	otherPeer := addTestPeer(t, sm, 0)
+	goner := addTestPeer(t, sm, 0)
+	delete(sm.peerStates, goner)
+
+	// The header of a known block is removed without requesting it.
+	genesisHash := *sm.chainParams.GenesisHash
+	sm.pendingHeaders.add(&genesisHash, peer)
+
+	// The header of a block which is already requested isn't requested
+	// again, and headers of peers which are gone aren't requested at all.
+	requested := chainhash.Hash{0x01}
+	sm.pendingHeaders.add(&requested, peer)
+	sm.requestedBlocks[requested] = struct{}{}
+	gone := chainhash.Hash{0x02}
+	sm.pendingHeaders.add(&gone, goner)
+
+	const numHashes = maxPendingBlockRequests + 10
+	hashes := make([]chainhash.Hash, numHashes)
+	for i := range hashes {
+		hashes[i] = chainhash.Hash{byte(i), byte(i >> 8), 0xff}
+		sm.pendingHeaders.add(&hashes[i], peer)
+	}
+	otherHash := chainhash.Hash{0x03}
+	sm.pendingHeaders.add(&otherHash, otherPeer)
+
+	sm.fetchPendingBlocks()
+	if sm.pendingHeaders.has(&genesisHash) {
+		t.Fatal("header of a known block is still pending")
+	}
+	state := sm.peerStates[peer]
+	if len(state.requestedBlocks) != maxPendingBlockRequests {
+		t.Fatalf("unexpected number of blocks %d requested from the "+
+			"peer, want %d", len(state.requestedBlocks),
+			maxPendingBlockRequests)
+	}
+	for i := range hashes {
+		_, ok := state.requestedBlocks[hashes[i]]
+		if ok != (i < maxPendingBlockRequests) {
+			t.Fatalf("unexpected request state of block %d", i)
+		}
+		if !sm.pendingHeaders.has(&hashes[i]) {
+			t.Fatalf("header %d is no longer pending", i)
+		}
+	}
+	if _, ok := state.requestedBlocks[requested]; ok {
+		t.Fatal("already requested block was requested again")
+	}
+	if _, ok := sm.requestedBlocks[gone]; ok {
+		t.Fatal("block was requested from a peer which is gone")
+	}
+	if _, ok := sm.peerStates[otherPeer].requestedBlocks[otherHash]; !ok {
+		t.Fatal("block was not requested from the peer which sent " +
+			"the header")
+	}
+
+	// The remaining blocks are requested once the blocks in flight were
+	// received.
+	for i := 0; i < maxPendingBlockRequests; i++ {
+		delete(state.requestedBlocks, hashes[i])
+		sm.pendingHeaders.remove(&hashes[i])
+	}
+	sm.fetchPendingBlocks()
+	if len(state.requestedBlocks) != numHashes-maxPendingBlockRequests {
+		t.Fatalf("unexpected number of blocks %d requested from the "+
+			"peer", len(state.requestedBlocks))
+	}
+}
@@ -6,8 +6,25 @@
 Package netsync implements a concurrency safe block syncing protocol. The
 SyncManager communicates with connected peers to perform an initial block
 download, keep the chain and unconfirmed transaction pool in sync, and announce
-new blocks connected to the chain. Currently the sync manager selects a single
-sync peer that it downloads all blocks from until it is up to date with the
-longest chain the sync peer is aware of.
+new blocks connected to the chain. The sync manager selects a sync peer that it
+downloads the block headers from.  The blocks the headers describe are then
+downloaded from all suitable peers in parallel within a sliding window starting
+at the next block to process, with peers which stall the download being
+disconnected.  Once past the final checkpoint, the sync manager downloads the
+remaining blocks from the sync peer until it is up to date with the longest
+chain the sync peer is aware of.
+
+Instead of relying on checkpoints, the sync manager can also be configured to
+use SyncModeHeadersPresync.  In this mode the headers of the chain of the sync
+peer are downloaded twice.  The first pass verifies the headers form a valid
+chain with sufficient work while only keeping a small salted commitment to
+them, and the second pass verifies the headers against the commitment before
+handing them over to the block download.  This bounds the memory peers are able
+to consume with low-work header chains.
+
+When the chain state was loaded from a utxo snapshot, the sync manager can also
+be given a background chain.  Once the chain is current, the blocks leading up
+to the snapshot are downloaded for the background chain, which verifies the
+snapshot after connecting the snapshot block.
 */
 package netsync
@@ -0,0 +1,333 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package netsync
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// SyncMode identifies how the sync manager learns about the blocks of the
+// chain during the initial block download.
+type SyncMode uint8
+
+const (
+	// SyncModeCheckpoints downloads the headers up to the next checkpoint
+	// first and the blocks they describe afterwards.  The checkpoints
+	// prove the headers are part of the chain before they are kept in
+	// memory.  Past the final checkpoint, blocks are learned about from
+	// inventory announcements.
+	SyncModeCheckpoints SyncMode = iota
+
+	// SyncModeHeadersPresync downloads the headers of the chain of the sync
+	// peer twice without relying on checkpoints.  The first pass only
+	// verifies the headers build a valid chain with sufficient work while
+	// keeping a small commitment to them.  The second pass verifies the
+	// headers match the commitment and hands them over to the block
+	// download.  This prevents peers from exhausting the memory of the
+	// node with long chains of low-work headers.
+	SyncModeHeadersPresync
+)
+
+// String returns the SyncMode in human-readable form.
+func (m SyncMode) String() string {
+	switch m {
+	case SyncModeCheckpoints:
+		return "checkpoints"
+	case SyncModeHeadersPresync:
+		return "headerspresync"
+	}
+	return fmt.Sprintf("Unknown SyncMode (%d)", uint8(m))
+}
+
+const (
+	// headerCommitmentPeriod is the number of headers per bit of the
+	// commitment to the pre-synced headers.
+	headerCommitmentPeriod = 600
+
+	// redownloadBufferSize is the number of redownloaded headers which are
+	// kept until they are handed over to the block download.  The headers
+	// cover multiple commitment bits, which makes it prohibitively
+	// expensive for a peer to serve a chain during the second pass which
+	// differs from the one it served during the first pass.
+	redownloadBufferSize = 25 * headerCommitmentPeriod
+
+	// maxHeadersPerSecond is the maximum rate at which the timestamps of a
+	// valid chain may advance, which is limited by the median time rule.
+	maxHeadersPerSecond = 6
+
+	// maxTimeOffset is the maximum time the timestamp of a header may be
+	// ahead of the current time.
+	maxTimeOffset = 2 * time.Hour
+)
+
+var (
+	// errInsufficientWork is returned when the headers served by a peer
+	// end before reaching the minimum amount of work.
+	errInsufficientWork = errors.New("header chain has insufficient work")
+)
+
+// headersSyncPhase identifies the phase of a headers sync.
+type headersSyncPhase uint8
+
+const (
+	// headersPresync is the first pass over the headers, which verifies
+	// them and records the commitment.
+	headersPresync headersSyncPhase = iota
+
+	// headersRedownload is the second pass over the headers, which
+	// verifies them against the commitment and releases them.
+	headersRedownload
+
+	// headersSyncDone indicates all of the headers were released.
+	headersSyncDone
+)
+
+// headerChainState houses the tip of a chain of headers being verified.
+type headerChainState struct {
+	hash   chainhash.Hash
+	height int32
+	bits   uint32
+	work   *big.Int
+}
+
+// extend verifies the passed header connects to the tip, satisfies its proof
+// of work, and follows the difficulty retarget rules before making it the new
+// tip.
+func (s *headerChainState) extend(header *wire.BlockHeader,
+	params *chaincfg.Params) error {
+
+	if header.PrevBlock != s.hash {
+		return fmt.Errorf("header %v does not connect to %v",
+			header.BlockHash(), s.hash)
+	}
+	if err := blockchain.CheckHeaderProofOfWork(header, params.PowLimit); err != nil {
+		return err
+	}
+	if !blockchain.PermittedDifficultyTransition(params, s.height+1,
+		s.bits, header.Bits) {
+
+		return fmt.Errorf("header %v at height %d has invalid "+
+			"difficulty bits %08x", header.BlockHash(), s.height+1,
+			header.Bits)
+	}
+
+	s.hash = header.BlockHash()
+	s.height++
+	s.bits = header.Bits
+	s.work = new(big.Int).Add(s.work, blockchain.CalcWork(header.Bits))
+	return nil
+}
+
+// headersSync synchronizes the headers of the chain of a single peer in two
+// passes as described by SyncModeHeadersPresync.
+type headersSync struct {
+	params         *chaincfg.Params
+	start          headerChainState
+	minimumWork    *big.Int
+	maxCommitments int
+	salt           [16]byte
+	commitOffset   int32
+	phase          headersSyncPhase
+
+	// The following fields are used during the first pass.
+	presync     headerChainState
+	commitments []bool
+
+	// The following fields are used during the second pass.
+	redownload     headerChainState
+	nextCommitment int
+	buffer         []*headerNode
+	releaseAll     bool
+}
+
+// newHeadersSync returns a headers sync starting at the passed block which
+// requires the synced chain to have more work than the passed minimum.
+func newHeadersSync(params *chaincfg.Params, startHash *chainhash.Hash,
+	startHeight int32, startHeader *wire.BlockHeader, startWork,
+	minimumWork *big.Int) *headersSync {
+
+	start := headerChainState{
+		hash:   *startHash,
+		height: startHeight,
+		bits:   startHeader.Bits,
+		work:   startWork,
+	}
+	hs := &headersSync{
+		params:      params,
+		start:       start,
+		minimumWork: minimumWork,
+		presync:     start,
+	}
+
+	// The salt and the offset of the heights the commitment has bits for
+	// are random, so peers can't predict the bits or the heights they are
+	// checked at.
+	var offset [4]byte
+	crand.Read(hs.salt[:])
+	crand.Read(offset[:])
+	hs.commitOffset = int32(binary.LittleEndian.Uint32(offset[:]) %
+		headerCommitmentPeriod)
+
+	// Bound the size of the commitment by the number of headers a valid
+	// chain starting at the start block may have at the current time.
+	maxTime := time.Now().Add(maxTimeOffset)
+	maxHeaders := int64(maxTime.Sub(startHeader.Timestamp)/time.Second) *
+		maxHeadersPerSecond
+	hs.maxCommitments = int(maxHeaders/headerCommitmentPeriod) + 1
+
+	return hs
+}
+
+// isCommitmentHeight returns whether the commitment has a bit for the header
+// at the passed height.
+func (hs *headersSync) isCommitmentHeight(height int32) bool {
+	return (height-hs.start.height)%headerCommitmentPeriod == hs.commitOffset
+}
+
+// commitmentBit returns the bit of the commitment for the passed header hash.
+func (hs *headersSync) commitmentBit(hash *chainhash.Hash) bool {
+	data := make([]byte, 0, len(hs.salt)+chainhash.HashSize)
+	data = append(data, hs.salt[:]...)
+	data = append(data, hash[:]...)
+	return chainhash.HashB(data)[0]&1 == 1
+}
+
+// processHeaders processes the passed headers, which must connect to the last
+// processed header, and returns the headers which are released for the block
+// download.  The more flag indicates the headers filled a complete headers
+// message, so the peer has more headers.
+func (hs *headersSync) processHeaders(headers []*wire.BlockHeader,
+	more bool) ([]*headerNode, error) {
+
+	switch hs.phase {
+	case headersPresync:
+		return nil, hs.processPresyncHeaders(headers, more)
+	case headersRedownload:
+		return hs.processRedownloadHeaders(headers, more)
+	}
+	return nil, fmt.Errorf("unexpected headers after headers sync")
+}
+
+// processPresyncHeaders verifies the passed headers and records the
+// commitment to them.  The second pass is started once the headers have
+// sufficient work.
+func (hs *headersSync) processPresyncHeaders(headers []*wire.BlockHeader,
+	more bool) error {
+
+	for i := range headers {
+		if err := hs.presync.extend(headers[i], hs.params); err != nil {
+			return err
+		}
+		if !hs.isCommitmentHeight(hs.presync.height) {
+			continue
+		}
+		if len(hs.commitments) >= hs.maxCommitments {
+			return fmt.Errorf("header chain exceeds the maximum "+
+				"possible length at height %d",
+				hs.presync.height)
+		}
+		hs.commitments = append(hs.commitments,
+			hs.commitmentBit(&hs.presync.hash))
+	}
+
+	if hs.presync.work.Cmp(hs.minimumWork) >= 0 {
+		log.Debugf("Pre-synced headers up to height %d with "+
+			"sufficient work, redownloading them", hs.presync.height)
+		hs.phase = headersRedownload
+		hs.redownload = hs.start
+		return nil
+	}
+	if !more {
+		return errInsufficientWork
+	}
+	return nil
+}
+
+// processRedownloadHeaders verifies the passed headers against the commitment
+// and returns the headers which are released for the block download.  All
+// headers are released once the redownloaded headers have sufficient work.
+func (hs *headersSync) processRedownloadHeaders(headers []*wire.BlockHeader,
+	more bool) ([]*headerNode, error) {
+
+	for i := range headers {
+		if err := hs.redownload.extend(headers[i], hs.params); err != nil {
+			return nil, err
+		}
+
+		// The commitment only covers the pre-synced headers, which
+		// have sufficient work, so the remaining headers are accepted
+		// without checking it once the redownloaded headers have
+		// sufficient work as well.
+		if !hs.releaseAll && hs.isCommitmentHeight(hs.redownload.height) {
+			if hs.nextCommitment >= len(hs.commitments) {
+				return nil, fmt.Errorf("redownloaded headers "+
+					"exceed the pre-synced headers at "+
+					"height %d", hs.redownload.height)
+			}
+			bit := hs.commitmentBit(&hs.redownload.hash)
+			if bit != hs.commitments[hs.nextCommitment] {
+				return nil, fmt.Errorf("redownloaded header at "+
+					"height %d does not match the "+
+					"pre-synced headers",
+					hs.redownload.height)
+			}
+			hs.nextCommitment++
+		}
+
+		hash := hs.redownload.hash
+		hs.buffer = append(hs.buffer, &headerNode{
+			height: hs.redownload.height,
+			hash:   &hash,
+		})
+		if hs.redownload.work.Cmp(hs.minimumWork) >= 0 {
+			hs.releaseAll = true
+		}
+	}
+
+	if !more {
+		if !hs.releaseAll {
+			return nil, errInsufficientWork
+		}
+		hs.phase = headersSyncDone
+	}
+
+	numRelease := len(hs.buffer) - redownloadBufferSize
+	if hs.releaseAll {
+		numRelease = len(hs.buffer)
+	}
+	if numRelease <= 0 {
+		return nil, nil
+	}
+	released := hs.buffer[:numRelease]
+	hs.buffer = append([]*headerNode(nil), hs.buffer[numRelease:]...)
+	return released, nil
+}
+
+// locator returns the block locator to request the next headers with.
+func (hs *headersSync) locator() blockchain.BlockLocator {
+	last, start := hs.presync.hash, hs.start.hash
+	if hs.phase != headersPresync {
+		last = hs.redownload.hash
+	}
+	if last == start {
+		return blockchain.BlockLocator{&start}
+	}
+	return blockchain.BlockLocator{&last, &start}
+}
+
+// done returns whether all headers were released.
+func (hs *headersSync) done() bool {
+	return hs.phase == headersSyncDone
+}
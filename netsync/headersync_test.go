@@ -0,0 +1,180 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package netsync
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// generateHeaders returns a chain of the passed number of headers with valid
+// proof of work on the passed params which builds on the passed header.  The
+// passed tag makes the chain differ from other chains building on the same
+// header.
+func generateHeaders(params *chaincfg.Params, prev *wire.BlockHeader,
+	num int, tag byte) []*wire.BlockHeader {
+
+	headers := make([]*wire.BlockHeader, 0, num)
+	for i := 0; i < num; i++ {
+		header := &wire.BlockHeader{
+			Version:   1,
+			PrevBlock: prev.BlockHash(),
+			Timestamp: prev.Timestamp.Add(10 * time.Minute),
+			Bits:      params.PowLimitBits,
+		}
+		header.MerkleRoot[0] = tag
+		for blockchain.CheckHeaderProofOfWork(header,
+			params.PowLimit) != nil {
+
+			header.Nonce++
+		}
+		headers = append(headers, header)
+		prev = header
+	}
+	return headers
+}
+
+// newTestHeadersSync returns a headers sync starting at the genesis block of
+// the passed params which requires the work of the passed number of headers.
+func newTestHeadersSync(params *chaincfg.Params, minHeaders int64) *headersSync {
+	genesis := &params.GenesisBlock.Header
+	startWork := blockchain.CalcWork(genesis.Bits)
+	minimumWork := new(big.Int).Mul(blockchain.CalcWork(genesis.Bits),
+		big.NewInt(minHeaders+1))
+	return newHeadersSync(params, params.GenesisHash, 0, genesis,
+		startWork, minimumWork)
+}
+
+// processHeaderMsgs feeds the passed headers to the passed headers sync in
+// messages of at most wire.MaxBlockHeadersPerMsg headers and returns the
+// released headers.  It stops at the first message which changes the phase of
+// the headers sync.
+func processHeaderMsgs(hs *headersSync, headers []*wire.BlockHeader) ([]*headerNode, error) {
+	var released []*headerNode
+	phase := hs.phase
+	for len(headers) > 0 && hs.phase == phase {
+		num := len(headers)
+		if num > wire.MaxBlockHeadersPerMsg {
+			num = wire.MaxBlockHeadersPerMsg
+		}
+		more := num == wire.MaxBlockHeadersPerMsg
+		nodes, err := hs.processHeaders(headers[:num], more)
+		if err != nil {
+			return released, err
+		}
+		released = append(released, nodes...)
+		headers = headers[num:]
+	}
+	return released, nil
+}
+
+// TestHeadersSync ensures the headers of a chain with sufficient work are
+// released after both passes, including the headers past the pre-synced ones.
+func TestHeadersSync(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	headers := generateHeaders(params, &params.GenesisBlock.Header,
+		3000, 0)
+
+	// The pre-sync stops after the first message since its headers
+	// already have sufficient work.
+	hs := newTestHeadersSync(params, 1500)
+	if _, err := processHeaderMsgs(hs, headers); err != nil {
+		t.Fatalf("unexpected pre-sync error: %v", err)
+	}
+	if hs.phase != headersRedownload {
+		t.Fatalf("unexpected phase %d after pre-sync", hs.phase)
+	}
+	if hs.presync.height != wire.MaxBlockHeadersPerMsg {
+		t.Fatalf("unexpected pre-synced height %d", hs.presync.height)
+	}
+	locator := hs.locator()
+	if len(locator) != 1 || *locator[0] != *params.GenesisHash {
+		t.Fatalf("unexpected redownload locator %v", locator)
+	}
+
+	// All of the redownloaded headers must be released, including the
+	// ones past the pre-synced headers which aren't covered by the
+	// commitment.
+	released, err := processHeaderMsgs(hs, headers)
+	if err != nil {
+		t.Fatalf("unexpected redownload error: %v", err)
+	}
+	if !hs.done() {
+		t.Fatalf("headers sync is not done")
+	}
+	if len(released) != len(headers) {
+		t.Fatalf("unexpected number of released headers %d, want %d",
+			len(released), len(headers))
+	}
+	for i, node := range released {
+		wantHash := headers[i].BlockHash()
+		if node.height != int32(i+1) || *node.hash != wantHash {
+			t.Fatalf("unexpected released header %d: height %d, "+
+				"hash %v", i, node.height, node.hash)
+		}
+	}
+	if _, err := hs.processHeaders(headers[:1], false); err == nil {
+		t.Fatal("headers after the headers sync were accepted")
+	}
+}
+
+// TestHeadersSyncErrors ensures the headers sync rejects chains with
+// insufficient work, unconnected headers and redownloaded headers which don't
+// match the pre-synced ones.
+func TestHeadersSyncErrors(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	headers := generateHeaders(params, &params.GenesisBlock.Header,
+		2100, 0)
+
+	// A chain which ends before reaching the minimum work is rejected in
+	// both passes.
+	hs := newTestHeadersSync(params, 3000)
+	_, err := processHeaderMsgs(hs, headers)
+	if err != errInsufficientWork {
+		t.Fatalf("unexpected pre-sync error: %v", err)
+	}
+	hs = newTestHeadersSync(params, 2050)
+	if _, err := processHeaderMsgs(hs, headers); err != nil {
+		t.Fatalf("unexpected pre-sync error: %v", err)
+	}
+	_, err = processHeaderMsgs(hs, headers[:2000])
+	if err != nil {
+		t.Fatalf("unexpected redownload error: %v", err)
+	}
+	_, err = hs.processHeaders(headers[2000:2010], false)
+	if err != errInsufficientWork {
+		t.Fatalf("unexpected redownload error: %v", err)
+	}
+
+	// Headers which don't connect to the previous ones are rejected.
+	hs = newTestHeadersSync(params, 1000)
+	_, err = hs.processHeaders(headers[1:10], true)
+	if err == nil || !strings.Contains(err.Error(), "does not connect") {
+		t.Fatalf("unexpected error for unconnected headers: %v", err)
+	}
+
+	// Redownloaded headers which don't match the commitment to the
+	// pre-synced headers are rejected.
+	hs = newTestHeadersSync(params, 1500)
+	if _, err := processHeaderMsgs(hs, headers); err != nil {
+		t.Fatalf("unexpected pre-sync error: %v", err)
+	}
+	if len(hs.commitments) == 0 {
+		t.Fatal("pre-sync did not record a commitment")
+	}
+	for i := range hs.commitments {
+		hs.commitments[i] = !hs.commitments[i]
+	}
+	_, err = processHeaderMsgs(hs, headers)
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("unexpected error for mismatching headers: %v", err)
+	}
+}
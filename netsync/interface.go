@@ -5,6 +5,8 @@
 package netsync
 
 import (
+	"math/big"
+
 	"github.com/btcsuite/btcd/blockchain"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
@@ -37,5 +39,23 @@ type Config struct {
 	DisableCheckpoints bool
 	MaxPeers           int
 
+	// SyncMode is the mode used to learn about the blocks of the chain
+	// during the initial block download.
+	SyncMode SyncMode
+
+	// MinimumChainWork is the minimum total work the chain of a peer must
+	// have for its headers to be downloaded when syncing in
+	// SyncModeHeadersPresync.  The chain must always have more work than
+	// the current best chain.
+	MinimumChainWork *big.Int
+
+	// BackgroundChain validates the blocks leading up to the utxo snapshot
+	// the chain was loaded from, if any.  The blocks are downloaded once
+	// the chain is current, and BackgroundChainDone is called with the
+	// result of verifying the snapshot once the background chain reached
+	// the snapshot block.
+	BackgroundChain     *blockchain.BlockChain
+	BackgroundChainDone func(error)
+
 	FeeEstimator *mempool.FeeEstimator
 }
@@ -6,6 +6,7 @@ package netsync
 
 import (
 	"container/list"
+	"math/big"
 	"math/rand"
 	"net"
 	"sync"
@@ -23,10 +24,20 @@ import (
 )
 
 const (
-	// minInFlightBlocks is the minimum number of blocks that should be
-	// in the request queue for headers-first mode before requesting
-	// more.
-	minInFlightBlocks = 10
+	// blockDownloadWindow is the maximum number of blocks past the next
+	// block to process which may be requested or waiting to be processed
+	// in headers-first mode.  Blocks which arrive out of order are kept
+	// in memory until all of their ancestors were processed.
+	blockDownloadWindow = 128
+
+	// maxInFlightBlocksPerPeer is the maximum number of blocks which may
+	// be requested from a single peer at once in headers-first mode.
+	maxInFlightBlocksPerPeer = 16
+
+	// maxBlockStallDuration is the time after which a peer which hasn't
+	// delivered the next block to process is disconnected in headers-first
+	// mode when the download window is exhausted.
+	maxBlockStallDuration = 10 * time.Second
 
 	// maxRejectedTxns is the maximum number of rejected transactions
 	// hashes to store in memory.
@@ -138,6 +149,12 @@ type pauseMsg struct {
 	unpause <-chan struct{}
 }
 
+// getPendingHeaderStatsMsg is a message type to be sent across the message
+// channel for retrieving statistics about the pending headers.
+type getPendingHeaderStatsMsg struct {
+	reply chan PendingHeaderStats
+}
+
 // headerNode is used as a node in a list of headers that are linked together
 // between checkpoints.
 type headerNode struct {
@@ -145,6 +162,14 @@ type headerNode struct {
 	hash   *chainhash.Hash
 }
 
+// blockRequest houses a block requested in headers-first mode along with the
+// peer it was requested from.
+type blockRequest struct {
+	peer      *peerpkg.Peer
+	height    int32
+	requested time.Time
+}
+
 // peerSyncState stores additional information that the SyncManager tracks
 // about a peer.
 type peerSyncState struct {
@@ -152,6 +177,13 @@ type peerSyncState struct {
 	requestQueue    []*wire.InvVect
 	requestedTxns   map[chainhash.Hash]struct{}
 	requestedBlocks map[chainhash.Hash]struct{}
+
+	// requestedHeaders is the number of getheaders requests sent to the
+	// peer outside of headers-first mode which it didn't reply to yet and
+	// unconnectingHeaders is the number of consecutive headers messages
+	// from the peer which didn't connect.
+	requestedHeaders    int
+	unconnectingHeaders int
 }
 
 // limitAdd is a helper function for maps that require a maximum limit by
@@ -198,11 +230,38 @@ type SyncManager struct {
 	peerStates       map[*peerpkg.Peer]*peerSyncState
 	lastProgressTime time.Time
 
-	// The following fields are used for headers-first mode.
+	// The following fields are used outside of headers-first mode to only
+	// process blocks once their headers are known to connect to the block
+	// chain.
+	pendingHeaders    *pendingHeaders
+	unconnectedBlocks uint64
+
+	// The following fields are used for headers-first mode.  The blocks
+	// are requested from all sync candidates in parallel once the headers
+	// up to the next checkpoint were downloaded from the sync peer.
 	headersFirstMode bool
+	fetchingBlocks   bool
 	headerList       *list.List
-	startHeader      *list.Element
 	nextCheckpoint   *chaincfg.Checkpoint
+	blockRequests    map[chainhash.Hash]*blockRequest
+	downloadedBlocks map[chainhash.Hash]*blockMsg
+
+	// The following fields are used for headers-first mode without
+	// checkpoints.  The headers are synced from the sync peer by
+	// headersSync and handed over to the block download as they are
+	// released.  headersSynced indicates all headers were released.
+	syncMode         SyncMode
+	minimumChainWork *big.Int
+	headersSync      *headersSync
+	headersSynced    bool
+
+	// The following fields are used to validate the blocks leading up to
+	// the utxo snapshot the chain was loaded from with the background
+	// chain.  backgroundChainDone is called once the snapshot was
+	// verified.
+	backgroundChain     *blockchain.BlockChain
+	backgroundChainDone func(error)
+	backgroundRequests  map[chainhash.Hash]*blockRequest
 
 	// An optional fee estimator.
 	feeEstimator *mempool.FeeEstimator
@@ -212,8 +271,12 @@ type SyncManager struct {
 // syncing from a new peer.
 func (sm *SyncManager) resetHeaderState(newestHash *chainhash.Hash, newestHeight int32) {
 	sm.headersFirstMode = false
+	sm.fetchingBlocks = false
 	sm.headerList.Init()
-	sm.startHeader = nil
+	sm.blockRequests = make(map[chainhash.Hash]*blockRequest)
+	sm.downloadedBlocks = make(map[chainhash.Hash]*blockMsg)
+	sm.headersSync = nil
+	sm.headersSynced = false
 
 	// When there is a next checkpoint, add an entry for the latest known
 	// block into the header pool.  This allows the next downloaded header
@@ -366,6 +429,13 @@ func (sm *SyncManager) startSync() {
 			log.Infof("Downloading headers for blocks %d to "+
 				"%d from peer %s", best.Height+1,
 				sm.nextCheckpoint.Height, bestPeer.Addr())
+		} else if sm.syncMode == SyncModeHeadersPresync &&
+			bestPeer.LastBlock() > best.Height &&
+			sm.chainParams != &chaincfg.RegressionNetParams {
+
+			if !sm.startHeadersSync(bestPeer, best) {
+				return
+			}
 		} else {
 			bestPeer.PushGetBlocksMsg(locator, &zeroHash)
 		}
@@ -380,6 +450,122 @@ func (sm *SyncManager) startSync() {
 	}
 }
 
+// startHeadersSync starts syncing the headers of the chain of the passed peer
+// without checkpoints as described by SyncModeHeadersPresync.  It returns
+// whether the sync was started.
+func (sm *SyncManager) startHeadersSync(peer *peerpkg.Peer,
+	best *blockchain.BestState) bool {
+
+	header, err := sm.chain.HeaderByHash(&best.Hash)
+	if err != nil {
+		log.Errorf("Failed to get header of the latest block: %v", err)
+		return false
+	}
+
+	// The headers must have more work than the current best chain and
+	// at least the configured minimum.
+	minimumWork := new(big.Int).Add(best.WorkSum, big.NewInt(1))
+	if sm.minimumChainWork != nil &&
+		sm.minimumChainWork.Cmp(minimumWork) > 0 {
+
+		minimumWork = sm.minimumChainWork
+	}
+
+	sm.resetHeaderState(&best.Hash, best.Height)
+	sm.headersSync = newHeadersSync(sm.chainParams, &best.Hash,
+		best.Height, &header, best.WorkSum, minimumWork)
+	sm.headersFirstMode = true
+	peer.PushGetHeadersMsg(sm.headersSync.locator(), &zeroHash)
+	log.Infof("Pre-syncing headers after block %d from peer %s",
+		best.Height, peer.Addr())
+	return true
+}
+
+// handleHeadersSyncMsg handles block header messages while syncing headers
+// without checkpoints.  The released headers are handed over to the block
+// download right away.
+func (sm *SyncManager) handleHeadersSyncMsg(peer *peerpkg.Peer,
+	msg *wire.MsgHeaders) {
+
+	// Headers which arrive after the sync finished or from a peer other
+	// than the sync peer weren't requested by the current headers sync.
+	hs := sm.headersSync
+	if hs == nil || peer != sm.syncPeer {
+		log.Debugf("Ignoring %d unexpected headers from %s",
+			len(msg.Headers), peer)
+		return
+	}
+
+	more := len(msg.Headers) == wire.MaxBlockHeadersPerMsg
+	released, err := hs.processHeaders(msg.Headers, more)
+	if err == errInsufficientWork {
+		log.Infof("Headers from peer %s have insufficient work -- "+
+			"choosing another sync peer", peer)
+		sm.peerStates[peer].syncCandidate = false
+		sm.updateSyncPeer(false)
+		return
+	}
+	if err != nil {
+		log.Warnf("Received invalid headers from peer %s: %v -- "+
+			"disconnecting", peer, err)
+		sm.updateSyncPeer(true)
+		return
+	}
+	sm.lastProgressTime = time.Now()
+
+	for _, node := range released {
+		sm.headerList.PushBack(node)
+	}
+	if len(released) > 0 {
+		if !sm.fetchingBlocks {
+			log.Infof("Received block headers with sufficient "+
+				"work: Fetching blocks from height %d",
+				released[0].height)
+			sm.progressLogger.SetLastLogTime(time.Now())
+			sm.fetchingBlocks = true
+		}
+		sm.fetchHeaderBlocks()
+	}
+
+	if !hs.done() {
+		err := peer.PushGetHeadersMsg(hs.locator(), &zeroHash)
+		if err != nil {
+			log.Warnf("Failed to send getheaders message to "+
+				"peer %s: %v", peer.Addr(), err)
+		}
+		return
+	}
+
+	log.Infof("Synced headers up to height %d from peer %s",
+		hs.redownload.height, peer.Addr())
+	sm.headersSync = nil
+	sm.headersSynced = true
+	if sm.headerList.Len() == 0 {
+		sm.finishHeadersSync()
+	}
+}
+
+// finishHeadersSync switches to normal mode once the blocks of all headers
+// synced without checkpoints were processed.
+func (sm *SyncManager) finishHeadersSync() {
+	sm.headersFirstMode = false
+	sm.fetchingBlocks = false
+	sm.headersSynced = false
+	log.Infof("Processed the blocks of all synced headers -- switching " +
+		"to normal mode")
+
+	if sm.syncPeer == nil {
+		return
+	}
+	locator, err := sm.chain.LatestBlockLocator()
+	if err != nil {
+		log.Errorf("Failed to get block locator for the latest "+
+			"block: %v", err)
+		return
+	}
+	sm.syncPeer.PushGetBlocksMsg(locator, &zeroHash)
+}
+
 // isSyncCandidate returns whether or not the peer is a candidate to consider
 // syncing from.
 func (sm *SyncManager) isSyncCandidate(peer *peerpkg.Peer) bool {
@@ -440,6 +626,11 @@ func (sm *SyncManager) handleNewPeerMsg(peer *peerpkg.Peer) {
 	if isSyncCandidate && sm.syncPeer == nil {
 		sm.startSync()
 	}
+
+	// Put the new peer to work when blocks are being downloaded.
+	if isSyncCandidate && sm.fetchingBlocks {
+		sm.fetchHeaderBlocks()
+	}
 }
 
 // handleStallSample will switch to a new sync peer if the current one has
@@ -451,11 +642,23 @@ func (sm *SyncManager) handleStallSample() {
 		return
 	}
 
+	// Keep the validation of the background chain going.
+	sm.handleBackgroundStall()
+
 	// If we don't have an active sync peer, exit early.
 	if sm.syncPeer == nil {
 		return
 	}
 
+	// Detect peers stalling the block download in headers-first mode
+	// even when no blocks arrive at all.
+	if sm.fetchingBlocks {
+		sm.checkDownloadStall(len(sm.downloadPeers()))
+		if sm.syncPeer == nil {
+			return
+		}
+	}
+
 	// If the stall timeout has not elapsed, exit early.
 	if time.Since(sm.lastProgressTime) <= maxStallDuration {
 		return
@@ -512,12 +715,23 @@ func (sm *SyncManager) handleDonePeerMsg(peer *peerpkg.Peer) {
 	log.Infof("Lost peer %s", peer)
 
 	sm.clearRequestedState(state)
+	sm.removeBackgroundRequests(func(req *blockRequest) bool {
+		return req.peer == peer
+	})
 
 	if peer == sm.syncPeer {
 		// Update the sync peer. The server has already disconnected the
 		// peer before signaling to the sync manager.
 		sm.updateSyncPeer(false)
+		return
 	}
+
+	// Request the blocks which were in flight from the peer from the
+	// remaining peers.
+	if sm.fetchingBlocks {
+		sm.fetchHeaderBlocks()
+	}
+	sm.fetchBackgroundBlocks()
 }
 
 // clearRequestedState wipes all expected transactions and blocks from the sync
@@ -536,6 +750,15 @@ func (sm *SyncManager) clearRequestedState(state *peerSyncState) {
 	// and request them now to speed things up a little.
 	for blockHash := range state.requestedBlocks {
 		delete(sm.requestedBlocks, blockHash)
+
+		// Only release headers-first requests which weren't reassigned
+		// to another peer in the meantime.
+		if req, ok := sm.blockRequests[blockHash]; ok {
+			reqState := sm.peerStates[req.peer]
+			if reqState == nil || reqState == state {
+				delete(sm.blockRequests, blockHash)
+			}
+		}
 	}
 }
 
@@ -675,13 +898,77 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 		}
 	}
 
+	// Remove block from request maps. Either chain will know about it and
+	// so we shouldn't have any more instances of trying to fetch it, or we
+	// will fail the insert and thus we'll retry next time we get an inv.
+	delete(state.requestedBlocks, *blockHash)
+	delete(sm.requestedBlocks, *blockHash)
+	delete(sm.blockRequests, *blockHash)
+
+	// Blocks requested for the background chain are processed by it.
+	if _, ok := sm.backgroundRequests[*blockHash]; ok {
+		delete(sm.backgroundRequests, *blockHash)
+		sm.processBackgroundBlock(bmsg.block, peer)
+		return
+	}
+
+	// Outside of headers-first mode, blocks which neither build on a known
+	// block nor have a pending header are dropped instead of being kept as
+	// orphans.  The headers leading up to the block are requested instead,
+	// so the block is requested again once its header connects.
+	if !sm.headersFirstMode && !sm.blockConnects(bmsg.block) {
+		log.Debugf("Dropping unconnected block %v from %s", blockHash,
+			peer)
+		sm.unconnectedBlocks++
+		sm.requestHeaders(peer, state, blockHash)
+		return
+	}
+
+	// When downloading blocks in headers-first mode, blocks which arrive
+	// before their ancestors were processed are kept until they are next
+	// in line.
+	if sm.fetchingBlocks && sm.inDownloadWindow(blockHash) {
+		sm.downloadedBlocks[*blockHash] = bmsg
+	} else {
+		sm.processBlock(bmsg.block, peer)
+	}
+
+	// Process the downloaded blocks which are next in line and request
+	// more blocks to fill the download window.
+	for sm.fetchingBlocks && sm.headerList.Len() > 0 {
+		frontNode := sm.headerList.Front().Value.(*headerNode)
+		downloaded, ok := sm.downloadedBlocks[*frontNode.hash]
+		if !ok {
+			break
+		}
+		delete(sm.downloadedBlocks, *frontNode.hash)
+		sm.processBlock(downloaded.block, downloaded.peer)
+	}
+	if sm.headersSynced && sm.headerList.Len() == 0 {
+		sm.finishHeadersSync()
+		return
+	}
+	if sm.fetchingBlocks {
+		sm.fetchHeaderBlocks()
+	}
+	if !sm.headersFirstMode {
+		sm.fetchPendingBlocks()
+	}
+	sm.fetchBackgroundBlocks()
+}
+
+// processBlock processes a block received from the passed peer and takes care
+// of advancing the sync accordingly.
+func (sm *SyncManager) processBlock(block *btcutil.Block, peer *peerpkg.Peer) {
+	blockHash := block.Hash()
+
 	// When in headers-first mode, if the block matches the hash of the
 	// first header in the list of headers that are being fetched, it's
-	// eligible for less validation since the headers have already been
-	// verified to link together and are valid up to the next checkpoint.
-	// Also, remove the list entry for all blocks except the checkpoint
-	// since it is needed to verify the next round of headers links
-	// properly.
+	// eligible for less validation when syncing up to a checkpoint since
+	// the headers have already been verified to link together and are
+	// valid up to the next checkpoint.  Also, remove the list entry for
+	// all blocks except the checkpoint since it is needed to verify the
+	// next round of headers links properly.
 	isCheckpointBlock := false
 	behaviorFlags := blockchain.BFNone
 	if sm.headersFirstMode {
@@ -689,8 +976,12 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 		if firstNodeEl != nil {
 			firstNode := firstNodeEl.Value.(*headerNode)
 			if blockHash.IsEqual(firstNode.hash) {
-				behaviorFlags |= blockchain.BFFastAdd
-				if firstNode.hash.IsEqual(sm.nextCheckpoint.Hash) {
+				if sm.nextCheckpoint != nil {
+					behaviorFlags |= blockchain.BFFastAdd
+				}
+				if sm.nextCheckpoint != nil &&
+					firstNode.hash.IsEqual(sm.nextCheckpoint.Hash) {
+
 					isCheckpointBlock = true
 				} else {
 					sm.headerList.Remove(firstNodeEl)
@@ -699,15 +990,9 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 		}
 	}
 
-	// Remove block from request maps. Either chain will know about it and
-	// so we shouldn't have any more instances of trying to fetch it, or we
-	// will fail the insert and thus we'll retry next time we get an inv.
-	delete(state.requestedBlocks, *blockHash)
-	delete(sm.requestedBlocks, *blockHash)
-
 	// Process the block to include validation, best chain selection, orphan
 	// handling, etc.
-	_, isOrphan, err := sm.chain.ProcessBlock(bmsg.block, behaviorFlags)
+	_, isOrphan, err := sm.chain.ProcessBlock(block, behaviorFlags)
 	if err != nil {
 		// When the error is a rule error, it means the block was simply
 		// rejected as opposed to something actually going wrong, so log
@@ -725,6 +1010,9 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 			panic(dbErr)
 		}
 
+		// Don't request the rejected block again.
+		sm.pendingHeaders.remove(blockHash)
+
 		// Convert the error into an appropriate reject message and
 		// send it.
 		code, reason := mempool.ErrToRejectErr(err)
@@ -751,9 +1039,9 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 		// block height from the scriptSig of the coinbase transaction.
 		// Extraction is only attempted if the block's version is
 		// high enough (ver 2+).
-		header := &bmsg.block.MsgBlock().Header
+		header := &block.MsgBlock().Header
 		if blockchain.ShouldHaveSerializedBlockHeight(header) {
-			coinbaseTx := bmsg.block.Transactions()[0]
+			coinbaseTx := block.Transactions()[0]
 			cbHeight, err := blockchain.ExtractCoinbaseHeight(coinbaseTx)
 			if err != nil {
 				log.Warnf("Unable to extract height from "+
@@ -766,22 +1054,22 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 			}
 		}
 
-		orphanRoot := sm.chain.GetOrphanRoot(blockHash)
-		locator, err := sm.chain.LatestBlockLocator()
-		if err != nil {
-			log.Warnf("Failed to get block locator for the "+
-				"latest block: %v", err)
-		} else {
-			peer.PushGetBlocksMsg(locator, orphanRoot)
+		// Request the headers leading up to the orphans so the
+		// missing blocks are requested once the headers connect.
+		if state, exists := sm.peerStates[peer]; exists {
+			orphanRoot := sm.chain.GetOrphanRoot(blockHash)
+			sm.requestHeaders(peer, state, orphanRoot)
 		}
 	} else {
-		if peer == sm.syncPeer {
+		// Blocks are downloaded from all sync candidates in
+		// headers-first mode, so any block counts as progress then.
+		if peer == sm.syncPeer || sm.headersFirstMode {
 			sm.lastProgressTime = time.Now()
 		}
 
 		// When the block is not an orphan, log information about it and
 		// update the chain state.
-		sm.progressLogger.LogBlockHeight(bmsg.block)
+		sm.progressLogger.LogBlockHeight(block)
 
 		// Update this peer's latest block height, for future
 		// potential sync node candidacy.
@@ -805,35 +1093,33 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 		}
 	}
 
-	// Nothing more to do if we aren't in headers-first mode.
-	if !sm.headersFirstMode {
+	// Nothing more to do unless the block is a checkpoint in headers-first
+	// mode.  More blocks are requested by the caller.
+	if !sm.headersFirstMode || !isCheckpointBlock {
 		return
 	}
 
-	// This is headers-first mode, so if the block is not a checkpoint
-	// request more blocks using the header list when the request queue is
-	// getting short.
-	if !isCheckpointBlock {
-		if sm.startHeader != nil &&
-			len(state.requestedBlocks) < minInFlightBlocks {
-			sm.fetchHeaderBlocks()
-		}
+	// This is headers-first mode and the block is a checkpoint, so all of
+	// the blocks up to it have been processed.  The headers are requested
+	// from the sync peer since the block may come from any peer.
+	sm.fetchingBlocks = false
+	if sm.syncPeer == nil {
 		return
 	}
 
-	// This is headers-first mode and the block is a checkpoint.  When
-	// there is a next checkpoint, get the next round of headers by asking
-	// for headers starting from the block after this one up to the next
-	// checkpoint.
+	// When there is a next checkpoint, get the next round of headers by
+	// asking for headers starting from the block after this one up to the
+	// next checkpoint.
 	prevHeight := sm.nextCheckpoint.Height
 	prevHash := sm.nextCheckpoint.Hash
 	sm.nextCheckpoint = sm.findNextHeaderCheckpoint(prevHeight)
 	if sm.nextCheckpoint != nil {
 		locator := blockchain.BlockLocator([]*chainhash.Hash{prevHash})
-		err := peer.PushGetHeadersMsg(locator, sm.nextCheckpoint.Hash)
+		err := sm.syncPeer.PushGetHeadersMsg(locator,
+			sm.nextCheckpoint.Hash)
 		if err != nil {
 			log.Warnf("Failed to send getheaders message to "+
-				"peer %s: %v", peer.Addr(), err)
+				"peer %s: %v", sm.syncPeer.Addr(), err)
 			return
 		}
 		log.Infof("Downloading headers for blocks %d to %d from "+
@@ -849,34 +1135,79 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 	sm.headerList.Init()
 	log.Infof("Reached the final checkpoint -- switching to normal mode")
 	locator := blockchain.BlockLocator([]*chainhash.Hash{blockHash})
-	err = peer.PushGetBlocksMsg(locator, &zeroHash)
+	err = sm.syncPeer.PushGetBlocksMsg(locator, &zeroHash)
 	if err != nil {
 		log.Warnf("Failed to send getblocks message to peer %s: %v",
-			peer.Addr(), err)
+			sm.syncPeer.Addr(), err)
 		return
 	}
 }
 
-// fetchHeaderBlocks creates and sends a request to the syncPeer for the next
-// list of blocks to be downloaded based on the current list of headers.
+// inDownloadWindow returns whether the block with the passed hash is within the
+// download window of headers-first mode, but not the next block to process.
+func (sm *SyncManager) inDownloadWindow(hash *chainhash.Hash) bool {
+	e := sm.headerList.Front()
+	if e == nil {
+		return false
+	}
+	for i := 1; i < blockDownloadWindow; i++ {
+		e = e.Next()
+		if e == nil {
+			break
+		}
+		if e.Value.(*headerNode).hash.IsEqual(hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// downloadPeers returns the peers which blocks may be requested from in
+// headers-first mode along with the number of blocks in flight from each.
+func (sm *SyncManager) downloadPeers() map[*peerpkg.Peer]int {
+	peers := make(map[*peerpkg.Peer]int)
+	for peer, state := range sm.peerStates {
+		if state.syncCandidate {
+			peers[peer] = 0
+		}
+	}
+	for _, req := range sm.blockRequests {
+		if _, ok := peers[req.peer]; ok {
+			peers[req.peer]++
+		}
+	}
+	return peers
+}
+
+// fetchHeaderBlocks requests the blocks within the download window, which
+// starts at the next block to process in the list of headers, that are neither
+// in flight nor downloaded yet.  The blocks are spread over all sync
+// candidates, preferring the ones with the fewest blocks in flight, and no
+// more than maxInFlightBlocksPerPeer blocks are requested from a single peer.
 func (sm *SyncManager) fetchHeaderBlocks() {
-	// Nothing to do if there is no start header.
-	if sm.startHeader == nil {
-		log.Warnf("fetchHeaderBlocks called with no start header")
+	// Nothing to do unless the blocks for the headers are being fetched.
+	if !sm.fetchingBlocks {
+		log.Warnf("fetchHeaderBlocks called while not fetching blocks")
 		return
 	}
 
-	// Build up a getdata request for the list of blocks the headers
-	// describe.  The size hint will be limited to wire.MaxInvPerMsg by
-	// the function, so no need to double check it here.
-	gdmsg := wire.NewMsgGetDataSizeHint(uint(sm.headerList.Len()))
-	numRequested := 0
-	for e := sm.startHeader; e != nil; e = e.Next() {
+	peers := sm.downloadPeers()
+	requests := make(map[*peerpkg.Peer]*wire.MsgGetData)
+	now := time.Now()
+	i := 0
+	for e := sm.headerList.Front(); e != nil && i < blockDownloadWindow; e = e.Next() {
+		i++
 		node, ok := e.Value.(*headerNode)
 		if !ok {
 			log.Warn("Header list node type is not a headerNode")
 			continue
 		}
+		if _, ok := sm.blockRequests[*node.hash]; ok {
+			continue
+		}
+		if _, ok := sm.downloadedBlocks[*node.hash]; ok {
+			continue
+		}
 
 		iv := wire.NewInvVect(wire.InvTypeBlock, node.hash)
 		haveInv, err := sm.haveInventory(iv)
@@ -885,44 +1216,126 @@ func (sm *SyncManager) fetchHeaderBlocks() {
 				"existing inventory during header block "+
 				"fetch: %v", err)
 		}
-		if !haveInv {
-			syncPeerState := sm.peerStates[sm.syncPeer]
+		if haveInv {
+			continue
+		}
 
-			sm.requestedBlocks[*node.hash] = struct{}{}
-			syncPeerState.requestedBlocks[*node.hash] = struct{}{}
+		// Pick the least busy peer which has the block and isn't
+		// at its limit of blocks in flight.
+		var peer *peerpkg.Peer
+		for p, numInFlight := range peers {
+			if numInFlight >= maxInFlightBlocksPerPeer ||
+				p.LastBlock() < node.height {
 
-			// If we're fetching from a witness enabled peer
-			// post-fork, then ensure that we receive all the
-			// witness data in the blocks.
-			if sm.syncPeer.IsWitnessEnabled() {
-				iv.Type = wire.InvTypeWitnessBlock
+				continue
+			}
+			if peer == nil || numInFlight < peers[peer] {
+				peer = p
 			}
-
-			gdmsg.AddInvVect(iv)
-			numRequested++
 		}
-		sm.startHeader = e.Next()
-		if numRequested >= wire.MaxInvPerMsg {
+		if peer == nil {
 			break
 		}
+		peers[peer]++
+
+		sm.requestedBlocks[*node.hash] = struct{}{}
+		sm.peerStates[peer].requestedBlocks[*node.hash] = struct{}{}
+		sm.blockRequests[*node.hash] = &blockRequest{
+			peer:      peer,
+			height:    node.height,
+			requested: now,
+		}
+
+		// If we're fetching from a witness enabled peer post-fork,
+		// then ensure that we receive all the witness data in the
+		// blocks.
+		if peer.IsWitnessEnabled() {
+			iv.Type = wire.InvTypeWitnessBlock
+		}
+
+		gdmsg, ok := requests[peer]
+		if !ok {
+			gdmsg = wire.NewMsgGetDataSizeHint(maxInFlightBlocksPerPeer)
+			requests[peer] = gdmsg
+		}
+		gdmsg.AddInvVect(iv)
 	}
-	if len(gdmsg.InvList) > 0 {
-		sm.syncPeer.QueueMessage(gdmsg, nil)
+	for peer, gdmsg := range requests {
+		peer.QueueMessage(gdmsg, nil)
+	}
+
+	sm.checkDownloadStall(len(peers))
+}
+
+// checkDownloadStall disconnects the peer the next block to process was
+// requested from in headers-first mode when it failed to deliver the block
+// within maxBlockStallDuration while the download window is exhausted, since
+// it holds up the download from all other peers then.  The peer is kept when
+// there are no other peers to download the block from.
+func (sm *SyncManager) checkDownloadStall(numPeers int) {
+	front := sm.headerList.Front()
+	if front == nil || numPeers < 2 {
+		return
+	}
+	req, ok := sm.blockRequests[*front.Value.(*headerNode).hash]
+	if !ok || time.Since(req.requested) <= maxBlockStallDuration {
+		return
+	}
+
+	windowSize := sm.headerList.Len()
+	if windowSize > blockDownloadWindow {
+		windowSize = blockDownloadWindow
+	}
+	if len(sm.blockRequests)+len(sm.downloadedBlocks) < windowSize {
+		return
+	}
+
+	log.Infof("Peer %s is stalling the block download at height %d -- "+
+		"disconnecting", req.peer, req.height)
+	if req.peer == sm.syncPeer {
+		sm.clearRequestedState(sm.peerStates[req.peer])
+		sm.updateSyncPeer(true)
+		return
 	}
+
+	// Stop downloading from the peer and request its blocks from the
+	// remaining peers right away rather than once it is gone.  The blocks
+	// are forgotten by the peer state as well, so they aren't cleared
+	// again once the peer is gone while being requested from other peers.
+	state := sm.peerStates[req.peer]
+	state.syncCandidate = false
+	sm.clearRequestedState(state)
+	state.requestedBlocks = make(map[chainhash.Hash]struct{})
+	req.peer.Disconnect()
+	sm.fetchHeaderBlocks()
 }
 
 // handleHeadersMsg handles block header messages from all peers.  Headers are
-// requested when performing a headers-first sync.
+// requested when performing a headers-first sync and for blocks which don't
+// connect to the block chain otherwise.
 func (sm *SyncManager) handleHeadersMsg(hmsg *headersMsg) {
 	peer := hmsg.peer
-	_, exists := sm.peerStates[peer]
+	state, exists := sm.peerStates[peer]
 	if !exists {
 		log.Warnf("Received headers message from unknown peer %s", peer)
 		return
 	}
 
-	// The remote peer is misbehaving if we didn't request headers.
+	// Headers are handled separately when syncing without checkpoints.
 	msg := hmsg.headers
+	if sm.headersFirstMode && sm.nextCheckpoint == nil {
+		sm.handleHeadersSyncMsg(peer, msg)
+		return
+	}
+
+	// Headers requested outside of headers-first mode lead to the blocks
+	// which didn't connect to the block chain.
+	if !sm.headersFirstMode && state.requestedHeaders > 0 {
+		sm.handlePendingHeadersMsg(peer, state, msg)
+		return
+	}
+
+	// The remote peer is misbehaving if we didn't request headers.
 	numHeaders := len(msg.Headers)
 	if !sm.headersFirstMode {
 		log.Warnf("Got %d unrequested headers from %s -- "+
@@ -959,10 +1372,7 @@ func (sm *SyncManager) handleHeadersMsg(hmsg *headersMsg) {
 		prevNode := prevNodeEl.Value.(*headerNode)
 		if prevNode.hash.IsEqual(&blockHeader.PrevBlock) {
 			node.height = prevNode.height + 1
-			e := sm.headerList.PushBack(&node)
-			if sm.startHeader == nil {
-				sm.startHeader = e
-			}
+			sm.headerList.PushBack(&node)
 		} else {
 			log.Warnf("Received block header that does not "+
 				"properly connect to the chain from peer %s "+
@@ -1003,6 +1413,7 @@ func (sm *SyncManager) handleHeadersMsg(hmsg *headersMsg) {
 		log.Infof("Received %v block headers: Fetching blocks",
 			sm.headerList.Len())
 		sm.progressLogger.SetLastLogTime(time.Now())
+		sm.fetchingBlocks = true
 		sm.fetchHeaderBlocks()
 		return
 	}
@@ -1037,6 +1448,14 @@ func (sm *SyncManager) handleNotFoundMsg(nfmsg *notFoundMsg) {
 			if _, exists := state.requestedBlocks[inv.Hash]; exists {
 				delete(state.requestedBlocks, inv.Hash)
 				delete(sm.requestedBlocks, inv.Hash)
+				req, ok := sm.blockRequests[inv.Hash]
+				if ok && req.peer == peer {
+					delete(sm.blockRequests, inv.Hash)
+				}
+				req, ok = sm.backgroundRequests[inv.Hash]
+				if ok && req.peer == peer {
+					delete(sm.backgroundRequests, inv.Hash)
+				}
 			}
 
 		case wire.InvTypeWitnessTx:
@@ -1203,27 +1622,16 @@ func (sm *SyncManager) handleInvMsg(imsg *invMsg) {
 		if iv.Type == wire.InvTypeBlock {
 			// The block is an orphan block that we already have.
 			// When the existing orphan was processed, it requested
-			// the missing parent blocks.  When this scenario
-			// happens, it means there were more blocks missing
-			// than are allowed into a single inventory message.  As
-			// a result, once this peer requested the final
-			// advertised block, the remote peer noticed and is now
-			// resending the orphan block as an available block
-			// to signal there are more missing blocks that need to
-			// be requested.
+			// the headers leading up to it.  When this scenario
+			// happens, the missing parent blocks may not have been
+			// requested yet, for example since their headers were
+			// evicted in the meantime.
 			if sm.chain.IsKnownOrphan(&iv.Hash) {
-				// Request blocks starting at the latest known
-				// up to the root of the orphan that just came
-				// in.
+				// Request the headers starting at the latest
+				// known block up to the root of the orphan
+				// that was announced.
 				orphanRoot := sm.chain.GetOrphanRoot(&iv.Hash)
-				locator, err := sm.chain.LatestBlockLocator()
-				if err != nil {
-					log.Errorf("PEER: Failed to get block "+
-						"locator for the latest block: "+
-						"%v", err)
-					continue
-				}
-				peer.PushGetBlocksMsg(locator, orphanRoot)
+				sm.requestHeaders(peer, state, orphanRoot)
 				continue
 			}
 
@@ -1366,6 +1774,13 @@ out:
 				// Wait until the sender unpauses the manager.
 				<-msg.unpause
 
+			case getPendingHeaderStatsMsg:
+				msg.reply <- PendingHeaderStats{
+					Count:             sm.pendingHeaders.order.Len(),
+					Evicted:           sm.pendingHeaders.evicted,
+					UnconnectedBlocks: sm.unconnectedBlocks,
+				}
+
 			default:
 				log.Warnf("Invalid message type in block "+
 					"handler: %T", msg)
@@ -1604,6 +2019,14 @@ func (sm *SyncManager) IsCurrent() bool {
 	return <-reply
 }
 
+// PendingHeaderStats returns statistics about the headers of blocks which
+// aren't connected to the block chain yet.
+func (sm *SyncManager) PendingHeaderStats() PendingHeaderStats {
+	reply := make(chan PendingHeaderStats)
+	sm.msgChan <- getPendingHeaderStatsMsg{reply: reply}
+	return <-reply
+}
+
 // Pause pauses the sync manager until the returned channel is closed.
 //
 // Note that while paused, all peer and block processing is halted.  The
@@ -1618,23 +2041,34 @@ func (sm *SyncManager) Pause() chan<- struct{} {
 // block, tx, and inv updates.
 func New(config *Config) (*SyncManager, error) {
 	sm := SyncManager{
-		peerNotifier:    config.PeerNotifier,
-		chain:           config.Chain,
-		txMemPool:       config.TxMemPool,
-		chainParams:     config.ChainParams,
-		rejectedTxns:    make(map[chainhash.Hash]struct{}),
-		requestedTxns:   make(map[chainhash.Hash]struct{}),
-		requestedBlocks: make(map[chainhash.Hash]struct{}),
-		peerStates:      make(map[*peerpkg.Peer]*peerSyncState),
-		progressLogger:  newBlockProgressLogger("Processed", log),
-		msgChan:         make(chan interface{}, config.MaxPeers*3),
-		headerList:      list.New(),
-		quit:            make(chan struct{}),
-		feeEstimator:    config.FeeEstimator,
+		peerNotifier:     config.PeerNotifier,
+		chain:            config.Chain,
+		txMemPool:        config.TxMemPool,
+		chainParams:      config.ChainParams,
+		rejectedTxns:     make(map[chainhash.Hash]struct{}),
+		requestedTxns:    make(map[chainhash.Hash]struct{}),
+		requestedBlocks:  make(map[chainhash.Hash]struct{}),
+		peerStates:       make(map[*peerpkg.Peer]*peerSyncState),
+		pendingHeaders:   newPendingHeaders(),
+		progressLogger:   newBlockProgressLogger("Processed", log),
+		msgChan:          make(chan interface{}, config.MaxPeers*3),
+		headerList:       list.New(),
+		blockRequests:    make(map[chainhash.Hash]*blockRequest),
+		downloadedBlocks: make(map[chainhash.Hash]*blockMsg),
+		quit:             make(chan struct{}),
+		feeEstimator:     config.FeeEstimator,
+		syncMode:         config.SyncMode,
+		minimumChainWork: config.MinimumChainWork,
+
+		backgroundChain:     config.BackgroundChain,
+		backgroundChainDone: config.BackgroundChainDone,
+		backgroundRequests:  make(map[chainhash.Hash]*blockRequest),
 	}
 
 	best := sm.chain.BestSnapshot()
-	if !config.DisableCheckpoints {
+	if config.SyncMode == SyncModeHeadersPresync {
+		log.Info("Syncing headers without checkpoints")
+	} else if !config.DisableCheckpoints {
 		// Initialize the next checkpoint based on the current height.
 		sm.nextCheckpoint = sm.findNextHeaderCheckpoint(best.Height)
 		if sm.nextCheckpoint != nil {
@@ -0,0 +1,323 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package netsync
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/database"
+	_ "github.com/btcsuite/btcd/database/ffldb"
+	peerpkg "github.com/btcsuite/btcd/peer"
+)
+
+// newTestSyncManager returns a sync manager for a new chain on the regression
+// test network which is fetching the blocks of the passed number of headers in
+// headers-first mode, along with a function to tear it down.
+func newTestSyncManager(t *testing.T, numHeaders int) (*SyncManager, func()) {
+	t.Helper()
+
+	params := &chaincfg.RegressionNetParams
+	dbPath, err := ioutil.TempDir("", "netsynctest")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	db, err := database.Create("ffldb", filepath.Join(dbPath, "db"),
+		params.Net)
+	if err != nil {
+		os.RemoveAll(dbPath)
+		t.Fatalf("unable to create database: %v", err)
+	}
+	teardown := func() {
+		db.Close()
+		os.RemoveAll(dbPath)
+	}
+
+	chain, err := blockchain.New(&blockchain.Config{
+		DB:          db,
+		ChainParams: params,
+		TimeSource:  blockchain.NewMedianTime(),
+	})
+	if err != nil {
+		teardown()
+		t.Fatalf("unable to create chain: %v", err)
+	}
+	sm, err := New(&Config{
+		Chain:       chain,
+		ChainParams: params,
+		MaxPeers:    8,
+	})
+	if err != nil {
+		teardown()
+		t.Fatalf("unable to create sync manager: %v", err)
+	}
+
+	// The headers don't need to be valid since only their hashes are
+	// used to request the blocks.
+	sm.headersFirstMode = true
+	sm.fetchingBlocks = true
+	for height := int32(1); height <= int32(numHeaders); height++ {
+		hash := chainhash.Hash{byte(height), byte(height >> 8), 0xff}
+		sm.headerList.PushBack(&headerNode{height: height, hash: &hash})
+	}
+
+	return sm, teardown
+}
+
+// addTestPeer adds a sync candidate which knows about the blocks up to the
+// passed height to the passed sync manager.  The peer isn't connected, so
+// messages queued to it are dropped.
+func addTestPeer(t *testing.T, sm *SyncManager, lastBlock int32) *peerpkg.Peer {
+	t.Helper()
+
+	peer, err := peerpkg.NewOutboundPeer(&peerpkg.Config{
+		ChainParams: sm.chainParams,
+	}, "127.0.0.1:18444")
+	if err != nil {
+		t.Fatalf("unable to create peer: %v", err)
+	}
+	peer.UpdateLastBlockHeight(lastBlock)
+	sm.peerStates[peer] = &peerSyncState{
+		syncCandidate:   true,
+		requestedTxns:   make(map[chainhash.Hash]struct{}),
+		requestedBlocks: make(map[chainhash.Hash]struct{}),
+	}
+	return peer
+}
+
+// checkBlockRequests ensures the blocks of the first passed number of headers
+// are requested, no other blocks are requested, and the requests are recorded
+// in the state of the peers they were requested from.  It returns the number
+// of blocks requested from each peer.
+func checkBlockRequests(t *testing.T, sm *SyncManager, numRequested int) map[*peerpkg.Peer]int {
+	t.Helper()
+
+	if len(sm.blockRequests) != numRequested {
+		t.Fatalf("unexpected number of requested blocks %d, want %d",
+			len(sm.blockRequests), numRequested)
+	}
+	i := 0
+	for e := sm.headerList.Front(); e != nil && i < numRequested; e = e.Next() {
+		i++
+		node := e.Value.(*headerNode)
+		req, ok := sm.blockRequests[*node.hash]
+		if !ok {
+			t.Fatalf("block %d was not requested", node.height)
+		}
+		if req.height != node.height {
+			t.Fatalf("unexpected height %d of the request for "+
+				"block %d", req.height, node.height)
+		}
+		if req.peer.LastBlock() < node.height {
+			t.Fatalf("block %d requested from peer which only "+
+				"knows blocks up to %d", node.height,
+				req.peer.LastBlock())
+		}
+		if _, ok := sm.requestedBlocks[*node.hash]; !ok {
+			t.Fatalf("block %d is not marked as requested",
+				node.height)
+		}
+		state := sm.peerStates[req.peer]
+		if _, ok := state.requestedBlocks[*node.hash]; !ok {
+			t.Fatalf("block %d is not marked as requested from "+
+				"the peer", node.height)
+		}
+	}
+
+	numInFlight := make(map[*peerpkg.Peer]int)
+	for _, req := range sm.blockRequests {
+		numInFlight[req.peer]++
+	}
+	for peer, num := range numInFlight {
+		if num > maxInFlightBlocksPerPeer {
+			t.Fatalf("%d blocks requested from a single peer", num)
+		}
+		if len(sm.peerStates[peer].requestedBlocks) != num {
+			t.Fatalf("peer state has %d requested blocks, want %d",
+				len(sm.peerStates[peer].requestedBlocks), num)
+		}
+	}
+	return numInFlight
+}
+
+// TestFetchHeaderBlocks ensures the blocks of the headers are requested from
+// all sync candidates which have them within the download window and the limit
+// of blocks in flight per peer.
+func TestFetchHeaderBlocks(t *testing.T) {
+	sm, teardown := newTestSyncManager(t, 2*blockDownloadWindow)
+	defer teardown()
+
+	// The number of requests is limited by the blocks in flight per peer
+	// with only a few peers.  The peer which only knows the first blocks
+	// doesn't get any later ones.
+	const lowHeight = 10
+	var peers []*peerpkg.Peer
+	for i := 0; i < 3; i++ {
+		peers = append(peers, addTestPeer(t, sm, 2*blockDownloadWindow))
+	}
+	lowPeer := addTestPeer(t, sm, lowHeight)
+	sm.fetchHeaderBlocks()
+	numLow := len(sm.peerStates[lowPeer].requestedBlocks)
+	if numLow == 0 || numLow > lowHeight {
+		t.Fatalf("unexpected number of blocks %d requested from the "+
+			"peer with few blocks", numLow)
+	}
+	numInFlight := checkBlockRequests(t, sm,
+		len(peers)*maxInFlightBlocksPerPeer+numLow)
+	for _, peer := range peers {
+		if numInFlight[peer] != maxInFlightBlocksPerPeer {
+			t.Fatalf("unexpected number of blocks %d requested "+
+				"from a peer", numInFlight[peer])
+		}
+	}
+
+	// Additional peers fill the download window, but no blocks past it
+	// are requested.
+	for i := 0; i < 7; i++ {
+		peers = append(peers, addTestPeer(t, sm, 2*blockDownloadWindow))
+	}
+	sm.fetchHeaderBlocks()
+	checkBlockRequests(t, sm, blockDownloadWindow)
+
+	// Blocks which were downloaded already aren't requested again and
+	// the window moves on once the next block was processed.
+	front := sm.headerList.Front()
+	node := front.Value.(*headerNode)
+	req := sm.blockRequests[*node.hash]
+	delete(sm.blockRequests, *node.hash)
+	delete(sm.requestedBlocks, *node.hash)
+	delete(sm.peerStates[req.peer].requestedBlocks, *node.hash)
+	sm.downloadedBlocks[*node.hash] = &blockMsg{peer: req.peer}
+	sm.fetchHeaderBlocks()
+	if _, ok := sm.blockRequests[*node.hash]; ok {
+		t.Fatal("downloaded block was requested again")
+	}
+	if len(sm.blockRequests) != blockDownloadWindow-1 {
+		t.Fatalf("unexpected number of requested blocks %d",
+			len(sm.blockRequests))
+	}
+
+	delete(sm.downloadedBlocks, *node.hash)
+	sm.headerList.Remove(front)
+	sm.fetchHeaderBlocks()
+	checkBlockRequests(t, sm, blockDownloadWindow)
+}
+
+// TestCheckDownloadStall ensures a peer which fails to deliver the next block
+// while the download window is exhausted is disconnected and its blocks are
+// requested from the remaining peers.
+func TestCheckDownloadStall(t *testing.T) {
+	sm, teardown := newTestSyncManager(t, 2*blockDownloadWindow)
+	defer teardown()
+
+	const numPeers = 10
+	for i := 0; i < numPeers; i++ {
+		addTestPeer(t, sm, 2*blockDownloadWindow)
+	}
+	sm.fetchHeaderBlocks()
+	checkBlockRequests(t, sm, blockDownloadWindow)
+
+	front := sm.headerList.Front().Value.(*headerNode)
+	req := sm.blockRequests[*front.hash]
+	stallPeer := req.peer
+	sm.syncPeer = addTestPeer(t, sm, 0)
+
+	// A peer which didn't exceed the stall duration is kept.
+	sm.checkDownloadStall(numPeers)
+	if !sm.peerStates[stallPeer].syncCandidate {
+		t.Fatal("peer disconnected before exceeding the stall duration")
+	}
+
+	// A stalling peer is kept when the download window isn't exhausted,
+	// since other peers may still download blocks then, and when there
+	// are no other peers to download the block from.
+	req.requested = time.Now().Add(-2 * maxBlockStallDuration)
+	last := sm.headerList.Front()
+	for i := 1; i < blockDownloadWindow; i++ {
+		last = last.Next()
+	}
+	lastNode := last.Value.(*headerNode)
+	lastReq := sm.blockRequests[*lastNode.hash]
+	delete(sm.blockRequests, *lastNode.hash)
+	sm.checkDownloadStall(numPeers)
+	if !sm.peerStates[stallPeer].syncCandidate {
+		t.Fatal("peer disconnected while the download window " +
+			"isn't exhausted")
+	}
+	sm.blockRequests[*lastNode.hash] = lastReq
+	sm.checkDownloadStall(1)
+	if !sm.peerStates[stallPeer].syncCandidate {
+		t.Fatal("peer disconnected without other peers")
+	}
+
+	// The stalling peer is disconnected once the window is exhausted and
+	// all of its blocks are requested from the remaining peers.
+	sm.checkDownloadStall(numPeers)
+	if sm.peerStates[stallPeer].syncCandidate {
+		t.Fatal("stalling peer is still a sync candidate")
+	}
+	if len(sm.peerStates[stallPeer].requestedBlocks) != 0 {
+		t.Fatal("blocks are still requested from the stalling peer")
+	}
+	numInFlight := checkBlockRequests(t, sm, blockDownloadWindow)
+	if numInFlight[stallPeer] != 0 {
+		t.Fatalf("%d blocks requested from the stalling peer",
+			numInFlight[stallPeer])
+	}
+	if sm.blockRequests[*front.hash].peer == stallPeer {
+		t.Fatal("next block was not reassigned")
+	}
+}
+
+// TestDonePeerReassignsBlocks ensures the blocks in flight from a peer which
+// disconnects are requested from the remaining peers.
+func TestDonePeerReassignsBlocks(t *testing.T) {
+	sm, teardown := newTestSyncManager(t, 2*blockDownloadWindow)
+	defer teardown()
+
+	for i := 0; i < 10; i++ {
+		addTestPeer(t, sm, 2*blockDownloadWindow)
+	}
+	sm.syncPeer = addTestPeer(t, sm, 0)
+	sm.fetchHeaderBlocks()
+	checkBlockRequests(t, sm, blockDownloadWindow)
+
+	front := sm.headerList.Front().Value.(*headerNode)
+	donePeer := sm.blockRequests[*front.hash].peer
+	sm.handleDonePeerMsg(donePeer)
+	if _, ok := sm.peerStates[donePeer]; ok {
+		t.Fatal("state of the disconnected peer was kept")
+	}
+	numInFlight := checkBlockRequests(t, sm, blockDownloadWindow)
+	if numInFlight[donePeer] != 0 {
+		t.Fatalf("%d blocks requested from the disconnected peer",
+			numInFlight[donePeer])
+	}
+
+	// The blocks can't be reassigned once the remaining peers are at
+	// their limit of blocks in flight.
+	for peer := range numInFlight {
+		if len(sm.peerStates) <= 8 {
+			break
+		}
+		sm.handleDonePeerMsg(peer)
+	}
+	if len(sm.blockRequests) != 7*maxInFlightBlocksPerPeer {
+		t.Fatalf("unexpected number of requested blocks %d",
+			len(sm.blockRequests))
+	}
+	for _, req := range sm.blockRequests {
+		if _, ok := sm.peerStates[req.peer]; !ok {
+			t.Fatalf("block %d requested from disconnected peer",
+				req.height)
+		}
+	}
+}
@@ -5,6 +5,9 @@
 package main
 
 import (
+	"fmt"
+	"strconv"
+
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/wire"
 )
@@ -18,6 +21,7 @@ var activeNetParams = &mainNetParams
 type params struct {
 	*chaincfg.Params
 	rpcPort string
+	sv2Port string
 }
 
 // mainNetParams contains parameters specific to the main network
@@ -29,6 +33,7 @@ type params struct {
 var mainNetParams = params{
 	Params:  &chaincfg.MainNetParams,
 	rpcPort: "8334",
+	sv2Port: "8336",
 }
 
 // regressionNetParams contains parameters specific to the regression test
@@ -38,6 +43,7 @@ var mainNetParams = params{
 var regressionNetParams = params{
 	Params:  &chaincfg.RegressionNetParams,
 	rpcPort: "18334",
+	sv2Port: "18447",
 }
 
 // testNet3Params contains parameters specific to the test network (version 3)
@@ -46,6 +52,7 @@ var regressionNetParams = params{
 var testNet3Params = params{
 	Params:  &chaincfg.TestNet3Params,
 	rpcPort: "18334",
+	sv2Port: "18336",
 }
 
 // simNetParams contains parameters specific to the simulation test network
@@ -53,6 +60,7 @@ var testNet3Params = params{
 var simNetParams = params{
 	Params:  &chaincfg.SimNetParams,
 	rpcPort: "18556",
+	sv2Port: "18558",
 }
 
 // sigNetParams contains parameters specific to the Signet network
@@ -60,6 +68,25 @@ var simNetParams = params{
 var sigNetParams = params{
 	Params:  &chaincfg.SigNetParams,
 	rpcPort: "38332",
+	sv2Port: "38336",
+}
+
+// customNetParams returns the parameters for the passed custom network, which
+// has been registered with chaincfg.RegisterCustomNetwork.  Following the
+// default networks, the RPC port defaults to the one after the peer-to-peer
+// port and the Stratum V2 port to the third one after it.
+func customNetParams(chainParams *chaincfg.Params) (*params, error) {
+	port, err := strconv.ParseUint(chainParams.DefaultPort, 10, 16)
+	if err != nil || port > 65535-3 {
+		return nil, fmt.Errorf("invalid default port %q for network %s",
+			chainParams.DefaultPort, chainParams.Name)
+	}
+
+	return &params{
+		Params:  chainParams,
+		rpcPort: strconv.FormatUint(port+1, 10),
+		sv2Port: strconv.FormatUint(port+3, 10),
+	}, nil
 }
 
 // netName returns the name used when referring to a bitcoin network.  At the
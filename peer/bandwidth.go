@@ -0,0 +1,229 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package peer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// MessageCategory identifies the category of a message for the purposes of
+// bandwidth accounting and rate limiting.
+type MessageCategory uint8
+
+const (
+	// CategoryBlock is the category of messages carrying block data, that
+	// is block, merkleblock, cmpctblock, and blocktxn messages.
+	CategoryBlock MessageCategory = iota
+
+	// CategoryTx is the category of messages carrying transactions, that
+	// is tx and pkgtxns messages.
+	CategoryTx
+
+	// CategoryAddr is the category of address relay messages, that is
+	// addr, addrv2, and getaddr messages.
+	CategoryAddr
+
+	// CategoryOther is the category of all other messages, including
+	// inventory announcements.
+	CategoryOther
+
+	// numCategories is the number of message categories.
+	numCategories
+)
+
+// String returns the MessageCategory in human-readable form.
+func (c MessageCategory) String() string {
+	switch c {
+	case CategoryBlock:
+		return "block"
+	case CategoryTx:
+		return "tx"
+	case CategoryAddr:
+		return "addr"
+	case CategoryOther:
+		return "other"
+	}
+	return fmt.Sprintf("Unknown MessageCategory (%d)", uint8(c))
+}
+
+// messageCategory returns the category of the passed message.  A nil message,
+// as is the case for messages which failed to decode, is categorized as other.
+func messageCategory(msg wire.Message) MessageCategory {
+	switch msg.(type) {
+	case *wire.MsgBlock, *wire.MsgMerkleBlock, *wire.MsgCmpctBlock,
+		*wire.MsgBlockTxn:
+
+		return CategoryBlock
+
+	case *wire.MsgTx, *wire.MsgPkgTxns:
+		return CategoryTx
+
+	case *wire.MsgAddr, *wire.MsgAddrV2, *wire.MsgGetAddr:
+		return CategoryAddr
+	}
+	return CategoryOther
+}
+
+// RateLimit specifies a token bucket rate limit for the messages of a
+// category.
+type RateLimit struct {
+	// BytesPerSecond is the rate at which bytes may be transferred on
+	// average.  Zero means the category is not limited.
+	BytesPerSecond uint64
+
+	// Burst is the number of bytes which may be transferred at once before
+	// the rate applies.  It defaults to BytesPerSecond when zero.  Messages
+	// larger than the burst are not rejected, but delay the messages after
+	// them accordingly.
+	Burst uint64
+}
+
+// CategoryStats houses the traffic statistics of a message category.
+type CategoryStats struct {
+	// Messages is the number of messages transferred.
+	Messages uint64
+
+	// Bytes is the number of bytes transferred including message headers.
+	Bytes uint64
+
+	// Throttled is the total time messages were delayed to enforce the
+	// rate limit.
+	Throttled time.Duration
+}
+
+// TrafficStats houses the traffic statistics of a peer by message category.
+type TrafficStats struct {
+	Inbound  map[MessageCategory]CategoryStats
+	Outbound map[MessageCategory]CategoryStats
+}
+
+// tokenBucket implements a token bucket with tokens denominated in bytes.  The
+// bucket may go into debt, in which case the time until the debt is paid off
+// is the time the caller has to wait.
+type tokenBucket struct {
+	rate   float64 // tokens added per second
+	burst  float64 // maximum number of tokens
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a full token bucket for the passed rate limit or nil
+// when the limit doesn't restrict the rate.
+func newTokenBucket(limit RateLimit, now time.Time) *tokenBucket {
+	if limit.BytesPerSecond == 0 {
+		return nil
+	}
+	burst := limit.Burst
+	if burst == 0 {
+		burst = limit.BytesPerSecond
+	}
+	return &tokenBucket{
+		rate:   float64(limit.BytesPerSecond),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+// take removes the passed number of tokens from the bucket and returns how
+// long to wait until the bucket is no longer in debt.
+func (b *tokenBucket) take(n int, now time.Time) time.Duration {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// trafficMeter accounts the traffic of one direction of a peer connection
+// and enforces the configured rate limits.
+type trafficMeter struct {
+	mtx     sync.Mutex
+	buckets [numCategories]*tokenBucket
+	stats   [numCategories]CategoryStats
+}
+
+// newTrafficMeter returns a traffic meter enforcing the passed rate limits.
+func newTrafficMeter(limits map[MessageCategory]RateLimit) *trafficMeter {
+	now := time.Now()
+	var m trafficMeter
+	for category, limit := range limits {
+		if category < numCategories {
+			m.buckets[category] = newTokenBucket(limit, now)
+		}
+	}
+	return &m
+}
+
+// record accounts a message of the passed size and returns how long to wait
+// before the next message of the category may be transferred.
+func (m *trafficMeter) record(msg wire.Message, n int) time.Duration {
+	category := messageCategory(msg)
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	stats := &m.stats[category]
+	if msg != nil {
+		stats.Messages++
+	}
+	stats.Bytes += uint64(n)
+
+	bucket := m.buckets[category]
+	if bucket == nil {
+		return 0
+	}
+	wait := bucket.take(n, time.Now())
+	stats.Throttled += wait
+	return wait
+}
+
+// snapshot returns the current statistics by category.
+func (m *trafficMeter) snapshot() map[MessageCategory]CategoryStats {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	stats := make(map[MessageCategory]CategoryStats, numCategories)
+	for category := MessageCategory(0); category < numCategories; category++ {
+		stats[category] = m.stats[category]
+	}
+	return stats
+}
+
+// TrafficStats returns the traffic statistics of the peer by message category.
+//
+// This function is safe for concurrent access.
+func (p *Peer) TrafficStats() TrafficStats {
+	return TrafficStats{
+		Inbound:  p.inTraffic.snapshot(),
+		Outbound: p.outTraffic.snapshot(),
+	}
+}
+
+// throttle blocks for the passed duration or until the peer is disconnected.
+func (p *Peer) throttle(wait time.Duration) {
+	if wait <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-p.quit:
+	}
+}
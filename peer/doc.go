@@ -128,12 +128,26 @@ appropriate reject message based on the provided parameters as well as
 optionally provides a flag to cause it to block until the message is actually
 sent.
 
+Message Interceptors
+
+The AddMessageInterceptor function registers interceptors which are invoked
+with the messages received from and sent to the peer, optionally restricted to
+messages with certain commands.  Interceptors are able to observe, modify,
+replace, delay, or drop messages, which is useful to simulate misbehaving
+networks and peers in tests.  The InterceptMetrics field of the Config struct
+can be set to observe what the interceptors did with each message.
+
 Peer Statistics
 
 A snapshot of the current peer statistics can be obtained with the StatsSnapshot
 function.  This includes statistics such as the total number of bytes read and
 written, the remote address, user agent, and negotiated protocol version.
 
+The TrafficStats function additionally breaks down the traffic by message
+category.  Token bucket rate limits for each category and direction can be
+configured with the InboundRateLimits and OutboundRateLimits fields of the
+Config struct.
+
 Logging
 
 This package provides extensive logging capabilities through the UseLogger
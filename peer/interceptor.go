@@ -0,0 +1,178 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package peer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// MessageDirection identifies whether a message is received from or sent to a
+// peer.
+type MessageDirection uint8
+
+const (
+	// MsgInbound identifies messages received from the remote peer.
+	MsgInbound MessageDirection = iota
+
+	// MsgOutbound identifies messages sent to the remote peer.
+	MsgOutbound
+)
+
+// String returns the MessageDirection in human-readable form.
+func (d MessageDirection) String() string {
+	switch d {
+	case MsgInbound:
+		return "inbound"
+	case MsgOutbound:
+		return "outbound"
+	}
+	return fmt.Sprintf("Unknown MessageDirection (%d)", uint8(d))
+}
+
+// MessageInterceptor is invoked with every message received from or sent to a
+// peer the interceptor is registered for.  It returns the message to process
+// or send in place of the passed message, which may be the passed message
+// itself, a modified version of it, or an entirely different message.
+// Returning nil drops the message.
+//
+// Interceptors are invoked from the goroutine reading or writing the message,
+// so they may delay a message by blocking, which stalls all messages in the
+// same direction just like a slow network link would.  Inbound messages are
+// intercepted before they are handled and outbound messages right before they
+// are written, which includes the messages of the version negotiation.
+type MessageInterceptor func(p *Peer, dir MessageDirection, msg wire.Message) wire.Message
+
+// InterceptAction describes what an interceptor did with a message.
+type InterceptAction uint8
+
+const (
+	// InterceptPass indicates the interceptor returned the message it was
+	// passed.  Note that the message may still have been modified in place.
+	InterceptPass InterceptAction = iota
+
+	// InterceptReplace indicates the interceptor returned a different
+	// message than the one it was passed.
+	InterceptReplace
+
+	// InterceptDrop indicates the interceptor dropped the message.
+	InterceptDrop
+)
+
+// String returns the InterceptAction in human-readable form.
+func (a InterceptAction) String() string {
+	switch a {
+	case InterceptPass:
+		return "pass"
+	case InterceptReplace:
+		return "replace"
+	case InterceptDrop:
+		return "drop"
+	}
+	return fmt.Sprintf("Unknown InterceptAction (%d)", uint8(a))
+}
+
+// InterceptMetricsFunc is invoked after each invocation of an interceptor with
+// the command of the message the interceptor was passed, the action it took,
+// and the time it took to do so.
+type InterceptMetricsFunc func(p *Peer, dir MessageDirection, command string,
+	action InterceptAction, elapsed time.Duration)
+
+// interceptorEntry houses a registered interceptor along with the commands of
+// the messages it is invoked for.
+type interceptorEntry struct {
+	id          uint64
+	interceptor MessageInterceptor
+	commands    map[string]struct{} // nil means all commands
+}
+
+// AddMessageInterceptor registers an interceptor which is invoked for messages
+// with any of the passed commands in both directions.  The interceptor is
+// invoked for all messages when no commands are passed.  Interceptors are
+// invoked in the order they were registered, with each one being passed the
+// message returned by the previous one.  The returned function removes the
+// interceptor again.
+//
+// Interceptors which should see the messages of the version negotiation must
+// be registered before the peer is associated with a connection.
+//
+// This function is safe for concurrent access.
+func (p *Peer) AddMessageInterceptor(interceptor MessageInterceptor,
+	commands ...string) func() {
+
+	entry := interceptorEntry{interceptor: interceptor}
+	if len(commands) > 0 {
+		entry.commands = make(map[string]struct{}, len(commands))
+		for _, command := range commands {
+			entry.commands[command] = struct{}{}
+		}
+	}
+
+	p.interceptorsMtx.Lock()
+	p.nextInterceptorID++
+	entry.id = p.nextInterceptorID
+
+	// The slice is copied so chains which are currently being run are
+	// unaffected.
+	interceptors := make([]interceptorEntry, 0, len(p.interceptors)+1)
+	interceptors = append(interceptors, p.interceptors...)
+	p.interceptors = append(interceptors, entry)
+	p.interceptorsMtx.Unlock()
+
+	return func() {
+		p.interceptorsMtx.Lock()
+		defer p.interceptorsMtx.Unlock()
+
+		interceptors := make([]interceptorEntry, 0, len(p.interceptors))
+		for _, e := range p.interceptors {
+			if e.id != entry.id {
+				interceptors = append(interceptors, e)
+			}
+		}
+		p.interceptors = interceptors
+	}
+}
+
+// interceptMessage runs the passed message through the chain of registered
+// interceptors and returns the resulting message or nil when it was dropped.
+func (p *Peer) interceptMessage(dir MessageDirection, msg wire.Message) wire.Message {
+	p.interceptorsMtx.RLock()
+	interceptors := p.interceptors
+	p.interceptorsMtx.RUnlock()
+
+	for _, entry := range interceptors {
+		command := msg.Command()
+		if entry.commands != nil {
+			if _, ok := entry.commands[command]; !ok {
+				continue
+			}
+		}
+
+		start := time.Now()
+		newMsg := entry.interceptor(p, dir, msg)
+		action := InterceptPass
+		switch {
+		case newMsg == nil:
+			action = InterceptDrop
+		case newMsg != msg:
+			action = InterceptReplace
+		}
+		if p.cfg.InterceptMetrics != nil {
+			p.cfg.InterceptMetrics(p, dir, command, action,
+				time.Since(start))
+		}
+
+		if newMsg == nil {
+			log.Debugf("Interceptor dropped %s %v message for %s",
+				dir, command, p)
+			return nil
+		}
+		msg = newMsg
+	}
+
+	return msg
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcd/wire/v2transport"
 	"github.com/btcsuite/go-socks/socks"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/decred/dcrd/lru"
@@ -102,6 +103,9 @@ type MessageListeners struct {
 	// OnAddr is invoked when a peer receives an addr bitcoin message.
 	OnAddr func(p *Peer, msg *wire.MsgAddr)
 
+	// OnAddrV2 is invoked when a peer receives an addrv2 bitcoin message.
+	OnAddrV2 func(p *Peer, msg *wire.MsgAddrV2)
+
 	// OnPing is invoked when a peer receives a ping bitcoin message.
 	OnPing func(p *Peer, msg *wire.MsgPing)
 
@@ -276,6 +280,41 @@ type Config struct {
 	// connection detecting and disconnect logic since they intentionally
 	// do so for testing purposes.
 	AllowSelfConns bool
+
+	// V2Transport specifies whether the BIP-324 encrypted transport should
+	// be used for the connection.  Inbound peers fall back to the plaintext
+	// v1 protocol when the remote peer starts with a v1 version message.
+	// Outbound peers have no way to fall back on the same connection, so
+	// the connection fails if the remote peer doesn't support it and
+	// V2HandshakeFailed reports true.  Callers should therefore only enable
+	// it for outbound peers which advertise wire.SFNodeP2PV2 and retry over
+	// a new v1 connection when the handshake fails.
+	V2Transport bool
+
+	// MessageLimits specifies limits which are enforced on messages read
+	// from the remote peer in addition to the protocol limits.  This field
+	// can be nil in which case only the protocol limits apply.
+	MessageLimits *wire.MessageLimits
+
+	// InterceptMetrics is invoked after each invocation of a message
+	// interceptor registered with AddMessageInterceptor.  This field can be
+	// nil in which case no metrics are reported.
+	InterceptMetrics InterceptMetricsFunc
+
+	// InboundRateLimits specifies the rate limits of messages received
+	// from the remote peer by message category.  Messages exceeding the
+	// limit are not dropped, but reading from the connection is paused
+	// until the rate is back within the limit.  Categories without a limit
+	// are not restricted.  Note that limits which are low enough to delay
+	// requested data for longer than the stall timeout cause the peer to
+	// be disconnected.
+	InboundRateLimits map[MessageCategory]RateLimit
+
+	// OutboundRateLimits specifies the rate limits of messages sent to the
+	// remote peer by message category.  Sending is paused until the rate is
+	// back within the limit.  Categories without a limit are not
+	// restricted.
+	OutboundRateLimits map[MessageCategory]RateLimit
 }
 
 // minUint32 is a helper function to return the minimum of two uint32s.
@@ -387,12 +426,12 @@ type StatsSnap struct {
 type HashFunc func() (hash *chainhash.Hash, height int32, err error)
 
 // AddrFunc is a func which takes an address and returns a related address.
-type AddrFunc func(remoteAddr *wire.NetAddress) *wire.NetAddress
+type AddrFunc func(remoteAddr *wire.NetAddressV2) *wire.NetAddressV2
 
 // HostToNetAddrFunc is a func which takes a host, port, services and returns
 // the netaddress.
 type HostToNetAddrFunc func(host string, port uint16,
-	services wire.ServiceFlag) (*wire.NetAddress, error)
+	services wire.ServiceFlag) (*wire.NetAddressV2, error)
 
 // NOTE: The overall data flow of a peer is split into 3 goroutines.  Inbound
 // messages are read via the inHandler goroutine and generally dispatched to
@@ -428,9 +467,20 @@ type Peer struct {
 	lastSend      int64
 	connected     int32
 	disconnect    int32
+	v2Failed      int32
 
 	conn net.Conn
 
+	// connReader is the reader messages are read from.  It is the
+	// connection itself unless a v2 handshake fell back to v1, in which
+	// case it replays the bytes consumed by the handshake first.
+	connReader io.Reader
+
+	// v2 is the BIP-324 transport used for messages when the v2 handshake
+	// succeeded and nil otherwise.  It is set during negotiation, before
+	// any other goroutine reads or writes messages.
+	v2 *v2transport.Transport
+
 	// These fields are set at creation time and never modified, so they are
 	// safe to read from concurrently without a mutex.
 	addr    string
@@ -438,7 +488,7 @@ type Peer struct {
 	inbound bool
 
 	flagsMtx             sync.Mutex // protects the peer flags below
-	na                   *wire.NetAddress
+	na                   *wire.NetAddressV2
 	id                   int32
 	userAgent            string
 	services             wire.ServiceFlag
@@ -448,9 +498,22 @@ type Peer struct {
 	sendHeadersPreferred bool   // peer sent a sendheaders message
 	verAckReceived       bool
 	witnessEnabled       bool
+	sendAddrV2           bool
 
 	wireEncoding wire.MessageEncoding
 
+	// interceptors is the chain of registered message interceptors.  The
+	// slice is replaced rather than modified when interceptors are added
+	// or removed.
+	interceptorsMtx   sync.RWMutex
+	interceptors      []interceptorEntry
+	nextInterceptorID uint64
+
+	// inTraffic and outTraffic account the traffic by message category
+	// and enforce the configured rate limits.
+	inTraffic  *trafficMeter
+	outTraffic *trafficMeter
+
 	knownInventory     lru.Cache
 	prevGetBlocksMtx   sync.Mutex
 	prevGetBlocksBegin *chainhash.Hash
@@ -578,7 +641,7 @@ func (p *Peer) ID() int32 {
 // NA returns the peer network address.
 //
 // This function is safe for concurrent access.
-func (p *Peer) NA() *wire.NetAddress {
+func (p *Peer) NA() *wire.NetAddressV2 {
 	p.flagsMtx.Lock()
 	na := p.na
 	p.flagsMtx.Unlock()
@@ -813,6 +876,18 @@ func (p *Peer) IsWitnessEnabled() bool {
 	return witnessEnabled
 }
 
+// WantsAddrV2 returns true if the peer has signalled with a sendaddrv2 message
+// that it would like to receive addrv2 messages instead of addr messages.
+//
+// This function is safe for concurrent access.
+func (p *Peer) WantsAddrV2() bool {
+	p.flagsMtx.Lock()
+	sendAddrV2 := p.sendAddrV2
+	p.flagsMtx.Unlock()
+
+	return sendAddrV2
+}
+
 // PushAddrMsg sends an addr message to the connected peer using the provided
 // addresses.  This function is useful over manually sending the message via
 // QueueMessage since it automatically limits the addresses to the maximum
@@ -849,6 +924,40 @@ func (p *Peer) PushAddrMsg(addresses []*wire.NetAddress) ([]*wire.NetAddress, er
 	return msg.AddrList, nil
 }
 
+// PushAddrV2Msg sends an addrv2 message to the connected peer using the
+// provided addresses.  It behaves like PushAddrMsg, but is able to relay
+// addresses of all networks and should only be used for peers which signalled
+// support for addrv2 messages, see WantsAddrV2.
+//
+// This function is safe for concurrent access.
+func (p *Peer) PushAddrV2Msg(addresses []*wire.NetAddressV2) ([]*wire.NetAddressV2, error) {
+	addressCount := len(addresses)
+
+	// Nothing to send.
+	if addressCount == 0 {
+		return nil, nil
+	}
+
+	msg := wire.NewMsgAddrV2()
+	msg.AddrList = make([]*wire.NetAddressV2, addressCount)
+	copy(msg.AddrList, addresses)
+
+	// Randomize the addresses sent if there are more than the maximum allowed.
+	if addressCount > wire.MaxAddrPerMsg {
+		// Shuffle the address list.
+		for i := 0; i < wire.MaxAddrPerMsg; i++ {
+			j := i + rand.Intn(addressCount-i)
+			msg.AddrList[i], msg.AddrList[j] = msg.AddrList[j], msg.AddrList[i]
+		}
+
+		// Truncate it to the maximum size.
+		msg.AddrList = msg.AddrList[:wire.MaxAddrPerMsg]
+	}
+
+	p.QueueMessage(msg, nil)
+	return msg.AddrList, nil
+}
+
 // PushGetBlocksMsg sends a getblocks message for the provided block locator
 // and stop hash.  It will ignore back-to-back duplicate requests.
 //
@@ -1011,35 +1120,62 @@ func (p *Peer) handlePongMsg(msg *wire.MsgPong) {
 
 // readMessage reads the next bitcoin message from the peer with logging.
 func (p *Peer) readMessage(encoding wire.MessageEncoding) (wire.Message, []byte, error) {
-	n, msg, buf, err := wire.ReadMessageWithEncodingN(p.conn,
-		p.ProtocolVersion(), p.cfg.ChainParams.Net, encoding)
-	atomic.AddUint64(&p.bytesReceived, uint64(n))
-	if p.cfg.Listeners.OnRead != nil {
-		p.cfg.Listeners.OnRead(p, n, msg, err)
-	}
-	if err != nil {
-		return nil, nil, err
-	}
+	for {
+		var (
+			n   int
+			msg wire.Message
+			buf []byte
+			err error
+		)
+		if p.v2 != nil {
+			n, msg, buf, err = p.v2.ReadMessage(p.ProtocolVersion(),
+				encoding)
+		} else {
+			n, msg, buf, err = wire.ReadMessageWithLimitsN(p.connReader,
+				p.ProtocolVersion(), p.cfg.ChainParams.Net, encoding,
+				p.cfg.MessageLimits)
+		}
+		atomic.AddUint64(&p.bytesReceived, uint64(n))
+		if p.cfg.Listeners.OnRead != nil {
+			p.cfg.Listeners.OnRead(p, n, msg, err)
+		}
+		if err != nil {
+			p.inTraffic.record(msg, n)
+			return nil, nil, err
+		}
 
-	// Use closures to log expensive operations so they are only run when
-	// the logging level requires it.
-	log.Debugf("%v", newLogClosure(func() string {
-		// Debug summary of message.
-		summary := messageSummary(msg)
-		if len(summary) > 0 {
-			summary = " (" + summary + ")"
+		// Pause reading when the message exceeds the rate limit of its
+		// category.
+		p.throttle(p.inTraffic.record(msg, n))
+
+		// Run the message through the interceptors and read the next
+		// message when it was dropped.  Note that the raw bytes are
+		// left as they were received.
+		msg = p.interceptMessage(MsgInbound, msg)
+		if msg == nil {
+			continue
 		}
-		return fmt.Sprintf("Received %v%s from %s",
-			msg.Command(), summary, p)
-	}))
-	log.Tracef("%v", newLogClosure(func() string {
-		return spew.Sdump(msg)
-	}))
-	log.Tracef("%v", newLogClosure(func() string {
-		return spew.Sdump(buf)
-	}))
 
-	return msg, buf, nil
+		// Use closures to log expensive operations so they are only run
+		// when the logging level requires it.
+		log.Debugf("%v", newLogClosure(func() string {
+			// Debug summary of message.
+			summary := messageSummary(msg)
+			if len(summary) > 0 {
+				summary = " (" + summary + ")"
+			}
+			return fmt.Sprintf("Received %v%s from %s",
+				msg.Command(), summary, p)
+		}))
+		log.Tracef("%v", newLogClosure(func() string {
+			return spew.Sdump(msg)
+		}))
+		log.Tracef("%v", newLogClosure(func() string {
+			return spew.Sdump(buf)
+		}))
+
+		return msg, buf, nil
+	}
 }
 
 // writeMessage sends a bitcoin message to the peer with logging.
@@ -1049,6 +1185,13 @@ func (p *Peer) writeMessage(msg wire.Message, enc wire.MessageEncoding) error {
 		return nil
 	}
 
+	// Run the message through the interceptors.  Dropped messages are
+	// treated as if they were sent.
+	msg = p.interceptMessage(MsgOutbound, msg)
+	if msg == nil {
+		return nil
+	}
+
 	// Use closures to log expensive operations so they are only run when
 	// the logging level requires it.
 	log.Debugf("%v", newLogClosure(func() string {
@@ -1074,12 +1217,24 @@ func (p *Peer) writeMessage(msg wire.Message, enc wire.MessageEncoding) error {
 	}))
 
 	// Write the message to the peer.
-	n, err := wire.WriteMessageWithEncodingN(p.conn, msg,
-		p.ProtocolVersion(), p.cfg.ChainParams.Net, enc)
+	var (
+		n   int
+		err error
+	)
+	if p.v2 != nil {
+		n, err = p.v2.WriteMessage(msg, p.ProtocolVersion(), enc)
+	} else {
+		n, err = wire.WriteMessageWithEncodingN(p.conn, msg,
+			p.ProtocolVersion(), p.cfg.ChainParams.Net, enc)
+	}
 	atomic.AddUint64(&p.bytesSent, uint64(n))
 	if p.cfg.Listeners.OnWrite != nil {
 		p.cfg.Listeners.OnWrite(p, n, msg, err)
 	}
+
+	// Pause sending when the message exceeds the rate limit of its
+	// category.
+	p.throttle(p.outTraffic.record(msg, n))
 	return err
 }
 
@@ -1399,6 +1554,17 @@ out:
 				p.cfg.Listeners.OnAddr(p, msg)
 			}
 
+		case *wire.MsgAddrV2:
+			if p.cfg.Listeners.OnAddrV2 != nil {
+				p.cfg.Listeners.OnAddrV2(p, msg)
+			}
+
+		case *wire.MsgSendAddrV2:
+			// Support for addrv2 messages must be signalled before
+			// the verack message, so late signals are ignored.
+			log.Debugf("Ignoring sendaddrv2 message from %v sent "+
+				"after verack", p)
+
 		case *wire.MsgPing:
 			p.handlePingMsg(msg)
 			if p.cfg.Listeners.OnPing != nil {
@@ -1973,36 +2139,61 @@ func (p *Peer) readRemoteVersionMsg() error {
 
 // readRemoteVerAckMsg waits for the next message to arrive from the remote
 // peer. If this message is not a verack message, then an error is returned.
-// This method is to be used as part of the version negotiation upon a new
-// connection.
+// The only exception is a sendaddrv2 message which signals support for addrv2
+// messages (BIP0155) and must be sent before the verack message.  This method
+// is to be used as part of the version negotiation upon a new connection.
 func (p *Peer) readRemoteVerAckMsg() error {
-	// Read the next message from the wire.
-	remoteMsg, _, err := p.readMessage(wire.LatestEncoding)
-	if err != nil {
-		return err
-	}
+	for {
+		// Read the next message from the wire.
+		remoteMsg, _, err := p.readMessage(wire.LatestEncoding)
+		if err != nil {
+			return err
+		}
 
-	// It should be a verack message, otherwise send a reject message to the
-	// peer explaining why.
-	msg, ok := remoteMsg.(*wire.MsgVerAck)
-	if !ok {
-		reason := "a verack message must follow version"
-		rejectMsg := wire.NewMsgReject(
-			msg.Command(), wire.RejectMalformed, reason,
-		)
-		_ = p.writeMessage(rejectMsg, wire.LatestEncoding)
-		return errors.New(reason)
+		switch msg := remoteMsg.(type) {
+		case *wire.MsgSendAddrV2:
+			p.flagsMtx.Lock()
+			p.sendAddrV2 = true
+			p.flagsMtx.Unlock()
+
+		case *wire.MsgVerAck:
+			p.flagsMtx.Lock()
+			p.verAckReceived = true
+			p.flagsMtx.Unlock()
+
+			if p.cfg.Listeners.OnVerAck != nil {
+				p.cfg.Listeners.OnVerAck(p, msg)
+			}
+
+			return nil
+
+		default:
+			// It should be a verack message, otherwise send a
+			// reject message to the peer explaining why.
+			reason := "a verack message must follow version"
+			rejectMsg := wire.NewMsgReject(
+				remoteMsg.Command(), wire.RejectMalformed, reason,
+			)
+			_ = p.writeMessage(rejectMsg, wire.LatestEncoding)
+			return errors.New(reason)
+		}
 	}
+}
 
+// writeSendAddrV2Msg signals support for addrv2 messages (BIP0155) to the
+// remote peer when its advertised protocol version indicates it knows about
+// them.  It must be called after the remote version message has been read and
+// before our verack message is sent.
+func (p *Peer) writeSendAddrV2Msg() error {
 	p.flagsMtx.Lock()
-	p.verAckReceived = true
+	advertisedProtoVer := p.advertisedProtoVer
 	p.flagsMtx.Unlock()
 
-	if p.cfg.Listeners.OnVerAck != nil {
-		p.cfg.Listeners.OnVerAck(p, msg)
+	if advertisedProtoVer < wire.AddrV2Version {
+		return nil
 	}
 
-	return nil
+	return p.writeMessage(wire.NewMsgSendAddrV2(), wire.LatestEncoding)
 }
 
 // localVersionMsg creates a version message that can be used to send to the
@@ -2025,12 +2216,22 @@ func (p *Peer) localVersionMsg() (*wire.MsgVersion, error) {
 	if p.cfg.Proxy != "" {
 		proxyaddress, _, err := net.SplitHostPort(p.cfg.Proxy)
 		// invalid proxy means poorly configured, be on the safe side.
-		if err != nil || p.na.IP.String() == proxyaddress {
-			theirNA = wire.NewNetAddressIPPort(net.IP([]byte{0, 0, 0, 0}), 0,
-				theirNA.Services)
+		if err != nil || p.na.Host() == proxyaddress {
+			theirNA = wire.NetAddressV2FromLegacy(
+				wire.NewNetAddressIPPort(net.IP([]byte{0, 0, 0, 0}),
+					0, theirNA.Services))
 		}
 	}
 
+	// The version message is only able to carry addresses which can be
+	// represented by legacy addresses, so send an unroutable address for
+	// peers of other networks.
+	theirLegacyNA, ok := theirNA.ToLegacy()
+	if !ok {
+		theirLegacyNA = wire.NewNetAddressIPPort(
+			net.IP([]byte{0, 0, 0, 0}), 0, theirNA.Services)
+	}
+
 	// Create a wire.NetAddress with only the services set to use as the
 	// "addrme" in the version message.
 	//
@@ -2051,7 +2252,7 @@ func (p *Peer) localVersionMsg() (*wire.MsgVersion, error) {
 	sentNonces.Add(nonce)
 
 	// Version message.
-	msg := wire.NewMsgVersion(ourNA, theirNA, nonce, blockNum)
+	msg := wire.NewMsgVersion(ourNA, theirLegacyNA, nonce, blockNum)
 	msg.AddUserAgent(p.cfg.UserAgentName, p.cfg.UserAgentVersion,
 		p.cfg.UserAgentComments...)
 
@@ -2083,8 +2284,9 @@ func (p *Peer) writeLocalVersionMsg() error {
 //
 //   1. Remote peer sends their version.
 //   2. We send our version.
-//   3. We send our verack.
-//   4. Remote peer sends their verack.
+//   3. We send our sendaddrv2 if the remote peer supports it.
+//   4. We send our verack.
+//   5. Remote peer sends their verack, optionally preceded by a sendaddrv2.
 func (p *Peer) negotiateInboundProtocol() error {
 	if err := p.readRemoteVersionMsg(); err != nil {
 		return err
@@ -2094,6 +2296,10 @@ func (p *Peer) negotiateInboundProtocol() error {
 		return err
 	}
 
+	if err := p.writeSendAddrV2Msg(); err != nil {
+		return err
+	}
+
 	err := p.writeMessage(wire.NewMsgVerAck(), wire.LatestEncoding)
 	if err != nil {
 		return err
@@ -2108,8 +2314,9 @@ func (p *Peer) negotiateInboundProtocol() error {
 //
 //   1. We send our version.
 //   2. Remote peer sends their version.
-//   3. Remote peer sends their verack.
-//   4. We send our verack.
+//   3. Remote peer sends their verack, optionally preceded by a sendaddrv2.
+//   4. We send our sendaddrv2 if the remote peer supports it.
+//   5. We send our verack.
 func (p *Peer) negotiateOutboundProtocol() error {
 	if err := p.writeLocalVersionMsg(); err != nil {
 		return err
@@ -2123,15 +2330,68 @@ func (p *Peer) negotiateOutboundProtocol() error {
 		return err
 	}
 
+	if err := p.writeSendAddrV2Msg(); err != nil {
+		return err
+	}
+
 	return p.writeMessage(wire.NewMsgVerAck(), wire.LatestEncoding)
 }
 
+// negotiateV2Transport performs the BIP-324 handshake with the remote peer.
+// Inbound peers which turn out to use the v1 protocol are not an error and
+// continue with the v1 protocol.
+func (p *Peer) negotiateV2Transport() error {
+	t := v2transport.NewTransport(p.conn, p.cfg.ChainParams.Net, !p.inbound)
+	t.SetMessageLimits(p.cfg.MessageLimits)
+	err := t.Handshake()
+	switch {
+	case err == v2transport.ErrV1Fallback:
+		log.Debugf("Peer %s does not support v2 transport, using v1", p)
+		p.connReader = io.MultiReader(bytes.NewReader(t.Buffered()),
+			p.conn)
+		return nil
+
+	case err != nil:
+		atomic.StoreInt32(&p.v2Failed, 1)
+		return fmt.Errorf("v2 handshake failed: %v", err)
+	}
+
+	log.Debugf("Established v2 transport with %s (session id %x)", p,
+		t.SessionID())
+	p.v2 = t
+	return nil
+}
+
+// V2Transport returns whether the connection to the peer uses the BIP-324
+// encrypted transport.
+//
+// This function is safe for concurrent access once the peer has completed
+// protocol negotiation.
+func (p *Peer) V2Transport() bool {
+	return p.v2 != nil
+}
+
+// V2HandshakeFailed returns whether the BIP-324 handshake with the peer failed.
+// Outbound peers can't fall back to the v1 protocol on the same connection, so
+// this allows the caller to retry the connection with the v1 protocol.
+//
+// This function is safe for concurrent access.
+func (p *Peer) V2HandshakeFailed() bool {
+	return atomic.LoadInt32(&p.v2Failed) != 0
+}
+
 // start begins processing input and output messages.
 func (p *Peer) start() error {
 	log.Tracef("Starting peer %s", p)
 
 	negotiateErr := make(chan error, 1)
 	go func() {
+		if p.cfg.V2Transport {
+			if err := p.negotiateV2Transport(); err != nil {
+				negotiateErr <- err
+				return
+			}
+		}
 		if p.inbound {
 			negotiateErr <- p.negotiateInboundProtocol()
 		} else {
@@ -2172,6 +2432,7 @@ func (p *Peer) AssociateConnection(conn net.Conn) {
 	}
 
 	p.conn = conn
+	p.connReader = conn
 	p.timeConnected = time.Now()
 
 	if p.inbound {
@@ -2186,7 +2447,7 @@ func (p *Peer) AssociateConnection(conn net.Conn) {
 			p.Disconnect()
 			return
 		}
-		p.na = na
+		p.na = wire.NetAddressV2FromLegacy(na)
 	}
 
 	go func() {
@@ -2229,6 +2490,8 @@ func newPeerBase(origCfg *Config, inbound bool) *Peer {
 	p := Peer{
 		inbound:         inbound,
 		wireEncoding:    wire.BaseEncoding,
+		inTraffic:       newTrafficMeter(cfg.InboundRateLimits),
+		outTraffic:      newTrafficMeter(cfg.OutboundRateLimits),
 		knownInventory:  lru.NewCache(maxKnownInventory),
 		stallControl:    make(chan stallControlMsg, 1), // nonblocking sync
 		outputQueue:     make(chan outMsg, outputBufferSize),
@@ -2274,7 +2537,8 @@ func NewOutboundPeer(cfg *Config, addr string) (*Peer, error) {
 		}
 		p.na = na
 	} else {
-		p.na = wire.NewNetAddressIPPort(net.ParseIP(host), uint16(port), 0)
+		na := wire.NewNetAddressIPPort(net.ParseIP(host), uint16(port), 0)
+		p.na = wire.NetAddressV2FromLegacy(na)
 	}
 
 	return p, nil
@@ -10,6 +10,7 @@ import (
 	"io"
 	"net"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -923,6 +924,430 @@ func TestDuplicateVersionMsg(t *testing.T) {
 	}
 }
 
+// TestV2TransportPeer ensures peers configured to use the BIP-324 transport
+// negotiate it with each other and that an inbound v2 peer falls back to the
+// v1 protocol for a v1 outbound peer.
+func TestV2TransportPeer(t *testing.T) {
+	tests := []struct {
+		name      string
+		outV2     bool
+		wantV2    bool
+		inboundV2 bool
+	}{
+		{name: "both v2", outV2: true, inboundV2: true, wantV2: true},
+		{name: "v1 outbound", outV2: false, inboundV2: true, wantV2: false},
+	}
+
+	for _, test := range tests {
+		verack := make(chan struct{}, 2)
+		peerCfg := peer.Config{
+			Listeners: peer.MessageListeners{
+				OnVerAck: func(p *peer.Peer, msg *wire.MsgVerAck) {
+					verack <- struct{}{}
+				},
+			},
+			UserAgentName:    "peer",
+			UserAgentVersion: "1.0",
+			ChainParams:      &chaincfg.MainNetParams,
+			AllowSelfConns:   true,
+		}
+		inConn, outConn := pipe(
+			&conn{laddr: "10.0.0.1:8333", raddr: "10.0.0.2:8333"},
+			&conn{laddr: "10.0.0.2:8333", raddr: "10.0.0.1:8333"},
+		)
+
+		outCfg := peerCfg
+		outCfg.V2Transport = test.outV2
+		outPeer, err := peer.NewOutboundPeer(&outCfg, inConn.laddr)
+		if err != nil {
+			t.Fatalf("%s: NewOutboundPeer: unexpected err: %v",
+				test.name, err)
+		}
+		outPeer.AssociateConnection(outConn)
+
+		inCfg := peerCfg
+		inCfg.V2Transport = test.inboundV2
+		inPeer := peer.NewInboundPeer(&inCfg)
+		inPeer.AssociateConnection(inConn)
+
+		for i := 0; i < 2; i++ {
+			select {
+			case <-verack:
+			case <-time.After(time.Second * 5):
+				t.Fatalf("%s: verack timeout", test.name)
+			}
+		}
+
+		if outPeer.V2Transport() != test.wantV2 ||
+			inPeer.V2Transport() != test.wantV2 {
+
+			t.Fatalf("%s: unexpected v2 transport use -- got "+
+				"outbound %v, inbound %v, want %v", test.name,
+				outPeer.V2Transport(), inPeer.V2Transport(),
+				test.wantV2)
+		}
+		if outPeer.V2HandshakeFailed() || inPeer.V2HandshakeFailed() {
+			t.Fatalf("%s: v2 handshake reported as failed",
+				test.name)
+		}
+
+		// Ensure messages continue to flow after negotiation.
+		done := make(chan struct{})
+		outPeer.QueueMessage(wire.NewMsgPing(1), done)
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("%s: send ping timeout", test.name)
+		}
+
+		outPeer.Disconnect()
+		inPeer.Disconnect()
+		outPeer.WaitForDisconnect()
+		inPeer.WaitForDisconnect()
+	}
+}
+
+// TestV2TransportHandshakeFailure ensures an outbound peer configured to use
+// the BIP-324 transport disconnects and reports the failed handshake when the
+// remote peer closes the connection after receiving the v2 public key, like v1
+// peers do.
+func TestV2TransportHandshakeFailure(t *testing.T) {
+	r1, w1 := io.Pipe()
+	r2, w2 := io.Pipe()
+	outConn := &conn{
+		Reader: r1,
+		Writer: w2,
+		Closer: w2,
+		laddr:  "10.0.0.2:8333",
+		raddr:  "10.0.0.1:8333",
+	}
+	go func() {
+		// Read the start of the public key, which is an invalid v1
+		// message header, and close the connection.
+		var header [24]byte
+		io.ReadFull(r2, header[:])
+		r2.Close()
+		w1.Close()
+	}()
+
+	peerCfg := &peer.Config{
+		UserAgentName:    "peer",
+		UserAgentVersion: "1.0",
+		ChainParams:      &chaincfg.MainNetParams,
+		AllowSelfConns:   true,
+		V2Transport:      true,
+	}
+	outPeer, err := peer.NewOutboundPeer(peerCfg, outConn.raddr)
+	if err != nil {
+		t.Fatalf("NewOutboundPeer: unexpected err: %v", err)
+	}
+	outPeer.AssociateConnection(outConn)
+
+	disconnected := make(chan struct{})
+	go func() {
+		outPeer.WaitForDisconnect()
+		close(disconnected)
+	}()
+	select {
+	case <-disconnected:
+	case <-time.After(time.Second * 5):
+		t.Fatal("peer did not disconnect")
+	}
+	if !outPeer.V2HandshakeFailed() {
+		t.Fatal("failed v2 handshake was not reported")
+	}
+	if outPeer.V2Transport() {
+		t.Fatal("peer reports v2 transport after failed handshake")
+	}
+}
+
+// TestPeerSendAddrV2 ensures peers signal support for addrv2 messages during
+// the version negotiation when the remote peer advertises a protocol version
+// which knows about them and that addrv2 messages are delivered to the
+// listener.
+func TestPeerSendAddrV2(t *testing.T) {
+	tests := []struct {
+		name       string
+		pver       uint32
+		wantAddrV2 bool
+	}{
+		{name: "addrv2 version", pver: wire.AddrV2Version, wantAddrV2: true},
+		{name: "old version", pver: wire.FeeFilterVersion, wantAddrV2: false},
+	}
+
+	for _, test := range tests {
+		verack := make(chan struct{}, 2)
+		addrV2 := make(chan *wire.MsgAddrV2, 1)
+		peerCfg := peer.Config{
+			Listeners: peer.MessageListeners{
+				OnVerAck: func(p *peer.Peer, msg *wire.MsgVerAck) {
+					verack <- struct{}{}
+				},
+				OnAddrV2: func(p *peer.Peer, msg *wire.MsgAddrV2) {
+					addrV2 <- msg
+				},
+			},
+			UserAgentName:    "peer",
+			UserAgentVersion: "1.0",
+			ChainParams:      &chaincfg.MainNetParams,
+			ProtocolVersion:  test.pver,
+			AllowSelfConns:   true,
+		}
+		inConn, outConn := pipe(
+			&conn{laddr: "10.0.0.1:8333", raddr: "10.0.0.2:8333"},
+			&conn{laddr: "10.0.0.2:8333", raddr: "10.0.0.1:8333"},
+		)
+
+		outPeer, err := peer.NewOutboundPeer(&peerCfg, inConn.laddr)
+		if err != nil {
+			t.Fatalf("%s: NewOutboundPeer: unexpected err: %v",
+				test.name, err)
+		}
+		outPeer.AssociateConnection(outConn)
+
+		inPeer := peer.NewInboundPeer(&peerCfg)
+		inPeer.AssociateConnection(inConn)
+
+		for i := 0; i < 2; i++ {
+			select {
+			case <-verack:
+			case <-time.After(time.Second * 5):
+				t.Fatalf("%s: verack timeout", test.name)
+			}
+		}
+
+		if outPeer.WantsAddrV2() != test.wantAddrV2 ||
+			inPeer.WantsAddrV2() != test.wantAddrV2 {
+
+			t.Fatalf("%s: unexpected addrv2 support -- got "+
+				"outbound %v, inbound %v, want %v", test.name,
+				outPeer.WantsAddrV2(), inPeer.WantsAddrV2(),
+				test.wantAddrV2)
+		}
+
+		// Ensure addrv2 messages reach the listener.
+		na := wire.NewNetAddressV2(time.Now(), wire.SFNodeNetwork,
+			wire.NetIDTorV3, make([]byte, 32), 8333)
+		_, err = outPeer.PushAddrV2Msg([]*wire.NetAddressV2{na})
+		if err != nil {
+			t.Fatalf("%s: PushAddrV2Msg: unexpected err: %v",
+				test.name, err)
+		}
+		select {
+		case msg := <-addrV2:
+			if len(msg.AddrList) != 1 ||
+				msg.AddrList[0].NetworkID != wire.NetIDTorV3 {
+
+				t.Fatalf("%s: unexpected addrv2 message %+v",
+					test.name, msg)
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatalf("%s: addrv2 timeout", test.name)
+		}
+
+		outPeer.Disconnect()
+		inPeer.Disconnect()
+		outPeer.WaitForDisconnect()
+		inPeer.WaitForDisconnect()
+	}
+}
+
+// TestMessageInterceptors ensures registered interceptors are able to drop and
+// replace messages in both directions and that the metrics hook reports what
+// they did.
+func TestMessageInterceptors(t *testing.T) {
+	feeFilter := make(chan *wire.MsgFeeFilter, 1)
+	memPool := make(chan struct{}, 1)
+	verack := make(chan struct{}, 2)
+	var actionsMtx sync.Mutex
+	actions := make(map[peer.InterceptAction]int)
+	peerCfg := peer.Config{
+		Listeners: peer.MessageListeners{
+			OnVerAck: func(p *peer.Peer, msg *wire.MsgVerAck) {
+				verack <- struct{}{}
+			},
+			OnFeeFilter: func(p *peer.Peer, msg *wire.MsgFeeFilter) {
+				feeFilter <- msg
+			},
+			OnMemPool: func(p *peer.Peer, msg *wire.MsgMemPool) {
+				memPool <- struct{}{}
+			},
+		},
+		UserAgentName:    "peer",
+		UserAgentVersion: "1.0",
+		ChainParams:      &chaincfg.MainNetParams,
+		AllowSelfConns:   true,
+		InterceptMetrics: func(p *peer.Peer, dir peer.MessageDirection,
+			command string, action peer.InterceptAction,
+			elapsed time.Duration) {
+
+			actionsMtx.Lock()
+			actions[action]++
+			actionsMtx.Unlock()
+		},
+	}
+	inConn, outConn := pipe(
+		&conn{laddr: "10.0.0.1:8333", raddr: "10.0.0.2:8333"},
+		&conn{laddr: "10.0.0.2:8333", raddr: "10.0.0.1:8333"},
+	)
+
+	outPeer, err := peer.NewOutboundPeer(&peerCfg, inConn.laddr)
+	if err != nil {
+		t.Fatalf("NewOutboundPeer: unexpected err: %v", err)
+	}
+
+	// Replace outbound fee filters with a different fee rate.
+	outPeer.AddMessageInterceptor(func(p *peer.Peer,
+		dir peer.MessageDirection, msg wire.Message) wire.Message {
+
+		if dir != peer.MsgOutbound {
+			return msg
+		}
+		return wire.NewMsgFeeFilter(2000)
+	}, wire.CmdFeeFilter)
+	outPeer.AssociateConnection(outConn)
+
+	// Drop inbound mempool messages.
+	inPeer := peer.NewInboundPeer(&peerCfg)
+	inPeer.AddMessageInterceptor(func(p *peer.Peer,
+		dir peer.MessageDirection, msg wire.Message) wire.Message {
+
+		if dir == peer.MsgInbound {
+			return nil
+		}
+		return msg
+	}, wire.CmdMemPool)
+	inPeer.AssociateConnection(inConn)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-verack:
+		case <-time.After(time.Second * 5):
+			t.Fatal("verack timeout")
+		}
+	}
+
+	// The fee filter is sent after the mempool message, so the mempool
+	// message must have been dropped once the fee filter arrives.
+	outPeer.QueueMessage(wire.NewMsgMemPool(), nil)
+	outPeer.QueueMessage(wire.NewMsgFeeFilter(1000), nil)
+	select {
+	case msg := <-feeFilter:
+		if msg.MinFee != 2000 {
+			t.Fatalf("unexpected fee rate -- got %d, want %d",
+				msg.MinFee, 2000)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("feefilter timeout")
+	}
+	select {
+	case <-memPool:
+		t.Fatal("received dropped mempool message")
+	default:
+	}
+
+	actionsMtx.Lock()
+	if actions[peer.InterceptReplace] != 1 ||
+		actions[peer.InterceptDrop] != 1 {
+
+		t.Fatalf("unexpected interceptor actions: %v", actions)
+	}
+	actionsMtx.Unlock()
+
+	outPeer.Disconnect()
+	inPeer.Disconnect()
+	outPeer.WaitForDisconnect()
+	inPeer.WaitForDisconnect()
+}
+
+// TestPeerRateLimits ensures traffic is accounted by message category and that
+// messages exceeding the inbound rate limit of their category are delayed.
+func TestPeerRateLimits(t *testing.T) {
+	const numPings = 10
+
+	verack := make(chan struct{}, 2)
+	pings := make(chan struct{}, numPings)
+	peerCfg := peer.Config{
+		Listeners: peer.MessageListeners{
+			OnVerAck: func(p *peer.Peer, msg *wire.MsgVerAck) {
+				verack <- struct{}{}
+			},
+			OnPing: func(p *peer.Peer, msg *wire.MsgPing) {
+				pings <- struct{}{}
+			},
+		},
+		UserAgentName:    "peer",
+		UserAgentVersion: "1.0",
+		ChainParams:      &chaincfg.MainNetParams,
+		AllowSelfConns:   true,
+	}
+	inConn, outConn := pipe(
+		&conn{laddr: "10.0.0.1:8333", raddr: "10.0.0.2:8333"},
+		&conn{laddr: "10.0.0.2:8333", raddr: "10.0.0.1:8333"},
+	)
+
+	outPeer, err := peer.NewOutboundPeer(&peerCfg, inConn.laddr)
+	if err != nil {
+		t.Fatalf("NewOutboundPeer: unexpected err: %v", err)
+	}
+	outPeer.AssociateConnection(outConn)
+
+	// Limit the other category of the inbound peer to a rate which allows
+	// the version negotiation, but not all of the pings, to pass
+	// without delay.
+	inCfg := peerCfg
+	inCfg.InboundRateLimits = map[peer.MessageCategory]peer.RateLimit{
+		peer.CategoryOther: {BytesPerSecond: 2000, Burst: 300},
+	}
+	inPeer := peer.NewInboundPeer(&inCfg)
+	inPeer.AssociateConnection(inConn)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-verack:
+		case <-time.After(time.Second * 5):
+			t.Fatal("verack timeout")
+		}
+	}
+
+	for i := 0; i < numPings; i++ {
+		outPeer.QueueMessage(wire.NewMsgPing(uint64(i)), nil)
+	}
+	for i := 0; i < numPings; i++ {
+		select {
+		case <-pings:
+		case <-time.After(time.Second * 5):
+			t.Fatal("ping timeout")
+		}
+	}
+
+	stats := inPeer.TrafficStats()
+	other := stats.Inbound[peer.CategoryOther]
+	if other.Messages < numPings+2 {
+		t.Fatalf("unexpected number of inbound messages -- got %d, "+
+			"want at least %d", other.Messages, numPings+2)
+	}
+	if other.Throttled == 0 {
+		t.Fatal("inbound messages were not throttled")
+	}
+	if block := stats.Inbound[peer.CategoryBlock]; block.Messages != 0 ||
+		block.Bytes != 0 {
+
+		t.Fatalf("unexpected inbound block traffic: %+v", block)
+	}
+	if out := outPeer.TrafficStats().Outbound[peer.CategoryOther]; out.Bytes !=
+		other.Bytes || out.Throttled != 0 {
+
+		t.Fatalf("unexpected outbound traffic -- got %+v, want %d "+
+			"bytes without throttling", out, other.Bytes)
+	}
+
+	outPeer.Disconnect()
+	inPeer.Disconnect()
+	outPeer.WaitForDisconnect()
+	inPeer.WaitForDisconnect()
+}
+
 // TestUpdateLastBlockHeight ensures the last block height is set properly
 // during the initial version negotiation and is only allowed to advance to
 // higher values via the associated update function.
@@ -0,0 +1,364 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// rpcCookieUser is the username of the credentials written to the
+	// authentication cookie file.
+	rpcCookieUser = "__cookie__"
+
+	// rpcCookiePassLen is the number of random bytes of the password of
+	// the authentication cookie.
+	rpcCookiePassLen = 32
+)
+
+// The permission tiers which may be granted to RPC users in place of methods.
+const (
+	// rpcTierReadOnly grants access to the limited set of methods except
+	// for those relaying transactions and blocks.
+	rpcTierReadOnly = "readonly"
+
+	// rpcTierWallet grants access to the limited set of methods, which are
+	// the methods used by wallets.
+	rpcTierWallet = "wallet"
+
+	// rpcTierAdmin grants access to all methods.
+	rpcTierAdmin = "admin"
+)
+
+// rpcRelayMethods are the methods of the limited set of methods which relay
+// transactions or blocks and are thus not available to read-only users.
+var rpcRelayMethods = map[string]struct{}{
+	"sendrawtransaction": {},
+	"submitblock":        {},
+	"submitpackage":      {},
+}
+
+// rpcTierMethods adds the methods of the passed permission tier to methods.  It
+// returns false if the tier grants access to all methods.
+func rpcTierMethods(tier string, methods map[string]struct{}) (bool, error) {
+	switch tier {
+	case rpcTierAdmin:
+		return false, nil
+
+	case rpcTierWallet, rpcTierReadOnly:
+		for method := range rpcLimited {
+			_, relay := rpcRelayMethods[method]
+			if relay && tier == rpcTierReadOnly {
+				continue
+			}
+			methods[method] = struct{}{}
+		}
+		return true, nil
+	}
+
+	return false, fmt.Errorf("unknown RPC permission tier %q", tier)
+}
+
+// rpcUser is an authenticated RPC user along with the methods it may call.
+type rpcUser struct {
+	name string
+
+	// methods are the methods the user may call.  A nil map grants access
+	// to all methods.
+	methods map[string]struct{}
+}
+
+// isAllowed returns whether the user may call the passed method.
+func (u *rpcUser) isAllowed(method string) bool {
+	if u.methods == nil {
+		return true
+	}
+	_, ok := u.methods[method]
+	return ok
+}
+
+// parseRPCWhitelist parses an --rpcwhitelist option of the form
+// <user>:<method>,<method>,... where permission tiers prefixed with @ may be
+// given in place of methods.  It returns the user along with the methods it may
+// call, which is nil if the user may call all methods.
+func parseRPCWhitelist(option string) (string, map[string]struct{}, error) {
+	parts := strings.SplitN(option, ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", nil, fmt.Errorf("malformed RPC whitelist %q -- "+
+			"expected <user>:<method>,...", option)
+	}
+
+	methods := make(map[string]struct{})
+	for _, entry := range strings.Split(parts[1], ",") {
+		entry = strings.TrimSpace(entry)
+		switch {
+		case entry == "":
+			continue
+
+		case strings.HasPrefix(entry, "@"):
+			restricted, err := rpcTierMethods(entry[1:], methods)
+			if err != nil {
+				return "", nil, err
+			}
+			if !restricted {
+				return parts[0], nil, nil
+			}
+
+		default:
+			methods[entry] = struct{}{}
+		}
+	}
+
+	return parts[0], methods, nil
+}
+
+// rpcHashedPass is the salted hash of the password of a user given by the
+// --rpcauth option.
+type rpcHashedPass struct {
+	salt string
+	hash []byte
+}
+
+// parseRPCAuth parses an --rpcauth option of the form <user>:<salt>$<hash>,
+// where hash is the hex-encoded HMAC-SHA256 of the password keyed by the salt.
+// The format matches the rpcauth option of Bitcoin Core.
+func parseRPCAuth(option string) (string, *rpcHashedPass, error) {
+	parts := strings.SplitN(option, ":", 2)
+	if len(parts) == 2 && parts[0] != "" {
+		saltHash := strings.SplitN(parts[1], "$", 2)
+		if len(saltHash) == 2 && saltHash[0] != "" {
+			hash, err := hex.DecodeString(saltHash[1])
+			if err == nil && len(hash) == sha256.Size {
+				return parts[0], &rpcHashedPass{
+					salt: saltHash[0],
+					hash: hash,
+				}, nil
+			}
+		}
+	}
+
+	return "", nil, fmt.Errorf("malformed RPC auth %q -- expected "+
+		"<user>:<salt>$<hash>", option)
+}
+
+// matches returns whether the passed password matches the hashed password.
+//
+// This check is time-constant.
+func (p *rpcHashedPass) matches(password string) bool {
+	mac := hmac.New(sha256.New, []byte(p.salt))
+	mac.Write([]byte(password))
+	return hmac.Equal(mac.Sum(nil), p.hash)
+}
+
+// rpcPlainCredential are the credentials of a user given in plain text by the
+// configuration or the authentication cookie.
+type rpcPlainCredential struct {
+	user    string
+	authsha [sha256.Size]byte
+}
+
+// rpcAuthenticator authenticates the clients of the RPC server and determines
+// the methods they may call.
+type rpcAuthenticator struct {
+	plain  []rpcPlainCredential
+	hashed map[string][]*rpcHashedPass
+
+	// whitelists are the methods the whitelisted users may call keyed by
+	// user.  A nil map grants access to all methods.
+	whitelists map[string]map[string]struct{}
+
+	// limitUser is the user which may only call the limited set of methods
+	// unless whitelisted.
+	limitUser string
+
+	// clientCerts specifies whether clients presenting a verified
+	// certificate are authenticated.
+	clientCerts bool
+
+	// cookieFile is the path of the authentication cookie file, if any.
+	cookieFile string
+}
+
+// newRPCAuthenticator returns an authenticator for the users of the passed
+// configuration.  The authentication cookie file is written when cookie
+// authentication is used.
+func newRPCAuthenticator(c *config) (*rpcAuthenticator, error) {
+	a := &rpcAuthenticator{
+		hashed:      make(map[string][]*rpcHashedPass),
+		whitelists:  make(map[string]map[string]struct{}),
+		clientCerts: c.RPCClientCA != "",
+	}
+
+	if c.RPCUser != "" && c.RPCPass != "" {
+		a.addPlain(c.RPCUser, c.RPCPass)
+	}
+	if c.RPCLimitUser != "" && c.RPCLimitPass != "" {
+		a.addPlain(c.RPCLimitUser, c.RPCLimitPass)
+		a.limitUser = c.RPCLimitUser
+	}
+
+	for _, option := range c.RPCAuth {
+		user, pass, err := parseRPCAuth(option)
+		if err != nil {
+			return nil, err
+		}
+		a.hashed[user] = append(a.hashed[user], pass)
+	}
+
+	// The methods of all whitelists of a user are combined.
+	for _, option := range c.RPCWhitelist {
+		user, methods, err := parseRPCWhitelist(option)
+		if err != nil {
+			return nil, err
+		}
+		existing, ok := a.whitelists[user]
+		switch {
+		case !ok:
+			a.whitelists[user] = methods
+		case existing == nil || methods == nil:
+			a.whitelists[user] = nil
+		default:
+			for method := range methods {
+				existing[method] = struct{}{}
+			}
+		}
+	}
+
+	if (c.RPCUser == "" || c.RPCPass == "") && c.RPCCookie {
+		pass, err := writeRPCCookie(c.RPCCookieFile)
+		if err != nil {
+			return nil, err
+		}
+		a.addPlain(rpcCookieUser, pass)
+		a.cookieFile = c.RPCCookieFile
+		rpcsLog.Infof("Wrote RPC authentication cookie to %s",
+			c.RPCCookieFile)
+	}
+
+	return a, nil
+}
+
+// addPlain adds the passed plain text credentials.
+func (a *rpcAuthenticator) addPlain(user, pass string) {
+	login := user + ":" + pass
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
+	a.plain = append(a.plain, rpcPlainCredential{
+		user:    user,
+		authsha: sha256.Sum256([]byte(auth)),
+	})
+}
+
+// user returns the passed authenticated user along with its permissions.
+func (a *rpcAuthenticator) user(name string) *rpcUser {
+	if methods, ok := a.whitelists[name]; ok {
+		return &rpcUser{name: name, methods: methods}
+	}
+	if name == a.limitUser {
+		return &rpcUser{name: name, methods: rpcLimited}
+	}
+	return &rpcUser{name: name}
+}
+
+// authenticate returns the user with the passed credentials, or nil if they
+// don't match any user.
+//
+// This check is time-constant.
+func (a *rpcAuthenticator) authenticate(username, password string) *rpcUser {
+	login := username + ":" + password
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
+	authsha := sha256.Sum256([]byte(auth))
+
+	// All plain text credentials are compared to not leak which of them
+	// matched.
+	var match *rpcPlainCredential
+	for i := range a.plain {
+		cmp := subtle.ConstantTimeCompare(authsha[:], a.plain[i].authsha[:])
+		if cmp == 1 {
+			match = &a.plain[i]
+		}
+	}
+	if match != nil {
+		return a.user(match.user)
+	}
+
+	for _, pass := range a.hashed[username] {
+		if pass.matches(password) {
+			return a.user(username)
+		}
+	}
+
+	return nil
+}
+
+// certUser returns the user named by the common name of the verified client
+// certificate of the passed TLS connection, or nil if there is none.  Only
+// users with a whitelist get its permissions, since the certificate authority
+// may sign certificates for any name.  All other users may only call the
+// limited set of methods.
+func (a *rpcAuthenticator) certUser(state *tls.ConnectionState) *rpcUser {
+	if !a.clientCerts || state == nil || len(state.VerifiedChains) == 0 {
+		return nil
+	}
+	name := state.VerifiedChains[0][0].Subject.CommonName
+	if name == "" {
+		return nil
+	}
+	if methods, ok := a.whitelists[name]; ok {
+		return &rpcUser{name: name, methods: methods}
+	}
+	return &rpcUser{name: name, methods: rpcLimited}
+}
+
+// removeCookie removes the authentication cookie file if it was written.
+func (a *rpcAuthenticator) removeCookie() {
+	if a.cookieFile == "" {
+		return
+	}
+	if err := os.Remove(a.cookieFile); err != nil && !os.IsNotExist(err) {
+		rpcsLog.Warnf("Unable to remove RPC authentication cookie: %v",
+			err)
+	}
+}
+
+// writeRPCCookie writes credentials of the cookie user with a random password
+// to the passed file, which is only accessible by the current user, and
+// returns the password.
+func writeRPCCookie(path string) (string, error) {
+	var randomBytes [rpcCookiePassLen]byte
+	if _, err := rand.Read(randomBytes[:]); err != nil {
+		return "", err
+	}
+	pass := hex.EncodeToString(randomBytes[:])
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+
+	// The cookie is written to a temporary file first, so clients never
+	// read a partially written cookie.
+	tmpPath := path + ".tmp"
+	os.Remove(tmpPath)
+	err := ioutil.WriteFile(tmpPath, []byte(rpcCookieUser+":"+pass), 0600)
+	if err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	return pass, nil
+}
@@ -0,0 +1,346 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btclog"
+)
+
+// TestParseRPCWhitelist ensures whitelists are parsed into the methods the
+// users may call, including permission tiers.
+func TestParseRPCWhitelist(t *testing.T) {
+	readOnly := make(map[string]struct{})
+	for method := range rpcLimited {
+		if _, ok := rpcRelayMethods[method]; !ok {
+			readOnly[method] = struct{}{}
+		}
+	}
+	withStop := map[string]struct{}{"stop": {}}
+	for method := range rpcLimited {
+		withStop[method] = struct{}{}
+	}
+
+	tests := []struct {
+		option  string
+		user    string
+		methods map[string]struct{}
+		err     bool
+	}{{
+		option: "alice:getblock, getblockcount,",
+		user:   "alice",
+		methods: map[string]struct{}{
+			"getblock":      {},
+			"getblockcount": {},
+		},
+	}, {
+		option:  "bob:",
+		user:    "bob",
+		methods: map[string]struct{}{},
+	}, {
+		option:  "carol:@readonly",
+		user:    "carol",
+		methods: readOnly,
+	}, {
+		option:  "dave:stop,@wallet",
+		user:    "dave",
+		methods: withStop,
+	}, {
+		option:  "erin:getblock,@admin",
+		user:    "erin",
+		methods: nil,
+	}, {
+		option: "frank:@superuser",
+		err:    true,
+	}, {
+		option: "getblock",
+		err:    true,
+	}, {
+		option: ":getblock",
+		err:    true,
+	}}
+
+	for _, test := range tests {
+		user, methods, err := parseRPCWhitelist(test.option)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected error", test.option)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.option, err)
+			continue
+		}
+		if user != test.user || !reflect.DeepEqual(methods, test.methods) {
+			t.Errorf("%q: got user %q with methods %v, want user "+
+				"%q with methods %v", test.option, user, methods,
+				test.user, test.methods)
+		}
+	}
+}
+
+// rpcAuthHash returns the hex-encoded hash of the passed password as used by
+// the rpcauth option.
+func rpcAuthHash(salt, password string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(password))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// TestParseRPCAuth ensures rpcauth options are parsed and only match the
+// hashed password.
+func TestParseRPCAuth(t *testing.T) {
+	hash := rpcAuthHash("salt", "secret")
+	user, pass, err := parseRPCAuth("alice:salt$" + hash)
+	if err != nil {
+		t.Fatalf("parseRPCAuth: unexpected error: %v", err)
+	}
+	if user != "alice" || !pass.matches("secret") || pass.matches("wrong") {
+		t.Fatalf("unexpected parsed rpcauth for user %q", user)
+	}
+
+	malformed := []string{
+		"alice",
+		":salt$" + hash,
+		"alice:salt",
+		"alice:$" + hash,
+		"alice:salt$" + hash[:62],
+		"alice:salt$" + strings.Repeat("zz", 32),
+	}
+	for _, option := range malformed {
+		if _, _, err := parseRPCAuth(option); err == nil {
+			t.Errorf("%q: expected error", option)
+		}
+	}
+}
+
+// certConnState returns a TLS connection state with a verified client
+// certificate for the passed common name.
+func certConnState(commonName string) *tls.ConnectionState {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+	return &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{cert}},
+	}
+}
+
+// TestRPCAuthenticator ensures the authenticator authenticates the configured
+// users, the cookie user and client certificates with the right permissions.
+func TestRPCAuthenticator(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "rpcauth")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Writing the cookie is logged, which requires an initialized log
+	// rotator unless logging is disabled.
+	defer func(logger btclog.Logger) { rpcsLog = logger }(rpcsLog)
+	rpcsLog = btclog.Disabled
+
+	cookieFile := filepath.Join(tmpDir, "data", ".cookie")
+	cfg := &config{
+		RPCLimitUser:  "limited",
+		RPCLimitPass:  "limitedpass",
+		RPCAuth:       []string{"hashed:salt$" + rpcAuthHash("salt", "hashedpass")},
+		RPCWhitelist:  []string{"hashed:getblock", "hashed:getblockcount", "certified:@admin"},
+		RPCCookie:     true,
+		RPCCookieFile: cookieFile,
+		RPCClientCA:   "ca.pem",
+	}
+	a, err := newRPCAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("newRPCAuthenticator: unexpected error: %v", err)
+	}
+
+	// The cookie must be written since it is enabled and no admin user is
+	// configured, and removed again on request.
+	cookie, err := ioutil.ReadFile(cookieFile)
+	if err != nil {
+		t.Fatalf("unable to read cookie: %v", err)
+	}
+	cookieParts := strings.SplitN(string(cookie), ":", 2)
+	if len(cookieParts) != 2 || cookieParts[0] != rpcCookieUser ||
+		len(cookieParts[1]) != 2*rpcCookiePassLen {
+
+		t.Fatalf("unexpected cookie %q", cookie)
+	}
+	defer func() {
+		a.removeCookie()
+		if _, err := os.Stat(cookieFile); !os.IsNotExist(err) {
+			t.Errorf("cookie was not removed: %v", err)
+		}
+	}()
+
+	// admin is nil for users which may call all methods.
+	admin := map[string]struct{}(nil)
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		methods  map[string]struct{}
+		fail     bool
+	}{{
+		name:     "cookie user",
+		user:     rpcCookieUser,
+		password: cookieParts[1],
+		methods:  admin,
+	}, {
+		name:     "limited user",
+		user:     "limited",
+		password: "limitedpass",
+		methods:  rpcLimited,
+	}, {
+		name:     "hashed user with combined whitelists",
+		user:     "hashed",
+		password: "hashedpass",
+		methods: map[string]struct{}{
+			"getblock":      {},
+			"getblockcount": {},
+		},
+	}, {
+		name:     "wrong password",
+		user:     "limited",
+		password: "wrong",
+		fail:     true,
+	}, {
+		name:     "wrong hashed password",
+		user:     "hashed",
+		password: "limitedpass",
+		fail:     true,
+	}, {
+		name:     "password of other user",
+		user:     rpcCookieUser,
+		password: "limitedpass",
+		fail:     true,
+	}, {
+		name:     "unknown user",
+		user:     "mallory",
+		password: "limitedpass",
+		fail:     true,
+	}}
+
+	for _, test := range tests {
+		user := a.authenticate(test.user, test.password)
+		if test.fail {
+			if user != nil {
+				t.Errorf("%s: unexpectedly authenticated as %q",
+					test.name, user.name)
+			}
+			continue
+		}
+		if user == nil {
+			t.Errorf("%s: not authenticated", test.name)
+			continue
+		}
+		if user.name != test.user ||
+			!reflect.DeepEqual(user.methods, test.methods) {
+
+			t.Errorf("%s: got user %q with methods %v", test.name,
+				user.name, user.methods)
+		}
+	}
+
+	// Client certificates only get the permissions of whitelisted users,
+	// all other users may only call the limited methods.
+	certTests := []struct {
+		commonName string
+		methods    map[string]struct{}
+	}{
+		{commonName: "certified", methods: admin},
+		{commonName: "hashed", methods: map[string]struct{}{
+			"getblock":      {},
+			"getblockcount": {},
+		}},
+		{commonName: rpcCookieUser, methods: rpcLimited},
+		{commonName: "limited", methods: rpcLimited},
+		{commonName: "anyone", methods: rpcLimited},
+	}
+	for _, test := range certTests {
+		user := a.certUser(certConnState(test.commonName))
+		if user == nil || user.name != test.commonName ||
+			!reflect.DeepEqual(user.methods, test.methods) {
+
+			t.Errorf("certificate for %q: got user %+v",
+				test.commonName, user)
+		}
+	}
+	if user := a.certUser(certConnState("")); user != nil {
+		t.Errorf("certificate without common name authenticated as %q",
+			user.name)
+	}
+	if user := a.certUser(&tls.ConnectionState{}); user != nil {
+		t.Errorf("unverified connection authenticated as %q", user.name)
+	}
+	if user := a.certUser(nil); user != nil {
+		t.Errorf("connection without TLS authenticated as %q", user.name)
+	}
+}
+
+// TestRPCAuthenticatorNoCookie ensures no cookie is written when an admin user
+// is configured or cookies are not enabled, and client certificates are
+// ignored without certificate authorities.
+func TestRPCAuthenticatorNoCookie(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "rpcauth")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cookieFile := filepath.Join(tmpDir, ".cookie")
+	configs := []*config{{
+		RPCUser:       "admin",
+		RPCPass:       "adminpass",
+		RPCCookie:     true,
+		RPCCookieFile: cookieFile,
+	}, {
+		RPCCookieFile: cookieFile,
+	}}
+	for i, cfg := range configs {
+		a, err := newRPCAuthenticator(cfg)
+		if err != nil {
+			t.Fatalf("%d: newRPCAuthenticator: unexpected error: %v",
+				i, err)
+		}
+		if _, err := os.Stat(cookieFile); !os.IsNotExist(err) {
+			t.Fatalf("%d: unexpected cookie file: %v", i, err)
+		}
+		if user := a.certUser(certConnState("admin")); user != nil {
+			t.Fatalf("%d: certificate authenticated as %q", i,
+				user.name)
+		}
+	}
+
+	a, err := newRPCAuthenticator(configs[0])
+	if err != nil {
+		t.Fatalf("newRPCAuthenticator: unexpected error: %v", err)
+	}
+	user := a.authenticate("admin", "adminpass")
+	if user == nil || user.methods != nil {
+		t.Fatalf("unexpected admin user %+v", user)
+	}
+
+	// Invalid options are rejected.
+	_, err = newRPCAuthenticator(&config{RPCAuth: []string{"admin"}})
+	if err == nil {
+		t.Fatal("malformed rpcauth was accepted")
+	}
+	_, err = newRPCAuthenticator(&config{RPCWhitelist: []string{"admin"}})
+	if err == nil {
+		t.Fatal("malformed rpcwhitelist was accepted")
+	}
+}
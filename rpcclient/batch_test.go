@@ -0,0 +1,161 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+// newTestBatchClient returns a batch client connected to a server which
+// answers getblockhash requests in reverse order with the height as result,
+// and fails requests for a negative height.  The sizes of the received
+// batches are recorded.
+func newTestBatchClient(t *testing.T, maxBatchSize int,
+	batchSizes *[]int) *Client {
+
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var reqs []btcjson.Request
+			if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+				t.Errorf("unable to decode batch: %v", err)
+				return
+			}
+			*batchSizes = append(*batchSizes, len(reqs))
+
+			replies := make([]string, 0, len(reqs))
+			for i := len(reqs) - 1; i >= 0; i-- {
+				id, _ := json.Marshal(reqs[i].ID)
+				var height int64
+				json.Unmarshal(reqs[i].Params[0], &height)
+				reply := fmt.Sprintf(`{"result":"%d","error":null`,
+					height)
+				if height < 0 {
+					reply = `{"result":null,"error":{"code":-8,` +
+						`"message":"out of range"}`
+				}
+				replies = append(replies, reply+`,"id":`+
+					string(id)+`}`)
+			}
+			w.Write([]byte("[" + strings.Join(replies, ",") + "]"))
+		}))
+	t.Cleanup(server.Close)
+
+	client, err := NewBatch(&ConnConfig{
+		Host:         strings.TrimPrefix(server.URL, "http://"),
+		User:         "user",
+		Pass:         "pass",
+		HTTPPostMode: true,
+		DisableTLS:   true,
+		MaxBatchSize: maxBatchSize,
+	})
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	t.Cleanup(client.Shutdown)
+	return client
+}
+
+// TestBatchSend ensures the responses of a batch are delivered to the futures
+// of their requests and that batches are split at the maximum batch size.
+func TestBatchSend(t *testing.T) {
+	var batchSizes []int
+	client := newTestBatchClient(t, 2, &batchSizes)
+
+	heights := []int64{1, -1, 3, 4, 5}
+	futures := make([]chan *response, 0, len(heights))
+	for _, height := range heights {
+		cmd := btcjson.NewGetBlockHashCmd(height)
+		futures = append(futures, client.sendCmd(cmd))
+	}
+	if err := client.Send(); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	for i, height := range heights {
+		result, err := receiveFuture(futures[i])
+		if height < 0 {
+			if _, ok := err.(*btcjson.RPCError); !ok {
+				t.Fatalf("request %d: unexpected error %v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		if want := fmt.Sprintf(`"%d"`, height); string(result) != want {
+			t.Fatalf("request %d: got %s, want %s", i, result, want)
+		}
+	}
+	if fmt.Sprint(batchSizes) != "[2 2 1]" {
+		t.Fatalf("unexpected batch sizes %v", batchSizes)
+	}
+
+	// Nothing is sent once the queue is empty.
+	if err := client.Send(); err != nil || len(batchSizes) != 3 {
+		t.Fatalf("empty batch was sent: %v", err)
+	}
+}
+
+// TestBatchSendStream ensures the responses of a batch are streamed in the
+// order they are read along with the position of their requests.
+func TestBatchSendStream(t *testing.T) {
+	var batchSizes []int
+	client := newTestBatchClient(t, 3, &batchSizes)
+
+	heights := []int64{10, 11, -1, 13}
+	for _, height := range heights {
+		client.GetBlockHashAsync(height)
+	}
+
+	var indexes []int
+	for resp := range client.SendStream() {
+		indexes = append(indexes, resp.Index)
+		if resp.Method != "getblockhash" {
+			t.Fatalf("unexpected method %q", resp.Method)
+		}
+		height := heights[resp.Index]
+		if height < 0 {
+			if resp.Err == nil || resp.Result != nil {
+				t.Fatalf("request %d: expected error", resp.Index)
+			}
+			continue
+		}
+		want := fmt.Sprintf(`"%d"`, height)
+		if resp.Err != nil || string(resp.Result) != want {
+			t.Fatalf("request %d: got %s (%v), want %s", resp.Index,
+				resp.Result, resp.Err, want)
+		}
+	}
+
+	// The server answers each batch in reverse order.
+	if fmt.Sprint(indexes) != "[2 1 0 3]" {
+		t.Fatalf("unexpected response order %v", indexes)
+	}
+}
+
+// TestBatchSendFailure ensures the futures of a batch which can't be sent
+// receive the error.
+func TestBatchSendFailure(t *testing.T) {
+	var batchSizes []int
+	client := newTestBatchClient(t, 0, &batchSizes)
+	client.config.Host = "127.0.0.1:1"
+
+	future := client.GetBlockCountAsync()
+	if err := client.Send(); err == nil {
+		t.Fatalf("expected error sending batch")
+	}
+	if _, err := future.Receive(); err == nil {
+		t.Fatalf("expected error for request of failed batch")
+	}
+}
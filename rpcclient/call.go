@@ -0,0 +1,84 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package rpcclient
+
+import (
+	"encoding/json"
+)
+
+// FutureCallResult is a future promise to deliver the result of a Call or
+// CallCmd invocation (or an applicable error) unmarshalled into the type T.
+type FutureCallResult[T any] chan *response
+
+// Receive waits for the response promised by the future and returns the result
+// unmarshalled into the type T, or an error if the request was unsuccessful.
+func (r FutureCallResult[T]) Receive() (T, error) {
+	var result T
+	res, err := receiveFuture(r)
+	if err != nil {
+		return result, err
+	}
+
+	err = json.Unmarshal(res, &result)
+	return result, err
+}
+
+// CallAsync returns an instance of a type that can be used to get the result
+// of an arbitrary RPC request at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See Call for the blocking version and more details.
+func CallAsync[T any](c *Client, method string,
+	params ...interface{}) FutureCallResult[T] {
+
+	// Marshal each parameter on its own, so they are sent as a JSON array
+	// of the passed values.
+	rawParams := make([]json.RawMessage, 0, len(params))
+	for _, param := range params {
+		rawParam, err := json.Marshal(param)
+		if err != nil {
+			return newFutureError(err)
+		}
+		rawParams = append(rawParams, rawParam)
+	}
+
+	return FutureCallResult[T](c.RawRequestAsync(method, rawParams))
+}
+
+// Call sends a request for the passed method with the passed parameters, which
+// are marshalled to JSON, to the server and unmarshals the result into the
+// type T.  This allows calling methods the client doesn't provide typed
+// wrappers for yet, such as methods recently added to the server, without
+// giving up typed results:
+//
+//	info, err := rpcclient.Call[btcjson.GetMempoolInfoResult](client,
+//		"getmempoolinfo")
+//
+// The type T may be json.RawMessage to receive the raw result or
+// map[string]interface{} to inspect results of unknown shape.
+func Call[T any](c *Client, method string, params ...interface{}) (T, error) {
+	return CallAsync[T](c, method, params...).Receive()
+}
+
+// CallCmdAsync returns an instance of a type that can be used to get the
+// result of the passed command at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See CallCmd for the blocking version and more details.
+func CallCmdAsync[T any](c *Client, cmd interface{}) FutureCallResult[T] {
+	return FutureCallResult[T](c.sendCmd(cmd))
+}
+
+// CallCmd sends the passed command to the server and unmarshals the result
+// into the type T.  The command must be registered with btcjson, which
+// includes commands registered by the caller with btcjson.RegisterCmd.  This
+// allows passing through commands defined outside of this package with the
+// same marshalling as the typed wrappers of the client.
+func CallCmd[T any](c *Client, cmd interface{}) (T, error) {
+	return CallCmdAsync[T](c, cmd).Receive()
+}
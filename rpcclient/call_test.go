@@ -0,0 +1,120 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package rpcclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+// newTestCallClient returns a client in HTTP POST mode connected to a server
+// which replies to every request with the passed result and records the
+// method and parameters of the last request.
+func newTestCallClient(t *testing.T, result string,
+	lastReq *btcjson.Request) *Client {
+
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if err := json.NewDecoder(r.Body).Decode(lastReq); err != nil {
+				t.Errorf("unable to decode request: %v", err)
+			}
+			id, _ := json.Marshal(lastReq.ID)
+			w.Write([]byte(`{"result":` + result + `,"error":null,` +
+				`"id":` + string(id) + `}`))
+		}))
+	t.Cleanup(server.Close)
+
+	client, err := New(&ConnConfig{
+		Host:         strings.TrimPrefix(server.URL, "http://"),
+		User:         "user",
+		Pass:         "pass",
+		HTTPPostMode: true,
+		DisableTLS:   true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	t.Cleanup(client.Shutdown)
+	return client
+}
+
+// TestCall ensures Call marshals the parameters of arbitrary methods and
+// unmarshals the result into the requested type.
+func TestCall(t *testing.T) {
+	type futureResult struct {
+		Size  int64  `json:"size"`
+		Extra string `json:"extra"`
+	}
+
+	var req btcjson.Request
+	client := newTestCallClient(t, `{"size":5,"extra":"new"}`, &req)
+
+	result, err := Call[futureResult](client, "getfuturething", "abc", 3,
+		true)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	want := futureResult{Size: 5, Extra: "new"}
+	if result != want {
+		t.Fatalf("unexpected result: got %+v, want %+v", result, want)
+	}
+	if req.Method != "getfuturething" {
+		t.Fatalf("unexpected method %q", req.Method)
+	}
+	wantParams := []json.RawMessage{
+		json.RawMessage(`"abc"`), json.RawMessage(`3`),
+		json.RawMessage(`true`),
+	}
+	if !reflect.DeepEqual(req.Params, wantParams) {
+		t.Fatalf("unexpected params: got %s, want %s", req.Params,
+			wantParams)
+	}
+
+	// A result which doesn't match the requested type is an error.
+	if _, err := Call[[]string](client, "getfuturething"); err == nil {
+		t.Fatalf("Call succeeded with mismatched result type")
+	}
+
+	// An empty method is rejected before sending the request.
+	if _, err := Call[int](client, ""); err == nil {
+		t.Fatalf("Call succeeded without method")
+	}
+}
+
+// TestCallCmd ensures CallCmd sends registered commands and unmarshals the
+// result into the requested type.
+func TestCallCmd(t *testing.T) {
+	var req btcjson.Request
+	client := newTestCallClient(t, `101`, &req)
+
+	count, err := CallCmdAsync[int64](client,
+		btcjson.NewGetBlockCountCmd()).Receive()
+	if err != nil {
+		t.Fatalf("CallCmd failed: %v", err)
+	}
+	if count != 101 {
+		t.Fatalf("unexpected result %d", count)
+	}
+	if req.Method != "getblockcount" {
+		t.Fatalf("unexpected method %q", req.Method)
+	}
+
+	// Unregistered commands are rejected.
+	type unregisteredCmd struct{}
+	if _, err := CallCmd[int64](client, &unregisteredCmd{}); err == nil {
+		t.Fatalf("CallCmd succeeded with unregistered command")
+	}
+}
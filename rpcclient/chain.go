@@ -1151,6 +1151,77 @@ func (c *Client) InvalidateBlock(blockHash *chainhash.Hash) error {
 	return c.InvalidateBlockAsync(blockHash).Receive()
 }
 
+// FutureReconsiderBlockResult is a future promise to deliver the result of a
+// ReconsiderBlockAsync RPC invocation (or an applicable error).
+type FutureReconsiderBlockResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if the block could not be reconsidered.
+func (r FutureReconsiderBlockResult) Receive() error {
+	_, err := receiveFuture(r)
+
+	return err
+}
+
+// ReconsiderBlockAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ReconsiderBlock for the blocking version and more details.
+func (c *Client) ReconsiderBlockAsync(blockHash *chainhash.Hash) FutureReconsiderBlockResult {
+	hash := ""
+	if blockHash != nil {
+		hash = blockHash.String()
+	}
+
+	cmd := btcjson.NewReconsiderBlockCmd(hash)
+	return c.sendCmd(cmd)
+}
+
+// ReconsiderBlock removes the invalid status of a specific block, such as set
+// by InvalidateBlock, along with its ancestors and descendants.
+func (c *Client) ReconsiderBlock(blockHash *chainhash.Hash) error {
+	return c.ReconsiderBlockAsync(blockHash).Receive()
+}
+
+// FutureSaveMempoolResult is a future promise to deliver the result of a
+// SaveMempoolAsync RPC invocation (or an applicable error).
+type FutureSaveMempoolResult chan *response
+
+// Receive waits for the response promised by the future and returns the path
+// of the file the transactions of the memory pool were saved to.
+func (r FutureSaveMempoolResult) Receive() (string, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return "", err
+	}
+
+	var result btcjson.SaveMempoolResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return "", err
+	}
+
+	return result.Filename, nil
+}
+
+// SaveMempoolAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See SaveMempool for the blocking version and more details.
+func (c *Client) SaveMempoolAsync() FutureSaveMempoolResult {
+	cmd := btcjson.NewSaveMempoolCmd()
+	return c.sendCmd(cmd)
+}
+
+// SaveMempool saves the transactions in the memory pool of the server to disk,
+// so they are loaded again on its next start.  It returns the path of the file
+// they were saved to.
+func (c *Client) SaveMempool() (string, error) {
+	return c.SaveMempoolAsync().Receive()
+}
+
 // FutureGetCFilterResult is a future promise to deliver the result of a
 // GetCFilterAsync RPC invocation (or an applicable error).
 type FutureGetCFilterResult chan *response
@@ -1260,6 +1331,48 @@ func (c *Client) GetCFilterHeader(blockHash *chainhash.Hash,
 	return c.GetCFilterHeaderAsync(blockHash, filterType).Receive()
 }
 
+// FutureGetSilentPaymentTweaksResult is a future promise to deliver the result
+// of a GetSilentPaymentTweaksAsync RPC invocation (or an applicable error).
+type FutureGetSilentPaymentTweaksResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// silent payments tweaks of each block in the requested range.
+func (r FutureGetSilentPaymentTweaksResult) Receive() ([]btcjson.GetSilentPaymentTweaksResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var tweaks []btcjson.GetSilentPaymentTweaksResult
+	err = json.Unmarshal(res, &tweaks)
+	if err != nil {
+		return nil, err
+	}
+
+	return tweaks, nil
+}
+
+// GetSilentPaymentTweaksAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetSilentPaymentTweaks for the blocking version and more details.
+func (c *Client) GetSilentPaymentTweaksAsync(startHeight,
+	endHeight int32) FutureGetSilentPaymentTweaksResult {
+
+	cmd := btcjson.NewGetSilentPaymentTweaksCmd(startHeight, endHeight)
+	return c.sendCmd(cmd)
+}
+
+// GetSilentPaymentTweaks returns the BIP-352 silent payments tweaks of the
+// eligible transactions of each main chain block from startHeight to endHeight
+// inclusive.  The server must maintain the tweak index.
+func (c *Client) GetSilentPaymentTweaks(startHeight,
+	endHeight int32) ([]btcjson.GetSilentPaymentTweaksResult, error) {
+
+	return c.GetSilentPaymentTweaksAsync(startHeight, endHeight).Receive()
+}
+
 // FutureGetBlockStatsResult is a future promise to deliver the result of a
 // GetBlockStatsAsync RPC invocation (or an applicable error).
 type FutureGetBlockStatsResult chan *response
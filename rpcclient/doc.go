@@ -57,6 +57,26 @@ the Receive method on the returned instance will either return the result
 immediately if it has already arrived, or block until it has.  This is useful
 since it provides the caller with greater control over concurrency.
 
+Methods Without Typed Wrappers
+
+Methods the client doesn't provide typed wrappers for yet, such as methods
+recently added to the server, can be called with the generic Call function.  It
+marshals the passed parameters and unmarshals the result into the requested
+type.  CallCmd does the same for commands registered with btcjson.RegisterCmd,
+including commands registered by the caller.  Both have asynchronous variants
+and require Go 1.18 or later.  RawRequest provides the same without generics
+and leaves the unmarshalling of the raw result to the caller.
+
+Multiple Backends
+
+A ClientPool provides the same methods as a Client, but sends the requests to
+one of several RPC servers in HTTP POST mode.  Requests go to the first healthy
+backend and fail over to the next one when a backend can't be reached.  Errors
+returned by a server are passed to the caller as usual.  Unhealthy backends are
+checked periodically and used again once they recover.  With the RoundRobin
+option, requests which only read the state of the server are balanced across
+all healthy backends, while other requests still go to the first one.
+
 Notifications
 
 The first important part of notifications is to realize that they will only
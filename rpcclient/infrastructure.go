@@ -167,6 +167,10 @@ type Client struct {
 	batch     bool
 	batchList *list.List
 
+	// pool is the client pool the requests are handed to when the client
+	// is embedded in one.
+	pool *ClientPool
+
 	// retryCount holds the number of times the client has tried to
 	// reconnect to the RPC server.
 	retryCount int64
@@ -885,23 +889,19 @@ func receiveFuture(f chan *response) ([]byte, error) {
 	return r.result, r.err
 }
 
-// sendPost sends the passed request to the server by issuing an HTTP POST
-// request using the provided response channel for the reply.  Typically a new
-// connection is opened and closed for each command when using this method,
-// however, the underlying HTTP client might coalesce multiple commands
-// depending on several factors including the remote server configuration.
-func (c *Client) sendPost(jReq *jsonRequest) {
+// newPostRequest returns an HTTP POST request with the passed body to the
+// configured RPC server.
+func (c *Client) newPostRequest(body []byte) (*http.Request, error) {
 	// Generate a request to the configured RPC server.
 	protocol := "http"
 	if !c.config.DisableTLS {
 		protocol = "https"
 	}
 	url := protocol + "://" + c.config.Host
-	bodyReader := bytes.NewReader(jReq.marshalledJSON)
+	bodyReader := bytes.NewReader(body)
 	httpReq, err := http.NewRequest("POST", url, bodyReader)
 	if err != nil {
-		jReq.responseChan <- &response{result: nil, err: err}
-		return
+		return nil, err
 	}
 	httpReq.Close = true
 	httpReq.Header.Set("Content-Type", "application/json")
@@ -911,11 +911,25 @@ func (c *Client) sendPost(jReq *jsonRequest) {
 
 	// Configure basic access authorization.
 	user, pass, err := c.config.getAuth()
+	if err != nil {
+		return nil, err
+	}
+	httpReq.SetBasicAuth(user, pass)
+
+	return httpReq, nil
+}
+
+// sendPost sends the passed request to the server by issuing an HTTP POST
+// request using the provided response channel for the reply.  Typically a new
+// connection is opened and closed for each command when using this method,
+// however, the underlying HTTP client might coalesce multiple commands
+// depending on several factors including the remote server configuration.
+func (c *Client) sendPost(jReq *jsonRequest) {
+	httpReq, err := c.newPostRequest(jReq.marshalledJSON)
 	if err != nil {
 		jReq.responseChan <- &response{result: nil, err: err}
 		return
 	}
-	httpReq.SetBasicAuth(user, pass)
 
 	log.Tracef("Sending command [%s] with id %d", jReq.method, jReq.id)
 	c.sendPostRequest(httpReq, jReq)
@@ -929,6 +943,11 @@ func (c *Client) sendRequest(jReq *jsonRequest) {
 	// the client running in HTTP POST mode or not.  When running in HTTP
 	// POST mode, the command is issued via an HTTP client.  Otherwise,
 	// the command is issued via the asynchronous websocket channels.
+	// Clients embedded in a pool hand the command to the pool instead.
+	if c.pool != nil {
+		c.pool.sendRequest(jReq)
+		return
+	}
 	if c.config.HTTPPostMode {
 		if c.batch {
 			if err := c.addRequest(jReq); err != nil {
@@ -1237,6 +1256,11 @@ type ConnConfig struct {
 	// EnableBCInfoHacks is an option provided to enable compatibility hacks
 	// when connecting to blockchain.info RPC server
 	EnableBCInfoHacks bool
+
+	// MaxBatchSize is the maximum number of requests a batch client sends
+	// to the server at once.  Larger batches are split into multiple
+	// requests.  A value of 0 means no limit.
+	MaxBatchSize int
 }
 
 // getAuth returns the username and passphrase that will actually be used for
@@ -1621,68 +1645,211 @@ func (c *Client) BackendVersion() (BackendVersion, error) {
 	return *c.backendVersion, nil
 }
 
-func (c *Client) sendAsync() FutureGetBulkResult {
-	// convert the array of marshalled json requests to a single request we can send
-	responseChan := make(chan *response, 1)
-	marshalledRequest := []byte("[")
+// BatchResponse is the response to a request of a batch sent with SendStream.
+type BatchResponse struct {
+	// Index is the position of the request in the batch, which is the
+	// order the requests were queued in.
+	Index int
+
+	// Method is the method of the request.
+	Method string
+
+	// Result is the raw result of the request.  It is nil when Err is set.
+	Result json.RawMessage
+
+	// Err is the error returned by the server for the request, or the
+	// error which prevented the request from being answered.
+	Err error
+}
+
+// batchRawResponse is a response to a request of a batch.
+type batchRawResponse struct {
+	rawResponse
+	ID *uint64 `json:"id"`
+}
+
+// takeBatch removes the requests queued by a batch client and returns them in
+// the order they were queued.
+//
+// This function is safe for concurrent access.
+func (c *Client) takeBatch() []*jsonRequest {
+	c.requestLock.Lock()
+	defer c.requestLock.Unlock()
+
+	requests := make([]*jsonRequest, 0, c.batchList.Len())
 	for iter := c.batchList.Front(); iter != nil; iter = iter.Next() {
 		request := iter.Value.(*jsonRequest)
-		marshalledRequest = append(marshalledRequest, request.marshalledJSON...)
-		marshalledRequest = append(marshalledRequest, []byte(",")...)
-	}
-	if len(marshalledRequest) > 0 {
-		// removes the trailing comma to process the request individually
-		marshalledRequest = marshalledRequest[:len(marshalledRequest)-1]
-	}
-	marshalledRequest = append(marshalledRequest, []byte("]")...)
-	request := jsonRequest{
-		id:             c.NextID(),
-		method:         "",
-		cmd:            nil,
-		marshalledJSON: marshalledRequest,
-		responseChan:   responseChan,
+		delete(c.requestMap, request.id)
+		requests = append(requests, request)
 	}
-	c.sendPost(&request)
-	return responseChan
+	c.batchList = list.New()
+	return requests
 }
 
-// Marshall's bulk requests and sends to the server
-// creates a response channel to receive the response
-func (c *Client) Send() error {
-	// if batchlist is empty, there's nothing to send
-	if c.batchList.Len() == 0 {
-		return nil
+// sendRequests sends the passed requests to the server in batches of at most
+// the configured maximum batch size.  Each response is delivered to the
+// response channel of its request as soon as it is unmarshalled, and to the
+// passed stream when it is not nil.  The first error which prevented a batch
+// from being answered is returned, but the error is also delivered for each
+// affected request.
+func (c *Client) sendRequests(requests []*jsonRequest,
+	stream chan<- *BatchResponse) error {
+
+	size := c.config.MaxBatchSize
+	if size <= 0 {
+		size = len(requests)
+	}
+
+	var firstErr error
+	for start := 0; start < len(requests); start += size {
+		end := start + size
+		if end > len(requests) {
+			end = len(requests)
+		}
+		err := c.sendBatch(requests[start:end], start, stream)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
 	}
+	return firstErr
+}
 
-	// clear batchlist in case of an error
-	defer func() {
-		c.batchList = list.New()
-	}()
-
-	result, err := c.sendAsync().Receive()
+// sendBatch sends the passed requests to the server in a single batch and
+// decodes the responses one at a time, so the whole reply is never held in
+// memory.  The index of the first request in the full batch is passed as
+// offset.
+func (c *Client) sendBatch(requests []*jsonRequest, offset int,
+	stream chan<- *BatchResponse) error {
+
+	pending := make(map[uint64]int, len(requests))
+	for i, request := range requests {
+		pending[request.id] = i
+	}
+
+	// deliver hands the response to the request at the passed position
+	// in the batch to its response channel and to the stream.  The stream
+	// is abandoned if the client shuts down while waiting for its reader.
+	streaming := stream != nil
+	deliver := func(i int, result []byte, err error) {
+		request := requests[i]
+		delete(pending, request.id)
+		request.responseChan <- &response{result: result, err: err}
+		if !streaming {
+			return
+		}
+		select {
+		case stream <- &BatchResponse{
+			Index:  offset + i,
+			Method: request.method,
+			Result: result,
+			Err:    err,
+		}:
+		case <-c.shutdown:
+			streaming = false
+		}
+	}
 
-	if err != nil {
+	// failPending delivers the passed error to all requests which haven't
+	// been answered yet.
+	failPending := func(err error) error {
+		for i, request := range requests {
+			if _, ok := pending[request.id]; ok {
+				deliver(i, nil, err)
+			}
+		}
 		return err
 	}
 
-	for iter := c.batchList.Front(); iter != nil; iter = iter.Next() {
-		var requestError error
-		request := iter.Value.(*jsonRequest)
-		individualResult := result[request.id]
-		fullResult, err := json.Marshal(individualResult.Result)
-		if err != nil {
-			return err
-		}
+	select {
+	case <-c.shutdown:
+		return failPending(ErrClientShutdown)
+	default:
+	}
 
-		if individualResult.Error != nil {
-			requestError = individualResult.Error
+	// Convert the marshalled json requests to a single request.
+	marshalledRequest := []byte("[")
+	for i, request := range requests {
+		if i > 0 {
+			marshalledRequest = append(marshalledRequest, ',')
 		}
+		marshalledRequest = append(marshalledRequest,
+			request.marshalledJSON...)
+	}
+	marshalledRequest = append(marshalledRequest, ']')
 
-		result := response{
-			result: fullResult,
-			err:    requestError,
+	httpReq, err := c.newPostRequest(marshalledRequest)
+	if err != nil {
+		return failPending(err)
+	}
+	log.Tracef("Sending batch of %d commands", len(requests))
+	httpResponse, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return failPending(err)
+	}
+	defer httpResponse.Body.Close()
+
+	// The reply must be an array of responses.  Anything else, such as
+	// an error page or a single error response rejecting the whole batch,
+	// fails all requests.
+	dec := json.NewDecoder(httpResponse.Body)
+	tok, err := dec.Token()
+	if delim, ok := tok.(json.Delim); err != nil || !ok || delim != '[' {
+		return failPending(fmt.Errorf("status code: %d, response is "+
+			"not a batch reply", httpResponse.StatusCode))
+	}
+	for dec.More() {
+		var resp batchRawResponse
+		if err := dec.Decode(&resp); err != nil {
+			return failPending(fmt.Errorf("error reading json "+
+				"reply: %v", err))
+		}
+		if resp.ID == nil {
+			continue
+		}
+		i, ok := pending[*resp.ID]
+		if !ok {
+			log.Warnf("Received unexpected batch response with id %d",
+				*resp.ID)
+			continue
 		}
-		request.responseChan <- &result
+		result, err := resp.result()
+		deliver(i, result, err)
+		if stream != nil && !streaming {
+			return failPending(ErrClientShutdown)
+		}
+	}
+
+	if len(pending) > 0 {
+		return failPending(errors.New("no response received for " +
+			"request of batch"))
 	}
 	return nil
 }
+
+// Send sends all requests queued by a batch client to the server and delivers
+// the responses to their futures.  The requests are split into batches of at
+// most ConnConfig.MaxBatchSize requests, and each response is delivered as
+// soon as it is read rather than once the whole reply is received.
+//
+// An error is returned if any batch could not be answered, in which case the
+// error is also delivered to the futures of the affected requests.
+func (c *Client) Send() error {
+	return c.sendRequests(c.takeBatch(), nil)
+}
+
+// SendStream sends all requests queued by a batch client like Send, and
+// additionally returns a channel on which the responses are delivered in the
+// order they are read.  The channel is closed once all responses have been
+// delivered.  Since the responses are read as the channel is drained, large
+// batches don't need to be held in memory as a whole.
+//
+// The channel must be drained unless the client is shut down.
+func (c *Client) SendStream() <-chan *BatchResponse {
+	requests := c.takeBatch()
+	stream := make(chan *BatchResponse)
+	go func() {
+		c.sendRequests(requests, stream)
+		close(stream)
+	}()
+	return stream
+}
@@ -179,6 +179,12 @@ type NotificationHandlers struct {
 	// made to register for the notification and the function is non-nil.
 	OnTxAcceptedVerbose func(txDetails *btcjson.TxRawResult)
 
+	// OnTxReplaced is invoked when a transaction is evicted from the memory
+	// pool by a replacement transaction.  It will only be invoked if a
+	// preceding call to NotifyNewTransactions has been made to register
+	// for the notification and the function is non-nil.
+	OnTxReplaced func(replaced, replacement *chainhash.Hash)
+
 	// OnBtcdConnected is invoked when a wallet connects or disconnects from
 	// btcd.
 	//
@@ -409,6 +415,24 @@ func (c *Client) handleNotification(ntfn *rawNotification) {
 
 		c.ntfnHandlers.OnTxAcceptedVerbose(rawTx)
 
+	// OnTxReplaced
+	case btcjson.TxReplacedNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnTxReplaced == nil {
+			return
+		}
+
+		replaced, replacement, err := parseTxReplacedNtfnParams(
+			ntfn.Params)
+		if err != nil {
+			log.Warnf("Received invalid tx replaced notification: %v",
+				err)
+			return
+		}
+
+		c.ntfnHandlers.OnTxReplaced(replaced, replacement)
+
 	// OnBtcdConnected
 	case btcjson.BtcdConnectedNtfnMethod:
 		// Ignore the notification if the client is not interested in
@@ -775,6 +799,34 @@ func parseTxAcceptedVerboseNtfnParams(params []json.RawMessage) (*btcjson.TxRawR
 	return &rawTx, nil
 }
 
+// parseTxReplacedNtfnParams parses out the hashes of the replaced and the
+// replacement transaction from the parameters of a txreplaced notification.
+func parseTxReplacedNtfnParams(params []json.RawMessage) (*chainhash.Hash,
+	*chainhash.Hash, error) {
+
+	if len(params) != 2 {
+		return nil, nil, wrongNumParams(len(params))
+	}
+
+	hashes := make([]*chainhash.Hash, len(params))
+	for i, param := range params {
+		// Unmarshal the parameter as a string.
+		var txHashStr string
+		err := json.Unmarshal(param, &txHashStr)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Decode string encoding of the transaction hash.
+		hashes[i], err = chainhash.NewHashFromStr(txHashStr)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return hashes[0], hashes[1], nil
+}
+
 // parseBtcdConnectedNtfnParams parses out the connection status of btcd
 // and btcwallet from the parameters of a btcdconnected notification.
 func parseBtcdConnectedNtfnParams(params []json.RawMessage) (bool, error) {
@@ -1030,7 +1082,7 @@ func (c *Client) NotifyNewTransactionsAsync(verbose bool) FutureNotifyNewTransac
 //
 // The notifications delivered as a result of this call will be via one of
 // OnTxAccepted (when verbose is false) or OnTxAcceptedVerbose (when verbose is
-// true).
+// true).  Transactions evicted by replacements are delivered via OnTxReplaced.
 //
 // NOTE: This is a btcd extension and requires a websocket connection.
 func (c *Client) NotifyNewTransactions(verbose bool) error {
@@ -1355,3 +1407,46 @@ func (c *Client) LoadTxFilterAsync(reload bool, addresses []btcutil.Address,
 func (c *Client) LoadTxFilter(reload bool, addresses []btcutil.Address, outPoints []wire.OutPoint) error {
 	return c.LoadTxFilterAsync(reload, addresses, outPoints).Receive()
 }
+
+// LoadTxFilterWithOptionsAsync returns an instance of a type that can be used
+// to get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See LoadTxFilterWithOptions for the blocking version and more details.
+//
+// NOTE: This is a btcd extension and requires a websocket connection.
+func (c *Client) LoadTxFilterWithOptionsAsync(reload bool,
+	addresses []btcutil.Address, outPoints []wire.OutPoint,
+	options *btcjson.LoadTxFilterOptions) FutureLoadTxFilterResult {
+
+	addrStrs := make([]string, len(addresses))
+	for i, a := range addresses {
+		addrStrs[i] = a.EncodeAddress()
+	}
+	outPointObjects := make([]btcjson.OutPoint, len(outPoints))
+	for i := range outPoints {
+		outPointObjects[i] = btcjson.OutPoint{
+			Hash:  outPoints[i].Hash.String(),
+			Index: outPoints[i].Index,
+		}
+	}
+
+	cmd := btcjson.NewLoadTxFilterWithOptionsCmd(reload, addrStrs,
+		outPointObjects, options)
+	return c.sendCmd(cmd)
+}
+
+// LoadTxFilterWithOptions loads, reloads, or adds data to a websocket client's
+// transaction filter like LoadTxFilter, and additionally adds the output
+// scripts, descriptors and silent payments addresses of the passed options.
+// Matching transactions are notified through the OnRelevantTxAccepted
+// callback.
+//
+// NOTE: This is a btcd extension and requires a websocket connection.
+func (c *Client) LoadTxFilterWithOptions(reload bool,
+	addresses []btcutil.Address, outPoints []wire.OutPoint,
+	options *btcjson.LoadTxFilterOptions) error {
+
+	return c.LoadTxFilterWithOptionsAsync(reload, addresses, outPoints,
+		options).Receive()
+}
@@ -0,0 +1,342 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcclient
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+const (
+	// defaultHealthCheckInterval is the default interval at which the
+	// backends of a pool are checked.
+	defaultHealthCheckInterval = 10 * time.Second
+)
+
+// poolWriteMethods are the registered methods which change the state of the
+// server or depend on state kept by it.  They are always sent to the first
+// healthy backend rather than being balanced across backends.
+var poolWriteMethods = map[string]struct{}{
+	"addnode":            {},
+	"debuglevel":         {},
+	"generate":           {},
+	"generatetoaddress":  {},
+	"getblocktemplate":   {},
+	"invalidateblock":    {},
+	"node":               {},
+	"ping":               {},
+	"reconsiderblock":    {},
+	"savemempool":        {},
+	"sendrawtransaction": {},
+	"setgenerate":        {},
+	"stop":               {},
+	"submitblock":        {},
+	"submitpackage":      {},
+}
+
+// PoolConfig describes the configuration of a client pool.
+type PoolConfig struct {
+	// Backends are the connection configurations of the backends in order
+	// of preference.  All of them must run in HTTP POST mode.
+	Backends []*ConnConfig
+
+	// HealthCheckInterval is the interval at which the backends are
+	// checked.  It defaults to 10 seconds.
+	HealthCheckInterval time.Duration
+
+	// HealthCheck is the function used to check whether a backend is
+	// healthy.  It defaults to requesting the block count.
+	HealthCheck func(*Client) error
+
+	// RoundRobin balances the requests of read-only methods across all
+	// healthy backends.  When unset, all requests are sent to the first
+	// healthy backend.
+	RoundRobin bool
+}
+
+// BackendStatus describes the state of a backend of a client pool.
+type BackendStatus struct {
+	// Host is the host of the backend.
+	Host string
+
+	// Healthy reports whether the backend answered its last request or
+	// health check.
+	Healthy bool
+
+	// LastErr is the error of the last failed request or health check of
+	// an unhealthy backend.
+	LastErr error
+}
+
+// poolBackend is a backend of a client pool.
+type poolBackend struct {
+	client *Client
+
+	mtx     sync.Mutex
+	healthy bool
+	lastErr error
+}
+
+// setHealth records the result of a request or a health check of the backend.
+func (b *poolBackend) setHealth(err error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if err == nil && !b.healthy {
+		log.Infof("RPC backend %s is healthy", b.client.config.Host)
+	} else if err != nil && b.healthy {
+		log.Warnf("RPC backend %s is unhealthy: %v",
+			b.client.config.Host, err)
+	}
+	b.healthy = err == nil
+	b.lastErr = err
+}
+
+// isHealthy returns whether the backend is considered healthy.
+func (b *poolBackend) isHealthy() bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	return b.healthy
+}
+
+// ClientPool distributes requests across multiple RPC servers.  Requests are
+// sent to the first healthy backend and fail over to the next one when the
+// backend can't be reached, which is detected by any error other than an error
+// returned by the server itself.  Unhealthy backends are periodically checked
+// and used again once they recover.
+//
+// The pool embeds a Client, so it provides all of its methods.  Notifications
+// are not supported since the backends run in HTTP POST mode.
+type ClientPool struct {
+	*Client
+
+	backends    []*poolBackend
+	healthCheck func(*Client) error
+	interval    time.Duration
+	roundRobin  bool
+
+	// next is the index of the backend the next balanced request is sent
+	// to first.
+	nextMtx sync.Mutex
+	next    int
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewClientPool creates a client pool for the passed backends and starts
+// checking their health.  All backends are initially considered healthy.
+func NewClientPool(cfg *PoolConfig) (*ClientPool, error) {
+	if len(cfg.Backends) == 0 {
+		return nil, errors.New("client pool requires at least one backend")
+	}
+	for _, backendCfg := range cfg.Backends {
+		if !backendCfg.HTTPPostMode {
+			return nil, errors.New("http post mode is required for " +
+				"the backends of a client pool")
+		}
+	}
+
+	p := &ClientPool{
+		healthCheck: cfg.HealthCheck,
+		interval:    cfg.HealthCheckInterval,
+		roundRobin:  cfg.RoundRobin,
+		quit:        make(chan struct{}),
+	}
+	if p.healthCheck == nil {
+		p.healthCheck = func(c *Client) error {
+			_, err := c.GetBlockCount()
+			return err
+		}
+	}
+	if p.interval <= 0 {
+		p.interval = defaultHealthCheckInterval
+	}
+
+	for _, backendCfg := range cfg.Backends {
+		client, err := New(backendCfg, nil)
+		if err != nil {
+			p.shutdownBackends()
+			return nil, err
+		}
+		p.backends = append(p.backends, &poolBackend{
+			client:  client,
+			healthy: true,
+		})
+	}
+
+	// The embedded client uses the configuration of the first backend,
+	// which determines the chain parameters, and hands all of its requests
+	// to the pool.
+	client, err := New(cfg.Backends[0], nil)
+	if err != nil {
+		p.shutdownBackends()
+		return nil, err
+	}
+	client.pool = p
+	p.Client = client
+
+	p.wg.Add(1)
+	go p.healthCheckHandler()
+
+	return p, nil
+}
+
+// isReadMethod returns whether requests of the passed method only read the
+// state of the server and can thus be sent to any backend.
+func isReadMethod(method string) bool {
+	if _, ok := poolWriteMethods[method]; ok {
+		return false
+	}
+	flags, err := btcjson.MethodUsageFlags(method)
+	if err != nil {
+		return false
+	}
+	return flags&btcjson.UFWalletOnly == 0
+}
+
+// candidates returns the backends to try for a request of the passed method
+// in order.  Healthy backends come first, starting at the next backend in
+// turn when the method is balanced.  Unhealthy backends are tried last.
+func (p *ClientPool) candidates(method string) []*poolBackend {
+	start := 0
+	if p.roundRobin && isReadMethod(method) {
+		p.nextMtx.Lock()
+		start = p.next
+		p.next = (p.next + 1) % len(p.backends)
+		p.nextMtx.Unlock()
+	}
+
+	healthy := make([]*poolBackend, 0, len(p.backends))
+	var unhealthy []*poolBackend
+	for i := range p.backends {
+		b := p.backends[(start+i)%len(p.backends)]
+		if b.isHealthy() {
+			healthy = append(healthy, b)
+		} else {
+			unhealthy = append(unhealthy, b)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// sendRequest sends the passed request to the backends without blocking.
+func (p *ClientPool) sendRequest(jReq *jsonRequest) {
+	select {
+	case <-p.quit:
+		jReq.responseChan <- &response{err: ErrClientShutdown}
+		return
+	default:
+	}
+
+	p.wg.Add(1)
+	go p.handleRequest(jReq)
+}
+
+// handleRequest sends the passed request to the backends in turn until one of
+// them answers it, and delivers the response.
+//
+// This function MUST be run as a goroutine.
+func (p *ClientPool) handleRequest(jReq *jsonRequest) {
+	defer p.wg.Done()
+
+	var resp *response
+	for _, b := range p.candidates(jReq.method) {
+		responseChan := make(chan *response, 1)
+		b.client.sendRequest(&jsonRequest{
+			id:             jReq.id,
+			method:         jReq.method,
+			cmd:            jReq.cmd,
+			marshalledJSON: jReq.marshalledJSON,
+			responseChan:   responseChan,
+		})
+		resp = <-responseChan
+
+		// Errors returned by the server mean the backend is reachable,
+		// so only other errors cause a failover.
+		if _, ok := resp.err.(*btcjson.RPCError); ok || resp.err == nil {
+			b.setHealth(nil)
+			break
+		}
+		b.setHealth(resp.err)
+		log.Debugf("Request %s to RPC backend %s failed: %v",
+			jReq.method, b.client.config.Host, resp.err)
+	}
+	jReq.responseChan <- resp
+}
+
+// healthCheckHandler periodically checks the health of all backends until the
+// pool is shut down.
+//
+// This function MUST be run as a goroutine.
+func (p *ClientPool) healthCheckHandler() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, b := range p.backends {
+				b.setHealth(p.healthCheck(b.client))
+			}
+
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// Status returns the state of all backends in order of preference.
+//
+// This function is safe for concurrent access.
+func (p *ClientPool) Status() []BackendStatus {
+	status := make([]BackendStatus, 0, len(p.backends))
+	for _, b := range p.backends {
+		b.mtx.Lock()
+		status = append(status, BackendStatus{
+			Host:    b.client.config.Host,
+			Healthy: b.healthy,
+			LastErr: b.lastErr,
+		})
+		b.mtx.Unlock()
+	}
+	return status
+}
+
+// shutdownBackends shuts down the clients of all backends.
+func (p *ClientPool) shutdownBackends() {
+	for _, b := range p.backends {
+		b.client.Shutdown()
+	}
+}
+
+// Shutdown stops checking the health of the backends and shuts down the
+// clients of the pool.  Pending requests fail with ErrClientShutdown.
+func (p *ClientPool) Shutdown() {
+	select {
+	case <-p.quit:
+		return
+	default:
+	}
+	close(p.quit)
+
+	p.Client.Shutdown()
+	p.shutdownBackends()
+}
+
+// WaitForShutdown blocks until the pool and all of its clients have shut down.
+func (p *ClientPool) WaitForShutdown() {
+	p.wg.Wait()
+	p.Client.WaitForShutdown()
+	for _, b := range p.backends {
+		b.client.WaitForShutdown()
+	}
+}
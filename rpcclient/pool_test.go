@@ -0,0 +1,163 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+// testPoolBackend is an RPC server which replies to every request with the
+// same response and counts the requests.
+type testPoolBackend struct {
+	server   *httptest.Server
+	requests int32
+}
+
+// newTestPoolBackend starts a test backend replying with the passed result
+// and error.
+func newTestPoolBackend(t *testing.T, result, rpcErr string) *testPoolBackend {
+	t.Helper()
+
+	b := &testPoolBackend{}
+	b.server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&b.requests, 1)
+			var req btcjson.Request
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("unable to decode request: %v", err)
+			}
+			id, _ := json.Marshal(req.ID)
+			w.Write([]byte(`{"result":` + result + `,"error":` +
+				rpcErr + `,"id":` + string(id) + `}`))
+		}))
+	t.Cleanup(b.server.Close)
+	return b
+}
+
+// connConfig returns the configuration to connect to the backend.
+func (b *testPoolBackend) connConfig() *ConnConfig {
+	return &ConnConfig{
+		Host:         strings.TrimPrefix(b.server.URL, "http://"),
+		User:         "user",
+		Pass:         "pass",
+		HTTPPostMode: true,
+		DisableTLS:   true,
+	}
+}
+
+// newTestPool returns a pool of the passed backends.
+func newTestPool(t *testing.T, roundRobin bool,
+	backends ...*testPoolBackend) *ClientPool {
+
+	t.Helper()
+
+	cfg := &PoolConfig{RoundRobin: roundRobin}
+	for _, b := range backends {
+		cfg.Backends = append(cfg.Backends, b.connConfig())
+	}
+	pool, err := NewClientPool(cfg)
+	if err != nil {
+		t.Fatalf("unable to create pool: %v", err)
+	}
+	t.Cleanup(func() {
+		pool.Shutdown()
+		pool.WaitForShutdown()
+	})
+	return pool
+}
+
+// TestClientPoolFailover ensures requests fail over to the next backend when a
+// backend is unreachable, but not when it returns an error.
+func TestClientPoolFailover(t *testing.T) {
+	down := newTestPoolBackend(t, "1", "null")
+	down.server.Close()
+	up := newTestPoolBackend(t, "100", "null")
+	pool := newTestPool(t, false, down, up)
+
+	count, err := pool.GetBlockCount()
+	if err != nil {
+		t.Fatalf("GetBlockCount failed: %v", err)
+	}
+	if count != 100 {
+		t.Fatalf("unexpected block count %d", count)
+	}
+	status := pool.Status()
+	if status[0].Healthy || status[0].LastErr == nil || !status[1].Healthy {
+		t.Fatalf("unexpected backend status: %+v", status)
+	}
+
+	// The unhealthy backend is tried last, so the next request goes
+	// straight to the healthy one.
+	if _, err := pool.GetBlockCount(); err != nil {
+		t.Fatalf("GetBlockCount failed: %v", err)
+	}
+	if got := atomic.LoadInt32(&up.requests); got != 2 {
+		t.Fatalf("unexpected number of requests: %d", got)
+	}
+
+	// Errors returned by a server are passed through.
+	failing := newTestPoolBackend(t, "null",
+		`{"code":-8,"message":"bad param"}`)
+	spare := newTestPoolBackend(t, "100", "null")
+	pool = newTestPool(t, false, failing, spare)
+	_, err = pool.GetBlockCount()
+	rpcErr, ok := err.(*btcjson.RPCError)
+	if !ok || rpcErr.Code != btcjson.ErrRPCInvalidParameter {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(&spare.requests); got != 0 {
+		t.Fatalf("request failed over on server error")
+	}
+}
+
+// TestClientPoolRoundRobin ensures read requests are balanced across backends
+// while write requests go to the first backend.
+func TestClientPoolRoundRobin(t *testing.T) {
+	first := newTestPoolBackend(t, "5", "null")
+	second := newTestPoolBackend(t, "5", "null")
+	pool := newTestPool(t, true, first, second)
+
+	for i := 0; i < 4; i++ {
+		if _, err := pool.GetBlockCount(); err != nil {
+			t.Fatalf("GetBlockCount failed: %v", err)
+		}
+	}
+	if atomic.LoadInt32(&first.requests) != 2 ||
+		atomic.LoadInt32(&second.requests) != 2 {
+
+		t.Fatalf("read requests not balanced")
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := pool.RawRequest("sendrawtransaction",
+			[]json.RawMessage{json.RawMessage(`"00"`)})
+		if err != nil {
+			t.Fatalf("sendrawtransaction failed: %v", err)
+		}
+	}
+	if atomic.LoadInt32(&first.requests) != 4 {
+		t.Fatalf("write requests not sent to the first backend")
+	}
+}
+
+// TestNewClientPoolErrors ensures invalid pool configurations are rejected.
+func TestNewClientPoolErrors(t *testing.T) {
+	if _, err := NewClientPool(&PoolConfig{}); err == nil {
+		t.Fatalf("expected error for pool without backends")
+	}
+	_, err := NewClientPool(&PoolConfig{
+		Backends: []*ConnConfig{{Host: "127.0.0.1:1"}},
+	})
+	if err == nil {
+		t.Fatalf("expected error for websocket backend")
+	}
+}
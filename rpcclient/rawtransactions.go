@@ -57,6 +57,11 @@ const (
 	// people to add inputs.  In addition, it uses the SigHashSingle signing
 	// method for outputs.
 	SigHashSingleAnyoneCanPay SigHashType = "SINGLE|ANYONECANPAY"
+
+	// SigHashDefault indicates the default signature hash type should be
+	// used, which is equivalent to ALL but omits the hash type from taproot
+	// signatures.
+	SigHashDefault SigHashType = "DEFAULT"
 )
 
 // String returns the SighHashType in human-readable form.
@@ -882,3 +887,98 @@ func (c *Client) DecodeScriptAsync(serializedScript []byte) FutureDecodeScriptRe
 func (c *Client) DecodeScript(serializedScript []byte) (*btcjson.DecodeScriptResult, error) {
 	return c.DecodeScriptAsync(serializedScript).Receive()
 }
+
+// FutureUtxoUpdatePsbtResult is a future promise to deliver the result of a
+// UtxoUpdatePsbtAsync RPC invocation (or an applicable error).
+type FutureUtxoUpdatePsbtResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// updated base64-encoded PSBT.
+func (r FutureUtxoUpdatePsbtResult) Receive() (string, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return "", err
+	}
+
+	// Unmarshal result as a string.
+	var psbt string
+	err = json.Unmarshal(res, &psbt)
+	if err != nil {
+		return "", err
+	}
+
+	return psbt, nil
+}
+
+// UtxoUpdatePsbtAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See UtxoUpdatePsbt for the blocking version and more details.
+func (c *Client) UtxoUpdatePsbtAsync(psbt string,
+	descriptors []btcjson.PsbtDescriptor) FutureUtxoUpdatePsbtResult {
+
+	var descs *[]btcjson.PsbtDescriptor
+	if len(descriptors) > 0 {
+		descs = &descriptors
+	}
+	cmd := btcjson.NewUtxoUpdatePsbtCmd(psbt, descs)
+	return c.sendCmd(cmd)
+}
+
+// UtxoUpdatePsbt updates the inputs of the passed base64-encoded PSBT with
+// the UTXOs they spend from the UTXO set or the memory pool, and with the
+// information provided by the passed descriptors, which may be nil.
+func (c *Client) UtxoUpdatePsbt(psbt string,
+	descriptors []btcjson.PsbtDescriptor) (string, error) {
+
+	return c.UtxoUpdatePsbtAsync(psbt, descriptors).Receive()
+}
+
+// FutureDescriptorProcessPsbtResult is a future promise to deliver the result
+// of a DescriptorProcessPsbtAsync RPC invocation (or an applicable error).
+type FutureDescriptorProcessPsbtResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// updated PSBT and whether the transaction has a complete set of signatures.
+func (r FutureDescriptorProcessPsbtResult) Receive() (*btcjson.DescriptorProcessPsbtResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a descriptorprocesspsbt result object.
+	var psbtRes btcjson.DescriptorProcessPsbtResult
+	err = json.Unmarshal(res, &psbtRes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &psbtRes, nil
+}
+
+// DescriptorProcessPsbtAsync returns an instance of a type that can be used
+// to get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See DescriptorProcessPsbt for the blocking version and more details.
+func (c *Client) DescriptorProcessPsbtAsync(psbt string,
+	descriptors []btcjson.PsbtDescriptor, sighashType SigHashType,
+	bip32Derivs, finalize *bool) FutureDescriptorProcessPsbtResult {
+
+	cmd := btcjson.NewDescriptorProcessPsbtCmd(psbt, descriptors,
+		btcjson.String(sighashType.String()), bip32Derivs, finalize)
+	return c.sendCmd(cmd)
+}
+
+// DescriptorProcessPsbt updates the passed base64-encoded PSBT with the
+// information provided by the passed descriptors and signs the inputs for
+// which the descriptors contain private keys.  Complete inputs are finalized
+// unless finalize is false.
+func (c *Client) DescriptorProcessPsbt(psbt string,
+	descriptors []btcjson.PsbtDescriptor, sighashType SigHashType,
+	bip32Derivs, finalize *bool) (*btcjson.DescriptorProcessPsbtResult, error) {
+
+	return c.DescriptorProcessPsbtAsync(psbt, descriptors, sighashType,
+		bip32Derivs, finalize).Receive()
+}
@@ -2405,6 +2405,80 @@ func (c *Client) ImportMulti(requests []btcjson.ImportMultiRequest, options *btc
 	return c.ImportMultiAsync(requests, options).Receive()
 }
 
+// FutureImportDescriptorsResult is a future promise to deliver the result of
+// an ImportDescriptorsAsync RPC invocation (or an applicable error).
+type FutureImportDescriptorsResult chan *response
+
+// Receive waits for the response promised by the future and returns the result
+// of importing the descriptors.
+func (r FutureImportDescriptorsResult) Receive() (btcjson.ImportDescriptorsResults, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var importDescriptorsResults btcjson.ImportDescriptorsResults
+	err = json.Unmarshal(res, &importDescriptorsResults)
+	if err != nil {
+		return nil, err
+	}
+	return importDescriptorsResults, nil
+}
+
+// ImportDescriptorsAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See ImportDescriptors for the blocking version and more details.
+func (c *Client) ImportDescriptorsAsync(requests []btcjson.ImportDescriptorsRequest) FutureImportDescriptorsResult {
+	cmd := btcjson.NewImportDescriptorsCmd(requests)
+	return c.sendCmd(cmd)
+}
+
+// ImportDescriptors imports descriptors into a descriptor wallet, rescanning
+// the blockchain from the earliest timestamp of the imported descriptors.
+//
+// See btcjson.ImportDescriptorsRequest for details on the requests parameter.
+func (c *Client) ImportDescriptors(requests []btcjson.ImportDescriptorsRequest) (btcjson.ImportDescriptorsResults, error) {
+	return c.ImportDescriptorsAsync(requests).Receive()
+}
+
+// FutureListDescriptorsResult is a future promise to deliver the result of a
+// ListDescriptorsAsync RPC invocation (or an applicable error).
+type FutureListDescriptorsResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// descriptors of the wallet.
+func (r FutureListDescriptorsResult) Receive() (*btcjson.ListDescriptorsResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var listDescriptorsResult btcjson.ListDescriptorsResult
+	err = json.Unmarshal(res, &listDescriptorsResult)
+	if err != nil {
+		return nil, err
+	}
+	return &listDescriptorsResult, nil
+}
+
+// ListDescriptorsAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See ListDescriptors for the blocking version and more details.
+func (c *Client) ListDescriptorsAsync(private bool) FutureListDescriptorsResult {
+	cmd := btcjson.NewListDescriptorsCmd(&private)
+	return c.sendCmd(cmd)
+}
+
+// ListDescriptors returns the descriptors of a descriptor wallet.  The
+// descriptors include their private keys when private is true.
+func (c *Client) ListDescriptors(private bool) (*btcjson.ListDescriptorsResult, error) {
+	return c.ListDescriptorsAsync(private).Receive()
+}
+
 // FutureImportPrivKeyResult is a future promise to deliver the result of an
 // ImportPrivKeyAsync RPC invocation (or an applicable error).
 type FutureImportPrivKeyResult chan *response
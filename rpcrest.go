@@ -0,0 +1,580 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// restPathPrefix is the path the REST interface is served under.
+	restPathPrefix = "/rest/"
+
+	// restMaxHeaders is the maximum number of headers or filter headers
+	// returned by a single REST request.
+	restMaxHeaders = 2000
+
+	// restDefaultHeaders is the number of headers or filter headers
+	// returned when the request doesn't specify a count.
+	restDefaultHeaders = 5
+)
+
+// restFormat identifies the format of a REST response, which is selected by
+// the extension of the requested path.
+type restFormat int
+
+// These constants define the supported formats of REST responses.
+const (
+	restFormatBinary restFormat = iota
+	restFormatHex
+	restFormatJSON
+)
+
+// restFormats maps the extensions of REST paths to the response format.
+var restFormats = map[string]restFormat{
+	"bin":  restFormatBinary,
+	"hex":  restFormatHex,
+	"json": restFormatJSON,
+}
+
+// restFilterTypes maps the filter type names used by the REST interface to the
+// committed filter types.
+var restFilterTypes = map[string]wire.FilterType{
+	"basic": wire.GCSFilterRegular,
+}
+
+// restHandler describes a callback function used to handle a REST request.
+// The passed parameters are the slash-separated parts of the path after the
+// name of the endpoint with the extension removed.  The binary and hex
+// formats expect the handler to return the serialized data as a byte slice,
+// while the JSON format expects a value which is marshalled as the response.
+type restHandler func(s *rpcServer, params []string, format restFormat,
+	query url.Values) (interface{}, error)
+
+// restHandlers maps the REST endpoints to the appropriate handler.  Endpoints
+// are matched by the longest prefix, so the order of this slice matters.
+var restHandlers = []struct {
+	prefix  string
+	handler restHandler
+}{
+	{"block/notxdetails/", handleRESTBlockNoTxDetails},
+	{"block/", handleRESTBlock},
+	{"blockfilter/", handleRESTBlockFilter},
+	{"blockfilterheaders/", handleRESTBlockFilterHeaders},
+	{"blockhashbyheight/", handleRESTBlockHashByHeight},
+	{"headers/", handleRESTHeaders},
+	{"tx/", handleRESTTx},
+}
+
+// restError is an error of a REST request which is returned to the client
+// along with its HTTP status code.
+type restError struct {
+	status int
+	msg    string
+}
+
+// Error satisfies the error interface.
+func (e *restError) Error() string {
+	return e.msg
+}
+
+// restBadRequest returns a REST error for a malformed request.
+func restBadRequest(format string, args ...interface{}) *restError {
+	return &restError{
+		status: http.StatusBadRequest,
+		msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+// restStatus returns the HTTP status code and message to return to a REST
+// client for the passed error, which is either a REST error or an error of
+// one of the RPC handlers the endpoints are built upon.
+func restStatus(err error) (int, string) {
+	switch e := err.(type) {
+	case *restError:
+		return e.status, e.msg
+
+	case *btcjson.RPCError:
+		switch e.Code {
+		case btcjson.ErrRPCBlockNotFound:
+			// This also covers the codes for missing transactions
+			// and a disabled index, which share the same value.
+			return http.StatusNotFound, e.Message
+
+		case btcjson.ErrRPCInvalidParameter,
+			btcjson.ErrRPCDecodeHexString,
+			btcjson.ErrRPCOutOfRange:
+
+			return http.StatusBadRequest, e.Message
+		}
+	}
+
+	return http.StatusInternalServerError, err.Error()
+}
+
+// restAllowedClient returns whether the client with the passed remote address
+// may use the REST interface.  The interface is unauthenticated, so only
+// clients connecting from the local machine are allowed.
+func restAllowedClient(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// restHandler handles requests to the unauthenticated REST interface.  The
+// endpoints mirror the ones of Bitcoin Core and serve read-only access to
+// blocks, headers, committed filters and transactions in binary, hex or JSON
+// format to clients connecting from the local machine.
+func (s *rpcServer) restHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Connection", "close")
+	r.Close = true
+
+	// Since the REST interface doesn't require the RPC credentials, it is
+	// not served to remote clients.
+	if !restAllowedClient(r.RemoteAddr) {
+		rpcsLog.Warnf("Rejected REST request from remote client %s",
+			r.RemoteAddr)
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	// Limit the number of connections to max allowed.
+	if s.limitConnections(w, r.RemoteAddr) {
+		return
+	}
+
+	// Keep track of the number of connected clients.
+	s.incrementClients()
+	defer s.decrementClients()
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Split the extension, which selects the format of the response, off
+	// the path of the request.
+	path := strings.TrimPrefix(r.URL.Path, restPathPrefix)
+	dot := strings.LastIndex(path, ".")
+	if dot == -1 {
+		http.Error(w, "Output format not found (available: bin, hex, "+
+			"json)", http.StatusNotFound)
+		return
+	}
+	format, ok := restFormats[path[dot+1:]]
+	if !ok {
+		http.Error(w, "Output format not found (available: bin, hex, "+
+			"json)", http.StatusNotFound)
+		return
+	}
+	path = path[:dot]
+
+	var handler restHandler
+	for _, h := range restHandlers {
+		if strings.HasPrefix(path, h.prefix) {
+			path = strings.TrimPrefix(path, h.prefix)
+			handler = h.handler
+			break
+		}
+	}
+	if handler == nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	result, err := handler(s, strings.Split(path, "/"), format,
+		r.URL.Query())
+	if err != nil {
+		status, msg := restStatus(err)
+		rpcsLog.Debugf("REST request %s from %s failed: %v",
+			r.URL.Path, r.RemoteAddr, msg)
+		http.Error(w, msg, status)
+		return
+	}
+
+	var resp []byte
+	switch format {
+	case restFormatBinary:
+		w.Header().Set("Content-Type", "application/octet-stream")
+		resp = result.([]byte)
+
+	case restFormatHex:
+		w.Header().Set("Content-Type", "text/plain")
+		resp = []byte(hex.EncodeToString(result.([]byte)) + "\n")
+
+	case restFormatJSON:
+		w.Header().Set("Content-Type", "application/json")
+		resp, err = json.Marshal(result)
+		if err != nil {
+			rpcsLog.Errorf("Failed to marshal REST response: %v", err)
+			http.Error(w, "Internal error",
+				http.StatusInternalServerError)
+			return
+		}
+		resp = append(resp, '\n')
+	}
+
+	if _, err := w.Write(resp); err != nil {
+		rpcsLog.Errorf("Failed to write REST response: %v", err)
+	}
+}
+
+// restParseHash returns the hash encoded by the passed parameter.
+func restParseHash(param string) (*chainhash.Hash, error) {
+	hash, err := chainhash.NewHashFromStr(param)
+	if err != nil || len(param) != chainhash.MaxHashStringSize {
+		return nil, restBadRequest("Invalid hash: %s", param)
+	}
+	return hash, nil
+}
+
+// restParseCount returns the number of headers or filter headers requested by
+// the passed parameter.
+func restParseCount(param string) (int32, error) {
+	if param == "" {
+		return restDefaultHeaders, nil
+	}
+	count, err := strconv.ParseInt(param, 10, 32)
+	if err != nil || count < 1 || count > restMaxHeaders {
+		return 0, restBadRequest("Header count is invalid or out of "+
+			"acceptable range (1-%d): %s", restMaxHeaders, param)
+	}
+	return int32(count), nil
+}
+
+// restHeaderHashes returns the hashes of up to count blocks of the main chain
+// starting with the passed block.  Only the passed block is returned when it
+// is not part of the main chain.
+func restHeaderHashes(s *rpcServer, hash *chainhash.Hash,
+	count int32) ([]chainhash.Hash, error) {
+
+	chain := s.cfg.Chain
+	if !chain.MainChainHasBlock(hash) {
+		if _, err := chain.HeaderByHash(hash); err != nil {
+			return nil, &restError{
+				status: http.StatusNotFound,
+				msg:    fmt.Sprintf("%s not found", hash),
+			}
+		}
+		return []chainhash.Hash{*hash}, nil
+	}
+
+	height, err := chain.BlockHeightByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	return chain.HeightRange(height, height+count)
+}
+
+// handleRESTBlock handles the block endpoint, which returns the block with the
+// hash given by the path.  The JSON format includes the details of the
+// transactions.
+func handleRESTBlock(s *rpcServer, params []string, format restFormat,
+	query url.Values) (interface{}, error) {
+
+	return restBlock(s, params, format, 2)
+}
+
+// handleRESTBlockNoTxDetails handles the block/notxdetails endpoint, which is
+// the same as the block endpoint except that the JSON format only includes the
+// hashes of the transactions.
+func handleRESTBlockNoTxDetails(s *rpcServer, params []string,
+	format restFormat, query url.Values) (interface{}, error) {
+
+	return restBlock(s, params, format, 1)
+}
+
+// restBlock returns the block with the hash given by the passed parameters
+// using the getblock RPC with the passed verbosity for the JSON format.
+func restBlock(s *rpcServer, params []string, format restFormat,
+	verbosity int) (interface{}, error) {
+
+	if len(params) != 1 {
+		return nil, restBadRequest("Invalid URI format. Expected " +
+			"/rest/block/<hash>.<ext>")
+	}
+	hash, err := restParseHash(params[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if format != restFormatJSON {
+		verbosity = 0
+	}
+	c := &btcjson.GetBlockCmd{Hash: hash.String(), Verbosity: &verbosity}
+	result, err := handleGetBlock(s, c, nil)
+	if err != nil {
+		return nil, err
+	}
+	if format == restFormatJSON {
+		return result, nil
+	}
+	return hex.DecodeString(result.(string))
+}
+
+// handleRESTHeaders handles the headers endpoint, which returns the header of
+// the block with the hash given by the path along with the headers of the
+// blocks following it in the main chain.  The number of headers is given by
+// the count query parameter, or by the deprecated form of the path which
+// includes the count before the hash.
+func handleRESTHeaders(s *rpcServer, params []string, format restFormat,
+	query url.Values) (interface{}, error) {
+
+	var hashParam, countParam string
+	switch len(params) {
+	case 1:
+		hashParam, countParam = params[0], query.Get("count")
+	case 2:
+		hashParam, countParam = params[1], params[0]
+	default:
+		return nil, restBadRequest("Invalid URI format. Expected " +
+			"/rest/headers/<hash>.<ext>?count=<count>")
+	}
+	hash, err := restParseHash(hashParam)
+	if err != nil {
+		return nil, err
+	}
+	count, err := restParseCount(countParam)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes, err := restHeaderHashes(s, hash, count)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == restFormatJSON {
+		verbose := true
+		headers := make([]interface{}, 0, len(hashes))
+		for i := range hashes {
+			c := &btcjson.GetBlockHeaderCmd{
+				Hash:    hashes[i].String(),
+				Verbose: &verbose,
+			}
+			header, err := handleGetBlockHeader(s, c, nil)
+			if err != nil {
+				return nil, err
+			}
+			headers = append(headers, header)
+		}
+		return headers, nil
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(hashes) * wire.MaxBlockHeaderPayload)
+	for i := range hashes {
+		header, err := s.cfg.Chain.HeaderByHash(&hashes[i])
+		if err != nil {
+			return nil, err
+		}
+		if err := header.Serialize(&buf); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// handleRESTBlockHashByHeight handles the blockhashbyheight endpoint, which
+// returns the hash of the block at the height given by the path in the main
+// chain.
+func handleRESTBlockHashByHeight(s *rpcServer, params []string,
+	format restFormat, query url.Values) (interface{}, error) {
+
+	if len(params) != 1 {
+		return nil, restBadRequest("Invalid URI format. Expected " +
+			"/rest/blockhashbyheight/<height>.<ext>")
+	}
+	height, err := strconv.ParseInt(params[0], 10, 32)
+	if err != nil || height < 0 {
+		return nil, restBadRequest("Invalid height: %s", params[0])
+	}
+
+	hash, err := s.cfg.Chain.BlockHashByHeight(int32(height))
+	if err != nil {
+		return nil, &restError{
+			status: http.StatusNotFound,
+			msg:    "Block height out of range",
+		}
+	}
+
+	if format == restFormatJSON {
+		return map[string]string{"blockhash": hash.String()}, nil
+	}
+	return hash[:], nil
+}
+
+// restFilterType returns the committed filter type given by the passed
+// parameter and ensures the committed filter index is enabled.
+func restFilterType(s *rpcServer, param string) (wire.FilterType, error) {
+	filterType, ok := restFilterTypes[param]
+	if !ok {
+		return 0, restBadRequest("Unknown filter type: %s", param)
+	}
+	if s.cfg.CfIndex == nil {
+		return 0, &restError{
+			status: http.StatusServiceUnavailable,
+			msg:    "The CF index must be enabled for this endpoint",
+		}
+	}
+	return filterType, nil
+}
+
+// handleRESTBlockFilter handles the blockfilter endpoint, which returns the
+// committed filter of the given type for the block with the hash given by the
+// path.  The binary format is the serialization used by Bitcoin Core, which
+// consists of the block hash, the filter type and the filter.
+func handleRESTBlockFilter(s *rpcServer, params []string, format restFormat,
+	query url.Values) (interface{}, error) {
+
+	if len(params) != 2 {
+		return nil, restBadRequest("Invalid URI format. Expected " +
+			"/rest/blockfilter/<filtertype>/<hash>.<ext>")
+	}
+	filterType, err := restFilterType(s, params[0])
+	if err != nil {
+		return nil, err
+	}
+	hash, err := restParseHash(params[1])
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := s.cfg.CfIndex.FilterByBlockHash(hash, filterType)
+	if err != nil || filter == nil {
+		return nil, &restError{
+			status: http.StatusNotFound,
+			msg:    fmt.Sprintf("Filter for %s not found", hash),
+		}
+	}
+
+	if format == restFormatJSON {
+		return map[string]string{"filter": hex.EncodeToString(filter)},
+			nil
+	}
+
+	var buf bytes.Buffer
+	buf.Write(hash[:])
+	buf.WriteByte(byte(filterType))
+	if err := wire.WriteVarBytes(&buf, 0, filter); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// handleRESTBlockFilterHeaders handles the blockfilterheaders endpoint, which
+// returns the committed filter header of the given type for the block with the
+// hash given by the path along with the filter headers of the blocks following
+// it in the main chain.  The number of filter headers is given by the count
+// query parameter, or by the deprecated form of the path which includes the
+// count before the hash.
+func handleRESTBlockFilterHeaders(s *rpcServer, params []string,
+	format restFormat, query url.Values) (interface{}, error) {
+
+	var hashParam, countParam string
+	switch len(params) {
+	case 2:
+		hashParam, countParam = params[1], query.Get("count")
+	case 3:
+		hashParam, countParam = params[2], params[1]
+	default:
+		return nil, restBadRequest("Invalid URI format. Expected " +
+			"/rest/blockfilterheaders/<filtertype>/<hash>.<ext>" +
+			"?count=<count>")
+	}
+	filterType, err := restFilterType(s, params[0])
+	if err != nil {
+		return nil, err
+	}
+	hash, err := restParseHash(hashParam)
+	if err != nil {
+		return nil, err
+	}
+	count, err := restParseCount(countParam)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes, err := restHeaderHashes(s, hash, count)
+	if err != nil {
+		return nil, err
+	}
+	hashPtrs := make([]*chainhash.Hash, 0, len(hashes))
+	for i := range hashes {
+		hashPtrs = append(hashPtrs, &hashes[i])
+	}
+	filterHeaders, err := s.cfg.CfIndex.FilterHeadersByBlockHashes(
+		hashPtrs, filterType)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == restFormatJSON {
+		headers := make([]string, 0, len(filterHeaders))
+		for _, headerBytes := range filterHeaders {
+			if len(headerBytes) == 0 {
+				break
+			}
+			var header chainhash.Hash
+			header.SetBytes(headerBytes)
+			headers = append(headers, header.String())
+		}
+		return headers, nil
+	}
+
+	buf := make([]byte, 0, len(filterHeaders)*chainhash.HashSize)
+	for _, headerBytes := range filterHeaders {
+		if len(headerBytes) == 0 {
+			break
+		}
+		buf = append(buf, headerBytes...)
+	}
+	return buf, nil
+}
+
+// handleRESTTx handles the tx endpoint, which returns the transaction with the
+// hash given by the path.  Transactions which are not in the memory pool are
+// only available when the transaction index is enabled.
+func handleRESTTx(s *rpcServer, params []string, format restFormat,
+	query url.Values) (interface{}, error) {
+
+	if len(params) != 1 {
+		return nil, restBadRequest("Invalid URI format. Expected " +
+			"/rest/tx/<txid>.<ext>")
+	}
+	hash, err := restParseHash(params[0])
+	if err != nil {
+		return nil, err
+	}
+
+	verbose := 0
+	if format == restFormatJSON {
+		verbose = 1
+	}
+	c := &btcjson.GetRawTransactionCmd{Txid: hash.String(), Verbose: &verbose}
+	result, err := handleGetRawTransaction(s, c, nil)
+	if err != nil {
+		return nil, err
+	}
+	if format == restFormatJSON {
+		return result, nil
+	}
+	return hex.DecodeString(result.(string))
+}
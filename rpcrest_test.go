@@ -0,0 +1,580 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/blockchain/indexers"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/database"
+	_ "github.com/btcsuite/btcd/database/ffldb"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// restTestBlocks returns a chain of the passed number of blocks with valid
+// proof of work on the passed params which builds on the genesis block.  Each
+// block only contains a coinbase paying to an anyone-can-spend script.
+func restTestBlocks(params *chaincfg.Params, num int) []*btcutil.Block {
+	prev := btcutil.NewBlock(params.GenesisBlock)
+	prev.SetHeight(0)
+
+	blocks := make([]*btcutil.Block, 0, num)
+	for i := 0; i < num; i++ {
+		height := prev.Height() + 1
+		sigScript, err := txscript.NewScriptBuilder().
+			AddInt64(int64(height)).AddInt64(0).Script()
+		if err != nil {
+			panic(err)
+		}
+		coinbase := wire.NewMsgTx(1)
+		coinbase.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{},
+				wire.MaxPrevOutIndex),
+			SignatureScript: sigScript,
+			Sequence:        wire.MaxTxInSequenceNum,
+		})
+		coinbase.AddTxOut(wire.NewTxOut(blockchain.CalcBlockSubsidy(
+			height, params), []byte{txscript.OP_TRUE}))
+
+		txns := []*btcutil.Tx{btcutil.NewTx(coinbase)}
+		merkles := blockchain.BuildMerkleTreeStore(txns, false)
+		msgBlock := wire.NewMsgBlock(&wire.BlockHeader{
+			Version:    4,
+			PrevBlock:  *prev.Hash(),
+			MerkleRoot: *merkles[len(merkles)-1],
+			Timestamp: prev.MsgBlock().Header.Timestamp.Add(
+				time.Minute),
+			Bits: params.PowLimitBits,
+		})
+		msgBlock.AddTransaction(coinbase)
+		for blockchain.CheckProofOfWork(btcutil.NewBlock(msgBlock),
+			params.PowLimit) != nil {
+
+			msgBlock.Header.Nonce++
+		}
+
+		block := btcutil.NewBlock(msgBlock)
+		block.SetHeight(height)
+		blocks = append(blocks, block)
+		prev = block
+	}
+	return blocks
+}
+
+// newRESTTestServer returns an RPC server for a chain on the regression test
+// network with the passed blocks and the transaction and committed filter
+// indexes enabled, along with a function to tear it down.
+func newRESTTestServer(t *testing.T, blocks []*btcutil.Block) (*rpcServer, func()) {
+	t.Helper()
+
+	params := &chaincfg.RegressionNetParams
+	dbPath, err := ioutil.TempDir("", "rpcresttest")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	db, err := database.Create("ffldb", filepath.Join(dbPath, "db"),
+		params.Net)
+	if err != nil {
+		os.RemoveAll(dbPath)
+		t.Fatalf("unable to create database: %v", err)
+	}
+
+	txIndex := indexers.NewTxIndex(db)
+	cfIndex := indexers.NewCfIndex(db, params)
+	indexManager := indexers.NewManager(db,
+		[]indexers.Indexer{txIndex, cfIndex})
+	teardown := func() {
+		indexManager.Stop()
+		db.Close()
+		os.RemoveAll(dbPath)
+	}
+
+	timeSource := blockchain.NewMedianTime()
+	chain, err := blockchain.New(&blockchain.Config{
+		DB:           db,
+		ChainParams:  params,
+		TimeSource:   timeSource,
+		IndexManager: indexManager,
+	})
+	if err != nil {
+		teardown()
+		t.Fatalf("unable to create chain: %v", err)
+	}
+	for _, block := range blocks {
+		_, _, err := chain.ProcessBlock(block, blockchain.BFNone)
+		if err != nil {
+			teardown()
+			t.Fatalf("unable to process block %d: %v",
+				block.Height(), err)
+		}
+	}
+
+	// The committed filter index is built in the background.
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		height, bestHeight, err := cfIndex.BuildProgress()
+		if err != nil {
+			teardown()
+			t.Fatalf("unable to get cf index progress: %v", err)
+		}
+		if height == bestHeight {
+			break
+		}
+		if time.Now().After(deadline) {
+			teardown()
+			t.Fatalf("cf index did not catch up")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	s := &rpcServer{
+		cfg: rpcserverConfig{
+			TimeSource:  timeSource,
+			Chain:       chain,
+			ChainParams: params,
+			DB:          db,
+			TxMemPool:   mempool.New(&mempool.Config{}),
+			TxIndex:     txIndex,
+			CfIndex:     cfIndex,
+		},
+	}
+	return s, teardown
+}
+
+// setupRESTTest sets the configuration used by the REST interface and turns off
+// the logging of all subsystems, which requires an initialized log rotator.  It
+// returns a function which restores the configuration and the default log
+// level.
+func setupRESTTest() func() {
+	origCfg := cfg
+	cfg = &config{RPCMaxClients: 10}
+	setLogLevels("off")
+
+	return func() {
+		cfg = origCfg
+		setLogLevels(defaultLogLevel)
+	}
+}
+
+// restRequest serves a REST request for the passed path with the passed method
+// from a local client using the passed server.
+func restRequest(s *rpcServer, method, path string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	r.RemoteAddr = "127.0.0.1:50000"
+	w := httptest.NewRecorder()
+	s.restHandler(w, r)
+	return w
+}
+
+// restJSON returns the JSON response expected for the passed result.
+func restJSON(t *testing.T, result interface{}) string {
+	t.Helper()
+
+	resp, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("unable to marshal result: %v", err)
+	}
+	return string(resp) + "\n"
+}
+
+// TestRESTHandlers ensures the endpoints of the REST interface return the
+// expected responses in all formats and reject malformed requests.
+func TestRESTHandlers(t *testing.T) {
+	defer setupRESTTest()()
+
+	params := &chaincfg.RegressionNetParams
+	blocks := restTestBlocks(params, 3)
+	s, teardown := newRESTTestServer(t, blocks)
+	defer teardown()
+
+	genesisHash := params.GenesisHash.String()
+	block := blocks[0]
+	blockHash := block.Hash().String()
+	blockBytes, err := block.Bytes()
+	if err != nil {
+		t.Fatalf("unable to serialize block: %v", err)
+	}
+	tx := block.Transactions()[0]
+	txHash := tx.Hash().String()
+	var txBuf bytes.Buffer
+	if err := tx.MsgTx().Serialize(&txBuf); err != nil {
+		t.Fatalf("unable to serialize transaction: %v", err)
+	}
+	unknownHash := strings.Repeat("ab", chainhash.HashSize)
+
+	// The expected JSON responses are the ones of the RPCs the endpoints
+	// are built upon.
+	verbosity := 2
+	blockJSON, err := handleGetBlock(s, &btcjson.GetBlockCmd{
+		Hash: blockHash, Verbosity: &verbosity}, nil)
+	if err != nil {
+		t.Fatalf("getblock: unexpected error: %v", err)
+	}
+	verbosity = 1
+	blockNoTxDetailsJSON, err := handleGetBlock(s, &btcjson.GetBlockCmd{
+		Hash: blockHash, Verbosity: &verbosity}, nil)
+	if err != nil {
+		t.Fatalf("getblock: unexpected error: %v", err)
+	}
+	txJSON, err := handleGetRawTransaction(s, &btcjson.GetRawTransactionCmd{
+		Txid: txHash, Verbose: &verbosity}, nil)
+	if err != nil {
+		t.Fatalf("getrawtransaction: unexpected error: %v", err)
+	}
+	verbose := true
+	var headersJSON []interface{}
+	var headers bytes.Buffer
+	for _, hash := range []*chainhash.Hash{params.GenesisHash, block.Hash()} {
+		header, err := handleGetBlockHeader(s,
+			&btcjson.GetBlockHeaderCmd{Hash: hash.String(),
+				Verbose: &verbose}, nil)
+		if err != nil {
+			t.Fatalf("getblockheader: unexpected error: %v", err)
+		}
+		headersJSON = append(headersJSON, header)
+	}
+	params.GenesisBlock.Header.Serialize(&headers)
+	block.MsgBlock().Header.Serialize(&headers)
+	var allHeaders bytes.Buffer
+	params.GenesisBlock.Header.Serialize(&allHeaders)
+	for _, b := range blocks {
+		b.MsgBlock().Header.Serialize(&allHeaders)
+	}
+
+	filter, err := s.cfg.CfIndex.FilterByBlockHash(block.Hash(),
+		wire.GCSFilterRegular)
+	if err != nil || len(filter) == 0 {
+		t.Fatalf("unable to fetch filter: %v", err)
+	}
+	var filterBuf bytes.Buffer
+	filterBuf.Write(block.Hash()[:])
+	filterBuf.WriteByte(byte(wire.GCSFilterRegular))
+	wire.WriteVarBytes(&filterBuf, 0, filter)
+	filterHeaders, err := s.cfg.CfIndex.FilterHeadersByBlockHashes(
+		[]*chainhash.Hash{params.GenesisHash, block.Hash()},
+		wire.GCSFilterRegular)
+	if err != nil {
+		t.Fatalf("unable to fetch filter headers: %v", err)
+	}
+	var filterHeaderStrs []string
+	for _, headerBytes := range filterHeaders {
+		var header chainhash.Hash
+		header.SetBytes(headerBytes)
+		filterHeaderStrs = append(filterHeaderStrs, header.String())
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		body   string
+	}{{
+		name:   "block binary",
+		path:   "/rest/block/" + blockHash + ".bin",
+		status: http.StatusOK,
+		body:   string(blockBytes),
+	}, {
+		name:   "block hex",
+		path:   "/rest/block/" + blockHash + ".hex",
+		status: http.StatusOK,
+		body:   hex.EncodeToString(blockBytes) + "\n",
+	}, {
+		name:   "block json",
+		path:   "/rest/block/" + blockHash + ".json",
+		status: http.StatusOK,
+		body:   restJSON(t, blockJSON),
+	}, {
+		name:   "block without tx details binary",
+		path:   "/rest/block/notxdetails/" + blockHash + ".bin",
+		status: http.StatusOK,
+		body:   string(blockBytes),
+	}, {
+		name:   "block without tx details json",
+		path:   "/rest/block/notxdetails/" + blockHash + ".json",
+		status: http.StatusOK,
+		body:   restJSON(t, blockNoTxDetailsJSON),
+	}, {
+		name:   "block with malformed hash",
+		path:   "/rest/block/" + blockHash[:63] + "g.bin",
+		status: http.StatusBadRequest,
+	}, {
+		name:   "block with short hash",
+		path:   "/rest/block/" + blockHash[:62] + ".bin",
+		status: http.StatusBadRequest,
+	}, {
+		name:   "block with extra path",
+		path:   "/rest/block/" + blockHash + "/1.bin",
+		status: http.StatusBadRequest,
+	}, {
+		name:   "unknown block",
+		path:   "/rest/block/" + unknownHash + ".bin",
+		status: http.StatusNotFound,
+	}, {
+		name:   "unknown format",
+		path:   "/rest/block/" + blockHash + ".xml",
+		status: http.StatusNotFound,
+	}, {
+		name:   "missing format",
+		path:   "/rest/block/" + blockHash,
+		status: http.StatusNotFound,
+	}, {
+		name:   "unknown endpoint",
+		path:   "/rest/mempool/contents.json",
+		status: http.StatusNotFound,
+	}, {
+		name:   "other method",
+		method: http.MethodPost,
+		path:   "/rest/block/" + blockHash + ".bin",
+		status: http.StatusMethodNotAllowed,
+	}, {
+		name:   "headers binary",
+		path:   "/rest/headers/" + genesisHash + ".bin?count=2",
+		status: http.StatusOK,
+		body:   headers.String(),
+	}, {
+		name:   "headers hex with default count past the tip",
+		path:   "/rest/headers/" + genesisHash + ".hex",
+		status: http.StatusOK,
+		body:   hex.EncodeToString(allHeaders.Bytes()) + "\n",
+	}, {
+		name:   "headers json",
+		path:   "/rest/headers/" + genesisHash + ".json?count=2",
+		status: http.StatusOK,
+		body:   restJSON(t, headersJSON),
+	}, {
+		name:   "headers with deprecated count",
+		path:   "/rest/headers/2/" + genesisHash + ".bin",
+		status: http.StatusOK,
+		body:   headers.String(),
+	}, {
+		name:   "headers with zero count",
+		path:   "/rest/headers/" + genesisHash + ".bin?count=0",
+		status: http.StatusBadRequest,
+	}, {
+		name:   "headers with excessive count",
+		path:   "/rest/headers/" + genesisHash + ".bin?count=2001",
+		status: http.StatusBadRequest,
+	}, {
+		name:   "headers with malformed count",
+		path:   "/rest/headers/" + genesisHash + ".bin?count=two",
+		status: http.StatusBadRequest,
+	}, {
+		name:   "headers with malformed deprecated count",
+		path:   "/rest/headers/-1/" + genesisHash + ".bin",
+		status: http.StatusBadRequest,
+	}, {
+		name:   "headers with malformed hash",
+		path:   "/rest/headers/" + genesisHash[1:] + ".bin",
+		status: http.StatusBadRequest,
+	}, {
+		name:   "headers of unknown block",
+		path:   "/rest/headers/" + unknownHash + ".bin",
+		status: http.StatusNotFound,
+	}, {
+		name:   "block hash by height binary",
+		path:   "/rest/blockhashbyheight/1.bin",
+		status: http.StatusOK,
+		body:   string(block.Hash()[:]),
+	}, {
+		name:   "block hash by height hex",
+		path:   "/rest/blockhashbyheight/1.hex",
+		status: http.StatusOK,
+		body:   hex.EncodeToString(block.Hash()[:]) + "\n",
+	}, {
+		name:   "block hash by height json",
+		path:   "/rest/blockhashbyheight/1.json",
+		status: http.StatusOK,
+		body:   `{"blockhash":"` + blockHash + `"}` + "\n",
+	}, {
+		name:   "block hash by height past the tip",
+		path:   "/rest/blockhashbyheight/4.bin",
+		status: http.StatusNotFound,
+	}, {
+		name:   "block hash by negative height",
+		path:   "/rest/blockhashbyheight/-1.bin",
+		status: http.StatusBadRequest,
+	}, {
+		name:   "block hash by malformed height",
+		path:   "/rest/blockhashbyheight/one.bin",
+		status: http.StatusBadRequest,
+	}, {
+		name:   "block filter binary",
+		path:   "/rest/blockfilter/basic/" + blockHash + ".bin",
+		status: http.StatusOK,
+		body:   filterBuf.String(),
+	}, {
+		name:   "block filter hex",
+		path:   "/rest/blockfilter/basic/" + blockHash + ".hex",
+		status: http.StatusOK,
+		body:   hex.EncodeToString(filterBuf.Bytes()) + "\n",
+	}, {
+		name:   "block filter json",
+		path:   "/rest/blockfilter/basic/" + blockHash + ".json",
+		status: http.StatusOK,
+		body:   `{"filter":"` + hex.EncodeToString(filter) + `"}` + "\n",
+	}, {
+		name:   "block filter of unknown type",
+		path:   "/rest/blockfilter/extended/" + blockHash + ".bin",
+		status: http.StatusBadRequest,
+	}, {
+		name:   "block filter without type",
+		path:   "/rest/blockfilter/" + blockHash + ".bin",
+		status: http.StatusBadRequest,
+	}, {
+		name:   "block filter with malformed hash",
+		path:   "/rest/blockfilter/basic/" + blockHash[:60] + ".bin",
+		status: http.StatusBadRequest,
+	}, {
+		name:   "block filter of unknown block",
+		path:   "/rest/blockfilter/basic/" + unknownHash + ".bin",
+		status: http.StatusNotFound,
+	}, {
+		name: "block filter headers binary",
+		path: "/rest/blockfilterheaders/basic/" + genesisHash +
+			".bin?count=2",
+		status: http.StatusOK,
+		body:   string(filterHeaders[0]) + string(filterHeaders[1]),
+	}, {
+		name: "block filter headers json",
+		path: "/rest/blockfilterheaders/basic/" + genesisHash +
+			".json?count=2",
+		status: http.StatusOK,
+		body:   restJSON(t, filterHeaderStrs),
+	}, {
+		name: "block filter headers with deprecated count",
+		path: "/rest/blockfilterheaders/basic/2/" + genesisHash +
+			".hex",
+		status: http.StatusOK,
+		body: hex.EncodeToString(append(filterHeaders[0],
+			filterHeaders[1]...)) + "\n",
+	}, {
+		name: "block filter headers with excessive count",
+		path: "/rest/blockfilterheaders/basic/" + genesisHash +
+			".bin?count=2001",
+		status: http.StatusBadRequest,
+	}, {
+		name: "block filter headers with malformed hash",
+		path: "/rest/blockfilterheaders/basic/" + genesisHash[2:] +
+			".bin",
+		status: http.StatusBadRequest,
+	}, {
+		name:   "transaction binary",
+		path:   "/rest/tx/" + txHash + ".bin",
+		status: http.StatusOK,
+		body:   txBuf.String(),
+	}, {
+		name:   "transaction hex",
+		path:   "/rest/tx/" + txHash + ".hex",
+		status: http.StatusOK,
+		body:   hex.EncodeToString(txBuf.Bytes()) + "\n",
+	}, {
+		name:   "transaction json",
+		path:   "/rest/tx/" + txHash + ".json",
+		status: http.StatusOK,
+		body:   restJSON(t, txJSON),
+	}, {
+		name:   "transaction with malformed hash",
+		path:   "/rest/tx/xyz.bin",
+		status: http.StatusBadRequest,
+	}, {
+		name:   "unknown transaction",
+		path:   "/rest/tx/" + unknownHash + ".bin",
+		status: http.StatusNotFound,
+	}}
+
+	for _, test := range tests {
+		method := test.method
+		if method == "" {
+			method = http.MethodGet
+		}
+		w := restRequest(s, method, test.path)
+		if w.Code != test.status {
+			t.Errorf("%s: got status %d, want %d: %s", test.name,
+				w.Code, test.status, w.Body.String())
+			continue
+		}
+		if test.status != http.StatusOK {
+			continue
+		}
+		if got := w.Body.String(); got != test.body {
+			t.Errorf("%s: got body %q, want %q", test.name, got,
+				test.body)
+		}
+	}
+
+	// The filter endpoints are unavailable without the committed filter
+	// index.
+	s.cfg.CfIndex = nil
+	w := restRequest(s, http.MethodGet,
+		"/rest/blockfilter/basic/"+blockHash+".bin")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("block filter without cf index: got status %d",
+			w.Code)
+	}
+}
+
+// TestRESTRemoteClients ensures the REST interface is only served to clients
+// connecting from a loopback address.
+func TestRESTRemoteClients(t *testing.T) {
+	defer setupRESTTest()()
+
+	params := &chaincfg.RegressionNetParams
+	s, teardown := newRESTTestServer(t, nil)
+	defer teardown()
+
+	tests := []struct {
+		remoteAddr string
+		allowed    bool
+	}{
+		{remoteAddr: "127.0.0.1:50000", allowed: true},
+		{remoteAddr: "127.1.2.3:50000", allowed: true},
+		{remoteAddr: "[::1]:50000", allowed: true},
+		{remoteAddr: "192.168.1.1:50000", allowed: false},
+		{remoteAddr: "[2001:db8::1]:50000", allowed: false},
+		{remoteAddr: "[::ffff:10.0.0.1]:50000", allowed: false},
+		{remoteAddr: "localhost:50000", allowed: false},
+		{remoteAddr: "127.0.0.1", allowed: false},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/rest/blockhashbyheight/0.hex", nil)
+		r.RemoteAddr = test.remoteAddr
+		w := httptest.NewRecorder()
+		s.restHandler(w, r)
+
+		wantStatus := http.StatusForbidden
+		if test.allowed {
+			wantStatus = http.StatusOK
+		}
+		if w.Code != wantStatus {
+			t.Errorf("%s: got status %d, want %d", test.remoteAddr,
+				w.Code, wantStatus)
+			continue
+		}
+		want := hex.EncodeToString(params.GenesisHash[:]) + "\n"
+		if test.allowed && w.Body.String() != want {
+			t.Errorf("%s: unexpected body %q", test.remoteAddr,
+				w.Body.String())
+		}
+	}
+}
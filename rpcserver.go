@@ -7,8 +7,6 @@ package main
 
 import (
 	"bytes"
-	"crypto/sha256"
-	"crypto/subtle"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
@@ -21,6 +19,8 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -37,8 +37,10 @@ import (
 	"github.com/btcsuite/btcd/mempool"
 	"github.com/btcsuite/btcd/mining"
 	"github.com/btcsuite/btcd/mining/cpuminer"
+	"github.com/btcsuite/btcd/netsync"
 	"github.com/btcsuite/btcd/peer"
 	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/txscript/descriptor"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/websocket"
@@ -75,6 +77,10 @@ const (
 
 	// maxProtocolVersion is the max protocol version the server supports.
 	maxProtocolVersion = 70002
+
+	// maxTweakBlockRange is the maximum number of blocks the tweaks can be
+	// requested for with a single getsilentpaymenttweaks command.
+	maxTweakBlockRange = 1000
 )
 
 var (
@@ -86,6 +92,26 @@ var (
 		"time", "transactions/add", "prevblock", "coinbase/append",
 	}
 
+	// gbtKnownMutations are all of the mutations defined by BIP 0023.  They
+	// are used to tell the mutations a caller of the getblocktemplate RPC
+	// supports apart from its other capabilities.
+	gbtKnownMutations = map[string]struct{}{
+		"coinbase":         {},
+		"coinbase/append":  {},
+		"generation":       {},
+		"prevblock":        {},
+		"submit/coinbase":  {},
+		"submit/hash":      {},
+		"submit/truncate":  {},
+		"time":             {},
+		"time/decrement":   {},
+		"time/increment":   {},
+		"transactions":     {},
+		"transactions/add": {},
+		"version/force":    {},
+		"version/reduce":   {},
+	}
+
 	// gbtCoinbaseAux describes additional data that miners should include
 	// in the coinbase signature script.  It is declared here to avoid the
 	// overhead of creating a new object on every invocation for constant
@@ -135,7 +161,9 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"debuglevel":             handleDebugLevel,
 	"decoderawtransaction":   handleDecodeRawTransaction,
 	"decodescript":           handleDecodeScript,
+	"dumptxoutset":           handleDumpTxOutSet,
 	"estimatefee":            handleEstimateFee,
+	"estimatesmartfee":       handleEstimateSmartFee,
 	"generate":               handleGenerate,
 	"getaddednodeinfo":       handleGetAddedNodeInfo,
 	"getbestblock":           handleGetBestBlock,
@@ -145,6 +173,7 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"getblockcount":          handleGetBlockCount,
 	"getblockhash":           handleGetBlockHash,
 	"getblockheader":         handleGetBlockHeader,
+	"getblockstats":          handleGetBlockStats,
 	"getblocktemplate":       handleGetBlockTemplate,
 	"getcfilter":             handleGetCFilter,
 	"getcfilterheader":       handleGetCFilterHeader,
@@ -155,24 +184,33 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"gethashespersec":        handleGetHashesPerSec,
 	"getheaders":             handleGetHeaders,
 	"getinfo":                handleGetInfo,
+	"getmempoolentry":        handleGetMempoolEntry,
 	"getmempoolinfo":         handleGetMempoolInfo,
 	"getmininginfo":          handleGetMiningInfo,
 	"getnettotals":           handleGetNetTotals,
 	"getnetworkhashps":       handleGetNetworkHashPS,
 	"getnodeaddresses":       handleGetNodeAddresses,
+	"getorphaninfo":          handleGetOrphanInfo,
 	"getpeerinfo":            handleGetPeerInfo,
 	"getrawmempool":          handleGetRawMempool,
 	"getrawtransaction":      handleGetRawTransaction,
+	"getsilentpaymenttweaks": handleGetSilentPaymentTweaks,
 	"gettxout":               handleGetTxOut,
 	"help":                   handleHelp,
+	"invalidateblock":        handleInvalidateBlock,
 	"node":                   handleNode,
 	"ping":                   handlePing,
+	"reconsiderblock":        handleReconsiderBlock,
+	"savemempool":            handleSaveMempool,
+	"scantxoutset":           handleScanTxOutSet,
 	"searchrawtransactions":  handleSearchRawTransactions,
 	"sendrawtransaction":     handleSendRawTransaction,
 	"setgenerate":            handleSetGenerate,
 	"signmessagewithprivkey": handleSignMessageWithPrivKey,
 	"stop":                   handleStop,
 	"submitblock":            handleSubmitBlock,
+	"submitpackage":          handleSubmitPackage,
+	"testmempoolaccept":      handleTestMempoolAccept,
 	"uptime":                 handleUptime,
 	"validateaddress":        handleValidateAddress,
 	"verifychain":            handleVerifyChain,
@@ -232,12 +270,9 @@ var rpcAskWallet = map[string]struct{}{
 var rpcUnimplemented = map[string]struct{}{
 	"estimatepriority": {},
 	"getchaintips":     {},
-	"getmempoolentry":  {},
 	"getnetworkinfo":   {},
 	"getwork":          {},
-	"invalidateblock":  {},
 	"preciousblock":    {},
-	"reconsiderblock":  {},
 }
 
 // Commands that are available to a limited user
@@ -256,34 +291,39 @@ var rpcLimited = map[string]struct{}{
 	"help": {},
 
 	// HTTP/S-only commands
-	"createrawtransaction":  {},
-	"decoderawtransaction":  {},
-	"decodescript":          {},
-	"estimatefee":           {},
-	"getbestblock":          {},
-	"getbestblockhash":      {},
-	"getblock":              {},
-	"getblockcount":         {},
-	"getblockhash":          {},
-	"getblockheader":        {},
-	"getcfilter":            {},
-	"getcfilterheader":      {},
-	"getcurrentnet":         {},
-	"getdifficulty":         {},
-	"getheaders":            {},
-	"getinfo":               {},
-	"getnettotals":          {},
-	"getnetworkhashps":      {},
-	"getrawmempool":         {},
-	"getrawtransaction":     {},
-	"gettxout":              {},
-	"searchrawtransactions": {},
-	"sendrawtransaction":    {},
-	"submitblock":           {},
-	"uptime":                {},
-	"validateaddress":       {},
-	"verifymessage":         {},
-	"version":               {},
+	"createrawtransaction":   {},
+	"decoderawtransaction":   {},
+	"decodescript":           {},
+	"estimatefee":            {},
+	"estimatesmartfee":       {},
+	"getbestblock":           {},
+	"getbestblockhash":       {},
+	"getblock":               {},
+	"getblockcount":          {},
+	"getblockhash":           {},
+	"getblockheader":         {},
+	"getblockstats":          {},
+	"getcfilter":             {},
+	"getcfilterheader":       {},
+	"getcurrentnet":          {},
+	"getdifficulty":          {},
+	"getheaders":             {},
+	"getinfo":                {},
+	"getnettotals":           {},
+	"getnetworkhashps":       {},
+	"getrawmempool":          {},
+	"getrawtransaction":      {},
+	"getsilentpaymenttweaks": {},
+	"gettxout":               {},
+	"searchrawtransactions":  {},
+	"sendrawtransaction":     {},
+	"submitblock":            {},
+	"submitpackage":          {},
+	"testmempoolaccept":      {},
+	"uptime":                 {},
+	"validateaddress":        {},
+	"verifymessage":          {},
+	"version":                {},
 }
 
 // builderScript is a convenience function which is used for hard-coded scripts
@@ -340,14 +380,24 @@ type gbtWorkState struct {
 	template      *mining.BlockTemplate
 	notifyMap     map[chainhash.Hash]map[int64]chan struct{}
 	timeSource    blockchain.MedianTimeSource
+
+	// newFees is the sum of the fees of the transactions accepted to the
+	// memory pool since the current template was generated.  A new
+	// template is generated before gbtRegenerateSeconds have passed once
+	// it reaches feeDelta.
+	newFees  btcutil.Amount
+	feeDelta btcutil.Amount
 }
 
 // newGbtWorkState returns a new instance of a gbtWorkState with all internal
 // fields initialized and ready to use.
-func newGbtWorkState(timeSource blockchain.MedianTimeSource) *gbtWorkState {
+func newGbtWorkState(timeSource blockchain.MedianTimeSource,
+	feeDelta btcutil.Amount) *gbtWorkState {
+
 	return &gbtWorkState{
 		notifyMap:  make(map[chainhash.Hash]map[int64]chan struct{}),
 		timeSource: timeSource,
+		feeDelta:   feeDelta,
 	}
 }
 
@@ -779,6 +829,133 @@ func createTxRawResult(chainParams *chaincfg.Params, mtx *wire.MsgTx,
 	return txReply, nil
 }
 
+// createSpentOutputResult converts the passed output spent by a transaction
+// input to a JSON object.
+func createSpentOutputResult(stxo *blockchain.SpentTxOut,
+	chainParams *chaincfg.Params) *btcjson.SpentOutputResult {
+
+	// The disassembled string will contain [error] inline if the script
+	// doesn't fully parse, so ignore the error here.
+	disbuf, _ := txscript.DisasmString(stxo.PkScript)
+
+	// Ignore the error here since an error means the script couldn't parse
+	// and there is no additional information about it anyways.
+	scriptClass, addrs, reqSigs, _ := txscript.ExtractPkScriptAddrs(
+		stxo.PkScript, chainParams)
+	encodedAddrs := make([]string, len(addrs))
+	for i, addr := range addrs {
+		encodedAddrs[i] = addr.EncodeAddress()
+	}
+
+	return &btcjson.SpentOutputResult{
+		Generated: stxo.IsCoinBase,
+		Height:    stxo.Height,
+		Value:     btcutil.Amount(stxo.Amount).ToBTC(),
+		ScriptPubKey: btcjson.ScriptPubKeyResult{
+			Asm:       disbuf,
+			Hex:       hex.EncodeToString(stxo.PkScript),
+			ReqSigs:   int32(reqSigs),
+			Type:      scriptClass.String(),
+			Addresses: encodedAddrs,
+		},
+	}
+}
+
+// addSpentOutputs sets the fee of the passed raw transaction result from the
+// outputs spent by the inputs of the transaction, which must be in input
+// order.  The spent outputs are also added to the inputs when includePrevOuts
+// is set.  Nothing is added for coinbase transactions since they don't spend
+// any outputs.
+func addSpentOutputs(txReply *btcjson.TxRawResult, mtx *wire.MsgTx,
+	stxos []blockchain.SpentTxOut, includePrevOuts bool,
+	chainParams *chaincfg.Params) {
+
+	if blockchain.IsCoinBaseTx(mtx) || len(stxos) != len(mtx.TxIn) {
+		return
+	}
+
+	var fee int64
+	for i := range stxos {
+		fee += stxos[i].Amount
+		if includePrevOuts {
+			txReply.Vin[i].PrevOut = createSpentOutputResult(
+				&stxos[i], chainParams)
+		}
+	}
+	for _, txOut := range mtx.TxOut {
+		fee -= txOut.Value
+	}
+	txReply.Fee = btcutil.Amount(fee).ToBTC()
+}
+
+// fetchSpentOutputs returns the outputs spent by the inputs of the passed
+// transaction in input order.  The outputs spent by a transaction confirmed in
+// the block with the passed hash are loaded from the spend journal of the
+// block, while the ones spent by an unconfirmed transaction are looked up in
+// the memory pool and the utxo set.  Nil is returned when the outputs are not
+// available.
+func fetchSpentOutputs(s *rpcServer, mtx *wire.MsgTx,
+	blkHash *chainhash.Hash) []blockchain.SpentTxOut {
+
+	if blockchain.IsCoinBaseTx(mtx) {
+		return nil
+	}
+
+	if blkHash != nil {
+		spentOutputs, err := s.cfg.Chain.FetchSpentOutputs(blkHash)
+		if err != nil {
+			rpcsLog.Debugf("Spent outputs of block %v are not "+
+				"available: %v", blkHash, err)
+			return nil
+		}
+		block, err := s.cfg.Chain.BlockByHash(blkHash)
+		if err != nil {
+			return nil
+		}
+		txHash := mtx.TxHash()
+		for i, tx := range block.Transactions() {
+			if tx.Hash().IsEqual(&txHash) {
+				return spentOutputs[i]
+			}
+		}
+		return nil
+	}
+
+	stxos := make([]blockchain.SpentTxOut, 0, len(mtx.TxIn))
+	for _, txIn := range mtx.TxIn {
+		prevOut := &txIn.PreviousOutPoint
+
+		// Outputs of unconfirmed transactions are reported at the
+		// height used for unmined transactions.
+		parent, err := s.cfg.TxMemPool.FetchTransaction(&prevOut.Hash)
+		if err == nil {
+			parentTxOuts := parent.MsgTx().TxOut
+			if prevOut.Index >= uint32(len(parentTxOuts)) {
+				return nil
+			}
+			txOut := parentTxOuts[prevOut.Index]
+			stxos = append(stxos, blockchain.SpentTxOut{
+				Amount:   txOut.Value,
+				PkScript: txOut.PkScript,
+				Height:   mining.UnminedHeight,
+			})
+			continue
+		}
+
+		entry, err := s.cfg.Chain.FetchUtxoEntry(*prevOut)
+		if err != nil || entry == nil || entry.IsSpent() {
+			return nil
+		}
+		stxos = append(stxos, blockchain.SpentTxOut{
+			Amount:     entry.Amount(),
+			PkScript:   entry.PkScript(),
+			Height:     entry.BlockHeight(),
+			IsCoinBase: entry.IsCoinBase(),
+		})
+	}
+	return stxos
+}
+
 // handleDecodeRawTransaction handles decoderawtransaction commands.
 func handleDecodeRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*btcjson.DecodeRawTransactionCmd)
@@ -860,6 +1037,54 @@ func handleDecodeScript(s *rpcServer, cmd interface{}, closeChan <-chan struct{}
 	return reply, nil
 }
 
+// handleDumpTxOutSet handles dumptxoutset commands.
+func handleDumpTxOutSet(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.DumpTxOutSetCmd)
+
+	// Relative paths are relative to the data directory.  An existing
+	// file is never overwritten.
+	path := c.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cfg.DataDir, path)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("%s already exists", path),
+		}
+	}
+
+	// Write the snapshot to a temporary file first so an interrupted dump
+	// doesn't leave an incomplete snapshot at the requested path.
+	tmpPath := path + ".incomplete"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		context := "Failed to create utxo snapshot file"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	snapshot, err := s.cfg.Chain.WriteUtxoSnapshot(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		context := "Failed to write utxo snapshot"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	return &btcjson.DumpTxOutSetResult{
+		CoinsWritten: snapshot.NumCoins,
+		BaseHash:     snapshot.BlockHash.String(),
+		BaseHeight:   snapshot.Height,
+		Path:         path,
+		TxOutSetHash: snapshot.UtxoHash.String(),
+		NChainTx:     snapshot.TotalTxns,
+	}, nil
+}
+
 // handleEstimateFee handles estimatefee commands.
 func handleEstimateFee(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*btcjson.EstimateFeeCmd)
@@ -882,6 +1107,59 @@ func handleEstimateFee(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 	return float64(feeRate), nil
 }
 
+// handleEstimateSmartFee handles estimatesmartfee commands.
+func handleEstimateSmartFee(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.EstimateSmartFeeCmd)
+
+	if s.cfg.FeeEstimator == nil {
+		return nil, errors.New("Fee estimation disabled")
+	}
+
+	if c.ConfTarget < 1 || c.ConfTarget > mempool.MaxConfirmationTarget {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Invalid conf_target, must be "+
+				"between 1 and %d", mempool.MaxConfirmationTarget),
+		}
+	}
+
+	mode := mempool.EstimateConservative
+	if c.EstimateMode != nil {
+		switch *c.EstimateMode {
+		case btcjson.EstimateModeUnset, btcjson.EstimateModeConservative:
+		case btcjson.EstimateModeEconomical:
+			mode = mempool.EstimateEconomical
+		default:
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: "Invalid estimate_mode parameter",
+			}
+		}
+	}
+
+	feeRate, target, err := s.cfg.FeeEstimator.EstimateSmartFee(
+		uint32(c.ConfTarget), mode,
+	)
+	if err != nil {
+		return &btcjson.EstimateSmartFeeResult{
+			Errors: []string{err.Error()},
+			Blocks: c.ConfTarget,
+		}, nil
+	}
+
+	// Transactions paying less than the minimum relay fee are not relayed,
+	// so never estimate less than that.
+	result := float64(feeRate)
+	if minRelayFee := cfg.minRelayTxFee.ToBTC(); result < minRelayFee {
+		result = minRelayFee
+	}
+
+	return &btcjson.EstimateSmartFeeResult{
+		FeeRate: &result,
+		Blocks:  int64(target),
+	}, nil
+}
+
 // handleGenerate handles generate commands.
 func handleGenerate(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	// Respond with an error if there are no addresses to pay the
@@ -1149,6 +1427,17 @@ func handleGetBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 
 		blockReply.Tx = txNames
 	} else {
+		// The outputs spent by the transactions provide their fees and,
+		// with verbosity 3, the prevout of each input.  They are loaded
+		// from the spend journal, which isn't available for blocks that
+		// are not part of the main chain, so the fields are omitted for
+		// them.
+		spentOutputs, err := s.cfg.Chain.FetchSpentOutputs(hash)
+		if err != nil {
+			rpcsLog.Debugf("Spent outputs of block %v are not "+
+				"available: %v", hash, err)
+		}
+
 		txns := blk.Transactions()
 		rawTxns := make([]btcjson.TxRawResult, len(txns))
 		for i, tx := range txns {
@@ -1158,6 +1447,11 @@ func handleGetBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 			if err != nil {
 				return nil, err
 			}
+			if spentOutputs != nil {
+				addSpentOutputs(rawTxn, tx.MsgTx(),
+					spentOutputs[i], *c.Verbosity >= 3,
+					params)
+			}
 			rawTxns[i] = *rawTxn
 		}
 		blockReply.RawTx = rawTxns
@@ -1388,6 +1682,346 @@ func handleGetBlockHeader(s *rpcServer, cmd interface{}, closeChan <-chan struct
 	return blockHeaderReply, nil
 }
 
+// blockStatsPerUtxoOverhead is the number of bytes every unspent transaction
+// output adds to the utxo set besides its serialized output for the utxo set
+// size statistics of the getblockstats command.  It consists of the outpoint
+// hash and index, the block height and the coinbase flag.
+const blockStatsPerUtxoOverhead = chainhash.HashSize + 4 + 4 + 1
+
+// blockStatsPercentiles are the percentiles of the fee rates of a block
+// reported by the getblockstats command.
+var blockStatsPercentiles = []float64{0.1, 0.25, 0.5, 0.75, 0.9}
+
+// blockStatsSpentOutputs is the set of statistics of the getblockstats command
+// which require the outputs spent by the block.  Loading them from the spend
+// journal is avoided when none of them is selected.
+var blockStatsSpentOutputs = map[string]struct{}{
+	"avgfee":               {},
+	"avgfeerate":           {},
+	"feerate_percentiles":  {},
+	"maxfee":               {},
+	"maxfeerate":           {},
+	"medianfee":            {},
+	"minfee":               {},
+	"minfeerate":           {},
+	"taproot_txs":          {},
+	"totalfee":             {},
+	"utxo_size_inc":        {},
+	"utxo_size_inc_actual": {},
+}
+
+// txFeeRate houses the fee rate of a transaction along with its weight for
+// calculating the weighted fee rate percentiles of a block.
+type txFeeRate struct {
+	feeRate int64
+	weight  int64
+}
+
+// calcFeeRatePercentiles returns the blockStatsPercentiles of the passed fee
+// rates weighted by the weights of their transactions, which add up to the
+// passed total weight.
+func calcFeeRatePercentiles(feeRates []txFeeRate, totalWeight int64) []int64 {
+	percentiles := make([]int64, len(blockStatsPercentiles))
+	if len(feeRates) == 0 {
+		return percentiles
+	}
+
+	sort.Slice(feeRates, func(i, j int) bool {
+		return feeRates[i].feeRate < feeRates[j].feeRate
+	})
+	var next int
+	var cumulativeWeight int64
+	for _, fr := range feeRates {
+		cumulativeWeight += fr.weight
+		for next < len(percentiles) && float64(cumulativeWeight) >=
+			float64(totalWeight)*blockStatsPercentiles[next] {
+
+			percentiles[next] = fr.feeRate
+			next++
+		}
+	}
+	for ; next < len(percentiles); next++ {
+		percentiles[next] = feeRates[len(feeRates)-1].feeRate
+	}
+	return percentiles
+}
+
+// calcTruncatedMedian returns the median of the passed values, which is
+// truncated to an integer for an even number of values.
+func calcTruncatedMedian(values []int64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		return values[i] < values[j]
+	})
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
+
+// isPayToTaproot returns whether the passed public key script is a version 1
+// segregated witness program with a 32-byte x-only public key.
+func isPayToTaproot(pkScript []byte) bool {
+	version, program, err := txscript.ExtractWitnessProgramInfo(pkScript)
+	return err == nil && version == 1 && len(program) == 32
+}
+
+// createBlockStatsResult calculates the statistics of the getblockstats command
+// for the passed block at the passed height.  The passed spent outputs, which
+// are indexed by transaction and input as returned by FetchSpentOutputs, may
+// be nil, in which case the statistics requiring them are left zero.
+func createBlockStatsResult(block *btcutil.Block, height int32,
+	medianTime time.Time, spentOutputs [][]blockchain.SpentTxOut,
+	params *chaincfg.Params) *btcjson.GetBlockStatsResult {
+
+	result := &btcjson.GetBlockStatsResult{
+		Hash:       block.Hash().String(),
+		Height:     int64(height),
+		MedianTime: medianTime.Unix(),
+		Subsidy:    blockchain.CalcBlockSubsidy(height, params),
+		Time:       block.MsgBlock().Header.Timestamp.Unix(),
+		Txs:        int64(len(block.Transactions())),
+	}
+
+	var outs, outsActual int64
+	var txSizes, fees []int64
+	var feeRates []txFeeRate
+	for i, tx := range block.Transactions() {
+		msgTx := tx.MsgTx()
+
+		// The outputs of all transactions, including the coinbase, are
+		// added to the utxo set unless they are provably unspendable.
+		var txTotalOut int64
+		for _, txOut := range msgTx.TxOut {
+			txTotalOut += txOut.Value
+			utxoSize := int64(txOut.SerializeSize() +
+				blockStatsPerUtxoOverhead)
+			outs++
+			result.UTXOSizeIncrease += utxoSize
+			if !txscript.IsUnspendable(txOut.PkScript) {
+				outsActual++
+				result.UTXOSizeIncreaseActual += utxoSize
+			}
+			if isPayToTaproot(txOut.PkScript) {
+				result.TaprootOuts++
+			}
+		}
+		if i == 0 {
+			continue
+		}
+
+		result.Ins += int64(len(msgTx.TxIn))
+		result.TotalOut += txTotalOut
+
+		txSize := int64(msgTx.SerializeSize())
+		txSizes = append(txSizes, txSize)
+		if result.MinTxSize == 0 || txSize < result.MinTxSize {
+			result.MinTxSize = txSize
+		}
+		if txSize > result.MaxTxSize {
+			result.MaxTxSize = txSize
+		}
+		result.TotalSize += txSize
+
+		weight := blockchain.GetTransactionWeight(tx)
+		result.TotalWeight += weight
+		if msgTx.HasWitness() {
+			result.SegWitTxs++
+			result.SegWitTotalSize += txSize
+			result.SegWitTotalWeight += weight
+		}
+
+		if spentOutputs == nil {
+			continue
+		}
+		var txTotalIn int64
+		var spendsTaproot bool
+		for _, stxo := range spentOutputs[i] {
+			txTotalIn += stxo.Amount
+			utxoSize := int64(8 + wire.VarIntSerializeSize(
+				uint64(len(stxo.PkScript))) + len(stxo.PkScript) +
+				blockStatsPerUtxoOverhead)
+			result.UTXOSizeIncrease -= utxoSize
+			result.UTXOSizeIncreaseActual -= utxoSize
+			if isPayToTaproot(stxo.PkScript) {
+				spendsTaproot = true
+			}
+		}
+		if spendsTaproot {
+			result.TaprootTxs++
+		}
+
+		fee := txTotalIn - txTotalOut
+		fees = append(fees, fee)
+		if len(fees) == 1 || fee < result.MinFee {
+			result.MinFee = fee
+		}
+		if fee > result.MaxFee {
+			result.MaxFee = fee
+		}
+		result.TotalFee += fee
+
+		// Fee rates are in satoshis per virtual byte.
+		var feeRate int64
+		if weight > 0 {
+			feeRate = fee * blockchain.WitnessScaleFactor / weight
+		}
+		feeRates = append(feeRates, txFeeRate{feeRate, weight})
+		if len(feeRates) == 1 || feeRate < result.MinFeeRate {
+			result.MinFeeRate = feeRate
+		}
+		if feeRate > result.MaxFeeRate {
+			result.MaxFeeRate = feeRate
+		}
+	}
+
+	numTxns := result.Txs - 1
+	if numTxns > 0 {
+		result.AverageFee = result.TotalFee / numTxns
+		result.AverageTxSize = result.TotalSize / numTxns
+	}
+	if result.TotalWeight > 0 {
+		result.AverageFeeRate = result.TotalFee *
+			blockchain.WitnessScaleFactor / result.TotalWeight
+	}
+	result.FeeratePercentiles = calcFeeRatePercentiles(feeRates,
+		result.TotalWeight)
+	result.MedianFee = calcTruncatedMedian(fees)
+	result.MedianTxSize = calcTruncatedMedian(txSizes)
+	result.Outs = outs
+	result.UTXOIncrease = outs - result.Ins
+	result.UTXOIncreaseActual = outsActual - result.Ins
+
+	return result
+}
+
+// handleGetBlockStats implements the getblockstats command.
+func handleGetBlockStats(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetBlockStatsCmd)
+
+	// Look up the main chain block by its hash or height.
+	var hash *chainhash.Hash
+	var height int32
+	switch v := c.HashOrHeight.Value.(type) {
+	case int:
+		best := s.cfg.Chain.BestSnapshot()
+		if v < 0 || v > int(best.Height) {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCInvalidParameter,
+				Message: fmt.Sprintf("Target block height %d is "+
+					"out of range [0,%d]", v, best.Height),
+			}
+		}
+		height = int32(v)
+		var err error
+		hash, err = s.cfg.Chain.BlockHashByHeight(height)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCOutOfRange,
+				Message: "Block number out of range",
+			}
+		}
+
+	case string:
+		var err error
+		hash, err = chainhash.NewHashFromStr(v)
+		if err != nil {
+			return nil, rpcDecodeHexError(v)
+		}
+		height, err = s.cfg.Chain.BlockHeightByHash(hash)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCBlockNotFound,
+				Message: "Block not found in the main chain",
+			}
+		}
+
+	default:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "hash_or_height must be a block hash or height",
+		}
+	}
+
+	// The outputs spent by the block are only needed by some of the
+	// statistics, so avoid loading them unless one of those is selected.
+	var selected []string
+	if c.Stats != nil {
+		selected = *c.Stats
+	}
+	needSpentOutputs := len(selected) == 0
+	for _, stat := range selected {
+		if _, ok := blockStatsSpentOutputs[stat]; ok {
+			needSpentOutputs = true
+		}
+	}
+
+	block, err := s.cfg.Chain.BlockByHash(hash)
+	if err != nil {
+		context := "Failed to load block"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	medianTime, err := s.cfg.Chain.MedianTimeByHash(hash)
+	if err != nil {
+		context := "Failed to calculate median time"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	var spentOutputs [][]blockchain.SpentTxOut
+	if needSpentOutputs {
+		spentOutputs, err = s.cfg.Chain.FetchSpentOutputs(hash)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCMisc,
+				Message: "Spent outputs of the block are not " +
+					"available: " + err.Error(),
+			}
+		}
+	}
+
+	result := createBlockStatsResult(block, height, medianTime,
+		spentOutputs, s.cfg.ChainParams)
+	return selectBlockStats(result, selected)
+}
+
+// selectBlockStats returns the passed statistics of the getblockstats command
+// limited to the selected ones, which are identified by the JSON field names of
+// the result.  All statistics are returned when none are selected.
+func selectBlockStats(result *btcjson.GetBlockStatsResult,
+	selected []string) (interface{}, error) {
+
+	if len(selected) == 0 {
+		return result, nil
+	}
+
+	marshalled, err := json.Marshal(result)
+	if err != nil {
+		context := "Failed to marshal block stats"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	var stats map[string]json.RawMessage
+	if err := json.Unmarshal(marshalled, &stats); err != nil {
+		context := "Failed to unmarshal block stats"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	selectedStats := make(map[string]json.RawMessage, len(selected))
+	for _, stat := range selected {
+		value, ok := stats[stat]
+		if !ok {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCInvalidParameter,
+				Message: fmt.Sprintf("Invalid selected statistic "+
+					"%q", stat),
+			}
+		}
+		selectedStats[stat] = value
+	}
+	return selectedStats, nil
+}
+
 // encodeTemplateID encodes the passed details into an ID that can be used to
 // uniquely identify a block template.
 func encodeTemplateID(prevHash *chainhash.Hash, lastGenerated time.Time) string {
@@ -1478,10 +2112,11 @@ func (state *gbtWorkState) NotifyBlockConnected(blockHash *chainhash.Hash) {
 }
 
 // NotifyMempoolTx uses the new last updated time for the transaction memory
-// pool to notify any long poll clients with a new block template when their
-// existing block template is stale due to enough time passing and the contents
-// of the memory pool changing.
-func (state *gbtWorkState) NotifyMempoolTx(lastUpdated time.Time) {
+// pool and the fee of the new transaction to notify any long poll clients with
+// a new block template when their existing block template is stale due to the
+// contents of the memory pool changing and either enough time passing or the
+// new transactions paying enough additional fees.
+func (state *gbtWorkState) NotifyMempoolTx(lastUpdated time.Time, fee int64) {
 	go func() {
 		state.Lock()
 		defer state.Unlock()
@@ -1492,14 +2127,27 @@ func (state *gbtWorkState) NotifyMempoolTx(lastUpdated time.Time) {
 			return
 		}
 
-		if time.Now().After(state.lastGenerated.Add(time.Second *
-			gbtRegenerateSeconds)) {
-
+		state.newFees += btcutil.Amount(fee)
+		if state.isStale() {
 			state.notifyLongPollers(state.prevHash, lastUpdated)
 		}
 	}()
 }
 
+// isStale returns whether the current block template should be replaced due
+// to changes to the memory pool.  This is the case when it has been at least
+// gbtRegenerateSeconds since it was generated, or when the transactions that
+// were accepted since then pay at least the configured fee delta.
+//
+// This function MUST be called with the state locked.
+func (state *gbtWorkState) isStale() bool {
+	if state.feeDelta > 0 && state.newFees >= state.feeDelta {
+		return true
+	}
+	return time.Now().After(state.lastGenerated.Add(time.Second *
+		gbtRegenerateSeconds))
+}
+
 // templateUpdateChan returns a channel that will be closed once the block
 // template associated with the passed previous hash and last generated time
 // is stale.  The function will return existing channels for duplicate
@@ -1531,7 +2179,8 @@ func (state *gbtWorkState) templateUpdateChan(prevHash *chainhash.Hash, lastGene
 // updateBlockTemplate creates or updates a block template for the work state.
 // A new block template will be generated when the current best block has
 // changed or the transactions in the memory pool have been updated and it has
-// been long enough since the last template was generated.  Otherwise, the
+// been long enough since the last template was generated or the new
+// transactions pay enough additional fees.  Otherwise, the
 // timestamp for the existing block template is updated (and possibly the
 // difficulty on testnet per the consesus rules).  Finally, if the
 // useCoinbaseValue flag is false and the existing block template does not
@@ -1549,17 +2198,16 @@ func (state *gbtWorkState) updateBlockTemplate(s *rpcServer, useCoinbaseValue bo
 
 	// Generate a new block template when the current best block has
 	// changed or the transactions in the memory pool have been updated and
-	// it has been at least gbtRegenerateSecond since the last template was
-	// generated.
+	// either it has been at least gbtRegenerateSeconds since the last
+	// template was generated or the new transactions pay at least the fee
+	// delta.
 	var msgBlock *wire.MsgBlock
 	var targetDifficulty string
 	latestHash := &s.cfg.Chain.BestSnapshot().Hash
 	template := state.template
 	if template == nil || state.prevHash == nil ||
 		!state.prevHash.IsEqual(latestHash) ||
-		(state.lastTxUpdate != lastTxUpdate &&
-			time.Now().After(state.lastGenerated.Add(time.Second*
-				gbtRegenerateSeconds))) {
+		(state.lastTxUpdate != lastTxUpdate && state.isStale()) {
 
 		// Reset the previous best hash the block template was generated
 		// against so any errors below cause the next invocation to try
@@ -1602,6 +2250,7 @@ func (state *gbtWorkState) updateBlockTemplate(s *rpcServer, useCoinbaseValue bo
 		state.lastTxUpdate = lastTxUpdate
 		state.prevHash = latestHash
 		state.minTimestamp = minTimestamp
+		state.newFees = 0
 
 		rpcsLog.Debugf("Generated block template (timestamp %v, "+
 			"target %s, merkle root %s)",
@@ -1665,10 +2314,11 @@ func (state *gbtWorkState) updateBlockTemplate(s *rpcServer, useCoinbaseValue bo
 
 // blockTemplateResult returns the current block template associated with the
 // state as a btcjson.GetBlockTemplateResult that is ready to be encoded to JSON
-// and returned to the caller.
+// and returned to the caller.  The passed mutations are the ones negotiated
+// with the caller via gbtMutations.
 //
 // This function MUST be called with the state locked.
-func (state *gbtWorkState) blockTemplateResult(useCoinbaseValue bool, submitOld *bool) (*btcjson.GetBlockTemplateResult, error) {
+func (state *gbtWorkState) blockTemplateResult(useCoinbaseValue bool, mutable []string, submitOld *bool) (*btcjson.GetBlockTemplateResult, error) {
 	// Ensure the timestamps are still in valid range for the template.
 	// This should really only ever happen if the local clock is changed
 	// after the template is generated, but it's important to avoid serving
@@ -1761,7 +2411,7 @@ func (state *gbtWorkState) blockTemplateResult(useCoinbaseValue bool, submitOld
 		Target:       targetDifficulty,
 		MinTime:      state.minTimestamp.Unix(),
 		MaxTime:      maxTime.Unix(),
-		Mutable:      gbtMutableFields,
+		Mutable:      mutable,
 		NonceRange:   gbtNonceRange,
 		Capabilities: gbtCapabilities,
 	}
@@ -1809,6 +2459,45 @@ func (state *gbtWorkState) blockTemplateResult(useCoinbaseValue bool, submitOld
 	return &reply, nil
 }
 
+// gbtMutations returns the mutations to the block template that are
+// advertised to a caller of the getblocktemplate RPC with the passed
+// capabilities.  Appending to the coinbase is only offered when the caller is
+// given a coinbase transaction since it creates the entire coinbase otherwise.
+// When the caller announces the mutations it supports among its capabilities
+// as described by BIP 0023, only the mutations supported by both sides are
+// advertised.  Supporting a mutation such as "time" implies support for its
+// more specific forms such as "time/increment".
+func gbtMutations(capabilities []string, useCoinbaseValue bool) []string {
+	mutable := make([]string, 0, len(gbtMutableFields))
+	for _, mutation := range gbtMutableFields {
+		if useCoinbaseValue && mutation == "coinbase/append" {
+			continue
+		}
+		mutable = append(mutable, mutation)
+	}
+
+	supported := make(map[string]struct{})
+	for _, capability := range capabilities {
+		if _, ok := gbtKnownMutations[capability]; ok {
+			supported[capability] = struct{}{}
+		}
+	}
+	if len(supported) == 0 {
+		return mutable
+	}
+
+	negotiated := make([]string, 0, len(mutable))
+	for _, mutation := range mutable {
+		general := strings.SplitN(mutation, "/", 2)[0]
+		_, ok := supported[mutation]
+		_, okGeneral := supported[general]
+		if ok || okGeneral {
+			negotiated = append(negotiated, mutation)
+		}
+	}
+	return negotiated
+}
+
 // handleGetBlockTemplateLongPoll is a helper for handleGetBlockTemplateRequest
 // which deals with handling long polling for block templates.  When a caller
 // sends a request with a long poll ID that was previously returned, a response
@@ -1819,7 +2508,7 @@ func (state *gbtWorkState) blockTemplateResult(useCoinbaseValue bool, submitOld
 // has passed without finding a solution.
 //
 // See https://en.bitcoin.it/wiki/BIP_0022 for more details.
-func handleGetBlockTemplateLongPoll(s *rpcServer, longPollID string, useCoinbaseValue bool, closeChan <-chan struct{}) (interface{}, error) {
+func handleGetBlockTemplateLongPoll(s *rpcServer, longPollID string, useCoinbaseValue bool, mutable []string, closeChan <-chan struct{}) (interface{}, error) {
 	state := s.gbtWorkState
 	state.Lock()
 	// The state unlock is intentionally not deferred here since it needs to
@@ -1835,7 +2524,7 @@ func handleGetBlockTemplateLongPoll(s *rpcServer, longPollID string, useCoinbase
 	// the caller is invalid.
 	prevHash, lastGenerated, err := decodeTemplateID(longPollID)
 	if err != nil {
-		result, err := state.blockTemplateResult(useCoinbaseValue, nil)
+		result, err := state.blockTemplateResult(useCoinbaseValue, mutable, nil)
 		if err != nil {
 			state.Unlock()
 			return nil, err
@@ -1857,7 +2546,7 @@ func handleGetBlockTemplateLongPoll(s *rpcServer, longPollID string, useCoinbase
 		// already been found and added to the block chain.
 		submitOld := prevHash.IsEqual(prevTemplateHash)
 		result, err := state.blockTemplateResult(useCoinbaseValue,
-			&submitOld)
+			mutable, &submitOld)
 		if err != nil {
 			state.Unlock()
 			return nil, err
@@ -1897,7 +2586,8 @@ func handleGetBlockTemplateLongPoll(s *rpcServer, longPollID string, useCoinbase
 	// block template depending on whether or not a solution has already
 	// been found and added to the block chain.
 	submitOld := prevHash.IsEqual(&state.template.Block.Header.PrevBlock)
-	result, err := state.blockTemplateResult(useCoinbaseValue, &submitOld)
+	result, err := state.blockTemplateResult(useCoinbaseValue, mutable,
+		&submitOld)
 	if err != nil {
 		return nil, err
 	}
@@ -1910,14 +2600,16 @@ func handleGetBlockTemplateLongPoll(s *rpcServer, longPollID string, useCoinbase
 // handles both long poll requests as specified by BIP 0022 as well as regular
 // requests.  In addition, it detects the capabilities reported by the caller
 // in regards to whether or not it supports creating its own coinbase (the
-// coinbasetxn and coinbasevalue capabilities) and modifies the returned block
-// template accordingly.
+// coinbasetxn and coinbasevalue capabilities) and the mutations it supports,
+// and modifies the returned block template accordingly.
 func handleGetBlockTemplateRequest(s *rpcServer, request *btcjson.TemplateRequest, closeChan <-chan struct{}) (interface{}, error) {
 	// Extract the relevant passed capabilities and restrict the result to
 	// either a coinbase value or a coinbase transaction object depending on
 	// the request.  Default to only providing a coinbase value.
 	useCoinbaseValue := true
+	var capabilities []string
 	if request != nil {
+		capabilities = request.Capabilities
 		var hasCoinbaseValue, hasCoinbaseTxn bool
 		for _, capability := range request.Capabilities {
 			switch capability {
@@ -1966,12 +2658,16 @@ func handleGetBlockTemplateRequest(s *rpcServer, request *btcjson.TemplateReques
 		}
 	}
 
+	// Negotiate the mutations the caller is allowed to make to the block
+	// template.
+	mutable := gbtMutations(capabilities, useCoinbaseValue)
+
 	// When a long poll ID was provided, this is a long poll request by the
 	// client to be notified when block template referenced by the ID should
 	// be replaced with a new one.
 	if request != nil && request.LongPollID != "" {
 		return handleGetBlockTemplateLongPoll(s, request.LongPollID,
-			useCoinbaseValue, closeChan)
+			useCoinbaseValue, mutable, closeChan)
 	}
 
 	// Protect concurrent access when updating block templates.
@@ -1988,7 +2684,7 @@ func handleGetBlockTemplateRequest(s *rpcServer, request *btcjson.TemplateReques
 	if err := state.updateBlockTemplate(s, useCoinbaseValue); err != nil {
 		return nil, err
 	}
-	return state.blockTemplateResult(useCoinbaseValue, nil)
+	return state.blockTemplateResult(useCoinbaseValue, mutable, nil)
 }
 
 // chainErrToGBTErrString converts an error returned from btcchain to a string
@@ -2088,14 +2784,17 @@ func chainErrToGBTErrString(err error) string {
 	case blockchain.ErrInvalidAncestorBlock:
 		return "bad-prevblk"
 	case blockchain.ErrPrevBlockNotBest:
-		return "inconclusive-not-best-prvblk"
+		return "inconclusive-not-best-prevblk"
 	}
 
 	return "rejected: " + err.Error()
 }
 
 // handleGetBlockTemplateProposal is a helper for handleGetBlockTemplate which
-// deals with block proposals.
+// deals with block proposals.  A proposal is accepted, which is indicated by a
+// null result, when it is a valid block building on the current best chain
+// aside from the proof of work.  Otherwise, the reason for the rejection is
+// returned.
 //
 // See https://en.bitcoin.it/wiki/BIP_0023 for more details.
 func handleGetBlockTemplateProposal(s *rpcServer, request *btcjson.TemplateRequest) (interface{}, error) {
@@ -2130,14 +2829,46 @@ func handleGetBlockTemplateProposal(s *rpcServer, request *btcjson.TemplateReque
 	}
 	block := btcutil.NewBlock(&msgBlock)
 
+	// Report proposals for blocks which are already known as duplicates
+	// along with their validity, if known.
+	chain := s.cfg.Chain
+	blockHash := block.Hash()
+	switch {
+	case chain.IsKnownInvalid(blockHash):
+		return "duplicate-invalid", nil
+	case chain.MainChainHasBlock(blockHash):
+		return "duplicate", nil
+	}
+	haveBlock, err := chain.HaveBlock(blockHash)
+	if err != nil {
+		context := "Failed to look up proposed block"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	if haveBlock {
+		return "duplicate-inconclusive", nil
+	}
+
 	// Ensure the block is building from the expected previous block.
-	expectedPrevHash := s.cfg.Chain.BestSnapshot().Hash
+	// Proposals building on another known block can't be fully checked
+	// since the block is only validated against the current best chain.
+	expectedPrevHash := chain.BestSnapshot().Hash
 	prevHash := &block.MsgBlock().Header.PrevBlock
 	if !expectedPrevHash.IsEqual(prevHash) {
-		return "bad-prevblk", nil
+		haveBlock, err := chain.HaveBlock(prevHash)
+		if err != nil {
+			context := "Failed to look up previous block"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		switch {
+		case !haveBlock:
+			return "prev-blk-not-found", nil
+		case chain.IsKnownInvalid(prevHash):
+			return "bad-prevblk", nil
+		}
+		return "inconclusive-not-best-prevblk", nil
 	}
 
-	if err := s.cfg.Chain.CheckConnectBlockTemplate(block); err != nil {
+	if err := chain.CheckConnectBlockTemplate(block); err != nil {
 		if _, ok := err.(blockchain.RuleError); !ok {
 			errStr := fmt.Sprintf("Failed to process block proposal: %v", err)
 			rpcsLog.Error(errStr)
@@ -2327,7 +3058,57 @@ func handleGetInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (in
 	return ret, nil
 }
 
-// handleGetMempoolInfo implements the getmempoolinfo command.
+// handleGetMempoolEntry implements the getmempoolentry command.
+func handleGetMempoolEntry(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetMempoolEntryCmd)
+	txHash, err := chainhash.NewHashFromStr(c.TxID)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.TxID)
+	}
+
+	entry, err := s.cfg.TxMemPool.MempoolEntry(txHash)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCNoTxInfo,
+			Message: "Transaction not in mempool",
+		}
+	}
+
+	tx := entry.Tx
+	fee := btcutil.Amount(entry.Fee).ToBTC()
+	ancestorFees := btcutil.Amount(entry.AncestorFees).ToBTC()
+	descendantFees := btcutil.Amount(entry.DescendantFees).ToBTC()
+	result := &btcjson.GetMempoolEntryResult{
+		VSize:           int32(entry.VSize),
+		Size:            int32(tx.MsgTx().SerializeSize()),
+		Weight:          blockchain.GetTransactionWeight(tx),
+		Fee:             fee,
+		ModifiedFee:     fee,
+		Time:            entry.Added.Unix(),
+		Height:          int64(entry.Height),
+		DescendantCount: int64(entry.DescendantCount),
+		DescendantSize:  entry.DescendantSize,
+		DescendantFees:  descendantFees,
+		AncestorCount:   int64(entry.AncestorCount),
+		AncestorSize:    entry.AncestorSize,
+		AncestorFees:    ancestorFees,
+		WTxId:           tx.WitnessHash().String(),
+		Fees: btcjson.MempoolFees{
+			Base:       fee,
+			Modified:   fee,
+			Ancestor:   ancestorFees,
+			Descendant: descendantFees,
+		},
+		Depends: make([]string, 0, len(entry.Depends)),
+	}
+	for _, hash := range entry.Depends {
+		result.Depends = append(result.Depends, hash.String())
+	}
+
+	return result, nil
+}
+
+// handleGetMempoolInfo implements the getmempoolinfo command.
 func handleGetMempoolInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	mempoolTxns := s.cfg.TxMemPool.TxDescs()
 
@@ -2510,7 +3291,7 @@ func handleGetNodeAddresses(s *rpcServer, cmd interface{}, closeChan <-chan stru
 		address := &btcjson.GetNodeAddressesResult{
 			Time:     node.Timestamp.Unix(),
 			Services: uint64(node.Services),
-			Address:  node.IP.String(),
+			Address:  node.Host(),
 			Port:     node.Port,
 		}
 		addresses = append(addresses, address)
@@ -2519,6 +3300,23 @@ func handleGetNodeAddresses(s *rpcServer, cmd interface{}, closeChan <-chan stru
 	return addresses, nil
 }
 
+// handleGetOrphanInfo implements the getorphaninfo command.
+func handleGetOrphanInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	orphans := s.cfg.Chain.OrphanStats()
+	headers := s.cfg.SyncMgr.PendingHeaderStats()
+	return &btcjson.GetOrphanInfoResult{
+		Orphans:           orphans.Count,
+		Bytes:             orphans.Size,
+		Added:             orphans.Added,
+		Processed:         orphans.Processed,
+		Evicted:           orphans.Evicted,
+		Expired:           orphans.Expired,
+		PendingHeaders:    headers.Count,
+		EvictedHeaders:    headers.Evicted,
+		UnconnectedBlocks: headers.UnconnectedBlocks,
+	}, nil
+}
+
 // handleGetPeerInfo implements the getpeerinfo command.
 func handleGetPeerInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	peers := s.cfg.ConnMgr.ConnectedPeers()
@@ -2589,8 +3387,10 @@ func handleGetRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan str
 	}
 
 	verbose := false
+	var verbosity int
 	if c.Verbose != nil {
-		verbose = *c.Verbose != 0
+		verbosity = *c.Verbose
+		verbose = verbosity != 0
 	}
 
 	// Try to fetch the transaction from the memory pool and if that fails,
@@ -2694,9 +3494,70 @@ func handleGetRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan str
 	if err != nil {
 		return nil, err
 	}
+
+	// Verbosity 2 adds the fee and the outputs spent by the inputs when
+	// they are available.
+	if verbosity >= 2 {
+		stxos := fetchSpentOutputs(s, mtx, blkHash)
+		addSpentOutputs(rawTxn, mtx, stxos, true, s.cfg.ChainParams)
+	}
 	return *rawTxn, nil
 }
 
+// handleGetSilentPaymentTweaks implements the getsilentpaymenttweaks command.
+func handleGetSilentPaymentTweaks(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if s.cfg.TweakIndex == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Tweak index must be enabled (--tweakindex)",
+		}
+	}
+
+	c := cmd.(*btcjson.GetSilentPaymentTweaksCmd)
+	best := s.cfg.Chain.BestSnapshot()
+	if c.StartHeight < 0 || c.EndHeight < c.StartHeight ||
+		c.EndHeight > best.Height {
+
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCOutOfRange,
+			Message: fmt.Sprintf("Block range must be between 0 and "+
+				"%d and the start height must not exceed the end "+
+				"height", best.Height),
+		}
+	}
+	if c.EndHeight-c.StartHeight >= maxTweakBlockRange {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCOutOfRange,
+			Message: fmt.Sprintf("Block range must not exceed %d "+
+				"blocks", maxTweakBlockRange),
+		}
+	}
+
+	results := make([]btcjson.GetSilentPaymentTweaksResult, 0,
+		c.EndHeight-c.StartHeight+1)
+	err := s.cfg.TweakIndex.TweaksByBlockRange(c.StartHeight, c.EndHeight,
+		func(hash *chainhash.Hash, height int32, tweaks [][]byte) error {
+			hexTweaks := make([]string, 0, len(tweaks))
+			for _, tweak := range tweaks {
+				hexTweaks = append(hexTweaks, hex.EncodeToString(tweak))
+			}
+			results = append(results, btcjson.GetSilentPaymentTweaksResult{
+				Hash:   hash.String(),
+				Height: height,
+				Tweaks: hexTweaks,
+			})
+			return nil
+		})
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: err.Error(),
+		}
+	}
+
+	return results, nil
+}
+
 // handleGetTxOut handles gettxout commands.
 func handleGetTxOut(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*btcjson.GetTxOutCmd)
@@ -2842,6 +3703,31 @@ func handleHelp(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (inter
 	return help, nil
 }
 
+// handleInvalidateBlock implements the invalidateblock command.
+func handleInvalidateBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.InvalidateBlockCmd)
+
+	hash, err := chainhash.NewHashFromStr(c.BlockHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.BlockHash)
+	}
+	if _, err := s.cfg.Chain.HeaderByHash(hash); err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCBlockNotFound,
+			Message: "Block not found",
+		}
+	}
+
+	if err := s.cfg.Chain.InvalidateBlock(hash); err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: err.Error(),
+		}
+	}
+
+	return nil, nil
+}
+
 // handlePing implements the ping command.
 func handlePing(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	// Ask server to ping \o_
@@ -3076,6 +3962,265 @@ func fetchMempoolTxnsForAddress(s *rpcServer, addr btcutil.Address, numToSkip, n
 	return mpTxns[numToSkip:rangeEnd], numToSkip
 }
 
+// handleReconsiderBlock implements the reconsiderblock command.
+func handleReconsiderBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.ReconsiderBlockCmd)
+
+	hash, err := chainhash.NewHashFromStr(c.BlockHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.BlockHash)
+	}
+	if _, err := s.cfg.Chain.HeaderByHash(hash); err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCBlockNotFound,
+			Message: "Block not found",
+		}
+	}
+
+	if err := s.cfg.Chain.ReconsiderBlock(hash); err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: err.Error(),
+		}
+	}
+
+	return nil, nil
+}
+
+// handleSaveMempool implements the savemempool command.
+func handleSaveMempool(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	path, err := s.cfg.SaveMempool()
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Unable to save mempool: " + err.Error(),
+		}
+	}
+
+	return &btcjson.SaveMempoolResult{Filename: path}, nil
+}
+
+// txOutSetScan houses the state of the utxo set scan started by the
+// scantxoutset command.  Only a single scan may be active at a time.
+type txOutSetScan struct {
+	mtx      sync.Mutex
+	abort    chan struct{} // nil when no scan is active
+	aborted  bool
+	progress float64
+}
+
+// start marks a new scan as active and returns the channel which is closed
+// when it is aborted.  It returns false when another scan is already active.
+func (t *txOutSetScan) start() (chan struct{}, bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if t.abort != nil {
+		return nil, false
+	}
+	t.abort = make(chan struct{})
+	t.aborted = false
+	t.progress = 0
+	return t.abort, true
+}
+
+// finish marks the active scan as finished.
+func (t *txOutSetScan) finish() {
+	t.mtx.Lock()
+	t.abort = nil
+	t.mtx.Unlock()
+}
+
+// stop aborts the active scan and returns whether it was running.  When the
+// passed abort channel is not nil, the scan is only aborted when it is the one
+// the channel was returned for.
+func (t *txOutSetScan) stop(abort chan struct{}) bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if t.abort == nil || t.aborted || (abort != nil && abort != t.abort) {
+		return false
+	}
+	close(t.abort)
+	t.aborted = true
+	return true
+}
+
+// setProgress sets the progress of the active scan in percent.
+func (t *txOutSetScan) setProgress(progress float64) {
+	t.mtx.Lock()
+	t.progress = progress
+	t.mtx.Unlock()
+}
+
+// status returns the progress of the active scan in percent and whether a scan
+// is active.
+func (t *txOutSetScan) status() (float64, bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	return t.progress, t.abort != nil
+}
+
+// scanObjectScripts returns the output scripts described by the passed scan
+// objects mapped to the descriptors describing them.  Ranged descriptors are
+// expanded over their range, which defaults to the indexes 0 through 999.
+func scanObjectScripts(scanObjects []btcjson.ScanObject,
+	params *chaincfg.Params) (map[string]string, error) {
+
+	scripts := make(map[string]string)
+	for _, obj := range scanObjects {
+		desc, err := descriptor.Parse(obj.Descriptor, params)
+		if err != nil {
+			return nil, fmt.Errorf("invalid descriptor %q: %v",
+				obj.Descriptor, err)
+		}
+
+		begin, end := 0, 0
+		if desc.IsRange() {
+			begin, end, err = filterDescriptorRange(obj.Range)
+			if err != nil {
+				return nil, fmt.Errorf("invalid range of "+
+					"descriptor %q: %v", obj.Descriptor, err)
+			}
+		}
+		for i := begin; i <= end; i++ {
+			script, err := desc.Script(uint32(i))
+			if err != nil {
+				return nil, fmt.Errorf("unable to derive script "+
+					"%d of descriptor %q: %v", i,
+					obj.Descriptor, err)
+			}
+			scripts[string(script)] = desc.String()
+		}
+	}
+	return scripts, nil
+}
+
+// scanTxOutSet implements the start action of the scantxoutset command.  The
+// utxo set is scanned for the outputs matching the passed scan objects.  When
+// not nil, the passed match function is invoked for every found output and the
+// passed progress function with the progress of the scan in percent.  The scan
+// is aborted when the passed close channel is closed.
+func (s *rpcServer) scanTxOutSet(scanObjects []btcjson.ScanObject,
+	match func(*btcjson.ScanTxOutSetUnspent), progress func(float64),
+	closeChan <-chan struct{}) (*btcjson.ScanTxOutSetResult, error) {
+
+	scripts, err := scanObjectScripts(scanObjects, s.cfg.ChainParams)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+
+	abort, ok := s.txOutSetScan.start()
+	if !ok {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCMisc,
+			Message: "Scan already in progress, use action " +
+				"\"abort\" or \"status\"",
+		}
+	}
+	defer s.txOutSetScan.finish()
+
+	// Abort the scan when the client disconnects.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-closeChan:
+			s.txOutSetScan.stop(abort)
+		case <-done:
+		}
+	}()
+
+	result := &btcjson.ScanTxOutSetResult{
+		Unspents: []btcjson.ScanTxOutSetUnspent{},
+	}
+	var totalAmount btcutil.Amount
+	scanFn := func(outpoint wire.OutPoint, entry *blockchain.UtxoEntry) error {
+		result.TxOuts++
+		desc, ok := scripts[string(entry.PkScript())]
+		if !ok {
+			return nil
+		}
+
+		unspent := btcjson.ScanTxOutSetUnspent{
+			TxID:         outpoint.Hash.String(),
+			Vout:         outpoint.Index,
+			ScriptPubKey: hex.EncodeToString(entry.PkScript()),
+			Desc:         desc,
+			Amount:       btcutil.Amount(entry.Amount()).ToBTC(),
+			Coinbase:     entry.IsCoinBase(),
+			Height:       entry.BlockHeight(),
+		}
+		totalAmount += btcutil.Amount(entry.Amount())
+		result.Unspents = append(result.Unspents, unspent)
+		if match != nil {
+			match(&unspent)
+		}
+		return nil
+	}
+	progressFn := func(p float64) {
+		s.txOutSetScan.setProgress(p * 100)
+		if progress != nil {
+			progress(p * 100)
+		}
+	}
+	hash, height, err := s.cfg.Chain.ScanUtxoSet(scanFn, progressFn, abort)
+	if err != nil && err != blockchain.ErrUtxoScanInterrupted {
+		context := "Failed to scan utxo set"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	result.Success = err == nil
+	result.Height = height
+	result.BestBlock = hash.String()
+	result.TotalAmount = totalAmount.ToBTC()
+	return result, nil
+}
+
+// handleScanTxOutSet implements the scantxoutset command.
+func handleScanTxOutSet(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return s.handleScanTxOutSetAction(cmd.(*btcjson.ScanTxOutSetCmd), nil,
+		nil, closeChan)
+}
+
+// handleScanTxOutSetAction performs the action of the passed scantxoutset
+// command.  The passed match and progress functions are only used by the start
+// action as described by scanTxOutSet.
+func (s *rpcServer) handleScanTxOutSetAction(c *btcjson.ScanTxOutSetCmd,
+	match func(*btcjson.ScanTxOutSetUnspent), progress func(float64),
+	closeChan <-chan struct{}) (interface{}, error) {
+
+	switch c.Action {
+	case "start":
+		if c.ScanObjects == nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: "scanobjects argument is required for the start action",
+			}
+		}
+		return s.scanTxOutSet(*c.ScanObjects, match, progress, closeChan)
+
+	case "abort":
+		return s.txOutSetScan.stop(nil), nil
+
+	case "status":
+		progress, ok := s.txOutSetScan.status()
+		if !ok {
+			return nil, nil
+		}
+		return &btcjson.ScanTxOutSetStatusResult{Progress: progress}, nil
+	}
+
+	return nil, &btcjson.RPCError{
+		Code:    btcjson.ErrRPCInvalidParameter,
+		Message: fmt.Sprintf("Invalid action %q", c.Action),
+	}
+}
+
 // handleSearchRawTransactions implements the searchrawtransactions command.
 func handleSearchRawTransactions(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	// Respond with an error if the address index is not enabled.
@@ -3566,6 +4711,203 @@ func handleSubmitBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 	return nil, nil
 }
 
+// decodeRawTransactions deserializes the passed hex-encoded raw transactions
+// of the testmempoolaccept and submitpackage commands, which may hold at most
+// the maximum number of transactions of a package.
+func decodeRawTransactions(rawTxns []string) ([]*btcutil.Tx, error) {
+	if len(rawTxns) == 0 || len(rawTxns) > mempool.MaxPackageCount {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Array must contain between 1 and "+
+				"%d transactions", mempool.MaxPackageCount),
+		}
+	}
+
+	txns := make([]*btcutil.Tx, 0, len(rawTxns))
+	for _, hexStr := range rawTxns {
+		if len(hexStr)%2 != 0 {
+			hexStr = "0" + hexStr
+		}
+		serializedTx, err := hex.DecodeString(hexStr)
+		if err != nil {
+			return nil, rpcDecodeHexError(hexStr)
+		}
+		var msgTx wire.MsgTx
+		err = msgTx.Deserialize(bytes.NewReader(serializedTx))
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCDeserialization,
+				Message: "TX decode failed: " + err.Error(),
+			}
+		}
+		txns = append(txns, btcutil.NewTx(&msgTx))
+	}
+
+	return txns, nil
+}
+
+// handleSubmitPackage implements the submitpackage command.
+func handleSubmitPackage(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.SubmitPackageCmd)
+
+	txns, err := decodeRawTransactions(c.RawTxs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Transactions of a package can't replace transactions in the pool, so
+	// the list of replaced transactions is always empty.
+	result := &btcjson.SubmitPackageResult{
+		TxResults:            make(map[string]btcjson.SubmitPackageTxResult),
+		ReplacedTransactions: []string{},
+	}
+
+	acceptedTxs, err := s.cfg.TxMemPool.ProcessTransactionPackage(txns)
+	if err != nil {
+		// A rule error means the package was simply rejected as
+		// opposed to something actually going wrong, so it is reported
+		// in the result.
+		if _, ok := err.(mempool.RuleError); !ok {
+			context := "Failed to process package"
+			return nil, internalRPCError(err.Error(), context)
+		}
+
+		rpcsLog.Debugf("Rejected package of %d transactions: %v",
+			len(txns), err)
+		result.PackageMsg = err.Error()
+		return result, nil
+	}
+
+	// Generate and relay inventory vectors for all newly accepted
+	// transactions and notify both websocket and getblocktemplate long
+	// poll clients of them.
+	s.cfg.ConnMgr.RelayTransactions(acceptedTxs)
+	s.NotifyNewTransactions(acceptedTxs)
+
+	// The transactions of the package which were newly accepted are
+	// validated with the fee rate of all of them, while transactions which
+	// were already in the pool keep their own.
+	accepted := make(map[chainhash.Hash]*mempool.TxDesc, len(acceptedTxs))
+	for _, txD := range acceptedTxs {
+		accepted[*txD.Tx.Hash()] = txD
+	}
+	var pkgFee, pkgSize int64
+	var pkgIncludes []string
+	for _, tx := range txns {
+		if txD, ok := accepted[*tx.Hash()]; ok {
+			pkgFee += txD.Fee
+			pkgSize += mempool.GetTxVirtualSize(tx)
+			pkgIncludes = append(pkgIncludes,
+				tx.WitnessHash().String())
+		}
+	}
+
+	for _, tx := range txns {
+		txResult := btcjson.SubmitPackageTxResult{
+			Txid: tx.Hash().String(),
+		}
+		txD, ok := accepted[*tx.Hash()]
+		switch {
+		case ok:
+			txResult.Vsize = int32(mempool.GetTxVirtualSize(tx))
+			txResult.Fees = &btcjson.MempoolFeesResult{
+				Base: btcutil.Amount(txD.Fee).ToBTC(),
+				EffectiveFeeRate: btcutil.Amount(
+					pkgFee * 1000 / pkgSize).ToBTC(),
+				EffectiveIncludes: pkgIncludes,
+			}
+
+		default:
+			// The transaction was already in the pool, possibly
+			// with a different witness.
+			entry, err := s.cfg.TxMemPool.MempoolEntry(tx.Hash())
+			if err != nil {
+				txResult.Error = err.Error()
+				break
+			}
+			if !entry.Tx.WitnessHash().IsEqual(tx.WitnessHash()) {
+				txResult.OtherWtxid = entry.Tx.WitnessHash().String()
+			}
+			vsize := mempool.GetTxVirtualSize(entry.Tx)
+			txResult.Vsize = int32(vsize)
+			txResult.Fees = &btcjson.MempoolFeesResult{
+				Base: btcutil.Amount(entry.Fee).ToBTC(),
+			}
+		}
+		result.TxResults[tx.WitnessHash().String()] = txResult
+	}
+
+	rpcsLog.Infof("Accepted package of %d transactions via submitpackage",
+		len(txns))
+	result.PackageMsg = "success"
+	return result, nil
+}
+
+// handleTestMempoolAccept implements the testmempoolaccept command.
+func handleTestMempoolAccept(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.TestMempoolAcceptCmd)
+
+	txns, err := decodeRawTransactions(c.RawTxns)
+	if err != nil {
+		return nil, err
+	}
+
+	// The maximum fee rate is given in BTC/kvB, where zero disables the
+	// check.
+	var maxFeeRate btcutil.Amount
+	if c.MaxFeeRate != nil {
+		maxFeeRate, err = btcutil.NewAmount(*c.MaxFeeRate)
+		if err != nil || maxFeeRate < 0 {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: "Invalid maxfeerate",
+			}
+		}
+	}
+
+	// Each transaction is checked on its own against the current state of
+	// the memory pool, so none of them may spend another one of the list.
+	results := make([]btcjson.TestMempoolAcceptResult, 0, len(txns))
+	for _, tx := range txns {
+		result := btcjson.TestMempoolAcceptResult{
+			Txid:  tx.Hash().String(),
+			Wtxid: tx.WitnessHash().String(),
+		}
+
+		acceptResult, err := s.cfg.TxMemPool.CheckMempoolAcceptance(tx)
+		switch {
+		case err != nil:
+			if _, ok := err.(mempool.RuleError); !ok {
+				context := "Failed to check transaction"
+				return nil, internalRPCError(err.Error(), context)
+			}
+			result.RejectReason = err.Error()
+
+		case len(acceptResult.MissingParents) > 0:
+			result.RejectReason = "missing-inputs"
+
+		default:
+			feeRate := acceptResult.TxFee * 1000 /
+				btcutil.Amount(acceptResult.TxSize)
+			if maxFeeRate != 0 && feeRate > maxFeeRate {
+				result.RejectReason = "max-fee-exceeded"
+				break
+			}
+
+			result.Allowed = true
+			result.Vsize = int32(acceptResult.TxSize)
+			result.Fees = &btcjson.MempoolFeesResult{
+				Base:              acceptResult.TxFee.ToBTC(),
+				EffectiveFeeRate:  feeRate.ToBTC(),
+				EffectiveIncludes: []string{result.Wtxid},
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
 // handleUptime implements the uptime command.
 func handleUptime(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	return time.Now().Unix() - s.cfg.StartupTime, nil
@@ -3753,14 +5095,14 @@ type rpcServer struct {
 	started                int32
 	shutdown               int32
 	cfg                    rpcserverConfig
-	authsha                [sha256.Size]byte
-	limitauthsha           [sha256.Size]byte
+	auth                   *rpcAuthenticator
 	ntfnMgr                *wsNotificationManager
 	numClients             int32
 	statusLines            map[int]string
 	statusLock             sync.RWMutex
 	wg                     sync.WaitGroup
 	gbtWorkState           *gbtWorkState
+	txOutSetScan           txOutSetScan
 	helpCacher             *helpCacher
 	requestProcessShutdown chan struct{}
 	quit                   chan int
@@ -3839,6 +5181,7 @@ func (s *rpcServer) Stop() error {
 	s.ntfnMgr.WaitForShutdown()
 	close(s.quit)
 	s.wg.Wait()
+	s.auth.removeCookie()
 	rpcsLog.Infof("RPC server shutdown complete")
 	return nil
 }
@@ -3860,10 +5203,17 @@ func (s *rpcServer) NotifyNewTransactions(txns []*mempool.TxDesc) {
 
 		// Potentially notify any getblocktemplate long poll clients
 		// about stale block templates due to the new transaction.
-		s.gbtWorkState.NotifyMempoolTx(s.cfg.TxMemPool.LastUpdated())
+		s.gbtWorkState.NotifyMempoolTx(s.cfg.TxMemPool.LastUpdated(),
+			txD.Fee)
 	}
 }
 
+// NotifyTxReplaced notifies websocket clients that the passed transaction was
+// evicted from the mempool by the passed replacement transaction.
+func (s *rpcServer) NotifyTxReplaced(replaced, replacement *btcutil.Tx) {
+	s.ntfnMgr.NotifyTxReplaced(replaced, replacement)
+}
+
 // limitConnections responds with a 503 service unavailable and returns true if
 // adding another client would exceed the maximum allow RPC clients.
 //
@@ -3898,47 +5248,41 @@ func (s *rpcServer) decrementClients() {
 	atomic.AddInt32(&s.numClients, -1)
 }
 
-// checkAuth checks the HTTP Basic authentication supplied by a wallet
-// or RPC client in the HTTP request r.  If the supplied authentication
-// does not match the username and password expected, a non-nil error is
-// returned.
+// checkAuth checks the authentication supplied by a wallet or RPC client in
+// the HTTP request r, which is either a verified TLS client certificate or HTTP
+// Basic authentication.  If the supplied authentication does not match any
+// user, a non-nil error is returned.
 //
-// This check is time-constant.
+// The check of the HTTP Basic authentication is time-constant.
 //
-// The first bool return value signifies auth success (true if successful) and
-// the second bool return value specifies whether the user can change the state
-// of the server (true) or whether the user is limited (false). The second is
-// always false if the first is.
-func (s *rpcServer) checkAuth(r *http.Request, require bool) (bool, bool, error) {
+// The returned user determines the methods the client may call.  It is nil if
+// no authentication is supplied and it is not required.
+func (s *rpcServer) checkAuth(r *http.Request, require bool) (*rpcUser, error) {
+	if user := s.auth.certUser(r.TLS); user != nil {
+		return user, nil
+	}
+
 	authhdr := r.Header["Authorization"]
 	if len(authhdr) <= 0 {
 		if require {
 			rpcsLog.Warnf("RPC authentication failure from %s",
 				r.RemoteAddr)
-			return false, false, errors.New("auth failure")
+			return nil, errors.New("auth failure")
 		}
 
-		return false, false, nil
-	}
-
-	authsha := sha256.Sum256([]byte(authhdr[0]))
-
-	// Check for limited auth first as in environments with limited users, those
-	// are probably expected to have a higher volume of calls
-	limitcmp := subtle.ConstantTimeCompare(authsha[:], s.limitauthsha[:])
-	if limitcmp == 1 {
-		return true, false, nil
+		return nil, nil
 	}
 
-	// Check for admin-level auth
-	cmp := subtle.ConstantTimeCompare(authsha[:], s.authsha[:])
-	if cmp == 1 {
-		return true, true, nil
+	username, password, ok := r.BasicAuth()
+	if ok {
+		if user := s.auth.authenticate(username, password); user != nil {
+			return user, nil
+		}
 	}
 
-	// Request's auth doesn't match either user
+	// Request's auth doesn't match any user
 	rpcsLog.Warnf("RPC authentication failure from %s", r.RemoteAddr)
-	return false, false, errors.New("auth failure")
+	return nil, errors.New("auth failure")
 }
 
 // parsedRPCCmd represents a JSON-RPC request object that has been parsed into
@@ -4028,16 +5372,14 @@ func createMarshalledReply(rpcVersion btcjson.RPCVersion, id interface{}, result
 
 // processRequest determines the incoming request type (single or batched),
 // parses it and returns a marshalled response.
-func (s *rpcServer) processRequest(request *btcjson.Request, isAdmin bool, closeChan <-chan struct{}) []byte {
+func (s *rpcServer) processRequest(request *btcjson.Request, user *rpcUser, closeChan <-chan struct{}) []byte {
 	var result interface{}
 	var err error
 	var jsonErr *btcjson.RPCError
 
-	if !isAdmin {
-		if _, ok := rpcLimited[request.Method]; !ok {
-			jsonErr = internalRPCError("limited user not "+
-				"authorized for this method", "")
-		}
+	if !user.isAllowed(request.Method) {
+		jsonErr = internalRPCError("user not authorized for this "+
+			"method", "")
 	}
 
 	if jsonErr == nil {
@@ -4091,7 +5433,7 @@ func (s *rpcServer) processRequest(request *btcjson.Request, isAdmin bool, close
 }
 
 // jsonRPCRead handles reading and responding to RPC messages.
-func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin bool) {
+func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, user *rpcUser) {
 	if atomic.LoadInt32(&s.shutdown) != 0 {
 		return
 	}
@@ -4190,7 +5532,7 @@ func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin
 			if req.ID == nil && !(cfg.RPCQuirks && req.Jsonrpc == "") {
 				return
 			}
-			resp = s.processRequest(&req, isAdmin, closeChan)
+			resp = s.processRequest(&req, user, closeChan)
 		}
 
 		if resp != nil {
@@ -4279,7 +5621,7 @@ func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin
 						continue
 					}
 
-					resp = s.processRequest(&req, isAdmin, closeChan)
+					resp = s.processRequest(&req, user, closeChan)
 					if resp != nil {
 						results = append(results, resp)
 					}
@@ -4364,19 +5706,19 @@ func (s *rpcServer) Start() {
 		// Keep track of the number of connected clients.
 		s.incrementClients()
 		defer s.decrementClients()
-		_, isAdmin, err := s.checkAuth(r, true)
+		user, err := s.checkAuth(r, true)
 		if err != nil {
 			jsonAuthFail(w)
 			return
 		}
 
 		// Read and respond to the request.
-		s.jsonRPCRead(w, r, isAdmin)
+		s.jsonRPCRead(w, r, user)
 	})
 
 	// Websocket endpoint.
 	rpcServeMux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
-		authenticated, isAdmin, err := s.checkAuth(r, false)
+		user, err := s.checkAuth(r, false)
 		if err != nil {
 			jsonAuthFail(w)
 			return
@@ -4393,9 +5735,14 @@ func (s *rpcServer) Start() {
 			http.Error(w, "400 Bad Request.", http.StatusBadRequest)
 			return
 		}
-		s.WebsocketHandler(ws, r.RemoteAddr, authenticated, isAdmin)
+		s.WebsocketHandler(ws, r.RemoteAddr, user)
 	})
 
+	// REST endpoints.
+	if cfg.Rest {
+		rpcServeMux.HandleFunc(restPathPrefix, s.restHandler)
+	}
+
 	for _, listener := range s.cfg.Listeners {
 		s.wg.Add(1)
 		go func(listener net.Listener) {
@@ -4516,7 +5863,7 @@ type rpcserverConnManager interface {
 
 	// NodeAddresses returns an array consisting node addresses which can
 	// potentially be used to find new nodes in the network.
-	NodeAddresses() []*wire.NetAddress
+	NodeAddresses() []*wire.NetAddressV2
 }
 
 // rpcserverSyncManager represents a sync manager for use with the RPC server.
@@ -4544,6 +5891,10 @@ type rpcserverSyncManager interface {
 	// current tip is reached, up to a max of wire.MaxBlockHeadersPerMsg
 	// hashes.
 	LocateHeaders(locators []*chainhash.Hash, hashStop *chainhash.Hash) []wire.BlockHeader
+
+	// PendingHeaderStats returns statistics about the headers of blocks
+	// which aren't connected to the block chain yet.
+	PendingHeaderStats() netsync.PendingHeaderStats
 }
 
 // rpcserverConfig is a descriptor containing the RPC server configuration.
@@ -4577,6 +5928,10 @@ type rpcserverConfig struct {
 	// TxMemPool defines the transaction memory pool to interact with.
 	TxMemPool *mempool.TxPool
 
+	// SaveMempool saves the transactions in the memory pool to the mempool
+	// file and returns its path.
+	SaveMempool func() (string, error)
+
 	// These fields allow the RPC server to interface with mining.
 	//
 	// Generator produces block templates and the CPUMiner solves them using
@@ -4587,9 +5942,10 @@ type rpcserverConfig struct {
 
 	// These fields define any optional indexes the RPC server can make use
 	// of to provide additional data when queried.
-	TxIndex   *indexers.TxIndex
-	AddrIndex *indexers.AddrIndex
-	CfIndex   *indexers.CfIndex
+	TxIndex    *indexers.TxIndex
+	AddrIndex  *indexers.AddrIndex
+	CfIndex    *indexers.CfIndex
+	TweakIndex *indexers.TweakIndex
 
 	// The fee estimator keeps track of how long transactions are left in
 	// the mempool before they are mined into blocks.
@@ -4601,21 +5957,16 @@ func newRPCServer(config *rpcserverConfig) (*rpcServer, error) {
 	rpc := rpcServer{
 		cfg:                    *config,
 		statusLines:            make(map[int]string),
-		gbtWorkState:           newGbtWorkState(config.TimeSource),
+		gbtWorkState:           newGbtWorkState(config.TimeSource, cfg.gbtFeeDelta),
 		helpCacher:             newHelpCacher(),
 		requestProcessShutdown: make(chan struct{}),
 		quit:                   make(chan int),
 	}
-	if cfg.RPCUser != "" && cfg.RPCPass != "" {
-		login := cfg.RPCUser + ":" + cfg.RPCPass
-		auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
-		rpc.authsha = sha256.Sum256([]byte(auth))
-	}
-	if cfg.RPCLimitUser != "" && cfg.RPCLimitPass != "" {
-		login := cfg.RPCLimitUser + ":" + cfg.RPCLimitPass
-		auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
-		rpc.limitauthsha = sha256.Sum256([]byte(auth))
+	auth, err := newRPCAuthenticator(cfg)
+	if err != nil {
+		return nil, err
 	}
+	rpc.auth = auth
 	rpc.ntfnMgr = newWsNotificationManager(&rpc)
 	rpc.cfg.Chain.Subscribe(rpc.handleBlockchainNotification)
 
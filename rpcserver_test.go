@@ -0,0 +1,302 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// TestCalcFeeRatePercentiles ensures the fee rate percentiles of the
+// getblockstats command are weighted by the transaction weights.
+func TestCalcFeeRatePercentiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		feeRates    []txFeeRate
+		totalWeight int64
+		want        []int64
+	}{{
+		name: "no transactions",
+		want: []int64{0, 0, 0, 0, 0},
+	}, {
+		name:        "single transaction",
+		feeRates:    []txFeeRate{{10, 400}},
+		totalWeight: 400,
+		want:        []int64{10, 10, 10, 10, 10},
+	}, {
+		name: "equal weights unsorted",
+		feeRates: []txFeeRate{
+			{4, 100}, {1, 100}, {5, 100}, {3, 100}, {2, 100},
+		},
+		totalWeight: 500,
+		want:        []int64{1, 2, 3, 4, 5},
+	}, {
+		name:        "dominating weight",
+		feeRates:    []txFeeRate{{100, 200}, {1, 800}},
+		totalWeight: 1000,
+		want:        []int64{1, 1, 1, 1, 100},
+	}}
+
+	for _, test := range tests {
+		got := calcFeeRatePercentiles(test.feeRates, test.totalWeight)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got percentiles %v, want %v", test.name,
+				got, test.want)
+		}
+	}
+}
+
+// TestCalcTruncatedMedian ensures the medians of the getblockstats command are
+// truncated to integers.
+func TestCalcTruncatedMedian(t *testing.T) {
+	tests := []struct {
+		values []int64
+		want   int64
+	}{
+		{values: nil, want: 0},
+		{values: []int64{7}, want: 7},
+		{values: []int64{3, 1, 2}, want: 2},
+		{values: []int64{4, 1}, want: 2},
+		{values: []int64{2, 1}, want: 1},
+	}
+
+	for _, test := range tests {
+		got := calcTruncatedMedian(test.values)
+		if got != test.want {
+			t.Errorf("median of %v: got %d, want %d", test.values,
+				got, test.want)
+		}
+	}
+}
+
+// blockStatsTestBlock returns a block with a coinbase paying to a spendable and
+// an unspendable output, a legacy transaction with a fee of 10000 satoshis and
+// a segwit transaction spending and creating a taproot output with a fee of
+// 1000 satoshis, along with the outputs spent by the block.
+func blockStatsTestBlock() (*btcutil.Block, [][]blockchain.SpentTxOut) {
+	p2pkh := append([]byte{0x76, 0xa9, 0x14}, make([]byte, 20)...)
+	p2pkh = append(p2pkh, 0x88, 0xac)
+	p2tr := append([]byte{0x51, 0x20}, make([]byte, 32)...)
+	nullData := []byte{0x6a, 0x04, 0xde, 0xad, 0xbe, 0xef}
+
+	coinbase := wire.NewMsgTx(1)
+	coinbase.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{},
+			wire.MaxPrevOutIndex),
+		SignatureScript: []byte{0x01, 0x01},
+		Sequence:        wire.MaxTxInSequenceNum,
+	})
+	coinbase.AddTxOut(wire.NewTxOut(50e8+11000, p2pkh))
+	coinbase.AddTxOut(wire.NewTxOut(0, nullData))
+
+	legacy := wire.NewMsgTx(1)
+	legacy.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0x01}, 0),
+		make([]byte, 107), nil))
+	legacy.AddTxOut(wire.NewTxOut(90000, p2pkh))
+
+	segwit := wire.NewMsgTx(2)
+	segwit.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0x02}, 1),
+		nil, wire.TxWitness{make([]byte, 64)}))
+	segwit.AddTxOut(wire.NewTxOut(49000, p2tr))
+
+	msgBlock := wire.NewMsgBlock(&wire.BlockHeader{
+		Timestamp: time.Unix(1700000000, 0),
+	})
+	msgBlock.AddTransaction(coinbase)
+	msgBlock.AddTransaction(legacy)
+	msgBlock.AddTransaction(segwit)
+
+	spentOutputs := [][]blockchain.SpentTxOut{
+		nil,
+		{{Amount: 100000, PkScript: p2pkh}},
+		{{Amount: 50000, PkScript: p2tr}},
+	}
+	return btcutil.NewBlock(msgBlock), spentOutputs
+}
+
+// TestCreateBlockStatsResult ensures the statistics of the getblockstats
+// command are calculated correctly for known blocks.
+func TestCreateBlockStatsResult(t *testing.T) {
+	params := &chaincfg.MainNetParams
+	genesis := btcutil.NewBlock(params.GenesisBlock)
+	block, spentOutputs := blockStatsTestBlock()
+	medianTime := time.Unix(1699999000, 0)
+
+	tests := []struct {
+		name         string
+		block        *btcutil.Block
+		height       int32
+		spentOutputs [][]blockchain.SpentTxOut
+		want         btcjson.GetBlockStatsResult
+	}{{
+		name:   "genesis block",
+		block:  genesis,
+		height: 0,
+		want: btcjson.GetBlockStatsResult{
+			FeeratePercentiles:     []int64{0, 0, 0, 0, 0},
+			Hash:                   params.GenesisHash.String(),
+			MedianTime:             medianTime.Unix(),
+			Outs:                   1,
+			Subsidy:                50e8,
+			Time:                   1231006505,
+			Txs:                    1,
+			UTXOIncrease:           1,
+			UTXOIncreaseActual:     1,
+			UTXOSizeIncrease:       117,
+			UTXOSizeIncreaseActual: 117,
+		},
+	}, {
+		name:   "block without spent outputs",
+		block:  block,
+		height: 100,
+		want: btcjson.GetBlockStatsResult{
+			AverageTxSize:          177,
+			FeeratePercentiles:     []int64{0, 0, 0, 0, 0},
+			Hash:                   block.Hash().String(),
+			Height:                 100,
+			Ins:                    2,
+			MaxTxSize:              192,
+			MedianTime:             medianTime.Unix(),
+			MedianTxSize:           177,
+			MinTxSize:              162,
+			Outs:                   4,
+			SegWitTotalSize:        162,
+			SegWitTotalWeight:      444,
+			SegWitTxs:              1,
+			Subsidy:                50e8,
+			TaprootOuts:            1,
+			Time:                   1700000000,
+			TotalOut:               139000,
+			TotalSize:              354,
+			TotalWeight:            1212,
+			Txs:                    3,
+			UTXOIncrease:           2,
+			UTXOIncreaseActual:     1,
+			UTXOSizeIncrease:       290,
+			UTXOSizeIncreaseActual: 234,
+		},
+	}, {
+		name:         "block with spent outputs",
+		block:        block,
+		height:       100,
+		spentOutputs: spentOutputs,
+		want: btcjson.GetBlockStatsResult{
+			AverageFee:             5500,
+			AverageFeeRate:         36,
+			AverageTxSize:          177,
+			FeeratePercentiles:     []int64{9, 9, 52, 52, 52},
+			Hash:                   block.Hash().String(),
+			Height:                 100,
+			Ins:                    2,
+			MaxFee:                 10000,
+			MaxFeeRate:             52,
+			MaxTxSize:              192,
+			MedianFee:              5500,
+			MedianTime:             medianTime.Unix(),
+			MedianTxSize:           177,
+			MinFee:                 1000,
+			MinFeeRate:             9,
+			MinTxSize:              162,
+			Outs:                   4,
+			SegWitTotalSize:        162,
+			SegWitTotalWeight:      444,
+			SegWitTxs:              1,
+			Subsidy:                50e8,
+			TaprootOuts:            1,
+			TaprootTxs:             1,
+			Time:                   1700000000,
+			TotalFee:               11000,
+			TotalOut:               139000,
+			TotalSize:              354,
+			TotalWeight:            1212,
+			Txs:                    3,
+			UTXOIncrease:           2,
+			UTXOIncreaseActual:     1,
+			UTXOSizeIncrease:       131,
+			UTXOSizeIncreaseActual: 75,
+		},
+	}}
+
+	for _, test := range tests {
+		got := createBlockStatsResult(test.block, test.height,
+			medianTime, test.spentOutputs, params)
+		if !reflect.DeepEqual(*got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, *got,
+				test.want)
+		}
+	}
+}
+
+// TestSelectBlockStats ensures only the selected statistics of the
+// getblockstats command are returned and invalid ones are rejected.
+func TestSelectBlockStats(t *testing.T) {
+	block, spentOutputs := blockStatsTestBlock()
+	result := createBlockStatsResult(block, 100, time.Unix(0, 0),
+		spentOutputs, &chaincfg.MainNetParams)
+
+	// All statistics are returned when none are selected.
+	all, err := selectBlockStats(result, nil)
+	if err != nil {
+		t.Fatalf("selectBlockStats: unexpected error: %v", err)
+	}
+	if all != result {
+		t.Fatalf("unexpected statistics %v", all)
+	}
+
+	tests := []struct {
+		name     string
+		selected []string
+		want     string
+		errCode  btcjson.RPCErrorCode
+	}{{
+		name:     "single statistic",
+		selected: []string{"totalfee"},
+		want:     `{"totalfee":11000}`,
+	}, {
+		name:     "multiple statistics",
+		selected: []string{"height", "feerate_percentiles", "minfee"},
+		want: `{"feerate_percentiles":[9,9,52,52,52],"height":100,` +
+			`"minfee":1000}`,
+	}, {
+		name:     "invalid statistic",
+		selected: []string{"height", "nosuchstat"},
+		errCode:  btcjson.ErrRPCInvalidParameter,
+	}}
+
+	for _, test := range tests {
+		stats, err := selectBlockStats(result, test.selected)
+		if test.errCode != 0 {
+			rpcErr, ok := err.(*btcjson.RPCError)
+			if !ok || rpcErr.Code != test.errCode {
+				t.Errorf("%s: unexpected error %v", test.name,
+					err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		got, err := json.Marshal(stats)
+		if err != nil {
+			t.Errorf("%s: unable to marshal statistics: %v",
+				test.name, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("%s: got statistics %s, want %s", test.name,
+				got, test.want)
+		}
+	}
+}
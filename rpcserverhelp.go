@@ -80,6 +80,13 @@ var helpDescsEnUS = map[string]string{
 	"vin-scriptSig":   "The signature script used to redeem the origin transaction as a JSON object (non-coinbase txns only)",
 	"vin-txinwitness": "The witness used to redeem the input encoded as a string array of its items",
 	"vin-sequence":    "The script sequence number",
+	"vin-prevout":     "The output spent by the input (only for getblock with verbosity=3 and getrawtransaction with verbose=2)",
+
+	// SpentOutputResult help.
+	"spentoutputresult-generated":    "Whether the spent output was created by a coinbase transaction",
+	"spentoutputresult-height":       "The height of the block containing the spent output",
+	"spentoutputresult-value":        "The amount of the spent output in BTC",
+	"spentoutputresult-scriptPubKey": "The public key script of the spent output",
 
 	// ScriptPubKeyResult help.
 	"scriptpubkeyresult-asm":       "Disassembly of the script",
@@ -115,6 +122,19 @@ var helpDescsEnUS = map[string]string{
 	"decodescript--synopsis": "Returns a JSON object with information about the provided hex-encoded script.",
 	"decodescript-hexscript": "Hex-encoded script",
 
+	// DumpTxOutSetCmd help.
+	"dumptxoutset--synopsis": "Writes a snapshot of the unspent transaction output set at the current best block to a file.\n" +
+		"The snapshot can be used to bootstrap the chain state of a new node with the --loadutxosnapshot option.",
+	"dumptxoutset-path": "Path of the snapshot file, relative to the data directory if not absolute",
+
+	// DumpTxOutSetResult help.
+	"dumptxoutsetresult-coins_written": "The number of unspent transaction outputs written",
+	"dumptxoutsetresult-base_hash":     "The hash of the block the snapshot was taken at",
+	"dumptxoutsetresult-base_height":   "The height of the block the snapshot was taken at",
+	"dumptxoutsetresult-path":          "The absolute path of the snapshot file",
+	"dumptxoutsetresult-txoutset_hash": "The hash of the unspent transaction output set, used to verify the snapshot when loading it",
+	"dumptxoutsetresult-nchaintx":      "The total number of transactions in the chain up to and including the base block",
+
 	// EstimateFeeCmd help.
 	"estimatefee--synopsis": "Estimate the fee per kilobyte in satoshis " +
 		"required for a transaction to be mined before a certain number of " +
@@ -124,6 +144,17 @@ var helpDescsEnUS = map[string]string{
 	"estimatefee--result0": "Estimated fee per kilobyte in satoshis for a block to " +
 		"be mined in the next NumBlocks blocks.",
 
+	// EstimateSmartFeeCmd help.
+	"estimatesmartfee--synopsis": "Estimate the fee per kilobyte in bitcoins required for a transaction to be confirmed\n" +
+		"within a certain number of blocks, based on how quickly transactions with different fee rates were confirmed.",
+	"estimatesmartfee-conftarget":   "The number of blocks the transaction should be confirmed within (1 to 1008)",
+	"estimatesmartfee-estimatemode": "The estimation mode, either ECONOMICAL or CONSERVATIVE, where CONSERVATIVE also considers the fee rates required over a longer history",
+
+	// EstimateSmartFeeResult help.
+	"estimatesmartfeeresult-feerate": "The estimated fee per kilobyte in bitcoins, omitted if no estimate is available",
+	"estimatesmartfeeresult-errors":  "The errors encountered while estimating the fee",
+	"estimatesmartfeeresult-blocks":  "The number of blocks the estimate is for, which is lower than the requested target if not enough blocks were observed",
+
 	// GenerateCmd help
 	"generate--synopsis": "Generates a set number of blocks (simnet or regtest only) and returns a JSON\n" +
 		" array of their hashes.",
@@ -162,7 +193,7 @@ var helpDescsEnUS = map[string]string{
 	// GetBlockCmd help.
 	"getblock--synopsis":   "Returns information about a block given its hash.",
 	"getblock-hash":        "The hash of the block",
-	"getblock-verbosity":   "Specifies whether the block data should be returned as a hex-encoded string (0), as parsed data with a slice of TXIDs (1), or as parsed data with parsed transaction data (2) ",
+	"getblock-verbosity":   "Specifies whether the block data should be returned as a hex-encoded string (0), as parsed data with a slice of TXIDs (1), or as parsed data with parsed transaction data and their fees (2), or additionally with the outputs spent by each input (3)",
 	"getblock--condition0": "verbosity=0",
 	"getblock--condition1": "verbosity=1",
 	"getblock--result0":    "Hex-encoded bytes of the serialized block",
@@ -212,6 +243,7 @@ var helpDescsEnUS = map[string]string{
 	"txrawresult-locktime":      "The transaction lock time",
 	"txrawresult-vin":           "The transaction inputs as JSON objects",
 	"txrawresult-vout":          "The transaction outputs as JSON objects",
+	"txrawresult-fee":           "The fee of the transaction in BTC (only when the spent outputs are available)",
 	"txrawresult-blockhash":     "Hash of the block the transaction is part of",
 	"txrawresult-confirmations": "Number of confirmations of the block",
 	"txrawresult-time":          "Transaction time in seconds since 1 Jan 1970 GMT",
@@ -246,7 +278,7 @@ var helpDescsEnUS = map[string]string{
 	"getblockverboseresult-versionHex":        "The block version in hexadecimal",
 	"getblockverboseresult-merkleroot":        "Root hash of the merkle tree",
 	"getblockverboseresult-tx":                "The transaction hashes (only when verbosity=1)",
-	"getblockverboseresult-rawtx":             "The transactions as JSON objects (only when verbosity=2)",
+	"getblockverboseresult-rawtx":             "The transactions as JSON objects (only when verbosity>=2)",
 	"getblockverboseresult-time":              "The block time in seconds since 1 Jan 1970 GMT",
 	"getblockverboseresult-nonce":             "The block nonce",
 	"getblockverboseresult-bits":              "The bits which represent the block difficulty",
@@ -287,9 +319,52 @@ var helpDescsEnUS = map[string]string{
 	"getblockheaderverboseresult-previousblockhash": "The hash of the previous block",
 	"getblockheaderverboseresult-nextblockhash":     "The hash of the next block (only if there is one)",
 
+	// GetBlockStatsCmd help.
+	"getblockstats--synopsis": "Returns statistics of a block in the main chain calculated from the block and the outputs it spends.\n" +
+		"Amounts are in satoshis and fee rates in satoshis per virtual byte.\n" +
+		"The spent outputs are only loaded when a statistic requiring them is selected.  They are not available for blocks up to the snapshot block of a chain state loaded from a utxo snapshot.",
+	"getblockstats-hashorheight": "The hash or the height of the block",
+	"getblockstats-stats":        "The statistics to return, identified by their field names (default all)",
+	"hashorheight-value":         "The hash or the height of the block",
+
+	// GetBlockStatsResult help.
+	"getblockstatsresult-avgfee":               "The average fee of the transactions in the block",
+	"getblockstatsresult-avgfeerate":           "The average fee rate of the transactions in the block",
+	"getblockstatsresult-avgtxsize":            "The average size of the transactions in the block",
+	"getblockstatsresult-feerate_percentiles":  "The 10th, 25th, 50th, 75th and 90th percentiles of the fee rates weighted by transaction weight",
+	"getblockstatsresult-blockhash":            "The hash of the block",
+	"getblockstatsresult-height":               "The height of the block",
+	"getblockstatsresult-ins":                  "The number of inputs, excluding the coinbase input",
+	"getblockstatsresult-maxfee":               "The maximum fee of a transaction in the block",
+	"getblockstatsresult-maxfeerate":           "The maximum fee rate of a transaction in the block",
+	"getblockstatsresult-maxtxsize":            "The maximum size of a transaction in the block",
+	"getblockstatsresult-medianfee":            "The median fee of the transactions in the block",
+	"getblockstatsresult-mediantime":           "The median time of the block",
+	"getblockstatsresult-mediantxsize":         "The median size of the transactions in the block",
+	"getblockstatsresult-minfee":               "The minimum fee of a transaction in the block",
+	"getblockstatsresult-minfeerate":           "The minimum fee rate of a transaction in the block",
+	"getblockstatsresult-mintxsize":            "The minimum size of a transaction in the block",
+	"getblockstatsresult-outs":                 "The number of outputs, including the coinbase outputs",
+	"getblockstatsresult-swtotal_size":         "The total size of the segwit transactions",
+	"getblockstatsresult-swtotal_weight":       "The total weight of the segwit transactions",
+	"getblockstatsresult-swtxs":                "The number of segwit transactions",
+	"getblockstatsresult-subsidy":              "The block subsidy",
+	"getblockstatsresult-taproot_outs":         "The number of taproot outputs, including the coinbase outputs",
+	"getblockstatsresult-taproot_txs":          "The number of transactions spending at least one taproot output",
+	"getblockstatsresult-time":                 "The block time in seconds since 1 Jan 1970 GMT",
+	"getblockstatsresult-totalfee":             "The total fee of the transactions in the block",
+	"getblockstatsresult-total_out":            "The total amount of the outputs, excluding the coinbase outputs",
+	"getblockstatsresult-total_size":           "The total size of the transactions, excluding the coinbase transaction",
+	"getblockstatsresult-total_weight":         "The total weight of the transactions, excluding the coinbase transaction",
+	"getblockstatsresult-txs":                  "The number of transactions, including the coinbase transaction",
+	"getblockstatsresult-utxo_increase":        "The increase of the number of unspent transaction outputs",
+	"getblockstatsresult-utxo_increase_actual": "The increase of the number of unspent transaction outputs, excluding unspendable outputs",
+	"getblockstatsresult-utxo_size_inc":        "The increase of the size of the unspent transaction output set",
+	"getblockstatsresult-utxo_size_inc_actual": "The increase of the size of the unspent transaction output set, excluding unspendable outputs",
+
 	// TemplateRequest help.
 	"templaterequest-mode":         "This is 'template', 'proposal', or omitted",
-	"templaterequest-capabilities": "List of capabilities",
+	"templaterequest-capabilities": "List of capabilities including the supported mutations, which restrict the mutations allowed by the server to the ones supported by both sides",
 	"templaterequest-longpollid":   "The long poll ID of a job to monitor for expiration; required and valid only for long poll requests ",
 	"templaterequest-sigoplimit":   "Number of signature operations allowed in blocks (this parameter is ignored)",
 	"templaterequest-sizelimit":    "Number of bytes allowed in blocks (this parameter is ignored)",
@@ -418,6 +493,34 @@ var helpDescsEnUS = map[string]string{
 	// GetInfoCmd help.
 	"getinfo--synopsis": "Returns a JSON object containing various state info.",
 
+	// GetMempoolEntryCmd help.
+	"getmempoolentry--synopsis": "Returns information about a transaction in the memory pool along with its unconfirmed ancestors and descendants.",
+	"getmempoolentry-txid":      "The hash of the transaction",
+
+	// GetMempoolEntryResult help.
+	"getmempoolentryresult-vsize":           "The virtual size of the transaction",
+	"getmempoolentryresult-size":            "The size of the transaction in bytes",
+	"getmempoolentryresult-weight":          "The weight of the transaction",
+	"getmempoolentryresult-fee":             "The fee of the transaction in bitcoins",
+	"getmempoolentryresult-modifiedfee":     "The fee of the transaction in bitcoins used for mining priority, which is the same as the fee",
+	"getmempoolentryresult-time":            "Local time transaction entered pool in seconds since 1 Jan 1970 GMT",
+	"getmempoolentryresult-height":          "Block height when transaction entered the pool",
+	"getmempoolentryresult-descendantcount": "The number of unconfirmed descendants in the pool, including the transaction",
+	"getmempoolentryresult-descendantsize":  "The virtual size of the unconfirmed descendants in the pool, including the transaction",
+	"getmempoolentryresult-descendantfees":  "The fees of the unconfirmed descendants in the pool in bitcoins, including the transaction",
+	"getmempoolentryresult-ancestorcount":   "The number of unconfirmed ancestors in the pool, including the transaction",
+	"getmempoolentryresult-ancestorsize":    "The virtual size of the unconfirmed ancestors in the pool, including the transaction",
+	"getmempoolentryresult-ancestorfees":    "The fees of the unconfirmed ancestors in the pool in bitcoins, including the transaction",
+	"getmempoolentryresult-wtxid":           "The witness hash of the transaction",
+	"getmempoolentryresult-fees":            "The fees of the transaction in bitcoins",
+	"getmempoolentryresult-depends":         "Unconfirmed transactions used as inputs for this transaction",
+
+	// MempoolFees help.
+	"mempoolfees-base":       "The fee of the transaction in bitcoins",
+	"mempoolfees-modified":   "The fee of the transaction in bitcoins used for mining priority",
+	"mempoolfees-ancestor":   "The fees of the unconfirmed ancestors in bitcoins, including the transaction",
+	"mempoolfees-descendant": "The fees of the unconfirmed descendants in bitcoins, including the transaction",
+
 	// GetMempoolInfoCmd help.
 	"getmempoolinfo--synopsis": "Returns memory pool information",
 
@@ -490,6 +593,20 @@ var helpDescsEnUS = map[string]string{
 	"getpeerinforesult-feefilter":      "The requested minimum fee a transaction must have to be announced to the peer",
 	"getpeerinforesult-syncnode":       "Whether or not the peer is the sync peer",
 
+	// GetOrphanInfoCmd help.
+	"getorphaninfo--synopsis": "Returns statistics about the orphan blocks and the headers of blocks which don't connect to the block chain yet.",
+
+	// GetOrphanInfoResult help.
+	"getorphaninforesult-orphans":           "Number of orphan blocks",
+	"getorphaninforesult-bytes":             "Total serialized size in bytes of the orphan blocks",
+	"getorphaninforesult-added":             "Total number of orphan blocks which were added",
+	"getorphaninforesult-processed":         "Total number of orphan blocks which were processed once their parents arrived",
+	"getorphaninforesult-evicted":           "Total number of orphan blocks which were evicted to make room for new ones",
+	"getorphaninforesult-expired":           "Total number of orphan blocks which expired before their parents arrived",
+	"getorphaninforesult-pendingheaders":    "Number of headers of requested blocks which don't connect to the block chain yet",
+	"getorphaninforesult-evictedheaders":    "Total number of pending headers which were evicted to make room for new ones",
+	"getorphaninforesult-unconnectedblocks": "Total number of blocks which were dropped since neither they nor their headers connected to the block chain",
+
 	// GetPeerInfoCmd help.
 	"getpeerinfo--synopsis": "Returns data about each connected network peer as an array of json objects.",
 
@@ -514,11 +631,22 @@ var helpDescsEnUS = map[string]string{
 	// GetRawTransactionCmd help.
 	"getrawtransaction--synopsis":   "Returns information about a transaction given its hash.",
 	"getrawtransaction-txid":        "The hash of the transaction",
-	"getrawtransaction-verbose":     "Specifies the transaction is returned as a JSON object instead of a hex-encoded string",
+	"getrawtransaction-verbose":     "Specifies the transaction is returned as a hex-encoded string (0), as a JSON object (1), or as a JSON object with its fee and the outputs spent by its inputs (2)",
 	"getrawtransaction--condition0": "verbose=false",
 	"getrawtransaction--condition1": "verbose=true",
 	"getrawtransaction--result0":    "Hex-encoded bytes of the serialized transaction",
 
+	// GetSilentPaymentTweaksCmd help.
+	"getsilentpaymenttweaks--synopsis": "Returns the BIP-352 silent payments tweaks of the eligible transactions of each main chain block in a range of heights.\n" +
+		"Light clients scan for silent payments by multiplying each tweak with their scan key.",
+	"getsilentpaymenttweaks-startheight": "The height of the first block",
+	"getsilentpaymenttweaks-endheight":   "The height of the last block",
+
+	// GetSilentPaymentTweaksResult help.
+	"getsilentpaymenttweaksresult-hash":   "The hash of the block",
+	"getsilentpaymenttweaksresult-height": "The height of the block",
+	"getsilentpaymenttweaksresult-tweaks": "The hex-encoded tweaks of the eligible transactions of the block in the order they appear in it",
+
 	// GetTxOutResult help.
 	"gettxoutresult-bestblock":     "The block hash that contains the transaction output",
 	"gettxoutresult-confirmations": "The number of confirmations",
@@ -541,10 +669,63 @@ var helpDescsEnUS = map[string]string{
 	"help--result0":    "List of commands",
 	"help--result1":    "Help for specified command",
 
+	// InvalidateBlockCmd help.
+	"invalidateblock--synopsis": "Permanently marks a block and all of its descendants as invalid, as if they violated a consensus rule.\n" +
+		"The chain is reorganized to the remaining valid chain with the most work when the block is part of the main chain.",
+	"invalidateblock-blockhash": "The hash of the block to mark as invalid",
+
 	// PingCmd help.
 	"ping--synopsis": "Queues a ping to be sent to each connected peer.\n" +
 		"Ping times are provided by getpeerinfo via the pingtime and pingwait fields.",
 
+	// ReconsiderBlockCmd help.
+	"reconsiderblock--synopsis": "Removes the invalid status set by invalidateblock from a block along with its ancestors and descendants.\n" +
+		"The chain is reorganized to the valid chain with the most work, validating the reconsidered blocks again as needed.",
+	"reconsiderblock-blockhash": "The hash of the block to reconsider",
+
+	// SaveMempoolCmd help.
+	"savemempool--synopsis": "Saves the transactions in the memory pool to disk, so they are loaded again on the next start.\n" +
+		"Fails while the transactions saved on the last shutdown are still being loaded.",
+
+	// SaveMempoolResult help.
+	"savemempoolresult-filename": "The path of the file the transactions were saved to",
+
+	// ScanTxOutSetCmd help.
+	"scantxoutset--synopsis": "Scans the unspent transaction output set for outputs matching output descriptors.\n" +
+		"Raw output scripts are matched with raw() descriptors.\n" +
+		"Only a single scan may be active at a time.  The scan is aborted when the client disconnects.\n" +
+		"Websocket clients are sent every found output as a scantxoutsetmatch notification and the progress of the scan as scantxoutsetprogress notifications.",
+	"scantxoutset-action":      "The action to execute: \"start\" to start a scan, \"abort\" to abort the active scan or \"status\" to get the progress of the active scan",
+	"scantxoutset-scanobjects": "Array of output descriptors to scan for, either as descriptor strings or objects with the descriptor and its range (required for \"start\")",
+	"scantxoutset--condition0": "action=start",
+	"scantxoutset--condition1": "action=status",
+	"scantxoutset--condition2": "action=abort",
+	"scantxoutset--result2":    "Whether or not a scan was aborted",
+
+	// ScanObject help.
+	"scanobject-desc":  "The output descriptor",
+	"scanobject-range": "The end or the [begin,end] range of indexes a ranged descriptor is expanded over (default [0,999])",
+
+	// ScanTxOutSetResult help.
+	"scantxoutsetresult-success":      "Whether or not the scan completed without being aborted",
+	"scantxoutsetresult-txouts":       "The number of unspent transaction outputs scanned",
+	"scantxoutsetresult-height":       "The height of the block the unspent transaction output set was scanned at",
+	"scantxoutsetresult-bestblock":    "The hash of the block the unspent transaction output set was scanned at",
+	"scantxoutsetresult-unspents":     "The unspent transaction outputs matching the scan objects",
+	"scantxoutsetresult-total_amount": "The total amount of the found outputs in BTC",
+
+	// ScanTxOutSetUnspent help.
+	"scantxoutsetunspent-txid":         "The hash of the transaction of the output",
+	"scantxoutsetunspent-vout":         "The index of the output",
+	"scantxoutsetunspent-scriptPubKey": "The hex-encoded public key script of the output",
+	"scantxoutsetunspent-desc":         "The descriptor of the scan object matching the output",
+	"scantxoutsetunspent-amount":       "The amount of the output in BTC",
+	"scantxoutsetunspent-coinbase":     "Whether or not the output was created by a coinbase transaction",
+	"scantxoutsetunspent-height":       "The height of the block containing the transaction of the output",
+
+	// ScanTxOutSetStatusResult help.
+	"scantxoutsetstatusresult-progress": "The progress of the active scan in percent",
+
 	// SearchRawTransactionsCmd help.
 	"searchrawtransactions--synopsis": "Returns raw data for transactions involving the passed address.\n" +
 		"Returned transactions are pulled from both the database, and transactions currently in the mempool.\n" +
@@ -595,6 +776,47 @@ var helpDescsEnUS = map[string]string{
 	"submitblock--condition1": "Block rejected",
 	"submitblock--result1":    "The reason the block was rejected",
 
+	// MempoolFeesResult help.
+	"mempoolfeesresult-base":               "The fee paid by the transaction in BTC",
+	"mempoolfeesresult-effective-feerate":  "The fee rate in BTC/kvB the transaction was validated with, which is the fee rate of the package for transactions accepted as part of one",
+	"mempoolfeesresult-effective-includes": "The wtxids of the transactions whose fees and sizes are included in the effective fee rate",
+
+	// SubmitPackageTxResult help.
+	"submitpackagetxresult-txid":        "The hash of the transaction",
+	"submitpackagetxresult-other-wtxid": "The wtxid of a transaction with the same txid but a different witness which is already in the memory pool",
+	"submitpackagetxresult-vsize":       "The virtual size of the transaction in the memory pool",
+	"submitpackagetxresult-fees":        "The fees of the transaction",
+	"submitpackagetxresult-error":       "The reason the transaction could not be evaluated",
+
+	// SubmitPackageResult help.
+	"submitpackageresult-package_msg":           "The string 'success' when the package was accepted or the reason it was rejected",
+	"submitpackageresult-tx-results":            "The results of the transactions of the package, which is empty if the package was rejected",
+	"submitpackageresult-tx-results--key":       "wtxid",
+	"submitpackageresult-tx-results--value":     "An object describing the transaction with the witness hash",
+	"submitpackageresult-tx-results--desc":      "The results of the transactions keyed by their witness hash",
+	"submitpackageresult-replaced-transactions": "The hashes of the transactions replaced by the package, which is always empty since packages may not replace transactions",
+
+	// SubmitPackageCmd help.
+	"submitpackage--synopsis": "Submits a package of serialized, hex-encoded transactions to the memory pool and relays them to the network.\n" +
+		"The package must consist of a child transaction, which must be the last one, and its parents sorted such that no transaction spends a transaction which comes after it.\n" +
+		"The fee rate of the package as a whole must satisfy the fee policy of the memory pool, which allows a child to pay for parents which would not be accepted on their own.",
+	"submitpackage-rawtxs": "The serialized, hex-encoded transactions of the package",
+
+	// TestMempoolAcceptResult help.
+	"testmempoolacceptresult-txid":          "The hash of the transaction",
+	"testmempoolacceptresult-wtxid":         "The witness hash of the transaction",
+	"testmempoolacceptresult-package-error": "The reason the transactions were rejected as a package (currently unused)",
+	"testmempoolacceptresult-allowed":       "Whether or not the transaction would be accepted to the memory pool",
+	"testmempoolacceptresult-vsize":         "The virtual size of the transaction (only when allowed is true)",
+	"testmempoolacceptresult-fees":          "The fees of the transaction (only when allowed is true)",
+	"testmempoolacceptresult-reject-reason": "The reason the transaction would be rejected (only when allowed is false)",
+
+	// TestMempoolAcceptCmd help.
+	"testmempoolaccept--synopsis": "Returns whether the serialized, hex-encoded transactions would be accepted to the memory pool without submitting them.\n" +
+		"Each transaction is checked on its own against the current memory pool, so the transactions may not spend each other.",
+	"testmempoolaccept-rawtxns":    "The serialized, hex-encoded transactions to check",
+	"testmempoolaccept-maxfeerate": "Reject transactions whose fee rate in BTC/kvB is higher than this value, or 0 to accept any fee rate",
+
 	// ValidateAddressResult help.
 	"validateaddresschainresult-isvalid":         "Whether or not the address is valid",
 	"validateaddresschainresult-address":         "The bitcoin address (only when isvalid is true)",
@@ -637,7 +859,7 @@ var helpDescsEnUS = map[string]string{
 	"stopnotifyblocks--synopsis": "Cancel registered notifications for whenever a block is connected or disconnected from the main (best) chain.",
 
 	// NotifyNewTransactionsCmd help.
-	"notifynewtransactions--synopsis": "Send either a txaccepted or a txacceptedverbose notification when a new transaction is accepted into the mempool.",
+	"notifynewtransactions--synopsis": "Send either a txaccepted or a txacceptedverbose notification when a new transaction is accepted into the mempool and a txreplaced notification when a transaction is evicted from it by a replacement.",
 	"notifynewtransactions-verbose":   "Specifies which type of notification to receive. If verbose is true, then the caller receives txacceptedverbose, otherwise the caller receives txaccepted",
 
 	// StopNotifyNewTransactionsCmd help.
@@ -669,6 +891,23 @@ var helpDescsEnUS = map[string]string{
 	"loadtxfilter-reload":    "Load a new filter instead of adding data to an existing one",
 	"loadtxfilter-addresses": "Array of addresses to add to the transaction filter",
 	"loadtxfilter-outpoints": "Array of outpoints to add to the transaction filter",
+	"loadtxfilter-options":   "Output scripts, descriptors and silent payments addresses to add to the transaction filter",
+
+	// LoadTxFilterOptions help.
+	"loadtxfilteroptions-scripts":           "Array of hex-encoded output scripts to add to the transaction filter",
+	"loadtxfilteroptions-descriptors":       "Array of output descriptors whose scripts are added to the transaction filter",
+	"loadtxfilteroptions-silentpaymentkeys": "Array of silent payments addresses whose outputs are matched by scanning transactions",
+
+	// FilterDescriptor help.
+	"filterdescriptor-desc":  "The output descriptor",
+	"filterdescriptor-range": "The end or the [begin,end] range of indexes a ranged descriptor is expanded over (default [0,999])",
+
+	// DescriptorRange help.
+	"descriptorrange-value": "The end index or the [begin,end] range of indexes",
+
+	// SilentPaymentKey help.
+	"silentpaymentkey-scankey":  "The hex-encoded private scan key",
+	"silentpaymentkey-spendkey": "The hex-encoded compressed public spend key",
 
 	// Rescan help.
 	"rescan--synopsis": "Rescan block chain for transactions to addresses.\n" +
@@ -717,7 +956,9 @@ var rpcResultTypes = map[string][]interface{}{
 	"debuglevel":             {(*string)(nil), (*string)(nil)},
 	"decoderawtransaction":   {(*btcjson.TxRawDecodeResult)(nil)},
 	"decodescript":           {(*btcjson.DecodeScriptResult)(nil)},
+	"dumptxoutset":           {(*btcjson.DumpTxOutSetResult)(nil)},
 	"estimatefee":            {(*float64)(nil)},
+	"estimatesmartfee":       {(*btcjson.EstimateSmartFeeResult)(nil)},
 	"generate":               {(*[]string)(nil)},
 	"getaddednodeinfo":       {(*[]string)(nil), (*[]btcjson.GetAddedNodeInfoResult)(nil)},
 	"getbestblock":           {(*btcjson.GetBestBlockResult)(nil)},
@@ -726,6 +967,7 @@ var rpcResultTypes = map[string][]interface{}{
 	"getblockcount":          {(*int64)(nil)},
 	"getblockhash":           {(*string)(nil)},
 	"getblockheader":         {(*string)(nil), (*btcjson.GetBlockHeaderVerboseResult)(nil)},
+	"getblockstats":          {(*btcjson.GetBlockStatsResult)(nil)},
 	"getblocktemplate":       {(*btcjson.GetBlockTemplateResult)(nil), (*string)(nil), nil},
 	"getblockchaininfo":      {(*btcjson.GetBlockChainInfoResult)(nil)},
 	"getcfilter":             {(*string)(nil)},
@@ -737,24 +979,33 @@ var rpcResultTypes = map[string][]interface{}{
 	"gethashespersec":        {(*float64)(nil)},
 	"getheaders":             {(*[]string)(nil)},
 	"getinfo":                {(*btcjson.InfoChainResult)(nil)},
+	"getmempoolentry":        {(*btcjson.GetMempoolEntryResult)(nil)},
 	"getmempoolinfo":         {(*btcjson.GetMempoolInfoResult)(nil)},
 	"getmininginfo":          {(*btcjson.GetMiningInfoResult)(nil)},
 	"getnettotals":           {(*btcjson.GetNetTotalsResult)(nil)},
 	"getnetworkhashps":       {(*int64)(nil)},
 	"getnodeaddresses":       {(*[]btcjson.GetNodeAddressesResult)(nil)},
+	"getorphaninfo":          {(*btcjson.GetOrphanInfoResult)(nil)},
 	"getpeerinfo":            {(*[]btcjson.GetPeerInfoResult)(nil)},
 	"getrawmempool":          {(*[]string)(nil), (*btcjson.GetRawMempoolVerboseResult)(nil)},
 	"getrawtransaction":      {(*string)(nil), (*btcjson.TxRawResult)(nil)},
+	"getsilentpaymenttweaks": {(*[]btcjson.GetSilentPaymentTweaksResult)(nil)},
 	"gettxout":               {(*btcjson.GetTxOutResult)(nil)},
 	"node":                   nil,
 	"help":                   {(*string)(nil), (*string)(nil)},
+	"invalidateblock":        nil,
 	"ping":                   nil,
+	"reconsiderblock":        nil,
+	"savemempool":            {(*btcjson.SaveMempoolResult)(nil)},
+	"scantxoutset":           {(*btcjson.ScanTxOutSetResult)(nil), (*btcjson.ScanTxOutSetStatusResult)(nil), (*bool)(nil)},
 	"searchrawtransactions":  {(*string)(nil), (*[]btcjson.SearchRawTransactionsResult)(nil)},
 	"sendrawtransaction":     {(*string)(nil)},
 	"setgenerate":            nil,
 	"signmessagewithprivkey": {(*string)(nil)},
 	"stop":                   {(*string)(nil)},
 	"submitblock":            {nil, (*string)(nil)},
+	"submitpackage":          {(*btcjson.SubmitPackageResult)(nil)},
+	"testmempoolaccept":      {(*[]btcjson.TestMempoolAcceptResult)(nil)},
 	"uptime":                 {(*int64)(nil)},
 	"validateaddress":        {(*btcjson.ValidateAddressChainResult)(nil)},
 	"verifychain":            {(*bool)(nil)},
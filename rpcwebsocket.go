@@ -8,24 +8,26 @@ package main
 import (
 	"bytes"
 	"container/list"
-	"crypto/sha256"
-	"crypto/subtle"
-	"encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"math"
+	"math/big"
 	"sync"
 	"time"
 
 	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/blockchain/indexers"
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/btcjson"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/database"
 	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/txscript/descriptor"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/websocket"
@@ -76,6 +78,7 @@ var wsHandlersBeforeInit = map[string]wsCommandHandler{
 	"stopnotifyreceived":        handleStopNotifyReceived,
 	"rescan":                    handleRescan,
 	"rescanblocks":              handleRescanBlocks,
+	"scantxoutset":              handleWebsocketScanTxOutSet,
 }
 
 // WebsocketHandler handles a new websocket client by creating a new wsClient,
@@ -84,7 +87,7 @@ var wsHandlersBeforeInit = map[string]wsCommandHandler{
 // server handler which runs each new connection in a new goroutine thereby
 // satisfying the requirement.
 func (s *rpcServer) WebsocketHandler(conn *websocket.Conn, remoteAddr string,
-	authenticated bool, isAdmin bool) {
+	user *rpcUser) {
 
 	// Clear the read deadline that was set before the websocket hijacked
 	// the connection.
@@ -103,7 +106,7 @@ func (s *rpcServer) WebsocketHandler(conn *websocket.Conn, remoteAddr string,
 	// Create a new websocket client to handle the new websocket connection
 	// and wait for it to shutdown.  Once it has shutdown (and hence
 	// disconnected), remove it and any notifications it registered for.
-	client, err := newWebsocketClient(s, conn, remoteAddr, authenticated, isAdmin)
+	client, err := newWebsocketClient(s, conn, remoteAddr, user)
 	if err != nil {
 		rpcsLog.Errorf("Failed to serve client %s: %v", remoteAddr, err)
 		conn.Close()
@@ -246,6 +249,27 @@ func (m *wsNotificationManager) NotifyMempoolTx(tx *btcutil.Tx, isNew bool) {
 	}
 }
 
+// NotifyTxReplaced passes a transaction which was evicted from the mempool by a
+// replacement transaction to the notification manager for notification
+// processing.
+func (m *wsNotificationManager) NotifyTxReplaced(replaced,
+	replacement *btcutil.Tx) {
+
+	n := &notificationTxReplacedInMempool{
+		replaced:    replaced,
+		replacement: replacement,
+	}
+
+	// As NotifyTxReplaced will be called by mempool and the RPC server
+	// may no longer be running, use a select statement to unblock
+	// enqueuing the notification once the RPC server has begun
+	// shutting down.
+	select {
+	case m.queueNotification <- n:
+	case <-m.quit:
+	}
+}
+
 // wsClientFilter tracks relevant addresses for each websocket client for
 // the `rescanblocks` extension. It is modified by the `loadtxfilter` command.
 //
@@ -266,6 +290,14 @@ type wsClientFilter struct {
 
 	// Outpoints of unspent outputs.
 	unspent map[wire.OutPoint]struct{}
+
+	// Output scripts keyed by their serialization.  They allow matching
+	// outputs without an address encoding, such as taproot outputs.
+	scripts map[string]struct{}
+
+	// Silent payments addresses whose outputs are found by scanning the
+	// eligible transactions.
+	silentPaymentKeys []*silentPaymentKey
 }
 
 // newWSClientFilter creates a new, empty wsClientFilter struct to be used
@@ -280,6 +312,7 @@ func newWSClientFilter(addresses []string, unspentOutPoints []wire.OutPoint, par
 		uncompressedPubKeys: map[[65]byte]struct{}{},
 		otherAddresses:      map[string]struct{}{},
 		unspent:             make(map[wire.OutPoint]struct{}, len(unspentOutPoints)),
+		scripts:             map[string]struct{}{},
 	}
 
 	for _, s := range addresses {
@@ -444,6 +477,195 @@ func (f *wsClientFilter) removeUnspentOutPoint(op *wire.OutPoint) {
 	delete(f.unspent, *op)
 }
 
+// addScript adds an output script to the wsClientFilter.
+func (f *wsClientFilter) addScript(pkScript []byte) {
+	f.scripts[string(pkScript)] = struct{}{}
+}
+
+// addSilentPaymentKey adds a silent payments address to the wsClientFilter.
+func (f *wsClientFilter) addSilentPaymentKey(key *silentPaymentKey) {
+	for _, k := range f.silentPaymentKeys {
+		if k.spendKey.IsEqual(key.spendKey) &&
+			bytes.Equal(k.scanKey, key.scanKey) {
+
+			return
+		}
+	}
+	f.silentPaymentKeys = append(f.silentPaymentKeys, key)
+}
+
+// existsOutput returns true if the passed output script or any of the passed
+// addresses it pays to has been added to the wsClientFilter.
+func (f *wsClientFilter) existsOutput(pkScript []byte,
+	addrs []btcutil.Address) bool {
+
+	if _, ok := f.scripts[string(pkScript)]; ok {
+		return true
+	}
+	for _, a := range addrs {
+		if f.existsAddress(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// silentPaymentOutputs returns the indexes of the outputs of the passed
+// transaction which pay to any of the silent payments addresses of the
+// wsClientFilter.  The tweak function returns the silent payments tweak of the
+// transaction, or nil if it isn't eligible.  It is only called when the
+// transaction has taproot outputs.
+func (f *wsClientFilter) silentPaymentOutputs(tx *wire.MsgTx,
+	tweak func() []byte) []uint32 {
+
+	if len(f.silentPaymentKeys) == 0 {
+		return nil
+	}
+	outputKeys := make(map[[32]byte]uint32)
+	for i, txOut := range tx.TxOut {
+		if len(txOut.PkScript) == 34 &&
+			txOut.PkScript[0] == txscript.OP_1 &&
+			txOut.PkScript[1] == txscript.OP_DATA_32 {
+
+			var outputKey [32]byte
+			copy(outputKey[:], txOut.PkScript[2:])
+			outputKeys[outputKey] = uint32(i)
+		}
+	}
+	if len(outputKeys) == 0 {
+		return nil
+	}
+	txTweak := tweak()
+	if txTweak == nil {
+		return nil
+	}
+
+	var matches []uint32
+	for _, key := range f.silentPaymentKeys {
+		matches = append(matches, key.outputs(txTweak, outputKeys)...)
+	}
+	return matches
+}
+
+// silentPaymentSharedSecretTag is the tag of the hash deriving the output keys
+// of silent payments from the shared secret as defined by BIP-352.
+var silentPaymentSharedSecretTag = []byte("BIP0352/SharedSecret")
+
+// silentPaymentKey is a silent payments address as defined by BIP-352, given
+// by the private scan key and the public spend key.  Labels are not supported.
+type silentPaymentKey struct {
+	scanKey  []byte
+	spendKey *btcec.PublicKey
+}
+
+// parseSilentPaymentKey parses the hex encoded private scan key and public
+// spend key of a silent payments address.
+func parseSilentPaymentKey(key *btcjson.SilentPaymentKey) (*silentPaymentKey, error) {
+	scanKey, err := hex.DecodeString(key.ScanKey)
+	if err != nil || len(scanKey) != btcec.PrivKeyBytesLen {
+		return nil, fmt.Errorf("invalid silent payments scan key %q",
+			key.ScanKey)
+	}
+	scalar := new(big.Int).SetBytes(scanKey)
+	if scalar.Sign() == 0 || scalar.Cmp(btcec.S256().N) >= 0 {
+		return nil, fmt.Errorf("invalid silent payments scan key %q",
+			key.ScanKey)
+	}
+	serializedSpendKey, err := hex.DecodeString(key.SpendKey)
+	if err != nil ||
+		len(serializedSpendKey) != btcec.PubKeyBytesLenCompressed {
+
+		return nil, fmt.Errorf("invalid silent payments spend key %q",
+			key.SpendKey)
+	}
+	spendKey, err := btcec.ParsePubKey(serializedSpendKey, btcec.S256())
+	if err != nil {
+		return nil, fmt.Errorf("invalid silent payments spend key "+
+			"%q: %v", key.SpendKey, err)
+	}
+	return &silentPaymentKey{scanKey: scanKey, spendKey: spendKey}, nil
+}
+
+// outputs returns the indexes of the passed taproot output keys, which belong
+// to a transaction with the passed silent payments tweak, that pay to the
+// silent payments address.  The output keys are derived in order until one is
+// not found as defined by BIP-352.
+func (k *silentPaymentKey) outputs(tweak []byte,
+	outputKeys map[[32]byte]uint32) []uint32 {
+
+	curve := btcec.S256()
+	tweakKey, err := btcec.ParsePubKey(tweak, curve)
+	if err != nil {
+		return nil
+	}
+	sharedX, sharedY := curve.ScalarMult(tweakKey.X, tweakKey.Y, k.scanKey)
+	shared := (&btcec.PublicKey{Curve: curve, X: sharedX, Y: sharedY}).
+		SerializeCompressed()
+
+	var matches []uint32
+	for n := uint32(0); int(n) < len(outputKeys); n++ {
+		var serializedN [4]byte
+		binary.BigEndian.PutUint32(serializedN[:], n)
+		t := chainhash.TaggedHash(silentPaymentSharedSecretTag, shared,
+			serializedN[:])
+		tx, ty := curve.ScalarBaseMult(t[:])
+		px, _ := curve.Add(k.spendKey.X, k.spendKey.Y, tx, ty)
+
+		var outputKey [32]byte
+		pxBytes := px.Bytes()
+		copy(outputKey[32-len(pxBytes):], pxBytes)
+		i, ok := outputKeys[outputKey]
+		if !ok {
+			break
+		}
+		matches = append(matches, i)
+	}
+	return matches
+}
+
+// silentPaymentTweak returns the silent payments tweak of the passed
+// transaction given the outputs it spends, or nil if the transaction is not
+// eligible or the spent outputs are not available.
+func silentPaymentTweak(tx *wire.MsgTx, spent []blockchain.SpentTxOut) []byte {
+	if len(spent) != len(tx.TxIn) {
+		return nil
+	}
+	prevScripts := make([][]byte, len(spent))
+	for i := range spent {
+		prevScripts[i] = spent[i].PkScript
+	}
+	tweak, ok := indexers.TxTweak(tx, prevScripts)
+	if !ok {
+		return nil
+	}
+	return tweak
+}
+
+// blockSpentOutputs returns a function returning the outputs spent by the
+// transaction at the passed index of the passed main chain block.  The spent
+// outputs of the block are only fetched on first use.
+func blockSpentOutputs(chain *blockchain.BlockChain,
+	block *btcutil.Block) func(int) []blockchain.SpentTxOut {
+
+	var spentOutputs [][]blockchain.SpentTxOut
+	var fetched bool
+	return func(txIdx int) []blockchain.SpentTxOut {
+		if !fetched {
+			fetched = true
+			var err error
+			spentOutputs, err = chain.FetchSpentOutputs(block.Hash())
+			if err != nil {
+				rpcsLog.Debugf("Spent outputs of block %v are not "+
+					"available: %v", block.Hash(), err)
+			}
+		}
+		if txIdx >= len(spentOutputs) {
+			return nil
+		}
+		return spentOutputs[txIdx]
+	}
+}
+
 // Notification types
 type notificationBlockConnected btcutil.Block
 type notificationBlockDisconnected btcutil.Block
@@ -451,6 +673,10 @@ type notificationTxAcceptedByMempool struct {
 	isNew bool
 	tx    *btcutil.Tx
 }
+type notificationTxReplacedInMempool struct {
+	replaced    *btcutil.Tx
+	replacement *btcutil.Tx
+}
 
 // Notification control requests
 type notificationRegisterClient wsClient
@@ -539,6 +765,12 @@ out:
 				m.notifyForTx(watchedOutPoints, watchedAddrs, n.tx, nil)
 				m.notifyRelevantTxAccepted(n.tx, clients)
 
+			case *notificationTxReplacedInMempool:
+				if len(txNotifications) != 0 {
+					m.notifyTxReplaced(txNotifications,
+						n.replaced, n.replacement)
+				}
+
 			case *notificationRegisterBlocks:
 				wsc := (*wsClient)(n)
 				blockNotifications[wsc.quit] = wsc
@@ -627,10 +859,13 @@ func (m *wsNotificationManager) UnregisterBlockUpdates(wsc *wsClient) {
 
 // subscribedClients returns the set of all websocket client quit channels that
 // are registered to receive notifications regarding tx, either due to tx
-// spending a watched output or outputting to a watched address.  Matching
-// client's filters are updated based on this transaction's outputs and output
-// addresses that may be relevant for a client.
+// spending a watched output or outputting to a watched address, script or
+// silent payments address.  Matching client's filters are updated based on
+// this transaction's outputs and output addresses that may be relevant for a
+// client.  The spent function returns the outputs spent by the transaction,
+// which are only needed to scan for silent payments.
 func (m *wsNotificationManager) subscribedClients(tx *btcutil.Tx,
+	spent func() []blockchain.SpentTxOut,
 	clients map[chan struct{}]*wsClient) map[chan struct{}]struct{} {
 
 	// Use a map of client quit channels as keys to prevent duplicates when
@@ -655,13 +890,11 @@ func (m *wsNotificationManager) subscribedClients(tx *btcutil.Tx,
 	}
 
 	for i, output := range msgTx.TxOut {
-		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		// Clients are not able to subscribe to the addresses of
+		// nonstandard or non-address outputs, but may still watch
+		// their output script.
+		_, addrs, _, _ := txscript.ExtractPkScriptAddrs(
 			output.PkScript, m.server.cfg.ChainParams)
-		if err != nil {
-			// Clients are not able to subscribe to
-			// nonstandard or non-address outputs.
-			continue
-		}
 		for quitChan, wsc := range clients {
 			wsc.Lock()
 			filter := wsc.filterData
@@ -670,20 +903,48 @@ func (m *wsNotificationManager) subscribedClients(tx *btcutil.Tx,
 				continue
 			}
 			filter.mu.Lock()
-			for _, a := range addrs {
-				if filter.existsAddress(a) {
-					subscribed[quitChan] = struct{}{}
-					op := wire.OutPoint{
-						Hash:  *tx.Hash(),
-						Index: uint32(i),
-					}
-					filter.addUnspentOutPoint(&op)
+			if filter.existsOutput(output.PkScript, addrs) {
+				subscribed[quitChan] = struct{}{}
+				op := wire.OutPoint{
+					Hash:  *tx.Hash(),
+					Index: uint32(i),
 				}
+				filter.addUnspentOutPoint(&op)
 			}
 			filter.mu.Unlock()
 		}
 	}
 
+	// The silent payments tweak is shared by all clients, so it is only
+	// computed once when first needed.
+	var tweak []byte
+	var tweakDone bool
+	txTweak := func() []byte {
+		if !tweakDone {
+			tweakDone = true
+			tweak = silentPaymentTweak(msgTx, spent())
+		}
+		return tweak
+	}
+	for quitChan, wsc := range clients {
+		wsc.Lock()
+		filter := wsc.filterData
+		wsc.Unlock()
+		if filter == nil {
+			continue
+		}
+		filter.mu.Lock()
+		for _, i := range filter.silentPaymentOutputs(msgTx, txTweak) {
+			subscribed[quitChan] = struct{}{}
+			op := wire.OutPoint{
+				Hash:  *tx.Hash(),
+				Index: i,
+			}
+			filter.addUnspentOutPoint(&op)
+		}
+		filter.mu.Unlock()
+	}
+
 	return subscribed
 }
 
@@ -750,9 +1011,13 @@ func (m *wsNotificationManager) notifyFilteredBlockConnected(clients map[chan st
 	// Search for relevant transactions for each client and save them
 	// serialized in hex encoding for the notification.
 	subscribedTxs := make(map[chan struct{}][]string)
-	for _, tx := range block.Transactions() {
+	spentOutputs := blockSpentOutputs(m.server.cfg.Chain, block)
+	for i, tx := range block.Transactions() {
+		spent := func() []blockchain.SpentTxOut {
+			return spentOutputs(i)
+		}
 		var txHex string
-		for quitChan := range m.subscribedClients(tx, clients) {
+		for quitChan := range m.subscribedClients(tx, spent, clients) {
 			if txHex == "" {
 				txHex = txHexString(tx.MsgTx())
 			}
@@ -868,6 +1133,25 @@ func (m *wsNotificationManager) notifyForNewTx(clients map[chan struct{}]*wsClie
 	}
 }
 
+// notifyTxReplaced notifies websocket clients that have registered for updates
+// when new transactions are added to the memory pool that a transaction was
+// evicted from it by a replacement transaction.
+func (m *wsNotificationManager) notifyTxReplaced(clients map[chan struct{}]*wsClient,
+	replaced, replacement *btcutil.Tx) {
+
+	ntfn := btcjson.NewTxReplacedNtfn(replaced.Hash().String(),
+		replacement.Hash().String())
+	marshalledJSON, err := btcjson.MarshalCmd(btcjson.RpcVersion1, nil, ntfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal tx replaced notification: %v",
+			err)
+		return
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
 // RegisterSpentRequests requests a notification when each of the passed
 // outpoints is confirmed spent (contained in a block connected to the main
 // chain) for the passed websocket client.  The request is automatically
@@ -1043,7 +1327,10 @@ func (m *wsNotificationManager) notifyForTxOuts(ops map[wire.OutPoint]map[chan s
 func (m *wsNotificationManager) notifyRelevantTxAccepted(tx *btcutil.Tx,
 	clients map[chan struct{}]*wsClient) {
 
-	clientsToNotify := m.subscribedClients(tx, clients)
+	spent := func() []blockchain.SpentTxOut {
+		return fetchSpentOutputs(m.server, tx.MsgTx(), nil)
+	}
+	clientsToNotify := m.subscribedClients(tx, spent, clients)
 
 	if len(clientsToNotify) != 0 {
 		n := btcjson.NewRelevantTxAcceptedNtfn(txHexString(tx.MsgTx()))
@@ -1264,9 +1551,9 @@ type wsClient struct {
 	// and therefore is allowed to communicated over the websocket.
 	authenticated bool
 
-	// isAdmin specifies whether a client may change the state of the server;
-	// false means its access is only to the limited set of RPC calls.
-	isAdmin bool
+	// user is the authenticated user of the client, which determines the
+	// RPC calls it may make.
+	user *rpcUser
 
 	// sessionID is a random ID generated for each client when connected.
 	// These IDs may be queried by a client using the session RPC.  A change
@@ -1410,17 +1697,14 @@ out:
 				break out
 			case !c.authenticated:
 				// Check credentials.
-				login := authCmd.Username + ":" + authCmd.Passphrase
-				auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
-				authSha := sha256.Sum256([]byte(auth))
-				cmp := subtle.ConstantTimeCompare(authSha[:], c.server.authsha[:])
-				limitcmp := subtle.ConstantTimeCompare(authSha[:], c.server.limitauthsha[:])
-				if cmp != 1 && limitcmp != 1 {
+				user := c.server.auth.authenticate(authCmd.Username,
+					authCmd.Passphrase)
+				if user == nil {
 					rpcsLog.Warnf("Auth failure.")
 					break out
 				}
 				c.authenticated = true
-				c.isAdmin = cmp == 1
+				c.user = user
 
 				// Marshal and send response.
 				reply, err = createMarshalledReply(cmd.jsonrpc, cmd.id, nil, nil)
@@ -1433,24 +1717,22 @@ out:
 				continue
 			}
 
-			// Check if the client is using limited RPC credentials and
+			// Check if the client is using restricted RPC credentials and
 			// error when not authorized to call the supplied RPC.
-			if !c.isAdmin {
-				if _, ok := rpcLimited[req.Method]; !ok {
-					jsonErr := &btcjson.RPCError{
-						Code:    btcjson.ErrRPCInvalidParams.Code,
-						Message: "limited user not authorized for this method",
-					}
-					// Marshal and send response.
-					reply, err = createMarshalledReply("", req.ID, nil, jsonErr)
-					if err != nil {
-						rpcsLog.Errorf("Failed to marshal parse failure "+
-							"reply: %v", err)
-						continue
-					}
-					c.SendMessage(reply, nil)
+			if !c.user.isAllowed(req.Method) {
+				jsonErr := &btcjson.RPCError{
+					Code:    btcjson.ErrRPCInvalidParams.Code,
+					Message: "user not authorized for this method",
+				}
+				// Marshal and send response.
+				reply, err = createMarshalledReply("", req.ID, nil, jsonErr)
+				if err != nil {
+					rpcsLog.Errorf("Failed to marshal parse failure "+
+						"reply: %v", err)
 					continue
 				}
+				c.SendMessage(reply, nil)
+				continue
 			}
 
 			// Asynchronously handle the request.  A semaphore is used to
@@ -1647,18 +1929,15 @@ out:
 							break out
 						case !c.authenticated:
 							// Check credentials.
-							login := authCmd.Username + ":" + authCmd.Passphrase
-							auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
-							authSha := sha256.Sum256([]byte(auth))
-							cmp := subtle.ConstantTimeCompare(authSha[:], c.server.authsha[:])
-							limitcmp := subtle.ConstantTimeCompare(authSha[:], c.server.limitauthsha[:])
-							if cmp != 1 && limitcmp != 1 {
+							user := c.server.auth.authenticate(authCmd.Username,
+								authCmd.Passphrase)
+							if user == nil {
 								rpcsLog.Warnf("Auth failure.")
 								break out
 							}
 
 							c.authenticated = true
-							c.isAdmin = cmp == 1
+							c.user = user
 
 							// Marshal and send response.
 							reply, err = createMarshalledReply(cmd.jsonrpc, cmd.id, nil, nil)
@@ -1674,27 +1953,25 @@ out:
 							continue
 						}
 
-						// Check if the client is using limited RPC credentials and
+						// Check if the client is using restricted RPC credentials and
 						// error when not authorized to call the supplied RPC.
-						if !c.isAdmin {
-							if _, ok := rpcLimited[req.Method]; !ok {
-								jsonErr := &btcjson.RPCError{
-									Code:    btcjson.ErrRPCInvalidParams.Code,
-									Message: "limited user not authorized for this method",
-								}
-								// Marshal and send response.
-								reply, err = createMarshalledReply(req.Jsonrpc, req.ID, nil, jsonErr)
-								if err != nil {
-									rpcsLog.Errorf("Failed to marshal parse failure "+
-										"reply: %v", err)
-									continue
-								}
-
-								if reply != nil {
-									results = append(results, reply)
-								}
+						if !c.user.isAllowed(req.Method) {
+							jsonErr := &btcjson.RPCError{
+								Code:    btcjson.ErrRPCInvalidParams.Code,
+								Message: "user not authorized for this method",
+							}
+							// Marshal and send response.
+							reply, err = createMarshalledReply(req.Jsonrpc, req.ID, nil, jsonErr)
+							if err != nil {
+								rpcsLog.Errorf("Failed to marshal parse failure "+
+									"reply: %v", err)
 								continue
 							}
+
+							if reply != nil {
+								results = append(results, reply)
+							}
+							continue
 						}
 
 						// Lookup the websocket extension for the command, if it doesn't
@@ -1985,13 +2262,14 @@ func (c *wsClient) WaitForShutdown() {
 }
 
 // newWebsocketClient returns a new websocket client given the notification
-// manager, websocket connection, remote address, and whether or not the client
-// has already been authenticated (via HTTP Basic access authentication).  The
+// manager, websocket connection, remote address, and the user of the client if
+// it has already been authenticated (via HTTP Basic access authentication or a
+// client certificate).  The
 // returned client is ready to start.  Once started, the client will process
 // incoming and outgoing messages in separate goroutines complete with queuing
 // and asynchrous handling for long-running operations.
 func newWebsocketClient(server *rpcServer, conn *websocket.Conn,
-	remoteAddr string, authenticated bool, isAdmin bool) (*wsClient, error) {
+	remoteAddr string, user *rpcUser) (*wsClient, error) {
 
 	sessionID, err := wire.RandomUint64()
 	if err != nil {
@@ -2001,8 +2279,8 @@ func newWebsocketClient(server *rpcServer, conn *websocket.Conn,
 	client := &wsClient{
 		conn:              conn,
 		addr:              remoteAddr,
-		authenticated:     authenticated,
-		isAdmin:           isAdmin,
+		authenticated:     user != nil,
+		user:              user,
 		sessionID:         sessionID,
 		server:            server,
 		addrRequests:      make(map[string]struct{}),
@@ -2086,27 +2364,132 @@ func handleLoadTxFilter(wsc *wsClient, icmd interface{}) (interface{}, error) {
 
 	params := wsc.server.cfg.ChainParams
 
+	var scripts [][]byte
+	var spKeys []*silentPaymentKey
+	if cmd.Options != nil {
+		var err error
+		scripts, err = filterScripts(cmd.Options, params)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: err.Error(),
+			}
+		}
+		for i := range cmd.Options.SilentPaymentKeys {
+			key, err := parseSilentPaymentKey(
+				&cmd.Options.SilentPaymentKeys[i])
+			if err != nil {
+				return nil, &btcjson.RPCError{
+					Code:    btcjson.ErrRPCInvalidParameter,
+					Message: err.Error(),
+				}
+			}
+			spKeys = append(spKeys, key)
+		}
+	}
+
 	wsc.Lock()
-	if cmd.Reload || wsc.filterData == nil {
-		wsc.filterData = newWSClientFilter(cmd.Addresses, outPoints,
-			params)
+	filter := wsc.filterData
+	if cmd.Reload || filter == nil {
+		filter = newWSClientFilter(cmd.Addresses, outPoints, params)
+		wsc.filterData = filter
 		wsc.Unlock()
+
+		filter.mu.Lock()
 	} else {
 		wsc.Unlock()
 
-		wsc.filterData.mu.Lock()
+		filter.mu.Lock()
 		for _, a := range cmd.Addresses {
-			wsc.filterData.addAddressStr(a, params)
+			filter.addAddressStr(a, params)
 		}
 		for i := range outPoints {
-			wsc.filterData.addUnspentOutPoint(&outPoints[i])
+			filter.addUnspentOutPoint(&outPoints[i])
 		}
-		wsc.filterData.mu.Unlock()
 	}
+	for _, script := range scripts {
+		filter.addScript(script)
+	}
+	for _, key := range spKeys {
+		filter.addSilentPaymentKey(key)
+	}
+	filter.mu.Unlock()
 
 	return nil, nil
 }
 
+// maxFilterDescriptorScripts is the maximum number of scripts a ranged
+// descriptor of a transaction filter may expand to.
+const maxFilterDescriptorScripts = 10000
+
+// filterScripts returns the output scripts given by the raw scripts and the
+// descriptors of the passed transaction filter options.  Ranged descriptors
+// are expanded over their range, which defaults to the indexes 0 through 999.
+func filterScripts(opts *btcjson.LoadTxFilterOptions,
+	params *chaincfg.Params) ([][]byte, error) {
+
+	scripts := make([][]byte, 0, len(opts.Scripts))
+	for _, s := range opts.Scripts {
+		script, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid script %q: %v", s, err)
+		}
+		scripts = append(scripts, script)
+	}
+
+	for _, fd := range opts.Descriptors {
+		desc, err := descriptor.Parse(fd.Descriptor, params)
+		if err != nil {
+			return nil, fmt.Errorf("invalid descriptor %q: %v",
+				fd.Descriptor, err)
+		}
+
+		begin, end := 0, 0
+		if desc.IsRange() {
+			begin, end, err = filterDescriptorRange(fd.Range)
+			if err != nil {
+				return nil, fmt.Errorf("invalid range of "+
+					"descriptor %q: %v", fd.Descriptor, err)
+			}
+		}
+		for i := begin; i <= end; i++ {
+			script, err := desc.Script(uint32(i))
+			if err != nil {
+				return nil, fmt.Errorf("unable to derive script "+
+					"%d of descriptor %q: %v", i,
+					fd.Descriptor, err)
+			}
+			scripts = append(scripts, script)
+		}
+	}
+
+	return scripts, nil
+}
+
+// filterDescriptorRange returns the first and last index of the passed range of
+// a ranged descriptor, which defaults to the indexes 0 through 999.
+func filterDescriptorRange(r *btcjson.DescriptorRange) (int, int, error) {
+	begin, end := 0, 999
+	if r != nil {
+		switch v := r.Value.(type) {
+		case int:
+			end = v
+		case []int:
+			begin, end = v[0], v[1]
+		default:
+			return 0, 0, fmt.Errorf("unsupported range %v", r.Value)
+		}
+	}
+	if begin < 0 || end < begin || end > math.MaxInt32 {
+		return 0, 0, fmt.Errorf("range [%d,%d] is invalid", begin, end)
+	}
+	if end-begin >= maxFilterDescriptorScripts {
+		return 0, 0, fmt.Errorf("range [%d,%d] exceeds the maximum "+
+			"of %d scripts", begin, end, maxFilterDescriptorScripts)
+	}
+	return begin, end, nil
+}
+
 // handleNotifyBlocks implements the notifyblocks command extension for
 // websocket connections.
 func handleNotifyBlocks(wsc *wsClient, icmd interface{}) (interface{}, error) {
@@ -2114,6 +2497,45 @@ func handleNotifyBlocks(wsc *wsClient, icmd interface{}) (interface{}, error) {
 	return nil, nil
 }
 
+// handleWebsocketScanTxOutSet implements the scantxoutset command extension
+// for websocket connections.  Scans started by websocket clients stream every
+// found output as a scantxoutsetmatch notification and the progress of the
+// scan in whole percents as scantxoutsetprogress notifications.  The scan is
+// aborted when the client disconnects.
+func handleWebsocketScanTxOutSet(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*btcjson.ScanTxOutSetCmd)
+	if !ok {
+		return nil, btcjson.ErrRPCInternal
+	}
+
+	queueNtfn := func(ntfn interface{}) {
+		marshalled, err := btcjson.MarshalCmd(btcjson.RpcVersion1, nil, ntfn)
+		if err != nil {
+			rpcsLog.Errorf("Failed to marshal scantxoutset "+
+				"notification: %v", err)
+			return
+		}
+
+		// A disconnected client aborts the scan, so the error can be
+		// ignored.
+		_ = wsc.QueueNotification(marshalled)
+	}
+	match := func(unspent *btcjson.ScanTxOutSetUnspent) {
+		queueNtfn(btcjson.NewScanTxOutSetMatchNtfn(*unspent))
+	}
+	lastProgress := 0.0
+	progress := func(p float64) {
+		p = math.Floor(p)
+		if p <= lastProgress {
+			return
+		}
+		lastProgress = p
+		queueNtfn(btcjson.NewScanTxOutSetProgressNtfn(p))
+	}
+
+	return wsc.server.handleScanTxOutSetAction(cmd, match, progress, wsc.quit)
+}
+
 // handleSession implements the session command extension for websocket
 // connections.
 func handleSession(wsc *wsClient, icmd interface{}) (interface{}, error) {
@@ -2427,14 +2849,19 @@ func rescanBlock(wsc *wsClient, lookups *rescanKeys, blk *btcutil.Block) {
 
 // rescanBlockFilter rescans a block for any relevant transactions for the
 // passed lookup keys. Any discovered transactions are returned hex encoded as
-// a string slice.
+// a string slice.  The outputs spent by the block are only fetched from the
+// chain when scanning for silent payments.
 //
 // NOTE: This extension is ported from github.com/decred/dcrd
-func rescanBlockFilter(filter *wsClientFilter, block *btcutil.Block, params *chaincfg.Params) []string {
+func rescanBlockFilter(filter *wsClientFilter, block *btcutil.Block,
+	chain *blockchain.BlockChain, params *chaincfg.Params) []string {
+
 	var transactions []string
 
+	spentOutputs := blockSpentOutputs(chain, block)
+
 	filter.mu.Lock()
-	for _, tx := range block.Transactions() {
+	for txIdx, tx := range block.Transactions() {
 		msgTx := tx.MsgTx()
 
 		// Keep track of whether the transaction has already been added
@@ -2458,28 +2885,42 @@ func rescanBlockFilter(filter *wsClientFilter, block *btcutil.Block, params *cha
 
 		// Scan outputs.
 		for i, output := range msgTx.TxOut {
-			_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+			_, addrs, _, _ := txscript.ExtractPkScriptAddrs(
 				output.PkScript, params)
-			if err != nil {
+			if !filter.existsOutput(output.PkScript, addrs) {
 				continue
 			}
-			for _, a := range addrs {
-				if !filter.existsAddress(a) {
-					continue
-				}
 
-				op := wire.OutPoint{
-					Hash:  *tx.Hash(),
-					Index: uint32(i),
-				}
-				filter.addUnspentOutPoint(&op)
+			op := wire.OutPoint{
+				Hash:  *tx.Hash(),
+				Index: uint32(i),
+			}
+			filter.addUnspentOutPoint(&op)
 
-				if !added {
-					transactions = append(
-						transactions,
-						txHexString(msgTx))
-					added = true
-				}
+			if !added {
+				transactions = append(
+					transactions,
+					txHexString(msgTx))
+				added = true
+			}
+		}
+
+		// Scan outputs for silent payments.
+		tweak := func() []byte {
+			return silentPaymentTweak(msgTx, spentOutputs(txIdx))
+		}
+		for _, i := range filter.silentPaymentOutputs(msgTx, tweak) {
+			op := wire.OutPoint{
+				Hash:  *tx.Hash(),
+				Index: i,
+			}
+			filter.addUnspentOutPoint(&op)
+
+			if !added {
+				transactions = append(
+					transactions,
+					txHexString(msgTx))
+				added = true
 			}
 		}
 	}
@@ -2543,7 +2984,7 @@ func handleRescanBlocks(wsc *wsClient, icmd interface{}) (interface{}, error) {
 		}
 		lastBlockHash = blockHashes[i]
 
-		transactions := rescanBlockFilter(filter, block, params)
+		transactions := rescanBlockFilter(filter, block, bc, params)
 		if len(transactions) != 0 {
 			discoveredData = append(discoveredData, btcjson.RescannedBlock{
 				Hash:         cmd.BlockHashes[i],
@@ -6,14 +6,19 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"crypto/rand"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"math"
 	"net"
+	"os"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strconv"
@@ -25,6 +30,7 @@ import (
 	"github.com/btcsuite/btcd/addrmgr"
 	"github.com/btcsuite/btcd/blockchain"
 	"github.com/btcsuite/btcd/blockchain/indexers"
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/connmgr"
@@ -32,10 +38,12 @@ import (
 	"github.com/btcsuite/btcd/mempool"
 	"github.com/btcsuite/btcd/mining"
 	"github.com/btcsuite/btcd/mining/cpuminer"
+	"github.com/btcsuite/btcd/mining/sv2"
 	"github.com/btcsuite/btcd/netsync"
 	"github.com/btcsuite/btcd/peer"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcd/zmq"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcutil/bloom"
 )
@@ -94,6 +102,28 @@ func (oa *onionAddr) Network() string {
 // Ensure onionAddr implements the net.Addr interface.
 var _ net.Addr = (*onionAddr)(nil)
 
+// i2pAddr implements the net.Addr interface and represents an I2P address.
+type i2pAddr struct {
+	addr string
+}
+
+// String returns the I2P address.
+//
+// This is part of the net.Addr interface.
+func (ia *i2pAddr) String() string {
+	return ia.addr
+}
+
+// Network returns "i2p".
+//
+// This is part of the net.Addr interface.
+func (ia *i2pAddr) Network() string {
+	return "i2p"
+}
+
+// Ensure i2pAddr implements the net.Addr interface.
+var _ net.Addr = (*i2pAddr)(nil)
+
 // simpleAddr implements the net.Addr interface with two struct fields
 type simpleAddr struct {
 	net, addr string
@@ -204,17 +234,22 @@ type server struct {
 	shutdown      int32
 	shutdownSched int32
 	startupTime   int64
+	mempoolLoaded int32
 
 	chainParams          *chaincfg.Params
 	addrManager          *addrmgr.AddrManager
 	connManager          *connmgr.ConnManager
-	sigCache             *txscript.SigCache
+	sigCache             *txscript.ShardedSigCache
 	hashCache            *txscript.HashCache
+	scriptPool           *blockchain.ScriptValidationPool
 	rpcServer            *rpcServer
 	syncManager          *netsync.SyncManager
 	chain                *blockchain.BlockChain
+	backgroundChain      *blockchain.BlockChain
 	txMemPool            *mempool.TxPool
 	cpuMiner             *cpuminer.CPUMiner
+	sv2Provider          *sv2.TemplateProvider
+	zmqNotifier          *zmq.Notifier
 	modifyRebroadcastInv chan interface{}
 	newPeers             chan *serverPeer
 	donePeers            chan *serverPeer
@@ -234,9 +269,14 @@ type server struct {
 	// if the associated index is not enabled.  These fields are set during
 	// initial creation of the server and never changed afterwards, so they
 	// do not need to be protected for concurrent access.
-	txIndex   *indexers.TxIndex
-	addrIndex *indexers.AddrIndex
-	cfIndex   *indexers.CfIndex
+	txIndex    *indexers.TxIndex
+	addrIndex  *indexers.AddrIndex
+	cfIndex    *indexers.CfIndex
+	tweakIndex *indexers.TweakIndex
+
+	// indexManager manages the optional indexes above.  It is nil when
+	// none of them are enabled.
+	indexManager *indexers.Manager
 
 	// The fee estimator keeps track of how long transactions are left in
 	// the mempool before they are mined into blocks.
@@ -254,6 +294,12 @@ type server struct {
 	// agentWhitelist is a list of whitelisted user agent substrings, no
 	// whitelisting will be applied if the list is empty or nil.
 	agentWhitelist []string
+
+	// v1Retries holds the addresses of outbound peers whose BIP-324
+	// handshake failed, so the next connection to them uses the v1
+	// protocol instead.
+	v1Retries    map[string]struct{}
+	v1RetriesMtx sync.Mutex
 }
 
 // serverPeer extends the peer to maintain state shared by the server and
@@ -272,6 +318,7 @@ type serverPeer struct {
 	disableRelayTx bool
 	sentAddrs      bool
 	isWhitelisted  bool
+	v2Transport    bool
 	filter         *bloom.Filter
 	addressesMtx   sync.RWMutex
 	knownAddresses map[string]struct{}
@@ -305,7 +352,7 @@ func (sp *serverPeer) newestBlock() (*chainhash.Hash, int32, error) {
 
 // addKnownAddresses adds the given addresses to the set of known addresses to
 // the peer to prevent sending duplicate addresses.
-func (sp *serverPeer) addKnownAddresses(addresses []*wire.NetAddress) {
+func (sp *serverPeer) addKnownAddresses(addresses []*wire.NetAddressV2) {
 	sp.addressesMtx.Lock()
 	for _, na := range addresses {
 		sp.knownAddresses[addrmgr.NetAddressKey(na)] = struct{}{}
@@ -314,7 +361,7 @@ func (sp *serverPeer) addKnownAddresses(addresses []*wire.NetAddress) {
 }
 
 // addressKnown true if the given address is already known to the peer.
-func (sp *serverPeer) addressKnown(na *wire.NetAddress) bool {
+func (sp *serverPeer) addressKnown(na *wire.NetAddressV2) bool {
 	sp.addressesMtx.RLock()
 	_, exists := sp.knownAddresses[addrmgr.NetAddressKey(na)]
 	sp.addressesMtx.RUnlock()
@@ -342,21 +389,48 @@ func (sp *serverPeer) relayTxDisabled() bool {
 
 // pushAddrMsg sends an addr message to the connected peer using the provided
 // addresses.
-func (sp *serverPeer) pushAddrMsg(addresses []*wire.NetAddress) {
+func (sp *serverPeer) pushAddrMsg(addresses []*wire.NetAddressV2) {
 	// Filter addresses already known to the peer.
-	addrs := make([]*wire.NetAddress, 0, len(addresses))
+	addrs := make([]*wire.NetAddressV2, 0, len(addresses))
 	for _, addr := range addresses {
 		if !sp.addressKnown(addr) {
 			addrs = append(addrs, addr)
 		}
 	}
-	known, err := sp.PushAddrMsg(addrs)
+
+	// Peers which signalled support for addrv2 messages are sent
+	// addresses of all networks.
+	if sp.WantsAddrV2() {
+		known, err := sp.PushAddrV2Msg(addrs)
+		if err != nil {
+			peerLog.Errorf("Can't push address message to %s: %v",
+				sp.Peer, err)
+			sp.Disconnect()
+			return
+		}
+		sp.addKnownAddresses(known)
+		return
+	}
+
+	// All other peers only receive the addresses which can be represented
+	// by legacy addr messages.
+	legacyAddrs := make([]*wire.NetAddress, 0, len(addrs))
+	for _, addr := range addrs {
+		if legacyAddr, ok := addr.ToLegacy(); ok {
+			legacyAddrs = append(legacyAddrs, legacyAddr)
+		}
+	}
+	known, err := sp.PushAddrMsg(legacyAddrs)
 	if err != nil {
 		peerLog.Errorf("Can't push address message to %s: %v", sp.Peer, err)
 		sp.Disconnect()
 		return
 	}
-	sp.addKnownAddresses(known)
+	knownAddrs := make([]*wire.NetAddressV2, 0, len(known))
+	for _, na := range known {
+		knownAddrs = append(knownAddrs, wire.NetAddressV2FromLegacy(na))
+	}
+	sp.addKnownAddresses(knownAddrs)
 }
 
 // addBanScore increases the persistent and decaying ban score fields by the
@@ -543,6 +617,10 @@ func (sp *serverPeer) OnTx(_ *peer.Peer, msg *wire.MsgTx) {
 		return
 	}
 
+	// Transactions received from peers are never modified, so their
+	// hashes can safely be cached while they are validated.
+	msg.EnableHashCache()
+
 	// Add the transaction to the known inventory for the peer.
 	// Convert the raw MsgTx to a btcutil.Tx which provides some convenience
 	// methods and things such as hash caching.
@@ -562,6 +640,12 @@ func (sp *serverPeer) OnTx(_ *peer.Peer, msg *wire.MsgTx) {
 // OnBlock is invoked when a peer receives a block bitcoin message.  It
 // blocks until the bitcoin block has been fully processed.
 func (sp *serverPeer) OnBlock(_ *peer.Peer, msg *wire.MsgBlock, buf []byte) {
+	// Blocks received from peers are never modified, so the hashes of their
+	// transactions can safely be cached while they are validated.
+	for _, tx := range msg.Transactions {
+		tx.EnableHashCache()
+	}
+
 	// Convert the raw MsgBlock to a btcutil.Block which provides some
 	// convenience methods and things such as hash caching.
 	block := btcutil.NewBlockFromBlockAndBytes(msg, buf)
@@ -1272,7 +1356,39 @@ func (sp *serverPeer) OnAddr(_ *peer.Peer, msg *wire.MsgAddr) {
 		return
 	}
 
+	addrs := make([]*wire.NetAddressV2, 0, len(msg.AddrList))
 	for _, na := range msg.AddrList {
+		addrs = append(addrs, wire.NetAddressV2FromLegacy(na))
+	}
+	sp.addAddresses(addrs)
+}
+
+// OnAddrV2 is invoked when a peer receives an addrv2 bitcoin message and is
+// used to notify the server about advertised addresses.
+func (sp *serverPeer) OnAddrV2(_ *peer.Peer, msg *wire.MsgAddrV2) {
+	// Ignore addresses when running on the simulation test network.  This
+	// helps prevent the network from becoming another public test network
+	// since it will not be able to learn about other peers that have not
+	// specifically been provided.
+	if cfg.SimNet {
+		return
+	}
+
+	// A message that has no addresses is invalid.
+	if len(msg.AddrList) == 0 {
+		peerLog.Errorf("Command [%s] from %s does not contain any addresses",
+			msg.Command(), sp.Peer)
+		sp.Disconnect()
+		return
+	}
+
+	sp.addAddresses(msg.AddrList)
+}
+
+// addAddresses adds the addresses advertised by the peer to the set of
+// addresses known to the peer and to the server address manager.
+func (sp *serverPeer) addAddresses(addrs []*wire.NetAddressV2) {
+	for _, na := range addrs {
 		// Don't add more address if we're disconnecting.
 		if !sp.Connected() {
 			return
@@ -1287,7 +1403,7 @@ func (sp *serverPeer) OnAddr(_ *peer.Peer, msg *wire.MsgAddr) {
 		}
 
 		// Add address to known addresses for this peer.
-		sp.addKnownAddresses([]*wire.NetAddress{na})
+		sp.addKnownAddresses([]*wire.NetAddressV2{na})
 	}
 
 	// Add addresses to server address manager.  The address manager handles
@@ -1295,7 +1411,7 @@ func (sp *serverPeer) OnAddr(_ *peer.Peer, msg *wire.MsgAddr) {
 	// addresses, and last seen updates.
 	// XXX bitcoind gives a 2 hour time penalty here, do we want to do the
 	// same?
-	sp.server.addrManager.AddAddresses(msg.AddrList, sp.NA())
+	sp.server.addrManager.AddAddresses(addrs, sp.NA())
 }
 
 // OnRead is invoked when a peer receives a message and it is used to update
@@ -1398,6 +1514,13 @@ func (s *server) relayTransactions(txns []*mempool.TxDesc) {
 	for _, txD := range txns {
 		iv := wire.NewInvVect(wire.InvTypeTx, txD.Tx.Hash())
 		s.RelayInventory(iv, txD)
+
+		// All transactions newly added to the mempool are relayed,
+		// whether they were received from peers or the RPC server, so
+		// ZMQ subscribers are notified about them here as well.
+		if s.zmqNotifier != nil {
+			s.zmqNotifier.TransactionAccepted(txD.Tx)
+		}
 	}
 }
 
@@ -1429,6 +1552,47 @@ func (s *server) TransactionConfirmed(tx *btcutil.Tx) {
 	s.RemoveRebroadcastInventory(iv)
 }
 
+// TransactionReplaced is invoked when a transaction in the memory pool is
+// evicted by the passed replacement transaction.  The replaced transaction no
+// longer needs rebroadcasting and websocket clients are notified about the
+// replacement.
+func (s *server) TransactionReplaced(replaced, replacement *btcutil.Tx) {
+	if s.zmqNotifier != nil {
+		s.zmqNotifier.TransactionRemoved(replaced)
+	}
+
+	// Rebroadcasting is only necessary when the RPC server is active.
+	if s.rpcServer == nil {
+		return
+	}
+
+	iv := wire.NewInvVect(wire.InvTypeTx, replaced.Hash())
+	s.RemoveRebroadcastInventory(iv)
+	s.rpcServer.NotifyTxReplaced(replaced, replacement)
+}
+
+// handleZMQChainNotification notifies ZMQ subscribers about blocks connected
+// to and disconnected from the main chain.
+func (s *server) handleZMQChainNotification(notification *blockchain.Notification) {
+	switch notification.Type {
+	case blockchain.NTBlockConnected:
+		block, ok := notification.Data.(*btcutil.Block)
+		if !ok {
+			srvrLog.Warnf("Chain connected notification is not a block.")
+			break
+		}
+		s.zmqNotifier.BlockConnected(block)
+
+	case blockchain.NTBlockDisconnected:
+		block, ok := notification.Data.(*btcutil.Block)
+		if !ok {
+			srvrLog.Warnf("Chain disconnected notification is not a block.")
+			break
+		}
+		s.zmqNotifier.BlockDisconnected(block)
+	}
+}
+
 // pushTxMsg sends a tx message for the provided transaction hash to the
 // connected peer.  An error is returned if the transaction hash is not known.
 func (s *server) pushTxMsg(sp *serverPeer, hash *chainhash.Hash, doneChan chan<- struct{},
@@ -1700,7 +1864,7 @@ func (s *server) handleAddPeerMsg(state *peerState, sp *serverPeer) bool {
 			lna := s.addrManager.GetBestLocalAddress(sp.NA())
 			if addrmgr.IsRoutable(lna) {
 				// Filter addresses the peer already knows about.
-				addresses := []*wire.NetAddress{lna}
+				addresses := []*wire.NetAddressV2{lna}
 				sp.pushAddrMsg(addresses)
 			}
 		}
@@ -1735,10 +1899,30 @@ func (s *server) handleDonePeerMsg(state *peerState, sp *serverPeer) {
 	// Regardless of whether the peer was found in our list, we'll inform
 	// our connection manager about the disconnection. This can happen if we
 	// process a peer's `done` message before its `add`.
+	//
+	// Outbound peers whose v2 handshake failed are reconnected using the
+	// v1 protocol right away instead of connecting to a new address.
+	// Persistent peers are retried by the connection manager anyway.
 	if !sp.Inbound() {
-		if sp.persistent {
+		v1Retry := sp.V2HandshakeFailed()
+		if v1Retry {
+			srvrLog.Debugf("Retrying %s with v1 transport", sp)
+			s.v1RetriesMtx.Lock()
+			s.v1Retries[sp.connReq.Addr.String()] = struct{}{}
+			s.v1RetriesMtx.Unlock()
+		}
+
+		switch {
+		case sp.persistent:
 			s.connManager.Disconnect(sp.connReq.ID())
-		} else {
+
+		case v1Retry:
+			s.connManager.Remove(sp.connReq.ID())
+			go s.connManager.Connect(&connmgr.ConnReq{
+				Addr: sp.connReq.Addr,
+			})
+
+		default:
 			s.connManager.Remove(sp.connReq.ID())
 			go s.connManager.NewConnReq()
 		}
@@ -2044,6 +2228,7 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 			OnFilterLoad:   sp.OnFilterLoad,
 			OnGetAddr:      sp.OnGetAddr,
 			OnAddr:         sp.OnAddr,
+			OnAddrV2:       sp.OnAddrV2,
 			OnRead:         sp.OnRead,
 			OnWrite:        sp.OnWrite,
 			OnNotFound:     sp.OnNotFound,
@@ -2065,6 +2250,7 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 		DisableRelayTx:    cfg.BlocksOnly,
 		ProtocolVersion:   peer.MaxProtocolVersion,
 		TrickleInterval:   cfg.TrickleInterval,
+		V2Transport:       sp.v2Transport,
 	}
 }
 
@@ -2075,6 +2261,7 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 func (s *server) inboundPeerConnected(conn net.Conn) {
 	sp := newServerPeer(s, false)
 	sp.isWhitelisted = isWhitelisted(conn.RemoteAddr())
+	sp.v2Transport = cfg.V2Transport
 	sp.Peer = peer.NewInboundPeer(newPeerConfig(sp))
 	sp.AssociateConnection(conn)
 	go s.peerDoneHandler(sp)
@@ -2087,6 +2274,7 @@ func (s *server) inboundPeerConnected(conn net.Conn) {
 // manager of the attempt.
 func (s *server) outboundPeerConnected(c *connmgr.ConnReq, conn net.Conn) {
 	sp := newServerPeer(s, c.Permanent)
+	sp.v2Transport = s.useV2Transport(c.Addr)
 	p, err := peer.NewOutboundPeer(newPeerConfig(sp), c.Addr.String())
 	if err != nil {
 		srvrLog.Debugf("Cannot create outbound peer %s: %v", c.Addr, err)
@@ -2105,6 +2293,32 @@ func (s *server) outboundPeerConnected(c *connmgr.ConnReq, conn net.Conn) {
 	go s.peerDoneHandler(sp)
 }
 
+// useV2Transport returns whether an outbound connection to the passed address
+// should use the BIP-324 encrypted transport.  Outbound peers can't fall back
+// to the v1 protocol on the same connection, so it is only used when the
+// address manager knows the address advertises support for it and the previous
+// v2 handshake with the address didn't fail.
+func (s *server) useV2Transport(addr net.Addr) bool {
+	if !cfg.V2Transport {
+		return false
+	}
+
+	s.v1RetriesMtx.Lock()
+	_, retry := s.v1Retries[addr.String()]
+	delete(s.v1Retries, addr.String())
+	s.v1RetriesMtx.Unlock()
+	if retry {
+		return false
+	}
+
+	na, err := s.addrManager.DeserializeNetAddress(addr.String(), 0)
+	if err != nil {
+		return false
+	}
+	services := s.addrManager.Services(na)
+	return services&wire.SFNodeP2PV2 == wire.SFNodeP2PV2
+}
+
 // peerDoneHandler handles peer disconnects by notifiying the server that it's
 // done along with other performing other desirable cleanup.
 func (s *server) peerDoneHandler(sp *serverPeer) {
@@ -2157,7 +2371,12 @@ func (s *server) peerHandler() {
 				// DNS seed lookups will vary quite a lot.
 				// to replicate this behaviour we put all addresses as
 				// having come from the first one.
-				s.addrManager.AddAddresses(addrs, addrs[0])
+				addrsV2 := make([]*wire.NetAddressV2, 0, len(addrs))
+				for _, na := range addrs {
+					addrsV2 = append(addrsV2,
+						wire.NetAddressV2FromLegacy(na))
+				}
+				s.addrManager.AddAddresses(addrsV2, addrsV2[0])
 			})
 	}
 	go s.connManager.Start()
@@ -2207,6 +2426,25 @@ out:
 	s.syncManager.Stop()
 	s.addrManager.Stop()
 
+	// Write the changes to the utxo set kept in memory to the database now
+	// that no more blocks are processed.
+	if err := s.chain.FlushUtxoCache(); err != nil {
+		srvrLog.Errorf("Unable to flush the utxo cache: %v", err)
+	}
+	if s.backgroundChain != nil {
+		if err := s.backgroundChain.FlushUtxoCache(); err != nil {
+			srvrLog.Errorf("Unable to flush the utxo cache of the "+
+				"background chain: %v", err)
+		}
+	}
+	s.scriptPool.Stop()
+
+	// Stop building indexes in the background before the database is
+	// closed.
+	if s.indexManager != nil {
+		s.indexManager.Stop()
+	}
+
 	// Drain channels before exiting so nothing is left waiting around
 	// to send.
 cleanup:
@@ -2387,10 +2625,29 @@ func (s *server) Start() {
 		s.rpcServer.Start()
 	}
 
+	// Load the transactions saved on the last shutdown into the memory
+	// pool.
+	if cfg.NoPersistMempool {
+		atomic.StoreInt32(&s.mempoolLoaded, 1)
+	} else {
+		s.wg.Add(1)
+		go s.loadMempool()
+	}
+
 	// Start the CPU miner if generation is enabled.
 	if cfg.Generate {
 		s.cpuMiner.Start()
 	}
+
+	// Start the Stratum V2 template provider if it is enabled.
+	if cfg.SV2 {
+		s.sv2Provider.Start()
+	}
+
+	// Start the ZMQ notifier if any topics are published.
+	if s.zmqNotifier != nil {
+		s.zmqNotifier.Start()
+	}
 }
 
 // Stop gracefully shuts down the server by stopping and disconnecting all
@@ -2407,11 +2664,32 @@ func (s *server) Stop() error {
 	// Stop the CPU miner if needed
 	s.cpuMiner.Stop()
 
+	// Stop the Stratum V2 template provider if it is enabled.
+	if cfg.SV2 {
+		s.sv2Provider.Stop()
+	}
+
+	// Stop the ZMQ notifier if any topics are published.
+	if s.zmqNotifier != nil {
+		s.zmqNotifier.Stop()
+	}
+
 	// Shutdown the RPC server if it's not disabled.
 	if !cfg.DisableRPC {
 		s.rpcServer.Stop()
 	}
 
+	// Save the transactions in the memory pool, so they are loaded again
+	// on the next start.
+	if !cfg.NoPersistMempool {
+		path, err := s.saveMempool()
+		if err != nil {
+			srvrLog.Errorf("Unable to save mempool: %v", err)
+		} else {
+			srvrLog.Infof("Saved mempool to %s", path)
+		}
+	}
+
 	// Save fee estimator state in the database.
 	s.db.Update(func(tx database.Tx) error {
 		metadata := tx.Metadata()
@@ -2425,6 +2703,72 @@ func (s *server) Stop() error {
 	return nil
 }
 
+// loadMempool loads the transactions saved to the mempool file into the memory
+// pool.  Transactions which are no longer valid are skipped.  It must be run as
+// a goroutine.
+func (s *server) loadMempool() {
+	defer s.wg.Done()
+
+	path := filepath.Join(cfg.DataDir, mempoolFilename)
+	f, err := os.Open(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			srvrLog.Errorf("Unable to open mempool file: %v", err)
+		}
+		atomic.StoreInt32(&s.mempoolLoaded, 1)
+		return
+	}
+	defer f.Close()
+
+	srvrLog.Infof("Loading mempool from %s", path)
+	n, err := s.txMemPool.Load(bufio.NewReader(f), s.quit)
+	if err != nil {
+		srvrLog.Errorf("Unable to load mempool: %v", err)
+	}
+	select {
+	case <-s.quit:
+		return
+	default:
+	}
+	srvrLog.Infof("Loaded %d transactions into the mempool", n)
+	atomic.StoreInt32(&s.mempoolLoaded, 1)
+}
+
+// saveMempool saves the transactions in the memory pool to the mempool file
+// and returns its path.  It returns an error when the transactions saved on
+// the last shutdown are still being loaded, so they are not overwritten.
+func (s *server) saveMempool() (string, error) {
+	if atomic.LoadInt32(&s.mempoolLoaded) == 0 {
+		return "", errors.New("the mempool is still being loaded")
+	}
+
+	// Write the transactions to a temporary file first, so the mempool
+	// file is replaced atomically.
+	path := filepath.Join(cfg.DataDir, mempoolFilename)
+	tmpPath := path + ".new"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return "", err
+	}
+	w := bufio.NewWriter(f)
+	err = s.txMemPool.Save(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
 // WaitForShutdown blocks until the main listener and peer handlers are stopped.
 func (s *server) WaitForShutdown() {
 	s.wg.Wait()
@@ -2542,8 +2886,9 @@ out:
 					srvrLog.Warnf("UPnP can't get external address: %v", err)
 					continue out
 				}
-				na := wire.NewNetAddressIPPort(externalip, uint16(listenPort),
-					s.services)
+				na := wire.NetAddressV2FromLegacy(
+					wire.NewNetAddressIPPort(externalip,
+						uint16(listenPort), s.services))
 				err = s.addrManager.AddLocalAddress(na, addrmgr.UpnpPrio)
 				if err != nil {
 					// XXX DeletePortMapping?
@@ -2593,6 +2938,22 @@ func setupRPCListeners() ([]net.Listener, error) {
 			MinVersion:   tls.VersionTLS12,
 		}
 
+		// Verify the certificates of clients which present one when
+		// client certificate authentication is enabled.
+		if cfg.RPCClientCA != "" {
+			pem, err := ioutil.ReadFile(cfg.RPCClientCA)
+			if err != nil {
+				return nil, err
+			}
+			clientCAs := x509.NewCertPool()
+			if !clientCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s",
+					cfg.RPCClientCA)
+			}
+			tlsConfig.ClientCAs = clientCAs
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+
 		// Change the standard net.Listen function to the tls one.
 		listenFunc = func(net string, laddr string) (net.Listener, error) {
 			return tls.Listen(net, laddr, &tlsConfig)
@@ -2617,13 +2978,77 @@ func setupRPCListeners() ([]net.Listener, error) {
 	return listeners, nil
 }
 
+// setupSV2Listeners returns a slice of listeners that are configured for use
+// with the Stratum V2 template provider.
+func setupSV2Listeners() ([]net.Listener, error) {
+	netAddrs, err := parseListeners(cfg.SV2Listeners)
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make([]net.Listener, 0, len(netAddrs))
+	for _, addr := range netAddrs {
+		listener, err := net.Listen(addr.Network(), addr.String())
+		if err != nil {
+			minrLog.Warnf("Can't listen on %s: %v", addr, err)
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// loadSV2Key loads the private key stored in the passed file.  A new key is
+// generated and stored when the file doesn't exist yet.
+func loadSV2Key(path string) (*btcec.PrivateKey, error) {
+	if !fileExists(path) {
+		privKey, err := btcec.NewPrivateKey(btcec.S256())
+		if err != nil {
+			return nil, err
+		}
+		err = ioutil.WriteFile(path, privKey.Serialize(), 0600)
+		if err != nil {
+			return nil, err
+		}
+		return privKey, nil
+	}
+
+	keyBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(keyBytes) != btcec.PrivKeyBytesLen {
+		return nil, fmt.Errorf("invalid private key in %s", path)
+	}
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), keyBytes)
+	return privKey, nil
+}
+
 // newServer returns a new btcd server configured to listen on addr for the
 // bitcoin network type specified by chainParams.  Use start to begin accepting
 // connections from peers.
 func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
-	db database.DB, chainParams *chaincfg.Params,
+	db, backgroundDB database.DB, chainParams *chaincfg.Params,
 	interrupt <-chan struct{}) (*server, error) {
 
+	// The optional indexes require the historical blocks which aren't
+	// available when the chain state was loaded from a utxo snapshot.
+	snapshot, _, err := blockchain.FetchUtxoSnapshot(db)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot != nil && (cfg.TxIndex || cfg.AddrIndex || !cfg.NoCFilters ||
+		cfg.TweakIndex) {
+
+		srvrLog.Warnf("Optional indexes are disabled since the chain " +
+			"state was loaded from a utxo snapshot")
+		cfg.TxIndex = false
+		cfg.AddrIndex = false
+		cfg.NoCFilters = true
+		cfg.TweakIndex = false
+	}
+
 	services := defaultServices
 	if cfg.NoPeerBloomFilters {
 		services &^= wire.SFNodeBloom
@@ -2631,8 +3056,17 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 	if cfg.NoCFilters {
 		services &^= wire.SFNodeCF
 	}
+	if cfg.V2Transport {
+		services |= wire.SFNodeP2PV2
+	}
 
-	amgr := addrmgr.New(cfg.DataDir, btcdLookup)
+	var amgr *addrmgr.AddrManager
+	if cfg.PeerStore == "db" {
+		amgr = addrmgr.NewWithStore(cfg.DataDir, addrmgr.NewDBStore(db),
+			btcdLookup)
+	} else {
+		amgr = addrmgr.New(cfg.DataDir, btcdLookup)
+	}
 
 	var listeners []net.Listener
 	var nat NAT
@@ -2670,11 +3104,13 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		db:                   db,
 		timeSource:           blockchain.NewMedianTime(),
 		services:             services,
-		sigCache:             txscript.NewSigCache(cfg.SigCacheMaxSize),
+		sigCache:             txscript.NewShardedSigCache(cfg.SigCacheMaxSize),
 		hashCache:            txscript.NewHashCache(cfg.SigCacheMaxSize),
+		scriptPool:           blockchain.NewScriptValidationPool(cfg.ScriptWorkers),
 		cfCheckptCaches:      make(map[wire.FilterType][]cfHeaderKV),
 		agentBlacklist:       agentBlacklist,
 		agentWhitelist:       agentWhitelist,
+		v1Retries:            make(map[string]struct{}),
 	}
 
 	// Create the transaction and address indexes if needed.
@@ -2708,11 +3144,17 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		s.cfIndex = indexers.NewCfIndex(db, chainParams)
 		indexes = append(indexes, s.cfIndex)
 	}
+	if cfg.TweakIndex {
+		indxLog.Info("Silent payments tweak index is enabled")
+		s.tweakIndex = indexers.NewTweakIndex(db)
+		indexes = append(indexes, s.tweakIndex)
+	}
 
 	// Create an index manager if any of the optional indexes are enabled.
 	var indexManager blockchain.IndexManager
 	if len(indexes) > 0 {
-		indexManager = indexers.NewManager(db, indexes)
+		s.indexManager = indexers.NewManager(db, indexes)
+		indexManager = s.indexManager
 	}
 
 	// Merge given checkpoints with the default ones unless they are disabled.
@@ -2722,7 +3164,7 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 	}
 
 	// Create a new block chain instance with the appropriate configuration.
-	var err error
+	utxoCacheMaxSize := uint64(cfg.UtxoCacheMaxSizeMiB) * 1024 * 1024
 	s.chain, err = blockchain.New(&blockchain.Config{
 		DB:           s.db,
 		Interrupt:    interrupt,
@@ -2732,11 +3174,36 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		SigCache:     s.sigCache,
 		IndexManager: indexManager,
 		HashCache:    s.hashCache,
+
+		UtxoCacheMaxSize:       utxoCacheMaxSize,
+		UtxoCacheFlushInterval: cfg.UtxoFlushInterval,
+		ScriptValidationPool:   s.scriptPool,
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	// Create the chain which validates the blocks leading up to the utxo
+	// snapshot the chain state was loaded from in the background.
+	if backgroundDB != nil {
+		s.backgroundChain, err = blockchain.New(&blockchain.Config{
+			DB:          backgroundDB,
+			Interrupt:   interrupt,
+			ChainParams: s.chainParams,
+			Checkpoints: checkpoints,
+			TimeSource:  s.timeSource,
+			SigCache:    s.sigCache,
+			HashCache:   s.hashCache,
+
+			UtxoCacheMaxSize:       utxoCacheMaxSize,
+			UtxoCacheFlushInterval: cfg.UtxoFlushInterval,
+			ScriptValidationPool:   s.scriptPool,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Search for a FeeEstimator state in the database. If none can be found
 	// or if it cannot be loaded, create a new one.
 	db.Update(func(tx database.Tx) error {
@@ -2778,6 +3245,11 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 			MinRelayTxFee:        cfg.minRelayTxFee,
 			MaxTxVersion:         2,
 			RejectReplacement:    cfg.RejectReplacement,
+			FullRBF:              cfg.MempoolFullRBF,
+			MaxAncestorCount:     int(cfg.LimitAncestorCount),
+			MaxAncestorSize:      int(cfg.LimitAncestorSize * 1000),
+			MaxDescendantCount:   int(cfg.LimitDescendantCount),
+			MaxDescendantSize:    int(cfg.LimitDescendantSize * 1000),
 		},
 		ChainParams:    chainParams,
 		FetchUtxoView:  s.chain.FetchUtxoView,
@@ -2786,11 +3258,13 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		CalcSequenceLock: func(tx *btcutil.Tx, view *blockchain.UtxoViewpoint) (*blockchain.SequenceLock, error) {
 			return s.chain.CalcSequenceLock(tx, view, true)
 		},
-		IsDeploymentActive: s.chain.IsDeploymentActive,
-		SigCache:           s.sigCache,
-		HashCache:          s.hashCache,
-		AddrIndex:          s.addrIndex,
-		FeeEstimator:       s.feeEstimator,
+		IsDeploymentActive:   s.chain.IsDeploymentActive,
+		SigCache:             s.sigCache,
+		HashCache:            s.hashCache,
+		ScriptValidationPool: s.scriptPool,
+		AddrIndex:            s.addrIndex,
+		FeeEstimator:         s.feeEstimator,
+		TxReplaced:           s.TransactionReplaced,
 	}
 	s.txMemPool = mempool.New(&txC)
 
@@ -2801,7 +3275,22 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		ChainParams:        s.chainParams,
 		DisableCheckpoints: cfg.DisableCheckpoints,
 		MaxPeers:           cfg.MaxPeers,
+		SyncMode:           cfg.syncMode,
+		MinimumChainWork:   cfg.minimumChainWork,
 		FeeEstimator:       s.feeEstimator,
+		BackgroundChain:    s.backgroundChain,
+		BackgroundChainDone: func(err error) {
+			if err != nil {
+				srvrLog.Errorf("Invalid utxo snapshot: %v -- "+
+					"the block database must be removed", err)
+				go func() {
+					shutdownRequestChannel <- struct{}{}
+				}()
+				return
+			}
+			srvrLog.Infof("Utxo snapshot validated -- the " +
+				"background database is removed on the next start")
+		},
 	})
 	if err != nil {
 		return nil, err
@@ -2832,6 +3321,56 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		IsCurrent:              s.syncManager.IsCurrent,
 	})
 
+	if cfg.SV2 {
+		sv2Listeners, err := setupSV2Listeners()
+		if err != nil {
+			return nil, err
+		}
+		if len(sv2Listeners) == 0 {
+			return nil, errors.New("SV2: No valid listen address")
+		}
+
+		// The static key is used for the encryption of connections,
+		// while the authority key signs certificates for it.  Clients
+		// must be configured with the public authority key.
+		staticKey, err := loadSV2Key(filepath.Join(cfg.DataDir,
+			sv2StaticKeyFilename))
+		if err != nil {
+			return nil, err
+		}
+		authorityKey, err := loadSV2Key(filepath.Join(cfg.DataDir,
+			sv2AuthorityKeyFilename))
+		if err != nil {
+			return nil, err
+		}
+		minrLog.Infof("Stratum V2 authority public key: %s",
+			sv2.EncodeAuthorityKey(authorityKey.PubKey()))
+
+		s.sv2Provider = sv2.New(&sv2.Config{
+			Listeners:              sv2Listeners,
+			Chain:                  s.chain,
+			BlockTemplateGenerator: blockTemplateGenerator,
+			ProcessBlock:           s.syncManager.ProcessBlock,
+			IsCurrent:              s.syncManager.IsCurrent,
+			StaticKey:              staticKey,
+			AuthorityKey:           authorityKey,
+			Interval:               cfg.SV2Interval,
+			FeeDelta:               cfg.sv2FeeDelta,
+		})
+	}
+
+	// Publish notifications about blocks and transactions to ZMQ
+	// subscribers if any topics are configured.
+	if len(cfg.zmqEndpoints) > 0 {
+		s.zmqNotifier, err = zmq.New(&zmq.Config{
+			Endpoints: cfg.zmqEndpoints,
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.chain.Subscribe(s.handleZMQChainNotification)
+	}
+
 	// Only setup a function to return new addresses to connect to when
 	// not running in connect-only mode.  The simulation network is always
 	// in connect-only mode since it is only intended to connect to
@@ -2858,6 +3397,17 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 					continue
 				}
 
+				// Addresses of the CJDNS network are only
+				// relayed since connecting to them is not
+				// supported.  The same applies to I2P addresses
+				// unless an I2P proxy is configured.
+				if addrmgr.IsCJDNS(addr.NetAddress()) ||
+					(addrmgr.IsI2P(addr.NetAddress()) &&
+						cfg.I2PProxy == "") {
+
+					continue
+				}
+
 				// only allow recent nodes (10mins) after we failed 30
 				// times
 				if tries < 30 && time.Since(addr.LastAttempt()) < 10*time.Minute {
@@ -2943,7 +3493,9 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 			TxIndex:      s.txIndex,
 			AddrIndex:    s.addrIndex,
 			CfIndex:      s.cfIndex,
+			TweakIndex:   s.tweakIndex,
 			FeeEstimator: s.feeEstimator,
+			SaveMempool:  s.saveMempool,
 		})
 		if err != nil {
 			return nil, err
@@ -3070,6 +3622,16 @@ func addrStringToNetAddr(addr string) (net.Addr, error) {
 		return &onionAddr{addr: addr}, nil
 	}
 
+	// The same applies to I2P addresses, which can only be connected to
+	// through an I2P proxy.
+	if strings.HasSuffix(host, ".i2p") {
+		if cfg.I2PProxy == "" {
+			return nil, errors.New("i2p has not been configured")
+		}
+
+		return &i2pAddr{addr: addr}, nil
+	}
+
 	// Attempt to look up an IP address associated with the parsed host.
 	ips, err := btcdLookup(host)
 	if err != nil {
@@ -3116,7 +3678,8 @@ func addLocalAddress(addrMgr *addrmgr.AddrManager, addr string, services wire.Se
 				continue
 			}
 
-			netAddr := wire.NewNetAddressIPPort(ifaceIP, uint16(port), services)
+			netAddr := wire.NetAddressV2FromLegacy(
+				wire.NewNetAddressIPPort(ifaceIP, uint16(port), services))
 			addrMgr.AddLocalAddress(netAddr, addrmgr.BoundPrio)
 		}
 	} else {
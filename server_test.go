@@ -0,0 +1,73 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/btcsuite/btcd/addrmgr"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// TestUseV2Transport ensures outbound connections only use the BIP-324
+// transport when it is enabled and the address advertises support for it, and
+// that a failed v2 handshake makes the next connection use the v1 protocol.
+func TestUseV2Transport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "usev2transport")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	origCfg := cfg
+	cfg = &config{V2Transport: true}
+	defer func() {
+		cfg = origCfg
+	}()
+
+	s := &server{
+		addrManager: addrmgr.New(dir, nil),
+		v1Retries:   make(map[string]struct{}),
+	}
+	srcAddr := wire.NetAddressV2FromLegacy(
+		wire.NewNetAddressIPPort(net.IPv4(173, 144, 173, 111), 8333, 0))
+	addNode := func(ip net.IP, services wire.ServiceFlag) net.Addr {
+		na := wire.NetAddressV2FromLegacy(
+			wire.NewNetAddressIPPort(ip, 8333, services))
+		s.addrManager.AddAddress(na, srcAddr)
+		return &net.TCPAddr{IP: ip, Port: 8333}
+	}
+	v1Addr := addNode(net.IPv4(173, 194, 115, 66), wire.SFNodeNetwork)
+	v2Addr := addNode(net.IPv4(173, 194, 115, 67),
+		wire.SFNodeNetwork|wire.SFNodeP2PV2)
+	unknownAddr := &net.TCPAddr{IP: net.IPv4(173, 194, 115, 68), Port: 8333}
+
+	if s.useV2Transport(v1Addr) {
+		t.Fatal("v2 transport used for address without v2 support")
+	}
+	if s.useV2Transport(unknownAddr) {
+		t.Fatal("v2 transport used for unknown address")
+	}
+	if !s.useV2Transport(v2Addr) {
+		t.Fatal("v2 transport not used for address with v2 support")
+	}
+
+	// A failed v2 handshake makes only the next connection use v1.
+	s.v1Retries[v2Addr.String()] = struct{}{}
+	if s.useV2Transport(v2Addr) {
+		t.Fatal("v2 transport used for retry after failed handshake")
+	}
+	if !s.useV2Transport(v2Addr) {
+		t.Fatal("v2 transport not used after retry")
+	}
+
+	cfg.V2Transport = false
+	if s.useV2Transport(v2Addr) {
+		t.Fatal("v2 transport used while disabled")
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// StepInfo houses the state of the script engine right before the opcode at
+// the program counter is executed.  It is passed to the step callback of an
+// engine created with NewDebugEngine.
+type StepInfo struct {
+	// ScriptIndex is the index of the script being executed.  Index 0 is
+	// the signature script, 1 is the public key script, and any further
+	// scripts are the redeem or witness scripts pulled in for execution.
+	ScriptIndex int
+
+	// OpcodeIndex is the offset of the opcode to execute within the
+	// script.
+	OpcodeIndex int
+
+	// Opcode is the value of the opcode to execute.
+	Opcode byte
+
+	// Disasm is the full disassembly of the opcode to execute, including
+	// any pushed data.
+	Disasm string
+
+	// RemainingScript is the one-line disassembly of the opcodes of the
+	// script that follow the opcode to execute.
+	RemainingScript string
+
+	// Stack and AltStack are the contents of the data and alternate
+	// stacks where the last item is the top of the stack.
+	Stack    [][]byte
+	AltStack [][]byte
+
+	// BranchExecuting is false when the opcode is in a conditional branch
+	// that is not taken.
+	BranchExecuting bool
+
+	// NumOps is the number of non-push operations executed by the script
+	// so far.
+	NumOps int
+
+	// WitnessVersion and WitnessProgram identify the witness program spent
+	// by the input.  WitnessProgram is nil when the input does not spend a
+	// witness program.
+	WitnessVersion int
+	WitnessProgram []byte
+}
+
+// stepInfoJSON is the machine-readable trace representation of a StepInfo,
+// which encodes all byte slices as hex.
+type stepInfoJSON struct {
+	ScriptIndex     int      `json:"script"`
+	OpcodeIndex     int      `json:"pc"`
+	Opcode          byte     `json:"opcode"`
+	Disasm          string   `json:"disasm"`
+	RemainingScript string   `json:"remaining"`
+	Stack           []string `json:"stack"`
+	AltStack        []string `json:"altstack"`
+	BranchExecuting bool     `json:"executing"`
+	NumOps          int      `json:"numops"`
+	WitnessVersion  *int     `json:"witnessversion,omitempty"`
+	WitnessProgram  string   `json:"witnessprogram,omitempty"`
+}
+
+// hexItems returns the hex encoding of each of the passed items.
+func hexItems(items [][]byte) []string {
+	hexItems := make([]string, len(items))
+	for i, item := range items {
+		hexItems[i] = hex.EncodeToString(item)
+	}
+	return hexItems
+}
+
+// MarshalJSON returns the machine-readable trace representation of the step.
+// Stack items and the witness program are hex encoded and the witness fields
+// are omitted when the input does not spend a witness program.
+//
+// This is part of the json.Marshaler interface.
+func (s *StepInfo) MarshalJSON() ([]byte, error) {
+	info := stepInfoJSON{
+		ScriptIndex:     s.ScriptIndex,
+		OpcodeIndex:     s.OpcodeIndex,
+		Opcode:          s.Opcode,
+		Disasm:          s.Disasm,
+		RemainingScript: s.RemainingScript,
+		Stack:           hexItems(s.Stack),
+		AltStack:        hexItems(s.AltStack),
+		BranchExecuting: s.BranchExecuting,
+		NumOps:          s.NumOps,
+	}
+	if s.WitnessProgram != nil {
+		witnessVersion := s.WitnessVersion
+		info.WitnessVersion = &witnessVersion
+		info.WitnessProgram = hex.EncodeToString(s.WitnessProgram)
+	}
+	return json.Marshal(&info)
+}
+
+// StepCallback is invoked by a debug engine with the engine state before each
+// opcode is executed.  Returning an error stops the execution and the error is
+// returned by Step.
+type StepCallback func(*StepInfo) error
+
+// NewTraceWriter returns a step callback which writes the machine-readable
+// trace of every step to the passed writer as one JSON object per line.
+func NewTraceWriter(w io.Writer) StepCallback {
+	enc := json.NewEncoder(w)
+	return func(info *StepInfo) error {
+		return enc.Encode(info)
+	}
+}
+
+// scriptPos identifies the position of an opcode within the scripts of an
+// engine.
+type scriptPos struct {
+	scriptIdx int
+	opcodeIdx int
+}
+
+// NewDebugEngine returns a new script engine like NewEngine which invokes the
+// passed step callback before executing each opcode.  The callback may be nil
+// when the engine is only driven through StepN and breakpoints.
+func NewDebugEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int,
+	flags ScriptFlags, sigCache SignatureCache, hashCache *TxSigHashes,
+	inputAmount int64, stepCallback StepCallback) (*Engine, error) {
+
+	vm, err := NewEngine(scriptPubKey, tx, txIdx, flags, sigCache,
+		hashCache, inputAmount)
+	if err != nil {
+		return nil, err
+	}
+	vm.stepCallback = stepCallback
+	return vm, nil
+}
+
+// stepInfo returns the state of the engine before the opcode at the program
+// counter is executed.  The program counter must be valid.
+func (vm *Engine) stepInfo() *StepInfo {
+	script := vm.scripts[vm.scriptIdx]
+	pop := &script[vm.scriptOff]
+
+	remaining := make([]string, 0, len(script)-vm.scriptOff-1)
+	for i := vm.scriptOff + 1; i < len(script); i++ {
+		remaining = append(remaining, script[i].print(true))
+	}
+
+	return &StepInfo{
+		ScriptIndex:     vm.scriptIdx,
+		OpcodeIndex:     vm.scriptOff,
+		Opcode:          pop.opcode.value,
+		Disasm:          pop.print(false),
+		RemainingScript: strings.Join(remaining, " "),
+		Stack:           vm.GetStack(),
+		AltStack:        vm.GetAltStack(),
+		BranchExecuting: vm.isBranchExecuting(),
+		NumOps:          vm.numOps,
+		WitnessVersion:  vm.witnessVersion,
+		WitnessProgram:  vm.witnessProgram,
+	}
+}
+
+// StepN executes up to n opcodes by calling Step.  It stops early and returns
+// true once all scripts are done, and returns any error from Step.
+func (vm *Engine) StepN(n int) (done bool, err error) {
+	for i := 0; i < n; i++ {
+		done, err = vm.Step()
+		if done || err != nil {
+			return done, err
+		}
+	}
+	return false, nil
+}
+
+// SetBreakpoint sets a breakpoint at the opcode with the passed offset in the
+// script with the passed index, where Continue stops before executing it.
+// Index 0 is the signature script and 1 is the public key script.
+func (vm *Engine) SetBreakpoint(scriptIdx, opcodeIdx int) error {
+	if scriptIdx < 0 || opcodeIdx < 0 {
+		str := fmt.Sprintf("breakpoint %02x:%04x is negative",
+			scriptIdx, opcodeIdx)
+		return scriptError(ErrInvalidIndex, str)
+	}
+
+	// Breakpoints may be set in scripts that are only pulled in for
+	// execution later on, so only existing scripts are bounds checked.
+	if scriptIdx < len(vm.scripts) && opcodeIdx >= len(vm.scripts[scriptIdx]) {
+		str := fmt.Sprintf("breakpoint opcode index %d >= script "+
+			"length %d", opcodeIdx, len(vm.scripts[scriptIdx]))
+		return scriptError(ErrInvalidIndex, str)
+	}
+
+	if vm.breakpoints == nil {
+		vm.breakpoints = make(map[scriptPos]struct{})
+	}
+	vm.breakpoints[scriptPos{scriptIdx, opcodeIdx}] = struct{}{}
+	return nil
+}
+
+// ClearBreakpoint removes the breakpoint at the passed position, if any.
+func (vm *Engine) ClearBreakpoint(scriptIdx, opcodeIdx int) {
+	delete(vm.breakpoints, scriptPos{scriptIdx, opcodeIdx})
+}
+
+// Continue executes opcodes until the program counter reaches a breakpoint or
+// all scripts are done.  At least one opcode is executed, so calling Continue
+// while stopped at a breakpoint moves on to the next one.  It returns true
+// once all scripts are done, and returns any error from Step.
+func (vm *Engine) Continue() (done bool, err error) {
+	for {
+		done, err = vm.Step()
+		if done || err != nil {
+			return done, err
+		}
+
+		pos := scriptPos{vm.scriptIdx, vm.scriptOff}
+		if _, ok := vm.breakpoints[pos]; ok {
+			return false, nil
+		}
+	}
+}
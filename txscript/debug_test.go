@@ -0,0 +1,199 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// debugTestTx returns a transaction with a single input with the passed
+// signature script to execute debug engines against.
+func debugTestTx(sigScript []byte) *wire.MsgTx {
+	return &wire.MsgTx{
+		Version: 1,
+		TxIn: []*wire.TxIn{{
+			SignatureScript: sigScript,
+			Sequence:        wire.MaxTxInSequenceNum,
+		}},
+		TxOut: []*wire.TxOut{{Value: 0, PkScript: []byte{OP_RETURN}}},
+	}
+}
+
+// TestDebugEngineStepCallback ensures the step callback of a debug engine is
+// invoked with the engine state before every opcode and that an error returned
+// by it stops the execution.
+func TestDebugEngineStepCallback(t *testing.T) {
+	t.Parallel()
+
+	sigScript := mustParseShortForm("1 2")
+	pkScript := mustParseShortForm("TOALTSTACK 0 IF 3 ENDIF FROMALTSTACK EQUAL")
+	tx := debugTestTx(sigScript)
+
+	var steps []*StepInfo
+	callback := func(info *StepInfo) error {
+		steps = append(steps, info)
+		return nil
+	}
+	vm, err := NewDebugEngine(pkScript, tx, 0, 0, nil, nil, 0, callback)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	if err := vm.Execute(); !IsErrorCode(err, ErrEvalFalse) {
+		t.Fatalf("unexpected error - got %v, want %v", err, ErrEvalFalse)
+	}
+
+	if len(steps) != 9 {
+		t.Fatalf("unexpected number of steps - got %d, want 9",
+			len(steps))
+	}
+	want := &StepInfo{
+		ScriptIndex:     1,
+		OpcodeIndex:     3,
+		Opcode:          OP_3,
+		Disasm:          "OP_3",
+		RemainingScript: "OP_ENDIF OP_FROMALTSTACK OP_EQUAL",
+		Stack:           [][]byte{{0x01}},
+		AltStack:        [][]byte{{0x02}},
+		BranchExecuting: false,
+		NumOps:          2,
+	}
+	if !reflect.DeepEqual(steps[5], want) {
+		t.Fatalf("unexpected step info - got %+v, want %+v",
+			steps[5], want)
+	}
+
+	// Ensure an error returned by the callback stops the execution.
+	errStop := errors.New("stop")
+	var numSteps int
+	callback = func(info *StepInfo) error {
+		numSteps++
+		if info.Opcode == OP_EQUAL {
+			return errStop
+		}
+		return nil
+	}
+	vm, err = NewDebugEngine(pkScript, tx, 0, 0, nil, nil, 0, callback)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	if err := vm.Execute(); err != errStop {
+		t.Fatalf("unexpected error - got %v, want %v", err, errStop)
+	}
+	if numSteps != 9 {
+		t.Fatalf("unexpected number of steps - got %d, want 9",
+			numSteps)
+	}
+}
+
+// TestDebugEngineBreakpoints ensures StepN and Continue stop at the expected
+// program counters.
+func TestDebugEngineBreakpoints(t *testing.T) {
+	t.Parallel()
+
+	sigScript := mustParseShortForm("1 2")
+	pkScript := mustParseShortForm("ADD 3 EQUALVERIFY 4 DROP 1")
+	tx := debugTestTx(sigScript)
+
+	vm, err := NewDebugEngine(pkScript, tx, 0, 0, nil, nil, 0, nil)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	if err := vm.SetBreakpoint(1, 6); !IsErrorCode(err, ErrInvalidIndex) {
+		t.Fatalf("unexpected error - got %v, want %v", err,
+			ErrInvalidIndex)
+	}
+	if err := vm.SetBreakpoint(1, 1); err != nil {
+		t.Fatalf("unexpected error setting breakpoint: %v", err)
+	}
+	if err := vm.SetBreakpoint(1, 4); err != nil {
+		t.Fatalf("unexpected error setting breakpoint: %v", err)
+	}
+
+	checkPC := func(wantScript, wantOff int) {
+		t.Helper()
+		scriptIdx, scriptOff, err := vm.curPC()
+		if err != nil {
+			t.Fatalf("unexpected error getting pc: %v", err)
+		}
+		if scriptIdx != wantScript || scriptOff != wantOff {
+			t.Fatalf("unexpected pc - got %02x:%04x, want "+
+				"%02x:%04x", scriptIdx, scriptOff, wantScript,
+				wantOff)
+		}
+	}
+
+	if done, err := vm.StepN(3); done || err != nil {
+		t.Fatalf("unexpected StepN result: %v, %v", done, err)
+	}
+	checkPC(1, 1)
+
+	if done, err := vm.Continue(); done || err != nil {
+		t.Fatalf("unexpected Continue result: %v, %v", done, err)
+	}
+	checkPC(1, 4)
+	if stack := vm.GetStack(); !reflect.DeepEqual(stack, [][]byte{{0x04}}) {
+		t.Fatalf("unexpected stack at breakpoint: %x", stack)
+	}
+
+	vm.ClearBreakpoint(1, 1)
+	if done, err := vm.Continue(); !done || err != nil {
+		t.Fatalf("unexpected Continue result: %v, %v", done, err)
+	}
+	if err := vm.CheckErrorCondition(true); err != nil {
+		t.Fatalf("unexpected error at end of script: %v", err)
+	}
+}
+
+// TestTraceWriter ensures the trace writer emits one JSON object per step with
+// hex encoded stack items.
+func TestTraceWriter(t *testing.T) {
+	t.Parallel()
+
+	tx := debugTestTx(mustParseShortForm("DATA_2 0xabcd"))
+	pkScript := mustParseShortForm("SIZE 2 EQUALVERIFY")
+
+	var buf bytes.Buffer
+	vm, err := NewDebugEngine(pkScript, tx, 0, 0, nil, nil, 0,
+		NewTraceWriter(&buf))
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	if err := vm.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dec := json.NewDecoder(&buf)
+	var entries []map[string]interface{}
+	for dec.More() {
+		var entry map[string]interface{}
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("failed to decode trace entry: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("unexpected number of trace entries - got %d, want 4",
+			len(entries))
+	}
+
+	entry := entries[2]
+	wantStack := []interface{}{"abcd", "02"}
+	if !reflect.DeepEqual(entry["stack"], wantStack) {
+		t.Fatalf("unexpected stack - got %v, want %v", entry["stack"],
+			wantStack)
+	}
+	if entry["disasm"] != "OP_2" || entry["remaining"] != "OP_EQUALVERIFY" {
+		t.Fatalf("unexpected trace entry: %v", entry)
+	}
+	if _, ok := entry["witnessprogram"]; ok {
+		t.Fatalf("unexpected witness program in trace entry: %v", entry)
+	}
+}
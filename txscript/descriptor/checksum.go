@@ -0,0 +1,118 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// inputCharset is the set of characters allowed in a descriptor.  The
+	// position of each character determines its value in the checksum
+	// computation, which is designed so that the most common characters
+	// fall into the first group of 32.
+	inputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+		"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+		"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+
+	// checksumCharset is the bech32 character set used to encode the
+	// checksum.
+	checksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	// checksumLength is the number of characters in a descriptor
+	// checksum.
+	checksumLength = 8
+)
+
+// polyMod is the BCH code generator used by the descriptor checksum.  It
+// processes a single 5-bit value on top of the running checksum c.
+func polyMod(c uint64, val int) uint64 {
+	c0 := c >> 35
+	c = ((c & 0x7ffffffff) << 5) ^ uint64(val)
+	if c0&1 != 0 {
+		c ^= 0xf5dee51989
+	}
+	if c0&2 != 0 {
+		c ^= 0xa9fdca3312
+	}
+	if c0&4 != 0 {
+		c ^= 0x1bab10e32d
+	}
+	if c0&8 != 0 {
+		c ^= 0x3706b1677a
+	}
+	if c0&16 != 0 {
+		c ^= 0x644d626ffd
+	}
+	return c
+}
+
+// Checksum returns the 8 character checksum for the passed descriptor, which
+// must not already contain a checksum.  An error is returned if the
+// descriptor contains characters outside of the descriptor character set.
+func Checksum(desc string) (string, error) {
+	c := uint64(1)
+	cls, clsCount := 0, 0
+	for i := 0; i < len(desc); i++ {
+		pos := strings.IndexByte(inputCharset, desc[i])
+		if pos == -1 {
+			return "", fmt.Errorf("invalid character %q at position "+
+				"%d", desc[i], i)
+		}
+
+		// Emit a symbol for the position inside the group for every
+		// character, and one symbol for the groups of every three
+		// characters.
+		c = polyMod(c, pos&31)
+		cls = cls*3 + pos>>5
+		clsCount++
+		if clsCount == 3 {
+			c = polyMod(c, cls)
+			cls, clsCount = 0, 0
+		}
+	}
+	if clsCount > 0 {
+		c = polyMod(c, cls)
+	}
+
+	// Shift further to determine the checksum.
+	for i := 0; i < checksumLength; i++ {
+		c = polyMod(c, 0)
+	}
+
+	// Prevent appending zeroes from not affecting the checksum.
+	c ^= 1
+
+	var sum [checksumLength]byte
+	for i := range sum {
+		sum[i] = checksumCharset[(c>>(5*(7-uint(i))))&31]
+	}
+	return string(sum[:]), nil
+}
+
+// splitChecksum separates the passed descriptor into the descriptor itself
+// and its checksum.  When a checksum is present it is verified.
+func splitChecksum(desc string) (string, error) {
+	idx := strings.IndexByte(desc, '#')
+	if idx == -1 {
+		return desc, nil
+	}
+
+	expr, sum := desc[:idx], desc[idx+1:]
+	if len(sum) != checksumLength {
+		return "", fmt.Errorf("%w: expected %d characters, got %d",
+			ErrInvalidChecksum, checksumLength, len(sum))
+	}
+	want, err := Checksum(expr)
+	if err != nil {
+		return "", err
+	}
+	if sum != want {
+		return "", fmt.Errorf("%w: got %s, want %s",
+			ErrInvalidChecksum, sum, want)
+	}
+	return expr, nil
+}
@@ -0,0 +1,432 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+)
+
+var (
+	// ErrInvalidChecksum is returned when a descriptor carries a checksum
+	// that does not match its contents.
+	ErrInvalidChecksum = errors.New("invalid descriptor checksum")
+
+	// ErrInvalidKey is returned when a key expression cannot be parsed.
+	ErrInvalidKey = errors.New("invalid key expression")
+
+	// ErrInvalidExpression is returned when a script expression is
+	// malformed or used in a context where it is not allowed.
+	ErrInvalidExpression = errors.New("invalid script expression")
+
+	// ErrUnsupported is returned for descriptor expressions that are
+	// valid but not supported by this package.
+	ErrUnsupported = errors.New("unsupported descriptor expression")
+
+	// ErrNoAddress is returned by Address for descriptors whose output
+	// script has no address encoding, such as bare multisig.
+	ErrNoAddress = errors.New("descriptor has no address encoding")
+)
+
+// scriptContext identifies where a script expression appears, which
+// determines the expressions and keys it may contain.
+type scriptContext int
+
+const (
+	// contextTop is the top level of a descriptor.
+	contextTop scriptContext = iota
+
+	// contextP2SH is the inside of a sh() expression.
+	contextP2SH
+
+	// contextP2WSH is the inside of a wsh() expression.
+	contextP2WSH
+)
+
+// Maximum number of public keys allowed in a multisig expression for each
+// script context.
+const (
+	maxBareMultisigKeys = 3
+	maxP2SHMultisigKeys = 15
+	maxMultisigKeys     = 20
+)
+
+// exprType identifies the kind of a script expression.
+type exprType int
+
+const (
+	exprPK exprType = iota
+	exprPKH
+	exprWPKH
+	exprSH
+	exprWSH
+	exprMulti
+	exprSortedMulti
+	exprAddr
+	exprRaw
+)
+
+// scriptExpr is a parsed SCRIPT expression.  Only the fields relevant to its
+// type are set.
+type scriptExpr struct {
+	typ       exprType
+	keys      []*keyExpr
+	threshold int
+	sub       *scriptExpr
+	addr      btcutil.Address
+	raw       []byte
+}
+
+// Descriptor is a parsed output script descriptor.
+type Descriptor struct {
+	expr *scriptExpr
+	desc string
+	net  *chaincfg.Params
+}
+
+// Parse parses the passed output descriptor for the given network.  The
+// descriptor checksum is optional, but is verified when present.
+func Parse(desc string, net *chaincfg.Params) (*Descriptor, error) {
+	expr, err := splitChecksum(desc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure all characters are valid before attempting to parse.
+	if _, err := Checksum(expr); err != nil {
+		return nil, err
+	}
+
+	script, err := parseScript(expr, contextTop, net)
+	if err != nil {
+		return nil, err
+	}
+	return &Descriptor{expr: script, desc: expr, net: net}, nil
+}
+
+// String returns the descriptor including its checksum.
+func (d *Descriptor) String() string {
+	// The characters were validated during parsing, so computing the
+	// checksum can't fail.
+	sum, _ := Checksum(d.desc)
+	return d.desc + "#" + sum
+}
+
+// IsRange returns whether the descriptor contains a key expression with a
+// wildcard and therefore describes a range of output scripts.
+func (d *Descriptor) IsRange() bool {
+	return d.expr.isRange()
+}
+
+// Script returns the output script described by the descriptor.  For ranged
+// descriptors, index selects the child to derive for every wildcard.  It is
+// ignored otherwise.
+func (d *Descriptor) Script(index uint32) ([]byte, error) {
+	return d.expr.script(index)
+}
+
+// Address returns the address of the output script described by the
+// descriptor at the given child index.  ErrNoAddress is returned for
+// descriptors without an address encoding such as pk() and bare multisig.
+func (d *Descriptor) Address(index uint32) (btcutil.Address, error) {
+	if d.expr.typ == exprAddr {
+		return d.expr.addr, nil
+	}
+
+	script, err := d.Script(index)
+	if err != nil {
+		return nil, err
+	}
+	class, addrs, _, err := txscript.ExtractPkScriptAddrs(script, d.net)
+	if err != nil {
+		return nil, err
+	}
+	switch class {
+	case txscript.PubKeyHashTy, txscript.ScriptHashTy,
+		txscript.WitnessV0PubKeyHashTy, txscript.WitnessV0ScriptHashTy:
+
+		if len(addrs) == 1 {
+			return addrs[0], nil
+		}
+	}
+	return nil, ErrNoAddress
+}
+
+// isRange returns whether any key within the expression is ranged.
+func (e *scriptExpr) isRange() bool {
+	if e.sub != nil {
+		return e.sub.isRange()
+	}
+	for _, key := range e.keys {
+		if key.isRange() {
+			return true
+		}
+	}
+	return false
+}
+
+// script compiles the expression into a script at the given child index.
+func (e *scriptExpr) script(index uint32) ([]byte, error) {
+	switch e.typ {
+	case exprPK:
+		pubKey, err := e.keys[0].serializedPubKey(index)
+		if err != nil {
+			return nil, err
+		}
+		return txscript.NewScriptBuilder().AddData(pubKey).
+			AddOp(txscript.OP_CHECKSIG).Script()
+
+	case exprPKH:
+		pubKey, err := e.keys[0].serializedPubKey(index)
+		if err != nil {
+			return nil, err
+		}
+		return txscript.NewScriptBuilder().AddOp(txscript.OP_DUP).
+			AddOp(txscript.OP_HASH160).
+			AddData(btcutil.Hash160(pubKey)).
+			AddOp(txscript.OP_EQUALVERIFY).
+			AddOp(txscript.OP_CHECKSIG).Script()
+
+	case exprWPKH:
+		pubKey, err := e.keys[0].serializedPubKey(index)
+		if err != nil {
+			return nil, err
+		}
+		return txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+			AddData(btcutil.Hash160(pubKey)).Script()
+
+	case exprSH:
+		redeemScript, err := e.sub.script(index)
+		if err != nil {
+			return nil, err
+		}
+		return txscript.NewScriptBuilder().AddOp(txscript.OP_HASH160).
+			AddData(btcutil.Hash160(redeemScript)).
+			AddOp(txscript.OP_EQUAL).Script()
+
+	case exprWSH:
+		witnessScript, err := e.sub.script(index)
+		if err != nil {
+			return nil, err
+		}
+		scriptHash := sha256.Sum256(witnessScript)
+		return txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+			AddData(scriptHash[:]).Script()
+
+	case exprMulti, exprSortedMulti:
+		pubKeys := make([][]byte, 0, len(e.keys))
+		for _, key := range e.keys {
+			pubKey, err := key.serializedPubKey(index)
+			if err != nil {
+				return nil, err
+			}
+			pubKeys = append(pubKeys, pubKey)
+		}
+		if e.typ == exprSortedMulti {
+			sort.Slice(pubKeys, func(i, j int) bool {
+				return bytes.Compare(pubKeys[i], pubKeys[j]) < 0
+			})
+		}
+
+		builder := txscript.NewScriptBuilder().
+			AddInt64(int64(e.threshold))
+		for _, pubKey := range pubKeys {
+			builder.AddData(pubKey)
+		}
+		return builder.AddInt64(int64(len(pubKeys))).
+			AddOp(txscript.OP_CHECKMULTISIG).Script()
+
+	case exprAddr:
+		return txscript.PayToAddrScript(e.addr)
+
+	case exprRaw:
+		return e.raw, nil
+	}
+
+	return nil, fmt.Errorf("unknown expression type %d", e.typ)
+}
+
+// splitArgs splits the arguments of a script expression on top-level commas.
+func splitArgs(args string) []string {
+	var (
+		parts []string
+		depth int
+		start int
+	)
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, args[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, args[start:])
+}
+
+// parseScript parses a SCRIPT expression appearing in the given context.
+func parseScript(expr string, ctx scriptContext,
+	net *chaincfg.Params) (*scriptExpr, error) {
+
+	open := strings.IndexByte(expr, '(')
+	if open <= 0 || !strings.HasSuffix(expr, ")") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidExpression, expr)
+	}
+	name, args := expr[:open], expr[open+1:len(expr)-1]
+
+	switch name {
+	case "pk", "pkh", "wpkh":
+		if name == "wpkh" && ctx == contextP2WSH {
+			return nil, fmt.Errorf("%w: wpkh is not allowed inside "+
+				"wsh", ErrInvalidExpression)
+		}
+		key, err := parseKey(args, net)
+		if err != nil {
+			return nil, err
+		}
+		if (name == "wpkh" || ctx == contextP2WSH) &&
+			!key.isCompressed() {
+
+			return nil, fmt.Errorf("%w: uncompressed keys are not "+
+				"allowed in segwit scripts", ErrInvalidKey)
+		}
+
+		typ := exprPK
+		switch name {
+		case "pkh":
+			typ = exprPKH
+		case "wpkh":
+			typ = exprWPKH
+		}
+		return &scriptExpr{typ: typ, keys: []*keyExpr{key}}, nil
+
+	case "sh":
+		if ctx != contextTop {
+			return nil, fmt.Errorf("%w: sh is only allowed at the "+
+				"top level", ErrInvalidExpression)
+		}
+		sub, err := parseScript(args, contextP2SH, net)
+		if err != nil {
+			return nil, err
+		}
+		// The redeem script must not exceed the push limit.
+		if sub.typ == exprMulti || sub.typ == exprSortedMulti {
+			redeemScript, err := sub.script(0)
+			if err != nil {
+				return nil, err
+			}
+			if len(redeemScript) > txscript.MaxScriptElementSize {
+				return nil, fmt.Errorf("%w: redeem script is %d "+
+					"bytes, max %d", ErrInvalidExpression,
+					len(redeemScript),
+					txscript.MaxScriptElementSize)
+			}
+		}
+		return &scriptExpr{typ: exprSH, sub: sub}, nil
+
+	case "wsh":
+		if ctx == contextP2WSH {
+			return nil, fmt.Errorf("%w: wsh is not allowed inside "+
+				"wsh", ErrInvalidExpression)
+		}
+		sub, err := parseScript(args, contextP2WSH, net)
+		if err != nil {
+			return nil, err
+		}
+		return &scriptExpr{typ: exprWSH, sub: sub}, nil
+
+	case "multi", "sortedmulti":
+		parts := splitArgs(args)
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("%w: %s requires a threshold and "+
+				"at least one key", ErrInvalidExpression, name)
+		}
+		threshold, err := strconv.Atoi(parts[0])
+		if err != nil || threshold < 1 || threshold > len(parts)-1 {
+			return nil, fmt.Errorf("%w: invalid multisig threshold "+
+				"%q", ErrInvalidExpression, parts[0])
+		}
+
+		maxKeys := maxMultisigKeys
+		switch ctx {
+		case contextTop:
+			maxKeys = maxBareMultisigKeys
+		case contextP2SH:
+			maxKeys = maxP2SHMultisigKeys
+		}
+		if len(parts)-1 > maxKeys {
+			return nil, fmt.Errorf("%w: %d keys exceeds the maximum "+
+				"of %d", ErrInvalidExpression, len(parts)-1,
+				maxKeys)
+		}
+
+		e := &scriptExpr{typ: exprMulti, threshold: threshold}
+		if name == "sortedmulti" {
+			e.typ = exprSortedMulti
+		}
+		for _, part := range parts[1:] {
+			key, err := parseKey(part, net)
+			if err != nil {
+				return nil, err
+			}
+			if ctx == contextP2WSH && !key.isCompressed() {
+				return nil, fmt.Errorf("%w: uncompressed keys "+
+					"are not allowed in segwit scripts",
+					ErrInvalidKey)
+			}
+			e.keys = append(e.keys, key)
+		}
+		return e, nil
+
+	case "addr":
+		if ctx != contextTop {
+			return nil, fmt.Errorf("%w: addr is only allowed at "+
+				"the top level", ErrInvalidExpression)
+		}
+		addr, err := btcutil.DecodeAddress(args, net)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExpression,
+				err)
+		}
+		if !addr.IsForNet(net) {
+			return nil, fmt.Errorf("%w: address is not for network "+
+				"%s", ErrInvalidExpression, net.Name)
+		}
+		return &scriptExpr{typ: exprAddr, addr: addr}, nil
+
+	case "raw":
+		if ctx != contextTop {
+			return nil, fmt.Errorf("%w: raw is only allowed at the "+
+				"top level", ErrInvalidExpression)
+		}
+		script, err := hex.DecodeString(args)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExpression,
+				err)
+		}
+		return &scriptExpr{typ: exprRaw, raw: script}, nil
+
+	case "tr", "rawtr":
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, name)
+	}
+
+	return nil, fmt.Errorf("%w: unknown function %q", ErrInvalidExpression,
+		name)
+}
@@ -0,0 +1,282 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+// Keys used throughout the tests.
+const (
+	testKey1 = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	testKey2 = "02f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9"
+	testKey3 = "03fff97bd5755eeea420453a14355235d382f6472f8568a18b2f057a1460297556"
+
+	testUncompressedKey = "04a34b99f22c790c4e36b2b3c2c35a36db06226e41c692fc82b8b56ac1c540c5bd5b8dec5235a0fa8722476c7709c02559e3aa73aa03918ba2d492eea75abea235"
+
+	// testXPub is the master public key of BIP-32 test vector 1.
+	testXPub = "xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8"
+)
+
+// hexToBytes converts the passed hex string into bytes and will panic if
+// there is an error.  This is only provided for the hard-coded constants so
+// errors in the source code can be detected.
+func hexToBytes(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic("invalid hex in source file: " + s)
+	}
+	return b
+}
+
+// TestChecksum ensures descriptor checksums are computed and verified
+// according to the BIP-380 through BIP-382 test vectors.
+func TestChecksum(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		sum  string
+	}{
+		{"raw(deadbeef)", "89f8spxm"},
+		{"pk(" + testKey1 + ")", "gn28ywm7"},
+		{"wpkh(" + testKey2 + ")", "8zl0zxma"},
+		{"sh(wpkh(" + testKey3 + "))", "qkrrc7je"},
+	}
+	for _, test := range tests {
+		sum, err := Checksum(test.desc)
+		if err != nil {
+			t.Errorf("Checksum(%s): unexpected error: %v", test.desc, err)
+			continue
+		}
+		if sum != test.sum {
+			t.Errorf("Checksum(%s): got %s, want %s", test.desc, sum,
+				test.sum)
+			continue
+		}
+
+		// Parsing must accept the correct checksum and reject a
+		// modified one.
+		desc := test.desc + "#" + test.sum
+		parsed, err := Parse(desc, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Errorf("Parse(%s): unexpected error: %v", desc, err)
+			continue
+		}
+		if parsed.String() != desc {
+			t.Errorf("String: got %s, want %s", parsed.String(), desc)
+		}
+		_, err = Parse(test.desc+"#"+test.sum[1:]+"q",
+			&chaincfg.MainNetParams)
+		if !errors.Is(err, ErrInvalidChecksum) {
+			t.Errorf("Parse(%s) with bad checksum: got %v, want %v",
+				test.desc, err, ErrInvalidChecksum)
+		}
+	}
+}
+
+// TestParseScripts ensures descriptors with fixed keys compile to the
+// expected output scripts.
+func TestParseScripts(t *testing.T) {
+	t.Parallel()
+
+	multiScript := func(threshold byte, keys ...string) []byte {
+		script := []byte{0x50 + threshold}
+		for _, key := range keys {
+			script = append(script, 0x21)
+			script = append(script, hexToBytes(key)...)
+		}
+		return append(script, 0x50+byte(len(keys)), 0xae)
+	}
+	p2wpkh := func(key string) []byte {
+		return append([]byte{0x00, 0x14},
+			btcutil.Hash160(hexToBytes(key))...)
+	}
+	p2sh := func(script []byte) []byte {
+		out := append([]byte{0xa9, 0x14}, btcutil.Hash160(script)...)
+		return append(out, 0x87)
+	}
+
+	tests := []struct {
+		name   string
+		desc   string
+		script []byte
+	}{{
+		name:   "pk",
+		desc:   "pk(" + testKey1 + ")",
+		script: hexToBytes("21" + testKey1 + "ac"),
+	}, {
+		name:   "pkh uncompressed",
+		desc:   "pkh(" + testUncompressedKey + ")",
+		script: hexToBytes("76a914b5bd079c4d57cc7fc28ecf8213a6b791625b818388ac"),
+	}, {
+		name:   "wpkh",
+		desc:   "wpkh(" + testKey2 + ")",
+		script: hexToBytes("00147dd65592d0ab2fe0d0257d571abf032cd9db93dc"),
+	}, {
+		name:   "sh wpkh",
+		desc:   "sh(wpkh(" + testKey3 + "))",
+		script: p2sh(p2wpkh(testKey3)),
+	}, {
+		name:   "multi keeps key order",
+		desc:   "multi(1," + testKey3 + "," + testKey1 + ")",
+		script: multiScript(1, testKey3, testKey1),
+	}, {
+		name:   "sh sortedmulti sorts keys",
+		desc:   "sh(sortedmulti(2," + testKey3 + "," + testKey2 + "," + testKey1 + "))",
+		script: p2sh(multiScript(2, testKey1, testKey2, testKey3)),
+	}, {
+		name:   "raw",
+		desc:   "raw(deadbeef)",
+		script: hexToBytes("deadbeef"),
+	}, {
+		name:   "addr",
+		desc:   "addr(1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2)",
+		script: hexToBytes("76a91477bff20c60e522dfaa3350c39b030a5d004e839a88ac"),
+	}}
+
+	for _, test := range tests {
+		desc, err := Parse(test.desc, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Errorf("%s: unexpected parse error: %v", test.name, err)
+			continue
+		}
+		if desc.IsRange() {
+			t.Errorf("%s: descriptor unexpectedly ranged", test.name)
+		}
+		script, err := desc.Script(0)
+		if err != nil {
+			t.Errorf("%s: unexpected script error: %v", test.name, err)
+			continue
+		}
+		if !bytes.Equal(script, test.script) {
+			t.Errorf("%s: got script %x, want %x", test.name, script,
+				test.script)
+		}
+	}
+}
+
+// TestParseErrors ensures invalid descriptors are rejected with the expected
+// error.
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		desc string
+		err  error
+	}{
+		{"unknown function", "foo(" + testKey1 + ")", ErrInvalidExpression},
+		{"missing paren", "pk(" + testKey1, ErrInvalidExpression},
+		{"nested sh", "sh(sh(pk(" + testKey1 + ")))", ErrInvalidExpression},
+		{"wsh in wsh", "wsh(wsh(pk(" + testKey1 + ")))", ErrInvalidExpression},
+		{"wpkh in wsh", "wsh(wpkh(" + testKey1 + "))", ErrInvalidExpression},
+		{"raw in sh", "sh(raw(deadbeef))", ErrInvalidExpression},
+		{"uncompressed wpkh", "wpkh(" + testUncompressedKey + ")", ErrInvalidKey},
+		{"uncompressed in wsh", "wsh(pk(" + testUncompressedKey + "))", ErrInvalidKey},
+		{"threshold too high", "multi(3," + testKey1 + "," + testKey2 + ")", ErrInvalidExpression},
+		{"threshold zero", "multi(0," + testKey1 + ")", ErrInvalidExpression},
+		{"too many bare keys", "multi(1," + testKey1 + "," + testKey2 + "," + testKey3 + "," + testKey1 + ")", ErrInvalidExpression},
+		{"bad key", "pk(02aa)", ErrInvalidKey},
+		{"bad origin", "pk([d34db33/44'/0']" + testKey1 + ")", ErrInvalidKey},
+		{"path on plain key", "pk(" + testKey1 + "/0)", ErrInvalidKey},
+		{"hardened from xpub", "pkh(" + testXPub + "/0'/*)", ErrInvalidKey},
+		{"hardened wildcard from xpub", "pkh(" + testXPub + "/*')", ErrInvalidKey},
+		{"wildcard not last", "pkh(" + testXPub + "/*/0)", ErrInvalidKey},
+		{"wrong network", "pkh(" + testXPub + ")", ErrInvalidKey},
+		{"taproot", "tr(" + testKey1 + ")", ErrUnsupported},
+	}
+
+	for _, test := range tests {
+		net := &chaincfg.MainNetParams
+		if test.name == "wrong network" {
+			net = &chaincfg.TestNet3Params
+		}
+		_, err := Parse(test.desc, net)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: got error %v, want %v", test.name, err,
+				test.err)
+		}
+	}
+}
+
+// TestRangedDescriptor ensures ranged descriptors derive the expected child
+// keys and addresses.
+func TestRangedDescriptor(t *testing.T) {
+	t.Parallel()
+
+	desc, err := Parse("wpkh([d34db33f/84'/0'/0']"+testXPub+"/1/*)",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if !desc.IsRange() {
+		t.Fatalf("descriptor not reported as ranged")
+	}
+
+	master, err := hdkeychain.NewKeyFromString(testXPub)
+	if err != nil {
+		t.Fatalf("unable to parse extended key: %v", err)
+	}
+	branch, err := master.Derive(1)
+	if err != nil {
+		t.Fatalf("unable to derive branch: %v", err)
+	}
+
+	for i := uint32(0); i < 3; i++ {
+		child, err := branch.Derive(i)
+		if err != nil {
+			t.Fatalf("unable to derive child %d: %v", i, err)
+		}
+		pubKey, err := child.ECPubKey()
+		if err != nil {
+			t.Fatalf("unable to get child %d pubkey: %v", i, err)
+		}
+		want, err := btcutil.NewAddressWitnessPubKeyHash(
+			btcutil.Hash160(pubKey.SerializeCompressed()),
+			&chaincfg.MainNetParams,
+		)
+		if err != nil {
+			t.Fatalf("unable to create address: %v", err)
+		}
+
+		addr, err := desc.Address(i)
+		if err != nil {
+			t.Fatalf("index %d: unexpected address error: %v", i, err)
+		}
+		if addr.EncodeAddress() != want.EncodeAddress() {
+			t.Errorf("index %d: got address %s, want %s", i,
+				addr.EncodeAddress(), want.EncodeAddress())
+		}
+	}
+}
+
+// TestAddress ensures Address returns ErrNoAddress for output scripts without
+// an address encoding.
+func TestAddress(t *testing.T) {
+	t.Parallel()
+
+	for _, str := range []string{
+		"pk(" + testKey1 + ")",
+		"multi(1," + testKey1 + "," + testKey2 + ")",
+		"raw(deadbeef)",
+	} {
+		desc, err := Parse(str, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatalf("%s: unexpected parse error: %v", str, err)
+		}
+		if _, err := desc.Address(0); err != ErrNoAddress {
+			t.Errorf("%s: got error %v, want %v", str, err,
+				ErrNoAddress)
+		}
+	}
+}
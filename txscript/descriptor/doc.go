@@ -0,0 +1,41 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package descriptor implements parsing of output script descriptors as defined
+by BIP-380 through BIP-383 and compiles them into output scripts and addresses.
+
+A descriptor is a human readable string that fully describes a set of output
+scripts, for example:
+
+	wpkh([d34db33f/84'/0'/0']xpub.../0/*)#checksum
+
+The following script expressions are supported:
+
+	pk(KEY)                 - pay to public key
+	pkh(KEY)                - pay to public key hash
+	wpkh(KEY)               - pay to witness public key hash
+	sh(SCRIPT)              - pay to script hash
+	wsh(SCRIPT)             - pay to witness script hash
+	multi(k,KEY,...)        - bare k-of-n multisig
+	sortedmulti(k,KEY,...)  - k-of-n multisig with lexicographically sorted keys
+	addr(ADDR)              - the output script of an encoded address
+	raw(HEX)                - a raw hex encoded output script
+
+Key expressions may be hex encoded public keys, WIF encoded private keys or
+extended keys followed by a derivation path.  Extended keys may carry key
+origin information and may end in a /* or /*' wildcard, which makes the
+descriptor ranged.  Scripts and addresses for ranged descriptors are derived
+by passing the child index to Script or Address.
+
+The taproot expressions tr and rawtr are recognized but rejected since this
+module does not implement taproot outputs yet.
+
+Checksums
+
+A descriptor may be suffixed with an 8 character checksum separated by a #.
+When present, Parse verifies it.  The Checksum function computes the checksum
+for a descriptor string and Descriptor.String always includes it.
+*/
+package descriptor
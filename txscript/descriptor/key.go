@@ -0,0 +1,256 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+// rangeType describes whether and how a key expression is ranged.
+type rangeType int
+
+const (
+	// rangeNone indicates a key expression that describes a single key.
+	rangeNone rangeType = iota
+
+	// rangeUnhardened indicates a key expression ending in /*.
+	rangeUnhardened
+
+	// rangeHardened indicates a key expression ending in /*' or /*h.
+	rangeHardened
+)
+
+// keyExpr is a parsed KEY expression of a descriptor.  It either holds a
+// single fixed public key or an extended key along with the derivation path
+// to apply to it.
+type keyExpr struct {
+	// pubKey and compressed describe a fixed public key.  They are only
+	// set when extKey is nil.
+	pubKey     *btcec.PublicKey
+	compressed bool
+
+	// extKey is the extended key to derive from, path the derivation
+	// steps following it and ranged whether the final step is a wildcard.
+	extKey *hdkeychain.ExtendedKey
+	path   []uint32
+	ranged rangeType
+}
+
+// isRange returns whether the key expression ends in a wildcard.
+func (k *keyExpr) isRange() bool {
+	return k.ranged != rangeNone
+}
+
+// isCompressed returns whether the keys produced by the expression are
+// serialized in compressed form.  Extended keys always produce compressed
+// keys.
+func (k *keyExpr) isCompressed() bool {
+	return k.extKey != nil || k.compressed
+}
+
+// serializedPubKey returns the serialized public key described by the
+// expression for the given child index.  The index is ignored for key
+// expressions that are not ranged.
+func (k *keyExpr) serializedPubKey(index uint32) ([]byte, error) {
+	if k.extKey == nil {
+		if k.compressed {
+			return k.pubKey.SerializeCompressed(), nil
+		}
+		return k.pubKey.SerializeUncompressed(), nil
+	}
+
+	key := k.extKey
+	for _, step := range k.path {
+		var err error
+		key, err = key.Derive(step)
+		if err != nil {
+			return nil, err
+		}
+	}
+	switch k.ranged {
+	case rangeUnhardened:
+		if index >= hdkeychain.HardenedKeyStart {
+			return nil, fmt.Errorf("child index %d is out of range",
+				index)
+		}
+		derived, err := key.Derive(index)
+		if err != nil {
+			return nil, err
+		}
+		key = derived
+
+	case rangeHardened:
+		if index >= hdkeychain.HardenedKeyStart {
+			return nil, fmt.Errorf("child index %d is out of range",
+				index)
+		}
+		derived, err := key.Derive(index + hdkeychain.HardenedKeyStart)
+		if err != nil {
+			return nil, err
+		}
+		key = derived
+	}
+
+	pubKey, err := key.ECPubKey()
+	if err != nil {
+		return nil, err
+	}
+	return pubKey.SerializeCompressed(), nil
+}
+
+// parsePathElement parses a single BIP-32 derivation path element, which is a
+// decimal number optionally followed by ' or h to indicate a hardened step.
+func parsePathElement(elem string) (uint32, error) {
+	var offset uint32
+	if strings.HasSuffix(elem, "'") || strings.HasSuffix(elem, "h") {
+		offset = hdkeychain.HardenedKeyStart
+		elem = elem[:len(elem)-1]
+	}
+
+	idx, err := strconv.ParseUint(elem, 10, 32)
+	if err != nil || uint32(idx) >= hdkeychain.HardenedKeyStart {
+		return 0, fmt.Errorf("%w: invalid path element %q",
+			ErrInvalidKey, elem)
+	}
+	return uint32(idx) + offset, nil
+}
+
+// parseOrigin validates the key origin information enclosed in square
+// brackets, which consists of an 8 character hex fingerprint optionally
+// followed by a derivation path.
+func parseOrigin(origin string) error {
+	parts := strings.Split(origin, "/")
+	if len(parts[0]) != 8 {
+		return fmt.Errorf("%w: fingerprint %q is not 4 bytes",
+			ErrInvalidKey, parts[0])
+	}
+	if _, err := hex.DecodeString(parts[0]); err != nil {
+		return fmt.Errorf("%w: fingerprint %q is not hex",
+			ErrInvalidKey, parts[0])
+	}
+	for _, elem := range parts[1:] {
+		if _, err := parsePathElement(elem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseKey parses a KEY expression.  The network is used to reject WIF and
+// extended keys that belong to a different network.
+func parseKey(expr string, net *chaincfg.Params) (*keyExpr, error) {
+	// Strip and validate the optional key origin.
+	if strings.HasPrefix(expr, "[") {
+		end := strings.IndexByte(expr, ']')
+		if end == -1 {
+			return nil, fmt.Errorf("%w: unterminated key origin "+
+				"in %q", ErrInvalidKey, expr)
+		}
+		if err := parseOrigin(expr[1:end]); err != nil {
+			return nil, err
+		}
+		expr = expr[end+1:]
+	}
+
+	parts := strings.Split(expr, "/")
+	keyStr := parts[0]
+
+	// Anything that decodes as hex is a raw public key.
+	if keyBytes, err := hex.DecodeString(keyStr); err == nil {
+		if len(parts) > 1 {
+			return nil, fmt.Errorf("%w: derivation path on a "+
+				"non-extended key", ErrInvalidKey)
+		}
+		pubKey, err := btcec.ParsePubKey(keyBytes, btcec.S256())
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+		return &keyExpr{
+			pubKey:     pubKey,
+			compressed: len(keyBytes) == btcec.PubKeyBytesLenCompressed,
+		}, nil
+	}
+
+	// Next, try to decode a WIF encoded private key.
+	if wif, err := btcutil.DecodeWIF(keyStr); err == nil {
+		if len(parts) > 1 {
+			return nil, fmt.Errorf("%w: derivation path on a "+
+				"non-extended key", ErrInvalidKey)
+		}
+		if !wif.IsForNet(net) {
+			return nil, fmt.Errorf("%w: private key is not for "+
+				"network %s", ErrInvalidKey, net.Name)
+		}
+		return &keyExpr{
+			pubKey:     wif.PrivKey.PubKey(),
+			compressed: wif.CompressPubKey,
+		}, nil
+	}
+
+	// Finally, the key must be an extended key followed by an optional
+	// derivation path and wildcard.
+	extKey, err := hdkeychain.NewKeyFromString(keyStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to decode %q",
+			ErrInvalidKey, keyStr)
+	}
+	if !extKey.IsForNet(net) {
+		return nil, fmt.Errorf("%w: extended key is not for network %s",
+			ErrInvalidKey, net.Name)
+	}
+
+	key := &keyExpr{extKey: extKey}
+	for i, elem := range parts[1:] {
+		isLast := i == len(parts)-2
+		switch elem {
+		case "*":
+			if !isLast {
+				return nil, fmt.Errorf("%w: wildcard must be the "+
+					"last path element", ErrInvalidKey)
+			}
+			key.ranged = rangeUnhardened
+			continue
+
+		case "*'", "*h":
+			if !isLast {
+				return nil, fmt.Errorf("%w: wildcard must be the "+
+					"last path element", ErrInvalidKey)
+			}
+			key.ranged = rangeHardened
+			continue
+		}
+
+		step, err := parsePathElement(elem)
+		if err != nil {
+			return nil, err
+		}
+		key.path = append(key.path, step)
+	}
+
+	// Hardened derivation is only possible from a private extended key.
+	if !extKey.IsPrivate() {
+		if key.ranged == rangeHardened {
+			return nil, fmt.Errorf("%w: hardened derivation from a "+
+				"public extended key", ErrInvalidKey)
+		}
+		for _, step := range key.path {
+			if step >= hdkeychain.HardenedKeyStart {
+				return nil, fmt.Errorf("%w: hardened derivation "+
+					"from a public extended key",
+					ErrInvalidKey)
+			}
+		}
+	}
+
+	return key, nil
+}
@@ -94,6 +94,23 @@ const (
 	// operation whose public key isn't serialized in a compressed format
 	// non-standard.
 	ScriptVerifyWitnessPubKeyType
+
+	// ScriptVerifyCheckTemplateVerify defines whether to allow execution
+	// pathways of a script to be restricted to a transaction template
+	// using the OP_CHECKTEMPLATEVERIFY opcode as defined by BIP0119.  It
+	// is not activated on any network and is meant for experimentation.
+	ScriptVerifyCheckTemplateVerify
+
+	// ScriptDiscourageUpgradableTemplateHash defines whether to verify
+	// that an OP_CHECKTEMPLATEVERIFY checks a 32-byte template hash
+	// rather than an item reserved for future template types.  This is
+	// not a consensus rule.
+	ScriptDiscourageUpgradableTemplateHash
+
+	// ScriptVerifyCat defines whether to re-enable the OP_CAT opcode as
+	// proposed by BIP0347.  It is not activated on any network and is
+	// meant for experimentation.
+	ScriptVerifyCat
 )
 
 const (
@@ -129,13 +146,15 @@ type Engine struct {
 	condStack       []int
 	numOps          int
 	flags           ScriptFlags
-	sigCache        *SigCache
+	sigCache        SignatureCache
 	hashCache       *TxSigHashes
 	bip16           bool     // treat execution as pay-to-script-hash
 	savedFirstStack [][]byte // stack from first script for bip16 scripts
 	witnessVersion  int
 	witnessProgram  []byte
 	inputAmount     int64
+	stepCallback    StepCallback
+	breakpoints     map[scriptPos]struct{}
 }
 
 // hasFlag returns whether the script engine instance has the passed flag set.
@@ -158,8 +177,10 @@ func (vm *Engine) isBranchExecuting() bool {
 // whether or not it is hidden by conditionals, but some rules still must be
 // tested in this case.
 func (vm *Engine) executeOpcode(pop *parsedOpcode) error {
-	// Disabled opcodes are fail on program counter.
-	if pop.isDisabled() {
+	// Disabled opcodes are fail on program counter.  OP_CAT is re-enabled
+	// by the ScriptVerifyCat flag.
+	catEnabled := pop.opcode.value == OP_CAT && vm.hasFlag(ScriptVerifyCat)
+	if pop.isDisabled() && !catEnabled {
 		str := fmt.Sprintf("attempt to execute disabled opcode %s",
 			pop.opcode.name)
 		return scriptError(ErrDisabledOpcode, str)
@@ -249,6 +270,24 @@ func (vm *Engine) isWitnessVersionActive(version uint) bool {
 	return vm.witnessProgram != nil && uint(vm.witnessVersion) == version
 }
 
+// sigHashes returns the precomputed sighash midstate of the transaction being
+// validated.  When the engine was not created with precomputed sighashes, they
+// are computed on first use and kept, so all signature checks of the input
+// share them.
+func (vm *Engine) sigHashes() *TxSigHashes {
+	if vm.hashCache == nil {
+		vm.hashCache = NewTxSigHashes(&vm.tx)
+	}
+	return vm.hashCache
+}
+
+// isPayToAnchorSpend returns true if the input being validated spends a native
+// pay-to-anchor output with the passed witness, which must be empty.  Such
+// spends are not discouraged although the witness version is not defined yet.
+func (vm *Engine) isPayToAnchorSpend(witness [][]byte) bool {
+	return len(witness) == 0 && isPayToAnchor(vm.scripts[1])
+}
+
 // verifyWitnessProgram validates the stored witness program using the passed
 // witness as input.
 func (vm *Engine) verifyWitnessProgram(witness [][]byte) error {
@@ -329,7 +368,9 @@ func (vm *Engine) verifyWitnessProgram(witness [][]byte) error {
 				len(vm.witnessProgram))
 			return scriptError(ErrWitnessProgramWrongLength, errStr)
 		}
-	} else if vm.hasFlag(ScriptVerifyDiscourageUpgradeableWitnessProgram) {
+	} else if vm.hasFlag(ScriptVerifyDiscourageUpgradeableWitnessProgram) &&
+		!vm.isPayToAnchorSpend(witness) {
+
 		errStr := fmt.Sprintf("new witness program versions "+
 			"invalid: %v", vm.witnessProgram)
 		return scriptError(ErrDiscourageUpgradableWitnessProgram, errStr)
@@ -445,6 +486,15 @@ func (vm *Engine) Step() (done bool, err error) {
 	if err != nil {
 		return true, err
 	}
+
+	// Hand the state prior to executing the opcode to the step callback of
+	// debug engines.
+	if vm.stepCallback != nil {
+		if err := vm.stepCallback(vm.stepInfo()); err != nil {
+			return true, err
+		}
+	}
+
 	opcode := &vm.scripts[vm.scriptIdx][vm.scriptOff]
 	vm.scriptOff++
 
@@ -851,9 +901,12 @@ func (vm *Engine) SetAltStack(data [][]byte) {
 
 // NewEngine returns a new script engine for the provided public key script,
 // transaction, and input index.  The flags modify the behavior of the script
-// engine according to the description provided by each flag.
+// engine according to the description provided by each flag.  The optional
+// hashCache holds the sighash midstate of the transaction, which should be
+// shared by the engines validating its inputs.  It is computed by the engine
+// when nil.
 func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int, flags ScriptFlags,
-	sigCache *SigCache, hashCache *TxSigHashes, inputAmount int64) (*Engine, error) {
+	sigCache SignatureCache, hashCache *TxSigHashes, inputAmount int64) (*Engine, error) {
 
 	// The provided transaction input index must refer to a valid input.
 	if txIdx < 0 || txIdx >= len(tx.TxIn) {
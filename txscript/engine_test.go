@@ -5,6 +5,7 @@
 package txscript
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
@@ -145,6 +146,211 @@ func TestCheckErrorCondition(t *testing.T) {
 	}
 }
 
+// TestPayToAnchorSpend ensures spends of pay-to-anchor outputs with an empty
+// witness are not discouraged while spends of other unknown witness versions
+// and pay-to-anchor spends with a witness are.
+func TestPayToAnchorSpend(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		pkScript []byte
+		witness  wire.TxWitness
+		valid    bool
+	}{{
+		name:     "anchor with empty witness",
+		pkScript: PayToAnchorScript(),
+		valid:    true,
+	}, {
+		name:     "anchor with witness",
+		pkScript: PayToAnchorScript(),
+		witness:  wire.TxWitness{{0x01}},
+	}, {
+		name:     "unknown witness version",
+		pkScript: mustParseShortForm("1 DATA_2 0x4e74"),
+	}}
+
+	flags := ScriptBip16 | ScriptVerifyWitness |
+		ScriptVerifyDiscourageUpgradeableWitnessProgram
+	for _, test := range tests {
+		tx := &wire.MsgTx{
+			Version: 2,
+			TxIn: []*wire.TxIn{{
+				Witness:  test.witness,
+				Sequence: wire.MaxTxInSequenceNum,
+			}},
+			TxOut: []*wire.TxOut{{Value: 0, PkScript: []byte{OP_RETURN}}},
+		}
+		vm, err := NewEngine(test.pkScript, tx, 0, flags, nil, nil, 240)
+		if err != nil {
+			t.Fatalf("%s: failed to create engine: %v", test.name, err)
+		}
+		err = vm.Execute()
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if !test.valid &&
+			!IsErrorCode(err, ErrDiscourageUpgradableWitnessProgram) {
+
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+	}
+}
+
+// TestCheckTemplateVerify ensures OP_CHECKTEMPLATEVERIFY only allows spends
+// by transactions matching the committed template hash when the
+// ScriptVerifyCheckTemplateVerify flag is set and otherwise acts as OP_NOP4.
+func TestCheckTemplateVerify(t *testing.T) {
+	t.Parallel()
+
+	tx := &wire.MsgTx{
+		Version: 2,
+		TxIn: []*wire.TxIn{{
+			Sequence: wire.MaxTxInSequenceNum,
+		}},
+		TxOut: []*wire.TxOut{{Value: 1000, PkScript: []byte{OP_TRUE}}},
+	}
+	templateHash := CalcTemplateHash(tx, 0)
+	otherHash := templateHash
+	otherHash[0] ^= 0x01
+
+	ctvScript := func(item []byte) []byte {
+		script, err := NewScriptBuilder().AddData(item).
+			AddOp(OP_CHECKTEMPLATEVERIFY).Script()
+		if err != nil {
+			t.Fatalf("failed to build script: %v", err)
+		}
+		return script
+	}
+
+	tests := []struct {
+		name    string
+		item    []byte
+		flags   ScriptFlags
+		errCode ErrorCode
+		valid   bool
+	}{{
+		name:  "matching template hash",
+		item:  templateHash[:],
+		flags: ScriptVerifyCheckTemplateVerify,
+		valid: true,
+	}, {
+		name:    "mismatched template hash",
+		item:    otherHash[:],
+		flags:   ScriptVerifyCheckTemplateVerify,
+		errCode: ErrTemplateMismatch,
+	}, {
+		name:  "mismatched template hash without flag",
+		item:  otherHash[:],
+		valid: true,
+	}, {
+		name:    "mismatched template hash discouraged nop",
+		item:    otherHash[:],
+		flags:   ScriptDiscourageUpgradableNops,
+		errCode: ErrDiscourageUpgradableNOPs,
+	}, {
+		name:  "upgradable template type",
+		item:  []byte{0x01, 0x02},
+		flags: ScriptVerifyCheckTemplateVerify,
+		valid: true,
+	}, {
+		name: "discouraged upgradable template type",
+		item: []byte{0x01, 0x02},
+		flags: ScriptVerifyCheckTemplateVerify |
+			ScriptDiscourageUpgradableTemplateHash,
+		errCode: ErrDiscourageUpgradableTemplateHash,
+	}}
+
+	for _, test := range tests {
+		vm, err := NewEngine(ctvScript(test.item), tx, 0, test.flags,
+			nil, nil, 0)
+		if err != nil {
+			t.Fatalf("%s: failed to create engine: %v", test.name, err)
+		}
+		err = vm.Execute()
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if !test.valid && !IsErrorCode(err, test.errCode) {
+			t.Errorf("%s: unexpected error - got %v, want %v",
+				test.name, err, test.errCode)
+		}
+	}
+}
+
+// TestOpcodeCat ensures OP_CAT is only executed when the ScriptVerifyCat flag
+// is set and that it enforces the maximum script element size.
+func TestOpcodeCat(t *testing.T) {
+	t.Parallel()
+
+	bigItem := bytes.Repeat([]byte{0x01}, MaxScriptElementSize/2+1)
+	bigCatScript, err := NewScriptBuilder().AddData(bigItem).
+		AddData(bigItem).AddOp(OP_CAT).AddOp(OP_DROP).AddOp(OP_TRUE).
+		Script()
+	if err != nil {
+		t.Fatalf("failed to build script: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		pkScript []byte
+		flags    ScriptFlags
+		errCode  ErrorCode
+		valid    bool
+	}{{
+		name:     "concatenation",
+		pkScript: mustParseShortForm("'ab' 'cd' CAT 'abcd' EQUAL"),
+		flags:    ScriptVerifyCat,
+		valid:    true,
+	}, {
+		name:     "concatenation of empty items",
+		pkScript: mustParseShortForm("0 0 CAT 0 EQUAL"),
+		flags:    ScriptVerifyCat,
+		valid:    true,
+	}, {
+		name:     "disabled without flag",
+		pkScript: mustParseShortForm("'ab' 'cd' CAT 'abcd' EQUAL"),
+		errCode:  ErrDisabledOpcode,
+	}, {
+		name:     "disabled in unexecuted branch without flag",
+		pkScript: mustParseShortForm("0 IF CAT ENDIF 1"),
+		errCode:  ErrDisabledOpcode,
+	}, {
+		name:     "stack underflow",
+		pkScript: mustParseShortForm("'ab' CAT"),
+		flags:    ScriptVerifyCat,
+		errCode:  ErrInvalidStackOperation,
+	}, {
+		name:     "result too big",
+		pkScript: bigCatScript,
+		flags:    ScriptVerifyCat,
+		errCode:  ErrElementTooBig,
+	}}
+
+	tx := &wire.MsgTx{
+		Version: 2,
+		TxIn: []*wire.TxIn{{
+			Sequence: wire.MaxTxInSequenceNum,
+		}},
+		TxOut: []*wire.TxOut{{Value: 0, PkScript: []byte{OP_RETURN}}},
+	}
+	for _, test := range tests {
+		vm, err := NewEngine(test.pkScript, tx, 0, test.flags, nil,
+			nil, 0)
+		if err != nil {
+			t.Fatalf("%s: failed to create engine: %v", test.name, err)
+		}
+		err = vm.Execute()
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if !test.valid && !IsErrorCode(err, test.errCode) {
+			t.Errorf("%s: unexpected error - got %v, want %v",
+				test.name, err, test.errCode)
+		}
+	}
+}
+
 // TestInvalidFlagCombinations ensures the script engine returns the expected
 // error when disallowed flag combinations are specified.
 func TestInvalidFlagCombinations(t *testing.T) {
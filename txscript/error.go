@@ -338,6 +338,67 @@ const (
 	// serialized in a compressed format.
 	ErrWitnessPubKeyType
 
+	// -----------------------------------------
+	// Failures related to covenant proposals.
+	// -----------------------------------------
+
+	// ErrTemplateMismatch is returned if ScriptVerifyCheckTemplateVerify
+	// is set and the 32-byte template hash checked by an
+	// OP_CHECKTEMPLATEVERIFY does not match the standard template hash of
+	// the transaction.
+	ErrTemplateMismatch
+
+	// ErrDiscourageUpgradableTemplateHash is returned if
+	// ScriptDiscourageUpgradableTemplateHash is set and the item checked
+	// by an OP_CHECKTEMPLATEVERIFY is not a 32-byte template hash.
+	ErrDiscourageUpgradableTemplateHash
+
+	// -----------------------------------------------
+	// Failures related to standardness policy checks.
+	// -----------------------------------------------
+
+	// ErrNonStandardVersion is returned when a transaction has a version
+	// outside of the range considered standard.
+	ErrNonStandardVersion
+
+	// ErrNonStandardTxWeight is returned when a transaction exceeds the
+	// maximum weight considered standard.
+	ErrNonStandardTxWeight
+
+	// ErrNonStandardSigScript is returned when a signature script exceeds
+	// the maximum size considered standard or does not only push data.
+	ErrNonStandardSigScript
+
+	// ErrNonStandardPkScript is returned when an output script is not of
+	// a standard form.
+	ErrNonStandardPkScript
+
+	// ErrDustOutput is returned when an output pays an amount that costs
+	// more to spend than it is worth at the minimum relay fee.
+	ErrDustOutput
+
+	// ErrTooManyNullData is returned when a transaction has more than one
+	// data carrier output.
+	ErrTooManyNullData
+
+	// ErrNonStandardInput is returned when an input spends an output with
+	// a non-standard script or has too many pay-to-script-hash signature
+	// operations.
+	ErrNonStandardInput
+
+	// ErrNonStandardWitness is returned when an input has a witness that
+	// is not considered standard, such as a taproot annex or an oversized
+	// pay-to-witness-script-hash witness.
+	ErrNonStandardWitness
+
+	// ErrNonStandardSigOpCost is returned when the signature operation
+	// cost of a transaction exceeds the maximum considered standard.
+	ErrNonStandardSigOpCost
+
+	// ErrMissingPrevOut is returned when the output spent by an input can
+	// not be fetched.
+	ErrMissingPrevOut
+
 	// numErrorCodes is the maximum error code number used in tests.  This
 	// entry MUST be the last entry in the enum.
 	numErrorCodes
@@ -409,6 +470,18 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrMinimalIf:                          "ErrMinimalIf",
 	ErrWitnessPubKeyType:                  "ErrWitnessPubKeyType",
 	ErrDiscourageUpgradableWitnessProgram: "ErrDiscourageUpgradableWitnessProgram",
+	ErrTemplateMismatch:                   "ErrTemplateMismatch",
+	ErrDiscourageUpgradableTemplateHash:   "ErrDiscourageUpgradableTemplateHash",
+	ErrNonStandardVersion:                 "ErrNonStandardVersion",
+	ErrNonStandardTxWeight:                "ErrNonStandardTxWeight",
+	ErrNonStandardSigScript:               "ErrNonStandardSigScript",
+	ErrNonStandardPkScript:                "ErrNonStandardPkScript",
+	ErrDustOutput:                         "ErrDustOutput",
+	ErrTooManyNullData:                    "ErrTooManyNullData",
+	ErrNonStandardInput:                   "ErrNonStandardInput",
+	ErrNonStandardWitness:                 "ErrNonStandardWitness",
+	ErrNonStandardSigOpCost:               "ErrNonStandardSigOpCost",
+	ErrMissingPrevOut:                     "ErrMissingPrevOut",
 }
 
 // String returns the ErrorCode as a human-readable name.
@@ -80,6 +80,18 @@ func TestErrorCodeStringer(t *testing.T) {
 		{ErrMinimalIf, "ErrMinimalIf"},
 		{ErrWitnessPubKeyType, "ErrWitnessPubKeyType"},
 		{ErrDiscourageUpgradableWitnessProgram, "ErrDiscourageUpgradableWitnessProgram"},
+		{ErrTemplateMismatch, "ErrTemplateMismatch"},
+		{ErrDiscourageUpgradableTemplateHash, "ErrDiscourageUpgradableTemplateHash"},
+		{ErrNonStandardVersion, "ErrNonStandardVersion"},
+		{ErrNonStandardTxWeight, "ErrNonStandardTxWeight"},
+		{ErrNonStandardSigScript, "ErrNonStandardSigScript"},
+		{ErrNonStandardPkScript, "ErrNonStandardPkScript"},
+		{ErrDustOutput, "ErrDustOutput"},
+		{ErrTooManyNullData, "ErrTooManyNullData"},
+		{ErrNonStandardInput, "ErrNonStandardInput"},
+		{ErrNonStandardWitness, "ErrNonStandardWitness"},
+		{ErrNonStandardSigOpCost, "ErrNonStandardSigOpCost"},
+		{ErrMissingPrevOut, "ErrMissingPrevOut"},
 		{0xffff, "Unknown ErrorCode (65535)"},
 	}
 
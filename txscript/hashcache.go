@@ -11,18 +11,104 @@ import (
 	"github.com/btcsuite/btcd/wire"
 )
 
+// PrevOutputFetcher is an interface used to supply the previous outputs
+// spent by the inputs of a transaction, which are committed to by the BIP0341
+// sighash midstate.
+type PrevOutputFetcher interface {
+	// FetchPrevOutput returns the output referenced by the passed
+	// outpoint, or nil if it is unknown.
+	FetchPrevOutput(wire.OutPoint) *wire.TxOut
+}
+
+// CannedPrevOutputFetcher is an implementation of PrevOutputFetcher which
+// returns the same output for every outpoint.  It is useful when all inputs
+// of a transaction spend outputs with the same script and amount.
+type CannedPrevOutputFetcher struct {
+	pkScript []byte
+	amt      int64
+}
+
+// NewCannedPrevOutputFetcher returns a fetcher which returns an output with
+// the passed script and amount for every outpoint.
+func NewCannedPrevOutputFetcher(pkScript []byte, amt int64) *CannedPrevOutputFetcher {
+	return &CannedPrevOutputFetcher{
+		pkScript: pkScript,
+		amt:      amt,
+	}
+}
+
+// FetchPrevOutput returns the canned output.
+//
+// This is part of the PrevOutputFetcher interface.
+func (c *CannedPrevOutputFetcher) FetchPrevOutput(wire.OutPoint) *wire.TxOut {
+	return wire.NewTxOut(c.amt, c.pkScript)
+}
+
+// A compile-time assertion to ensure CannedPrevOutputFetcher implements the
+// PrevOutputFetcher interface.
+var _ PrevOutputFetcher = (*CannedPrevOutputFetcher)(nil)
+
+// MultiPrevOutFetcher is an implementation of PrevOutputFetcher backed by a
+// map of outpoints to the outputs they reference.
+type MultiPrevOutFetcher struct {
+	prevOuts map[wire.OutPoint]*wire.TxOut
+}
+
+// NewMultiPrevOutFetcher returns a fetcher for the passed outputs.  A nil map
+// results in an empty fetcher.
+func NewMultiPrevOutFetcher(prevOuts map[wire.OutPoint]*wire.TxOut) *MultiPrevOutFetcher {
+	if prevOuts == nil {
+		prevOuts = make(map[wire.OutPoint]*wire.TxOut)
+	}
+
+	return &MultiPrevOutFetcher{
+		prevOuts: prevOuts,
+	}
+}
+
+// FetchPrevOutput returns the output referenced by the passed outpoint, or nil
+// if it was not added to the fetcher.
+//
+// This is part of the PrevOutputFetcher interface.
+func (m *MultiPrevOutFetcher) FetchPrevOutput(op wire.OutPoint) *wire.TxOut {
+	return m.prevOuts[op]
+}
+
+// AddPrevOut adds the output referenced by the passed outpoint.
+func (m *MultiPrevOutFetcher) AddPrevOut(op wire.OutPoint, txOut *wire.TxOut) {
+	m.prevOuts[op] = txOut
+}
+
+// A compile-time assertion to ensure MultiPrevOutFetcher implements the
+// PrevOutputFetcher interface.
+var _ PrevOutputFetcher = (*MultiPrevOutFetcher)(nil)
+
 // TxSigHashes houses the partial set of sighashes introduced within BIP0143.
 // This partial set of sighashes may be re-used within each input across a
 // transaction when validating all inputs. As a result, validation complexity
 // for SigHashAll can be reduced by a polynomial factor.
+//
+// When created with a PrevOutputFetcher, it additionally houses the single
+// SHA256 hashes committed to by the BIP0341 sighash, which also cover the
+// amounts and scripts of all spent outputs.
 type TxSigHashes struct {
 	HashPrevOuts chainhash.Hash
 	HashSequence chainhash.Hash
 	HashOutputs  chainhash.Hash
+
+	HashPrevOutsV1     chainhash.Hash
+	HashSequenceV1     chainhash.Hash
+	HashOutputsV1      chainhash.Hash
+	HashInputScriptsV1 chainhash.Hash
+	HashInputAmountsV1 chainhash.Hash
+
+	// HasV1Hashes reports whether the BIP0341 hashes are set.
+	HasV1Hashes bool
 }
 
 // NewTxSigHashes computes, and returns the cached sighashes of the given
-// transaction.
+// transaction.  Only the BIP0143 sighashes are computed since the BIP0341 ones
+// require the spent outputs, see NewTxSigHashesWithFetcher.
 func NewTxSigHashes(tx *wire.MsgTx) *TxSigHashes {
 	return &TxSigHashes{
 		HashPrevOuts: calcHashPrevOuts(tx),
@@ -31,6 +117,36 @@ func NewTxSigHashes(tx *wire.MsgTx) *TxSigHashes {
 	}
 }
 
+// NewTxSigHashesWithFetcher computes, and returns the cached BIP0143 and
+// BIP0341 sighashes of the given transaction.  The passed fetcher must return
+// the outputs spent by all inputs of the transaction.
+//
+// The BIP0143 hashes are the double SHA256 of the same serializations hashed
+// once by BIP0341, so they are derived from the latter without serializing
+// the transaction again.
+func NewTxSigHashesWithFetcher(tx *wire.MsgTx,
+	fetcher PrevOutputFetcher) (*TxSigHashes, error) {
+
+	prevOuts, err := fetchPrevOutputs(tx, fetcher)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &TxSigHashes{
+		HashPrevOutsV1:     calcHashPrevOutsV1(tx),
+		HashSequenceV1:     calcHashSequenceV1(tx),
+		HashOutputsV1:      calcHashOutputsV1(tx),
+		HashInputScriptsV1: calcHashInputScriptsV1(prevOuts),
+		HashInputAmountsV1: calcHashInputAmountsV1(prevOuts),
+		HasV1Hashes:        true,
+	}
+	h.HashPrevOuts = chainhash.HashH(h.HashPrevOutsV1[:])
+	h.HashSequence = chainhash.HashH(h.HashSequenceV1[:])
+	h.HashOutputs = chainhash.HashH(h.HashOutputsV1[:])
+
+	return h, nil
+}
+
 // HashCache houses a set of partial sighashes keyed by txid. The set of partial
 // sighashes are those introduced within BIP0143 by the new more efficient
 // sighash digest calculation algorithm. Using this threadsafe shared cache,
@@ -58,6 +174,24 @@ func (h *HashCache) AddSigHashes(tx *wire.MsgTx) {
 	h.Unlock()
 }
 
+// AddSigHashesWithFetcher computes, then adds the partial BIP0143 and BIP0341
+// sighashes for the passed transaction using the passed fetcher for the
+// outputs spent by it.
+func (h *HashCache) AddSigHashesWithFetcher(tx *wire.MsgTx,
+	fetcher PrevOutputFetcher) error {
+
+	sigHashes, err := NewTxSigHashesWithFetcher(tx, fetcher)
+	if err != nil {
+		return err
+	}
+
+	h.Lock()
+	h.sigHashes[tx.TxHash()] = sigHashes
+	h.Unlock()
+
+	return nil
+}
+
 // ContainsHashes returns true if the partial sighashes for the passed
 // transaction currently exist within the HashCache, and false otherwise.
 func (h *HashCache) ContainsHashes(txid *chainhash.Hash) bool {
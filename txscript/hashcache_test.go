@@ -5,10 +5,13 @@
 package txscript
 
 import (
+	"bytes"
+	"encoding/binary"
 	"math/rand"
 	"testing"
 	"time"
 
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/davecgh/go-spew/spew"
 )
@@ -178,3 +181,78 @@ func TestHashCachePurge(t *testing.T) {
 		}
 	}
 }
+
+// TestTxSigHashesWithFetcher ensures the sighashes computed with a previous
+// output fetcher match the BIP0143 sighashes and commit to the spent outputs,
+// and that missing outputs are rejected.
+func TestTxSigHashesWithFetcher(t *testing.T) {
+	t.Parallel()
+
+	randTx, err := genTestTx()
+	if err != nil {
+		t.Fatalf("unable to generate tx: %v", err)
+	}
+
+	var amounts, scripts bytes.Buffer
+	fetcher := NewMultiPrevOutFetcher(nil)
+	for i, txIn := range randTx.TxIn {
+		prevOut := wire.NewTxOut(int64(i)*1000, []byte{OP_TRUE, byte(i)})
+		fetcher.AddPrevOut(txIn.PreviousOutPoint, prevOut)
+
+		var amt [8]byte
+		binary.LittleEndian.PutUint64(amt[:], uint64(prevOut.Value))
+		amounts.Write(amt[:])
+		wire.WriteVarBytes(&scripts, 0, prevOut.PkScript)
+	}
+
+	sigHashes, err := NewTxSigHashesWithFetcher(randTx, fetcher)
+	if err != nil {
+		t.Fatalf("unable to compute sighashes: %v", err)
+	}
+	if !sigHashes.HasV1Hashes {
+		t.Fatalf("BIP0341 sighashes not computed")
+	}
+
+	// The BIP0143 sighashes must match those computed without a fetcher.
+	v0Hashes := NewTxSigHashes(randTx)
+	if sigHashes.HashPrevOuts != v0Hashes.HashPrevOuts ||
+		sigHashes.HashSequence != v0Hashes.HashSequence ||
+		sigHashes.HashOutputs != v0Hashes.HashOutputs {
+
+		t.Fatalf("BIP0143 sighashes don't match: expected %v, got %v",
+			spew.Sdump(v0Hashes), spew.Sdump(sigHashes))
+	}
+
+	if sigHashes.HashInputAmountsV1 != chainhash.HashH(amounts.Bytes()) {
+		t.Fatalf("unexpected input amounts hash %v",
+			sigHashes.HashInputAmountsV1)
+	}
+	if sigHashes.HashInputScriptsV1 != chainhash.HashH(scripts.Bytes()) {
+		t.Fatalf("unexpected input scripts hash %v",
+			sigHashes.HashInputScriptsV1)
+	}
+
+	// All outputs are the same for a canned fetcher.
+	canned := NewCannedPrevOutputFetcher([]byte{OP_TRUE}, 5000)
+	cannedHashes, err := NewTxSigHashesWithFetcher(randTx, canned)
+	if err != nil {
+		t.Fatalf("unable to compute sighashes: %v", err)
+	}
+	if cannedHashes.HashPrevOutsV1 != sigHashes.HashPrevOutsV1 ||
+		cannedHashes.HashInputAmountsV1 == sigHashes.HashInputAmountsV1 {
+
+		t.Fatalf("unexpected sighashes for canned fetcher: %v",
+			spew.Sdump(cannedHashes))
+	}
+
+	// Sighashes can't be computed when a spent output is unknown.
+	cache := NewHashCache(10)
+	err = cache.AddSigHashesWithFetcher(randTx, NewMultiPrevOutFetcher(nil))
+	if err == nil {
+		t.Fatalf("expected error for unknown spent outputs")
+	}
+	txid := randTx.TxHash()
+	if cache.ContainsHashes(&txid) {
+		t.Fatalf("sighashes added despite unknown spent outputs")
+	}
+}
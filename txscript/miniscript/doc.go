@@ -0,0 +1,34 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package miniscript implements parsing, type checking, compilation and
+satisfaction of Miniscript expressions as described in BIP-379.
+
+Miniscript is a structured representation of a subset of bitcoin script that
+allows generic analysis of spending conditions.  A parsed expression can be
+compiled into a witness script, analyzed for the maximum size of the witness
+needed to spend it and satisfied given the signatures, hash preimages and
+timelocks available to the spender.
+
+Only the P2WSH context is supported.  Keys are hex encoded compressed public
+keys.  The Tapscript context requires OP_CHECKSIGADD and x-only keys, which the
+script engine in this module does not implement yet.
+
+Type System
+
+Every expression has exactly one basic type:
+
+	B - base: pushes a nonzero value on success and zero on failure
+	V - verify: continues on success and aborts the script on failure
+	K - key: pushes a public key for which a signature is to be checked
+	W - wrapped: a B expression operating one element below the stack top
+
+along with the type properties z (consumes no stack elements), o (consumes
+exactly one stack element), n (the top stack element is never zero when
+satisfied), d (can be dissatisfied without aborting) and u (pushes exactly 1
+when satisfied).  Parse rejects expressions that violate the typing rules and
+requires the top level expression to be of type B.
+*/
+package miniscript
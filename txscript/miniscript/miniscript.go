@@ -0,0 +1,470 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package miniscript
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+var (
+	// ErrParse is returned when an expression cannot be parsed.
+	ErrParse = errors.New("miniscript parse error")
+
+	// ErrType is returned when an expression violates the typing rules.
+	ErrType = errors.New("miniscript type error")
+
+	// ErrScriptSize is returned when the compiled script of an expression
+	// exceeds the maximum script size.
+	ErrScriptSize = errors.New("miniscript script too large")
+)
+
+// fragment identifies a Miniscript fragment or wrapper.
+type fragment int
+
+const (
+	fragJust0 fragment = iota
+	fragJust1
+	fragPkK
+	fragPkH
+	fragOlder
+	fragAfter
+	fragSha256
+	fragHash256
+	fragRipemd160
+	fragHash160
+	fragAndOr
+	fragAndV
+	fragAndB
+	fragOrB
+	fragOrC
+	fragOrD
+	fragOrI
+	fragThresh
+	fragMulti
+	fragWrapA
+	fragWrapS
+	fragWrapC
+	fragWrapD
+	fragWrapV
+	fragWrapJ
+	fragWrapN
+)
+
+// fragmentNames maps the fragments that are written as functions to their
+// names.
+var fragmentNames = map[fragment]string{
+	fragJust0:     "0",
+	fragJust1:     "1",
+	fragPkK:       "pk_k",
+	fragPkH:       "pk_h",
+	fragOlder:     "older",
+	fragAfter:     "after",
+	fragSha256:    "sha256",
+	fragHash256:   "hash256",
+	fragRipemd160: "ripemd160",
+	fragHash160:   "hash160",
+	fragAndOr:     "andor",
+	fragAndV:      "and_v",
+	fragAndB:      "and_b",
+	fragOrB:       "or_b",
+	fragOrC:       "or_c",
+	fragOrD:       "or_d",
+	fragOrI:       "or_i",
+	fragThresh:    "thresh",
+	fragMulti:     "multi",
+}
+
+// binaryFragments maps the names of the fragments that take exactly two sub
+// expressions to their fragments.
+var binaryFragments = map[string]fragment{
+	"and_v": fragAndV,
+	"and_b": fragAndB,
+	"or_b":  fragOrB,
+	"or_c":  fragOrC,
+	"or_d":  fragOrD,
+	"or_i":  fragOrI,
+}
+
+// wrappers maps wrapper characters to their fragments.
+var wrappers = map[byte]fragment{
+	'a': fragWrapA,
+	's': fragWrapS,
+	'c': fragWrapC,
+	'd': fragWrapD,
+	'v': fragWrapV,
+	'j': fragWrapJ,
+	'n': fragWrapN,
+}
+
+// Node is a node of a parsed Miniscript expression.
+type Node struct {
+	frag fragment
+	subs []*Node
+
+	// k is the threshold of thresh and multi and the lock time of older
+	// and after.
+	k int64
+
+	// keys holds the serialized compressed public keys of pk_k, pk_h and
+	// multi.
+	keys [][]byte
+
+	// hash holds the hash of the hash fragments.
+	hash []byte
+
+	typ Type
+}
+
+// Type returns the type of the expression.
+func (n *Node) Type() Type {
+	return n.typ
+}
+
+// Parse parses and type checks the passed Miniscript expression in the P2WSH
+// context.  The top level expression must be of type B and its compiled
+// script must not exceed the maximum script size.
+func Parse(expr string) (*Node, error) {
+	node, err := parseNode(expr)
+	if err != nil {
+		return nil, err
+	}
+	if node.typ.base != baseB {
+		return nil, fmt.Errorf("%w: top level expression is of type "+
+			"%v, not B", ErrType, node.typ)
+	}
+	if _, err := node.Script(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrScriptSize, err)
+	}
+	return node, nil
+}
+
+// splitArgs splits the arguments of a fragment on top-level commas.
+func splitArgs(args string) []string {
+	var (
+		parts []string
+		depth int
+		start int
+	)
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, args[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, args[start:])
+}
+
+// parseKey decodes a hex encoded compressed public key.
+func parseKey(s string) ([]byte, error) {
+	key, err := hex.DecodeString(s)
+	if err != nil || len(key) != btcec.PubKeyBytesLenCompressed {
+		return nil, fmt.Errorf("%w: invalid compressed public key %q",
+			ErrParse, s)
+	}
+	if _, err := btcec.ParsePubKey(key, btcec.S256()); err != nil {
+		return nil, fmt.Errorf("%w: invalid public key %q: %v",
+			ErrParse, s, err)
+	}
+	return key, nil
+}
+
+// newNode creates a node for the given fragment and computes its type.
+func newNode(frag fragment, subs ...*Node) (*Node, error) {
+	n := &Node{frag: frag, subs: subs}
+	if err := n.computeType(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// parseNode parses an expression including its wrappers.
+func parseNode(expr string) (*Node, error) {
+	// Wrappers are a run of letters terminated by a colon that appears
+	// before the opening parenthesis of the fragment.
+	colon := strings.IndexByte(expr, ':')
+	paren := strings.IndexByte(expr, '(')
+	if colon != -1 && (paren == -1 || colon < paren) {
+		wrapperChars, inner := expr[:colon], expr[colon+1:]
+		if wrapperChars == "" {
+			return nil, fmt.Errorf("%w: empty wrapper in %q",
+				ErrParse, expr)
+		}
+		node, err := parseNode(inner)
+		if err != nil {
+			return nil, err
+		}
+
+		// Wrappers apply from the innermost (rightmost) outwards.
+		for i := len(wrapperChars) - 1; i >= 0; i-- {
+			node, err = wrapNode(wrapperChars[i], node)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return node, nil
+	}
+
+	switch expr {
+	case "0":
+		return newNode(fragJust0)
+	case "1":
+		return newNode(fragJust1)
+	}
+
+	if paren <= 0 || !strings.HasSuffix(expr, ")") {
+		return nil, fmt.Errorf("%w: malformed expression %q", ErrParse,
+			expr)
+	}
+	name, args := expr[:paren], splitArgs(expr[paren+1:len(expr)-1])
+
+	checkArgs := func(want int) error {
+		if len(args) != want {
+			return fmt.Errorf("%w: %s takes %d arguments, got %d",
+				ErrParse, name, want, len(args))
+		}
+		return nil
+	}
+
+	switch name {
+	case "pk_k", "pk_h", "pk", "pkh":
+		if err := checkArgs(1); err != nil {
+			return nil, err
+		}
+		key, err := parseKey(args[0])
+		if err != nil {
+			return nil, err
+		}
+		frag := fragPkK
+		if name == "pk_h" || name == "pkh" {
+			frag = fragPkH
+		}
+		node := &Node{frag: frag, keys: [][]byte{key}}
+		if err := node.computeType(); err != nil {
+			return nil, err
+		}
+
+		// pk and pkh are shorthands for c:pk_k and c:pk_h.
+		if name == "pk" || name == "pkh" {
+			return newNode(fragWrapC, node)
+		}
+		return node, nil
+
+	case "older", "after":
+		if err := checkArgs(1); err != nil {
+			return nil, err
+		}
+		k, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || k < 1 || k >= 1<<31 {
+			return nil, fmt.Errorf("%w: invalid lock time %q",
+				ErrParse, args[0])
+		}
+		frag := fragOlder
+		if name == "after" {
+			frag = fragAfter
+		}
+		node := &Node{frag: frag, k: k}
+		return node, node.computeType()
+
+	case "sha256", "hash256", "ripemd160", "hash160":
+		if err := checkArgs(1); err != nil {
+			return nil, err
+		}
+		frag, size := fragSha256, 32
+		switch name {
+		case "hash256":
+			frag = fragHash256
+		case "ripemd160":
+			frag, size = fragRipemd160, 20
+		case "hash160":
+			frag, size = fragHash160, 20
+		}
+		hash, err := hex.DecodeString(args[0])
+		if err != nil || len(hash) != size {
+			return nil, fmt.Errorf("%w: %s requires a %d byte hex "+
+				"hash", ErrParse, name, size)
+		}
+		node := &Node{frag: frag, hash: hash}
+		return node, node.computeType()
+
+	case "thresh", "multi":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("%w: %s requires a threshold "+
+				"and at least one argument", ErrParse, name)
+		}
+		k, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || k < 1 || k > int64(len(args)-1) {
+			return nil, fmt.Errorf("%w: invalid threshold %q",
+				ErrParse, args[0])
+		}
+		if name == "multi" {
+			if len(args)-1 > txscript.MaxPubKeysPerMultiSig {
+				return nil, fmt.Errorf("%w: multi with more "+
+					"than %d keys", ErrParse,
+					txscript.MaxPubKeysPerMultiSig)
+			}
+			node := &Node{frag: fragMulti, k: k}
+			for _, arg := range args[1:] {
+				key, err := parseKey(arg)
+				if err != nil {
+					return nil, err
+				}
+				node.keys = append(node.keys, key)
+			}
+			return node, node.computeType()
+		}
+
+		node := &Node{frag: fragThresh, k: k}
+		for _, arg := range args[1:] {
+			sub, err := parseNode(arg)
+			if err != nil {
+				return nil, err
+			}
+			node.subs = append(node.subs, sub)
+		}
+		return node, node.computeType()
+	}
+
+	// The remaining fragments all take sub expressions.
+	_, isBinary := binaryFragments[name]
+	if !isBinary && name != "andor" && name != "and_n" {
+		return nil, fmt.Errorf("%w: unknown fragment %q", ErrParse, name)
+	}
+	subs := make([]*Node, 0, len(args))
+	for _, arg := range args {
+		sub, err := parseNode(arg)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	switch name {
+	case "andor":
+		if err := checkArgs(3); err != nil {
+			return nil, err
+		}
+		return newNode(fragAndOr, subs...)
+
+	case "and_n":
+		// and_n(X,Y) is a shorthand for andor(X,Y,0).
+		if err := checkArgs(2); err != nil {
+			return nil, err
+		}
+		zero, err := newNode(fragJust0)
+		if err != nil {
+			return nil, err
+		}
+		return newNode(fragAndOr, subs[0], subs[1], zero)
+	}
+
+	if err := checkArgs(2); err != nil {
+		return nil, err
+	}
+	return newNode(binaryFragments[name], subs...)
+}
+
+// wrapNode applies the wrapper identified by the passed character to the
+// node.
+func wrapNode(c byte, node *Node) (*Node, error) {
+	if frag, ok := wrappers[c]; ok {
+		return newNode(frag, node)
+	}
+
+	// The remaining wrappers are shorthands for other fragments.
+	var zeroOrOne fragment
+	switch c {
+	case 't':
+		zeroOrOne = fragJust1
+	case 'l', 'u':
+		zeroOrOne = fragJust0
+	default:
+		return nil, fmt.Errorf("%w: unknown wrapper %q", ErrParse, c)
+	}
+	other, err := newNode(zeroOrOne)
+	if err != nil {
+		return nil, err
+	}
+	switch c {
+	case 't':
+		return newNode(fragAndV, node, other)
+	case 'l':
+		return newNode(fragOrI, other, node)
+	default:
+		return newNode(fragOrI, node, other)
+	}
+}
+
+// String returns the canonical string representation of the expression.
+// Shorthands such as pk() and t: are returned in their expanded form.
+func (n *Node) String() string {
+	var sb strings.Builder
+	n.writeString(&sb, false)
+	return sb.String()
+}
+
+// writeString writes the expression to the builder.  The wrapped parameter
+// indicates whether the caller already wrote a wrapper prefix, in which case
+// only the colon is left to be written before a non-wrapper fragment.
+func (n *Node) writeString(sb *strings.Builder, wrapped bool) {
+	for c, frag := range wrappers {
+		if frag == n.frag {
+			sb.WriteByte(c)
+			n.subs[0].writeString(sb, true)
+			return
+		}
+	}
+	if wrapped {
+		sb.WriteByte(':')
+	}
+
+	sb.WriteString(fragmentNames[n.frag])
+	switch n.frag {
+	case fragJust0, fragJust1:
+		return
+
+	case fragPkK, fragPkH:
+		fmt.Fprintf(sb, "(%x)", n.keys[0])
+
+	case fragOlder, fragAfter:
+		fmt.Fprintf(sb, "(%d)", n.k)
+
+	case fragSha256, fragHash256, fragRipemd160, fragHash160:
+		fmt.Fprintf(sb, "(%x)", n.hash)
+
+	case fragMulti:
+		fmt.Fprintf(sb, "(%d", n.k)
+		for _, key := range n.keys {
+			fmt.Fprintf(sb, ",%x", key)
+		}
+		sb.WriteByte(')')
+
+	default:
+		sb.WriteByte('(')
+		if n.frag == fragThresh {
+			fmt.Fprintf(sb, "%d,", n.k)
+		}
+		for i, sub := range n.subs {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sub.writeString(sb, false)
+		}
+		sb.WriteByte(')')
+	}
+}
@@ -0,0 +1,350 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package miniscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// testKeys holds deterministic private keys used by the tests.
+var testKeys = func() []*btcec.PrivateKey {
+	keys := make([]*btcec.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = btcec.PrivKeyFromBytes(btcec.S256(),
+			[]byte{byte(i + 1)})
+	}
+	return keys
+}()
+
+// keyHex returns the hex encoded compressed public key of test key i.
+func keyHex(i int) string {
+	return hex.EncodeToString(testKeys[i].PubKey().SerializeCompressed())
+}
+
+// TestParse ensures expressions are parsed, typed and compiled as expected.
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	k0, k1 := keyHex(0), keyHex(1)
+	k1Hash := hex.EncodeToString(btcutil.Hash160(
+		testKeys[1].PubKey().SerializeCompressed()))
+	zeroHash := hex.EncodeToString(make([]byte, 32))
+
+	tests := []struct {
+		expr   string
+		typ    string
+		str    string
+		script string
+	}{{
+		expr:   "pk(" + k0 + ")",
+		typ:    "Bondu",
+		str:    "c:pk_k(" + k0 + ")",
+		script: "21" + k0 + "ac",
+	}, {
+		expr:   "and_v(v:pk(" + k0 + "),pk(" + k1 + "))",
+		typ:    "Bnu",
+		str:    "and_v(vc:pk_k(" + k0 + "),c:pk_k(" + k1 + "))",
+		script: "21" + k0 + "ad21" + k1 + "ac",
+	}, {
+		expr:   "or_d(pk(" + k0 + "),and_v(v:pk(" + k1 + "),older(144)))",
+		typ:    "B",
+		str:    "or_d(c:pk_k(" + k0 + "),and_v(vc:pk_k(" + k1 + "),older(144)))",
+		script: "21" + k0 + "ac736421" + k1 + "ad029000b268",
+	}, {
+		expr:   "thresh(2,pk(" + k0 + "),s:pk(" + k1 + "),sln:after(10))",
+		typ:    "Bdu",
+		str:    "thresh(2,c:pk_k(" + k0 + "),sc:pk_k(" + k1 + "),s:or_i(0,n:after(10)))",
+		script: "21" + k0 + "ac7c21" + k1 + "ac937c6300675ab19268935287",
+	}, {
+		expr:   "t:or_c(pk(" + k0 + "),v:pkh(" + k1 + "))",
+		typ:    "Bu",
+		str:    "and_v(or_c(c:pk_k(" + k0 + "),vc:pk_h(" + k1 + ")),1)",
+		script: "21" + k0 + "ac6476a914" + k1Hash + "88ad6851",
+	}, {
+		expr:   "and_n(sha256(" + zeroHash + "),multi(1," + k0 + "," + k1 + "))",
+		typ:    "Bdu",
+		str:    "andor(sha256(" + zeroHash + "),multi(1," + k0 + "," + k1 + "),0)",
+		script: "82012088a820" + zeroHash + "87640067" + "5121" + k0 + "21" + k1 + "52ae68",
+	}, {
+		expr:   "or_i(and_b(pk(" + k0 + "),a:hash160(" + k1Hash + ")),j:multi(1," + k1 + "))",
+		typ:    "Bdu",
+		str:    "or_i(and_b(c:pk_k(" + k0 + "),a:hash160(" + k1Hash + ")),j:multi(1," + k1 + "))",
+		script: "6321" + k0 + "ac6b82012088a914" + k1Hash + "876c9a678292635121" + k1 + "51ae6868",
+	}}
+
+	for _, test := range tests {
+		node, err := Parse(test.expr)
+		if err != nil {
+			t.Errorf("Parse(%s): unexpected error: %v", test.expr, err)
+			continue
+		}
+		if got := node.Type().String(); got != test.typ {
+			t.Errorf("Parse(%s): got type %s, want %s", test.expr,
+				got, test.typ)
+		}
+		if got := node.String(); got != test.str {
+			t.Errorf("Parse(%s): got string %s, want %s", test.expr,
+				got, test.str)
+		}
+
+		// The canonical string must parse to the same expression.
+		reparsed, err := Parse(node.String())
+		if err != nil || reparsed.String() != node.String() {
+			t.Errorf("Parse(%s): round trip failed: %v", test.expr,
+				err)
+		}
+
+		script, err := node.Script()
+		if err != nil {
+			t.Errorf("Parse(%s): unexpected script error: %v",
+				test.expr, err)
+			continue
+		}
+		if hex.EncodeToString(script) != test.script {
+			t.Errorf("Parse(%s): got script %x, want %s", test.expr,
+				script, test.script)
+		}
+	}
+}
+
+// TestParseErrors ensures malformed and ill-typed expressions are rejected.
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	k0, k1 := keyHex(0), keyHex(1)
+	tests := []struct {
+		expr string
+		err  error
+	}{
+		{"pk_k(" + k0 + ")", ErrType},
+		{"older(144)1", ErrParse},
+		{"foo(" + k0 + ")", ErrParse},
+		{"pk(02aa)", ErrParse},
+		{"older(0)", ErrParse},
+		{"sha256(00)", ErrParse},
+		{"multi(3," + k0 + "," + k1 + ")", ErrParse},
+		{"and_v(pk(" + k0 + "),pk(" + k1 + "))", ErrType},
+		{"and_b(pk(" + k0 + "),pk(" + k1 + "))", ErrType},
+		{"or_b(pk(" + k0 + "),older(1))", ErrType},
+		{"thresh(1,pk(" + k0 + "),pk(" + k1 + "))", ErrType},
+		{"d:pk(" + k0 + ")", ErrType},
+		{"s:older(1)", ErrType},
+		{"x:pk(" + k0 + ")", ErrParse},
+		{"v:pk(" + k0 + ")", ErrType},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.expr)
+		if !errors.Is(err, test.err) {
+			t.Errorf("Parse(%s): got error %v, want %v", test.expr,
+				err, test.err)
+		}
+	}
+}
+
+// testSatisfier is a Satisfier backed by a set of private keys, preimages
+// and lock times that signs the input of a transaction.
+type testSatisfier struct {
+	tx        *wire.MsgTx
+	sigHashes *txscript.TxSigHashes
+	script    []byte
+	amount    int64
+	keys      map[string]*btcec.PrivateKey
+	preimages map[string][]byte
+	older     int64
+	after     int64
+}
+
+func (s *testSatisfier) Signature(pubKey []byte) ([]byte, bool) {
+	key, ok := s.keys[string(pubKey)]
+	if !ok {
+		return nil, false
+	}
+	sig, err := txscript.RawTxInWitnessSignature(s.tx, s.sigHashes, 0,
+		s.amount, s.script, txscript.SigHashAll, key)
+	return sig, err == nil
+}
+
+func (s *testSatisfier) Preimage(_ HashFunc, hash []byte) ([]byte, bool) {
+	preimage, ok := s.preimages[string(hash)]
+	return preimage, ok
+}
+
+func (s *testSatisfier) CheckOlder(lockTime int64) bool {
+	return lockTime <= s.older
+}
+
+func (s *testSatisfier) CheckAfter(lockTime int64) bool {
+	return lockTime <= s.after
+}
+
+// TestSatisfy ensures satisfactions produced for an expression are accepted
+// by the script engine and stay within the computed size bound.
+func TestSatisfy(t *testing.T) {
+	t.Parallel()
+
+	k0, k1, k2 := keyHex(0), keyHex(1), keyHex(2)
+	preimage := bytes.Repeat([]byte{0x42}, 32)
+	preimageHash := sha256.Sum256(preimage)
+	hashHex := hex.EncodeToString(preimageHash[:])
+
+	tests := []struct {
+		name      string
+		expr      string
+		keys      []int
+		preimage  bool
+		older     int64
+		satisfied bool
+	}{
+		{"pk", "pk(" + k0 + ")", []int{0}, false, 0, true},
+		{"pk without key", "pk(" + k0 + ")", nil, false, 0, false},
+		{"or_d first branch", "or_d(pk(" + k0 + "),and_v(v:pk(" + k1 + "),older(144)))", []int{0}, false, 0, true},
+		{"or_d timelocked branch", "or_d(pk(" + k0 + "),and_v(v:pk(" + k1 + "),older(144)))", []int{1}, false, 144, true},
+		{"or_d timelock not met", "or_d(pk(" + k0 + "),and_v(v:pk(" + k1 + "),older(144)))", []int{1}, false, 100, false},
+		{"thresh 2 of 3", "thresh(2,pk(" + k0 + "),s:pk(" + k1 + "),s:pk(" + k2 + "))", []int{0, 2}, false, 0, true},
+		{"thresh missing key", "thresh(2,pk(" + k0 + "),s:pk(" + k1 + "),s:pk(" + k2 + "))", []int{1}, false, 0, false},
+		{"multi", "multi(2," + k0 + "," + k1 + "," + k2 + ")", []int{1, 2}, false, 0, true},
+		{"hash lock", "and_v(v:sha256(" + hashHex + "),pk(" + k1 + "))", []int{1}, true, 0, true},
+		{"hash lock without preimage", "and_v(v:sha256(" + hashHex + "),pk(" + k1 + "))", []int{1}, false, 0, false},
+		{"or_i", "or_i(pkh(" + k0 + "),and_b(pk(" + k1 + "),a:pk(" + k2 + ")))", []int{1, 2}, false, 0, true},
+		{"andor", "andor(pk(" + k0 + "),pk(" + k1 + "),pk(" + k2 + "))", []int{2}, false, 0, true},
+		{"or_b", "or_b(pk(" + k0 + "),s:pk(" + k1 + "))", []int{1}, false, 0, true},
+		{"d wrapper", "or_b(pk(" + k0 + "),sdv:older(10))", []int{}, false, 10, true},
+		{"j wrapper", "or_d(j:pk(" + k0 + "),pk(" + k1 + "))", []int{1}, false, 0, true},
+	}
+
+	for _, test := range tests {
+		node, err := Parse(test.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected parse error: %v", test.name, err)
+			continue
+		}
+		script, err := node.Script()
+		if err != nil {
+			t.Errorf("%s: unexpected script error: %v", test.name, err)
+			continue
+		}
+
+		// Create a transaction spending a P2WSH output paying to the
+		// compiled script.
+		const amount = 100000
+		scriptHash := sha256.Sum256(script)
+		pkScript, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+			AddData(scriptHash[:]).Script()
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{1}},
+			nil, nil))
+		tx.TxIn[0].Sequence = uint32(test.older)
+		tx.AddTxOut(wire.NewTxOut(amount-1000, pkScript))
+
+		satisfier := &testSatisfier{
+			tx:        tx,
+			sigHashes: txscript.NewTxSigHashes(tx),
+			script:    script,
+			amount:    amount,
+			keys:      make(map[string]*btcec.PrivateKey),
+			preimages: make(map[string][]byte),
+			older:     test.older,
+		}
+		for _, i := range test.keys {
+			pubKey := testKeys[i].PubKey().SerializeCompressed()
+			satisfier.keys[string(pubKey)] = testKeys[i]
+		}
+		if test.preimage {
+			satisfier.preimages[string(preimageHash[:])] = preimage
+		}
+
+		stack, err := node.Satisfy(satisfier)
+		if !test.satisfied {
+			if err != ErrUnsatisfiable {
+				t.Errorf("%s: got error %v, want %v", test.name,
+					err, ErrUnsatisfiable)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected satisfy error: %v", test.name,
+				err)
+			continue
+		}
+
+		tx.TxIn[0].Witness = append(stack, script)
+		vm, err := txscript.NewEngine(pkScript, tx, 0,
+			txscript.StandardVerifyFlags, nil, satisfier.sigHashes,
+			amount)
+		if err != nil {
+			t.Errorf("%s: unable to create engine: %v", test.name, err)
+			continue
+		}
+		if err := vm.Execute(); err != nil {
+			t.Errorf("%s: satisfaction rejected by engine: %v",
+				test.name, err)
+			continue
+		}
+
+		// The actual witness must not exceed the computed bound.
+		maxSize, err := node.MaxWitnessSize()
+		if err != nil {
+			t.Errorf("%s: unexpected size error: %v", test.name, err)
+			continue
+		}
+		if size := tx.TxIn[0].Witness.SerializeSize(); size > maxSize {
+			t.Errorf("%s: witness size %d exceeds bound %d",
+				test.name, size, maxSize)
+		}
+	}
+}
+
+// TestMaxSatisfactionSize ensures the satisfaction size bounds match the
+// expected values for a few simple expressions.
+func TestMaxSatisfactionSize(t *testing.T) {
+	t.Parallel()
+
+	k0, k1, k2 := keyHex(0), keyHex(1), keyHex(2)
+	tests := []struct {
+		expr string
+		size int
+	}{
+		{"pk(" + k0 + ")", 73},
+		{"pkh(" + k0 + ")", 73 + 34},
+		{"multi(2," + k0 + "," + k1 + "," + k2 + ")", 1 + 2*73},
+		{"or_d(pk(" + k0 + "),and_v(v:pk(" + k1 + "),older(144)))", 73 + 1},
+		{"or_i(pk(" + k0 + "),pkh(" + k1 + "))", 73 + 34 + 1},
+	}
+	for _, test := range tests {
+		node, err := Parse(test.expr)
+		if err != nil {
+			t.Errorf("Parse(%s): unexpected error: %v", test.expr, err)
+			continue
+		}
+		size, err := node.MaxSatisfactionSize()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.expr, err)
+			continue
+		}
+		if size != test.size {
+			t.Errorf("%s: got size %d, want %d", test.expr, size,
+				test.size)
+		}
+	}
+
+	// An expression that can never be satisfied has no size.
+	node, err := Parse("and_b(0,a:1)")
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if _, err := node.MaxSatisfactionSize(); err != ErrUnsatisfiable {
+		t.Fatalf("got error %v, want %v", err, ErrUnsatisfiable)
+	}
+}
@@ -0,0 +1,476 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package miniscript
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ErrUnsatisfiable is returned when an expression can't be satisfied, either
+// in general or with the data available to a Satisfier.
+var ErrUnsatisfiable = errors.New("miniscript expression is not satisfiable")
+
+const (
+	// maxSigSize is the maximum size of a low-S DER encoded signature
+	// including the sighash type, plus its length prefix.
+	maxSigSize = 1 + 72
+
+	// pubKeyPushSize is the size of a compressed public key witness
+	// element including its length prefix.
+	pubKeyPushSize = 1 + 33
+
+	// preimagePushSize is the size of a hash preimage witness element
+	// including its length prefix.
+	preimagePushSize = 1 + 32
+
+	// emptyPushSize and onePushSize are the sizes of the empty and the
+	// single 0x01 byte witness elements including their length prefix.
+	emptyPushSize = 1
+	onePushSize   = 2
+)
+
+// sizeInfo is an upper bound on the size of a satisfaction or
+// dissatisfaction in bytes along with an upper bound on its number of
+// witness elements.  The zero value represents an impossible one.
+type sizeInfo struct {
+	ok    bool
+	size  int
+	elems int
+}
+
+// newSize returns a possible sizeInfo with the given size and element count.
+func newSize(size, elems int) sizeInfo {
+	return sizeInfo{ok: true, size: size, elems: elems}
+}
+
+// plus returns the size of the concatenation of both witnesses.
+func (s sizeInfo) plus(o sizeInfo) sizeInfo {
+	if !s.ok || !o.ok {
+		return sizeInfo{}
+	}
+	return newSize(s.size+o.size, s.elems+o.elems)
+}
+
+// or returns the upper bound of two alternative witnesses.
+func (s sizeInfo) or(o sizeInfo) sizeInfo {
+	switch {
+	case !s.ok:
+		return o
+	case !o.ok:
+		return s
+	}
+	r := s
+	if o.size > r.size {
+		r.size = o.size
+	}
+	if o.elems > r.elems {
+		r.elems = o.elems
+	}
+	return r
+}
+
+// maxSizes returns upper bounds on the size of the satisfaction and
+// dissatisfaction of the expression.
+func (n *Node) maxSizes() (sat, dsat sizeInfo) {
+	empty, one := newSize(emptyPushSize, 1), newSize(onePushSize, 1)
+
+	switch n.frag {
+	case fragJust0:
+		return sizeInfo{}, newSize(0, 0)
+
+	case fragJust1:
+		return newSize(0, 0), sizeInfo{}
+
+	case fragPkK:
+		return newSize(maxSigSize, 1), empty
+
+	case fragPkH:
+		key := newSize(pubKeyPushSize, 1)
+		return newSize(maxSigSize, 1).plus(key), empty.plus(key)
+
+	case fragOlder, fragAfter:
+		return newSize(0, 0), sizeInfo{}
+
+	case fragSha256, fragHash256, fragRipemd160, fragHash160:
+		preimage := newSize(preimagePushSize, 1)
+		return preimage, preimage
+
+	case fragMulti:
+		// The extra empty element is consumed by the CHECKMULTISIG
+		// off-by-one bug.
+		sigs := newSize(int(n.k)*maxSigSize, int(n.k))
+		empties := newSize(int(n.k)*emptyPushSize, int(n.k))
+		return empty.plus(sigs), empty.plus(empties)
+
+	case fragWrapA, fragWrapS, fragWrapC, fragWrapN:
+		return n.subs[0].maxSizes()
+
+	case fragWrapD:
+		xSat, _ := n.subs[0].maxSizes()
+		return xSat.plus(one), empty
+
+	case fragWrapV:
+		xSat, _ := n.subs[0].maxSizes()
+		return xSat, sizeInfo{}
+
+	case fragWrapJ:
+		xSat, _ := n.subs[0].maxSizes()
+		return xSat, empty
+
+	case fragThresh:
+		return n.threshMaxSizes()
+	}
+
+	xSat, xDsat := n.subs[0].maxSizes()
+	ySat, yDsat := n.subs[1].maxSizes()
+	switch n.frag {
+	case fragAndOr:
+		zSat, zDsat := n.subs[2].maxSizes()
+		sat = ySat.plus(xSat).or(zSat.plus(xDsat))
+		return sat, zDsat.plus(xDsat)
+
+	case fragAndV:
+		return ySat.plus(xSat), sizeInfo{}
+
+	case fragAndB:
+		return ySat.plus(xSat), yDsat.plus(xDsat)
+
+	case fragOrB:
+		sat = yDsat.plus(xSat).or(ySat.plus(xDsat))
+		return sat, yDsat.plus(xDsat)
+
+	case fragOrC:
+		return xSat.or(ySat.plus(xDsat)), sizeInfo{}
+
+	case fragOrD:
+		return xSat.or(ySat.plus(xDsat)), yDsat.plus(xDsat)
+
+	case fragOrI:
+		sat = xSat.plus(one).or(ySat.plus(empty))
+		dsat = xDsat.plus(one).or(yDsat.plus(empty))
+		return sat, dsat
+	}
+
+	return sizeInfo{}, sizeInfo{}
+}
+
+// threshMaxSizes returns upper bounds on the size of the satisfaction and
+// dissatisfaction of a thresh fragment.
+func (n *Node) threshMaxSizes() (sat, dsat sizeInfo) {
+	// best[i] holds the largest witness with exactly i satisfied sub
+	// expressions among the ones processed so far.
+	best := []sizeInfo{newSize(0, 0)}
+	for _, sub := range n.subs {
+		subSat, subDsat := sub.maxSizes()
+		next := make([]sizeInfo, len(best)+1)
+		for i, cur := range best {
+			next[i] = next[i].or(cur.plus(subDsat))
+			next[i+1] = next[i+1].or(cur.plus(subSat))
+		}
+		best = next
+	}
+	return best[n.k], best[0]
+}
+
+// MaxSatisfactionSize returns an upper bound on the serialized size of the
+// witness stack elements needed to satisfy the expression, including their
+// length prefixes but excluding the witness script itself.
+func (n *Node) MaxSatisfactionSize() (int, error) {
+	sat, _ := n.maxSizes()
+	if !sat.ok {
+		return 0, ErrUnsatisfiable
+	}
+	return sat.size, nil
+}
+
+// MaxWitnessSize returns an upper bound on the serialized size of the full
+// P2WSH witness needed to spend an output paying to the expression.  This
+// includes the element count, all satisfaction elements and the witness
+// script.  Since witness data is not scaled, this is also its weight.
+func (n *Node) MaxWitnessSize() (int, error) {
+	sat, _ := n.maxSizes()
+	if !sat.ok {
+		return 0, ErrUnsatisfiable
+	}
+	script, err := n.Script()
+	if err != nil {
+		return 0, err
+	}
+	return wire.VarIntSerializeSize(uint64(sat.elems+1)) + sat.size +
+		wire.VarIntSerializeSize(uint64(len(script))) + len(script), nil
+}
+
+// HashFunc identifies the hash function of a hash fragment.
+type HashFunc int
+
+const (
+	// HashSha256 identifies the sha256 fragment.
+	HashSha256 HashFunc = iota
+
+	// HashHash256 identifies the hash256 fragment.
+	HashHash256
+
+	// HashRipemd160 identifies the ripemd160 fragment.
+	HashRipemd160
+
+	// HashHash160 identifies the hash160 fragment.
+	HashHash160
+)
+
+// Satisfier provides the data needed to satisfy an expression.
+type Satisfier interface {
+	// Signature returns the signature, including the sighash type, for
+	// the passed compressed public key if it is available.
+	Signature(pubKey []byte) ([]byte, bool)
+
+	// Preimage returns the preimage of the passed hash under the given
+	// hash function if it is known.
+	Preimage(hashFunc HashFunc, hash []byte) ([]byte, bool)
+
+	// CheckOlder returns whether the relative lock time of an older
+	// fragment is satisfied by the spending input.
+	CheckOlder(lockTime int64) bool
+
+	// CheckAfter returns whether the absolute lock time of an after
+	// fragment is satisfied by the spending transaction.
+	CheckAfter(lockTime int64) bool
+}
+
+// witness is a possible satisfaction or dissatisfaction.  A nil stack with ok
+// set to false represents an unavailable one.
+type witness struct {
+	ok    bool
+	stack [][]byte
+}
+
+// size returns the serialized size of the witness elements.
+func (w witness) size() int {
+	size := 0
+	for _, elem := range w.stack {
+		size += wire.VarIntSerializeSize(uint64(len(elem))) + len(elem)
+	}
+	return size
+}
+
+// newWitness returns an available witness made up of the passed elements.
+func newWitness(elems ...[]byte) witness {
+	return witness{ok: true, stack: elems}
+}
+
+// concat returns the witness with the elements of w at the bottom and the
+// elements of o on top.
+func (w witness) concat(o witness) witness {
+	if !w.ok || !o.ok {
+		return witness{}
+	}
+	stack := make([][]byte, 0, len(w.stack)+len(o.stack))
+	stack = append(stack, w.stack...)
+	return witness{ok: true, stack: append(stack, o.stack...)}
+}
+
+// smaller returns the smaller one of two alternative witnesses.
+func (w witness) smaller(o witness) witness {
+	switch {
+	case !w.ok:
+		return o
+	case !o.ok:
+		return w
+	case o.size() < w.size():
+		return o
+	}
+	return w
+}
+
+// Satisfy returns the smallest witness stack, excluding the witness script,
+// that satisfies the expression using the data provided by the satisfier.
+// The first element of the returned slice is the bottom of the stack, which
+// matches the order of wire.TxWitness.
+//
+// The satisfaction is not guaranteed to be non-malleable: dissatisfactions of
+// hash fragments use an all-zero preimage and any satisfiable branch may be
+// chosen.
+func (n *Node) Satisfy(satisfier Satisfier) ([][]byte, error) {
+	sat, _ := n.satisfy(satisfier)
+	if !sat.ok {
+		return nil, ErrUnsatisfiable
+	}
+	return sat.stack, nil
+}
+
+// satisfy returns the smallest satisfaction and dissatisfaction of the
+// expression that can be built with the data provided by the satisfier.
+func (n *Node) satisfy(s Satisfier) (sat, dsat witness) {
+	empty, one := newWitness(nil), newWitness([]byte{1})
+
+	switch n.frag {
+	case fragJust0:
+		return witness{}, newWitness()
+
+	case fragJust1:
+		return newWitness(), witness{}
+
+	case fragPkK:
+		if sig, ok := s.Signature(n.keys[0]); ok {
+			sat = newWitness(sig)
+		}
+		return sat, empty
+
+	case fragPkH:
+		key := newWitness(n.keys[0])
+		if sig, ok := s.Signature(n.keys[0]); ok {
+			sat = newWitness(sig).concat(key)
+		}
+		return sat, empty.concat(key)
+
+	case fragOlder:
+		if s.CheckOlder(n.k) {
+			sat = newWitness()
+		}
+		return sat, witness{}
+
+	case fragAfter:
+		if s.CheckAfter(n.k) {
+			sat = newWitness()
+		}
+		return sat, witness{}
+
+	case fragSha256, fragHash256, fragRipemd160, fragHash160:
+		hashFunc := map[fragment]HashFunc{
+			fragSha256:    HashSha256,
+			fragHash256:   HashHash256,
+			fragRipemd160: HashRipemd160,
+			fragHash160:   HashHash160,
+		}[n.frag]
+		if preimage, ok := s.Preimage(hashFunc, n.hash); ok &&
+			len(preimage) == 32 {
+
+			sat = newWitness(preimage)
+		}
+		return sat, newWitness(make([]byte, 32))
+
+	case fragMulti:
+		// Signatures must be provided in the order of the keys.
+		sat = empty
+		numSigs := int64(0)
+		for _, key := range n.keys {
+			if numSigs == n.k {
+				break
+			}
+			if sig, ok := s.Signature(key); ok {
+				sat = sat.concat(newWitness(sig))
+				numSigs++
+			}
+		}
+		if numSigs < n.k {
+			sat = witness{}
+		}
+		dsat = empty
+		for i := int64(0); i < n.k; i++ {
+			dsat = dsat.concat(empty)
+		}
+		return sat, dsat
+
+	case fragWrapA, fragWrapS, fragWrapC, fragWrapN:
+		return n.subs[0].satisfy(s)
+
+	case fragWrapD:
+		xSat, _ := n.subs[0].satisfy(s)
+		return xSat.concat(one), empty
+
+	case fragWrapV:
+		xSat, _ := n.subs[0].satisfy(s)
+		return xSat, witness{}
+
+	case fragWrapJ:
+		xSat, _ := n.subs[0].satisfy(s)
+		return xSat, empty
+
+	case fragThresh:
+		return n.threshSatisfy(s)
+	}
+
+	xSat, xDsat := n.subs[0].satisfy(s)
+	ySat, yDsat := n.subs[1].satisfy(s)
+	switch n.frag {
+	case fragAndOr:
+		zSat, zDsat := n.subs[2].satisfy(s)
+		sat = ySat.concat(xSat).smaller(zSat.concat(xDsat))
+		return sat, zDsat.concat(xDsat)
+
+	case fragAndV:
+		return ySat.concat(xSat), witness{}
+
+	case fragAndB:
+		return ySat.concat(xSat), yDsat.concat(xDsat)
+
+	case fragOrB:
+		sat = yDsat.concat(xSat).smaller(ySat.concat(xDsat))
+		return sat, yDsat.concat(xDsat)
+
+	case fragOrC:
+		return xSat.smaller(ySat.concat(xDsat)), witness{}
+
+	case fragOrD:
+		return xSat.smaller(ySat.concat(xDsat)), yDsat.concat(xDsat)
+
+	case fragOrI:
+		sat = xSat.concat(one).smaller(ySat.concat(empty))
+		dsat = xDsat.concat(one).smaller(yDsat.concat(empty))
+		return sat, dsat
+	}
+
+	return witness{}, witness{}
+}
+
+// threshSatisfy returns the smallest satisfaction and dissatisfaction of a
+// thresh fragment.
+func (n *Node) threshSatisfy(s Satisfier) (sat, dsat witness) {
+	// The witness for the first sub expression must end up on top of the
+	// stack, so sub expressions are stacked in reverse order.
+	sats := make([]witness, len(n.subs))
+	dsats := make([]witness, len(n.subs))
+	for i, sub := range n.subs {
+		sats[i], dsats[i] = sub.satisfy(s)
+	}
+
+	// Every sub expression is of type d, so it can always be dissatisfied.
+	// Satisfy the k sub expressions for which that adds the least amount
+	// of data.
+	var candidates []int
+	for i := range n.subs {
+		if sats[i].ok && dsats[i].ok {
+			candidates = append(candidates, i)
+		}
+	}
+	if int64(len(candidates)) >= n.k {
+		sort.SliceStable(candidates, func(a, b int) bool {
+			i, j := candidates[a], candidates[b]
+			return sats[i].size()-dsats[i].size() <
+				sats[j].size()-dsats[j].size()
+		})
+		chosen := make(map[int]bool)
+		for _, i := range candidates[:n.k] {
+			chosen[i] = true
+		}
+
+		sat = newWitness()
+		for i := len(n.subs) - 1; i >= 0; i-- {
+			if chosen[i] {
+				sat = sat.concat(sats[i])
+			} else {
+				sat = sat.concat(dsats[i])
+			}
+		}
+	}
+
+	dsat = newWitness()
+	for i := len(n.subs) - 1; i >= 0; i-- {
+		dsat = dsat.concat(dsats[i])
+	}
+	return sat, dsat
+}
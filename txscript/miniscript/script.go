@@ -0,0 +1,165 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package miniscript
+
+import (
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+)
+
+// verifyOps maps opcodes to their VERIFY variants, which the v: wrapper uses
+// in place of appending a separate OP_VERIFY.
+var verifyOps = map[byte]byte{
+	txscript.OP_EQUAL:         txscript.OP_EQUALVERIFY,
+	txscript.OP_NUMEQUAL:      txscript.OP_NUMEQUALVERIFY,
+	txscript.OP_CHECKSIG:      txscript.OP_CHECKSIGVERIFY,
+	txscript.OP_CHECKMULTISIG: txscript.OP_CHECKMULTISIGVERIFY,
+}
+
+// Script compiles the expression into its witness script.
+func (n *Node) Script() ([]byte, error) {
+	builder := txscript.NewScriptBuilder()
+	n.compile(builder)
+	return builder.Script()
+}
+
+// compile appends the script for the expression to the builder.
+func (n *Node) compile(b *txscript.ScriptBuilder) {
+	switch n.frag {
+	case fragJust0:
+		b.AddOp(txscript.OP_0)
+
+	case fragJust1:
+		b.AddOp(txscript.OP_1)
+
+	case fragPkK:
+		b.AddData(n.keys[0])
+
+	case fragPkH:
+		b.AddOp(txscript.OP_DUP).AddOp(txscript.OP_HASH160).
+			AddData(btcutil.Hash160(n.keys[0])).
+			AddOp(txscript.OP_EQUALVERIFY)
+
+	case fragOlder:
+		b.AddInt64(n.k).AddOp(txscript.OP_CHECKSEQUENCEVERIFY)
+
+	case fragAfter:
+		b.AddInt64(n.k).AddOp(txscript.OP_CHECKLOCKTIMEVERIFY)
+
+	case fragSha256, fragHash256, fragRipemd160, fragHash160:
+		hashOp := map[fragment]byte{
+			fragSha256:    txscript.OP_SHA256,
+			fragHash256:   txscript.OP_HASH256,
+			fragRipemd160: txscript.OP_RIPEMD160,
+			fragHash160:   txscript.OP_HASH160,
+		}[n.frag]
+		b.AddOp(txscript.OP_SIZE).AddInt64(32).
+			AddOp(txscript.OP_EQUALVERIFY).AddOp(hashOp).
+			AddData(n.hash).AddOp(txscript.OP_EQUAL)
+
+	case fragAndOr:
+		n.subs[0].compile(b)
+		b.AddOp(txscript.OP_NOTIF)
+		n.subs[2].compile(b)
+		b.AddOp(txscript.OP_ELSE)
+		n.subs[1].compile(b)
+		b.AddOp(txscript.OP_ENDIF)
+
+	case fragAndV:
+		n.subs[0].compile(b)
+		n.subs[1].compile(b)
+
+	case fragAndB:
+		n.subs[0].compile(b)
+		n.subs[1].compile(b)
+		b.AddOp(txscript.OP_BOOLAND)
+
+	case fragOrB:
+		n.subs[0].compile(b)
+		n.subs[1].compile(b)
+		b.AddOp(txscript.OP_BOOLOR)
+
+	case fragOrC:
+		n.subs[0].compile(b)
+		b.AddOp(txscript.OP_NOTIF)
+		n.subs[1].compile(b)
+		b.AddOp(txscript.OP_ENDIF)
+
+	case fragOrD:
+		n.subs[0].compile(b)
+		b.AddOp(txscript.OP_IFDUP).AddOp(txscript.OP_NOTIF)
+		n.subs[1].compile(b)
+		b.AddOp(txscript.OP_ENDIF)
+
+	case fragOrI:
+		b.AddOp(txscript.OP_IF)
+		n.subs[0].compile(b)
+		b.AddOp(txscript.OP_ELSE)
+		n.subs[1].compile(b)
+		b.AddOp(txscript.OP_ENDIF)
+
+	case fragThresh:
+		for i, sub := range n.subs {
+			sub.compile(b)
+			if i > 0 {
+				b.AddOp(txscript.OP_ADD)
+			}
+		}
+		b.AddInt64(n.k).AddOp(txscript.OP_EQUAL)
+
+	case fragMulti:
+		b.AddInt64(n.k)
+		for _, key := range n.keys {
+			b.AddData(key)
+		}
+		b.AddInt64(int64(len(n.keys))).AddOp(txscript.OP_CHECKMULTISIG)
+
+	case fragWrapA:
+		b.AddOp(txscript.OP_TOALTSTACK)
+		n.subs[0].compile(b)
+		b.AddOp(txscript.OP_FROMALTSTACK)
+
+	case fragWrapS:
+		b.AddOp(txscript.OP_SWAP)
+		n.subs[0].compile(b)
+
+	case fragWrapC:
+		n.subs[0].compile(b)
+		b.AddOp(txscript.OP_CHECKSIG)
+
+	case fragWrapD:
+		b.AddOp(txscript.OP_DUP).AddOp(txscript.OP_IF)
+		n.subs[0].compile(b)
+		b.AddOp(txscript.OP_ENDIF)
+
+	case fragWrapV:
+		n.subs[0].compile(b)
+		script, err := b.Script()
+		if err != nil {
+			return
+		}
+
+		// Expressions of type B always end in an opcode rather than a
+		// data push, so the final byte can be merged with OP_VERIFY
+		// when a VERIFY variant of it exists.
+		last := len(script) - 1
+		if verifyOp, ok := verifyOps[script[last]]; ok {
+			prefix := append([]byte(nil), script[:last]...)
+			b.Reset().AddOps(prefix).AddOp(verifyOp)
+		} else {
+			b.AddOp(txscript.OP_VERIFY)
+		}
+
+	case fragWrapJ:
+		b.AddOp(txscript.OP_SIZE).AddOp(txscript.OP_0NOTEQUAL).
+			AddOp(txscript.OP_IF)
+		n.subs[0].compile(b)
+		b.AddOp(txscript.OP_ENDIF)
+
+	case fragWrapN:
+		n.subs[0].compile(b)
+		b.AddOp(txscript.OP_0NOTEQUAL)
+	}
+}
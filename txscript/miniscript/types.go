@@ -0,0 +1,282 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package miniscript
+
+import (
+	"fmt"
+	"strings"
+)
+
+// basicType is one of the four basic Miniscript types.
+type basicType int
+
+const (
+	baseB basicType = iota
+	baseV
+	baseK
+	baseW
+)
+
+// String returns the single letter name of the basic type.
+func (b basicType) String() string {
+	return [...]string{"B", "V", "K", "W"}[b]
+}
+
+// Type is the type of a Miniscript expression, which consists of a basic
+// type and a set of properties.  See the package documentation for their
+// meaning.
+type Type struct {
+	base basicType
+	z    bool
+	o    bool
+	n    bool
+	d    bool
+	u    bool
+}
+
+// String returns the type in the conventional notation of its basic type
+// followed by its properties, for example "Bdu".
+func (t Type) String() string {
+	var sb strings.Builder
+	sb.WriteString(t.base.String())
+	for _, prop := range []struct {
+		set  bool
+		name byte
+	}{{t.z, 'z'}, {t.o, 'o'}, {t.n, 'n'}, {t.d, 'd'}, {t.u, 'u'}} {
+		if prop.set {
+			sb.WriteByte(prop.name)
+		}
+	}
+	return sb.String()
+}
+
+// typeErr returns a type error for the node's fragment.
+func (n *Node) typeErr(format string, args ...interface{}) error {
+	name := fragmentNames[n.frag]
+	for c, frag := range wrappers {
+		if frag == n.frag {
+			name = string(c) + ":"
+		}
+	}
+	return fmt.Errorf("%w: %s: %s", ErrType, name,
+		fmt.Sprintf(format, args...))
+}
+
+// computeType computes and assigns the type of the node from the types of
+// its sub expressions according to the BIP-379 typing rules.  An error is
+// returned if the sub expressions don't satisfy the requirements of the
+// fragment.
+func (n *Node) computeType() error {
+	var x, y, z Type
+	switch len(n.subs) {
+	case 3:
+		z = n.subs[2].typ
+		fallthrough
+	case 2:
+		y = n.subs[1].typ
+		fallthrough
+	case 1:
+		x = n.subs[0].typ
+	}
+
+	var t Type
+	switch n.frag {
+	case fragJust0:
+		t = Type{base: baseB, z: true, u: true, d: true}
+
+	case fragJust1:
+		t = Type{base: baseB, z: true, u: true}
+
+	case fragPkK:
+		t = Type{base: baseK, o: true, n: true, d: true, u: true}
+
+	case fragPkH:
+		t = Type{base: baseK, n: true, d: true, u: true}
+
+	case fragOlder, fragAfter:
+		t = Type{base: baseB, z: true}
+
+	case fragSha256, fragHash256, fragRipemd160, fragHash160:
+		t = Type{base: baseB, o: true, n: true, d: true, u: true}
+
+	case fragMulti:
+		t = Type{base: baseB, n: true, d: true, u: true}
+
+	case fragAndOr:
+		// X is Bdu; Y and Z are both B, K, or V.
+		if x.base != baseB || !x.d || !x.u {
+			return n.typeErr("X must be Bdu, got %v", x)
+		}
+		if y.base != z.base || y.base == baseW {
+			return n.typeErr("Y and Z must both be B, K or V, got "+
+				"%v and %v", y, z)
+		}
+		t = Type{
+			base: y.base,
+			z:    x.z && y.z && z.z,
+			o:    (x.z && y.o && z.o) || (x.o && y.z && z.z),
+			u:    y.u && z.u,
+			d:    z.d,
+		}
+
+	case fragAndV:
+		// X is V; Y is B, K, or V.
+		if x.base != baseV {
+			return n.typeErr("X must be V, got %v", x)
+		}
+		if y.base == baseW {
+			return n.typeErr("Y must be B, K or V, got %v", y)
+		}
+		t = Type{
+			base: y.base,
+			z:    x.z && y.z,
+			o:    (x.z && y.o) || (x.o && y.z),
+			n:    x.n || (x.z && y.n),
+			u:    y.u,
+		}
+
+	case fragAndB:
+		// X is B; Y is W.
+		if x.base != baseB || y.base != baseW {
+			return n.typeErr("X must be B and Y must be W, got %v "+
+				"and %v", x, y)
+		}
+		t = Type{
+			base: baseB,
+			z:    x.z && y.z,
+			o:    (x.z && y.o) || (x.o && y.z),
+			n:    x.n || (x.z && y.n),
+			d:    x.d && y.d,
+			u:    true,
+		}
+
+	case fragOrB:
+		// X is Bd; Z is Wd.
+		if x.base != baseB || !x.d || y.base != baseW || !y.d {
+			return n.typeErr("X must be Bd and Z must be Wd, got "+
+				"%v and %v", x, y)
+		}
+		t = Type{
+			base: baseB,
+			z:    x.z && y.z,
+			o:    (x.z && y.o) || (x.o && y.z),
+			d:    true,
+			u:    true,
+		}
+
+	case fragOrC:
+		// X is Bdu; Z is V.
+		if x.base != baseB || !x.d || !x.u || y.base != baseV {
+			return n.typeErr("X must be Bdu and Z must be V, got "+
+				"%v and %v", x, y)
+		}
+		t = Type{
+			base: baseV,
+			z:    x.z && y.z,
+			o:    x.o && y.z,
+		}
+
+	case fragOrD:
+		// X is Bdu; Z is B.
+		if x.base != baseB || !x.d || !x.u || y.base != baseB {
+			return n.typeErr("X must be Bdu and Z must be B, got "+
+				"%v and %v", x, y)
+		}
+		t = Type{
+			base: baseB,
+			z:    x.z && y.z,
+			o:    x.o && y.z,
+			d:    y.d,
+			u:    y.u,
+		}
+
+	case fragOrI:
+		// X and Z are both B, K, or V.
+		if x.base != y.base || x.base == baseW {
+			return n.typeErr("X and Z must both be B, K or V, got "+
+				"%v and %v", x, y)
+		}
+		t = Type{
+			base: x.base,
+			o:    x.z && y.z,
+			u:    x.u && y.u,
+			d:    x.d || y.d,
+		}
+
+	case fragThresh:
+		// X1 is Bdu; the others are Wdu.
+		t = Type{base: baseB, z: true, d: true, u: true}
+		numO := 0
+		for i, sub := range n.subs {
+			st := sub.typ
+			want := baseW
+			if i == 0 {
+				want = baseB
+			}
+			if st.base != want || !st.d || !st.u {
+				return n.typeErr("argument %d must be %vdu, got "+
+					"%v", i+1, want, st)
+			}
+			t.z = t.z && st.z
+			switch {
+			case st.o:
+				numO++
+			case !st.z:
+				numO = len(n.subs) + 1
+			}
+		}
+		t.o = numO == 1
+
+	case fragWrapA:
+		if x.base != baseB {
+			return n.typeErr("X must be B, got %v", x)
+		}
+		t = Type{base: baseW, d: x.d, u: x.u}
+
+	case fragWrapS:
+		if x.base != baseB || !x.o {
+			return n.typeErr("X must be Bo, got %v", x)
+		}
+		t = Type{base: baseW, d: x.d, u: x.u}
+
+	case fragWrapC:
+		if x.base != baseK {
+			return n.typeErr("X must be K, got %v", x)
+		}
+		t = Type{base: baseB, o: x.o, n: x.n, d: x.d, u: true}
+
+	case fragWrapD:
+		// The u property of d: only holds in the Tapscript context,
+		// where MINIMALIF is a consensus rule.
+		if x.base != baseV || !x.z {
+			return n.typeErr("X must be Vz, got %v", x)
+		}
+		t = Type{base: baseB, o: true, n: true, d: true}
+
+	case fragWrapV:
+		if x.base != baseB {
+			return n.typeErr("X must be B, got %v", x)
+		}
+		t = Type{base: baseV, z: x.z, o: x.o, n: x.n}
+
+	case fragWrapJ:
+		if x.base != baseB || !x.n {
+			return n.typeErr("X must be Bn, got %v", x)
+		}
+		t = Type{base: baseB, o: x.o, n: true, d: true, u: x.u}
+
+	case fragWrapN:
+		if x.base != baseB {
+			return n.typeErr("X must be B, got %v", x)
+		}
+		t = Type{base: baseB, z: x.z, o: x.o, n: x.n, d: x.d, u: true}
+
+	default:
+		return n.typeErr("unknown fragment")
+	}
+
+	n.typ = t
+	return nil
+}
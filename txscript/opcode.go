@@ -218,6 +218,7 @@ const (
 	OP_NOP3                = 0xb2 // 178
 	OP_CHECKSEQUENCEVERIFY = 0xb2 // 178 - AKA OP_NOP3
 	OP_NOP4                = 0xb3 // 179
+	OP_CHECKTEMPLATEVERIFY = 0xb3 // 179 - AKA OP_NOP4
 	OP_NOP5                = 0xb4 // 180
 	OP_NOP6                = 0xb5 // 181
 	OP_NOP7                = 0xb6 // 182
@@ -419,6 +420,7 @@ var opcodeArray = [256]opcode{
 	OP_RETURN:              {OP_RETURN, "OP_RETURN", 1, opcodeReturn},
 	OP_CHECKLOCKTIMEVERIFY: {OP_CHECKLOCKTIMEVERIFY, "OP_CHECKLOCKTIMEVERIFY", 1, opcodeCheckLockTimeVerify},
 	OP_CHECKSEQUENCEVERIFY: {OP_CHECKSEQUENCEVERIFY, "OP_CHECKSEQUENCEVERIFY", 1, opcodeCheckSequenceVerify},
+	OP_CHECKTEMPLATEVERIFY: {OP_CHECKTEMPLATEVERIFY, "OP_CHECKTEMPLATEVERIFY", 1, opcodeCheckTemplateVerify},
 
 	// Stack opcodes.
 	OP_TOALTSTACK:   {OP_TOALTSTACK, "OP_TOALTSTACK", 1, opcodeToAltStack},
@@ -442,7 +444,7 @@ var opcodeArray = [256]opcode{
 	OP_TUCK:         {OP_TUCK, "OP_TUCK", 1, opcodeTuck},
 
 	// Splice opcodes.
-	OP_CAT:    {OP_CAT, "OP_CAT", 1, opcodeDisabled},
+	OP_CAT:    {OP_CAT, "OP_CAT", 1, opcodeCat},
 	OP_SUBSTR: {OP_SUBSTR, "OP_SUBSTR", 1, opcodeDisabled},
 	OP_LEFT:   {OP_LEFT, "OP_LEFT", 1, opcodeDisabled},
 	OP_RIGHT:  {OP_RIGHT, "OP_RIGHT", 1, opcodeDisabled},
@@ -501,7 +503,6 @@ var opcodeArray = [256]opcode{
 
 	// Reserved opcodes.
 	OP_NOP1:  {OP_NOP1, "OP_NOP1", 1, opcodeNop},
-	OP_NOP4:  {OP_NOP4, "OP_NOP4", 1, opcodeNop},
 	OP_NOP5:  {OP_NOP5, "OP_NOP5", 1, opcodeNop},
 	OP_NOP6:  {OP_NOP6, "OP_NOP6", 1, opcodeNop},
 	OP_NOP7:  {OP_NOP7, "OP_NOP7", 1, opcodeNop},
@@ -980,7 +981,7 @@ func opcodeN(op *parsedOpcode, vm *Engine) error {
 // the flag to discourage use of NOPs is set for select opcodes.
 func opcodeNop(op *parsedOpcode, vm *Engine) error {
 	switch op.opcode.value {
-	case OP_NOP1, OP_NOP4, OP_NOP5,
+	case OP_NOP1, OP_NOP5,
 		OP_NOP6, OP_NOP7, OP_NOP8, OP_NOP9, OP_NOP10:
 		if vm.hasFlag(ScriptDiscourageUpgradableNops) {
 			str := fmt.Sprintf("OP_NOP%d reserved for soft-fork "+
@@ -1343,6 +1344,48 @@ func opcodeCheckSequenceVerify(op *parsedOpcode, vm *Engine) error {
 		wire.SequenceLockTimeIsSeconds, sequence&lockTimeMask)
 }
 
+// opcodeCheckTemplateVerify compares the top item on the data stack, when it
+// is a 32-byte hash, to the standard template hash of the transaction for the
+// input being validated as defined by BIP0119.  Items of other sizes are
+// reserved for future template types and treated as a NOP.  If flag
+// ScriptVerifyCheckTemplateVerify is not set, the code continues as if OP_NOP4
+// were executed.
+func opcodeCheckTemplateVerify(op *parsedOpcode, vm *Engine) error {
+	// If the ScriptVerifyCheckTemplateVerify script flag is not set, treat
+	// opcode as OP_NOP4 instead.
+	if !vm.hasFlag(ScriptVerifyCheckTemplateVerify) {
+		if vm.hasFlag(ScriptDiscourageUpgradableNops) {
+			return scriptError(ErrDiscourageUpgradableNOPs,
+				"OP_NOP4 reserved for soft-fork upgrades")
+		}
+		return nil
+	}
+
+	// Like the other upgraded NOPs, the item is left on the stack.
+	templateHash, err := vm.dstack.PeekByteArray(0)
+	if err != nil {
+		return err
+	}
+
+	if len(templateHash) != chainhash.HashSize {
+		if vm.hasFlag(ScriptDiscourageUpgradableTemplateHash) {
+			str := fmt.Sprintf("template hash of %d bytes reserved "+
+				"for soft-fork upgrades", len(templateHash))
+			return scriptError(ErrDiscourageUpgradableTemplateHash, str)
+		}
+		return nil
+	}
+
+	wantHash := CalcTemplateHash(&vm.tx, vm.txIdx)
+	if !bytes.Equal(templateHash, wantHash[:]) {
+		str := fmt.Sprintf("template hash %x does not match the "+
+			"transaction template hash %v", templateHash, wantHash)
+		return scriptError(ErrTemplateMismatch, str)
+	}
+
+	return nil
+}
+
 // opcodeToAltStack removes the top item from the main data stack and pushes it
 // onto the alternate data stack.
 //
@@ -1525,6 +1568,33 @@ func opcodeTuck(op *parsedOpcode, vm *Engine) error {
 	return vm.dstack.Tuck()
 }
 
+// opcodeCat removes the top 2 items of the data stack and pushes their
+// concatenation.  It is disabled unless the ScriptVerifyCat flag is set, in
+// which case it follows BIP0347.
+//
+// Stack transformation: [... x1 x2] -> [... x1||x2]
+func opcodeCat(op *parsedOpcode, vm *Engine) error {
+	b, err := vm.dstack.PopByteArray()
+	if err != nil {
+		return err
+	}
+	a, err := vm.dstack.PopByteArray()
+	if err != nil {
+		return err
+	}
+
+	if len(a)+len(b) > MaxScriptElementSize {
+		str := fmt.Sprintf("concatenated size %d exceeds max allowed "+
+			"size %d", len(a)+len(b), MaxScriptElementSize)
+		return scriptError(ErrElementTooBig, str)
+	}
+
+	cat := make([]byte, 0, len(a)+len(b))
+	cat = append(cat, a...)
+	vm.dstack.PushByteArray(append(cat, b...))
+	return nil
+}
+
 // opcodeSize pushes the size of the top item of the data stack onto the data
 // stack.
 //
@@ -2164,15 +2234,8 @@ func opcodeCheckSig(op *parsedOpcode, vm *Engine) error {
 	// Generate the signature hash based on the signature hash type.
 	var hash []byte
 	if vm.isWitnessVersionActive(0) {
-		var sigHashes *TxSigHashes
-		if vm.hashCache != nil {
-			sigHashes = vm.hashCache
-		} else {
-			sigHashes = NewTxSigHashes(&vm.tx)
-		}
-
-		hash, err = calcWitnessSignatureHash(subScript, sigHashes, hashType,
-			&vm.tx, vm.txIdx, vm.inputAmount)
+		hash, err = calcWitnessSignatureHash(subScript, vm.sigHashes(),
+			hashType, &vm.tx, vm.txIdx, vm.inputAmount)
 		if err != nil {
 			return err
 		}
@@ -2437,15 +2500,8 @@ func opcodeCheckMultiSig(op *parsedOpcode, vm *Engine) error {
 		// Generate the signature hash based on the signature hash type.
 		var hash []byte
 		if vm.isWitnessVersionActive(0) {
-			var sigHashes *TxSigHashes
-			if vm.hashCache != nil {
-				sigHashes = vm.hashCache
-			} else {
-				sigHashes = NewTxSigHashes(&vm.tx)
-			}
-
-			hash, err = calcWitnessSignatureHash(script, sigHashes, hashType,
-				&vm.tx, vm.txIdx, vm.inputAmount)
+			hash, err = calcWitnessSignatureHash(script, vm.sigHashes(),
+				hashType, &vm.tx, vm.txIdx, vm.inputAmount)
 			if err != nil {
 				return err
 			}
@@ -2507,9 +2563,10 @@ var OpcodeByName = make(map[string]byte)
 
 func init() {
 	// Initialize the opcode name to value map using the contents of the
-	// opcode array.  Also add entries for "OP_FALSE", "OP_TRUE", and
-	// "OP_NOP2" since they are aliases for "OP_0", "OP_1",
-	// and "OP_CHECKLOCKTIMEVERIFY" respectively.
+	// opcode array.  Also add entries for "OP_FALSE", "OP_TRUE",
+	// "OP_NOP2", "OP_NOP3" and "OP_NOP4" since they are aliases for
+	// "OP_0", "OP_1", "OP_CHECKLOCKTIMEVERIFY", "OP_CHECKSEQUENCEVERIFY"
+	// and "OP_CHECKTEMPLATEVERIFY" respectively.
 	for _, op := range opcodeArray {
 		OpcodeByName[op.name] = op.value
 	}
@@ -2517,4 +2574,5 @@ func init() {
 	OpcodeByName["OP_TRUE"] = OP_TRUE
 	OpcodeByName["OP_NOP2"] = OP_CHECKLOCKTIMEVERIFY
 	OpcodeByName["OP_NOP3"] = OP_CHECKSEQUENCEVERIFY
+	OpcodeByName["OP_NOP4"] = OP_CHECKTEMPLATEVERIFY
 }
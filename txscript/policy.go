@@ -0,0 +1,551 @@
+// Copyright (c) 2013-2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// MaxStandardP2SHSigOps is the maximum number of signature operations
+	// that are considered standard in a pay-to-script-hash script.
+	MaxStandardP2SHSigOps = 15
+
+	// MaxStandardTxWeight is the max weight permitted by any transaction
+	// according to the current default policy.
+	MaxStandardTxWeight = 400000
+
+	// MaxStandardSigScriptSize is the maximum size allowed for a
+	// transaction input signature script to be considered standard.  This
+	// value allows for a 15-of-15 CHECKMULTISIG pay-to-script-hash with
+	// compressed keys.
+	//
+	// The form of the overall script is: OP_0 <15 signatures> OP_PUSHDATA2
+	// <2 bytes len> [OP_15 <15 pubkeys> OP_15 OP_CHECKMULTISIG]
+	//
+	// For the p2sh script portion, each of the 15 compressed pubkeys are
+	// 33 bytes (plus one for the OP_DATA_33 opcode), and the thus it totals
+	// to (15*34)+3 = 513 bytes.  Next, each of the 15 signatures is a max
+	// of 73 bytes (plus one for the OP_DATA_73 opcode).  Also, there is one
+	// extra byte for the initial extra OP_0 push and 3 bytes for the
+	// OP_PUSHDATA2 needed to specify the 513 bytes for the script push.
+	// That brings the total to 1+(15*74)+3+513 = 1627.  This value also
+	// adds a few extra bytes to provide a little buffer.
+	// (1 + 15*74 + 3) + (15*34 + 3) + 23 = 1650
+	MaxStandardSigScriptSize = 1650
+
+	// MaxStandardMultiSigKeys is the maximum number of public keys allowed
+	// in a multi-signature transaction output script for it to be
+	// considered standard.
+	MaxStandardMultiSigKeys = 3
+
+	// MaxStandardTxSigOpCost is the maximum signature operation cost of a
+	// transaction to be considered standard.  It is a fifth of the
+	// maximum signature operation cost allowed in a block.
+	MaxStandardTxSigOpCost = 80000 / 5
+
+	// MaxStandardP2WSHScriptSize is the maximum size of a witness script
+	// spent by a pay-to-witness-script-hash input to be considered
+	// standard.
+	MaxStandardP2WSHScriptSize = 3600
+
+	// MaxStandardP2WSHStackItems is the maximum number of witness items,
+	// excluding the witness script, of a pay-to-witness-script-hash input
+	// to be considered standard.
+	MaxStandardP2WSHStackItems = 100
+
+	// MaxStandardP2WSHStackItemSize is the maximum size of each witness
+	// item, excluding the witness script, of a pay-to-witness-script-hash
+	// input to be considered standard.
+	MaxStandardP2WSHStackItemSize = 80
+
+	// DefaultMaxTxVersion is the highest transaction version considered
+	// standard by CheckStandard.
+	DefaultMaxTxVersion = 2
+
+	// DefaultMinRelayTxFee is the minimum fee in satoshi that is required
+	// for a transaction to be treated as free for relay and mining
+	// purposes.  It is also used to help determine if a transaction is
+	// considered dust and as a base for calculating minimum required fees
+	// for larger transactions.  This value is in Satoshi/1000 bytes.
+	DefaultMinRelayTxFee = btcutil.Amount(1000)
+
+	// annexTag is the first byte of the last witness item of a taproot
+	// spend which marks the item as an annex as defined by BIP0341.
+	annexTag = 0x50
+
+	// witnessScaleFactor is the discount applied to witness data when
+	// computing the weight of a transaction as defined by BIP0141.
+	witnessScaleFactor = 4
+)
+
+// policyError creates an Error given a set of arguments for an input or
+// output of a transaction that is not standard.
+func policyError(c ErrorCode, prefix string, idx int, desc string) Error {
+	return scriptError(c, fmt.Sprintf("transaction %s %d: %s", prefix,
+		idx, desc))
+}
+
+// CheckPkScriptStandard performs a series of checks on a transaction output
+// script (public key script) to ensure it is a "standard" public key script.
+// A standard public key script is one that is a recognized form, and for
+// multi-signature scripts, only contains from 1 to MaxStandardMultiSigKeys
+// public keys.
+func CheckPkScriptStandard(pkScript []byte) error {
+	switch GetScriptClass(pkScript) {
+	case MultiSigTy:
+		numPubKeys, numSigs, err := CalcMultiSigStats(pkScript)
+		if err != nil {
+			str := fmt.Sprintf("multi-signature script parse "+
+				"failure: %v", err)
+			return scriptError(ErrNonStandardPkScript, str)
+		}
+
+		// A standard multi-signature public key script must contain
+		// from 1 to MaxStandardMultiSigKeys public keys.
+		if numPubKeys < 1 {
+			str := "multi-signature script with no pubkeys"
+			return scriptError(ErrNonStandardPkScript, str)
+		}
+		if numPubKeys > MaxStandardMultiSigKeys {
+			str := fmt.Sprintf("multi-signature script with %d "+
+				"public keys which is more than the allowed "+
+				"max of %d", numPubKeys, MaxStandardMultiSigKeys)
+			return scriptError(ErrNonStandardPkScript, str)
+		}
+
+		// A standard multi-signature public key script must have at
+		// least 1 signature and no more signatures than available
+		// public keys.
+		if numSigs < 1 {
+			return scriptError(ErrNonStandardPkScript,
+				"multi-signature script with no signatures")
+		}
+		if numSigs > numPubKeys {
+			str := fmt.Sprintf("multi-signature script with %d "+
+				"signatures which is more than the available "+
+				"%d public keys", numSigs, numPubKeys)
+			return scriptError(ErrNonStandardPkScript, str)
+		}
+
+	case NonStandardTy:
+		return scriptError(ErrNonStandardPkScript,
+			"non-standard script form")
+	}
+
+	return nil
+}
+
+// IsDust returns whether or not the passed transaction output amount is
+// considered dust or not based on the passed minimum transaction relay fee.
+// Dust is defined in terms of the minimum transaction relay fee.  In
+// particular, if the cost to the network to spend coins is more than 1/3 of the
+// minimum transaction relay fee, it is considered dust.
+func IsDust(txOut *wire.TxOut, minRelayTxFee btcutil.Amount) bool {
+	// Unspendable outputs are considered dust.
+	if IsUnspendable(txOut.PkScript) {
+		return true
+	}
+
+	// The total serialized size consists of the output and the associated
+	// input script to redeem it.  Since there is no input script
+	// to redeem it yet, use the minimum size of a typical input script.
+	//
+	// Pay-to-pubkey-hash bytes breakdown:
+	//
+	//  Output to hash (34 bytes):
+	//   8 value, 1 script len, 25 script [1 OP_DUP, 1 OP_HASH_160,
+	//   1 OP_DATA_20, 20 hash, 1 OP_EQUALVERIFY, 1 OP_CHECKSIG]
+	//
+	//  Input with compressed pubkey (148 bytes):
+	//   36 prev outpoint, 1 script len, 107 script [1 OP_DATA_72, 72 sig,
+	//   1 OP_DATA_33, 33 compressed pubkey], 4 sequence
+	//
+	//  Input with uncompressed pubkey (180 bytes):
+	//   36 prev outpoint, 1 script len, 139 script [1 OP_DATA_72, 72 sig,
+	//   1 OP_DATA_65, 65 compressed pubkey], 4 sequence
+	//
+	// Pay-to-pubkey bytes breakdown:
+	//
+	//  Output to compressed pubkey (44 bytes):
+	//   8 value, 1 script len, 35 script [1 OP_DATA_33,
+	//   33 compressed pubkey, 1 OP_CHECKSIG]
+	//
+	//  Output to uncompressed pubkey (76 bytes):
+	//   8 value, 1 script len, 67 script [1 OP_DATA_65, 65 pubkey,
+	//   1 OP_CHECKSIG]
+	//
+	//  Input (114 bytes):
+	//   36 prev outpoint, 1 script len, 73 script [1 OP_DATA_72,
+	//   72 sig], 4 sequence
+	//
+	// Pay-to-witness-pubkey-hash bytes breakdown:
+	//
+	//  Output to witness key hash (31 bytes);
+	//   8 value, 1 script len, 22 script [1 OP_0, 1 OP_DATA_20,
+	//   20 bytes hash160]
+	//
+	//  Input (67 bytes as the 107 witness stack is discounted):
+	//   36 prev outpoint, 1 script len, 0 script (not sigScript), 107
+	//   witness stack bytes [1 element length, 33 compressed pubkey,
+	//   element length 72 sig], 4 sequence
+	//
+	//
+	// Theoretically this could examine the script type of the output script
+	// and use a different size for the typical input script size for
+	// pay-to-pubkey vs pay-to-pubkey-hash inputs per the above breakdowns,
+	// but the only combination which is less than the value chosen is
+	// a pay-to-pubkey script with a compressed pubkey, which is not very
+	// common.
+	//
+	// The most common scripts are pay-to-pubkey-hash, and as per the above
+	// breakdown, the minimum size of a p2pkh input script is 148 bytes.  So
+	// that figure is used. If the output being spent is a witness program,
+	// then we apply the witness discount to the size of the signature.
+	//
+	// The segwit analogue to p2pkh is a p2wkh output. This is the smallest
+	// output possible using the new segwit features. The 107 bytes of
+	// witness data is discounted by a factor of 4, leading to a computed
+	// value of 67 bytes of witness data.
+	//
+	// Both cases share a 41 byte preamble required to reference the input
+	// being spent and the sequence number of the input.
+	totalSize := txOut.SerializeSize() + 41
+	if IsWitnessProgram(txOut.PkScript) {
+		totalSize += (107 / witnessScaleFactor)
+	} else {
+		totalSize += 107
+	}
+
+	// The output is considered dust if the cost to the network to spend the
+	// coins is more than 1/3 of the minimum free transaction relay fee.
+	// minFreeTxRelayFee is in Satoshi/KB, so multiply by 1000 to
+	// convert to bytes.
+	//
+	// Using the typical values for a pay-to-pubkey-hash transaction from
+	// the breakdown above and the default minimum free transaction relay
+	// fee of 1000, this equates to values less than 546 satoshi being
+	// considered dust.
+	//
+	// The following is equivalent to (value/totalSize) * (1/3) * 1000
+	// without needing to do floating point math.
+	return txOut.Value*1000/(3*int64(totalSize)) < int64(minRelayTxFee)
+}
+
+// CheckTransactionStandard performs a series of checks on a transaction to
+// ensure it is a "standard" transaction without looking at the outputs it
+// spends.  A standard transaction is one that conforms to several additional
+// limiting cases over what is considered a "sane" transaction such as having
+// a version in the supported range, conforming to more stringent size
+// constraints, having scripts of recognized forms, and not containing "dust"
+// outputs (those that are so small it costs more to process them than they
+// are worth).
+//
+// Whether the transaction is finalized is not checked since it depends on the
+// state of the chain.
+func CheckTransactionStandard(tx *wire.MsgTx, maxTxVersion int32,
+	minRelayTxFee btcutil.Amount) error {
+
+	// The transaction must be a currently supported version.
+	if tx.Version > maxTxVersion || tx.Version < 1 {
+		str := fmt.Sprintf("transaction version %d is not in the "+
+			"valid range of %d-%d", tx.Version, 1, maxTxVersion)
+		return scriptError(ErrNonStandardVersion, str)
+	}
+
+	// Since extremely large transactions with a lot of inputs can cost
+	// almost as much to process as the sender fees, limit the maximum
+	// size of a transaction.  This also helps mitigate CPU exhaustion
+	// attacks.
+	txWeight := tx.SerializeSizeStripped()*(witnessScaleFactor-1) +
+		tx.SerializeSize()
+	if txWeight > MaxStandardTxWeight {
+		str := fmt.Sprintf("weight of transaction %v is larger than max "+
+			"allowed weight of %v", txWeight, MaxStandardTxWeight)
+		return scriptError(ErrNonStandardTxWeight, str)
+	}
+
+	for i, txIn := range tx.TxIn {
+		// Each transaction input signature script must not exceed the
+		// maximum size allowed for a standard transaction.  See
+		// the comment on MaxStandardSigScriptSize for more details.
+		sigScriptLen := len(txIn.SignatureScript)
+		if sigScriptLen > MaxStandardSigScriptSize {
+			str := fmt.Sprintf("signature script size of %d bytes "+
+				"is large than max allowed size of %d bytes",
+				sigScriptLen, MaxStandardSigScriptSize)
+			return policyError(ErrNonStandardSigScript, "input", i,
+				str)
+		}
+
+		// Each transaction input signature script must only contain
+		// opcodes which push data onto the stack.
+		if !IsPushOnlyScript(txIn.SignatureScript) {
+			return policyError(ErrNonStandardSigScript, "input", i,
+				"signature script is not push only")
+		}
+	}
+
+	// None of the output public key scripts can be a non-standard script or
+	// be "dust" (except when the script is a null data script).
+	numNullDataOutputs := 0
+	for i, txOut := range tx.TxOut {
+		if err := CheckPkScriptStandard(txOut.PkScript); err != nil {
+			return policyError(ErrNonStandardPkScript, "output", i,
+				err.Error())
+		}
+
+		// Accumulate the number of outputs which only carry data.  For
+		// all other script types, ensure the output value is not
+		// "dust".
+		if GetScriptClass(txOut.PkScript) == NullDataTy {
+			numNullDataOutputs++
+		} else if IsDust(txOut, minRelayTxFee) {
+			str := fmt.Sprintf("payment of %d is dust", txOut.Value)
+			return policyError(ErrDustOutput, "output", i, str)
+		}
+	}
+
+	// A standard transaction must not have more than one output script that
+	// only carries data.
+	if numNullDataOutputs > 1 {
+		return scriptError(ErrTooManyNullData, "more than one "+
+			"transaction output in a nulldata script")
+	}
+
+	return nil
+}
+
+// fetchPrevOutput returns the output spent by the input with the passed index
+// using the passed fetcher.
+func fetchPrevOutput(tx *wire.MsgTx, idx int,
+	prevOutFetcher PrevOutputFetcher) (*wire.TxOut, error) {
+
+	outpoint := tx.TxIn[idx].PreviousOutPoint
+	prevOut := prevOutFetcher.FetchPrevOutput(outpoint)
+	if prevOut == nil {
+		str := fmt.Sprintf("unable to fetch output %v", outpoint)
+		return nil, policyError(ErrMissingPrevOut, "input", idx, str)
+	}
+	return prevOut, nil
+}
+
+// checkWitnessStandard ensures the witness of the passed input, which spends
+// an output with the passed public key script, is standard.  Taproot spends
+// must not have an annex, and pay-to-witness-script-hash spends, native or
+// nested in pay-to-script-hash, must not exceed the standard witness script
+// and witness item limits.
+func checkWitnessStandard(txIn *wire.TxIn, idx int, pkScript []byte) error {
+	witness := txIn.Witness
+	if len(witness) == 0 {
+		return nil
+	}
+
+	// The witness program of nested spends is the last item pushed by the
+	// signature script.
+	program := pkScript
+	nested := false
+	if IsPayToScriptHash(pkScript) {
+		pushes, err := PushedData(txIn.SignatureScript)
+		if err != nil || len(pushes) == 0 {
+			return nil
+		}
+		program = pushes[len(pushes)-1]
+		nested = true
+	}
+	if !IsWitnessProgram(program) {
+		return nil
+	}
+	version, witnessProgram, err := ExtractWitnessProgramInfo(program)
+	if err != nil {
+		return nil
+	}
+
+	switch {
+	case version == 0 && len(witnessProgram) == payToWitnessScriptHashDataSize:
+		witnessScript := witness[len(witness)-1]
+		if len(witnessScript) > MaxStandardP2WSHScriptSize {
+			str := fmt.Sprintf("witness script size of %d bytes "+
+				"is larger than max allowed size of %d bytes",
+				len(witnessScript), MaxStandardP2WSHScriptSize)
+			return policyError(ErrNonStandardWitness, "input", idx,
+				str)
+		}
+
+		items := witness[:len(witness)-1]
+		if len(items) > MaxStandardP2WSHStackItems {
+			str := fmt.Sprintf("witness has %d items which is more "+
+				"than the allowed max of %d", len(items),
+				MaxStandardP2WSHStackItems)
+			return policyError(ErrNonStandardWitness, "input", idx,
+				str)
+		}
+		for _, item := range items {
+			if len(item) > MaxStandardP2WSHStackItemSize {
+				str := fmt.Sprintf("witness item size of %d "+
+					"bytes is larger than max allowed "+
+					"size of %d bytes", len(item),
+					MaxStandardP2WSHStackItemSize)
+				return policyError(ErrNonStandardWitness,
+					"input", idx, str)
+			}
+		}
+
+	// Taproot is only defined for native version 1 witness programs.
+	case version == 1 && len(witnessProgram) == 32 && !nested:
+		lastItem := witness[len(witness)-1]
+		if len(witness) >= 2 && len(lastItem) > 0 &&
+			lastItem[0] == annexTag {
+
+			return policyError(ErrNonStandardWitness, "input", idx,
+				"witness has an annex")
+		}
+	}
+
+	return nil
+}
+
+// CheckInputsStandard performs a series of checks on a transaction's inputs
+// to ensure they are "standard".  A standard transaction input within the
+// context of this function is one whose referenced public key script is of a
+// standard form and, for pay-to-script-hash, does not have more than
+// MaxStandardP2SHSigOps signature operations.  Its witness must also be
+// standard as described by MaxStandardP2WSHScriptSize and the related limits,
+// and taproot spends must not have an annex.  However, it should also be noted
+// that standard inputs also are those which have a clean stack after execution
+// and only contain pushed data in their signature scripts.  This function does
+// not perform those checks because the script engine already does this more
+// accurately and concisely via the ScriptVerifyCleanStack and
+// ScriptVerifySigPushOnly flags.
+//
+// The passed fetcher must provide the outputs spent by all inputs, so the
+// transaction must not be a coinbase.
+func CheckInputsStandard(tx *wire.MsgTx, prevOutFetcher PrevOutputFetcher) error {
+	for i, txIn := range tx.TxIn {
+		prevOut, err := fetchPrevOutput(tx, i, prevOutFetcher)
+		if err != nil {
+			return err
+		}
+
+		originPkScript := prevOut.PkScript
+		switch GetScriptClass(originPkScript) {
+		case ScriptHashTy:
+			numSigOps := GetPreciseSigOpCount(
+				txIn.SignatureScript, originPkScript, true)
+			if numSigOps > MaxStandardP2SHSigOps {
+				str := fmt.Sprintf("%d signature operations "+
+					"which is more than the allowed max "+
+					"amount of %d", numSigOps,
+					MaxStandardP2SHSigOps)
+				return policyError(ErrNonStandardInput, "input",
+					i, str)
+			}
+
+		case NonStandardTy:
+			return policyError(ErrNonStandardInput, "input", i,
+				"non-standard script form")
+		}
+
+		err = checkWitnessStandard(txIn, i, originPkScript)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetSigOpCost returns the signature operation cost of the passed transaction
+// as defined by BIP0141, which includes the legacy signature operations, the
+// precise signature operations of pay-to-script-hash inputs, and the witness
+// signature operations.  The passed fetcher must provide the outputs spent by
+// all inputs, so the transaction must not be a coinbase.
+func GetSigOpCost(tx *wire.MsgTx, prevOutFetcher PrevOutputFetcher) (int, error) {
+	var numSigOps int
+	for _, txIn := range tx.TxIn {
+		numSigOps += GetSigOpCount(txIn.SignatureScript)
+	}
+	for _, txOut := range tx.TxOut {
+		numSigOps += GetSigOpCount(txOut.PkScript)
+	}
+	numSigOps *= witnessScaleFactor
+
+	for i, txIn := range tx.TxIn {
+		prevOut, err := fetchPrevOutput(tx, i, prevOutFetcher)
+		if err != nil {
+			return 0, err
+		}
+
+		sigScript := txIn.SignatureScript
+		pkScript := prevOut.PkScript
+		if IsPayToScriptHash(pkScript) {
+			numSigOps += GetPreciseSigOpCount(sigScript, pkScript,
+				true) * witnessScaleFactor
+		}
+		numSigOps += GetWitnessSigOpCount(sigScript, pkScript,
+			txIn.Witness)
+	}
+
+	return numSigOps, nil
+}
+
+// CheckStandard returns an error when the passed transaction would not be
+// relayed by nodes using the default policy, which makes it possible for
+// wallets to predict whether a transaction is accepted before broadcasting
+// it.  The passed fetcher must provide the outputs spent by all inputs.
+//
+// The transaction must be standard as described by CheckTransactionStandard
+// using DefaultMaxTxVersion and DefaultMinRelayTxFee, its inputs must be
+// standard as described by CheckInputsStandard, and its signature operation
+// cost must not exceed MaxStandardTxSigOpCost.  Finally, the scripts of all
+// inputs are executed with the passed flags, which are typically
+// StandardVerifyFlags, to ensure rules such as the clean stack rule are
+// followed.
+func CheckStandard(tx *wire.MsgTx, prevOutFetcher PrevOutputFetcher,
+	flags ScriptFlags) error {
+
+	err := CheckTransactionStandard(tx, DefaultMaxTxVersion,
+		DefaultMinRelayTxFee)
+	if err != nil {
+		return err
+	}
+
+	if err := CheckInputsStandard(tx, prevOutFetcher); err != nil {
+		return err
+	}
+
+	sigOpCost, err := GetSigOpCost(tx, prevOutFetcher)
+	if err != nil {
+		return err
+	}
+	if sigOpCost > MaxStandardTxSigOpCost {
+		str := fmt.Sprintf("signature operation cost of %d is more "+
+			"than the allowed max of %d", sigOpCost,
+			MaxStandardTxSigOpCost)
+		return scriptError(ErrNonStandardSigOpCost, str)
+	}
+
+	sigHashes, err := NewTxSigHashesWithFetcher(tx, prevOutFetcher)
+	if err != nil {
+		return err
+	}
+	for i := range tx.TxIn {
+		// The outputs were all fetched successfully above.
+		prevOut, _ := fetchPrevOutput(tx, i, prevOutFetcher)
+		vm, err := NewEngine(prevOut.PkScript, tx, i, flags, nil,
+			sigHashes, prevOut.Value)
+		if err != nil {
+			return err
+		}
+		if err := vm.Execute(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,424 @@
+// Copyright (c) 2013-2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// TestCheckPkScriptStandard tests the CheckPkScriptStandard API.
+func TestCheckPkScriptStandard(t *testing.T) {
+	var pubKeys [][]byte
+	for i := 0; i < 4; i++ {
+		pk, err := btcec.NewPrivateKey(btcec.S256())
+		if err != nil {
+			t.Fatalf("TestCheckPkScriptStandard NewPrivateKey failed: %v",
+				err)
+			return
+		}
+		pubKeys = append(pubKeys, pk.PubKey().SerializeCompressed())
+	}
+
+	tests := []struct {
+		name       string // test description.
+		script     *ScriptBuilder
+		isStandard bool
+	}{
+		{
+			"key1 and key2",
+			NewScriptBuilder().AddOp(OP_2).
+				AddData(pubKeys[0]).AddData(pubKeys[1]).
+				AddOp(OP_2).AddOp(OP_CHECKMULTISIG),
+			true,
+		},
+		{
+			"key1 or key2",
+			NewScriptBuilder().AddOp(OP_1).
+				AddData(pubKeys[0]).AddData(pubKeys[1]).
+				AddOp(OP_2).AddOp(OP_CHECKMULTISIG),
+			true,
+		},
+		{
+			"escrow",
+			NewScriptBuilder().AddOp(OP_2).
+				AddData(pubKeys[0]).AddData(pubKeys[1]).
+				AddData(pubKeys[2]).
+				AddOp(OP_3).AddOp(OP_CHECKMULTISIG),
+			true,
+		},
+		{
+			"one of four",
+			NewScriptBuilder().AddOp(OP_1).
+				AddData(pubKeys[0]).AddData(pubKeys[1]).
+				AddData(pubKeys[2]).AddData(pubKeys[3]).
+				AddOp(OP_4).AddOp(OP_CHECKMULTISIG),
+			false,
+		},
+		{
+			"malformed1",
+			NewScriptBuilder().AddOp(OP_3).
+				AddData(pubKeys[0]).AddData(pubKeys[1]).
+				AddOp(OP_2).AddOp(OP_CHECKMULTISIG),
+			false,
+		},
+		{
+			"malformed2",
+			NewScriptBuilder().AddOp(OP_2).
+				AddData(pubKeys[0]).AddData(pubKeys[1]).
+				AddOp(OP_3).AddOp(OP_CHECKMULTISIG),
+			false,
+		},
+		{
+			"malformed3",
+			NewScriptBuilder().AddOp(OP_0).
+				AddData(pubKeys[0]).AddData(pubKeys[1]).
+				AddOp(OP_2).AddOp(OP_CHECKMULTISIG),
+			false,
+		},
+		{
+			"malformed4",
+			NewScriptBuilder().AddOp(OP_1).
+				AddData(pubKeys[0]).AddData(pubKeys[1]).
+				AddOp(OP_0).AddOp(OP_CHECKMULTISIG),
+			false,
+		},
+		{
+			"malformed5",
+			NewScriptBuilder().AddOp(OP_1).
+				AddData(pubKeys[0]).AddData(pubKeys[1]).
+				AddOp(OP_CHECKMULTISIG),
+			false,
+		},
+		{
+			"malformed6",
+			NewScriptBuilder().AddOp(OP_1).
+				AddData(pubKeys[0]).AddData(pubKeys[1]),
+			false,
+		},
+		{
+			"pay to anchor",
+			NewScriptBuilder().AddOp(OP_1).
+				AddData([]byte{0x4e, 0x73}),
+			true,
+		},
+		{
+			"future witness version",
+			NewScriptBuilder().AddOp(OP_2).
+				AddData(pubKeys[0][1:]),
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		script, err := test.script.Script()
+		if err != nil {
+			t.Fatalf("TestCheckPkScriptStandard test '%s' "+
+				"failed: %v", test.name, err)
+			continue
+		}
+		got := CheckPkScriptStandard(script)
+		if (test.isStandard && got != nil) ||
+			(!test.isStandard && got == nil) {
+
+			t.Fatalf("TestCheckPkScriptStandard test '%s' failed",
+				test.name)
+			return
+		}
+	}
+}
+
+// TestDust tests the IsDust API.
+func TestDust(t *testing.T) {
+	pkScript := []byte{0x76, 0xa9, 0x21, 0x03, 0x2f, 0x7e, 0x43,
+		0x0a, 0xa4, 0xc9, 0xd1, 0x59, 0x43, 0x7e, 0x84, 0xb9,
+		0x75, 0xdc, 0x76, 0xd9, 0x00, 0x3b, 0xf0, 0x92, 0x2c,
+		0xf3, 0xaa, 0x45, 0x28, 0x46, 0x4b, 0xab, 0x78, 0x0d,
+		0xba, 0x5e, 0x88, 0xac}
+
+	tests := []struct {
+		name     string // test description
+		txOut    wire.TxOut
+		relayFee btcutil.Amount // minimum relay transaction fee.
+		isDust   bool
+	}{
+		{
+			// Any value is allowed with a zero relay fee.
+			"zero value with zero relay fee",
+			wire.TxOut{Value: 0, PkScript: pkScript},
+			0,
+			false,
+		},
+		{
+			// Zero value is dust with any relay fee"
+			"zero value with very small tx fee",
+			wire.TxOut{Value: 0, PkScript: pkScript},
+			1,
+			true,
+		},
+		{
+			"38 byte public key script with value 584",
+			wire.TxOut{Value: 584, PkScript: pkScript},
+			1000,
+			true,
+		},
+		{
+			"38 byte public key script with value 585",
+			wire.TxOut{Value: 585, PkScript: pkScript},
+			1000,
+			false,
+		},
+		{
+			"pay-to-anchor script with value 239",
+			wire.TxOut{Value: 239, PkScript: PayToAnchorScript()},
+			1000,
+			true,
+		},
+		{
+			"pay-to-anchor script with value 240",
+			wire.TxOut{Value: 240, PkScript: PayToAnchorScript()},
+			1000,
+			false,
+		},
+		{
+			// Maximum allowed value is never dust.
+			"max satoshi amount is never dust",
+			wire.TxOut{Value: btcutil.MaxSatoshi, PkScript: pkScript},
+			btcutil.MaxSatoshi,
+			false,
+		},
+		{
+			// Maximum int64 value causes overflow.
+			"maximum int64 value",
+			wire.TxOut{Value: 1<<63 - 1, PkScript: pkScript},
+			1<<63 - 1,
+			true,
+		},
+		{
+			// Unspendable pkScript due to an invalid public key
+			// script.
+			"unspendable pkScript",
+			wire.TxOut{Value: 5000, PkScript: []byte{0x01}},
+			0, // no relay fee
+			true,
+		},
+	}
+	for _, test := range tests {
+		res := IsDust(&test.txOut, test.relayFee)
+		if res != test.isDust {
+			t.Fatalf("Dust test '%s' failed: want %v got %v",
+				test.name, test.isDust, res)
+			continue
+		}
+	}
+}
+
+// policyTestTx returns a standard transaction spending a single output with a
+// pay-to-pubkey-hash output along with a fetcher returning the passed
+// previous output script for its input.
+func policyTestTx(sigScript []byte, witness wire.TxWitness,
+	prevPkScript []byte) (*wire.MsgTx, PrevOutputFetcher) {
+
+	pkScript, _ := payToPubKeyHashScript(make([]byte, 20))
+	tx := &wire.MsgTx{
+		Version: 2,
+		TxIn: []*wire.TxIn{{
+			PreviousOutPoint: wire.OutPoint{Index: 1},
+			SignatureScript:  sigScript,
+			Witness:          witness,
+			Sequence:         wire.MaxTxInSequenceNum,
+		}},
+		TxOut: []*wire.TxOut{{Value: 100000, PkScript: pkScript}},
+	}
+	return tx, NewCannedPrevOutputFetcher(prevPkScript, 200000)
+}
+
+// TestCheckTransactionStandardErrors ensures CheckTransactionStandard returns
+// the expected error codes for non-standard transactions.
+func TestCheckTransactionStandardErrors(t *testing.T) {
+	t.Parallel()
+
+	nullData := mustParseShortForm("RETURN DATA_1 0x01")
+	tests := []struct {
+		name    string
+		modify  func(tx *wire.MsgTx)
+		errCode ErrorCode
+		valid   bool
+	}{{
+		name:   "standard",
+		modify: func(tx *wire.MsgTx) {},
+		valid:  true,
+	}, {
+		name:    "version too high",
+		modify:  func(tx *wire.MsgTx) { tx.Version = 3 },
+		errCode: ErrNonStandardVersion,
+	}, {
+		name: "weight too high",
+		modify: func(tx *wire.MsgTx) {
+			tx.TxOut[0].PkScript = make([]byte,
+				MaxStandardTxWeight/4)
+		},
+		errCode: ErrNonStandardTxWeight,
+	}, {
+		name: "signature script not push only",
+		modify: func(tx *wire.MsgTx) {
+			tx.TxIn[0].SignatureScript = []byte{OP_DUP}
+		},
+		errCode: ErrNonStandardSigScript,
+	}, {
+		name:    "dust output",
+		modify:  func(tx *wire.MsgTx) { tx.TxOut[0].Value = 545 },
+		errCode: ErrDustOutput,
+	}, {
+		name: "non-standard output",
+		modify: func(tx *wire.MsgTx) {
+			tx.TxOut[0].PkScript = []byte{OP_TRUE}
+		},
+		errCode: ErrNonStandardPkScript,
+	}, {
+		name: "two null data outputs",
+		modify: func(tx *wire.MsgTx) {
+			tx.AddTxOut(wire.NewTxOut(0, nullData))
+			tx.AddTxOut(wire.NewTxOut(0, nullData))
+		},
+		errCode: ErrTooManyNullData,
+	}}
+
+	for _, test := range tests {
+		tx, _ := policyTestTx(nil, nil, nil)
+		test.modify(tx)
+		err := CheckTransactionStandard(tx, DefaultMaxTxVersion,
+			DefaultMinRelayTxFee)
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if !test.valid && !IsErrorCode(err, test.errCode) {
+			t.Errorf("%s: unexpected error - got %v, want %v",
+				test.name, err, test.errCode)
+		}
+	}
+}
+
+// TestCheckInputsStandard ensures CheckInputsStandard rejects inputs spending
+// non-standard outputs, with too many pay-to-script-hash signature operations,
+// and with non-standard witnesses.
+func TestCheckInputsStandard(t *testing.T) {
+	t.Parallel()
+
+	p2wshScript := func(witnessScript []byte) []byte {
+		hash := sha256.Sum256(witnessScript)
+		script, _ := payToWitnessScriptHashScript(hash[:])
+		return script
+	}
+	taprootScript := mustParseShortForm("1 DATA_32 0x" +
+		"0101010101010101010101010101010101010101010101010101010101010101")
+	manySigOps := bytes.Repeat([]byte{OP_CHECKSIG}, MaxStandardP2SHSigOps+1)
+	manySigOpsScript, _ := payToScriptHashScript(btcutil.Hash160(manySigOps))
+	manySigOpsSigScript, _ := NewScriptBuilder().AddData(manySigOps).Script()
+	bigWitnessScript := make([]byte, MaxStandardP2WSHScriptSize+1)
+
+	tests := []struct {
+		name         string
+		sigScript    []byte
+		witness      wire.TxWitness
+		prevPkScript []byte
+		errCode      ErrorCode
+		valid        bool
+	}{{
+		name:         "p2wsh",
+		witness:      wire.TxWitness{{0x01}, {OP_TRUE}},
+		prevPkScript: p2wshScript([]byte{OP_TRUE}),
+		valid:        true,
+	}, {
+		name:         "taproot without annex",
+		witness:      wire.TxWitness{make([]byte, 64)},
+		prevPkScript: taprootScript,
+		valid:        true,
+	}, {
+		name:         "non-standard output",
+		prevPkScript: []byte{OP_TRUE},
+		errCode:      ErrNonStandardInput,
+	}, {
+		name:         "too many p2sh sigops",
+		sigScript:    manySigOpsSigScript,
+		prevPkScript: manySigOpsScript,
+		errCode:      ErrNonStandardInput,
+	}, {
+		name:         "taproot with annex",
+		witness:      wire.TxWitness{make([]byte, 64), {annexTag}},
+		prevPkScript: taprootScript,
+		errCode:      ErrNonStandardWitness,
+	}, {
+		name:         "p2wsh witness script too big",
+		witness:      wire.TxWitness{bigWitnessScript},
+		prevPkScript: p2wshScript(bigWitnessScript),
+		errCode:      ErrNonStandardWitness,
+	}, {
+		name: "p2wsh witness item too big",
+		witness: wire.TxWitness{
+			make([]byte, MaxStandardP2WSHStackItemSize+1),
+			{OP_TRUE},
+		},
+		prevPkScript: p2wshScript([]byte{OP_TRUE}),
+		errCode:      ErrNonStandardWitness,
+	}}
+
+	for _, test := range tests {
+		tx, fetcher := policyTestTx(test.sigScript, test.witness,
+			test.prevPkScript)
+		err := CheckInputsStandard(tx, fetcher)
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if !test.valid && !IsErrorCode(err, test.errCode) {
+			t.Errorf("%s: unexpected error - got %v, want %v",
+				test.name, err, test.errCode)
+		}
+	}
+
+	// Ensure an unknown previous output is reported.
+	tx, _ := policyTestTx(nil, nil, nil)
+	err := CheckInputsStandard(tx, NewMultiPrevOutFetcher(nil))
+	if !IsErrorCode(err, ErrMissingPrevOut) {
+		t.Errorf("unexpected error - got %v, want %v", err,
+			ErrMissingPrevOut)
+	}
+}
+
+// TestCheckStandard ensures CheckStandard executes the input scripts with the
+// passed flags on top of the transaction and input checks.
+func TestCheckStandard(t *testing.T) {
+	t.Parallel()
+
+	redeemScript := []byte{OP_TRUE}
+	prevPkScript, _ := payToScriptHashScript(btcutil.Hash160(redeemScript))
+	cleanSigScript, _ := NewScriptBuilder().AddData(redeemScript).Script()
+	dirtySigScript, _ := NewScriptBuilder().AddOp(OP_TRUE).
+		AddData(redeemScript).Script()
+
+	tx, fetcher := policyTestTx(cleanSigScript, nil, prevPkScript)
+	if err := CheckStandard(tx, fetcher, StandardVerifyFlags); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tx, fetcher = policyTestTx(dirtySigScript, nil, prevPkScript)
+	err := CheckStandard(tx, fetcher, StandardVerifyFlags)
+	if !IsErrorCode(err, ErrCleanStack) {
+		t.Fatalf("unexpected error - got %v, want %v", err,
+			ErrCleanStack)
+	}
+
+	tx.Version = 3
+	err = CheckStandard(tx, fetcher, StandardVerifyFlags)
+	if !IsErrorCode(err, ErrNonStandardVersion) {
+		t.Fatalf("unexpected error - got %v, want %v", err,
+			ErrNonStandardVersion)
+	}
+}
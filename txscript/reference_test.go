@@ -329,7 +329,7 @@ type scriptWithInputVal struct {
 // parameter.
 func testScripts(t *testing.T, tests [][]interface{}, useSigCache bool) {
 	// Create a signature cache to use only if requested.
-	var sigCache *SigCache
+	var sigCache SignatureCache
 	if useSigCache {
 		sigCache = NewSigCache(10)
 	}
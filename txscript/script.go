@@ -88,6 +88,37 @@ func IsPayToWitnessScriptHash(script []byte) bool {
 	return isWitnessScriptHash(pops)
 }
 
+// payToAnchorProgram is the witness program of pay-to-anchor outputs, which
+// are version 1 witness programs.
+var payToAnchorProgram = []byte{0x4e, 0x73}
+
+// isPayToAnchor returns true if the passed script is a pay-to-anchor output,
+// false otherwise.
+func isPayToAnchor(pops []parsedOpcode) bool {
+	return len(pops) == 2 &&
+		pops[0].opcode.value == OP_1 &&
+		pops[1].opcode.value == OP_DATA_2 &&
+		bytes.Equal(pops[1].data, payToAnchorProgram)
+}
+
+// IsPayToAnchor returns true if the script is in the standard pay-to-anchor
+// (P2A) format, false otherwise.  Pay-to-anchor outputs may be spent by anyone
+// with an empty witness, which allows attaching a child transaction to bump
+// the fee of its parent.
+func IsPayToAnchor(script []byte) bool {
+	pops, err := parseScript(script)
+	if err != nil {
+		return false
+	}
+	return isPayToAnchor(pops)
+}
+
+// isWitnessUnknown returns true if the passed script is a witness program of a
+// version that has no defined semantics yet, false otherwise.
+func isWitnessUnknown(pops []parsedOpcode) bool {
+	return isWitnessProgram(pops) && pops[0].opcode.value != OP_0
+}
+
 // IsPayToWitnessPubKeyHash returns true if the is in the standard
 // pay-to-witness-pubkey-hash (P2WKH) format, false otherwise.
 func IsPayToWitnessPubKeyHash(script []byte) bool {
@@ -342,13 +373,10 @@ func removeOpcodeByData(pkscript []parsedOpcode, data []byte) []parsedOpcode {
 
 }
 
-// calcHashPrevOuts calculates a single hash of all the previous outputs
-// (txid:index) referenced within the passed transaction. This calculated hash
-// can be re-used when validating all inputs spending segwit outputs, with a
-// signature hash type of SigHashAll. This allows validation to re-use previous
-// hashing computation, reducing the complexity of validating SigHashAll inputs
-// from  O(N^2) to O(N).
-func calcHashPrevOuts(tx *wire.MsgTx) chainhash.Hash {
+// calcHashPrevOutsV1 calculates the single SHA256 hash of all the previous
+// outputs (txid:index) referenced within the passed transaction as defined by
+// BIP0341.  The BIP0143 variant is the SHA256 of this hash.
+func calcHashPrevOutsV1(tx *wire.MsgTx) chainhash.Hash {
 	var b bytes.Buffer
 	for _, in := range tx.TxIn {
 		// First write out the 32-byte transaction ID one of whose
@@ -362,7 +390,32 @@ func calcHashPrevOuts(tx *wire.MsgTx) chainhash.Hash {
 		b.Write(buf[:])
 	}
 
-	return chainhash.DoubleHashH(b.Bytes())
+	return chainhash.HashH(b.Bytes())
+}
+
+// calcHashPrevOuts calculates a single hash of all the previous outputs
+// (txid:index) referenced within the passed transaction. This calculated hash
+// can be re-used when validating all inputs spending segwit outputs, with a
+// signature hash type of SigHashAll. This allows validation to re-use previous
+// hashing computation, reducing the complexity of validating SigHashAll inputs
+// from  O(N^2) to O(N).
+func calcHashPrevOuts(tx *wire.MsgTx) chainhash.Hash {
+	hash := calcHashPrevOutsV1(tx)
+	return chainhash.HashH(hash[:])
+}
+
+// calcHashSequenceV1 computes the single SHA256 hash of the sequence numbers
+// of all inputs of the passed transaction as defined by BIP0341.  The BIP0143
+// variant is the SHA256 of this hash.
+func calcHashSequenceV1(tx *wire.MsgTx) chainhash.Hash {
+	var b bytes.Buffer
+	for _, in := range tx.TxIn {
+		var buf [4]byte
+		binary.LittleEndian.PutUint32(buf[:], in.Sequence)
+		b.Write(buf[:])
+	}
+
+	return chainhash.HashH(b.Bytes())
 }
 
 // calcHashSequence computes an aggregated hash of each of the sequence numbers
@@ -372,14 +425,20 @@ func calcHashPrevOuts(tx *wire.MsgTx) chainhash.Hash {
 // hashing computation, reducing the complexity of validating SigHashAll inputs
 // from O(N^2) to O(N).
 func calcHashSequence(tx *wire.MsgTx) chainhash.Hash {
+	hash := calcHashSequenceV1(tx)
+	return chainhash.HashH(hash[:])
+}
+
+// calcHashOutputsV1 computes the single SHA256 hash of all outputs created by
+// the transaction encoded using the wire format as defined by BIP0341.  The
+// BIP0143 variant is the SHA256 of this hash.
+func calcHashOutputsV1(tx *wire.MsgTx) chainhash.Hash {
 	var b bytes.Buffer
-	for _, in := range tx.TxIn {
-		var buf [4]byte
-		binary.LittleEndian.PutUint32(buf[:], in.Sequence)
-		b.Write(buf[:])
+	for _, out := range tx.TxOut {
+		wire.WriteTxOut(&b, 0, 0, out)
 	}
 
-	return chainhash.DoubleHashH(b.Bytes())
+	return chainhash.HashH(b.Bytes())
 }
 
 // calcHashOutputs computes a hash digest of all outputs created by the
@@ -388,12 +447,51 @@ func calcHashSequence(tx *wire.MsgTx) chainhash.Hash {
 // signatures using the SigHashAll sighash type. This allows computation to be
 // cached, reducing the total hashing complexity from O(N^2) to O(N).
 func calcHashOutputs(tx *wire.MsgTx) chainhash.Hash {
+	hash := calcHashOutputsV1(tx)
+	return chainhash.HashH(hash[:])
+}
+
+// fetchPrevOutputs returns the outputs referenced by all inputs of the passed
+// transaction using the passed fetcher.
+func fetchPrevOutputs(tx *wire.MsgTx,
+	fetcher PrevOutputFetcher) ([]*wire.TxOut, error) {
+
+	prevOuts := make([]*wire.TxOut, 0, len(tx.TxIn))
+	for i, in := range tx.TxIn {
+		prevOut := fetcher.FetchPrevOutput(in.PreviousOutPoint)
+		if prevOut == nil {
+			return nil, fmt.Errorf("unable to fetch output %v "+
+				"referenced by input %d", in.PreviousOutPoint, i)
+		}
+		prevOuts = append(prevOuts, prevOut)
+	}
+
+	return prevOuts, nil
+}
+
+// calcHashInputAmountsV1 computes the single SHA256 hash of the amounts of
+// the passed previous outputs as defined by BIP0341.
+func calcHashInputAmountsV1(prevOuts []*wire.TxOut) chainhash.Hash {
 	var b bytes.Buffer
-	for _, out := range tx.TxOut {
-		wire.WriteTxOut(&b, 0, 0, out)
+	for _, prevOut := range prevOuts {
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], uint64(prevOut.Value))
+		b.Write(buf[:])
 	}
 
-	return chainhash.DoubleHashH(b.Bytes())
+	return chainhash.HashH(b.Bytes())
+}
+
+// calcHashInputScriptsV1 computes the single SHA256 hash of the public key
+// scripts of the passed previous outputs, each prefixed by its length, as
+// defined by BIP0341.
+func calcHashInputScriptsV1(prevOuts []*wire.TxOut) chainhash.Hash {
+	var b bytes.Buffer
+	for _, prevOut := range prevOuts {
+		wire.WriteVarBytes(&b, 0, prevOut.PkScript)
+	}
+
+	return chainhash.HashH(b.Bytes())
 }
 
 // calcWitnessSignatureHash computes the sighash digest of a transaction's
@@ -526,6 +624,50 @@ func CalcWitnessSigHash(script []byte, sigHashes *TxSigHashes, hType SigHashType
 		amt)
 }
 
+// CalcTemplateHash computes the standard template hash of the passed
+// transaction for the input with the passed index as defined by BIP0119.  An
+// OP_CHECKTEMPLATEVERIFY checking the returned hash only allows the output it
+// locks to be spent by a transaction with the same version, lock time, input
+// scripts, sequences and outputs, where the output is spent by the input at
+// the same index.
+func CalcTemplateHash(tx *wire.MsgTx, idx int) chainhash.Hash {
+	var b bytes.Buffer
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], uint32(tx.Version))
+	b.Write(buf[:])
+	binary.LittleEndian.PutUint32(buf[:], tx.LockTime)
+	b.Write(buf[:])
+
+	// The signature scripts are only committed to when any of them is not
+	// empty, which is never the case for transactions spending segwit
+	// outputs only.
+	var scriptSigs bytes.Buffer
+	var hasScriptSig bool
+	for _, in := range tx.TxIn {
+		wire.WriteVarBytes(&scriptSigs, 0, in.SignatureScript)
+		hasScriptSig = hasScriptSig || len(in.SignatureScript) != 0
+	}
+	if hasScriptSig {
+		scriptSigsHash := chainhash.HashH(scriptSigs.Bytes())
+		b.Write(scriptSigsHash[:])
+	}
+
+	binary.LittleEndian.PutUint32(buf[:], uint32(len(tx.TxIn)))
+	b.Write(buf[:])
+	sequenceHash := calcHashSequenceV1(tx)
+	b.Write(sequenceHash[:])
+
+	binary.LittleEndian.PutUint32(buf[:], uint32(len(tx.TxOut)))
+	b.Write(buf[:])
+	outputsHash := calcHashOutputsV1(tx)
+	b.Write(outputsHash[:])
+
+	binary.LittleEndian.PutUint32(buf[:], uint32(idx))
+	b.Write(buf[:])
+
+	return chainhash.HashH(b.Bytes())
+}
+
 // shallowCopyTx creates a shallow copy of the transaction for use when
 // calculating the signature hash.  It is used over the Copy method on the
 // transaction itself since that is a deep copy and therefore does more work and
@@ -9,6 +9,32 @@ import (
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/decred/dcrd/lru"
+)
+
+// SignatureCache is the interface the script engine uses to look up and record
+// successfully verified signatures. Implementations must only ever report a
+// hit for the exact (sigHash, signature, public key) triplet that was added.
+//
+// Implementations must be safe for concurrent access since a single cache is
+// shared between block validation and mempool acceptance.
+type SignatureCache interface {
+	// Exists returns true if a previously added entry of 'sig' over
+	// 'sigHash' for public key 'pubKey' is found within the cache.
+	Exists(sigHash chainhash.Hash, sig *btcec.Signature,
+		pubKey *btcec.PublicKey) bool
+
+	// Add records that 'sig' is a valid signature over 'sigHash' for
+	// public key 'pubKey'.
+	Add(sigHash chainhash.Hash, sig *btcec.Signature,
+		pubKey *btcec.PublicKey)
+}
+
+// Ensure the provided cache implementations satisfy the SignatureCache
+// interface.
+var (
+	_ SignatureCache = (*SigCache)(nil)
+	_ SignatureCache = (*ShardedSigCache)(nil)
 )
 
 // sigCacheEntry represents an entry in the SigCache. Entries within the
@@ -53,9 +79,15 @@ func NewSigCache(maxEntries uint) *SigCache {
 // Exists returns true if an existing entry of 'sig' over 'sigHash' for public
 // key 'pubKey' is found within the SigCache. Otherwise, false is returned.
 //
+// A nil SigCache never contains any entries.
+//
 // NOTE: This function is safe for concurrent access. Readers won't be blocked
 // unless there exists a writer, adding an entry to the SigCache.
 func (s *SigCache) Exists(sigHash chainhash.Hash, sig *btcec.Signature, pubKey *btcec.PublicKey) bool {
+	if s == nil {
+		return false
+	}
+
 	s.RLock()
 	entry, ok := s.validSigs[sigHash]
 	s.RUnlock()
@@ -66,11 +98,15 @@ func (s *SigCache) Exists(sigHash chainhash.Hash, sig *btcec.Signature, pubKey *
 // Add adds an entry for a signature over 'sigHash' under public key 'pubKey'
 // to the signature cache. In the event that the SigCache is 'full', an
 // existing entry is randomly chosen to be evicted in order to make space for
-// the new entry.
+// the new entry.  Adding an entry to a nil SigCache has no effect.
 //
 // NOTE: This function is safe for concurrent access. Writers will block
 // simultaneous readers until function execution has concluded.
 func (s *SigCache) Add(sigHash chainhash.Hash, sig *btcec.Signature, pubKey *btcec.PublicKey) {
+	if s == nil {
+		return
+	}
+
 	s.Lock()
 	defer s.Unlock()
 
@@ -97,3 +133,104 @@ func (s *SigCache) Add(sigHash chainhash.Hash, sig *btcec.Signature, pubKey *btc
 	}
 	s.validSigs[sigHash] = sigCacheEntry{sig, pubKey}
 }
+
+// numSigCacheShards is the number of independent shards a ShardedSigCache
+// splits its entries across.  Each shard has its own lock, so this bounds the
+// amount of contention between concurrent script validation goroutines.
+const numSigCacheShards = 16
+
+// sigCacheKey is the key type used by ShardedSigCache.  It is the
+// concatenation of the signature hash, the compressed public key and the
+// 32-byte big-endian R and S values of the signature, which makes a lookup an
+// exact match on the full triplet rather than on the signature hash alone.
+type sigCacheKey [chainhash.HashSize + btcec.PubKeyBytesLenCompressed + 64]byte
+
+// newSigCacheKey returns the cache key for the passed signature triplet.
+func newSigCacheKey(sigHash *chainhash.Hash, sig *btcec.Signature,
+	pubKey *btcec.PublicKey) sigCacheKey {
+
+	var key sigCacheKey
+	offset := copy(key[:], sigHash[:])
+	offset += copy(key[offset:], pubKey.SerializeCompressed())
+
+	// Both R and S are guaranteed to be less than the group order for any
+	// signature that passed verification, so they always fit in 32 bytes.
+	rBytes, sBytes := sig.R.Bytes(), sig.S.Bytes()
+	copy(key[offset+32-len(rBytes):], rBytes)
+	copy(key[offset+64-len(sBytes):], sBytes)
+	return key
+}
+
+// ShardedSigCache implements the SignatureCache interface with a set of
+// least-recently-used caches.  Entries are distributed across the shards by
+// their signature hash so that concurrent lookups for different signatures
+// rarely contend on the same lock, and eviction always removes the entry that
+// has gone unused the longest instead of a random one.
+type ShardedSigCache struct {
+	shards  [numSigCacheShards]lru.Cache
+	enabled bool
+}
+
+// NewShardedSigCache creates and initializes a new instance of
+// ShardedSigCache which holds at most 'maxEntries' entries in total.  The
+// limit is split evenly across the shards, with every shard able to hold at
+// least a single entry.  A 'maxEntries' value of zero disables caching.
+func NewShardedSigCache(maxEntries uint) *ShardedSigCache {
+	if maxEntries == 0 {
+		return &ShardedSigCache{}
+	}
+
+	perShard := maxEntries / numSigCacheShards
+	if perShard == 0 {
+		perShard = 1
+	}
+
+	s := ShardedSigCache{enabled: true}
+	for i := range s.shards {
+		s.shards[i] = lru.NewCache(perShard)
+	}
+	return &s
+}
+
+// shard returns the shard responsible for the passed signature hash, or nil
+// if caching is disabled or the cache is nil.
+func (s *ShardedSigCache) shard(sigHash *chainhash.Hash) *lru.Cache {
+	if s == nil || !s.enabled {
+		return nil
+	}
+	return &s.shards[sigHash[0]%numSigCacheShards]
+}
+
+// Exists returns true if an existing entry of 'sig' over 'sigHash' for public
+// key 'pubKey' is found within the cache.  A hit marks the entry as the most
+// recently used one within its shard.
+//
+// This function is safe for concurrent access.
+//
+// This is part of the SignatureCache interface.
+func (s *ShardedSigCache) Exists(sigHash chainhash.Hash, sig *btcec.Signature,
+	pubKey *btcec.PublicKey) bool {
+
+	shard := s.shard(&sigHash)
+	if shard == nil {
+		return false
+	}
+	return shard.Contains(newSigCacheKey(&sigHash, sig, pubKey))
+}
+
+// Add adds an entry for a signature over 'sigHash' under public key 'pubKey'
+// to the cache, evicting the least recently used entry of the responsible
+// shard if it is full.
+//
+// This function is safe for concurrent access.
+//
+// This is part of the SignatureCache interface.
+func (s *ShardedSigCache) Add(sigHash chainhash.Hash, sig *btcec.Signature,
+	pubKey *btcec.PublicKey) {
+
+	shard := s.shard(&sigHash)
+	if shard == nil {
+		return
+	}
+	shard.Add(newSigCacheKey(&sigHash, sig, pubKey))
+}
@@ -138,3 +138,117 @@ func TestSigCacheAddMaxEntriesZeroOrNegative(t *testing.T) {
 			"been added", len(sigCache.validSigs))
 	}
 }
+
+// TestShardedSigCacheAddExists tests the ability to add, and later check the
+// existence of a signature triplet in the sharded signature cache, and that
+// lookups only succeed when the full triplet matches.
+func TestShardedSigCacheAddExists(t *testing.T) {
+	sigCache := NewShardedSigCache(200)
+
+	msg1, sig1, key1, err := genRandomSig()
+	if err != nil {
+		t.Fatalf("unable to generate random signature test data")
+	}
+	_, sig2, key2, err := genRandomSig()
+	if err != nil {
+		t.Fatalf("unable to generate random signature test data")
+	}
+
+	sigCache.Add(*msg1, sig1, key1)
+
+	// The previously added triplet should now be found within the cache
+	// when queried with separately parsed copies.
+	sig1Copy, _ := btcec.ParseSignature(sig1.Serialize(), btcec.S256())
+	key1Copy, _ := btcec.ParsePubKey(key1.SerializeCompressed(), btcec.S256())
+	if !sigCache.Exists(*msg1, sig1Copy, key1Copy) {
+		t.Fatalf("previously added item not found in signature cache")
+	}
+
+	// Swapping either the signature or the public key must not produce a
+	// hit even though the signature hash matches.
+	if sigCache.Exists(*msg1, sig2, key1) {
+		t.Fatalf("entry with mismatched signature found in cache")
+	}
+	if sigCache.Exists(*msg1, sig1, key2) {
+		t.Fatalf("entry with mismatched public key found in cache")
+	}
+}
+
+// TestShardedSigCacheEviction ensures the sharded signature cache evicts the
+// least recently used entry of a shard once it is full.
+func TestShardedSigCacheEviction(t *testing.T) {
+	// Create a cache with room for two entries per shard.
+	sigCache := NewShardedSigCache(2 * numSigCacheShards)
+
+	// Generate three triplets that map to the same shard.
+	type triplet struct {
+		msg *chainhash.Hash
+		sig *btcec.Signature
+		key *btcec.PublicKey
+	}
+	var entries []triplet
+	for len(entries) < 3 {
+		msg, sig, key, err := genRandomSig()
+		if err != nil {
+			t.Fatalf("unable to generate random signature test data")
+		}
+		if msg[0]%numSigCacheShards != 0 {
+			continue
+		}
+		entries = append(entries, triplet{msg, sig, key})
+	}
+
+	// Add the first two entries and touch the first one so the second
+	// becomes the least recently used.
+	sigCache.Add(*entries[0].msg, entries[0].sig, entries[0].key)
+	sigCache.Add(*entries[1].msg, entries[1].sig, entries[1].key)
+	if !sigCache.Exists(*entries[0].msg, entries[0].sig, entries[0].key) {
+		t.Fatalf("first entry not found in signature cache")
+	}
+
+	// Adding the third entry must evict the second.
+	sigCache.Add(*entries[2].msg, entries[2].sig, entries[2].key)
+	if sigCache.Exists(*entries[1].msg, entries[1].sig, entries[1].key) {
+		t.Fatalf("least recently used entry was not evicted")
+	}
+	for _, i := range []int{0, 2} {
+		e := entries[i]
+		if !sigCache.Exists(*e.msg, e.sig, e.key) {
+			t.Fatalf("entry %d not found in signature cache", i)
+		}
+	}
+}
+
+// TestShardedSigCacheMaxEntriesZero tests that a sharded signature cache
+// created with a max size of zero never stores any entries.
+func TestShardedSigCacheMaxEntriesZero(t *testing.T) {
+	sigCache := NewShardedSigCache(0)
+
+	msg1, sig1, key1, err := genRandomSig()
+	if err != nil {
+		t.Fatalf("unable to generate random signature test data")
+	}
+
+	sigCache.Add(*msg1, sig1, key1)
+	if sigCache.Exists(*msg1, sig1, key1) {
+		t.Fatalf("signature found in disabled sigcache")
+	}
+}
+
+// TestNilSigCaches ensures nil signature caches stored in the SignatureCache
+// interface, as done by callers passing through their optional caches, behave
+// like empty caches instead of panicking.
+func TestNilSigCaches(t *testing.T) {
+	msg, sig, key, err := genRandomSig()
+	if err != nil {
+		t.Fatalf("unable to generate random signature test data")
+	}
+
+	caches := []SignatureCache{(*SigCache)(nil), (*ShardedSigCache)(nil)}
+	for _, cache := range caches {
+		cache.Add(*msg, sig, key)
+		if cache.Exists(*msg, sig, key) {
+			t.Errorf("%T: signature found in nil cache", cache)
+		}
+	}
+}
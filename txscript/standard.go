@@ -59,6 +59,7 @@ const (
 	MultiSigTy                               // Multi signature.
 	NullDataTy                               // Empty data-only (provably prunable).
 	WitnessUnknownTy                         // Witness unknown
+	PayToAnchorTy                            // Pay to anchor.
 )
 
 // scriptClassToName houses the human-readable strings which describe each
@@ -73,6 +74,7 @@ var scriptClassToName = []string{
 	MultiSigTy:            "multisig",
 	NullDataTy:            "nulldata",
 	WitnessUnknownTy:      "witness_unknown",
+	PayToAnchorTy:         "anchor",
 }
 
 // String implements the Stringer interface by returning the name of
@@ -171,6 +173,10 @@ func typeOfScript(pops []parsedOpcode) ScriptClass {
 		return ScriptHashTy
 	} else if isWitnessScriptHash(pops) {
 		return WitnessV0ScriptHashTy
+	} else if isPayToAnchor(pops) {
+		return PayToAnchorTy
+	} else if isWitnessUnknown(pops) {
+		return WitnessUnknownTy
 	} else if isMultiSig(pops) {
 		return MultiSigTy
 	} else if isNullData(pops) {
@@ -481,6 +487,14 @@ func PayToAddrScript(addr btcutil.Address) ([]byte, error) {
 	return nil, scriptError(ErrUnsupportedAddress, str)
 }
 
+// PayToAnchorScript returns the pay-to-anchor output script, which may be
+// spent by anyone with an empty witness.  It is used to create anchor outputs
+// that allow bumping the fee of a transaction with a child transaction.
+func PayToAnchorScript() []byte {
+	return []byte{OP_1, OP_DATA_2, payToAnchorProgram[0],
+		payToAnchorProgram[1]}
+}
+
 // NullDataScript creates a provably-prunable script containing OP_RETURN
 // followed by the passed data.  An Error with the error code ErrTooMuchNullData
 // will be returned if the length of the passed data exceeds MaxDataCarrierSize.
@@ -634,6 +648,14 @@ func ExtractPkScriptAddrs(pkScript []byte, chainParams *chaincfg.Params) (Script
 		// Null data transactions have no addresses or required
 		// signatures.
 
+	case PayToAnchorTy:
+		// Pay-to-anchor outputs may be spent by anyone, so they have
+		// no addresses or required signatures.
+
+	case WitnessUnknownTy:
+		// Witness programs of unknown versions have no address
+		// encoding and no known signature requirements.
+
 	case NonStandardTy:
 		// Don't attempt to extract addresses or required signatures for
 		// nonstandard transactions.
@@ -327,6 +327,13 @@ func TestExtractPkScriptAddrs(t *testing.T) {
 			reqSigs: 1,
 			class:   MultiSigTy,
 		},
+		{
+			name:    "pay to anchor",
+			script:  PayToAnchorScript(),
+			addrs:   nil,
+			reqSigs: 0,
+			class:   PayToAnchorTy,
+		},
 		{
 			name:    "empty script",
 			script:  []byte{},
@@ -1045,6 +1052,32 @@ var scriptClassTests = []struct {
 		script: "0 DATA_32 0x9f96ade4b41d5433f4eda31e1738ec2b36f6e7d1420d94a6af99801a88f7f7ff",
 		class:  WitnessV0ScriptHashTy,
 	},
+	{
+		// A pay to anchor pk script.
+		name:   "Pay To Anchor",
+		script: "1 DATA_2 0x4e73",
+		class:  PayToAnchorTy,
+	},
+	{
+		// A version 1 witness program with a different 2-byte program
+		// is not an anchor.
+		name:   "almost Pay To Anchor",
+		script: "1 DATA_2 0x4e74",
+		class:  WitnessUnknownTy,
+	},
+	{
+		// A version 2 witness program with a 32-byte program.
+		name:   "Pay To Future Witness Version",
+		script: "2 DATA_32 0x9f96ade4b41d5433f4eda31e1738ec2b36f6e7d1420d94a6af99801a88f7f7ff",
+		class:  WitnessUnknownTy,
+	},
+	{
+		// A version 0 witness program of an undefined length is
+		// invalid rather than unknown.
+		name:   "version 0 witness program of wrong length",
+		script: "0 DATA_2 0x4e73",
+		class:  NonStandardTy,
+	},
 }
 
 // TestScriptClass ensures all the scripts in scriptClassTests have the expected
@@ -1113,6 +1146,16 @@ func TestStringifyClass(t *testing.T) {
 			class:    NullDataTy,
 			stringed: "nulldata",
 		},
+		{
+			name:     "witnessunknown",
+			class:    WitnessUnknownTy,
+			stringed: "witness_unknown",
+		},
+		{
+			name:     "paytoanchor",
+			class:    PayToAnchorTy,
+			stringed: "anchor",
+		},
 		{
 			name:     "broken",
 			class:    ScriptClass(255),
@@ -333,6 +333,29 @@ func BenchmarkDeserializeTxLarge(b *testing.B) {
 	}
 }
 
+// BenchmarkDeserializeTxLargeNoCopy performs a benchmark on how long it takes
+// to deserialize a very large transaction in zero-copy mode.
+func BenchmarkDeserializeTxLargeNoCopy(b *testing.B) {
+	// tx bb41a757f405890fb0f5856228e23b715702d714d59bf2b1feb70d8b2b4e3e08
+	// from the main block chain.
+	fi, err := os.Open("testdata/megatx.bin.bz2")
+	if err != nil {
+		b.Fatalf("Failed to read transaction data: %v", err)
+	}
+	defer fi.Close()
+	buf, err := ioutil.ReadAll(bzip2.NewReader(fi))
+	if err != nil {
+		b.Fatalf("Failed to read transaction data: %v", err)
+	}
+
+	var tx MsgTx
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tx.DeserializeNoCopy(buf)
+	}
+}
+
 // BenchmarkSerializeTx performs a benchmark on how long it takes to serialize
 // a transaction.
 func BenchmarkSerializeTx(b *testing.B) {
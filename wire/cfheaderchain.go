@@ -0,0 +1,223 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// CalcFilterHeader returns the committed filter header for a block given the
+// hash of its filter and the filter header of the previous block as defined by
+// BIP0157.  The previous filter header of the genesis block is the zero hash.
+func CalcFilterHeader(filterHash, prevHeader *chainhash.Hash) chainhash.Hash {
+	var b [chainhash.HashSize * 2]byte
+	copy(b[:], filterHash[:])
+	copy(b[chainhash.HashSize:], prevHeader[:])
+	return chainhash.DoubleHashH(b[:])
+}
+
+// FilterHeaderConflictError describes a filter header derived from a cfheaders
+// message which does not match the filter header already known for the same
+// height, either from a checkpoint or from a previously connected message.
+// It indicates that one of the peers which sent the conflicting data is
+// serving invalid filter headers.
+type FilterHeaderConflictError struct {
+	// Height is the height of the block the conflicting filter header is
+	// for.
+	Height int32
+
+	// Want is the filter header already known for the height and Got is
+	// the conflicting filter header derived from the message.
+	Want chainhash.Hash
+	Got  chainhash.Hash
+
+	// Checkpoint is true when Want is a filter header checkpoint.
+	Checkpoint bool
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e *FilterHeaderConflictError) Error() string {
+	source := "connected"
+	if e.Checkpoint {
+		source = "checkpoint"
+	}
+	return fmt.Sprintf("filter header %v at height %d conflicts with %s "+
+		"filter header %v", e.Got, e.Height, source, e.Want)
+}
+
+// FilterHeaderChain verifies the committed filter headers of a single filter
+// type delivered by cfheaders messages (MsgCFHeaders) and keeps the verified
+// chain of filter headers starting at the genesis block.  Filter headers at
+// checkpoint heights are verified against the filter header checkpoints, which
+// are usually obtained from a cfcheckpt message (MsgCFCheckpt), and headers
+// overlapping the verified chain must match it, which allows detecting peers
+// serving conflicting filter headers.
+//
+// It is safe for concurrent access.
+type FilterHeaderChain struct {
+	mtx         sync.RWMutex
+	filterType  FilterType
+	checkpoints []chainhash.Hash
+	headers     []chainhash.Hash
+	heights     map[chainhash.Hash]int32
+}
+
+// NewFilterHeaderChain returns an empty filter header chain for the passed
+// filter type which verifies filter headers against the passed checkpoints.
+// The checkpoint with index i is the filter header of the block at height
+// (i+1) * CFCheckptInterval as delivered by a cfcheckpt message.
+func NewFilterHeaderChain(filterType FilterType,
+	checkpoints []*chainhash.Hash) *FilterHeaderChain {
+
+	chain := FilterHeaderChain{
+		filterType:  filterType,
+		checkpoints: make([]chainhash.Hash, len(checkpoints)),
+		heights:     make(map[chainhash.Hash]int32),
+	}
+	for i, checkpoint := range checkpoints {
+		chain.checkpoints[i] = *checkpoint
+	}
+	return &chain
+}
+
+// checkpoint returns the filter header checkpoint for the passed height, if
+// any.
+//
+// This function MUST be called with the chain lock held (for reads).
+func (c *FilterHeaderChain) checkpoint(height int32) (*chainhash.Hash, bool) {
+	if height == 0 || height%CFCheckptInterval != 0 {
+		return nil, false
+	}
+	idx := int(height/CFCheckptInterval) - 1
+	if idx >= len(c.checkpoints) {
+		return nil, false
+	}
+	return &c.checkpoints[idx], true
+}
+
+// ConnectCFHeaders verifies the filter headers derived from the passed
+// cfheaders message and extends the chain with them.  The message must
+// continue from a filter header of the verified chain, or from the zero hash
+// to start at the genesis block.
+//
+// It returns the number of filter headers the chain was extended with, which
+// is zero when the message only covers filter headers that were already
+// verified.  A FilterHeaderConflictError is returned when a derived filter
+// header does not match a checkpoint or the verified chain, in which case the
+// chain is not modified.
+func (c *FilterHeaderChain) ConnectCFHeaders(msg *MsgCFHeaders) (int, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if msg.FilterType != c.filterType {
+		str := fmt.Sprintf("filter type %d does not match the chain "+
+			"filter type %d", msg.FilterType, c.filterType)
+		return 0, messageError("FilterHeaderChain.ConnectCFHeaders", str)
+	}
+
+	// Find the height the message starts at.  The zero hash is the
+	// previous filter header of the genesis block.
+	height := int32(0)
+	if msg.PrevFilterHeader != (chainhash.Hash{}) {
+		prevHeight, ok := c.heights[msg.PrevFilterHeader]
+		if !ok {
+			str := fmt.Sprintf("previous filter header %v is not "+
+				"in the chain", msg.PrevFilterHeader)
+			return 0, messageError("FilterHeaderChain."+
+				"ConnectCFHeaders", str)
+		}
+		height = prevHeight + 1
+	}
+
+	// Derive and verify all filter headers before modifying the chain.
+	prevHeader := msg.PrevFilterHeader
+	headers := make([]chainhash.Hash, 0, len(msg.FilterHashes))
+	for _, filterHash := range msg.FilterHashes {
+		header := CalcFilterHeader(filterHash, &prevHeader)
+		if int(height) < len(c.headers) {
+			if header != c.headers[height] {
+				return 0, &FilterHeaderConflictError{
+					Height: height,
+					Want:   c.headers[height],
+					Got:    header,
+				}
+			}
+		} else if checkpoint, ok := c.checkpoint(height); ok &&
+			header != *checkpoint {
+
+			return 0, &FilterHeaderConflictError{
+				Height:     height,
+				Want:       *checkpoint,
+				Got:        header,
+				Checkpoint: true,
+			}
+		} else {
+			headers = append(headers, header)
+		}
+
+		prevHeader = header
+		height++
+	}
+
+	for _, header := range headers {
+		c.heights[header] = int32(len(c.headers))
+		c.headers = append(c.headers, header)
+	}
+	return len(headers), nil
+}
+
+// Tip returns the filter header of the last block in the verified chain along
+// with its height.  The height is -1 and the header is the zero hash when the
+// chain is empty.
+func (c *FilterHeaderChain) Tip() (chainhash.Hash, int32) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	if len(c.headers) == 0 {
+		return chainhash.Hash{}, -1
+	}
+	return c.headers[len(c.headers)-1], int32(len(c.headers) - 1)
+}
+
+// FilterHeader returns the verified filter header of the block at the passed
+// height.
+func (c *FilterHeaderChain) FilterHeader(height int32) (*chainhash.Hash, error) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	if height < 0 || int(height) >= len(c.headers) {
+		str := fmt.Sprintf("no filter header at height %d", height)
+		return nil, messageError("FilterHeaderChain.FilterHeader", str)
+	}
+	header := c.headers[height]
+	return &header, nil
+}
+
+// FilterHeaders returns the verified filter headers of the blocks from the
+// passed start height up to and including the passed end height.
+func (c *FilterHeaderChain) FilterHeaders(startHeight,
+	endHeight int32) ([]*chainhash.Hash, error) {
+
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	if startHeight < 0 || startHeight > endHeight ||
+		int(endHeight) >= len(c.headers) {
+
+		str := fmt.Sprintf("no filter headers for heights %d to %d",
+			startHeight, endHeight)
+		return nil, messageError("FilterHeaderChain.FilterHeaders", str)
+	}
+
+	headers := make([]*chainhash.Hash, 0, endHeight-startHeight+1)
+	for i := startHeight; i <= endHeight; i++ {
+		header := c.headers[i]
+		headers = append(headers, &header)
+	}
+	return headers, nil
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// testCFHeaders returns a cfheaders message with the filter hashes of the
+// blocks from the passed start height up to but excluding the passed end
+// height along with the resulting filter headers.  The filter hash of each
+// block is derived from its height and the passed seed.
+func testCFHeaders(start, end int, prevHeader chainhash.Hash,
+	seed byte) (*MsgCFHeaders, []chainhash.Hash) {
+
+	msg := &MsgCFHeaders{
+		FilterType:       GCSFilterRegular,
+		PrevFilterHeader: prevHeader,
+	}
+	var headers []chainhash.Hash
+	for height := start; height < end; height++ {
+		filterHash := chainhash.DoubleHashH([]byte{seed, byte(height),
+			byte(height >> 8)})
+		msg.AddCFHash(&filterHash)
+		prevHeader = CalcFilterHeader(&filterHash, &prevHeader)
+		headers = append(headers, prevHeader)
+	}
+	return msg, headers
+}
+
+// TestFilterHeaderChain ensures the filter header chain connects and verifies
+// cfheaders messages as expected.
+func TestFilterHeaderChain(t *testing.T) {
+	msg1, headers1 := testCFHeaders(0, 1000, chainhash.Hash{}, 0)
+	msg2, headers2 := testCFHeaders(1000, 2000, headers1[999], 0)
+	checkpoint := headers2[0]
+
+	chain := NewFilterHeaderChain(GCSFilterRegular,
+		[]*chainhash.Hash{&checkpoint})
+	if _, height := chain.Tip(); height != -1 {
+		t.Fatalf("unexpected height of empty chain: %d", height)
+	}
+
+	// A message which doesn't continue the chain must be rejected.
+	if _, err := chain.ConnectCFHeaders(msg2); err == nil {
+		t.Fatal("connected message not continuing the chain")
+	}
+
+	n, err := chain.ConnectCFHeaders(msg1)
+	if err != nil || n != 1000 {
+		t.Fatalf("unexpected result connecting headers: %d, %v", n, err)
+	}
+
+	// Headers conflicting with the checkpoint must be rejected without
+	// modifying the chain.
+	badMsg, _ := testCFHeaders(1000, 2000, headers1[999], 1)
+	_, err = chain.ConnectCFHeaders(badMsg)
+	conflict, ok := err.(*FilterHeaderConflictError)
+	if !ok || !conflict.Checkpoint || conflict.Height != 1000 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tip, height := chain.Tip(); height != 999 || tip != headers1[999] {
+		t.Fatalf("unexpected tip %v at height %d", tip, height)
+	}
+
+	n, err = chain.ConnectCFHeaders(msg2)
+	if err != nil || n != 1000 {
+		t.Fatalf("unexpected result connecting headers: %d, %v", n, err)
+	}
+
+	// Headers overlapping the verified chain must match it.  A matching
+	// message extending the chain is connected partially.
+	overlapMsg, overlapHeaders := testCFHeaders(1500, 2500,
+		headers2[499], 0)
+	n, err = chain.ConnectCFHeaders(overlapMsg)
+	if err != nil || n != 500 {
+		t.Fatalf("unexpected result connecting headers: %d, %v", n, err)
+	}
+	conflictMsg, _ := testCFHeaders(1500, 1600, headers2[499], 2)
+	_, err = chain.ConnectCFHeaders(conflictMsg)
+	conflict, ok = err.(*FilterHeaderConflictError)
+	if !ok || conflict.Checkpoint || conflict.Height != 1500 ||
+		conflict.Want != headers2[500] {
+
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tip, height := chain.Tip()
+	if height != 2499 || tip != overlapHeaders[999] {
+		t.Fatalf("unexpected tip %v at height %d", tip, height)
+	}
+	header, err := chain.FilterHeader(1000)
+	if err != nil || *header != checkpoint {
+		t.Fatalf("unexpected filter header: %v, %v", header, err)
+	}
+	if _, err := chain.FilterHeader(2500); err == nil {
+		t.Fatal("got filter header beyond the tip")
+	}
+	headers, err := chain.FilterHeaders(998, 1001)
+	if err != nil || len(headers) != 4 || *headers[0] != headers1[998] ||
+		*headers[3] != headers2[1] {
+
+		t.Fatalf("unexpected filter headers: %v, %v", headers, err)
+	}
+
+	// Messages for other filter types must be rejected.
+	msg1.FilterType = GCSFilterRegular + 1
+	if _, err := chain.ConnectCFHeaders(msg1); err == nil {
+		t.Fatal("connected message with different filter type")
+	}
+}
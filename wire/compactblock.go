@@ -0,0 +1,212 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+)
+
+// CompactBlockTxSource is invoked by a CompactBlockReconstructor to look up
+// the transactions available to fill in a compact block, typically those in
+// the mempool.  It must call yield for each available transaction and stop
+// as soon as yield returns false.
+type CompactBlockTxSource func(yield func(tx *MsgTx) bool)
+
+// CompactBlockReconstructor reconstructs full blocks from compact blocks as
+// defined by BIP0152.  The transactions identified by short ids are looked up
+// by matching them against the short ids of the transactions provided by a
+// CompactBlockTxSource.  Transactions which can't be found that way must be
+// requested from the peer with a getblocktxn message and then be passed to
+// FillMissing.
+//
+// Short ids are not collision resistant, so the merkle root of a
+// reconstructed block must be checked by the caller.  Should it not match,
+// the full block has to be requested instead.
+type CompactBlockReconstructor struct {
+	header  BlockHeader
+	version uint64
+	k0, k1  uint64
+
+	// txns houses the transactions of the block.  Entries which are nil
+	// have not been found yet.
+	txns []*MsgTx
+
+	// slots maps the short ids of the compact block to the index of the
+	// respective transaction in the block.
+	slots map[uint64]int
+
+	// collided houses the indexes of the transactions for which more than
+	// one transaction with the same short id was provided.
+	collided map[int]struct{}
+
+	// missing is the number of transactions which have not been found.
+	missing int
+}
+
+// NewCompactBlockReconstructor returns a reconstructor for the passed compact
+// block using the given compact block version, which determines how short
+// ids are computed.  An error is returned when the compact block is malformed
+// or contains duplicate short ids, in which case the full block should be
+// requested instead.
+func NewCompactBlockReconstructor(msg *MsgCmpctBlock,
+	version uint64) (*CompactBlockReconstructor, error) {
+
+	total := msg.TxCount()
+	if total == 0 {
+		str := "compact block does not contain any transactions"
+		return nil, messageError("NewCompactBlockReconstructor", str)
+	}
+	if total > maxTxPerBlock {
+		str := fmt.Sprintf("too many transactions to fit into a block "+
+			"[count %d, max %d]", total, maxTxPerBlock)
+		return nil, messageError("NewCompactBlockReconstructor", str)
+	}
+
+	k0, k1 := msg.ShortIDKeys()
+	r := &CompactBlockReconstructor{
+		header:   msg.Header,
+		version:  version,
+		k0:       k0,
+		k1:       k1,
+		txns:     make([]*MsgTx, total),
+		slots:    make(map[uint64]int, len(msg.ShortIDs)),
+		collided: make(map[int]struct{}),
+		missing:  len(msg.ShortIDs),
+	}
+
+	// Place the prefilled transactions at their indexes.
+	nextIndex := 0
+	for _, ptx := range msg.PrefilledTxns {
+		index := int(ptx.Index)
+		if index < nextIndex || index >= total || ptx.Tx == nil {
+			str := fmt.Sprintf("invalid prefilled transaction at "+
+				"index %d", ptx.Index)
+			return nil, messageError("NewCompactBlockReconstructor",
+				str)
+		}
+		r.txns[index] = ptx.Tx
+		nextIndex = index + 1
+	}
+
+	// The transactions identified by short ids fill the remaining indexes
+	// in order.
+	index := 0
+	for _, shortID := range msg.ShortIDs {
+		for r.txns[index] != nil {
+			index++
+		}
+		if _, ok := r.slots[shortID]; ok {
+			str := fmt.Sprintf("duplicate short id %012x", shortID)
+			return nil, messageError("NewCompactBlockReconstructor",
+				str)
+		}
+		r.slots[shortID] = index
+		index++
+	}
+
+	return r, nil
+}
+
+// ShortID returns the short id of the passed transaction for the compact
+// block being reconstructed.
+func (r *CompactBlockReconstructor) ShortID(tx *MsgTx) uint64 {
+	hash := cmpctTxHash(tx, r.version)
+	return CmpctShortID(r.k0, r.k1, &hash)
+}
+
+// Reconstruct fills in the transactions of the compact block from the passed
+// source.  It returns the full block when all transactions were found.
+// Otherwise, it returns a getblocktxn message requesting the missing
+// transactions, which are then to be passed to FillMissing.
+func (r *CompactBlockReconstructor) Reconstruct(
+	source CompactBlockTxSource) (*MsgBlock, *MsgGetBlockTxn) {
+
+	if r.missing > 0 && source != nil {
+		source(func(tx *MsgTx) bool {
+			r.match(tx)
+			return r.missing > 0
+		})
+	}
+
+	if r.missing == 0 {
+		return r.block(), nil
+	}
+
+	blockHash := r.header.BlockHash()
+	indexes := make([]uint32, 0, r.missing)
+	for i, tx := range r.txns {
+		if tx == nil {
+			indexes = append(indexes, uint32(i))
+		}
+	}
+	return nil, NewMsgGetBlockTxn(&blockHash, indexes)
+}
+
+// match places the passed transaction into the block if its short id belongs
+// to a transaction of the block.  When two different transactions share the
+// short id of a transaction in the block, the transaction is considered
+// missing so it is requested from the peer.
+func (r *CompactBlockReconstructor) match(tx *MsgTx) {
+	index, ok := r.slots[r.ShortID(tx)]
+	if !ok {
+		return
+	}
+	if _, ok := r.collided[index]; ok {
+		return
+	}
+
+	existing := r.txns[index]
+	switch {
+	case existing == nil:
+		r.txns[index] = tx
+		r.missing--
+
+	case existing != tx && cmpctTxHash(existing, r.version) !=
+		cmpctTxHash(tx, r.version):
+
+		r.txns[index] = nil
+		r.collided[index] = struct{}{}
+		r.missing++
+	}
+}
+
+// FillMissing completes the block with the transactions of a blocktxn message
+// sent in response to the getblocktxn message returned by Reconstruct and
+// returns the full block.
+func (r *CompactBlockReconstructor) FillMissing(
+	msg *MsgBlockTxn) (*MsgBlock, error) {
+
+	if msg.BlockHash != r.header.BlockHash() {
+		str := fmt.Sprintf("blocktxn message for unexpected block %v",
+			msg.BlockHash)
+		return nil, messageError("CompactBlockReconstructor.FillMissing",
+			str)
+	}
+	if len(msg.Transactions) != r.missing {
+		str := fmt.Sprintf("blocktxn message contains %d transactions, "+
+			"but %d are missing", len(msg.Transactions), r.missing)
+		return nil, messageError("CompactBlockReconstructor.FillMissing",
+			str)
+	}
+
+	next := 0
+	for i, tx := range r.txns {
+		if tx == nil {
+			r.txns[i] = msg.Transactions[next]
+			next++
+		}
+	}
+	r.missing = 0
+	return r.block(), nil
+}
+
+// block returns the reconstructed block.  It must only be called once all
+// transactions have been found.
+func (r *CompactBlockReconstructor) block() *MsgBlock {
+	block := NewMsgBlock(&r.header)
+	block.Transactions = make([]*MsgTx, len(r.txns))
+	copy(block.Transactions, r.txns)
+	return block
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// cmpctTestBlock returns a block with a coinbase and several other
+// transactions for use in the compact block tests.
+func cmpctTestBlock() *MsgBlock {
+	tx := multiTx.Copy()
+	tx.LockTime = 1
+
+	block := NewMsgBlock(&blockOne.Header)
+	block.AddTransaction(blockOne.Transactions[0])
+	block.AddTransaction(multiTx)
+	block.AddTransaction(multiWitnessTx)
+	block.AddTransaction(tx)
+	return block
+}
+
+// txSource returns a CompactBlockTxSource which provides the passed
+// transactions.
+func txSource(txns ...*MsgTx) CompactBlockTxSource {
+	return func(yield func(tx *MsgTx) bool) {
+		for _, tx := range txns {
+			if !yield(tx) {
+				return
+			}
+		}
+	}
+}
+
+// serializeBlock returns the serialized passed block.
+func serializeBlock(t *testing.T, block *MsgBlock) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := block.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestCompactBlockReconstructor ensures blocks are reconstructed from compact
+// blocks for both compact block versions, whether all transactions are
+// available or some must be requested from the peer.
+func TestCompactBlockReconstructor(t *testing.T) {
+	block := cmpctTestBlock()
+	want := serializeBlock(t, block)
+	unrelated := multiTx.Copy()
+	unrelated.LockTime = 2
+
+	tests := []struct {
+		name        string
+		version     uint64
+		available   []*MsgTx
+		wantMissing []uint32
+	}{{
+		name:    "v1 all available",
+		version: CmpctBlockVersion1,
+		available: []*MsgTx{
+			unrelated, block.Transactions[3],
+			block.Transactions[1], block.Transactions[2],
+		},
+	}, {
+		name:    "v2 all available",
+		version: CmpctBlockVersion2,
+		available: []*MsgTx{
+			block.Transactions[2], block.Transactions[1],
+			block.Transactions[3],
+		},
+	}, {
+		name:        "v2 some missing",
+		version:     CmpctBlockVersion2,
+		available:   []*MsgTx{unrelated, block.Transactions[2]},
+		wantMissing: []uint32{1, 3},
+	}, {
+		name:        "v1 none available",
+		version:     CmpctBlockVersion1,
+		wantMissing: []uint32{1, 2, 3},
+	}}
+
+	for _, test := range tests {
+		// Send the compact block over the wire to ensure the encoding
+		// round trips.
+		cmpct := NewMsgCmpctBlockFromBlock(block, 0x1122334455667788,
+			test.version)
+		var buf bytes.Buffer
+		err := cmpct.BtcEncode(&buf, CompactBlocksVersion,
+			WitnessEncoding)
+		if err != nil {
+			t.Fatalf("%s: BtcEncode: %v", test.name, err)
+		}
+		var decoded MsgCmpctBlock
+		err = decoded.BtcDecode(&buf, CompactBlocksVersion,
+			WitnessEncoding)
+		if err != nil {
+			t.Fatalf("%s: BtcDecode: %v", test.name, err)
+		}
+
+		r, err := NewCompactBlockReconstructor(&decoded, test.version)
+		if err != nil {
+			t.Fatalf("%s: NewCompactBlockReconstructor: %v",
+				test.name, err)
+		}
+		got, getBlockTxn := r.Reconstruct(txSource(test.available...))
+		if len(test.wantMissing) == 0 {
+			if got == nil {
+				t.Fatalf("%s: block not reconstructed, missing %v",
+					test.name, getBlockTxn.Indexes)
+			}
+			if !bytes.Equal(serializeBlock(t, got), want) {
+				t.Fatalf("%s: reconstructed block mismatch",
+					test.name)
+			}
+			continue
+		}
+
+		if got != nil {
+			t.Fatalf("%s: unexpected reconstructed block", test.name)
+		}
+		if !reflect.DeepEqual(getBlockTxn.Indexes, test.wantMissing) {
+			t.Fatalf("%s: missing indexes %v, want %v", test.name,
+				getBlockTxn.Indexes, test.wantMissing)
+		}
+		if getBlockTxn.BlockHash != block.BlockHash() {
+			t.Fatalf("%s: getblocktxn for wrong block %v", test.name,
+				getBlockTxn.BlockHash)
+		}
+
+		// Respond with the missing transactions.
+		blockTxn := NewMsgBlockTxn(&getBlockTxn.BlockHash)
+		for _, index := range getBlockTxn.Indexes {
+			blockTxn.Transactions = append(blockTxn.Transactions,
+				block.Transactions[index])
+		}
+		got, err = r.FillMissing(blockTxn)
+		if err != nil {
+			t.Fatalf("%s: FillMissing: %v", test.name, err)
+		}
+		if !bytes.Equal(serializeBlock(t, got), want) {
+			t.Fatalf("%s: reconstructed block mismatch", test.name)
+		}
+	}
+}
+
+// TestCompactBlockReconstructorErrors ensures malformed compact blocks and
+// unexpected blocktxn messages are rejected.
+func TestCompactBlockReconstructorErrors(t *testing.T) {
+	block := cmpctTestBlock()
+	cmpct := NewMsgCmpctBlockFromBlock(block, 0, CmpctBlockVersion2)
+
+	dupShortIDs := *cmpct
+	dupShortIDs.ShortIDs = []uint64{1, 2, 1}
+
+	badPrefilled := *cmpct
+	badPrefilled.PrefilledTxns = []*PrefilledTx{{
+		Index: 4,
+		Tx:    block.Transactions[0],
+	}}
+
+	tests := []struct {
+		name  string
+		cmpct *MsgCmpctBlock
+	}{
+		{"no transactions", NewMsgCmpctBlock(&block.Header, 0)},
+		{"duplicate short ids", &dupShortIDs},
+		{"prefilled index out of range", &badPrefilled},
+	}
+	for _, test := range tests {
+		_, err := NewCompactBlockReconstructor(test.cmpct,
+			CmpctBlockVersion2)
+		if _, ok := err.(*MessageError); !ok {
+			t.Errorf("%s: expected MessageError, got %v", test.name,
+				err)
+		}
+	}
+
+	r, err := NewCompactBlockReconstructor(cmpct, CmpctBlockVersion2)
+	if err != nil {
+		t.Fatalf("NewCompactBlockReconstructor: %v", err)
+	}
+	_, getBlockTxn := r.Reconstruct(nil)
+
+	wrongBlock := NewMsgBlockTxn(&blockOne.Header.PrevBlock)
+	if _, err := r.FillMissing(wrongBlock); err == nil {
+		t.Fatal("FillMissing: expected error for wrong block")
+	}
+	tooFew := NewMsgBlockTxn(&getBlockTxn.BlockHash)
+	tooFew.Transactions = block.Transactions[1:2]
+	if _, err := r.FillMissing(tooFew); err == nil {
+		t.Fatal("FillMissing: expected error for too few transactions")
+	}
+}
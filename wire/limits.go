@@ -0,0 +1,141 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// MessageLimits houses limits which are applied to messages read from the
+// wire in addition to the limits imposed by the protocol.  They allow callers
+// to restrict the resources a remote peer can make them spend below what the
+// protocol permits.
+//
+// A zero value for any of the fields means the respective limit is not
+// restricted beyond the protocol limits, so the zero value of the struct, as
+// well as a nil pointer, imposes no additional limits.  Limits larger than the
+// respective protocol limit have no effect.
+type MessageLimits struct {
+	// MaxPayload is the maximum payload size of any message.
+	MaxPayload uint32
+
+	// MaxPayloadByCommand is the maximum payload size of messages with
+	// the given command.
+	MaxPayloadByCommand map[string]uint32
+
+	// MaxInvVects is the maximum number of inventory vectors in inv,
+	// getdata, and notfound messages.
+	MaxInvVects uint32
+
+	// MaxBlockHeaders is the maximum number of block headers in headers
+	// messages.
+	MaxBlockHeaders uint32
+
+	// MaxAddresses is the maximum number of addresses in addr and addrv2
+	// messages.
+	MaxAddresses uint32
+
+	// MaxPayloadPreAlloc is the maximum number of bytes allocated for a
+	// payload before it is received.  Larger payloads are read in chunks
+	// into a buffer which grows as data arrives, so a peer can't make the
+	// reader allocate more memory than it actually sends by announcing a
+	// large payload in the message header.
+	MaxPayloadPreAlloc uint32
+}
+
+// maxPayload returns the maximum payload size allowed for a message with the
+// passed command given its protocol limit.
+func (l *MessageLimits) maxPayload(command string, protocolMax uint32) uint32 {
+	max := protocolMax
+	if l == nil {
+		return max
+	}
+	if l.MaxPayload != 0 && l.MaxPayload < max {
+		max = l.MaxPayload
+	}
+	if cmdMax, ok := l.MaxPayloadByCommand[command]; ok && cmdMax < max {
+		max = cmdMax
+	}
+	return max
+}
+
+// maxCount returns the maximum number of entries allowed in messages with the
+// passed command and whether the command is subject to a count limit at all.
+func (l *MessageLimits) maxCount(command string) (uint32, bool) {
+	if l == nil {
+		return 0, false
+	}
+
+	var max uint32
+	switch command {
+	case CmdInv, CmdGetData, CmdNotFound:
+		max = l.MaxInvVects
+	case CmdHeaders:
+		max = l.MaxBlockHeaders
+	case CmdAddr, CmdAddrV2:
+		max = l.MaxAddresses
+	}
+	return max, max != 0
+}
+
+// CheckPayload returns an error when the passed payload of a message with the
+// given command violates the limits.  The protocol version is needed to parse
+// the number of entries in the payload.
+//
+// The payload is only inspected as far as necessary to determine the number of
+// entries, so this is cheap to do before fully decoding the message.  This
+// function is safe to call on a nil receiver.
+func (l *MessageLimits) CheckPayload(command string, payload []byte,
+	pver uint32) error {
+
+	payloadLen := uint32(len(payload))
+	if max := l.maxPayload(command, MaxMessagePayload); payloadLen > max {
+		str := fmt.Sprintf("payload exceeds configured limit - %d "+
+			"bytes, but max payload size for messages of type "+
+			"[%v] is %d", payloadLen, command, max)
+		return messageError("CheckPayload", str)
+	}
+
+	max, ok := l.maxCount(command)
+	if !ok {
+		return nil
+	}
+
+	// All messages subject to count limits start with the entry count.
+	// Malformed payloads are left for the message decoder to reject.
+	count, err := ReadVarInt(bytes.NewReader(payload), pver)
+	if err != nil {
+		return nil
+	}
+	if count > uint64(max) {
+		str := fmt.Sprintf("too many entries in message of type [%v] "+
+			"- %d, but configured max is %d", command, count, max)
+		return messageError("CheckPayload", str)
+	}
+	return nil
+}
+
+// readPayload reads a payload of the given length from r.  At most the
+// configured number of bytes is allocated up front.
+func (l *MessageLimits) readPayload(r io.Reader, length uint32) ([]byte, int,
+	error) {
+
+	if l == nil || l.MaxPayloadPreAlloc == 0 ||
+		length <= l.MaxPayloadPreAlloc {
+
+		payload := make([]byte, length)
+		n, err := io.ReadFull(r, payload)
+		return payload, n, err
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, l.MaxPayloadPreAlloc))
+	n, err := io.CopyN(buf, r, int64(length))
+	if err == io.EOF && n > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return buf.Bytes(), int(n), err
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// TestReadMessageWithLimits ensures configured message limits are enforced
+// when reading messages and that messages within the limits are unaffected.
+func TestReadMessageWithLimits(t *testing.T) {
+	pver := ProtocolVersion
+	btcnet := MainNet
+
+	invMsg := func(n int) Message {
+		msg := NewMsgInv()
+		for i := 0; i < n; i++ {
+			hash := chainhash.Hash{byte(i)}
+			msg.AddInvVect(NewInvVect(InvTypeTx, &hash))
+		}
+		return msg
+	}
+	headersMsg := func(n int) Message {
+		msg := NewMsgHeaders()
+		for i := 0; i < n; i++ {
+			msg.AddBlockHeader(&blockOne.Header)
+		}
+		return msg
+	}
+
+	tests := []struct {
+		name    string
+		msg     Message
+		limits  *MessageLimits
+		wantErr bool
+	}{{
+		name:   "nil limits",
+		msg:    invMsg(10),
+		limits: nil,
+	}, {
+		name:   "zero limits",
+		msg:    invMsg(10),
+		limits: &MessageLimits{},
+	}, {
+		name:    "inv count at limit",
+		msg:     invMsg(10),
+		limits:  &MessageLimits{MaxInvVects: 10},
+		wantErr: false,
+	}, {
+		name:    "inv count over limit",
+		msg:     invMsg(11),
+		limits:  &MessageLimits{MaxInvVects: 10},
+		wantErr: true,
+	}, {
+		name:    "headers count over limit",
+		msg:     headersMsg(3),
+		limits:  &MessageLimits{MaxBlockHeaders: 2},
+		wantErr: true,
+	}, {
+		name:    "count limit of other message",
+		msg:     headersMsg(3),
+		limits:  &MessageLimits{MaxInvVects: 2},
+		wantErr: false,
+	}, {
+		name:    "payload over global limit",
+		msg:     invMsg(10),
+		limits:  &MessageLimits{MaxPayload: 100},
+		wantErr: true,
+	}, {
+		name: "payload over command limit",
+		msg:  invMsg(10),
+		limits: &MessageLimits{
+			MaxPayloadByCommand: map[string]uint32{CmdInv: 100},
+		},
+		wantErr: true,
+	}, {
+		name: "command limit of other message",
+		msg:  invMsg(10),
+		limits: &MessageLimits{
+			MaxPayloadByCommand: map[string]uint32{CmdHeaders: 100},
+		},
+		wantErr: false,
+	}, {
+		name:   "payload read without full pre-allocation",
+		msg:    invMsg(100),
+		limits: &MessageLimits{MaxPayloadPreAlloc: 64},
+	}}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := WriteMessage(&buf, test.msg, pver, btcnet); err != nil {
+			t.Errorf("%s: unable to write message: %v", test.name, err)
+			continue
+		}
+		wantBytes := buf.Len()
+
+		n, msg, _, err := ReadMessageWithLimitsN(&buf, pver, btcnet,
+			BaseEncoding, test.limits)
+		if test.wantErr {
+			if _, ok := err.(*MessageError); !ok {
+				t.Errorf("%s: expected MessageError, got %v (%T)",
+					test.name, err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if n != wantBytes {
+			t.Errorf("%s: read %d bytes, want %d", test.name, n,
+				wantBytes)
+		}
+		if !reflect.DeepEqual(msg, test.msg) {
+			t.Errorf("%s: mismatched message -- got %v, want %v",
+				test.name, msg, test.msg)
+		}
+	}
+}
+
+// TestReadMessageWithLimitsShortRead ensures a truncated payload which is read
+// without full pre-allocation is reported as an unexpected EOF.
+func TestReadMessageWithLimitsShortRead(t *testing.T) {
+	var buf bytes.Buffer
+	msg := NewMsgInv()
+	for i := 0; i < 100; i++ {
+		hash := chainhash.Hash{byte(i)}
+		msg.AddInvVect(NewInvVect(InvTypeTx, &hash))
+	}
+	if err := WriteMessage(&buf, msg, ProtocolVersion, MainNet); err != nil {
+		t.Fatalf("unable to write message: %v", err)
+	}
+	truncated := bytes.NewReader(buf.Bytes()[:buf.Len()-1])
+
+	limits := &MessageLimits{MaxPayloadPreAlloc: 64}
+	_, _, _, err := ReadMessageWithLimitsN(truncated, ProtocolVersion,
+		MainNet, BaseEncoding, limits)
+	if err != io.ErrUnexpectedEOF {
+		t.Fatalf("unexpected error -- got %v, want %v", err,
+			io.ErrUnexpectedEOF)
+	}
+}
@@ -58,6 +58,20 @@ const (
 	CmdCFHeaders    = "cfheaders"
 	CmdCFCheckpt    = "cfcheckpt"
 	CmdSendAddrV2   = "sendaddrv2"
+	CmdAddrV2       = "addrv2"
+	CmdSendPackages = "sendpackages"
+	CmdAncPkgInfo   = "ancpkginfo"
+	CmdGetPkgTxns   = "getpkgtxns"
+	CmdPkgTxns      = "pkgtxns"
+	CmdSendTxRcncl  = "sendtxrcncl"
+	CmdReqRecon     = "reqrecon"
+	CmdSketch       = "sketch"
+	CmdReqSketchExt = "reqsketchext"
+	CmdReconcilDiff = "reconcildiff"
+	CmdSendCmpct    = "sendcmpct"
+	CmdCmpctBlock   = "cmpctblock"
+	CmdGetBlockTxn  = "getblocktxn"
+	CmdBlockTxn     = "blocktxn"
 )
 
 // MessageEncoding represents the wire message encoding format to be used.
@@ -109,6 +123,9 @@ func makeEmptyMessage(command string) (Message, error) {
 	case CmdAddr:
 		msg = &MsgAddr{}
 
+	case CmdAddrV2:
+		msg = &MsgAddrV2{}
+
 	case CmdGetBlocks:
 		msg = &MsgGetBlocks{}
 
@@ -184,12 +201,58 @@ func makeEmptyMessage(command string) (Message, error) {
 	case CmdCFCheckpt:
 		msg = &MsgCFCheckpt{}
 
+	case CmdSendPackages:
+		msg = &MsgSendPackages{}
+
+	case CmdAncPkgInfo:
+		msg = &MsgAncPkgInfo{}
+
+	case CmdGetPkgTxns:
+		msg = &MsgGetPkgTxns{}
+
+	case CmdPkgTxns:
+		msg = &MsgPkgTxns{}
+
+	case CmdSendTxRcncl:
+		msg = &MsgSendTxRcncl{}
+
+	case CmdReqRecon:
+		msg = &MsgReqRecon{}
+
+	case CmdSketch:
+		msg = &MsgSketch{}
+
+	case CmdReqSketchExt:
+		msg = &MsgReqSketchExt{}
+
+	case CmdReconcilDiff:
+		msg = &MsgReconcilDiff{}
+
+	case CmdSendCmpct:
+		msg = &MsgSendCmpct{}
+
+	case CmdCmpctBlock:
+		msg = &MsgCmpctBlock{}
+
+	case CmdGetBlockTxn:
+		msg = &MsgGetBlockTxn{}
+
+	case CmdBlockTxn:
+		msg = &MsgBlockTxn{}
+
 	default:
 		return nil, fmt.Errorf("unhandled command [%s]", command)
 	}
 	return msg, nil
 }
 
+// MakeEmptyMessage creates a message of the appropriate concrete type based on
+// the command.  It is intended for alternative transports which frame messages
+// differently and therefore can't make use of ReadMessage.
+func MakeEmptyMessage(command string) (Message, error) {
+	return makeEmptyMessage(command)
+}
+
 // messageHeader defines the header structure for all bitcoin protocol messages.
 type messageHeader struct {
 	magic    BitcoinNet // 4 bytes
@@ -344,6 +407,16 @@ func WriteMessageWithEncodingN(w io.Writer, msg Message, pver uint32,
 func ReadMessageWithEncodingN(r io.Reader, pver uint32, btcnet BitcoinNet,
 	enc MessageEncoding) (int, Message, []byte, error) {
 
+	return ReadMessageWithLimitsN(r, pver, btcnet, enc, nil)
+}
+
+// ReadMessageWithLimitsN reads, validates, and parses the next bitcoin Message
+// from r for the provided protocol version and bitcoin network.  This function
+// is the same as ReadMessageWithEncodingN except it additionally enforces the
+// passed limits, which may be nil.  See MessageLimits for details.
+func ReadMessageWithLimitsN(r io.Reader, pver uint32, btcnet BitcoinNet,
+	enc MessageEncoding, limits *MessageLimits) (int, Message, []byte, error) {
+
 	totalBytes := 0
 	n, hdr, err := readMessageHeader(r)
 	totalBytes += n
@@ -386,7 +459,7 @@ func ReadMessageWithEncodingN(r io.Reader, pver uint32, btcnet BitcoinNet,
 	// Check for maximum length based on the message type as a malicious client
 	// could otherwise create a well-formed header and set the length to max
 	// numbers in order to exhaust the machine's memory.
-	mpl := msg.MaxPayloadLength(pver)
+	mpl := limits.maxPayload(command, msg.MaxPayloadLength(pver))
 	if hdr.length > mpl {
 		discardInput(r, hdr.length)
 		str := fmt.Sprintf("payload exceeds max length - header "+
@@ -396,8 +469,7 @@ func ReadMessageWithEncodingN(r io.Reader, pver uint32, btcnet BitcoinNet,
 	}
 
 	// Read payload.
-	payload := make([]byte, hdr.length)
-	n, err = io.ReadFull(r, payload)
+	payload, n, err := limits.readPayload(r, hdr.length)
 	totalBytes += n
 	if err != nil {
 		return totalBytes, nil, nil, err
@@ -412,6 +484,12 @@ func ReadMessageWithEncodingN(r io.Reader, pver uint32, btcnet BitcoinNet,
 		return totalBytes, nil, nil, messageError("ReadMessage", str)
 	}
 
+	// Enforce the configured entry count limits before decoding the
+	// message so the entries are never allocated.
+	if err := limits.CheckPayload(command, payload, pver); err != nil {
+		return totalBytes, nil, nil, err
+	}
+
 	// Unmarshal message.  NOTE: This must be a *bytes.Buffer since the
 	// MsgVersion BtcDecode function requires it.
 	pr := bytes.NewBuffer(payload)
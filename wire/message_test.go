@@ -51,6 +51,7 @@ func TestMessage(t *testing.T) {
 	msgVerack := NewMsgVerAck()
 	msgGetAddr := NewMsgGetAddr()
 	msgAddr := NewMsgAddr()
+	msgAddrV2 := NewMsgAddrV2()
 	msgGetBlocks := NewMsgGetBlocks(&chainhash.Hash{})
 	msgBlock := &blockOne
 	msgInv := NewMsgInv()
@@ -76,6 +77,22 @@ func TestMessage(t *testing.T) {
 		[]byte("payload"))
 	msgCFHeaders := NewMsgCFHeaders()
 	msgCFCheckpt := NewMsgCFCheckpt(GCSFilterRegular, &chainhash.Hash{}, 0)
+	msgSendPackages := NewMsgSendPackages(PkgRelayAncestor)
+	msgAncPkgInfo := NewMsgAncPkgInfo()
+	msgGetPkgTxns := NewMsgGetPkgTxns()
+	msgPkgTxns := NewMsgPkgTxns()
+	msgSendTxRcncl := NewMsgSendTxRcncl(TxReconciliationV1, 123123)
+	msgReqRecon := NewMsgReqRecon(10, 5)
+	msgSketch := NewMsgSketch(&Sketch{Syndromes: []uint32{1, 2}})
+	msgReqSketchExt := NewMsgReqSketchExt()
+	msgReconcilDiff := NewMsgReconcilDiff(true)
+	msgReconcilDiff.AddShortID(1)
+	msgReconcilDiff.AddShortID(2)
+	msgSendCmpct := NewMsgSendCmpct(true, CmpctBlockVersion2)
+	msgCmpctBlock := NewMsgCmpctBlockFromBlock(&blockOne, 0,
+		CmpctBlockVersion2)
+	msgGetBlockTxn := NewMsgGetBlockTxn(&chainhash.Hash{}, []uint32{1})
+	msgBlockTxn := NewMsgBlockTxn(&chainhash.Hash{})
 
 	tests := []struct {
 		in     Message    // Value to encode
@@ -88,6 +105,7 @@ func TestMessage(t *testing.T) {
 		{msgVerack, msgVerack, pver, MainNet, 24},
 		{msgGetAddr, msgGetAddr, pver, MainNet, 24},
 		{msgAddr, msgAddr, pver, MainNet, 25},
+		{msgAddrV2, msgAddrV2, pver, MainNet, 25},
 		{msgGetBlocks, msgGetBlocks, pver, MainNet, 61},
 		{msgBlock, msgBlock, pver, MainNet, 239},
 		{msgInv, msgInv, pver, MainNet, 25},
@@ -111,6 +129,19 @@ func TestMessage(t *testing.T) {
 		{msgCFilter, msgCFilter, pver, MainNet, 65},
 		{msgCFHeaders, msgCFHeaders, pver, MainNet, 90},
 		{msgCFCheckpt, msgCFCheckpt, pver, MainNet, 58},
+		{msgSendPackages, msgSendPackages, PackageRelayVersion, MainNet, 32},
+		{msgAncPkgInfo, msgAncPkgInfo, PackageRelayVersion, MainNet, 25},
+		{msgGetPkgTxns, msgGetPkgTxns, PackageRelayVersion, MainNet, 25},
+		{msgPkgTxns, msgPkgTxns, PackageRelayVersion, MainNet, 25},
+		{msgSendTxRcncl, msgSendTxRcncl, TxReconciliationVersion, MainNet, 36},
+		{msgReqRecon, msgReqRecon, TxReconciliationVersion, MainNet, 28},
+		{msgSketch, msgSketch, TxReconciliationVersion, MainNet, 33},
+		{msgReqSketchExt, msgReqSketchExt, TxReconciliationVersion, MainNet, 24},
+		{msgReconcilDiff, msgReconcilDiff, TxReconciliationVersion, MainNet, 34},
+		{msgSendCmpct, msgSendCmpct, CompactBlocksVersion, MainNet, 33},
+		{msgCmpctBlock, msgCmpctBlock, CompactBlocksVersion, MainNet, 249},
+		{msgGetBlockTxn, msgGetBlockTxn, CompactBlocksVersion, MainNet, 58},
+		{msgBlockTxn, msgBlockTxn, CompactBlocksVersion, MainNet, 57},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -0,0 +1,100 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestAddrV2Wire tests the MsgAddrV2 wire encode and decode.
+func TestAddrV2Wire(t *testing.T) {
+	ts := time.Unix(0x495fab29, 0) // 2009-01-03 12:15:05 -0600 CST
+	msg := NewMsgAddrV2()
+	msg.AddAddress(NewNetAddressV2(ts, SFNodeNetwork, NetIDIPv4,
+		[]byte{127, 0, 0, 1}, 8333))
+	msg.AddAddress(NewNetAddressV2(ts, SFNodeNetwork|SFNodeWitness,
+		NetIDTorV3, bytes.Repeat([]byte{0xaa}, 32), 8333))
+
+	// Unknown networks are preserved so they can be ignored.
+	msg.AddAddress(NewNetAddressV2(ts, 0, NetworkID(0x42),
+		[]byte{1, 2, 3}, 1))
+
+	want := []byte{
+		0x03,                   // Varint for number of addresses
+		0x29, 0xab, 0x5f, 0x49, // Timestamp
+		0x01,                         // Varint for services
+		0x01,                         // Network id
+		0x04, 0x7f, 0x00, 0x00, 0x01, // Address
+		0x20, 0x8d, // Port 8333 in big-endian
+		0x29, 0xab, 0x5f, 0x49, // Timestamp
+		0x09, // Varint for services
+		0x04, // Network id
+		0x20, // Varint for address length
+	}
+	want = append(want, bytes.Repeat([]byte{0xaa}, 32)...)
+	want = append(want, []byte{
+		0x20, 0x8d, // Port 8333 in big-endian
+		0x29, 0xab, 0x5f, 0x49, // Timestamp
+		0x00,                   // Varint for services
+		0x42,                   // Network id
+		0x03, 0x01, 0x02, 0x03, // Address
+		0x00, 0x01, // Port 1 in big-endian
+	}...)
+
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, ProtocolVersion, BaseEncoding); err != nil {
+		t.Fatalf("BtcEncode: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("BtcEncode\n got: %x want: %x", buf.Bytes(), want)
+	}
+
+	var decoded MsgAddrV2
+	err := decoded.BtcDecode(&buf, ProtocolVersion, BaseEncoding)
+	if err != nil {
+		t.Fatalf("BtcDecode: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.AddrList, msg.AddrList) {
+		t.Fatalf("BtcDecode\n got: %v want: %v", decoded.AddrList,
+			msg.AddrList)
+	}
+}
+
+// TestAddrV2WireErrors ensures addresses of known networks with an invalid
+// size and messages with too many addresses are rejected.
+func TestAddrV2WireErrors(t *testing.T) {
+	badSize := []byte{
+		0x01,                   // Varint for number of addresses
+		0x29, 0xab, 0x5f, 0x49, // Timestamp
+		0x01,                   // Varint for services
+		0x01,                   // Network id IPv4
+		0x03, 0x7f, 0x00, 0x00, // Address of 3 bytes
+		0x20, 0x8d, // Port
+	}
+	var msg MsgAddrV2
+	err := msg.BtcDecode(bytes.NewReader(badSize), ProtocolVersion,
+		BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcDecode: expected MessageError, got %v", err)
+	}
+
+	tooMany := []byte{0xfd, 0xe9, 0x03} // 1001 addresses
+	err = msg.BtcDecode(bytes.NewReader(tooMany), ProtocolVersion,
+		BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcDecode: expected MessageError, got %v", err)
+	}
+
+	bad := NewMsgAddrV2()
+	bad.AddAddress(&NetAddressV2{NetworkID: NetIDTorV3, Addr: []byte{1}})
+	var buf bytes.Buffer
+	err = bad.BtcEncode(&buf, ProtocolVersion, BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcEncode: expected MessageError, got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// MaxPackageTxs is the maximum number of transactions in a package relayed
+// with the BIP0331 package relay messages.
+const MaxPackageTxs = 25
+
+// MsgAncPkgInfo implements the Message interface and represents a bitcoin
+// ancpkginfo message.  It is sent in response to a getdata message for an
+// InvTypeAncPkgInfo inventory vector and lists the witness hashes of the
+// requested transaction and all of its unconfirmed ancestors.
+//
+// This message was not added until protocol versions starting with
+// PackageRelayVersion.
+type MsgAncPkgInfo struct {
+	WitnessHashes []*chainhash.Hash
+}
+
+// AddWitnessHash adds a new witness hash to the message.
+func (msg *MsgAncPkgInfo) AddWitnessHash(hash *chainhash.Hash) error {
+	if len(msg.WitnessHashes)+1 > MaxPackageTxs {
+		str := fmt.Sprintf("too many witness hashes in message [max %v]",
+			MaxPackageTxs)
+		return messageError("MsgAncPkgInfo.AddWitnessHash", str)
+	}
+
+	msg.WitnessHashes = append(msg.WitnessHashes, hash)
+	return nil
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgAncPkgInfo) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	hashes, err := readPackageHashes(r, pver, "MsgAncPkgInfo.BtcDecode")
+	if err != nil {
+		return err
+	}
+	msg.WitnessHashes = hashes
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgAncPkgInfo) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	return writePackageHashes(w, pver, msg.WitnessHashes,
+		"MsgAncPkgInfo.BtcEncode")
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgAncPkgInfo) Command() string {
+	return CmdAncPkgInfo
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgAncPkgInfo) MaxPayloadLength(pver uint32) uint32 {
+	// Num witness hashes (varInt) + max allowed witness hashes.
+	return MaxVarIntPayload + (MaxPackageTxs * chainhash.HashSize)
+}
+
+// NewMsgAncPkgInfo returns a new bitcoin ancpkginfo message that conforms to
+// the Message interface.  See MsgAncPkgInfo for details.
+func NewMsgAncPkgInfo() *MsgAncPkgInfo {
+	return &MsgAncPkgInfo{
+		WitnessHashes: make([]*chainhash.Hash, 0, MaxPackageTxs),
+	}
+}
+
+// readPackageHashes reads a list of witness hashes as used by the package
+// relay messages from r.  The passed function name is used in errors.
+func readPackageHashes(r io.Reader, pver uint32,
+	funcName string) ([]*chainhash.Hash, error) {
+
+	if pver < PackageRelayVersion {
+		str := fmt.Sprintf("package relay message invalid for "+
+			"protocol version %d", pver)
+		return nil, messageError(funcName, str)
+	}
+
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return nil, err
+	}
+
+	// Limit to max transactions per package.
+	if count > MaxPackageTxs {
+		str := fmt.Sprintf("too many witness hashes for message "+
+			"[count %v, max %v]", count, MaxPackageTxs)
+		return nil, messageError(funcName, str)
+	}
+
+	// Create a contiguous slice of hashes to deserialize into in order to
+	// reduce the number of allocations.
+	hashes := make([]chainhash.Hash, count)
+	list := make([]*chainhash.Hash, 0, count)
+	for i := uint64(0); i < count; i++ {
+		hash := &hashes[i]
+		if err := readElement(r, hash); err != nil {
+			return nil, err
+		}
+		list = append(list, hash)
+	}
+
+	return list, nil
+}
+
+// writePackageHashes writes a list of witness hashes as used by the package
+// relay messages to w.  The passed function name is used in errors.
+func writePackageHashes(w io.Writer, pver uint32, hashes []*chainhash.Hash,
+	funcName string) error {
+
+	if pver < PackageRelayVersion {
+		str := fmt.Sprintf("package relay message invalid for "+
+			"protocol version %d", pver)
+		return messageError(funcName, str)
+	}
+
+	// Limit to max transactions per package.
+	count := len(hashes)
+	if count > MaxPackageTxs {
+		str := fmt.Sprintf("too many witness hashes for message "+
+			"[count %v, max %v]", count, MaxPackageTxs)
+		return messageError(funcName, str)
+	}
+
+	if err := WriteVarInt(w, pver, uint64(count)); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if err := writeElement(w, hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
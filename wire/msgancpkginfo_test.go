@@ -0,0 +1,125 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// TestPackageHashesWire tests the wire encode and decode of the ancpkginfo
+// and getpkgtxns messages, which both consist of a list of witness hashes.
+func TestPackageHashesWire(t *testing.T) {
+	hash1 := chainhash.Hash{0x01}
+	hash2 := chainhash.Hash{0x02}
+
+	ancPkgInfo := NewMsgAncPkgInfo()
+	ancPkgInfo.AddWitnessHash(&hash1)
+	ancPkgInfo.AddWitnessHash(&hash2)
+
+	getPkgTxns := NewMsgGetPkgTxns()
+	getPkgTxns.AddWitnessHash(&hash2)
+
+	wantAncPkgInfo := append([]byte{0x02}, hash1[:]...)
+	wantAncPkgInfo = append(wantAncPkgInfo, hash2[:]...)
+	wantGetPkgTxns := append([]byte{0x01}, hash2[:]...)
+
+	tests := []struct {
+		in   Message // Message to encode
+		out  Message // Empty message to decode into
+		buf  []byte  // Wire encoding
+		pver uint32  // Protocol version for wire encoding
+	}{
+		{ancPkgInfo, &MsgAncPkgInfo{}, wantAncPkgInfo, PackageRelayVersion},
+		{getPkgTxns, &MsgGetPkgTxns{}, wantGetPkgTxns, PackageRelayVersion},
+		{getPkgTxns, &MsgGetPkgTxns{}, wantGetPkgTxns, ProtocolVersion},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		wantErr := test.pver < PackageRelayVersion
+
+		// Encode the message to wire format.
+		var buf bytes.Buffer
+		err := test.in.BtcEncode(&buf, test.pver, BaseEncoding)
+		if _, ok := err.(*MessageError); ok != wantErr {
+			t.Errorf("BtcEncode #%d unexpected error %v", i, err)
+			continue
+		}
+		if !wantErr && !bytes.Equal(buf.Bytes(), test.buf) {
+			t.Errorf("BtcEncode #%d\n got: %x want: %x", i,
+				buf.Bytes(), test.buf)
+			continue
+		}
+
+		// Decode the message from wire format.
+		rbuf := bytes.NewReader(test.buf)
+		err = test.out.BtcDecode(rbuf, test.pver, BaseEncoding)
+		if _, ok := err.(*MessageError); ok != wantErr {
+			t.Errorf("BtcDecode #%d unexpected error %v", i, err)
+			continue
+		}
+		if wantErr {
+			continue
+		}
+
+		var got []*chainhash.Hash
+		var want []*chainhash.Hash
+		switch msg := test.out.(type) {
+		case *MsgAncPkgInfo:
+			got = msg.WitnessHashes
+			want = test.in.(*MsgAncPkgInfo).WitnessHashes
+		case *MsgGetPkgTxns:
+			got = msg.WitnessHashes
+			want = test.in.(*MsgGetPkgTxns).WitnessHashes
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("BtcDecode #%d\n got: %v want: %v", i, got, want)
+		}
+	}
+}
+
+// TestPackageHashesLimit ensures the ancpkginfo and getpkgtxns messages
+// reject more than the maximum number of witness hashes.
+func TestPackageHashesLimit(t *testing.T) {
+	ancPkgInfo := NewMsgAncPkgInfo()
+	getPkgTxns := NewMsgGetPkgTxns()
+	for i := 0; i < MaxPackageTxs; i++ {
+		hash := chainhash.Hash{byte(i)}
+		if err := ancPkgInfo.AddWitnessHash(&hash); err != nil {
+			t.Fatalf("AddWitnessHash #%d: %v", i, err)
+		}
+		if err := getPkgTxns.AddWitnessHash(&hash); err != nil {
+			t.Fatalf("AddWitnessHash #%d: %v", i, err)
+		}
+	}
+	hash := chainhash.Hash{0xff}
+	if err := ancPkgInfo.AddWitnessHash(&hash); err == nil {
+		t.Fatal("MsgAncPkgInfo.AddWitnessHash: expected error")
+	}
+	if err := getPkgTxns.AddWitnessHash(&hash); err == nil {
+		t.Fatal("MsgGetPkgTxns.AddWitnessHash: expected error")
+	}
+
+	// Ensure a message claiming too many hashes is rejected on decode
+	// and that an oversized list is rejected on encode.
+	ancPkgInfo.WitnessHashes = append(ancPkgInfo.WitnessHashes, &hash)
+	var buf bytes.Buffer
+	err := ancPkgInfo.BtcEncode(&buf, PackageRelayVersion, BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Fatalf("BtcEncode: expected MessageError, got %v", err)
+	}
+
+	tooMany := []byte{MaxPackageTxs + 1}
+	var msg MsgGetPkgTxns
+	err = msg.BtcDecode(bytes.NewReader(tooMany), PackageRelayVersion,
+		BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Fatalf("BtcDecode: expected MessageError, got %v", err)
+	}
+}
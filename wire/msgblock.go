@@ -122,6 +122,31 @@ func (msg *MsgBlock) DeserializeNoWitness(r io.Reader) error {
 	return msg.BtcDecode(r, 0, BaseEncoding)
 }
 
+// DeserializeNoCopy decodes a block from buf into the receiver in the same
+// manner as Deserialize, however all scripts and witness items of its
+// transactions reference buf directly instead of being copied.  See
+// MsgTx.DeserializeNoCopy for details.
+//
+// The caller must not modify buf for as long as the block is in use.  Use Copy
+// to obtain a block which doesn't reference buf.
+func (msg *MsgBlock) DeserializeNoCopy(buf []byte) error {
+	return msg.BtcDecode(&noCopyReader{buf: buf}, 0, WitnessEncoding)
+}
+
+// Copy creates a deep copy of a block so that the original does not get
+// modified when the copy is manipulated.  The copy doesn't reference the
+// buffer of a block decoded with DeserializeNoCopy.
+func (msg *MsgBlock) Copy() *MsgBlock {
+	newBlock := MsgBlock{
+		Header:       msg.Header,
+		Transactions: make([]*MsgTx, 0, len(msg.Transactions)),
+	}
+	for _, tx := range msg.Transactions {
+		newBlock.Transactions = append(newBlock.Transactions, tx.Copy())
+	}
+	return &newBlock
+}
+
 // DeserializeTxLoc decodes r in the same manner Deserialize does, but it takes
 // a byte buffer instead of a generic reader and returns a slice containing the
 // start and length of each transaction within the raw data that is being
@@ -325,6 +325,37 @@ func TestBlockSerialize(t *testing.T) {
 	}
 }
 
+// TestBlockDeserializeNoCopy ensures blocks decoded in zero-copy mode are
+// identical to those decoded normally and that Copy produces an equal block.
+func TestBlockDeserializeNoCopy(t *testing.T) {
+	var buf bytes.Buffer
+	if err := blockOne.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+
+	var block MsgBlock
+	if err := block.DeserializeNoCopy(buf.Bytes()); err != nil {
+		t.Fatalf("DeserializeNoCopy: %v", err)
+	}
+	var want MsgBlock
+	if err := want.Deserialize(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if !reflect.DeepEqual(&block, &want) {
+		t.Fatalf("mismatched block - got %v, want %v",
+			spew.Sdump(&block), spew.Sdump(&want))
+	}
+
+	blockCopy := block.Copy()
+	if !reflect.DeepEqual(blockCopy, &want) {
+		t.Fatalf("mismatched block copy - got %v, want %v",
+			spew.Sdump(blockCopy), spew.Sdump(&want))
+	}
+	if blockCopy.Transactions[0] == block.Transactions[0] {
+		t.Fatal("block copy shares transactions")
+	}
+}
+
 // TestBlockSerializeErrors performs negative tests against wire encode and
 // decode of MsgBlock to confirm error paths work correctly.
 func TestBlockSerializeErrors(t *testing.T) {
@@ -0,0 +1,278 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+const (
+	// CmpctShortIDSize is the number of bytes of a short transaction id in
+	// a compact block.
+	CmpctShortIDSize = 6
+
+	// cmpctShortIDMask masks the bits of a SipHash output which form a
+	// short transaction id.
+	cmpctShortIDMask = 1<<(8*CmpctShortIDSize) - 1
+)
+
+// PrefilledTx is a transaction which is sent in full as part of a compact
+// block along with its index in the block.
+type PrefilledTx struct {
+	Index uint32
+	Tx    *MsgTx
+}
+
+// MsgCmpctBlock implements the Message interface and represents a bitcoin
+// cmpctblock message as defined by BIP0152.  It consists of the block header,
+// a short id for most of the transactions in the block, and the remaining
+// transactions in full, which always includes the coinbase.  The receiver is
+// expected to fill in the transactions identified by short ids from its
+// mempool, see CompactBlockReconstructor.
+//
+// The indexes of the prefilled transactions are absolute and must be strictly
+// increasing.  They are differentially encoded on the wire.
+//
+// This message was not added until protocol versions starting with
+// CompactBlocksVersion.
+type MsgCmpctBlock struct {
+	Header        BlockHeader
+	Nonce         uint64
+	ShortIDs      []uint64
+	PrefilledTxns []*PrefilledTx
+}
+
+// TxCount returns the number of transactions in the block described by the
+// message.
+func (msg *MsgCmpctBlock) TxCount() int {
+	return len(msg.ShortIDs) + len(msg.PrefilledTxns)
+}
+
+// ShortIDKeys returns the SipHash keys used to compute the short transaction
+// ids of the message.  They are derived from the single SHA256 of the block
+// header followed by the nonce.
+func (msg *MsgCmpctBlock) ShortIDKeys() (uint64, uint64) {
+	var buf bytes.Buffer
+	buf.Grow(MaxBlockHeaderPayload + 8)
+	_ = writeBlockHeader(&buf, 0, &msg.Header)
+	_ = binarySerializer.PutUint64(&buf, littleEndian, msg.Nonce)
+
+	hash := chainhash.HashB(buf.Bytes())
+	return binary.LittleEndian.Uint64(hash[0:8]),
+		binary.LittleEndian.Uint64(hash[8:16])
+}
+
+// CmpctShortID returns the short transaction id of the passed hash using the
+// given SipHash keys.  The hash is the transaction hash for compact block
+// version 1 and the witness transaction hash for version 2.
+func CmpctShortID(k0, k1 uint64, hash *chainhash.Hash) uint64 {
+	return sipHash24(k0, k1, hash[:]) & cmpctShortIDMask
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgCmpctBlock) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	if pver < CompactBlocksVersion {
+		str := fmt.Sprintf("cmpctblock message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCmpctBlock.BtcDecode", str)
+	}
+
+	err := readBlockHeader(r, pver, &msg.Header)
+	if err != nil {
+		return err
+	}
+	msg.Nonce, err = binarySerializer.Uint64(r, littleEndian)
+	if err != nil {
+		return err
+	}
+
+	// Prevent more short ids than could possibly fit into a block.
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > maxTxPerBlock {
+		str := fmt.Sprintf("too many short ids to fit into a block "+
+			"[count %d, max %d]", count, maxTxPerBlock)
+		return messageError("MsgCmpctBlock.BtcDecode", str)
+	}
+
+	var buf [8]byte
+	msg.ShortIDs = make([]uint64, count)
+	for i := range msg.ShortIDs {
+		_, err := io.ReadFull(r, buf[:CmpctShortIDSize])
+		if err != nil {
+			return err
+		}
+		msg.ShortIDs[i] = binary.LittleEndian.Uint64(buf[:])
+	}
+
+	// Prevent more transactions in total than could possibly fit into a
+	// block.
+	prefilledCount, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if prefilledCount > maxTxPerBlock-count {
+		str := fmt.Sprintf("too many transactions to fit into a block "+
+			"[count %d, max %d]", count+prefilledCount,
+			maxTxPerBlock)
+		return messageError("MsgCmpctBlock.BtcDecode", str)
+	}
+
+	total := count + prefilledCount
+	msg.PrefilledTxns = make([]*PrefilledTx, 0, prefilledCount)
+	var nextIndex uint64
+	for i := uint64(0); i < prefilledCount; i++ {
+		diff, err := ReadVarInt(r, pver)
+		if err != nil {
+			return err
+		}
+		if diff >= total-nextIndex {
+			str := fmt.Sprintf("prefilled transaction index out "+
+				"of range [diff %d, count %d]", diff, total)
+			return messageError("MsgCmpctBlock.BtcDecode", str)
+		}
+		index := nextIndex + diff
+		nextIndex = index + 1
+
+		tx := MsgTx{}
+		if err := tx.BtcDecode(r, pver, enc); err != nil {
+			return err
+		}
+		msg.PrefilledTxns = append(msg.PrefilledTxns, &PrefilledTx{
+			Index: uint32(index),
+			Tx:    &tx,
+		})
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgCmpctBlock) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	if pver < CompactBlocksVersion {
+		str := fmt.Sprintf("cmpctblock message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCmpctBlock.BtcEncode", str)
+	}
+
+	total := msg.TxCount()
+	if total > maxTxPerBlock {
+		str := fmt.Sprintf("too many transactions to fit into a block "+
+			"[count %d, max %d]", total, maxTxPerBlock)
+		return messageError("MsgCmpctBlock.BtcEncode", str)
+	}
+
+	err := writeBlockHeader(w, pver, &msg.Header)
+	if err != nil {
+		return err
+	}
+	err = binarySerializer.PutUint64(w, littleEndian, msg.Nonce)
+	if err != nil {
+		return err
+	}
+
+	err = WriteVarInt(w, pver, uint64(len(msg.ShortIDs)))
+	if err != nil {
+		return err
+	}
+	var buf [8]byte
+	for _, shortID := range msg.ShortIDs {
+		binary.LittleEndian.PutUint64(buf[:], shortID)
+		if _, err := w.Write(buf[:CmpctShortIDSize]); err != nil {
+			return err
+		}
+	}
+
+	err = WriteVarInt(w, pver, uint64(len(msg.PrefilledTxns)))
+	if err != nil {
+		return err
+	}
+	var nextIndex uint32
+	for _, ptx := range msg.PrefilledTxns {
+		if ptx.Index < nextIndex || int(ptx.Index) >= total {
+			str := fmt.Sprintf("prefilled transaction index %d "+
+				"is out of order or out of range", ptx.Index)
+			return messageError("MsgCmpctBlock.BtcEncode", str)
+		}
+		err := WriteVarInt(w, pver, uint64(ptx.Index-nextIndex))
+		if err != nil {
+			return err
+		}
+		nextIndex = ptx.Index + 1
+
+		if err := ptx.Tx.BtcEncode(w, pver, enc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgCmpctBlock) Command() string {
+	return CmdCmpctBlock
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgCmpctBlock) MaxPayloadLength(pver uint32) uint32 {
+	// A compact block is never larger than the full block it describes.
+	return MaxBlockPayload
+}
+
+// NewMsgCmpctBlock returns a new bitcoin cmpctblock message that conforms to
+// the Message interface using the passed parameters and defaults for the
+// remaining fields.  See MsgCmpctBlock for details.
+func NewMsgCmpctBlock(header *BlockHeader, nonce uint64) *MsgCmpctBlock {
+	return &MsgCmpctBlock{
+		Header: *header,
+		Nonce:  nonce,
+	}
+}
+
+// NewMsgCmpctBlockFromBlock returns a new bitcoin cmpctblock message which
+// describes the passed block.  The coinbase transaction is prefilled and all
+// other transactions are referenced by their short ids, which are computed for
+// the given compact block version.
+func NewMsgCmpctBlockFromBlock(block *MsgBlock, nonce uint64,
+	version uint64) *MsgCmpctBlock {
+
+	msg := NewMsgCmpctBlock(&block.Header, nonce)
+	if len(block.Transactions) == 0 {
+		return msg
+	}
+
+	msg.PrefilledTxns = []*PrefilledTx{{
+		Index: 0,
+		Tx:    block.Transactions[0],
+	}}
+
+	k0, k1 := msg.ShortIDKeys()
+	msg.ShortIDs = make([]uint64, 0, len(block.Transactions)-1)
+	for _, tx := range block.Transactions[1:] {
+		hash := cmpctTxHash(tx, version)
+		msg.ShortIDs = append(msg.ShortIDs, CmpctShortID(k0, k1, &hash))
+	}
+	return msg
+}
+
+// cmpctTxHash returns the hash of the passed transaction which is used to
+// compute its short id for the given compact block version.
+func cmpctTxHash(tx *MsgTx, version uint64) chainhash.Hash {
+	if version >= CmpctBlockVersion2 {
+		return tx.WitnessHash()
+	}
+	return tx.TxHash()
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// TestCmpctBlockWire tests the MsgCmpctBlock wire encoding of short ids and
+// differentially encoded prefilled transaction indexes.
+func TestCmpctBlockWire(t *testing.T) {
+	msg := NewMsgCmpctBlock(&blockOne.Header, 0x0102030405060708)
+	msg.ShortIDs = []uint64{0x060504030201, 0xffffffffffff}
+	msg.PrefilledTxns = []*PrefilledTx{
+		{Index: 0, Tx: NewMsgTx(1)},
+		{Index: 3, Tx: NewMsgTx(1)},
+	}
+
+	var want bytes.Buffer
+	writeBlockHeader(&want, 0, &blockOne.Header)
+	want.Write([]byte{
+		0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01, // Nonce
+		0x02,                               // Varint for number of short ids
+		0x01, 0x02, 0x03, 0x04, 0x05, 0x06, // Short id
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, // Short id
+		0x02, // Varint for number of prefilled txns
+		0x00, // Index 0
+	})
+	NewMsgTx(1).BtcEncode(&want, CompactBlocksVersion, BaseEncoding)
+	want.WriteByte(0x02) // Index 3 differentially encoded
+	NewMsgTx(1).BtcEncode(&want, CompactBlocksVersion, BaseEncoding)
+
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, CompactBlocksVersion, BaseEncoding)
+	if err != nil {
+		t.Fatalf("BtcEncode: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), want.Bytes()) {
+		t.Fatalf("BtcEncode\n got: %x want: %x", buf.Bytes(),
+			want.Bytes())
+	}
+
+	var decoded MsgCmpctBlock
+	err = decoded.BtcDecode(&buf, CompactBlocksVersion, BaseEncoding)
+	if err != nil {
+		t.Fatalf("BtcDecode: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.ShortIDs, msg.ShortIDs) {
+		t.Fatalf("short ids: got %x, want %x", decoded.ShortIDs,
+			msg.ShortIDs)
+	}
+	for i, ptx := range decoded.PrefilledTxns {
+		if ptx.Index != msg.PrefilledTxns[i].Index {
+			t.Fatalf("prefilled index %d: got %d, want %d", i,
+				ptx.Index, msg.PrefilledTxns[i].Index)
+		}
+	}
+
+	// Out of order prefilled transactions must be rejected.
+	msg.PrefilledTxns[0].Index = 3
+	err = msg.BtcEncode(&buf, CompactBlocksVersion, BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Fatalf("BtcEncode: expected MessageError, got %v", err)
+	}
+
+	// Prefilled indexes beyond the transaction count must be rejected.
+	outOfRange := want.Bytes()
+	outOfRange[MaxBlockHeaderPayload+8+1+12+1] = 0x04
+	err = decoded.BtcDecode(bytes.NewReader(outOfRange),
+		CompactBlocksVersion, BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Fatalf("BtcDecode: expected MessageError, got %v", err)
+	}
+}
+
+// TestGetBlockTxnWire tests the MsgGetBlockTxn wire encoding of
+// differentially encoded indexes.
+func TestGetBlockTxnWire(t *testing.T) {
+	hash := chainhash.Hash{0x01}
+	msg := NewMsgGetBlockTxn(&hash, []uint32{1, 2, 5, 300})
+
+	want := append([]byte{}, hash[:]...)
+	want = append(want, 0x04, 0x01, 0x00, 0x02, 0xfd, 0x26, 0x01)
+
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, CompactBlocksVersion, BaseEncoding)
+	if err != nil {
+		t.Fatalf("BtcEncode: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("BtcEncode\n got: %x want: %x", buf.Bytes(), want)
+	}
+
+	var decoded MsgGetBlockTxn
+	err = decoded.BtcDecode(&buf, CompactBlocksVersion, BaseEncoding)
+	if err != nil {
+		t.Fatalf("BtcDecode: %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, msg) {
+		t.Fatalf("BtcDecode: got %v, want %v", decoded, msg)
+	}
+
+	// Messages are invalid before compact blocks were introduced.
+	err = msg.BtcEncode(&buf, CompactBlocksVersion-1, BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Fatalf("BtcEncode: expected MessageError, got %v", err)
+	}
+}
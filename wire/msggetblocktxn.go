@@ -0,0 +1,137 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// MsgGetBlockTxn implements the Message interface and represents a bitcoin
+// getblocktxn message as defined by BIP0152.  It is used to request the
+// transactions of a compact block which could not be filled in from the
+// mempool, identified by their indexes in the block.
+//
+// The indexes are absolute and must be strictly increasing.  They are
+// differentially encoded on the wire.
+//
+// This message was not added until protocol versions starting with
+// CompactBlocksVersion.
+type MsgGetBlockTxn struct {
+	BlockHash chainhash.Hash
+	Indexes   []uint32
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetBlockTxn) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	if pver < CompactBlocksVersion {
+		str := fmt.Sprintf("getblocktxn message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetBlockTxn.BtcDecode", str)
+	}
+
+	if err := readElement(r, &msg.BlockHash); err != nil {
+		return err
+	}
+
+	// Prevent more indexes than could possibly fit into a block.
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > maxTxPerBlock {
+		str := fmt.Sprintf("too many indexes to fit into a block "+
+			"[count %d, max %d]", count, maxTxPerBlock)
+		return messageError("MsgGetBlockTxn.BtcDecode", str)
+	}
+
+	msg.Indexes = make([]uint32, 0, count)
+	var nextIndex uint64
+	for i := uint64(0); i < count; i++ {
+		diff, err := ReadVarInt(r, pver)
+		if err != nil {
+			return err
+		}
+		if diff >= maxTxPerBlock-nextIndex {
+			str := fmt.Sprintf("transaction index out of range "+
+				"[diff %d, max %d]", diff, maxTxPerBlock)
+			return messageError("MsgGetBlockTxn.BtcDecode", str)
+		}
+		index := nextIndex + diff
+		nextIndex = index + 1
+		msg.Indexes = append(msg.Indexes, uint32(index))
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetBlockTxn) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	if pver < CompactBlocksVersion {
+		str := fmt.Sprintf("getblocktxn message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetBlockTxn.BtcEncode", str)
+	}
+
+	count := len(msg.Indexes)
+	if count > maxTxPerBlock {
+		str := fmt.Sprintf("too many indexes to fit into a block "+
+			"[count %d, max %d]", count, maxTxPerBlock)
+		return messageError("MsgGetBlockTxn.BtcEncode", str)
+	}
+
+	if err := writeElement(w, &msg.BlockHash); err != nil {
+		return err
+	}
+	if err := WriteVarInt(w, pver, uint64(count)); err != nil {
+		return err
+	}
+	var nextIndex uint32
+	for _, index := range msg.Indexes {
+		if index < nextIndex {
+			str := fmt.Sprintf("transaction index %d is out of "+
+				"order", index)
+			return messageError("MsgGetBlockTxn.BtcEncode", str)
+		}
+		err := WriteVarInt(w, pver, uint64(index-nextIndex))
+		if err != nil {
+			return err
+		}
+		nextIndex = index + 1
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetBlockTxn) Command() string {
+	return CmdGetBlockTxn
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetBlockTxn) MaxPayloadLength(pver uint32) uint32 {
+	// Block hash + num indexes (varInt) + max indexes, each of which is
+	// encoded in at most 5 bytes given the max number of transactions per
+	// block.
+	return chainhash.HashSize + MaxVarIntPayload + maxTxPerBlock*5
+}
+
+// NewMsgGetBlockTxn returns a new bitcoin getblocktxn message that conforms
+// to the Message interface using the passed parameters.  See MsgGetBlockTxn
+// for details.
+func NewMsgGetBlockTxn(blockHash *chainhash.Hash,
+	indexes []uint32) *MsgGetBlockTxn {
+
+	return &MsgGetBlockTxn{
+		BlockHash: *blockHash,
+		Indexes:   indexes,
+	}
+}
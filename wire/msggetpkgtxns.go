@@ -0,0 +1,74 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// MsgGetPkgTxns implements the Message interface and represents a bitcoin
+// getpkgtxns message.  It is used to request the transactions of a package,
+// identified by their witness hashes, which are then sent in a single pkgtxns
+// message.
+//
+// This message was not added until protocol versions starting with
+// PackageRelayVersion.
+type MsgGetPkgTxns struct {
+	WitnessHashes []*chainhash.Hash
+}
+
+// AddWitnessHash adds a new witness hash to the message.
+func (msg *MsgGetPkgTxns) AddWitnessHash(hash *chainhash.Hash) error {
+	if len(msg.WitnessHashes)+1 > MaxPackageTxs {
+		str := fmt.Sprintf("too many witness hashes in message [max %v]",
+			MaxPackageTxs)
+		return messageError("MsgGetPkgTxns.AddWitnessHash", str)
+	}
+
+	msg.WitnessHashes = append(msg.WitnessHashes, hash)
+	return nil
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetPkgTxns) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	hashes, err := readPackageHashes(r, pver, "MsgGetPkgTxns.BtcDecode")
+	if err != nil {
+		return err
+	}
+	msg.WitnessHashes = hashes
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetPkgTxns) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	return writePackageHashes(w, pver, msg.WitnessHashes,
+		"MsgGetPkgTxns.BtcEncode")
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetPkgTxns) Command() string {
+	return CmdGetPkgTxns
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetPkgTxns) MaxPayloadLength(pver uint32) uint32 {
+	// Num witness hashes (varInt) + max allowed witness hashes.
+	return MaxVarIntPayload + (MaxPackageTxs * chainhash.HashSize)
+}
+
+// NewMsgGetPkgTxns returns a new bitcoin getpkgtxns message that conforms to
+// the Message interface.  See MsgGetPkgTxns for details.
+func NewMsgGetPkgTxns() *MsgGetPkgTxns {
+	return &MsgGetPkgTxns{
+		WitnessHashes: make([]*chainhash.Hash, 0, MaxPackageTxs),
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// MsgPkgTxns implements the Message interface and represents a bitcoin
+// pkgtxns message.  It is sent in response to a getpkgtxns message and
+// contains the requested transactions of a package.
+//
+// This message was not added until protocol versions starting with
+// PackageRelayVersion.
+type MsgPkgTxns struct {
+	Txns []*MsgTx
+}
+
+// AddTransaction adds a transaction to the message.
+func (msg *MsgPkgTxns) AddTransaction(tx *MsgTx) error {
+	if len(msg.Txns)+1 > MaxPackageTxs {
+		str := fmt.Sprintf("too many transactions in message [max %v]",
+			MaxPackageTxs)
+		return messageError("MsgPkgTxns.AddTransaction", str)
+	}
+
+	msg.Txns = append(msg.Txns, tx)
+	return nil
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgPkgTxns) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	if pver < PackageRelayVersion {
+		str := fmt.Sprintf("pkgtxns message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgPkgTxns.BtcDecode", str)
+	}
+
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+
+	// Limit to max transactions per package.
+	if count > MaxPackageTxs {
+		str := fmt.Sprintf("too many transactions for message "+
+			"[count %v, max %v]", count, MaxPackageTxs)
+		return messageError("MsgPkgTxns.BtcDecode", str)
+	}
+
+	msg.Txns = make([]*MsgTx, 0, count)
+	for i := uint64(0); i < count; i++ {
+		tx := MsgTx{}
+		if err := tx.BtcDecode(r, pver, enc); err != nil {
+			return err
+		}
+		msg.Txns = append(msg.Txns, &tx)
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgPkgTxns) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	if pver < PackageRelayVersion {
+		str := fmt.Sprintf("pkgtxns message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgPkgTxns.BtcEncode", str)
+	}
+
+	// Limit to max transactions per package.
+	count := len(msg.Txns)
+	if count > MaxPackageTxs {
+		str := fmt.Sprintf("too many transactions for message "+
+			"[count %v, max %v]", count, MaxPackageTxs)
+		return messageError("MsgPkgTxns.BtcEncode", str)
+	}
+
+	if err := WriteVarInt(w, pver, uint64(count)); err != nil {
+		return err
+	}
+	for _, tx := range msg.Txns {
+		if err := tx.BtcEncode(w, pver, enc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgPkgTxns) Command() string {
+	return CmdPkgTxns
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgPkgTxns) MaxPayloadLength(pver uint32) uint32 {
+	// Packages are restricted to well below the size of a block.
+	return MaxBlockPayload
+}
+
+// NewMsgPkgTxns returns a new bitcoin pkgtxns message that conforms to the
+// Message interface.  See MsgPkgTxns for details.
+func NewMsgPkgTxns() *MsgPkgTxns {
+	return &MsgPkgTxns{
+		Txns: make([]*MsgTx, 0, MaxPackageTxs),
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestPkgTxnsWire tests the MsgPkgTxns wire encode and decode for various
+// protocol versions and encodings.
+func TestPkgTxnsWire(t *testing.T) {
+	msg := NewMsgPkgTxns()
+	msg.AddTransaction(multiTx)
+	msg.AddTransaction(multiWitnessTx)
+
+	tests := []struct {
+		pver    uint32          // Protocol version for wire encoding
+		enc     MessageEncoding // Message encoding format
+		wantErr bool            // Whether encoding and decoding fail
+	}{
+		{PackageRelayVersion, WitnessEncoding, false},
+		{PackageRelayVersion, BaseEncoding, false},
+		{ProtocolVersion, WitnessEncoding, true},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode the message to wire format.
+		var buf bytes.Buffer
+		err := msg.BtcEncode(&buf, test.pver, test.enc)
+		if _, ok := err.(*MessageError); ok != test.wantErr {
+			t.Errorf("BtcEncode #%d unexpected error %v", i, err)
+			continue
+		}
+		if test.wantErr {
+			continue
+		}
+
+		// Decode the message from wire format and ensure the
+		// transactions serialize identically.
+		var readMsg MsgPkgTxns
+		err = readMsg.BtcDecode(&buf, test.pver, test.enc)
+		if err != nil {
+			t.Errorf("BtcDecode #%d error %v", i, err)
+			continue
+		}
+		if len(readMsg.Txns) != len(msg.Txns) {
+			t.Errorf("BtcDecode #%d: got %d txns, want %d", i,
+				len(readMsg.Txns), len(msg.Txns))
+			continue
+		}
+		for j, tx := range readMsg.Txns {
+			var got, want bytes.Buffer
+			tx.BtcEncode(&got, test.pver, test.enc)
+			msg.Txns[j].BtcEncode(&want, test.pver, test.enc)
+			if !bytes.Equal(got.Bytes(), want.Bytes()) {
+				t.Errorf("BtcDecode #%d: tx %d mismatch", i, j)
+			}
+		}
+	}
+}
+
+// TestPkgTxnsLimit ensures MsgPkgTxns rejects more than the maximum number of
+// transactions.
+func TestPkgTxnsLimit(t *testing.T) {
+	msg := NewMsgPkgTxns()
+	for i := 0; i < MaxPackageTxs; i++ {
+		if err := msg.AddTransaction(NewMsgTx(1)); err != nil {
+			t.Fatalf("AddTransaction #%d: %v", i, err)
+		}
+	}
+	if err := msg.AddTransaction(NewMsgTx(1)); err == nil {
+		t.Fatal("AddTransaction: expected error")
+	}
+
+	var readMsg MsgPkgTxns
+	tooMany := []byte{MaxPackageTxs + 1}
+	err := readMsg.BtcDecode(bytes.NewReader(tooMany), PackageRelayVersion,
+		WitnessEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Fatalf("BtcDecode: expected MessageError, got %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// MsgReconcilDiff implements the Message interface and represents a bitcoin
+// reconcildiff message.  It concludes a transaction reconciliation round and
+// reports whether the sketch could be decoded.  On success it lists the short
+// ids of the transactions the sender is missing, while the transactions the
+// receiver is missing are announced separately with an inv message.
+//
+// This message was not added until protocol versions starting with
+// TxReconciliationVersion.
+type MsgReconcilDiff struct {
+	Success     bool
+	AskShortIDs []uint32
+}
+
+// AddShortID adds a short id of a missing transaction to the message.
+func (msg *MsgReconcilDiff) AddShortID(shortID uint32) error {
+	if len(msg.AskShortIDs)+1 > MaxSketchCapacity {
+		str := fmt.Sprintf("too many short ids in message [max %v]",
+			MaxSketchCapacity)
+		return messageError("MsgReconcilDiff.AddShortID", str)
+	}
+
+	msg.AskShortIDs = append(msg.AskShortIDs, shortID)
+	return nil
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgReconcilDiff) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	if pver < TxReconciliationVersion {
+		str := fmt.Sprintf("reconcildiff message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgReconcilDiff.BtcDecode", str)
+	}
+
+	success, err := binarySerializer.Uint8(r)
+	if err != nil {
+		return err
+	}
+	msg.Success = success != 0
+
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+
+	// Limit to the max number of differences a sketch can recover.
+	if count > MaxSketchCapacity {
+		str := fmt.Sprintf("too many short ids for message "+
+			"[count %v, max %v]", count, MaxSketchCapacity)
+		return messageError("MsgReconcilDiff.BtcDecode", str)
+	}
+
+	msg.AskShortIDs = make([]uint32, count)
+	for i := range msg.AskShortIDs {
+		shortID, err := binarySerializer.Uint32(r, littleEndian)
+		if err != nil {
+			return err
+		}
+		msg.AskShortIDs[i] = shortID
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgReconcilDiff) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	if pver < TxReconciliationVersion {
+		str := fmt.Sprintf("reconcildiff message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgReconcilDiff.BtcEncode", str)
+	}
+
+	// Limit to the max number of differences a sketch can recover.
+	count := len(msg.AskShortIDs)
+	if count > MaxSketchCapacity {
+		str := fmt.Sprintf("too many short ids for message "+
+			"[count %v, max %v]", count, MaxSketchCapacity)
+		return messageError("MsgReconcilDiff.BtcEncode", str)
+	}
+
+	var success uint8
+	if msg.Success {
+		success = 1
+	}
+	if err := binarySerializer.PutUint8(w, success); err != nil {
+		return err
+	}
+
+	if err := WriteVarInt(w, pver, uint64(count)); err != nil {
+		return err
+	}
+	for _, shortID := range msg.AskShortIDs {
+		err := binarySerializer.PutUint32(w, littleEndian, shortID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgReconcilDiff) Command() string {
+	return CmdReconcilDiff
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgReconcilDiff) MaxPayloadLength(pver uint32) uint32 {
+	// Success flag 1 byte + num short ids (varInt) + max short ids.
+	return 1 + MaxVarIntPayload + (MaxSketchCapacity * 4)
+}
+
+// NewMsgReconcilDiff returns a new bitcoin reconcildiff message that conforms
+// to the Message interface.  See MsgReconcilDiff for details.
+func NewMsgReconcilDiff(success bool) *MsgReconcilDiff {
+	return &MsgReconcilDiff{
+		Success: success,
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// TestReconcilDiffWire tests the MsgReconcilDiff wire encode and decode for
+// various protocol versions.
+func TestReconcilDiffWire(t *testing.T) {
+	withIDs := NewMsgReconcilDiff(true)
+	withIDs.AddShortID(0x04030201)
+	withIDs.AddShortID(0x08070605)
+
+	tests := []struct {
+		in      *MsgReconcilDiff // Message to encode
+		out     *MsgReconcilDiff // Expected decoded message
+		buf     []byte           // Wire encoding
+		pver    uint32           // Protocol version for wire encoding
+		wantErr bool             // Whether encoding and decoding fail
+	}{
+		// Successful reconciliation with missing transactions.
+		{
+			withIDs,
+			withIDs,
+			[]byte{
+				0x01, 0x02,
+				0x01, 0x02, 0x03, 0x04,
+				0x05, 0x06, 0x07, 0x08,
+			},
+			TxReconciliationVersion,
+			false,
+		},
+
+		// Failed reconciliation.
+		{
+			NewMsgReconcilDiff(false),
+			&MsgReconcilDiff{AskShortIDs: []uint32{}},
+			[]byte{0x00, 0x00},
+			TxReconciliationVersion,
+			false,
+		},
+
+		// Protocol version before reconciliation.
+		{
+			withIDs,
+			withIDs,
+			[]byte{0x00, 0x00},
+			TxReconciliationVersion - 1,
+			true,
+		},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode the message to wire format.
+		var buf bytes.Buffer
+		err := test.in.BtcEncode(&buf, test.pver, BaseEncoding)
+		if _, ok := err.(*MessageError); ok != test.wantErr {
+			t.Errorf("BtcEncode #%d unexpected error %v", i, err)
+			continue
+		}
+		if !test.wantErr && !bytes.Equal(buf.Bytes(), test.buf) {
+			t.Errorf("BtcEncode #%d\n got: %x want: %x", i,
+				buf.Bytes(), test.buf)
+			continue
+		}
+
+		// Decode the message from wire format.
+		var msg MsgReconcilDiff
+		rbuf := bytes.NewReader(test.buf)
+		err = msg.BtcDecode(rbuf, test.pver, BaseEncoding)
+		if _, ok := err.(*MessageError); ok != test.wantErr {
+			t.Errorf("BtcDecode #%d unexpected error %v", i, err)
+			continue
+		}
+		if !test.wantErr && !reflect.DeepEqual(&msg, test.out) {
+			t.Errorf("BtcDecode #%d\n got: %v want: %v", i, msg,
+				test.out)
+		}
+	}
+
+	// Ensure a message claiming too many short ids is rejected.
+	var msg MsgReconcilDiff
+	tooMany := []byte{0x01, 0xfd, 0x01, 0x20}
+	err := msg.BtcDecode(bytes.NewReader(tooMany), TxReconciliationVersion,
+		BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcDecode: expected MessageError, got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// MsgReqSketchExt implements the Message interface and represents a bitcoin
+// reqsketchext message.  It is used to request an extension of a sketch
+// which could not be decoded, allowing the reconciliation round to be retried
+// with twice the capacity.
+//
+// This message was not added until protocol versions starting with
+// TxReconciliationVersion and has no payload.
+type MsgReqSketchExt struct{}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgReqSketchExt) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	if pver < TxReconciliationVersion {
+		str := fmt.Sprintf("reqsketchext message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgReqSketchExt.BtcDecode", str)
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgReqSketchExt) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	if pver < TxReconciliationVersion {
+		str := fmt.Sprintf("reqsketchext message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgReqSketchExt.BtcEncode", str)
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgReqSketchExt) Command() string {
+	return CmdReqSketchExt
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgReqSketchExt) MaxPayloadLength(pver uint32) uint32 {
+	return 0
+}
+
+// NewMsgReqSketchExt returns a new bitcoin reqsketchext message that conforms
+// to the Message interface.  See MsgReqSketchExt for details.
+func NewMsgReqSketchExt() *MsgReqSketchExt {
+	return &MsgReqSketchExt{}
+}
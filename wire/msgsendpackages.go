@@ -0,0 +1,78 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// PackageVersions is a bit field of the package relay protocols a peer
+// supports as defined by BIP0331.
+type PackageVersions uint64
+
+const (
+	// PkgRelayAncestor indicates support for ancestor package relay.
+	PkgRelayAncestor PackageVersions = 1 << 0
+)
+
+// MsgSendPackages implements the Message interface and represents a bitcoin
+// sendpackages message.  It is sent before the verack message to signal
+// support for the package relay protocols defined by BIP0331.
+//
+// This message was not added until protocol versions starting with
+// PackageRelayVersion.
+type MsgSendPackages struct {
+	Versions PackageVersions
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgSendPackages) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	if pver < PackageRelayVersion {
+		str := fmt.Sprintf("sendpackages message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgSendPackages.BtcDecode", str)
+	}
+
+	versions, err := binarySerializer.Uint64(r, littleEndian)
+	if err != nil {
+		return err
+	}
+	msg.Versions = PackageVersions(versions)
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgSendPackages) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	if pver < PackageRelayVersion {
+		str := fmt.Sprintf("sendpackages message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgSendPackages.BtcEncode", str)
+	}
+
+	return binarySerializer.PutUint64(w, littleEndian, uint64(msg.Versions))
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgSendPackages) Command() string {
+	return CmdSendPackages
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgSendPackages) MaxPayloadLength(pver uint32) uint32 {
+	return 8
+}
+
+// NewMsgSendPackages returns a new bitcoin sendpackages message that conforms
+// to the Message interface.  See MsgSendPackages for details.
+func NewMsgSendPackages(versions PackageVersions) *MsgSendPackages {
+	return &MsgSendPackages{
+		Versions: versions,
+	}
+}
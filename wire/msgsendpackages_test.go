@@ -0,0 +1,75 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// TestSendPackagesWire tests the MsgSendPackages wire encode and decode for
+// various protocol versions.
+func TestSendPackagesWire(t *testing.T) {
+	tests := []struct {
+		in      *MsgSendPackages // Message to encode
+		buf     []byte           // Wire encoding
+		pver    uint32           // Protocol version for wire encoding
+		wantErr bool             // Whether encoding and decoding fail
+	}{
+		// Package relay version.
+		{
+			NewMsgSendPackages(PkgRelayAncestor),
+			[]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			PackageRelayVersion,
+			false,
+		},
+
+		// Unknown versions are preserved.
+		{
+			NewMsgSendPackages(PkgRelayAncestor | 1<<63),
+			[]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80},
+			PackageRelayVersion,
+			false,
+		},
+
+		// Protocol version before package relay.
+		{
+			NewMsgSendPackages(PkgRelayAncestor),
+			[]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			PackageRelayVersion - 1,
+			true,
+		},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode the message to wire format.
+		var buf bytes.Buffer
+		err := test.in.BtcEncode(&buf, test.pver, BaseEncoding)
+		if _, ok := err.(*MessageError); ok != test.wantErr {
+			t.Errorf("BtcEncode #%d unexpected error %v", i, err)
+			continue
+		}
+		if !test.wantErr && !bytes.Equal(buf.Bytes(), test.buf) {
+			t.Errorf("BtcEncode #%d\n got: %x want: %x", i,
+				buf.Bytes(), test.buf)
+			continue
+		}
+
+		// Decode the message from wire format.
+		var msg MsgSendPackages
+		rbuf := bytes.NewReader(test.buf)
+		err = msg.BtcDecode(rbuf, test.pver, BaseEncoding)
+		if _, ok := err.(*MessageError); ok != test.wantErr {
+			t.Errorf("BtcDecode #%d unexpected error %v", i, err)
+			continue
+		}
+		if !test.wantErr && !reflect.DeepEqual(&msg, test.in) {
+			t.Errorf("BtcDecode #%d\n got: %v want: %v", i, msg,
+				test.in)
+		}
+	}
+}
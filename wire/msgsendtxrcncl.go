@@ -0,0 +1,72 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// TxReconciliationV1 is the version of the transaction reconciliation
+// protocol defined by BIP0330.
+const TxReconciliationV1 uint32 = 1
+
+// MsgSendTxRcncl implements the Message interface and represents a bitcoin
+// sendtxrcncl message.  It is sent before the verack message to signal
+// support for transaction reconciliation (BIP0330) and to announce the salt
+// used to compute the short ids of transactions.
+//
+// This message was not added until protocol versions starting with
+// TxReconciliationVersion.
+type MsgSendTxRcncl struct {
+	Version uint32
+	Salt    uint64
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgSendTxRcncl) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	if pver < TxReconciliationVersion {
+		str := fmt.Sprintf("sendtxrcncl message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgSendTxRcncl.BtcDecode", str)
+	}
+
+	return readElements(r, &msg.Version, &msg.Salt)
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgSendTxRcncl) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	if pver < TxReconciliationVersion {
+		str := fmt.Sprintf("sendtxrcncl message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgSendTxRcncl.BtcEncode", str)
+	}
+
+	return writeElements(w, msg.Version, msg.Salt)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgSendTxRcncl) Command() string {
+	return CmdSendTxRcncl
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgSendTxRcncl) MaxPayloadLength(pver uint32) uint32 {
+	// Version 4 bytes + salt 8 bytes.
+	return 12
+}
+
+// NewMsgSendTxRcncl returns a new bitcoin sendtxrcncl message that conforms
+// to the Message interface.  See MsgSendTxRcncl for details.
+func NewMsgSendTxRcncl(version uint32, salt uint64) *MsgSendTxRcncl {
+	return &MsgSendTxRcncl{
+		Version: version,
+		Salt:    salt,
+	}
+}
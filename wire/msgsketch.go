@@ -0,0 +1,77 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// MsgSketch implements the Message interface and represents a bitcoin sketch
+// message.  It is sent in response to a reqrecon or reqsketchext message and
+// carries the serialized sketch of the local reconciliation set.  Use
+// NewSketchFromBytes to parse the sketch.
+//
+// This message was not added until protocol versions starting with
+// TxReconciliationVersion.
+type MsgSketch struct {
+	SketchData []byte
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgSketch) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	if pver < TxReconciliationVersion {
+		str := fmt.Sprintf("sketch message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgSketch.BtcDecode", str)
+	}
+
+	var err error
+	msg.SketchData, err = ReadVarBytes(r, pver,
+		MaxSketchCapacity*SketchFieldBytes, "sketch data")
+	return err
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgSketch) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	if pver < TxReconciliationVersion {
+		str := fmt.Sprintf("sketch message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgSketch.BtcEncode", str)
+	}
+
+	size := len(msg.SketchData)
+	if size > MaxSketchCapacity*SketchFieldBytes {
+		str := fmt.Sprintf("sketch size too large for message "+
+			"[size %v, max %v]", size,
+			MaxSketchCapacity*SketchFieldBytes)
+		return messageError("MsgSketch.BtcEncode", str)
+	}
+
+	return WriteVarBytes(w, pver, msg.SketchData)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgSketch) Command() string {
+	return CmdSketch
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgSketch) MaxPayloadLength(pver uint32) uint32 {
+	return uint32(VarIntSerializeSize(MaxSketchCapacity*SketchFieldBytes)) +
+		MaxSketchCapacity*SketchFieldBytes
+}
+
+// NewMsgSketch returns a new bitcoin sketch message that conforms to the
+// Message interface.  See MsgSketch for details.
+func NewMsgSketch(sketch *Sketch) *MsgSketch {
+	return &MsgSketch{
+		SketchData: sketch.Bytes(),
+	}
+}
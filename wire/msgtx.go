@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"sync"
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 )
@@ -315,20 +316,81 @@ type MsgTx struct {
 	TxIn     []*TxIn
 	TxOut    []*TxOut
 	LockTime uint32
+
+	// hashCache houses the lazily computed hashes of the transaction once
+	// caching is enabled with EnableHashCache.  It is nil otherwise.
+	hashCache *txHashCache
+}
+
+// txHashCache houses the lazily computed hashes of a transaction.
+type txHashCache struct {
+	mtx         sync.Mutex
+	txHash      *chainhash.Hash
+	witnessHash *chainhash.Hash
+}
+
+// EnableHashCache enables caching of the hashes returned by TxHash and
+// WitnessHash, which are then computed at most once.  This is intended for
+// transactions which are no longer modified, such as those received from the
+// network, and are hashed by several subsystems.
+//
+// Modifying the transaction through AddTxIn, AddTxOut, or by decoding into it
+// clears the cached hashes, but other modifications, such as assigning to
+// its fields directly, are not detected.  The caller must call
+// InvalidateHashCache after such modifications.
+//
+// This function must not be called concurrently with any other methods of the
+// transaction.  Once enabled, TxHash and WitnessHash are safe for concurrent
+// access.
+func (msg *MsgTx) EnableHashCache() {
+	if msg.hashCache == nil {
+		msg.hashCache = &txHashCache{}
+	}
+}
+
+// InvalidateHashCache clears any hashes cached since EnableHashCache was
+// called so they are recomputed on next use.  It has no effect when caching is
+// not enabled.
+func (msg *MsgTx) InvalidateHashCache() {
+	if c := msg.hashCache; c != nil {
+		c.mtx.Lock()
+		c.txHash = nil
+		c.witnessHash = nil
+		c.mtx.Unlock()
+	}
 }
 
 // AddTxIn adds a transaction input to the message.
 func (msg *MsgTx) AddTxIn(ti *TxIn) {
 	msg.TxIn = append(msg.TxIn, ti)
+	msg.InvalidateHashCache()
 }
 
 // AddTxOut adds a transaction output to the message.
 func (msg *MsgTx) AddTxOut(to *TxOut) {
 	msg.TxOut = append(msg.TxOut, to)
+	msg.InvalidateHashCache()
 }
 
-// TxHash generates the Hash for the transaction.
+// TxHash generates the Hash for the transaction.  The hash is only computed
+// once when caching is enabled via EnableHashCache.
 func (msg *MsgTx) TxHash() chainhash.Hash {
+	c := msg.hashCache
+	if c == nil {
+		return msg.txHash()
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.txHash == nil {
+		hash := msg.txHash()
+		c.txHash = &hash
+	}
+	return *c.txHash
+}
+
+// txHash computes the hash of the transaction without consulting the cache.
+func (msg *MsgTx) txHash() chainhash.Hash {
 	// Encode the transaction and calculate double sha256 on the result.
 	// Ignore the error returns since the only way the encode could fail
 	// is being out of memory or due to nil pointers, both of which would
@@ -342,19 +404,38 @@ func (msg *MsgTx) TxHash() chainhash.Hash {
 // the new witness serialization defined in BIP0141 and BIP0144. The final
 // output is used within the Segregated Witness commitment of all the witnesses
 // within a block. If a transaction has no witness data, then the witness hash,
-// is the same as its txid.
+// is the same as its txid.  The hash is only computed once when caching is
+// enabled via EnableHashCache.
 func (msg *MsgTx) WitnessHash() chainhash.Hash {
-	if msg.HasWitness() {
-		buf := bytes.NewBuffer(make([]byte, 0, msg.SerializeSize()))
-		_ = msg.Serialize(buf)
-		return chainhash.DoubleHashH(buf.Bytes())
+	if !msg.HasWitness() {
+		return msg.TxHash()
 	}
 
-	return msg.TxHash()
+	c := msg.hashCache
+	if c == nil {
+		return msg.witnessHash()
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.witnessHash == nil {
+		hash := msg.witnessHash()
+		c.witnessHash = &hash
+	}
+	return *c.witnessHash
+}
+
+// witnessHash computes the witness hash of a transaction with witness data
+// without consulting the cache.
+func (msg *MsgTx) witnessHash() chainhash.Hash {
+	buf := bytes.NewBuffer(make([]byte, 0, msg.SerializeSize()))
+	_ = msg.Serialize(buf)
+	return chainhash.DoubleHashH(buf.Bytes())
 }
 
 // Copy creates a deep copy of a transaction so that the original does not get
-// modified when the copy is manipulated.
+// modified when the copy is manipulated.  The copy doesn't reference the
+// buffer of a transaction decoded with DeserializeNoCopy.
 func (msg *MsgTx) Copy() *MsgTx {
 	// Create new tx and start by copying primitive values and making space
 	// for the transaction inputs and outputs.
@@ -433,6 +514,8 @@ func (msg *MsgTx) Copy() *MsgTx {
 // See Deserialize for decoding transactions stored to disk, such as in a
 // database, as opposed to decoding transactions from the wire.
 func (msg *MsgTx) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	msg.InvalidateHashCache()
+
 	version, err := binarySerializer.Uint32(r, littleEndian)
 	if err != nil {
 		return err
@@ -479,12 +562,20 @@ func (msg *MsgTx) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error
 		return messageError("MsgTx.BtcDecode", str)
 	}
 
+	// Scripts read from a noCopyReader reference its buffer directly, so
+	// they neither come from the pool nor need to be moved to a contiguous
+	// buffer.
+	_, noCopy := r.(*noCopyReader)
+
 	// returnScriptBuffers is a closure that returns any script buffers that
 	// were borrowed from the pool when there are any deserialization
 	// errors.  This is only valid to call before the final step which
 	// replaces the scripts with the location in a contiguous buffer and
 	// returns them.
 	returnScriptBuffers := func() {
+		if noCopy {
+			return
+		}
 		for _, txIn := range msg.TxIn {
 			if txIn == nil {
 				continue
@@ -603,6 +694,10 @@ func (msg *MsgTx) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error
 		return err
 	}
 
+	if noCopy {
+		return nil
+	}
+
 	// Create a single allocation to house all of the scripts and set each
 	// input signature script and output public key script to the
 	// appropriate subslice of the overall contiguous buffer.  Then, return
@@ -699,6 +794,20 @@ func (msg *MsgTx) DeserializeNoWitness(r io.Reader) error {
 	return msg.BtcDecode(r, 0, BaseEncoding)
 }
 
+// DeserializeNoCopy decodes a transaction from buf into the receiver in the
+// same manner as Deserialize, however the signature scripts, public key
+// scripts, and witness items of the transaction reference buf directly instead
+// of being copied.  This avoids nearly all allocations for script data, which
+// makes it well suited for callers decoding large amounts of transactions such
+// as indexers.
+//
+// The caller must not modify buf for as long as the transaction is in use, and
+// modifying the scripts of the transaction modifies buf.  Use Copy to obtain a
+// transaction which doesn't reference buf.
+func (msg *MsgTx) DeserializeNoCopy(buf []byte) error {
+	return msg.BtcDecode(&noCopyReader{buf: buf}, 0, WitnessEncoding)
+}
+
 // BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
 // This is part of the Message interface implementation.
 // See Serialize for encoding transactions to be stored to disk, such as in a
@@ -963,6 +1072,12 @@ func readScript(r io.Reader, pver uint32, maxAllowed uint32, fieldName string) (
 		return nil, messageError("readScript", str)
 	}
 
+	// Reference the script in the underlying buffer when decoding in
+	// zero-copy mode.
+	if ncr, ok := r.(*noCopyReader); ok {
+		return ncr.next(count)
+	}
+
 	b := scriptPool.Borrow(count)
 	_, err = io.ReadFull(r, b)
 	if err != nil {
@@ -778,6 +778,135 @@ func TestTxWitnessSize(t *testing.T) {
 	}
 }
 
+// TestTxDeserializeNoCopy ensures transactions decoded in zero-copy mode are
+// identical to those decoded normally, that their scripts reference the
+// provided buffer, and that Copy detaches them from it.
+func TestTxDeserializeNoCopy(t *testing.T) {
+	tests := []struct {
+		name string
+		tx   *MsgTx
+	}{
+		{"multiTx", multiTx},
+		{"multiWitnessTx", multiWitnessTx},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := test.tx.Serialize(&buf); err != nil {
+			t.Errorf("%s: Serialize: %v", test.name, err)
+			continue
+		}
+		serialized := buf.Bytes()
+
+		var want MsgTx
+		if err := want.Deserialize(bytes.NewReader(serialized)); err != nil {
+			t.Errorf("%s: Deserialize: %v", test.name, err)
+			continue
+		}
+		var tx MsgTx
+		if err := tx.DeserializeNoCopy(serialized); err != nil {
+			t.Errorf("%s: DeserializeNoCopy: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(&tx, &want) {
+			t.Errorf("%s: mismatched transaction - got %v, want %v",
+				test.name, spew.Sdump(&tx), spew.Sdump(&want))
+			continue
+		}
+
+		// Ensure the public key script references the buffer and the
+		// copy does not.
+		txCopy := tx.Copy()
+		pkScript := tx.TxOut[0].PkScript
+		idx := bytes.Index(serialized, pkScript)
+		serialized[idx] ^= 0xff
+		if pkScript[0] != serialized[idx] {
+			t.Errorf("%s: script does not reference the buffer",
+				test.name)
+		}
+		var copyBuf bytes.Buffer
+		if err := txCopy.Serialize(&copyBuf); err != nil {
+			t.Errorf("%s: Serialize: %v", test.name, err)
+			continue
+		}
+		serialized[idx] ^= 0xff
+		if !bytes.Equal(copyBuf.Bytes(), serialized) {
+			t.Errorf("%s: copy references the buffer", test.name)
+		}
+
+		// Ensure truncated buffers are rejected.
+		for i := 0; i < len(serialized); i++ {
+			var tx MsgTx
+			if err := tx.DeserializeNoCopy(serialized[:i]); err == nil {
+				t.Errorf("%s: no error for buffer truncated to %d "+
+					"bytes", test.name, i)
+				break
+			}
+		}
+	}
+}
+
+// TestTxHashCache ensures cached transaction hashes match the uncached ones
+// and that the cache is invalidated when the transaction is modified.
+func TestTxHashCache(t *testing.T) {
+	tx := multiWitnessTx.Copy()
+	wantHash := tx.TxHash()
+	wantWitnessHash := tx.WitnessHash()
+
+	tx.EnableHashCache()
+	for i := 0; i < 2; i++ {
+		if got := tx.TxHash(); got != wantHash {
+			t.Fatalf("TxHash #%d: got %v, want %v", i, got, wantHash)
+		}
+		if got := tx.WitnessHash(); got != wantWitnessHash {
+			t.Fatalf("WitnessHash #%d: got %v, want %v", i, got,
+				wantWitnessHash)
+		}
+	}
+
+	// Adding an output must invalidate the cached hashes.
+	tx.AddTxOut(NewTxOut(1, []byte{0x51}))
+	if got := tx.TxHash(); got == wantHash {
+		t.Fatalf("TxHash not updated after AddTxOut: %v", got)
+	}
+	if got := tx.WitnessHash(); got == wantWitnessHash {
+		t.Fatalf("WitnessHash not updated after AddTxOut: %v", got)
+	}
+
+	// Modifying a field directly requires explicit invalidation.
+	modified := tx.TxHash()
+	tx.LockTime++
+	if got := tx.TxHash(); got != modified {
+		t.Fatalf("TxHash changed without invalidation: %v", got)
+	}
+	tx.InvalidateHashCache()
+	if got := tx.TxHash(); got == modified {
+		t.Fatalf("TxHash not updated after invalidation: %v", got)
+	}
+
+	// Decoding into the transaction must invalidate the cached hashes.
+	var buf bytes.Buffer
+	if err := multiWitnessTx.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	if err := tx.Deserialize(&buf); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if got := tx.TxHash(); got != wantHash {
+		t.Fatalf("TxHash after Deserialize: got %v, want %v", got,
+			wantHash)
+	}
+	if got := tx.WitnessHash(); got != wantWitnessHash {
+		t.Fatalf("WitnessHash after Deserialize: got %v, want %v", got,
+			wantWitnessHash)
+	}
+
+	// Copies do not share the cache.
+	if txCopy := tx.Copy(); txCopy.hashCache != nil {
+		t.Fatal("Copy shares the hash cache")
+	}
+}
+
 // multiTx is a MsgTx with an input and output and used in various tests.
 var multiTx = &MsgTx{
 	Version: 1,
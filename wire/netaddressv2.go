@@ -0,0 +1,391 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// NetworkID identifies the network of an address as defined by BIP0155.
+type NetworkID uint8
+
+const (
+	// NetIDIPv4 identifies IPv4 addresses, which are 4 bytes.
+	NetIDIPv4 NetworkID = 1
+
+	// NetIDIPv6 identifies IPv6 addresses, which are 16 bytes.
+	NetIDIPv6 NetworkID = 2
+
+	// NetIDTorV2 identifies Tor v2 onion services, which are 10 bytes.
+	NetIDTorV2 NetworkID = 3
+
+	// NetIDTorV3 identifies Tor v3 onion services, which are identified by
+	// their 32-byte ed25519 public key.
+	NetIDTorV3 NetworkID = 4
+
+	// NetIDI2P identifies I2P destinations, which are identified by the
+	// 32-byte SHA256 of the destination.
+	NetIDI2P NetworkID = 5
+
+	// NetIDCJDNS identifies CJDNS addresses, which are 16-byte IPv6
+	// addresses in fc00::/8.
+	NetIDCJDNS NetworkID = 6
+)
+
+// MaxNetAddressV2Size is the maximum size of the address of any network,
+// including unknown ones, in an addrv2 message.
+const MaxNetAddressV2Size = 512
+
+// netIDAddrSizes houses the address sizes of the known networks.
+var netIDAddrSizes = map[NetworkID]int{
+	NetIDIPv4:  4,
+	NetIDIPv6:  16,
+	NetIDTorV2: 10,
+	NetIDTorV3: 32,
+	NetIDI2P:   32,
+	NetIDCJDNS: 16,
+}
+
+// Map of network ids back to their names for pretty printing.
+var netIDStrings = map[NetworkID]string{
+	NetIDIPv4:  "IPv4",
+	NetIDIPv6:  "IPv6",
+	NetIDTorV2: "TorV2",
+	NetIDTorV3: "TorV3",
+	NetIDI2P:   "I2P",
+	NetIDCJDNS: "CJDNS",
+}
+
+// String returns the NetworkID in human-readable form.
+func (id NetworkID) String() string {
+	if s, ok := netIDStrings[id]; ok {
+		return s
+	}
+
+	return fmt.Sprintf("Unknown NetworkID (%d)", uint8(id))
+}
+
+// IsKnown returns whether the network is one defined by BIP0155.
+func (id NetworkID) IsKnown() bool {
+	_, ok := netIDAddrSizes[id]
+	return ok
+}
+
+var (
+	// onionCatPrefix is the IPv6 prefix which is used to encode Tor v2
+	// addresses in legacy addr messages.
+	onionCatPrefix = []byte{0xfd, 0x87, 0xd8, 0x7e, 0xeb, 0x43}
+
+	// torV3Checksum is the prefix of the data hashed to compute the
+	// checksum of a Tor v3 onion address.
+	torV3Checksum = []byte(".onion checksum")
+
+	// torV3Version is the version byte of Tor v3 onion addresses.
+	torV3Version = byte(0x03)
+
+	// onionEncoding is the encoding used by Tor and I2P addresses.
+	onionEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+// NetAddressV2 defines information about a peer on the network including the
+// time it was last seen, the services it supports, its address, and port as
+// defined by BIP0155.  Unlike NetAddress, it is able to represent addresses
+// of networks other than IPv4 and IPv6, such as Tor v3 onion services, I2P,
+// and CJDNS.
+type NetAddressV2 struct {
+	// Last time the address was seen.
+	Timestamp time.Time
+
+	// Bitfield which identifies the services supported by the address.
+	Services ServiceFlag
+
+	// NetworkID identifies the network of the address and thereby how Addr
+	// is to be interpreted.
+	NetworkID NetworkID
+
+	// Addr is the address in the network specific encoding.
+	Addr []byte
+
+	// Port the peer is using.
+	Port uint16
+}
+
+// HasService returns whether the specified service is supported by the address.
+func (na *NetAddressV2) HasService(service ServiceFlag) bool {
+	return na.Services&service == service
+}
+
+// AddService adds service as a supported service by the peer generating the
+// message.
+func (na *NetAddressV2) AddService(service ServiceFlag) {
+	na.Services |= service
+}
+
+// IP returns the IP address of addresses of the IPv4, IPv6, and CJDNS
+// networks and nil for all other networks.
+func (na *NetAddressV2) IP() net.IP {
+	switch na.NetworkID {
+	case NetIDIPv4, NetIDIPv6, NetIDCJDNS:
+		return net.IP(na.Addr)
+	}
+	return nil
+}
+
+// Host returns the address in the form used to connect to it, that is an IP
+// address for IPv4, IPv6, and CJDNS addresses, a .onion address for Tor
+// addresses, and a .b32.i2p address for I2P addresses.  Addresses of unknown
+// networks are rendered with their network id and hex encoded address.
+func (na *NetAddressV2) Host() string {
+	switch na.NetworkID {
+	case NetIDIPv4, NetIDIPv6, NetIDCJDNS:
+		return net.IP(na.Addr).String()
+
+	case NetIDTorV2:
+		return strings.ToLower(onionEncoding.EncodeToString(na.Addr)) +
+			".onion"
+
+	case NetIDTorV3:
+		data := make([]byte, 0, 35)
+		data = append(data, na.Addr...)
+		data = append(data, torV3AddrChecksum(na.Addr)...)
+		data = append(data, torV3Version)
+		return strings.ToLower(onionEncoding.EncodeToString(data)) +
+			".onion"
+
+	case NetIDI2P:
+		return strings.ToLower(onionEncoding.EncodeToString(na.Addr)) +
+			".b32.i2p"
+	}
+
+	return fmt.Sprintf("net%d-%s", uint8(na.NetworkID),
+		hex.EncodeToString(na.Addr))
+}
+
+// String returns the address in the form host:port.
+func (na *NetAddressV2) String() string {
+	return net.JoinHostPort(na.Host(), fmt.Sprint(na.Port))
+}
+
+// ToLegacy returns the address as a NetAddress which can be sent in addr and
+// version messages.  Only IPv4, IPv6, and Tor v2 addresses can be represented
+// that way, so false is returned for all other networks.
+func (na *NetAddressV2) ToLegacy() (*NetAddress, bool) {
+	var ip net.IP
+	switch na.NetworkID {
+	case NetIDIPv4, NetIDIPv6:
+		ip = net.IP(na.Addr)
+	case NetIDTorV2:
+		ip = make(net.IP, 0, net.IPv6len)
+		ip = append(ip, onionCatPrefix...)
+		ip = append(ip, na.Addr...)
+	default:
+		return nil, false
+	}
+
+	return &NetAddress{
+		Timestamp: na.Timestamp,
+		Services:  na.Services,
+		IP:        ip,
+		Port:      na.Port,
+	}, true
+}
+
+// NewNetAddressV2 returns a new NetAddressV2 using the provided timestamp,
+// services, network, address, and port.  The timestamp is rounded to single
+// second precision.
+func NewNetAddressV2(timestamp time.Time, services ServiceFlag,
+	netID NetworkID, addr []byte, port uint16) *NetAddressV2 {
+
+	return &NetAddressV2{
+		Timestamp: time.Unix(timestamp.Unix(), 0),
+		Services:  services,
+		NetworkID: netID,
+		Addr:      addr,
+		Port:      port,
+	}
+}
+
+// NetAddressV2FromLegacy converts the passed NetAddress to a NetAddressV2.
+// IPv4-mapped IPv6 addresses become IPv4 addresses and addresses in the range
+// used to encode Tor v2 addresses become Tor v2 addresses.
+func NetAddressV2FromLegacy(na *NetAddress) *NetAddressV2 {
+	netID, addr := NetIDIPv6, []byte(na.IP.To16())
+	switch {
+	case na.IP.To4() != nil:
+		netID, addr = NetIDIPv4, []byte(na.IP.To4())
+	case addr == nil:
+		addr = make([]byte, net.IPv6len)
+	case bytes.HasPrefix(addr, onionCatPrefix):
+		netID, addr = NetIDTorV2, addr[len(onionCatPrefix):]
+	}
+
+	return &NetAddressV2{
+		Timestamp: na.Timestamp,
+		Services:  na.Services,
+		NetworkID: netID,
+		Addr:      addr,
+		Port:      na.Port,
+	}
+}
+
+// NewNetAddressV2FromHost returns a new NetAddressV2 for the passed host,
+// which must be an IP address, a Tor v2 or v3 .onion address, or an I2P
+// .b32.i2p address.  Host names are not resolved.  IP addresses in fc00::/8
+// are treated as IPv6 addresses since they can't be distinguished from CJDNS
+// addresses.
+func NewNetAddressV2FromHost(host string, port uint16,
+	services ServiceFlag) (*NetAddressV2, error) {
+
+	now := time.Now()
+	if ip := net.ParseIP(host); ip != nil {
+		na := NewNetAddressIPPort(ip, port, services)
+		return NetAddressV2FromLegacy(na), nil
+	}
+
+	lowerHost := strings.ToLower(host)
+	switch {
+	case strings.HasSuffix(lowerHost, ".onion"):
+		encoded := strings.TrimSuffix(lowerHost, ".onion")
+		data, err := onionEncoding.DecodeString(strings.ToUpper(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("invalid onion address %s: %v",
+				host, err)
+		}
+
+		switch len(data) {
+		case 10:
+			return NewNetAddressV2(now, services, NetIDTorV2, data,
+				port), nil
+
+		case 35:
+			pubKey := data[:32]
+			checksum := torV3AddrChecksum(pubKey)
+			if data[34] != torV3Version ||
+				!bytes.Equal(data[32:34], checksum) {
+
+				return nil, fmt.Errorf("invalid onion address "+
+					"%s: bad checksum or version", host)
+			}
+			return NewNetAddressV2(now, services, NetIDTorV3,
+				pubKey, port), nil
+		}
+
+		return nil, fmt.Errorf("invalid onion address %s: unexpected "+
+			"length", host)
+
+	case strings.HasSuffix(lowerHost, ".b32.i2p"):
+		encoded := strings.TrimSuffix(lowerHost, ".b32.i2p")
+		data, err := onionEncoding.DecodeString(strings.ToUpper(encoded))
+		if err != nil || len(data) != 32 {
+			return nil, fmt.Errorf("invalid i2p address %s", host)
+		}
+		return NewNetAddressV2(now, services, NetIDI2P, data, port), nil
+	}
+
+	return nil, fmt.Errorf("unsupported host %s", host)
+}
+
+// torV3AddrChecksum returns the checksum of the Tor v3 onion address with the
+// passed public key.
+func torV3AddrChecksum(pubKey []byte) []byte {
+	h := sha3.New256()
+	h.Write(torV3Checksum)
+	h.Write(pubKey)
+	h.Write([]byte{torV3Version})
+	return h.Sum(nil)[:2]
+}
+
+// maxNetAddressV2Payload returns the max payload size for a NetAddressV2 in
+// an addrv2 message.
+func maxNetAddressV2Payload() uint32 {
+	// Timestamp 4 bytes + services (varInt) + network id 1 byte + address
+	// (varInt + max address size) + port 2 bytes.
+	return 4 + MaxVarIntPayload + 1 +
+		uint32(VarIntSerializeSize(MaxNetAddressV2Size)) +
+		MaxNetAddressV2Size + 2
+}
+
+// readNetAddressV2 reads an encoded NetAddressV2 from r.  Addresses of known
+// networks must have the size defined for the network, while addresses of
+// unknown networks are read as is so they can be ignored by the caller.
+func readNetAddressV2(r io.Reader, pver uint32, na *NetAddressV2) error {
+	err := readElement(r, (*uint32Time)(&na.Timestamp))
+	if err != nil {
+		return err
+	}
+
+	services, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	na.Services = ServiceFlag(services)
+
+	netID, err := binarySerializer.Uint8(r)
+	if err != nil {
+		return err
+	}
+	na.NetworkID = NetworkID(netID)
+
+	na.Addr, err = ReadVarBytes(r, pver, MaxNetAddressV2Size, "address")
+	if err != nil {
+		return err
+	}
+	if size, ok := netIDAddrSizes[na.NetworkID]; ok && len(na.Addr) != size {
+		str := fmt.Sprintf("invalid address size %d for network %v",
+			len(na.Addr), na.NetworkID)
+		return messageError("readNetAddressV2", str)
+	}
+
+	// Sigh.  Bitcoin protocol mixes little and big endian.
+	na.Port, err = binarySerializer.Uint16(r, bigEndian)
+	return err
+}
+
+// writeNetAddressV2 serializes a NetAddressV2 to w.
+func writeNetAddressV2(w io.Writer, pver uint32, na *NetAddressV2) error {
+	if size, ok := netIDAddrSizes[na.NetworkID]; ok && len(na.Addr) != size {
+		str := fmt.Sprintf("invalid address size %d for network %v",
+			len(na.Addr), na.NetworkID)
+		return messageError("writeNetAddressV2", str)
+	}
+	if len(na.Addr) > MaxNetAddressV2Size {
+		str := fmt.Sprintf("address size %d exceeds max %d",
+			len(na.Addr), MaxNetAddressV2Size)
+		return messageError("writeNetAddressV2", str)
+	}
+
+	err := writeElement(w, uint32(na.Timestamp.Unix()))
+	if err != nil {
+		return err
+	}
+	err = WriteVarInt(w, pver, uint64(na.Services))
+	if err != nil {
+		return err
+	}
+	err = binarySerializer.PutUint8(w, uint8(na.NetworkID))
+	if err != nil {
+		return err
+	}
+	err = WriteVarBytes(w, pver, na.Addr)
+	if err != nil {
+		return err
+	}
+
+	// Sigh.  Bitcoin protocol mixes little and big endian.
+	var port [2]byte
+	binary.BigEndian.PutUint16(port[:], na.Port)
+	_, err = w.Write(port[:])
+	return err
+}
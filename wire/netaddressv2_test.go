@@ -0,0 +1,113 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+// TestNetAddressV2FromHost ensures hosts of the supported networks are parsed
+// into the expected network and rendered back to the same host.
+func TestNetAddressV2FromHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		netID   NetworkID
+		addrLen int
+	}{
+		{"127.0.0.1", NetIDIPv4, 4},
+		{"2001:db8::1", NetIDIPv6, 16},
+		{"aaaaaaaaaaaaaaaa.onion", NetIDTorV2, 10},
+		{
+			"pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryd.onion",
+			NetIDTorV3, 32,
+		},
+		{
+			"ukeu3k5oycgaauneqgtnvselmt4yemvoilkln7jpvamvfx7dnkdq.b32.i2p",
+			NetIDI2P, 32,
+		},
+	}
+
+	for _, test := range tests {
+		na, err := NewNetAddressV2FromHost(test.host, 8333, SFNodeNetwork)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.host, err)
+			continue
+		}
+		if na.NetworkID != test.netID {
+			t.Errorf("%s: network %v, want %v", test.host,
+				na.NetworkID, test.netID)
+			continue
+		}
+		if len(na.Addr) != test.addrLen {
+			t.Errorf("%s: address length %d, want %d", test.host,
+				len(na.Addr), test.addrLen)
+			continue
+		}
+		if na.Host() != test.host {
+			t.Errorf("%s: host round trip got %s", test.host,
+				na.Host())
+		}
+	}
+
+	invalid := []string{
+		// Bad checksum.
+		"pg6mmjiyjmcrsslvykfwnntlaru7p5svn6y2ymmju6nubxndf4pscryc.onion",
+		// Bad length.
+		"aaaaaaaaaaaaaaaaaaaa.onion",
+		"aaaa.b32.i2p",
+		// Host names aren't resolved.
+		"example.com",
+	}
+	for _, host := range invalid {
+		if _, err := NewNetAddressV2FromHost(host, 8333, 0); err == nil {
+			t.Errorf("%s: expected error", host)
+		}
+	}
+}
+
+// TestNetAddressV2Legacy ensures addresses are converted between NetAddress
+// and NetAddressV2 where possible.
+func TestNetAddressV2Legacy(t *testing.T) {
+	tests := []struct {
+		ip    string
+		netID NetworkID
+		addr  []byte
+	}{
+		{"192.168.0.1", NetIDIPv4, []byte{192, 168, 0, 1}},
+		{"::ffff:192.168.0.1", NetIDIPv4, []byte{192, 168, 0, 1}},
+		{"2001:db8::1", NetIDIPv6, net.ParseIP("2001:db8::1")},
+		{
+			"fd87:d87e:eb43:0102:0304:0506:0708:090a", NetIDTorV2,
+			[]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		},
+	}
+
+	for _, test := range tests {
+		legacy := NewNetAddressIPPort(net.ParseIP(test.ip), 8333,
+			SFNodeNetwork)
+		na := NetAddressV2FromLegacy(legacy)
+		if na.NetworkID != test.netID || !bytes.Equal(na.Addr, test.addr) {
+			t.Errorf("%s: got %v %x, want %v %x", test.ip,
+				na.NetworkID, na.Addr, test.netID, test.addr)
+			continue
+		}
+		if na.Port != 8333 || na.Services != SFNodeNetwork {
+			t.Errorf("%s: port or services not converted", test.ip)
+			continue
+		}
+
+		back, ok := na.ToLegacy()
+		if !ok || !back.IP.Equal(legacy.IP) {
+			t.Errorf("%s: legacy round trip got %v", test.ip, back)
+		}
+	}
+
+	torV3 := &NetAddressV2{NetworkID: NetIDTorV3, Addr: make([]byte, 32)}
+	if _, ok := torV3.ToLegacy(); ok {
+		t.Error("ToLegacy: unexpected conversion of Tor v3 address")
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import "io"
+
+// noCopyReader is an io.Reader over a byte slice which additionally allows
+// variable length fields to be taken as sub-slices of the underlying buffer
+// instead of being copied.  Decoders detect it to implement the zero-copy
+// deserialization mode.
+//
+// Handing out sub-slices of the buffer is safe for the following reasons.
+// The sub-slices keep the whole buffer reachable, so the garbage collector
+// never frees it while a decoded message references it.  The caller owns the
+// buffer and must not modify it for as long as the message is in use, as
+// documented on the DeserializeNoCopy methods.
+//
+// Only variable length fields are taken as sub-slices.  Fixed size fields such
+// as hashes and integers are copied through Read, so they never alias the
+// buffer.  The capacity of every sub-slice is limited to its length, so
+// appending to one field reallocates instead of overwriting the field after
+// it.  Finally, the sub-slices are never returned to the script pool nor moved
+// to the contiguous script buffer, so the pool can't hand the memory of the
+// buffer to another decoder.
+type noCopyReader struct {
+	buf []byte
+	off int
+}
+
+// Read reads the next len(p) bytes from the buffer into p.  It is part of the
+// io.Reader interface implementation.
+func (r *noCopyReader) Read(p []byte) (int, error) {
+	if r.off >= len(r.buf) {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	n := copy(p, r.buf[r.off:])
+	r.off += n
+	return n, nil
+}
+
+// next returns the next n bytes of the buffer without copying them.  The
+// capacity of the returned slice is limited to its length so appending to it
+// can't overwrite the data following it.
+func (r *noCopyReader) next(n uint64) ([]byte, error) {
+	remaining := uint64(len(r.buf) - r.off)
+	if n > remaining {
+		r.off = len(r.buf)
+		if remaining == 0 {
+			return nil, io.EOF
+		}
+		return nil, io.ErrUnexpectedEOF
+	}
+	end := r.off + int(n)
+	b := r.buf[r.off:end:end]
+	r.off = end
+	return b, nil
+}
@@ -51,6 +51,27 @@ const (
 	// FeeFilterVersion is the protocol version which added a new
 	// feefilter message.
 	FeeFilterVersion uint32 = 70013
+
+	// CompactBlocksVersion is the protocol version which added the
+	// compact block messages defined by BIP0152.
+	CompactBlocksVersion uint32 = 70014
+
+	// PackageRelayVersion is the protocol version from which the package
+	// relay messages defined by BIP0331 may be used.  Package relay
+	// requires wtxid based relay, which was added in this version.
+	PackageRelayVersion uint32 = 70016
+
+	// AddrV2Version is the protocol version from which peers are sent a
+	// sendaddrv2 message to signal support for addrv2 messages (BIP0155).
+	// The messages are valid for all protocol versions, but older peers
+	// may not know about them.
+	AddrV2Version uint32 = 70016
+
+	// TxReconciliationVersion is the protocol version from which the
+	// transaction reconciliation messages defined by BIP0330 (Erlay) may
+	// be used.  Like package relay, reconciliation relies on wtxid based
+	// relay.
+	TxReconciliationVersion uint32 = 70016
 )
 
 // ServiceFlag identifies services supported by a bitcoin peer.
@@ -86,6 +107,10 @@ const (
 	// SFNode2X is a flag used to indicate a peer is running the Segwit2X
 	// software.
 	SFNode2X
+
+	// SFNodeP2PV2 is a flag used to indicate a peer supports the BIP-324
+	// v2 encrypted transport protocol.
+	SFNodeP2PV2 ServiceFlag = 1 << 11
 )
 
 // Map of service flags back to their constant names for pretty printing.
@@ -98,6 +123,7 @@ var sfStrings = map[ServiceFlag]string{
 	SFNodeBit5:    "SFNodeBit5",
 	SFNodeCF:      "SFNodeCF",
 	SFNode2X:      "SFNode2X",
+	SFNodeP2PV2:   "SFNodeP2PV2",
 }
 
 // orderedSFStrings is an ordered list of service flags from highest to
@@ -111,6 +137,7 @@ var orderedSFStrings = []ServiceFlag{
 	SFNodeBit5,
 	SFNodeCF,
 	SFNode2X,
+	SFNodeP2PV2,
 }
 
 // String returns the ServiceFlag in human-readable form.
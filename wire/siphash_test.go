@@ -0,0 +1,38 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"testing"
+)
+
+// TestSipHash24 ensures SipHash-2-4 produces the results of the reference
+// implementation for messages of various lengths.
+func TestSipHash24(t *testing.T) {
+	// The reference test vectors use the key 00 01 02 ... 0f and messages
+	// consisting of the bytes 00 01 02 ... of increasing length.
+	const k0, k1 = 0x0706050403020100, 0x0f0e0d0c0b0a0908
+	tests := []struct {
+		length int
+		want   uint64
+	}{
+		{0, 0x726fdb47dd0e0e31},
+		{1, 0x74f839c593dc67fd},
+		{7, 0xab0200f58b01d137},
+		{8, 0x93f5f5799a932462},
+		{15, 0xa129ca6149be45e5},
+	}
+
+	for _, test := range tests {
+		data := make([]byte, test.length)
+		for i := range data {
+			data[i] = byte(i)
+		}
+		if got := sipHash24(k0, k1, data); got != test.want {
+			t.Errorf("sipHash24 length %d: got %016x, want %016x",
+				test.length, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// SketchFieldBytes is the number of bytes of each element of the
+	// sketches used for transaction reconciliation.  Transactions are
+	// identified by 32-bit short ids, so the sketches operate in
+	// GF(2^32).
+	SketchFieldBytes = 4
+
+	// MaxSketchCapacity is the maximum capacity of a sketch exchanged
+	// during transaction reconciliation.  It also bounds the number of
+	// short ids in a reconcildiff message.
+	MaxSketchCapacity = 8192
+)
+
+// Sketch houses the syndromes of a PinSketch over GF(2^32) in a form which is
+// compatible with the serialization used by the minisketch library.  A sketch
+// with capacity c consists of c syndromes, each of which is serialized as a
+// 32-bit little-endian value, so its serialized size is c*SketchFieldBytes.
+//
+// This type only provides the container and the operations which do not
+// depend on the field arithmetic, such as combining sketches.  Computing and
+// decoding sketches is left to the reconciliation logic.
+type Sketch struct {
+	Syndromes []uint32
+}
+
+// NewSketch returns an empty sketch with the given capacity.
+func NewSketch(capacity int) *Sketch {
+	return &Sketch{
+		Syndromes: make([]uint32, capacity),
+	}
+}
+
+// NewSketchFromBytes returns the sketch serialized in the passed bytes.  An
+// error is returned if the serialized sketch is malformed or exceeds
+// MaxSketchCapacity.
+func NewSketchFromBytes(b []byte) (*Sketch, error) {
+	if len(b)%SketchFieldBytes != 0 {
+		str := fmt.Sprintf("sketch length %d is not a multiple of %d",
+			len(b), SketchFieldBytes)
+		return nil, messageError("NewSketchFromBytes", str)
+	}
+	capacity := len(b) / SketchFieldBytes
+	if capacity > MaxSketchCapacity {
+		str := fmt.Sprintf("sketch capacity %d exceeds max %d",
+			capacity, MaxSketchCapacity)
+		return nil, messageError("NewSketchFromBytes", str)
+	}
+
+	sketch := NewSketch(capacity)
+	for i := range sketch.Syndromes {
+		offset := i * SketchFieldBytes
+		sketch.Syndromes[i] = binary.LittleEndian.Uint32(b[offset:])
+	}
+	return sketch, nil
+}
+
+// Capacity returns the maximum number of differences the sketch is able to
+// recover.
+func (s *Sketch) Capacity() int {
+	return len(s.Syndromes)
+}
+
+// Bytes returns the serialized sketch.
+func (s *Sketch) Bytes() []byte {
+	b := make([]byte, len(s.Syndromes)*SketchFieldBytes)
+	for i, syndrome := range s.Syndromes {
+		binary.LittleEndian.PutUint32(b[i*SketchFieldBytes:], syndrome)
+	}
+	return b
+}
+
+// Merge combines the passed sketch into s so that s becomes a sketch of the
+// symmetric difference of the sets of both sketches.  As with minisketch, the
+// capacity of the result is the smaller of both capacities.
+func (s *Sketch) Merge(other *Sketch) {
+	if other.Capacity() < s.Capacity() {
+		s.Syndromes = s.Syndromes[:other.Capacity()]
+	}
+	for i := range s.Syndromes {
+		s.Syndromes[i] ^= other.Syndromes[i]
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// TestSketchSerialize ensures sketches serialize in the minisketch format and
+// that malformed serialized sketches are rejected.
+func TestSketchSerialize(t *testing.T) {
+	sketch := &Sketch{Syndromes: []uint32{0x04030201, 0xddccbbaa}}
+	want := []byte{0x01, 0x02, 0x03, 0x04, 0xaa, 0xbb, 0xcc, 0xdd}
+	if got := sketch.Bytes(); !bytes.Equal(got, want) {
+		t.Fatalf("Bytes: got %x, want %x", got, want)
+	}
+
+	parsed, err := NewSketchFromBytes(want)
+	if err != nil {
+		t.Fatalf("NewSketchFromBytes: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, sketch) {
+		t.Fatalf("NewSketchFromBytes: got %v, want %v", parsed, sketch)
+	}
+	if parsed.Capacity() != 2 {
+		t.Fatalf("Capacity: got %d, want 2", parsed.Capacity())
+	}
+
+	tests := []struct {
+		name string
+		buf  []byte
+	}{
+		{"partial element", []byte{0x01, 0x02, 0x03}},
+		{"too large", make([]byte, (MaxSketchCapacity+1)*SketchFieldBytes)},
+	}
+	for _, test := range tests {
+		_, err := NewSketchFromBytes(test.buf)
+		if _, ok := err.(*MessageError); !ok {
+			t.Errorf("%s: expected MessageError, got %v", test.name,
+				err)
+		}
+	}
+}
+
+// TestSketchMerge ensures merging sketches combines their syndromes and
+// truncates the result to the smaller capacity.
+func TestSketchMerge(t *testing.T) {
+	a := &Sketch{Syndromes: []uint32{0x0f, 0xf0, 0xff}}
+	b := &Sketch{Syndromes: []uint32{0xff, 0xff}}
+
+	a.Merge(b)
+	want := []uint32{0xf0, 0x0f}
+	if !reflect.DeepEqual(a.Syndromes, want) {
+		t.Fatalf("Merge: got %x, want %x", a.Syndromes, want)
+	}
+
+	// Merging a sketch with itself results in an empty sketch.
+	a.Merge(a)
+	if !reflect.DeepEqual(a.Syndromes, []uint32{0, 0}) {
+		t.Fatalf("Merge: got %x, want empty sketch", a.Syndromes)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package v2transport
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// rekeyInterval is the number of messages encrypted with the same key by both
+// forward secure ciphers before the key is replaced.
+const rekeyInterval = 224
+
+// fsChaCha20 is the forward secure stream cipher used to encrypt the length
+// prefix of each packet.  Consecutive messages consume a single continuous
+// keystream and after every rekeyInterval messages the next 32 bytes of
+// keystream become the new key.
+type fsChaCha20 struct {
+	key          [32]byte
+	stream       *chacha20.Cipher
+	chunkCounter uint32
+	rekeyCounter uint64
+}
+
+// newFSChaCha20 returns a forward secure stream cipher using the passed key.
+func newFSChaCha20(key []byte) *fsChaCha20 {
+	c := &fsChaCha20{}
+	copy(c.key[:], key)
+	c.resetStream()
+	return c
+}
+
+// resetStream starts a new keystream for the current key and rekey counter.
+func (c *fsChaCha20) resetStream() {
+	var nonce [chacha20.NonceSize]byte
+	binary.LittleEndian.PutUint64(nonce[4:], c.rekeyCounter)
+
+	// The key and nonce have the correct sizes, so this can't fail.
+	c.stream, _ = chacha20.NewUnauthenticatedCipher(c.key[:], nonce[:])
+}
+
+// crypt encrypts or decrypts the passed chunk in place.
+func (c *fsChaCha20) crypt(chunk []byte) {
+	c.stream.XORKeyStream(chunk, chunk)
+
+	c.chunkCounter++
+	if c.chunkCounter == rekeyInterval {
+		var newKey [32]byte
+		c.stream.XORKeyStream(newKey[:], newKey[:])
+		c.key = newKey
+		c.chunkCounter = 0
+		c.rekeyCounter++
+		c.resetStream()
+	}
+}
+
+// fsChaCha20Poly1305 is the forward secure AEAD used to encrypt the contents
+// of each packet.  The nonce is derived from a packet counter and after every
+// rekeyInterval packets the key is replaced by keystream generated under a
+// dedicated nonce.
+type fsChaCha20Poly1305 struct {
+	aead          cipher.AEAD
+	packetCounter uint32
+	rekeyCounter  uint64
+}
+
+// newFSChaCha20Poly1305 returns a forward secure AEAD using the passed key.
+func newFSChaCha20Poly1305(key []byte) *fsChaCha20Poly1305 {
+	// The key has the correct size, so this can't fail.
+	aead, _ := chacha20poly1305.New(key)
+	return &fsChaCha20Poly1305{aead: aead}
+}
+
+// nonce returns the nonce for the current packet.
+func (c *fsChaCha20Poly1305) nonce() []byte {
+	var nonce [chacha20poly1305.NonceSize]byte
+	binary.LittleEndian.PutUint32(nonce[:4], c.packetCounter)
+	binary.LittleEndian.PutUint64(nonce[4:], c.rekeyCounter)
+	return nonce[:]
+}
+
+// nextPacket advances the packet counter and rekeys when required.
+func (c *fsChaCha20Poly1305) nextPacket() {
+	c.packetCounter++
+	if c.packetCounter != rekeyInterval {
+		return
+	}
+
+	// The new key is the first 32 bytes of keystream following the block
+	// used for the Poly1305 key under the reserved nonce.  Encrypting zeros
+	// yields exactly that keystream.
+	var nonce [chacha20poly1305.NonceSize]byte
+	binary.LittleEndian.PutUint32(nonce[:4], 0xffffffff)
+	binary.LittleEndian.PutUint64(nonce[4:], c.rekeyCounter)
+	var zeros [32]byte
+	newKey := c.aead.Seal(nil, nonce[:], zeros[:], nil)[:32]
+	c.aead, _ = chacha20poly1305.New(newKey)
+
+	c.packetCounter = 0
+	c.rekeyCounter++
+}
+
+// seal encrypts and authenticates plaintext along with the additional data
+// and appends the result to dst.
+func (c *fsChaCha20Poly1305) seal(dst, plaintext, aad []byte) []byte {
+	ret := c.aead.Seal(dst, c.nonce(), plaintext, aad)
+	c.nextPacket()
+	return ret
+}
+
+// open authenticates and decrypts ciphertext along with the additional data
+// and appends the result to dst.  The packet counter only advances when the
+// ciphertext is authentic.
+func (c *fsChaCha20Poly1305) open(dst, ciphertext, aad []byte) ([]byte, error) {
+	ret, err := c.aead.Open(dst, c.nonce(), ciphertext, aad)
+	if err != nil {
+		return nil, err
+	}
+	c.nextPacket()
+	return ret, nil
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package v2transport implements the BIP-324 version 2 encrypted transport
+protocol for bitcoin peer connections.
+
+The v1 protocol implemented by the wire package sends every message in
+plaintext behind a fixed header, which makes bitcoin traffic trivial to
+identify and tamper with.  The v2 protocol starts every connection with an
+ElligatorSwift encoded key exchange, which is indistinguishable from random
+bytes, and then encrypts every message with ChaCha20-Poly1305 using keys that
+are replaced periodically for forward secrecy.
+
+Usage
+
+A Transport wraps an established connection.  Both sides call Handshake first
+and then use ReadMessage and WriteMessage in place of the functions of the same
+name in the wire package:
+
+	t := v2transport.NewTransport(conn, wire.MainNet, true)
+	if err := t.Handshake(); err != nil {
+		// Handle the error.
+	}
+	_, err := t.WriteMessage(msg, pver, wire.WitnessEncoding)
+
+A responder which receives a v1 version message instead of a public key fails
+the handshake with ErrV1Fallback.  The bytes consumed up to that point are
+available via Buffered so the caller can continue the connection with the v1
+protocol.  Initiators have no such fallback since a v1 peer simply doesn't
+respond, so callers should only initiate v2 connections to peers which are
+known to support it.
+
+Message Types
+
+Commonly used messages are sent with the one byte message type ids assigned by
+BIP-324.  All other messages use the full 12-byte command, so messages which
+are unknown to this package are still supported as long as the wire package
+can decode them.
+*/
+package v2transport
@@ -0,0 +1,52 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package v2transport
+
+import "github.com/btcsuite/btcd/wire"
+
+// shortIDCommands maps the one byte message type ids defined by BIP-324 to the
+// commands they abbreviate.  Index 0 is reserved to signal that a full 12-byte
+// command follows.
+var shortIDCommands = [...]string{
+	1:  wire.CmdAddr,
+	2:  wire.CmdBlock,
+	3:  wire.CmdBlockTxn,
+	4:  wire.CmdCmpctBlock,
+	5:  wire.CmdFeeFilter,
+	6:  wire.CmdFilterAdd,
+	7:  wire.CmdFilterClear,
+	8:  wire.CmdFilterLoad,
+	9:  wire.CmdGetBlocks,
+	10: wire.CmdGetBlockTxn,
+	11: wire.CmdGetData,
+	12: wire.CmdGetHeaders,
+	13: wire.CmdHeaders,
+	14: wire.CmdInv,
+	15: wire.CmdMemPool,
+	16: wire.CmdMerkleBlock,
+	17: wire.CmdNotFound,
+	18: wire.CmdPing,
+	19: wire.CmdPong,
+	20: wire.CmdSendCmpct,
+	21: wire.CmdTx,
+	22: wire.CmdGetCFilters,
+	23: wire.CmdCFilter,
+	24: wire.CmdGetCFHeaders,
+	25: wire.CmdCFHeaders,
+	26: wire.CmdGetCFCheckpt,
+	27: wire.CmdCFCheckpt,
+	28: wire.CmdAddrV2,
+}
+
+// commandShortIDs is the reverse mapping of shortIDCommands.
+var commandShortIDs = func() map[string]byte {
+	ids := make(map[string]byte, len(shortIDCommands))
+	for id, cmd := range shortIDCommands {
+		if cmd != "" {
+			ids[cmd] = byte(id)
+		}
+	}
+	return ids
+}()
@@ -0,0 +1,542 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package v2transport
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"unicode/utf8"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/wire"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// MaxGarbageLen is the maximum number of garbage bytes that may follow
+	// the public key of either party during the handshake.
+	MaxGarbageLen = 4095
+
+	// garbageTerminatorLen is the length of the garbage terminators.
+	garbageTerminatorLen = 16
+
+	// lengthFieldLen is the length of the encrypted length prefix of each
+	// packet.
+	lengthFieldLen = 3
+
+	// headerLen is the length of the header byte which is encrypted along
+	// with the contents of each packet.
+	headerLen = 1
+
+	// tagLen is the length of the Poly1305 authentication tag appended to
+	// the encrypted contents of each packet.
+	tagLen = 16
+
+	// ignoreBit is set in the header byte of decoy packets which must be
+	// discarded by the receiver.
+	ignoreBit = 1 << 7
+
+	// maxContentsLen is the largest packet contents accepted, which is the
+	// largest message payload along with a full 13-byte message type.
+	maxContentsLen = 1 + wire.CommandSize + wire.MaxMessagePayload
+
+	// sharedSecretSalt is the prefix of the HKDF salt used to derive the
+	// session keys.  The network magic is appended to it.
+	sharedSecretSalt = "bitcoin_v2_shared_secret"
+)
+
+var (
+	// ErrV1Fallback is returned from the handshake of a responding
+	// transport when the remote peer started the connection with a v1
+	// version message.  The bytes read so far are available via Buffered
+	// so the caller can continue with the v1 protocol.
+	ErrV1Fallback = errors.New("remote peer uses the v1 protocol")
+
+	// ErrGarbageTooLong is returned when the remote peer didn't send the
+	// expected garbage terminator within MaxGarbageLen bytes.
+	ErrGarbageTooLong = errors.New("garbage terminator not found")
+
+	// ErrPacketTooLarge is returned when a packet exceeds the maximum
+	// allowed size.
+	ErrPacketTooLarge = errors.New("packet too large")
+
+	// ErrDecryptionFailed is returned when a packet fails to
+	// authenticate.
+	ErrDecryptionFailed = errors.New("packet decryption failed")
+
+	// ErrHandshakeIncomplete is returned when messages are sent or
+	// received before a successful handshake.
+	ErrHandshakeIncomplete = errors.New("v2 handshake not completed")
+)
+
+// Transport implements the BIP-324 v2 encrypted transport protocol on top of
+// an underlying connection.  After a successful Handshake, ReadMessage and
+// WriteMessage replace the wire package functions of the same name.  Reading
+// and writing may happen concurrently from different goroutines, but
+// concurrent reads or concurrent writes are not safe.
+type Transport struct {
+	rw        io.ReadWriter
+	btcnet    wire.BitcoinNet
+	initiator bool
+
+	sendLen    *fsChaCha20
+	sendPacket *fsChaCha20Poly1305
+	recvLen    *fsChaCha20
+	recvPacket *fsChaCha20Poly1305
+
+	sendTerminator [garbageTerminatorLen]byte
+	recvTerminator [garbageTerminatorLen]byte
+	sessionID      [32]byte
+
+	limits      *wire.MessageLimits
+	buffered    []byte
+	established bool
+}
+
+// NewTransport returns a v2 transport for the passed connection and network.
+// The initiator flag must be set for the party which opened the connection.
+func NewTransport(rw io.ReadWriter, btcnet wire.BitcoinNet,
+	initiator bool) *Transport {
+
+	return &Transport{
+		rw:        rw,
+		btcnet:    btcnet,
+		initiator: initiator,
+	}
+}
+
+// SetMessageLimits sets the limits enforced on messages read from the
+// transport in addition to the protocol limits.  It must not be called
+// concurrently with ReadMessage.
+func (t *Transport) SetMessageLimits(limits *wire.MessageLimits) {
+	t.limits = limits
+}
+
+// SessionID returns the session id derived during the handshake.  Both
+// parties arrive at the same value, so it may be compared out of band to
+// detect a man in the middle.
+func (t *Transport) SessionID() [32]byte {
+	return t.sessionID
+}
+
+// Buffered returns the bytes read from the connection by a handshake that
+// failed with ErrV1Fallback.  They are the start of the remote peer's v1
+// version message.
+func (t *Transport) Buffered() []byte {
+	return t.buffered
+}
+
+// v1Prefix returns the first 16 bytes of a v1 version message on the
+// transport's network.
+func (t *Transport) v1Prefix() []byte {
+	var prefix [16]byte
+	binary.LittleEndian.PutUint32(prefix[:4], uint32(t.btcnet))
+	copy(prefix[4:], wire.CmdVersion)
+	return prefix[:]
+}
+
+// randomGarbage returns a random amount of random bytes to send after the
+// public key.
+func randomGarbage() ([]byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(MaxGarbageLen+1))
+	if err != nil {
+		return nil, err
+	}
+	garbage := make([]byte, n.Int64())
+	if _, err := rand.Read(garbage); err != nil {
+		return nil, err
+	}
+	return garbage, nil
+}
+
+// Handshake performs the BIP-324 key exchange with the remote peer and must
+// be called before any messages are sent or received.  A responding transport
+// returns ErrV1Fallback when the remote peer turns out to speak the v1
+// protocol.
+func (t *Transport) Handshake() error {
+	privKey, ourKey, err := btcec.NewEllswiftPrivateKey()
+	if err != nil {
+		return err
+	}
+	garbage, err := randomGarbage()
+	if err != nil {
+		return err
+	}
+
+	// The handshake is symmetric enough that both sides may be blocked
+	// writing while the other one is blocked writing as well, so writes are
+	// performed by a separate goroutine.
+	writes := make(chan []byte, 3)
+	writeErr := make(chan error, 1)
+	go func() {
+		var err error
+		for b := range writes {
+			if err == nil {
+				_, err = t.rw.Write(b)
+			}
+		}
+		writeErr <- err
+	}()
+
+	err = t.handshake(privKey, &ourKey, garbage, writes)
+	close(writes)
+	if err != nil {
+		return err
+	}
+	if err := <-writeErr; err != nil {
+		return err
+	}
+
+	t.established = true
+	return nil
+}
+
+// handshake performs the actual handshake, queueing everything to send on the
+// passed channel.
+func (t *Transport) handshake(privKey *btcec.PrivateKey,
+	ourKey *[btcec.EllswiftPubKeyLen]byte, garbage []byte,
+	writes chan<- []byte) error {
+
+	keyAndGarbage := make([]byte, 0, len(ourKey)+len(garbage))
+	keyAndGarbage = append(keyAndGarbage, ourKey[:]...)
+	keyAndGarbage = append(keyAndGarbage, garbage...)
+	if t.initiator {
+		writes <- keyAndGarbage
+	}
+
+	// Read the public key of the remote peer.  A responder checks the
+	// start of it first so it can fall back to v1 without waiting for
+	// more bytes than a v1 peer would send unprompted.
+	var theirKey [btcec.EllswiftPubKeyLen]byte
+	prefixLen := len(t.v1Prefix())
+	if _, err := io.ReadFull(t.rw, theirKey[:prefixLen]); err != nil {
+		return err
+	}
+	if !t.initiator && bytes.Equal(theirKey[:prefixLen], t.v1Prefix()) {
+		t.buffered = append([]byte(nil), theirKey[:prefixLen]...)
+		return ErrV1Fallback
+	}
+	if _, err := io.ReadFull(t.rw, theirKey[prefixLen:]); err != nil {
+		return err
+	}
+
+	if err := t.deriveKeys(privKey, &theirKey, ourKey); err != nil {
+		return err
+	}
+
+	// Send the garbage terminator followed by the version packet, which
+	// authenticates the garbage sent.
+	if !t.initiator {
+		writes <- keyAndGarbage
+	}
+	version := t.encryptPacket(nil, garbage, false)
+	terminatorAndVersion := make([]byte, 0, garbageTerminatorLen+len(version))
+	terminatorAndVersion = append(terminatorAndVersion, t.sendTerminator[:]...)
+	terminatorAndVersion = append(terminatorAndVersion, version...)
+	writes <- terminatorAndVersion
+
+	// Skip the garbage of the remote peer and read its version packet
+	// along with any decoys preceding it.  The contents of the version
+	// packet are reserved for future extensions and ignored.
+	theirGarbage, err := t.readGarbage()
+	if err != nil {
+		return err
+	}
+	aad := theirGarbage
+	for {
+		header, _, _, err := t.readPacket(aad)
+		if err != nil {
+			return err
+		}
+		aad = nil
+		if header&ignoreBit == 0 {
+			return nil
+		}
+	}
+}
+
+// deriveKeys derives the session keys from the ECDH shared secret.
+func (t *Transport) deriveKeys(privKey *btcec.PrivateKey, theirKey,
+	ourKey *[btcec.EllswiftPubKeyLen]byte) error {
+
+	secret := btcec.EllswiftBIP324ECDH(privKey, theirKey, ourKey,
+		t.initiator)
+
+	var salt [len(sharedSecretSalt) + 4]byte
+	copy(salt[:], sharedSecretSalt)
+	binary.LittleEndian.PutUint32(salt[len(sharedSecretSalt):],
+		uint32(t.btcnet))
+	prk := hkdf.Extract(sha256.New, secret[:], salt[:])
+
+	expand := func(info string, n int) ([]byte, error) {
+		out := make([]byte, n)
+		r := hkdf.Expand(sha256.New, prk, []byte(info))
+		if _, err := io.ReadFull(r, out); err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+
+	var keys [4][]byte
+	for i, info := range []string{"initiator_L", "initiator_P",
+		"responder_L", "responder_P"} {
+
+		key, err := expand(info, chacha20poly1305.KeySize)
+		if err != nil {
+			return err
+		}
+		keys[i] = key
+	}
+	terminators, err := expand("garbage_terminators",
+		2*garbageTerminatorLen)
+	if err != nil {
+		return err
+	}
+	sessionID, err := expand("session_id", len(t.sessionID))
+	if err != nil {
+		return err
+	}
+	copy(t.sessionID[:], sessionID)
+
+	initiatorL := newFSChaCha20(keys[0])
+	initiatorP := newFSChaCha20Poly1305(keys[1])
+	responderL := newFSChaCha20(keys[2])
+	responderP := newFSChaCha20Poly1305(keys[3])
+	if t.initiator {
+		t.sendLen, t.sendPacket = initiatorL, initiatorP
+		t.recvLen, t.recvPacket = responderL, responderP
+		copy(t.sendTerminator[:], terminators[:garbageTerminatorLen])
+		copy(t.recvTerminator[:], terminators[garbageTerminatorLen:])
+	} else {
+		t.sendLen, t.sendPacket = responderL, responderP
+		t.recvLen, t.recvPacket = initiatorL, initiatorP
+		copy(t.sendTerminator[:], terminators[garbageTerminatorLen:])
+		copy(t.recvTerminator[:], terminators[:garbageTerminatorLen])
+	}
+	return nil
+}
+
+// readGarbage reads from the connection up to and including the garbage
+// terminator of the remote peer and returns the garbage preceding it.
+func (t *Transport) readGarbage() ([]byte, error) {
+	buf := make([]byte, garbageTerminatorLen,
+		MaxGarbageLen+garbageTerminatorLen)
+	if _, err := io.ReadFull(t.rw, buf); err != nil {
+		return nil, err
+	}
+	for {
+		tail := buf[len(buf)-garbageTerminatorLen:]
+		if bytes.Equal(tail, t.recvTerminator[:]) {
+			return buf[:len(buf)-garbageTerminatorLen], nil
+		}
+		if len(buf) == cap(buf) {
+			return nil, ErrGarbageTooLong
+		}
+
+		var b [1]byte
+		if _, err := io.ReadFull(t.rw, b[:]); err != nil {
+			return nil, err
+		}
+		buf = append(buf, b[0])
+	}
+}
+
+// encryptPacket returns the encrypted packet for the passed contents.
+func (t *Transport) encryptPacket(contents, aad []byte, ignore bool) []byte {
+	packet := make([]byte, lengthFieldLen,
+		lengthFieldLen+headerLen+len(contents)+tagLen)
+	contentsLen := uint32(len(contents))
+	packet[0] = byte(contentsLen)
+	packet[1] = byte(contentsLen >> 8)
+	packet[2] = byte(contentsLen >> 16)
+	t.sendLen.crypt(packet[:lengthFieldLen])
+
+	plaintext := make([]byte, headerLen, headerLen+len(contents))
+	if ignore {
+		plaintext[0] = ignoreBit
+	}
+	plaintext = append(plaintext, contents...)
+	return t.sendPacket.seal(packet, plaintext, aad)
+}
+
+// readPacket reads and decrypts the next packet from the connection.  It
+// returns the header byte, the contents, and the number of bytes read.
+func (t *Transport) readPacket(aad []byte) (byte, []byte, int, error) {
+	var lenBuf [lengthFieldLen]byte
+	n, err := io.ReadFull(t.rw, lenBuf[:])
+	if err != nil {
+		return 0, nil, n, err
+	}
+	t.recvLen.crypt(lenBuf[:])
+	contentsLen := uint32(lenBuf[0]) | uint32(lenBuf[1])<<8 |
+		uint32(lenBuf[2])<<16
+	if contentsLen > maxContentsLen {
+		return 0, nil, n, ErrPacketTooLarge
+	}
+
+	ciphertext := make([]byte, headerLen+int(contentsLen)+
+		tagLen)
+	read, err := io.ReadFull(t.rw, ciphertext)
+	n += read
+	if err != nil {
+		return 0, nil, n, err
+	}
+	plaintext, err := t.recvPacket.open(ciphertext[:0], ciphertext, aad)
+	if err != nil {
+		return 0, nil, n, ErrDecryptionFailed
+	}
+	return plaintext[0], plaintext[headerLen:], n, nil
+}
+
+// WriteMessage encodes the passed message and sends it as a single encrypted
+// packet.  It returns the number of bytes written.
+func (t *Transport) WriteMessage(msg wire.Message, pver uint32,
+	enc wire.MessageEncoding) (int, error) {
+
+	if !t.established {
+		return 0, ErrHandshakeIncomplete
+	}
+
+	cmd := msg.Command()
+	if len(cmd) > wire.CommandSize {
+		str := fmt.Sprintf("command [%s] is too long [max %v]", cmd,
+			wire.CommandSize)
+		return 0, messageError("WriteMessage", str)
+	}
+
+	// Encode the message type followed by the payload.
+	var bw bytes.Buffer
+	if id, ok := commandShortIDs[cmd]; ok {
+		bw.WriteByte(id)
+	} else {
+		var command [1 + wire.CommandSize]byte
+		copy(command[1:], cmd)
+		bw.Write(command[:])
+	}
+	typeLen := bw.Len()
+	if err := msg.BtcEncode(&bw, pver, enc); err != nil {
+		return 0, err
+	}
+
+	// Enforce the maximum message payload based on the message type.
+	payloadLen := bw.Len() - typeLen
+	if mpl := msg.MaxPayloadLength(pver); uint32(payloadLen) > mpl ||
+		payloadLen > wire.MaxMessagePayload {
+
+		str := fmt.Sprintf("message payload is too large - encoded "+
+			"%d bytes, but maximum message payload size for "+
+			"messages of type [%s] is %d.", payloadLen, cmd, mpl)
+		return 0, messageError("WriteMessage", str)
+	}
+	if bw.Len() >= 1<<(8*lengthFieldLen) {
+		return 0, ErrPacketTooLarge
+	}
+
+	return t.rw.Write(t.encryptPacket(bw.Bytes(), nil, false))
+}
+
+// WriteDecoy sends a decoy packet with the passed contents, which the remote
+// peer discards.  Decoys may be used to obscure traffic patterns.
+func (t *Transport) WriteDecoy(contents []byte) (int, error) {
+	if !t.established {
+		return 0, ErrHandshakeIncomplete
+	}
+	if len(contents) >= 1<<(8*lengthFieldLen) {
+		return 0, ErrPacketTooLarge
+	}
+	return t.rw.Write(t.encryptPacket(contents, nil, true))
+}
+
+// ReadMessage reads, decrypts, and parses the next message from the
+// connection, skipping any decoy packets.  It returns the number of bytes
+// read along with the parsed message and its raw payload.
+func (t *Transport) ReadMessage(pver uint32,
+	enc wire.MessageEncoding) (int, wire.Message, []byte, error) {
+
+	if !t.established {
+		return 0, nil, nil, ErrHandshakeIncomplete
+	}
+
+	var (
+		totalBytes int
+		contents   []byte
+	)
+	for {
+		header, c, n, err := t.readPacket(nil)
+		totalBytes += n
+		if err != nil {
+			return totalBytes, nil, nil, err
+		}
+		if header&ignoreBit == 0 {
+			contents = c
+			break
+		}
+	}
+
+	// Decode the message type.
+	if len(contents) == 0 {
+		return totalBytes, nil, nil, messageError("ReadMessage",
+			"missing message type")
+	}
+	var command string
+	if contents[0] == 0 {
+		if len(contents) < 1+wire.CommandSize {
+			return totalBytes, nil, nil, messageError(
+				"ReadMessage", "truncated message type")
+		}
+		command = string(bytes.TrimRight(
+			contents[1:1+wire.CommandSize], "\x00"))
+		contents = contents[1+wire.CommandSize:]
+	} else {
+		id := contents[0]
+		if int(id) >= len(shortIDCommands) {
+			str := fmt.Sprintf("unknown short message id %d", id)
+			return totalBytes, nil, nil, messageError("ReadMessage",
+				str)
+		}
+		command = shortIDCommands[id]
+		contents = contents[1:]
+	}
+	if !utf8.ValidString(command) {
+		str := fmt.Sprintf("invalid command %v", []byte(command))
+		return totalBytes, nil, nil, messageError("ReadMessage", str)
+	}
+
+	msg, err := wire.MakeEmptyMessage(command)
+	if err != nil {
+		return totalBytes, nil, nil, messageError("ReadMessage",
+			err.Error())
+	}
+	if mpl := msg.MaxPayloadLength(pver); uint32(len(contents)) > mpl {
+		str := fmt.Sprintf("payload exceeds max length - packet "+
+			"contains %v bytes, but max payload size for messages "+
+			"of type [%v] is %v.", len(contents), command, mpl)
+		return totalBytes, nil, nil, messageError("ReadMessage", str)
+	}
+
+	if err := t.limits.CheckPayload(command, contents, pver); err != nil {
+		return totalBytes, nil, nil, err
+	}
+
+	// NOTE: This must be a *bytes.Buffer since the MsgVersion BtcDecode
+	// function requires it.
+	if err := msg.BtcDecode(bytes.NewBuffer(contents), pver, enc); err != nil {
+		return totalBytes, nil, nil, err
+	}
+	return totalBytes, msg, contents, nil
+}
+
+// messageError creates a wire.MessageError so callers can treat malformed
+// messages the same way regardless of the transport.
+func messageError(f string, desc string) *wire.MessageError {
+	return &wire.MessageError{Func: f, Description: desc}
+}
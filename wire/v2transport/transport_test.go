@@ -0,0 +1,217 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package v2transport
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// handshakePair returns an initiating and a responding transport connected to
+// each other which have completed the handshake.
+func handshakePair(t *testing.T) (*Transport, *Transport, func()) {
+	t.Helper()
+
+	c1, c2 := net.Pipe()
+	initiator := NewTransport(c1, wire.MainNet, true)
+	responder := NewTransport(c2, wire.MainNet, false)
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- responder.Handshake()
+	}()
+	if err := initiator.Handshake(); err != nil {
+		t.Fatalf("initiator handshake failed: %v", err)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("responder handshake failed: %v", err)
+	}
+
+	cleanup := func() {
+		c1.Close()
+		c2.Close()
+	}
+	return initiator, responder, cleanup
+}
+
+// sendMessage writes msg on one transport and returns what the other one
+// reads.
+func sendMessage(t *testing.T, from, to *Transport,
+	msg wire.Message) wire.Message {
+
+	t.Helper()
+
+	errChan := make(chan error, 1)
+	go func() {
+		_, err := from.WriteMessage(msg, wire.ProtocolVersion,
+			wire.LatestEncoding)
+		errChan <- err
+	}()
+	_, got, _, err := to.ReadMessage(wire.ProtocolVersion,
+		wire.LatestEncoding)
+	if err != nil {
+		t.Fatalf("ReadMessage failed: %v", err)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("WriteMessage failed: %v", err)
+	}
+	return got
+}
+
+// TestTransportRoundTrip ensures messages sent in both directions arrive
+// intact, including messages using short and long message types and across
+// several rekeys.
+func TestTransportRoundTrip(t *testing.T) {
+	initiator, responder, cleanup := handshakePair(t)
+	defer cleanup()
+
+	if initiator.SessionID() != responder.SessionID() {
+		t.Fatalf("session ids differ: %x != %x", initiator.SessionID(),
+			responder.SessionID())
+	}
+
+	hash := chainhash.DoubleHashH([]byte("v2transport"))
+	getData := wire.NewMsgGetData()
+	getData.AddInvVect(wire.NewInvVect(wire.InvTypeBlock, &hash))
+	msgs := []wire.Message{
+		// Short message type.
+		wire.NewMsgPing(42),
+		getData,
+
+		// Full message type.
+		wire.NewMsgVerAck(),
+		wire.NewMsgSendHeaders(),
+	}
+
+	for i := 0; i < 3*rekeyInterval; i++ {
+		msg := msgs[i%len(msgs)]
+		got := sendMessage(t, initiator, responder, msg)
+		if !reflect.DeepEqual(got, msg) {
+			t.Fatalf("message %d: got %v, want %v", i, got, msg)
+		}
+		got = sendMessage(t, responder, initiator, msg)
+		if !reflect.DeepEqual(got, msg) {
+			t.Fatalf("message %d: got %v, want %v", i, got, msg)
+		}
+	}
+}
+
+// TestTransportDecoy ensures decoy packets are skipped by the receiver.
+func TestTransportDecoy(t *testing.T) {
+	initiator, responder, cleanup := handshakePair(t)
+	defer cleanup()
+
+	go func() {
+		initiator.WriteDecoy(bytes.Repeat([]byte{0x01}, 100))
+		initiator.WriteDecoy(nil)
+		initiator.WriteMessage(wire.NewMsgPong(7), wire.ProtocolVersion,
+			wire.LatestEncoding)
+	}()
+
+	_, msg, _, err := responder.ReadMessage(wire.ProtocolVersion,
+		wire.LatestEncoding)
+	if err != nil {
+		t.Fatalf("ReadMessage failed: %v", err)
+	}
+	pong, ok := msg.(*wire.MsgPong)
+	if !ok || pong.Nonce != 7 {
+		t.Fatalf("unexpected message %v", msg)
+	}
+}
+
+// TestTransportTampered ensures a modified packet fails to authenticate.
+func TestTransportTampered(t *testing.T) {
+	initiator, responder, cleanup := handshakePair(t)
+	defer cleanup()
+
+	var buf bytes.Buffer
+	initiator.rw = &buf
+	_, err := initiator.WriteMessage(wire.NewMsgPing(1),
+		wire.ProtocolVersion, wire.LatestEncoding)
+	if err != nil {
+		t.Fatalf("WriteMessage failed: %v", err)
+	}
+	packet := buf.Bytes()
+	packet[len(packet)-1] ^= 0x01
+
+	responder.rw = struct {
+		io.Reader
+		io.Writer
+	}{bytes.NewReader(packet), ioutil.Discard}
+	_, _, _, err = responder.ReadMessage(wire.ProtocolVersion,
+		wire.LatestEncoding)
+	if err != ErrDecryptionFailed {
+		t.Fatalf("unexpected error -- got %v, want %v", err,
+			ErrDecryptionFailed)
+	}
+}
+
+// TestTransportV1Fallback ensures a responder detects a v1 peer and returns
+// the bytes consumed so far.
+func TestTransportV1Fallback(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	responder := NewTransport(c2, wire.MainNet, false)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- responder.Handshake()
+	}()
+
+	var v1 bytes.Buffer
+	err := wire.WriteMessage(&v1, wire.NewMsgVerAck(), wire.ProtocolVersion,
+		wire.MainNet)
+	if err != nil {
+		t.Fatalf("unable to encode message: %v", err)
+	}
+	v1Bytes := v1.Bytes()
+	copy(v1Bytes[4:16], append([]byte(wire.CmdVersion), 0, 0, 0, 0, 0))
+	go c1.Write(v1Bytes)
+
+	if err := <-errChan; err != ErrV1Fallback {
+		t.Fatalf("unexpected error -- got %v, want %v", err,
+			ErrV1Fallback)
+	}
+	if !bytes.Equal(responder.Buffered(), v1Bytes[:16]) {
+		t.Fatalf("unexpected buffered bytes %x", responder.Buffered())
+	}
+	if _, _, _, err := responder.ReadMessage(wire.ProtocolVersion,
+		wire.LatestEncoding); err != ErrHandshakeIncomplete {
+
+		t.Fatalf("unexpected error -- got %v, want %v", err,
+			ErrHandshakeIncomplete)
+	}
+}
+
+// TestFSChaCha20Rekey ensures the forward secure stream cipher rekeys after
+// rekeyInterval chunks so that two ciphers kept in sync continue to agree and
+// the keystream changes.
+func TestFSChaCha20Rekey(t *testing.T) {
+	key := bytes.Repeat([]byte{0x11}, 32)
+	enc := newFSChaCha20(key)
+	dec := newFSChaCha20(key)
+	for i := 0; i < 2*rekeyInterval+5; i++ {
+		chunk := []byte{1, 2, 3}
+		enc.crypt(chunk)
+		dec.crypt(chunk)
+		if !bytes.Equal(chunk, []byte{1, 2, 3}) {
+			t.Fatalf("chunk %d did not round trip: %x", i, chunk)
+		}
+	}
+	if enc.rekeyCounter != 2 {
+		t.Fatalf("unexpected rekey counter %d", enc.rekeyCounter)
+	}
+	if bytes.Equal(enc.key[:], key) {
+		t.Fatal("key was not replaced")
+	}
+}
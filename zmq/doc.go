@@ -0,0 +1,35 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package zmq implements a ZeroMQ-compatible notification publisher.
+
+The publisher sends the same notifications as the ZMQ interface of Bitcoin
+Core, so software written for it, such as block explorers and Electrum servers,
+can subscribe to btcd without changes.  It implements the publishing side of
+the ZeroMQ Message Transport Protocol (ZMTP 3.0) with the NULL security
+mechanism natively, so no ZeroMQ library is required.  Subscribers connect with
+any ZeroMQ SUB socket.
+
+The following topics are supported:
+
+	hashblock  The hash of each connected block
+	hashtx     The hash of each transaction added to the memory pool or
+	           included in a connected or disconnected block
+	rawblock   The serialized connected block
+	rawtx      The serialized transaction for the same events as hashtx
+	sequence   The hash of a block or transaction followed by a label,
+	           which is C for connected blocks, D for disconnected blocks,
+	           A for transactions added to the memory pool and R for
+	           transactions removed from it due to a replacement.  The
+	           labels A and R are followed by the little-endian 64-bit
+	           memory pool sequence number.
+
+Every notification is a multipart message consisting of the topic, the body
+and the little-endian 32-bit sequence number of the message, which is counted
+per topic and endpoint.  Hashes are sent in the byte order they are displayed
+in.  Like ZeroMQ, the publisher drops messages for subscribers which fall too
+far behind rather than blocking.
+*/
+package zmq
@@ -0,0 +1,263 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package zmq
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+)
+
+// The topics published by the notifier.
+const (
+	TopicHashBlock = "hashblock"
+	TopicHashTx    = "hashtx"
+	TopicRawBlock  = "rawblock"
+	TopicRawTx     = "rawtx"
+	TopicSequence  = "sequence"
+)
+
+// The labels of the messages of the sequence topic.
+const (
+	labelBlockConnected    = 'C'
+	labelBlockDisconnected = 'D'
+	labelTxAdded           = 'A'
+	labelTxRemoved         = 'R'
+)
+
+// topics are all topics supported by the notifier.
+var topics = map[string]struct{}{
+	TopicHashBlock: {},
+	TopicHashTx:    {},
+	TopicRawBlock:  {},
+	TopicRawTx:     {},
+	TopicSequence:  {},
+}
+
+// Config is a descriptor containing the notifier configuration.
+type Config struct {
+	// Endpoints maps the topics to publish to the address to publish them
+	// on, which must be of the form tcp://host:port.  Topics may share
+	// the same address.
+	Endpoints map[string]string
+
+	// Listen is the function used to listen on the addresses of the
+	// endpoints.  It defaults to net.Listen.
+	Listen func(network, address string) (net.Listener, error)
+}
+
+// Notifier publishes notifications about blocks and transactions to ZeroMQ
+// subscribers.
+type Notifier struct {
+	publishers map[string]*publisher // keyed by topic
+	listeners  []*publisher
+
+	// mempoolSeq is the sequence number of the events of the memory pool
+	// sent on the sequence topic.
+	mtx        sync.Mutex
+	mempoolSeq uint64
+}
+
+// parseEndpoint returns the host and port of the passed endpoint address.
+func parseEndpoint(endpoint string) (string, error) {
+	const scheme = "tcp://"
+	if !strings.HasPrefix(endpoint, scheme) {
+		return "", fmt.Errorf("unsupported ZMQ endpoint %q -- only "+
+			"tcp:// endpoints are supported", endpoint)
+	}
+	addr := strings.TrimPrefix(endpoint, scheme)
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return "", fmt.Errorf("invalid ZMQ endpoint %q: %v", endpoint,
+			err)
+	}
+	return addr, nil
+}
+
+// New returns a notifier publishing the configured topics.  It listens on the
+// addresses of all endpoints, but doesn't accept subscribers until it is
+// started.
+func New(cfg *Config) (*Notifier, error) {
+	listen := cfg.Listen
+	if listen == nil {
+		listen = net.Listen
+	}
+
+	n := &Notifier{publishers: make(map[string]*publisher)}
+	byAddr := make(map[string]*publisher)
+	for topic, endpoint := range cfg.Endpoints {
+		if _, ok := topics[topic]; !ok {
+			n.closeListeners()
+			return nil, fmt.Errorf("unknown ZMQ topic %q", topic)
+		}
+		addr, err := parseEndpoint(endpoint)
+		if err != nil {
+			n.closeListeners()
+			return nil, err
+		}
+		p, ok := byAddr[addr]
+		if !ok {
+			listener, err := listen("tcp", addr)
+			if err != nil {
+				n.closeListeners()
+				return nil, err
+			}
+			p = newPublisher(listener)
+			byAddr[addr] = p
+			n.listeners = append(n.listeners, p)
+		}
+		n.publishers[topic] = p
+	}
+
+	return n, nil
+}
+
+// closeListeners closes the listeners of a notifier which failed to be
+// created.
+func (n *Notifier) closeListeners() {
+	for _, p := range n.listeners {
+		p.listener.Close()
+	}
+}
+
+// Start begins accepting subscribers on all endpoints.
+func (n *Notifier) Start() {
+	for _, p := range n.listeners {
+		p.start()
+	}
+}
+
+// Stop disconnects all subscribers and closes the endpoints.
+func (n *Notifier) Stop() {
+	for _, p := range n.listeners {
+		p.stop()
+	}
+}
+
+// Addrs returns the addresses the notifier listens on keyed by topic.
+func (n *Notifier) Addrs() map[string]net.Addr {
+	addrs := make(map[string]net.Addr, len(n.publishers))
+	for topic, p := range n.publishers {
+		addrs[topic] = p.listener.Addr()
+	}
+	return addrs
+}
+
+// publish sends a message to the subscribers of the passed topic.  The body
+// is only created when the topic is published.
+func (n *Notifier) publish(topic string, body func() []byte) {
+	p, ok := n.publishers[topic]
+	if !ok {
+		return
+	}
+	p.publish(topic, body())
+}
+
+// reversedHash returns the passed hash in the byte order it is displayed in.
+func reversedHash(hash *chainhash.Hash) []byte {
+	b := make([]byte, chainhash.HashSize)
+	for i := range hash {
+		b[chainhash.HashSize-1-i] = hash[i]
+	}
+	return b
+}
+
+// sequenceBody returns the body of a message of the sequence topic.  The
+// memory pool sequence number is only included for transaction events.
+func sequenceBody(hash *chainhash.Hash, label byte, mempoolSeq *uint64) []byte {
+	body := append(reversedHash(hash), label)
+	if mempoolSeq != nil {
+		var seq [8]byte
+		binary.LittleEndian.PutUint64(seq[:], *mempoolSeq)
+		body = append(body, seq[:]...)
+	}
+	return body
+}
+
+// notifyTx publishes the hash and the serialization of the passed
+// transaction.
+func (n *Notifier) notifyTx(tx *btcutil.Tx) {
+	n.publish(TopicHashTx, func() []byte {
+		return reversedHash(tx.Hash())
+	})
+	n.publish(TopicRawTx, func() []byte {
+		var buf bytes.Buffer
+		buf.Grow(tx.MsgTx().SerializeSize())
+		tx.MsgTx().Serialize(&buf)
+		return buf.Bytes()
+	})
+}
+
+// BlockConnected publishes the transactions of the passed block, which was
+// connected to the main chain, followed by the block itself.
+//
+// This function is safe for concurrent access.
+func (n *Notifier) BlockConnected(block *btcutil.Block) {
+	for _, tx := range block.Transactions() {
+		n.notifyTx(tx)
+	}
+	n.publish(TopicSequence, func() []byte {
+		return sequenceBody(block.Hash(), labelBlockConnected, nil)
+	})
+	n.publish(TopicHashBlock, func() []byte {
+		return reversedHash(block.Hash())
+	})
+	n.publish(TopicRawBlock, func() []byte {
+		blockBytes, err := block.Bytes()
+		if err != nil {
+			log.Errorf("Can't serialize block %v: %v", block.Hash(),
+				err)
+		}
+		return blockBytes
+	})
+}
+
+// BlockDisconnected publishes the transactions of the passed block, which was
+// disconnected from the main chain, followed by the disconnection of the block
+// on the sequence topic.
+//
+// This function is safe for concurrent access.
+func (n *Notifier) BlockDisconnected(block *btcutil.Block) {
+	for _, tx := range block.Transactions() {
+		n.notifyTx(tx)
+	}
+	n.publish(TopicSequence, func() []byte {
+		return sequenceBody(block.Hash(), labelBlockDisconnected, nil)
+	})
+}
+
+// TransactionAccepted publishes the passed transaction, which was added to the
+// memory pool.
+//
+// This function is safe for concurrent access.
+func (n *Notifier) TransactionAccepted(tx *btcutil.Tx) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	n.notifyTx(tx)
+	n.mempoolSeq++
+	n.publish(TopicSequence, func() []byte {
+		return sequenceBody(tx.Hash(), labelTxAdded, &n.mempoolSeq)
+	})
+}
+
+// TransactionRemoved publishes the removal of the passed transaction from the
+// memory pool for a reason other than its inclusion in a block.
+//
+// This function is safe for concurrent access.
+func (n *Notifier) TransactionRemoved(tx *btcutil.Tx) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	n.mempoolSeq++
+	n.publish(TopicSequence, func() []byte {
+		return sequenceBody(tx.Hash(), labelTxRemoved, &n.mempoolSeq)
+	})
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package zmq
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// testSubscriber is a minimal ZeroMQ SUB socket used to receive the messages
+// of a notifier.
+type testSubscriber struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// dialSubscriber connects a test subscriber to the passed address.
+func dialSubscriber(t *testing.T, addr net.Addr) *testSubscriber {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatalf("unable to connect: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if err := handshake(conn, socketTypeSub, socketTypePub); err != nil {
+		t.Fatalf("handshake failed: %v", err)
+	}
+	return &testSubscriber{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+// recv reads the next multipart message.
+func (s *testSubscriber) recv() [][]byte {
+	s.t.Helper()
+
+	var parts [][]byte
+	for {
+		flags, body, err := readFrame(s.r, 1<<20)
+		if err != nil {
+			s.t.Fatalf("unable to read frame: %v", err)
+		}
+		if flags&flagCommand != 0 {
+			continue
+		}
+		parts = append(parts, body)
+		if flags&flagMore == 0 {
+			return parts
+		}
+	}
+}
+
+// waitSubscriptions waits until the publisher of the passed topic has the
+// passed number of subscriptions.
+func waitSubscriptions(t *testing.T, n *Notifier, topic string, want int) {
+	t.Helper()
+
+	p := n.publishers[topic]
+	for i := 0; i < 500; i++ {
+		p.mtx.Lock()
+		var got int
+		for s := range p.subscribers {
+			for _, count := range s.prefixes {
+				got += count
+			}
+		}
+		p.mtx.Unlock()
+		if got == want {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("subscriptions of topic %s not registered", topic)
+}
+
+// TestNotifier ensures subscribers receive the messages of the topics they
+// subscribed to with the expected framing and sequence numbers.
+func TestNotifier(t *testing.T) {
+	n, err := New(&Config{
+		Endpoints: map[string]string{
+			TopicHashTx:   "tcp://127.0.0.1:0",
+			TopicSequence: "tcp://127.0.0.1:0",
+			TopicRawTx:    "tcp://[::1]:0",
+		},
+		Listen: func(network, address string) (net.Listener, error) {
+			return net.Listen(network, "127.0.0.1:0")
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to create notifier: %v", err)
+	}
+	n.Start()
+	defer n.Stop()
+
+	addrs := n.Addrs()
+	if addrs[TopicHashTx] != addrs[TopicSequence] {
+		t.Fatalf("topics with the same endpoint use different listeners")
+	}
+	if addrs[TopicHashTx] == addrs[TopicRawTx] {
+		t.Fatalf("topics with different endpoints share a listener")
+	}
+
+	// Subscribe to the hashtx topic with a ZMTP 3.0 subscription message
+	// and to the sequence topic with a ZMTP 3.1 command.
+	sub := dialSubscriber(t, addrs[TopicHashTx])
+	defer sub.conn.Close()
+	err = writeFrame(sub.conn, 0, append([]byte{1}, TopicHashTx...))
+	if err != nil {
+		t.Fatalf("unable to subscribe: %v", err)
+	}
+	err = writeFrame(sub.conn, flagCommand, encodeCommand(cmdSubscribe,
+		[]byte(TopicSequence)))
+	if err != nil {
+		t.Fatalf("unable to subscribe: %v", err)
+	}
+	waitSubscriptions(t, n, TopicHashTx, 2)
+
+	tx := btcutil.NewTx(&wire.MsgTx{
+		Version: 1,
+		TxIn: []*wire.TxIn{{
+			PreviousOutPoint: wire.OutPoint{Index: 1},
+			Sequence:         wire.MaxTxInSequenceNum,
+		}},
+		TxOut: []*wire.TxOut{{Value: 1000, PkScript: []byte{0x51}}},
+	})
+	n.TransactionAccepted(tx)
+	n.TransactionRemoved(tx)
+
+	reversed := reversedHash(tx.Hash())
+	seq := func(s uint32) []byte {
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], s)
+		return b[:]
+	}
+	mempoolSeq := func(s uint64, label byte) []byte {
+		b := append(append([]byte(nil), reversed...), label)
+		var seq [8]byte
+		binary.LittleEndian.PutUint64(seq[:], s)
+		return append(b, seq[:]...)
+	}
+	tests := [][][]byte{
+		{[]byte(TopicHashTx), reversed, seq(0)},
+		{[]byte(TopicSequence), mempoolSeq(1, 'A'), seq(0)},
+		{[]byte(TopicSequence), mempoolSeq(2, 'R'), seq(1)},
+	}
+	for i, want := range tests {
+		got := sub.recv()
+		if len(got) != len(want) {
+			t.Fatalf("message #%d: got %d parts, want %d", i,
+				len(got), len(want))
+		}
+		for j := range want {
+			if !bytes.Equal(got[j], want[j]) {
+				t.Fatalf("message #%d part #%d: got %x, want %x",
+					i, j, got[j], want[j])
+			}
+		}
+	}
+
+	// The reversed hash must match the displayed hash.
+	if got := tx.Hash().String(); got != hex.EncodeToString(reversed) {
+		t.Fatalf("hash is not in display order: %x vs %s", reversed,
+			got)
+	}
+}
+
+// TestNewErrors ensures invalid configurations are rejected.
+func TestNewErrors(t *testing.T) {
+	tests := []map[string]string{
+		{"rawmempool": "tcp://127.0.0.1:0"},
+		{TopicHashBlock: "ipc:///tmp/btcd.sock"},
+		{TopicHashBlock: "tcp://127.0.0.1"},
+	}
+	for i, endpoints := range tests {
+		if _, err := New(&Config{Endpoints: endpoints}); err == nil {
+			t.Errorf("#%d: expected error for %v", i, endpoints)
+		}
+	}
+}
+
+// TestFrames ensures frames of all sizes survive a round trip.
+func TestFrames(t *testing.T) {
+	for _, size := range []int{0, 1, 255, 256, 70000} {
+		body := bytes.Repeat([]byte{0xab}, size)
+		var buf bytes.Buffer
+		if err := writeFrame(&buf, flagMore, body); err != nil {
+			t.Fatalf("size %d: unable to write frame: %v", size, err)
+		}
+		flags, got, err := readFrame(&buf, 1<<20)
+		if err != nil {
+			t.Fatalf("size %d: unable to read frame: %v", size, err)
+		}
+		if flags&flagMore == 0 || (flags&flagLong != 0) != (size > 255) {
+			t.Fatalf("size %d: unexpected flags %x", size, flags)
+		}
+		if !bytes.Equal(got, body) {
+			t.Fatalf("size %d: body mismatch", size)
+		}
+	}
+
+	var buf bytes.Buffer
+	writeFrame(&buf, 0, make([]byte, 300))
+	if _, _, err := readFrame(&buf, 299); err != errFrameTooLong {
+		t.Fatalf("unexpected error for long frame: %v", err)
+	}
+}
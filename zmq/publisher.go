@@ -0,0 +1,310 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package zmq
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// sendQueueLen is the maximum number of messages queued for a
+	// subscriber.  Further messages are dropped until the subscriber
+	// catches up, which matches the default high water mark of ZeroMQ.
+	sendQueueLen = 1000
+
+	// handshakeTimeout is the maximum duration of the handshake with a
+	// subscriber.
+	handshakeTimeout = 10 * time.Second
+)
+
+// message is a multipart message queued for a subscriber.
+type message [][]byte
+
+// publisher accepts subscribers on a listener and sends them the messages of
+// the topics they are subscribed to.
+type publisher struct {
+	listener net.Listener
+
+	mtx         sync.Mutex
+	subscribers map[*subscriber]struct{}
+	sequences   map[string]uint32
+	shutdown    bool
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// subscriber is a connection of a ZeroMQ SUB socket to a publisher.
+type subscriber struct {
+	conn net.Conn
+
+	// prefixes counts the subscriptions to each topic prefix.  It is
+	// protected by the mutex of the publisher.
+	prefixes map[string]int
+
+	sendQueue chan message
+	quit      chan struct{}
+}
+
+// newPublisher returns a publisher accepting subscribers on the passed
+// listener.
+func newPublisher(listener net.Listener) *publisher {
+	return &publisher{
+		listener:    listener,
+		subscribers: make(map[*subscriber]struct{}),
+		sequences:   make(map[string]uint32),
+		quit:        make(chan struct{}),
+	}
+}
+
+// start begins accepting subscribers.
+func (p *publisher) start() {
+	p.wg.Add(1)
+	go p.listenHandler()
+}
+
+// stop closes the listener and all subscriber connections and waits for the
+// publisher to shut down.
+func (p *publisher) stop() {
+	p.mtx.Lock()
+	if p.shutdown {
+		p.mtx.Unlock()
+		return
+	}
+	p.shutdown = true
+	close(p.quit)
+	p.listener.Close()
+	for s := range p.subscribers {
+		s.conn.Close()
+	}
+	p.mtx.Unlock()
+
+	p.wg.Wait()
+}
+
+// publish queues a message with the passed topic and body for all subscribers
+// of the topic.  The sequence number of the topic is incremented even when
+// there are no subscribers, so they can detect missed messages.
+func (p *publisher) publish(topic string, body []byte) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	var seq [4]byte
+	binary.LittleEndian.PutUint32(seq[:], p.sequences[topic])
+	p.sequences[topic]++
+
+	msg := message{[]byte(topic), body, seq[:]}
+	for s := range p.subscribers {
+		if !s.subscribed(topic) {
+			continue
+		}
+		select {
+		case s.sendQueue <- msg:
+		default:
+			log.Debugf("Dropping %s message for slow ZMQ subscriber "+
+				"%s", topic, s.conn.RemoteAddr())
+		}
+	}
+}
+
+// listenHandler accepts subscribers until the publisher is stopped.
+//
+// This function MUST be run as a goroutine.
+func (p *publisher) listenHandler() {
+	defer p.wg.Done()
+
+	log.Infof("ZMQ publisher listening on %s", p.listener.Addr())
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			select {
+			case <-p.quit:
+			default:
+				log.Errorf("Can't accept ZMQ connection: %v", err)
+			}
+			return
+		}
+
+		p.wg.Add(1)
+		go p.connHandler(conn)
+	}
+}
+
+// connHandler performs the handshake with a new subscriber and handles its
+// subscriptions until it disconnects.
+//
+// This function MUST be run as a goroutine.
+func (p *publisher) connHandler(conn net.Conn) {
+	defer p.wg.Done()
+
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	err := handshake(conn, socketTypePub, socketTypeSub, socketTypeXSub)
+	if err != nil {
+		log.Debugf("ZMQ handshake with %s failed: %v",
+			conn.RemoteAddr(), err)
+		conn.Close()
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	s := &subscriber{
+		conn:      conn,
+		prefixes:  make(map[string]int),
+		sendQueue: make(chan message, sendQueueLen),
+		quit:      make(chan struct{}),
+	}
+	p.mtx.Lock()
+	if p.shutdown {
+		p.mtx.Unlock()
+		conn.Close()
+		return
+	}
+	p.subscribers[s] = struct{}{}
+	p.mtx.Unlock()
+
+	log.Debugf("New ZMQ subscriber %s", conn.RemoteAddr())
+
+	p.wg.Add(1)
+	go p.sendHandler(s)
+
+	p.recvHandler(s)
+
+	p.mtx.Lock()
+	delete(p.subscribers, s)
+	p.mtx.Unlock()
+	close(s.quit)
+	conn.Close()
+
+	log.Debugf("ZMQ subscriber %s disconnected", conn.RemoteAddr())
+}
+
+// recvHandler handles the subscriptions and commands sent by the subscriber
+// until it disconnects.
+func (p *publisher) recvHandler(s *subscriber) {
+	r := bufio.NewReader(s.conn)
+	for {
+		flags, body, err := readFrame(r, maxRecvFrameLen)
+		if err != nil {
+			return
+		}
+
+		// Subscriptions are sent as messages by ZMTP 3.0 peers and as
+		// commands by later versions.
+		if flags&flagCommand == 0 {
+			if len(body) == 0 {
+				continue
+			}
+			p.updateSubscription(s, body[0] == 1, string(body[1:]))
+			continue
+		}
+
+		name, data, err := decodeCommand(body)
+		if err != nil {
+			log.Debugf("Invalid command from ZMQ subscriber %s: %v",
+				s.conn.RemoteAddr(), err)
+			return
+		}
+		switch name {
+		case cmdSubscribe:
+			p.updateSubscription(s, true, string(data))
+
+		case cmdCancel:
+			p.updateSubscription(s, false, string(data))
+
+		case cmdPing:
+			// The ping consists of a 16-bit TTL followed by a
+			// context which is echoed by the pong.
+			if len(data) < 2 {
+				return
+			}
+			pong := encodeCommand(cmdPong, data[2:])
+			select {
+			case s.sendQueue <- message{nil, pong}:
+			default:
+			}
+		}
+	}
+}
+
+// updateSubscription adds or removes a subscription of the subscriber to the
+// passed topic prefix.
+func (p *publisher) updateSubscription(s *subscriber, subscribe bool,
+	prefix string) {
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if subscribe {
+		s.prefixes[prefix]++
+		return
+	}
+	if s.prefixes[prefix] <= 1 {
+		delete(s.prefixes, prefix)
+		return
+	}
+	s.prefixes[prefix]--
+}
+
+// subscribed returns whether the subscriber is subscribed to the passed topic.
+//
+// This function MUST be called with the publisher lock held.
+func (s *subscriber) subscribed(topic string) bool {
+	for prefix := range s.prefixes {
+		if strings.HasPrefix(topic, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// sendHandler writes the queued messages to the subscriber until it
+// disconnects.  A message with a nil first part holds a single command
+// frame.
+//
+// This function MUST be run as a goroutine.
+func (p *publisher) sendHandler(s *subscriber) {
+	defer p.wg.Done()
+
+	w := bufio.NewWriter(s.conn)
+	for {
+		var msg message
+		select {
+		case msg = <-s.sendQueue:
+		case <-s.quit:
+			return
+		}
+
+		var err error
+		if msg[0] == nil {
+			err = writeFrame(w, flagCommand, msg[1])
+		} else {
+			for i, part := range msg {
+				var flags byte
+				if i < len(msg)-1 {
+					flags = flagMore
+				}
+				if err = writeFrame(w, flags, part); err != nil {
+					break
+				}
+			}
+		}
+
+		// Only flush once the queue is drained to batch writes.
+		if err == nil && len(s.sendQueue) == 0 {
+			err = w.Flush()
+		}
+		if err != nil {
+			log.Debugf("Can't send to ZMQ subscriber %s: %v",
+				s.conn.RemoteAddr(), err)
+			s.conn.Close()
+			return
+		}
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2026 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package zmq
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// greetingLen is the length of the greeting exchanged at the start of
+	// a ZMTP connection.
+	greetingLen = 64
+
+	// zmtpMajorVersion and zmtpMinorVersion are the version of ZMTP
+	// announced in the greeting.  Peers implementing a later minor version
+	// fall back to the framing of this one.
+	zmtpMajorVersion = 3
+	zmtpMinorVersion = 0
+
+	// flagMore marks a frame which is followed by another frame of the
+	// same message.
+	flagMore = 0x01
+
+	// flagLong marks a frame whose size is encoded with 8 bytes rather
+	// than 1.
+	flagLong = 0x02
+
+	// flagCommand marks a frame which holds a command rather than a part
+	// of a message.
+	flagCommand = 0x04
+
+	// maxRecvFrameLen is the maximum length of frames received from
+	// subscribers, which only send commands and subscriptions.
+	maxRecvFrameLen = 1 << 16
+
+	// socketTypePub and socketTypeSub are the socket types of publishers
+	// and subscribers announced in the READY command.
+	socketTypePub  = "PUB"
+	socketTypeSub  = "SUB"
+	socketTypeXSub = "XSUB"
+)
+
+// The names of the commands exchanged by the publisher and its subscribers.
+const (
+	cmdReady       = "READY"
+	cmdSubscribe   = "SUBSCRIBE"
+	cmdCancel      = "CANCEL"
+	cmdPing        = "PING"
+	cmdPong        = "PONG"
+	propSocketType = "Socket-Type"
+)
+
+// mechanismNull is the name of the NULL security mechanism, which is the only
+// one supported.
+var mechanismNull = []byte("NULL")
+
+// errFrameTooLong is returned when a received frame exceeds the maximum
+// length.
+var errFrameTooLong = errors.New("frame exceeds maximum length")
+
+// writeGreeting writes the greeting announcing the supported version and the
+// NULL security mechanism.
+func writeGreeting(w io.Writer) error {
+	var greeting [greetingLen]byte
+	greeting[0] = 0xff
+	greeting[9] = 0x7f
+	greeting[10] = zmtpMajorVersion
+	greeting[11] = zmtpMinorVersion
+	copy(greeting[12:32], mechanismNull)
+	_, err := w.Write(greeting[:])
+	return err
+}
+
+// readGreeting reads the greeting of the peer and ensures it speaks ZMTP 3 or
+// later with the NULL security mechanism.
+func readGreeting(r io.Reader) error {
+	var greeting [greetingLen]byte
+	if _, err := io.ReadFull(r, greeting[:]); err != nil {
+		return err
+	}
+	if greeting[0] != 0xff || greeting[9] != 0x7f {
+		return errors.New("invalid greeting signature")
+	}
+	if greeting[10] < zmtpMajorVersion {
+		return fmt.Errorf("unsupported ZMTP version %d.%d",
+			greeting[10], greeting[11])
+	}
+	mechanism := bytes.TrimRight(greeting[12:32], "\x00")
+	if !bytes.Equal(mechanism, mechanismNull) {
+		return fmt.Errorf("unsupported security mechanism %q",
+			mechanism)
+	}
+	return nil
+}
+
+// writeFrame writes a frame with the passed flags and body.  The long flag is
+// set as needed.
+func writeFrame(w io.Writer, flags byte, body []byte) error {
+	var header [9]byte
+	headerLen := 2
+	if len(body) > 255 {
+		flags |= flagLong
+		binary.BigEndian.PutUint64(header[1:], uint64(len(body)))
+		headerLen = 9
+	} else {
+		header[1] = byte(len(body))
+	}
+	header[0] = flags
+	if _, err := w.Write(header[:headerLen]); err != nil {
+		return err
+	}
+	_, err := w.Write(body)
+	return err
+}
+
+// readFrame reads a frame and returns its flags and body.  Frames longer than
+// the passed maximum length are rejected.
+func readFrame(r io.Reader, maxLen uint64) (byte, []byte, error) {
+	var header [9]byte
+	if _, err := io.ReadFull(r, header[:2]); err != nil {
+		return 0, nil, err
+	}
+	flags := header[0]
+	bodyLen := uint64(header[1])
+	if flags&flagLong != 0 {
+		if _, err := io.ReadFull(r, header[2:]); err != nil {
+			return 0, nil, err
+		}
+		bodyLen = binary.BigEndian.Uint64(header[1:])
+	}
+	if bodyLen > maxLen {
+		return 0, nil, errFrameTooLong
+	}
+	body := make([]byte, bodyLen)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return flags, body, nil
+}
+
+// encodeCommand returns the body of a command frame with the passed name and
+// data.
+func encodeCommand(name string, data []byte) []byte {
+	body := make([]byte, 0, 1+len(name)+len(data))
+	body = append(body, byte(len(name)))
+	body = append(body, name...)
+	return append(body, data...)
+}
+
+// decodeCommand returns the name and data of the passed command frame body.
+func decodeCommand(body []byte) (string, []byte, error) {
+	if len(body) == 0 || int(body[0]) > len(body)-1 {
+		return "", nil, errors.New("malformed command")
+	}
+	nameLen := int(body[0])
+	return string(body[1 : 1+nameLen]), body[1+nameLen:], nil
+}
+
+// encodeReady returns the body of a READY command announcing the passed
+// socket type.
+func encodeReady(socketType string) []byte {
+	var data []byte
+	data = append(data, byte(len(propSocketType)))
+	data = append(data, propSocketType...)
+	var valueLen [4]byte
+	binary.BigEndian.PutUint32(valueLen[:], uint32(len(socketType)))
+	data = append(data, valueLen[:]...)
+	data = append(data, socketType...)
+	return encodeCommand(cmdReady, data)
+}
+
+// decodeReadyProperties returns the metadata properties of a READY command.
+func decodeReadyProperties(data []byte) (map[string]string, error) {
+	props := make(map[string]string)
+	for len(data) > 0 {
+		nameLen := int(data[0])
+		if len(data) < 1+nameLen+4 {
+			return nil, errors.New("malformed READY property")
+		}
+		name := string(data[1 : 1+nameLen])
+		data = data[1+nameLen:]
+		valueLen := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		if uint64(len(data)) < uint64(valueLen) {
+			return nil, errors.New("malformed READY property")
+		}
+		props[name] = string(data[:valueLen])
+		data = data[valueLen:]
+	}
+	return props, nil
+}
+
+// handshake exchanges the greeting and the READY command with the peer.  It
+// announces the passed socket type and ensures the peer announces one of the
+// passed compatible socket types.
+func handshake(rw io.ReadWriter, socketType string,
+	peerTypes ...string) error {
+
+	if err := writeGreeting(rw); err != nil {
+		return err
+	}
+	if err := readGreeting(rw); err != nil {
+		return err
+	}
+	if err := writeFrame(rw, flagCommand, encodeReady(socketType)); err != nil {
+		return err
+	}
+
+	flags, body, err := readFrame(rw, maxRecvFrameLen)
+	if err != nil {
+		return err
+	}
+	if flags&flagCommand == 0 {
+		return errors.New("expected READY command")
+	}
+	name, data, err := decodeCommand(body)
+	if err != nil {
+		return err
+	}
+	if name != cmdReady {
+		return fmt.Errorf("expected READY command, got %s", name)
+	}
+	props, err := decodeReadyProperties(data)
+	if err != nil {
+		return err
+	}
+	peerType := props[propSocketType]
+	for _, t := range peerTypes {
+		if peerType == t {
+			return nil
+		}
+	}
+	return fmt.Errorf("incompatible socket type %q", peerType)
+}